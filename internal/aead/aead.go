@@ -0,0 +1,54 @@
+// Package aead provides authenticated encryption helpers shared by Bloblang
+// methods, where each sealed payload is prefixed with a randomly generated
+// nonce so that a single key can be safely reused across many messages.
+package aead
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+// Schemes lists the names of supported AEAD schemes.
+var Schemes = []string{"aes_gcm", "chacha20_poly1305", "xchacha20_poly1305"}
+
+// New creates an AEAD cipher from a scheme name and a key. AES-GCM accepts
+// keys of 16, 24 or 32 bytes, and the ChaCha20 schemes require 32 byte keys.
+func New(scheme string, key []byte) (cipher.AEAD, error) {
+	switch scheme {
+	case "aes_gcm":
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, err
+		}
+		return cipher.NewGCM(block)
+	case "chacha20_poly1305":
+		return chacha20poly1305.New(key)
+	case "xchacha20_poly1305":
+		return chacha20poly1305.NewX(key)
+	}
+	return nil, fmt.Errorf("unrecognised scheme: %v", scheme)
+}
+
+// Seal encrypts and authenticates a plaintext along with optional additional
+// data, and returns the result prefixed with a random nonce.
+func Seal(a cipher.AEAD, plaintext, additionalData []byte) ([]byte, error) {
+	out := make([]byte, a.NonceSize(), a.NonceSize()+len(plaintext)+a.Overhead())
+	if _, err := rand.Read(out); err != nil {
+		return nil, err
+	}
+	return a.Seal(out, out, plaintext, additionalData), nil
+}
+
+// Open decrypts and authenticates a payload created with Seal.
+func Open(a cipher.AEAD, payload, additionalData []byte) ([]byte, error) {
+	if len(payload) < a.NonceSize()+a.Overhead() {
+		return nil, errors.New("ciphertext is too short")
+	}
+	nonce, ciphertext := payload[:a.NonceSize()], payload[a.NonceSize():]
+	return a.Open(nil, nonce, ciphertext, additionalData)
+}
@@ -0,0 +1,103 @@
+package aead
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSealOpen(t *testing.T) {
+	key := []byte("0123456789abcdef0123456789abcdef")
+	for _, scheme := range Schemes {
+		scheme := scheme
+		t.Run(scheme, func(t *testing.T) {
+			a, err := New(scheme, key)
+			require.NoError(t, err)
+
+			sealed, err := Seal(a, []byte("hello world"), []byte("foo"))
+			require.NoError(t, err)
+
+			sealedAgain, err := Seal(a, []byte("hello world"), []byte("foo"))
+			require.NoError(t, err)
+			assert.NotEqual(t, sealed, sealedAgain)
+
+			opened, err := Open(a, sealed, []byte("foo"))
+			require.NoError(t, err)
+			assert.Equal(t, "hello world", string(opened))
+
+			_, err = Open(a, sealed, []byte("bar"))
+			require.Error(t, err)
+
+			_, err = Open(a, sealed[:4], nil)
+			require.Error(t, err)
+		})
+	}
+
+	_, err := New("nope", key)
+	require.Error(t, err)
+}
+
+func TestEnvelope(t *testing.T) {
+	dataKey := []byte("0123456789abcdef0123456789abcdef")
+
+	envelope, err := SealEnvelope([]byte("encrypted key"), dataKey, []byte("hello world"))
+	require.NoError(t, err)
+
+	opened, err := OpenEnvelope(envelope, func(encryptedKey []byte) ([]byte, error) {
+		assert.Equal(t, "encrypted key", string(encryptedKey))
+		return dataKey, nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "hello world", string(opened))
+
+	_, err = OpenEnvelope(envelope, func(encryptedKey []byte) ([]byte, error) {
+		return nil, errors.New("nope")
+	})
+	require.EqualError(t, err, "nope")
+
+	_, err = OpenEnvelope(envelope[:5], func(encryptedKey []byte) ([]byte, error) {
+		return dataKey, nil
+	})
+	require.Error(t, err)
+}
+
+func TestKeyCache(t *testing.T) {
+	cache := NewKeyCache(time.Hour)
+
+	generated := 0
+	generate := func() (DataKey, error) {
+		generated++
+		return DataKey{Plaintext: []byte("plain"), Encrypted: []byte("encrypted")}, nil
+	}
+
+	for i := 0; i < 3; i++ {
+		key, err := cache.ForEncrypt("foo", generate)
+		require.NoError(t, err)
+		assert.Equal(t, "plain", string(key.Plaintext))
+	}
+	assert.Equal(t, 1, generated)
+
+	decrypted := 0
+	decrypt := func() ([]byte, error) {
+		decrypted++
+		return []byte("other plain"), nil
+	}
+
+	for i := 0; i < 3; i++ {
+		plain, err := cache.ForDecrypt("foo", []byte("other"), decrypt)
+		require.NoError(t, err)
+		assert.Equal(t, "other plain", string(plain))
+	}
+	assert.Equal(t, 1, decrypted)
+
+	// Keys decrypted within one scope are not shared with another, and keys
+	// generated for encryption are never returned for decryption.
+	_, err := cache.ForDecrypt("bar", []byte("other"), decrypt)
+	require.NoError(t, err)
+	_, err = cache.ForDecrypt("foo", []byte("encrypted"), decrypt)
+	require.NoError(t, err)
+	assert.Equal(t, 3, decrypted)
+}
@@ -0,0 +1,163 @@
+package aead
+
+import (
+	"encoding/binary"
+	"errors"
+	"sync"
+	"time"
+)
+
+// EnvelopeScheme is the AEAD scheme used for encrypting the payloads of
+// envelopes with data keys.
+const EnvelopeScheme = "aes_gcm"
+
+// SealEnvelope encrypts a plaintext with a data key and returns an envelope
+// containing both the sealed plaintext and the data key encrypted by a key
+// management service, which is required in order to open it.
+//
+// The envelope is formatted as the length of the encrypted data key as a two
+// byte big endian integer, followed by the encrypted data key, followed by the
+// payload sealed with AES-GCM.
+func SealEnvelope(encryptedKey, dataKey, plaintext []byte) ([]byte, error) {
+	if len(encryptedKey) > 0xffff {
+		return nil, errors.New("encrypted data key is too large")
+	}
+	a, err := New(EnvelopeScheme, dataKey)
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := Seal(a, plaintext, nil)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, 2, 2+len(encryptedKey)+len(sealed))
+	binary.BigEndian.PutUint16(out, uint16(len(encryptedKey)))
+	out = append(out, encryptedKey...)
+	return append(out, sealed...), nil
+}
+
+// OpenEnvelope decrypts an envelope created with SealEnvelope, where the
+// encrypted data key of the envelope is decrypted with the provided function.
+func OpenEnvelope(envelope []byte, decryptKey func(encryptedKey []byte) ([]byte, error)) ([]byte, error) {
+	if len(envelope) < 2 {
+		return nil, errors.New("envelope is too short")
+	}
+	keyLen := int(binary.BigEndian.Uint16(envelope))
+	if len(envelope) < 2+keyLen {
+		return nil, errors.New("envelope is too short")
+	}
+	dataKey, err := decryptKey(envelope[2 : 2+keyLen])
+	if err != nil {
+		return nil, err
+	}
+	a, err := New(EnvelopeScheme, dataKey)
+	if err != nil {
+		return nil, err
+	}
+	return Open(a, envelope[2+keyLen:], nil)
+}
+
+//------------------------------------------------------------------------------
+
+// DataKey is a plaintext data key along with its encrypted form.
+type DataKey struct {
+	Plaintext []byte
+	Encrypted []byte
+}
+
+type cachedKey struct {
+	key     DataKey
+	expires time.Time
+}
+
+type decryptID struct {
+	scope     string
+	encrypted string
+}
+
+// KeyCache stores data keys for a period of time in order to reduce the number
+// of calls made to a key management service. Keys used for encryption are
+// cached by the ID of the master key that generated them, and keys used for
+// decryption are cached by their encrypted form along with a scope provided by
+// the caller, which should identify the master key, region and credentials
+// used to decrypt them.
+//
+// A cache should only be shared by callers that use the same credentials, as a
+// cached key is returned without the key management service authorizing the
+// caller. The lock of the cache is not held while keys are generated or
+// decrypted, and therefore concurrent calls for a missing key may each call
+// the key management service.
+type KeyCache struct {
+	ttl time.Duration
+
+	mut       sync.Mutex
+	byID      map[string]cachedKey
+	decrypted map[decryptID]cachedKey
+}
+
+// NewKeyCache creates a cache where keys are reused for a given duration.
+func NewKeyCache(ttl time.Duration) *KeyCache {
+	return &KeyCache{
+		ttl:       ttl,
+		byID:      map[string]cachedKey{},
+		decrypted: map[decryptID]cachedKey{},
+	}
+}
+
+// ForEncrypt returns a data key generated by a master key, creating a new one
+// with the provided function when a key is not cached or has expired.
+func (k *KeyCache) ForEncrypt(id string, generate func() (DataKey, error)) (DataKey, error) {
+	k.mut.Lock()
+	c, exists := k.byID[id]
+	k.mut.Unlock()
+	if exists && time.Now().Before(c.expires) {
+		return c.key, nil
+	}
+
+	key, err := generate()
+	if err != nil {
+		return DataKey{}, err
+	}
+
+	k.mut.Lock()
+	k.byID[id] = cachedKey{key: key, expires: time.Now().Add(k.ttl)}
+	k.mut.Unlock()
+	return key, nil
+}
+
+// ForDecrypt returns the plaintext of an encrypted data key within a scope,
+// decrypting it with the provided function when it is not cached or has
+// expired.
+func (k *KeyCache) ForDecrypt(scope string, encrypted []byte, decrypt func() ([]byte, error)) ([]byte, error) {
+	id := decryptID{scope: scope, encrypted: string(encrypted)}
+
+	k.mut.Lock()
+	c, exists := k.decrypted[id]
+	k.mut.Unlock()
+	if exists && time.Now().Before(c.expires) {
+		return c.key.Plaintext, nil
+	}
+
+	plaintext, err := decrypt()
+	if err != nil {
+		return nil, err
+	}
+
+	k.mut.Lock()
+	k.decrypted[id] = cachedKey{
+		key:     DataKey{Plaintext: plaintext, Encrypted: append([]byte(nil), encrypted...)},
+		expires: time.Now().Add(k.ttl),
+	}
+	k.pruneExpired()
+	k.mut.Unlock()
+	return plaintext, nil
+}
+
+func (k *KeyCache) pruneExpired() {
+	now := time.Now()
+	for id, c := range k.decrypted {
+		if now.After(c.expires) {
+			delete(k.decrypted, id)
+		}
+	}
+}
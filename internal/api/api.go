@@ -2,6 +2,7 @@ package api
 
 import (
 	"context"
+	"crypto/subtle"
 	"crypto/tls"
 	"encoding/json"
 	"errors"
@@ -9,6 +10,7 @@ import (
 	"net/http"
 	"net/http/pprof"
 	"runtime"
+	"strings"
 	"sync"
 
 	"github.com/gorilla/mux"
@@ -27,6 +29,7 @@ type Config struct {
 	Enabled        bool                `json:"enabled" yaml:"enabled"`
 	RootPath       string              `json:"root_path" yaml:"root_path"`
 	DebugEndpoints bool                `json:"debug_endpoints" yaml:"debug_endpoints"`
+	DebugToken     string              `json:"debug_token" yaml:"debug_token"`
 	CertFile       string              `json:"cert_file" yaml:"cert_file"`
 	KeyFile        string              `json:"key_file" yaml:"key_file"`
 	CORS           httpdocs.ServerCORS `json:"cors" yaml:"cors"`
@@ -39,6 +42,7 @@ func NewConfig() Config {
 		Enabled:        true,
 		RootPath:       "/benthos",
 		DebugEndpoints: false,
+		DebugToken:     "",
 		CertFile:       "",
 		KeyFile:        "",
 		CORS:           httpdocs.NewServerCORS(),
@@ -190,15 +194,24 @@ func New(
 		)
 		t.RegisterEndpoint(
 			"/debug/pprof/heap", "DEBUG: Responds with a pprof-formatted heap profile.",
-			pprof.Index,
+			pprof.Handler("heap").ServeHTTP,
+		)
+		t.RegisterEndpoint(
+			"/debug/pprof/allocs", "DEBUG: Responds with a pprof-formatted profile of all past memory allocations.",
+			pprof.Handler("allocs").ServeHTTP,
 		)
 		t.RegisterEndpoint(
 			"/debug/pprof/block", "DEBUG: Responds with a pprof-formatted block profile.",
-			pprof.Index,
+			pprof.Handler("block").ServeHTTP,
 		)
 		t.RegisterEndpoint(
 			"/debug/pprof/mutex", "DEBUG: Responds with a pprof-formatted mutex profile.",
-			pprof.Index,
+			pprof.Handler("mutex").ServeHTTP,
+		)
+		t.RegisterEndpoint(
+			"/debug/pprof/goroutine", "DEBUG: Responds with a pprof-formatted goroutine profile."+
+				" Set the GET parameter debug=2 for a dump of all goroutine stack traces.",
+			pprof.Handler("goroutine").ServeHTTP,
 		)
 		t.RegisterEndpoint(
 			"/debug/pprof/symbol", "DEBUG: looks up the program counters listed"+
@@ -219,6 +232,8 @@ func New(
 	t.RegisterEndpoint("/version", "Returns the service version.", handleVersion)
 	t.RegisterEndpoint("/endpoints", "Returns this map of endpoints.", handleEndpoints)
 
+	t.registerLogLevels(log)
+
 	// If we want to expose a stats endpoint we register the endpoints.
 	if wHandlerFunc := stats.HandlerFunc(); wHandlerFunc != nil {
 		t.RegisterEndpoint("/stats", "Exposes service-wide metrics in the format configured.", wHandlerFunc)
@@ -233,8 +248,19 @@ func New(
 }
 
 // RegisterEndpoint registers a http.HandlerFunc under a path with a
-// description that will be displayed under the /endpoints path.
+// description that will be displayed under the /endpoints path. Endpoints
+// under the path /debug/ are only registered when debug endpoints are enabled,
+// and require the debug token when one is configured.
 func (t *Type) RegisterEndpoint(path, desc string, handlerFunc http.HandlerFunc) {
+	if strings.HasPrefix(path, "/debug/") {
+		if !t.conf.DebugEndpoints {
+			return
+		}
+		if t.conf.DebugToken != "" {
+			handlerFunc = debugTokenHandler(t.conf.DebugToken, handlerFunc)
+		}
+	}
+
 	t.endpointsMut.Lock()
 	defer t.endpointsMut.Unlock()
 
@@ -256,6 +282,17 @@ func (t *Type) RegisterEndpoint(path, desc string, handlerFunc http.HandlerFunc)
 	t.handlers[path] = handlerFunc
 }
 
+func debugTokenHandler(token string, h http.HandlerFunc) http.HandlerFunc {
+	expected := []byte("Bearer " + token)
+	return func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), expected) != 1 {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		h(w, r)
+	}
+}
+
 // ListenAndServe launches the API and blocks until the server closes or fails.
 func (t *Type) ListenAndServe() error {
 	if !t.conf.Enabled {
@@ -1,8 +1,10 @@
 package api
 
 import (
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -82,3 +84,84 @@ func TestAPIEnableCORSNoHeaders(t *testing.T) {
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "must specify at least one allowed origin")
 }
+
+func TestAPILogLevels(t *testing.T) {
+	lConf := log.NewConfig()
+	lConf.LogLevel = "NONE"
+	logger, err := log.NewV2(io.Discard, lConf)
+	require.NoError(t, err)
+
+	s, err := New("", "", NewConfig(), nil, logger, metrics.Noop())
+	require.NoError(t, err)
+
+	handler := s.server.Handler
+
+	request, _ := http.NewRequest("POST", "/log/levels", strings.NewReader(`{"path":"root.input","level":"DEBUG"}`))
+	response := httptest.NewRecorder()
+	handler.ServeHTTP(response, request)
+
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Equal(t, `{"root.input":"DEBUG"}`, response.Body.String())
+
+	request, _ = http.NewRequest("POST", "/log/levels", strings.NewReader(`{"path":"root.input","level":"nope"}`))
+	response = httptest.NewRecorder()
+	handler.ServeHTTP(response, request)
+
+	assert.Equal(t, http.StatusBadRequest, response.Code)
+
+	request, _ = http.NewRequest("GET", "/log/levels", http.NoBody)
+	response = httptest.NewRecorder()
+	handler.ServeHTTP(response, request)
+
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Equal(t, `{"root.input":"DEBUG"}`, response.Body.String())
+	assert.Equal(t, map[string]string{"root.input": "DEBUG"}, logger.(log.LevelOverrider).LevelOverrides())
+}
+
+func TestAPIDebugToken(t *testing.T) {
+	conf := NewConfig()
+	conf.DebugEndpoints = true
+	conf.DebugToken = "foo"
+
+	s, err := New("", "", conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	handler := s.server.Handler
+
+	for _, path := range []string{"/debug/stack", "/benthos/debug/pprof/goroutine"} {
+		request, _ := http.NewRequest("GET", path, http.NoBody)
+		response := httptest.NewRecorder()
+		handler.ServeHTTP(response, request)
+		assert.Equal(t, http.StatusUnauthorized, response.Code, path)
+
+		request, _ = http.NewRequest("GET", path, http.NoBody)
+		request.Header.Set("Authorization", "Bearer bar")
+		response = httptest.NewRecorder()
+		handler.ServeHTTP(response, request)
+		assert.Equal(t, http.StatusUnauthorized, response.Code, path)
+
+		request, _ = http.NewRequest("GET", path+"?debug=2", http.NoBody)
+		request.Header.Set("Authorization", "Bearer foo")
+		response = httptest.NewRecorder()
+		handler.ServeHTTP(response, request)
+		assert.Equal(t, http.StatusOK, response.Code, path)
+		assert.Contains(t, response.Body.String(), "goroutine ", path)
+	}
+
+	request, _ := http.NewRequest("GET", "/ping", http.NoBody)
+	response := httptest.NewRecorder()
+	handler.ServeHTTP(response, request)
+	assert.Equal(t, http.StatusOK, response.Code)
+}
+
+func TestAPIDebugEndpointsDisabled(t *testing.T) {
+	s, err := New("", "", NewConfig(), nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	s.RegisterEndpoint("/debug/foo", "Foo.", func(w http.ResponseWriter, r *http.Request) {})
+
+	request, _ := http.NewRequest("GET", "/debug/foo", http.NoBody)
+	response := httptest.NewRecorder()
+	s.server.Handler.ServeHTTP(response, request)
+	assert.Equal(t, http.StatusNotFound, response.Code)
+}
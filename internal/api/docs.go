@@ -16,6 +16,9 @@ func Spec() docs.FieldSpecs {
 		docs.FieldBool(
 			"debug_endpoints", "Whether to register a few extra endpoints that can be useful for debugging performance or behavioral problems.",
 		).HasDefault(false),
+		docs.FieldString(
+			"debug_token", "An optional token required in order to access debug endpoints, which must be provided in requests with the header `Authorization: Bearer <token>`.",
+		).Advanced().HasDefault("").AtVersion("4.1.0"),
 		docs.FieldString("cert_file", "An optional certificate file for enabling TLS.").Advanced().HasDefault(""),
 		docs.FieldString("key_file", "An optional key file for enabling TLS.").Advanced().HasDefault(""),
 		httpdocs.ServerCORSFieldSpec(),
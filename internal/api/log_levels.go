@@ -0,0 +1,60 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/benthosdev/benthos/v4/internal/log"
+)
+
+type logLevelRequest struct {
+	Path  string `json:"path"`
+	Level string `json:"level"`
+}
+
+// registerLogLevels exposes an endpoint for adjusting the log level of
+// component paths at runtime, if the logger supports level overrides.
+func (t *Type) registerLogLevels(l log.Modular) {
+	lo, ok := l.(log.LevelOverrider)
+	if !ok {
+		return
+	}
+
+	t.RegisterEndpoint(
+		"/log/levels",
+		"GET: Returns the log level overrides of component paths as a JSON object. "+
+			"POST: Sets the log level of a component path from a JSON object of the form "+
+			`{"path":"root.input","level":"DEBUG"}, where an empty level removes the override.`,
+		func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method {
+			case "GET":
+			case "POST":
+				var req logLevelRequest
+				if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
+				if req.Path == "" {
+					http.Error(w, "a path must be specified", http.StatusBadRequest)
+					return
+				}
+				if err := lo.SetLevelOverride(req.Path, req.Level); err != nil {
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
+				t.log.Infof("Log level override of path '%v' set to '%v' via API\n", req.Path, req.Level)
+			default:
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+
+			resBytes, err := json.Marshal(lo.LevelOverrides())
+			if err != nil {
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write(resBytes)
+		},
+	)
+}
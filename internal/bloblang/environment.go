@@ -63,6 +63,10 @@ func (e *Environment) NewField(expr string) (*field.Expression, error) {
 // NewMapping parses a Bloblang mapping using the Environment to determine the
 // features (functions and methods) available to the mapping.
 //
+// Files imported by the mapping are compiled once and cached within the
+// environment, where subsequent mappings that import the same unchanged files
+// reuse the compiled result.
+//
 // When a parsing error occurs the error will be the type *parser.Error, which
 // gives access to the line and column where the error occurred, as well as a
 // method for creating a well formatted error message.
@@ -101,16 +105,19 @@ func (e *Environment) OnlyPure() *Environment {
 	env := *e
 	env.pCtx.Functions = env.pCtx.Functions.OnlyPure()
 	env.pCtx.Methods = env.pCtx.Methods.OnlyPure()
+	env.pCtx = env.pCtx.WithoutImportCache()
 	return &env
 }
 
 // RegisterMethod adds a new Bloblang method to the environment.
 func (e *Environment) RegisterMethod(spec query.MethodSpec, ctor query.MethodCtor) error {
+	e.pCtx = e.pCtx.WithoutImportCache()
 	return e.pCtx.Methods.Add(spec, ctor)
 }
 
 // RegisterFunction adds a new Bloblang function to the environment.
 func (e *Environment) RegisterFunction(spec query.FunctionSpec, ctor query.FunctionCtor) error {
+	e.pCtx = e.pCtx.WithoutImportCache()
 	return e.pCtx.Functions.Add(spec, ctor)
 }
 
@@ -156,6 +163,7 @@ func (e *Environment) WithCustomImporter(fn func(name string) ([]byte, error)) *
 func (e *Environment) WithoutMethods(names ...string) *Environment {
 	env := *e
 	env.pCtx.Methods = env.pCtx.Methods.Without(names...)
+	env.pCtx = env.pCtx.WithoutImportCache()
 	return &env
 }
 
@@ -165,6 +173,7 @@ func (e *Environment) WithoutMethods(names ...string) *Environment {
 func (e *Environment) WithoutFunctions(names ...string) *Environment {
 	env := *e
 	env.pCtx.Functions = env.pCtx.Functions.Without(names...)
+	env.pCtx = env.pCtx.WithoutImportCache()
 	return &env
 }
 
@@ -85,6 +85,13 @@ func (e *Executor) SetMaxMapRecursion(m int) {
 	e.maxMapStacks = m
 }
 
+// Clone returns a shallow copy of the executor, which can be configured
+// independently of the original.
+func (e *Executor) Clone() *Executor {
+	c := *e
+	return &c
+}
+
 // Annotation returns a string annotation that describes the mapping executor.
 func (e *Executor) Annotation() string {
 	return e.annotation
@@ -159,7 +166,7 @@ func (e *Executor) mapPart(appendTo *message.Part, index int, reference Message)
 		newPart = reference.Get(index).Copy()
 	} else {
 		newPart = appendTo
-		if appendObj, err := appendTo.JSON(); err == nil {
+		if appendObj, err := appendTo.JSONMut(); err == nil {
 			newValue = appendObj
 		}
 	}
@@ -5,7 +5,9 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"sync"
 
+	"github.com/benthosdev/benthos/v4/internal/bloblang/mapping"
 	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
 )
 
@@ -16,6 +18,8 @@ type Context struct {
 	Methods      *query.MethodSet
 	namedContext *namedContext
 	importer     Importer
+	imports      *importCache
+	importDeps   *[]importDep
 }
 
 // EmptyContext returns a parser context with no functions, methods or import
@@ -25,6 +29,7 @@ func EmptyContext() Context {
 		Functions: query.NewFunctionSet(),
 		Methods:   query.NewMethodSet(),
 		importer:  newOSImporter(),
+		imports:   newImportCache(),
 	}
 }
 
@@ -35,6 +40,7 @@ func GlobalContext() Context {
 		Functions: query.AllFunctions,
 		Methods:   query.AllMethods,
 		importer:  newOSImporter(),
+		imports:   newImportCache(),
 	}
 }
 
@@ -78,6 +84,7 @@ func (pCtx Context) InitMethod(name string, target query.Function, args *query.P
 // Importer implementation.
 func (pCtx Context) WithImporter(importer Importer) Context {
 	pCtx.importer = importer
+	pCtx.imports = newImportCache()
 	return pCtx
 }
 
@@ -98,6 +105,7 @@ func (pCtx Context) Deactivated() Context {
 	nextCtx := pCtx
 	nextCtx.Functions = pCtx.Functions.Deactivated()
 	nextCtx.Methods = pCtx.Methods.Deactivated()
+	nextCtx.imports = newImportCache()
 	return nextCtx
 }
 
@@ -107,6 +115,7 @@ func (pCtx Context) Deactivated() Context {
 func (pCtx Context) CustomImporter(fn func(name string) ([]byte, error)) Context {
 	nextCtx := pCtx
 	nextCtx.importer = newCustomImporter(fn)
+	nextCtx.imports = newImportCache()
 	return nextCtx
 }
 
@@ -116,6 +125,17 @@ func (pCtx Context) CustomImporter(fn func(name string) ([]byte, error)) Context
 func (pCtx Context) DisabledImports() Context {
 	nextCtx := pCtx
 	nextCtx.importer = disabledImporter{}
+	nextCtx.imports = newImportCache()
+	return nextCtx
+}
+
+// WithoutImportCache returns a version of the parser context where previously
+// compiled imports are forgotten. This should be called whenever the functions
+// or methods available to the context are modified, as imports compiled prior
+// to that modification would no longer reflect it.
+func (pCtx Context) WithoutImportCache() Context {
+	nextCtx := pCtx
+	nextCtx.imports = newImportCache()
 	return nextCtx
 }
 
@@ -138,6 +158,75 @@ type Importer interface {
 
 //------------------------------------------------------------------------------
 
+// resolvingImporter is implemented by importers that are able to resolve an
+// import path into a path that uniquely identifies the imported file, which
+// allows the compiled result of importing it to be cached.
+type resolvingImporter interface {
+	resolve(pathStr string) string
+}
+
+// importCache stores the compiled results of imported files so that mappings
+// throughout a config importing the same files do not need to parse them
+// repeatedly. Cached entries are only reused when the contents of the file,
+// and all files that it imports, have not changed since they were compiled.
+type importCache struct {
+	mut       sync.Mutex
+	executors map[string]cachedImport
+}
+
+type cachedImport struct {
+	exec *mapping.Executor
+	deps []importDep
+}
+
+// importDep describes a file read during the compilation of an import.
+type importDep struct {
+	importer Importer
+	path     string
+	contents string
+}
+
+func (d importDep) unchanged() bool {
+	contents, err := d.importer.Import(d.path)
+	return err == nil && string(contents) == d.contents
+}
+
+func newImportCache() *importCache {
+	return &importCache{
+		executors: map[string]cachedImport{},
+	}
+}
+
+func (c *importCache) get(key string, self importDep) (cachedImport, bool) {
+	if c == nil {
+		return cachedImport{}, false
+	}
+	c.mut.Lock()
+	cached, exists := c.executors[key]
+	c.mut.Unlock()
+
+	if !exists || len(cached.deps) == 0 || cached.deps[0].contents != self.contents {
+		return cachedImport{}, false
+	}
+	for _, dep := range cached.deps[1:] {
+		if !dep.unchanged() {
+			return cachedImport{}, false
+		}
+	}
+	return cached, true
+}
+
+func (c *importCache) set(key string, cached cachedImport) {
+	if c == nil {
+		return
+	}
+	c.mut.Lock()
+	c.executors[key] = cached
+	c.mut.Unlock()
+}
+
+//------------------------------------------------------------------------------
+
 type osImporter struct {
 	relativePath string
 }
@@ -149,12 +238,15 @@ func newOSImporter() Importer {
 	}
 }
 
-func (i *osImporter) Import(pathStr string) ([]byte, error) {
+func (i *osImporter) resolve(pathStr string) string {
 	if !filepath.IsAbs(pathStr) {
 		pathStr = filepath.Join(i.relativePath, pathStr)
 	}
+	return pathStr
+}
 
-	f, err := os.Open(pathStr)
+func (i *osImporter) Import(pathStr string) ([]byte, error) {
+	f, err := os.Open(i.resolve(pathStr))
 	if err != nil {
 		return nil, err
 	}
@@ -191,12 +283,15 @@ func newCustomImporter(readFn func(name string) ([]byte, error)) Importer {
 	}
 }
 
-func (i *customImporter) Import(pathStr string) ([]byte, error) {
+func (i *customImporter) resolve(pathStr string) string {
 	if !filepath.IsAbs(pathStr) {
 		pathStr = filepath.Join(i.relativePath, pathStr)
 	}
+	return pathStr
+}
 
-	return i.readFn(pathStr)
+func (i *customImporter) Import(pathStr string) ([]byte, error) {
+	return i.readFn(i.resolve(pathStr))
 }
 
 func (i *customImporter) RelativeToFile(filePath string) Importer {
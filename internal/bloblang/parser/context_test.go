@@ -9,6 +9,8 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/message"
 )
 
 func TestContextImportIsolation(t *testing.T) {
@@ -169,3 +171,52 @@ func TestContextCustomImports(t *testing.T) {
 		assert.Equal(t, `map baz { root.baz = this.baz }`, string(content))
 	}
 }
+
+func TestContextImportCache(t *testing.T) {
+	mappings := map[string]string{
+		"mappings/foo.blobl": `import "./bar.blobl"
+map foo { root.foo = this.foo.apply("bar") }`,
+		"mappings/bar.blobl": `map bar { root = this.uppercase() }`,
+	}
+
+	importer := func(name string) ([]byte, error) {
+		name = path.Clean(name)
+		s, ok := mappings[name]
+		if !ok {
+			return nil, fmt.Errorf("mapping %v not found", name)
+		}
+		return []byte(s), nil
+	}
+
+	pCtx := GlobalContext().CustomImporter(importer)
+
+	execA, err := ParseMapping(pCtx, `import "mappings/foo.blobl"
+root = this.apply("foo")`)
+	require.Nil(t, err)
+
+	execB, err := ParseMapping(pCtx, `import "mappings/foo.blobl"
+root.nested = this.apply("foo")`)
+	require.Nil(t, err)
+
+	assert.Len(t, pCtx.imports.executors, 2)
+	assert.Same(t, execA.Maps()["foo"], execB.Maps()["foo"])
+	assert.Same(t, execA.Maps()["bar"], execB.Maps()["bar"])
+
+	res, rerr := execB.MapPart(0, message.QuickBatch([][]byte{[]byte(`{"foo":"hello"}`)}))
+	require.NoError(t, rerr)
+	assert.Equal(t, `{"nested":{"foo":"HELLO"}}`, string(res.Get()))
+
+	// Changes to imported files are reflected in subsequent parses.
+	mappings["mappings/bar.blobl"] = `map bar { root = this.lowercase() }`
+
+	execC, err := ParseMapping(pCtx, `import "mappings/foo.blobl"
+root = this.apply("foo")`)
+	require.Nil(t, err)
+
+	assert.NotSame(t, execA.Maps()["foo"], execC.Maps()["foo"])
+	res, rerr = execC.MapPart(0, message.QuickBatch([][]byte{[]byte(`{"foo":"HELLO"}`)}))
+	require.NoError(t, rerr)
+	assert.Equal(t, `{"foo":"hello"}`, string(res.Get()))
+
+	assert.Empty(t, pCtx.WithoutImportCache().imports.executors)
+}
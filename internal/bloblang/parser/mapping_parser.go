@@ -115,20 +115,58 @@ func singleRootImport(pCtx Context) Func {
 		}
 
 		fpath := res.Payload.([]interface{})[3].(string)
-		contents, err := pCtx.importer.Import(fpath)
+		exec, err := parseImport(pCtx, input, fpath)
 		if err != nil {
-			return Fail(NewFatalError(input, fmt.Errorf("failed to read import: %w", err)), input)
+			return Fail(err, input)
 		}
 
-		nextCtx := pCtx.WithImporterRelativeToFile(fpath)
+		// The executor is copied as it may be modified by the caller, whereas
+		// the compiled import could be shared with other mappings.
+		return Success(exec.Clone(), res.Remaining)
+	}
+}
 
-		importContent := []rune(string(contents))
-		execRes := parseExecutor(nextCtx)(importContent)
-		if execRes.Err != nil {
-			return Fail(NewFatalError(input, NewImportError(fpath, importContent, execRes.Err)), input)
+// parseImport reads and compiles a mapping file from the importer of a parser
+// context. When the importer is able to resolve the file then the compiled
+// result is cached within the context, and reused for subsequent imports of the
+// same file for as long as its contents, and the contents of any files it
+// imports, remain unchanged.
+func parseImport(pCtx Context, input []rune, fpath string) (*mapping.Executor, *Error) {
+	contents, err := pCtx.importer.Import(fpath)
+	if err != nil {
+		return nil, NewFatalError(input, fmt.Errorf("failed to read import: %w", err))
+	}
+	self := importDep{importer: pCtx.importer, path: fpath, contents: string(contents)}
+
+	var cacheKey string
+	if r, ok := pCtx.importer.(resolvingImporter); ok {
+		cacheKey = r.resolve(fpath)
+		if cached, exists := pCtx.imports.get(cacheKey, self); exists {
+			if pCtx.importDeps != nil {
+				*pCtx.importDeps = append(*pCtx.importDeps, cached.deps...)
+			}
+			return cached.exec, nil
 		}
-		return Success(execRes.Payload.(*mapping.Executor), res.Remaining)
 	}
+
+	deps := []importDep{self}
+	nextCtx := pCtx.WithImporterRelativeToFile(fpath)
+	nextCtx.importDeps = &deps
+
+	importContent := []rune(string(contents))
+	execRes := parseExecutor(nextCtx)(importContent)
+	if execRes.Err != nil {
+		return nil, NewFatalError(input, NewImportError(fpath, importContent, execRes.Err))
+	}
+
+	exec := execRes.Payload.(*mapping.Executor)
+	if cacheKey != "" {
+		pCtx.imports.set(cacheKey, cachedImport{exec: exec, deps: deps})
+	}
+	if pCtx.importDeps != nil {
+		*pCtx.importDeps = append(*pCtx.importDeps, deps...)
+	}
+	return exec, nil
 }
 
 func singleRootMapping(pCtx Context) Func {
@@ -200,20 +238,11 @@ func importParser(maps map[string]query.Function, pCtx Context) Func {
 		}
 
 		fpath := res.Payload.([]interface{})[2].(string)
-		contents, err := pCtx.importer.Import(fpath)
-		if err != nil {
-			return Fail(NewFatalError(input, fmt.Errorf("failed to read import: %w", err)), input)
-		}
-
-		nextCtx := pCtx.WithImporterRelativeToFile(fpath)
-
-		importContent := []rune(string(contents))
-		execRes := parseExecutor(nextCtx)(importContent)
-		if execRes.Err != nil {
-			return Fail(NewFatalError(input, NewImportError(fpath, importContent, execRes.Err)), input)
+		exec, perr := parseImport(pCtx, input, fpath)
+		if perr != nil {
+			return Fail(perr, input)
 		}
 
-		exec := execRes.Payload.(*mapping.Executor)
 		if len(exec.Maps()) == 0 {
 			err := fmt.Errorf("no maps to import from '%v'", fpath)
 			return Fail(NewFatalError(input, err), input)
@@ -143,6 +143,7 @@ var (
 	MethodCategoryParsing        MethodCategory = "Parsing"
 	MethodCategoryObjectAndArray MethodCategory = "Object & Array Manipulation"
 	MethodCategoryGeoIP          MethodCategory = "GeoIP"
+	MethodCategoryGeospatial     MethodCategory = "Geospatial"
 	MethodCategoryDeprecated     MethodCategory = "Deprecated"
 	MethodCategoryPlugin         MethodCategory = "Plugin"
 )
@@ -2,6 +2,7 @@ package query
 
 import (
 	"context"
+	crand "crypto/rand"
 	"errors"
 	"fmt"
 	"math/rand"
@@ -385,6 +386,45 @@ func fileFunction(args *ParsedParams) (Function, error) {
 
 //------------------------------------------------------------------------------
 
+var _ = registerFunction(
+	NewFunctionSpec(
+		FunctionCategoryEnvironment, "cache",
+		"Returns the value stored under a key within a [cache resource](/docs/components/caches/about) as a string, or `null` if the key does not exist. This function is only available within mappings of components that have access to resources.",
+		NewExampleSpec("",
+			`root.user = cache("users", this.user_id).parse_json().catch({})`,
+		),
+	).Beta().MarkImpure().
+		Param(ParamString("resource", "The label of a cache resource.")).
+		Param(ParamString("key", "The key of the value to obtain.")),
+	resourcesUnavailableFunction("cache"),
+)
+
+var _ = registerFunction(
+	NewFunctionSpec(
+		FunctionCategoryEnvironment, "rate_limit",
+		"Attempts to access a [rate limit resource](/docs/components/rate_limits/about) and returns `true` if the access was permitted, or `false` if the rate limit has been reached. Unlike components that use rate limits this function never blocks. This function is only available within mappings of components that have access to resources.",
+		NewExampleSpec("",
+			`root = if !rate_limit("alerts") { deleted() }`,
+		),
+	).Beta().MarkImpure().
+		Param(ParamString("resource", "The label of a rate limit resource.")),
+	resourcesUnavailableFunction("rate_limit"),
+)
+
+// The functions cache and rate_limit are registered globally so that mappings
+// referencing them can be parsed and linted, but they're only functional
+// within environments that replace their constructors with ones that have
+// access to resources.
+func resourcesUnavailableFunction(name string) FunctionCtor {
+	return func(args *ParsedParams) (Function, error) {
+		return ClosureFunction("function "+name, func(ctx FunctionContext) (interface{}, error) {
+			return nil, fmt.Errorf("function %v requires access to resources, which is not available within this context", name)
+		}, nil), nil
+	}
+}
+
+//------------------------------------------------------------------------------
+
 var _ = registerFunction(
 	NewFunctionSpec(
 		FunctionCategoryGeneral, "range",
@@ -832,6 +872,170 @@ var _ = registerSimpleFunction(
 
 //------------------------------------------------------------------------------
 
+var _ = registerSimpleFunction(
+	NewFunctionSpec(
+		FunctionCategoryGeneral, "uuid_v7",
+		"Generates a new time-ordered UUID (version 7) each time it is invoked and prints a string representation. UUIDs generated in later milliseconds sort lexicographically after those generated earlier.",
+		NewExampleSpec("", `root.id = uuid_v7()`),
+	),
+	func(_ FunctionContext) (interface{}, error) {
+		var u uuid.UUID
+		if _, err := crand.Read(u[6:]); err != nil {
+			return nil, err
+		}
+		putUnixMillis48(u[:6], time.Now())
+		u[6] = 0x70 | (u[6] & 0x0f)
+		u[8] = 0x80 | (u[8] & 0x3f)
+		return u.String(), nil
+	},
+)
+
+//------------------------------------------------------------------------------
+
+var _ = registerSimpleFunction(
+	NewFunctionSpec(
+		FunctionCategoryGeneral, "ulid",
+		"Generates a new [ULID](https://github.com/ulid/spec) each time it is invoked and prints a string representation. ULIDs generated in later milliseconds sort lexicographically after those generated earlier.",
+		NewExampleSpec("", `root.id = ulid()`),
+	),
+	func(_ FunctionContext) (interface{}, error) {
+		var id [16]byte
+		if _, err := crand.Read(id[6:]); err != nil {
+			return nil, err
+		}
+		putUnixMillis48(id[:6], time.Now())
+		return encodeULID(id), nil
+	},
+)
+
+// putUnixMillis48 writes the unix time of t in milliseconds as a 48 bit big
+// endian integer.
+func putUnixMillis48(b []byte, t time.Time) {
+	ms := uint64(t.UnixNano() / int64(time.Millisecond))
+	for i := 5; i >= 0; i-- {
+		b[i] = byte(ms)
+		ms >>= 8
+	}
+}
+
+const crockfordAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// encodeULID encodes 128 bits as 26 characters of Crockford's base32, where
+// the first character encodes only the two most significant bits.
+func encodeULID(id [16]byte) string {
+	var hi, lo uint64
+	for i := 0; i < 8; i++ {
+		hi = hi<<8 | uint64(id[i])
+		lo = lo<<8 | uint64(id[i+8])
+	}
+
+	var out [26]byte
+	for i := 25; i >= 0; i-- {
+		out[i] = crockfordAlphabet[lo&0x1f]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(out[:])
+}
+
+//------------------------------------------------------------------------------
+
+var _ = registerFunction(
+	NewFunctionSpec(
+		FunctionCategoryGeneral, "snowflake",
+		"Generates a new 64 bit [snowflake ID](https://en.wikipedia.org/wiki/Snowflake_ID) each time it is invoked, composed of a 41 bit millisecond timestamp (since the Twitter epoch of 1288834974657), a 10 bit node ID and a 12 bit sequence number. IDs generated with the same node ID are unique within a process and sort in the order that they were generated, and so each process generating IDs should use a distinct node ID.",
+		NewExampleSpec("", `root.id = snowflake(1)`),
+		NewExampleSpec("The node ID can be obtained from the environment.", `root.id = snowflake(env("NODE_ID").number())`),
+	).
+		Param(ParamInt64("node_id", "An ID between 0 and 1023 identifying the generator.")),
+	snowflakeFunction,
+)
+
+const (
+	snowflakeEpochMillis = 1288834974657
+	snowflakeMaxNode     = 1<<10 - 1
+	snowflakeMaxSequence = 1<<12 - 1
+)
+
+type snowflakeGenerator struct {
+	mut        sync.Mutex
+	node       int64
+	lastMillis int64
+	sequence   int64
+
+	nowFn   func() time.Time
+	sleepFn func(time.Duration)
+}
+
+func newSnowflakeGenerator(node int64) *snowflakeGenerator {
+	return &snowflakeGenerator{
+		node:       node,
+		lastMillis: -1,
+		nowFn:      time.Now,
+		sleepFn:    time.Sleep,
+	}
+}
+
+func (s *snowflakeGenerator) next() int64 {
+	for {
+		id, wait := s.tryNext()
+		if wait <= 0 {
+			return id
+		}
+		// The lock is released whilst waiting so that other callers aren't
+		// blocked behind a clock that has moved backwards.
+		s.sleepFn(wait)
+	}
+}
+
+// tryNext attempts to generate an ID, and when the sequence for the last
+// timestamp used is exhausted returns the duration to wait before trying
+// again instead.
+func (s *snowflakeGenerator) tryNext() (id int64, wait time.Duration) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	now := s.nowFn().UnixNano()/int64(time.Millisecond) - snowflakeEpochMillis
+	if now < s.lastMillis {
+		// Avoid duplicates when the clock moves backwards by continuing from
+		// the last timestamp used.
+		if s.sequence == snowflakeMaxSequence {
+			return 0, time.Duration(s.lastMillis-now+1) * time.Millisecond
+		}
+		now = s.lastMillis
+	}
+	if now == s.lastMillis {
+		if s.sequence == snowflakeMaxSequence {
+			return 0, 100 * time.Microsecond
+		}
+		s.sequence++
+	} else {
+		s.sequence = 0
+	}
+	s.lastMillis = now
+	return now<<22 | s.node<<12 | s.sequence, 0
+}
+
+// Generators are shared by node ID so that separate mappings within a process
+// do not produce colliding IDs.
+var snowflakeGenerators sync.Map
+
+func snowflakeFunction(args *ParsedParams) (Function, error) {
+	node, err := args.FieldInt64("node_id")
+	if err != nil {
+		return nil, err
+	}
+	if node < 0 || node > snowflakeMaxNode {
+		return nil, fmt.Errorf("node_id must be between 0 and %v, got %v", snowflakeMaxNode, node)
+	}
+	gen, _ := snowflakeGenerators.LoadOrStore(node, newSnowflakeGenerator(node))
+	return ClosureFunction("function snowflake", func(ctx FunctionContext) (interface{}, error) {
+		return gen.(*snowflakeGenerator).next(), nil
+	}, nil), nil
+}
+
+//------------------------------------------------------------------------------
+
 var _ = registerFunction(
 	NewHiddenFunctionSpec("var").Param(ParamString("name", "The name of the target variable.")),
 	func(args *ParsedParams) (Function, error) {
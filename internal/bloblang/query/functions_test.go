@@ -5,6 +5,7 @@ import (
 	"os"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -268,6 +269,90 @@ func TestNanoidFunctionAlphabet(t *testing.T) {
 	assert.Equal(t, "a", res)
 }
 
+func TestUUIDV7Function(t *testing.T) {
+	e, err := InitFunctionHelper("uuid_v7")
+	require.NoError(t, err)
+
+	first, err := e.Exec(FunctionContext{})
+	require.NoError(t, err)
+	require.Regexp(t, "^[0-9a-f]{8}-[0-9a-f]{4}-7[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$", first)
+
+	time.Sleep(time.Millisecond * 2)
+
+	second, err := e.Exec(FunctionContext{})
+	require.NoError(t, err)
+	assert.Less(t, first, second)
+}
+
+func TestULIDFunction(t *testing.T) {
+	e, err := InitFunctionHelper("ulid")
+	require.NoError(t, err)
+
+	first, err := e.Exec(FunctionContext{})
+	require.NoError(t, err)
+	require.Regexp(t, "^[0-7][0-9A-HJKMNP-TV-Z]{25}$", first)
+
+	time.Sleep(time.Millisecond * 2)
+
+	second, err := e.Exec(FunctionContext{})
+	require.NoError(t, err)
+	assert.Less(t, first, second)
+
+	assert.Equal(t, "0000000000000000000000000Z", encodeULID([16]byte{15: 0x1f}))
+	assert.Equal(t, "7ZZZZZZZZZZZZZZZZZZZZZZZZZ", encodeULID([16]byte{
+		0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+		0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+	}))
+}
+
+func TestSnowflakeFunction(t *testing.T) {
+	_, err := InitFunctionHelper("snowflake", int64(1024))
+	require.Error(t, err)
+
+	e, err := InitFunctionHelper("snowflake", int64(5))
+	require.NoError(t, err)
+
+	var last int64
+	for i := 0; i < 10000; i++ {
+		res, err := e.Exec(FunctionContext{})
+		require.NoError(t, err)
+
+		id := res.(int64)
+		require.Greater(t, id, last)
+		assert.Equal(t, int64(5), (id>>12)&0x3ff)
+		last = id
+	}
+}
+
+func TestSnowflakeClockBackwards(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	now := start
+
+	s := newSnowflakeGenerator(1)
+	s.nowFn = func() time.Time {
+		return now
+	}
+	var slept time.Duration
+	s.sleepFn = func(d time.Duration) {
+		// Other callers must not be blocked whilst waiting.
+		require.True(t, s.mut.TryLock())
+		s.mut.Unlock()
+
+		slept += d
+		now = now.Add(d)
+	}
+
+	first := s.next()
+
+	s.sequence = snowflakeMaxSequence
+	now = start.Add(-5 * time.Millisecond)
+
+	id := s.next()
+	assert.Greater(t, id, first)
+	assert.Equal(t, int64(0), id&snowflakeMaxSequence)
+	assert.Equal(t, 6*time.Millisecond, slept)
+}
+
 func TestKsuidFunction(t *testing.T) {
 	e, err := InitFunctionHelper("ksuid")
 	require.Nil(t, err)
@@ -0,0 +1,399 @@
+package query
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+var _ = registerSimpleMethod(
+	NewMethodSpec(
+		"geohash_encode", "",
+	).InCategory(
+		MethodCategoryGeospatial,
+		"Encodes a geographic point as a [geohash](https://en.wikipedia.org/wiki/Geohash) string. The point can either be an object containing the numerical fields `lat` and `lon`, or a GeoJSON `Point` geometry.",
+		NewExampleSpec("",
+			`root.hash = this.location.geohash_encode(precision: 7)`,
+			`{"location":{"lat":51.5074,"lon":-0.1278}}`,
+			`{"hash":"gcpvj0d"}`,
+			`{"location":{"type":"Point","coordinates":[-0.1278,51.5074]}}`,
+			`{"hash":"gcpvj0d"}`,
+		),
+	).Beta().
+		Param(ParamInt64("precision", "The number of characters of the resulting geohash, between 1 and 12.").Default(12)),
+	func(args *ParsedParams) (simpleMethod, error) {
+		precision, err := args.FieldInt64("precision")
+		if err != nil {
+			return nil, err
+		}
+		if precision < 1 || precision > 12 {
+			return nil, fmt.Errorf("precision must be between 1 and 12, got %v", precision)
+		}
+		return func(v interface{}, ctx FunctionContext) (interface{}, error) {
+			lat, lon, err := geoPointFromValue(v)
+			if err != nil {
+				return nil, err
+			}
+			return geohashEncode(lat, lon, int(precision)), nil
+		}, nil
+	},
+)
+
+var _ = registerSimpleMethod(
+	NewMethodSpec(
+		"geohash_decode", "",
+	).InCategory(
+		MethodCategoryGeospatial,
+		"Decodes a [geohash](https://en.wikipedia.org/wiki/Geohash) string into an object containing the `lat` and `lon` of the center of the area it describes.",
+		NewExampleSpec("",
+			`root.location = this.hash.geohash_decode()`,
+			`{"hash":"u4pruydqqvj"}`,
+			`{"location":{"lat":57.649111,"lon":10.40744}}`,
+		),
+	).Beta(),
+	func(*ParsedParams) (simpleMethod, error) {
+		return func(v interface{}, ctx FunctionContext) (interface{}, error) {
+			hash, err := IGetString(v)
+			if err != nil {
+				return nil, err
+			}
+			lat, lon, err := geohashDecode(hash)
+			if err != nil {
+				return nil, err
+			}
+			return map[string]interface{}{
+				"lat": lat,
+				"lon": lon,
+			}, nil
+		}, nil
+	},
+)
+
+var _ = registerSimpleMethod(
+	NewMethodSpec(
+		"haversine_distance", "",
+	).InCategory(
+		MethodCategoryGeospatial,
+		"Calculates the great-circle distance in meters between a geographic point and the coordinates provided as arguments using the [haversine formula](https://en.wikipedia.org/wiki/Haversine_formula). The point can either be an object containing the numerical fields `lat` and `lon`, or a GeoJSON `Point` geometry.",
+		NewExampleSpec("",
+			`root.distance_km = (this.location.haversine_distance(lat: 48.8566, lon: 2.3522) / 1000).round()`,
+			`{"location":{"lat":51.5074,"lon":-0.1278}}`,
+			`{"distance_km":344}`,
+		),
+	).Beta().
+		Param(ParamFloat("lat", "The latitude of the point to measure the distance to.")).
+		Param(ParamFloat("lon", "The longitude of the point to measure the distance to.")),
+	func(args *ParsedParams) (simpleMethod, error) {
+		toLat, err := args.FieldFloat("lat")
+		if err != nil {
+			return nil, err
+		}
+		toLon, err := args.FieldFloat("lon")
+		if err != nil {
+			return nil, err
+		}
+		return func(v interface{}, ctx FunctionContext) (interface{}, error) {
+			lat, lon, err := geoPointFromValue(v)
+			if err != nil {
+				return nil, err
+			}
+			return haversineDistance(lat, lon, toLat, toLon), nil
+		}, nil
+	},
+)
+
+var _ = registerSimpleMethod(
+	NewMethodSpec(
+		"geo_within", "",
+	).InCategory(
+		MethodCategoryGeospatial,
+		"Checks whether a geographic point lies within a GeoJSON geometry, which can be a `Polygon` or `MultiPolygon` geometry, a `Feature` containing one, or a `FeatureCollection` where the point lies within any of its features. Holes within polygons are respected. The point can either be an object containing the numerical fields `lat` and `lon`, or a GeoJSON `Point` geometry.",
+		NewExampleSpec("",
+			`root.in_zone = this.location.geo_within({"type":"Polygon","coordinates":[[[-0.5,51.3],[0.3,51.3],[0.3,51.7],[-0.5,51.7],[-0.5,51.3]]]})`,
+			`{"location":{"lat":51.5074,"lon":-0.1278}}`,
+			`{"in_zone":true}`,
+			`{"location":{"lat":48.8566,"lon":2.3522}}`,
+			`{"in_zone":false}`,
+		),
+	).Beta().
+		Param(ParamObject("geometry", "A GeoJSON object describing the area to check.")),
+	func(args *ParsedParams) (simpleMethod, error) {
+		geometry, err := args.Field("geometry")
+		if err != nil {
+			return nil, err
+		}
+		polygons, err := geoPolygonsFromGeoJSON(geometry)
+		if err != nil {
+			return nil, err
+		}
+		return func(v interface{}, ctx FunctionContext) (interface{}, error) {
+			lat, lon, err := geoPointFromValue(v)
+			if err != nil {
+				return nil, err
+			}
+			for _, p := range polygons {
+				if p.contains(lon, lat) {
+					return true, nil
+				}
+			}
+			return false, nil
+		}, nil
+	},
+)
+
+//------------------------------------------------------------------------------
+
+const earthRadiusMeters = 6371008.8
+
+func haversineDistance(latA, lonA, latB, lonB float64) float64 {
+	toRad := func(deg float64) float64 {
+		return deg * math.Pi / 180
+	}
+	dLat := toRad(latB - latA)
+	dLon := toRad(lonB - lonA)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRad(latA))*math.Cos(toRad(latB))*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusMeters * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+// geoPointFromValue extracts the latitude and longitude of either an object
+// with lat and lon fields or a GeoJSON point.
+func geoPointFromValue(v interface{}) (lat, lon float64, err error) {
+	obj, ok := v.(map[string]interface{})
+	if !ok {
+		return 0, 0, NewTypeError(v, ValueObject)
+	}
+	if t, _ := obj["type"].(string); t == "Point" {
+		coords, ok := obj["coordinates"].([]interface{})
+		if !ok || len(coords) < 2 {
+			return 0, 0, errors.New("expected GeoJSON point to contain an array of at least two coordinates")
+		}
+		if lon, err = IGetNumber(coords[0]); err != nil {
+			return 0, 0, fmt.Errorf("longitude: %w", err)
+		}
+		if lat, err = IGetNumber(coords[1]); err != nil {
+			return 0, 0, fmt.Errorf("latitude: %w", err)
+		}
+	} else {
+		if lat, err = IGetNumber(obj["lat"]); err != nil {
+			return 0, 0, fmt.Errorf("field lat: %w", err)
+		}
+		if lon, err = IGetNumber(obj["lon"]); err != nil {
+			return 0, 0, fmt.Errorf("field lon: %w", err)
+		}
+	}
+	if lat < -90 || lat > 90 {
+		return 0, 0, fmt.Errorf("latitude %v is out of range", lat)
+	}
+	if lon < -180 || lon > 180 {
+		return 0, 0, fmt.Errorf("longitude %v is out of range", lon)
+	}
+	return lat, lon, nil
+}
+
+//------------------------------------------------------------------------------
+
+const geohashAlphabet = "0123456789bcdefghjkmnpqrstuvwxyz"
+
+func geohashEncode(lat, lon float64, precision int) string {
+	latRange := [2]float64{-90, 90}
+	lonRange := [2]float64{-180, 180}
+
+	var b strings.Builder
+	evenBit := true
+	bit, idx := 0, 0
+	for b.Len() < precision {
+		if evenBit {
+			mid := (lonRange[0] + lonRange[1]) / 2
+			if lon >= mid {
+				idx = idx*2 + 1
+				lonRange[0] = mid
+			} else {
+				idx *= 2
+				lonRange[1] = mid
+			}
+		} else {
+			mid := (latRange[0] + latRange[1]) / 2
+			if lat >= mid {
+				idx = idx*2 + 1
+				latRange[0] = mid
+			} else {
+				idx *= 2
+				latRange[1] = mid
+			}
+		}
+		evenBit = !evenBit
+		if bit++; bit == 5 {
+			b.WriteByte(geohashAlphabet[idx])
+			bit, idx = 0, 0
+		}
+	}
+	return b.String()
+}
+
+func geohashDecode(hash string) (lat, lon float64, err error) {
+	if hash == "" {
+		return 0, 0, errors.New("geohash must not be empty")
+	}
+
+	latRange := [2]float64{-90, 90}
+	lonRange := [2]float64{-180, 180}
+
+	evenBit := true
+	for _, c := range strings.ToLower(hash) {
+		idx := strings.IndexRune(geohashAlphabet, c)
+		if idx < 0 {
+			return 0, 0, fmt.Errorf("invalid geohash character: %q", c)
+		}
+		for n := 4; n >= 0; n-- {
+			bitN := (idx >> n) & 1
+			if evenBit {
+				mid := (lonRange[0] + lonRange[1]) / 2
+				if bitN == 1 {
+					lonRange[0] = mid
+				} else {
+					lonRange[1] = mid
+				}
+			} else {
+				mid := (latRange[0] + latRange[1]) / 2
+				if bitN == 1 {
+					latRange[0] = mid
+				} else {
+					latRange[1] = mid
+				}
+			}
+			evenBit = !evenBit
+		}
+	}
+
+	lat = (latRange[0] + latRange[1]) / 2
+	lon = (lonRange[0] + lonRange[1]) / 2
+
+	// Round to the number of decimal places that are significant given the
+	// size of the area described by the hash.
+	latPlaces := math.Max(1, -math.Floor(math.Log10(latRange[1]-latRange[0])))
+	lonPlaces := math.Max(1, -math.Floor(math.Log10(lonRange[1]-lonRange[0])))
+	lat = roundToPlaces(lat, latPlaces)
+	lon = roundToPlaces(lon, lonPlaces)
+	return lat, lon, nil
+}
+
+func roundToPlaces(v, places float64) float64 {
+	p := math.Pow(10, places)
+	return math.Round(v*p) / p
+}
+
+//------------------------------------------------------------------------------
+
+// geoRing is a closed sequence of points, each consisting of a longitude and a
+// latitude.
+type geoRing [][2]float64
+
+func (r geoRing) contains(x, y float64) bool {
+	inside := false
+	for i, j := 0, len(r)-1; i < len(r); j, i = i, i+1 {
+		xi, yi := r[i][0], r[i][1]
+		xj, yj := r[j][0], r[j][1]
+		if (yi > y) != (yj > y) && x < (xj-xi)*(y-yi)/(yj-yi)+xi {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// geoPolygon is an exterior ring followed by zero or more rings describing
+// holes within it.
+type geoPolygon []geoRing
+
+func (p geoPolygon) contains(x, y float64) bool {
+	if len(p) == 0 || !p[0].contains(x, y) {
+		return false
+	}
+	for _, hole := range p[1:] {
+		if hole.contains(x, y) {
+			return false
+		}
+	}
+	return true
+}
+
+func geoPolygonsFromGeoJSON(v interface{}) ([]geoPolygon, error) {
+	obj, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, NewTypeError(v, ValueObject)
+	}
+
+	switch t, _ := obj["type"].(string); t {
+	case "Polygon":
+		p, err := geoPolygonFromCoords(obj["coordinates"])
+		if err != nil {
+			return nil, err
+		}
+		return []geoPolygon{p}, nil
+	case "MultiPolygon":
+		coords, ok := obj["coordinates"].([]interface{})
+		if !ok {
+			return nil, errors.New("expected MultiPolygon coordinates to be an array")
+		}
+		polygons := make([]geoPolygon, 0, len(coords))
+		for _, c := range coords {
+			p, err := geoPolygonFromCoords(c)
+			if err != nil {
+				return nil, err
+			}
+			polygons = append(polygons, p)
+		}
+		return polygons, nil
+	case "Feature":
+		return geoPolygonsFromGeoJSON(obj["geometry"])
+	case "FeatureCollection":
+		features, ok := obj["features"].([]interface{})
+		if !ok {
+			return nil, errors.New("expected FeatureCollection features to be an array")
+		}
+		var polygons []geoPolygon
+		for i, f := range features {
+			p, err := geoPolygonsFromGeoJSON(f)
+			if err != nil {
+				return nil, fmt.Errorf("feature %v: %w", i, err)
+			}
+			polygons = append(polygons, p...)
+		}
+		return polygons, nil
+	default:
+		return nil, fmt.Errorf("unsupported GeoJSON type: %q", t)
+	}
+}
+
+func geoPolygonFromCoords(v interface{}) (geoPolygon, error) {
+	rings, ok := v.([]interface{})
+	if !ok || len(rings) == 0 {
+		return nil, errors.New("expected Polygon coordinates to be a non-empty array of rings")
+	}
+	polygon := make(geoPolygon, 0, len(rings))
+	for _, r := range rings {
+		points, ok := r.([]interface{})
+		if !ok || len(points) < 4 {
+			return nil, errors.New("expected Polygon rings to be arrays of at least four positions")
+		}
+		ring := make(geoRing, 0, len(points))
+		for _, p := range points {
+			pos, ok := p.([]interface{})
+			if !ok || len(pos) < 2 {
+				return nil, errors.New("expected Polygon positions to be arrays of at least two coordinates")
+			}
+			lon, err := IGetNumber(pos[0])
+			if err != nil {
+				return nil, err
+			}
+			lat, err := IGetNumber(pos[1])
+			if err != nil {
+				return nil, err
+			}
+			ring = append(ring, [2]float64{lon, lat})
+		}
+		polygon = append(polygon, ring)
+	}
+	return polygon, nil
+}
@@ -34,6 +34,7 @@ import (
 	"golang.org/x/text/language"
 	"gopkg.in/yaml.v3"
 
+	"github.com/benthosdev/benthos/v4/internal/aead"
 	"github.com/benthosdev/benthos/v4/internal/impl/xml"
 )
 
@@ -436,6 +437,90 @@ root.decrypted = this.value.decode("hex").decrypt_aes("ctr", $key, $vector).stri
 
 //------------------------------------------------------------------------------
 
+var _ = registerSimpleMethod(
+	NewMethodSpec(
+		"encrypt_aead", "",
+	).InCategory(
+		MethodCategoryEncoding,
+		"Encrypts and authenticates a string or byte array target according to a chosen AEAD scheme and returns a byte array result. A random nonce is generated for each invocation and prefixed to the result, and therefore the same key can be safely used for any number of messages. Available schemes are: `aes_gcm`, which accepts keys of 16, 24 or 32 bytes, and `chacha20_poly1305` and `xchacha20_poly1305`, which require keys of 32 bytes.",
+		NewExampleSpec("",
+			`let key = "2b7e151628aed2a6abf7158809cf4f3c2b7e151628aed2a6abf7158809cf4f3c".decode("hex")
+root.encrypted = this.value.encrypt_aead("chacha20_poly1305", $key).encode("base64")`,
+		),
+	).
+		Param(ParamString("scheme", "The scheme to use for encryption, one of `aes_gcm`, `chacha20_poly1305`, `xchacha20_poly1305`.")).
+		Param(ParamString("key", "A key to encrypt with.")).
+		Param(ParamString("additional_data", "Optional data that is authenticated but not encrypted, which must be provided again in order to decrypt the result.").Default("")),
+	func(args *ParsedParams) (simpleMethod, error) {
+		a, additionalData, err := aeadFromArgs(args)
+		if err != nil {
+			return nil, err
+		}
+		return func(v interface{}, ctx FunctionContext) (interface{}, error) {
+			b, err := IGetBytes(v)
+			if err != nil {
+				return nil, err
+			}
+			return aead.Seal(a, b, additionalData)
+		}, nil
+	},
+)
+
+var _ = registerSimpleMethod(
+	NewMethodSpec(
+		"decrypt_aead", "",
+	).InCategory(
+		MethodCategoryEncoding,
+		"Decrypts and authenticates a string or byte array target that was encrypted with the method `encrypt_aead` and returns the result as a byte array. Available schemes are: `aes_gcm`, `chacha20_poly1305`, `xchacha20_poly1305`.",
+		NewExampleSpec("",
+			`let key = "2b7e151628aed2a6abf7158809cf4f3c2b7e151628aed2a6abf7158809cf4f3c".decode("hex")
+root.decrypted = this.value.decode("base64").decrypt_aead("chacha20_poly1305", $key).string()`,
+		),
+	).
+		Param(ParamString("scheme", "The scheme to use for decryption, one of `aes_gcm`, `chacha20_poly1305`, `xchacha20_poly1305`.")).
+		Param(ParamString("key", "A key to decrypt with.")).
+		Param(ParamString("additional_data", "Optional data that was authenticated during encryption.").Default("")),
+	func(args *ParsedParams) (simpleMethod, error) {
+		a, additionalData, err := aeadFromArgs(args)
+		if err != nil {
+			return nil, err
+		}
+		return func(v interface{}, ctx FunctionContext) (interface{}, error) {
+			b, err := IGetBytes(v)
+			if err != nil {
+				return nil, err
+			}
+			return aead.Open(a, b, additionalData)
+		}, nil
+	},
+)
+
+func aeadFromArgs(args *ParsedParams) (cipher.AEAD, []byte, error) {
+	schemeStr, err := args.FieldString("scheme")
+	if err != nil {
+		return nil, nil, err
+	}
+	keyStr, err := args.FieldString("key")
+	if err != nil {
+		return nil, nil, err
+	}
+	additionalStr, err := args.FieldString("additional_data")
+	if err != nil {
+		return nil, nil, err
+	}
+	a, err := aead.New(schemeStr, []byte(keyStr))
+	if err != nil {
+		return nil, nil, err
+	}
+	var additionalData []byte
+	if additionalStr != "" {
+		additionalData = []byte(additionalStr)
+	}
+	return a, additionalData, nil
+}
+
+//------------------------------------------------------------------------------
+
 var _ = registerSimpleMethod(
 	NewMethodSpec(
 		"escape_html", "",
@@ -1257,12 +1342,24 @@ var _ = registerSimpleMethod(
 			`{"doc":{"timestamp":"2020-Aug-14"}}`,
 			`{"doc":{"timestamp":"2020-08-14T00:00:00Z"}}`,
 		),
-	).Beta().Param(ParamString("format", "The format of the target string.")),
+		NewExampleSpec(
+			"An optional timezone can be specified, in which case timestamps that do not contain an explicit offset are interpreted as being within that timezone.",
+			`root.doc.timestamp = this.doc.timestamp.parse_timestamp(format: "2006-01-02 15:04", tz: "Europe/Paris")`,
+			`{"doc":{"timestamp":"2020-08-14 11:45"}}`,
+			`{"doc":{"timestamp":"2020-08-14T11:45:00+02:00"}}`,
+		),
+	).Beta().
+		Param(ParamString("format", "The format of the target string.")).
+		Param(ParamString("tz", "An optional timezone to interpret timestamps within when they do not contain an explicit offset, otherwise UTC is used.").Optional()),
 	func(args *ParsedParams) (simpleMethod, error) {
 		layout, err := args.FieldString("format")
 		if err != nil {
 			return nil, err
 		}
+		timezone, err := timezoneFromArgs(args, time.UTC)
+		if err != nil {
+			return nil, err
+		}
 		return func(v interface{}, ctx FunctionContext) (interface{}, error) {
 			var str string
 			switch t := v.(type) {
@@ -1273,7 +1370,7 @@ var _ = registerSimpleMethod(
 			default:
 				return nil, NewTypeError(v, ValueString)
 			}
-			ut, err := time.Parse(layout, str)
+			ut, err := time.ParseInLocation(layout, str, timezone)
 			if err != nil {
 				return nil, err
 			}
@@ -1296,12 +1393,29 @@ var _ = registerSimpleMethod(
 			`{"doc":{"timestamp":"2020-Aug-14"}}`,
 			`{"doc":{"timestamp":"2020-08-14T00:00:00Z"}}`,
 		),
-	).Beta().Param(ParamString("format", "The format of the target string.")),
+		NewExampleSpec(
+			"An optional timezone can be specified, in which case timestamps that do not contain an explicit offset are interpreted as being within that timezone. Month and day names can also be parsed in a language other than English by specifying a locale, which is one of `de`, `en`, `es`, `fr`, `it`, `nl` or `pt`.",
+			`root.doc.timestamp = this.doc.timestamp.parse_timestamp_strptime(format: "%d %B %Y %H:%M", tz: "Europe/Berlin", locale: "de")`,
+			`{"doc":{"timestamp":"14 März 2020 11:45"}}`,
+			`{"doc":{"timestamp":"2020-03-14T11:45:00+01:00"}}`,
+		),
+	).Beta().
+		Param(ParamString("format", "The format of the target string.")).
+		Param(ParamString("tz", "An optional timezone to interpret timestamps within when they do not contain an explicit offset, otherwise UTC is used.").Optional()).
+		Param(ParamString("locale", "An optional locale of month and day names within the target string.").Default("en")),
 	func(args *ParsedParams) (simpleMethod, error) {
 		layout, err := args.FieldString("format")
 		if err != nil {
 			return nil, err
 		}
+		timezone, err := timezoneFromArgs(args, time.UTC)
+		if err != nil {
+			return nil, err
+		}
+		locale, err := timeLocaleFromArgs(args)
+		if err != nil {
+			return nil, err
+		}
 		return func(v interface{}, ctx FunctionContext) (interface{}, error) {
 			var str string
 			switch t := v.(type) {
@@ -1312,7 +1426,7 @@ var _ = registerSimpleMethod(
 			default:
 				return nil, NewTypeError(v, ValueString)
 			}
-			ut, err := timefmt.Parse(str, layout)
+			ut, err := timefmt.ParseInLocation(locale.englishStrptime(layout, str), layout, timezone)
 			if err != nil {
 				return nil, err
 			}
@@ -1409,16 +1523,10 @@ var _ = registerSimpleMethod(
 		if err != nil {
 			return nil, err
 		}
-		var timezone *time.Location
-		tzOpt, err := args.FieldOptionalString("tz")
+		timezone, err := timezoneFromArgs(args, nil)
 		if err != nil {
 			return nil, err
 		}
-		if tzOpt != nil {
-			if timezone, err = time.LoadLocation(*tzOpt); err != nil {
-				return nil, fmt.Errorf("failed to parse timezone location name: %w", err)
-			}
-		}
 		return func(v interface{}, ctx FunctionContext) (interface{}, error) {
 			target, err := IGetTimestamp(v)
 			if err != nil {
@@ -1456,23 +1564,70 @@ var _ = registerSimpleMethod(
 			`{"created_at":"2020-08-14T11:50:26.371Z"}`,
 			`{"something_at":"2020-Aug-14 11:50:26"}`,
 		),
+		NewExampleSpec(
+			"Month and day names can be written in a language other than English by specifying a locale, which is one of `de`, `en`, `es`, `fr`, `it`, `nl` or `pt`.",
+			`root.something_at = this.created_at.format_timestamp_strftime(format: "%A %d %B %Y", tz: "UTC", locale: "fr")`,
+
+			`{"created_at":1597405526}`,
+			`{"something_at":"vendredi 14 août 2020"}`,
+		),
 	).Beta().
 		Param(ParamString("format", "The output format to use.")).
-		Param(ParamString("tz", "An optional timezone to use, otherwise the timezone of the input string is used.").Optional()),
+		Param(ParamString("tz", "An optional timezone to use, otherwise the timezone of the input string is used.").Optional()).
+		Param(ParamString("locale", "An optional locale of month and day names within the output string.").Default("en")),
 	func(args *ParsedParams) (simpleMethod, error) {
 		layout, err := args.FieldString("format")
 		if err != nil {
 			return nil, err
 		}
-		var timezone *time.Location
-		tzOpt, err := args.FieldOptionalString("tz")
+		timezone, err := timezoneFromArgs(args, nil)
+		if err != nil {
+			return nil, err
+		}
+		locale, err := timeLocaleFromArgs(args)
 		if err != nil {
 			return nil, err
 		}
-		if tzOpt != nil {
-			if timezone, err = time.LoadLocation(*tzOpt); err != nil {
-				return nil, fmt.Errorf("failed to parse timezone location name: %w", err)
+		return func(v interface{}, ctx FunctionContext) (interface{}, error) {
+			target, err := IGetTimestamp(v)
+			if err != nil {
+				return nil, err
 			}
+			if timezone != nil {
+				target = target.In(timezone)
+			}
+			return timefmt.Format(target, locale.localiseStrftime(layout, target)), nil
+		}, nil
+	},
+)
+
+//------------------------------------------------------------------------------
+
+var _ = registerSimpleMethod(
+	NewMethodSpec(
+		"format_timestamp_iso_week", "",
+	).InCategory(
+		MethodCategoryTime,
+		"Attempts to obtain the ISO 8601 week number of a timestamp value along with the year that the week belongs to, which may differ from the calendar year of timestamps at the beginning and end of a year. Timestamp values can either be a numerical unix time in seconds (with up to nanosecond precision via decimals), or a string in ISO 8601 format.",
+		NewExampleSpec("",
+			`root.week = this.created_at.format_timestamp_iso_week()`,
+			`{"created_at":"2020-08-14T11:50:26.371Z"}`,
+			`{"week":{"week":33,"year":2020}}`,
+			`{"created_at":"2021-01-01T00:00:00Z"}`,
+			`{"week":{"week":53,"year":2020}}`,
+		),
+		NewExampleSpec(
+			"An optional timezone can be specified in order to determine the week of the timestamp within that timezone, otherwise the timezone of the input string is used, or in the case of unix timestamps the local timezone is used.",
+			`root.week = this.created_at.format_timestamp_iso_week("America/New_York")`,
+			`{"created_at":"2020-01-06T02:00:00Z"}`,
+			`{"week":{"week":1,"year":2020}}`,
+		),
+	).Beta().
+		Param(ParamString("tz", "An optional timezone to use, otherwise the timezone of the input string is used.").Optional()),
+	func(args *ParsedParams) (simpleMethod, error) {
+		timezone, err := timezoneFromArgs(args, nil)
+		if err != nil {
+			return nil, err
 		}
 		return func(v interface{}, ctx FunctionContext) (interface{}, error) {
 			target, err := IGetTimestamp(v)
@@ -1482,11 +1637,40 @@ var _ = registerSimpleMethod(
 			if timezone != nil {
 				target = target.In(timezone)
 			}
-			return timefmt.Format(target, layout), nil
+			year, week := target.ISOWeek()
+			return map[string]interface{}{
+				"year": int64(year),
+				"week": int64(week),
+			}, nil
 		}, nil
 	},
 )
 
+// timezoneFromArgs loads the location of an optional tz parameter, returning a
+// fallback when it is not set.
+func timezoneFromArgs(args *ParsedParams, fallback *time.Location) (*time.Location, error) {
+	tzOpt, err := args.FieldOptionalString("tz")
+	if err != nil {
+		return nil, err
+	}
+	if tzOpt == nil {
+		return fallback, nil
+	}
+	timezone, err := time.LoadLocation(*tzOpt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse timezone location name: %w", err)
+	}
+	return timezone, nil
+}
+
+func timeLocaleFromArgs(args *ParsedParams) (*timeLocale, error) {
+	name, err := args.FieldString("locale")
+	if err != nil {
+		return nil, err
+	}
+	return getTimeLocale(name)
+}
+
 //------------------------------------------------------------------------------
 
 var _ = registerSimpleMethod(
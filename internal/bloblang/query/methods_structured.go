@@ -632,6 +632,61 @@ var _ = registerSimpleMethod(
 	},
 )
 
+var _ = registerSimpleMethod(
+	NewMethodSpec(
+		"validate_json_schema",
+		"Checks a [JSON schema](https://json-schema.org/) against a value and returns an array of the validation errors found, which is empty when the value matches the schema. Each error is an object containing the [dot path][field_paths] of the invalid field (empty for the root of the value), the schema keyword that failed, and a message describing the failure. Unlike the method `json_schema` this allows invalid documents to be annotated rather than rejected.",
+	).InCategory(
+		MethodCategoryObjectAndArray,
+		"",
+		NewExampleSpec("",
+			`root = this
+root.schema_errors = this.validate_json_schema("""{
+  "type":"object",
+  "properties":{
+    "foo":{
+      "type":"string"
+    }
+  },
+  "required":["bar"]
+}""")`,
+			`{"bar":"baz","foo":"bar"}`,
+			`{"bar":"baz","foo":"bar","schema_errors":[]}`,
+			`{"foo":5}`,
+			`{"foo":5,"schema_errors":[{"keyword":"required","message":"bar is required","path":""},{"keyword":"invalid_type","message":"Invalid type. Expected: string, given: integer","path":"foo"}]}`,
+		),
+	).Beta().Param(ParamString("schema", "The schema to check values against.")),
+	func(args *ParsedParams) (simpleMethod, error) {
+		schemaStr, err := args.FieldString("schema")
+		if err != nil {
+			return nil, err
+		}
+		schema, err := jsonschema.NewSchema(jsonschema.NewStringLoader(schemaStr))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse json schema definition: %w", err)
+		}
+		return func(res interface{}, ctx FunctionContext) (interface{}, error) {
+			result, err := schema.Validate(jsonschema.NewGoLoader(res))
+			if err != nil {
+				return nil, err
+			}
+			validationErrs := make([]interface{}, 0, len(result.Errors()))
+			for _, desc := range result.Errors() {
+				path := desc.Field()
+				if path == jsonschema.STRING_ROOT_SCHEMA_PROPERTY {
+					path = ""
+				}
+				validationErrs = append(validationErrs, map[string]interface{}{
+					"path":    path,
+					"keyword": desc.Type(),
+					"message": desc.Description(),
+				})
+			}
+			return validationErrs, nil
+		}, nil
+	},
+)
+
 //------------------------------------------------------------------------------
 
 var _ = registerSimpleMethod(
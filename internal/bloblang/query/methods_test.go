@@ -1639,6 +1639,47 @@ func TestMethods(t *testing.T) {
 			),
 			output: `84e9b31ff7400bdf80be7254`,
 		},
+		"check aead roundtrip": {
+			input: methods(
+				literalFn("hello world!"),
+				method(
+					"encrypt_aead", "xchacha20_poly1305",
+					methods(
+						literalFn("2b7e151628aed2a6abf7158809cf4f3c2b7e151628aed2a6abf7158809cf4f3c"),
+						method("decode", "hex"),
+					),
+				),
+				method(
+					"decrypt_aead", "xchacha20_poly1305",
+					methods(
+						literalFn("2b7e151628aed2a6abf7158809cf4f3c2b7e151628aed2a6abf7158809cf4f3c"),
+						method("decode", "hex"),
+					),
+				),
+				method("string"),
+			),
+			output: `hello world!`,
+		},
+		"check aead bad key": {
+			input: methods(
+				literalFn("hello world!"),
+				method(
+					"encrypt_aead", "aes_gcm",
+					methods(
+						literalFn("2b7e151628aed2a6abf7158809cf4f3c2b7e151628aed2a6abf7158809cf4f3c"),
+						method("decode", "hex"),
+					),
+				),
+				method(
+					"decrypt_aead", "aes_gcm",
+					methods(
+						literalFn("0b7e151628aed2a6abf7158809cf4f3c2b7e151628aed2a6abf7158809cf4f3c"),
+						method("decode", "hex"),
+					),
+				),
+			),
+			err: "method encrypt_aead: cipher: message authentication failed",
+		},
 		"check aes-ctr decryption": {
 			input: methods(
 				literalFn("84e9b31ff7400bdf80be7254"),
@@ -1993,6 +2034,105 @@ func TestMethods(t *testing.T) {
 			),
 			output: "2020-Aug-14 11:45:26",
 		},
+		"check format_timestamp_strftime locale": {
+			input: methods(
+				literalFn(int64(1597405526)),
+				method("format_timestamp_strftime", "%a %d %b %Y, %A %B %%B", "UTC", "es"),
+			),
+			output: "vie 14 ago 2020, viernes agosto %B",
+		},
+		"check parse_timestamp_strptime locale": {
+			input: methods(
+				literalFn("sábado 15 agosto 2020"),
+				method("parse_timestamp_strptime", "%A %d %B %Y", "Europe/Madrid", "es"),
+			),
+			output: "2020-08-15T00:00:00+02:00",
+		},
+		"check parse_timestamp_strptime locale ambiguous names": {
+			input: methods(
+				literalFn("mar 01 mar 2022"),
+				method("parse_timestamp_strptime", "%a %d %b %Y", "UTC", "es"),
+			),
+			output: "2022-03-01T00:00:00Z",
+		},
+		"check parse_timestamp_strptime locale whole words": {
+			input: methods(
+				literalFn("martedì 01 marzo 2022 domodossola"),
+				method("parse_timestamp_strptime", "%A %d %B %Y domodossola", "UTC", "it"),
+			),
+			output: "2022-03-01T00:00:00Z",
+		},
+		"check parse_timestamp tz": {
+			input: methods(
+				literalFn("2020-01-14 11:45"),
+				method("parse_timestamp", "2006-01-02 15:04", "America/New_York"),
+			),
+			output: "2020-01-14T11:45:00-05:00",
+		},
+		"check parse_timestamp tz explicit offset": {
+			input: methods(
+				literalFn("2020-01-14 11:45 +0100"),
+				method("parse_timestamp", "2006-01-02 15:04 -0700", "America/New_York"),
+			),
+			output: "2020-01-14T11:45:00+01:00",
+		},
+		"check format_timestamp_iso_week": {
+			input: methods(
+				literalFn("2024-12-30T10:00:00Z"),
+				method("format_timestamp_iso_week"),
+			),
+			output: map[string]interface{}{
+				"year": int64(2025),
+				"week": int64(1),
+			},
+		},
+		"check geohash_encode default precision": {
+			input: methods(
+				jsonFn(`{"lat":57.64911,"lon":10.40744}`),
+				method("geohash_encode"),
+			),
+			output: "u4pruydqqvj8",
+		},
+		"check geohash_encode out of range": {
+			input: methods(
+				jsonFn(`{"lat":97.1,"lon":10.40744}`),
+				method("geohash_encode"),
+			),
+			err: "object literal: latitude 97.1 is out of range",
+		},
+		"check geohash_decode invalid": {
+			input: methods(
+				literalFn("u4pa"),
+				method("geohash_decode"),
+			),
+			err: `string literal: invalid geohash character: 'a'`,
+		},
+		"check geo_within polygon hole": {
+			input: methods(
+				jsonFn(`{"lat":0.5,"lon":0.5}`),
+				method("geo_within", jsonFn(`{
+  "type": "Polygon",
+  "coordinates": [
+    [[-2,-2],[2,-2],[2,2],[-2,2],[-2,-2]],
+    [[0,0],[1,0],[1,1],[0,1],[0,0]]
+  ]
+}`)),
+			),
+			output: false,
+		},
+		"check geo_within feature collection": {
+			input: methods(
+				jsonFn(`{"type":"Point","coordinates":[10.5,10.5]}`),
+				method("geo_within", jsonFn(`{
+  "type": "FeatureCollection",
+  "features": [
+    {"type":"Feature","geometry":{"type":"Polygon","coordinates":[[[0,0],[1,0],[1,1],[0,1],[0,0]]]}},
+    {"type":"Feature","geometry":{"type":"MultiPolygon","coordinates":[[[[10,10],[11,10],[11,11],[10,11],[10,10]]]]}}
+  ]
+}`)),
+			),
+			output: true,
+		},
 		"check floor": {
 			input:  methods(literalFn(5.8), method("floor")),
 			output: int64(5),
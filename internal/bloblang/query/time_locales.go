@@ -0,0 +1,214 @@
+package query
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// timeLocale contains the names of months and days of the week of a language,
+// ordered from January and Sunday respectively.
+type timeLocale struct {
+	months      [12]string
+	shortMonths [12]string
+	days        [7]string
+	shortDays   [7]string
+
+	// The localised names matched by each strftime name directive, along with
+	// their English equivalents, ordered from longest to shortest.
+	names map[byte][]localisedName
+}
+
+type localisedName struct {
+	name, english string
+}
+
+var (
+	englishMonths = [12]string{"January", "February", "March", "April", "May", "June", "July", "August", "September", "October", "November", "December"}
+	englishDays   = [7]string{"Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday"}
+)
+
+var timeLocales = map[string]*timeLocale{
+	"en": newTimeLocale(
+		englishMonths,
+		[12]string{"Jan", "Feb", "Mar", "Apr", "May", "Jun", "Jul", "Aug", "Sep", "Oct", "Nov", "Dec"},
+		englishDays,
+		[7]string{"Sun", "Mon", "Tue", "Wed", "Thu", "Fri", "Sat"},
+	),
+	"de": newTimeLocale(
+		[12]string{"Januar", "Februar", "März", "April", "Mai", "Juni", "Juli", "August", "September", "Oktober", "November", "Dezember"},
+		[12]string{"Jan", "Feb", "Mär", "Apr", "Mai", "Jun", "Jul", "Aug", "Sep", "Okt", "Nov", "Dez"},
+		[7]string{"Sonntag", "Montag", "Dienstag", "Mittwoch", "Donnerstag", "Freitag", "Samstag"},
+		[7]string{"So", "Mo", "Di", "Mi", "Do", "Fr", "Sa"},
+	),
+	"es": newTimeLocale(
+		[12]string{"enero", "febrero", "marzo", "abril", "mayo", "junio", "julio", "agosto", "septiembre", "octubre", "noviembre", "diciembre"},
+		[12]string{"ene", "feb", "mar", "abr", "may", "jun", "jul", "ago", "sep", "oct", "nov", "dic"},
+		[7]string{"domingo", "lunes", "martes", "miércoles", "jueves", "viernes", "sábado"},
+		[7]string{"dom", "lun", "mar", "mié", "jue", "vie", "sáb"},
+	),
+	"fr": newTimeLocale(
+		[12]string{"janvier", "février", "mars", "avril", "mai", "juin", "juillet", "août", "septembre", "octobre", "novembre", "décembre"},
+		[12]string{"janv.", "févr.", "mars", "avr.", "mai", "juin", "juil.", "août", "sept.", "oct.", "nov.", "déc."},
+		[7]string{"dimanche", "lundi", "mardi", "mercredi", "jeudi", "vendredi", "samedi"},
+		[7]string{"dim.", "lun.", "mar.", "mer.", "jeu.", "ven.", "sam."},
+	),
+	"it": newTimeLocale(
+		[12]string{"gennaio", "febbraio", "marzo", "aprile", "maggio", "giugno", "luglio", "agosto", "settembre", "ottobre", "novembre", "dicembre"},
+		[12]string{"gen", "feb", "mar", "apr", "mag", "giu", "lug", "ago", "set", "ott", "nov", "dic"},
+		[7]string{"domenica", "lunedì", "martedì", "mercoledì", "giovedì", "venerdì", "sabato"},
+		[7]string{"dom", "lun", "mar", "mer", "gio", "ven", "sab"},
+	),
+	"nl": newTimeLocale(
+		[12]string{"januari", "februari", "maart", "april", "mei", "juni", "juli", "augustus", "september", "oktober", "november", "december"},
+		[12]string{"jan", "feb", "mrt", "apr", "mei", "jun", "jul", "aug", "sep", "okt", "nov", "dec"},
+		[7]string{"zondag", "maandag", "dinsdag", "woensdag", "donderdag", "vrijdag", "zaterdag"},
+		[7]string{"zo", "ma", "di", "wo", "do", "vr", "za"},
+	),
+	"pt": newTimeLocale(
+		[12]string{"janeiro", "fevereiro", "março", "abril", "maio", "junho", "julho", "agosto", "setembro", "outubro", "novembro", "dezembro"},
+		[12]string{"jan", "fev", "mar", "abr", "mai", "jun", "jul", "ago", "set", "out", "nov", "dez"},
+		[7]string{"domingo", "segunda-feira", "terça-feira", "quarta-feira", "quinta-feira", "sexta-feira", "sábado"},
+		[7]string{"dom", "seg", "ter", "qua", "qui", "sex", "sáb"},
+	),
+}
+
+// timeLocaleNames returns the names of supported locales in sorted order.
+func timeLocaleNames() []string {
+	names := make([]string, 0, len(timeLocales))
+	for k := range timeLocales {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func newTimeLocale(months, shortMonths [12]string, days, shortDays [7]string) *timeLocale {
+	l := &timeLocale{
+		months:      months,
+		shortMonths: shortMonths,
+		days:        days,
+		shortDays:   shortDays,
+		names:       map[byte][]localisedName{},
+	}
+	for i := range months {
+		l.names['B'] = append(l.names['B'], localisedName{months[i], englishMonths[i]})
+		l.names['b'] = append(l.names['b'], localisedName{shortMonths[i], englishMonths[i][:3]})
+	}
+	for i := range days {
+		l.names['A'] = append(l.names['A'], localisedName{days[i], englishDays[i]})
+		l.names['a'] = append(l.names['a'], localisedName{shortDays[i], englishDays[i][:3]})
+	}
+	l.names['h'] = l.names['b']
+
+	// Longer names are matched first so that names which are prefixed by
+	// others are matched in full.
+	for _, names := range l.names {
+		sort.SliceStable(names, func(i, j int) bool {
+			return len(names[i].name) > len(names[j].name)
+		})
+	}
+	return l
+}
+
+func getTimeLocale(name string) (*timeLocale, error) {
+	l, exists := timeLocales[strings.ToLower(name)]
+	if !exists {
+		return nil, fmt.Errorf("unsupported locale %v, expected one of: %v", name, strings.Join(timeLocaleNames(), ", "))
+	}
+	return l, nil
+}
+
+// localiseStrftime returns a copy of a strftime format where the directives
+// for month and day names are replaced with the localised names of a
+// timestamp.
+func (l *timeLocale) localiseStrftime(format string, t time.Time) string {
+	var b strings.Builder
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' || i == len(format)-1 {
+			b.WriteByte(format[i])
+			continue
+		}
+		i++
+		switch format[i] {
+		case 'B':
+			b.WriteString(escapeStrftime(l.months[t.Month()-1]))
+		case 'b', 'h':
+			b.WriteString(escapeStrftime(l.shortMonths[t.Month()-1]))
+		case 'A':
+			b.WriteString(escapeStrftime(l.days[t.Weekday()]))
+		case 'a':
+			b.WriteString(escapeStrftime(l.shortDays[t.Weekday()]))
+		default:
+			b.WriteByte('%')
+			b.WriteByte(format[i])
+		}
+	}
+	return b.String()
+}
+
+func escapeStrftime(s string) string {
+	return strings.ReplaceAll(s, "%", "%%")
+}
+
+// englishStrptime returns a copy of a value to be parsed with a strftime
+// format where localised month and day names are replaced with English names.
+// Names are only replaced when they are whole words, and each name directive
+// of the format is matched in order against the next word that is a name of
+// its kind, which resolves names that are shared by months and days (such as
+// "mar" in Spanish) by their position.
+func (l *timeLocale) englishStrptime(format, value string) string {
+	var directives []byte
+	for i := 0; i < len(format)-1; i++ {
+		if format[i] != '%' {
+			continue
+		}
+		i++
+		if _, exists := l.names[format[i]]; exists {
+			directives = append(directives, format[i])
+		}
+	}
+	if len(directives) == 0 {
+		return value
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(value); {
+		if len(directives) > 0 && !isLetterBefore(value, i) {
+			if n, ok := l.matchName(directives[0], value[i:]); ok {
+				b.WriteString(n.english)
+				i += len(n.name)
+				directives = directives[1:]
+				continue
+			}
+		}
+		_, size := utf8.DecodeRuneInString(value[i:])
+		b.WriteString(value[i : i+size])
+		i += size
+	}
+	return b.String()
+}
+
+// matchName returns the name of a directive that the value begins with as a
+// whole word.
+func (l *timeLocale) matchName(directive byte, value string) (localisedName, bool) {
+	for _, n := range l.names[directive] {
+		if strings.HasPrefix(value, n.name) && !isLetterAt(value, len(n.name)) {
+			return n, true
+		}
+	}
+	return localisedName{}, false
+}
+
+func isLetterBefore(s string, i int) bool {
+	r, size := utf8.DecodeLastRuneInString(s[:i])
+	return size > 0 && unicode.IsLetter(r)
+}
+
+func isLetterAt(s string, i int) bool {
+	r, size := utf8.DecodeRuneInString(s[i:])
+	return size > 0 && unicode.IsLetter(r)
+}
@@ -69,6 +69,9 @@ func (s *MetricsSet) Init(conf metrics.Config, log log.Modular) (*metrics.Namesp
 		}
 		ns = ns.WithMapping(mmap)
 	}
+	if conf.MaxLabelCardinality > 0 {
+		ns = ns.WithLabelCardinalityLimit(conf.MaxLabelCardinality, log)
+	}
 	return ns, nil
 }
 
@@ -5,6 +5,7 @@ import (
 	"sort"
 
 	"github.com/benthosdev/benthos/v4/internal/component"
+	"github.com/benthosdev/benthos/v4/internal/component/metrics"
 	"github.com/benthosdev/benthos/v4/internal/component/tracer"
 	"github.com/benthosdev/benthos/v4/internal/docs"
 )
@@ -17,7 +18,7 @@ var AllTracers = &TracerSet{
 //------------------------------------------------------------------------------
 
 // TracerConstructor constructs an tracer component.
-type TracerConstructor func(tracer.Config) (tracer.Type, error)
+type TracerConstructor func(tracer.Config, metrics.Type) (tracer.Type, error)
 
 type tracerSpec struct {
 	constructor TracerConstructor
@@ -48,12 +49,12 @@ func (s *TracerSet) Add(constructor TracerConstructor, spec docs.ComponentSpec)
 }
 
 // Init attempts to initialise an tracer from a config.
-func (s *TracerSet) Init(conf tracer.Config) (tracer.Type, error) {
+func (s *TracerSet) Init(conf tracer.Config, stats metrics.Type) (tracer.Type, error) {
 	spec, exists := s.specs[conf.Type]
 	if !exists {
 		return nil, component.ErrInvalidType("tracer", conf.Type)
 	}
-	return spec.constructor(conf)
+	return spec.constructor(conf, stats)
 }
 
 // Docs returns a slice of tracer specs, which document each method.
@@ -4,6 +4,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/benthosdev/benthos/v4/internal/component"
 	"github.com/benthosdev/benthos/v4/internal/component/input"
 	"github.com/benthosdev/benthos/v4/internal/message"
 	"github.com/benthosdev/benthos/v4/internal/shutdown"
@@ -59,6 +60,11 @@ func (t *tracedInput) Connected() bool {
 	return t.wrapped.Connected()
 }
 
+// LastError returns the most recent error encountered by the wrapped input.
+func (t *tracedInput) LastError() (time.Time, error) {
+	return component.LastErrorOf(t.wrapped)
+}
+
 func (t *tracedInput) CloseAsync() {
 	t.wrapped.CloseAsync()
 }
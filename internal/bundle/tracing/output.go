@@ -4,6 +4,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/benthosdev/benthos/v4/internal/component"
 	"github.com/benthosdev/benthos/v4/internal/component/output"
 	"github.com/benthosdev/benthos/v4/internal/message"
 	"github.com/benthosdev/benthos/v4/internal/shutdown"
@@ -58,6 +59,11 @@ func (t *tracedOutput) Connected() bool {
 	return t.wrapped.Connected()
 }
 
+// LastError returns the most recent error encountered by the wrapped output.
+func (t *tracedOutput) LastError() (time.Time, error) {
+	return component.LastErrorOf(t.wrapped)
+}
+
 func (t *tracedOutput) CloseAsync() {
 	t.wrapped.CloseAsync()
 }
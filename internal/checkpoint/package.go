@@ -1,4 +1,5 @@
 // Package checkpoint implements a mechanism for tracking checkpointed integer
 // offsets for sequential read at-least-once queue systems such as Kafka or
-// Kinesis.
+// Kinesis, and a store for persisting the positions of inputs that lack native
+// offset storage.
 package checkpoint
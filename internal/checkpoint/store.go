@@ -0,0 +1,196 @@
+package checkpoint
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/internal/component"
+	"github.com/benthosdev/benthos/v4/internal/component/cache"
+	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+	"github.com/benthosdev/benthos/v4/internal/docs"
+)
+
+// StoreConfig contains configuration fields for a checkpoint store, which is
+// declared as a checkpoint resource.
+type StoreConfig struct {
+	Label     string `json:"label" yaml:"label"`
+	Cache     string `json:"cache" yaml:"cache"`
+	Directory string `json:"directory" yaml:"directory"`
+	KeyPrefix string `json:"key_prefix" yaml:"key_prefix"`
+}
+
+// NewStoreConfig creates a new StoreConfig with default values.
+func NewStoreConfig() StoreConfig {
+	return StoreConfig{
+		Label:     "",
+		Cache:     "",
+		Directory: "",
+		KeyPrefix: "",
+	}
+}
+
+// StoreFieldSpecs returns the field specs of a checkpoint resource.
+func StoreFieldSpecs() docs.FieldSpecs {
+	return docs.FieldSpecs{
+		docs.FieldString("label", "A unique label that inputs reference the checkpoint resource by.").HasDefault(""),
+		docs.FieldString("cache", "A [cache resource](/docs/components/caches/about) to store positions within, which cannot be combined with a `directory`.").HasDefault(""),
+		docs.FieldString("directory", "A directory to store positions within as files, which is created when it does not exist.", "./checkpoints").HasDefault(""),
+		docs.FieldString("key_prefix", "A prefix added to the keys of stored positions, which allows several checkpoint resources to share a cache or directory.").HasDefault("").Advanced(),
+	}
+}
+
+// StoreManager contains the resources accessed by a checkpoint store.
+type StoreManager interface {
+	ProbeCache(name string) bool
+	AccessCache(ctx context.Context, name string, fn func(cache.V1)) error
+}
+
+// Store persists the positions of inputs that lack native offset storage,
+// keyed by strings unique to each position of an input. Reads of positions
+// that have never been stored result in component.ErrKeyNotFound.
+type Store struct {
+	mgr       StoreManager
+	cache     string
+	directory string
+	keyPrefix string
+
+	mGet     metrics.StatCounter
+	mSet     metrics.StatCounter
+	mDelete  metrics.StatCounter
+	mErr     metrics.StatCounter
+	mLatency metrics.StatTimer
+}
+
+// NewStore creates a checkpoint store from a config, a store without a cache
+// or directory is disabled.
+func NewStore(conf StoreConfig, mgr StoreManager, stats metrics.Type) (*Store, error) {
+	if conf.Cache != "" && conf.Directory != "" {
+		return nil, errors.New("checkpoints cannot be stored within both a cache and a directory")
+	}
+	if conf.Cache != "" && !mgr.ProbeCache(conf.Cache) {
+		return nil, fmt.Errorf("cache resource '%v' was not found", conf.Cache)
+	}
+	if conf.Directory != "" {
+		if err := os.MkdirAll(conf.Directory, 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create checkpoint directory: %w", err)
+		}
+	}
+	return &Store{
+		mgr:       mgr,
+		cache:     conf.Cache,
+		directory: conf.Directory,
+		keyPrefix: conf.KeyPrefix,
+
+		mGet:     stats.GetCounter("checkpoint_store_get"),
+		mSet:     stats.GetCounter("checkpoint_store_set"),
+		mDelete:  stats.GetCounter("checkpoint_store_delete"),
+		mErr:     stats.GetCounter("checkpoint_store_error"),
+		mLatency: stats.GetTimer("checkpoint_store_latency_ns"),
+	}, nil
+}
+
+// Enabled returns whether positions are stored, when false all reads result
+// in component.ErrKeyNotFound and writes are ignored.
+func (s *Store) Enabled() bool {
+	return s.cache != "" || s.directory != ""
+}
+
+// The file of a key, which is escaped such that keys containing path
+// separators do not result in nested directories.
+func (s *Store) keyPath(key string) string {
+	return filepath.Join(s.directory, url.PathEscape(s.keyPrefix+key))
+}
+
+func (s *Store) observe(tStarted time.Time, err error) {
+	s.mLatency.Timing(time.Since(tStarted).Nanoseconds())
+	if err != nil && !errors.Is(err, component.ErrKeyNotFound) {
+		s.mErr.Incr(1)
+	}
+}
+
+// Get returns the stored position of a key.
+func (s *Store) Get(ctx context.Context, key string) (value []byte, err error) {
+	if !s.Enabled() {
+		return nil, component.ErrKeyNotFound
+	}
+
+	s.mGet.Incr(1)
+	tStarted := time.Now()
+	defer func() {
+		s.observe(tStarted, err)
+	}()
+
+	if s.directory != "" {
+		if value, err = os.ReadFile(s.keyPath(key)); errors.Is(err, os.ErrNotExist) {
+			err = component.ErrKeyNotFound
+		}
+		return
+	}
+	if cerr := s.mgr.AccessCache(ctx, s.cache, func(c cache.V1) {
+		value, err = c.Get(ctx, s.keyPrefix+key)
+	}); cerr != nil {
+		err = cerr
+	}
+	return
+}
+
+// Set stores the position of a key.
+func (s *Store) Set(ctx context.Context, key string, value []byte) (err error) {
+	if !s.Enabled() {
+		return nil
+	}
+
+	s.mSet.Incr(1)
+	tStarted := time.Now()
+	defer func() {
+		s.observe(tStarted, err)
+	}()
+
+	if s.directory != "" {
+		// Positions are written to a temporary file that's then moved in order
+		// to avoid partially written positions.
+		path := s.keyPath(key)
+		if err = os.WriteFile(path+".tmp", value, 0o644); err != nil {
+			return
+		}
+		err = os.Rename(path+".tmp", path)
+		return
+	}
+	if cerr := s.mgr.AccessCache(ctx, s.cache, func(c cache.V1) {
+		err = c.Set(ctx, s.keyPrefix+key, value, nil)
+	}); cerr != nil {
+		err = cerr
+	}
+	return
+}
+
+// Delete removes the stored position of a key.
+func (s *Store) Delete(ctx context.Context, key string) (err error) {
+	if !s.Enabled() {
+		return nil
+	}
+
+	s.mDelete.Incr(1)
+	tStarted := time.Now()
+	defer func() {
+		s.observe(tStarted, err)
+	}()
+
+	if s.directory != "" {
+		if err = os.Remove(s.keyPath(key)); errors.Is(err, os.ErrNotExist) {
+			err = nil
+		}
+		return
+	}
+	if cerr := s.mgr.AccessCache(ctx, s.cache, func(c cache.V1) {
+		err = c.Delete(ctx, s.keyPrefix+key)
+	}); cerr != nil {
+		err = cerr
+	}
+	return
+}
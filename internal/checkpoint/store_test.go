@@ -0,0 +1,103 @@
+package checkpoint_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/checkpoint"
+	"github.com/benthosdev/benthos/v4/internal/component"
+	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+	"github.com/benthosdev/benthos/v4/internal/manager/mock"
+)
+
+func testStoreRoundTrip(t *testing.T, s *checkpoint.Store) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	_, err := s.Get(ctx, "foo/bar")
+	assert.ErrorIs(t, err, component.ErrKeyNotFound)
+
+	require.NoError(t, s.Set(ctx, "foo/bar", []byte("first")))
+	require.NoError(t, s.Set(ctx, "foo/bar", []byte("second")))
+	require.NoError(t, s.Set(ctx, "baz", []byte("third")))
+
+	v, err := s.Get(ctx, "foo/bar")
+	require.NoError(t, err)
+	assert.Equal(t, "second", string(v))
+
+	require.NoError(t, s.Delete(ctx, "foo/bar"))
+	require.NoError(t, s.Delete(ctx, "foo/bar"))
+
+	_, err = s.Get(ctx, "foo/bar")
+	assert.ErrorIs(t, err, component.ErrKeyNotFound)
+
+	v, err = s.Get(ctx, "baz")
+	require.NoError(t, err)
+	assert.Equal(t, "third", string(v))
+}
+
+func TestStoreCache(t *testing.T) {
+	mgr := mock.NewManager()
+	mgr.Caches["foocache"] = map[string]mock.CacheItem{}
+
+	conf := checkpoint.NewStoreConfig()
+	conf.KeyPrefix = "foo_"
+	conf.Cache = "foocache"
+
+	stats := metrics.NewLocal()
+	s, err := checkpoint.NewStore(conf, mgr, stats)
+	require.NoError(t, err)
+	assert.True(t, s.Enabled())
+
+	testStoreRoundTrip(t, s)
+
+	assert.Equal(t, "third", mgr.Caches["foocache"]["foo_baz"].Value)
+	assert.Equal(t, int64(4), stats.GetCounters()["checkpoint_store_get"])
+	assert.Equal(t, int64(3), stats.GetCounters()["checkpoint_store_set"])
+	assert.Equal(t, int64(2), stats.GetCounters()["checkpoint_store_delete"])
+}
+
+func TestStoreDirectory(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "checkpoints")
+
+	conf := checkpoint.NewStoreConfig()
+	conf.KeyPrefix = "foo_"
+	conf.Directory = dir
+
+	s, err := checkpoint.NewStore(conf, mock.NewManager(), metrics.Noop())
+	require.NoError(t, err)
+	assert.True(t, s.Enabled())
+
+	testStoreRoundTrip(t, s)
+
+	assert.FileExists(t, filepath.Join(dir, "foo_baz"))
+}
+
+func TestStoreDisabled(t *testing.T) {
+	s, err := checkpoint.NewStore(checkpoint.NewStoreConfig(), mock.NewManager(), metrics.Noop())
+	require.NoError(t, err)
+	assert.False(t, s.Enabled())
+
+	ctx := context.Background()
+	require.NoError(t, s.Set(ctx, "foo", []byte("bar")))
+
+	_, err = s.Get(ctx, "foo")
+	assert.ErrorIs(t, err, component.ErrKeyNotFound)
+}
+
+func TestStoreConfigErrors(t *testing.T) {
+	conf := checkpoint.NewStoreConfig()
+	conf.Cache = "foocache"
+
+	_, err := checkpoint.NewStore(conf, mock.NewManager(), metrics.Noop())
+	require.EqualError(t, err, "cache resource 'foocache' was not found")
+
+	conf.Directory = t.TempDir()
+	_, err = checkpoint.NewStore(conf, mock.NewManager(), metrics.Noop())
+	require.EqualError(t, err, "checkpoints cannot be stored within both a cache and a directory")
+}
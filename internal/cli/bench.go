@@ -0,0 +1,140 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/benthosdev/benthos/v4/internal/cli/bench"
+	"github.com/benthosdev/benthos/v4/internal/config"
+	"github.com/benthosdev/benthos/v4/internal/log"
+)
+
+func benchCliCommand() *cli.Command {
+	defaults := bench.NewConfig()
+	return &cli.Command{
+		Name:  "bench",
+		Usage: "Benchmark a config with synthetic data",
+		Description: `
+Runs a config with its input replaced by a generator of synthetic messages and
+reports the sustained throughput, end-to-end latency percentiles and
+allocations of the config, along with the latency of each component:
+
+  benthos -c ./config.yaml bench
+  benthos bench --duration 30s --batch-size 10 ./config.yaml
+
+Messages are generated with a Bloblang mapping that can be customised with the
+--mapping flag. The output of the config is used unless the --drop-output flag
+is set, in which case messages are dropped after processing.
+
+Allocations of each processor at the root of the pipeline are measured by
+running it in isolation before the benchmark begins. Buffers are not included
+in the benchmark.`[1:],
+		Flags: []cli.Flag{
+			&cli.DurationFlag{
+				Name:    "duration",
+				Aliases: []string{"d"},
+				Value:   defaults.Duration,
+				Usage:   "The length of time over which messages are generated.",
+			},
+			&cli.StringFlag{
+				Name:    "mapping",
+				Aliases: []string{"m"},
+				Value:   defaults.Mapping,
+				Usage:   "A Bloblang mapping used to generate messages.",
+			},
+			&cli.IntFlag{
+				Name:  "batch-size",
+				Value: defaults.BatchSize,
+				Usage: "The number of messages within each generated batch.",
+			},
+			&cli.IntFlag{
+				Name:  "max-in-flight",
+				Value: defaults.MaxInFlight,
+				Usage: "The maximum number of batches pending acknowledgement at any given time.",
+			},
+			&cli.BoolFlag{
+				Name:  "drop-output",
+				Value: defaults.DropOutput,
+				Usage: "Replace the output of the config with a drop output.",
+			},
+			&cli.IntFlag{
+				Name:  "alloc-samples",
+				Value: defaults.AllocSamples,
+				Usage: "The number of batches used to measure the allocations of each processor, set to 0 to disable.",
+			},
+			&cli.BoolFlag{
+				Name:  "json",
+				Value: false,
+				Usage: "Print the report as a JSON object.",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			confPaths := c.StringSlice("config")
+			if c.Args().Len() > 0 {
+				confPaths = c.Args().Slice()
+			}
+
+			confReader := readConfig(confPaths, false, c.StringSlice("resources"), nil, c.StringSlice("set"))
+			conf := config.New()
+			lints, err := confReader.Read(&conf)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Configuration file read error: %v\n", err)
+				os.Exit(1)
+			}
+			if !c.Bool("chilled") && len(lints) > 0 {
+				for _, lint := range lints {
+					fmt.Fprintln(os.Stderr, lint)
+				}
+				fmt.Fprintln(os.Stderr, "Shutting down due to linter errors, to prevent shutdown run Benthos with --chilled")
+				os.Exit(1)
+			}
+
+			if level := c.String("log.level"); len(level) > 0 {
+				conf.Logger.LogLevel = strings.ToUpper(level)
+			}
+			logger, err := log.NewV2(os.Stderr, conf.Logger)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to create logger: %v\n", err)
+				os.Exit(1)
+			}
+
+			bConf := bench.NewConfig()
+			bConf.Duration = c.Duration("duration")
+			bConf.Mapping = c.String("mapping")
+			bConf.BatchSize = c.Int("batch-size")
+			bConf.MaxInFlight = c.Int("max-in-flight")
+			bConf.DropOutput = c.Bool("drop-output")
+			bConf.AllocSamples = c.Int("alloc-samples")
+
+			ctx, done := signal.NotifyContext(optContext, os.Interrupt, syscall.SIGTERM)
+			defer done()
+
+			report, err := bench.Run(ctx, conf, bConf, logger)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Benchmark error: %v\n", err)
+				os.Exit(1)
+			}
+
+			if !c.Bool("json") {
+				report.Print(os.Stdout)
+				return nil
+			}
+			resBytes, err := json.MarshalIndent(struct {
+				*bench.Report
+				Throughput float64 `json:"throughput"`
+			}{report, report.Throughput()}, "", "  ")
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to marshal report: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Println(string(resBytes))
+			return nil
+		},
+	}
+}
@@ -0,0 +1,291 @@
+package bench
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	gometrics "github.com/rcrowley/go-metrics"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang/mapping"
+	"github.com/benthosdev/benthos/v4/internal/bloblang/parser"
+	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
+	"github.com/benthosdev/benthos/v4/internal/bundle"
+	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+	ioutput "github.com/benthosdev/benthos/v4/internal/component/output"
+	iprocessor "github.com/benthosdev/benthos/v4/internal/component/processor"
+	"github.com/benthosdev/benthos/v4/internal/config"
+	"github.com/benthosdev/benthos/v4/internal/log"
+	"github.com/benthosdev/benthos/v4/internal/manager"
+	"github.com/benthosdev/benthos/v4/internal/manager/mock"
+	"github.com/benthosdev/benthos/v4/internal/message"
+	"github.com/benthosdev/benthos/v4/internal/old/processor"
+	"github.com/benthosdev/benthos/v4/internal/pipeline"
+)
+
+// DefaultMapping is the Bloblang mapping used for generating messages when a
+// benchmark is not configured with one.
+const DefaultMapping = `root = {"id": uuid_v4(), "message": "hello world", "timestamp": now()}`
+
+// Config describes how a benchmark should be run.
+type Config struct {
+	// Duration is the length of time over which messages are generated.
+	Duration time.Duration
+
+	// Mapping is a Bloblang mapping executed for each generated message.
+	Mapping string
+
+	// BatchSize is the number of messages within each generated batch.
+	BatchSize int
+
+	// MaxInFlight is the maximum number of batches that may be pending
+	// acknowledgement at any given time.
+	MaxInFlight int
+
+	// DropOutput replaces the output of the config with a drop output, which
+	// allows benchmarking processors without the overhead of an output.
+	DropOutput bool
+
+	// AllocSamples is the number of batches fed through each processor of the
+	// pipeline in isolation in order to measure its allocations, where zero
+	// disables the measurement.
+	AllocSamples int
+
+	// DrainTimeout is the maximum length of time to wait for in flight
+	// messages to be acknowledged once generation stops.
+	DrainTimeout time.Duration
+}
+
+// NewConfig returns a benchmark config with default values.
+func NewConfig() Config {
+	return Config{
+		Duration:     time.Second * 10,
+		Mapping:      DefaultMapping,
+		BatchSize:    1,
+		MaxInFlight:  64,
+		DropOutput:   false,
+		AllocSamples: 1000,
+		DrainTimeout: time.Second * 10,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type generator struct {
+	exec      *mapping.Executor
+	batchSize int
+}
+
+func (g generator) next() (*message.Batch, error) {
+	batch := message.QuickBatch(nil)
+	for i := 0; i < g.batchSize; i++ {
+		p, err := g.exec.MapPart(0, message.QuickBatch(nil))
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate message: %w", err)
+		}
+		if p == nil {
+			return nil, errors.New("failed to generate message: mapping deleted the message")
+		}
+		batch.Append(p)
+	}
+	return batch, nil
+}
+
+// measureAllocs feeds generated batches through a processor and returns the
+// average number of heap allocations and bytes allocated per message.
+func measureAllocs(proc iprocessor.V1, gen generator, samples int) (allocs, bytes float64, err error) {
+	batches := make([]*message.Batch, samples)
+	for i := range batches {
+		if batches[i], err = gen.next(); err != nil {
+			return
+		}
+	}
+
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	for _, b := range batches {
+		_, _ = proc.ProcessMessage(b)
+	}
+	runtime.ReadMemStats(&after)
+
+	total := float64(samples * gen.batchSize)
+	allocs = float64(after.Mallocs-before.Mallocs) / total
+	bytes = float64(after.TotalAlloc-before.TotalAlloc) / total
+	return
+}
+
+// Run a benchmark of a config, where the input of the config is replaced with
+// a synthetic generator and messages are sent through the pipeline and output
+// for the configured duration, or until the context is cancelled.
+//
+// Buffers are not included in the benchmark as the acknowledgement of a
+// message by a buffer does not reflect the end-to-end latency of the message.
+func Run(ctx context.Context, conf config.Type, bConf Config, logger log.Modular) (*Report, error) {
+	if bConf.BatchSize < 1 {
+		return nil, errors.New("batch size must be greater than zero")
+	}
+	if bConf.MaxInFlight < 1 {
+		return nil, errors.New("max in flight must be greater than zero")
+	}
+
+	exec, perr := parser.ParseMapping(parser.GlobalContext(), bConf.Mapping)
+	if perr != nil {
+		return nil, fmt.Errorf("failed to parse mapping: %v", perr.ErrorAtPosition([]rune(bConf.Mapping)))
+	}
+	gen := generator{exec: exec, batchSize: bConf.BatchSize}
+
+	local := metrics.NewLocal()
+	mgr, err := manager.NewV2(conf.ResourceConfig, mock.NewManager(), logger, metrics.NewNamespaced(local))
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialise resources: %w", err)
+	}
+	defer func() {
+		mgr.CloseAsync()
+		_ = mgr.WaitForClose(time.Second * 10)
+	}()
+
+	report := &Report{}
+
+	// Allocations are measured before the benchmark begins so that each
+	// processor is measured in isolation.
+	allocs := map[string][2]float64{}
+	if bConf.AllocSamples > 0 {
+		for i, pConf := range conf.Pipeline.Processors {
+			pMgr := mgr.IntoPath("pipeline", "processors", strconv.Itoa(i))
+			proc, err := processor.New(pConf, pMgr, pMgr.Logger(), pMgr.Metrics())
+			if err != nil {
+				return nil, fmt.Errorf("failed to initialise processor %v: %w", i, err)
+			}
+			allocsPerMsg, bytesPerMsg, err := measureAllocs(proc, gen, bConf.AllocSamples)
+			proc.CloseAsync()
+			if err != nil {
+				return nil, err
+			}
+			allocs["root."+query.SliceToDotPath(pMgr.Path()...)] = [2]float64{allocsPerMsg, bytesPerMsg}
+		}
+		_ = local.FlushTimings()
+		_ = local.FlushCounters()
+	}
+
+	tranChan := make(chan message.Transaction)
+	nextTranChan := (<-chan message.Transaction)(tranChan)
+
+	var pipe pipeline.Type
+	if len(conf.Pipeline.Processors) > 0 {
+		if pipe, err = pipeline.New(conf.Pipeline, mgr.IntoPath("pipeline")); err != nil {
+			return nil, fmt.Errorf("failed to initialise pipeline: %w", err)
+		}
+		if err = pipe.Consume(nextTranChan); err != nil {
+			return nil, err
+		}
+		nextTranChan = pipe.TransactionChan()
+	}
+
+	outConf := conf.Output
+	if bConf.DropOutput {
+		outConf.Type = "drop"
+	}
+	var out ioutput.Streamed
+	if out, err = mgr.IntoPath("output").(bundle.NewManagement).NewOutput(outConf); err != nil {
+		return nil, fmt.Errorf("failed to initialise output: %w", err)
+	}
+	if err = out.Consume(nextTranChan); err != nil {
+		return nil, err
+	}
+
+	latency := gometrics.NewTimer()
+	var messages, errored int64
+
+	inFlight := make(chan struct{}, bConf.MaxInFlight)
+
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+
+	benchCtx, done := context.WithTimeout(ctx, bConf.Duration)
+	defer done()
+
+	started := time.Now()
+	var genErr error
+generateLoop:
+	for {
+		select {
+		case inFlight <- struct{}{}:
+		case <-benchCtx.Done():
+			break generateLoop
+		}
+
+		batch, err := gen.next()
+		if err != nil {
+			<-inFlight
+			genErr = err
+			break generateLoop
+		}
+
+		n, sent := int64(batch.Len()), time.Now()
+		tran := message.NewTransactionFunc(batch, func(ctx context.Context, err error) error {
+			latency.UpdateSince(sent)
+			if err != nil {
+				atomic.AddInt64(&errored, n)
+			} else {
+				atomic.AddInt64(&messages, n)
+			}
+			<-inFlight
+			return nil
+		})
+
+		select {
+		case tranChan <- tran:
+		case <-benchCtx.Done():
+			<-inFlight
+			break generateLoop
+		}
+	}
+
+	// Wait for all in flight messages to be acknowledged by filling the
+	// in flight channel.
+	drainDeadline := time.After(bConf.DrainTimeout)
+drainLoop:
+	for i := 0; i < bConf.MaxInFlight; i++ {
+		select {
+		case inFlight <- struct{}{}:
+		case <-drainDeadline:
+			logger.Warnf("Timed out waiting for %v batches to be acknowledged", bConf.MaxInFlight-i)
+			break drainLoop
+		}
+	}
+	report.Elapsed = time.Since(started)
+
+	runtime.ReadMemStats(&after)
+
+	close(tranChan)
+	if pipe != nil {
+		pipe.CloseAsync()
+		if err := pipe.WaitForClose(time.Second * 10); err != nil {
+			logger.Warnf("Failed to close pipeline: %v", err)
+		}
+	}
+	out.CloseAsync()
+	if err := out.WaitForClose(time.Second * 10); err != nil {
+		logger.Warnf("Failed to close output: %v", err)
+	}
+	if genErr != nil {
+		return nil, genErr
+	}
+
+	report.Messages = atomic.LoadInt64(&messages)
+	report.Errors = atomic.LoadInt64(&errored)
+	report.Latency = newLatencyStats(latency)
+	report.GCCycles = after.NumGC - before.NumGC
+	if total := float64(report.Messages + report.Errors); total > 0 {
+		report.AllocsPerMessage = float64(after.Mallocs-before.Mallocs) / total
+		report.BytesPerMessage = float64(after.TotalAlloc-before.TotalAlloc) / total
+	}
+	report.Components = componentReports(local, allocs)
+	return report, nil
+}
@@ -0,0 +1,78 @@
+package bench_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/benthosdev/benthos/v4/internal/cli/bench"
+	"github.com/benthosdev/benthos/v4/internal/config"
+	"github.com/benthosdev/benthos/v4/internal/log"
+
+	_ "github.com/benthosdev/benthos/v4/public/components/all"
+)
+
+func TestBenchRun(t *testing.T) {
+	conf := config.New()
+	require.NoError(t, yaml.Unmarshal([]byte(`
+pipeline:
+  processors:
+    - bloblang: 'root = this.message.uppercase()'
+    - label: foo
+      bloblang: 'root = content() + " world"'
+output:
+  label: bar
+  drop: {}
+`), &conf))
+
+	bConf := bench.NewConfig()
+	bConf.Duration = time.Millisecond * 200
+	bConf.Mapping = `root.message = "hello"`
+	bConf.BatchSize = 2
+	bConf.AllocSamples = 10
+
+	report, err := bench.Run(context.Background(), conf, bConf, log.Noop())
+	require.NoError(t, err)
+
+	assert.Greater(t, report.Messages, int64(0))
+	assert.Equal(t, int64(0), report.Messages%2)
+	assert.Equal(t, int64(0), report.Errors)
+	assert.Greater(t, report.Throughput(), float64(0))
+	assert.Greater(t, int64(report.Latency.Max), int64(0))
+
+	type component struct {
+		Type, Label, Path string
+		HasAllocs         bool
+	}
+	var components []component
+	for _, c := range report.Components {
+		components = append(components, component{
+			Type: c.Type, Label: c.Label, Path: c.Path, HasAllocs: c.HasAllocs,
+		})
+		assert.Equal(t, report.Messages/2, c.Count, c.Path)
+	}
+	assert.Equal(t, []component{
+		{Type: "output", Label: "bar", Path: "root.output"},
+		{Type: "processor", Path: "root.pipeline.processors.0", HasAllocs: true},
+		{Type: "processor", Label: "foo", Path: "root.pipeline.processors.1", HasAllocs: true},
+	}, components)
+
+	var buf bytes.Buffer
+	report.Print(&buf)
+	assert.Contains(t, buf.String(), "Throughput:")
+	assert.Contains(t, buf.String(), "root.pipeline.processors.1")
+}
+
+func TestBenchRunBadMapping(t *testing.T) {
+	bConf := bench.NewConfig()
+	bConf.Mapping = `root = `
+
+	_, err := bench.Run(context.Background(), config.New(), bConf, log.Noop())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to parse mapping")
+}
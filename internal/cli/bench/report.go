@@ -0,0 +1,144 @@
+package bench
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	gometrics "github.com/rcrowley/go-metrics"
+
+	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+)
+
+// LatencyStats summarises a distribution of latencies.
+type LatencyStats struct {
+	P50 time.Duration `json:"p50"`
+	P90 time.Duration `json:"p90"`
+	P99 time.Duration `json:"p99"`
+	Max time.Duration `json:"max"`
+}
+
+func newLatencyStats(t gometrics.Timer) LatencyStats {
+	ps := t.Percentiles([]float64{0.5, 0.9, 0.99})
+	return LatencyStats{
+		P50: time.Duration(ps[0]),
+		P90: time.Duration(ps[1]),
+		P99: time.Duration(ps[2]),
+		Max: time.Duration(t.Max()),
+	}
+}
+
+func (l LatencyStats) String() string {
+	return fmt.Sprintf("p50 %v, p90 %v, p99 %v, max %v", l.P50, l.P90, l.P99, l.Max)
+}
+
+// ComponentReport contains the results of a benchmark for an individual
+// component, identified by its type, label and path within the config. The
+// count is the number of batches that the latency was measured for.
+type ComponentReport struct {
+	Type    string       `json:"type"`
+	Label   string       `json:"label"`
+	Path    string       `json:"path"`
+	Count   int64        `json:"count"`
+	Latency LatencyStats `json:"latency"`
+
+	// Allocations are only measured for the processors at the root of the
+	// pipeline, where HasAllocs is true.
+	HasAllocs        bool    `json:"has_allocs"`
+	AllocsPerMessage float64 `json:"allocs_per_message"`
+	BytesPerMessage  float64 `json:"bytes_per_message"`
+}
+
+// Report contains the results of a benchmark.
+type Report struct {
+	Elapsed          time.Duration     `json:"elapsed"`
+	Messages         int64             `json:"messages"`
+	Errors           int64             `json:"errors"`
+	Latency          LatencyStats      `json:"latency"`
+	AllocsPerMessage float64           `json:"allocs_per_message"`
+	BytesPerMessage  float64           `json:"bytes_per_message"`
+	GCCycles         uint32            `json:"gc_cycles"`
+	Components       []ComponentReport `json:"components"`
+}
+
+// Throughput returns the number of messages successfully delivered per second.
+func (r *Report) Throughput() float64 {
+	if r.Elapsed <= 0 {
+		return 0
+	}
+	return float64(r.Messages) / r.Elapsed.Seconds()
+}
+
+// Print a human readable summary of the report.
+func (r *Report) Print(w io.Writer) {
+	fmt.Fprintf(w, "Throughput:  %.1f msg/s (%v messages in %v, %v errors)\n", r.Throughput(), r.Messages, r.Elapsed.Round(time.Millisecond), r.Errors)
+	fmt.Fprintf(w, "Latency:     %v\n", r.Latency)
+	fmt.Fprintf(w, "Allocations: %.1f allocs/msg, %v/msg, %v GC cycles\n", r.AllocsPerMessage, byteSize(r.BytesPerMessage), r.GCCycles)
+
+	if len(r.Components) == 0 {
+		return
+	}
+	fmt.Fprintln(w)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "COMPONENT\tLABEL\tPATH\tCOUNT\tP50\tP99\tALLOCS/MSG\tBYTES/MSG")
+	for _, c := range r.Components {
+		allocs, bytes := "-", "-"
+		if c.HasAllocs {
+			allocs, bytes = fmt.Sprintf("%.1f", c.AllocsPerMessage), byteSize(c.BytesPerMessage)
+		}
+		fmt.Fprintf(tw, "%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v\n", c.Type, c.Label, c.Path, c.Count, c.Latency.P50, c.Latency.P99, allocs, bytes)
+	}
+	_ = tw.Flush()
+}
+
+func byteSize(b float64) string {
+	switch {
+	case b >= 1<<20:
+		return fmt.Sprintf("%.1fMB", b/(1<<20))
+	case b >= 1<<10:
+		return fmt.Sprintf("%.1fKB", b/(1<<10))
+	}
+	return fmt.Sprintf("%.0fB", b)
+}
+
+// componentReports extracts a report for each component from the latency
+// timings of a metrics aggregator, sorted by the path of the component.
+func componentReports(local *metrics.Local, allocs map[string][2]float64) []ComponentReport {
+	var reports []ComponentReport
+	for k, t := range local.GetTimings() {
+		name, tagNames, tagValues := metrics.ReverseLabelledPath(k)
+		if !strings.HasSuffix(name, "_latency_ns") || t.Count() == 0 {
+			continue
+		}
+
+		c := ComponentReport{
+			Type:    strings.TrimSuffix(name, "_latency_ns"),
+			Count:   t.Count(),
+			Latency: newLatencyStats(t),
+		}
+		for i, tag := range tagNames {
+			switch tag {
+			case "label":
+				c.Label = tagValues[i]
+			case "path":
+				c.Path = tagValues[i]
+			}
+		}
+		if a, exists := allocs[c.Path]; exists && c.Type == "processor" {
+			c.HasAllocs = true
+			c.AllocsPerMessage, c.BytesPerMessage = a[0], a[1]
+		}
+		reports = append(reports, c)
+	}
+	sort.Slice(reports, func(i, j int) bool {
+		if reports[i].Path == reports[j].Path {
+			return reports[i].Type < reports[j].Type
+		}
+		return reports[i].Path < reports[j].Path
+	})
+	return reports
+}
@@ -21,6 +21,7 @@ import (
 
 	"github.com/benthosdev/benthos/v4/internal/bloblang"
 	"github.com/benthosdev/benthos/v4/internal/bloblang/parser"
+	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
 
 	_ "embed"
 )
@@ -143,12 +144,12 @@ func runServer(c *cli.Context) error {
 	defer fSync.write()
 
 	mux := http.NewServeMux()
-	execCache := newExecCache()
 
 	mux.HandleFunc("/execute", func(w http.ResponseWriter, r *http.Request) {
 		req := struct {
-			Mapping string `json:"mapping"`
-			Input   string `json:"input"`
+			Mapping       string `json:"mapping"`
+			Input         string `json:"input"`
+			InputMetadata string `json:"input_metadata"`
 		}{}
 		dec := json.NewDecoder(r.Body)
 		if err := dec.Decode(&req); err != nil {
@@ -159,10 +160,14 @@ func runServer(c *cli.Context) error {
 		fSync.update(req.Input, req.Mapping)
 
 		res := struct {
-			ParseError   string `json:"parse_error"`
-			MappingError string `json:"mapping_error"`
-			Result       string `json:"result"`
-		}{}
+			ParseError     string            `json:"parse_error"`
+			MetadataError  string            `json:"metadata_error"`
+			MappingError   string            `json:"mapping_error"`
+			Result         string            `json:"result"`
+			ResultMetadata map[string]string `json:"result_metadata"`
+		}{
+			ResultMetadata: map[string]string{},
+		}
 		defer func() {
 			resBytes, err := json.Marshal(res)
 			if err != nil {
@@ -172,6 +177,21 @@ func runServer(c *cli.Context) error {
 			_, _ = w.Write(resBytes)
 		}()
 
+		// Each execution uses a fresh message so that metadata set by previous
+		// executions does not leak into the next.
+		execCache := newExecCache()
+
+		if req.InputMetadata != "" {
+			var inputMeta map[string]interface{}
+			if err := json.Unmarshal([]byte(req.InputMetadata), &inputMeta); err != nil {
+				res.MetadataError = fmt.Sprintf("failed to parse metadata as a JSON object: %v", err)
+				return
+			}
+			for k, v := range inputMeta {
+				execCache.msg.Get(0).MetaSet(k, query.IToString(v))
+			}
+		}
+
 		exec, err := bloblang.GlobalEnvironment().NewMapping(req.Mapping)
 		if err != nil {
 			if perr, ok := err.(*parser.Error); ok {
@@ -185,19 +205,25 @@ func runServer(c *cli.Context) error {
 		output, err := execCache.executeMapping(exec, false, true, []byte(req.Input))
 		if err != nil {
 			res.MappingError = err.Error()
-		} else {
-			res.Result = output
+			return
 		}
+		res.Result = output
+		_ = execCache.msg.Get(0).MetaIter(func(k, v string) error {
+			res.ResultMetadata[k] = v
+			return nil
+		})
 	})
 
 	indexTemplate := template.Must(template.New("index").Parse(bloblangEditorPage))
 
 	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		err := indexTemplate.Execute(w, struct {
-			InitialInput   string
-			InitialMapping string
+			InitialInput    string
+			InitialMetadata string
+			InitialMapping  string
 		}{
 			fSync.input(),
+			`{}`,
 			fSync.mapping(),
 		})
 		if err != nil {
@@ -0,0 +1,178 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/urfave/cli/v2"
+	"gopkg.in/yaml.v3"
+
+	"github.com/benthosdev/benthos/v4/internal/config"
+)
+
+func readConfigNode(path string) (*yaml.Node, error) {
+	// Environment variables and secrets are deliberately not replaced as we
+	// want to preserve the config as it was written.
+	configBytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var node yaml.Node
+	if err := yaml.Unmarshal(configBytes, &node); err != nil {
+		return nil, err
+	}
+	return &node, nil
+}
+
+func diffValueStr(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(b)
+}
+
+func configDiffCliCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "diff",
+		Usage: "Compare a config against the default values of its fields",
+		Description: `
+Prints each field that is explicitly set within a config along with how it
+compares to the default value of the field:
+
+  benthos config diff ./config.yaml
+
+Each field is prefixed with one of the following symbols:
+
+  + The field has no default value.
+  ~ The field differs from its default value.
+  = The field is equal to its default value and is therefore redundant.
+  ! The field or component is deprecated.
+
+Environment variable interpolations and secret references are not resolved,
+and are therefore compared to default values as they were written.`[1:],
+		Action: func(c *cli.Context) error {
+			if c.Args().Len() != 1 {
+				fmt.Fprintln(os.Stderr, "Expected a single config path argument")
+				os.Exit(1)
+			}
+
+			node, err := readConfigNode(c.Args().First())
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Configuration file read error: %v\n", err)
+				os.Exit(1)
+			}
+
+			entries, err := config.Diff(node)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Diff error: %v\n", err)
+				os.Exit(1)
+			}
+
+			for _, e := range entries {
+				switch {
+				case e.Component:
+					fmt.Printf("%v %v: %v (deprecated component, must be migrated manually)\n", red("!"), e.Path, e.Value)
+				case e.Deprecated && e.Migratable:
+					fmt.Printf("%v %v: %v (deprecated, can be migrated automatically)\n", red("!"), e.Path, diffValueStr(e.Value))
+				case e.Deprecated:
+					fmt.Printf("%v %v: %v (deprecated, must be migrated manually)\n", red("!"), e.Path, diffValueStr(e.Value))
+				case e.Redundant:
+					fmt.Printf("%v %v: %v (equal to the default)\n", yellow("="), e.Path, diffValueStr(e.Value))
+				case e.Default == nil:
+					fmt.Printf("+ %v: %v\n", e.Path, diffValueStr(e.Value))
+				default:
+					fmt.Printf("~ %v: %v (default: %v)\n", e.Path, diffValueStr(e.Value), diffValueStr(*e.Default))
+				}
+			}
+			return nil
+		},
+	}
+}
+
+func configMigrateCliCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "migrate",
+		Usage: "Remove redundant fields and migrate deprecated fields of a config",
+		Description: `
+Rewrites a config such that fields equal to their default values are removed
+and deprecated fields are replaced with their modern equivalents. The result
+is printed to stdout unless the --write flag is set, in which case the config
+file is overwritten:
+
+  benthos config migrate ./config.yaml
+  benthos config migrate --write ./config.yaml
+
+Deprecated fields and components that cannot be migrated automatically are
+reported as warnings and left unchanged.`[1:],
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:    "write",
+				Aliases: []string{"w"},
+				Value:   false,
+				Usage:   "Overwrite the config file with the result rather than printing it.",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			if c.Args().Len() != 1 {
+				fmt.Fprintln(os.Stderr, "Expected a single config path argument")
+				os.Exit(1)
+			}
+			path := c.Args().First()
+
+			node, err := readConfigNode(path)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Configuration file read error: %v\n", err)
+				os.Exit(1)
+			}
+
+			lints, err := config.Migrate(node)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Migrate error: %v\n", err)
+				os.Exit(1)
+			}
+			for _, l := range lints {
+				fmt.Fprintf(os.Stderr, "%v: line %v: %v\n", path, l.Line, yellow(l.What))
+			}
+
+			if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+				node = node.Content[0]
+			}
+			configYAML, err := config.MarshalYAML(*node)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Migrate error: %v\n", err)
+				os.Exit(1)
+			}
+
+			if !c.Bool("write") {
+				fmt.Print(string(configYAML))
+				return nil
+			}
+			if err := os.WriteFile(path, configYAML, 0o644); err != nil {
+				fmt.Fprintf(os.Stderr, "Configuration file write error: %v\n", err)
+				os.Exit(1)
+			}
+			return nil
+		},
+	}
+}
+
+func configCliCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "config",
+		Usage: "Compare configs against their defaults and migrate deprecated usage",
+		Description: `
+Allows comparing configs against the default values of their fields, and
+rewriting them in order to remove redundant fields and migrate deprecated
+fields.
+
+  benthos config diff ./config.yaml
+  benthos config migrate ./config.yaml`[1:],
+		Subcommands: []*cli.Command{
+			configDiffCliCommand(),
+			configMigrateCliCommand(),
+		},
+	}
+}
@@ -27,9 +27,18 @@ type pathLint struct {
 	err    string
 }
 
-func lintFile(path string, rejectDeprecated bool) (pathLints []pathLint) {
+func newLintContext(rejectDeprecated bool, policy *config.Policy) docs.LintContext {
+	lintCtx := docs.NewLintContext()
+	lintCtx.RejectDeprecated = rejectDeprecated
+	if policy != nil {
+		lintCtx.Policy = policy
+	}
+	return lintCtx
+}
+
+func lintFile(path string, rejectDeprecated bool, policy *config.Policy) (pathLints []pathLint) {
 	conf := config.New()
-	lints, err := config.ReadFileLinted(path, rejectDeprecated, &conf)
+	lints, err := config.ReadFileLintedWithContext(newLintContext(rejectDeprecated, policy), path, &conf)
 	if err != nil {
 		pathLints = append(pathLints, pathLint{
 			source: path,
@@ -46,7 +55,7 @@ func lintFile(path string, rejectDeprecated bool) (pathLints []pathLint) {
 	return
 }
 
-func lintMDSnippets(path string, rejectDeprecated bool) (pathLints []pathLint) {
+func lintMDSnippets(path string, rejectDeprecated bool, policy *config.Policy) (pathLints []pathLint) {
 	rawBytes, err := os.ReadFile(path)
 	if err != nil {
 		pathLints = append(pathLints, pathLint{
@@ -85,9 +94,7 @@ func lintMDSnippets(path string, rejectDeprecated bool) (pathLints []pathLint) {
 				err:    err.Error(),
 			})
 		} else {
-			lintCtx := docs.NewLintContext()
-			lintCtx.RejectDeprecated = rejectDeprecated
-			lints, err := config.LintBytes(lintCtx, configBytes)
+			lints, err := config.LintBytes(newLintContext(rejectDeprecated, policy), configBytes)
 			if err != nil {
 				pathLints = append(pathLints, pathLint{
 					source: path,
@@ -122,15 +129,24 @@ Exits with a status code 1 if any linting errors are detected:
   benthos lint ./configs/*.yaml
   benthos lint ./foo.yaml ./bar.yaml
   benthos lint ./configs/...
+  benthos lint --policy ./policy.yaml ./configs/...
 
 If a path ends with '...' then Benthos will walk the target and lint any
-files with the .yaml or .yml extension.`[1:],
+files with the .yaml or .yml extension.
+
+Custom rules can be checked against configs by providing policy files with
+the --policy flag, for more information check out the docs at:
+https://benthos.dev/docs/configuration/linting_policies`[1:],
 		Flags: []cli.Flag{
 			&cli.BoolFlag{
 				Name:  "deprecated",
 				Value: false,
 				Usage: "Print linting errors for the presence of deprecated fields.",
 			},
+			&cli.StringSliceFlag{
+				Name:  "policy",
+				Usage: "A path to a policy file containing custom linting rules, can be specified multiple times.",
+			},
 		},
 		Action: func(c *cli.Context) error {
 			targets, err := ifilepath.GlobsAndSuperPaths(c.Args().Slice(), "yaml", "yml")
@@ -138,12 +154,18 @@ files with the .yaml or .yml extension.`[1:],
 				fmt.Fprintf(os.Stderr, "Lint paths error: %v\n", err)
 				os.Exit(1)
 			}
-			if conf := c.String("config"); len(conf) > 0 {
-				targets = append(targets, conf)
-			}
+			targets = append(targets, c.StringSlice("config")...)
 
 			rejectDeprecated := c.Bool("deprecated")
 
+			var policy *config.Policy
+			if policyPaths := c.StringSlice("policy"); len(policyPaths) > 0 {
+				if policy, err = config.ReadPolicyFiles(policyPaths...); err != nil {
+					fmt.Fprintf(os.Stderr, "Policy file read error: %v\n", err)
+					os.Exit(1)
+				}
+			}
+
 			var pathLintMut sync.Mutex
 			var pathLints []pathLint
 			threads := runtime.NumCPU()
@@ -161,9 +183,9 @@ files with the .yaml or .yml extension.`[1:],
 						}
 						var lints []pathLint
 						if path.Ext(target) == ".md" {
-							lints = lintMDSnippets(target, rejectDeprecated)
+							lints = lintMDSnippets(target, rejectDeprecated, policy)
 						} else {
-							lints = lintFile(target, rejectDeprecated)
+							lints = lintFile(target, rejectDeprecated, policy)
 						}
 						if len(lints) > 0 {
 							pathLintMut.Lock()
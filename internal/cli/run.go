@@ -136,11 +136,10 @@ func Run() {
 			Aliases: []string{"s"},
 			Usage:   "set a field (identified by a dot path) in the main configuration file, e.g. `\"metrics.type=prometheus\"`",
 		},
-		&cli.StringFlag{
+		&cli.StringSliceFlag{
 			Name:    "config",
 			Aliases: []string{"c"},
-			Value:   "",
-			Usage:   "a path to a configuration file",
+			Usage:   "a path to a configuration file, when specified multiple times subsequent files are merged on top of the first as overlays",
 		},
 		&cli.StringSliceFlag{
 			Name:    "resources",
@@ -163,6 +162,11 @@ func Run() {
 			Value:   false,
 			Usage:   "EXPERIMENTAL: watch config files for changes and automatically apply them",
 		},
+		&cli.DurationFlag{
+			Name:  "secrets-refresh",
+			Value: 0,
+			Usage: "EXPERIMENTAL: when watching config files, an interval at which referenced secrets are obtained again and config files are reapplied if any have changed",
+		},
 	}
 	if len(customFlags) > 0 {
 		flags = append(flags, customFlags...)
@@ -223,12 +227,13 @@ Either run Benthos as a stream processor or choose a command:
 				os.Exit(1)
 			}
 			os.Exit(cmdService(
-				c.String("config"),
+				c.StringSlice("config"),
 				c.StringSlice("resources"),
 				c.StringSlice("set"),
 				c.String("log.level"),
 				!c.Bool("chilled"),
 				c.Bool("watcher"),
+				c.Duration("secrets-refresh"),
 				false,
 				false,
 				nil,
@@ -246,7 +251,7 @@ variables have been resolved:
 
   benthos -c ./config.yaml echo | less`[1:],
 				Action: func(c *cli.Context) error {
-					confReader := readConfig(c.String("config"), false, c.StringSlice("resources"), nil, c.StringSlice("set"))
+					confReader := readConfig(c.StringSlice("config"), false, c.StringSlice("resources"), nil, c.StringSlice("set"))
 					conf := config.New()
 					if _, err := confReader.Read(&conf); err != nil {
 						fmt.Fprintf(os.Stderr, "Configuration file read error: %v\n", err)
@@ -273,6 +278,7 @@ variables have been resolved:
 				},
 			},
 			lintCliCommand(),
+			configCliCommand(),
 			{
 				Name:  "streams",
 				Usage: "Run Benthos in streams mode",
@@ -301,12 +307,13 @@ https://benthos.dev/docs/guides/streams_mode/about`[1:],
 				},
 				Action: func(c *cli.Context) error {
 					os.Exit(cmdService(
-						c.String("config"),
+						c.StringSlice("config"),
 						c.StringSlice("resources"),
 						c.StringSlice("set"),
 						c.String("log.level"),
 						!c.Bool("chilled"),
 						c.Bool("watcher"),
+						c.Duration("secrets-refresh"),
 						!c.Bool("no-api"),
 						true,
 						c.Args().Slice(),
@@ -317,6 +324,7 @@ https://benthos.dev/docs/guides/streams_mode/about`[1:],
 			listCliCommand(),
 			createCliCommand(),
 			test.CliCommand(testSuffix),
+			benchCliCommand(),
 			clitemplate.CliCommand(),
 			blobl.CliCommand(),
 			studio.CliCommand(Version, DateBuilt),
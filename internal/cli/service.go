@@ -37,7 +37,12 @@ type stoppable interface {
 
 //------------------------------------------------------------------------------
 
-func readConfig(path string, streamsMode bool, resourcesPaths, streamsPaths, overrides []string) *config.Reader {
+func readConfig(paths []string, streamsMode bool, resourcesPaths, streamsPaths, overrides []string, extraOpts ...config.OptFunc) *config.Reader {
+	var path string
+	var overlayPaths []string
+	if len(paths) > 0 {
+		path, overlayPaths = paths[0], paths[1:]
+	}
 	if path == "" {
 		// Iterate default config paths
 		for _, dpath := range []string{
@@ -54,11 +59,13 @@ func readConfig(path string, streamsMode bool, resourcesPaths, streamsPaths, ove
 	}
 	opts := []config.OptFunc{
 		config.OptAddOverrides(overrides...),
+		config.OptAddOverlays(overlayPaths...),
 		config.OptTestSuffix(testSuffix),
 	}
 	if streamsMode {
 		opts = append(opts, config.OptSetStreamPaths(streamsPaths...))
 	}
+	opts = append(opts, extraOpts...)
 	return config.NewReader(path, resourcesPaths, opts...)
 }
 
@@ -66,12 +73,13 @@ func readConfig(path string, streamsMode bool, resourcesPaths, streamsPaths, ove
 
 func initStreamsMode(
 	strict, watching, enableAPI bool,
+	shutdownConf stream.ShutdownConfig,
 	confReader *config.Reader,
 	manager *manager.Type,
 	logger log.Modular,
 	stats *metrics.Namespaced,
 ) stoppable {
-	streamMgr := strmmgr.New(manager, strmmgr.OptAPIEnabled(enableAPI))
+	streamMgr := strmmgr.New(manager, strmmgr.OptAPIEnabled(enableAPI), strmmgr.OptShutdown(shutdownConf))
 
 	streamConfs := map[string]stream.Config{}
 	lints, err := confReader.ReadStreams(streamConfs)
@@ -175,6 +183,7 @@ func initNormalMode(
 	streamInit := func() (stoppable, error) {
 		return stream.New(
 			conf.Config, manager,
+			stream.OptShutdown(conf.Shutdown),
 			stream.OptOnClose(func() {
 				if !watching {
 					close(stoppedChan)
@@ -220,15 +229,20 @@ func initNormalMode(
 }
 
 func cmdService(
-	confPath string,
+	confPaths []string,
 	resourcesPaths []string,
 	confOverrides []string,
 	overrideLogLevel string,
-	strict, watching, enableStreamsAPI bool,
+	strict, watching bool,
+	secretsRefresh time.Duration,
+	enableStreamsAPI bool,
 	streamsMode bool,
 	streamsPaths []string,
 ) int {
-	confReader := readConfig(confPath, streamsMode, resourcesPaths, streamsPaths, confOverrides)
+	confReader := readConfig(
+		confPaths, streamsMode, resourcesPaths, streamsPaths, confOverrides,
+		config.OptSecretsRefreshPeriod(secretsRefresh),
+	)
 	conf := config.New()
 
 	lints, err := confReader.Read(&conf)
@@ -262,6 +276,9 @@ func cmdService(
 		fmt.Printf("Failed to create logger: %v\n", err)
 		return 1
 	}
+	if closer, ok := logger.(interface{ Close() error }); ok {
+		defer closer.Close()
+	}
 
 	for _, lint := range lints {
 		logger.Infoln(lint)
@@ -282,7 +299,7 @@ func cmdService(
 
 	// Create our tracer type.
 	var trac tracer.Type
-	if trac, err = bundle.AllTracers.Init(conf.Tracer); err != nil {
+	if trac, err = bundle.AllTracers.Init(conf.Tracer, stats); err != nil {
 		logger.Errorf("Failed to initialise tracer: %v\n", err)
 		return 1
 	}
@@ -317,7 +334,7 @@ func cmdService(
 
 	// Create data streams.
 	if streamsMode {
-		stoppableStream = initStreamsMode(strict, watching, enableStreamsAPI, confReader, manager, logger, stats)
+		stoppableStream = initStreamsMode(strict, watching, enableStreamsAPI, conf.Shutdown, confReader, manager, logger, stats)
 	} else {
 		stoppableStream, dataStreamClosedChan = initNormalMode(conf, strict, watching, confReader, manager, logger, stats)
 	}
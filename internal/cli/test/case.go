@@ -1,12 +1,20 @@
 package test
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 
 	yaml "gopkg.in/yaml.v3"
 
+	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+	ioutput "github.com/benthosdev/benthos/v4/internal/component/output"
 	iprocessor "github.com/benthosdev/benthos/v4/internal/component/processor"
 	"github.com/benthosdev/benthos/v4/internal/message"
 	"github.com/benthosdev/benthos/v4/internal/old/processor"
@@ -62,15 +70,64 @@ func (i *InputPart) UnmarshalYAML(value *yaml.Node) error {
 	return nil
 }
 
+// MetricCondition checks the value of a counter or gauge emitted during a test
+// case, where the values of all metrics with a matching name and labels are
+// summed.
+type MetricCondition struct {
+	Name   string            `yaml:"name"`
+	Labels map[string]string `yaml:"labels"`
+	Value  int64             `yaml:"value"`
+}
+
+func (m MetricCondition) String() string {
+	if len(m.Labels) == 0 {
+		return m.Name
+	}
+	var labels []string
+	for k, v := range m.Labels {
+		labels = append(labels, fmt.Sprintf("%v=%q", k, v))
+	}
+	sort.Strings(labels)
+	return m.Name + "{" + strings.Join(labels, ",") + "}"
+}
+
+func (m MetricCondition) valueFrom(counters map[string]int64) (total int64) {
+	for path, v := range counters {
+		name, tagNames, tagValues := metrics.ReverseLabelledPath(path)
+		if name != m.Name {
+			continue
+		}
+		tags := map[string]string{}
+		for i, k := range tagNames {
+			tags[k] = tagValues[i]
+		}
+		matched := true
+		for k, v := range m.Labels {
+			if tags[k] != v {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			total += v
+		}
+	}
+	return
+}
+
 // Case contains a definition of a single Benthos config test case.
 type Case struct {
-	Name             string               `yaml:"name"`
-	Environment      map[string]string    `yaml:"environment"`
-	TargetProcessors string               `yaml:"target_processors"`
-	TargetMapping    string               `yaml:"target_mapping"`
-	Mocks            map[string]yaml.Node `yaml:"mocks"`
-	InputBatch       []InputPart          `yaml:"input_batch"`
-	OutputBatches    [][]ConditionsMap    `yaml:"output_batches"`
+	Name             string                       `yaml:"name"`
+	Environment      map[string]string            `yaml:"environment"`
+	TargetProcessors string                       `yaml:"target_processors"`
+	TargetMapping    string                       `yaml:"target_mapping"`
+	TargetOutput     string                       `yaml:"target_output"`
+	Mocks            map[string]yaml.Node         `yaml:"mocks"`
+	ResourceStubs    ResourceStubs                `yaml:"resource_stubs"`
+	InputBatch       []InputPart                  `yaml:"input_batch"`
+	OutputBatches    [][]ConditionsMap            `yaml:"output_batches"`
+	CapturedOutputs  map[string][][]ConditionsMap `yaml:"captured_outputs"`
+	Metrics          []MetricCondition            `yaml:"metrics"`
 
 	line int
 }
@@ -88,9 +145,13 @@ func NewCase() Case {
 		Environment:      map[string]string{},
 		TargetProcessors: "/pipeline/processors",
 		TargetMapping:    "",
+		TargetOutput:     "",
 		Mocks:            map[string]yaml.Node{},
+		ResourceStubs:    ResourceStubs{},
 		InputBatch:       []InputPart{},
 		OutputBatches:    [][]ConditionsMap{},
+		CapturedOutputs:  map[string][][]ConditionsMap{},
+		Metrics:          []MetricCondition{},
 	}
 }
 
@@ -105,6 +166,20 @@ func (c *Case) UnmarshalYAML(value *yaml.Node) error {
 
 	*c = Case(aliased)
 	c.line = value.Line
+
+	// When an output is targeted the input batch is written to it directly
+	// unless processors are also targeted explicitly.
+	if c.TargetOutput != "" {
+		explicitProcs := false
+		for i := 0; i < len(value.Content)-1; i += 2 {
+			if value.Content[i].Value == "target_processors" {
+				explicitProcs = true
+			}
+		}
+		if !explicitProcs {
+			c.TargetProcessors = ""
+		}
+	}
 	return nil
 }
 
@@ -122,21 +197,38 @@ func (c CaseFailure) String() string {
 	return fmt.Sprintf("%v [line %v]: %v", c.Name, c.TestLine, c.Reason)
 }
 
-// ProcProvider returns compiled processors extracted from a Benthos config
-// using a JSON Pointer.
+// ProcProvider returns compiled processors and outputs extracted from a
+// Benthos config using a JSON Pointer.
 type ProcProvider interface {
-	Provide(jsonPtr string, environment map[string]string, mocks map[string]yaml.Node) ([]iprocessor.V1, error)
+	Provide(jsonPtr string, environment map[string]string, mocks map[string]yaml.Node, opts ...ProvideOptFunc) ([]iprocessor.V1, error)
 	ProvideBloblang(path string) ([]iprocessor.V1, error)
+	ProvideOutput(jsonPtr string, environment map[string]string, mocks map[string]yaml.Node, captures []string, opts ...ProvideOptFunc) (ioutput.Streamed, map[string]<-chan message.Transaction, error)
 }
 
+// The maximum period to wait for a targeted output to acknowledge a batch.
+var outputTimeout = time.Second * 10
+
 func (c *Case) executeFrom(dir string, provider ProcProvider) (failures []CaseFailure, err error) {
+	if len(c.CapturedOutputs) > 0 && c.TargetOutput == "" {
+		return nil, errors.New("captured outputs require a target_output to be set")
+	}
+	if len(c.OutputBatches) > 0 && c.TargetOutput != "" && c.TargetProcessors == "" && c.TargetMapping == "" {
+		return nil, errors.New("output_batches cannot be checked when a target_output is set without target_processors, use captured_outputs instead")
+	}
+
+	stats := metrics.NewLocal()
+	provideOpts := []ProvideOptFunc{
+		OptProvideResourceStubs(c.ResourceStubs),
+		OptProvideMetrics(stats),
+	}
+
 	var procSet []iprocessor.V1
 	if c.TargetMapping != "" {
 		if procSet, err = provider.ProvideBloblang(c.TargetMapping); err != nil {
 			return nil, fmt.Errorf("failed to initialise Bloblang mapping '%v': %v", c.TargetMapping, err)
 		}
-	} else {
-		if procSet, err = provider.Provide(c.TargetProcessors, c.Environment, c.Mocks); err != nil {
+	} else if c.TargetProcessors != "" {
+		if procSet, err = provider.Provide(c.TargetProcessors, c.Environment, c.Mocks, provideOpts...); err != nil {
 			return nil, fmt.Errorf("failed to initialise processors '%v': %v", c.TargetProcessors, err)
 		}
 	}
@@ -170,33 +262,132 @@ func (c *Case) executeFrom(dir string, provider ProcProvider) (failures []CaseFa
 		reportFailure(fmt.Sprintf("processors resulted in error: %v", result))
 	}
 
-	if lExp, lAct := len(c.OutputBatches), len(outputBatches); lAct < lExp {
-		reportFailure(fmt.Sprintf("wrong batch count, expected %v, got %v", lExp, lAct))
+	if c.TargetMapping != "" || c.TargetProcessors != "" {
+		checkBatches(dir, "", c.OutputBatches, outputBatches, reportFailure)
+	}
+
+	if c.TargetOutput != "" {
+		var captured map[string][]*message.Batch
+		if captured, err = c.executeOutput(provider, provideOpts, outputBatches, reportFailure); err != nil {
+			return nil, err
+		}
+
+		labels := make([]string, 0, len(c.CapturedOutputs))
+		for label := range c.CapturedOutputs {
+			labels = append(labels, label)
+		}
+		sort.Strings(labels)
+		for _, label := range labels {
+			checkBatches(dir, fmt.Sprintf("output %v ", label), c.CapturedOutputs[label], captured[label], reportFailure)
+		}
+	}
+
+	counters := stats.GetCounters()
+	for _, m := range c.Metrics {
+		if v := m.valueFrom(counters); v != m.Value {
+			reportFailure(fmt.Sprintf("metric %v: expected %v, got %v", m, m.Value, v))
+		}
+	}
+	return
+}
+
+// executeOutput writes batches to the targeted output and returns the batches
+// received by each captured output.
+func (c *Case) executeOutput(provider ProcProvider, opts []ProvideOptFunc, batches []*message.Batch, reportFailure func(string)) (map[string][]*message.Batch, error) {
+	labels := make([]string, 0, len(c.CapturedOutputs))
+	for label := range c.CapturedOutputs {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+
+	out, pipes, err := provider.ProvideOutput(c.TargetOutput, c.Environment, c.Mocks, labels, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialise output '%v': %v", c.TargetOutput, err)
+	}
+
+	var capturedMut sync.Mutex
+	captured := map[string][]*message.Batch{}
+
+	var wg sync.WaitGroup
+	for label, pipe := range pipes {
+		wg.Add(1)
+		go func(label string, pipe <-chan message.Transaction) {
+			defer wg.Done()
+			for t := range pipe {
+				capturedMut.Lock()
+				captured[label] = append(captured[label], t.Payload.Copy())
+				capturedMut.Unlock()
+				_ = t.Ack(context.Background(), nil)
+			}
+		}(label, pipe)
 	}
 
-	for i, v := range outputBatches {
-		if len(c.OutputBatches) <= i {
-			reportFailure(fmt.Sprintf("unexpected batch: %s", message.GetAllBytes(v)))
+	tranChan := make(chan message.Transaction)
+	if err := out.Consume(tranChan); err != nil {
+		out.CloseAsync()
+		return nil, fmt.Errorf("failed to initialise output '%v': %v", c.TargetOutput, err)
+	}
+
+	for i, b := range batches {
+		resChan := make(chan error)
+		select {
+		case tranChan <- message.NewTransaction(b, resChan):
+		case <-time.After(outputTimeout):
+			reportFailure(fmt.Sprintf("output timed out waiting to accept batch %v", i))
 			continue
 		}
-		expectedBatch := c.OutputBatches[i]
+		select {
+		case res := <-resChan:
+			if res != nil {
+				reportFailure(fmt.Sprintf("output resulted in error for batch %v: %v", i, res))
+			}
+		case <-time.After(outputTimeout):
+			reportFailure(fmt.Sprintf("output timed out waiting to acknowledge batch %v", i))
+		}
+	}
+
+	close(tranChan)
+	out.CloseAsync()
+	if err := out.WaitForClose(outputTimeout); err != nil {
+		reportFailure(fmt.Sprintf("output failed to close: %v", err))
+	}
+	wg.Wait()
+	return captured, nil
+}
+
+// checkBatches compares batches against the conditions expected of them,
+// where the prefix identifies the batches within failures.
+func checkBatches(dir, prefix string, expected [][]ConditionsMap, actual []*message.Batch, reportFailure func(string)) {
+	if lExp, lAct := len(expected), len(actual); lAct < lExp {
+		reportFailure(fmt.Sprintf("wrong %vbatch count, expected %v, got %v", prefix, lExp, lAct))
+	}
+
+	for i, v := range actual {
+		if len(expected) <= i {
+			reportFailure(fmt.Sprintf("unexpected %vbatch: %s", prefix, message.GetAllBytes(v)))
+			continue
+		}
+		expectedBatch := expected[i]
 		if lExp, lAct := len(expectedBatch), v.Len(); lExp != lAct {
-			reportFailure(fmt.Sprintf("mismatch of output batch %v message counts, expected %v, got %v", i, lExp, lAct))
+			batchName := "output batch"
+			if prefix != "" {
+				batchName = prefix + "batch"
+			}
+			reportFailure(fmt.Sprintf("mismatch of %v %v message counts, expected %v, got %v", batchName, i, lExp, lAct))
 		}
 		_ = v.Iter(func(i2 int, part *message.Part) error {
 			if len(expectedBatch) <= i2 {
-				reportFailure(fmt.Sprintf("unexpected message from batch %v: %s", i, part.Get()))
+				reportFailure(fmt.Sprintf("unexpected message from %vbatch %v: %s", prefix, i, part.Get()))
 				return nil
 			}
 			condErrs := expectedBatch[i2].CheckAll(dir, part)
 			for _, condErr := range condErrs {
-				reportFailure(fmt.Sprintf("batch %v message %v: %v", i, i2, condErr))
+				reportFailure(fmt.Sprintf("%vbatch %v message %v: %v", prefix, i, i2, condErr))
 			}
 			if procErr := part.ErrorGet(); procErr != nil && len(condErrs) > 0 {
-				reportFailure(fmt.Sprintf("batch %v message %v: %v", i, i2, red(procErr)))
+				reportFailure(fmt.Sprintf("%vbatch %v message %v: %v", prefix, i, i2, red(procErr)))
 			}
 			return nil
 		})
 	}
-	return
 }
@@ -13,15 +13,17 @@ import (
 	yaml "gopkg.in/yaml.v3"
 
 	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+	ioutput "github.com/benthosdev/benthos/v4/internal/component/output"
 	iprocessor "github.com/benthosdev/benthos/v4/internal/component/processor"
 	"github.com/benthosdev/benthos/v4/internal/log"
 	"github.com/benthosdev/benthos/v4/internal/manager/mock"
+	"github.com/benthosdev/benthos/v4/internal/message"
 	"github.com/benthosdev/benthos/v4/internal/old/processor"
 )
 
 type mockProvider map[string][]iprocessor.V1
 
-func (m mockProvider) Provide(ptr string, env map[string]string, mocks map[string]yaml.Node) ([]iprocessor.V1, error) {
+func (m mockProvider) Provide(ptr string, env map[string]string, mocks map[string]yaml.Node, opts ...ProvideOptFunc) ([]iprocessor.V1, error) {
 	if procs, ok := m[ptr]; ok {
 		return procs, nil
 	}
@@ -35,6 +37,10 @@ func (m mockProvider) ProvideBloblang(name string) ([]iprocessor.V1, error) {
 	return nil, errors.New("mapping not found")
 }
 
+func (m mockProvider) ProvideOutput(ptr string, env map[string]string, mocks map[string]yaml.Node, captures []string, opts ...ProvideOptFunc) (ioutput.Streamed, map[string]<-chan message.Transaction, error) {
+	return nil, nil, errors.New("outputs not supported")
+}
+
 func TestCase(t *testing.T) {
 	color.NoColor = true
 
@@ -6,6 +6,9 @@ import (
 	"testing"
 
 	"github.com/fatih/color"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	yaml "gopkg.in/yaml.v3"
 
 	"github.com/benthosdev/benthos/v4/internal/cli/test"
 	"github.com/benthosdev/benthos/v4/internal/log"
@@ -163,3 +166,126 @@ pipeline:
 		t.Errorf("Mismatched fail message: %v != %v", act, exp)
 	}
 }
+
+func TestDefinitionOutputCapturesStubsAndMetrics(t *testing.T) {
+	color.NoColor = true
+
+	testDir, err := initTestFiles(t, map[string]string{
+		"config1.yaml": `
+pipeline:
+  processors:
+    - cache:
+        resource: foocache
+        operator: get
+        key: ${! content() }
+    - rate_limit:
+        resource: foolimit
+    - metric:
+        type: counter
+        name: things_seen
+        labels:
+          topic: ${! meta("topic") }
+
+output:
+  switch:
+    cases:
+      - check: this.type == "error"
+        output:
+          label: errors_out
+          http_client:
+            url: http://example.com/errors
+      - output:
+          label: everything_else
+          processors:
+            - bloblang: root = content().uppercase()
+          http_client:
+            url: http://example.com/everything
+
+cache_resources:
+  - label: foocache
+    redis:
+      url: redis://localhost:6379
+
+rate_limit_resources:
+  - label: foolimit
+    local:
+      count: 1
+      interval: 1h
+`,
+	})
+	require.NoError(t, err)
+
+	var def test.Definition
+	require.NoError(t, yaml.Unmarshal([]byte(`
+tests:
+  - name: captures outputs
+    target_processors: /pipeline/processors
+    target_output: /output
+    resource_stubs:
+      caches:
+        foocache:
+          a: '{"type":"error"}'
+          b: '{"type":"info"}'
+      rate_limits: [ foolimit ]
+    input_batch:
+      - content: a
+        metadata: { topic: foo }
+      - content: b
+        metadata: { topic: foo }
+    output_batches:
+      - - content_equals: '{"type":"error"}'
+        - content_equals: '{"type":"info"}'
+    captured_outputs:
+      errors_out:
+        - - content_equals: '{"type":"error"}'
+      everything_else:
+        - - content_equals: '{"TYPE":"INFO"}'
+    metrics:
+      - name: things_seen
+        labels: { topic: foo }
+        value: 2
+
+  - name: output without processors
+    target_output: everything_else
+    input_batch:
+      - content: hello world
+    captured_outputs:
+      everything_else:
+        - - content_equals: HELLO WORLD
+    metrics:
+      - name: things_seen
+        value: 1
+`), &def))
+
+	failures, err := def.Execute(filepath.Join(testDir, "config1.yaml"), nil, log.Noop())
+	require.NoError(t, err)
+
+	require.Len(t, failures, 1)
+	assert.Equal(t, "output without processors [line 30]: metric things_seen: expected 1, got 0", failures[0].String())
+}
+
+func TestDefinitionOutputBatchesWithoutProcessors(t *testing.T) {
+	testDir, err := initTestFiles(t, map[string]string{
+		"config1.yaml": `
+output:
+  label: foo_output
+  drop: {}
+`,
+	})
+	require.NoError(t, err)
+
+	var def test.Definition
+	require.NoError(t, yaml.Unmarshal([]byte(`
+tests:
+  - name: output batches are ignored
+    target_output: foo_output
+    input_batch:
+      - content: hello world
+    output_batches:
+      - - content_equals: hello world
+`), &def))
+
+	_, err = def.Execute(filepath.Join(testDir, "config1.yaml"), nil, log.Noop())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "output_batches cannot be checked when a target_output is set without target_processors")
+}
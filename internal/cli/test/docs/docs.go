@@ -65,6 +65,28 @@ It is also possible to target processors in a separate file by prefixing the tar
 				},
 			},
 		).Map().Optional(),
+		docs.FieldString(
+			"target_output",
+			"A label or [JSON Pointer][json-pointer] that identifies an output to write the batches of the test to. When set, the input batch is written directly to the output unless `target_processors` is also set explicitly. Batches that reach outputs are checked with `captured_outputs`, and `output_batches` can only be specified when `target_processors` is also set.",
+			"/output",
+			"foo_output",
+		).HasDefault("").AtVersion("4.1.0"),
+		docs.FieldObject(
+			"resource_stubs", "Resources of the config to replace with local stubs for the duration of the test.",
+		).Optional().AtVersion("4.1.0").WithChildren(
+			docs.FieldAnything(
+				"caches", "A map of cache resource labels to key/values, where each cache is replaced with a `memory` cache seeded with the key/values.",
+				map[string]interface{}{
+					"foocache": map[string]interface{}{
+						"foo": "bar",
+					},
+				},
+			).Map().Optional(),
+			docs.FieldString(
+				"rate_limits", "A list of rate limit resource labels, where each rate limit is replaced with one that never blocks.",
+				[]string{"foolimit"},
+			).Array().Optional(),
+		),
 		docs.FieldObject(
 			"input_batch", "",
 		).Array().Optional().WithChildren(
@@ -121,5 +143,23 @@ It is also possible to target processors in a separate file by prefixing the tar
 				map[string]interface{}{"key": "value"},
 			).Optional(),
 		),
+		docs.FieldAnything(
+			"captured_outputs",
+			"A map of output labels to the batches expected to reach them, where each output must be nested within the `target_output` and is replaced for the duration of the test. The batches are checked with the same conditions as `output_batches`.",
+			map[string]interface{}{
+				"errors_out": []interface{}{
+					[]interface{}{
+						map[string]interface{}{"content_equals": "foo"},
+					},
+				},
+			},
+		).Map().Optional().AtVersion("4.1.0"),
+		docs.FieldObject(
+			"metrics", "A list of conditions on the metrics emitted during the test, where the values of all counters and gauges with a matching name and labels are summed.",
+		).Array().Optional().AtVersion("4.1.0").WithChildren(
+			docs.FieldString("name", "The name of the metric."),
+			docs.FieldString("labels", "An optional map of labels that the metric must have.").Map().Optional(),
+			docs.FieldInt("value", "The expected value of the metric."),
+		),
 	)
 }
@@ -2,6 +2,7 @@ package test
 
 import (
 	"fmt"
+	"math"
 	"net/url"
 	"os"
 	"path/filepath"
@@ -11,19 +12,25 @@ import (
 	yaml "gopkg.in/yaml.v3"
 
 	"github.com/benthosdev/benthos/v4/internal/bloblang/parser"
+	"github.com/benthosdev/benthos/v4/internal/component/cache"
 	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+	ioutput "github.com/benthosdev/benthos/v4/internal/component/output"
 	iprocessor "github.com/benthosdev/benthos/v4/internal/component/processor"
+	"github.com/benthosdev/benthos/v4/internal/component/ratelimit"
 	"github.com/benthosdev/benthos/v4/internal/config"
 	"github.com/benthosdev/benthos/v4/internal/docs"
 	"github.com/benthosdev/benthos/v4/internal/log"
 	"github.com/benthosdev/benthos/v4/internal/manager"
 	"github.com/benthosdev/benthos/v4/internal/manager/mock"
+	"github.com/benthosdev/benthos/v4/internal/message"
+	"github.com/benthosdev/benthos/v4/internal/old/output"
 	"github.com/benthosdev/benthos/v4/internal/old/processor"
 )
 
 type cachedConfig struct {
-	mgr   manager.ResourceConfig
-	procs []processor.Config
+	mgr    manager.ResourceConfig
+	procs  []processor.Config
+	output output.Config
 }
 
 // ProcessorsProvider consumes a Benthos config and, given a JSON Pointer,
@@ -65,16 +72,91 @@ func OptProcessorsProviderSetLogger(logger log.Modular) func(*ProcessorsProvider
 
 //------------------------------------------------------------------------------
 
+// ResourceStubs describes resources of a config that should be replaced with
+// local equivalents for the duration of a test.
+type ResourceStubs struct {
+	// Caches is a map of cache resource labels to the key/values that the
+	// memory cache replacing each should be seeded with.
+	Caches map[string]map[string]string `yaml:"caches"`
+
+	// RateLimits is a list of rate limit resource labels that should be
+	// replaced with a rate limit that never blocks.
+	RateLimits []string `yaml:"rate_limits"`
+}
+
+type provideConfig struct {
+	stubs ResourceStubs
+	stats metrics.Type
+}
+
+// ProvideOptFunc customises how the components of a test are constructed.
+type ProvideOptFunc func(*provideConfig)
+
+// OptProvideResourceStubs replaces resources of the config with stubs.
+func OptProvideResourceStubs(stubs ResourceStubs) ProvideOptFunc {
+	return func(c *provideConfig) {
+		c.stubs = stubs
+	}
+}
+
+// OptProvideMetrics sets the metrics type used by the constructed components
+// and their resources.
+func OptProvideMetrics(stats metrics.Type) ProvideOptFunc {
+	return func(c *provideConfig) {
+		c.stats = stats
+	}
+}
+
+func newProvideConfig(opts []ProvideOptFunc) provideConfig {
+	conf := provideConfig{
+		stats: metrics.Noop(),
+	}
+	for _, opt := range opts {
+		opt(&conf)
+	}
+	return conf
+}
+
 // Provide attempts to extract an array of processors from a Benthos config.
 // Supports injected mocked components in the parsed config. If the JSON Pointer
 // targets a single processor config it will be constructed and returned as an
 // array of one element.
-func (p *ProcessorsProvider) Provide(jsonPtr string, environment map[string]string, mocks map[string]yaml.Node) ([]iprocessor.V1, error) {
-	confs, err := p.getConfs(jsonPtr, environment, mocks)
+func (p *ProcessorsProvider) Provide(jsonPtr string, environment map[string]string, mocks map[string]yaml.Node, opts ...ProvideOptFunc) ([]iprocessor.V1, error) {
+	confs, err := p.getConfs(jsonPtr, environment, mocks, nil, false)
 	if err != nil {
 		return nil, err
 	}
-	return p.initProcs(confs)
+	return p.initProcs(confs, newProvideConfig(opts))
+}
+
+// ProvideOutput attempts to extract an output from a Benthos config and
+// construct it. Supports injected mocked components in the parsed config, and
+// each output with a label listed in captures is replaced with one that sends
+// transactions to a pipe that is returned in a map keyed by the label.
+func (p *ProcessorsProvider) ProvideOutput(jsonPtr string, environment map[string]string, mocks map[string]yaml.Node, captures []string, opts ...ProvideOptFunc) (ioutput.Streamed, map[string]<-chan message.Transaction, error) {
+	confs, err := p.getConfs(jsonPtr, environment, mocks, captures, true)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	mgr, err := p.initManager(confs, newProvideConfig(opts))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	out, err := mgr.NewOutput(confs.output)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to initialise output: %v", err)
+	}
+
+	pipes := map[string]<-chan message.Transaction{}
+	for _, label := range captures {
+		if pipes[label], err = mgr.GetPipe(capturePipeName(label)); err != nil {
+			out.CloseAsync()
+			return nil, nil, fmt.Errorf("output '%v' could not be captured: %v", label, err)
+		}
+	}
+	return out, pipes, nil
 }
 
 // ProvideBloblang attempts to parse a Bloblang mapping and returns a processor
@@ -102,24 +184,139 @@ func (p *ProcessorsProvider) ProvideBloblang(pathStr string) ([]iprocessor.V1, e
 
 //------------------------------------------------------------------------------
 
-func (p *ProcessorsProvider) initProcs(confs cachedConfig) ([]iprocessor.V1, error) {
-	mgr, err := manager.NewV2(confs.mgr, mock.NewManager(), p.logger, metrics.Noop())
+func stubNodeFor(label, typeStr string, conf interface{}) (*yaml.Node, error) {
+	var node yaml.Node
+	if err := node.Encode(map[string]interface{}{
+		"label": label,
+		typeStr: conf,
+	}); err != nil {
+		return nil, err
+	}
+	return &node, nil
+}
+
+func applyResourceStubs(mgrConf manager.ResourceConfig, stubs ResourceStubs) (manager.ResourceConfig, error) {
+	if len(stubs.Caches) > 0 {
+		caches := append([]cache.Config(nil), mgrConf.ResourceCaches...)
+		for label, values := range stubs.Caches {
+			if values == nil {
+				values = map[string]string{}
+			}
+			node, err := stubNodeFor(label, "memory", map[string]interface{}{
+				"init_values": values,
+			})
+			if err != nil {
+				return mgrConf, err
+			}
+
+			found := false
+			for i, c := range caches {
+				if c.Label != label {
+					continue
+				}
+				if err := node.Decode(&caches[i]); err != nil {
+					return mgrConf, fmt.Errorf("failed to stub cache resource '%v': %w", label, err)
+				}
+				found = true
+			}
+			if !found {
+				return mgrConf, fmt.Errorf("stub for cache resource '%v' could not be applied as the resource was not found", label)
+			}
+		}
+		mgrConf.ResourceCaches = caches
+	}
+
+	if len(stubs.RateLimits) > 0 {
+		rateLimits := append([]ratelimit.Config(nil), mgrConf.ResourceRateLimits...)
+		for _, label := range stubs.RateLimits {
+			node, err := stubNodeFor(label, "local", map[string]interface{}{
+				"count":    math.MaxInt32,
+				"interval": "1ns",
+			})
+			if err != nil {
+				return mgrConf, err
+			}
+
+			found := false
+			for i, r := range rateLimits {
+				if r.Label != label {
+					continue
+				}
+				if err := node.Decode(&rateLimits[i]); err != nil {
+					return mgrConf, fmt.Errorf("failed to stub rate limit resource '%v': %w", label, err)
+				}
+				found = true
+			}
+			if !found {
+				return mgrConf, fmt.Errorf("stub for rate limit resource '%v' could not be applied as the resource was not found", label)
+			}
+		}
+		mgrConf.ResourceRateLimits = rateLimits
+	}
+	return mgrConf, nil
+}
+
+func (p *ProcessorsProvider) initManager(confs cachedConfig, conf provideConfig) (*manager.Type, error) {
+	mgrConf, err := applyResourceStubs(confs.mgr, conf.stubs)
+	if err != nil {
+		return nil, err
+	}
+
+	mgr, err := manager.NewV2(mgrConf, mock.NewManager(), p.logger, metrics.NewNamespaced(conf.stats))
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialise resources: %v", err)
 	}
+	return mgr, nil
+}
+
+func (p *ProcessorsProvider) initProcs(confs cachedConfig, conf provideConfig) ([]iprocessor.V1, error) {
+	mgr, err := p.initManager(confs, conf)
+	if err != nil {
+		return nil, err
+	}
 
 	procs := make([]iprocessor.V1, len(confs.procs))
 	for i, conf := range confs.procs {
-		if procs[i], err = processor.New(conf, mgr, p.logger, metrics.Noop()); err != nil {
+		if procs[i], err = processor.New(conf, mgr, p.logger, mgr.Metrics()); err != nil {
 			return nil, fmt.Errorf("failed to initialise processor index '%v': %v", i, err)
 		}
 	}
 	return procs, nil
 }
 
-func confTargetID(jsonPtr string, environment map[string]string, mocks map[string]yaml.Node) string {
+func confTargetID(jsonPtr string, environment map[string]string, mocks map[string]yaml.Node, captures []string, isOutput bool) string {
 	mocksBytes, _ := yaml.Marshal(mocks)
-	return fmt.Sprintf("%v-%v-%s", jsonPtr, environment, mocksBytes)
+	return fmt.Sprintf("%v-%v-%s-%v-%v", jsonPtr, environment, mocksBytes, captures, isOutput)
+}
+
+func capturePipeName(label string) string {
+	return "benthos_test_capture_" + label
+}
+
+// captureOutputNode replaces the type specific fields of an output config with
+// an inproc output, retaining the label and processors of the output.
+func captureOutputNode(label string, node *yaml.Node) error {
+	if node.Kind != yaml.MappingNode {
+		return fmt.Errorf("output '%v' is not an object", label)
+	}
+
+	var newContent []*yaml.Node
+	for i := 0; i < len(node.Content)-1; i += 2 {
+		switch node.Content[i].Value {
+		case "label", "processors":
+			newContent = append(newContent, node.Content[i], node.Content[i+1])
+		}
+	}
+
+	var keyNode, valueNode yaml.Node
+	if err := keyNode.Encode("inproc"); err != nil {
+		return err
+	}
+	if err := valueNode.Encode(capturePipeName(label)); err != nil {
+		return err
+	}
+	node.Content = append(newContent, &keyNode, &valueNode)
+	return nil
 }
 
 func setEnvironment(vars map[string]string) func() {
@@ -171,8 +368,8 @@ func resolveProcessorsPointer(targetFile, jsonPtr string) (filePath, procPath st
 	return
 }
 
-func (p *ProcessorsProvider) getConfs(jsonPtr string, environment map[string]string, mocks map[string]yaml.Node) (cachedConfig, error) {
-	cacheKey := confTargetID(jsonPtr, environment, mocks)
+func (p *ProcessorsProvider) getConfs(jsonPtr string, environment map[string]string, mocks map[string]yaml.Node, captures []string, isOutput bool) (cachedConfig, error) {
+	cacheKey := confTargetID(jsonPtr, environment, mocks, captures, isOutput)
 
 	confs, exists := p.cachedConfigs[cacheKey]
 	if exists {
@@ -265,6 +462,23 @@ func (p *ProcessorsProvider) getConfs(jsonPtr string, environment map[string]str
 		}
 	}
 
+	for _, label := range captures {
+		if len(labelsToPaths) == 0 {
+			confSpec.YAMLLabelsToPaths(docs.DeprecatedProvider, root, labelsToPaths, nil)
+		}
+		capturePath, exists := labelsToPaths[label]
+		if !exists {
+			return confs, fmt.Errorf("capture for output '%v' could not be applied as the label was not found in the test target file", label)
+		}
+		captureNode, err := docs.GetYAMLPath(root, capturePath...)
+		if err != nil {
+			return confs, fmt.Errorf("failed to resolve captured output '%v': %w", label, err)
+		}
+		if err = captureOutputNode(label, captureNode); err != nil {
+			return confs, err
+		}
+	}
+
 	var pathSlice []string
 	if strings.HasPrefix(procPath, "/") {
 		if pathSlice, err = gabs.JSONPointerToSlice(procPath); err != nil {
@@ -283,7 +497,12 @@ func (p *ProcessorsProvider) getConfs(jsonPtr string, environment map[string]str
 		return confs, fmt.Errorf("failed to resolve case processors from '%v': %v", targetPath, err)
 	}
 
-	if root.Kind == yaml.SequenceNode {
+	if isOutput {
+		confs.output = output.NewConfig()
+		if err = root.Decode(&confs.output); err != nil {
+			return confs, fmt.Errorf("failed to resolve case output from '%v': %v", targetPath, err)
+		}
+	} else if root.Kind == yaml.SequenceNode {
 		if err = root.Decode(&confs.procs); err != nil {
 			return confs, fmt.Errorf("failed to resolve case processors from '%v': %v", targetPath, err)
 		}
@@ -390,3 +390,44 @@ pipeline:
 	_, err = provider.Provide("/pipeline/processors", nil, nil)
 	require.EqualError(t, err, "failed to initialise resources: cache resource label 'barcache' collides with a previously defined resource")
 }
+
+func TestProcessorsProviderResourceStubs(t *testing.T) {
+	files := map[string]string{
+		"config1.yaml": `
+pipeline:
+  processors:
+    - cache:
+        resource: foocache
+        operator: get
+        key: ${! content() }
+
+cache_resources:
+  - label: foocache
+    redis:
+      url: redis://localhost:6379
+`,
+	}
+
+	testDir, err := initTestFiles(t, files)
+	require.NoError(t, err)
+
+	provider := test.NewProcessorsProvider(filepath.Join(testDir, "config1.yaml"))
+
+	procs, err := provider.Provide("/pipeline/processors", nil, nil, test.OptProvideResourceStubs(test.ResourceStubs{
+		Caches: map[string]map[string]string{
+			"foocache": {"foo": "seeded value"},
+		},
+	}))
+	require.NoError(t, err)
+	require.Len(t, procs, 1)
+
+	msgs, res := processor.ExecuteAll(procs, message.QuickBatch([][]byte{[]byte("foo")}))
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+	assert.Equal(t, "seeded value", string(msgs[0].Get(0).Get()))
+
+	_, err = provider.Provide("/pipeline/processors", nil, nil, test.OptProvideResourceStubs(test.ResourceStubs{
+		RateLimits: []string{"nope"},
+	}))
+	require.EqualError(t, err, "stub for rate limit resource 'nope' could not be applied as the resource was not found")
+}
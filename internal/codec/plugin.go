@@ -0,0 +1,148 @@
+package codec
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// ReaderPluginConstructor creates a reader constructor for a codec plugin from
+// the arguments of the codec, which are the characters following the first
+// colon of the codec name. For example, the codec `foo:bar` provides the
+// arguments `bar` to the plugin `foo`.
+type ReaderPluginConstructor func(args string, conf ReaderConfig) (ReaderConstructor, error)
+
+// WriterPluginConstructor creates a writer constructor for a codec plugin from
+// the arguments of the codec, which are the characters following the first
+// colon of the codec name.
+type WriterPluginConstructor func(args string) (WriterConstructor, WriterConfig, error)
+
+var (
+	pluginsMut    sync.RWMutex
+	readerPlugins = map[string]ReaderPluginConstructor{}
+	writerPlugins = map[string]WriterPluginConstructor{}
+
+	fileWriterPlugins = map[string]WriterPluginConstructor{}
+)
+
+// RegisterReaderPlugin adds a reader codec that can be referenced by name from
+// the codec field of inputs. Built-in codecs take precedence over plugins of
+// the same name.
+func RegisterReaderPlugin(name string, ctor ReaderPluginConstructor) error {
+	if name == "" || strings.ContainsAny(name, ":/") {
+		return fmt.Errorf("codec name '%v' must be non-empty and must not contain ':' or '/'", name)
+	}
+
+	pluginsMut.Lock()
+	defer pluginsMut.Unlock()
+
+	if _, exists := readerPlugins[name]; exists {
+		return fmt.Errorf("reader codec '%v' is already registered", name)
+	}
+	readerPlugins[name] = ctor
+	return nil
+}
+
+// RegisterWriterPlugin adds a writer codec that can be referenced by name from
+// the codec field of outputs. Built-in codecs take precedence over plugins of
+// the same name.
+func RegisterWriterPlugin(name string, ctor WriterPluginConstructor) error {
+	if name == "" || strings.ContainsAny(name, ":/") {
+		return fmt.Errorf("codec name '%v' must be non-empty and must not contain ':' or '/'", name)
+	}
+
+	pluginsMut.Lock()
+	defer pluginsMut.Unlock()
+
+	if _, exists := writerPlugins[name]; exists {
+		return fmt.Errorf("writer codec '%v' is already registered", name)
+	}
+	writerPlugins[name] = ctor
+	return nil
+}
+
+// RegisterFileWriterPlugin adds a writer codec that can only be referenced
+// from the codec field of outputs that write files directly, which allows
+// codecs that need to finalise a whole file to be implemented outside of this
+// package.
+func RegisterFileWriterPlugin(name string, ctor WriterPluginConstructor) error {
+	if name == "" || strings.ContainsAny(name, ":/") {
+		return fmt.Errorf("codec name '%v' must be non-empty and must not contain ':' or '/'", name)
+	}
+
+	pluginsMut.Lock()
+	defer pluginsMut.Unlock()
+
+	if _, exists := fileWriterPlugins[name]; exists {
+		return fmt.Errorf("file writer codec '%v' is already registered", name)
+	}
+	fileWriterPlugins[name] = ctor
+	return nil
+}
+
+func splitPluginCodec(codec string) (name, args string) {
+	if i := strings.Index(codec, ":"); i >= 0 {
+		return codec[:i], codec[i+1:]
+	}
+	return codec, ""
+}
+
+func pluginReader(codec string, conf ReaderConfig) (ReaderConstructor, bool, error) {
+	name, args := splitPluginCodec(codec)
+
+	pluginsMut.RLock()
+	ctor, exists := readerPlugins[name]
+	pluginsMut.RUnlock()
+	if !exists {
+		return nil, false, nil
+	}
+
+	rCtor, err := ctor(args, conf)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to init codec '%v': %w", name, err)
+	}
+	return rCtor, true, nil
+}
+
+func pluginWriter(codec string) (WriterConstructor, WriterConfig, bool, error) {
+	name, args := splitPluginCodec(codec)
+
+	pluginsMut.RLock()
+	ctor, exists := writerPlugins[name]
+	pluginsMut.RUnlock()
+	if !exists {
+		return nil, WriterConfig{}, false, nil
+	}
+
+	wCtor, wConf, err := ctor(args)
+	if err != nil {
+		return nil, WriterConfig{}, false, fmt.Errorf("failed to init codec '%v': %w", name, err)
+	}
+	return wCtor, wConf, true, nil
+}
+
+func isFileWriterPlugin(codec string) (string, bool) {
+	name, _ := splitPluginCodec(codec)
+
+	pluginsMut.RLock()
+	_, exists := fileWriterPlugins[name]
+	pluginsMut.RUnlock()
+	return name, exists
+}
+
+func pluginFileWriter(codec string) (WriterConstructor, WriterConfig, bool, error) {
+	name, args := splitPluginCodec(codec)
+
+	pluginsMut.RLock()
+	ctor, exists := fileWriterPlugins[name]
+	pluginsMut.RUnlock()
+	if !exists {
+		return nil, WriterConfig{}, false, nil
+	}
+
+	wCtor, wConf, err := ctor(args)
+	if err != nil {
+		return nil, WriterConfig{}, false, fmt.Errorf("failed to init codec '%v': %w", name, err)
+	}
+	return wCtor, wConf, true, nil
+}
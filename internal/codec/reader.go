@@ -6,15 +6,18 @@ import (
 	"bytes"
 	"compress/gzip"
 	"context"
+	"encoding/binary"
 	"encoding/csv"
 	"errors"
 	"fmt"
 	"io"
+	"mime/multipart"
 	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/benthosdev/benthos/v4/internal/docs"
 	"github.com/benthosdev/benthos/v4/internal/message"
@@ -31,10 +34,13 @@ var ReaderDocs = docs.FieldString(
 	"csv:x", "Consume structured rows as values separated by a custom delimiter, the first row must be a header row. The custom delimiter must be a single character, e.g. the codec `\"csv:\\t\"` would consume a tab delimited file.",
 	"delim:x", "Consume the file in segments divided by a custom delimiter.",
 	"gzip", "Decompress a gzip file, this codec should precede another codec, e.g. `gzip/all-bytes`, `gzip/tar`, `gzip/csv`, etc.",
+	"length_prefixed:x", "Consume the file in frames where each frame is preceded by its length in bytes, where x is the encoding of the length prefix: `uint32_be` or `uint32_le` for a four byte unsigned integer with big or little endian byte order respectively, or `varint` for an unsigned varint as used by protobuf.",
 	"lines", "Consume the file in segments divided by linebreaks.",
+	"multiline:x", "Consume the file in records of one or more lines, where x describes how lines are grouped: `start=<regex>` begins a new record for each line matching the regular expression, whereas `continue=<regex>` appends each line matching the regular expression to the previous record. The pattern can be preceded by the options `max_lines=<n>,`, which ends a record once it reaches a number of lines, and `timeout=<duration>,`, which ends a record when no further lines arrive within a period. For example, `multiline:max_lines=100,timeout=1s,start=^\\d{4}-\\d{2}-\\d{2}` would consume log records that begin with a date.",
 	"multipart", "Consumes the output of another codec and batches messages together. A batch ends when an empty message is consumed. For example, the codec `lines/multipart` could be used to consume multipart messages where an empty line indicates the end of each batch.",
 	"regex:(?m)^\\d\\d:\\d\\d:\\d\\d", "Consume the file in segments divided by regular expression.",
-	"tar", "Parse the file as a tar archive, and consume each file of the archive as a message.",
+	"mime_multipart", "Parse the file as a MIME multipart body and consume each part as a message. The boundary is detected from the first line of the file, or can be specified explicitly with the codec `mime_multipart:x`, where x is the boundary. The metadata fields `mime_part_name`, `mime_part_filename`, `mime_part_content_type` and `mime_part_size` are added to each message.",
+	"tar", "Parse the file as a tar archive, and consume each file of the archive as a message. The metadata fields `tar_path`, `tar_size` and `tar_mod_time_unix` are added to each message.",
 ).LinterFunc(nil) // Disable default option linter as it doesn't include foo:bar formats.
 
 //------------------------------------------------------------------------------
@@ -218,6 +224,19 @@ func partReader(codec string, conf ReaderConfig) (ReaderConstructor, bool, error
 		}, true, nil
 	case "tar":
 		return newTarReader, true, nil
+	case "mime_multipart":
+		return func(path string, r io.ReadCloser, fn ReaderAckFn) (Reader, error) {
+			return newMIMEMultipartReader(r, "", fn)
+		}, true, nil
+	}
+	if strings.HasPrefix(codec, "mime_multipart:") {
+		boundary := strings.TrimPrefix(codec, "mime_multipart:")
+		if boundary == "" {
+			return nil, false, errors.New("mime_multipart codec requires a non-empty boundary")
+		}
+		return func(path string, r io.ReadCloser, fn ReaderAckFn) (Reader, error) {
+			return newMIMEMultipartReader(r, boundary, fn)
+		}, true, nil
 	}
 	if strings.HasPrefix(codec, "delim:") {
 		by := strings.TrimPrefix(codec, "delim:")
@@ -251,6 +270,24 @@ func partReader(codec string, conf ReaderConfig) (ReaderConstructor, bool, error
 			return newChunkerReader(conf, r, chunkSize, fn)
 		}, true, nil
 	}
+	if strings.HasPrefix(codec, "length_prefixed:") {
+		prefix, err := parseLengthPrefix(strings.TrimPrefix(codec, "length_prefixed:"))
+		if err != nil {
+			return nil, false, err
+		}
+		return func(path string, r io.ReadCloser, fn ReaderAckFn) (Reader, error) {
+			return newLengthPrefixedReader(r, prefix, fn)
+		}, true, nil
+	}
+	if strings.HasPrefix(codec, "multiline:") {
+		mConf, err := ParseMultilineConfig(strings.TrimPrefix(codec, "multiline:"))
+		if err != nil {
+			return nil, false, err
+		}
+		return func(path string, r io.ReadCloser, fn ReaderAckFn) (Reader, error) {
+			return newMultilineReader(conf, mConf, r, fn)
+		}, true, nil
+	}
 	if strings.HasPrefix(codec, "regex:") {
 		by := strings.TrimPrefix(codec, "regex:")
 		if by == "" {
@@ -260,7 +297,7 @@ func partReader(codec string, conf ReaderConfig) (ReaderConstructor, bool, error
 			return newRexExpSplitReader(conf, r, by, fn)
 		}, true, nil
 	}
-	return nil, false, nil
+	return pluginReader(codec, conf)
 }
 
 func convertDeprecatedCodec(codec string) string {
@@ -720,7 +757,7 @@ func (a *tarReader) ack(ctx context.Context, err error) error {
 }
 
 func (a *tarReader) Next(ctx context.Context) ([]*message.Part, ReaderAckFn, error) {
-	_, err := a.buf.Next()
+	hdr, err := a.buf.Next()
 
 	a.mut.Lock()
 	defer a.mut.Unlock()
@@ -732,7 +769,12 @@ func (a *tarReader) Next(ctx context.Context) ([]*message.Part, ReaderAckFn, err
 			return nil, nil, err
 		}
 		a.pending++
-		return []*message.Part{message.NewPart(fileBuf.Bytes())}, a.ack, nil
+
+		part := message.NewPart(fileBuf.Bytes())
+		part.MetaSet("tar_path", hdr.Name)
+		part.MetaSet("tar_size", strconv.FormatInt(hdr.Size, 10))
+		part.MetaSet("tar_mod_time_unix", strconv.FormatInt(hdr.ModTime.Unix(), 10))
+		return []*message.Part{part}, a.ack, nil
 	}
 
 	if err == io.EOF {
@@ -758,6 +800,490 @@ func (a *tarReader) Close(ctx context.Context) error {
 
 //------------------------------------------------------------------------------
 
+type mimeMultipartReader struct {
+	buf       *bufio.Reader
+	mr        *multipart.Reader
+	boundary  string
+	r         io.ReadCloser
+	sourceAck ReaderAckFn
+
+	mut      sync.Mutex
+	finished bool
+	pending  int32
+}
+
+func newMIMEMultipartReader(r io.ReadCloser, boundary string, ackFn ReaderAckFn) (Reader, error) {
+	return &mimeMultipartReader{
+		buf:       bufio.NewReader(r),
+		boundary:  boundary,
+		r:         r,
+		sourceAck: ackOnce(ackFn),
+	}, nil
+}
+
+func (a *mimeMultipartReader) ack(ctx context.Context, err error) error {
+	a.mut.Lock()
+	a.pending--
+	doAck := a.pending == 0 && a.finished
+	a.mut.Unlock()
+
+	if err != nil {
+		return a.sourceAck(ctx, err)
+	}
+	if doAck {
+		return a.sourceAck(ctx, nil)
+	}
+	return nil
+}
+
+// detectBoundary reads the boundary from the first non-empty line of the body,
+// which is expected to be the first delimiter of the form `--boundary`, and
+// returns a reader of the remaining body including that line.
+func (a *mimeMultipartReader) detectBoundary() (string, io.Reader, error) {
+	for {
+		line, err := a.buf.ReadString('\n')
+		if err != nil && (err != io.EOF || line == "") {
+			return "", nil, err
+		}
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			continue
+		}
+		if !strings.HasPrefix(trimmed, "--") || len(trimmed) == 2 {
+			return "", nil, errors.New("failed to detect multipart boundary from first line")
+		}
+		return trimmed[2:], io.MultiReader(strings.NewReader(line), a.buf), nil
+	}
+}
+
+func (a *mimeMultipartReader) nextPart() (*message.Part, error) {
+	if a.mr == nil {
+		var body io.Reader = a.buf
+		if a.boundary == "" {
+			var err error
+			if a.boundary, body, err = a.detectBoundary(); err != nil {
+				return nil, err
+			}
+		}
+		a.mr = multipart.NewReader(body, a.boundary)
+	}
+
+	p, err := a.mr.NextRawPart()
+	if err != nil {
+		return nil, err
+	}
+
+	var partBuf bytes.Buffer
+	if _, err := partBuf.ReadFrom(p); err != nil {
+		return nil, err
+	}
+
+	part := message.NewPart(partBuf.Bytes())
+	part.MetaSet("mime_part_name", p.FormName())
+	part.MetaSet("mime_part_filename", p.FileName())
+	part.MetaSet("mime_part_content_type", p.Header.Get("Content-Type"))
+	part.MetaSet("mime_part_size", strconv.Itoa(partBuf.Len()))
+	return part, nil
+}
+
+func (a *mimeMultipartReader) Next(ctx context.Context) ([]*message.Part, ReaderAckFn, error) {
+	part, err := a.nextPart()
+
+	a.mut.Lock()
+	defer a.mut.Unlock()
+
+	if err == nil {
+		a.pending++
+		return []*message.Part{part}, a.ack, nil
+	}
+
+	if err == io.EOF {
+		a.finished = true
+	} else {
+		_ = a.sourceAck(ctx, err)
+	}
+	return nil, nil, err
+}
+
+func (a *mimeMultipartReader) Close(ctx context.Context) error {
+	a.mut.Lock()
+	defer a.mut.Unlock()
+
+	if !a.finished {
+		_ = a.sourceAck(ctx, errors.New("service shutting down"))
+	}
+	if a.pending == 0 {
+		_ = a.sourceAck(ctx, nil)
+	}
+	return a.r.Close()
+}
+
+//------------------------------------------------------------------------------
+
+// MultilineConfig describes how lines are grouped into records by the
+// multiline codec.
+type MultilineConfig struct {
+	Start    *regexp.Regexp
+	Continue *regexp.Regexp
+	MaxLines int
+	Timeout  time.Duration
+}
+
+// Continues returns whether a line belongs to the record preceding it.
+func (m MultilineConfig) Continues(line []byte) bool {
+	if m.Start != nil {
+		return !m.Start.Match(line)
+	}
+	return m.Continue.Match(line)
+}
+
+// ParseMultilineConfig parses the arguments of a multiline codec, which are the
+// characters following `multiline:`.
+func ParseMultilineConfig(args string) (conf MultilineConfig, err error) {
+	for {
+		switch {
+		case strings.HasPrefix(args, "max_lines="), strings.HasPrefix(args, "timeout="):
+			i := strings.Index(args, ",")
+			if i == -1 {
+				return conf, errors.New("multiline codec requires a start or continue pattern")
+			}
+			opt := strings.SplitN(args[:i], "=", 2)
+			args = args[i+1:]
+			if opt[0] == "max_lines" {
+				if conf.MaxLines, err = strconv.Atoi(opt[1]); err != nil {
+					return conf, fmt.Errorf("invalid max_lines for multiline codec: %w", err)
+				}
+			} else if conf.Timeout, err = time.ParseDuration(opt[1]); err != nil {
+				return conf, fmt.Errorf("invalid timeout for multiline codec: %w", err)
+			}
+		case strings.HasPrefix(args, "start="):
+			if conf.Start, err = regexp.Compile(strings.TrimPrefix(args, "start=")); err != nil {
+				return conf, fmt.Errorf("invalid start pattern for multiline codec: %w", err)
+			}
+			return conf, nil
+		case strings.HasPrefix(args, "continue="):
+			if conf.Continue, err = regexp.Compile(strings.TrimPrefix(args, "continue=")); err != nil {
+				return conf, fmt.Errorf("invalid continue pattern for multiline codec: %w", err)
+			}
+			return conf, nil
+		default:
+			return conf, fmt.Errorf("multiline codec expected an option or a start or continue pattern, got: %v", args)
+		}
+	}
+}
+
+type multilineReader struct {
+	conf      MultilineConfig
+	more      chan struct{}
+	lines     chan []byte
+	awaiting  bool
+	scanDone  bool
+	scanErr   error
+	r         io.ReadCloser
+	sourceAck ReaderAckFn
+
+	record    [][]byte
+	lineCount int
+
+	mut      sync.Mutex
+	finished bool
+	pending  int32
+}
+
+func newMultilineReader(conf ReaderConfig, mConf MultilineConfig, r io.ReadCloser, ackFn ReaderAckFn) (Reader, error) {
+	scanner := bufio.NewScanner(r)
+	if conf.MaxScanTokenSize != bufio.MaxScanTokenSize {
+		scanner.Buffer([]byte{}, conf.MaxScanTokenSize)
+	}
+
+	m := &multilineReader{
+		conf:      mConf,
+		more:      make(chan struct{}),
+		lines:     make(chan []byte),
+		r:         r,
+		sourceAck: ackOnce(ackFn),
+	}
+
+	// Lines are scanned in the background on request so that a partial record
+	// can be flushed once the timeout elapses without waiting for the pending
+	// read to complete.
+	go func() {
+		defer close(m.lines)
+		for range m.more {
+			if !scanner.Scan() {
+				m.scanErr = scanner.Err()
+				return
+			}
+			bytesCopy := make([]byte, len(scanner.Bytes()))
+			copy(bytesCopy, scanner.Bytes())
+			m.lines <- bytesCopy
+		}
+	}()
+	return m, nil
+}
+
+func (a *multilineReader) ack(ctx context.Context, err error) error {
+	a.mut.Lock()
+	a.pending--
+	doAck := a.pending == 0 && a.finished
+	a.mut.Unlock()
+
+	if err != nil {
+		return a.sourceAck(ctx, err)
+	}
+	if doAck {
+		return a.sourceAck(ctx, nil)
+	}
+	return nil
+}
+
+func (a *multilineReader) flush() []*message.Part {
+	part := message.NewPart(bytes.Join(a.record, []byte("\n")))
+	a.record = nil
+	a.lineCount = 0
+	return []*message.Part{part}
+}
+
+func (a *multilineReader) nextRecord(ctx context.Context) ([]*message.Part, error) {
+	for {
+		if a.scanDone {
+			if a.scanErr != nil {
+				return nil, a.scanErr
+			}
+			return nil, io.EOF
+		}
+
+		var timeoutChan <-chan time.Time
+		if a.conf.Timeout > 0 && len(a.record) > 0 {
+			timeoutChan = time.After(a.conf.Timeout)
+		}
+		if !a.awaiting {
+			a.more <- struct{}{}
+			a.awaiting = true
+		}
+
+		select {
+		case line, open := <-a.lines:
+			a.awaiting = false
+			if !open {
+				a.scanDone = true
+				if len(a.record) > 0 && a.scanErr == nil {
+					return a.flush(), nil
+				}
+				continue
+			}
+
+			var flushed []*message.Part
+			if len(a.record) > 0 && !a.conf.Continues(line) {
+				flushed = a.flush()
+			}
+			a.record = append(a.record, line)
+			a.lineCount++
+
+			if flushed == nil && a.conf.MaxLines > 0 && a.lineCount >= a.conf.MaxLines {
+				flushed = a.flush()
+			}
+			if flushed != nil {
+				return flushed, nil
+			}
+		case <-timeoutChan:
+			return a.flush(), nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func (a *multilineReader) Next(ctx context.Context) ([]*message.Part, ReaderAckFn, error) {
+	parts, err := a.nextRecord(ctx)
+
+	a.mut.Lock()
+	defer a.mut.Unlock()
+
+	if err == nil {
+		a.pending++
+		return parts, a.ack, nil
+	}
+
+	if err == io.EOF {
+		a.finished = true
+	} else if !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded) {
+		_ = a.sourceAck(ctx, err)
+	}
+	return nil, nil, err
+}
+
+func (a *multilineReader) Close(ctx context.Context) error {
+	a.mut.Lock()
+	defer a.mut.Unlock()
+
+	if !a.finished {
+		_ = a.sourceAck(ctx, errors.New("service shutting down"))
+	}
+	if a.pending == 0 {
+		_ = a.sourceAck(ctx, nil)
+	}
+	err := a.r.Close()
+
+	// Drain any pending line so that the scanning goroutine exits.
+	if !a.scanDone {
+		a.scanDone = true
+		close(a.more)
+	}
+	go func() {
+		for range a.lines {
+		}
+	}()
+	return err
+}
+
+//------------------------------------------------------------------------------
+
+type lengthPrefix int
+
+const (
+	lengthPrefixUint32BE lengthPrefix = iota
+	lengthPrefixUint32LE
+	lengthPrefixVarint
+)
+
+func parseLengthPrefix(str string) (lengthPrefix, error) {
+	switch str {
+	case "uint32_be":
+		return lengthPrefixUint32BE, nil
+	case "uint32_le":
+		return lengthPrefixUint32LE, nil
+	case "varint":
+		return lengthPrefixVarint, nil
+	}
+	return 0, fmt.Errorf("length prefixed codec requires a prefix type of uint32_be, uint32_le or varint, got: %v", str)
+}
+
+// read a length prefix, returns io.EOF if the reader is exhausted before any
+// bytes of the prefix are read, and io.ErrUnexpectedEOF if the reader is
+// exhausted part way through the prefix.
+func (l lengthPrefix) read(r *bufio.Reader) (uint64, error) {
+	if l == lengthPrefixVarint {
+		if _, err := r.Peek(1); err != nil {
+			return 0, err
+		}
+		n, err := binary.ReadUvarint(r)
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return n, err
+	}
+
+	var prefix [4]byte
+	if _, err := io.ReadFull(r, prefix[:]); err != nil {
+		return 0, err
+	}
+	if l == lengthPrefixUint32LE {
+		return uint64(binary.LittleEndian.Uint32(prefix[:])), nil
+	}
+	return uint64(binary.BigEndian.Uint32(prefix[:])), nil
+}
+
+func (l lengthPrefix) append(b []byte, n uint64) []byte {
+	switch l {
+	case lengthPrefixVarint:
+		var prefix [binary.MaxVarintLen64]byte
+		return append(b, prefix[:binary.PutUvarint(prefix[:], n)]...)
+	case lengthPrefixUint32LE:
+		var prefix [4]byte
+		binary.LittleEndian.PutUint32(prefix[:], uint32(n))
+		return append(b, prefix[:]...)
+	}
+	var prefix [4]byte
+	binary.BigEndian.PutUint32(prefix[:], uint32(n))
+	return append(b, prefix[:]...)
+}
+
+type lengthPrefixedReader struct {
+	prefix    lengthPrefix
+	buf       *bufio.Reader
+	r         io.ReadCloser
+	sourceAck ReaderAckFn
+
+	mut      sync.Mutex
+	finished bool
+	pending  int32
+}
+
+func newLengthPrefixedReader(r io.ReadCloser, prefix lengthPrefix, ackFn ReaderAckFn) (Reader, error) {
+	return &lengthPrefixedReader{
+		prefix:    prefix,
+		buf:       bufio.NewReader(r),
+		r:         r,
+		sourceAck: ackOnce(ackFn),
+	}, nil
+}
+
+func (a *lengthPrefixedReader) ack(ctx context.Context, err error) error {
+	a.mut.Lock()
+	a.pending--
+	doAck := a.pending == 0 && a.finished
+	a.mut.Unlock()
+
+	if err != nil {
+		return a.sourceAck(ctx, err)
+	}
+	if doAck {
+		return a.sourceAck(ctx, nil)
+	}
+	return nil
+}
+
+func (a *lengthPrefixedReader) readFrame() ([]byte, error) {
+	n, err := a.prefix.read(a.buf)
+	if err != nil {
+		return nil, err
+	}
+
+	// Copying into a buffer rather than allocating the frame size up front
+	// prevents a corrupt prefix from triggering a huge allocation.
+	var frame bytes.Buffer
+	copied, err := io.CopyN(&frame, a.buf, int64(n))
+	if err == io.EOF || (err == nil && uint64(copied) < n) {
+		err = io.ErrUnexpectedEOF
+	}
+	return frame.Bytes(), err
+}
+
+func (a *lengthPrefixedReader) Next(ctx context.Context) ([]*message.Part, ReaderAckFn, error) {
+	frame, err := a.readFrame()
+
+	a.mut.Lock()
+	defer a.mut.Unlock()
+
+	if err == nil {
+		a.pending++
+		return []*message.Part{message.NewPart(frame)}, a.ack, nil
+	}
+
+	if err == io.EOF {
+		a.finished = true
+	} else {
+		_ = a.sourceAck(ctx, err)
+	}
+	return nil, nil, err
+}
+
+func (a *lengthPrefixedReader) Close(ctx context.Context) error {
+	a.mut.Lock()
+	defer a.mut.Unlock()
+
+	if !a.finished {
+		_ = a.sourceAck(ctx, errors.New("service shutting down"))
+	}
+	if a.pending == 0 {
+		_ = a.sourceAck(ctx, nil)
+	}
+	return a.r.Close()
+}
+
+//------------------------------------------------------------------------------
+
 type multipartReader struct {
 	child Reader
 }
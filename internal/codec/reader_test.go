@@ -770,3 +770,186 @@ func TestRegexpSplitReader(t *testing.T) {
 	data = []byte("")
 	testReaderSuite(t, "regex:split", "", data)
 }
+
+func TestMultilineReader(t *testing.T) {
+	data := []byte("2022-01-01 ERROR foo\n\tat bar\n\tat baz\n2022-01-01 INFO buz\n2022-01-01 ERROR qux\n\tat quz")
+	testReaderSuite(t, "multiline:start=^\\d{4}-", "", data,
+		"2022-01-01 ERROR foo\n\tat bar\n\tat baz", "2022-01-01 INFO buz", "2022-01-01 ERROR qux\n\tat quz")
+	testReaderSuite(t, "multiline:continue=^\\s", "", data,
+		"2022-01-01 ERROR foo\n\tat bar\n\tat baz", "2022-01-01 INFO buz", "2022-01-01 ERROR qux\n\tat quz")
+	testReaderSuite(t, "multiline:max_lines=2,start=^\\d{4}-", "", data,
+		"2022-01-01 ERROR foo\n\tat bar", "\tat baz", "2022-01-01 INFO buz", "2022-01-01 ERROR qux\n\tat quz")
+
+	data = []byte("")
+	testReaderSuite(t, "multiline:start=^\\d", "", data)
+
+	for _, codec := range []string{
+		"multiline:",
+		"multiline:max_lines=2",
+		"multiline:max_lines=nope,start=foo",
+		"multiline:timeout=nope,start=foo",
+		"multiline:start=(",
+		"multiline:nope=foo",
+	} {
+		_, err := GetReader(codec, NewReaderConfig())
+		assert.Error(t, err, codec)
+	}
+}
+
+func TestMultilineReaderTimeout(t *testing.T) {
+	pr, pw := io.Pipe()
+
+	ctor, err := GetReader("multiline:timeout=10ms,continue=^\\s", NewReaderConfig())
+	require.NoError(t, err)
+
+	var ackErr error
+	r, err := ctor("", pr, func(ctx context.Context, err error) error {
+		ackErr = err
+		return nil
+	})
+	require.NoError(t, err)
+
+	go func() {
+		_, _ = pw.Write([]byte("foo\n\tbar\n"))
+	}()
+
+	p, ackFn, err := r.Next(context.Background())
+	require.NoError(t, err)
+	require.Len(t, p, 1)
+	assert.Equal(t, "foo\n\tbar", string(p[0].Get()))
+	require.NoError(t, ackFn(context.Background(), nil))
+
+	go func() {
+		_, _ = pw.Write([]byte("baz\n"))
+		_ = pw.Close()
+	}()
+
+	p, ackFn, err = r.Next(context.Background())
+	require.NoError(t, err)
+	require.Len(t, p, 1)
+	assert.Equal(t, "baz", string(p[0].Get()))
+	require.NoError(t, ackFn(context.Background(), nil))
+
+	_, _, err = r.Next(context.Background())
+	assert.Equal(t, io.EOF, err)
+
+	require.NoError(t, r.Close(context.Background()))
+	assert.NoError(t, ackErr)
+}
+
+func TestLengthPrefixedReader(t *testing.T) {
+	data := []byte{0, 0, 0, 3, 'f', 'o', 'o', 0, 0, 0, 0, 0, 0, 0, 3, 'b', 'a', 'r'}
+	testReaderSuite(t, "length_prefixed:uint32_be", "", data, "foo", "", "bar")
+
+	data = []byte{3, 0, 0, 0, 'f', 'o', 'o', 3, 0, 0, 0, 'b', 'a', 'r'}
+	testReaderSuite(t, "length_prefixed:uint32_le", "", data, "foo", "bar")
+
+	data = []byte{3, 'f', 'o', 'o', 3, 'b', 'a', 'r'}
+	testReaderSuite(t, "length_prefixed:varint", "", data, "foo", "bar")
+
+	_, err := GetReader("length_prefixed:nope", NewReaderConfig())
+	require.Error(t, err)
+}
+
+func TestLengthPrefixedReaderTruncated(t *testing.T) {
+	for _, data := range [][]byte{
+		{0, 0, 0, 5, 'f', 'o', 'o'},
+		{0, 0},
+	} {
+		ctor, err := GetReader("length_prefixed:uint32_be", NewReaderConfig())
+		require.NoError(t, err)
+
+		var ackErr error
+		r, err := ctor("", noopCloser{bytes.NewReader(data), false}, func(ctx context.Context, err error) error {
+			ackErr = err
+			return nil
+		})
+		require.NoError(t, err)
+
+		_, _, err = r.Next(context.Background())
+		assert.Equal(t, io.ErrUnexpectedEOF, err)
+		assert.Equal(t, io.ErrUnexpectedEOF, ackErr)
+		require.NoError(t, r.Close(context.Background()))
+	}
+}
+
+func TestTarReaderMetadata(t *testing.T) {
+	var tarBuf bytes.Buffer
+	tw := tar.NewWriter(&tarBuf)
+	require.NoError(t, tw.WriteHeader(&tar.Header{
+		Name: "foo/bar.txt",
+		Mode: 0o600,
+		Size: 5,
+	}))
+	_, err := tw.Write([]byte("hello"))
+	require.NoError(t, err)
+	require.NoError(t, tw.Close())
+
+	ctor, err := GetReader("tar", NewReaderConfig())
+	require.NoError(t, err)
+
+	r, err := ctor("", noopCloser{bytes.NewReader(tarBuf.Bytes()), false}, func(ctx context.Context, err error) error {
+		return nil
+	})
+	require.NoError(t, err)
+
+	parts, _, err := r.Next(context.Background())
+	require.NoError(t, err)
+	require.Len(t, parts, 1)
+	assert.Equal(t, "hello", string(parts[0].Get()))
+	assert.Equal(t, "foo/bar.txt", parts[0].MetaGet("tar_path"))
+	assert.Equal(t, "5", parts[0].MetaGet("tar_size"))
+
+	require.NoError(t, r.Close(context.Background()))
+}
+
+func TestMIMEMultipartReader(t *testing.T) {
+	body := "\r\n--foobar\r\n" +
+		"Content-Disposition: form-data; name=\"first\"; filename=\"a.txt\"\r\n" +
+		"Content-Type: text/plain\r\n" +
+		"\r\n" +
+		"first document\r\n" +
+		"--foobar\r\n" +
+		"Content-Disposition: form-data; name=\"second\"\r\n" +
+		"\r\n" +
+		"second document\r\n" +
+		"--foobar--\r\n"
+
+	testReaderSuite(t, "mime_multipart", "", []byte(body), "first document", "second document")
+	testReaderSuite(t, "mime_multipart:foobar", "", []byte(body), "first document", "second document")
+
+	ctor, err := GetReader("mime_multipart", NewReaderConfig())
+	require.NoError(t, err)
+
+	r, err := ctor("", noopCloser{bytes.NewReader([]byte(body)), false}, func(ctx context.Context, err error) error {
+		return nil
+	})
+	require.NoError(t, err)
+
+	parts, _, err := r.Next(context.Background())
+	require.NoError(t, err)
+	require.Len(t, parts, 1)
+	assert.Equal(t, "first", parts[0].MetaGet("mime_part_name"))
+	assert.Equal(t, "a.txt", parts[0].MetaGet("mime_part_filename"))
+	assert.Equal(t, "text/plain", parts[0].MetaGet("mime_part_content_type"))
+	assert.Equal(t, "14", parts[0].MetaGet("mime_part_size"))
+
+	require.NoError(t, r.Close(context.Background()))
+}
+
+func TestMIMEMultipartReaderBadBoundary(t *testing.T) {
+	ctor, err := GetReader("mime_multipart", NewReaderConfig())
+	require.NoError(t, err)
+
+	r, err := ctor("", noopCloser{bytes.NewReader([]byte("not a multipart body\n")), false}, func(ctx context.Context, err error) error {
+		return nil
+	})
+	require.NoError(t, err)
+
+	_, _, err = r.Next(context.Background())
+	require.Error(t, err)
+	require.NoError(t, r.Close(context.Background()))
+
+	_, err = GetReader("mime_multipart:", NewReaderConfig())
+	require.Error(t, err)
+}
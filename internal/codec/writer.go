@@ -1,26 +1,45 @@
 package codec
 
 import (
+	"archive/tar"
 	"bytes"
 	"context"
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"io"
+	"math"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/benthosdev/benthos/v4/internal/docs"
 	"github.com/benthosdev/benthos/v4/internal/message"
 )
 
 // WriterDocs is a static field documentation for output codecs.
-var WriterDocs = docs.FieldString(
-	"codec", "The way in which the bytes of messages should be written out into the output data stream. It's possible to write lines using a custom delimiter with the `delim:x` codec, where x is the character sequence custom delimiter.", "lines", "delim:\t", "delim:foobar",
-).HasAnnotatedOptions(
-	"all-bytes", "Only applicable to file based outputs. Writes each message to a file in full, if the file already exists the old content is deleted.",
-	"append", "Append each message to the output stream without any delimiter or special encoding.",
-	"lines", "Append each message to the output stream followed by a line break.",
-	"delim:x", "Append each message to the output stream followed by a custom delimiter.",
-).LinterFunc(nil) // Disable default option linter as it doesn't include foo:bar formats.
+var WriterDocs = newWriterDocs()
+
+// FileWriterDocs is a static field documentation for output codecs that
+// includes codecs only supported by the file output.
+var FileWriterDocs = newWriterDocs(
+	"parquet:x:y", "EXPERIMENTAL: Writes each message as a row of a parquet file, where x is the compression type (`uncompressed`, `snappy`, `gzip`, `lz4` or `zstd`) and y is the path of a JSON schema file as described in the [`parquet` processor](/docs/components/processors/parquet). The file is finalised once the output moves onto a different path or shuts down.",
+)
+
+func newWriterDocs(extraOptions ...string) docs.FieldSpec {
+	options := []string{
+		"all-bytes", "Only applicable to file based outputs. Writes each message to a file in full, if the file already exists the old content is deleted.",
+		"append", "Append each message to the output stream without any delimiter or special encoding.",
+		"lines", "Append each message to the output stream followed by a line break.",
+		"tar", "Write each message as a file of a tar archive, where the file name is taken from the metadata field `tar_path` and otherwise defaults to the index of the message within the archive. The archive is finalised once the output moves onto a different path or shuts down.",
+		"delim:x", "Append each message to the output stream followed by a custom delimiter.",
+		"length_prefixed:x", "Append each message to the output stream preceded by its length in bytes, where x is the encoding of the length prefix: `uint32_be` or `uint32_le` for a four byte unsigned integer with big or little endian byte order respectively, or `varint` for an unsigned varint as used by protobuf.",
+	}
+	options = append(options, extraOptions...)
+	return docs.FieldString(
+		"codec", "The way in which the bytes of messages should be written out into the output data stream. It's possible to write lines using a custom delimiter with the `delim:x` codec, where x is the character sequence custom delimiter.", "lines", "delim:\t", "delim:foobar",
+	).HasAnnotatedOptions(options...).LinterFunc(nil) // Disable default option linter as it doesn't include foo:bar formats.
+}
 
 //------------------------------------------------------------------------------
 
@@ -54,6 +73,11 @@ func GetWriter(codec string) (WriterConstructor, WriterConfig, error) {
 		}, customDelimConfig, nil
 	case "lines":
 		return newLinesWriter, linesWriterConfig, nil
+	case "tar":
+		return newTarWriter, tarWriterConfig, nil
+	}
+	if name, ok := isFileWriterPlugin(codec); ok {
+		return nil, WriterConfig{}, fmt.Errorf("the %v codec is only supported by the file output", name)
 	}
 	if strings.HasPrefix(codec, "delim:") {
 		by := strings.TrimPrefix(codec, "delim:")
@@ -64,6 +88,18 @@ func GetWriter(codec string) (WriterConstructor, WriterConfig, error) {
 			return newCustomDelimWriter(w, by)
 		}, customDelimConfig, nil
 	}
+	if strings.HasPrefix(codec, "length_prefixed:") {
+		prefix, err := parseLengthPrefix(strings.TrimPrefix(codec, "length_prefixed:"))
+		if err != nil {
+			return nil, WriterConfig{}, err
+		}
+		return func(w io.WriteCloser) (Writer, error) {
+			return &lengthPrefixedWriter{w: w, prefix: prefix}, nil
+		}, lengthPrefixedConfig, nil
+	}
+	if ctor, conf, ok, err := pluginWriter(codec); ok || err != nil {
+		return ctor, conf, err
+	}
 	return nil, WriterConfig{}, fmt.Errorf("codec was not recognised: %v", codec)
 }
 
@@ -148,3 +184,86 @@ func (d *customDelimWriter) Write(ctx context.Context, p *message.Part) error {
 func (d *customDelimWriter) Close(ctx context.Context) error {
 	return d.w.Close()
 }
+
+//------------------------------------------------------------------------------
+
+var lengthPrefixedConfig = WriterConfig{
+	Append: true,
+}
+
+type lengthPrefixedWriter struct {
+	w      io.WriteCloser
+	prefix lengthPrefix
+}
+
+func (l *lengthPrefixedWriter) Write(ctx context.Context, p *message.Part) error {
+	partBytes := p.Get()
+	if l.prefix != lengthPrefixVarint && uint64(len(partBytes)) > math.MaxUint32 {
+		return fmt.Errorf("message size %v exceeds the maximum of a uint32 length prefix", len(partBytes))
+	}
+
+	// Write the prefix and message in a single call so that frames are never
+	// interleaved or partially written by a failed call.
+	frame := l.prefix.append(make([]byte, 0, len(partBytes)+binary.MaxVarintLen64), uint64(len(partBytes)))
+	_, err := l.w.Write(append(frame, partBytes...))
+	return err
+}
+
+func (l *lengthPrefixedWriter) Close(ctx context.Context) error {
+	return l.w.Close()
+}
+
+//------------------------------------------------------------------------------
+
+var tarWriterConfig = WriterConfig{
+	Truncate: true,
+}
+
+type tarWriter struct {
+	w     io.WriteCloser
+	tw    *tar.Writer
+	index int
+}
+
+func newTarWriter(w io.WriteCloser) (Writer, error) {
+	return &tarWriter{w: w, tw: tar.NewWriter(w)}, nil
+}
+
+func (t *tarWriter) Write(ctx context.Context, p *message.Part) error {
+	name := p.MetaGet("tar_path")
+	if name == "" {
+		name = strconv.Itoa(t.index)
+	}
+	t.index++
+
+	partBytes := p.Get()
+	if err := t.tw.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    0o644,
+		Size:    int64(len(partBytes)),
+		ModTime: time.Now(),
+	}); err != nil {
+		return err
+	}
+	_, err := t.tw.Write(partBytes)
+	return err
+}
+
+func (t *tarWriter) Close(ctx context.Context) error {
+	if err := t.tw.Close(); err != nil {
+		_ = t.w.Close()
+		return fmt.Errorf("failed to finalise tar archive: %w", err)
+	}
+	return t.w.Close()
+}
+
+//------------------------------------------------------------------------------
+
+// GetFileWriter returns a constructor that creates write codecs, including
+// codecs that are only supported when writing files directly.
+func GetFileWriter(codec string) (WriterConstructor, WriterConfig, error) {
+	if ctor, conf, ok, err := pluginFileWriter(codec); ok || err != nil {
+		return ctor, conf, err
+	}
+	return GetWriter(codec)
+}
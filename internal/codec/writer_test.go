@@ -0,0 +1,98 @@
+package codec
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+type closableBuffer struct {
+	bytes.Buffer
+}
+
+func (c *closableBuffer) Close() error {
+	return nil
+}
+
+func TestWriterLengthPrefixedRoundTrip(t *testing.T) {
+	for _, prefix := range []string{"uint32_be", "uint32_le", "varint"} {
+		prefix := prefix
+		t.Run(prefix, func(t *testing.T) {
+			ctor, conf, err := GetWriter("length_prefixed:" + prefix)
+			require.NoError(t, err)
+			assert.True(t, conf.Append)
+
+			var buf closableBuffer
+			w, err := ctor(&buf)
+			require.NoError(t, err)
+
+			ctx := context.Background()
+			input := []string{"foo", "", "hello world", string(bytes.Repeat([]byte("x"), 300))}
+			for _, s := range input {
+				require.NoError(t, w.Write(ctx, message.NewPart([]byte(s))))
+			}
+			require.NoError(t, w.Close(ctx))
+
+			testReaderSuite(t, "length_prefixed:"+prefix, "", buf.Bytes(), input...)
+		})
+	}
+}
+
+func TestWriterLengthPrefixedBigEndian(t *testing.T) {
+	ctor, _, err := GetWriter("length_prefixed:uint32_be")
+	require.NoError(t, err)
+
+	var buf closableBuffer
+	w, err := ctor(&buf)
+	require.NoError(t, err)
+
+	require.NoError(t, w.Write(context.Background(), message.NewPart([]byte("foo"))))
+	assert.Equal(t, []byte{0, 0, 0, 3, 'f', 'o', 'o'}, buf.Bytes())
+
+	_, _, err = GetWriter("length_prefixed:nope")
+	require.Error(t, err)
+}
+
+func TestWriterTarRoundTrip(t *testing.T) {
+	ctor, conf, err := GetWriter("tar")
+	require.NoError(t, err)
+	assert.True(t, conf.Truncate)
+
+	var buf closableBuffer
+	w, err := ctor(&buf)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	first := message.NewPart([]byte("first document"))
+	first.MetaSet("tar_path", "foo.txt")
+	require.NoError(t, w.Write(ctx, first))
+	require.NoError(t, w.Write(ctx, message.NewPart([]byte("second document"))))
+	require.NoError(t, w.Close(ctx))
+
+	testReaderSuite(t, "tar", "", buf.Bytes(), "first document", "second document")
+
+	rCtor, err := GetReader("tar", NewReaderConfig())
+	require.NoError(t, err)
+
+	r, err := rCtor("", noopCloser{bytes.NewReader(buf.Bytes()), false}, func(ctx context.Context, err error) error {
+		return nil
+	})
+	require.NoError(t, err)
+
+	var paths []string
+	for {
+		parts, _, err := r.Next(ctx)
+		if err != nil {
+			break
+		}
+		paths = append(paths, parts[0].MetaGet("tar_path"))
+	}
+	assert.Equal(t, []string{"foo.txt", "1"}, paths)
+	require.NoError(t, r.Close(ctx))
+}
@@ -79,6 +79,19 @@ func (a *metricsCache) Get(ctx context.Context, key string) ([]byte, error) {
 	return b, err
 }
 
+func (a *metricsCache) GetMulti(ctx context.Context, keys ...string) (map[string][]byte, error) {
+	started := time.Now()
+	res, err := a.c.GetMulti(ctx, keys...)
+	a.mGetLatency.Timing(int64(time.Since(started)))
+	if err != nil {
+		a.mGetError.Incr(int64(len(keys)))
+	} else {
+		a.mGetSuccess.Incr(int64(len(res)))
+		a.mGetNotFound.Incr(int64(len(keys) - len(res)))
+	}
+	return res, err
+}
+
 func (a *metricsCache) Set(ctx context.Context, key string, value []byte, ttl *time.Duration) error {
 	started := time.Now()
 	err := a.c.Set(ctx, key, value, ttl)
@@ -33,6 +33,19 @@ func (c *closableCache) Get(ctx context.Context, key string) ([]byte, error) {
 	return i.b, nil
 }
 
+func (c *closableCache) GetMulti(ctx context.Context, keys ...string) (map[string][]byte, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	res := map[string][]byte{}
+	for _, k := range keys {
+		if i, ok := c.m[k]; ok {
+			res[k] = i.b
+		}
+	}
+	return res, nil
+}
+
 func (c *closableCache) Set(ctx context.Context, key string, value []byte, ttl *time.Duration) error {
 	if c.err != nil {
 		return c.err
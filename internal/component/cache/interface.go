@@ -17,6 +17,11 @@ type V1 interface {
 	// error if the key does not exist or if the command fails.
 	Get(ctx context.Context, key string) ([]byte, error)
 
+	// GetMulti attempts to locate and return the cached values of multiple
+	// keys, keys that do not exist are omitted from the result. Returns an
+	// error if the command fails.
+	GetMulti(ctx context.Context, keys ...string) (map[string][]byte, error)
+
 	// Set attempts to set the value of a key, returns an error if the command
 	// fails.
 	Set(ctx context.Context, key string, value []byte, ttl *time.Duration) error
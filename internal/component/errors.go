@@ -44,14 +44,15 @@ var (
 
 // Manager errors
 var (
-	ErrInputNotFound     = errors.New("input not found")
-	ErrCacheNotFound     = errors.New("cache not found")
-	ErrProcessorNotFound = errors.New("processor not found")
-	ErrRateLimitNotFound = errors.New("rate limit not found")
-	ErrOutputNotFound    = errors.New("output not found")
-	ErrKeyAlreadyExists  = errors.New("key already exists")
-	ErrKeyNotFound       = errors.New("key does not exist")
-	ErrPipeNotFound      = errors.New("pipe was not found")
+	ErrInputNotFound      = errors.New("input not found")
+	ErrCacheNotFound      = errors.New("cache not found")
+	ErrProcessorNotFound  = errors.New("processor not found")
+	ErrRateLimitNotFound  = errors.New("rate limit not found")
+	ErrOutputNotFound     = errors.New("output not found")
+	ErrCheckpointNotFound = errors.New("checkpoint not found")
+	ErrKeyAlreadyExists   = errors.New("key already exists")
+	ErrKeyNotFound        = errors.New("key does not exist")
+	ErrPipeNotFound       = errors.New("pipe was not found")
 )
 
 //------------------------------------------------------------------------------
@@ -76,3 +77,19 @@ func (e ErrUnexpectedHTTPRes) Error() string {
 	body := strings.ReplaceAll(string(e.Body), "\n", "")
 	return fmt.Sprintf("HTTP request returned unexpected response code (%v): %v, Error: %v", e.Code, e.S, body)
 }
+
+// RejectionError is an error returned by the reject output containing a
+// structured reason for the rejection, which inputs may use when propagating
+// the nack upstream.
+type RejectionError struct {
+	Code    int
+	Message string
+}
+
+// Error returns the Error string.
+func (e *RejectionError) Error() string {
+	if e.Code == 0 {
+		return e.Message
+	}
+	return fmt.Sprintf("%v (code %v)", e.Message, e.Code)
+}
@@ -0,0 +1,48 @@
+package component
+
+import (
+	"sync"
+	"time"
+)
+
+// ErrorReporter is an optional interface implemented by components that track
+// the most recent error they encountered, which is exposed by detailed health
+// checks.
+type ErrorReporter interface {
+	// LastError returns the most recent error encountered by the component
+	// along with the time at which it occurred, or a nil error if the
+	// component has not encountered an error.
+	LastError() (time.Time, error)
+}
+
+// LastErrorOf returns the most recent error encountered by a component if it
+// implements ErrorReporter.
+func LastErrorOf(c interface{}) (time.Time, error) {
+	if r, ok := c.(ErrorReporter); ok {
+		return r.LastError()
+	}
+	return time.Time{}, nil
+}
+
+// LastErrorTracker is a concurrency safe implementation of ErrorReporter that
+// can be embedded within components.
+type LastErrorTracker struct {
+	mut sync.Mutex
+	at  time.Time
+	err error
+}
+
+// SetLastError records an error as the most recent error encountered.
+func (l *LastErrorTracker) SetLastError(err error) {
+	l.mut.Lock()
+	l.at, l.err = time.Now(), err
+	l.mut.Unlock()
+}
+
+// LastError returns the most recent error recorded along with the time at
+// which it was recorded.
+func (l *LastErrorTracker) LastError() (time.Time, error) {
+	l.mut.Lock()
+	defer l.mut.Unlock()
+	return l.at, l.err
+}
@@ -94,3 +94,52 @@ func (s *Reader) CloseAsync() {
 func (s *Reader) WaitForClose(timeout time.Duration) error {
 	return s.rdr.WaitForClose(timeout)
 }
+
+//------------------------------------------------------------------------------
+
+// MetadataReader wraps an async reader with a mechanism for extracting tracing
+// spans from the metadata of each consumed message in the format used by the
+// service wide tracer.
+type MetadataReader struct {
+	inputName string
+	rdr       reader.Async
+}
+
+// NewMetadataReader wraps an async reader with a mechanism for extracting
+// tracing spans from the metadata of each consumed message in the format used
+// by the service wide tracer.
+func NewMetadataReader(inputName string, rdr reader.Async) reader.Async {
+	return &MetadataReader{inputName, rdr}
+}
+
+// ConnectWithContext attempts to establish a connection to the source, if
+// unsuccessful returns an error. If the attempt is successful (or not
+// necessary) returns nil.
+func (s *MetadataReader) ConnectWithContext(ctx context.Context) error {
+	return s.rdr.ConnectWithContext(ctx)
+}
+
+// ReadWithContext attempts to read a new message from the source. If
+// successful a message is returned along with a function used to
+// acknowledge receipt of the returned message. It's safe to process the
+// returned message and read the next message asynchronously.
+func (s *MetadataReader) ReadWithContext(ctx context.Context) (*message.Batch, reader.AsyncAckFn, error) {
+	m, afn, err := s.rdr.ReadWithContext(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	tracing.InitSpansFromMetadata("input_"+s.inputName, m)
+	return m, afn, nil
+}
+
+// CloseAsync triggers the shut down of this component but should not block
+// the calling goroutine.
+func (s *MetadataReader) CloseAsync() {
+	s.rdr.CloseAsync()
+}
+
+// WaitForClose is a blocking call to wait until the component has finished
+// shutting down and cleaning up resources.
+func (s *MetadataReader) WaitForClose(timeout time.Duration) error {
+	return s.rdr.WaitForClose(timeout)
+}
@@ -0,0 +1,113 @@
+package metrics
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/benthosdev/benthos/v4/internal/log"
+)
+
+// CardinalityOverflowValue is the value given to all labels of a series that
+// would exceed the label cardinality limit of a metric, aggregating all such
+// series into one.
+const CardinalityOverflowValue = "__overflow__"
+
+// CardinalityExceededMetric is the name of a counter metric, labelled with the
+// name of the offending metric, that is incremented each time a series exceeds
+// the label cardinality limit.
+const CardinalityExceededMetric = "metrics_cardinality_exceeded"
+
+// cardinalityGuard tracks the unique label sets of each metric and aggregates
+// any new label sets beyond a limit into a single overflow series.
+type cardinalityGuard struct {
+	limit    int
+	exceeded StatCounterVec
+	log      log.Modular
+
+	mut    sync.Mutex
+	series map[string]map[string]struct{}
+	warned map[string]struct{}
+}
+
+func newCardinalityGuard(limit int, child Type, logger log.Modular) *cardinalityGuard {
+	return &cardinalityGuard{
+		limit:    limit,
+		exceeded: child.GetCounterVec(CardinalityExceededMetric, "metric"),
+		log:      logger,
+		series:   map[string]map[string]struct{}{},
+		warned:   map[string]struct{}{},
+	}
+}
+
+// labelValues returns the label values to use for a series of a metric, which
+// are either the provided values or, when the series is new and the metric
+// has reached its limit, overflow values.
+func (g *cardinalityGuard) labelValues(path string, values []string) []string {
+	key := strings.Join(values, "\x00")
+
+	g.mut.Lock()
+	seen, exists := g.series[path]
+	if !exists {
+		seen = map[string]struct{}{}
+		g.series[path] = seen
+	}
+	if _, exists = seen[key]; exists || len(seen) < g.limit {
+		seen[key] = struct{}{}
+		g.mut.Unlock()
+		return values
+	}
+	_, warned := g.warned[path]
+	g.warned[path] = struct{}{}
+	g.mut.Unlock()
+
+	g.exceeded.With(path).Incr(1)
+	if !warned && g.log != nil {
+		g.log.Warnf("Metric '%v' exceeded the label cardinality limit of %v, new series are being aggregated under the label value '%v'\n", path, g.limit, CardinalityOverflowValue)
+	}
+
+	overflow := make([]string, len(values))
+	for i := range overflow {
+		overflow[i] = CardinalityOverflowValue
+	}
+	return overflow
+}
+
+type counterVecWithGuard struct {
+	path  string
+	guard *cardinalityGuard
+	child StatCounterVec
+}
+
+func (c *counterVecWithGuard) With(values ...string) StatCounter {
+	return c.child.With(c.guard.labelValues(c.path, values)...)
+}
+
+type timerVecWithGuard struct {
+	path  string
+	guard *cardinalityGuard
+	child StatTimerVec
+}
+
+func (c *timerVecWithGuard) With(values ...string) StatTimer {
+	return c.child.With(c.guard.labelValues(c.path, values)...)
+}
+
+type gaugeVecWithGuard struct {
+	path  string
+	guard *cardinalityGuard
+	child StatGaugeVec
+}
+
+func (c *gaugeVecWithGuard) With(values ...string) StatGauge {
+	return c.child.With(c.guard.labelValues(c.path, values)...)
+}
+
+type observerVecWithGuard struct {
+	path  string
+	guard *cardinalityGuard
+	child StatObserverVec
+}
+
+func (c *observerVecWithGuard) With(values ...string) StatObserver {
+	return c.child.With(c.guard.labelValues(c.path, values)...)
+}
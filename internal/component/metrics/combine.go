@@ -1,6 +1,9 @@
 package metrics
 
-import "net/http"
+import (
+	"context"
+	"net/http"
+)
 
 type combinedWrapper struct {
 	t1 Type
@@ -61,6 +64,11 @@ func (c *combinedTimer) Timing(delta int64) {
 	c.c2.Timing(delta)
 }
 
+func (c *combinedTimer) TimingWithContext(ctx context.Context, delta int64) {
+	TimingWithContext(ctx, c.c1, delta)
+	TimingWithContext(ctx, c.c2, delta)
+}
+
 type combinedGauge struct {
 	c1 StatGauge
 	c2 StatGauge
@@ -81,6 +89,16 @@ func (c *combinedGauge) Decr(count int64) {
 	c.c2.Decr(count)
 }
 
+type combinedObserver struct {
+	c1 StatObserver
+	c2 StatObserver
+}
+
+func (c *combinedObserver) Observe(value float64) {
+	c.c1.Observe(value)
+	c.c2.Observe(value)
+}
+
 //------------------------------------------------------------------------------
 
 type combinedCounterVec struct {
@@ -119,6 +137,18 @@ func (c *combinedGaugeVec) With(labelValues ...string) StatGauge {
 	}
 }
 
+type combinedObserverVec struct {
+	c1 StatObserverVec
+	c2 StatObserverVec
+}
+
+func (c *combinedObserverVec) With(labelValues ...string) StatObserver {
+	return &combinedObserver{
+		c1: c.c1.With(labelValues...),
+		c2: c.c2.With(labelValues...),
+	}
+}
+
 //------------------------------------------------------------------------------
 
 func (c *combinedWrapper) GetCounter(path string) StatCounter {
@@ -163,6 +193,20 @@ func (c *combinedWrapper) GetGaugeVec(path string, n ...string) StatGaugeVec {
 	}
 }
 
+func (c *combinedWrapper) GetHistogramVec(path string, buckets []float64, n ...string) StatObserverVec {
+	return &combinedObserverVec{
+		c1: GetHistogramVec(c.t1, path, buckets, n...),
+		c2: GetHistogramVec(c.t2, path, buckets, n...),
+	}
+}
+
+func (c *combinedWrapper) GetSummaryVec(path string, objectives map[float64]float64, n ...string) StatObserverVec {
+	return &combinedObserverVec{
+		c1: GetSummaryVec(c.t1, path, objectives, n...),
+		c2: GetSummaryVec(c.t2, path, objectives, n...),
+	}
+}
+
 func (c *combinedWrapper) HandlerFunc() http.HandlerFunc {
 	if h := c.t1.HandlerFunc(); h != nil {
 		return h
@@ -11,29 +11,35 @@ import (
 // Config is the all encompassing configuration struct for all metric output
 // types.
 type Config struct {
-	Type          string           `json:"type" yaml:"type"`
-	Mapping       string           `json:"mapping" yaml:"mapping"`
-	AWSCloudWatch CloudWatchConfig `json:"aws_cloudwatch" yaml:"aws_cloudwatch"`
-	JSONAPI       JSONAPIConfig    `json:"json_api" yaml:"json_api"`
-	InfluxDB      InfluxDBConfig   `json:"influxdb" yaml:"influxdb"`
-	None          struct{}         `json:"none" yaml:"none"`
-	Prometheus    PrometheusConfig `json:"prometheus" yaml:"prometheus"`
-	Statsd        StatsdConfig     `json:"statsd" yaml:"statsd"`
-	Logger        LoggerConfig     `json:"logger" yaml:"logger"`
+	Type                string           `json:"type" yaml:"type"`
+	Mapping             string           `json:"mapping" yaml:"mapping"`
+	MaxLabelCardinality int              `json:"max_label_cardinality" yaml:"max_label_cardinality"`
+	AWSCloudWatch       CloudWatchConfig `json:"aws_cloudwatch" yaml:"aws_cloudwatch"`
+	JSONAPI             JSONAPIConfig    `json:"json_api" yaml:"json_api"`
+	InfluxDB            InfluxDBConfig   `json:"influxdb" yaml:"influxdb"`
+	Multi               MultiConfig      `json:"multi" yaml:"multi"`
+	None                struct{}         `json:"none" yaml:"none"`
+	Prometheus          PrometheusConfig `json:"prometheus" yaml:"prometheus"`
+	Statsd              StatsdConfig     `json:"statsd" yaml:"statsd"`
+	Logger              LoggerConfig     `json:"logger" yaml:"logger"`
+	Plugin              interface{}      `json:"plugin,omitempty" yaml:"plugin,omitempty"`
 }
 
 // NewConfig returns a configuration struct fully populated with default values.
 func NewConfig() Config {
 	return Config{
-		Type:          docs.DefaultTypeOf(docs.TypeMetrics),
-		Mapping:       "",
-		AWSCloudWatch: NewCloudWatchConfig(),
-		JSONAPI:       NewJSONAPIConfig(),
-		InfluxDB:      NewInfluxDBConfig(),
-		None:          struct{}{},
-		Prometheus:    NewPrometheusConfig(),
-		Statsd:        NewStatsdConfig(),
-		Logger:        NewLoggerConfig(),
+		Type:                docs.DefaultTypeOf(docs.TypeMetrics),
+		Mapping:             "",
+		MaxLabelCardinality: 0,
+		AWSCloudWatch:       NewCloudWatchConfig(),
+		JSONAPI:             NewJSONAPIConfig(),
+		InfluxDB:            NewInfluxDBConfig(),
+		Multi:               NewMultiConfig(),
+		None:                struct{}{},
+		Prometheus:          NewPrometheusConfig(),
+		Statsd:              NewStatsdConfig(),
+		Logger:              NewLoggerConfig(),
+		Plugin:              nil,
 	}
 }
 
@@ -50,10 +56,21 @@ func (conf *Config) UnmarshalYAML(value *yaml.Node) error {
 		return fmt.Errorf("line %v: %v", value.Line, err)
 	}
 
-	if aliased.Type, _, err = docs.GetInferenceCandidateFromYAML(docs.DeprecatedProvider, docs.TypeMetrics, value); err != nil {
+	var spec docs.ComponentSpec
+	if aliased.Type, spec, err = docs.GetInferenceCandidateFromYAML(docs.DeprecatedProvider, docs.TypeMetrics, value); err != nil {
 		return fmt.Errorf("line %v: %w", value.Line, err)
 	}
 
+	if spec.Plugin {
+		pluginNode, err := docs.GetPluginConfigYAML(aliased.Type, value)
+		if err != nil {
+			return fmt.Errorf("line %v: %v", value.Line, err)
+		}
+		aliased.Plugin = &pluginNode
+	} else {
+		aliased.Plugin = nil
+	}
+
 	*conf = Config(aliased)
 	return nil
 }
@@ -4,11 +4,32 @@ import (
 	"github.com/benthosdev/benthos/v4/internal/impl/aws/session"
 )
 
+// CloudWatchEMFConfig contains config fields for emitting CloudWatch metrics
+// as logs in the Embedded Metric Format.
+type CloudWatchEMFConfig struct {
+	Enabled        bool     `json:"enabled" yaml:"enabled"`
+	LogGroup       string   `json:"log_group" yaml:"log_group"`
+	LogStream      string   `json:"log_stream" yaml:"log_stream"`
+	PropertyLabels []string `json:"property_labels" yaml:"property_labels"`
+}
+
+// NewCloudWatchEMFConfig creates a CloudWatchEMFConfig struct with default
+// values.
+func NewCloudWatchEMFConfig() CloudWatchEMFConfig {
+	return CloudWatchEMFConfig{
+		Enabled:        false,
+		LogGroup:       "",
+		LogStream:      "benthos",
+		PropertyLabels: []string{},
+	}
+}
+
 // CloudWatchConfig contains config fields for the CloudWatch metrics type.
 type CloudWatchConfig struct {
 	session.Config `json:",inline" yaml:",inline"`
-	Namespace      string `json:"namespace" yaml:"namespace"`
-	FlushPeriod    string `json:"flush_period" yaml:"flush_period"`
+	Namespace      string              `json:"namespace" yaml:"namespace"`
+	FlushPeriod    string              `json:"flush_period" yaml:"flush_period"`
+	EMF            CloudWatchEMFConfig `json:"emf" yaml:"emf"`
 }
 
 // NewCloudWatchConfig creates an CloudWatchConfig struct with default values.
@@ -17,5 +38,6 @@ func NewCloudWatchConfig() CloudWatchConfig {
 		Config:      session.NewConfig(),
 		Namespace:   "Benthos",
 		FlushPeriod: "100ms",
+		EMF:         NewCloudWatchEMFConfig(),
 	}
 }
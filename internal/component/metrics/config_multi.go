@@ -0,0 +1,13 @@
+package metrics
+
+// MultiConfig contains configuration parameters for the multi metrics type.
+type MultiConfig struct {
+	Targets []Config `json:"targets" yaml:"targets"`
+}
+
+// NewMultiConfig returns a new MultiConfig with default values.
+func NewMultiConfig() MultiConfig {
+	return MultiConfig{
+		Targets: []Config{},
+	}
+}
@@ -7,6 +7,8 @@ package metrics
 type PrometheusConfig struct {
 	UseHistogramTiming bool                          `json:"use_histogram_timing" yaml:"use_histogram_timing"`
 	HistogramBuckets   []float64                     `json:"histogram_buckets" yaml:"histogram_buckets"`
+	NativeHistograms   PrometheusNativeHistograms    `json:"native_histograms" yaml:"native_histograms"`
+	AddExemplars       bool                          `json:"add_exemplars" yaml:"add_exemplars"`
 	AddProcessMetrics  bool                          `json:"add_process_metrics" yaml:"add_process_metrics"`
 	AddGoMetrics       bool                          `json:"add_go_metrics" yaml:"add_go_metrics"`
 	PushURL            string                        `json:"push_url" yaml:"push_url"`
@@ -16,6 +18,24 @@ type PrometheusConfig struct {
 	FileOutputPath     string                        `json:"file_output_path" yaml:"file_output_path"`
 }
 
+// PrometheusNativeHistograms contains parameters for exporting histograms as
+// native histograms, where bucket boundaries are determined dynamically.
+type PrometheusNativeHistograms struct {
+	Enabled      bool    `json:"enabled" yaml:"enabled"`
+	BucketFactor float64 `json:"bucket_factor" yaml:"bucket_factor"`
+	MaxBuckets   int     `json:"max_buckets" yaml:"max_buckets"`
+}
+
+// NewPrometheusNativeHistograms creates a new PrometheusNativeHistograms with
+// default values.
+func NewPrometheusNativeHistograms() PrometheusNativeHistograms {
+	return PrometheusNativeHistograms{
+		Enabled:      false,
+		BucketFactor: 1.1,
+		MaxBuckets:   160,
+	}
+}
+
 // PrometheusPushBasicAuthConfig contains parameters for establishing basic
 // authentication against a push service.
 type PrometheusPushBasicAuthConfig struct {
@@ -36,6 +56,8 @@ func NewPrometheusConfig() PrometheusConfig {
 	return PrometheusConfig{
 		UseHistogramTiming: false,
 		HistogramBuckets:   []float64{},
+		NativeHistograms:   NewPrometheusNativeHistograms(),
+		AddExemplars:       false,
 		PushURL:            "",
 		PushBasicAuth:      NewPrometheusPushBasicAuthConfig(),
 		PushInterval:       "",
@@ -2,9 +2,12 @@ package metrics
 
 // StatsdConfig is config for the Statsd metrics type.
 type StatsdConfig struct {
-	Address     string `json:"address" yaml:"address"`
-	FlushPeriod string `json:"flush_period" yaml:"flush_period"`
-	TagFormat   string `json:"tag_format" yaml:"tag_format"`
+	Address     string            `json:"address" yaml:"address"`
+	FlushPeriod string            `json:"flush_period" yaml:"flush_period"`
+	TagFormat   string            `json:"tag_format" yaml:"tag_format"`
+	TimingType  string            `json:"timing_type" yaml:"timing_type"`
+	Tags        map[string]string `json:"tags" yaml:"tags"`
+	PathTags    []string          `json:"path_tags" yaml:"path_tags"`
 }
 
 // NewStatsdConfig creates an StatsdConfig struct with default values.
@@ -13,5 +16,8 @@ func NewStatsdConfig() StatsdConfig {
 		Address:     "",
 		FlushPeriod: "100ms",
 		TagFormat:   "none",
+		TimingType:  "timing",
+		Tags:        map[string]string{},
+		PathTags:    []string{},
 	}
 }
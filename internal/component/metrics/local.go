@@ -139,6 +139,7 @@ func createLabelledPath(name string, tagNames, tagValues []string) string {
 		for k, v := range tagNames {
 			tags[v] = tagValues[k]
 		}
+		tagNames = append([]string(nil), tagNames...)
 		sort.Strings(tagNames)
 
 		b.WriteByte('{')
@@ -3,6 +3,8 @@ package metrics
 import (
 	"net/http"
 	"sort"
+
+	"github.com/benthosdev/benthos/v4/internal/log"
 )
 
 // Namespaced wraps a child metrics exporter and exposes a Type API that
@@ -10,6 +12,7 @@ import (
 type Namespaced struct {
 	labels   map[string]string
 	mappings []*Mapping
+	guard    *cardinalityGuard
 	child    Type
 }
 
@@ -62,6 +65,20 @@ func (n *Namespaced) WithMapping(m *Mapping) *Namespaced {
 	return &newNs
 }
 
+// WithLabelCardinalityLimit returns a namespaced metrics exporter that limits
+// the number of unique label sets of each metric with labels. Beyond this
+// limit new series are aggregated into a single series with overflow label
+// values, and a warning metric is incremented. A limit of zero or less
+// disables the guard.
+func (n *Namespaced) WithLabelCardinalityLimit(limit int, logger log.Modular) *Namespaced {
+	newNs := *n
+	newNs.guard = nil
+	if limit > 0 {
+		newNs.guard = newCardinalityGuard(limit, n.child, logger)
+	}
+	return &newNs
+}
+
 //------------------------------------------------------------------------------
 
 // Child returns the underlying metrics type.
@@ -133,6 +150,48 @@ func (c *gaugeVecWithStatic) With(values ...string) StatGauge {
 	return c.child.With(newValues...)
 }
 
+type observerVecWithStatic struct {
+	staticValues []string
+	child        StatObserverVec
+}
+
+func (c *observerVecWithStatic) With(values ...string) StatObserver {
+	newValues := make([]string, 0, len(c.staticValues)+len(values))
+	newValues = append(newValues, c.staticValues...)
+	newValues = append(newValues, values...)
+	return c.child.With(newValues...)
+}
+
+// Label cardinality is only guarded for metrics with dynamic labels, as the
+// cardinality of static labels is bounded by the config.
+func (n *Namespaced) guardCounterVec(path string, labelNames []string, v StatCounterVec) StatCounterVec {
+	if n.guard == nil || len(labelNames) == 0 {
+		return v
+	}
+	return &counterVecWithGuard{path: path, guard: n.guard, child: v}
+}
+
+func (n *Namespaced) guardTimerVec(path string, labelNames []string, v StatTimerVec) StatTimerVec {
+	if n.guard == nil || len(labelNames) == 0 {
+		return v
+	}
+	return &timerVecWithGuard{path: path, guard: n.guard, child: v}
+}
+
+func (n *Namespaced) guardGaugeVec(path string, labelNames []string, v StatGaugeVec) StatGaugeVec {
+	if n.guard == nil || len(labelNames) == 0 {
+		return v
+	}
+	return &gaugeVecWithGuard{path: path, guard: n.guard, child: v}
+}
+
+func (n *Namespaced) guardObserverVec(path string, labelNames []string, v StatObserverVec) StatObserverVec {
+	if n.guard == nil || len(labelNames) == 0 {
+		return v
+	}
+	return &observerVecWithGuard{path: path, guard: n.guard, child: v}
+}
+
 //------------------------------------------------------------------------------
 
 // GetCounter returns an editable counter stat for a given path.
@@ -161,12 +220,14 @@ func (n *Namespaced) GetCounterVec(path string, labelNames ...string) StatCounte
 		newNames := make([]string, 0, len(staticKeys)+len(labelNames))
 		newNames = append(newNames, staticKeys...)
 		newNames = append(newNames, labelNames...)
-		return &counterVecWithStatic{
+		// The guard is applied before static values are added as only the
+		// dynamic values have unbounded cardinality.
+		return n.guardCounterVec(path, labelNames, &counterVecWithStatic{
 			staticValues: staticValues,
 			child:        n.child.GetCounterVec(path, newNames...),
-		}
+		})
 	}
-	return n.child.GetCounterVec(path, labelNames...)
+	return n.guardCounterVec(path, labelNames, n.child.GetCounterVec(path, labelNames...))
 }
 
 // GetTimer returns an editable timer stat for a given path.
@@ -195,12 +256,12 @@ func (n *Namespaced) GetTimerVec(path string, labelNames ...string) StatTimerVec
 		newNames := make([]string, 0, len(staticKeys)+len(labelNames))
 		newNames = append(newNames, staticKeys...)
 		newNames = append(newNames, labelNames...)
-		return &timerVecWithStatic{
+		return n.guardTimerVec(path, labelNames, &timerVecWithStatic{
 			staticValues: staticValues,
 			child:        n.child.GetTimerVec(path, newNames...),
-		}
+		})
 	}
-	return n.child.GetTimerVec(path, labelNames...)
+	return n.guardTimerVec(path, labelNames, n.child.GetTimerVec(path, labelNames...))
 }
 
 // GetGauge returns an editable gauge stat for a given path.
@@ -229,12 +290,56 @@ func (n *Namespaced) GetGaugeVec(path string, labelNames ...string) StatGaugeVec
 		newNames := make([]string, 0, len(staticKeys)+len(labelNames))
 		newNames = append(newNames, staticKeys...)
 		newNames = append(newNames, labelNames...)
-		return &gaugeVecWithStatic{
+		return n.guardGaugeVec(path, labelNames, &gaugeVecWithStatic{
 			staticValues: staticValues,
 			child:        n.child.GetGaugeVec(path, newNames...),
-		}
+		})
+	}
+	return n.guardGaugeVec(path, labelNames, n.child.GetGaugeVec(path, labelNames...))
+}
+
+// GetHistogramVec returns an editable histogram stat for a given path with
+// labels and buckets. If the child does not support histograms then
+// observations are recorded as timings.
+func (n *Namespaced) GetHistogramVec(path string, buckets []float64, labelNames ...string) StatObserverVec {
+	path, staticKeys, staticValues := n.getPathAndLabels(path)
+	if path == "" {
+		return FakeObserverVec(func(...string) StatObserver {
+			return DudStat{}
+		})
+	}
+	if len(staticKeys) > 0 {
+		newNames := make([]string, 0, len(staticKeys)+len(labelNames))
+		newNames = append(newNames, staticKeys...)
+		newNames = append(newNames, labelNames...)
+		return n.guardObserverVec(path, labelNames, &observerVecWithStatic{
+			staticValues: staticValues,
+			child:        GetHistogramVec(n.child, path, buckets, newNames...),
+		})
+	}
+	return n.guardObserverVec(path, labelNames, GetHistogramVec(n.child, path, buckets, labelNames...))
+}
+
+// GetSummaryVec returns an editable summary stat for a given path with labels
+// and quantile objectives. If the child does not support summaries then
+// observations are recorded as timings.
+func (n *Namespaced) GetSummaryVec(path string, objectives map[float64]float64, labelNames ...string) StatObserverVec {
+	path, staticKeys, staticValues := n.getPathAndLabels(path)
+	if path == "" {
+		return FakeObserverVec(func(...string) StatObserver {
+			return DudStat{}
+		})
+	}
+	if len(staticKeys) > 0 {
+		newNames := make([]string, 0, len(staticKeys)+len(labelNames))
+		newNames = append(newNames, staticKeys...)
+		newNames = append(newNames, labelNames...)
+		return n.guardObserverVec(path, labelNames, &observerVecWithStatic{
+			staticValues: staticValues,
+			child:        GetSummaryVec(n.child, path, objectives, newNames...),
+		})
 	}
-	return n.child.GetGaugeVec(path, labelNames...)
+	return n.guardObserverVec(path, labelNames, GetSummaryVec(n.child, path, objectives, labelNames...))
 }
 
 // Close stops aggregating stats and cleans up resources.
@@ -252,3 +252,28 @@ root = this.replace_all("bar","baz")`, log.Noop())
 	assert.Contains(t, body, "\ngaugetwo{extra1=\"extravalue1\",extra2=\"extravalue2\",label2=\"value3\",static1=\"sbaz1\"} 12")
 	assert.Contains(t, body, "\ntimertwo_sum{extra1=\"extravalue1\",extra2=\"extravalue2\",label3=\"value4\",label4=\"value5\",static1=\"sbaz1\"} 1.3e-08")
 }
+
+func TestNamespacedLabelCardinalityLimit(t *testing.T) {
+	local := metrics.NewLocal()
+
+	nm := metrics.NewNamespaced(local).
+		WithLabels("static", "foo").
+		WithLabelCardinalityLimit(2, log.Noop())
+
+	ctr := nm.GetCounterVec("counterone", "dynamic")
+	ctr.With("a").Incr(1)
+	ctr.With("b").Incr(2)
+	ctr.With("a").Incr(3)
+	ctr.With("c").Incr(4)
+	ctr.With("d").Incr(5)
+
+	nm.GetCounter("countertwo").Incr(6)
+
+	assert.Equal(t, map[string]int64{
+		`counterone{dynamic="a",static="foo"}`:              4,
+		`counterone{dynamic="b",static="foo"}`:              2,
+		`counterone{dynamic="__overflow__",static="foo"}`:   9,
+		`countertwo{static="foo"}`:                          6,
+		`metrics_cardinality_exceeded{metric="counterone"}`: 2,
+	}, local.GetCounters())
+}
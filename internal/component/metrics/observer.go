@@ -0,0 +1,64 @@
+package metrics
+
+// StatObserver is a representation of a single histogram or summary metric
+// stat, which records the distribution of observed values. Interactions with
+// this stat are thread safe.
+type StatObserver interface {
+	// Observe adds a single observation to the distribution.
+	Observe(value float64)
+}
+
+// StatObserverVec creates StatObservers with dynamic labels.
+type StatObserverVec interface {
+	// With returns a StatObserver with a set of label values.
+	With(labelValues ...string) StatObserver
+}
+
+// ObserverType is implemented by metrics types that are able to record
+// histograms and summaries with a custom distribution.
+type ObserverType interface {
+	// GetHistogramVec returns an editable histogram stat for a given path with
+	// labels and buckets, these labels must be consistent with any other
+	// metrics registered on the same path.
+	GetHistogramVec(path string, buckets []float64, labelNames ...string) StatObserverVec
+
+	// GetSummaryVec returns an editable summary stat for a given path with
+	// labels and quantile objectives (a map of quantiles to their absolute
+	// error), these labels must be consistent with any other metrics
+	// registered on the same path.
+	GetSummaryVec(path string, objectives map[float64]float64, labelNames ...string) StatObserverVec
+}
+
+type timerObserver struct {
+	t StatTimer
+}
+
+func (t timerObserver) Observe(value float64) {
+	t.t.Timing(int64(value))
+}
+
+func timerObserverVec(t StatTimerVec) StatObserverVec {
+	return FakeObserverVec(func(labelValues ...string) StatObserver {
+		return timerObserver{t: t.With(labelValues...)}
+	})
+}
+
+// GetHistogramVec returns a histogram stat from a metrics type if it
+// implements ObserverType, otherwise observations are recorded as timings
+// truncated to integers.
+func GetHistogramVec(t Type, path string, buckets []float64, labelNames ...string) StatObserverVec {
+	if o, ok := t.(ObserverType); ok {
+		return o.GetHistogramVec(path, buckets, labelNames...)
+	}
+	return timerObserverVec(t.GetTimerVec(path, labelNames...))
+}
+
+// GetSummaryVec returns a summary stat from a metrics type if it implements
+// ObserverType, otherwise observations are recorded as timings truncated to
+// integers.
+func GetSummaryVec(t Type, path string, objectives map[float64]float64, labelNames ...string) StatObserverVec {
+	if o, ok := t.(ObserverType); ok {
+		return o.GetSummaryVec(path, objectives, labelNames...)
+	}
+	return timerObserverVec(t.GetTimerVec(path, labelNames...))
+}
@@ -1,6 +1,7 @@
 package metrics
 
 import (
+	"context"
 	"net/http"
 )
 
@@ -19,6 +20,24 @@ type StatTimer interface {
 	Timing(delta int64)
 }
 
+// StatTimerWithContext is an optional interface implemented by timers that are
+// able to enrich timing values with information extracted from a context, such
+// as attaching the trace ID of a span as an exemplar.
+type StatTimerWithContext interface {
+	// TimingWithContext sets a timing metric with a context.
+	TimingWithContext(ctx context.Context, delta int64)
+}
+
+// TimingWithContext sets a timing metric with a context when the timer
+// supports it, otherwise the context is ignored.
+func TimingWithContext(ctx context.Context, t StatTimer, delta int64) {
+	if ct, ok := t.(StatTimerWithContext); ok {
+		ct.TimingWithContext(ctx, delta)
+		return
+	}
+	t.Timing(delta)
+}
+
 // StatGauge is a representation of a single gauge metric stat. Interactions
 // with this stat are thread safe.
 type StatGauge interface {
@@ -48,3 +48,20 @@ func FakeGaugeVec(f func(...string) StatGauge) StatGaugeVec {
 		f: f,
 	}
 }
+
+//------------------------------------------------------------------------------
+
+type fObserverVec struct {
+	f func(...string) StatObserver
+}
+
+func (f *fObserverVec) With(labels ...string) StatObserver {
+	return f.f(labels...)
+}
+
+// FakeObserverVec returns an observer vec implementation that ignores labels.
+func FakeObserverVec(f func(...string) StatObserver) StatObserverVec {
+	return &fObserverVec{
+		f: f,
+	}
+}
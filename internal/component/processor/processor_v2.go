@@ -52,6 +52,7 @@ type v2ToV1Processor struct {
 	mBatchSent     metrics.StatCounter
 	mError         metrics.StatCounter
 	mLatency       metrics.StatTimer
+	mPending       metrics.StatGauge
 }
 
 // NewV2ToV1Processor wraps a processor.V2 with a struct that implements V1.
@@ -65,12 +66,15 @@ func NewV2ToV1Processor(typeStr string, p V2, stats metrics.Type) V1 {
 		mBatchSent:     stats.GetCounter("processor_batch_sent"),
 		mError:         stats.GetCounter("processor_error"),
 		mLatency:       stats.GetTimer("processor_latency_ns"),
+		mPending:       stats.GetGauge("processor_pending"),
 	}
 }
 
 func (a *v2ToV1Processor) ProcessMessage(msg *message.Batch) ([]*message.Batch, error) {
 	a.mReceived.Incr(int64(msg.Len()))
 	a.mBatchReceived.Incr(1)
+	a.mPending.Incr(1)
+	defer a.mPending.Decr(1)
 
 	tStarted := time.Now()
 
@@ -94,7 +98,7 @@ func (a *v2ToV1Processor) ProcessMessage(msg *message.Batch) ([]*message.Batch,
 		return nil
 	})
 
-	a.mLatency.Timing(time.Since(tStarted).Nanoseconds())
+	metrics.TimingWithContext(message.GetContext(msg.Get(0)), a.mLatency, time.Since(tStarted).Nanoseconds())
 	if len(newParts) == 0 {
 		return nil, nil
 	}
@@ -138,6 +142,7 @@ type v2BatchedToV1Processor struct {
 	mBatchSent     metrics.StatCounter
 	mError         metrics.StatCounter
 	mLatency       metrics.StatTimer
+	mPending       metrics.StatGauge
 }
 
 // NewV2BatchedToV1Processor wraps a processor.V2Batched with a struct that
@@ -152,12 +157,15 @@ func NewV2BatchedToV1Processor(typeStr string, p V2Batched, stats metrics.Type)
 		mBatchSent:     stats.GetCounter("processor_batch_sent"),
 		mError:         stats.GetCounter("processor_error"),
 		mLatency:       stats.GetTimer("processor_latency_ns"),
+		mPending:       stats.GetGauge("processor_pending"),
 	}
 }
 
 func (a *v2BatchedToV1Processor) ProcessMessage(msg *message.Batch) ([]*message.Batch, error) {
 	a.mReceived.Incr(int64(msg.Len()))
 	a.mBatchReceived.Incr(1)
+	a.mPending.Incr(1)
+	defer a.mPending.Decr(1)
 
 	tStarted := time.Now()
 	spans := tracing.CreateChildSpans(a.typeStr, msg)
@@ -177,7 +185,7 @@ func (a *v2BatchedToV1Processor) ProcessMessage(msg *message.Batch) ([]*message.
 		s.Finish()
 	}
 
-	a.mLatency.Timing(time.Since(tStarted).Nanoseconds())
+	metrics.TimingWithContext(message.GetContext(msg.Get(0)), a.mLatency, time.Since(tStarted).Nanoseconds())
 	if len(outputBatches) == 0 {
 		return nil, nil
 	}
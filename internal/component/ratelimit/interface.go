@@ -13,6 +13,11 @@ type V1 interface {
 	// requesting again.
 	Access(ctx context.Context) (time.Duration, error)
 
+	// AccessKey is equivalent to Access but targets a limit that is distinct
+	// to the provided key. Rate limits that do not support keys apply the same
+	// limit regardless of the key.
+	AccessKey(ctx context.Context, key string) (time.Duration, error)
+
 	// Close the component, blocks until either the underlying resources are
 	// cleaned up or the context is cancelled. Returns an error if the context
 	// is cancelled.
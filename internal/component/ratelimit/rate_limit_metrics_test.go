@@ -18,6 +18,10 @@ func (c *closableRateLimit) Access(ctx context.Context) (time.Duration, error) {
 	return 0, nil
 }
 
+func (c *closableRateLimit) AccessKey(ctx context.Context, key string) (time.Duration, error) {
+	return 0, nil
+}
+
 func (c *closableRateLimit) Close(ctx context.Context) error {
 	c.closed = true
 	return nil
@@ -18,17 +18,21 @@ type Type interface {
 
 // Config is the all encompassing configuration struct for all tracer types.
 type Config struct {
-	Type   string       `json:"type" yaml:"type"`
-	Jaeger JaegerConfig `json:"jaeger" yaml:"jaeger"`
-	None   struct{}     `json:"none" yaml:"none"`
+	Type                   string                       `json:"type" yaml:"type"`
+	Jaeger                 JaegerConfig                 `json:"jaeger" yaml:"jaeger"`
+	None                   struct{}                     `json:"none" yaml:"none"`
+	OpenTelemetryCollector OpenTelemetryCollectorConfig `json:"open_telemetry_collector" yaml:"open_telemetry_collector"`
+	Sampling               SamplingConfig               `json:"sampling" yaml:"sampling"`
 }
 
 // NewConfig returns a configuration struct fully populated with default values.
 func NewConfig() Config {
 	return Config{
-		Type:   "none",
-		Jaeger: NewJaegerConfig(),
-		None:   struct{}{},
+		Type:                   "none",
+		Jaeger:                 NewJaegerConfig(),
+		None:                   struct{}{},
+		OpenTelemetryCollector: NewOpenTelemetryCollectorConfig(),
+		Sampling:               NewSamplingConfig(),
 	}
 }
 
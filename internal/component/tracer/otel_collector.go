@@ -0,0 +1,26 @@
+package tracer
+
+// OpenTelemetryCollectorConfig is config for the OpenTelemetry collector
+// tracer type.
+type OpenTelemetryCollectorConfig struct {
+	HTTP []OpenTelemetryCollectorEndpoint `json:"http" yaml:"http"`
+	GRPC []OpenTelemetryCollectorEndpoint `json:"grpc" yaml:"grpc"`
+	Tags map[string]string                `json:"tags" yaml:"tags"`
+}
+
+// OpenTelemetryCollectorEndpoint describes a single collector to send
+// tracing events to.
+type OpenTelemetryCollectorEndpoint struct {
+	URL      string `json:"url" yaml:"url"`
+	Insecure bool   `json:"insecure" yaml:"insecure"`
+}
+
+// NewOpenTelemetryCollectorConfig creates an OpenTelemetryCollectorConfig
+// struct with default values.
+func NewOpenTelemetryCollectorConfig() OpenTelemetryCollectorConfig {
+	return OpenTelemetryCollectorConfig{
+		HTTP: []OpenTelemetryCollectorEndpoint{},
+		GRPC: []OpenTelemetryCollectorEndpoint{},
+		Tags: map[string]string{},
+	}
+}
@@ -0,0 +1,288 @@
+package tracer
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/codes"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/time/rate"
+
+	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+)
+
+// SamplingConfig describes tail-based sampling policies that decide whether
+// a trace is exported once it has completed.
+type SamplingConfig struct {
+	DecisionWait string                 `json:"decision_wait" yaml:"decision_wait"`
+	Policies     []SamplingPolicyConfig `json:"policies" yaml:"policies"`
+}
+
+// SamplingPolicyConfig describes a single sampling policy.
+type SamplingPolicyConfig struct {
+	Type            string  `json:"type" yaml:"type"`
+	Ratio           float64 `json:"ratio" yaml:"ratio"`
+	TracesPerSecond float64 `json:"traces_per_second" yaml:"traces_per_second"`
+}
+
+// NewSamplingConfig creates a SamplingConfig struct with default values.
+func NewSamplingConfig() SamplingConfig {
+	return SamplingConfig{
+		DecisionWait: "10s",
+		Policies:     []SamplingPolicyConfig{},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type samplingPolicy func(spans []tracesdk.ReadOnlySpan) bool
+
+func errorsPolicy(spans []tracesdk.ReadOnlySpan) bool {
+	for _, s := range spans {
+		if s.Status().Code == codes.Error {
+			return true
+		}
+		for _, attr := range s.Attributes() {
+			if attr.Key == "error" && attr.Value.AsString() == "true" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func probabilisticPolicy(ratio float64) samplingPolicy {
+	// Decisions are derived from the trace ID in the same way as the trace ID
+	// ratio based sampler so that they are consistent across services.
+	bound := uint64(ratio * (1 << 63))
+	return func(spans []tracesdk.ReadOnlySpan) bool {
+		tid := spans[0].SpanContext().TraceID()
+		return binary.BigEndian.Uint64(tid[8:16])>>1 < bound
+	}
+}
+
+func rateLimitPolicy(tracesPerSecond float64) samplingPolicy {
+	limiter := rate.NewLimiter(rate.Limit(tracesPerSecond), int(tracesPerSecond)+1)
+	return func(spans []tracesdk.ReadOnlySpan) bool {
+		return limiter.Allow()
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type pendingTrace struct {
+	spans     []tracesdk.ReadOnlySpan
+	firstSeen time.Time
+}
+
+type traceDecision struct {
+	keep      bool
+	decidedAt time.Time
+}
+
+// TailSampler is a span processor that buffers the spans of each trace until
+// the local root span of the trace ends, or the decision wait period elapses,
+// at which point the sampling policies are evaluated against all spans of the
+// trace and, if any policy matches, the spans are passed on to the child span
+// processors. When no policies are configured all spans are passed on.
+type TailSampler struct {
+	next     []tracesdk.SpanProcessor
+	policies []samplingPolicy
+	wait     time.Duration
+
+	mSampled metrics.StatCounter
+	mDropped metrics.StatCounter
+
+	mut     sync.Mutex
+	pending map[trace.TraceID]*pendingTrace
+	decided map[trace.TraceID]traceDecision
+
+	closeOnce sync.Once
+	closeChan chan struct{}
+}
+
+// NewTailSampler wraps one or more span processors, typically batchers of
+// exporters, with tail-based sampling policies.
+func NewTailSampler(conf SamplingConfig, stats metrics.Type, next ...tracesdk.SpanProcessor) (*TailSampler, error) {
+	wait, err := time.ParseDuration(conf.DecisionWait)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse decision wait: %w", err)
+	}
+	if wait <= 0 {
+		return nil, fmt.Errorf("decision wait must be greater than zero, got %v", wait)
+	}
+
+	var policies []samplingPolicy
+	for i, p := range conf.Policies {
+		switch p.Type {
+		case "errors":
+			policies = append(policies, errorsPolicy)
+		case "probabilistic":
+			if p.Ratio < 0 || p.Ratio > 1 {
+				return nil, fmt.Errorf("policy %v: ratio must be between 0 and 1, got %v", i, p.Ratio)
+			}
+			policies = append(policies, probabilisticPolicy(p.Ratio))
+		case "rate_limit":
+			if p.TracesPerSecond <= 0 {
+				return nil, fmt.Errorf("policy %v: traces_per_second must be greater than zero, got %v", i, p.TracesPerSecond)
+			}
+			policies = append(policies, rateLimitPolicy(p.TracesPerSecond))
+		default:
+			return nil, fmt.Errorf("policy %v: unrecognised sampling policy type: %v", i, p.Type)
+		}
+	}
+
+	t := &TailSampler{
+		next:      next,
+		policies:  policies,
+		wait:      wait,
+		mSampled:  stats.GetCounter("tracer_spans_sampled"),
+		mDropped:  stats.GetCounter("tracer_spans_dropped"),
+		pending:   map[trace.TraceID]*pendingTrace{},
+		decided:   map[trace.TraceID]traceDecision{},
+		closeChan: make(chan struct{}),
+	}
+	if len(policies) > 0 {
+		go t.loop()
+	}
+	return t, nil
+}
+
+func (t *TailSampler) loop() {
+	ticker := time.NewTicker(t.wait / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			t.decideExpired(time.Now())
+		case <-t.closeChan:
+			return
+		}
+	}
+}
+
+func (t *TailSampler) decideExpired(now time.Time) {
+	var expired []*pendingTrace
+
+	t.mut.Lock()
+	for id, p := range t.pending {
+		if now.Sub(p.firstSeen) >= t.wait {
+			delete(t.pending, id)
+			expired = append(expired, p)
+			t.decided[id] = traceDecision{keep: t.shouldKeep(p.spans), decidedAt: now}
+		}
+	}
+	for id, d := range t.decided {
+		if now.Sub(d.decidedAt) >= t.wait {
+			delete(t.decided, id)
+		}
+	}
+	decisions := make([]bool, len(expired))
+	for i, p := range expired {
+		decisions[i] = t.decided[p.spans[0].SpanContext().TraceID()].keep
+	}
+	t.mut.Unlock()
+
+	for i, p := range expired {
+		t.flush(decisions[i], p.spans)
+	}
+}
+
+func (t *TailSampler) shouldKeep(spans []tracesdk.ReadOnlySpan) bool {
+	for _, p := range t.policies {
+		if p(spans) {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *TailSampler) flush(keep bool, spans []tracesdk.ReadOnlySpan) {
+	if !keep {
+		t.mDropped.Incr(int64(len(spans)))
+		return
+	}
+	t.mSampled.Incr(int64(len(spans)))
+	for _, s := range spans {
+		for _, n := range t.next {
+			n.OnEnd(s)
+		}
+	}
+}
+
+func isLocalRoot(s tracesdk.ReadOnlySpan) bool {
+	return !s.Parent().IsValid() || s.Parent().IsRemote()
+}
+
+// OnStart is called when a span is started.
+func (t *TailSampler) OnStart(parent context.Context, s tracesdk.ReadWriteSpan) {
+	for _, n := range t.next {
+		n.OnStart(parent, s)
+	}
+}
+
+// OnEnd is called when a span is ended.
+func (t *TailSampler) OnEnd(s tracesdk.ReadOnlySpan) {
+	if len(t.policies) == 0 {
+		t.flush(true, []tracesdk.ReadOnlySpan{s})
+		return
+	}
+
+	id := s.SpanContext().TraceID()
+
+	t.mut.Lock()
+	if d, exists := t.decided[id]; exists {
+		// Spans that end after the decision of their trace follow it.
+		t.mut.Unlock()
+		t.flush(d.keep, []tracesdk.ReadOnlySpan{s})
+		return
+	}
+
+	p, exists := t.pending[id]
+	if !exists {
+		p = &pendingTrace{firstSeen: time.Now()}
+		t.pending[id] = p
+	}
+	p.spans = append(p.spans, s)
+	if !isLocalRoot(s) {
+		t.mut.Unlock()
+		return
+	}
+
+	delete(t.pending, id)
+	keep := t.shouldKeep(p.spans)
+	t.decided[id] = traceDecision{keep: keep, decidedAt: time.Now()}
+	t.mut.Unlock()
+
+	t.flush(keep, p.spans)
+}
+
+// ForceFlush decides all pending traces and flushes the child processor.
+func (t *TailSampler) ForceFlush(ctx context.Context) error {
+	t.decideExpired(time.Now().Add(t.wait))
+	for _, n := range t.next {
+		if err := n.ForceFlush(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Shutdown decides all pending traces and shuts down the child processor.
+func (t *TailSampler) Shutdown(ctx context.Context) error {
+	t.closeOnce.Do(func() {
+		close(t.closeChan)
+	})
+	t.decideExpired(time.Now().Add(t.wait))
+	var err error
+	for _, n := range t.next {
+		if nErr := n.Shutdown(ctx); nErr != nil && err == nil {
+			err = nErr
+		}
+	}
+	return err
+}
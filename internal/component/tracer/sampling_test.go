@@ -0,0 +1,86 @@
+package tracer_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+	"github.com/benthosdev/benthos/v4/internal/component/tracer"
+)
+
+func TestTailSamplerErrors(t *testing.T) {
+	conf := tracer.NewSamplingConfig()
+	conf.Policies = append(conf.Policies, tracer.SamplingPolicyConfig{Type: "errors"})
+
+	stats := metrics.NewLocal()
+	rec := tracetest.NewSpanRecorder()
+
+	sampler, err := tracer.NewTailSampler(conf, stats, rec)
+	require.NoError(t, err)
+
+	tp := tracesdk.NewTracerProvider(tracesdk.WithSpanProcessor(sampler))
+	tr := tp.Tracer("test")
+
+	ctx, root := tr.Start(context.Background(), "good")
+	_, child := tr.Start(ctx, "good_child")
+	child.End()
+	assert.Len(t, rec.Ended(), 0)
+	root.End()
+
+	ctx, root = tr.Start(context.Background(), "bad")
+	_, child = tr.Start(ctx, "bad_child")
+	child.SetStatus(codes.Error, "nope")
+	child.End()
+	root.End()
+
+	var names []string
+	for _, s := range rec.Ended() {
+		names = append(names, s.Name())
+	}
+	assert.Equal(t, []string{"bad_child", "bad"}, names)
+
+	require.NoError(t, tp.Shutdown(context.Background()))
+	assert.Equal(t, map[string]int64{
+		"tracer_spans_sampled": 2,
+		"tracer_spans_dropped": 2,
+	}, stats.GetCounters())
+}
+
+func TestTailSamplerPendingOnShutdown(t *testing.T) {
+	conf := tracer.NewSamplingConfig()
+	conf.Policies = append(conf.Policies, tracer.SamplingPolicyConfig{Type: "probabilistic", Ratio: 1})
+
+	rec := tracetest.NewSpanRecorder()
+	sampler, err := tracer.NewTailSampler(conf, metrics.Noop(), rec)
+	require.NoError(t, err)
+
+	tp := tracesdk.NewTracerProvider(tracesdk.WithSpanProcessor(sampler))
+	ctx, root := tp.Tracer("test").Start(context.Background(), "root")
+	_, child := tp.Tracer("test").Start(ctx, "child")
+	child.End()
+
+	assert.Len(t, rec.Ended(), 0)
+	require.NoError(t, tp.Shutdown(context.Background()))
+	require.Len(t, rec.Ended(), 1)
+	assert.Equal(t, "child", rec.Ended()[0].Name())
+	root.End()
+}
+
+func TestTailSamplerBadConfig(t *testing.T) {
+	for _, p := range []tracer.SamplingPolicyConfig{
+		{Type: "nope"},
+		{Type: "probabilistic", Ratio: 1.5},
+		{Type: "rate_limit"},
+	} {
+		conf := tracer.NewSamplingConfig()
+		conf.Policies = append(conf.Policies, p)
+		_, err := tracer.NewTailSampler(conf, metrics.Noop())
+		assert.Error(t, err, p.Type)
+	}
+}
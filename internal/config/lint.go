@@ -2,6 +2,7 @@ package config
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"os"
 	"unicode/utf8"
@@ -9,11 +10,21 @@ import (
 	"gopkg.in/yaml.v3"
 
 	"github.com/benthosdev/benthos/v4/internal/docs"
+	"github.com/benthosdev/benthos/v4/internal/secrets"
 )
 
 // ReadFileLinted will attempt to read a configuration file path into a
 // structure. Returns an array of lint messages or an error.
 func ReadFileLinted(path string, rejectDeprecated bool, config *Type) ([]string, error) {
+	lintCtx := docs.NewLintContext()
+	lintCtx.RejectDeprecated = rejectDeprecated
+	return ReadFileLintedWithContext(lintCtx, path, config)
+}
+
+// ReadFileLintedWithContext will attempt to read a configuration file path
+// into a structure using a custom lint context. Returns an array of lint
+// messages or an error.
+func ReadFileLintedWithContext(lintCtx docs.LintContext, path string, config *Type) ([]string, error) {
 	configBytes, lints, err := ReadFileEnvSwap(path)
 	if err != nil {
 		return nil, err
@@ -23,8 +34,6 @@ func ReadFileLinted(path string, rejectDeprecated bool, config *Type) ([]string,
 		return nil, err
 	}
 
-	lintCtx := docs.NewLintContext()
-	lintCtx.RejectDeprecated = rejectDeprecated
 	newLints, err := LintBytes(lintCtx, configBytes)
 	if err != nil {
 		return nil, err
@@ -45,8 +54,13 @@ func LintBytes(ctx docs.LintContext, rawBytes []byte) ([]string, error) {
 		return nil, err
 	}
 
+	lints := Spec().LintYAML(ctx, &rawNode)
+	if ctx.Policy != nil {
+		lints = append(lints, ctx.Policy.LintConfig(ctx, &rawNode)...)
+	}
+
 	var lintStrs []string
-	for _, lint := range Spec().LintYAML(ctx, &rawNode) {
+	for _, lint := range lints {
 		if lint.Level == docs.LintError {
 			lintStrs = append(lintStrs, fmt.Sprintf("line %v: %v", lint.Line, lint.What))
 		}
@@ -54,11 +68,15 @@ func LintBytes(ctx docs.LintContext, rawBytes []byte) ([]string, error) {
 	return lintStrs, nil
 }
 
-// ReadFileEnvSwap reads a file and replaces any environment variable
-// interpolations before returning the contents. Linting errors are returned if
-// the file has an unexpected higher level format, such as invalid utf-8
-// encoding.
+// ReadFileEnvSwap reads a file and replaces any secret references and
+// environment variable interpolations before returning the contents. Linting
+// errors are returned if the file has an unexpected higher level format, such
+// as invalid utf-8 encoding.
 func ReadFileEnvSwap(path string) (configBytes []byte, lints []string, err error) {
+	return readFileEnvSwap(secrets.NewCache(), path)
+}
+
+func readFileEnvSwap(secretsCache *secrets.Cache, path string) (configBytes []byte, lints []string, err error) {
 	configBytes, err = os.ReadFile(path)
 	if err != nil {
 		return nil, nil, err
@@ -68,6 +86,12 @@ func ReadFileEnvSwap(path string) (configBytes []byte, lints []string, err error
 		lints = append(lints, "Detected invalid utf-8 encoding in config, this may result in interpolation functions not working as expected")
 	}
 
+	// Secrets are replaced first as their references would otherwise be
+	// interpreted as environment variables.
+	if configBytes, err = secretsCache.Replace(context.Background(), configBytes); err != nil {
+		return nil, nil, err
+	}
+
 	configBytes = ReplaceEnvVariables(configBytes)
 	return configBytes, lints, nil
 }
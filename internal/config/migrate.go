@@ -0,0 +1,158 @@
+package config
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/benthosdev/benthos/v4/internal/docs"
+)
+
+// DiffEntry describes a field of a config that is set explicitly, or a
+// component of a config that is deprecated.
+type DiffEntry struct {
+	Path string
+	Line int
+
+	// Value of the field, or the name of the component when the entry is for
+	// a deprecated component.
+	Value interface{}
+
+	// Default value of the field, which is nil when the field has no default.
+	Default *interface{}
+
+	// Component is true when the entry is for a deprecated component rather
+	// than a field.
+	Component bool
+
+	// Redundant is true when the value of the field is equal to its default
+	// and the field could therefore be removed.
+	Redundant bool
+
+	// Deprecated is true when the field or component is deprecated.
+	Deprecated bool
+
+	// Migratable is true when a deprecated field can be migrated
+	// automatically.
+	Migratable bool
+}
+
+// fieldLine returns the line of the key of a field within an object node.
+func fieldLine(parent *yaml.Node, key string) int {
+	for i := 0; i < len(parent.Content)-1; i += 2 {
+		if parent.Content[i].Value == key {
+			return parent.Content[i].Line
+		}
+	}
+	return parent.Line
+}
+
+// Diff compares a parsed config against the default values of its fields and
+// returns an entry for each field that is explicitly set and is not an object
+// or component, as well as for each deprecated component.
+func Diff(node *yaml.Node) ([]DiffEntry, error) {
+	var entries []DiffEntry
+
+	walkConf := docs.NewWalkYAMLConfig()
+	walkConf.OnComponent = func(path string, cType docs.Type, spec docs.ComponentSpec, node *yaml.Node) error {
+		if spec.Status == docs.StatusDeprecated {
+			entries = append(entries, DiffEntry{
+				Path:       path,
+				Line:       node.Line,
+				Value:      spec.Name,
+				Component:  true,
+				Deprecated: true,
+			})
+		}
+		return nil
+	}
+	walkConf.OnField = func(path string, spec docs.FieldSpec, parent, value *yaml.Node) error {
+		if _, isCore := spec.Type.IsCoreComponent(); isCore {
+			return nil
+		}
+		if spec.Kind == docs.KindScalar && len(spec.Children) > 0 {
+			return nil
+		}
+
+		var v interface{}
+		if err := value.Decode(&v); err != nil {
+			return fmt.Errorf("line %v: %w", value.Line, err)
+		}
+		entries = append(entries, DiffEntry{
+			Path:       path,
+			Line:       fieldLine(parent, spec.Name),
+			Value:      v,
+			Default:    spec.Default,
+			Redundant:  !spec.IsDeprecated && spec.IsDefaultYAML(value),
+			Deprecated: spec.IsDeprecated,
+			Migratable: spec.IsDeprecated && spec.CanMigrate(),
+		})
+		return nil
+	}
+
+	if err := Spec().WalkYAML(node, walkConf); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// Migrate rewrites a parsed config in place such that fields equal to their
+// default values are removed, and deprecated fields are replaced with their
+// modern equivalents. Deprecated fields and components that cannot be migrated
+// automatically are returned as lint warnings.
+func Migrate(node *yaml.Node) ([]docs.Lint, error) {
+	type fieldRef struct {
+		spec   docs.FieldSpec
+		parent *yaml.Node
+		value  *yaml.Node
+	}
+
+	var lints []docs.Lint
+	var redundant, migrations, objects []fieldRef
+
+	walkConf := docs.NewWalkYAMLConfig()
+	walkConf.OnComponent = func(path string, cType docs.Type, spec docs.ComponentSpec, node *yaml.Node) error {
+		if spec.Status == docs.StatusDeprecated {
+			lints = append(lints, docs.NewLintWarning(node.Line, fmt.Sprintf("%v %v is deprecated and must be migrated manually", cType, spec.Name)))
+		}
+		return nil
+	}
+	walkConf.OnField = func(path string, spec docs.FieldSpec, parent, value *yaml.Node) error {
+		ref := fieldRef{spec: spec, parent: parent, value: value}
+		switch {
+		case spec.IsDeprecated && spec.CanMigrate():
+			migrations = append(migrations, ref)
+		case spec.IsDeprecated:
+			lints = append(lints, docs.NewLintWarning(fieldLine(parent, spec.Name), fmt.Sprintf("field %v is deprecated and must be migrated manually", path)))
+		case spec.IsDefaultYAML(value):
+			redundant = append(redundant, ref)
+		case spec.Kind == docs.KindScalar && spec.Type == docs.FieldTypeObject && len(spec.Children) > 0:
+			objects = append(objects, ref)
+		}
+		return nil
+	}
+
+	if err := Spec().WalkYAML(node, walkConf); err != nil {
+		return nil, err
+	}
+
+	// Redundant fields are removed before migrations are applied as a
+	// migration might set a field to a value that is not its default.
+	for _, ref := range redundant {
+		docs.RemoveYAMLField(ref.parent, ref.spec.Name)
+	}
+	for _, ref := range migrations {
+		if err := ref.spec.MigrateYAML(ref.parent); err != nil {
+			return nil, err
+		}
+	}
+
+	// Objects are visited in the reverse order of the walk so that nested
+	// objects left empty are removed before their parents are checked.
+	for i := len(objects) - 1; i >= 0; i-- {
+		if ref := objects[i]; ref.value.Kind == yaml.MappingNode && len(ref.value.Content) == 0 {
+			docs.RemoveYAMLField(ref.parent, ref.spec.Name)
+		}
+	}
+	return lints, nil
+}
@@ -0,0 +1,121 @@
+package config_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/benthosdev/benthos/v4/internal/config"
+	"github.com/benthosdev/benthos/v4/internal/docs"
+)
+
+const migrateTestConfig = `
+http:
+  address: 0.0.0.0:4195
+  debug_endpoints: true
+input:
+  subprocess:
+    name: cat
+    restart_on_exit: true
+    restart_policy: never
+pipeline:
+  threads: -1
+  processors:
+    - log:
+        level: INFO
+        fields:
+          foo: bar
+output:
+  elasticsearch:
+    urls: [ "${ES_URL:http://localhost:9200}" ]
+    index: foo
+    tls:
+      enabled: false
+`
+
+func TestConfigDiff(t *testing.T) {
+	var node yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(migrateTestConfig), &node))
+
+	entries, err := config.Diff(&node)
+	require.NoError(t, err)
+
+	type entry struct {
+		path       string
+		redundant  bool
+		deprecated bool
+		migratable bool
+	}
+	var actual []entry
+	for _, e := range entries {
+		actual = append(actual, entry{
+			path:       e.Path,
+			redundant:  e.Redundant,
+			deprecated: e.Deprecated,
+			migratable: e.Migratable,
+		})
+	}
+
+	assert.Equal(t, []entry{
+		{path: "http.address", redundant: true},
+		{path: "http.debug_endpoints"},
+		{path: "input.subprocess.name"},
+		{path: "input.subprocess.restart_on_exit", deprecated: true, migratable: true},
+		{path: "input.subprocess.restart_policy", redundant: true},
+		{path: "pipeline.threads", redundant: true},
+		{path: "pipeline.processors.0.log.level", redundant: true},
+		{path: "pipeline.processors.0.log.fields", deprecated: true},
+		{path: "output.elasticsearch.urls"},
+		{path: "output.elasticsearch.index"},
+		{path: "output.elasticsearch.tls.enabled", redundant: true},
+	}, actual)
+}
+
+func TestConfigMigrate(t *testing.T) {
+	var node yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(migrateTestConfig), &node))
+
+	lints, err := config.Migrate(&node)
+	require.NoError(t, err)
+	assert.Equal(t, []docs.Lint{
+		docs.NewLintWarning(15, "field pipeline.processors.0.log.fields is deprecated and must be migrated manually"),
+	}, lints)
+
+	resBytes, err := config.MarshalYAML(*node.Content[0])
+	require.NoError(t, err)
+	assert.Equal(t, `http:
+  debug_endpoints: true
+input:
+  subprocess:
+    name: cat
+    restart_policy: always
+pipeline:
+  processors:
+    - log:
+        fields:
+          foo: bar
+output:
+  elasticsearch:
+    urls: ["${ES_URL:http://localhost:9200}"]
+    index: foo
+`, string(resBytes))
+}
+
+func TestConfigMigrateDeprecatedComponent(t *testing.T) {
+	var node yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(`
+output:
+  sql:
+    driver: postgres
+    data_source_name: foo
+    query: INSERT INTO footable (foo) VALUES ($1);
+`), &node))
+
+	lints, err := config.Migrate(&node)
+	require.NoError(t, err)
+	assert.Equal(t, []docs.Lint{
+		docs.NewLintWarning(3, "output sql is deprecated and must be migrated manually"),
+	}, lints)
+}
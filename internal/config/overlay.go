@@ -0,0 +1,105 @@
+package config
+
+import (
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// mergeOverlay deep merges an overlay config document into a base document,
+// modifying the base in place. The following rules apply:
+//
+//   - Objects are merged key by key, where values of the overlay are merged into
+//     the values of the base under the same key.
+//   - A null value within the overlay removes the key from the base.
+//   - Arrays of resources at the root of the config (fields ending with
+//     _resources) are merged by label, where resources of the overlay are merged
+//     into the resource of the base with the same label, and are otherwise
+//     appended.
+//   - Any other value of the overlay, including arrays, replaces the value of the
+//     base.
+func mergeOverlay(base, overlay *yaml.Node) {
+	mergeOverlayNode(unwrapDocument(base), unwrapDocument(overlay), true)
+}
+
+func unwrapDocument(node *yaml.Node) *yaml.Node {
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		return node.Content[0]
+	}
+	return node
+}
+
+func isNullNode(node *yaml.Node) bool {
+	return node.Kind == yaml.ScalarNode && node.Tag == "!!null"
+}
+
+func mergeOverlayNode(base, overlay *yaml.Node, root bool) {
+	if base.Kind != yaml.MappingNode || overlay.Kind != yaml.MappingNode {
+		*base = *overlay
+		return
+	}
+
+	for i := 0; i < len(overlay.Content)-1; i += 2 {
+		key, value := overlay.Content[i].Value, overlay.Content[i+1]
+
+		baseIndex := -1
+		for j := 0; j < len(base.Content)-1; j += 2 {
+			if base.Content[j].Value == key {
+				baseIndex = j
+				break
+			}
+		}
+
+		switch {
+		case isNullNode(value):
+			if baseIndex >= 0 {
+				base.Content = append(base.Content[:baseIndex], base.Content[baseIndex+2:]...)
+			}
+		case baseIndex < 0:
+			base.Content = append(base.Content, overlay.Content[i], value)
+		case root && strings.HasSuffix(key, "_resources"):
+			mergeOverlayResources(base.Content[baseIndex+1], value)
+		default:
+			mergeOverlayNode(base.Content[baseIndex+1], value, false)
+		}
+	}
+}
+
+func resourceLabel(node *yaml.Node) string {
+	if node.Kind != yaml.MappingNode {
+		return ""
+	}
+	for i := 0; i < len(node.Content)-1; i += 2 {
+		if node.Content[i].Value == "label" {
+			return node.Content[i+1].Value
+		}
+	}
+	return ""
+}
+
+func mergeOverlayResources(base, overlay *yaml.Node) {
+	if base.Kind != yaml.SequenceNode || overlay.Kind != yaml.SequenceNode {
+		*base = *overlay
+		return
+	}
+
+	for _, res := range overlay.Content {
+		label := resourceLabel(res)
+
+		var baseRes *yaml.Node
+		if label != "" {
+			for _, r := range base.Content {
+				if resourceLabel(r) == label {
+					baseRes = r
+					break
+				}
+			}
+		}
+
+		if baseRes == nil {
+			base.Content = append(base.Content, res)
+		} else {
+			mergeOverlayNode(baseRes, res, false)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestMergeOverlay(t *testing.T) {
+	var base, overlay yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(`
+http:
+  address: 0.0.0.0:4195
+  debug_endpoints: true
+input:
+  kafka:
+    addresses: [ localhost:9092 ]
+    topics: [ foo ]
+cache_resources:
+  - label: foo
+    memory:
+      default_ttl: 5m
+  - label: bar
+    memory: {}
+logger:
+  level: DEBUG
+`), &base))
+	require.NoError(t, yaml.Unmarshal([]byte(`
+http:
+  address: 0.0.0.0:80
+input:
+  kafka:
+    addresses: [ kafka-a:9092, kafka-b:9092 ]
+cache_resources:
+  - label: bar
+    memory: null
+    redis:
+      url: redis://prod:6379
+  - label: baz
+    memory: {}
+logger: null
+`), &overlay))
+
+	mergeOverlay(&base, &overlay)
+
+	var result interface{}
+	require.NoError(t, base.Decode(&result))
+
+	var expected interface{}
+	require.NoError(t, yaml.Unmarshal([]byte(`
+http:
+  address: 0.0.0.0:80
+  debug_endpoints: true
+input:
+  kafka:
+    addresses: [ kafka-a:9092, kafka-b:9092 ]
+    topics: [ foo ]
+cache_resources:
+  - label: foo
+    memory:
+      default_ttl: 5m
+  - label: bar
+    redis:
+      url: redis://prod:6379
+  - label: baz
+    memory: {}
+`), &expected))
+
+	assert.Equal(t, expected, result)
+}
+
+func TestReaderOverlays(t *testing.T) {
+	dir := t.TempDir()
+
+	mainPath := filepath.Join(dir, "main.yaml")
+	require.NoError(t, os.WriteFile(mainPath, []byte(`
+input:
+  generate:
+    mapping: 'root = "hello"'
+    interval: 1s
+output:
+  drop: {}
+`), 0o644))
+
+	overlayPath := filepath.Join(dir, "prod.yaml")
+	require.NoError(t, os.WriteFile(overlayPath, []byte(`
+input:
+  generate:
+    interval: 10s
+    nope: true
+`), 0o644))
+
+	rdr := NewReader(mainPath, nil, OptAddOverlays(overlayPath), OptAddOverrides("input.generate.count=5"))
+
+	conf := New()
+	lints, err := rdr.Read(&conf)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{overlayPath + ": line 5: field nope not recognised"}, lints)
+	assert.Equal(t, "generate", conf.Input.Type)
+	assert.Equal(t, `root = "hello"`, conf.Input.Generate.Mapping)
+	assert.Equal(t, "10s", conf.Input.Generate.Interval)
+	assert.Equal(t, 5, conf.Input.Generate.Count)
+	assert.Equal(t, "drop", conf.Output.Type)
+}
@@ -0,0 +1,215 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang"
+	"github.com/benthosdev/benthos/v4/internal/bloblang/mapping"
+	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
+	"github.com/benthosdev/benthos/v4/internal/docs"
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+// PolicyRule is a user defined linting rule that is checked against either
+// each component of a config that matches its filters, or when no filters are
+// specified against the root of a config.
+type PolicyRule struct {
+	Name           string   `yaml:"name"`
+	Description    string   `yaml:"description"`
+	ComponentTypes []string `yaml:"component_types"`
+	Components     []string `yaml:"components"`
+	Check          string   `yaml:"check"`
+
+	check *mapping.Executor
+}
+
+func (r *PolicyRule) init(env *bloblang.Environment) error {
+	if r.Name == "" {
+		return errors.New("a rule must have a name")
+	}
+
+	validTypes := map[string]struct{}{}
+	for _, t := range docs.Types() {
+		validTypes[string(t)] = struct{}{}
+	}
+	for _, t := range r.ComponentTypes {
+		if _, exists := validTypes[t]; !exists {
+			return fmt.Errorf("rule %v: component type '%v' was not recognised", r.Name, t)
+		}
+	}
+
+	if r.Check == "" {
+		if !r.isComponentRule() {
+			return fmt.Errorf("rule %v: a check must be specified when a rule does not filter components", r.Name)
+		}
+		return nil
+	}
+
+	var err error
+	if r.check, err = env.NewMapping(r.Check); err != nil {
+		return fmt.Errorf("rule %v: failed to parse check: %w", r.Name, err)
+	}
+	return nil
+}
+
+func (r *PolicyRule) isComponentRule() bool {
+	return len(r.ComponentTypes) > 0 || len(r.Components) > 0
+}
+
+func (r *PolicyRule) matchesComponent(cType docs.Type, name string) bool {
+	if !r.isComponentRule() {
+		return false
+	}
+	if len(r.ComponentTypes) > 0 && !containsString(r.ComponentTypes, string(cType)) {
+		return false
+	}
+	if len(r.Components) > 0 && !containsString(r.Components, name) {
+		return false
+	}
+	return true
+}
+
+func containsString(strs []string, str string) bool {
+	for _, s := range strs {
+		if s == str {
+			return true
+		}
+	}
+	return false
+}
+
+// lint executes the check of the rule against a value and returns a lint
+// when the check fails. Rules without a check fail for any value.
+func (r *PolicyRule) lint(line int, value interface{}) []docs.Lint {
+	failed := docs.NewLintError(line, fmt.Sprintf("policy rule %v violated", r.Name))
+	if r.Description != "" {
+		failed.What += ": " + r.Description
+	}
+	if r.check == nil {
+		return []docs.Lint{failed}
+	}
+
+	res, err := r.check.Exec(query.FunctionContext{
+		Vars:     map[string]interface{}{},
+		Maps:     r.check.Maps(),
+		MsgBatch: message.QuickBatch(nil),
+	}.WithValue(value))
+	if err != nil {
+		return []docs.Lint{docs.NewLintError(line, fmt.Sprintf("policy rule %v check failed: %v", r.Name, err))}
+	}
+
+	passed, ok := res.(bool)
+	if !ok {
+		return []docs.Lint{docs.NewLintError(line, fmt.Sprintf("policy rule %v check returned a non-boolean value: %v", r.Name, query.ITypeOf(res)))}
+	}
+	if !passed {
+		return []docs.Lint{failed}
+	}
+	return nil
+}
+
+// Policy is a collection of user defined linting rules, such as those of an
+// organisation that forbid certain components or require certain fields.
+type Policy struct {
+	Rules []PolicyRule `yaml:"rules"`
+}
+
+// ReadPolicyFiles reads one or more policy files and returns a policy
+// containing the rules of all of them.
+func ReadPolicyFiles(paths ...string) (*Policy, error) {
+	env := bloblang.NewEnvironment().OnlyPure()
+
+	var p Policy
+	for _, path := range paths {
+		policyBytes, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		var filePolicy Policy
+		dec := yaml.NewDecoder(bytes.NewReader(policyBytes))
+		dec.KnownFields(true)
+		if err := dec.Decode(&filePolicy); err != nil {
+			return nil, fmt.Errorf("%v: %w", path, err)
+		}
+
+		for i := range filePolicy.Rules {
+			if err := filePolicy.Rules[i].init(env); err != nil {
+				return nil, fmt.Errorf("%v: %w", path, err)
+			}
+		}
+		p.Rules = append(p.Rules, filePolicy.Rules...)
+	}
+	return &p, nil
+}
+
+// LintConfig checks the rules of the policy that do not filter components
+// against the root of a config, where the value of `this` is the config as it
+// was written.
+func (p *Policy) LintConfig(ctx docs.LintContext, node *yaml.Node) (lints []docs.Lint) {
+	var rules []*PolicyRule
+	for i := range p.Rules {
+		if !p.Rules[i].isComponentRule() {
+			rules = append(rules, &p.Rules[i])
+		}
+	}
+	if len(rules) == 0 {
+		return nil
+	}
+
+	var value interface{}
+	if err := node.Decode(&value); err != nil {
+		return []docs.Lint{docs.NewLintError(node.Line, fmt.Sprintf("failed to decode config for policy rules: %v", err))}
+	}
+	if value == nil {
+		value = map[string]interface{}{}
+	}
+	for _, r := range rules {
+		lints = append(lints, r.lint(node.Line, value)...)
+	}
+	return
+}
+
+// LintComponent checks the rules of the policy that match a component against
+// it, where the value of `this` is an object containing the `type`, `name`,
+// `label` and `config` of the component.
+func (p *Policy) LintComponent(ctx docs.LintContext, cType docs.Type, name string, node *yaml.Node) (lints []docs.Lint) {
+	var rules []*PolicyRule
+	for i := range p.Rules {
+		if p.Rules[i].matchesComponent(cType, name) {
+			rules = append(rules, &p.Rules[i])
+		}
+	}
+	if len(rules) == 0 {
+		return nil
+	}
+
+	var raw map[string]interface{}
+	if err := node.Decode(&raw); err != nil {
+		return []docs.Lint{docs.NewLintError(node.Line, fmt.Sprintf("failed to decode component for policy rules: %v", err))}
+	}
+
+	label, _ := raw["label"].(string)
+	conf, exists := raw[name]
+	if !exists {
+		conf = raw["plugin"]
+	}
+	if conf == nil {
+		conf = map[string]interface{}{}
+	}
+	value := map[string]interface{}{
+		"type":   string(cType),
+		"name":   name,
+		"label":  label,
+		"config": conf,
+	}
+	for _, r := range rules {
+		lints = append(lints, r.lint(node.Line, value)...)
+	}
+	return
+}
@@ -0,0 +1,154 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/docs"
+)
+
+func TestPolicyLint(t *testing.T) {
+	policyPath := filepath.Join(t.TempDir(), "policy.yaml")
+	require.NoError(t, os.WriteFile(policyPath, []byte(`
+rules:
+  - name: require_labels
+    description: Inputs and outputs must be labelled.
+    component_types: [ input, output ]
+    check: this.label != ""
+  - name: no_stdout
+    component_types: [ output ]
+    components: [ stdout ]
+  - name: kafka_tls
+    components: [ kafka ]
+    check: this.config.tls.enabled.or(false)
+  - name: max_batch_count
+    component_types: [ output ]
+    check: this.config.batching.count.or(0) <= 100
+  - name: require_prometheus
+    description: Metrics must be exported to prometheus.
+    check: this.metrics.prometheus != null
+`), 0o644))
+
+	policy, err := ReadPolicyFiles(policyPath)
+	require.NoError(t, err)
+
+	lintCtx := docs.NewLintContext()
+	lintCtx.Policy = policy
+
+	lints, err := LintBytes(lintCtx, []byte(`
+input:
+  label: foo
+  kafka:
+    addresses: [ localhost:9092 ]
+    topics: [ foo ]
+output:
+  broker:
+    outputs:
+      - stdout: {}
+      - label: bar
+        kafka:
+          addresses: [ localhost:9092 ]
+          topic: bar
+          tls:
+            enabled: true
+          batching:
+            count: 1000
+`))
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{
+		"line 3: policy rule kafka_tls violated",
+		"line 8: policy rule require_labels violated: Inputs and outputs must be labelled.",
+		"line 10: policy rule require_labels violated: Inputs and outputs must be labelled.",
+		"line 10: policy rule no_stdout violated",
+		"line 11: policy rule max_batch_count violated",
+		"line 2: policy rule require_prometheus violated: Metrics must be exported to prometheus.",
+	}, lints)
+
+	lintCtx = docs.NewLintContext()
+	lintCtx.Policy = policy
+
+	lints, err = LintBytes(lintCtx, []byte(`
+input:
+  label: foo
+  generate:
+    mapping: 'root = {}'
+output:
+  label: bar
+  drop: {}
+metrics:
+  prometheus: {}
+`))
+	require.NoError(t, err)
+	assert.Empty(t, lints)
+}
+
+func TestPolicyErrors(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		policy string
+		err    string
+	}{
+		{
+			name:   "missing name",
+			policy: `rules: [ { check: 'true' } ]`,
+			err:    "a rule must have a name",
+		},
+		{
+			name:   "unknown field",
+			policy: `rules: [ { name: foo, nope: 'true' } ]`,
+			err:    "field nope not found",
+		},
+		{
+			name:   "bad component type",
+			policy: `rules: [ { name: foo, component_types: [ nope ] } ]`,
+			err:    "rule foo: component type 'nope' was not recognised",
+		},
+		{
+			name:   "root rule without check",
+			policy: `rules: [ { name: foo } ]`,
+			err:    "rule foo: a check must be specified when a rule does not filter components",
+		},
+		{
+			name:   "bad check",
+			policy: `rules: [ { name: foo, check: 'this.' } ]`,
+			err:    "rule foo: failed to parse check",
+		},
+	} {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			policyPath := filepath.Join(t.TempDir(), "policy.yaml")
+			require.NoError(t, os.WriteFile(policyPath, []byte(test.policy), 0o644))
+
+			_, err := ReadPolicyFiles(policyPath)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), test.err)
+		})
+	}
+}
+
+func TestPolicyNonBooleanCheck(t *testing.T) {
+	policyPath := filepath.Join(t.TempDir(), "policy.yaml")
+	require.NoError(t, os.WriteFile(policyPath, []byte(`
+rules:
+  - name: foo
+    components: [ drop ]
+    check: this.name
+`), 0o644))
+
+	policy, err := ReadPolicyFiles(policyPath)
+	require.NoError(t, err)
+
+	lintCtx := docs.NewLintContext()
+	lintCtx.Policy = policy
+
+	lints, err := LintBytes(lintCtx, []byte(`
+output:
+  drop: {}
+`))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"line 3: policy rule foo check returned a non-boolean value: string"}, lints)
+}
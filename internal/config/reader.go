@@ -16,6 +16,7 @@ import (
 
 	"github.com/benthosdev/benthos/v4/internal/bundle"
 	"github.com/benthosdev/benthos/v4/internal/docs"
+	"github.com/benthosdev/benthos/v4/internal/secrets"
 	"github.com/benthosdev/benthos/v4/internal/stream"
 )
 
@@ -43,6 +44,7 @@ type Reader struct {
 	testSuffix string
 
 	mainPath      string
+	overlayPaths  []string
 	resourcePaths []string
 	streamsPaths  []string
 	overrides     []string
@@ -68,6 +70,13 @@ type Reader struct {
 
 	changeFlushPeriod time.Duration
 	changeDelayPeriod time.Duration
+
+	// When non-zero secrets are refreshed at this interval during file
+	// watching, and all config files are read again when any have changed.
+	secretsRefreshPeriod time.Duration
+
+	// Values of secrets referenced by config files read by this reader.
+	secrets *secrets.Cache
 }
 
 // NewReader creates a new config reader.
@@ -80,6 +89,7 @@ func NewReader(mainPath string, resourcePaths []string, opts ...OptFunc) *Reader
 		resourceFileInfo:  map[string]resourceFileInfo{},
 		changeFlushPeriod: defaultChangeFlushPeriod,
 		changeDelayPeriod: defaultChangeDelayPeriod,
+		secrets:           secrets.NewCache(),
 	}
 	for _, opt := range opts {
 		opt(r)
@@ -109,6 +119,24 @@ func OptAddOverrides(overrides ...string) OptFunc {
 	}
 }
 
+// OptAddOverlays adds one or more paths of config files that are merged on top
+// of the main config file in the order they are specified.
+func OptAddOverlays(paths ...string) OptFunc {
+	return func(r *Reader) {
+		r.overlayPaths = append(r.overlayPaths, paths...)
+	}
+}
+
+// OptSecretsRefreshPeriod configures an interval at which the secrets
+// referenced by config files are obtained again from their providers whilst
+// config files are being watched. When the value of any secret changes all
+// config files are read again as if they had been modified.
+func OptSecretsRefreshPeriod(period time.Duration) OptFunc {
+	return func(r *Reader) {
+		r.secretsRefreshPeriod = period
+	}
+}
+
 // OptSetStreamPaths marks this config reader as operating in streams mode, and
 // adds a list of paths to obtain individual stream configs from.
 func OptSetStreamPaths(streamsPaths ...string) OptFunc {
@@ -207,6 +235,13 @@ func (r *Reader) BeginFileWatching(mgr bundle.NewManagement, strict bool) error
 		ticker := time.NewTicker(r.changeFlushPeriod)
 		defer ticker.Stop()
 
+		var refreshChan <-chan time.Time
+		if r.secretsRefreshPeriod > 0 {
+			refreshTicker := time.NewTicker(r.secretsRefreshPeriod)
+			defer refreshTicker.Stop()
+			refreshChan = refreshTicker.C
+		}
+
 		collapsedChanges := map[string]time.Time{}
 		lostNames := map[string]struct{}{}
 		for {
@@ -230,7 +265,7 @@ func (r *Reader) BeginFileWatching(mgr bundle.NewManagement, strict bool) error
 						continue
 					}
 					var succeeded bool
-					if nameClean == filepath.Clean(r.mainPath) {
+					if r.isMainPath(nameClean) {
 						succeeded = r.reactMainUpdate(mgr, strict)
 					} else if _, exists := r.streamFileInfo[nameClean]; exists {
 						succeeded = r.reactStreamUpdate(mgr, strict, nameClean)
@@ -249,6 +284,17 @@ func (r *Reader) BeginFileWatching(mgr bundle.NewManagement, strict bool) error
 						delete(lostNames, lostName)
 					}
 				}
+			case <-refreshChan:
+				changed, err := r.secrets.Refresh(context.Background())
+				if err != nil {
+					mgr.Logger().Errorf("Failed to refresh secrets: %v", err)
+				}
+				if changed {
+					mgr.Logger().Infoln("Secrets have changed, reading config files again.")
+					for _, name := range r.watchedPaths() {
+						collapsedChanges[name] = time.Time{}
+					}
+				}
 			case err, ok := <-watcher.Errors:
 				if !ok {
 					return
@@ -259,9 +305,11 @@ func (r *Reader) BeginFileWatching(mgr bundle.NewManagement, strict bool) error
 	}()
 
 	if !r.streamsMode && r.mainPath != "" {
-		if err := watcher.Add(r.mainPath); err != nil {
-			_ = watcher.Close()
-			return err
+		for _, p := range append([]string{r.mainPath}, r.overlayPaths...) {
+			if err := watcher.Add(p); err != nil {
+				_ = watcher.Close()
+				return err
+			}
 		}
 	}
 
@@ -301,6 +349,39 @@ func (r *Reader) Close(ctx context.Context) error {
 
 //------------------------------------------------------------------------------
 
+// watchedPaths returns the cleaned paths of all config files that have been
+// read and are therefore watched for changes. Overlays are omitted as they're
+// read along with the main config.
+func (r *Reader) watchedPaths() (paths []string) {
+	if !r.streamsMode && r.mainPath != "" {
+		paths = append(paths, filepath.Clean(r.mainPath))
+	}
+	for k := range r.streamFileInfo {
+		paths = append(paths, k)
+	}
+
+	r.resourceFileInfoMut.Lock()
+	for k := range r.resourceFileInfo {
+		paths = append(paths, k)
+	}
+	r.resourceFileInfoMut.Unlock()
+	return
+}
+
+// isMainPath returns whether a cleaned path is that of the main config file or
+// one of its overlays.
+func (r *Reader) isMainPath(nameClean string) bool {
+	if nameClean == filepath.Clean(r.mainPath) {
+		return true
+	}
+	for _, p := range r.overlayPaths {
+		if nameClean == filepath.Clean(p) {
+			return true
+		}
+	}
+	return false
+}
+
 func applyOverrides(specs docs.FieldSpecs, root *yaml.Node, overrides ...string) error {
 	for _, override := range overrides {
 		eqIndex := strings.Index(override, "=")
@@ -339,7 +420,7 @@ func (r *Reader) readMain(conf *Type) (lints []string, err error) {
 	var rawNode yaml.Node
 	var confBytes []byte
 	if r.mainPath != "" {
-		if confBytes, lints, err = ReadFileEnvSwap(r.mainPath); err != nil {
+		if confBytes, lints, err = readFileEnvSwap(r.secrets, r.mainPath); err != nil {
 			return
 		}
 		if err = yaml.Unmarshal(confBytes, &rawNode); err != nil {
@@ -360,21 +441,64 @@ func (r *Reader) readMain(conf *Type) (lints []string, err error) {
 		// input, output, etc)
 		confSpec = SpecWithoutStream()
 	}
+
+	lintFilePrefix := ""
+	if r.mainPath != "" {
+		lintFilePrefix = fmt.Sprintf("%v: ", r.mainPath)
+	}
+	lintMain := !bytes.HasPrefix(confBytes, []byte("# BENTHOS LINT DISABLE"))
+
+	if len(r.overlayPaths) > 0 {
+		// Files are linted individually before they're merged in order for
+		// lints to refer to the lines of the file they belong to.
+		if lintMain {
+			lints = append(lints, lintMainNode(confSpec, lintFilePrefix, &rawNode)...)
+			lintMain = false
+		}
+		for _, overlayPath := range r.overlayPaths {
+			var oLints []string
+			if oLints, err = r.readOverlay(confSpec, overlayPath, &rawNode); err != nil {
+				return
+			}
+			lints = append(lints, oLints...)
+		}
+	}
+
 	if err = applyOverrides(confSpec, &rawNode, r.overrides...); err != nil {
 		return
 	}
+	if lintMain {
+		lints = append(lints, lintMainNode(confSpec, lintFilePrefix, &rawNode)...)
+	}
+
+	err = rawNode.Decode(conf)
+	return
+}
+
+func lintMainNode(confSpec docs.FieldSpecs, lintFilePrefix string, rawNode *yaml.Node) (lints []string) {
+	for _, lint := range confSpec.LintYAML(docs.NewLintContext(), rawNode) {
+		lints = append(lints, fmt.Sprintf("%vline %v: %v", lintFilePrefix, lint.Line, lint.What))
+	}
+	return
+}
+
+// readOverlay reads and lints a config file and merges it on top of a main
+// config.
+func (r *Reader) readOverlay(confSpec docs.FieldSpecs, path string, rawNode *yaml.Node) (lints []string, err error) {
+	var confBytes []byte
+	if confBytes, lints, err = readFileEnvSwap(r.secrets, path); err != nil {
+		return
+	}
 
+	var overlayNode yaml.Node
+	if err = yaml.Unmarshal(confBytes, &overlayNode); err != nil {
+		return nil, fmt.Errorf("overlay %v: %w", path, err)
+	}
 	if !bytes.HasPrefix(confBytes, []byte("# BENTHOS LINT DISABLE")) {
-		lintFilePrefix := ""
-		if r.mainPath != "" {
-			lintFilePrefix = fmt.Sprintf("%v: ", r.mainPath)
-		}
-		for _, lint := range confSpec.LintYAML(docs.NewLintContext(), &rawNode) {
-			lints = append(lints, fmt.Sprintf("%vline %v: %v", lintFilePrefix, lint.Line, lint.What))
-		}
+		lints = append(lints, lintMainNode(confSpec, fmt.Sprintf("%v: ", path), &overlayNode)...)
 	}
 
-	err = rawNode.Decode(conf)
+	mergeOverlay(rawNode, &overlayNode)
 	return
 }
 
@@ -1,8 +1,10 @@
 package config
 
 import (
+	"context"
 	"os"
 	"path/filepath"
+	"sync"
 	"testing"
 	"time"
 
@@ -12,6 +14,7 @@ import (
 	"github.com/benthosdev/benthos/v4/internal/component/metrics"
 	"github.com/benthosdev/benthos/v4/internal/log"
 	"github.com/benthosdev/benthos/v4/internal/manager"
+	"github.com/benthosdev/benthos/v4/internal/secrets"
 	"github.com/benthosdev/benthos/v4/internal/stream"
 )
 
@@ -127,3 +130,55 @@ output:
 	assert.Equal(t, "kafka", updatedConf.Input.Type)
 	assert.Equal(t, "aws_s3", updatedConf.Output.Type)
 }
+
+func TestReaderSecretsRefresh(t *testing.T) {
+	var secretMut sync.Mutex
+	secretValue := "first"
+	require.NoError(t, secrets.RegisterProvider("test_reader", func(ctx context.Context, path string) (string, error) {
+		secretMut.Lock()
+		defer secretMut.Unlock()
+		return secretValue, nil
+	}))
+
+	confFilePath := filepath.Join(t.TempDir(), "main.yaml")
+	require.NoError(t, os.WriteFile(confFilePath, []byte(`
+input:
+  generate:
+    mapping: 'root = "${secret:test_reader://foo}"'
+output:
+  drop: {}
+`), 0o644))
+
+	rdr := newDummyReader(confFilePath)
+	rdr.secretsRefreshPeriod = time.Millisecond
+
+	conf := New()
+	_, err := rdr.Read(&conf)
+	require.NoError(t, err)
+	assert.Equal(t, `root = "first"`, conf.Input.Generate.Mapping)
+
+	changeChan := make(chan stream.Config, 1)
+	require.NoError(t, rdr.SubscribeConfigChanges(func(conf stream.Config) bool {
+		select {
+		case changeChan <- conf:
+		default:
+		}
+		return true
+	}))
+
+	testMgr, err := manager.NewV2(manager.NewResourceConfig(), nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	require.NoError(t, rdr.BeginFileWatching(testMgr, true))
+	defer rdr.Close(context.Background())
+
+	secretMut.Lock()
+	secretValue = "second"
+	secretMut.Unlock()
+
+	select {
+	case updatedConf := <-changeChan:
+		assert.Equal(t, `root = "second"`, updatedConf.Input.Generate.Mapping)
+	case <-time.After(time.Second):
+		require.FailNow(t, "Expected a config change to be triggered")
+	}
+}
@@ -81,7 +81,7 @@ func (r *Reader) readResources(conf *manager.ResourceConfig) (lints []string, er
 	for _, path := range resourcesPaths {
 		rconf := manager.NewResourceConfig()
 		var rLints []string
-		if rLints, err = readResource(path, &rconf); err != nil {
+		if rLints, err = r.readResource(path, &rconf); err != nil {
 			return
 		}
 		lints = append(lints, rLints...)
@@ -95,7 +95,7 @@ func (r *Reader) readResources(conf *manager.ResourceConfig) (lints []string, er
 	return
 }
 
-func readResource(path string, conf *manager.ResourceConfig) (lints []string, err error) {
+func (r *Reader) readResource(path string, conf *manager.ResourceConfig) (lints []string, err error) {
 	defer func() {
 		if err != nil {
 			err = fmt.Errorf("%v: %w", path, err)
@@ -103,7 +103,7 @@ func readResource(path string, conf *manager.ResourceConfig) (lints []string, er
 	}()
 
 	var confBytes []byte
-	if confBytes, lints, err = ReadFileEnvSwap(path); err != nil {
+	if confBytes, lints, err = readFileEnvSwap(r.secrets, path); err != nil {
 		return
 	}
 
@@ -136,7 +136,7 @@ func (r *Reader) reactResourceUpdate(mgr bundle.NewManagement, strict bool, path
 	mgr.Logger().Infof("Resource %v config updated, attempting to update resources.", path)
 
 	newResConf := manager.NewResourceConfig()
-	lints, err := readResource(path, &newResConf)
+	lints, err := r.readResource(path, &newResConf)
 	if err != nil {
 		mgr.Logger().Errorf("Failed to read updated resources config: %v", err)
 		return true
@@ -16,11 +16,12 @@ type Type struct {
 	HTTP                   api.Config `json:"http" yaml:"http"`
 	stream.Config          `json:",inline" yaml:",inline"`
 	manager.ResourceConfig `json:",inline" yaml:",inline"`
-	Logger                 log.Config     `json:"logger" yaml:"logger"`
-	Metrics                metrics.Config `json:"metrics" yaml:"metrics"`
-	Tracer                 tracer.Config  `json:"tracer" yaml:"tracer"`
-	SystemCloseTimeout     string         `json:"shutdown_timeout" yaml:"shutdown_timeout"`
-	Tests                  []interface{}  `json:"tests,omitempty" yaml:"tests,omitempty"`
+	Logger                 log.Config            `json:"logger" yaml:"logger"`
+	Metrics                metrics.Config        `json:"metrics" yaml:"metrics"`
+	Tracer                 tracer.Config         `json:"tracer" yaml:"tracer"`
+	SystemCloseTimeout     string                `json:"shutdown_timeout" yaml:"shutdown_timeout"`
+	Shutdown               stream.ShutdownConfig `json:"shutdown" yaml:"shutdown"`
+	Tests                  []interface{}         `json:"tests,omitempty" yaml:"tests,omitempty"`
 }
 
 // New returns a new configuration with default values.
@@ -33,6 +34,7 @@ func New() Type {
 		Metrics:            metrics.NewConfig(),
 		Tracer:             tracer.NewConfig(),
 		SystemCloseTimeout: "20s",
+		Shutdown:           stream.NewShutdownConfig(),
 		Tests:              nil,
 	}
 }
@@ -44,6 +46,7 @@ var observabilityFields = docs.FieldSpecs{
 	docs.FieldMetrics("metrics", "A mechanism for exporting metrics.").Optional(),
 	docs.FieldTracer("tracer", "A mechanism for exporting traces.").Optional(),
 	docs.FieldString("shutdown_timeout", "The maximum period of time to wait for a clean shutdown. If this time is exceeded Benthos will forcefully close.").HasDefault("20s"),
+	stream.ShutdownSpec(),
 }
 
 // Spec returns a docs.FieldSpec for an entire Benthos configuration.
@@ -14,6 +14,7 @@ import (
 	tdocs "github.com/benthosdev/benthos/v4/internal/cli/test/docs"
 	"github.com/benthosdev/benthos/v4/internal/docs"
 	ifilepath "github.com/benthosdev/benthos/v4/internal/filepath"
+	"github.com/benthosdev/benthos/v4/internal/secrets"
 	"github.com/benthosdev/benthos/v4/internal/stream"
 )
 
@@ -43,10 +44,14 @@ func InferStreamID(dir, path string) (string, error) {
 
 // ReadStreamFile attempts to read a stream config and returns the result
 func ReadStreamFile(path string) (conf stream.Config, lints []string, err error) {
+	return readStreamConfig(secrets.NewCache(), path)
+}
+
+func readStreamConfig(secretsCache *secrets.Cache, path string) (conf stream.Config, lints []string, err error) {
 	conf = stream.NewConfig()
 
 	var confBytes []byte
-	if confBytes, lints, err = ReadFileEnvSwap(path); err != nil {
+	if confBytes, lints, err = readFileEnvSwap(secretsCache, path); err != nil {
 		return
 	}
 
@@ -83,7 +88,7 @@ func (r *Reader) readStreamFile(dir, path string, confs map[string]stream.Config
 		return nil, fmt.Errorf("stream id (%v) collision from file: %v", id, path)
 	}
 
-	conf, lints, err := ReadStreamFile(path)
+	conf, lints, err := readStreamConfig(r.secrets, path)
 	if err != nil {
 		return nil, err
 	}
@@ -163,7 +168,7 @@ func (r *Reader) reactStreamUpdate(mgr bundle.NewManagement, strict bool, path s
 
 	mgr.Logger().Infof("Stream %v config updated, attempting to update stream.", info.id)
 
-	conf, lints, err := ReadStreamFile(path)
+	conf, lints, err := readStreamConfig(r.secrets, path)
 	if err != nil {
 		mgr.Logger().Errorf("Failed to read updated stream config: %v", err)
 		return true
@@ -76,6 +76,10 @@ func ReservedFieldsByType(t Type) map[string]FieldSpec {
 	}
 	if t == TypeMetrics {
 		m["mapping"] = MetricsMappingFieldSpec("mapping")
+		m["max_label_cardinality"] = MetricsLabelCardinalityFieldSpec("max_label_cardinality")
+	}
+	if t == TypeTracer {
+		m["sampling"] = TracerSamplingFieldSpec("sampling")
 	}
 	if _, isLabelType := map[Type]struct{}{
 		TypeInput:     {},
@@ -90,6 +90,11 @@ type FieldSpec struct {
 	// for backwards compatibility reasons.
 	IsDeprecated bool `json:"is_deprecated,omitempty"`
 
+	// IsSecret indicates that a field contains sensitive information that
+	// should be provided from an environment variable or secret provider
+	// rather than directly within a config.
+	IsSecret bool `json:"is_secret,omitempty"`
+
 	// IsOptional is a boolean flag indicating that a field is optional, even
 	// if there is no default. This prevents linting errors when the field
 	// is missing.
@@ -117,6 +122,10 @@ type FieldSpec struct {
 	// Children fields of this field (it must be an object).
 	Children FieldSpecs `json:"children,omitempty"`
 
+	// Shorthand is the name of a child field that can be set by specifying the
+	// object as the value of that field instead.
+	Shorthand string `json:"shorthand,omitempty"`
+
 	// Version is an explicit version when this field was introduced.
 	Version string `json:"version,omitempty"`
 
@@ -126,6 +135,7 @@ type FieldSpec struct {
 
 	omitWhenFn   func(field, parent interface{}) (why string, shouldOmit bool)
 	customLintFn LintFunc
+	migrateFn    FieldMigrateFunc
 }
 
 // IsInterpolated indicates that the field supports interpolation functions.
@@ -171,6 +181,20 @@ func (f FieldSpec) Deprecated() FieldSpec {
 	return f
 }
 
+// Secret marks this field as containing sensitive information.
+func (f FieldSpec) Secret() FieldSpec {
+	f.IsSecret = true
+	return f
+}
+
+// MigrateWith adds a function to a deprecated field that rewrites a config
+// using the field into an equivalent config that does not, allowing it to be
+// migrated automatically.
+func (f FieldSpec) MigrateWith(fn FieldMigrateFunc) FieldSpec {
+	f.migrateFn = fn
+	return f
+}
+
 // Array determines that this field is an array of the field type.
 func (f FieldSpec) Array() FieldSpec {
 	f.Kind = KindArray
@@ -248,6 +272,14 @@ func (f FieldSpec) WithChildren(children ...FieldSpec) FieldSpec {
 	return f
 }
 
+// HasShorthand indicates that an object field can also be specified as a scalar
+// or array value, which is equivalent to an object where only the named child
+// field is set to that value.
+func (f FieldSpec) HasShorthand(child string) FieldSpec {
+	f.Shorthand = child
+	return f
+}
+
 // OmitWhen specifies a custom func that, when provided a generic config struct,
 // returns a boolean indicating when the field can be safely omitted from a
 // config.
@@ -563,6 +595,9 @@ type LintContext struct {
 
 	// Reject any deprecated components or fields as linting errors.
 	RejectDeprecated bool
+
+	// An optional policy of additional rules to check components against.
+	Policy LintPolicy
 }
 
 // NewLintContext creates a new linting context.
@@ -37,6 +37,8 @@ func FieldsTemplate(lintableExamples bool) string {
 {{$field.Spec.Description}}
 {{if $field.Spec.Interpolated -}}
 This field supports [interpolation functions](/docs/configuration/interpolation#bloblang-queries).
+{{end}}{{if $field.Spec.IsSecret -}}
+This field contains sensitive information, consider providing it from an environment variable or a [secret provider](/docs/configuration/interpolation#secrets).
 {{end}}
 
 Type: {{if eq $field.Spec.Kind "array"}}list of {{end}}{{if eq $field.Spec.Kind "map"}}map of {{end}}` + "`{{$field.Spec.Type}}`" + `  
@@ -44,6 +44,15 @@ func (f FieldSpec) JSONSchema() interface{} {
 				spec["required"] = required
 			}
 			spec["additionalProperties"] = false
+			if f.Shorthand != "" {
+				for _, child := range f.Children {
+					if child.Name == f.Shorthand {
+						spec = map[string]interface{}{
+							"anyOf": []interface{}{spec, child.JSONSchema()},
+						}
+					}
+				}
+			}
 		case FieldTypeInput:
 			spec["$ref"] = "#/$defs/input"
 		case FieldTypeBuffer:
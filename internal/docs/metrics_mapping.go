@@ -14,3 +14,10 @@ func MetricsMappingFieldSpec(name string) FieldSpec {
 	summary := "An optional [Bloblang mapping](/docs/guides/bloblang/about) that allows you to rename or prevent certain metrics paths from being exported. For more information check out the [metrics documentation](/docs/components/metrics/about#metric-mapping). When metric paths are created, renamed and dropped a trace log is written, enabling TRACE level logging is therefore a good way to diagnose path mappings."
 	return FieldBloblang(name, summary, examples...).HasDefault("")
 }
+
+// MetricsLabelCardinalityFieldSpec is a field spec that describes a limit on
+// the number of unique label sets of each metric.
+func MetricsLabelCardinalityFieldSpec(name string) FieldSpec {
+	summary := "An optional limit on the number of unique label sets (series) of each metric with labels that are dynamic, such as those of the `metric` processor. Once a metric reaches this limit any new series are aggregated into a single series where all dynamic labels have the value `__overflow__`, and the counter `metrics_cardinality_exceeded` is incremented with the label `metric` set to the metric name. For more information check out the [metrics documentation](/docs/components/metrics/about#label-cardinality). A value of zero disables the limit."
+	return FieldInt(name, summary).HasDefault(0).Advanced().AtVersion("4.1.0")
+}
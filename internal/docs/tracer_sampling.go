@@ -0,0 +1,14 @@
+package docs
+
+// TracerSamplingFieldSpec is a field spec that describes tail-based sampling
+// policies for tracers.
+func TracerSamplingFieldSpec(name string) FieldSpec {
+	return FieldObject(name, "Optional tail-based sampling policies that are evaluated once all spans of a trace have been collected, allowing high-volume pipelines to only export interesting traces. For more information check out the [tracers documentation](/docs/components/tracers/about#sampling).").WithChildren(
+		FieldString("decision_wait", "The maximum period of time to wait for the spans of a trace before the sampling policies are evaluated. A decision is made sooner if the local root span of a trace ends.").HasDefault("10s"),
+		FieldObject("policies", "A list of sampling policies, a trace is exported if any policy matches it. If empty then all traces are exported.").Array().WithChildren(
+			FieldString("type", "The type of sampling policy.").HasOptions("errors", "probabilistic", "rate_limit").HasDefault(""),
+			FieldFloat("ratio", "For `probabilistic` policies, the ratio of traces to sample, between 0 and 1.").HasDefault(0.0),
+			FieldFloat("traces_per_second", "For `rate_limit` policies, the maximum number of traces to sample per second.").HasDefault(0.0),
+		).HasDefault([]interface{}{}),
+	).Advanced().AtVersion("4.1.0")
+}
@@ -338,6 +338,9 @@ func (f FieldSpec) SanitiseYAML(node *yaml.Node, conf SanitiseConfig) error {
 				}
 			}
 		default:
+			if f.Shorthand != "" && node.Kind != yaml.MappingNode {
+				return nil
+			}
 			if err := f.Children.SanitiseYAML(node, conf); err != nil {
 				return err
 			}
@@ -388,6 +391,24 @@ func (f FieldSpecs) SanitiseYAML(node *yaml.Node, conf SanitiseConfig) error {
 
 //------------------------------------------------------------------------------
 
+// expandShorthand returns a node that is the object equivalent of a scalar or
+// array value of a field with a shorthand, otherwise the node is returned
+// unchanged.
+func (f FieldSpec) expandShorthand(node *yaml.Node) *yaml.Node {
+	if f.Shorthand == "" || node.Kind == yaml.MappingNode {
+		return node
+	}
+	return &yaml.Node{
+		Kind:   yaml.MappingNode,
+		Line:   node.Line,
+		Column: node.Column,
+		Content: []*yaml.Node{
+			{Kind: yaml.ScalarNode, Value: f.Shorthand, Line: node.Line, Column: node.Column},
+			node,
+		},
+	}
+}
+
 func lintYAMLFromOmit(parentSpec FieldSpecs, lintTargetSpec FieldSpec, parent, node *yaml.Node) []Lint {
 	why, shouldOmit := lintTargetSpec.shouldOmitYAML(parentSpec, node, parent)
 	if shouldOmit {
@@ -419,6 +440,14 @@ func customLintFromYAML(ctx LintContext, spec FieldSpec, node *yaml.Node) []Lint
 	return lints
 }
 
+// LintPolicy describes additional linting rules, such as those of an
+// organisation, that are checked against the root of a config and each
+// component within it.
+type LintPolicy interface {
+	LintConfig(ctx LintContext, node *yaml.Node) []Lint
+	LintComponent(ctx LintContext, cType Type, name string, node *yaml.Node) []Lint
+}
+
 // LintYAML takes a yaml.Node and a config spec and returns a list of linting
 // errors found in the config.
 func LintYAML(ctx LintContext, cType Type, node *yaml.Node) []Lint {
@@ -466,6 +495,10 @@ func LintYAML(ctx LintContext, cType Type, node *yaml.Node) []Lint {
 		lints = append(lints, NewLintError(node.Line, fmt.Sprintf("component %v is deprecated", cSpec.Name)))
 	}
 
+	if ctx.Policy != nil {
+		lints = append(lints, ctx.Policy.LintComponent(ctx, cType, name, node)...)
+	}
+
 	nameFound := false
 	for i := 0; i < len(node.Content)-1; i += 2 {
 		if node.Content[i].Value == name {
@@ -556,7 +589,7 @@ func (f FieldSpec) LintYAML(ctx LintContext, node *yaml.Node) []Lint {
 
 	// If the field has children then lint the child fields
 	if len(f.Children) > 0 {
-		return append(lints, f.Children.LintYAML(ctx, node)...)
+		return append(lints, f.Children.LintYAML(ctx, f.expandShorthand(node))...)
 	}
 
 	// Otherwise we're a leaf node, so do basic type checking
@@ -785,7 +818,7 @@ func (f FieldSpec) YAMLToValue(node *yaml.Node, conf ToValueConfig) (interface{}
 		}
 		return b, nil
 	case FieldTypeObject:
-		return f.Children.YAMLToMap(node, conf)
+		return f.Children.YAMLToMap(f.expandShorthand(node), conf)
 	}
 
 	if conf.FallbackToInterface {
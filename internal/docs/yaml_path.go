@@ -366,7 +366,7 @@ func (f FieldSpec) YAMLLabelsToPaths(docsProvider Provider, node *yaml.Node, lab
 			}
 			coreFields.YAMLLabelsToPaths(docsProvider, node, labelsToPaths, path)
 		} else if len(f.Children) > 0 {
-			f.Children.YAMLLabelsToPaths(docsProvider, node, labelsToPaths, path)
+			f.Children.YAMLLabelsToPaths(docsProvider, f.expandShorthand(node), labelsToPaths, path)
 		} else if f.Name == labelField.Name && f.Description == labelField.Description {
 			pathCopy := make([]string, len(path)-1)
 			copy(pathCopy, path[:len(path)-1])
@@ -967,6 +967,32 @@ func TestYAMLLinting(t *testing.T) {
 				docs.NewLintError(1, "expected object value"),
 			},
 		},
+		{
+			name: "object shorthand",
+			inputSpec: docs.FieldObject("foo", "").WithChildren(
+				docs.FieldString("bar", ""),
+				docs.FieldString("baz", "").HasDefault(""),
+			).HasShorthand("bar"),
+			inputConf: `"foo"`,
+		},
+		{
+			name: "object shorthand wrong type",
+			inputSpec: docs.FieldObject("foo", "").WithChildren(
+				docs.FieldString("bar", ""),
+			).HasShorthand("bar"),
+			inputConf: `[ "foo" ]`,
+			res: []docs.Lint{
+				docs.NewLintError(1, "expected string value"),
+			},
+		},
+		{
+			name: "object array shorthand",
+			inputSpec: docs.FieldObject("foo", "").WithChildren(
+				docs.FieldString("bar", "").Array(),
+				docs.FieldString("baz", "").HasDefault(""),
+			).HasShorthand("bar"),
+			inputConf: `[ "foo", "bar" ]`,
+		},
 		{
 			name: "expected string got object",
 			inputSpec: docs.FieldObject("foo", "").WithChildren(
@@ -0,0 +1,282 @@
+package docs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+// WalkYAMLConfig describes the callbacks to execute when walking a parsed
+// config with its spec.
+type WalkYAMLConfig struct {
+	DocsProvider Provider
+
+	// OnComponent is called for each component within the config, where the
+	// path is the dot path of the component from the root of the config.
+	OnComponent func(path string, cType Type, spec ComponentSpec, node *yaml.Node) error
+
+	// OnField is called for each field within the config that has a spec,
+	// where the path is the dot path of the field from the root of the config
+	// and parent is the object node that contains the field.
+	OnField func(path string, spec FieldSpec, parent, value *yaml.Node) error
+}
+
+// NewWalkYAMLConfig creates a new walk config.
+func NewWalkYAMLConfig() WalkYAMLConfig {
+	return WalkYAMLConfig{
+		DocsProvider: DeprecatedProvider,
+	}
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// WalkComponentYAML walks a parsed config of a component along with its spec,
+// calling the callbacks of the walk config for the component and all fields
+// and components nested within it.
+func WalkComponentYAML(cType Type, node *yaml.Node, conf WalkYAMLConfig) error {
+	return walkComponentYAML("", cType, node, conf)
+}
+
+func walkComponentYAML(path string, cType Type, node *yaml.Node, conf WalkYAMLConfig) error {
+	node = unwrapDocumentNode(node)
+	if node.Kind != yaml.MappingNode || len(node.Content) == 0 {
+		return nil
+	}
+
+	name, cSpec, err := GetInferenceCandidateFromYAML(conf.DocsProvider, cType, node)
+	if err != nil {
+		return fmt.Errorf("line %v: %w", node.Line, err)
+	}
+
+	if conf.OnComponent != nil {
+		if err := conf.OnComponent(path, cType, cSpec, node); err != nil {
+			return err
+		}
+	}
+
+	reservedFields := ReservedFieldsByType(cType)
+	for i := 0; i < len(node.Content)-1; i += 2 {
+		key, value := node.Content[i].Value, node.Content[i+1]
+		if key == name || (key == "plugin" && cSpec.Plugin) {
+			if err := cSpec.Config.walkYAML(joinPath(path, key), value, conf); err != nil {
+				return err
+			}
+			continue
+		}
+		if key == "type" {
+			continue
+		}
+		spec, exists := reservedFields[key]
+		if !exists {
+			continue
+		}
+		fieldPath := joinPath(path, key)
+		if conf.OnField != nil {
+			if err := conf.OnField(fieldPath, spec, node, value); err != nil {
+				return err
+			}
+		}
+		if err := spec.walkYAML(fieldPath, value, conf); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WalkYAML walks a parsed config along with the spec of the field, calling the
+// callbacks of the walk config for all fields and components nested within it.
+func (f FieldSpec) WalkYAML(node *yaml.Node, conf WalkYAMLConfig) error {
+	return f.walkYAML("", node, conf)
+}
+
+func (f FieldSpec) walkYAML(path string, node *yaml.Node, conf WalkYAMLConfig) error {
+	node = unwrapDocumentNode(node)
+
+	var walkFn func(path string, node *yaml.Node) error
+	if coreType, isCore := f.Type.IsCoreComponent(); isCore {
+		walkFn = func(path string, node *yaml.Node) error {
+			return walkComponentYAML(path, coreType, node, conf)
+		}
+	} else if len(f.Children) > 0 {
+		walkFn = func(path string, node *yaml.Node) error {
+			return f.Children.walkYAML(path, f.expandShorthand(node), conf)
+		}
+	} else {
+		return nil
+	}
+
+	switch f.Kind {
+	case Kind2DArray:
+		for i := 0; i < len(node.Content); i++ {
+			for j := 0; j < len(node.Content[i].Content); j++ {
+				if err := walkFn(joinPath(path, strconv.Itoa(i)+"."+strconv.Itoa(j)), node.Content[i].Content[j]); err != nil {
+					return err
+				}
+			}
+		}
+	case KindArray:
+		for i := 0; i < len(node.Content); i++ {
+			if err := walkFn(joinPath(path, strconv.Itoa(i)), node.Content[i]); err != nil {
+				return err
+			}
+		}
+	case KindMap:
+		for i := 0; i < len(node.Content)-1; i += 2 {
+			if err := walkFn(joinPath(path, node.Content[i].Value), node.Content[i+1]); err != nil {
+				return err
+			}
+		}
+	default:
+		return walkFn(path, node)
+	}
+	return nil
+}
+
+// WalkYAML walks a parsed config object along with the spec of its fields,
+// calling the callbacks of the walk config for all fields and components
+// nested within it.
+func (f FieldSpecs) WalkYAML(node *yaml.Node, conf WalkYAMLConfig) error {
+	return f.walkYAML("", node, conf)
+}
+
+func (f FieldSpecs) walkYAML(path string, node *yaml.Node, conf WalkYAMLConfig) error {
+	node = unwrapDocumentNode(node)
+
+	fieldMap := map[string]FieldSpec{}
+	for _, field := range f {
+		fieldMap[field.Name] = field
+	}
+
+	for i := 0; i < len(node.Content)-1; i += 2 {
+		spec, exists := fieldMap[node.Content[i].Value]
+		if !exists {
+			continue
+		}
+		fieldPath := joinPath(path, spec.Name)
+		if conf.OnField != nil {
+			if err := conf.OnField(fieldPath, spec, node, node.Content[i+1]); err != nil {
+				return err
+			}
+		}
+		if err := spec.walkYAML(fieldPath, node.Content[i+1], conf); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+// FieldMigrateFunc rewrites the parent object of a deprecated field such that
+// the behaviour of the config is unchanged without the field, which is removed
+// from the parent once the function returns.
+type FieldMigrateFunc func(value, parent *yaml.Node) error
+
+// CanMigrate returns true if the field is able to be migrated automatically.
+func (f FieldSpec) CanMigrate() bool {
+	return f.migrateFn != nil
+}
+
+// MigrateYAML removes the field from a parent object node after rewriting the
+// parent with the migration function of the field.
+func (f FieldSpec) MigrateYAML(parent *yaml.Node) error {
+	if f.migrateFn == nil {
+		return fmt.Errorf("field %v cannot be migrated automatically", f.Name)
+	}
+	for i := 0; i < len(parent.Content)-1; i += 2 {
+		if parent.Content[i].Value != f.Name {
+			continue
+		}
+		if err := f.migrateFn(parent.Content[i+1], parent); err != nil {
+			return fmt.Errorf("line %v: field %v: %w", parent.Content[i].Line, f.Name, err)
+		}
+		// The migration may have modified the parent, and therefore we find
+		// the field again before removing it.
+		RemoveYAMLField(parent, f.Name)
+		return nil
+	}
+	return nil
+}
+
+// RemoveYAMLField removes a field from an object node, returning true if the
+// field was found.
+func RemoveYAMLField(parent *yaml.Node, key string) bool {
+	for i := 0; i < len(parent.Content)-1; i += 2 {
+		if parent.Content[i].Value == key {
+			parent.Content = append(parent.Content[:i], parent.Content[i+2:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// SetYAMLField sets the value of a field within an object node to a value,
+// adding the field when it does not already exist.
+func SetYAMLField(parent *yaml.Node, key string, value interface{}) error {
+	if parent.Kind != yaml.MappingNode {
+		return errors.New("expected object value")
+	}
+
+	var valueNode yaml.Node
+	if err := valueNode.Encode(value); err != nil {
+		return err
+	}
+	for i := 0; i < len(parent.Content)-1; i += 2 {
+		if parent.Content[i].Value == key {
+			parent.Content[i+1] = &valueNode
+			return nil
+		}
+	}
+
+	var keyNode yaml.Node
+	if err := keyNode.Encode(key); err != nil {
+		return err
+	}
+	parent.Content = append(parent.Content, &keyNode, &valueNode)
+	return nil
+}
+
+func normaliseValue(v interface{}) (interface{}, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var n interface{}
+	err = json.Unmarshal(b, &n)
+	return n, err
+}
+
+// IsDefaultYAML returns true if the value of a parsed field is equal to the
+// default value of the field, and therefore could be omitted without changing
+// the behaviour of the config. Fields without a default value, and fields of
+// a component type, are never considered equal to their default.
+func (f FieldSpec) IsDefaultYAML(node *yaml.Node) bool {
+	if f.Default == nil {
+		return false
+	}
+	if _, isCore := f.Type.IsCoreComponent(); isCore {
+		return false
+	}
+
+	v, err := f.YAMLToValue(node, ToValueConfig{FallbackToInterface: true})
+	if err != nil {
+		return false
+	}
+	if v, err = normaliseValue(v); err != nil {
+		return false
+	}
+	defV, err := normaliseValue(*f.Default)
+	if err != nil {
+		return false
+	}
+	return reflect.DeepEqual(v, defV)
+}
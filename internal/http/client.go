@@ -3,10 +3,13 @@ package http
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
 	"io"
 	"mime"
 	"mime/multipart"
+	"net"
 	"net/http"
 	"net/textproto"
 	"net/url"
@@ -15,6 +18,8 @@ import (
 	"sync"
 	"time"
 
+	"go.opentelemetry.io/otel/propagation"
+
 	"github.com/benthosdev/benthos/v4/internal/bloblang/field"
 	"github.com/benthosdev/benthos/v4/internal/component"
 	"github.com/benthosdev/benthos/v4/internal/component/metrics"
@@ -51,6 +56,7 @@ type Client struct {
 	headers           map[string]*field.Expression
 	multipart         []MultipartExpressions
 	host              *field.Expression
+	rateLimitKey      *field.Expression
 	metaInsertFilter  *metadata.IncludeFilter
 	metaExtractFilter *metadata.IncludeFilter
 
@@ -69,6 +75,50 @@ type Client struct {
 	oauthClientCancel func()
 }
 
+// transport returns the transport of the client for modification, creating
+// one from the default transport when the client doesn't have one.
+func (h *Client) transport(field string) (*http.Transport, error) {
+	if h.client.Transport == nil {
+		if c, ok := http.DefaultTransport.(*http.Transport); ok {
+			h.client.Transport = c.Clone()
+		} else {
+			h.client.Transport = &http.Transport{}
+		}
+	}
+	tr, ok := h.client.Transport.(*http.Transport)
+	if !ok {
+		return nil, fmt.Errorf("unable to apply %v to transport, unexpected type %T", field, h.client.Transport)
+	}
+	return tr, nil
+}
+
+// newResolver returns a DNS resolver that queries a list of servers in order
+// until one of them can be reached.
+func newResolver(servers []string) *net.Resolver {
+	addrs := make([]string, len(servers))
+	for i, s := range servers {
+		if _, _, err := net.SplitHostPort(s); err != nil {
+			s = net.JoinHostPort(s, "53")
+		}
+		addrs[i] = s
+	}
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			var d net.Dialer
+			var errs []string
+			for _, addr := range addrs {
+				conn, err := d.DialContext(ctx, network, addr)
+				if err == nil {
+					return conn, nil
+				}
+				errs = append(errs, err.Error())
+			}
+			return nil, errors.New(strings.Join(errs, ", "))
+		},
+	}
+}
+
 // NewClient creates a new http client that sends and receives Benthos messages.
 func NewClient(conf docs.Config, opts ...func(*Client)) (*Client, error) {
 	h := Client{
@@ -128,6 +178,47 @@ func NewClient(conf docs.Config, opts ...func(*Client)) (*Client, error) {
 		}
 	}
 
+	if len(h.conf.DNSResolvers) > 0 {
+		tr, err := h.transport("dns_resolvers")
+		if err != nil {
+			return nil, err
+		}
+		dialer := &net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+			Resolver:  newResolver(h.conf.DNSResolvers),
+		}
+		tr.DialContext = dialer.DialContext
+	}
+
+	if h.conf.MaxIdleConnsPerHost < 0 {
+		return nil, fmt.Errorf("max_idle_conns_per_host must not be negative, got: %v", h.conf.MaxIdleConnsPerHost)
+	}
+	if h.conf.MaxIdleConnsPerHost > 0 {
+		tr, err := h.transport("max_idle_conns_per_host")
+		if err != nil {
+			return nil, err
+		}
+		tr.MaxIdleConnsPerHost = h.conf.MaxIdleConnsPerHost
+		if tr.MaxIdleConns > 0 && tr.MaxIdleConns < h.conf.MaxIdleConnsPerHost {
+			tr.MaxIdleConns = h.conf.MaxIdleConnsPerHost
+		}
+	}
+
+	if h.conf.TLSSessionCacheSize < 0 {
+		return nil, fmt.Errorf("tls_session_cache_size must not be negative, got: %v", h.conf.TLSSessionCacheSize)
+	}
+	if h.conf.TLSSessionCacheSize > 0 {
+		tr, err := h.transport("tls_session_cache_size")
+		if err != nil {
+			return nil, err
+		}
+		if tr.TLSClientConfig == nil {
+			tr.TLSClientConfig = &tls.Config{}
+		}
+		tr.TLSClientConfig.ClientSessionCache = tls.NewLRUClientSessionCache(h.conf.TLSSessionCacheSize)
+	}
+
 	for _, c := range conf.BackoffOn {
 		h.backoffOn[c] = struct{}{}
 	}
@@ -159,6 +250,10 @@ func NewClient(conf docs.Config, opts ...func(*Client)) (*Client, error) {
 		}
 	}
 
+	if h.rateLimitKey, err = h.mgr.BloblEnvironment().NewField(conf.RateLimitKey); err != nil {
+		return nil, fmt.Errorf("failed to parse rate limit key expression: %v", err)
+	}
+
 	if h.metaInsertFilter, err = h.conf.Metadata.CreateFilter(); err != nil {
 		return nil, fmt.Errorf("failed to construct metadata filter: %w", err)
 	}
@@ -261,15 +356,20 @@ func (h *Client) incrCode(code int) {
 	h.codesMut.Unlock()
 }
 
-func (h *Client) waitForAccess(ctx context.Context) bool {
+func (h *Client) waitForAccess(ctx context.Context, refMsg *message.Batch) bool {
 	if h.conf.RateLimit == "" {
 		return true
 	}
+	key := h.rateLimitKey.String(0, refMsg)
 	for {
 		var period time.Duration
 		var err error
 		if rerr := h.mgr.AccessRateLimit(ctx, h.conf.RateLimit, func(rl ratelimit.V1) {
-			period, err = rl.Access(ctx)
+			if key != "" {
+				period, err = rl.AccessKey(ctx, key)
+			} else {
+				period, err = rl.Access(ctx)
+			}
 		}); rerr != nil {
 			err = rerr
 		}
@@ -364,6 +464,9 @@ func (h *Client) CreateRequest(sendMsg, refMsg *message.Batch) (req *http.Reques
 			return nil
 		})
 	}
+	if sendMsg != nil && sendMsg.Len() > 0 {
+		tracing.InjectPartCarrier(sendMsg.Get(0), propagation.HeaderCarrier(req.Header))
+	}
 
 	if h.host != nil {
 		req.Host = h.host.String(0, refMsg)
@@ -520,7 +623,7 @@ func (h *Client) SendToResponse(ctx context.Context, sendMsg, refMsg *message.Ba
 		}
 	}()
 
-	if !h.waitForAccess(ctx) {
+	if !h.waitForAccess(ctx, refMsg) {
 		return nil, component.ErrTypeClosed
 	}
 
@@ -558,7 +661,7 @@ func (h *Client) SendToResponse(ctx context.Context, sendMsg, refMsg *message.Ba
 				return nil, component.ErrTypeClosed
 			}
 		}
-		if !h.waitForAccess(ctx) {
+		if !h.waitForAccess(ctx, refMsg) {
 			return nil, component.ErrTypeClosed
 		}
 		rateLimited = false
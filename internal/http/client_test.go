@@ -7,6 +7,7 @@ import (
 	"io"
 	"mime"
 	"mime/multipart"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"net/textproto"
@@ -469,3 +470,65 @@ func TestHTTPClientReceiveMultipart(t *testing.T) {
 		assert.Equal(t, "201", resMsg.Get(1).MetaGet("http_status_code"))
 	}
 }
+
+func TestHTTPClientTransportTuning(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("foo"))
+	}))
+	defer ts.Close()
+
+	conf := docs.NewConfig()
+	conf.URL = ts.URL + "/testpost"
+	conf.MaxIdleConnsPerHost = 200
+	conf.TLSSessionCacheSize = 10
+
+	h, err := NewClient(conf)
+	require.NoError(t, err)
+	defer h.Close(context.Background())
+
+	tr, ok := h.client.Transport.(*http.Transport)
+	require.True(t, ok)
+	assert.Equal(t, 200, tr.MaxIdleConnsPerHost)
+	assert.Equal(t, 200, tr.MaxIdleConns)
+	require.NotNil(t, tr.TLSClientConfig)
+	assert.NotNil(t, tr.TLSClientConfig.ClientSessionCache)
+
+	out := message.QuickBatch([][]byte{[]byte("test")})
+	resMsg, err := h.Send(context.Background(), out, out)
+	require.NoError(t, err)
+	assert.Equal(t, "foo", string(resMsg.Get(0).Get()))
+}
+
+func TestHTTPClientDNSResolvers(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	queried := make(chan struct{}, 1)
+	go func() {
+		buf := make([]byte, 512)
+		if _, _, err := conn.ReadFrom(buf); err == nil {
+			queried <- struct{}{}
+		}
+	}()
+
+	conf := docs.NewConfig()
+	conf.URL = "http://benthos.invalid/testpost"
+	conf.DNSResolvers = []string{conn.LocalAddr().String()}
+	conf.NumRetries = 0
+	conf.Timeout = "100ms"
+
+	h, err := NewClient(conf)
+	require.NoError(t, err)
+	defer h.Close(context.Background())
+
+	out := message.QuickBatch([][]byte{[]byte("test")})
+	_, err = h.Send(context.Background(), out, out)
+	require.Error(t, err)
+
+	select {
+	case <-queried:
+	case <-time.After(time.Second):
+		t.Fatal("expected a query to reach the configured resolver")
+	}
+}
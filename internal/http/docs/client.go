@@ -32,6 +32,7 @@ func ClientFieldSpec(forOutput bool, extraChildren ...docs.FieldSpec) docs.Field
 	httpSpecs = append(httpSpecs, tls.FieldSpec(),
 		docs.FieldObject("extract_headers", extractHeadersDesc).WithChildren(metadata.IncludeFilterDocs()...).Advanced(),
 		docs.FieldString("rate_limit", "An optional [rate limit](/docs/components/rate_limits/about) to throttle requests by."),
+		docs.FieldInterpolatedString("rate_limit_key", "An optional key to access the rate limit with, allowing each distinct key its own limit when supported by the rate limit.", `${! meta("api_key") }`).AtVersion("4.1.0").Advanced(),
 		docs.FieldString("timeout", "A static timeout to apply to requests."),
 		docs.FieldString("retry_period", "The base period to wait between failed requests.").Advanced(),
 		docs.FieldString("max_retry_backoff", "The maximum period to wait between failed requests.").Advanced(),
@@ -40,6 +41,9 @@ func ClientFieldSpec(forOutput bool, extraChildren ...docs.FieldSpec) docs.Field
 		docs.FieldInt("drop_on", "A list of status codes whereby the request should be considered to have failed but retries should not be attempted. This is useful for preventing wasted retries for requests that will never succeed. Note that with these status codes the _request_ is dropped, but _message_ that caused the request will not be dropped.").Array().Advanced(),
 		docs.FieldInt("successful_on", "A list of status codes whereby the attempt should be considered successful, this is useful for dropping requests that return non-2XX codes indicating that the message has been dealt with, such as a 303 See Other or a 409 Conflict. All 2XX codes are considered successful unless they are present within `backoff_on` or `drop_on`, regardless of this field.").Array().Advanced(),
 		docs.FieldString("proxy_url", "An optional HTTP proxy URL.").Advanced(),
+		docs.FieldString("dns_resolvers", "An optional list of DNS server addresses to resolve hostnames with instead of the resolvers of the system, which are attempted in order. The port defaults to 53 when omitted. When a `proxy_url` is set only the hostname of the proxy is resolved with these servers.", []string{"10.0.0.2:53"}).Array().AtVersion("4.1.0").Advanced(),
+		docs.FieldInt("max_idle_conns_per_host", "The maximum number of idle connections to keep open for reuse with each host. High volume integrations that send many concurrent requests to the same host benefit from raising this value. When set to zero the default of the Go standard library is used, which is 2.").AtVersion("4.1.0").Advanced(),
+		docs.FieldInt("tls_session_cache_size", "The number of TLS sessions to cache for resumption, which avoids a full handshake when reconnecting to a host. When set to zero TLS sessions are not resumed.").AtVersion("4.1.0").Advanced(),
 	)
 	httpSpecs = append(httpSpecs, extraChildren...)
 
@@ -17,6 +17,7 @@ import (
 	"github.com/benthosdev/benthos/v4/internal/bundle"
 	"github.com/benthosdev/benthos/v4/internal/component"
 	"github.com/benthosdev/benthos/v4/internal/component/input"
+	"github.com/benthosdev/benthos/v4/internal/component/input/span"
 	"github.com/benthosdev/benthos/v4/internal/docs"
 	"github.com/benthosdev/benthos/v4/internal/log"
 	"github.com/benthosdev/benthos/v4/internal/message"
@@ -32,6 +33,7 @@ func init() {
 		if a, err = newAMQP09Reader(c.AMQP09, nm.Logger()); err != nil {
 			return nil, err
 		}
+		a = span.NewMetadataReader("amqp_0_9", a)
 		return oinput.NewAsyncReader("amqp_0_9", true, a, nm.Logger(), nm.Metrics())
 	}), docs.ComponentSpec{
 		Name: "amqp_0_9",
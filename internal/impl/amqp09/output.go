@@ -37,6 +37,9 @@ func init() {
 		if err != nil {
 			return nil, err
 		}
+		if aw, ok := w.(*ooutput.AsyncWriter); ok {
+			aw.SetInjectTracingMetadata()
+		}
 		return ooutput.OnlySinglePayloads(w), nil
 
 	}), docs.ComponentSpec{
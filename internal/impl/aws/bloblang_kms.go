@@ -0,0 +1,127 @@
+package aws
+
+import (
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/arn"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/kms"
+
+	"github.com/benthosdev/benthos/v4/internal/aead"
+	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
+	"github.com/benthosdev/benthos/v4/public/bloblang"
+)
+
+// The period of time that data keys are reused for in order to reduce the
+// number of calls made to KMS. Each method instance has its own client and
+// cache of data keys, and therefore keys are never shared between mappings.
+const kmsDataKeyTTL = time.Minute * 5
+
+func newKMSClient(region string) (*kms.KMS, error) {
+	conf := aws.NewConfig()
+	if region != "" {
+		conf = conf.WithRegion(region)
+	}
+	sess, err := session.NewSessionWithOptions(session.Options{
+		Config:            *conf,
+		SharedConfigState: session.SharedConfigEnable,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return kms.New(sess), nil
+}
+
+// kmsKeyRegion attempts to extract a region from a key ID in the form of an
+// ARN, otherwise the region is resolved from the environment.
+func kmsKeyRegion(keyID string) string {
+	if parsed, err := arn.Parse(keyID); err == nil {
+		return parsed.Region
+	}
+	return ""
+}
+
+func init() {
+	encryptSpec := bloblang.NewPluginSpec().
+		Category(string(query.MethodCategoryEncoding)).
+		Description(`Encrypts a string or byte array target with a data key generated by an [AWS KMS](https://aws.amazon.com/kms/) key and returns a byte array envelope containing both the encrypted data key and the payload encrypted with AES-GCM, which can be decrypted with the method `+"`decrypt_aws_kms_envelope`"+`.
+
+Generated data keys are reused by each call of the method within a mapping for five minutes in order to reduce the number of calls made to KMS. Credentials and the region (when the key ID is not an ARN) are obtained from the environment.`).
+		Param(bloblang.NewStringParam("key_id").Description("The ID, ARN or alias of the KMS key to generate data keys with.")).
+		Example("", `root.secret = this.secret.encrypt_aws_kms_envelope("alias/benthos").encode("base64")`)
+
+	if err := bloblang.RegisterMethodV2(
+		"encrypt_aws_kms_envelope", encryptSpec,
+		func(args *bloblang.ParsedParams) (bloblang.Method, error) {
+			keyID, err := args.GetString("key_id")
+			if err != nil {
+				return nil, err
+			}
+			client, err := newKMSClient(kmsKeyRegion(keyID))
+			if err != nil {
+				return nil, err
+			}
+			dataKeys := aead.NewKeyCache(kmsDataKeyTTL)
+			return bloblang.BytesMethod(func(b []byte) (interface{}, error) {
+				key, err := dataKeys.ForEncrypt(keyID, func() (aead.DataKey, error) {
+					out, err := client.GenerateDataKey(&kms.GenerateDataKeyInput{
+						KeyId:   aws.String(keyID),
+						KeySpec: aws.String(kms.DataKeySpecAes256),
+					})
+					if err != nil {
+						return aead.DataKey{}, err
+					}
+					return aead.DataKey{
+						Plaintext: out.Plaintext,
+						Encrypted: out.CiphertextBlob,
+					}, nil
+				})
+				if err != nil {
+					return nil, err
+				}
+				return aead.SealEnvelope(key.Encrypted, key.Plaintext, b)
+			}), nil
+		},
+	); err != nil {
+		panic(err)
+	}
+
+	decryptSpec := bloblang.NewPluginSpec().
+		Category(string(query.MethodCategoryEncoding)).
+		Description(`Decrypts an envelope created with the method `+"`encrypt_aws_kms_envelope`"+` and returns the result as a byte array. The data key of the envelope is decrypted with [AWS KMS](https://aws.amazon.com/kms/), and decrypted data keys are reused by each call of the method within a mapping for five minutes in order to reduce the number of calls made to KMS. Credentials and the region are obtained from the environment.`).
+		Param(bloblang.NewStringParam("region").Description("An optional region of the KMS key, which overrides the region obtained from the environment.").Default("")).
+		Example("", `root.secret = this.secret.decode("base64").decrypt_aws_kms_envelope().string()`)
+
+	if err := bloblang.RegisterMethodV2(
+		"decrypt_aws_kms_envelope", decryptSpec,
+		func(args *bloblang.ParsedParams) (bloblang.Method, error) {
+			region, err := args.GetString("region")
+			if err != nil {
+				return nil, err
+			}
+			client, err := newKMSClient(region)
+			if err != nil {
+				return nil, err
+			}
+			dataKeys := aead.NewKeyCache(kmsDataKeyTTL)
+			scope := aws.StringValue(client.Config.Region)
+			return bloblang.BytesMethod(func(b []byte) (interface{}, error) {
+				return aead.OpenEnvelope(b, func(encryptedKey []byte) ([]byte, error) {
+					return dataKeys.ForDecrypt(scope, encryptedKey, func() ([]byte, error) {
+						out, err := client.Decrypt(&kms.DecryptInput{
+							CiphertextBlob: encryptedKey,
+						})
+						if err != nil {
+							return nil, err
+						}
+						return out.Plaintext, nil
+					})
+				})
+			}), nil
+		},
+	); err != nil {
+		panic(err)
+	}
+}
@@ -31,6 +31,8 @@ a binary value using the ` + "`data_key`" + ` field name.`).
 		Description(`A prefix can be specified to allow multiple cache types to share a single DynamoDB table. An optional TTL duration (` + "`ttl`" + `) and field
 (` + "`ttl_key`" + `) can be specified if the backing table has TTL enabled.
 
+DynamoDB deletes expired items lazily, and therefore items with a TTL that has passed are treated as missing by Get commands, and are overwritten by Add commands, even when they still exist within the table.
+
 Strong read consistency can be enabled using the ` + "`consistent_read`" + ` configuration field.`).
 		Field(service.NewStringField("table").
 			Description("The table to store items in.")).
@@ -220,11 +222,109 @@ func (d *dynamodbCache) get(key string) ([]byte, error) {
 		return nil, err
 	}
 
-	val, ok := res.Item[d.dataKey]
-	if !ok || val.B == nil {
+	val, ok := d.itemValue(res.Item, time.Now())
+	if !ok {
 		return nil, service.ErrKeyNotFound
 	}
-	return val.B, nil
+	return val, nil
+}
+
+// itemValue extracts the value of an item, returns false if the item is
+// missing a value or has expired.
+func (d *dynamodbCache) itemValue(item map[string]*dynamodb.AttributeValue, now time.Time) ([]byte, bool) {
+	val, ok := item[d.dataKey]
+	if !ok || val.B == nil {
+		return nil, false
+	}
+	if d.ttlKey != nil {
+		if ttlVal, exists := item[*d.ttlKey]; exists && ttlVal.N != nil {
+			if expiry, err := strconv.ParseInt(*ttlVal.N, 10, 64); err == nil && expiry <= now.Unix() {
+				return nil, false
+			}
+		}
+	}
+	return val.B, true
+}
+
+// The maximum number of keys that can be requested within a single
+// BatchGetItem call.
+const dynamoDBMaxBatchGetKeys = 100
+
+// GetMulti attempts to obtain the values of multiple keys with batched
+// requests, keys that are not found are omitted from the result.
+func (d *dynamodbCache) GetMulti(ctx context.Context, keys ...string) (map[string][]byte, error) {
+	boff := d.boffPool.Get().(backoff.BackOff)
+	defer func() {
+		boff.Reset()
+		d.boffPool.Put(boff)
+	}()
+
+	// BatchGetItem rejects requests that contain duplicate keys.
+	uniqueKeys := make([]string, 0, len(keys))
+	seenKeys := make(map[string]struct{}, len(keys))
+	for _, k := range keys {
+		if _, exists := seenKeys[k]; !exists {
+			seenKeys[k] = struct{}{}
+			uniqueKeys = append(uniqueKeys, k)
+		}
+	}
+	keys = uniqueKeys
+
+	results := make(map[string][]byte, len(keys))
+	for len(keys) > 0 {
+		chunk := keys
+		if len(chunk) > dynamoDBMaxBatchGetKeys {
+			chunk = chunk[:dynamoDBMaxBatchGetKeys]
+		}
+		keys = keys[len(chunk):]
+
+		reqKeys := make([]map[string]*dynamodb.AttributeValue, 0, len(chunk))
+		for _, k := range chunk {
+			reqKeys = append(reqKeys, map[string]*dynamodb.AttributeValue{
+				d.hashKey: {S: aws.String(k)},
+			})
+		}
+
+		for len(reqKeys) > 0 {
+			res, err := d.client.BatchGetItemWithContext(ctx, &dynamodb.BatchGetItemInput{
+				RequestItems: map[string]*dynamodb.KeysAndAttributes{
+					*d.table: {
+						Keys:           reqKeys,
+						ConsistentRead: aws.Bool(d.consistentRead),
+					},
+				},
+			})
+			if err == nil {
+				now := time.Now()
+				for _, item := range res.Responses[*d.table] {
+					keyVal, exists := item[d.hashKey]
+					if !exists || keyVal.S == nil {
+						continue
+					}
+					if val, ok := d.itemValue(item, now); ok {
+						results[*keyVal.S] = val
+					}
+				}
+				reqKeys = nil
+				if unproc, exists := res.UnprocessedKeys[*d.table]; exists && len(unproc.Keys) > 0 {
+					reqKeys = unproc.Keys
+					err = fmt.Errorf("failed to get %v items", len(unproc.Keys))
+				}
+			}
+			if err != nil {
+				wait := boff.NextBackOff()
+				if wait == backoff.Stop {
+					return nil, err
+				}
+				select {
+				case <-time.After(wait):
+				case <-ctx.Done():
+					return nil, err
+				}
+			}
+		}
+	}
+	return results, nil
 }
 
 func (d *dynamodbCache) Set(ctx context.Context, key string, value []byte, ttl *time.Duration) error {
@@ -326,13 +426,20 @@ func (d *dynamodbCache) Add(ctx context.Context, key string, value []byte, ttl *
 func (d *dynamodbCache) add(key string, value []byte, ttl *time.Duration) error {
 	input := d.putItemInput(key, value, ttl)
 
+	cond := expression.AttributeNotExists(expression.Name(d.hashKey))
+	if d.ttlKey != nil {
+		// Items that have expired but are yet to be deleted can be replaced.
+		cond = expression.Or(cond, expression.Name(*d.ttlKey).LessThanEqual(expression.Value(time.Now().Unix())))
+	}
+
 	expr, err := expression.NewBuilder().
-		WithCondition(expression.AttributeNotExists(expression.Name(d.hashKey))).
+		WithCondition(cond).
 		Build()
 	if err != nil {
 		return err
 	}
 	input.ExpressionAttributeNames = expr.Names()
+	input.ExpressionAttributeValues = expr.Values()
 	input.ConditionExpression = expr.Condition()
 
 	if _, err = d.client.PutItem(input); err != nil {
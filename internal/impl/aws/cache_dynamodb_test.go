@@ -1,13 +1,22 @@
 package aws
 
 import (
+	"context"
+	"errors"
+	"strconv"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/cenkalti/backoff/v4"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
 )
 
 func TestDynamoDBCacheConfig(t *testing.T) {
@@ -86,3 +95,85 @@ ttl_key: buz
 		})
 	}
 }
+
+type mockCacheDynamoDB struct {
+	dynamodbiface.DynamoDBAPI
+	items map[string]map[string]*dynamodb.AttributeValue
+	calls int
+}
+
+func (m *mockCacheDynamoDB) GetItem(input *dynamodb.GetItemInput) (*dynamodb.GetItemOutput, error) {
+	return &dynamodb.GetItemOutput{
+		Item: m.items[*input.Key["id"].S],
+	}, nil
+}
+
+func (m *mockCacheDynamoDB) BatchGetItemWithContext(ctx context.Context, input *dynamodb.BatchGetItemInput, _ ...request.Option) (*dynamodb.BatchGetItemOutput, error) {
+	m.calls++
+
+	seen := map[string]struct{}{}
+	for _, k := range input.RequestItems["foo"].Keys {
+		if _, exists := seen[*k["id"].S]; exists {
+			return nil, errors.New("ValidationException: Provided list of item keys contains duplicates")
+		}
+		seen[*k["id"].S] = struct{}{}
+	}
+
+	out := &dynamodb.BatchGetItemOutput{
+		Responses:       map[string][]map[string]*dynamodb.AttributeValue{},
+		UnprocessedKeys: map[string]*dynamodb.KeysAndAttributes{},
+	}
+	for i, k := range input.RequestItems["foo"].Keys {
+		// Leave the last key of the first call unprocessed.
+		if m.calls == 1 && i > 0 && i == len(input.RequestItems["foo"].Keys)-1 {
+			out.UnprocessedKeys["foo"] = &dynamodb.KeysAndAttributes{
+				Keys: []map[string]*dynamodb.AttributeValue{k},
+			}
+			continue
+		}
+		if item, exists := m.items[*k["id"].S]; exists {
+			out.Responses["foo"] = append(out.Responses["foo"], item)
+		}
+	}
+	return out, nil
+}
+
+func TestDynamoDBCacheExpiry(t *testing.T) {
+	future := strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10)
+	past := strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10)
+
+	newItem := func(key, value, ttl string) map[string]*dynamodb.AttributeValue {
+		return map[string]*dynamodb.AttributeValue{
+			"id":   {S: aws.String(key)},
+			"data": {B: []byte(value)},
+			"ttl":  {N: aws.String(ttl)},
+		}
+	}
+
+	client := &mockCacheDynamoDB{
+		items: map[string]map[string]*dynamodb.AttributeValue{
+			"a": newItem("a", "a value", future),
+			"b": newItem("b", "b value", past),
+			"c": newItem("c", "c value", future),
+		},
+	}
+
+	boff := backoff.NewExponentialBackOff()
+	boff.InitialInterval = time.Millisecond
+	c := newDynamodbCache(client, "foo", "id", "data", false, aws.String("ttl"), nil, boff)
+
+	v, err := c.Get(context.Background(), "a")
+	require.NoError(t, err)
+	assert.Equal(t, "a value", string(v))
+
+	_, err = c.Get(context.Background(), "b")
+	assert.Equal(t, service.ErrKeyNotFound, err)
+
+	res, err := c.GetMulti(context.Background(), "a", "b", "c", "a", "d", "c")
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]byte{
+		"a": []byte("a value"),
+		"c": []byte("c value"),
+	}, res)
+	assert.Equal(t, 2, client.calls)
+}
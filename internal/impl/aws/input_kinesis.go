@@ -52,6 +52,10 @@ Benthos will not store a consumed sequence unless it is acknowledged at the outp
 
 By default messages of a shard can be processed in parallel, up to a limit determined by the field ` + "`checkpoint_limit`" + `. However, if strict ordered processing is required then this value must be set to 1 in order to process shard messages in lock-step. When doing so it is recommended that you perform batching at this component for performance as it will not be possible to batch lock-stepped messages at the output level.
 
+### Enhanced Fan-Out
+
+When the field ` + "`enhanced_fan_out.enabled`" + ` is set shards are consumed with [enhanced fan-out](https://docs.aws.amazon.com/streams/latest/dev/enhanced-consumers.html), where records are pushed to this input over a subscription with throughput dedicated to the consumer, rather than polled. The consumer named ` + "`enhanced_fan_out.consumer_name`" + ` is registered with each stream if it does not already exist, and all instances of this input that consume the same streams should share this name. Shards are balanced and checkpointed with the DynamoDB table in the same way as they are without enhanced fan-out.
+
 ### Table Schema
 
 It's possible to configure Benthos to create the DynamoDB table required for coordination if it does not already exist. However, if you wish to create this yourself (recommended) then create a table with a string HASH key ` + "`StreamID`" + ` and a string RANGE key ` + "`ShardID`" + `. 
@@ -78,6 +82,10 @@ Use the ` + "`batching`" + ` fields to configure an optional [batching policy](/
 			docs.FieldString("rebalance_period", "The period of time between each attempt to rebalance shards across clients.").Advanced(),
 			docs.FieldString("lease_period", "The period of time after which a client that has failed to update a shard checkpoint is assumed to be inactive.").Advanced(),
 			docs.FieldBool("start_from_oldest", "Whether to consume from the oldest message when a sequence does not yet exist for the stream."),
+			docs.FieldObject("enhanced_fan_out", "Consume shards with enhanced fan-out, where records are pushed to this input by Kinesis.").WithChildren(
+				docs.FieldBool("enabled", "Whether to consume shards with enhanced fan-out."),
+				docs.FieldString("consumer_name", "The name of the consumer to register with each stream and subscribe to shards with."),
+			).AtVersion("4.1.0").Advanced(),
 		).WithChildren(session.FieldSpecs()...).
 			WithChildren(policy.FieldSpec()).
 			ChildDefaultAndTypesFromStruct(oinput.NewAWSKinesisConfig()),
@@ -117,6 +125,7 @@ type kinesisReader struct {
 
 	streamShards    map[string][]string
 	balancedStreams []string
+	efoConsumers    map[string]string
 
 	commitPeriod    time.Duration
 	leasePeriod     time.Duration
@@ -293,6 +302,48 @@ const (
 	awsKinesisConsumerClosing
 )
 
+// kinesisPullGate determines when a shard consumer should next pull records.
+// The gate is unblocked (a closed channel) when we run out of pending records,
+// blocked whilst there are pending records, or a timed channel when our last
+// attempt yielded zero records. Records of enhanced fan-out subscriptions are
+// pushed to us rather than pulled, in which case the gate is always nil.
+type kinesisPullGate struct {
+	unblocked, blocked chan time.Time
+	next               <-chan time.Time
+}
+
+func newKinesisPullGate(efo bool) *kinesisPullGate {
+	g := &kinesisPullGate{
+		unblocked: make(chan time.Time),
+		blocked:   make(chan time.Time),
+	}
+	close(g.unblocked)
+	if !efo {
+		g.next = g.unblocked
+	}
+	return g
+}
+
+func (g *kinesisPullGate) isUnblocked() bool {
+	return g.next == g.unblocked
+}
+
+func (g *kinesisPullGate) block() {
+	g.next = g.blocked
+}
+
+func (g *kinesisPullGate) delay(d time.Duration) {
+	g.next = time.After(d)
+}
+
+// Switches the gate to unblocked only if it's currently blocked, as otherwise
+// it's either a timed channel that we do not want to disturb or nil.
+func (g *kinesisPullGate) unblock() {
+	if g.next == g.blocked {
+		g.next = g.unblocked
+	}
+}
+
 func (k *kinesisReader) runConsumer(wg *sync.WaitGroup, streamID, shardID, startingSequence string) (initErr error) {
 	defer func() {
 		if initErr != nil {
@@ -316,7 +367,16 @@ func (k *kinesisReader) runConsumer(wg *sync.WaitGroup, streamID, shardID, start
 	// Stores consumed records that have yet to be added to the batcher.
 	var pending []*kinesis.Record
 	var iter string
-	if iter, initErr = k.getIter(streamID, shardID, startingSequence); initErr != nil {
+
+	// When consuming with enhanced fan-out records are pushed to us by a
+	// subscription instead of pulled with a shard iterator.
+	var efoChan, efoNextChan chan kinesisEFOEvent
+	efoCtx, efoDone := context.WithCancel(k.ctx)
+	if consumerARN, exists := k.efoConsumers[streamID]; exists {
+		efoChan = make(chan kinesisEFOEvent)
+		go k.runEFOSubscription(efoCtx, consumerARN, shardID, startingSequence, efoChan)
+	} else if iter, initErr = k.getIter(streamID, shardID, startingSequence); initErr != nil {
+		efoDone()
 		return initErr
 	}
 
@@ -324,26 +384,26 @@ func (k *kinesisReader) runConsumer(wg *sync.WaitGroup, streamID, shardID, start
 	state := awsKinesisConsumerConsuming
 	var pendingMsg asyncMessage
 
-	unblockedChan, blockedChan := make(chan time.Time), make(chan time.Time)
-	close(unblockedChan)
+	pullGate := newKinesisPullGate(efoChan != nil)
 
 	// Channels (and contexts) representing the four main actions of the
 	// consumer goroutine:
 	// 1. Timed batches, this might be nil when timed batches are disabled.
 	// 2. Record pulling, this might be unblocked (closed channel) when we run
 	//    out of pending records, or a timed channel when our last attempt
-	//    yielded zero records.
+	//    yielded zero records. When consuming with enhanced fan-out we instead
+	//    wait on the subscription and this is always nil.
 	// 3. Message flush, this is the target of our current batched message, and
 	//    is nil when our current batched message is a zero value (we don't have
 	//    one prepared).
 	// 4. Next commit, is "done" when the next commit is due.
 	var nextTimedBatchChan <-chan time.Time
-	var nextPullChan <-chan time.Time = unblockedChan
 	var nextFlushChan chan<- asyncMessage
 	commitCtx, commitCtxClose := context.WithTimeout(k.ctx, k.commitPeriod)
 
 	go func() {
 		defer func() {
+			efoDone()
 			commitCtxClose()
 			recordBatcher.Close(state == awsKinesisConsumerFinished)
 			boff.Reset()
@@ -374,21 +434,17 @@ func (k *kinesisReader) runConsumer(wg *sync.WaitGroup, streamID, shardID, start
 
 		k.log.Debugf("Consuming stream '%v' shard '%v' as client '%v'\n", streamID, shardID, k.checkpointer.clientID)
 
-		// Switches our pull chan to unblocked only if it's currently blocked,
-		// as otherwise it's set to a timed channel that we do not want to
-		// disturb.
-		unblockPullChan := func() {
-			if nextPullChan == blockedChan {
-				nextPullChan = unblockedChan
-			}
-		}
-
 		for {
 			var err error
-			if state == awsKinesisConsumerConsuming && len(pending) == 0 && nextPullChan == unblockedChan {
+			if efoChan != nil {
+				if state == awsKinesisConsumerConsuming && len(pending) == 0 {
+					// Wait for the subscription to push more records.
+					efoNextChan = efoChan
+				}
+			} else if state == awsKinesisConsumerConsuming && len(pending) == 0 && pullGate.isUnblocked() {
 				if pending, iter, err = k.getRecords(streamID, shardID, iter); err != nil {
 					if !awsErrIsTimeout(err) {
-						nextPullChan = time.After(boff.NextBackOff())
+						pullGate.delay(boff.NextBackOff())
 
 						if aerr, ok := err.(awserr.Error); ok && aerr.Code() == kinesis.ErrCodeExpiredIteratorException {
 							k.log.Warnln("Shard iterator expired, attempting to refresh")
@@ -403,10 +459,10 @@ func (k *kinesisReader) runConsumer(wg *sync.WaitGroup, streamID, shardID, start
 						}
 					}
 				} else if len(pending) == 0 {
-					nextPullChan = time.After(boff.NextBackOff())
+					pullGate.delay(boff.NextBackOff())
 				} else {
 					boff.Reset()
-					nextPullChan = blockedChan
+					pullGate.block()
 				}
 				// The getRecords method ensures that it returns the input
 				// iterator whenever it errors out. Therefore, regardless of the
@@ -416,7 +472,7 @@ func (k *kinesisReader) runConsumer(wg *sync.WaitGroup, streamID, shardID, start
 					state = awsKinesisConsumerFinished
 				}
 			} else {
-				unblockPullChan()
+				pullGate.unblock()
 			}
 
 			if pendingMsg.msg == nil {
@@ -442,10 +498,10 @@ func (k *kinesisReader) runConsumer(wg *sync.WaitGroup, streamID, shardID, start
 						}
 					}
 					if pending = pending[i+1:]; len(pending) == 0 {
-						unblockPullChan()
+						pullGate.unblock()
 					}
 				} else {
-					unblockPullChan()
+					pullGate.unblock()
 				}
 			}
 
@@ -484,8 +540,22 @@ func (k *kinesisReader) runConsumer(wg *sync.WaitGroup, streamID, shardID, start
 				nextTimedBatchChan = nil
 			case nextFlushChan <- pendingMsg:
 				pendingMsg = asyncMessage{}
-			case <-nextPullChan:
-				nextPullChan = unblockedChan
+			case <-pullGate.next:
+				pullGate.next = pullGate.unblocked
+			case event, open := <-efoNextChan:
+				efoNextChan = nil
+				if !open {
+					// The subscription only closes without finishing the shard
+					// when we're shutting down.
+					state = awsKinesisConsumerClosing
+					return
+				}
+				if pending = event.records; len(pending) > 0 {
+					boff.Reset()
+				}
+				if event.finished {
+					state = awsKinesisConsumerFinished
+				}
 			case <-k.ctx.Done():
 				state = awsKinesisConsumerClosing
 				return
@@ -697,6 +767,20 @@ func (k *kinesisReader) ConnectWithContext(ctx context.Context) error {
 	}
 
 	k.svc = svc
+	if k.conf.EnhancedFanOut.Enabled {
+		efoConsumers := map[string]string{}
+		streams := append([]string{}, k.balancedStreams...)
+		for streamID := range k.streamShards {
+			streams = append(streams, streamID)
+		}
+		for _, streamID := range streams {
+			if efoConsumers[streamID], err = k.ensureEFOConsumer(ctx, streamID); err != nil {
+				return err
+			}
+		}
+		k.efoConsumers = efoConsumers
+	}
+
 	k.checkpointer = checkpointer
 	k.msgChan = make(chan asyncMessage)
 
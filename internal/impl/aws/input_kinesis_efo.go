@@ -0,0 +1,166 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/kinesis"
+	"github.com/cenkalti/backoff/v4"
+)
+
+// Registers the enhanced fan-out consumer of a stream if it does not already
+// exist and blocks until it is active, returning its ARN.
+func (k *kinesisReader) ensureEFOConsumer(ctx context.Context, streamID string) (string, error) {
+	summary, err := k.svc.DescribeStreamSummaryWithContext(ctx, &kinesis.DescribeStreamSummaryInput{
+		StreamName: aws.String(streamID),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to describe stream '%v': %w", streamID, err)
+	}
+	streamARN := summary.StreamDescriptionSummary.StreamARN
+	consumerName := aws.String(k.conf.EnhancedFanOut.ConsumerName)
+
+	var consumer *kinesis.ConsumerDescription
+	desc, err := k.svc.DescribeStreamConsumerWithContext(ctx, &kinesis.DescribeStreamConsumerInput{
+		StreamARN:    streamARN,
+		ConsumerName: consumerName,
+	})
+	if err == nil {
+		consumer = desc.ConsumerDescription
+	} else {
+		var aerr awserr.Error
+		if !errors.As(err, &aerr) || aerr.Code() != kinesis.ErrCodeResourceNotFoundException {
+			return "", fmt.Errorf("failed to describe consumer of stream '%v': %w", streamID, err)
+		}
+
+		k.log.Infof("Registering enhanced fan-out consumer '%v' of stream '%v'\n", *consumerName, streamID)
+		reg, err := k.svc.RegisterStreamConsumerWithContext(ctx, &kinesis.RegisterStreamConsumerInput{
+			StreamARN:    streamARN,
+			ConsumerName: consumerName,
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to register consumer of stream '%v': %w", streamID, err)
+		}
+		consumer = &kinesis.ConsumerDescription{
+			ConsumerARN:    reg.Consumer.ConsumerARN,
+			ConsumerStatus: reg.Consumer.ConsumerStatus,
+		}
+	}
+
+	// Consumers can only be subscribed with once they're active, which takes a
+	// few seconds after registering them.
+	for consumer.ConsumerStatus == nil || *consumer.ConsumerStatus != kinesis.ConsumerStatusActive {
+		select {
+		case <-time.After(time.Second):
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+		if desc, err = k.svc.DescribeStreamConsumerWithContext(ctx, &kinesis.DescribeStreamConsumerInput{
+			ConsumerARN: consumer.ConsumerARN,
+		}); err != nil {
+			return "", fmt.Errorf("failed to describe consumer of stream '%v': %w", streamID, err)
+		}
+		consumer = desc.ConsumerDescription
+	}
+	return *consumer.ConsumerARN, nil
+}
+
+//------------------------------------------------------------------------------
+
+type kinesisEFOEvent struct {
+	records  []*kinesis.Record
+	finished bool
+}
+
+// Subscribes to a shard with an enhanced fan-out consumer and writes the
+// records pushed by Kinesis to a channel. Subscriptions expire after five
+// minutes, at which point the shard is subscribed to again from the last
+// sequence received. The channel is closed once the shard has finished or the
+// context is cancelled.
+func (k *kinesisReader) runEFOSubscription(ctx context.Context, consumerARN, shardID, sequence string, eventsChan chan<- kinesisEFOEvent) {
+	defer close(eventsChan)
+
+	boff := k.boffPool.Get().(backoff.BackOff)
+	defer func() {
+		boff.Reset()
+		k.boffPool.Put(boff)
+	}()
+
+	for {
+		startingPos := &kinesis.StartingPosition{
+			Type: aws.String(kinesis.ShardIteratorTypeTrimHorizon),
+		}
+		if len(sequence) > 0 {
+			startingPos.Type = aws.String(kinesis.ShardIteratorTypeAfterSequenceNumber)
+			startingPos.SequenceNumber = aws.String(sequence)
+		} else if !k.conf.StartFromOldest {
+			startingPos.Type = aws.String(kinesis.ShardIteratorTypeLatest)
+		}
+
+		finished, err := k.readEFOSubscription(ctx, consumerARN, shardID, startingPos, &sequence, eventsChan)
+		if finished || ctx.Err() != nil {
+			return
+		}
+		if err == nil {
+			boff.Reset()
+			continue
+		}
+
+		k.log.Errorf("Failed to read subscription of shard '%v': %v\n", shardID, err)
+		select {
+		case <-time.After(boff.NextBackOff()):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (k *kinesisReader) readEFOSubscription(
+	ctx context.Context,
+	consumerARN, shardID string,
+	startingPos *kinesis.StartingPosition,
+	sequence *string,
+	eventsChan chan<- kinesisEFOEvent,
+) (finished bool, err error) {
+	res, err := k.svc.SubscribeToShardWithContext(ctx, &kinesis.SubscribeToShardInput{
+		ConsumerARN:      aws.String(consumerARN),
+		ShardId:          aws.String(shardID),
+		StartingPosition: startingPos,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	stream := res.GetStream()
+	defer stream.Close()
+
+	for event := range stream.Events() {
+		e, ok := event.(*kinesis.SubscribeToShardEvent)
+		if !ok {
+			continue
+		}
+
+		// The continuation sequence is only absent once the end of a closed
+		// shard has been reached.
+		if e.ContinuationSequenceNumber == nil {
+			finished = true
+		} else {
+			*sequence = *e.ContinuationSequenceNumber
+		}
+		if len(e.Records) > 0 || finished {
+			select {
+			case eventsChan <- kinesisEFOEvent{records: e.Records, finished: finished}:
+			case <-ctx.Done():
+				return false, ctx.Err()
+			}
+		}
+		if finished {
+			return true, nil
+		}
+	}
+	return false, stream.Err()
+}
@@ -0,0 +1,215 @@
+package aws
+
+import (
+	"context"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/kinesis"
+	"github.com/aws/aws-sdk-go/service/kinesis/kinesisiface"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/manager/mock"
+	oinput "github.com/benthosdev/benthos/v4/internal/old/input"
+)
+
+type mockEFOStreamReader struct {
+	events chan kinesis.SubscribeToShardEventStreamEvent
+}
+
+func (m *mockEFOStreamReader) Events() <-chan kinesis.SubscribeToShardEventStreamEvent {
+	return m.events
+}
+
+func (m *mockEFOStreamReader) Close() error {
+	return nil
+}
+
+func (m *mockEFOStreamReader) Err() error {
+	return nil
+}
+
+type mockEFOKinesis struct {
+	kinesisiface.KinesisAPI
+
+	mut           sync.Mutex
+	registered    []string
+	consumerState string
+	subscriptions []kinesis.StartingPosition
+	events        [][]*kinesis.SubscribeToShardEvent
+}
+
+func (m *mockEFOKinesis) DescribeStreamSummaryWithContext(ctx aws.Context, in *kinesis.DescribeStreamSummaryInput, opts ...request.Option) (*kinesis.DescribeStreamSummaryOutput, error) {
+	return &kinesis.DescribeStreamSummaryOutput{
+		StreamDescriptionSummary: &kinesis.StreamDescriptionSummary{
+			StreamARN: aws.String("arn:" + *in.StreamName),
+		},
+	}, nil
+}
+
+func (m *mockEFOKinesis) DescribeStreamConsumerWithContext(ctx aws.Context, in *kinesis.DescribeStreamConsumerInput, opts ...request.Option) (*kinesis.DescribeStreamConsumerOutput, error) {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+	if m.consumerState == "" {
+		return nil, awserr.New(kinesis.ErrCodeResourceNotFoundException, "not found", nil)
+	}
+	state := m.consumerState
+	m.consumerState = kinesis.ConsumerStatusActive
+	return &kinesis.DescribeStreamConsumerOutput{
+		ConsumerDescription: &kinesis.ConsumerDescription{
+			ConsumerARN:    aws.String("consumer-arn"),
+			ConsumerStatus: aws.String(state),
+		},
+	}, nil
+}
+
+func (m *mockEFOKinesis) RegisterStreamConsumerWithContext(ctx aws.Context, in *kinesis.RegisterStreamConsumerInput, opts ...request.Option) (*kinesis.RegisterStreamConsumerOutput, error) {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+	m.registered = append(m.registered, *in.StreamARN+"/"+*in.ConsumerName)
+	m.consumerState = kinesis.ConsumerStatusCreating
+	return &kinesis.RegisterStreamConsumerOutput{
+		Consumer: &kinesis.Consumer{
+			ConsumerARN:    aws.String("consumer-arn"),
+			ConsumerStatus: aws.String(kinesis.ConsumerStatusCreating),
+		},
+	}, nil
+}
+
+func (m *mockEFOKinesis) SubscribeToShardWithContext(ctx aws.Context, in *kinesis.SubscribeToShardInput, opts ...request.Option) (*kinesis.SubscribeToShardOutput, error) {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+
+	m.subscriptions = append(m.subscriptions, *in.StartingPosition)
+
+	reader := &mockEFOStreamReader{events: make(chan kinesis.SubscribeToShardEventStreamEvent, 10)}
+	if len(m.events) > 0 {
+		for _, e := range m.events[0] {
+			reader.events <- e
+		}
+		m.events = m.events[1:]
+	}
+	close(reader.events)
+
+	return &kinesis.SubscribeToShardOutput{
+		EventStream: kinesis.NewSubscribeToShardEventStream(func(es *kinesis.SubscribeToShardEventStream) {
+			es.Reader = reader
+			es.StreamCloser = io.NopCloser(strings.NewReader(""))
+		}),
+	}, nil
+}
+
+func TestKinesisEFOEnsureConsumer(t *testing.T) {
+	conf := oinput.NewAWSKinesisConfig()
+	conf.Streams = []string{"foo"}
+	conf.EnhancedFanOut.Enabled = true
+
+	k, err := newKinesisReader(conf, mock.NewManager())
+	require.NoError(t, err)
+
+	svc := &mockEFOKinesis{}
+	k.svc = svc
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*10)
+	defer done()
+
+	arn, err := k.ensureEFOConsumer(ctx, "foo")
+	require.NoError(t, err)
+	assert.Equal(t, "consumer-arn", arn)
+	assert.Equal(t, []string{"arn:foo/benthos"}, svc.registered)
+
+	arn, err = k.ensureEFOConsumer(ctx, "foo")
+	require.NoError(t, err)
+	assert.Equal(t, "consumer-arn", arn)
+	assert.Len(t, svc.registered, 1)
+}
+
+func TestKinesisEFOSubscription(t *testing.T) {
+	conf := oinput.NewAWSKinesisConfig()
+	conf.Streams = []string{"foo"}
+	conf.EnhancedFanOut.Enabled = true
+
+	k, err := newKinesisReader(conf, mock.NewManager())
+	require.NoError(t, err)
+
+	svc := &mockEFOKinesis{
+		events: [][]*kinesis.SubscribeToShardEvent{
+			{
+				{
+					Records:                    []*kinesis.Record{{Data: []byte("first")}},
+					ContinuationSequenceNumber: aws.String("2"),
+				},
+				{
+					ContinuationSequenceNumber: aws.String("3"),
+				},
+			},
+			{
+				{
+					Records: []*kinesis.Record{{Data: []byte("second")}},
+				},
+			},
+		},
+	}
+	k.svc = svc
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*10)
+	defer done()
+
+	eventsChan := make(chan kinesisEFOEvent)
+	go k.runEFOSubscription(ctx, "consumer-arn", "shard-0", "1", eventsChan)
+
+	var data []string
+	var finished bool
+	for e := range eventsChan {
+		for _, r := range e.records {
+			data = append(data, string(r.Data))
+		}
+		finished = e.finished
+	}
+
+	assert.Equal(t, []string{"first", "second"}, data)
+	assert.True(t, finished)
+	assert.Equal(t, []kinesis.StartingPosition{
+		{
+			Type:           aws.String(kinesis.ShardIteratorTypeAfterSequenceNumber),
+			SequenceNumber: aws.String("1"),
+		},
+		{
+			Type:           aws.String(kinesis.ShardIteratorTypeAfterSequenceNumber),
+			SequenceNumber: aws.String("3"),
+		},
+	}, svc.subscriptions)
+}
+
+func TestKinesisEFOIdleShardBlocks(t *testing.T) {
+	// An idle shard consumer unblocks its pull gate on every loop once it runs
+	// out of pending records, which must not wake up the select of an enhanced
+	// fan-out consumer as it only waits on the subscription.
+	gate := newKinesisPullGate(true)
+	assert.False(t, gate.isUnblocked())
+
+	gate.unblock()
+	select {
+	case <-gate.next:
+		t.Fatal("idle enhanced fan-out consumer woke up to pull records")
+	case <-time.After(time.Millisecond * 50):
+	}
+
+	gate = newKinesisPullGate(false)
+	assert.True(t, gate.isUnblocked())
+
+	gate.block()
+	gate.unblock()
+	select {
+	case <-gate.next:
+	case <-time.After(time.Second):
+		t.Fatal("idle consumer did not wake up to pull records")
+	}
+}
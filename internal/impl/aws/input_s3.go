@@ -19,11 +19,13 @@ import (
 	"github.com/aws/aws-sdk-go/service/sqs"
 
 	"github.com/benthosdev/benthos/v4/internal/bundle"
+	"github.com/benthosdev/benthos/v4/internal/checkpoint"
 	"github.com/benthosdev/benthos/v4/internal/codec"
 	"github.com/benthosdev/benthos/v4/internal/component"
 	"github.com/benthosdev/benthos/v4/internal/component/input"
 	"github.com/benthosdev/benthos/v4/internal/docs"
 	sess "github.com/benthosdev/benthos/v4/internal/impl/aws/session"
+	"github.com/benthosdev/benthos/v4/internal/interop"
 	"github.com/benthosdev/benthos/v4/internal/log"
 	"github.com/benthosdev/benthos/v4/internal/message"
 	oinput "github.com/benthosdev/benthos/v4/internal/old/input"
@@ -60,6 +62,14 @@ If your notification events are being routed to SQS via an SNS topic then the ev
 
 When using SQS please make sure you have sensible values for ` + "`sqs.max_messages`" + ` and also the visibility timeout of the queue itself. When Benthos consumes an S3 object the SQS message that triggered it is not deleted until the S3 object has been sent onwards. This ensures at-least-once crash resiliency, but also means that if the S3 object takes longer to process than the visibility timeout of your queue then the same objects might be processed multiple times.
 
+## Polling a Bucket Without SQS
+
+For buckets that do not emit event notifications the field ` + "`polling.enabled`" + ` can be set, in which case Benthos continuously lists the objects of the bucket (within ` + "`prefix`" + `) at the interval ` + "`polling.interval`" + `. Objects are listed in lexicographical order of their keys, and each listing resumes after the key of the last object seen, which means this mode is only suitable for buckets where new objects are written with keys that sort after those of existing objects, such as keys prefixed with a timestamp.
+
+The key of the last object processed, such that all objects listed before it have also been processed, is stored within the [cache resource](/docs/components/caches/about) ` + "`polling.cache`" + ` when specified, allowing Benthos to resume from where it left off after a restart. The initial listing can also be made to start after a given key with the field ` + "`start_after`" + `.
+
+Objects can be removed from the listed prefix once they're processed either by deleting them with ` + "`delete_objects`" + ` or by moving them to another prefix with ` + "`move_objects_to`" + `.
+
 ## Downloading Large Files
 
 When downloading large files it's often necessary to process it in streamed parts in order to avoid loading the entire file in memory at a given time. In order to do this a ` + "[`codec`](#codec)" + ` can be specified that determines how to break the input into smaller individual messages.
@@ -86,10 +96,18 @@ You can access these metadata fields using [function interpolation](/docs/config
 		Config: docs.FieldComponent().WithChildren(
 			docs.FieldString("bucket", "The bucket to consume from. If the field `sqs.url` is specified this field is optional."),
 			docs.FieldString("prefix", "An optional path prefix, if set only objects with the prefix are consumed when walking a bucket."),
+			docs.FieldString("start_after", "An optional key to start walking a bucket after, objects with keys that sort before or equal to this key are skipped.").AtVersion("4.1.0").Advanced(),
 		).WithChildren(sess.FieldSpecs()...).WithChildren(
 			docs.FieldBool("force_path_style_urls", "Forces the client API to use path style URLs for downloading keys, which is often required when connecting to custom endpoints.").Advanced(),
 			docs.FieldBool("delete_objects", "Whether to delete downloaded objects from the bucket once they are processed.").Advanced(),
+			docs.FieldString("move_objects_to", "An optional prefix to move downloaded objects to once they are processed, where each object is copied to this prefix followed by its original key and then deleted. When walking a bucket this prefix must not fall within `prefix`.", "processed/").AtVersion("4.1.0").Advanced(),
 			codec.ReaderDocs,
+			docs.FieldObject("polling", "Continuously list the objects of a bucket in order to consume new objects as they are added, without the need for SQS.").WithChildren(
+				docs.FieldBool("enabled", "Whether to continuously list the objects of the bucket."),
+				docs.FieldString("interval", "The period of time to wait before listing the bucket again once all listed objects have been consumed.", "30s", "5m"),
+				docs.FieldString("cache", "An optional [cache resource](/docs/components/caches/about) for storing the key of the last object processed, allowing listings to resume from it after a restart."),
+				docs.FieldString("cache_key", "The key under which the last object processed is stored within the cache, defaults to the bucket name followed by the prefix.").Advanced(),
+			).AtVersion("4.1.0"),
 			docs.FieldObject("sqs", "Consume SQS messages in order to trigger key downloads.").WithChildren(
 				docs.FieldString("url", "An optional SQS URL to connect to. When specified this queue will control which objects are downloaded."),
 				docs.FieldString("endpoint", "A custom endpoint to use when connecting to SQS.").Advanced(),
@@ -146,6 +164,7 @@ func deleteS3ObjectAckFn(
 	s3Client *s3.S3,
 	bucket, key string,
 	del bool,
+	moveTo string,
 	prev codec.ReaderAckFn,
 ) codec.ReaderAckFn {
 	return func(ctx context.Context, err error) error {
@@ -154,9 +173,18 @@ func deleteS3ObjectAckFn(
 				return aerr
 			}
 		}
-		if !del || err != nil {
+		if (!del && moveTo == "") || err != nil {
 			return nil
 		}
+		if moveTo != "" {
+			if _, aerr := s3Client.CopyObjectWithContext(ctx, &s3.CopyObjectInput{
+				Bucket:     aws.String(bucket),
+				CopySource: aws.String((&url.URL{Path: bucket + "/" + key}).EscapedPath()),
+				Key:        aws.String(moveTo + key),
+			}); aerr != nil {
+				return aerr
+			}
+		}
 		_, aerr := s3Client.DeleteObjectWithContext(ctx, &s3.DeleteObjectInput{
 			Bucket: aws.String(bucket),
 			Key:    aws.String(key),
@@ -187,6 +215,9 @@ func newStaticTargetReader(
 	if len(conf.Prefix) > 0 {
 		listInput.Prefix = aws.String(conf.Prefix)
 	}
+	if len(conf.StartAfter) > 0 {
+		listInput.StartAfter = aws.String(conf.StartAfter)
+	}
 	output, err := s3Client.ListObjectsV2WithContext(ctx, listInput)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list objects: %v", err)
@@ -196,7 +227,7 @@ func newStaticTargetReader(
 		conf: conf,
 	}
 	for _, obj := range output.Contents {
-		ackFn := deleteS3ObjectAckFn(s3Client, conf.Bucket, *obj.Key, conf.DeleteObjects, nil)
+		ackFn := deleteS3ObjectAckFn(s3Client, conf.Bucket, *obj.Key, conf.DeleteObjects, conf.MoveObjectsTo, nil)
 		staticKeys.pending = append(staticKeys.pending, newS3ObjectTarget(*obj.Key, conf.Bucket, time.Time{}, ackFn))
 	}
 	if len(output.Contents) > 0 {
@@ -221,7 +252,7 @@ func (s *staticTargetReader) Pop(ctx context.Context) (*s3ObjectTarget, error) {
 			return nil, fmt.Errorf("failed to list objects: %v", err)
 		}
 		for _, obj := range output.Contents {
-			ackFn := deleteS3ObjectAckFn(s.s3, s.conf.Bucket, *obj.Key, s.conf.DeleteObjects, nil)
+			ackFn := deleteS3ObjectAckFn(s.s3, s.conf.Bucket, *obj.Key, s.conf.DeleteObjects, s.conf.MoveObjectsTo, nil)
 			s.pending = append(s.pending, newS3ObjectTarget(*obj.Key, s.conf.Bucket, time.Time{}, ackFn))
 		}
 		if len(output.Contents) > 0 {
@@ -242,6 +273,147 @@ func (s staticTargetReader) Close(context.Context) error {
 
 //------------------------------------------------------------------------------
 
+type pollingTargetReader struct {
+	conf     oinput.AWSS3Config
+	log      log.Modular
+	mgr      interop.Manager
+	s3       *s3.S3
+	interval time.Duration
+	cacheKey string
+
+	startAfter  *string
+	nextRequest time.Time
+	pending     []*s3ObjectTarget
+
+	checkpointMut sync.Mutex
+	checkpointer  *checkpoint.Type
+	committed     string
+	store         *checkpoint.Store
+}
+
+func newPollingTargetReader(
+	ctx context.Context,
+	conf oinput.AWSS3Config,
+	log log.Modular,
+	mgr interop.Manager,
+	s3Client *s3.S3,
+	interval time.Duration,
+) (*pollingTargetReader, error) {
+	p := &pollingTargetReader{
+		conf:         conf,
+		log:          log,
+		mgr:          mgr,
+		s3:           s3Client,
+		interval:     interval,
+		cacheKey:     conf.Polling.CacheKey,
+		checkpointer: checkpoint.New(),
+	}
+	if p.cacheKey == "" {
+		p.cacheKey = conf.Bucket + "/" + conf.Prefix
+	}
+	if len(conf.StartAfter) > 0 {
+		p.startAfter = aws.String(conf.StartAfter)
+	}
+
+	var err error
+	storeConf := checkpoint.NewStoreConfig()
+	storeConf.Cache = conf.Polling.Cache
+	if p.store, err = checkpoint.NewStore(storeConf, mgr, mgr.Metrics()); err != nil {
+		return nil, err
+	}
+
+	marker, err := p.store.Get(ctx, p.cacheKey)
+	if err != nil {
+		if !errors.Is(err, component.ErrKeyNotFound) {
+			return nil, fmt.Errorf("failed to read last processed key from cache: %w", err)
+		}
+	} else if len(marker) > 0 {
+		p.committed = string(marker)
+		p.startAfter = aws.String(p.committed)
+		log.Infof("Resuming listing of bucket %v after key: %v\n", conf.Bucket, p.committed)
+	}
+	return p, nil
+}
+
+func (p *pollingTargetReader) commit(ctx context.Context, resolveFn func() interface{}) error {
+	p.checkpointMut.Lock()
+	defer p.checkpointMut.Unlock()
+
+	highest, _ := resolveFn().(string)
+	if highest == "" || highest == p.committed {
+		return nil
+	}
+	if err := p.store.Set(ctx, p.cacheKey, []byte(highest)); err != nil {
+		return fmt.Errorf("failed to store last processed key in cache: %w", err)
+	}
+	p.committed = highest
+	return nil
+}
+
+func (p *pollingTargetReader) list(ctx context.Context) error {
+	listInput := &s3.ListObjectsV2Input{
+		Bucket:     aws.String(p.conf.Bucket),
+		MaxKeys:    aws.Int64(100),
+		StartAfter: p.startAfter,
+	}
+	if len(p.conf.Prefix) > 0 {
+		listInput.Prefix = aws.String(p.conf.Prefix)
+	}
+	output, err := p.s3.ListObjectsV2WithContext(ctx, listInput)
+	if err != nil {
+		return fmt.Errorf("failed to list objects: %v", err)
+	}
+
+	p.checkpointMut.Lock()
+	defer p.checkpointMut.Unlock()
+
+	for _, obj := range output.Contents {
+		resolveFn := p.checkpointer.Track(*obj.Key, 1)
+		delFn := deleteS3ObjectAckFn(p.s3, p.conf.Bucket, *obj.Key, p.conf.DeleteObjects, p.conf.MoveObjectsTo, nil)
+		p.pending = append(p.pending, newS3ObjectTarget(*obj.Key, p.conf.Bucket, time.Time{}, func(ctx context.Context, err error) error {
+			if aerr := delFn(ctx, err); aerr != nil || err != nil {
+				return aerr
+			}
+			return p.commit(ctx, resolveFn)
+		}))
+	}
+	if len(output.Contents) > 0 {
+		p.startAfter = output.Contents[len(output.Contents)-1].Key
+	}
+	return nil
+}
+
+func (p *pollingTargetReader) Pop(ctx context.Context) (*s3ObjectTarget, error) {
+	if len(p.pending) == 0 {
+		if !p.nextRequest.IsZero() {
+			if until := time.Until(p.nextRequest); until > 0 {
+				select {
+				case <-time.After(until):
+				case <-ctx.Done():
+					return nil, ctx.Err()
+				}
+			}
+		}
+		if err := p.list(ctx); err != nil {
+			return nil, err
+		}
+		if len(p.pending) == 0 {
+			p.nextRequest = time.Now().Add(p.interval)
+			return nil, component.ErrTimeout
+		}
+		p.nextRequest = time.Time{}
+	}
+	obj := p.pending[0]
+	p.pending = p.pending[1:]
+	return obj, nil
+}
+
+func (p *pollingTargetReader) Close(context.Context) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
 type sqsTargetReader struct {
 	conf oinput.AWSS3Config
 	log  log.Modular
@@ -430,7 +602,7 @@ func (s *sqsTargetReader) readSQSEvents(ctx context.Context) ([]*s3ObjectTarget,
 			pendingObjects = append(pendingObjects, newS3ObjectTarget(
 				object.key, object.bucket, notificationAt,
 				deleteS3ObjectAckFn(
-					s.s3, object.bucket, object.key, s.conf.DeleteObjects,
+					s.s3, object.bucket, object.key, s.conf.DeleteObjects, s.conf.MoveObjectsTo,
 					func(ctx context.Context, err error) (aerr error) {
 						if err != nil {
 							nackOnce.Do(func() {
@@ -510,12 +682,14 @@ type awsS3Reader struct {
 	s3      *s3.S3
 	sqs     *sqs.SQS
 
-	gracePeriod time.Duration
+	gracePeriod  time.Duration
+	pollInterval time.Duration
 
 	objectMut sync.Mutex
 	object    *s3PendingObject
 
 	log log.Modular
+	mgr bundle.NewManagement
 }
 
 type s3PendingObject struct {
@@ -533,9 +707,21 @@ func newAmazonS3Reader(conf oinput.AWSS3Config, nm bundle.NewManagement) (*awsS3
 	if conf.Prefix != "" && conf.SQS.URL != "" {
 		return nil, errors.New("cannot specify both a prefix and sqs.url")
 	}
+	if conf.Polling.Enabled && conf.SQS.URL != "" {
+		return nil, errors.New("cannot enable polling when sqs.url is specified")
+	}
+	if conf.MoveObjectsTo != "" {
+		if conf.DeleteObjects {
+			return nil, errors.New("cannot specify both delete_objects and move_objects_to")
+		}
+		if conf.SQS.URL == "" && strings.HasPrefix(conf.MoveObjectsTo, conf.Prefix) {
+			return nil, errors.New("move_objects_to must not fall within prefix")
+		}
+	}
 	s := &awsS3Reader{
 		conf: conf,
 		log:  nm.Logger(),
+		mgr:  nm,
 	}
 	var err error
 	if s.objectScannerCtor, err = codec.GetReader(conf.Codec, codec.NewReaderConfig()); err != nil {
@@ -546,6 +732,14 @@ func newAmazonS3Reader(conf oinput.AWSS3Config, nm bundle.NewManagement) (*awsS3
 			return nil, fmt.Errorf("failed to parse grace period: %w", err)
 		}
 	}
+	if conf.Polling.Enabled {
+		if s.pollInterval, err = time.ParseDuration(conf.Polling.Interval); err != nil {
+			return nil, fmt.Errorf("failed to parse polling interval: %w", err)
+		}
+		if conf.Polling.Cache != "" && !nm.ProbeCache(conf.Polling.Cache) {
+			return nil, fmt.Errorf("cache resource '%v' was not found", conf.Polling.Cache)
+		}
+	}
 	return s, nil
 }
 
@@ -553,6 +747,9 @@ func (a *awsS3Reader) getTargetReader(ctx context.Context) (s3ObjectTargetReader
 	if a.sqs != nil {
 		return newSQSTargetReader(a.conf, a.log, a.s3, a.sqs), nil
 	}
+	if a.conf.Polling.Enabled {
+		return newPollingTargetReader(ctx, a.conf, a.log, a.mgr, a.s3, a.pollInterval)
+	}
 	return newStaticTargetReader(ctx, a.conf, a.log, a.s3)
 }
 
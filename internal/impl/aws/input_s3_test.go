@@ -0,0 +1,131 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/component"
+	"github.com/benthosdev/benthos/v4/internal/log"
+	"github.com/benthosdev/benthos/v4/internal/manager/mock"
+	oinput "github.com/benthosdev/benthos/v4/internal/old/input"
+)
+
+type fakeS3Bucket struct {
+	mut     sync.Mutex
+	keys    map[string]struct{}
+	deleted []string
+}
+
+func (f *fakeS3Bucket) add(keys ...string) {
+	f.mut.Lock()
+	for _, k := range keys {
+		f.keys[k] = struct{}{}
+	}
+	f.mut.Unlock()
+}
+
+func (f *fakeS3Bucket) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+
+	if r.Method == http.MethodDelete {
+		key := strings.TrimPrefix(r.URL.Path, "/bucket/")
+		delete(f.keys, key)
+		f.deleted = append(f.deleted, key)
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	startAfter := r.URL.Query().Get("start-after")
+	var keys []string
+	for k := range f.keys {
+		if k > startAfter {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	var contents string
+	for _, k := range keys {
+		contents += fmt.Sprintf("<Contents><Key>%v</Key></Contents>", k)
+	}
+	fmt.Fprintf(w, `<ListBucketResult xmlns="http://s3.amazonaws.com/doc/2006-03-01/"><Name>bucket</Name><KeyCount>%v</KeyCount><IsTruncated>false</IsTruncated>%v</ListBucketResult>`, len(keys), contents)
+}
+
+func TestS3PollingTargetReader(t *testing.T) {
+	bucket := &fakeS3Bucket{keys: map[string]struct{}{}}
+	bucket.add("a", "b", "c")
+
+	ts := httptest.NewServer(bucket)
+	defer ts.Close()
+
+	sess, err := session.NewSession(&aws.Config{
+		Region:           aws.String("eu-west-1"),
+		Endpoint:         aws.String(ts.URL),
+		Credentials:      credentials.NewStaticCredentials("id", "secret", ""),
+		S3ForcePathStyle: aws.Bool(true),
+	})
+	require.NoError(t, err)
+
+	mgr := mock.NewManager()
+	mgr.Caches["foocache"] = map[string]mock.CacheItem{
+		"bucket/": {Value: "a"},
+	}
+
+	conf := oinput.NewAWSS3Config()
+	conf.Bucket = "bucket"
+	conf.DeleteObjects = true
+	conf.Polling.Enabled = true
+	conf.Polling.Cache = "foocache"
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*10)
+	defer done()
+
+	rdr, err := newPollingTargetReader(ctx, conf, log.Noop(), mgr, s3.New(sess), time.Millisecond*50)
+	require.NoError(t, err)
+
+	tB, err := rdr.Pop(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "b", tB.key)
+
+	tC, err := rdr.Pop(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "c", tC.key)
+
+	_, err = rdr.Pop(ctx)
+	assert.Equal(t, component.ErrTimeout, err)
+
+	require.NoError(t, tC.ackFn(ctx, nil))
+	assert.Equal(t, "a", mgr.Caches["foocache"]["bucket/"].Value)
+
+	require.NoError(t, tB.ackFn(ctx, nil))
+	assert.Equal(t, "c", mgr.Caches["foocache"]["bucket/"].Value)
+
+	bucket.add("d")
+
+	tD, err := rdr.Pop(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "d", tD.key)
+
+	require.NoError(t, tD.ackFn(ctx, nil))
+	assert.Equal(t, "d", mgr.Caches["foocache"]["bucket/"].Value)
+
+	bucket.mut.Lock()
+	assert.Equal(t, []string{"c", "b", "d"}, bucket.deleted)
+	assert.Equal(t, map[string]struct{}{"a": {}}, bucket.keys)
+	bucket.mut.Unlock()
+}
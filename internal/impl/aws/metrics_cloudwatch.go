@@ -2,6 +2,7 @@ package aws
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"sync"
@@ -11,6 +12,8 @@ import (
 	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/service/cloudwatch"
 	"github.com/aws/aws-sdk-go/service/cloudwatch/cloudwatchiface"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs/cloudwatchlogsiface"
 
 	"github.com/benthosdev/benthos/v4/internal/bundle"
 	"github.com/benthosdev/benthos/v4/internal/component/metrics"
@@ -47,11 +50,23 @@ metrics:
     ].contains(this) { deleted() }
   aws_cloudwatch:
     namespace: Foo
-` + "```" + ``,
+` + "```" + `
+
+### Embedded Metric Format
+
+When ` + "`emf.enabled`" + ` is ` + "`true`" + ` metrics are written as log events in the [Embedded Metric Format](https://docs.aws.amazon.com/AmazonCloudWatch/latest/monitoring/CloudWatch_Embedded_Metric_Format_Specification.html) to a CloudWatch Logs group with the PutLogEvents endpoint instead of the PutMetricData endpoint, from which CloudWatch extracts the metrics asynchronously. This avoids the cost of PutMetricData requests, and labels listed in ` + "`emf.property_labels`" + ` are added to each log event as properties rather than dimensions, which allows high cardinality labels such as IDs to be searched with CloudWatch Logs Insights without creating a metric series for each value.
+
+The log stream is created if it does not already exist, but the log group must be created beforehand.`,
 		Config: docs.FieldComponent().WithChildren(
 			append(docs.FieldSpecs{
 				docs.FieldString("namespace", "The namespace used to distinguish metrics from other services.").HasDefault("Benthos"),
 				docs.FieldString("flush_period", "The period of time between PutMetricData requests.").Advanced().HasDefault("100ms"),
+				docs.FieldObject("emf", "Write metrics as log events in the Embedded Metric Format instead of calling PutMetricData.").WithChildren(
+					docs.FieldBool("enabled", "Whether to write metrics in the Embedded Metric Format.").HasDefault(false),
+					docs.FieldString("log_group", "The CloudWatch Logs group to write log events to.").HasDefault(""),
+					docs.FieldString("log_stream", "The CloudWatch Logs stream to write log events to.").HasDefault("benthos"),
+					docs.FieldString("property_labels", "A list of labels to add to log events as properties rather than dimensions.", []string{"request_id"}).Array().HasDefault([]interface{}{}),
+				).Advanced().AtVersion("4.1.0"),
 			}, session.FieldSpecs()...)...,
 		),
 	})
@@ -67,6 +82,7 @@ type cloudWatchDatum struct {
 	MetricName string
 	Unit       string
 	Dimensions []*cloudwatch.Dimension
+	Properties map[string]string
 	Timestamp  time.Time
 	Value      int64
 	Values     map[int64]int64
@@ -78,6 +94,7 @@ type cloudWatchStat struct {
 	name       string
 	unit       string
 	dimensions []*cloudwatch.Dimension
+	properties map[string]string
 }
 
 // Trims a map of datum values to a ceiling. The primary goal here is to be fast
@@ -113,6 +130,7 @@ func (c *cloudWatchStat) appendValue(v int64) {
 			MetricName: c.name,
 			Unit:       c.unit,
 			Dimensions: c.dimensions,
+			Properties: c.properties,
 			Timestamp:  time.Now(),
 			Values:     map[int64]int64{v: 1},
 		}
@@ -135,6 +153,7 @@ func (c *cloudWatchStat) addValue(v int64) {
 			MetricName: c.name,
 			Unit:       c.unit,
 			Dimensions: c.dimensions,
+			Properties: c.properties,
 			Timestamp:  time.Now(),
 			Value:      v,
 		}
@@ -179,15 +198,26 @@ func (c *cloudWatchStatVec) with(labelValues ...string) *cloudWatchStat {
 	if lDim >= maxCloudWatchDimensions {
 		lDim = maxCloudWatchDimensions
 	}
-	dimensions := make([]*cloudwatch.Dimension, lDim)
+	dimensions := make([]*cloudwatch.Dimension, 0, lDim)
+	var properties map[string]string
 	for i, k := range c.labelNames {
-		if len(labelValues) <= i || i >= maxCloudWatchDimensions {
+		if len(labelValues) <= i {
 			break
 		}
-		dimensions[i] = &cloudwatch.Dimension{
+		if _, isProperty := c.root.propertyLabels[k]; isProperty {
+			if properties == nil {
+				properties = map[string]string{}
+			}
+			properties[k] = labelValues[i]
+			continue
+		}
+		if len(dimensions) >= maxCloudWatchDimensions {
+			continue
+		}
+		dimensions = append(dimensions, &cloudwatch.Dimension{
 			Name:  aws.String(k),
 			Value: aws.String(labelValues[i]),
-		}
+		})
 	}
 	return &cloudWatchStat{
 		root:       c.root,
@@ -195,6 +225,7 @@ func (c *cloudWatchStatVec) with(labelValues ...string) *cloudWatchStat {
 		name:       c.name,
 		unit:       c.unit,
 		dimensions: dimensions,
+		properties: properties,
 	}
 }
 
@@ -227,6 +258,12 @@ func (c *cloudWatchGaugeVec) With(labelValues ...string) metrics.StatGauge {
 type cwMetrics struct {
 	client cloudwatchiface.CloudWatchAPI
 
+	// When set metrics are written as log events in the Embedded Metric
+	// Format rather than with PutMetricData requests.
+	logsClient     cloudwatchlogsiface.CloudWatchLogsAPI
+	propertyLabels map[string]struct{}
+	streamCreated  bool
+
 	datumses  map[string]*cloudWatchDatum
 	datumLock *sync.Mutex
 
@@ -258,7 +295,21 @@ func newCloudWatch(config metrics.CloudWatchConfig, log log.Modular) (metrics.Ty
 		return nil, fmt.Errorf("failed to parse flush period: %v", err)
 	}
 
-	c.client = cloudwatch.New(sess)
+	if config.EMF.Enabled {
+		if config.EMF.LogGroup == "" {
+			return nil, errors.New("a log group must be specified when emf is enabled")
+		}
+		if config.EMF.LogStream == "" {
+			return nil, errors.New("a log stream must be specified when emf is enabled")
+		}
+		c.logsClient = cloudwatchlogs.New(sess)
+		c.propertyLabels = map[string]struct{}{}
+		for _, l := range config.EMF.PropertyLabels {
+			c.propertyLabels[l] = struct{}{}
+		}
+	} else {
+		c.client = cloudwatch.New(sess)
+	}
 	go c.loop()
 	return c, nil
 }
@@ -400,6 +451,10 @@ func (c *cwMetrics) flush() error {
 	c.datumses = map[string]*cloudWatchDatum{}
 	c.datumLock.Unlock()
 
+	if c.logsClient != nil {
+		return c.flushEMF(datumMap)
+	}
+
 	datums := []*cloudwatch.MetricDatum{}
 	for _, v := range datumMap {
 		if v != nil {
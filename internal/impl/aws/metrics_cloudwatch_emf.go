@@ -0,0 +1,181 @@
+package aws
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
+)
+
+const (
+	maxEMFValues      = 100
+	maxLogEvents      = 10000
+	maxLogEventsBytes = 1048576
+
+	// The number of bytes that CloudWatch Logs adds to the size of each event
+	// when calculating the size of a batch.
+	logEventOverheadBytes = 26
+)
+
+type emfMetricDirective struct {
+	Name string `json:"Name"`
+	Unit string `json:"Unit"`
+}
+
+type emfMetricsDirective struct {
+	Namespace  string               `json:"Namespace"`
+	Dimensions [][]string           `json:"Dimensions"`
+	Metrics    []emfMetricDirective `json:"Metrics"`
+}
+
+type emfMetadata struct {
+	Timestamp         int64                 `json:"Timestamp"`
+	CloudWatchMetrics []emfMetricsDirective `json:"CloudWatchMetrics"`
+}
+
+// emfValues expands a map of values to their tallies into a list of values
+// with each value repeated by its tally, up to the maximum number of values
+// that an EMF metric may contain.
+func emfValues(m map[int64]int64) []float64 {
+	keys := make([]int64, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i] < keys[j]
+	})
+
+	values := make([]float64, 0, len(keys))
+	for _, k := range keys {
+		for i := int64(0); i < m[k] && len(values) < maxEMFValues; i++ {
+			values = append(values, float64(k))
+		}
+	}
+	return values
+}
+
+// emfEvent creates a log event in the Embedded Metric Format from a datum,
+// where dimensions and properties are added as members of the event.
+func (c *cwMetrics) emfEvent(d *cloudWatchDatum) (*cloudwatchlogs.InputLogEvent, error) {
+	dimensionNames := make([]string, 0, len(d.Dimensions))
+	event := map[string]interface{}{}
+	for k, v := range d.Properties {
+		event[k] = v
+	}
+	for _, dim := range d.Dimensions {
+		dimensionNames = append(dimensionNames, *dim.Name)
+		event[*dim.Name] = *dim.Value
+	}
+
+	if len(d.Values) > 0 {
+		event[d.MetricName] = emfValues(d.Values)
+	} else {
+		event[d.MetricName] = d.Value
+	}
+
+	timestamp := aws.TimeUnixMilli(d.Timestamp)
+	event["_aws"] = emfMetadata{
+		Timestamp: timestamp,
+		CloudWatchMetrics: []emfMetricsDirective{
+			{
+				Namespace:  c.config.Namespace,
+				Dimensions: [][]string{dimensionNames},
+				Metrics: []emfMetricDirective{
+					{Name: d.MetricName, Unit: d.Unit},
+				},
+			},
+		},
+	}
+
+	eventBytes, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+	return &cloudwatchlogs.InputLogEvent{
+		Message:   aws.String(string(eventBytes)),
+		Timestamp: aws.Int64(timestamp),
+	}, nil
+}
+
+func (c *cwMetrics) createLogStream() {
+	if c.streamCreated {
+		return
+	}
+	_, err := c.logsClient.CreateLogStream(&cloudwatchlogs.CreateLogStreamInput{
+		LogGroupName:  &c.config.EMF.LogGroup,
+		LogStreamName: &c.config.EMF.LogStream,
+	})
+	if err != nil {
+		if aerr, ok := err.(awserr.Error); !ok || aerr.Code() != cloudwatchlogs.ErrCodeResourceAlreadyExistsException {
+			c.log.Errorf("Failed to create log stream: %v\n", err)
+			return
+		}
+	}
+	c.streamCreated = true
+}
+
+func (c *cwMetrics) flushEMF(datumMap map[string]*cloudWatchDatum) error {
+	if len(datumMap) == 0 {
+		return nil
+	}
+
+	events := make([]*cloudwatchlogs.InputLogEvent, 0, len(datumMap))
+	for _, v := range datumMap {
+		if v == nil {
+			continue
+		}
+		e, err := c.emfEvent(v)
+		if err != nil {
+			c.log.Errorf("Failed to encode metric data: %v\n", err)
+			continue
+		}
+		events = append(events, e)
+	}
+
+	// Events within a batch must be in chronological order.
+	sort.Slice(events, func(i, j int) bool {
+		return *events[i].Timestamp < *events[j].Timestamp
+	})
+
+	c.createLogStream()
+
+	for len(events) > 0 {
+		n, size := 0, 0
+		for n < len(events) && n < maxLogEvents {
+			eventSize := len(*events[n].Message) + logEventOverheadBytes
+			if n > 0 && size+eventSize > maxLogEventsBytes {
+				break
+			}
+			size += eventSize
+			n++
+		}
+
+		_, err := c.logsClient.PutLogEvents(&cloudwatchlogs.PutLogEventsInput{
+			LogGroupName:  &c.config.EMF.LogGroup,
+			LogStreamName: &c.config.EMF.LogStream,
+			LogEvents:     events[:n],
+		})
+		if err == nil {
+			events = events[n:]
+			continue
+		}
+
+		if !request.IsErrorThrottle(err) {
+			c.log.Errorf("Failed to send metric data: %v\n", err)
+			events = events[n:]
+			continue
+		}
+
+		c.log.Warnln("Metrics request was throttled. Either increase flush period or reduce number of services sending metrics.")
+		select {
+		case <-time.After(time.Second):
+		case <-c.ctx.Done():
+			return c.ctx.Err()
+		}
+	}
+	return nil
+}
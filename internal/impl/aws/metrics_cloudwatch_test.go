@@ -2,6 +2,7 @@ package aws
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"sync"
 	"testing"
@@ -9,7 +10,10 @@ import (
 
 	"github.com/aws/aws-sdk-go/service/cloudwatch"
 	"github.com/aws/aws-sdk-go/service/cloudwatch/cloudwatchiface"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs/cloudwatchlogsiface"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/benthosdev/benthos/v4/internal/component/metrics"
 	"github.com/benthosdev/benthos/v4/internal/log"
@@ -433,3 +437,105 @@ func TestCloudWatchTagsMoreThan20(t *testing.T) {
 		},
 	}, checkInput(mockSvc.inputs[0]))
 }
+
+type mockCloudWatchLogsClient struct {
+	cloudwatchlogsiface.CloudWatchLogsAPI
+
+	streams []string
+	inputs  []cloudwatchlogs.PutLogEventsInput
+}
+
+func (m *mockCloudWatchLogsClient) CreateLogStream(input *cloudwatchlogs.CreateLogStreamInput) (*cloudwatchlogs.CreateLogStreamOutput, error) {
+	m.streams = append(m.streams, *input.LogGroupName+"/"+*input.LogStreamName)
+	return nil, nil
+}
+
+func (m *mockCloudWatchLogsClient) PutLogEvents(input *cloudwatchlogs.PutLogEventsInput) (*cloudwatchlogs.PutLogEventsOutput, error) {
+	m.inputs = append(m.inputs, *input)
+	return nil, nil
+}
+
+func TestCloudWatchEMF(t *testing.T) {
+	mockSvc := &mockCloudWatchLogsClient{}
+
+	conf := metrics.NewCloudWatchConfig()
+	conf.EMF.Enabled = true
+	conf.EMF.LogGroup = "foo"
+
+	cw := &cwMetrics{
+		config:         conf,
+		datumses:       map[string]*cloudWatchDatum{},
+		datumLock:      &sync.Mutex{},
+		log:            log.Noop(),
+		logsClient:     mockSvc,
+		propertyLabels: map[string]struct{}{"id": {}},
+	}
+	cw.ctx, cw.cancel = context.WithCancel(context.Background())
+
+	cw.GetCounterVec("counter.bar", "foo", "id").With("one", "abc").Incr(2)
+	tmg := cw.GetTimer("timer.foo")
+	tmg.Timing(23000)
+	tmg.Timing(23000)
+	tmg.Timing(87000)
+
+	cw.flush()
+
+	assert.Equal(t, []string{"foo/benthos"}, mockSvc.streams)
+	require.Len(t, mockSvc.inputs, 1)
+	require.Len(t, mockSvc.inputs[0].LogEvents, 2)
+
+	events := map[string]map[string]interface{}{}
+	for _, e := range mockSvc.inputs[0].LogEvents {
+		var event map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(*e.Message), &event))
+
+		meta := event["_aws"].(map[string]interface{})
+		assert.Equal(t, float64(*e.Timestamp), meta["Timestamp"])
+		delete(meta, "Timestamp")
+
+		if _, exists := event["counter.bar"]; exists {
+			events["counter.bar"] = event
+		} else {
+			events["timer.foo"] = event
+		}
+	}
+
+	assert.Equal(t, map[string]interface{}{
+		"_aws": map[string]interface{}{
+			"CloudWatchMetrics": []interface{}{
+				map[string]interface{}{
+					"Namespace":  "Benthos",
+					"Dimensions": []interface{}{[]interface{}{"foo"}},
+					"Metrics": []interface{}{
+						map[string]interface{}{"Name": "counter.bar", "Unit": "Count"},
+					},
+				},
+			},
+		},
+		"counter.bar": float64(2),
+		"foo":         "one",
+		"id":          "abc",
+	}, events["counter.bar"])
+
+	assert.Equal(t, map[string]interface{}{
+		"_aws": map[string]interface{}{
+			"CloudWatchMetrics": []interface{}{
+				map[string]interface{}{
+					"Namespace":  "Benthos",
+					"Dimensions": []interface{}{[]interface{}{}},
+					"Metrics": []interface{}{
+						map[string]interface{}{"Name": "timer.foo", "Unit": "Microseconds"},
+					},
+				},
+			},
+		},
+		"timer.foo": []interface{}{float64(23), float64(23), float64(87)},
+	}, events["timer.foo"])
+
+	cw.GetCounter("counter.baz").Incr(1)
+	cw.flush()
+
+	assert.Equal(t, []string{"foo/benthos"}, mockSvc.streams)
+	require.Len(t, mockSvc.inputs, 2)
+	require.Len(t, mockSvc.inputs[1].LogEvents, 1)
+}
@@ -7,14 +7,18 @@ import (
 	"net/url"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+	"github.com/dustin/go-humanize"
 
 	"github.com/benthosdev/benthos/v4/internal/batch/policy"
 	"github.com/benthosdev/benthos/v4/internal/bloblang/field"
+	"github.com/benthosdev/benthos/v4/internal/bloblang/mapping"
 	"github.com/benthosdev/benthos/v4/internal/bundle"
 	"github.com/benthosdev/benthos/v4/internal/component"
 	"github.com/benthosdev/benthos/v4/internal/component/output"
@@ -26,6 +30,7 @@ import (
 	"github.com/benthosdev/benthos/v4/internal/metadata"
 	ooutput "github.com/benthosdev/benthos/v4/internal/old/output"
 	"github.com/benthosdev/benthos/v4/internal/old/output/writer"
+	"github.com/benthosdev/benthos/v4/internal/shutdown"
 )
 
 func init() {
@@ -69,6 +74,27 @@ output:
       Timestamp: ${!meta("Timestamp")}
 `+"```"+`
 
+### Multipart Uploads
+
+When the field `+"`multipart.enabled`"+` is set objects are assembled from many messages with multipart uploads instead, where the contents of each message are appended to the object at the path resolved for it. The contents of an object are buffered and uploaded as parts of at least `+"`multipart.part_size`"+` bytes, and an object is completed once the `+"[Bloblang query](/docs/guides/bloblang/about)"+` `+"`multipart.check`"+` returns `+"`true`"+` for a message written to it, once no messages have been written to it for the period `+"`multipart.idle_timeout`"+`, or when Benthos shuts down.
+
+For example, in order to write the messages of each hour to a single object:
+
+`+"```yaml"+`
+output:
+  aws_s3:
+    bucket: hourly-events
+    path: ${!timestamp_unix().format_timestamp("2006-01-02T15", "UTC")}.jsonl
+    multipart:
+      enabled: true
+      part_size: 10MiB
+      idle_timeout: 10m
+  processors:
+    - bloblang: 'root = content().string() + "\n"'
+`+"```"+`
+
+Messages are only acknowledged once the part containing them has been uploaded, which means that messages within a buffer that has not yet reached the part size are held until the object is completed. Therefore `+"`max_in_flight`"+` should be large enough for the messages in flight to fill a part, and either `+"`multipart.check`"+` or `+"`multipart.idle_timeout`"+` should be set in order for partially filled parts to be uploaded before shutdown. If uploading a part fails the message that filled it is rejected and reattempted, and the messages buffered before it remain held until a later upload succeeds.
+
 ### Credentials
 
 By default Benthos will use a shared credentials file when connecting to AWS
@@ -143,6 +169,12 @@ output:
 			).IsInterpolated().Advanced(),
 			docs.FieldString("kms_key_id", "An optional server side encryption key.").Advanced(),
 			docs.FieldString("server_side_encryption", "An optional server side encryption algorithm.").AtVersion("3.63.0").Advanced(),
+			docs.FieldObject("multipart", "Assemble objects from many messages with multipart uploads, where the contents of messages are appended to the object at their path.").WithChildren(
+				docs.FieldBool("enabled", "Whether to assemble objects with multipart uploads."),
+				docs.FieldString("part_size", "The minimum size of each uploaded part, which must be at least 5MiB.", "5MiB", "64MiB"),
+				docs.FieldString("check", "An optional [Bloblang query](/docs/guides/bloblang/about) that should return a boolean value indicating whether the object that a message is written to should be completed after it.", `meta("eof") == "true"`).HasDefault(""),
+				docs.FieldString("idle_timeout", "The period of time after which an object that has not been written to is completed. Set to an empty string in order to disable.", "30s", "1h"),
+			).AtVersion("4.1.0").Advanced(),
 			docs.FieldBool("force_path_style_urls", "Forces the client API to use path style URLs, which helps when connecting to custom endpoints.").Advanced(),
 			docs.FieldInt("max_in_flight", "The maximum number of messages to have in flight at a given time. Increase this to improve throughput."),
 			docs.FieldString("timeout", "The maximum period to wait on an upload before abandoning it and reattempting.").Advanced(),
@@ -177,11 +209,57 @@ type amazonS3Writer struct {
 	storageClass            *field.Expression
 	metaFilter              *metadata.ExcludeFilter
 
+	partSize      int
+	completeCheck *mapping.Executor
+	idleTimeout   time.Duration
+	uploadsMut    sync.Mutex
+	uploads       map[string]*s3MultipartUpload
+
 	session  *session.Session
 	uploader *s3manager.Uploader
+	s3       *s3.S3
 	timeout  time.Duration
 
-	log log.Modular
+	log     log.Modular
+	shutSig *shutdown.Signaller
+}
+
+type s3MultipartUpload struct {
+	key       string
+	uploadID  *string
+	parts     []*s3.CompletedPart
+	buf       bytes.Buffer
+	bufFlush  *s3PartFlush
+	lastWrite time.Time
+
+	// Set when completing the upload has failed, in which case the upload
+	// must be completed before further writes to its key.
+	completing bool
+}
+
+// s3PartFlush is resolved once the buffered contents of an upload are uploaded
+// as a part, which is when the messages added to the buffer can be acked.
+type s3PartFlush struct {
+	done chan struct{}
+	err  error
+}
+
+func newS3PartFlush() *s3PartFlush {
+	return &s3PartFlush{done: make(chan struct{})}
+}
+
+func (f *s3PartFlush) resolve(err error) {
+	f.err = err
+	close(f.done)
+}
+
+func (f *s3PartFlush) wait(ctx context.Context) error {
+	select {
+	case <-f.done:
+		return f.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
 
 func newAmazonS3Writer(conf ooutput.AmazonS3Config, mgr interop.Manager) (*amazonS3Writer, error) {
@@ -196,6 +274,8 @@ func newAmazonS3Writer(conf ooutput.AmazonS3Config, mgr interop.Manager) (*amazo
 		conf:    conf,
 		log:     mgr.Logger(),
 		timeout: timeout,
+		uploads: map[string]*s3MultipartUpload{},
+		shutSig: shutdown.NewSignaller(),
 	}
 	var err error
 	if a.path, err = mgr.BloblEnvironment().NewField(conf.Path); err != nil {
@@ -242,6 +322,30 @@ func newAmazonS3Writer(conf ooutput.AmazonS3Config, mgr interop.Manager) (*amazo
 		return a.tags[i].key < a.tags[j].key
 	})
 
+	if conf.Multipart.Enabled {
+		partSize, err := humanize.ParseBytes(conf.Multipart.PartSize)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse multipart part size: %w", err)
+		}
+		if partSize < uint64(s3manager.MinUploadPartSize) {
+			return nil, fmt.Errorf("multipart part size must be at least %v bytes, got %v", s3manager.MinUploadPartSize, partSize)
+		}
+		if partSize > s3MaxUploadPartSize {
+			return nil, fmt.Errorf("multipart part size must not exceed %v bytes, got %v", s3MaxUploadPartSize, partSize)
+		}
+		a.partSize = int(partSize)
+		if conf.Multipart.Check != "" {
+			if a.completeCheck, err = mgr.BloblEnvironment().NewMapping(conf.Multipart.Check); err != nil {
+				return nil, fmt.Errorf("failed to parse multipart check query: %w", err)
+			}
+		}
+		if conf.Multipart.IdleTimeout != "" {
+			if a.idleTimeout, err = time.ParseDuration(conf.Multipart.IdleTimeout); err != nil {
+				return nil, fmt.Errorf("failed to parse multipart idle timeout: %w", err)
+			}
+		}
+		go a.multipartLoop()
+	}
 	return a, nil
 }
 
@@ -259,94 +363,317 @@ func (a *amazonS3Writer) ConnectWithContext(ctx context.Context) error {
 
 	a.session = sess
 	a.uploader = s3manager.NewUploader(sess)
+	a.s3 = s3.New(sess)
 
 	a.log.Infof("Uploading message parts as objects to Amazon S3 bucket: %v\n", a.conf.Bucket)
 	return nil
 }
 
+func (a *amazonS3Writer) uploadInput(i int, msg *message.Batch) *s3manager.UploadInput {
+	p := msg.Get(i)
+
+	metadata := map[string]*string{}
+	_ = a.metaFilter.Iter(p, func(k, v string) error {
+		metadata[k] = aws.String(v)
+		return nil
+	})
+
+	var contentEncoding *string
+	if ce := a.contentEncoding.String(i, msg); len(ce) > 0 {
+		contentEncoding = aws.String(ce)
+	}
+	var cacheControl *string
+	if ce := a.cacheControl.String(i, msg); len(ce) > 0 {
+		cacheControl = aws.String(ce)
+	}
+	var contentDisposition *string
+	if ce := a.contentDisposition.String(i, msg); len(ce) > 0 {
+		contentDisposition = aws.String(ce)
+	}
+	var contentLanguage *string
+	if ce := a.contentLanguage.String(i, msg); len(ce) > 0 {
+		contentLanguage = aws.String(ce)
+	}
+	var websiteRedirectLocation *string
+	if ce := a.websiteRedirectLocation.String(i, msg); len(ce) > 0 {
+		websiteRedirectLocation = aws.String(ce)
+	}
+
+	uploadInput := &s3manager.UploadInput{
+		Bucket:                  &a.conf.Bucket,
+		Key:                     aws.String(a.path.String(i, msg)),
+		Body:                    bytes.NewReader(p.Get()),
+		ContentType:             aws.String(a.contentType.String(i, msg)),
+		ContentEncoding:         contentEncoding,
+		CacheControl:            cacheControl,
+		ContentDisposition:      contentDisposition,
+		ContentLanguage:         contentLanguage,
+		WebsiteRedirectLocation: websiteRedirectLocation,
+		StorageClass:            aws.String(a.storageClass.String(i, msg)),
+		Metadata:                metadata,
+	}
+
+	// Prepare tags, escaping keys and values to ensure they're valid query string parameters.
+	if len(a.tags) > 0 {
+		tags := make([]string, len(a.tags))
+		for j, pair := range a.tags {
+			tags[j] = url.QueryEscape(pair.key) + "=" + url.QueryEscape(pair.value.String(i, msg))
+		}
+		uploadInput.Tagging = aws.String(strings.Join(tags, "&"))
+	}
+
+	if a.conf.KMSKeyID != "" {
+		uploadInput.ServerSideEncryption = aws.String("aws:kms")
+		uploadInput.SSEKMSKeyId = &a.conf.KMSKeyID
+	}
+
+	// NOTE: This overrides the ServerSideEncryption set above. We need this to preserve
+	// backwards compatibility, where it is allowed to only set kms_key_id in the config and
+	// the ServerSideEncryption value of "aws:kms" is implied.
+	if a.conf.ServerSideEncryption != "" {
+		uploadInput.ServerSideEncryption = &a.conf.ServerSideEncryption
+	}
+	return uploadInput
+}
+
 func (a *amazonS3Writer) WriteWithContext(wctx context.Context, msg *message.Batch) error {
 	if a.session == nil {
 		return component.ErrNotConnected
 	}
 
+	if a.conf.Multipart.Enabled {
+		return a.writeMultipart(wctx, msg)
+	}
+
 	ctx, cancel := context.WithTimeout(
 		wctx, a.timeout,
 	)
 	defer cancel()
 
 	return writer.IterateBatchedSend(msg, func(i int, p *message.Part) error {
-		metadata := map[string]*string{}
-		_ = a.metaFilter.Iter(p, func(k, v string) error {
-			metadata[k] = aws.String(v)
-			return nil
-		})
-
-		var contentEncoding *string
-		if ce := a.contentEncoding.String(i, msg); len(ce) > 0 {
-			contentEncoding = aws.String(ce)
+		if _, err := a.uploader.UploadWithContext(ctx, a.uploadInput(i, msg)); err != nil {
+			return err
 		}
-		var cacheControl *string
-		if ce := a.cacheControl.String(i, msg); len(ce) > 0 {
-			cacheControl = aws.String(ce)
+		return nil
+	})
+}
+
+//------------------------------------------------------------------------------
+
+// The maximum size of a part and the maximum number of parts of a multipart
+// upload as documented at:
+// https://docs.aws.amazon.com/AmazonS3/latest/userguide/qfacts.html
+const (
+	s3MaxUploadPartSize = 5 * 1024 * 1024 * 1024
+	s3MaxUploadParts    = 10000
+)
+
+// Adds the messages of a batch to the buffers of their uploads and waits for
+// the parts containing them to be uploaded.
+func (a *amazonS3Writer) writeMultipart(wctx context.Context, msg *message.Batch) error {
+	errs := make([]error, msg.Len())
+	flushes := make([]*s3PartFlush, msg.Len())
+
+	a.uploadsMut.Lock()
+	ctx, cancel := context.WithTimeout(wctx, a.timeout)
+	_ = msg.Iter(func(i int, p *message.Part) error {
+		flushes[i], errs[i] = a.bufferMultipart(ctx, i, msg)
+		return nil
+	})
+	cancel()
+	a.uploadsMut.Unlock()
+
+	return writer.IterateBatchedSend(msg, func(i int, p *message.Part) error {
+		if errs[i] != nil {
+			return errs[i]
 		}
-		var contentDisposition *string
-		if ce := a.contentDisposition.String(i, msg); len(ce) > 0 {
-			contentDisposition = aws.String(ce)
+		return flushes[i].wait(wctx)
+	})
+}
+
+// Adds a message to the buffer of its upload, returning the flush that is
+// resolved once the buffer is uploaded. Must be called with uploadsMut held.
+func (a *amazonS3Writer) bufferMultipart(ctx context.Context, i int, msg *message.Batch) (*s3PartFlush, error) {
+	key := a.path.String(i, msg)
+
+	upload, exists := a.uploads[key]
+	if exists && upload.completing {
+		if err := a.completeUpload(ctx, upload); err != nil {
+			return nil, err
 		}
-		var contentLanguage *string
-		if ce := a.contentLanguage.String(i, msg); len(ce) > 0 {
-			contentLanguage = aws.String(ce)
+		exists = false
+	}
+	if !exists {
+		in := a.uploadInput(i, msg)
+		out, err := a.s3.CreateMultipartUploadWithContext(ctx, &s3.CreateMultipartUploadInput{
+			Bucket:                  in.Bucket,
+			Key:                     in.Key,
+			ContentType:             in.ContentType,
+			ContentEncoding:         in.ContentEncoding,
+			CacheControl:            in.CacheControl,
+			ContentDisposition:      in.ContentDisposition,
+			ContentLanguage:         in.ContentLanguage,
+			WebsiteRedirectLocation: in.WebsiteRedirectLocation,
+			StorageClass:            in.StorageClass,
+			Metadata:                in.Metadata,
+			Tagging:                 in.Tagging,
+			ServerSideEncryption:    in.ServerSideEncryption,
+			SSEKMSKeyId:             in.SSEKMSKeyId,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create multipart upload: %w", err)
 		}
-		var websiteRedirectLocation *string
-		if ce := a.websiteRedirectLocation.String(i, msg); len(ce) > 0 {
-			websiteRedirectLocation = aws.String(ce)
+		upload = &s3MultipartUpload{
+			key:      key,
+			uploadID: out.UploadId,
 		}
+		a.uploads[key] = upload
+	}
+
+	prevLen := upload.buf.Len()
+	_, _ = upload.buf.Write(msg.Get(i).Get())
+	upload.lastWrite = time.Now()
+	if upload.bufFlush == nil {
+		upload.bufFlush = newS3PartFlush()
+	}
+	flush := upload.bufFlush
 
-		uploadInput := &s3manager.UploadInput{
-			Bucket:                  &a.conf.Bucket,
-			Key:                     aws.String(a.path.String(i, msg)),
-			Body:                    bytes.NewReader(p.Get()),
-			ContentType:             aws.String(a.contentType.String(i, msg)),
-			ContentEncoding:         contentEncoding,
-			CacheControl:            cacheControl,
-			ContentDisposition:      contentDisposition,
-			ContentLanguage:         contentLanguage,
-			WebsiteRedirectLocation: websiteRedirectLocation,
-			StorageClass:            aws.String(a.storageClass.String(i, msg)),
-			Metadata:                metadata,
+	if upload.buf.Len() >= a.partSize {
+		if len(upload.parts) >= s3MaxUploadParts-1 {
+			upload.buf.Truncate(prevLen)
+			return nil, fmt.Errorf("object %v has reached the maximum number of parts of a multipart upload", key)
 		}
+		if err := a.uploadPart(ctx, upload); err != nil {
+			upload.buf.Truncate(prevLen)
+			return nil, err
+		}
+	}
 
-		// Prepare tags, escaping keys and values to ensure they're valid query string parameters.
-		if len(a.tags) > 0 {
-			tags := make([]string, len(a.tags))
-			for j, pair := range a.tags {
-				tags[j] = url.QueryEscape(pair.key) + "=" + url.QueryEscape(pair.value.String(i, msg))
+	if a.completeCheck != nil {
+		complete, err := a.completeCheck.QueryPart(i, msg)
+		if err != nil {
+			a.log.Errorf("Failed to execute multipart check query: %v\n", err)
+		} else if complete {
+			// Failed completions are retried by the next write to the key or
+			// the idle loop, and the message remains buffered until then.
+			if err := a.completeUpload(ctx, upload); err != nil {
+				a.log.Errorf("Failed to complete multipart upload of object %v: %v\n", key, err)
 			}
-			uploadInput.Tagging = aws.String(strings.Join(tags, "&"))
 		}
+	}
+	return flush, nil
+}
+
+// Uploads the buffered contents of an upload as its next part.
+func (a *amazonS3Writer) uploadPart(ctx context.Context, upload *s3MultipartUpload) error {
+	partNumber := int64(len(upload.parts) + 1)
+	out, err := a.s3.UploadPartWithContext(ctx, &s3.UploadPartInput{
+		Bucket:     &a.conf.Bucket,
+		Key:        aws.String(upload.key),
+		UploadId:   upload.uploadID,
+		PartNumber: aws.Int64(partNumber),
+		Body:       bytes.NewReader(upload.buf.Bytes()),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to upload part %v: %w", partNumber, err)
+	}
+	upload.parts = append(upload.parts, &s3.CompletedPart{
+		ETag:       out.ETag,
+		PartNumber: aws.Int64(partNumber),
+	})
+	upload.buf.Reset()
+	if upload.bufFlush != nil {
+		upload.bufFlush.resolve(nil)
+		upload.bufFlush = nil
+	}
+	return nil
+}
+
+// Uploads any remaining buffered contents of an upload as its final part and
+// completes it. Must be called with uploadsMut held.
+func (a *amazonS3Writer) completeUpload(ctx context.Context, upload *s3MultipartUpload) error {
+	upload.completing = true
+	if upload.buf.Len() > 0 || len(upload.parts) == 0 {
+		if err := a.uploadPart(ctx, upload); err != nil {
+			return err
+		}
+	}
+	if _, err := a.s3.CompleteMultipartUploadWithContext(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:   &a.conf.Bucket,
+		Key:      aws.String(upload.key),
+		UploadId: upload.uploadID,
+		MultipartUpload: &s3.CompletedMultipartUpload{
+			Parts: upload.parts,
+		},
+	}); err != nil {
+		return fmt.Errorf("failed to complete multipart upload: %w", err)
+	}
+	delete(a.uploads, upload.key)
+	return nil
+}
 
-		if a.conf.KMSKeyID != "" {
-			uploadInput.ServerSideEncryption = aws.String("aws:kms")
-			uploadInput.SSEKMSKeyId = &a.conf.KMSKeyID
+// Completes uploads that are idle or awaiting completion, and all uploads once
+// the writer is closed, in which case messages buffered within uploads that
+// failed to complete are rejected.
+func (a *amazonS3Writer) multipartLoop() {
+	defer a.shutSig.ShutdownComplete()
+
+	var tickerChan <-chan time.Time
+	if a.idleTimeout > 0 {
+		interval := a.idleTimeout
+		if interval > time.Second {
+			interval = time.Second
 		}
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tickerChan = ticker.C
+	}
+
+	completeUploads := func(all bool) {
+		ctx, done := a.shutSig.CloseNowCtx(context.Background())
+		defer done()
+
+		a.uploadsMut.Lock()
+		defer a.uploadsMut.Unlock()
 
-		// NOTE: This overrides the ServerSideEncryption set above. We need this to preserve
-		// backwards compatibility, where it is allowed to only set kms_key_id in the config and
-		// the ServerSideEncryption value of "aws:kms" is implied.
-		if a.conf.ServerSideEncryption != "" {
-			uploadInput.ServerSideEncryption = &a.conf.ServerSideEncryption
+		for _, upload := range a.uploads {
+			if !all && !upload.completing && time.Since(upload.lastWrite) < a.idleTimeout {
+				continue
+			}
+			if err := a.completeUpload(ctx, upload); err != nil {
+				a.log.Errorf("Failed to complete multipart upload of object %v: %v\n", upload.key, err)
+				if all && upload.bufFlush != nil {
+					upload.bufFlush.resolve(err)
+					upload.bufFlush = nil
+				}
+			}
 		}
+	}
 
-		if _, err := a.uploader.UploadWithContext(ctx, uploadInput); err != nil {
-			return err
+	for {
+		select {
+		case <-tickerChan:
+			completeUploads(false)
+		case <-a.shutSig.CloseAtLeisureChan():
+			completeUploads(true)
+			return
 		}
-		return nil
-	})
+	}
 }
 
 func (a *amazonS3Writer) CloseAsync() {
+	a.shutSig.CloseAtLeisure()
 }
 
-func (a *amazonS3Writer) WaitForClose(time.Duration) error {
+func (a *amazonS3Writer) WaitForClose(timeout time.Duration) error {
+	if !a.conf.Multipart.Enabled {
+		return nil
+	}
+	select {
+	case <-a.shutSig.HasClosedChan():
+	case <-time.After(timeout):
+		a.shutSig.CloseNow()
+		return component.ErrTimeout
+	}
 	return nil
 }
@@ -0,0 +1,202 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/manager/mock"
+	"github.com/benthosdev/benthos/v4/internal/message"
+	ooutput "github.com/benthosdev/benthos/v4/internal/old/output"
+)
+
+type fakeS3Multipart struct {
+	mut       sync.Mutex
+	nextID    int
+	parts     map[string][]string
+	completed map[string]string
+
+	failParts bool
+}
+
+func (f *fakeS3Multipart) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+
+	key := strings.TrimPrefix(r.URL.Path, "/bucket/")
+	query := r.URL.Query()
+
+	_, isCreate := query["uploads"]
+	switch {
+	case r.Method == http.MethodPost && isCreate:
+		f.nextID++
+		uploadID := fmt.Sprintf("%v-%v", key, f.nextID)
+		f.parts[uploadID] = nil
+		fmt.Fprintf(w, `<InitiateMultipartUploadResult><Bucket>bucket</Bucket><Key>%v</Key><UploadId>%v</UploadId></InitiateMultipartUploadResult>`, key, uploadID)
+	case r.Method == http.MethodPut && query.Get("partNumber") != "" && f.failParts:
+		w.WriteHeader(http.StatusInternalServerError)
+	case r.Method == http.MethodPut && query.Get("partNumber") != "":
+		body, _ := io.ReadAll(r.Body)
+		uploadID := query.Get("uploadId")
+		f.parts[uploadID] = append(f.parts[uploadID], string(body))
+		w.Header().Set("ETag", fmt.Sprintf(`"%v-%v"`, uploadID, query.Get("partNumber")))
+	case r.Method == http.MethodPost && query.Get("uploadId") != "":
+		uploadID := query.Get("uploadId")
+		f.completed[key] += strings.Join(f.parts[uploadID], "")
+		delete(f.parts, uploadID)
+		fmt.Fprintf(w, `<CompleteMultipartUploadResult><Bucket>bucket</Bucket><Key>%v</Key></CompleteMultipartUploadResult>`, key)
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
+
+func TestS3MultipartWriter(t *testing.T) {
+	fake := &fakeS3Multipart{
+		parts:     map[string][]string{},
+		completed: map[string]string{},
+	}
+	ts := httptest.NewServer(fake)
+	defer ts.Close()
+
+	conf := ooutput.NewAmazonS3Config()
+	conf.Bucket = "bucket"
+	conf.Region = "eu-west-1"
+	conf.Endpoint = ts.URL
+	conf.Credentials.ID = "id"
+	conf.Credentials.Secret = "secret"
+	conf.ForcePathStyleURLs = true
+	conf.Path = `${! meta("key") }`
+	conf.Multipart.Enabled = true
+	conf.Multipart.Check = `meta("eof") == "true"`
+	conf.Multipart.IdleTimeout = ""
+
+	w, err := newAmazonS3Writer(conf, mock.NewManager())
+	require.NoError(t, err)
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*10)
+	defer done()
+
+	require.NoError(t, w.ConnectWithContext(ctx))
+
+	newMsg := func(key, content string, eof bool) *message.Batch {
+		part := message.NewPart([]byte(content))
+		part.MetaSet("key", key)
+		if eof {
+			part.MetaSet("eof", "true")
+		}
+		msg := message.QuickBatch(nil)
+		msg.Append(part)
+		return msg
+	}
+
+	// Messages are only acked once the parts containing them are uploaded, and
+	// therefore writes of buffered messages block.
+	writeAsync := func(msg *message.Batch) <-chan error {
+		resChan := make(chan error, 1)
+		go func() {
+			resChan <- w.WriteWithContext(ctx, msg)
+		}()
+		return resChan
+	}
+
+	fooFirstRes := writeAsync(newMsg("foo", "hello ", false))
+	barRes := writeAsync(newMsg("bar", "first", false))
+
+	select {
+	case err := <-fooFirstRes:
+		t.Fatalf("buffered message acked early: %v", err)
+	case <-time.After(time.Millisecond * 50):
+	}
+
+	require.NoError(t, w.WriteWithContext(ctx, newMsg("foo", "world", true)))
+	require.NoError(t, <-fooFirstRes)
+
+	fake.mut.Lock()
+	assert.Equal(t, map[string]string{"foo": "hello world"}, fake.completed)
+	fake.mut.Unlock()
+
+	select {
+	case err := <-barRes:
+		t.Fatalf("buffered message acked early: %v", err)
+	default:
+	}
+
+	w.CloseAsync()
+	require.NoError(t, w.WaitForClose(time.Second*5))
+	require.NoError(t, <-barRes)
+
+	fake.mut.Lock()
+	assert.Equal(t, map[string]string{
+		"foo": "hello world",
+		"bar": "first",
+	}, fake.completed)
+	assert.Empty(t, fake.parts)
+	fake.mut.Unlock()
+}
+
+func TestS3MultipartWriterCloseFailure(t *testing.T) {
+	fake := &fakeS3Multipart{
+		parts:     map[string][]string{},
+		completed: map[string]string{},
+		failParts: true,
+	}
+	ts := httptest.NewServer(fake)
+	defer ts.Close()
+
+	conf := ooutput.NewAmazonS3Config()
+	conf.Bucket = "bucket"
+	conf.Region = "eu-west-1"
+	conf.Endpoint = ts.URL
+	conf.Credentials.ID = "id"
+	conf.Credentials.Secret = "secret"
+	conf.ForcePathStyleURLs = true
+	conf.Path = "foo"
+	conf.Multipart.Enabled = true
+	conf.Multipart.IdleTimeout = ""
+
+	w, err := newAmazonS3Writer(conf, mock.NewManager())
+	require.NoError(t, err)
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*10)
+	defer done()
+
+	require.NoError(t, w.ConnectWithContext(ctx))
+
+	resChan := make(chan error, 1)
+	go func() {
+		resChan <- w.WriteWithContext(ctx, message.QuickBatch([][]byte{[]byte("hello")}))
+	}()
+
+	select {
+	case err := <-resChan:
+		t.Fatalf("buffered message acked early: %v", err)
+	case <-time.After(time.Millisecond * 50):
+	}
+
+	w.CloseAsync()
+	require.NoError(t, w.WaitForClose(time.Second*5))
+	require.Error(t, <-resChan)
+
+	fake.mut.Lock()
+	assert.Empty(t, fake.completed)
+	fake.mut.Unlock()
+}
+
+func TestS3MultipartConfigErrors(t *testing.T) {
+	conf := ooutput.NewAmazonS3Config()
+	conf.Multipart.Enabled = true
+	conf.Multipart.PartSize = "1MiB"
+
+	_, err := newAmazonS3Writer(conf, mock.NewManager())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "multipart part size must be at least")
+}
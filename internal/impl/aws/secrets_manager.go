@@ -0,0 +1,72 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+
+	"github.com/benthosdev/benthos/v4/internal/secrets"
+)
+
+var (
+	secretsManagerClientsMut sync.Mutex
+	secretsManagerClients    = map[string]*secretsmanager.SecretsManager{}
+)
+
+func secretsManagerClient(region string) (*secretsmanager.SecretsManager, error) {
+	secretsManagerClientsMut.Lock()
+	defer secretsManagerClientsMut.Unlock()
+
+	if c, exists := secretsManagerClients[region]; exists {
+		return c, nil
+	}
+
+	conf := aws.NewConfig()
+	if region != "" {
+		conf = conf.WithRegion(region)
+	}
+	sess, err := session.NewSessionWithOptions(session.Options{
+		Config:            *conf,
+		SharedConfigState: session.SharedConfigEnable,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	c := secretsmanager.New(sess)
+	secretsManagerClients[region] = c
+	return c, nil
+}
+
+// The path of a secret is either its name or ARN, and credentials and the
+// region (when the path is not an ARN) are obtained from the environment.
+func getSecretsManagerSecret(ctx context.Context, path string) (string, error) {
+	client, err := secretsManagerClient(kmsKeyRegion(path))
+	if err != nil {
+		return "", err
+	}
+
+	out, err := client.GetSecretValueWithContext(ctx, &secretsmanager.GetSecretValueInput{
+		SecretId: aws.String(path),
+	})
+	if err != nil {
+		return "", err
+	}
+	if out.SecretString != nil {
+		return *out.SecretString, nil
+	}
+	if out.SecretBinary != nil {
+		return string(out.SecretBinary), nil
+	}
+	return "", errors.New("secret value is empty")
+}
+
+func init() {
+	if err := secrets.RegisterProvider("aws_secrets_manager", getSecretsManagerSecret); err != nil {
+		panic(err)
+	}
+}
@@ -2,10 +2,9 @@ package aws
 
 import (
 	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/credentials"
-	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
 	"github.com/aws/aws-sdk-go/aws/session"
 
+	sess "github.com/benthosdev/benthos/v4/internal/impl/aws/session"
 	"github.com/benthosdev/benthos/v4/public/service"
 )
 
@@ -19,6 +18,11 @@ func sessionFields() []*service.ConfigField {
 			Description("Allows you to specify a custom endpoint for the AWS API.").
 			Default("").
 			Advanced(),
+		service.NewStringField("ca_bundle").
+			Description("An optional path to a PEM file of certificate authorities to trust when connecting to AWS, in addition to those of the system.").
+			Version("4.1.0").
+			Default("").
+			Advanced(),
 		service.NewObjectField("credentials",
 			service.NewStringField("profile").
 				Description("A profile from `~/.aws/credentials` to use.").
@@ -32,60 +36,73 @@ func sessionFields() []*service.ConfigField {
 			service.NewStringField("token").
 				Description("The token for the credentials being used, required when using short term credentials.").
 				Default("").Advanced(),
+			service.NewStringField("web_identity_token_file").
+				Description("A path to a web identity token file, such as those provided to pods by IAM roles for service accounts, to assume the `role` with.").
+				Version("4.1.0").
+				Default("").Advanced(),
 			service.NewStringField("role").
 				Description("A role ARN to assume.").
 				Default("").Advanced(),
 			service.NewStringField("role_external_id").
 				Description("An external ID to provide when assuming a role.").
+				Default("").Advanced(),
+			service.NewObjectListField("role_chain",
+				service.NewStringField("role").
+					Description("A role ARN to assume.").
+					Default(""),
+				service.NewStringField("role_external_id").
+					Description("An external ID to provide when assuming the role.").
+					Default(""),
+			).
+				Description("A list of roles to assume in order after `role`, where each role is assumed with the credentials of the previous one.").
+				Version("4.1.0").
+				Default([]interface{}{}).Advanced(),
+			service.NewStringField("sts_endpoint").
+				Description("Allows you to specify a custom endpoint for the AWS STS API, which is used when assuming roles.").
+				Version("4.1.0").
 				Default("").Advanced()).
 			Advanced().
 			Description("Optional manual configuration of AWS credentials to use. More information can be found [in this document](/docs/guides/cloud/aws)."),
 	}
 }
 
-func getSession(parsedConf *service.ParsedConfig, opts ...func(*aws.Config)) (*session.Session, error) {
-	awsConf := aws.NewConfig()
+func sessionConfigFromParsed(parsedConf *service.ParsedConfig) (sess.Config, error) {
+	conf := sess.NewConfig()
+	conf.Region, _ = parsedConf.FieldString("region")
+	conf.Endpoint, _ = parsedConf.FieldString("endpoint")
+	conf.CABundle, _ = parsedConf.FieldString("ca_bundle")
 
-	if region, _ := parsedConf.FieldString("region"); region != "" {
-		awsConf = awsConf.WithRegion(region)
-	}
-	if endpoint, _ := parsedConf.FieldString("endpoint"); endpoint != "" {
-		awsConf = awsConf.WithEndpoint(endpoint)
-	}
-	if profile, _ := parsedConf.FieldString("credentials", "profile"); profile != "" {
-		awsConf = awsConf.WithCredentials(credentials.NewSharedCredentials(
-			"", profile,
-		))
-	} else if id, _ := parsedConf.FieldString("credentials", "id"); id != "" {
-		secret, _ := parsedConf.FieldString("credentials", "secret")
-		token, _ := parsedConf.FieldString("credentials", "token")
-		awsConf = awsConf.WithCredentials(credentials.NewStaticCredentials(
-			id, secret, token,
-		))
-	}
+	creds := &conf.Credentials
+	creds.Profile, _ = parsedConf.FieldString("credentials", "profile")
+	creds.ID, _ = parsedConf.FieldString("credentials", "id")
+	creds.Secret, _ = parsedConf.FieldString("credentials", "secret")
+	creds.Token, _ = parsedConf.FieldString("credentials", "token")
+	creds.WebIdentityTokenFile, _ = parsedConf.FieldString("credentials", "web_identity_token_file")
+	creds.Role, _ = parsedConf.FieldString("credentials", "role")
+	creds.ExternalID, _ = parsedConf.FieldString("credentials", "role_external_id")
+	creds.STSEndpoint, _ = parsedConf.FieldString("credentials", "sts_endpoint")
 
-	for _, opt := range opts {
-		opt(awsConf)
+	if parsedConf.Contains("credentials", "role_chain") {
+		chain, err := parsedConf.FieldObjectList("credentials", "role_chain")
+		if err != nil {
+			return conf, err
+		}
+		for _, roleConf := range chain {
+			var role sess.RoleConfig
+			if role.Role, err = roleConf.FieldString("role"); err != nil {
+				return conf, err
+			}
+			role.ExternalID, _ = roleConf.FieldString("role_external_id")
+			creds.RoleChain = append(creds.RoleChain, role)
+		}
 	}
+	return conf, nil
+}
 
-	sess, err := session.NewSession(awsConf)
+func getSession(parsedConf *service.ParsedConfig, opts ...func(*aws.Config)) (*session.Session, error) {
+	conf, err := sessionConfigFromParsed(parsedConf)
 	if err != nil {
 		return nil, err
 	}
-
-	if role, _ := parsedConf.FieldString("credentials", "role"); role != "" {
-		var opts []func(*stscreds.AssumeRoleProvider)
-		if externalID, _ := parsedConf.FieldString("credentials", "role_external_id"); externalID != "" {
-			opts = []func(*stscreds.AssumeRoleProvider){
-				func(p *stscreds.AssumeRoleProvider) {
-					p.ExternalID = &externalID
-				},
-			}
-		}
-		sess.Config = sess.Config.WithCredentials(
-			stscreds.NewCredentials(sess, role, opts...),
-		)
-	}
-
-	return sess, nil
+	return conf.GetSession(opts...)
 }
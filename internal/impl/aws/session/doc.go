@@ -7,6 +7,7 @@ func FieldSpecs() docs.FieldSpecs {
 	return docs.FieldSpecs{
 		docs.FieldString("region", "The AWS region to target.").Advanced().HasDefault(""),
 		docs.FieldString("endpoint", "Allows you to specify a custom endpoint for the AWS API.").Advanced().HasDefault(""),
+		docs.FieldString("ca_bundle", "An optional path to a PEM file of certificate authorities to trust when connecting to AWS, in addition to those of the system.").AtVersion("4.1.0").Advanced().HasDefault(""),
 		docs.FieldObject("credentials", "Optional manual configuration of AWS credentials to use. More information can be found [in this document](/docs/guides/cloud/aws).").
 			Advanced().
 			WithChildren(
@@ -14,8 +15,14 @@ func FieldSpecs() docs.FieldSpecs {
 				docs.FieldString("id", "The ID of credentials to use.").HasDefault(""),
 				docs.FieldString("secret", "The secret for the credentials being used.").HasDefault(""),
 				docs.FieldString("token", "The token for the credentials being used, required when using short term credentials.").HasDefault(""),
+				docs.FieldString("web_identity_token_file", "A path to a web identity token file, such as those provided to pods by IAM roles for service accounts, to assume the `role` with.").AtVersion("4.1.0").HasDefault(""),
 				docs.FieldString("role", "A role ARN to assume.").HasDefault(""),
 				docs.FieldString("role_external_id", "An external ID to provide when assuming a role.").HasDefault(""),
+				docs.FieldObject("role_chain", "A list of roles to assume in order after `role`, where each role is assumed with the credentials of the previous one.").Array().AtVersion("4.1.0").HasDefault([]interface{}{}).WithChildren(
+					docs.FieldString("role", "A role ARN to assume.").HasDefault(""),
+					docs.FieldString("role_external_id", "An external ID to provide when assuming the role.").HasDefault(""),
+				),
+				docs.FieldString("sts_endpoint", "Allows you to specify a custom endpoint for the AWS STS API, which is used when assuming roles.").AtVersion("4.1.0").HasDefault(""),
 			),
 	}
 }
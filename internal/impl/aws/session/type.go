@@ -1,6 +1,10 @@
 package session
 
 import (
+	"errors"
+	"fmt"
+	"os"
+
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
@@ -9,42 +13,69 @@ import (
 
 //------------------------------------------------------------------------------
 
-// CredentialsConfig contains configuration params for AWS credentials.
-type CredentialsConfig struct {
-	Profile    string `json:"profile" yaml:"profile"`
-	ID         string `json:"id" yaml:"id"`
-	Secret     string `json:"secret" yaml:"secret"`
-	Token      string `json:"token" yaml:"token"`
+// RoleConfig contains configuration params for a role to assume.
+type RoleConfig struct {
 	Role       string `json:"role" yaml:"role"`
 	ExternalID string `json:"role_external_id" yaml:"role_external_id"`
 }
 
+// CredentialsConfig contains configuration params for AWS credentials.
+type CredentialsConfig struct {
+	Profile              string       `json:"profile" yaml:"profile"`
+	ID                   string       `json:"id" yaml:"id"`
+	Secret               string       `json:"secret" yaml:"secret"`
+	Token                string       `json:"token" yaml:"token"`
+	WebIdentityTokenFile string       `json:"web_identity_token_file" yaml:"web_identity_token_file"`
+	Role                 string       `json:"role" yaml:"role"`
+	ExternalID           string       `json:"role_external_id" yaml:"role_external_id"`
+	RoleChain            []RoleConfig `json:"role_chain" yaml:"role_chain"`
+	STSEndpoint          string       `json:"sts_endpoint" yaml:"sts_endpoint"`
+}
+
 // Config contains configuration fields for an AWS session. This config is
 // common across any AWS components.
 type Config struct {
 	Credentials CredentialsConfig `json:"credentials" yaml:"credentials"`
 	Endpoint    string            `json:"endpoint" yaml:"endpoint"`
 	Region      string            `json:"region" yaml:"region"`
+	CABundle    string            `json:"ca_bundle" yaml:"ca_bundle"`
 }
 
 // NewConfig returns a Config with default values.
 func NewConfig() Config {
 	return Config{
 		Credentials: CredentialsConfig{
-			Profile:    "",
-			ID:         "",
-			Secret:     "",
-			Token:      "",
-			Role:       "",
-			ExternalID: "",
+			Profile:              "",
+			ID:                   "",
+			Secret:               "",
+			Token:                "",
+			WebIdentityTokenFile: "",
+			Role:                 "",
+			ExternalID:           "",
+			RoleChain:            []RoleConfig{},
+			STSEndpoint:          "",
 		},
 		Endpoint: "",
 		Region:   "",
+		CABundle: "",
 	}
 }
 
 //------------------------------------------------------------------------------
 
+func assumeRole(sess *session.Session, role RoleConfig) *credentials.Credentials {
+	var opts []func(*stscreds.AssumeRoleProvider)
+	if len(role.ExternalID) > 0 {
+		externalID := role.ExternalID
+		opts = []func(*stscreds.AssumeRoleProvider){
+			func(p *stscreds.AssumeRoleProvider) {
+				p.ExternalID = &externalID
+			},
+		}
+	}
+	return stscreds.NewCredentials(sess, role.Role, opts...)
+}
+
 // GetSession attempts to create an AWS session based on Config.
 func (c Config) GetSession(opts ...func(*aws.Config)) (*session.Session, error) {
 	awsConf := aws.NewConfig()
@@ -72,25 +103,56 @@ func (c Config) GetSession(opts ...func(*aws.Config)) (*session.Session, error)
 		opt(awsConf)
 	}
 
-	sess, err := session.NewSession(awsConf)
+	sessOpts := session.Options{
+		Config: *awsConf,
+	}
+	if len(c.CABundle) > 0 {
+		caFile, err := os.Open(c.CABundle)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open ca_bundle: %w", err)
+		}
+		defer caFile.Close()
+		sessOpts.CustomCABundle = caFile
+	}
+
+	sess, err := session.NewSessionWithOptions(sessOpts)
 	if err != nil {
 		return nil, err
 	}
 
-	if len(c.Credentials.Role) > 0 {
-		var opts []func(*stscreds.AssumeRoleProvider)
-		if len(c.Credentials.ExternalID) > 0 {
-			opts = []func(*stscreds.AssumeRoleProvider){
-				func(p *stscreds.AssumeRoleProvider) {
-					p.ExternalID = &c.Credentials.ExternalID
-				},
-			}
+	// Requests to STS must not be sent to the endpoint of the component.
+	stsSess := sess.Copy(&aws.Config{
+		Endpoint: aws.String(c.Credentials.STSEndpoint),
+	})
+
+	var creds *credentials.Credentials
+	if len(c.Credentials.WebIdentityTokenFile) > 0 {
+		if len(c.Credentials.Role) == 0 {
+			return nil, errors.New("a role must be specified in order to use a web identity token file")
+		}
+		creds = stscreds.NewWebIdentityCredentials(stsSess, c.Credentials.Role, "", c.Credentials.WebIdentityTokenFile)
+	} else if len(c.Credentials.Role) > 0 {
+		creds = assumeRole(stsSess, RoleConfig{
+			Role:       c.Credentials.Role,
+			ExternalID: c.Credentials.ExternalID,
+		})
+	}
+
+	// Each role of the chain is assumed with the credentials of the previous
+	// one.
+	for i, role := range c.Credentials.RoleChain {
+		if len(role.Role) == 0 {
+			return nil, fmt.Errorf("role_chain entry %v is missing a role", i)
 		}
-		sess.Config = sess.Config.WithCredentials(
-			stscreds.NewCredentials(sess, c.Credentials.Role, opts...),
-		)
+		if creds != nil {
+			stsSess = stsSess.Copy(&aws.Config{Credentials: creds})
+		}
+		creds = assumeRole(stsSess, role)
 	}
 
+	if creds != nil {
+		sess.Config = sess.Config.WithCredentials(creds)
+	}
 	return sess, nil
 }
 
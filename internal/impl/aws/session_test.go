@@ -0,0 +1,133 @@
+package aws
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	sess "github.com/benthosdev/benthos/v4/internal/impl/aws/session"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func TestSessionConfigFromParsed(t *testing.T) {
+	spec := service.NewConfigSpec()
+	for _, f := range sessionFields() {
+		spec = spec.Field(f)
+	}
+
+	pConf, err := spec.ParseYAML(`
+region: eu-west-1
+endpoint: http://localhost:4566
+credentials:
+  id: foo
+  secret: bar
+  role: first
+  role_external_id: first_id
+  role_chain:
+    - role: second
+      role_external_id: second_id
+    - role: third
+  sts_endpoint: http://localhost:4567
+`, nil)
+	require.NoError(t, err)
+
+	conf, err := sessionConfigFromParsed(pConf)
+	require.NoError(t, err)
+
+	exp := sess.NewConfig()
+	exp.Region = "eu-west-1"
+	exp.Endpoint = "http://localhost:4566"
+	exp.Credentials.ID = "foo"
+	exp.Credentials.Secret = "bar"
+	exp.Credentials.Role = "first"
+	exp.Credentials.ExternalID = "first_id"
+	exp.Credentials.RoleChain = []sess.RoleConfig{
+		{Role: "second", ExternalID: "second_id"},
+		{Role: "third"},
+	}
+	exp.Credentials.STSEndpoint = "http://localhost:4567"
+	assert.Equal(t, exp, conf)
+}
+
+func TestSessionRoleChain(t *testing.T) {
+	var reqsMut sync.Mutex
+	var reqs []string
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+
+		auth := r.Header.Get("Authorization")
+		keyID := strings.SplitN(strings.SplitN(auth, "Credential=", 2)[1], "/", 2)[0]
+
+		reqsMut.Lock()
+		n := len(reqs)
+		reqs = append(reqs, fmt.Sprintf("%v %v %v %v", keyID, r.Form.Get("Action"), r.Form.Get("RoleArn"), r.Form.Get("ExternalId")))
+		reqsMut.Unlock()
+
+		fmt.Fprintf(w, `<AssumeRoleResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/">
+  <AssumeRoleResult>
+    <Credentials>
+      <AccessKeyId>key%v</AccessKeyId>
+      <SecretAccessKey>secret%v</SecretAccessKey>
+      <SessionToken>token%v</SessionToken>
+      <Expiration>2099-01-01T00:00:00Z</Expiration>
+    </Credentials>
+  </AssumeRoleResult>
+</AssumeRoleResponse>`, n, n, n)
+	}))
+	defer ts.Close()
+
+	conf := sess.NewConfig()
+	conf.Region = "eu-west-1"
+	conf.Endpoint = "http://localhost:1"
+	conf.Credentials.ID = "base"
+	conf.Credentials.Secret = "base_secret"
+	conf.Credentials.Role = "arn:aws:iam::123456789012:role/first"
+	conf.Credentials.ExternalID = "first_id"
+	conf.Credentials.RoleChain = []sess.RoleConfig{
+		{Role: "arn:aws:iam::123456789012:role/second", ExternalID: "second_id"},
+		{Role: "arn:aws:iam::123456789012:role/third"},
+	}
+	conf.Credentials.STSEndpoint = ts.URL
+
+	s, err := conf.GetSession()
+	require.NoError(t, err)
+
+	creds, err := s.Config.Credentials.Get()
+	require.NoError(t, err)
+	assert.Equal(t, "key2", creds.AccessKeyID)
+	assert.Equal(t, "http://localhost:1", *s.Config.Endpoint)
+
+	assert.Equal(t, []string{
+		"base AssumeRole arn:aws:iam::123456789012:role/first first_id",
+		"key0 AssumeRole arn:aws:iam::123456789012:role/second second_id",
+		"key1 AssumeRole arn:aws:iam::123456789012:role/third ",
+	}, reqs)
+}
+
+func TestSessionErrors(t *testing.T) {
+	conf := sess.NewConfig()
+	conf.Credentials.WebIdentityTokenFile = "./token"
+
+	_, err := conf.GetSession()
+	require.EqualError(t, err, "a role must be specified in order to use a web identity token file")
+
+	conf = sess.NewConfig()
+	conf.Credentials.RoleChain = []sess.RoleConfig{{ExternalID: "foo"}}
+
+	_, err = conf.GetSession()
+	require.EqualError(t, err, "role_chain entry 0 is missing a role")
+
+	conf = sess.NewConfig()
+	conf.CABundle = "./does_not_exist.pem"
+
+	_, err = conf.GetSession()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to open ca_bundle")
+}
@@ -0,0 +1,353 @@
+package container
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func dockerLogsInputConfig() *service.ConfigSpec {
+	spec := service.NewConfigSpec().
+		// Stable(). TODO
+		Version("4.1.0").
+		Categories("Local").
+		Summary("Tails the logs of Docker containers via the Docker Engine API.").
+		Description(`
+The running containers that match the ` + "`names`" + ` and ` + "`labels`" + ` filters are listed periodically, and the standard output and error of each container is consumed with a message per line. Containers are tailed for as long as they're running, and new containers that match the filters are tailed as they start.
+` + logsCheckpointingDocs + `
+
+### Metadata
+
+This input adds the following metadata fields to each message:
+
+` + "```text" + `
+- docker_container_id
+- docker_container_name
+- docker_container_image
+- docker_log_stream
+- docker_log_timestamp
+- All container labels, prefixed with docker_label_
+` + "```" + `
+
+The ` + "`docker_log_stream`" + ` field is either ` + "`stdout`" + ` or ` + "`stderr`" + `, and is not set for containers that run with a TTY.
+
+You can access these metadata fields using [function interpolation](/docs/configuration/interpolation#metadata).`).
+		Field(service.NewStringField("host").
+			Description("The address of the Docker Engine API, which can be a unix socket or a TCP address.").
+			Default("unix:///var/run/docker.sock").
+			Example("tcp://localhost:2375").
+			Example("https://docker.example.com:2376")).
+		Field(service.NewTLSToggledField("tls").
+			Description("Custom TLS settings can be used to override system defaults. When enabled TCP addresses are connected to with HTTPS.")).
+		Field(service.NewStringListField("names").
+			Description("An optional list of container name filters, where a container is tailed when its name matches any of them as a regular expression.").
+			Default([]string{}).
+			Example([]string{"nginx", "postgres"})).
+		Field(service.NewStringListField("labels").
+			Description("An optional list of label filters, where containers are only tailed when they match all of them. Each filter is either a label key, or a key and value in the form `key=value`.").
+			Default([]string{}).
+			Example([]string{"com.example.team=payments", "logging"}))
+
+	for _, f := range logsCommonFields("docker_logs_") {
+		spec = spec.Field(f)
+	}
+	return spec
+}
+
+func init() {
+	err := service.RegisterInput(
+		"docker_logs", dockerLogsInputConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.Input, error) {
+			return newDockerLogsInput(conf, mgr)
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+func newDockerLogsInput(conf *service.ParsedConfig, mgr *service.Resources) (service.Input, error) {
+	src, err := newDockerLogSource(conf)
+	if err != nil {
+		return nil, err
+	}
+	i, err := newLogsInputFromParsed(conf, src, "docker_", mgr, mgr.Logger())
+	if err != nil {
+		return nil, err
+	}
+	if i.cacheName != "" && !mgr.HasCache(i.cacheName) {
+		return nil, fmt.Errorf("cache resource '%v' was not found", i.cacheName)
+	}
+	return service.AutoRetryNacks(i), nil
+}
+
+//------------------------------------------------------------------------------
+
+type dockerLogSource struct {
+	baseURL string
+	client  *http.Client
+	filters map[string][]string
+}
+
+func newDockerLogSource(conf *service.ParsedConfig) (*dockerLogSource, error) {
+	host, err := conf.FieldString("host")
+	if err != nil {
+		return nil, err
+	}
+	tlsConf, tlsEnabled, err := conf.FieldTLSToggled("tls")
+	if err != nil {
+		return nil, err
+	}
+	names, err := conf.FieldStringList("names")
+	if err != nil {
+		return nil, err
+	}
+	labels, err := conf.FieldStringList("labels")
+	if err != nil {
+		return nil, err
+	}
+
+	d := &dockerLogSource{
+		filters: map[string][]string{
+			"status": {"running"},
+		},
+	}
+	if len(names) > 0 {
+		d.filters["name"] = names
+	}
+	if len(labels) > 0 {
+		d.filters["label"] = labels
+	}
+	if !tlsEnabled {
+		tlsConf = nil
+	}
+	if d.baseURL, d.client, err = dockerHTTPClient(host, tlsConf); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func dockerHTTPClient(host string, tlsConf *tls.Config) (string, *http.Client, error) {
+	u, err := url.Parse(host)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to parse host: %w", err)
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConf
+
+	var baseURL string
+	switch u.Scheme {
+	case "unix":
+		socketPath := u.Path
+		transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", socketPath)
+		}
+		baseURL = "http://docker"
+	case "tcp", "http", "https":
+		scheme := u.Scheme
+		if scheme == "tcp" {
+			scheme = "http"
+			if tlsConf != nil {
+				scheme = "https"
+			}
+		}
+		baseURL = scheme + "://" + u.Host
+	default:
+		return "", nil, fmt.Errorf("host scheme '%v' is not supported", u.Scheme)
+	}
+	return baseURL, &http.Client{Transport: transport}, nil
+}
+
+func (d *dockerLogSource) get(ctx context.Context, path string, query url.Values) (*http.Response, error) {
+	reqURL := d.baseURL + path
+	if len(query) > 0 {
+		reqURL += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := d.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		defer res.Body.Close()
+		var errBody struct {
+			Message string `json:"message"`
+		}
+		if err := json.NewDecoder(res.Body).Decode(&errBody); err == nil && errBody.Message != "" {
+			return nil, fmt.Errorf("%v: %v", res.Status, errBody.Message)
+		}
+		return nil, errors.New(res.Status)
+	}
+	return res, nil
+}
+
+func (d *dockerLogSource) getJSON(ctx context.Context, path string, query url.Values, v interface{}) error {
+	res, err := d.get(ctx, path, query)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	return json.NewDecoder(res.Body).Decode(v)
+}
+
+func (d *dockerLogSource) list(ctx context.Context) ([]logTarget, error) {
+	filtersBytes, err := json.Marshal(d.filters)
+	if err != nil {
+		return nil, err
+	}
+
+	var containers []struct {
+		ID     string            `json:"Id"`
+		Names  []string          `json:"Names"`
+		Image  string            `json:"Image"`
+		Labels map[string]string `json:"Labels"`
+	}
+	if err := d.getJSON(ctx, "/containers/json", url.Values{
+		"filters": []string{string(filtersBytes)},
+	}, &containers); err != nil {
+		return nil, err
+	}
+
+	targets := make([]logTarget, 0, len(containers))
+	for _, c := range containers {
+		name := c.ID
+		if len(c.Names) > 0 {
+			name = strings.TrimPrefix(c.Names[0], "/")
+		}
+		meta := map[string]string{
+			"docker_container_id":    c.ID,
+			"docker_container_name":  name,
+			"docker_container_image": c.Image,
+		}
+		for k, v := range c.Labels {
+			meta["docker_label_"+k] = v
+		}
+		targets = append(targets, logTarget{
+			id:   c.ID,
+			name: name,
+			meta: meta,
+		})
+	}
+	return targets, nil
+}
+
+func (d *dockerLogSource) follow(ctx context.Context, target logTarget, since time.Time) (logStream, error) {
+	var inspect struct {
+		Config struct {
+			Tty bool `json:"Tty"`
+		} `json:"Config"`
+	}
+	if err := d.getJSON(ctx, "/containers/"+url.PathEscape(target.id)+"/json", nil, &inspect); err != nil {
+		return nil, fmt.Errorf("failed to inspect container: %w", err)
+	}
+
+	query := url.Values{
+		"follow":     []string{"1"},
+		"stdout":     []string{"1"},
+		"stderr":     []string{"1"},
+		"timestamps": []string{"1"},
+	}
+	if !since.IsZero() {
+		query.Set("since", fmt.Sprintf("%d.%09d", since.Unix(), since.Nanosecond()))
+	}
+
+	res, err := d.get(ctx, "/containers/"+url.PathEscape(target.id)+"/logs", query)
+	if err != nil {
+		return nil, err
+	}
+	if inspect.Config.Tty {
+		return &bodyLogStream{
+			lines: newTimestampedLineReader(res.Body, ""),
+			body:  res.Body,
+		}, nil
+	}
+	return &bodyLogStream{
+		lines: newDockerDemuxReader(res.Body),
+		body:  res.Body,
+	}, nil
+}
+
+//------------------------------------------------------------------------------
+
+// dockerDemuxReader reads the multiplexed stream format used by the Docker
+// Engine API for containers that do not run with a TTY, where the output of
+// each stream is written within frames prefixed with an eight byte header.
+type dockerDemuxReader struct {
+	r       io.Reader
+	header  [8]byte
+	pending map[byte][]byte
+	lines   []logLine
+}
+
+func newDockerDemuxReader(r io.Reader) *dockerDemuxReader {
+	return &dockerDemuxReader{
+		r:       r,
+		pending: map[byte][]byte{},
+	}
+}
+
+func (d *dockerDemuxReader) next() (logLine, error) {
+	for len(d.lines) == 0 {
+		if _, err := io.ReadFull(d.r, d.header[:]); err != nil {
+			if errors.Is(err, io.ErrUnexpectedEOF) {
+				err = io.EOF
+			}
+			return logLine{}, err
+		}
+
+		streamType := d.header[0]
+		frame := make([]byte, binary.BigEndian.Uint32(d.header[4:]))
+		if _, err := io.ReadFull(d.r, frame); err != nil {
+			if errors.Is(err, io.ErrUnexpectedEOF) {
+				err = io.EOF
+			}
+			return logLine{}, err
+		}
+
+		var streamName string
+		switch streamType {
+		case 1:
+			streamName = "stdout"
+		case 2:
+			streamName = "stderr"
+		default:
+			continue
+		}
+
+		// Frames are not guaranteed to contain whole lines and so the
+		// trailing partial line of each stream is kept until it's completed.
+		buf := append(d.pending[streamType], frame...)
+		for {
+			i := bytes.IndexByte(buf, '\n')
+			if i < 0 {
+				break
+			}
+			if line, err := parseTimestampedLine(buf[:i+1], streamName); err == nil {
+				d.lines = append(d.lines, line)
+			}
+			buf = buf[i+1:]
+		}
+		d.pending[streamType] = append([]byte(nil), buf...)
+	}
+
+	line := d.lines[0]
+	d.lines = d.lines[1:]
+	return line, nil
+}
@@ -0,0 +1,269 @@
+package container
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+type mapCache struct {
+	mut   sync.Mutex
+	items map[string][]byte
+}
+
+func (m *mapCache) Get(ctx context.Context, key string) ([]byte, error) {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+	v, exists := m.items[key]
+	if !exists {
+		return nil, service.ErrKeyNotFound
+	}
+	return v, nil
+}
+
+func (m *mapCache) Set(ctx context.Context, key string, value []byte, ttl *time.Duration) error {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+	m.items[key] = value
+	return nil
+}
+
+func (m *mapCache) Add(ctx context.Context, key string, value []byte, ttl *time.Duration) error {
+	return m.Set(ctx, key, value, ttl)
+}
+
+func (m *mapCache) Delete(ctx context.Context, key string) error {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+	delete(m.items, key)
+	return nil
+}
+
+func (m *mapCache) Close(ctx context.Context) error {
+	return nil
+}
+
+type mockCacheProv struct {
+	caches map[string]service.Cache
+}
+
+func (m *mockCacheProv) AccessCache(ctx context.Context, name string, fn func(c service.Cache)) error {
+	c, ok := m.caches[name]
+	if !ok {
+		return errors.New("cache not found")
+	}
+	fn(c)
+	return nil
+}
+
+func readLogLines(t *testing.T, in *logsInput, n int) []*service.Message {
+	t.Helper()
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*5)
+	defer done()
+
+	var msgs []*service.Message
+	for len(msgs) < n {
+		msg, ackFn, err := in.Read(ctx)
+		require.NoError(t, err)
+		require.NoError(t, ackFn(ctx, nil))
+		msgs = append(msgs, msg)
+	}
+	return msgs
+}
+
+func dockerFrame(stream byte, line string) []byte {
+	frame := make([]byte, 8, 8+len(line))
+	frame[0] = stream
+	binary.BigEndian.PutUint32(frame[4:], uint32(len(line)))
+	return append(frame, line...)
+}
+
+func TestDockerDemuxReader(t *testing.T) {
+	var body []byte
+	body = append(body, dockerFrame(1, "2022-01-01T00:00:00.000000001Z foo\n")...)
+	body = append(body, dockerFrame(2, "2022-01-01T00:00:00.000000002Z bar ")...)
+	body = append(body, dockerFrame(1, "2022-01-01T00:00:00.000000003Z baz\n2022-01-01T00:00:00.000000004Z buz\n")...)
+	body = append(body, dockerFrame(2, "continued\n")...)
+
+	r := newDockerDemuxReader(strings.NewReader(string(body)))
+
+	var lines []string
+	for {
+		line, err := r.next()
+		if err != nil {
+			break
+		}
+		lines = append(lines, fmt.Sprintf("%v %v %s", line.timestamp.Nanosecond(), line.stream, line.data))
+	}
+	assert.Equal(t, []string{
+		"1 stdout foo",
+		"3 stdout baz",
+		"4 stdout buz",
+		"2 stderr bar continued",
+	}, lines)
+}
+
+type fakeDockerContainer struct {
+	id    string
+	name  string
+	lines []string
+}
+
+func startFakeDocker(t *testing.T, containers ...fakeDockerContainer) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/containers/json" {
+			var filters map[string][]string
+			require.NoError(t, json.Unmarshal([]byte(r.URL.Query().Get("filters")), &filters))
+			assert.Equal(t, []string{"running"}, filters["status"])
+			assert.Equal(t, []string{"app=foo"}, filters["label"])
+
+			var list []interface{}
+			for _, c := range containers {
+				list = append(list, map[string]interface{}{
+					"Id":     c.id,
+					"Names":  []string{"/" + c.name},
+					"Image":  "foo:latest",
+					"Labels": map[string]string{"app": "foo"},
+				})
+			}
+			_ = json.NewEncoder(w).Encode(list)
+			return
+		}
+
+		for _, c := range containers {
+			switch r.URL.Path {
+			case "/containers/" + c.id + "/json":
+				_, _ = w.Write([]byte(`{"Config":{"Tty":false}}`))
+				return
+			case "/containers/" + c.id + "/logs":
+				var since time.Time
+				if s := r.URL.Query().Get("since"); s != "" {
+					secs, err := strconv.ParseFloat(s, 64)
+					require.NoError(t, err)
+					since = time.Unix(int64(secs), 0)
+				}
+				for i, l := range c.lines {
+					ts := time.Date(2022, 1, 1, 0, 0, i, 0, time.UTC)
+					if ts.Before(since) {
+						continue
+					}
+					_, _ = w.Write(dockerFrame(1, ts.Format(time.RFC3339Nano)+" "+l+"\n"))
+				}
+				return
+			}
+		}
+		http.Error(w, `{"message":"not found"}`, http.StatusNotFound)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newTestDockerLogsInput(t *testing.T, srv *httptest.Server, cache service.Cache) *logsInput {
+	t.Helper()
+
+	conf, err := dockerLogsInputConfig().ParseYAML(fmt.Sprintf(`
+host: %v
+labels: [ app=foo ]
+sync_interval: 1h
+start_from_oldest: true
+checkpoint_cache: foocache
+`, srv.URL), nil)
+	require.NoError(t, err)
+
+	src, err := newDockerLogSource(conf)
+	require.NoError(t, err)
+
+	in, err := newLogsInputFromParsed(conf, src, "docker_", &mockCacheProv{
+		caches: map[string]service.Cache{"foocache": cache},
+	}, service.MockResources().Logger())
+	require.NoError(t, err)
+	return in
+}
+
+func TestDockerLogsInput(t *testing.T) {
+	srv := startFakeDocker(t, fakeDockerContainer{
+		id:    "abc",
+		name:  "foo",
+		lines: []string{"first", "second", "third"},
+	})
+	cache := &mapCache{items: map[string][]byte{}}
+
+	in := newTestDockerLogsInput(t, srv, cache)
+	require.NoError(t, in.Connect(context.Background()))
+
+	msgs := readLogLines(t, in, 3)
+	for i, exp := range []string{"first", "second", "third"} {
+		mBytes, err := msgs[i].AsBytes()
+		require.NoError(t, err)
+		assert.Equal(t, exp, string(mBytes))
+	}
+
+	meta := map[string]string{}
+	_ = msgs[0].MetaWalk(func(k, v string) error {
+		meta[k] = v
+		return nil
+	})
+	assert.Equal(t, map[string]string{
+		"docker_container_id":    "abc",
+		"docker_container_name":  "foo",
+		"docker_container_image": "foo:latest",
+		"docker_label_app":       "foo",
+		"docker_log_stream":      "stdout",
+		"docker_log_timestamp":   "2022-01-01T00:00:00Z",
+	}, meta)
+
+	require.NoError(t, in.Close(context.Background()))
+
+	var pos logPosition
+	require.NoError(t, json.Unmarshal(cache.items["docker_logs_abc"], &pos))
+	assert.Equal(t, logPosition{
+		Timestamp: time.Date(2022, 1, 1, 0, 0, 2, 0, time.UTC),
+		Count:     1,
+	}, pos)
+}
+
+func TestDockerLogsInputResume(t *testing.T) {
+	srv := startFakeDocker(t, fakeDockerContainer{
+		id:    "abc",
+		name:  "foo",
+		lines: []string{"first", "second", "third", "fourth"},
+	})
+
+	posBytes, err := json.Marshal(logPosition{
+		Timestamp: time.Date(2022, 1, 1, 0, 0, 1, 0, time.UTC),
+		Count:     1,
+	})
+	require.NoError(t, err)
+	cache := &mapCache{items: map[string][]byte{
+		"docker_logs_abc": posBytes,
+	}}
+
+	in := newTestDockerLogsInput(t, srv, cache)
+	require.NoError(t, in.Connect(context.Background()))
+
+	msgs := readLogLines(t, in, 2)
+	for i, exp := range []string{"third", "fourth"} {
+		mBytes, err := msgs[i].AsBytes()
+		require.NoError(t, err)
+		assert.Equal(t, exp, string(mBytes))
+	}
+
+	require.NoError(t, in.Close(context.Background()))
+}
@@ -0,0 +1,357 @@
+package container
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const (
+	kubeServiceAccountTokenPath = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+	kubeServiceAccountCAPath    = "/var/run/secrets/kubernetes.io/serviceaccount/ca.crt"
+)
+
+func kubernetesLogsInputConfig() *service.ConfigSpec {
+	spec := service.NewConfigSpec().
+		// Stable(). TODO
+		Version("4.1.0").
+		Categories("Services").
+		Summary("Tails the logs of Kubernetes pod containers via the Kubernetes API.").
+		Description(`
+The pods of the configured ` + "`namespaces`" + ` that match the ` + "`label_selector`" + ` are listed periodically, and the logs of each of their running containers are consumed with a message per line. Containers are tailed for as long as they're running, and containers of new pods, as well as restarted containers, are tailed as they start.
+
+When ` + "`api_url`" + ` is empty the input connects to the API server of the cluster it is running within using the token and certificate authority of the pod service account, which requires permission to ` + "`list`" + ` pods and ` + "`get`" + ` the ` + "`pods/log`" + ` subresource.
+` + logsCheckpointingDocs + `
+
+### Metadata
+
+This input adds the following metadata fields to each message:
+
+` + "```text" + `
+- kubernetes_namespace
+- kubernetes_pod_name
+- kubernetes_pod_uid
+- kubernetes_node_name
+- kubernetes_container_name
+- kubernetes_container_id
+- kubernetes_container_image
+- kubernetes_log_timestamp
+- All pod labels, prefixed with kubernetes_label_
+` + "```" + `
+
+You can access these metadata fields using [function interpolation](/docs/configuration/interpolation#metadata).`).
+		Field(service.NewStringField("api_url").
+			Description("The URL of the Kubernetes API server. When empty the in-cluster API server is used.").
+			Default("").
+			Example("https://kubernetes.default.svc")).
+		Field(service.NewStringField("token").
+			Description("A bearer token used to authenticate with the API server.").
+			Default("")).
+		Field(service.NewStringField("token_file").
+			Description("A file containing a bearer token used to authenticate with the API server, which is read again for each request in order to support rotated tokens. When both `token` and `token_file` are empty and `api_url` is also empty the service account token of the pod is used.").
+			Default("").
+			Advanced()).
+		Field(service.NewTLSField("tls").
+			Description("Custom TLS settings can be used to override system defaults. When `api_url` is empty and no root CAs are configured the certificate authority of the pod service account is trusted.")).
+		Field(service.NewStringListField("namespaces").
+			Description("A list of namespaces to tail the pods of. When empty the pods of all namespaces are tailed.").
+			Default([]string{}).
+			Example([]string{"default", "payments"})).
+		Field(service.NewStringField("label_selector").
+			Description("An optional [label selector](https://kubernetes.io/docs/concepts/overview/working-with-objects/labels/#label-selectors) used to filter pods.").
+			Default("").
+			Example("app=nginx").
+			Example("tier in (frontend,backend),!canary")).
+		Field(service.NewStringListField("containers").
+			Description("An optional list of container names to tail within each pod. When empty all containers of a pod are tailed.").
+			Default([]string{}).
+			Example([]string{"app"}))
+
+	for _, f := range logsCommonFields("kubernetes_logs_") {
+		spec = spec.Field(f)
+	}
+	return spec
+}
+
+func init() {
+	err := service.RegisterInput(
+		"kubernetes_logs", kubernetesLogsInputConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.Input, error) {
+			return newKubernetesLogsInput(conf, mgr)
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+func newKubernetesLogsInput(conf *service.ParsedConfig, mgr *service.Resources) (service.Input, error) {
+	src, err := newKubeLogSource(conf)
+	if err != nil {
+		return nil, err
+	}
+	i, err := newLogsInputFromParsed(conf, src, "kubernetes_", mgr, mgr.Logger())
+	if err != nil {
+		return nil, err
+	}
+	if i.cacheName != "" && !mgr.HasCache(i.cacheName) {
+		return nil, fmt.Errorf("cache resource '%v' was not found", i.cacheName)
+	}
+	return service.AutoRetryNacks(i), nil
+}
+
+//------------------------------------------------------------------------------
+
+type kubeLogSource struct {
+	apiURL        string
+	token         string
+	tokenFile     string
+	client        *http.Client
+	namespaces    []string
+	labelSelector string
+	containers    map[string]struct{}
+}
+
+func newKubeLogSource(conf *service.ParsedConfig) (*kubeLogSource, error) {
+	k := &kubeLogSource{}
+
+	var err error
+	if k.apiURL, err = conf.FieldString("api_url"); err != nil {
+		return nil, err
+	}
+	if k.token, err = conf.FieldString("token"); err != nil {
+		return nil, err
+	}
+	if k.tokenFile, err = conf.FieldString("token_file"); err != nil {
+		return nil, err
+	}
+	tlsConf, err := conf.FieldTLS("tls")
+	if err != nil {
+		return nil, err
+	}
+	if k.namespaces, err = conf.FieldStringList("namespaces"); err != nil {
+		return nil, err
+	}
+	if k.labelSelector, err = conf.FieldString("label_selector"); err != nil {
+		return nil, err
+	}
+
+	containers, err := conf.FieldStringList("containers")
+	if err != nil {
+		return nil, err
+	}
+	if len(containers) > 0 {
+		k.containers = map[string]struct{}{}
+		for _, c := range containers {
+			k.containers[c] = struct{}{}
+		}
+	}
+
+	if k.apiURL == "" {
+		host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+		if host == "" || port == "" {
+			return nil, errors.New("api_url must be set when not running within a Kubernetes cluster")
+		}
+		k.apiURL = "https://" + net.JoinHostPort(host, port)
+		if k.token == "" && k.tokenFile == "" {
+			k.tokenFile = kubeServiceAccountTokenPath
+		}
+		if tlsConf.RootCAs == nil {
+			if tlsConf.RootCAs, err = kubeServiceAccountCAs(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	k.apiURL = strings.TrimSuffix(k.apiURL, "/")
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConf
+	k.client = &http.Client{Transport: transport}
+	return k, nil
+}
+
+func kubeServiceAccountCAs() (*x509.CertPool, error) {
+	caBytes, err := os.ReadFile(kubeServiceAccountCAPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read service account CA: %w", err)
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(caBytes) {
+		return nil, errors.New("failed to parse service account CA")
+	}
+	return pool, nil
+}
+
+func (k *kubeLogSource) bearerToken() (string, error) {
+	if k.tokenFile == "" {
+		return k.token, nil
+	}
+	tokenBytes, err := os.ReadFile(k.tokenFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read token file: %w", err)
+	}
+	return strings.TrimSpace(string(tokenBytes)), nil
+}
+
+func (k *kubeLogSource) get(ctx context.Context, path string, query url.Values) (*http.Response, error) {
+	reqURL := k.apiURL + path
+	if len(query) > 0 {
+		reqURL += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := k.bearerToken()
+	if err != nil {
+		return nil, err
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	res, err := k.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		defer res.Body.Close()
+		var status struct {
+			Message string `json:"message"`
+		}
+		if err := json.NewDecoder(res.Body).Decode(&status); err == nil && status.Message != "" {
+			return nil, fmt.Errorf("%v: %v", res.Status, status.Message)
+		}
+		return nil, errors.New(res.Status)
+	}
+	return res, nil
+}
+
+type kubePod struct {
+	Metadata struct {
+		Name      string            `json:"name"`
+		Namespace string            `json:"namespace"`
+		UID       string            `json:"uid"`
+		Labels    map[string]string `json:"labels"`
+	} `json:"metadata"`
+	Spec struct {
+		NodeName string `json:"nodeName"`
+	} `json:"spec"`
+	Status struct {
+		ContainerStatuses []struct {
+			Name        string `json:"name"`
+			ContainerID string `json:"containerID"`
+			Image       string `json:"image"`
+			State       struct {
+				Running *struct{} `json:"running"`
+			} `json:"state"`
+		} `json:"containerStatuses"`
+	} `json:"status"`
+}
+
+func (k *kubeLogSource) listPods(ctx context.Context, path string) ([]kubePod, error) {
+	query := url.Values{}
+	if k.labelSelector != "" {
+		query.Set("labelSelector", k.labelSelector)
+	}
+
+	res, err := k.get(ctx, path, query)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	var podList struct {
+		Items []kubePod `json:"items"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&podList); err != nil {
+		return nil, fmt.Errorf("failed to parse pod list: %w", err)
+	}
+	return podList.Items, nil
+}
+
+func (k *kubeLogSource) list(ctx context.Context) ([]logTarget, error) {
+	var pods []kubePod
+	if len(k.namespaces) == 0 {
+		var err error
+		if pods, err = k.listPods(ctx, "/api/v1/pods"); err != nil {
+			return nil, err
+		}
+	}
+	for _, ns := range k.namespaces {
+		nsPods, err := k.listPods(ctx, "/api/v1/namespaces/"+url.PathEscape(ns)+"/pods")
+		if err != nil {
+			return nil, err
+		}
+		pods = append(pods, nsPods...)
+	}
+
+	var targets []logTarget
+	for _, pod := range pods {
+		for _, c := range pod.Status.ContainerStatuses {
+			if c.State.Running == nil || c.ContainerID == "" {
+				continue
+			}
+			if k.containers != nil {
+				if _, exists := k.containers[c.Name]; !exists {
+					continue
+				}
+			}
+
+			meta := map[string]string{
+				"kubernetes_namespace":       pod.Metadata.Namespace,
+				"kubernetes_pod_name":        pod.Metadata.Name,
+				"kubernetes_pod_uid":         pod.Metadata.UID,
+				"kubernetes_node_name":       pod.Spec.NodeName,
+				"kubernetes_container_name":  c.Name,
+				"kubernetes_container_id":    c.ContainerID,
+				"kubernetes_container_image": c.Image,
+			}
+			for key, v := range pod.Metadata.Labels {
+				meta["kubernetes_label_"+key] = v
+			}
+			targets = append(targets, logTarget{
+				id:   c.ContainerID,
+				name: pod.Metadata.Namespace + "/" + pod.Metadata.Name + "/" + c.Name,
+				meta: meta,
+			})
+		}
+	}
+	return targets, nil
+}
+
+func (k *kubeLogSource) follow(ctx context.Context, target logTarget, since time.Time) (logStream, error) {
+	query := url.Values{
+		"container":  []string{target.meta["kubernetes_container_name"]},
+		"follow":     []string{"true"},
+		"timestamps": []string{"true"},
+	}
+	if !since.IsZero() {
+		// The API only supports a precision of seconds.
+		query.Set("sinceTime", since.UTC().Truncate(time.Second).Format(time.RFC3339))
+	}
+
+	path := "/api/v1/namespaces/" + url.PathEscape(target.meta["kubernetes_namespace"]) +
+		"/pods/" + url.PathEscape(target.meta["kubernetes_pod_name"]) + "/log"
+	res, err := k.get(ctx, path, query)
+	if err != nil {
+		return nil, err
+	}
+	return &bodyLogStream{
+		lines: newTimestampedLineReader(res.Body, ""),
+		body:  res.Body,
+	}, nil
+}
@@ -0,0 +1,139 @@
+package container
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func TestKubernetesLogsInput(t *testing.T) {
+	lineTS := func(i int) time.Time {
+		return time.Date(2022, 1, 1, 0, 0, 0, i, time.UTC)
+	}
+
+	// The first log request ends after two lines in order to emulate a log
+	// rotation, the lines of subsequent requests start at the second of the
+	// requested time as they would with the real API.
+	var reqMut sync.Mutex
+	var logReqs []string
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer footoken" {
+			http.Error(w, `{"message":"nope"}`, http.StatusUnauthorized)
+			return
+		}
+
+		switch r.URL.Path {
+		case "/api/v1/namespaces/default/pods":
+			assert.Equal(t, "app=foo", r.URL.Query().Get("labelSelector"))
+			_, _ = w.Write([]byte(`{"items":[{
+  "metadata":{"name":"foo-1","namespace":"default","uid":"u1","labels":{"app":"foo"}},
+  "spec":{"nodeName":"node-a"},
+  "status":{"containerStatuses":[
+    {"name":"app","containerID":"containerd://c1","image":"foo:1","state":{"running":{}}},
+    {"name":"sidecar","containerID":"containerd://c2","image":"bar:1","state":{"running":{}}},
+    {"name":"app-old","containerID":"containerd://c0","image":"foo:0","state":{"terminated":{}}}
+  ]}
+}]}`))
+		case "/api/v1/namespaces/default/pods/foo-1/log":
+			query := r.URL.Query()
+			assert.Equal(t, "app", query.Get("container"))
+			assert.Equal(t, "true", query.Get("timestamps"))
+
+			reqMut.Lock()
+			logReqs = append(logReqs, query.Get("sinceTime"))
+			n := len(logReqs)
+			reqMut.Unlock()
+
+			end := 2
+			if n > 1 {
+				end = 4
+			}
+			for i := 0; i < end; i++ {
+				fmt.Fprintf(w, "%v line %v\n", lineTS(i).Format(time.RFC3339Nano), i)
+			}
+		default:
+			http.Error(w, `{"message":"not found"}`, http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	conf, err := kubernetesLogsInputConfig().ParseYAML(fmt.Sprintf(`
+api_url: %v
+token: footoken
+namespaces: [ default ]
+label_selector: app=foo
+containers: [ app ]
+sync_interval: 1h
+start_from_oldest: true
+checkpoint_cache: foocache
+`, srv.URL), nil)
+	require.NoError(t, err)
+
+	src, err := newKubeLogSource(conf)
+	require.NoError(t, err)
+
+	cache := &mapCache{items: map[string][]byte{}}
+	in, err := newLogsInputFromParsed(conf, src, "kubernetes_", &mockCacheProv{
+		caches: map[string]service.Cache{"foocache": cache},
+	}, service.MockResources().Logger())
+	require.NoError(t, err)
+
+	require.NoError(t, in.Connect(context.Background()))
+
+	msgs := readLogLines(t, in, 4)
+	for i, msg := range msgs {
+		mBytes, err := msg.AsBytes()
+		require.NoError(t, err)
+		assert.Equal(t, fmt.Sprintf("line %v", i), string(mBytes))
+	}
+
+	meta := map[string]string{}
+	_ = msgs[3].MetaWalk(func(k, v string) error {
+		meta[k] = v
+		return nil
+	})
+	assert.Equal(t, map[string]string{
+		"kubernetes_namespace":       "default",
+		"kubernetes_pod_name":        "foo-1",
+		"kubernetes_pod_uid":         "u1",
+		"kubernetes_node_name":       "node-a",
+		"kubernetes_container_name":  "app",
+		"kubernetes_container_id":    "containerd://c1",
+		"kubernetes_container_image": "foo:1",
+		"kubernetes_label_app":       "foo",
+		"kubernetes_log_timestamp":   lineTS(3).Format(time.RFC3339Nano),
+	}, meta)
+
+	require.NoError(t, in.Close(context.Background()))
+
+	reqMut.Lock()
+	assert.Equal(t, "", logReqs[0])
+	assert.Equal(t, "2022-01-01T00:00:00Z", logReqs[1])
+	reqMut.Unlock()
+
+	assert.Contains(t, string(cache.items["kubernetes_logs_containerd://c1"]), `"count":1`)
+}
+
+func TestKubernetesLogsInputConfigErrors(t *testing.T) {
+	if os.Getenv("KUBERNETES_SERVICE_HOST") != "" {
+		t.Skip("running within a Kubernetes cluster")
+	}
+
+	conf, err := kubernetesLogsInputConfig().ParseYAML(`namespaces: [ default ]`, nil)
+	require.NoError(t, err)
+
+	_, err = newKubeLogSource(conf)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "api_url must be set")
+}
@@ -0,0 +1,518 @@
+package container
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/internal/shutdown"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+// logLine is a single line of a container log along with the timestamp
+// assigned to it by the container runtime.
+type logLine struct {
+	timestamp time.Time
+	stream    string
+	data      []byte
+}
+
+// logStream provides the lines of a followed container log.
+type logStream interface {
+	next() (logLine, error)
+	Close() error
+}
+
+// logTarget describes a container that can be tailed, where id uniquely
+// identifies an instance of the container.
+type logTarget struct {
+	id   string
+	name string
+	meta map[string]string
+}
+
+// logSource is implemented by container runtime APIs.
+type logSource interface {
+	// list the containers that should currently be tailed.
+	list(ctx context.Context) ([]logTarget, error)
+
+	// follow the logs of a container, starting from the given time when it is
+	// non-zero. The precision of the starting time may be reduced by the
+	// source, and therefore lines prior to it may also be provided.
+	follow(ctx context.Context, target logTarget, since time.Time) (logStream, error)
+}
+
+// cacheProvider is the subset of service.Resources used for checkpoints.
+type cacheProvider interface {
+	AccessCache(ctx context.Context, name string, fn func(c service.Cache)) error
+}
+
+//------------------------------------------------------------------------------
+
+func logsCommonFields(defaultKeyPrefix string) []*service.ConfigField {
+	return []*service.ConfigField{
+		service.NewDurationField("sync_interval").
+			Description("The period between listing containers in order to find new containers to tail.").
+			Default("10s"),
+		service.NewBoolField("start_from_oldest").
+			Description("Whether to consume the existing logs of containers that are found when the input first connects and have no checkpoint. When `false` only lines logged after the input connected are consumed. Containers that start after the input has connected are always consumed from their first line.").
+			Default(false),
+		service.NewStringField("checkpoint_cache").
+			Description("An optional [cache resource](/docs/components/caches/about) used to store the position of the latest acknowledged line of each container, allowing the input to resume from it after a restart.").
+			Default(""),
+		service.NewStringField("checkpoint_key_prefix").
+			Description("A prefix added to the ID of each container in order to obtain the key its position is stored under within the `checkpoint_cache`.").
+			Default(defaultKeyPrefix).
+			Advanced(),
+		service.NewIntField("checkpoint_limit").
+			Description("The maximum number of lines of a given container that can be processed in parallel before applying back pressure. Positions are only committed once all prior lines of the container have been acknowledged.").
+			Default(1024).
+			Advanced(),
+	}
+}
+
+const logsCheckpointingDocs = `
+### Checkpointing
+
+Each line is read along with the timestamp assigned to it by the container runtime, which is used to resume a log after its stream is interrupted, such as when a log file is rotated, without consuming the same line twice.
+
+When ` + "`checkpoint_cache`" + ` is set the position of the latest acknowledged line of each container is stored in the cache resource under a key made of the ` + "`checkpoint_key_prefix`" + ` followed by the ID of the container. When a container is found and a position is present in the cache its log is resumed after it.
+
+Positions are only committed once all lines preceding them have also been acknowledged, and therefore lines may be delivered more than once after a restart.`
+
+//------------------------------------------------------------------------------
+
+type logPosition struct {
+	Timestamp time.Time `json:"timestamp"`
+	Count     int       `json:"count"`
+}
+
+type logTail struct {
+	target       logTarget
+	checkpointer *service.Checkpointer
+	commitMut    sync.Mutex
+
+	// Only accessed by the goroutine that is tailing the container.
+	position logPosition
+
+	// Protected by the tailsMut lock of the input.
+	active bool
+}
+
+type logMessage struct {
+	msg   *service.Message
+	ackFn service.AckFunc
+}
+
+type logsInput struct {
+	source          logSource
+	syncInterval    time.Duration
+	startFromOldest bool
+	checkpointLimit int
+	cacheName       string
+	cacheKeyPrefix  string
+	metaPrefix      string
+
+	mgr cacheProvider
+	log *service.Logger
+
+	msgChan chan logMessage
+	resync  chan struct{}
+	shutSig *shutdown.Signaller
+
+	tailsMut  sync.Mutex
+	tails     map[string]*logTail
+	startedAt time.Time
+	running   bool
+}
+
+func newLogsInputFromParsed(conf *service.ParsedConfig, source logSource, metaPrefix string, mgr cacheProvider, log *service.Logger) (*logsInput, error) {
+	i := &logsInput{
+		source:     source,
+		metaPrefix: metaPrefix,
+		mgr:        mgr,
+		log:        log,
+		msgChan:    make(chan logMessage),
+		resync:     make(chan struct{}, 1),
+		shutSig:    shutdown.NewSignaller(),
+		tails:      map[string]*logTail{},
+	}
+
+	var err error
+	if i.syncInterval, err = conf.FieldDuration("sync_interval"); err != nil {
+		return nil, err
+	}
+	if i.startFromOldest, err = conf.FieldBool("start_from_oldest"); err != nil {
+		return nil, err
+	}
+	if i.cacheName, err = conf.FieldString("checkpoint_cache"); err != nil {
+		return nil, err
+	}
+	if i.cacheKeyPrefix, err = conf.FieldString("checkpoint_key_prefix"); err != nil {
+		return nil, err
+	}
+	if i.checkpointLimit, err = conf.FieldInt("checkpoint_limit"); err != nil {
+		return nil, err
+	}
+	if i.checkpointLimit < 1 {
+		return nil, fmt.Errorf("checkpoint_limit must be greater than zero, got %v", i.checkpointLimit)
+	}
+	return i, nil
+}
+
+func (i *logsInput) loadPosition(ctx context.Context, id string) (*logPosition, error) {
+	if i.cacheName == "" {
+		return nil, nil
+	}
+
+	var posBytes []byte
+	var cerr error
+	if err := i.mgr.AccessCache(ctx, i.cacheName, func(c service.Cache) {
+		posBytes, cerr = c.Get(ctx, i.cacheKeyPrefix+id)
+	}); err != nil {
+		return nil, err
+	}
+	if errors.Is(cerr, service.ErrKeyNotFound) {
+		return nil, nil
+	}
+	if cerr != nil {
+		return nil, fmt.Errorf("failed to obtain checkpoint: %w", cerr)
+	}
+
+	var pos logPosition
+	if err := json.Unmarshal(posBytes, &pos); err != nil {
+		return nil, fmt.Errorf("failed to parse checkpoint: %w", err)
+	}
+	return &pos, nil
+}
+
+func (i *logsInput) storePosition(ctx context.Context, id string, pos logPosition) error {
+	if i.cacheName == "" {
+		return nil
+	}
+
+	posBytes, err := json.Marshal(pos)
+	if err != nil {
+		return err
+	}
+
+	var cerr error
+	if err := i.mgr.AccessCache(ctx, i.cacheName, func(c service.Cache) {
+		cerr = c.Set(ctx, i.cacheKeyPrefix+id, posBytes, nil)
+	}); err != nil {
+		return err
+	}
+	if cerr != nil {
+		return fmt.Errorf("failed to store checkpoint: %w", cerr)
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+func (i *logsInput) Connect(ctx context.Context) error {
+	i.tailsMut.Lock()
+	defer i.tailsMut.Unlock()
+	if i.running {
+		return nil
+	}
+
+	targets, err := i.source.list(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list containers: %w", err)
+	}
+
+	i.running = true
+	i.startedAt = time.Now()
+	go i.loop(targets)
+	return nil
+}
+
+func (i *logsInput) loop(targets []logTarget) {
+	ctx, done := i.shutSig.CloseNowCtx(context.Background())
+	defer done()
+
+	var wg sync.WaitGroup
+	defer func() {
+		wg.Wait()
+		i.shutSig.ShutdownComplete()
+	}()
+
+	i.sync(ctx, &wg, targets, true)
+
+	ticker := time.NewTicker(i.syncInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-i.resync:
+		case <-ctx.Done():
+			return
+		}
+
+		targets, err := i.source.list(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				i.log.Errorf("Failed to list containers: %v", err)
+			}
+			continue
+		}
+		i.sync(ctx, &wg, targets, false)
+	}
+}
+
+// sync starts tailing any listed containers that are not already being tailed
+// and forgets containers that are no longer listed.
+func (i *logsInput) sync(ctx context.Context, wg *sync.WaitGroup, targets []logTarget, initial bool) {
+	listed := make(map[string]struct{}, len(targets))
+	for _, target := range targets {
+		listed[target.id] = struct{}{}
+
+		i.tailsMut.Lock()
+		t, exists := i.tails[target.id]
+		if exists && t.active {
+			i.tailsMut.Unlock()
+			continue
+		}
+		i.tailsMut.Unlock()
+
+		if !exists {
+			t = &logTail{
+				target:       target,
+				checkpointer: service.NewCheckpointer(int64(i.checkpointLimit)),
+			}
+
+			pos, err := i.loadPosition(ctx, target.id)
+			if err != nil {
+				i.log.Errorf("Failed to load checkpoint of container %v: %v", target.name, err)
+				continue
+			}
+			if pos != nil {
+				t.position = *pos
+			} else if initial && !i.startFromOldest {
+				t.position = logPosition{Timestamp: i.startedAt}
+			}
+		}
+
+		i.tailsMut.Lock()
+		i.tails[target.id] = t
+		t.active = true
+		i.tailsMut.Unlock()
+
+		wg.Add(1)
+		go func(t *logTail) {
+			defer wg.Done()
+			i.tail(ctx, t)
+		}(t)
+	}
+
+	i.tailsMut.Lock()
+	for id, t := range i.tails {
+		if _, exists := listed[id]; !exists && !t.active {
+			delete(i.tails, id)
+		}
+	}
+	i.tailsMut.Unlock()
+}
+
+// tail follows the log of a container until its stream ends. When lines were
+// read before the stream ended, which happens when logs are rotated, the
+// containers are listed again immediately in order to resume it.
+func (i *logsInput) tail(ctx context.Context, t *logTail) {
+	read := false
+	defer func() {
+		i.tailsMut.Lock()
+		t.active = false
+		i.tailsMut.Unlock()
+
+		if read {
+			select {
+			case i.resync <- struct{}{}:
+			default:
+			}
+		}
+	}()
+
+	stream, err := i.source.follow(ctx, t.target, t.position.Timestamp)
+	if err != nil {
+		if ctx.Err() == nil {
+			i.log.Errorf("Failed to follow logs of container %v: %v", t.target.name, err)
+		}
+		return
+	}
+	defer stream.Close()
+
+	i.log.Debugf("Tailing logs of container %v", t.target.name)
+
+	resume := t.position
+	seenAtResume := 0
+	for {
+		line, err := stream.next()
+		if err != nil {
+			if ctx.Err() == nil && !errors.Is(err, io.EOF) {
+				i.log.Errorf("Lost log stream of container %v: %v", t.target.name, err)
+			}
+			return
+		}
+
+		// Lines already consumed prior to the stream being opened are
+		// skipped, including those that share the timestamp of the position.
+		if line.timestamp.Before(resume.Timestamp) {
+			continue
+		}
+		if line.timestamp.Equal(resume.Timestamp) {
+			if seenAtResume++; seenAtResume <= resume.Count {
+				continue
+			}
+		}
+
+		if line.timestamp.Equal(t.position.Timestamp) {
+			t.position.Count++
+		} else {
+			t.position = logPosition{Timestamp: line.timestamp, Count: 1}
+		}
+		read = true
+
+		release, err := t.checkpointer.Track(ctx, t.position, 1)
+		if err != nil {
+			return
+		}
+
+		msg := service.NewMessage(line.data)
+		for k, v := range t.target.meta {
+			msg.MetaSet(k, v)
+		}
+		i.setLineMeta(msg, line)
+
+		select {
+		case i.msgChan <- logMessage{msg: msg, ackFn: i.ackFn(t, release)}:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (i *logsInput) setLineMeta(msg *service.Message, line logLine) {
+	msg.MetaSet(i.metaPrefix+"log_timestamp", line.timestamp.Format(time.RFC3339Nano))
+	if line.stream != "" {
+		msg.MetaSet(i.metaPrefix+"log_stream", line.stream)
+	}
+}
+
+func (i *logsInput) ackFn(t *logTail, release func() interface{}) service.AckFunc {
+	return func(ctx context.Context, err error) error {
+		// The lock is held while storing the position so that an older
+		// position never overwrites a more recent one.
+		t.commitMut.Lock()
+		defer t.commitMut.Unlock()
+
+		highest := release()
+		if highest == nil {
+			return nil
+		}
+		return i.storePosition(ctx, t.target.id, highest.(logPosition))
+	}
+}
+
+func (i *logsInput) Read(ctx context.Context) (*service.Message, service.AckFunc, error) {
+	select {
+	case m := <-i.msgChan:
+		return m.msg, m.ackFn, nil
+	case <-i.shutSig.CloseNowChan():
+		return nil, nil, service.ErrEndOfInput
+	case <-ctx.Done():
+		return nil, nil, ctx.Err()
+	}
+}
+
+func (i *logsInput) Close(ctx context.Context) error {
+	i.shutSig.CloseNow()
+
+	i.tailsMut.Lock()
+	running := i.running
+	i.tailsMut.Unlock()
+	if !running {
+		return nil
+	}
+
+	select {
+	case <-i.shutSig.HasClosedChan():
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+// bodyLogStream reads the lines of a log from an HTTP response body.
+type bodyLogStream struct {
+	lines interface {
+		next() (logLine, error)
+	}
+	body io.Closer
+}
+
+func (b *bodyLogStream) next() (logLine, error) {
+	return b.lines.next()
+}
+
+func (b *bodyLogStream) Close() error {
+	return b.body.Close()
+}
+
+// timestampedLineReader reads lines that are prefixed with an RFC3339 timestamp
+// followed by a space, which is how both Docker and Kubernetes provide logs
+// when timestamps are requested.
+type timestampedLineReader struct {
+	r      *bufio.Reader
+	stream string
+}
+
+func newTimestampedLineReader(r io.Reader, stream string) *timestampedLineReader {
+	return &timestampedLineReader{r: bufio.NewReader(r), stream: stream}
+}
+
+func (t *timestampedLineReader) next() (logLine, error) {
+	for {
+		// A partial line is discarded when the stream ends, it will be read
+		// again in full once the stream is resumed.
+		lineBytes, err := t.r.ReadBytes('\n')
+		if err != nil {
+			return logLine{}, err
+		}
+
+		line, perr := parseTimestampedLine(lineBytes, t.stream)
+		if perr != nil {
+			// Lines without a valid timestamp cannot be checkpointed and
+			// are therefore skipped.
+			continue
+		}
+		return line, nil
+	}
+}
+
+func parseTimestampedLine(b []byte, stream string) (logLine, error) {
+	b = bytes.TrimSuffix(b, []byte("\n"))
+	b = bytes.TrimSuffix(b, []byte("\r"))
+
+	tsBytes, data := b, []byte(nil)
+	if i := bytes.IndexByte(b, ' '); i >= 0 {
+		tsBytes, data = b[:i], b[i+1:]
+	}
+	ts, err := time.Parse(time.RFC3339Nano, string(tsBytes))
+	if err != nil {
+		return logLine{}, fmt.Errorf("failed to parse line timestamp: %w", err)
+	}
+	return logLine{
+		timestamp: ts,
+		stream:    stream,
+		data:      append([]byte(nil), data...),
+	}, nil
+}
@@ -0,0 +1,183 @@
+package email
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/emersion/go-sasl"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const (
+	authMechanismLogin       = "login"
+	authMechanismPlain       = "plain"
+	authMechanismXOAuth2     = "xoauth2"
+	authMechanismOAuthBearer = "oauthbearer"
+)
+
+func authFieldSpec() *service.ConfigField {
+	return service.NewObjectField("auth",
+		service.NewStringAnnotatedEnumField("mechanism", map[string]string{
+			authMechanismLogin:       "Authenticate with a username and password using LOGIN, which sends credentials in plain text.",
+			authMechanismPlain:       "Authenticate with a username and password using the SASL PLAIN mechanism.",
+			authMechanismXOAuth2:     "Authenticate with a username and an OAuth2 access token using the XOAUTH2 mechanism, as supported by Gmail and Microsoft 365.",
+			authMechanismOAuthBearer: "Authenticate with a username and an OAuth2 access token using the SASL OAUTHBEARER mechanism.",
+		}).
+			Description("The authentication mechanism to use.").
+			Default(authMechanismLogin),
+		service.NewStringField("username").
+			Description("The username to authenticate as.").
+			Default(""),
+		service.NewStringField("password").
+			Description("The password to authenticate with, used by the `login` and `plain` mechanisms.").
+			Default(""),
+		service.NewStringField("access_token").
+			Description("A static OAuth2 access token, used by the `xoauth2` and `oauthbearer` mechanisms. When empty a token is obtained from the `oauth2` fields instead.").
+			Default(""),
+		service.NewObjectField("oauth2",
+			service.NewStringField("token_url").
+				Description("The URL of the token endpoint used to obtain access tokens.").
+				Default(""),
+			service.NewStringField("client_id").
+				Description("The client ID of the OAuth2 application.").
+				Default(""),
+			service.NewStringField("client_secret").
+				Description("The client secret of the OAuth2 application.").
+				Default(""),
+			service.NewStringField("refresh_token").
+				Description("A refresh token used to obtain access tokens. When empty the client credentials flow is used instead.").
+				Default(""),
+			service.NewStringListField("scopes").
+				Description("A list of scopes to request.").
+				Default([]string{}),
+		).
+			Description("Allows access tokens to be obtained (and refreshed) from an OAuth2 token endpoint.").
+			Advanced(),
+	).Description("Authentication options.")
+}
+
+type authConfig struct {
+	mechanism   string
+	username    string
+	password    string
+	tokenSource oauth2.TokenSource
+}
+
+func authConfigFromParsed(conf *service.ParsedConfig) (a authConfig, err error) {
+	if a.mechanism, err = conf.FieldString("mechanism"); err != nil {
+		return
+	}
+	if a.username, err = conf.FieldString("username"); err != nil {
+		return
+	}
+	if a.password, err = conf.FieldString("password"); err != nil {
+		return
+	}
+	if a.mechanism != authMechanismXOAuth2 && a.mechanism != authMechanismOAuthBearer {
+		return
+	}
+
+	var accessToken string
+	if accessToken, err = conf.FieldString("access_token"); err != nil {
+		return
+	}
+	if accessToken != "" {
+		a.tokenSource = oauth2.StaticTokenSource(&oauth2.Token{AccessToken: accessToken})
+		return
+	}
+
+	oConf := conf.Namespace("oauth2")
+
+	var tokenURL, clientID, clientSecret, refreshToken string
+	var scopes []string
+	if tokenURL, err = oConf.FieldString("token_url"); err != nil {
+		return
+	}
+	if clientID, err = oConf.FieldString("client_id"); err != nil {
+		return
+	}
+	if clientSecret, err = oConf.FieldString("client_secret"); err != nil {
+		return
+	}
+	if refreshToken, err = oConf.FieldString("refresh_token"); err != nil {
+		return
+	}
+	if scopes, err = oConf.FieldStringList("scopes"); err != nil {
+		return
+	}
+	if tokenURL == "" {
+		err = fmt.Errorf("auth mechanism %v requires either an access_token or an oauth2.token_url", a.mechanism)
+		return
+	}
+
+	if refreshToken != "" {
+		c := &oauth2.Config{
+			ClientID:     clientID,
+			ClientSecret: clientSecret,
+			Endpoint:     oauth2.Endpoint{TokenURL: tokenURL},
+			Scopes:       scopes,
+		}
+		a.tokenSource = c.TokenSource(context.Background(), &oauth2.Token{RefreshToken: refreshToken})
+	} else {
+		c := &clientcredentials.Config{
+			ClientID:     clientID,
+			ClientSecret: clientSecret,
+			TokenURL:     tokenURL,
+			Scopes:       scopes,
+		}
+		a.tokenSource = c.TokenSource(context.Background())
+	}
+	return
+}
+
+// saslClient returns a SASL client for the configured mechanism, or nil when
+// the LOGIN command should be used instead.
+func (a authConfig) saslClient(host string, port int) (sasl.Client, error) {
+	switch a.mechanism {
+	case authMechanismLogin:
+		return nil, nil
+	case authMechanismPlain:
+		return sasl.NewPlainClient("", a.username, a.password), nil
+	}
+
+	token, err := a.tokenSource.Token()
+	if err != nil {
+		return nil, fmt.Errorf("failed to obtain access token: %w", err)
+	}
+	if a.mechanism == authMechanismXOAuth2 {
+		return &xoauth2Client{username: a.username, token: token.AccessToken}, nil
+	}
+	return sasl.NewOAuthBearerClient(&sasl.OAuthBearerOptions{
+		Username: a.username,
+		Token:    token.AccessToken,
+		Host:     host,
+		Port:     port,
+	}), nil
+}
+
+//------------------------------------------------------------------------------
+
+// xoauth2Client implements the non-standard XOAUTH2 SASL mechanism described
+// at https://developers.google.com/gmail/imap/xoauth2-protocol.
+type xoauth2Client struct {
+	username string
+	token    string
+}
+
+func (x *xoauth2Client) Start() (mech string, ir []byte, err error) {
+	return "XOAUTH2", []byte("user=" + x.username + "\x01auth=Bearer " + x.token + "\x01\x01"), nil
+}
+
+func (x *xoauth2Client) Next(challenge []byte) ([]byte, error) {
+	// On failure the server responds with a challenge containing a JSON error
+	// description, which must be acknowledged with an empty response before
+	// the authentication fails.
+	if len(challenge) > 0 {
+		return []byte{}, nil
+	}
+	return nil, errors.New("unexpected server challenge")
+}
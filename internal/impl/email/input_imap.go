@@ -0,0 +1,593 @@
+package email
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/emersion/go-imap"
+	"github.com/emersion/go-imap/client"
+	_ "github.com/emersion/go-message/charset"
+	"github.com/emersion/go-message/mail"
+
+	"github.com/benthosdev/benthos/v4/internal/component"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func imapInputConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		// Stable(). TODO
+		Version("4.1.0").
+		Categories("Services").
+		Summary("Consumes emails from a mailbox of an IMAP server.").
+		Description(`
+Messages within the mailbox that match the ` + "`search`" + ` criteria are consumed as batches, where each email results in a single batch containing a message for each body part (such as plain text and HTML alternatives) followed by a message for each attachment.
+
+When the mailbox has no more matching messages and the server supports the IDLE extension this input waits for the server to notify it of changes, otherwise the mailbox is polled periodically.
+
+### Acknowledgements
+
+Emails are only modified once they've been acknowledged, at which point the flags in ` + "`on_ack.flags`" + ` are added to them and, when ` + "`on_ack.move_to`" + ` is set, they are moved to another mailbox. Emails that are consumed but not yet acknowledged are excluded from subsequent searches. Care should be taken to ensure that acknowledged emails no longer match the ` + "`search`" + ` criteria, otherwise they will be consumed again.
+
+### Metadata
+
+Each message of a batch contains the headers of the email as metadata fields, as well as the following:
+
+` + "```text" + `
+- imap_mailbox
+- imap_uid
+- imap_flags
+- imap_internal_date
+- imap_part_type
+- imap_content_type
+- imap_attachment_filename
+` + "```" + `
+
+The ` + "`imap_part_type`" + ` field is either ` + "`inline`" + ` or ` + "`attachment`" + `, and ` + "`imap_attachment_filename`" + ` is only set for attachments.
+
+You can access these metadata fields using [function interpolation](/docs/configuration/interpolation#metadata).`).
+		Field(service.NewStringField("address").
+			Description("The address of the IMAP server to connect to.").
+			Example("imap.gmail.com:993").
+			Example("localhost:143")).
+		Field(service.NewTLSToggledField("tls").
+			Description("Custom TLS settings can be used to override system defaults. When enabled the connection is established using implicit TLS, which is commonly served on port 993, unless `starttls` is set.")).
+		Field(service.NewBoolField("starttls").
+			Description("Whether to establish TLS by upgrading a plain text connection with the STARTTLS command rather than connecting with implicit TLS, which is commonly served on port 143. Only applies when `tls.enabled` is `true`.").
+			Default(false).
+			Advanced()).
+		Field(authFieldSpec()).
+		Field(service.NewStringField("mailbox").
+			Description("The mailbox to consume from.").
+			Default("INBOX")).
+		Field(service.NewStringField("search").
+			Description("The [search criteria](https://datatracker.ietf.org/doc/html/rfc3501#section-6.4.4) used to select emails within the mailbox.").
+			Default("UNSEEN").
+			Example("ALL").
+			Example(`UNSEEN FROM "alerts@example.com"`).
+			Example("UNFLAGGED SINCE 1-Jan-2022")).
+		Field(service.NewBoolField("idle").
+			Description("Whether to wait for changes to the mailbox with the IDLE command when all matching emails have been consumed. When disabled, or when the server does not support IDLE, the mailbox is polled instead.").
+			Default(true).
+			Advanced()).
+		Field(service.NewDurationField("poll_interval").
+			Description("The maximum period to wait before searching the mailbox again when all matching emails have been consumed.").
+			Default("1m")).
+		Field(service.NewObjectField("on_ack",
+			service.NewStringListField("flags").
+				Description("A list of flags to add to emails once they have been acknowledged.").
+				Default([]string{imap.SeenFlag}).
+				Example([]string{imap.SeenFlag, imap.FlaggedFlag}).
+				Example([]string{imap.DeletedFlag}),
+			service.NewStringField("move_to").
+				Description("An optional mailbox to move emails to once they have been acknowledged.").
+				Default("").
+				Example("Archive"),
+		).
+			Description("Modifications made to emails once they have been acknowledged."))
+}
+
+func init() {
+	err := service.RegisterBatchInput(
+		"imap", imapInputConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.BatchInput, error) {
+			i, err := newIMAPInput(conf, mgr.Logger())
+			if err != nil {
+				return nil, err
+			}
+			return service.AutoRetryNacksBatched(i), nil
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type imapInput struct {
+	address      string
+	tlsConf      *tls.Config
+	tlsEnabled   bool
+	startTLS     bool
+	auth         authConfig
+	mailbox      string
+	criteria     *imap.SearchCriteria
+	idle         bool
+	pollInterval time.Duration
+	ackFlags     []interface{}
+	ackMoveTo    string
+
+	log *service.Logger
+
+	// Signalled whenever the mailbox changes or a command needs to be issued
+	// from an ack func, interrupting any ongoing IDLE command.
+	wakeup chan struct{}
+
+	// The number of acks and closes waiting to obtain the cMut lock, reads do
+	// not wait for changes to the mailbox whilst this is non-zero.
+	lockWaiters int32
+
+	cMut        sync.Mutex
+	client      *client.Client
+	uidValidity uint32
+	pending     []uint32
+	inFlight    map[uint32]struct{}
+}
+
+func newIMAPInput(conf *service.ParsedConfig, log *service.Logger) (*imapInput, error) {
+	i := &imapInput{
+		log:      log,
+		wakeup:   make(chan struct{}, 1),
+		inFlight: map[uint32]struct{}{},
+	}
+
+	var err error
+	if i.address, err = conf.FieldString("address"); err != nil {
+		return nil, err
+	}
+	if i.tlsConf, i.tlsEnabled, err = conf.FieldTLSToggled("tls"); err != nil {
+		return nil, err
+	}
+	if i.startTLS, err = conf.FieldBool("starttls"); err != nil {
+		return nil, err
+	}
+	if i.auth, err = authConfigFromParsed(conf.Namespace("auth")); err != nil {
+		return nil, err
+	}
+	if i.mailbox, err = conf.FieldString("mailbox"); err != nil {
+		return nil, err
+	}
+
+	searchStr, err := conf.FieldString("search")
+	if err != nil {
+		return nil, err
+	}
+	if i.criteria, err = parseSearchCriteria(searchStr); err != nil {
+		return nil, fmt.Errorf("failed to parse search criteria: %w", err)
+	}
+
+	if i.idle, err = conf.FieldBool("idle"); err != nil {
+		return nil, err
+	}
+	if i.pollInterval, err = conf.FieldDuration("poll_interval"); err != nil {
+		return nil, err
+	}
+
+	ackFlags, err := conf.FieldStringList("on_ack", "flags")
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range ackFlags {
+		i.ackFlags = append(i.ackFlags, f)
+	}
+	if i.ackMoveTo, err = conf.FieldString("on_ack", "move_to"); err != nil {
+		return nil, err
+	}
+	return i, nil
+}
+
+func parseSearchCriteria(s string) (*imap.SearchCriteria, error) {
+	fields, err := imap.NewReader(bufio.NewReader(strings.NewReader(s + "\r\n"))).ReadLine()
+	if err != nil {
+		return nil, err
+	}
+	criteria := imap.NewSearchCriteria()
+	if err := criteria.ParseWithCharset(fields, nil); err != nil {
+		return nil, err
+	}
+	return criteria, nil
+}
+
+//------------------------------------------------------------------------------
+
+func (i *imapInput) dial() (*client.Client, error) {
+	if i.tlsEnabled && !i.startTLS {
+		return client.DialTLS(i.address, i.tlsConf)
+	}
+	c, err := client.Dial(i.address)
+	if err != nil {
+		return nil, err
+	}
+	if i.tlsEnabled {
+		tlsConf := i.tlsConf.Clone()
+		if tlsConf.ServerName == "" {
+			tlsConf.ServerName, _, _ = net.SplitHostPort(i.address)
+		}
+		if err := c.StartTLS(tlsConf); err != nil {
+			_ = c.Logout()
+			return nil, fmt.Errorf("failed to upgrade connection: %w", err)
+		}
+	}
+	return c, nil
+}
+
+func (i *imapInput) authenticate(c *client.Client) error {
+	host, portStr, _ := net.SplitHostPort(i.address)
+	port, _ := strconv.Atoi(portStr)
+
+	saslClient, err := i.auth.saslClient(host, port)
+	if err != nil {
+		return err
+	}
+	if saslClient == nil {
+		return c.Login(i.auth.username, i.auth.password)
+	}
+	return c.Authenticate(saslClient)
+}
+
+func (i *imapInput) Connect(ctx context.Context) error {
+	i.cMut.Lock()
+	defer i.cMut.Unlock()
+	if i.client != nil {
+		return nil
+	}
+
+	c, err := i.dial()
+	if err != nil {
+		return fmt.Errorf("failed to connect: %w", err)
+	}
+	if err := i.authenticate(c); err != nil {
+		_ = c.Logout()
+		return fmt.Errorf("failed to authenticate: %w", err)
+	}
+
+	status, err := c.Select(i.mailbox, false)
+	if err != nil {
+		_ = c.Logout()
+		return fmt.Errorf("failed to select mailbox: %w", err)
+	}
+
+	// Updates must be drained in order for the client to make progress, we
+	// only use them in order to interrupt IDLE commands.
+	updates := make(chan client.Update, 16)
+	c.Updates = updates
+	go func() {
+		for {
+			select {
+			case <-updates:
+				i.signalWakeup()
+			case <-c.LoggedOut():
+				return
+			}
+		}
+	}()
+
+	if status.UidValidity != i.uidValidity {
+		// Any emails previously consumed can no longer be identified and
+		// therefore their acknowledgements will fail.
+		i.inFlight = map[uint32]struct{}{}
+	}
+	i.uidValidity = status.UidValidity
+	i.pending = nil
+	i.client = c
+
+	i.log.Infof("Receiving emails from IMAP mailbox '%v' at %v", i.mailbox, i.address)
+	return nil
+}
+
+// lockInterrupt obtains the cMut lock outside of a read, interrupting any read
+// that is waiting for changes to the mailbox whilst holding it.
+func (i *imapInput) lockInterrupt() {
+	atomic.AddInt32(&i.lockWaiters, 1)
+	i.signalWakeup()
+	i.cMut.Lock()
+	atomic.AddInt32(&i.lockWaiters, -1)
+}
+
+func (i *imapInput) signalWakeup() {
+	select {
+	case i.wakeup <- struct{}{}:
+	default:
+	}
+}
+
+// disconnectOnErr closes the client when the error is due to the connection
+// being lost, in which case ErrNotConnected is returned. Must be called with
+// the cMut lock held.
+func (i *imapInput) disconnectOnErr(c *client.Client, err error) error {
+	select {
+	case <-c.LoggedOut():
+	default:
+		var netErr net.Error
+		if !errors.As(err, &netErr) && !errors.Is(err, io.EOF) {
+			return err
+		}
+		_ = c.Terminate()
+	}
+	if i.client == c {
+		i.client = nil
+	}
+	i.log.Errorf("Lost connection to IMAP server: %v", err)
+	return service.ErrNotConnected
+}
+
+// search populates the pending UIDs from the mailbox, excluding those that are
+// currently in flight. Must be called with the cMut lock held.
+func (i *imapInput) search(c *client.Client) error {
+	uids, err := c.UidSearch(i.criteria)
+	if err != nil {
+		return i.disconnectOnErr(c, err)
+	}
+	for _, uid := range uids {
+		if _, exists := i.inFlight[uid]; !exists {
+			i.pending = append(i.pending, uid)
+		}
+	}
+	return nil
+}
+
+// wait blocks until the mailbox has potentially changed, the poll interval has
+// elapsed or the context is cancelled. Must be called with the cMut lock held.
+//
+// When the wait is interrupted by a wakeup component.ErrTimeout is returned so
+// that the lock is released by the caller, as acks and closing the input
+// signal a wakeup in order to obtain the lock.
+func (i *imapInput) wait(ctx context.Context, c *client.Client) error {
+	timer := time.NewTimer(i.pollInterval)
+	defer timer.Stop()
+
+	if !i.idle {
+		select {
+		case <-i.wakeup:
+			return component.ErrTimeout
+		case <-timer.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		return nil
+	}
+
+	stop := make(chan struct{})
+	done := make(chan error, 1)
+	go func() {
+		done <- c.Idle(stop, &client.IdleOptions{PollInterval: i.pollInterval})
+	}()
+
+	var woken bool
+	select {
+	case <-i.wakeup:
+		woken = true
+	case <-timer.C:
+	case <-ctx.Done():
+	case err := <-done:
+		return i.disconnectOnErr(c, err)
+	}
+	close(stop)
+	if err := <-done; err != nil {
+		return i.disconnectOnErr(c, err)
+	}
+	if woken {
+		return component.ErrTimeout
+	}
+	return ctx.Err()
+}
+
+// next returns the next email to consume. Must be called with the cMut lock
+// held.
+func (i *imapInput) next(ctx context.Context) (*imap.Message, *imap.BodySectionName, error) {
+	section := &imap.BodySectionName{Peek: true}
+	items := []imap.FetchItem{imap.FetchUid, imap.FetchFlags, imap.FetchInternalDate, section.FetchItem()}
+
+	for {
+		c := i.client
+		if c == nil {
+			return nil, nil, service.ErrNotConnected
+		}
+
+		if len(i.pending) == 0 {
+			if err := i.search(c); err != nil {
+				return nil, nil, err
+			}
+		}
+		if len(i.pending) == 0 {
+			if atomic.LoadInt32(&i.lockWaiters) > 0 {
+				return nil, nil, component.ErrTimeout
+			}
+			if err := i.wait(ctx, c); err != nil {
+				return nil, nil, err
+			}
+			continue
+		}
+
+		uid := i.pending[0]
+		i.pending = i.pending[1:]
+
+		seqSet := new(imap.SeqSet)
+		seqSet.AddNum(uid)
+
+		msgChan := make(chan *imap.Message, 1)
+		if err := c.UidFetch(seqSet, items, msgChan); err != nil {
+			return nil, nil, i.disconnectOnErr(c, err)
+		}
+
+		// The email might have been removed since it was found.
+		if msg := <-msgChan; msg != nil {
+			i.inFlight[uid] = struct{}{}
+			return msg, section, nil
+		}
+	}
+}
+
+func (i *imapInput) ReadBatch(ctx context.Context) (service.MessageBatch, service.AckFunc, error) {
+	i.cMut.Lock()
+	msg, section, err := i.next(ctx)
+	uidValidity := i.uidValidity
+	i.cMut.Unlock()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	batch, err := i.emailToBatch(msg, section)
+	if err != nil {
+		i.log.Errorf("Failed to parse email %v: %v", msg.Uid, err)
+		var raw []byte
+		if body := msg.GetBody(section); body != nil {
+			raw, _ = io.ReadAll(body)
+		}
+		part := service.NewMessage(raw)
+		i.setEmailMeta(part, msg)
+		batch = service.MessageBatch{part}
+	}
+
+	uid := msg.Uid
+	return batch, func(ctx context.Context, err error) error {
+		i.lockInterrupt()
+		defer i.cMut.Unlock()
+		defer delete(i.inFlight, uid)
+
+		if err != nil {
+			return nil
+		}
+		if i.client == nil {
+			return service.ErrNotConnected
+		}
+		if i.uidValidity != uidValidity {
+			return fmt.Errorf("unable to acknowledge email %v as the mailbox UID validity has changed", uid)
+		}
+		return i.ackEmail(uid)
+	}, nil
+}
+
+// ackEmail applies the configured modifications to an acknowledged email. Must
+// be called with the cMut lock held.
+func (i *imapInput) ackEmail(uid uint32) error {
+	c := i.client
+
+	seqSet := new(imap.SeqSet)
+	seqSet.AddNum(uid)
+
+	if len(i.ackFlags) > 0 {
+		item := imap.FormatFlagsOp(imap.AddFlags, true)
+		if err := c.UidStore(seqSet, item, i.ackFlags, nil); err != nil {
+			return i.disconnectOnErr(c, fmt.Errorf("failed to add flags to email %v: %w", uid, err))
+		}
+	}
+	if i.ackMoveTo != "" {
+		if err := c.UidMove(seqSet, i.ackMoveTo); err != nil {
+			return i.disconnectOnErr(c, fmt.Errorf("failed to move email %v: %w", uid, err))
+		}
+	}
+	return nil
+}
+
+func (i *imapInput) setEmailMeta(part *service.Message, msg *imap.Message) {
+	part.MetaSet("imap_mailbox", i.mailbox)
+	part.MetaSet("imap_uid", strconv.FormatUint(uint64(msg.Uid), 10))
+	part.MetaSet("imap_flags", strings.Join(msg.Flags, ","))
+	part.MetaSet("imap_internal_date", msg.InternalDate.Format(time.RFC3339))
+}
+
+func (i *imapInput) emailToBatch(msg *imap.Message, section *imap.BodySectionName) (service.MessageBatch, error) {
+	body := msg.GetBody(section)
+	if body == nil {
+		return nil, errors.New("server did not return the email body")
+	}
+
+	mr, err := mail.CreateReader(body)
+	if err != nil {
+		return nil, err
+	}
+	defer mr.Close()
+
+	headers := map[string]string{}
+	fields := mr.Header.Fields()
+	for fields.Next() {
+		if _, exists := headers[fields.Key()]; exists {
+			continue
+		}
+		v, err := fields.Text()
+		if err != nil {
+			v = fields.Value()
+		}
+		headers[fields.Key()] = v
+	}
+
+	var batch service.MessageBatch
+	var attachments service.MessageBatch
+	for {
+		p, err := mr.NextPart()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		partBytes, err := io.ReadAll(p.Body)
+		if err != nil {
+			return nil, err
+		}
+
+		part := service.NewMessage(partBytes)
+		for k, v := range headers {
+			part.MetaSet(k, v)
+		}
+		i.setEmailMeta(part, msg)
+
+		switch h := p.Header.(type) {
+		case *mail.InlineHeader:
+			contentType, _, _ := h.ContentType()
+			part.MetaSet("imap_part_type", "inline")
+			part.MetaSet("imap_content_type", contentType)
+			batch = append(batch, part)
+		case *mail.AttachmentHeader:
+			contentType, _, _ := h.ContentType()
+			filename, _ := h.Filename()
+			part.MetaSet("imap_part_type", "attachment")
+			part.MetaSet("imap_content_type", contentType)
+			part.MetaSet("imap_attachment_filename", filename)
+			attachments = append(attachments, part)
+		}
+	}
+
+	batch = append(batch, attachments...)
+	if len(batch) == 0 {
+		part := service.NewMessage(nil)
+		for k, v := range headers {
+			part.MetaSet(k, v)
+		}
+		i.setEmailMeta(part, msg)
+		batch = append(batch, part)
+	}
+	return batch, nil
+}
+
+func (i *imapInput) Close(ctx context.Context) error {
+	i.lockInterrupt()
+	defer i.cMut.Unlock()
+	if i.client == nil {
+		return nil
+	}
+	err := i.client.Logout()
+	i.client = nil
+	return err
+}
@@ -0,0 +1,332 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/emersion/go-imap"
+	"github.com/emersion/go-imap/backend"
+	"github.com/emersion/go-imap/backend/memory"
+	"github.com/emersion/go-imap/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/component"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const testMultipartEmail = "From: Foo <foo@example.com>\r\n" +
+	"To: bar@example.com\r\n" +
+	"Subject: =?utf-8?q?Hello_w=C3=B6rld?=\r\n" +
+	"Date: Wed, 11 May 2022 14:31:59 +0000\r\n" +
+	"Message-ID: <1234@example.com>\r\n" +
+	"MIME-Version: 1.0\r\n" +
+	"Content-Type: multipart/mixed; boundary=outer\r\n" +
+	"\r\n" +
+	"--outer\r\n" +
+	"Content-Type: multipart/alternative; boundary=inner\r\n" +
+	"\r\n" +
+	"--inner\r\n" +
+	"Content-Type: text/plain\r\n" +
+	"\r\n" +
+	"hello world\r\n" +
+	"--inner\r\n" +
+	"Content-Type: text/html\r\n" +
+	"\r\n" +
+	"<p>hello world</p>\r\n" +
+	"--inner--\r\n" +
+	"--outer\r\n" +
+	"Content-Type: text/csv\r\n" +
+	"Content-Disposition: attachment; filename=\"data.csv\"\r\n" +
+	"\r\n" +
+	"a,b,c\r\n" +
+	"--outer--\r\n"
+
+const testPlainEmail = "From: baz@example.com\r\n" +
+	"To: bar@example.com\r\n" +
+	"Subject: Second\r\n" +
+	"Content-Type: text/plain\r\n" +
+	"\r\n" +
+	"second email"
+
+// The memory backend does not support the MOVE extension, which the server
+// advertises regardless.
+type movingBackend struct {
+	*memory.Backend
+}
+
+func (b movingBackend) Login(connInfo *imap.ConnInfo, username, password string) (backend.User, error) {
+	u, err := b.Backend.Login(connInfo, username, password)
+	if err != nil {
+		return nil, err
+	}
+	return movingUser{u}, nil
+}
+
+type movingUser struct {
+	backend.User
+}
+
+func (u movingUser) GetMailbox(name string) (backend.Mailbox, error) {
+	m, err := u.User.GetMailbox(name)
+	if err != nil {
+		return nil, err
+	}
+	return movingMailbox{m}, nil
+}
+
+type movingMailbox struct {
+	backend.Mailbox
+}
+
+func (m movingMailbox) MoveMessages(uid bool, seqset *imap.SeqSet, dest string) error {
+	if err := m.CopyMessages(uid, seqset, dest); err != nil {
+		return err
+	}
+	if err := m.UpdateMessagesFlags(uid, seqset, imap.AddFlags, []string{imap.DeletedFlag}); err != nil {
+		return err
+	}
+	return m.Expunge()
+}
+
+func startIMAPServer(t *testing.T, emails ...string) (addr string, user backend.User) {
+	t.Helper()
+
+	be := memory.New()
+	user, err := be.Login(nil, "username", "password")
+	require.NoError(t, err)
+	require.NoError(t, user.CreateMailbox("Archive"))
+
+	mbox, err := user.GetMailbox("INBOX")
+	require.NoError(t, err)
+	for _, e := range emails {
+		require.NoError(t, mbox.CreateMessage(nil, time.Now(), bytes.NewBufferString(e)))
+	}
+
+	s := server.New(movingBackend{be})
+	s.AllowInsecureAuth = true
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() {
+		_ = s.Serve(l)
+	}()
+	t.Cleanup(func() {
+		_ = s.Close()
+	})
+	return l.Addr().String(), user
+}
+
+func mailboxUIDs(t *testing.T, user backend.User, name string, criteria *imap.SearchCriteria) []uint32 {
+	t.Helper()
+
+	mbox, err := user.GetMailbox(name)
+	require.NoError(t, err)
+	uids, err := mbox.SearchMessages(true, criteria)
+	require.NoError(t, err)
+	return uids
+}
+
+func TestIMAPInputConfigErrors(t *testing.T) {
+	tests := map[string]struct {
+		conf        string
+		errContains string
+	}{
+		"bad search": {
+			conf: `
+address: localhost:143
+search: 'NOTAKEY'
+`,
+			errContains: "failed to parse search criteria",
+		},
+		"oauth2 without token": {
+			conf: `
+address: localhost:143
+auth:
+  mechanism: xoauth2
+  username: foo
+`,
+			errContains: "requires either an access_token or an oauth2.token_url",
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			parsed, err := imapInputConfig().ParseYAML(test.conf, service.NewEnvironment())
+			require.NoError(t, err)
+
+			_, err = newIMAPInput(parsed, service.MockResources().Logger())
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), test.errContains)
+		})
+	}
+}
+
+func TestXOAuth2Client(t *testing.T) {
+	c := &xoauth2Client{username: "foo@example.com", token: "abc"}
+
+	mech, ir, err := c.Start()
+	require.NoError(t, err)
+	assert.Equal(t, "XOAUTH2", mech)
+	assert.Equal(t, "user=foo@example.com\x01auth=Bearer abc\x01\x01", string(ir))
+
+	resp, err := c.Next([]byte(`{"status":"401"}`))
+	require.NoError(t, err)
+	assert.Empty(t, resp)
+}
+
+func TestIMAPInputReadAndAck(t *testing.T) {
+	addr, user := startIMAPServer(t, testMultipartEmail, testPlainEmail)
+
+	parsed, err := imapInputConfig().ParseYAML(`
+address: `+addr+`
+auth:
+  username: username
+  password: password
+search: 'UNSEEN'
+poll_interval: 100ms
+on_ack:
+  move_to: Archive
+`, service.NewEnvironment())
+	require.NoError(t, err)
+
+	in, err := newIMAPInput(parsed, service.MockResources().Logger())
+	require.NoError(t, err)
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*10)
+	defer done()
+
+	require.NoError(t, in.Connect(ctx))
+	defer func() {
+		require.NoError(t, in.Close(context.Background()))
+	}()
+
+	batch, ackFn, err := in.ReadBatch(ctx)
+	require.NoError(t, err)
+	require.Len(t, batch, 3)
+
+	var contents, contentTypes, partTypes []string
+	for _, part := range batch {
+		b, err := part.AsBytes()
+		require.NoError(t, err)
+		contents = append(contents, strings.TrimSpace(string(b)))
+
+		ct, _ := part.MetaGet("imap_content_type")
+		contentTypes = append(contentTypes, ct)
+		pt, _ := part.MetaGet("imap_part_type")
+		partTypes = append(partTypes, pt)
+
+		subject, _ := part.MetaGet("Subject")
+		assert.Equal(t, "Hello wörld", subject)
+		from, _ := part.MetaGet("From")
+		assert.Equal(t, "Foo <foo@example.com>", from)
+		mailbox, _ := part.MetaGet("imap_mailbox")
+		assert.Equal(t, "INBOX", mailbox)
+	}
+	assert.Equal(t, []string{"hello world", "<p>hello world</p>", "a,b,c"}, contents)
+	assert.Equal(t, []string{"text/plain", "text/html", "text/csv"}, contentTypes)
+	assert.Equal(t, []string{"inline", "inline", "attachment"}, partTypes)
+
+	filename, _ := batch[2].MetaGet("imap_attachment_filename")
+	assert.Equal(t, "data.csv", filename)
+
+	// The second email is consumed whilst the first remains unacknowledged.
+	batch2, ackFn2, err := in.ReadBatch(ctx)
+	require.NoError(t, err)
+	require.Len(t, batch2, 1)
+	b, err := batch2[0].AsBytes()
+	require.NoError(t, err)
+	assert.Equal(t, "second email", string(b))
+
+	// Nothing has been modified before acknowledgement.
+	unseen := &imap.SearchCriteria{WithoutFlags: []string{imap.SeenFlag}}
+	assert.Len(t, mailboxUIDs(t, user, "INBOX", unseen), 2)
+
+	require.NoError(t, ackFn(ctx, nil))
+	assert.Len(t, mailboxUIDs(t, user, "Archive", imap.NewSearchCriteria()), 1)
+	assert.Len(t, mailboxUIDs(t, user, "INBOX", unseen), 1)
+
+	// Rejected emails are not modified and are consumed again.
+	require.NoError(t, ackFn2(ctx, assert.AnError))
+	batch2, ackFn2, err = in.ReadBatch(ctx)
+	require.NoError(t, err)
+	require.Len(t, batch2, 1)
+
+	require.NoError(t, ackFn2(ctx, nil))
+	assert.Len(t, mailboxUIDs(t, user, "Archive", &imap.SearchCriteria{WithFlags: []string{imap.SeenFlag}}), 2)
+	assert.Len(t, mailboxUIDs(t, user, "INBOX", unseen), 0)
+
+	// With nothing left to consume reads block until cancelled.
+	readCtx, readDone := context.WithTimeout(ctx, time.Millisecond*300)
+	defer readDone()
+	for {
+		if _, _, err = in.ReadBatch(readCtx); !errors.Is(err, component.ErrTimeout) {
+			break
+		}
+	}
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestIMAPInputAckAndCloseWhileWaiting(t *testing.T) {
+	for _, idle := range []bool{false, true} {
+		idle := idle
+		t.Run(fmt.Sprintf("idle %v", idle), func(t *testing.T) {
+			addr, user := startIMAPServer(t, testPlainEmail)
+
+			parsed, err := imapInputConfig().ParseYAML(fmt.Sprintf(`
+address: %v
+auth:
+  username: username
+  password: password
+search: 'UNSEEN'
+idle: %v
+poll_interval: 1h
+`, addr, idle), service.NewEnvironment())
+			require.NoError(t, err)
+
+			in, err := newIMAPInput(parsed, service.MockResources().Logger())
+			require.NoError(t, err)
+
+			ctx, done := context.WithTimeout(context.Background(), time.Second*10)
+			defer done()
+
+			require.NoError(t, in.Connect(ctx))
+
+			_, ackFn, err := in.ReadBatch(ctx)
+			require.NoError(t, err)
+
+			// Keep reading in the background in the same way as the input
+			// reader would, where there is nothing left to read and
+			// therefore each read blocks.
+			readsDone := make(chan struct{})
+			go func() {
+				defer close(readsDone)
+				for {
+					_, _, err := in.ReadBatch(ctx)
+					if !errors.Is(err, component.ErrTimeout) {
+						return
+					}
+				}
+			}()
+
+			<-time.After(time.Millisecond * 100)
+			require.NoError(t, ackFn(ctx, nil))
+			unseen := &imap.SearchCriteria{WithoutFlags: []string{imap.SeenFlag}}
+			assert.Len(t, mailboxUIDs(t, user, "INBOX", unseen), 0)
+
+			require.NoError(t, in.Close(ctx))
+			select {
+			case <-readsDone:
+			case <-ctx.Done():
+				t.Fatal("timed out")
+			}
+		})
+	}
+}
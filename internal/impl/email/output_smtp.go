@@ -0,0 +1,484 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/emersion/go-message/mail"
+	"github.com/emersion/go-sasl"
+	"github.com/emersion/go-smtp"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func smtpOutputConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		// Stable(). TODO
+		Version("4.1.0").
+		Categories("Services").
+		Summary("Sends emails via an SMTP server.").
+		Description(`
+Each message batch is sent as a single email, where the headers and body are resolved from the first message of the batch, and each remaining message of the batch is included as an attachment. When sending messages individually, such as alerts from a [`+"`branch`"+`](/docs/components/processors/branch), each message therefore results in an email without attachments.
+
+The `+"`rate_limit`"+` field can be used to specify a rate limit [resource](/docs/components/rate_limits/about) to cap the rate of emails sent across parallel components service wide.`).
+		Field(service.NewStringField("address").
+			Description("The address of the SMTP server to connect to.").
+			Example("smtp.gmail.com:465").
+			Example("localhost:587")).
+		Field(service.NewTLSToggledField("tls").
+			Description("Custom TLS settings can be used to override system defaults. When enabled the connection is established using implicit TLS, which is commonly served on port 465, unless `starttls` is set.")).
+		Field(service.NewBoolField("starttls").
+			Description("Whether to establish TLS by upgrading a plain text connection with the STARTTLS command rather than connecting with implicit TLS, which is commonly served on port 587. Only applies when `tls.enabled` is `true`.").
+			Default(false).
+			Advanced()).
+		Field(authFieldSpec().
+			Description("Authentication options. Authentication is skipped when the `login` mechanism is used without a username.")).
+		Field(service.NewInterpolatedStringField("from").
+			Description("The address to send emails from.").
+			Example("Benthos <benthos@example.com>")).
+		Field(service.NewInterpolatedStringField("to").
+			Description("A comma separated list of addresses to send emails to.").
+			Example("alerts@example.com").
+			Example(`${! meta("recipients") }`)).
+		Field(service.NewInterpolatedStringField("cc").
+			Description("A comma separated list of addresses to send copies of emails to.").
+			Default("").
+			Advanced()).
+		Field(service.NewInterpolatedStringField("bcc").
+			Description("A comma separated list of addresses to send blind copies of emails to.").
+			Default("").
+			Advanced()).
+		Field(service.NewInterpolatedStringField("subject").
+			Description("The subject of emails.").
+			Example(`Alert: ${! json("title") }`)).
+		Field(service.NewInterpolatedStringField("body").
+			Description("The body of emails.").
+			Default("${! content() }").
+			Example(`Service ${! json("service") } reported: ${! json("message") }`)).
+		Field(service.NewInterpolatedStringField("content_type").
+			Description("The content type of the body of emails.").
+			Default("text/plain; charset=utf-8").
+			Example("text/html; charset=utf-8")).
+		Field(service.NewObjectField("attachment",
+			service.NewInterpolatedStringField("filename").
+				Description("The filename of an attachment, resolved from the message being attached.").
+				Default(`${! meta("filename").or("attachment_%v".format(batch_index())) }`),
+			service.NewInterpolatedStringField("content_type").
+				Description("The content type of an attachment, resolved from the message being attached.").
+				Default("application/octet-stream").
+				Example("text/csv"),
+		).
+			Description("Describes the attachments created from the messages of a batch following the first.").
+			Advanced()).
+		Field(service.NewStringField("rate_limit").
+			Description("An optional [`rate_limit`](/docs/components/rate_limits/about) to throttle emails by.").
+			Default("")).
+		Field(service.NewDurationField("timeout").
+			Description("The maximum period to wait for an email to be sent.").
+			Default("10s").
+			Advanced()).
+		Field(service.NewIntField("max_in_flight").
+			Description("The maximum number of emails to have in flight at a given time.").
+			Default(64)).
+		Field(service.NewBatchPolicyField("batching")).
+		Example("Alerting",
+			`Here we send an email for each error log that passes through a pipeline, limited to one per minute, whilst the original messages continue on to their regular output:`,
+			`
+output:
+  broker:
+    pattern: fan_out
+    outputs:
+      - resource: regular_output
+      - smtp:
+          address: smtp.example.com:587
+          tls:
+            enabled: true
+          starttls: true
+          auth:
+            username: benthos@example.com
+            password: ${SMTP_PASSWORD}
+          from: Benthos <benthos@example.com>
+          to: oncall@example.com
+          subject: 'Error in ${! json("service") }'
+          body: '${! json("message") }'
+          rate_limit: one_per_minute
+        processors:
+          - bloblang: 'root = if this.level != "error" { deleted() }'
+
+rate_limit_resources:
+  - label: one_per_minute
+    local:
+      count: 1
+      interval: 1m
+`,
+		)
+}
+
+func init() {
+	err := service.RegisterBatchOutput(
+		"smtp", smtpOutputConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (out service.BatchOutput, batchPolicy service.BatchPolicy, maxInFlight int, err error) {
+			if batchPolicy, err = conf.FieldBatchPolicy("batching"); err != nil {
+				return
+			}
+			if maxInFlight, err = conf.FieldInt("max_in_flight"); err != nil {
+				return
+			}
+			out, err = newSMTPOutputFromConfig(conf, mgr)
+			return
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type smtpOutput struct {
+	address    string
+	tlsConf    *tls.Config
+	tlsEnabled bool
+	startTLS   bool
+	auth       authConfig
+	timeout    time.Duration
+	rateLimit  string
+
+	from               *service.InterpolatedString
+	to                 *service.InterpolatedString
+	cc                 *service.InterpolatedString
+	bcc                *service.InterpolatedString
+	subject            *service.InterpolatedString
+	body               *service.InterpolatedString
+	contentType        *service.InterpolatedString
+	attachmentFilename *service.InterpolatedString
+	attachmentType     *service.InterpolatedString
+
+	mgr *service.Resources
+	log *service.Logger
+}
+
+func newSMTPOutputFromConfig(conf *service.ParsedConfig, mgr *service.Resources) (*smtpOutput, error) {
+	s := &smtpOutput{
+		mgr: mgr,
+		log: mgr.Logger(),
+	}
+
+	var err error
+	if s.address, err = conf.FieldString("address"); err != nil {
+		return nil, err
+	}
+	if s.tlsConf, s.tlsEnabled, err = conf.FieldTLSToggled("tls"); err != nil {
+		return nil, err
+	}
+	if s.startTLS, err = conf.FieldBool("starttls"); err != nil {
+		return nil, err
+	}
+	if s.auth, err = authConfigFromParsed(conf.Namespace("auth")); err != nil {
+		return nil, err
+	}
+	if s.timeout, err = conf.FieldDuration("timeout"); err != nil {
+		return nil, err
+	}
+	if s.rateLimit, err = conf.FieldString("rate_limit"); err != nil {
+		return nil, err
+	}
+	if s.rateLimit != "" && !mgr.HasRateLimit(s.rateLimit) {
+		return nil, fmt.Errorf("rate limit resource '%v' was not found", s.rateLimit)
+	}
+
+	for _, f := range []struct {
+		target **service.InterpolatedString
+		path   []string
+	}{
+		{&s.from, []string{"from"}},
+		{&s.to, []string{"to"}},
+		{&s.cc, []string{"cc"}},
+		{&s.bcc, []string{"bcc"}},
+		{&s.subject, []string{"subject"}},
+		{&s.body, []string{"body"}},
+		{&s.contentType, []string{"content_type"}},
+		{&s.attachmentFilename, []string{"attachment", "filename"}},
+		{&s.attachmentType, []string{"attachment", "content_type"}},
+	} {
+		if *f.target, err = conf.FieldInterpolatedString(f.path...); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+//------------------------------------------------------------------------------
+
+// dial establishes an authenticated session with the SMTP server, the
+// connection is given a deadline of the configured timeout.
+func (s *smtpOutput) dial() (*smtp.Client, error) {
+	host, portStr, err := net.SplitHostPort(s.address)
+	if err != nil {
+		return nil, err
+	}
+
+	dialer := &net.Dialer{Timeout: s.timeout}
+
+	var conn net.Conn
+	if s.tlsEnabled && !s.startTLS {
+		tlsConf := s.tlsConf.Clone()
+		if tlsConf.ServerName == "" {
+			tlsConf.ServerName = host
+		}
+		conn, err = tls.DialWithDialer(dialer, "tcp", s.address, tlsConf)
+	} else {
+		conn, err = dialer.Dial("tcp", s.address)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := conn.SetDeadline(time.Now().Add(s.timeout)); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	if s.tlsEnabled && s.startTLS {
+		tlsConf := s.tlsConf.Clone()
+		if tlsConf.ServerName == "" {
+			tlsConf.ServerName = host
+		}
+		if err := c.StartTLS(tlsConf); err != nil {
+			c.Close()
+			return nil, fmt.Errorf("failed to upgrade connection: %w", err)
+		}
+	}
+
+	if s.auth.mechanism == authMechanismLogin && s.auth.username == "" {
+		return c, nil
+	}
+
+	port, _ := strconv.Atoi(portStr)
+	saslClient, err := s.auth.saslClient(host, port)
+	if err != nil {
+		c.Close()
+		return nil, err
+	}
+	if saslClient == nil {
+		saslClient = sasl.NewLoginClient(s.auth.username, s.auth.password)
+	}
+	if err := c.Auth(saslClient); err != nil {
+		c.Close()
+		return nil, fmt.Errorf("failed to authenticate: %w", err)
+	}
+	return c, nil
+}
+
+func (s *smtpOutput) Connect(ctx context.Context) error {
+	c, err := s.dial()
+	if err != nil {
+		return err
+	}
+	_ = c.Quit()
+
+	s.log.Infof("Sending emails via SMTP server %v", s.address)
+	return nil
+}
+
+func (s *smtpOutput) waitForAccess(ctx context.Context) error {
+	if s.rateLimit == "" {
+		return nil
+	}
+	for {
+		var period time.Duration
+		var err error
+		if rerr := s.mgr.AccessRateLimit(ctx, s.rateLimit, func(rl service.RateLimit) {
+			period, err = rl.Access(ctx)
+		}); rerr != nil {
+			err = rerr
+		}
+		if err != nil {
+			s.log.Errorf("Rate limit error: %v\n", err)
+			period = time.Second
+		}
+		if period <= 0 {
+			return nil
+		}
+		select {
+		case <-time.After(period):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func parseAddressList(s string) ([]*mail.Address, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+	return mail.ParseAddressList(s)
+}
+
+func (s *smtpOutput) buildEmail(batch service.MessageBatch) (from string, rcpts []string, data []byte, err error) {
+	var fromAddrs []*mail.Address
+	if fromAddrs, err = parseAddressList(batch.InterpolatedString(0, s.from)); err != nil {
+		err = fmt.Errorf("failed to parse from address: %w", err)
+		return
+	}
+	if len(fromAddrs) != 1 {
+		err = fmt.Errorf("expected a single from address, got %v", len(fromAddrs))
+		return
+	}
+	from = fromAddrs[0].Address
+
+	var h mail.Header
+	h.SetDate(time.Now())
+	h.SetAddressList("From", fromAddrs)
+	h.SetSubject(batch.InterpolatedString(0, s.subject))
+	if err = h.GenerateMessageID(); err != nil {
+		return
+	}
+
+	for _, r := range []struct {
+		key    string
+		interp *service.InterpolatedString
+	}{
+		{"To", s.to},
+		{"Cc", s.cc},
+		{"Bcc", s.bcc},
+	} {
+		var addrs []*mail.Address
+		if addrs, err = parseAddressList(batch.InterpolatedString(0, r.interp)); err != nil {
+			err = fmt.Errorf("failed to parse %v addresses: %w", strings.ToLower(r.key), err)
+			return
+		}
+		for _, a := range addrs {
+			rcpts = append(rcpts, a.Address)
+		}
+		if len(addrs) > 0 && r.key != "Bcc" {
+			h.SetAddressList(r.key, addrs)
+		}
+	}
+	if len(rcpts) == 0 {
+		err = errors.New("email has no recipients")
+		return
+	}
+
+	var bodyHeader mail.InlineHeader
+	bodyHeader.Set("Content-Type", batch.InterpolatedString(0, s.contentType))
+	body := batch.InterpolatedBytes(0, s.body)
+
+	var buf bytes.Buffer
+	if len(batch) == 1 {
+		h.Set("Content-Type", bodyHeader.Get("Content-Type"))
+
+		var w io.WriteCloser
+		if w, err = mail.CreateSingleInlineWriter(&buf, h); err != nil {
+			return
+		}
+		if _, err = w.Write(body); err != nil {
+			return
+		}
+		if err = w.Close(); err != nil {
+			return
+		}
+		data = buf.Bytes()
+		return
+	}
+
+	var mw *mail.Writer
+	if mw, err = mail.CreateWriter(&buf, h); err != nil {
+		return
+	}
+
+	var bw io.WriteCloser
+	if bw, err = mw.CreateSingleInline(bodyHeader); err != nil {
+		return
+	}
+	if _, err = bw.Write(body); err != nil {
+		return
+	}
+	if err = bw.Close(); err != nil {
+		return
+	}
+
+	for i, part := range batch[1:] {
+		index := i + 1
+
+		var partBytes []byte
+		if partBytes, err = part.AsBytes(); err != nil {
+			return
+		}
+
+		var ah mail.AttachmentHeader
+		ah.Set("Content-Type", batch.InterpolatedString(index, s.attachmentType))
+		ah.SetFilename(batch.InterpolatedString(index, s.attachmentFilename))
+
+		var aw io.WriteCloser
+		if aw, err = mw.CreateAttachment(ah); err != nil {
+			return
+		}
+		if _, err = aw.Write(partBytes); err != nil {
+			return
+		}
+		if err = aw.Close(); err != nil {
+			return
+		}
+	}
+
+	if err = mw.Close(); err != nil {
+		return
+	}
+	data = buf.Bytes()
+	return
+}
+
+func (s *smtpOutput) WriteBatch(ctx context.Context, batch service.MessageBatch) error {
+	from, rcpts, data, err := s.buildEmail(batch)
+	if err != nil {
+		return err
+	}
+
+	if err := s.waitForAccess(ctx); err != nil {
+		return err
+	}
+
+	c, err := s.dial()
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	if err := c.Mail(from, nil); err != nil {
+		return err
+	}
+	for _, rcpt := range rcpts {
+		if err := c.Rcpt(rcpt); err != nil {
+			return fmt.Errorf("failed to add recipient %v: %w", rcpt, err)
+		}
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+func (s *smtpOutput) Close(ctx context.Context) error {
+	return nil
+}
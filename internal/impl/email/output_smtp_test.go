@@ -0,0 +1,255 @@
+package email
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/emersion/go-message/mail"
+	"github.com/emersion/go-smtp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+type testSentEmail struct {
+	from  string
+	rcpts []string
+	data  []byte
+}
+
+type testSMTPBackend struct {
+	mut    sync.Mutex
+	emails []testSentEmail
+}
+
+func (b *testSMTPBackend) Login(state *smtp.ConnectionState, username, password string) (smtp.Session, error) {
+	if username != "username" || password != "password" {
+		return nil, errors.New("invalid credentials")
+	}
+	return &testSMTPSession{b: b}, nil
+}
+
+func (b *testSMTPBackend) AnonymousLogin(state *smtp.ConnectionState) (smtp.Session, error) {
+	return nil, smtp.ErrAuthRequired
+}
+
+type testSMTPSession struct {
+	b       *testSMTPBackend
+	pending testSentEmail
+}
+
+func (s *testSMTPSession) Reset() {
+	s.pending = testSentEmail{}
+}
+
+func (s *testSMTPSession) Logout() error {
+	return nil
+}
+
+func (s *testSMTPSession) Mail(from string, opts smtp.MailOptions) error {
+	s.pending.from = from
+	return nil
+}
+
+func (s *testSMTPSession) Rcpt(to string) error {
+	s.pending.rcpts = append(s.pending.rcpts, to)
+	return nil
+}
+
+func (s *testSMTPSession) Data(r io.Reader) (err error) {
+	if s.pending.data, err = io.ReadAll(r); err != nil {
+		return
+	}
+	s.b.mut.Lock()
+	s.b.emails = append(s.b.emails, s.pending)
+	s.b.mut.Unlock()
+	return nil
+}
+
+func startSMTPServer(t *testing.T) (string, *testSMTPBackend) {
+	t.Helper()
+
+	be := &testSMTPBackend{}
+	s := smtp.NewServer(be)
+	s.AllowInsecureAuth = true
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() {
+		_ = s.Serve(l)
+	}()
+	t.Cleanup(func() {
+		_ = s.Close()
+	})
+	return l.Addr().String(), be
+}
+
+func newTestSMTPOutput(t *testing.T, conf string) *smtpOutput {
+	t.Helper()
+
+	parsed, err := smtpOutputConfig().ParseYAML(conf, service.NewEnvironment())
+	require.NoError(t, err)
+
+	out, err := newSMTPOutputFromConfig(parsed, service.MockResources())
+	require.NoError(t, err)
+	return out
+}
+
+func TestSMTPOutputSingle(t *testing.T) {
+	addr, be := startSMTPServer(t)
+
+	out := newTestSMTPOutput(t, `
+address: `+addr+`
+auth:
+  mechanism: plain
+  username: username
+  password: password
+from: 'Benthos <benthos@example.com>'
+to: '${! json("to") }'
+bcc: 'hidden@example.com'
+subject: 'Alert: ${! json("title") }'
+body: 'Something happened: ${! json("message") }'
+`)
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*10)
+	defer done()
+
+	require.NoError(t, out.Connect(ctx))
+	require.NoError(t, out.WriteBatch(ctx, service.MessageBatch{
+		service.NewMessage([]byte(`{"to":"foo@example.com, Bar <bar@example.com>","title":"disk full","message":"no space left"}`)),
+	}))
+	require.NoError(t, out.Close(ctx))
+
+	be.mut.Lock()
+	defer be.mut.Unlock()
+	require.Len(t, be.emails, 1)
+
+	e := be.emails[0]
+	assert.Equal(t, "benthos@example.com", e.from)
+	assert.Equal(t, []string{"foo@example.com", "bar@example.com", "hidden@example.com"}, e.rcpts)
+
+	mr, err := mail.CreateReader(strings.NewReader(string(e.data)))
+	require.NoError(t, err)
+
+	subject, err := mr.Header.Subject()
+	require.NoError(t, err)
+	assert.Equal(t, "Alert: disk full", subject)
+	assert.Empty(t, mr.Header.Get("Bcc"))
+
+	to, err := mr.Header.AddressList("To")
+	require.NoError(t, err)
+	require.Len(t, to, 2)
+	assert.Equal(t, "Bar", to[1].Name)
+
+	p, err := mr.NextPart()
+	require.NoError(t, err)
+	body, err := io.ReadAll(p.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "Something happened: no space left", strings.TrimSpace(string(body)))
+
+	_, err = mr.NextPart()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestSMTPOutputAttachments(t *testing.T) {
+	addr, be := startSMTPServer(t)
+
+	out := newTestSMTPOutput(t, `
+address: `+addr+`
+auth:
+  mechanism: plain
+  username: username
+  password: password
+from: benthos@example.com
+to: foo@example.com
+subject: Report
+body: 'See attached'
+attachment:
+  content_type: text/csv
+`)
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*10)
+	defer done()
+
+	first := service.NewMessage([]byte("a,b"))
+	first.MetaSet("filename", "first.csv")
+
+	require.NoError(t, out.Connect(ctx))
+	require.NoError(t, out.WriteBatch(ctx, service.MessageBatch{
+		service.NewMessage([]byte("ignored")),
+		first,
+		service.NewMessage([]byte("c,d")),
+	}))
+
+	be.mut.Lock()
+	defer be.mut.Unlock()
+	require.Len(t, be.emails, 1)
+
+	mr, err := mail.CreateReader(strings.NewReader(string(be.emails[0].data)))
+	require.NoError(t, err)
+
+	var bodies, filenames []string
+	for {
+		p, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+
+		b, err := io.ReadAll(p.Body)
+		require.NoError(t, err)
+		bodies = append(bodies, string(b))
+
+		if h, ok := p.Header.(*mail.AttachmentHeader); ok {
+			filename, err := h.Filename()
+			require.NoError(t, err)
+			filenames = append(filenames, filename)
+
+			ct, _, err := h.ContentType()
+			require.NoError(t, err)
+			assert.Equal(t, "text/csv", ct)
+		}
+	}
+	assert.Equal(t, []string{"See attached", "a,b", "c,d"}, bodies)
+	assert.Equal(t, []string{"first.csv", "attachment_2"}, filenames)
+}
+
+func TestSMTPOutputErrors(t *testing.T) {
+	addr, _ := startSMTPServer(t)
+
+	out := newTestSMTPOutput(t, `
+address: `+addr+`
+auth:
+  mechanism: plain
+  username: username
+  password: nope
+from: benthos@example.com
+to: foo@example.com
+subject: Report
+`)
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*10)
+	defer done()
+
+	err := out.Connect(ctx)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to authenticate")
+
+	out = newTestSMTPOutput(t, `
+address: `+addr+`
+from: benthos@example.com
+to: '${! meta("to").or("") }'
+subject: Report
+`)
+
+	err = out.WriteBatch(ctx, service.MessageBatch{service.NewMessage([]byte("hello"))})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "email has no recipients")
+}
@@ -0,0 +1,696 @@
+package fs
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/internal/codec"
+	"github.com/benthosdev/benthos/v4/internal/filepath"
+	"github.com/benthosdev/benthos/v4/internal/shutdown"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func tailInputConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		// Stable(). TODO
+		Version("4.1.0").
+		Categories("Local").
+		Summary("Follows files on disk as they're written to, tracking them through renames and rotations.").
+		Description(`
+The `+"`paths`"+` are expanded periodically in order to find files to follow, and each file is read as it grows, emitting a message for each record produced by the `+"`codec`"+`.
+
+### Rotation
+
+Files are tracked by their identity rather than their path, which on Unix systems is the device and inode of the file. When a file is renamed, such as when it's rotated, the input continues reading it under its new path for as long as it matches any of the `+"`paths`"+`, and otherwise finishes reading it before closing it. A new file created at the original path is read from its beginning.
+
+When a file shrinks below the offset that has been read, which happens when it's rotated with a copy followed by a truncation, it is read again from its beginning.
+
+### Checkpointing
+
+When `+"`checkpoint`"+` is set the offset of the latest acknowledged record of each file is stored within the [checkpoint resource](/docs/configuration/resources#checkpoints) under a key made of the prefix `+"`tail_`"+` followed by the identity of the file, allowing the input to resume from it after a restart. A fingerprint of the first bytes of the file, bounded by `+"`fingerprint_size`"+`, is stored along with the offset, and the checkpoint is discarded when the fingerprint no longer matches, which prevents a new file that reuses the inode of a deleted file from being resumed at the wrong offset.
+
+Offsets are only committed once all records preceding them have also been acknowledged, and therefore records may be delivered more than once after a restart.
+
+### Metadata
+
+This input adds the following metadata fields to each message:
+
+`+"```text"+`
+- path
+- tail_offset
+`+"```"+`
+
+The `+"`tail_offset`"+` field is the byte offset within the file of the beginning of the record.
+
+You can access these metadata fields using [function interpolation](/docs/configuration/interpolation#metadata).`).
+		Field(service.NewStringListField("paths").
+			Description("A list of paths of files to follow. Glob patterns are supported, including super globs (double star).").
+			Example([]string{"/var/log/app/*.log"}).
+			Example([]string{"/var/log/**/*.log", "/tmp/debug.log"})).
+		Field(service.NewStringField("codec").
+			Description("The way in which the bytes of a file are divided into records, which can be `lines`, `delim:x` for records divided by the custom delimiter x, or `multiline:x` for records of one or more lines as described by the [`multiline` codec](/docs/components/inputs/file#codec).").
+			Default("lines").
+			Example("lines").
+			Example("delim:\t").
+			Example(`multiline:timeout=1s,start=^\d{4}-\d{2}-\d{2}`)).
+		Field(service.NewIntField("max_buffer").
+			Description("The largest record size expected, records that grow beyond this size are emitted as a record of their own.").
+			Default(1000000).
+			Advanced()).
+		Field(service.NewDurationField("poll_interval").
+			Description("The period between checks for new data within files, and between expansions of the `paths` in order to find new files.").
+			Default("1s")).
+		Field(service.NewBoolField("start_from_beginning").
+			Description("Whether to read files that are found when the input first starts and have no checkpoint from their beginning. When `false` only data written after the input started is consumed from such files. Files that are created after the input has started are always read from their beginning.").
+			Default(true)).
+		Field(service.NewStringField("checkpoint").
+			Description("An optional [checkpoint resource](/docs/configuration/resources#checkpoints) to store the offset of the latest acknowledged record of each file within.").
+			Default("")).
+		Field(service.NewIntField("checkpoint_limit").
+			Description("The maximum number of records of a given file that can be processed in parallel before applying back pressure.").
+			Default(1024).
+			Advanced()).
+		Field(service.NewIntField("fingerprint_size").
+			Description("The maximum number of bytes from the beginning of a file used to fingerprint it.").
+			Default(1024).
+			Advanced()).
+		Example("Follow Application Logs", "Follow rotated log files where records span multiple lines, such as those containing stack traces, and resume from the last acknowledged record after restarts:", `
+input:
+  tail:
+    paths: [ /var/log/app/*.log ]
+    codec: 'multiline:timeout=1s,start=^\d{4}-\d{2}-\d{2}'
+    checkpoint: tail_offsets
+
+checkpoint_resources:
+  - label: tail_offsets
+    directory: /var/lib/benthos/tail
+`)
+}
+
+func init() {
+	err := service.RegisterInput(
+		"tail", tailInputConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.Input, error) {
+			t, err := newTailInputFromParsed(conf, mgr, mgr.Logger())
+			if err != nil {
+				return nil, err
+			}
+			return service.AutoRetryNacks(t), nil
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// checkpointProvider is the subset of service.Resources used for checkpoints.
+type checkpointProvider interface {
+	HasCheckpoint(name string) bool
+	AccessCheckpoint(ctx context.Context, name string, fn func(c service.CheckpointStore)) error
+}
+
+// The prefix of the keys that the positions of files are stored under within a
+// checkpoint resource.
+const tailCheckpointPrefix = "tail_"
+
+type tailPosition struct {
+	Path            string `json:"path"`
+	Offset          int64  `json:"offset"`
+	Fingerprint     string `json:"fingerprint"`
+	FingerprintSize int    `json:"fingerprint_size"`
+}
+
+type tailMessage struct {
+	msg   *service.Message
+	ackFn service.AckFunc
+}
+
+type tailInput struct {
+	paths              []string
+	delim              []byte
+	trimCR             bool
+	multiline          *codec.MultilineConfig
+	maxBuffer          int
+	pollInterval       time.Duration
+	startFromBeginning bool
+	store              service.CheckpointStore
+	checkpointLimit    int
+	fingerprintSize    int
+
+	log *service.Logger
+
+	msgChan chan tailMessage
+	shutSig *shutdown.Signaller
+
+	filesMut sync.Mutex
+	files    map[string]*tailedFile
+	running  bool
+}
+
+func newTailInputFromParsed(conf *service.ParsedConfig, mgr checkpointProvider, log *service.Logger) (*tailInput, error) {
+	t := &tailInput{
+		log:     log,
+		msgChan: make(chan tailMessage),
+		shutSig: shutdown.NewSignaller(),
+		files:   map[string]*tailedFile{},
+	}
+
+	var err error
+	if t.paths, err = conf.FieldStringList("paths"); err != nil {
+		return nil, err
+	}
+
+	codecStr, err := conf.FieldString("codec")
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case codecStr == "lines":
+		t.delim, t.trimCR = []byte("\n"), true
+	case strings.HasPrefix(codecStr, "delim:"):
+		if t.delim = []byte(strings.TrimPrefix(codecStr, "delim:")); len(t.delim) == 0 {
+			return nil, errors.New("delim codec requires a non-empty delimiter")
+		}
+	case strings.HasPrefix(codecStr, "multiline:"):
+		mConf, err := codec.ParseMultilineConfig(strings.TrimPrefix(codecStr, "multiline:"))
+		if err != nil {
+			return nil, err
+		}
+		t.delim, t.trimCR, t.multiline = []byte("\n"), true, &mConf
+	default:
+		return nil, fmt.Errorf("codec '%v' is not supported, expected lines, delim:x or multiline:x", codecStr)
+	}
+
+	if t.maxBuffer, err = conf.FieldInt("max_buffer"); err != nil {
+		return nil, err
+	}
+	if t.pollInterval, err = conf.FieldDuration("poll_interval"); err != nil {
+		return nil, err
+	}
+	if t.startFromBeginning, err = conf.FieldBool("start_from_beginning"); err != nil {
+		return nil, err
+	}
+	checkpointName, err := conf.FieldString("checkpoint")
+	if err != nil {
+		return nil, err
+	}
+	if checkpointName != "" {
+		if !mgr.HasCheckpoint(checkpointName) {
+			return nil, fmt.Errorf("checkpoint resource '%v' was not found", checkpointName)
+		}
+		if err = mgr.AccessCheckpoint(context.Background(), checkpointName, func(c service.CheckpointStore) {
+			t.store = c
+		}); err != nil {
+			return nil, err
+		}
+	}
+	if t.checkpointLimit, err = conf.FieldInt("checkpoint_limit"); err != nil {
+		return nil, err
+	}
+	if t.checkpointLimit < 1 {
+		return nil, fmt.Errorf("checkpoint_limit must be greater than zero, got %v", t.checkpointLimit)
+	}
+	if t.fingerprintSize, err = conf.FieldInt("fingerprint_size"); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (t *tailInput) loadPosition(ctx context.Context, id string) (*tailPosition, error) {
+	if t.store == nil {
+		return nil, nil
+	}
+
+	posBytes, err := t.store.Get(ctx, tailCheckpointPrefix+id)
+	if errors.Is(err, service.ErrKeyNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to obtain checkpoint: %w", err)
+	}
+
+	var pos tailPosition
+	if err := json.Unmarshal(posBytes, &pos); err != nil {
+		return nil, fmt.Errorf("failed to parse checkpoint: %w", err)
+	}
+	return &pos, nil
+}
+
+func (t *tailInput) storePosition(ctx context.Context, id string, pos tailPosition) error {
+	if t.store == nil {
+		return nil
+	}
+
+	posBytes, err := json.Marshal(pos)
+	if err != nil {
+		return err
+	}
+	if err := t.store.Set(ctx, tailCheckpointPrefix+id, posBytes); err != nil {
+		return fmt.Errorf("failed to store checkpoint: %w", err)
+	}
+	return nil
+}
+
+// fingerprintFile returns a hash of the first bytes of a file, up to a maximum
+// size, along with the number of bytes hashed.
+func fingerprintFile(f *os.File, size int) (string, int, error) {
+	buf := make([]byte, size)
+	n, err := f.ReadAt(buf, 0)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", 0, err
+	}
+	sum := sha256.Sum256(buf[:n])
+	return hex.EncodeToString(sum[:]), n, nil
+}
+
+//------------------------------------------------------------------------------
+
+func (t *tailInput) Connect(ctx context.Context) error {
+	if _, err := filepath.Globs(t.paths); err != nil {
+		return fmt.Errorf("failed to expand paths: %w", err)
+	}
+
+	t.filesMut.Lock()
+	running := t.running
+	t.running = true
+	t.filesMut.Unlock()
+	if running {
+		return nil
+	}
+
+	// The initial scan is performed before returning so that files read from
+	// their end are read from the point at which the input connected.
+	scanCtx, done := t.shutSig.CloseNowCtx(context.Background())
+	wg := &sync.WaitGroup{}
+	t.scan(scanCtx, wg, true)
+
+	go t.loop(scanCtx, done, wg)
+	return nil
+}
+
+func (t *tailInput) loop(ctx context.Context, done context.CancelFunc, wg *sync.WaitGroup) {
+	defer func() {
+		done()
+		wg.Wait()
+		t.shutSig.ShutdownComplete()
+	}()
+
+	ticker := time.NewTicker(t.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+		t.scan(ctx, wg, false)
+	}
+}
+
+// scan expands the paths in order to start following new files and to flag
+// followed files that are no longer found.
+func (t *tailInput) scan(ctx context.Context, wg *sync.WaitGroup, initial bool) {
+	paths, err := filepath.Globs(t.paths)
+	if err != nil {
+		t.log.Errorf("Failed to expand paths: %v", err)
+		return
+	}
+
+	found := map[string]struct{}{}
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			if !os.IsNotExist(err) {
+				t.log.Errorf("Failed to stat file %v: %v", path, err)
+			}
+			continue
+		}
+		if info.IsDir() {
+			continue
+		}
+
+		id := fileIdentity(path, info)
+		found[id] = struct{}{}
+
+		t.filesMut.Lock()
+		tf, exists := t.files[id]
+		t.filesMut.Unlock()
+		if exists {
+			tf.setPath(path)
+			continue
+		}
+
+		if tf, err = t.openFile(ctx, id, path, initial); err != nil {
+			t.log.Errorf("Failed to open file %v: %v", path, err)
+			continue
+		}
+
+		t.filesMut.Lock()
+		t.files[id] = tf
+		t.filesMut.Unlock()
+
+		wg.Add(1)
+		go func(tf *tailedFile) {
+			defer wg.Done()
+			t.follow(ctx, tf)
+		}(tf)
+	}
+
+	t.filesMut.Lock()
+	for id, tf := range t.files {
+		if _, exists := found[id]; !exists {
+			tf.setGone()
+		}
+	}
+	t.filesMut.Unlock()
+}
+
+func (t *tailInput) openFile(ctx context.Context, id, path string, initial bool) (*tailedFile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	tf := &tailedFile{
+		id:           id,
+		path:         path,
+		file:         f,
+		checkpointer: service.NewCheckpointer(int64(t.checkpointLimit)),
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+
+	pos, err := t.loadPosition(ctx, id)
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	if pos != nil && pos.Offset <= info.Size() {
+		fp, _, err := fingerprintFile(f, pos.FingerprintSize)
+		if err != nil {
+			_ = f.Close()
+			return nil, err
+		}
+		if fp == pos.Fingerprint {
+			tf.offset = pos.Offset
+		} else {
+			t.log.Infof("Discarding checkpoint of file %v as its fingerprint has changed", path)
+		}
+	} else if pos == nil && initial && !t.startFromBeginning {
+		tf.offset = info.Size()
+	}
+
+	if _, err := f.Seek(tf.offset, io.SeekStart); err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return tf, nil
+}
+
+//------------------------------------------------------------------------------
+
+type tailedFile struct {
+	id           string
+	file         *os.File
+	checkpointer *service.Checkpointer
+	commitMut    sync.Mutex
+
+	mut  sync.Mutex
+	path string
+	gone bool
+
+	// Only accessed by the goroutine following the file.
+	offset          int64
+	buf             []byte
+	fingerprint     string
+	fingerprintSize int
+
+	record      [][]byte
+	recordStart int64
+	recordEnd   int64
+	lastLineAt  time.Time
+}
+
+func (f *tailedFile) setPath(path string) {
+	f.mut.Lock()
+	f.path = path
+	f.gone = false
+	f.mut.Unlock()
+}
+
+func (f *tailedFile) setGone() {
+	f.mut.Lock()
+	f.gone = true
+	f.mut.Unlock()
+}
+
+func (f *tailedFile) state() (path string, gone bool) {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+	return f.path, f.gone
+}
+
+// follow reads a file as it grows until it's no longer found within the paths
+// and has been fully read.
+func (t *tailInput) follow(ctx context.Context, f *tailedFile) {
+	defer func() {
+		_ = f.file.Close()
+
+		t.filesMut.Lock()
+		if t.files[f.id] == f {
+			delete(t.files, f.id)
+		}
+		t.filesMut.Unlock()
+	}()
+
+	path, _ := f.state()
+	t.log.Debugf("Following file %v from offset %v", path, f.offset)
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	chunk := make([]byte, 32*1024)
+	idleWhileGone := 0
+	for {
+		n, err := f.file.Read(chunk)
+		if n > 0 {
+			idleWhileGone = 0
+			f.buf = append(f.buf, chunk[:n]...)
+			if !t.consumeBuffer(ctx, f) {
+				return
+			}
+			continue
+		}
+		if err != nil && !errors.Is(err, io.EOF) {
+			if ctx.Err() == nil {
+				t.log.Errorf("Failed to read file %v: %v", path, err)
+			}
+			return
+		}
+
+		// We've reached the end of the file for now.
+		path, gone := f.state()
+		if gone {
+			// Files that are no longer found are given one more interval for
+			// writers to finish with them before they are closed.
+			if idleWhileGone++; idleWhileGone > 1 {
+				if len(f.buf) > 0 {
+					if !t.emitLine(ctx, f, f.buf, f.offset+int64(len(f.buf))) {
+						return
+					}
+				}
+				t.flushRecord(ctx, f)
+				t.log.Debugf("Stopped following file %v", path)
+				return
+			}
+		}
+
+		if info, err := f.file.Stat(); err == nil && info.Size() < f.offset+int64(len(f.buf)) {
+			t.log.Infof("File %v has been truncated, reading from the beginning", path)
+			if _, err := f.file.Seek(0, io.SeekStart); err != nil {
+				t.log.Errorf("Failed to seek file %v: %v", path, err)
+				return
+			}
+			f.offset, f.buf, f.record = 0, nil, nil
+			f.fingerprint, f.fingerprintSize = "", 0
+			continue
+		}
+
+		wait := t.pollInterval
+		if t.multiline != nil && t.multiline.Timeout > 0 && len(f.record) > 0 {
+			remaining := t.multiline.Timeout - time.Since(f.lastLineAt)
+			if remaining <= 0 {
+				if !t.flushRecord(ctx, f) {
+					return
+				}
+				continue
+			}
+			if remaining < wait {
+				wait = remaining
+			}
+		}
+
+		timer.Reset(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// consumeBuffer emits the complete records within the read buffer, returning
+// false if the context was cancelled.
+func (t *tailInput) consumeBuffer(ctx context.Context, f *tailedFile) bool {
+	for {
+		i := bytes.Index(f.buf, t.delim)
+		if i < 0 {
+			if len(f.buf) >= t.maxBuffer {
+				return t.emitLine(ctx, f, f.buf, f.offset+int64(len(f.buf)))
+			}
+			return true
+		}
+		if !t.emitLine(ctx, f, f.buf[:i], f.offset+int64(i+len(t.delim))) {
+			return false
+		}
+	}
+}
+
+// emitLine consumes a line from the read buffer, which ends at the given
+// offset, and either emits it or adds it to the pending multiline record.
+func (t *tailInput) emitLine(ctx context.Context, f *tailedFile, line []byte, end int64) bool {
+	start := f.offset
+	data := line
+	if t.trimCR {
+		data = bytes.TrimSuffix(data, []byte("\r"))
+	}
+	data = append([]byte(nil), data...)
+
+	f.buf = f.buf[end-start:]
+	f.offset = end
+	if len(f.buf) == 0 {
+		f.buf = nil
+	}
+
+	if t.multiline == nil {
+		return t.emit(ctx, f, data, start, end)
+	}
+
+	if len(f.record) > 0 && !t.multiline.Continues(data) {
+		if !t.flushRecord(ctx, f) {
+			return false
+		}
+	}
+	if len(f.record) == 0 {
+		f.recordStart = start
+	}
+	f.record = append(f.record, data)
+	f.recordEnd = end
+	f.lastLineAt = time.Now()
+
+	if t.multiline.MaxLines > 0 && len(f.record) >= t.multiline.MaxLines {
+		return t.flushRecord(ctx, f)
+	}
+	return true
+}
+
+func (t *tailInput) flushRecord(ctx context.Context, f *tailedFile) bool {
+	if len(f.record) == 0 {
+		return true
+	}
+	data := bytes.Join(f.record, []byte("\n"))
+	f.record = nil
+	return t.emit(ctx, f, data, f.recordStart, f.recordEnd)
+}
+
+func (t *tailInput) emit(ctx context.Context, f *tailedFile, data []byte, start, end int64) bool {
+	if f.fingerprintSize < t.fingerprintSize {
+		var err error
+		if f.fingerprint, f.fingerprintSize, err = fingerprintFile(f.file, t.fingerprintSize); err != nil {
+			t.log.Errorf("Failed to fingerprint file: %v", err)
+		}
+	}
+
+	path, _ := f.state()
+	release, err := f.checkpointer.Track(ctx, tailPosition{
+		Path:            path,
+		Offset:          end,
+		Fingerprint:     f.fingerprint,
+		FingerprintSize: f.fingerprintSize,
+	}, 1)
+	if err != nil {
+		return false
+	}
+
+	msg := service.NewMessage(data)
+	msg.MetaSet("path", path)
+	msg.MetaSet("tail_offset", strconv.FormatInt(start, 10))
+
+	select {
+	case t.msgChan <- tailMessage{msg: msg, ackFn: t.ackFn(f, release)}:
+	case <-ctx.Done():
+		return false
+	}
+	return true
+}
+
+func (t *tailInput) ackFn(f *tailedFile, release func() interface{}) service.AckFunc {
+	return func(ctx context.Context, err error) error {
+		// The lock is held while storing the position so that an older
+		// position never overwrites a more recent one.
+		f.commitMut.Lock()
+		defer f.commitMut.Unlock()
+
+		highest := release()
+		if highest == nil {
+			return nil
+		}
+		return t.storePosition(ctx, f.id, highest.(tailPosition))
+	}
+}
+
+func (t *tailInput) Read(ctx context.Context) (*service.Message, service.AckFunc, error) {
+	select {
+	case m := <-t.msgChan:
+		return m.msg, m.ackFn, nil
+	case <-t.shutSig.CloseNowChan():
+		return nil, nil, service.ErrEndOfInput
+	case <-ctx.Done():
+		return nil, nil, ctx.Err()
+	}
+}
+
+func (t *tailInput) Close(ctx context.Context) error {
+	t.shutSig.CloseNow()
+
+	t.filesMut.Lock()
+	running := t.running
+	t.filesMut.Unlock()
+	if !running {
+		return nil
+	}
+
+	select {
+	case <-t.shutSig.HasClosedChan():
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return nil
+}
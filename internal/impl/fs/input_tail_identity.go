@@ -0,0 +1,20 @@
+//go:build !windows
+// +build !windows
+
+package fs
+
+import (
+	"os"
+	"strconv"
+	"syscall"
+)
+
+// fileIdentity returns a string that identifies a file regardless of its path,
+// which is the device and inode of the file.
+func fileIdentity(path string, info os.FileInfo) string {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return path
+	}
+	return strconv.FormatUint(uint64(stat.Dev), 10) + "_" + strconv.FormatUint(uint64(stat.Ino), 10)
+}
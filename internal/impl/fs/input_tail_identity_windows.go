@@ -0,0 +1,12 @@
+package fs
+
+import (
+	"os"
+)
+
+// fileIdentity returns a string that identifies a file. File IDs are not
+// exposed by os.FileInfo on Windows and therefore files are identified by their
+// path.
+func fileIdentity(path string, info os.FileInfo) string {
+	return path
+}
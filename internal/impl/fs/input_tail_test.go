@@ -0,0 +1,262 @@
+package fs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+type fakeCheckpointStore struct {
+	mut       sync.Mutex
+	positions map[string][]byte
+}
+
+func (f *fakeCheckpointStore) Get(ctx context.Context, key string) ([]byte, error) {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+	v, exists := f.positions[key]
+	if !exists {
+		return nil, service.ErrKeyNotFound
+	}
+	return v, nil
+}
+
+func (f *fakeCheckpointStore) Set(ctx context.Context, key string, value []byte) error {
+	f.mut.Lock()
+	f.positions[key] = value
+	f.mut.Unlock()
+	return nil
+}
+
+func (f *fakeCheckpointStore) Delete(ctx context.Context, key string) error {
+	f.mut.Lock()
+	delete(f.positions, key)
+	f.mut.Unlock()
+	return nil
+}
+
+type fakeCheckpointProvider map[string]service.CheckpointStore
+
+func (f fakeCheckpointProvider) HasCheckpoint(name string) bool {
+	_, exists := f[name]
+	return exists
+}
+
+func (f fakeCheckpointProvider) AccessCheckpoint(ctx context.Context, name string, fn func(c service.CheckpointStore)) error {
+	c, exists := f[name]
+	if !exists {
+		return service.ErrKeyNotFound
+	}
+	fn(c)
+	return nil
+}
+
+func newTestTailInput(t *testing.T, conf string) *tailInput {
+	t.Helper()
+	return newTestTailInputWithCheckpoints(t, conf, fakeCheckpointProvider{})
+}
+
+func newTestTailInputWithCheckpoints(t *testing.T, conf string, checkpoints fakeCheckpointProvider) *tailInput {
+	t.Helper()
+
+	parsed, err := tailInputConfig().ParseYAML(conf, nil)
+	require.NoError(t, err)
+
+	in, err := newTailInputFromParsed(parsed, checkpoints, service.MockResources().Logger())
+	require.NoError(t, err)
+
+	require.NoError(t, in.Connect(context.Background()))
+	t.Cleanup(func() {
+		_ = in.Close(context.Background())
+	})
+	return in
+}
+
+func readTailRecords(t *testing.T, in *tailInput, n int) []string {
+	t.Helper()
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*5)
+	defer done()
+
+	var records []string
+	for len(records) < n {
+		msg, ackFn, err := in.Read(ctx)
+		require.NoError(t, err)
+		require.NoError(t, ackFn(ctx, nil))
+
+		mBytes, err := msg.AsBytes()
+		require.NoError(t, err)
+		records = append(records, string(mBytes))
+	}
+	return records
+}
+
+func appendFile(t *testing.T, path, data string) {
+	t.Helper()
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	require.NoError(t, err)
+	_, err = f.WriteString(data)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+}
+
+func TestTailInputFollowAndRotate(t *testing.T) {
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "app.log")
+
+	appendFile(t, logPath, "first\nsecond\n")
+
+	in := newTestTailInput(t, fmt.Sprintf(`
+paths: [ "%v" ]
+poll_interval: 10ms
+`, filepath.Join(dir, "*.log")))
+
+	assert.Equal(t, []string{"first", "second"}, readTailRecords(t, in, 2))
+
+	appendFile(t, logPath, "thi")
+	appendFile(t, logPath, "rd\r\n")
+	assert.Equal(t, []string{"third"}, readTailRecords(t, in, 1))
+
+	// Rotate the file such that it's no longer matched by the paths, the
+	// remaining lines of the rotated file must still be consumed.
+	appendFile(t, logPath, "fourth\n")
+	require.NoError(t, os.Rename(logPath, logPath+".1"))
+	appendFile(t, logPath, "fifth\n")
+
+	records := readTailRecords(t, in, 2)
+	assert.ElementsMatch(t, []string{"fourth", "fifth"}, records)
+
+	appendFile(t, logPath, "sixth\n")
+	assert.Equal(t, []string{"sixth"}, readTailRecords(t, in, 1))
+}
+
+func TestTailInputTruncate(t *testing.T) {
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "app.log")
+
+	appendFile(t, logPath, "first\nsecond\n")
+
+	in := newTestTailInput(t, fmt.Sprintf(`
+paths: [ "%v" ]
+poll_interval: 10ms
+`, logPath))
+
+	assert.Equal(t, []string{"first", "second"}, readTailRecords(t, in, 2))
+
+	require.NoError(t, os.Truncate(logPath, 0))
+	appendFile(t, logPath, "third\n")
+	assert.Equal(t, []string{"third"}, readTailRecords(t, in, 1))
+}
+
+func TestTailInputCheckpoints(t *testing.T) {
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "app.log")
+
+	appendFile(t, logPath, "first\nsecond\n")
+
+	conf := fmt.Sprintf(`
+paths: [ "%v" ]
+poll_interval: 10ms
+checkpoint: positions
+`, logPath)
+
+	store := &fakeCheckpointStore{positions: map[string][]byte{}}
+	checkpoints := fakeCheckpointProvider{"positions": store}
+
+	in := newTestTailInputWithCheckpoints(t, conf, checkpoints)
+	assert.Equal(t, []string{"first", "second"}, readTailRecords(t, in, 2))
+	require.NoError(t, in.Close(context.Background()))
+
+	info, err := os.Stat(logPath)
+	require.NoError(t, err)
+	posBytes, err := store.Get(context.Background(), "tail_"+fileIdentity(logPath, info))
+	require.NoError(t, err)
+
+	var pos tailPosition
+	require.NoError(t, json.Unmarshal(posBytes, &pos))
+	assert.Equal(t, int64(13), pos.Offset)
+	assert.Equal(t, logPath, pos.Path)
+
+	appendFile(t, logPath, "third\n")
+
+	in = newTestTailInputWithCheckpoints(t, conf, checkpoints)
+	assert.Equal(t, []string{"third"}, readTailRecords(t, in, 1))
+	require.NoError(t, in.Close(context.Background()))
+
+	// Changing the beginning of the file invalidates the checkpoint.
+	require.NoError(t, os.WriteFile(logPath, []byte("FIRST\nSECOND\nTHIRD\nfourth\n"), 0o644))
+
+	in = newTestTailInputWithCheckpoints(t, conf, checkpoints)
+	assert.Equal(t, []string{"FIRST", "SECOND", "THIRD", "fourth"}, readTailRecords(t, in, 4))
+}
+
+func TestTailInputStartFromEnd(t *testing.T) {
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "app.log")
+
+	appendFile(t, logPath, "first\nsecond\n")
+
+	in := newTestTailInput(t, fmt.Sprintf(`
+paths: [ "%v" ]
+poll_interval: 10ms
+start_from_beginning: false
+`, filepath.Join(dir, "*.log")))
+
+	appendFile(t, logPath, "third\n")
+	assert.Equal(t, []string{"third"}, readTailRecords(t, in, 1))
+
+	// Files created after the input started are read from the beginning.
+	appendFile(t, filepath.Join(dir, "other.log"), "fourth\n")
+	assert.Equal(t, []string{"fourth"}, readTailRecords(t, in, 1))
+}
+
+func TestTailInputMultiline(t *testing.T) {
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "app.log")
+
+	appendFile(t, logPath, "2022-01-01 first\n  at foo\n  at bar\n2022-01-01 second\n")
+
+	in := newTestTailInput(t, fmt.Sprintf(`
+paths: [ "%v" ]
+poll_interval: 10ms
+codec: 'multiline:timeout=50ms,start=^\d{4}'
+`, logPath))
+
+	assert.Equal(t, []string{
+		"2022-01-01 first\n  at foo\n  at bar",
+		"2022-01-01 second",
+	}, readTailRecords(t, in, 2))
+}
+
+func TestTailInputConfigErrors(t *testing.T) {
+	parsed, err := tailInputConfig().ParseYAML(`
+paths: [ ./foo.log ]
+codec: csv
+`, nil)
+	require.NoError(t, err)
+
+	_, err = newTailInputFromParsed(parsed, fakeCheckpointProvider{}, service.MockResources().Logger())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "codec 'csv' is not supported")
+
+	parsed, err = tailInputConfig().ParseYAML(`
+paths: [ ./foo.log ]
+checkpoint: nope
+`, nil)
+	require.NoError(t, err)
+
+	_, err = newTailInputFromParsed(parsed, fakeCheckpointProvider{}, service.MockResources().Logger())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "checkpoint resource 'nope' was not found")
+}
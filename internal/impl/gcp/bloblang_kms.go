@@ -0,0 +1,149 @@
+package gcp
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"sync"
+	"time"
+
+	"google.golang.org/api/cloudkms/v1"
+
+	"github.com/benthosdev/benthos/v4/internal/aead"
+	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
+	"github.com/benthosdev/benthos/v4/public/bloblang"
+)
+
+// The period of time that data keys are reused for in order to reduce the
+// number of calls made to KMS.
+const kmsDataKeyTTL = time.Minute * 5
+
+// kmsEnvelopes holds the client and data keys of a method instance, which are
+// never shared between mappings.
+type kmsEnvelopes struct {
+	keyName  string
+	dataKeys *aead.KeyCache
+
+	serviceOnce sync.Once
+	service     *cloudkms.Service
+	serviceErr  error
+}
+
+func newKMSEnvelopes(keyName string) *kmsEnvelopes {
+	return &kmsEnvelopes{
+		keyName:  keyName,
+		dataKeys: aead.NewKeyCache(kmsDataKeyTTL),
+	}
+}
+
+// The service is created lazily so that mappings can be parsed without
+// credentials being available.
+func (k *kmsEnvelopes) cryptoKeys() (*cloudkms.ProjectsLocationsKeyRingsCryptoKeysService, error) {
+	k.serviceOnce.Do(func() {
+		k.service, k.serviceErr = cloudkms.NewService(context.Background())
+	})
+	if k.serviceErr != nil {
+		return nil, k.serviceErr
+	}
+	return k.service.Projects.Locations.KeyRings.CryptoKeys, nil
+}
+
+func (k *kmsEnvelopes) seal(b []byte) ([]byte, error) {
+	keys, err := k.cryptoKeys()
+	if err != nil {
+		return nil, err
+	}
+	key, err := k.dataKeys.ForEncrypt(k.keyName, func() (aead.DataKey, error) {
+		plaintext := make([]byte, 32)
+		if _, err := rand.Read(plaintext); err != nil {
+			return aead.DataKey{}, err
+		}
+		res, err := keys.Encrypt(k.keyName, &cloudkms.EncryptRequest{
+			Plaintext: base64.StdEncoding.EncodeToString(plaintext),
+		}).Do()
+		if err != nil {
+			return aead.DataKey{}, err
+		}
+		encrypted, err := base64.StdEncoding.DecodeString(res.Ciphertext)
+		if err != nil {
+			return aead.DataKey{}, err
+		}
+		return aead.DataKey{
+			Plaintext: plaintext,
+			Encrypted: encrypted,
+		}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return aead.SealEnvelope(key.Encrypted, key.Plaintext, b)
+}
+
+func (k *kmsEnvelopes) open(b []byte) ([]byte, error) {
+	keys, err := k.cryptoKeys()
+	if err != nil {
+		return nil, err
+	}
+	return aead.OpenEnvelope(b, func(encryptedKey []byte) ([]byte, error) {
+		return k.dataKeys.ForDecrypt(k.keyName, encryptedKey, func() ([]byte, error) {
+			res, err := keys.Decrypt(k.keyName, &cloudkms.DecryptRequest{
+				Ciphertext: base64.StdEncoding.EncodeToString(encryptedKey),
+			}).Do()
+			if err != nil {
+				return nil, err
+			}
+			return base64.StdEncoding.DecodeString(res.Plaintext)
+		})
+	})
+}
+
+func init() {
+	keyNameParam := bloblang.NewStringParam("key_name").
+		Description("The resource name of the KMS key, in the form `projects/*/locations/*/keyRings/*/cryptoKeys/*`.")
+
+	encryptSpec := bloblang.NewPluginSpec().
+		Category(string(query.MethodCategoryEncoding)).
+		Description(`Encrypts a string or byte array target with a randomly generated data key, which is itself encrypted by a [GCP Cloud KMS](https://cloud.google.com/kms) key, and returns a byte array envelope containing both the encrypted data key and the payload encrypted with AES-GCM, which can be decrypted with the method `+"`decrypt_gcp_kms_envelope`"+`.
+
+Generated data keys are reused by each call of the method within a mapping for five minutes in order to reduce the number of calls made to KMS. Credentials are obtained from the environment.`).
+		Param(keyNameParam).
+		Example("", `root.secret = this.secret.encrypt_gcp_kms_envelope("projects/foo/locations/global/keyRings/bar/cryptoKeys/baz").encode("base64")`)
+
+	if err := bloblang.RegisterMethodV2(
+		"encrypt_gcp_kms_envelope", encryptSpec,
+		func(args *bloblang.ParsedParams) (bloblang.Method, error) {
+			keyName, err := args.GetString("key_name")
+			if err != nil {
+				return nil, err
+			}
+			k := newKMSEnvelopes(keyName)
+			return bloblang.BytesMethod(func(b []byte) (interface{}, error) {
+				return k.seal(b)
+			}), nil
+		},
+	); err != nil {
+		panic(err)
+	}
+
+	decryptSpec := bloblang.NewPluginSpec().
+		Category(string(query.MethodCategoryEncoding)).
+		Description(`Decrypts an envelope created with the method `+"`encrypt_gcp_kms_envelope`"+` and returns the result as a byte array. The data key of the envelope is decrypted with [GCP Cloud KMS](https://cloud.google.com/kms), and decrypted data keys are reused by each call of the method within a mapping for five minutes in order to reduce the number of calls made to KMS. Credentials are obtained from the environment.`).
+		Param(keyNameParam).
+		Example("", `root.secret = this.secret.decode("base64").decrypt_gcp_kms_envelope("projects/foo/locations/global/keyRings/bar/cryptoKeys/baz").string()`)
+
+	if err := bloblang.RegisterMethodV2(
+		"decrypt_gcp_kms_envelope", decryptSpec,
+		func(args *bloblang.ParsedParams) (bloblang.Method, error) {
+			keyName, err := args.GetString("key_name")
+			if err != nil {
+				return nil, err
+			}
+			k := newKMSEnvelopes(keyName)
+			return bloblang.BytesMethod(func(b []byte) (interface{}, error) {
+				return k.open(b)
+			}), nil
+		},
+	); err != nil {
+		panic(err)
+	}
+}
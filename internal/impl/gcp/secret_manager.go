@@ -0,0 +1,52 @@
+package gcp
+
+import (
+	"context"
+	"encoding/base64"
+	"strings"
+	"sync"
+
+	"google.golang.org/api/secretmanager/v1"
+
+	"github.com/benthosdev/benthos/v4/internal/secrets"
+)
+
+var (
+	secretManagerServiceOnce sync.Once
+	secretManagerService     *secretmanager.Service
+	secretManagerServiceErr  error
+)
+
+// The path of a secret is its resource name in the form
+// `projects/*/secrets/*`, optionally followed by `/versions/*`, where the
+// latest version is accessed when the version is omitted. Credentials are
+// obtained from the environment.
+func getSecretManagerSecret(ctx context.Context, path string) (string, error) {
+	secretManagerServiceOnce.Do(func() {
+		secretManagerService, secretManagerServiceErr = secretmanager.NewService(context.Background())
+	})
+	if secretManagerServiceErr != nil {
+		return "", secretManagerServiceErr
+	}
+
+	if !strings.Contains(path, "/versions/") {
+		path += "/versions/latest"
+	}
+
+	res, err := secretManagerService.Projects.Secrets.Versions.Access(path).Context(ctx).Do()
+	if err != nil {
+		return "", err
+	}
+
+	data, err := base64.StdEncoding.DecodeString(res.Payload.Data)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func init() {
+	if err := secrets.RegisterProvider("gcp_secret_manager", getSecretManagerSecret); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,554 @@
+package influxdb
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
+	"github.com/benthosdev/benthos/v4/public/bloblang"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func lineProtocolOutputConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		// Stable(). TODO
+		Categories("Services").
+		Version("4.1.0").
+		Summary("Writes messages as time series points using the InfluxDB line protocol, which is also supported by QuestDB.").
+		Description(`
+Each message is converted into a single point, where the measurement is set with an interpolated string and the tags, fields and timestamp are set with [Bloblang mappings](/docs/guides/bloblang/about). Messages of a batch are written together, and messages that fail to be converted are excluded from the write and flagged individually as having failed.
+
+Points are written over TCP when the scheme of the `+"`url`"+` is `+"`tcp`"+`, and are otherwise posted to the URL over HTTP, in which case the `+"`precision`"+` is added to the URL as a query parameter.
+
+### Field Types
+
+Fields that are integers within the mapping, for example as a result of the `+"[`round` method](/docs/guides/bloblang/methods#round)"+`, are written as integers, all other numbers are written as floats. Strings and booleans are written as their respective types, and null values are omitted. Tag values are always written as strings.`).
+		Field(service.NewStringField("url").
+			Description("The URL to write points to, where the scheme determines whether points are written over TCP or HTTP.").
+			Example("tcp://localhost:9009").
+			Example("http://localhost:8086/api/v2/write?org=foo&bucket=bar")).
+		Field(service.NewInterpolatedStringField("measurement").
+			Description("The measurement of each point.").
+			Example("cpu").
+			Example(`${! meta("kafka_topic") }`)).
+		Field(service.NewBloblangField("tags_mapping").
+			Description("An optional mapping that results in an object of tags for each point.").
+			Example(`root.host = this.host
+root.region = meta("region")`).
+			Optional()).
+		Field(service.NewBloblangField("fields_mapping").
+			Description("A mapping that results in an object of fields for each point, at least one field must be set.").
+			Example(`root.usage = this.cpu.usage
+root.cores = this.cpu.cores.round()`)).
+		Field(service.NewBloblangField("timestamp_mapping").
+			Description("An optional mapping that results in the timestamp of each point, either as a timestamp, a string in RFC 3339 format or a number of seconds since the unix epoch. When omitted the timestamp is assigned by the server.").
+			Example(`root = this.created_at`).
+			Example(`root = now()`).
+			Optional()).
+		Field(service.NewStringEnumField("precision", "ns", "us", "ms", "s").
+			Description("The precision of timestamps.").
+			Default("ns").
+			Advanced()).
+		Field(service.NewBoolField("gzip").
+			Description("Whether to compress the body of HTTP requests with gzip.").
+			Default(false).
+			Advanced()).
+		Field(service.NewStringMapField("headers").
+			Description("A map of headers to add to HTTP requests, which can be used for authentication.").
+			Example(map[string]string{"Authorization": "Token foo"}).
+			Default(map[string]interface{}{}).
+			Advanced()).
+		Field(service.NewStringField("timeout").
+			Description("The maximum period to wait for a batch of points to be written.").
+			Default("5s").
+			Advanced()).
+		Field(service.NewTLSToggledField("tls")).
+		Field(service.NewIntField("max_in_flight").
+			Description("The maximum number of batches to have in flight at a given time. Increase this to improve throughput.").
+			Default(64)).
+		Field(service.NewBatchPolicyField("batching")).
+		Example("QuestDB",
+			`Here we write CPU readings to a QuestDB table over TCP:`,
+			`
+output:
+  line_protocol:
+    url: tcp://localhost:9009
+    measurement: cpu
+    tags_mapping: 'root.host = this.host'
+    fields_mapping: |
+      root.usage = this.usage
+      root.cores = this.cores.round()
+    timestamp_mapping: 'root = this.timestamp'
+    batching:
+      count: 1000
+      period: 1s
+`,
+		).
+		Example("InfluxDB 2.x",
+			`Here we write CPU readings to an InfluxDB bucket over HTTP with a precision of milliseconds:`,
+			`
+output:
+  line_protocol:
+    url: http://localhost:8086/api/v2/write?org=foo&bucket=bar
+    measurement: cpu
+    tags_mapping: 'root.host = this.host'
+    fields_mapping: 'root.usage = this.usage'
+    timestamp_mapping: 'root = this.timestamp'
+    precision: ms
+    gzip: true
+    headers:
+      Authorization: Token ${INFLUX_TOKEN}
+    batching:
+      count: 1000
+      period: 1s
+`,
+		)
+}
+
+func init() {
+	err := service.RegisterBatchOutput(
+		"line_protocol", lineProtocolOutputConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (out service.BatchOutput, batchPolicy service.BatchPolicy, maxInFlight int, err error) {
+			if batchPolicy, err = conf.FieldBatchPolicy("batching"); err != nil {
+				return
+			}
+			if maxInFlight, err = conf.FieldInt("max_in_flight"); err != nil {
+				return
+			}
+			out, err = newLineProtocolOutputFromConfig(conf, mgr.Logger())
+			return
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type lineProtocolOutput struct {
+	url       *url.URL
+	useTCP    bool
+	gzip      bool
+	headers   map[string]string
+	timeout   time.Duration
+	tlsConf   *tls.Config
+	precision time.Duration
+
+	measurement      *service.InterpolatedString
+	tagsMapping      *bloblang.Executor
+	fieldsMapping    *bloblang.Executor
+	timestampMapping *bloblang.Executor
+
+	log *service.Logger
+
+	connMut    sync.Mutex
+	conn       net.Conn
+	httpClient *http.Client
+}
+
+var lineProtocolPrecisions = map[string]time.Duration{
+	"ns": time.Nanosecond,
+	"us": time.Microsecond,
+	"ms": time.Millisecond,
+	"s":  time.Second,
+}
+
+func newLineProtocolOutputFromConfig(conf *service.ParsedConfig, log *service.Logger) (*lineProtocolOutput, error) {
+	l := &lineProtocolOutput{log: log}
+
+	urlStr, err := conf.FieldString("url")
+	if err != nil {
+		return nil, err
+	}
+	if l.url, err = url.Parse(urlStr); err != nil {
+		return nil, fmt.Errorf("failed to parse url: %w", err)
+	}
+	switch l.url.Scheme {
+	case "tcp":
+		l.useTCP = true
+	case "http", "https":
+	default:
+		return nil, fmt.Errorf("url scheme '%v' is not supported, expected tcp, http or https", l.url.Scheme)
+	}
+
+	if l.measurement, err = conf.FieldInterpolatedString("measurement"); err != nil {
+		return nil, err
+	}
+	if conf.Contains("tags_mapping") {
+		if l.tagsMapping, err = conf.FieldBloblang("tags_mapping"); err != nil {
+			return nil, err
+		}
+	}
+	if l.fieldsMapping, err = conf.FieldBloblang("fields_mapping"); err != nil {
+		return nil, err
+	}
+	if conf.Contains("timestamp_mapping") {
+		if l.timestampMapping, err = conf.FieldBloblang("timestamp_mapping"); err != nil {
+			return nil, err
+		}
+	}
+
+	precision, err := conf.FieldString("precision")
+	if err != nil {
+		return nil, err
+	}
+	l.precision = lineProtocolPrecisions[precision]
+	if !l.useTCP {
+		params := l.url.Query()
+		if params.Get("precision") == "" {
+			params.Set("precision", precision)
+			l.url.RawQuery = params.Encode()
+		}
+	}
+
+	if l.gzip, err = conf.FieldBool("gzip"); err != nil {
+		return nil, err
+	}
+	if l.headers, err = conf.FieldStringMap("headers"); err != nil {
+		return nil, err
+	}
+
+	timeoutStr, err := conf.FieldString("timeout")
+	if err != nil {
+		return nil, err
+	}
+	if l.timeout, err = time.ParseDuration(timeoutStr); err != nil {
+		return nil, fmt.Errorf("failed to parse timeout: %w", err)
+	}
+
+	tlsConf, tlsEnabled, err := conf.FieldTLSToggled("tls")
+	if err != nil {
+		return nil, err
+	}
+	if tlsEnabled {
+		l.tlsConf = tlsConf
+	}
+	return l, nil
+}
+
+//------------------------------------------------------------------------------
+
+func (l *lineProtocolOutput) Connect(ctx context.Context) error {
+	l.connMut.Lock()
+	defer l.connMut.Unlock()
+
+	if !l.useTCP {
+		if l.httpClient == nil {
+			l.httpClient = &http.Client{
+				Timeout: l.timeout,
+			}
+			if l.tlsConf != nil {
+				l.httpClient.Transport = &http.Transport{
+					TLSClientConfig: l.tlsConf,
+				}
+			}
+			l.log.Infof("Writing points to %v over HTTP", l.url.Host)
+		}
+		return nil
+	}
+
+	if l.conn != nil {
+		return nil
+	}
+
+	dialer := &net.Dialer{Timeout: l.timeout}
+
+	var err error
+	if l.tlsConf != nil {
+		l.conn, err = tls.DialWithDialer(dialer, "tcp", l.url.Host, l.tlsConf)
+	} else {
+		l.conn, err = dialer.DialContext(ctx, "tcp", l.url.Host)
+	}
+	if err != nil {
+		return err
+	}
+
+	l.log.Infof("Writing points to %v over TCP", l.url.Host)
+	return nil
+}
+
+func (l *lineProtocolOutput) WriteBatch(ctx context.Context, batch service.MessageBatch) error {
+	// Messages that fail to be converted are excluded from the write and
+	// reported individually, allowing the remaining points to be written.
+	var batchErr *service.BatchError
+	var buf bytes.Buffer
+	for i := range batch {
+		if err := l.writePoint(&buf, batch, i); err != nil {
+			if batchErr == nil {
+				batchErr = service.NewBatchError(batch, fmt.Errorf("message %v: %w", i, err))
+			}
+			batchErr.Failed(i, err)
+		}
+	}
+
+	if buf.Len() > 0 {
+		var err error
+		if l.useTCP {
+			err = l.writeTCP(buf.Bytes())
+		} else {
+			err = l.writeHTTP(ctx, buf.Bytes())
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if batchErr != nil {
+		return batchErr
+	}
+	return nil
+}
+
+func (l *lineProtocolOutput) writeTCP(data []byte) error {
+	l.connMut.Lock()
+	defer l.connMut.Unlock()
+
+	if l.conn == nil {
+		return service.ErrNotConnected
+	}
+
+	_ = l.conn.SetWriteDeadline(time.Now().Add(l.timeout))
+	if _, err := l.conn.Write(data); err != nil {
+		// The server closes the connection when points cannot be parsed, and
+		// therefore we reconnect after any failed write.
+		l.log.Errorf("Failed to write points: %v", err)
+		_ = l.conn.Close()
+		l.conn = nil
+		return service.ErrNotConnected
+	}
+	return nil
+}
+
+func (l *lineProtocolOutput) writeHTTP(ctx context.Context, data []byte) error {
+	l.connMut.Lock()
+	client := l.httpClient
+	l.connMut.Unlock()
+
+	if client == nil {
+		return service.ErrNotConnected
+	}
+
+	body := data
+	if l.gzip {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		if _, err := zw.Write(data); err != nil {
+			return err
+		}
+		if err := zw.Close(); err != nil {
+			return err
+		}
+		body = buf.Bytes()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, l.url.String(), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	if l.gzip {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+	for k, v := range l.headers {
+		req.Header.Set(k, v)
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		resBody, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return fmt.Errorf("write request returned status %v: %s", res.StatusCode, bytes.TrimSpace(resBody))
+	}
+	return nil
+}
+
+func (l *lineProtocolOutput) Close(ctx context.Context) error {
+	l.connMut.Lock()
+	defer l.connMut.Unlock()
+
+	if l.conn != nil {
+		_ = l.conn.Close()
+		l.conn = nil
+	}
+	if l.httpClient != nil {
+		l.httpClient.CloseIdleConnections()
+		l.httpClient = nil
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+func (l *lineProtocolOutput) queryObject(batch service.MessageBatch, i int, exec *bloblang.Executor) (map[string]interface{}, error) {
+	res, err := batch.BloblangQuery(i, exec)
+	if err != nil {
+		return nil, err
+	}
+	if res == nil {
+		return nil, nil
+	}
+	v, err := res.AsStructured()
+	if err != nil {
+		return nil, err
+	}
+	obj, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected mapping to result in an object, got: %T", v)
+	}
+	return obj, nil
+}
+
+// Writes a single point of the line protocol, terminated by a newline, to the
+// buffer. Nothing is written when the point cannot be created.
+func (l *lineProtocolOutput) writePoint(buf *bytes.Buffer, batch service.MessageBatch, i int) error {
+	measurement := batch.InterpolatedString(i, l.measurement)
+	if measurement == "" {
+		return errors.New("measurement is empty")
+	}
+
+	var tags map[string]interface{}
+	if l.tagsMapping != nil {
+		var err error
+		if tags, err = l.queryObject(batch, i, l.tagsMapping); err != nil {
+			return fmt.Errorf("tags mapping failed: %w", err)
+		}
+	}
+
+	fields, err := l.queryObject(batch, i, l.fieldsMapping)
+	if err != nil {
+		return fmt.Errorf("fields mapping failed: %w", err)
+	}
+
+	var line bytes.Buffer
+	line.WriteString(escapeLineProtocol(measurement, ", "))
+
+	for _, k := range sortedKeys(tags) {
+		if tags[k] == nil {
+			continue
+		}
+		v := escapeLineProtocol(query.IToString(tags[k]), ",= ")
+		if v == "" {
+			continue
+		}
+		line.WriteByte(',')
+		line.WriteString(escapeLineProtocol(k, ",= "))
+		line.WriteByte('=')
+		line.WriteString(v)
+	}
+
+	written := 0
+	for _, k := range sortedKeys(fields) {
+		if fields[k] == nil {
+			continue
+		}
+		v, err := formatLineProtocolField(fields[k])
+		if err != nil {
+			return fmt.Errorf("field '%v': %w", k, err)
+		}
+		if written == 0 {
+			line.WriteByte(' ')
+		} else {
+			line.WriteByte(',')
+		}
+		line.WriteString(escapeLineProtocol(k, ",= "))
+		line.WriteByte('=')
+		line.WriteString(v)
+		written++
+	}
+	if written == 0 {
+		return errors.New("fields mapping resulted in zero fields")
+	}
+
+	if l.timestampMapping != nil {
+		res, err := batch.BloblangQuery(i, l.timestampMapping)
+		if err != nil {
+			return fmt.Errorf("timestamp mapping failed: %w", err)
+		}
+		if res == nil {
+			return errors.New("timestamp mapping resulted in a deleted message")
+		}
+		v, err := res.AsStructured()
+		if err != nil {
+			return fmt.Errorf("timestamp mapping failed: %w", err)
+		}
+		ts, err := query.IGetTimestamp(v)
+		if err != nil {
+			return fmt.Errorf("timestamp mapping failed: %w", err)
+		}
+		line.WriteByte(' ')
+		line.WriteString(strconv.FormatInt(ts.UnixNano()/int64(l.precision), 10))
+	}
+
+	line.WriteByte('\n')
+	_, _ = line.WriteTo(buf)
+	return nil
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func escapeLineProtocol(s, chars string) string {
+	if !strings.ContainsAny(s, chars+"\n") {
+		return s
+	}
+	var b strings.Builder
+	for _, r := range s {
+		if r == '\n' {
+			b.WriteString(`\n`)
+			continue
+		}
+		if strings.ContainsRune(chars, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func formatLineProtocolField(v interface{}) (string, error) {
+	switch t := v.(type) {
+	case string:
+		return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(t) + `"`, nil
+	case bool:
+		return strconv.FormatBool(t), nil
+	case int:
+		return strconv.Itoa(t) + "i", nil
+	case int64:
+		return strconv.FormatInt(t, 10) + "i", nil
+	case uint64:
+		return strconv.FormatUint(t, 10) + "i", nil
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64), nil
+	case json.Number:
+		f, err := t.Float64()
+		if err != nil {
+			return "", err
+		}
+		return strconv.FormatFloat(f, 'f', -1, 64), nil
+	}
+	return "", fmt.Errorf("unsupported field type: %T", v)
+}
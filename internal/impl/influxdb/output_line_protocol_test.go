@@ -0,0 +1,153 @@
+package influxdb
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func testLineProtocolOutput(t *testing.T, conf string) *lineProtocolOutput {
+	t.Helper()
+
+	parsed, err := lineProtocolOutputConfig().ParseYAML(conf, nil)
+	require.NoError(t, err)
+
+	out, err := newLineProtocolOutputFromConfig(parsed, service.MockResources().Logger())
+	require.NoError(t, err)
+	return out
+}
+
+func TestLineProtocolTCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+
+	linesChan := make(chan string, 10)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		scanner := bufio.NewScanner(conn)
+		for scanner.Scan() {
+			linesChan <- scanner.Text()
+		}
+	}()
+
+	out := testLineProtocolOutput(t, `
+url: tcp://`+ln.Addr().String()+`
+measurement: ${! meta("measurement") }
+tags_mapping: |
+  root.host = this.host
+  root.region = "eu west"
+fields_mapping: |
+  root.usage = this.usage
+  root.cores = this.cores.round()
+  root.name = this.name
+  root.ok = true
+  root.missing = null
+timestamp_mapping: 'root = this.ts'
+precision: s
+`)
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*10)
+	defer done()
+
+	require.NoError(t, out.Connect(ctx))
+
+	first := service.NewMessage([]byte(`{"host":"a","usage":0.5,"cores":4,"name":"say \"hi\"","ts":1600000000}`))
+	first.MetaSet("measurement", "cpu,load")
+	noFields := service.NewMessage([]byte(`{"host":"b","usage":"nope"}`))
+	noFields.MetaSet("measurement", "cpu")
+
+	err = out.WriteBatch(ctx, service.MessageBatch{first, noFields})
+	require.Error(t, err)
+
+	berr, ok := err.(*service.BatchError)
+	require.True(t, ok, "%T", err)
+	var failed []int
+	berr.WalkMessages(func(i int, _ *service.Message, err error) bool {
+		if err != nil {
+			failed = append(failed, i)
+		}
+		return true
+	})
+	assert.Equal(t, []int{1}, failed)
+
+	select {
+	case line := <-linesChan:
+		assert.Equal(t, `cpu\,load,host=a,region=eu\ west cores=4i,name="say \"hi\"",ok=true,usage=0.5 1600000000`, line)
+	case <-ctx.Done():
+		t.Fatal("timed out")
+	}
+
+	require.NoError(t, out.Close(ctx))
+}
+
+func TestLineProtocolHTTP(t *testing.T) {
+	reqChan := make(chan *http.Request, 1)
+	bodyChan := make(chan string, 1)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		zr, err := gzip.NewReader(r.Body)
+		require.NoError(t, err)
+		body, err := io.ReadAll(zr)
+		require.NoError(t, err)
+
+		reqChan <- r
+		bodyChan <- string(body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	out := testLineProtocolOutput(t, `
+url: `+ts.URL+`/api/v2/write?bucket=foo
+measurement: cpu
+fields_mapping: 'root.usage = this.usage'
+gzip: true
+headers:
+  Authorization: Token bar
+`)
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*10)
+	defer done()
+
+	require.NoError(t, out.Connect(ctx))
+	require.NoError(t, out.WriteBatch(ctx, service.MessageBatch{
+		service.NewMessage([]byte(`{"usage":1}`)),
+		service.NewMessage([]byte(`{"usage":2.5}`)),
+	}))
+
+	req := <-reqChan
+	assert.Equal(t, "/api/v2/write", req.URL.Path)
+	assert.Equal(t, "foo", req.URL.Query().Get("bucket"))
+	assert.Equal(t, "ns", req.URL.Query().Get("precision"))
+	assert.Equal(t, "Token bar", req.Header.Get("Authorization"))
+	assert.Equal(t, "cpu usage=1\ncpu usage=2.5\n", <-bodyChan)
+
+	require.NoError(t, out.Close(ctx))
+}
+
+func TestLineProtocolURLScheme(t *testing.T) {
+	parsed, err := lineProtocolOutputConfig().ParseYAML(`
+url: udp://localhost:8089
+measurement: cpu
+fields_mapping: 'root.usage = this.usage'
+`, nil)
+	require.NoError(t, err)
+
+	_, err = newLineProtocolOutputFromConfig(parsed, service.MockResources().Logger())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "url scheme 'udp' is not supported")
+}
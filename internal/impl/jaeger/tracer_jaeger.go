@@ -16,6 +16,7 @@ import (
 	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
 
 	"github.com/benthosdev/benthos/v4/internal/bundle"
+	"github.com/benthosdev/benthos/v4/internal/component/metrics"
 	"github.com/benthosdev/benthos/v4/internal/component/tracer"
 	"github.com/benthosdev/benthos/v4/internal/docs"
 )
@@ -53,7 +54,7 @@ type Jaeger struct {
 }
 
 // NewJaeger creates and returns a new Jaeger object.
-func NewJaeger(config tracer.Config) (tracer.Type, error) {
+func NewJaeger(config tracer.Config, stats metrics.Type) (tracer.Type, error) {
 	j := &Jaeger{}
 
 	var sampler tracesdk.Sampler
@@ -112,8 +113,13 @@ func NewJaeger(config tracer.Config) (tracer.Type, error) {
 		batchOpts = append(batchOpts, tracesdk.WithBatchTimeout(flushInterval))
 	}
 
+	tailSampler, err := tracer.NewTailSampler(config.Sampling, stats, tracesdk.NewBatchSpanProcessor(exp, batchOpts...))
+	if err != nil {
+		return nil, err
+	}
+
 	tp := tracesdk.NewTracerProvider(
-		tracesdk.WithBatcher(exp, batchOpts...),
+		tracesdk.WithSpanProcessor(tailSampler),
 		tracesdk.WithResource(resource.NewWithAttributes(semconv.SchemaURL, attrs...)),
 		tracesdk.WithSampler(sampler),
 	)
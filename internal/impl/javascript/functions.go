@@ -0,0 +1,131 @@
+package javascript
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/dop251/goja"
+)
+
+func (r *vmRunner) msgAsString(call goja.FunctionCall) goja.Value {
+	b, err := r.currentMsg().AsBytes()
+	if err != nil {
+		r.throw(err)
+	}
+	return r.vm.ToValue(string(b))
+}
+
+func (r *vmRunner) msgAsStructured(call goja.FunctionCall) goja.Value {
+	b, err := r.currentMsg().AsBytes()
+	if err != nil {
+		r.throw(err)
+	}
+
+	// Parse the raw bytes rather than using AsStructured as numbers would
+	// otherwise be presented to scripts as json.Number strings.
+	var v interface{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		r.throw(fmt.Errorf("failed to parse message as JSON: %w", err))
+	}
+	return r.vm.ToValue(v)
+}
+
+func (r *vmRunner) msgSetString(call goja.FunctionCall) goja.Value {
+	if len(call.Arguments) != 1 {
+		r.throw(errors.New("expected one argument"))
+	}
+	r.currentMsg().SetBytes([]byte(call.Argument(0).String()))
+	return goja.Undefined()
+}
+
+func (r *vmRunner) msgSetStructured(call goja.FunctionCall) goja.Value {
+	if len(call.Arguments) != 1 {
+		r.throw(errors.New("expected one argument"))
+	}
+	r.currentMsg().SetStructured(call.Argument(0).Export())
+	return goja.Undefined()
+}
+
+func (r *vmRunner) msgExistsMeta(call goja.FunctionCall) goja.Value {
+	if len(call.Arguments) != 1 {
+		r.throw(errors.New("expected one argument"))
+	}
+	_, exists := r.currentMsg().MetaGet(call.Argument(0).String())
+	return r.vm.ToValue(exists)
+}
+
+func (r *vmRunner) msgGetMeta(call goja.FunctionCall) goja.Value {
+	if len(call.Arguments) != 1 {
+		r.throw(errors.New("expected one argument"))
+	}
+	v, exists := r.currentMsg().MetaGet(call.Argument(0).String())
+	if !exists {
+		return goja.Null()
+	}
+	return r.vm.ToValue(v)
+}
+
+func (r *vmRunner) msgSetMeta(call goja.FunctionCall) goja.Value {
+	if len(call.Arguments) != 2 {
+		r.throw(errors.New("expected two arguments"))
+	}
+	r.currentMsg().MetaSet(call.Argument(0).String(), call.Argument(1).String())
+	return goja.Undefined()
+}
+
+// fetch executes an HTTP request with the arguments url, headers, method and
+// payload, where all but the url are optional.
+func (r *vmRunner) fetch(call goja.FunctionCall) goja.Value {
+	if len(call.Arguments) < 1 || len(call.Arguments) > 4 {
+		r.throw(errors.New("expected between one and four arguments"))
+	}
+	r.currentMsg()
+
+	url := call.Argument(0).String()
+
+	var headers map[string]interface{}
+	if h := call.Argument(1); !goja.IsUndefined(h) && !goja.IsNull(h) {
+		var ok bool
+		if headers, ok = h.Export().(map[string]interface{}); !ok {
+			r.throw(errors.New("expected headers argument to be an object"))
+		}
+	}
+
+	method := "GET"
+	if m := call.Argument(2); !goja.IsUndefined(m) && !goja.IsNull(m) {
+		method = strings.ToUpper(m.String())
+	}
+
+	var body io.Reader
+	if p := call.Argument(3); !goja.IsUndefined(p) && !goja.IsNull(p) {
+		body = strings.NewReader(p.String())
+	}
+
+	req, err := http.NewRequestWithContext(r.ctx, method, url, body)
+	if err != nil {
+		r.throw(err)
+	}
+	for k, v := range headers {
+		req.Header.Set(k, fmt.Sprintf("%v", v))
+	}
+
+	res, err := r.client.Do(req)
+	if err != nil {
+		r.throw(err)
+	}
+	defer res.Body.Close()
+
+	resBytes, err := io.ReadAll(res.Body)
+	if err != nil {
+		r.throw(err)
+	}
+
+	return r.vm.ToValue(map[string]interface{}{
+		"status": res.StatusCode,
+		"body":   string(resBytes),
+	})
+}
@@ -0,0 +1,264 @@
+package javascript
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/dop251/goja"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func javascriptProcConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		Beta().
+		Categories("Mapping").
+		Version("4.1.0").
+		Summary("Executes a provided JavaScript code block or file for each message.").
+		Description(`
+The [execution engine](https://github.com/dop251/goja) behind this processor provides full ECMAScript 5.1 support (including regex and strict mode). Most of the ECMAScript 6 spec is implemented but this is a work in progress.
+
+Imports via `+"`require`"+` are not supported, and therefore scripts must be self contained.
+
+It is not possible to execute code asynchronously, and therefore functions such as `+"`setTimeout`"+` are not available. The last statement of a script does not need to return a value, instead the message being processed is accessed and modified with the functions described below.
+
+Each message is processed with a fresh runtime, and therefore state such as global variables is not shared between executions of a script.
+
+If you are simply mapping documents then consider using the `+"[`bloblang` processor](/docs/components/processors/bloblang)"+` instead, which is much more performant.
+
+### Functions
+
+The following functions are available within scripts under the global `+"`benthos`"+` object:
+
+- `+"`benthos.v0_msg_as_string()`"+` returns the contents of the message as a string.
+- `+"`benthos.v0_msg_as_structured()`"+` parses the contents of the message as JSON and returns the result.
+- `+"`benthos.v0_msg_set_string(value)`"+` sets the contents of the message to a string.
+- `+"`benthos.v0_msg_set_structured(value)`"+` sets the contents of the message to a structured value, which is serialised as JSON.
+- `+"`benthos.v0_msg_exists_meta(key)`"+` returns whether a metadata key exists on the message.
+- `+"`benthos.v0_msg_get_meta(key)`"+` returns the value of a metadata key, or `+"`null`"+` if it does not exist.
+- `+"`benthos.v0_msg_set_meta(key, value)`"+` sets the value of a metadata key.
+- `+"`benthos.v0_fetch(url, headers, method, payload)`"+` executes an HTTP request and returns an object with the fields `+"`status`"+` and `+"`body`"+`. The arguments `+"`headers`"+`, `+"`method`"+` and `+"`payload`"+` are optional, and the method defaults to `+"`GET`"+`.
+
+The function `+"`console.log`"+` is also available and writes its arguments to the Benthos logger at the `+"`INFO`"+` level.
+
+### Error Handling
+
+Exceptions thrown by a script, including those that are raised by the functions above, result in the message being flagged as having failed, allowing you to use [standard processor error handling patterns](/docs/configuration/error_handling).`).
+		Field(service.NewStringField("code").
+			Description("An inline JavaScript program to run. One of `code` or `file` must be defined.").
+			Optional()).
+		Field(service.NewStringField("file").
+			Description("A file containing a JavaScript program to run. One of `code` or `file` must be defined.").
+			Optional()).
+		Field(service.NewDurationField("fetch_timeout").
+			Description("The maximum period of time to wait for requests made with `benthos.v0_fetch` to complete.").
+			Default("30s").
+			Advanced()).
+		Example("Simple Mutation", `
+In this example we define a simple function that performs a basic mutation against a JSON message.`, `
+pipeline:
+  processors:
+    - javascript:
+        code: |
+          (() => {
+            let thing = benthos.v0_msg_as_structured();
+            thing.num_keys = Object.keys(thing).length;
+            delete thing["b"];
+            benthos.v0_msg_set_structured(thing);
+          })();
+`).
+		Example("Enrichment", `
+Scripts are able to make HTTP requests with `+"`benthos.v0_fetch`"+`, here we fetch a user profile and attach it to the message along with metadata describing the response.`, `
+pipeline:
+  processors:
+    - javascript:
+        code: |
+          (() => {
+            let doc = benthos.v0_msg_as_structured();
+            let res = benthos.v0_fetch("http://localhost:4196/users/" + doc.user_id);
+            benthos.v0_msg_set_meta("fetch_status", res.status.toString());
+            if (res.status === 200) {
+              doc.user = JSON.parse(res.body);
+            }
+            benthos.v0_msg_set_structured(doc);
+          })();
+`)
+}
+
+func init() {
+	err := service.RegisterProcessor(
+		"javascript", javascriptProcConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.Processor, error) {
+			return newJavascriptProcFromConfig(conf, mgr)
+		})
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type javascriptProc struct {
+	program *goja.Program
+	client  *http.Client
+	logger  *service.Logger
+}
+
+func newJavascriptProcFromConfig(conf *service.ParsedConfig, mgr *service.Resources) (*javascriptProc, error) {
+	var code, filename string
+	var err error
+	if conf.Contains("code") {
+		if code, err = conf.FieldString("code"); err != nil {
+			return nil, err
+		}
+	}
+	if conf.Contains("file") {
+		if filename, err = conf.FieldString("file"); err != nil {
+			return nil, err
+		}
+	}
+	if code != "" && filename != "" {
+		return nil, errors.New("only one of code or file may be specified")
+	}
+	if filename != "" {
+		codeBytes, err := os.ReadFile(filename)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read code file: %w", err)
+		}
+		code = string(codeBytes)
+	} else {
+		filename = "main.js"
+	}
+	if code == "" {
+		return nil, errors.New("either code or file must be specified")
+	}
+
+	fetchTimeout, err := conf.FieldDuration("fetch_timeout")
+	if err != nil {
+		return nil, err
+	}
+
+	program, err := goja.Compile(filename, code, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile javascript code: %w", err)
+	}
+
+	return &javascriptProc{
+		program: program,
+		client:  &http.Client{Timeout: fetchTimeout},
+		logger:  mgr.Logger(),
+	}, nil
+}
+
+func (j *javascriptProc) Process(ctx context.Context, msg *service.Message) (service.MessageBatch, error) {
+	// Runtimes are not reused across messages as top level declarations within
+	// the program would otherwise collide with those of previous runs.
+	r := newVMRunner(j.client, j.logger)
+	defer r.reset()
+
+	r.ctx, r.msg = ctx, msg
+
+	// Abort long running scripts when the context is cancelled.
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	defer func() {
+		close(done)
+		wg.Wait()
+	}()
+	go func() {
+		defer wg.Done()
+		select {
+		case <-ctx.Done():
+			r.vm.Interrupt(ctx.Err())
+		case <-done:
+		}
+	}()
+
+	if _, err := r.vm.RunProgram(j.program); err != nil {
+		return nil, err
+	}
+	return service.MessageBatch{r.msg}, nil
+}
+
+func (j *javascriptProc) Close(ctx context.Context) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+// vmRunner wraps a goja runtime, which is not safe for concurrent use, along
+// with the state of the message currently being processed by it.
+type vmRunner struct {
+	vm     *goja.Runtime
+	client *http.Client
+	logger *service.Logger
+
+	ctx context.Context
+	msg *service.Message
+}
+
+func newVMRunner(client *http.Client, logger *service.Logger) *vmRunner {
+	r := &vmRunner{
+		vm:     goja.New(),
+		client: client,
+		logger: logger,
+	}
+	r.registerFunctions()
+	return r
+}
+
+func (r *vmRunner) reset() {
+	r.ctx, r.msg = nil, nil
+}
+
+// throw aborts the execution of the current script with an exception.
+func (r *vmRunner) throw(err error) {
+	panic(r.vm.NewGoError(err))
+}
+
+func (r *vmRunner) registerFunctions() {
+	benthosObj := r.vm.NewObject()
+	for name, fn := range map[string]func(goja.FunctionCall) goja.Value{
+		"v0_msg_as_string":      r.msgAsString,
+		"v0_msg_as_structured":  r.msgAsStructured,
+		"v0_msg_set_string":     r.msgSetString,
+		"v0_msg_set_structured": r.msgSetStructured,
+		"v0_msg_exists_meta":    r.msgExistsMeta,
+		"v0_msg_get_meta":       r.msgGetMeta,
+		"v0_msg_set_meta":       r.msgSetMeta,
+		"v0_fetch":              r.fetch,
+	} {
+		_ = benthosObj.Set(name, fn)
+	}
+	_ = r.vm.Set("benthos", benthosObj)
+
+	consoleObj := r.vm.NewObject()
+	_ = consoleObj.Set("log", r.consoleLog)
+	_ = r.vm.Set("console", consoleObj)
+}
+
+func (r *vmRunner) consoleLog(call goja.FunctionCall) goja.Value {
+	args := make([]string, len(call.Arguments))
+	for i, a := range call.Arguments {
+		args[i] = a.String()
+	}
+	r.logger.Info(strings.Join(args, " "))
+	return goja.Undefined()
+}
+
+// currentMsg returns the message currently being processed, which would only
+// be missing if a script kept a reference to a function and called it
+// asynchronously.
+func (r *vmRunner) currentMsg() *service.Message {
+	if r.msg == nil {
+		r.throw(errors.New("no message is currently being processed"))
+	}
+	return r.msg
+}
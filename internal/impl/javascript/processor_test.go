@@ -0,0 +1,153 @@
+package javascript
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func newTestProc(t *testing.T, conf string) *javascriptProc {
+	t.Helper()
+
+	pConf, err := javascriptProcConfig().ParseYAML(conf, nil)
+	require.NoError(t, err)
+
+	proc, err := newJavascriptProcFromConfig(pConf, service.MockResources())
+	require.NoError(t, err)
+	return proc
+}
+
+func TestJavascriptMutation(t *testing.T) {
+	proc := newTestProc(t, `
+code: |
+  (() => {
+    let thing = benthos.v0_msg_as_structured();
+    thing.num_keys = Object.keys(thing).length;
+    delete thing["b"];
+    thing.source = benthos.v0_msg_get_meta("source");
+    thing.missing = benthos.v0_msg_get_meta("nope");
+    benthos.v0_msg_set_structured(thing);
+    benthos.v0_msg_set_meta("processed", "true");
+  })();
+`)
+
+	inMsg := service.NewMessage([]byte(`{"a":"a value","b":"b value","c":3}`))
+	inMsg.MetaSet("source", "foo")
+
+	batch, err := proc.Process(context.Background(), inMsg)
+	require.NoError(t, err)
+	require.Len(t, batch, 1)
+
+	b, err := batch[0].AsBytes()
+	require.NoError(t, err)
+	assert.Equal(t, `{"a":"a value","c":3,"missing":null,"num_keys":3,"source":"foo"}`, string(b))
+
+	v, exists := batch[0].MetaGet("processed")
+	assert.True(t, exists)
+	assert.Equal(t, "true", v)
+
+	require.NoError(t, proc.Close(context.Background()))
+}
+
+func TestJavascriptString(t *testing.T) {
+	proc := newTestProc(t, `
+code: |
+  benthos.v0_msg_set_string(benthos.v0_msg_as_string().toUpperCase());
+`)
+
+	for _, in := range []string{"hello world", "foo bar"} {
+		batch, err := proc.Process(context.Background(), service.NewMessage([]byte(in)))
+		require.NoError(t, err)
+		require.Len(t, batch, 1)
+
+		b, err := batch[0].AsBytes()
+		require.NoError(t, err)
+		assert.Equal(t, strings.ToUpper(in), string(b))
+	}
+}
+
+func TestJavascriptTopLevelDeclarations(t *testing.T) {
+	proc := newTestProc(t, `
+code: |
+  let doc = benthos.v0_msg_as_structured();
+  const suffix = "!";
+  class Counter {}
+  if (typeof seen !== "undefined") {
+    doc.seen = seen;
+  }
+  seen = doc.id;
+  doc.id = doc.id + suffix;
+  benthos.v0_msg_set_structured(doc);
+`)
+
+	for _, id := range []string{"foo", "bar"} {
+		batch, err := proc.Process(context.Background(), service.NewMessage([]byte(`{"id":"`+id+`"}`)))
+		require.NoError(t, err)
+		require.Len(t, batch, 1)
+
+		b, err := batch[0].AsBytes()
+		require.NoError(t, err)
+		assert.Equal(t, `{"id":"`+id+`!"}`, string(b))
+	}
+}
+
+func TestJavascriptError(t *testing.T) {
+	proc := newTestProc(t, `
+code: |
+  benthos.v0_msg_as_structured();
+`)
+
+	_, err := proc.Process(context.Background(), service.NewMessage([]byte(`not json`)))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to parse message as JSON")
+}
+
+func TestJavascriptFetch(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "POST", r.Method)
+		assert.Equal(t, "bar", r.Header.Get("X-Foo"))
+		_, _ = w.Write([]byte(`{"name":"blobby"}`))
+	}))
+	defer ts.Close()
+
+	proc := newTestProc(t, `
+code: |
+  (() => {
+    let doc = benthos.v0_msg_as_structured();
+    let res = benthos.v0_fetch(doc.url, { "X-Foo": "bar" }, "post", "hello");
+    doc.status = res.status;
+    doc.user = JSON.parse(res.body);
+    delete doc.url;
+    benthos.v0_msg_set_structured(doc);
+  })();
+`)
+
+	batch, err := proc.Process(context.Background(), service.NewMessage([]byte(`{"url":"`+ts.URL+`"}`)))
+	require.NoError(t, err)
+	require.Len(t, batch, 1)
+
+	b, err := batch[0].AsBytes()
+	require.NoError(t, err)
+	assert.Equal(t, `{"status":200,"user":{"name":"blobby"}}`, string(b))
+}
+
+func TestJavascriptConfigErrors(t *testing.T) {
+	for _, conf := range []string{
+		`{}`,
+		`{ code: "foo(", file: "bar.js" }`,
+		`{ code: "foo(" }`,
+	} {
+		pConf, err := javascriptProcConfig().ParseYAML(conf, nil)
+		require.NoError(t, err)
+
+		_, err = newJavascriptProcFromConfig(pConf, service.MockResources())
+		assert.Error(t, err, conf)
+	}
+}
@@ -282,6 +282,102 @@ input:
 
 }
 
+func TestIntegrationSaramaRedpandaChaos(t *testing.T) {
+	integration.CheckSkip(t)
+	t.Parallel()
+
+	pool, err := dockertest.NewPool("")
+	require.NoError(t, err)
+
+	pool.MaxWait = time.Second * 30
+
+	kafkaPort, err := integration.GetFreePort()
+	require.NoError(t, err)
+
+	kafkaPortStr := strconv.Itoa(kafkaPort)
+
+	// All traffic to the broker, including the advertised address, flows
+	// through the proxy so that latency and connection resets can be injected.
+	proxy, err := integration.NewChaosProxy("localhost:" + kafkaPortStr)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		assert.NoError(t, proxy.Close())
+	})
+
+	options := &dockertest.RunOptions{
+		Repository:   "docker.vectorized.io/vectorized/redpanda",
+		Tag:          "latest",
+		Hostname:     "redpanda",
+		ExposedPorts: []string{"9092"},
+		PortBindings: map[docker.Port][]docker.PortBinding{
+			"9092/tcp": {{HostIP: "", HostPort: kafkaPortStr}},
+		},
+		Cmd: []string{
+			"redpanda", "start", "--smp 1", "--overprovisioned",
+			"--kafka-addr 0.0.0.0:9092",
+			fmt.Sprintf("--advertise-kafka-addr localhost:%v", proxy.Port()),
+		},
+	}
+	resource, err := pool.RunWithOptions(options)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		assert.NoError(t, pool.Purge(resource))
+	})
+
+	_ = resource.Expire(900)
+	require.NoError(t, pool.Retry(func() error {
+		outConf := writer.NewKafkaConfig()
+		outConf.TargetVersion = "2.1.0"
+		outConf.Addresses = []string{"localhost:" + proxy.Port()}
+		outConf.Topic = "pls_ignore_just_testing_connection"
+		tmpOutput, serr := writer.NewKafka(outConf, mock.NewManager(), log.Noop(), metrics.Noop())
+		if serr != nil {
+			return serr
+		}
+		defer tmpOutput.CloseAsync()
+		if serr := tmpOutput.Connect(); serr != nil {
+			return serr
+		}
+		return tmpOutput.Write(message.QuickBatch([][]byte{
+			[]byte("foo message"),
+		}))
+	}))
+
+	template := `
+output:
+  kafka:
+    addresses: [ localhost:$PORT ]
+    topic: topic-$ID
+    max_in_flight: $MAX_IN_FLIGHT
+
+input:
+  kafka:
+    addresses: [ localhost:$PORT ]
+    topics: [ topic-$ID ]
+    consumer_group: "group$ID"
+    checkpoint_limit: 100
+    start_from_oldest: true
+`
+
+	suite := integration.StreamTests(
+		integration.StreamTestChaos(1000),
+	)
+
+	suite.Run(
+		t, template,
+		integration.StreamTestOptPreTest(func(t testing.TB, ctx context.Context, testID string, vars *integration.StreamTestConfigVars) {
+			require.NoError(t, createKafkaTopic("localhost:"+proxy.Port(), testID, 4))
+		}),
+		integration.StreamTestOptPort(proxy.Port()),
+		integration.StreamTestOptMaxInFlight(10),
+		integration.StreamTestOptChaos(
+			integration.ChaosLatency(proxy, time.Millisecond*200, time.Second*5),
+			integration.ChaosResetConnections(proxy),
+			integration.ChaosRestartContainer(pool, resource),
+		),
+	)
+}
+
 func createKafkaTopic(address, id string, partitions int32) error {
 	topicName := fmt.Sprintf("topic-%v", id)
 
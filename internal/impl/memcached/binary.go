@@ -0,0 +1,235 @@
+package memcached
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/bradfitz/gomemcache/memcache"
+)
+
+// memcachedClient describes the operations used by the cache, which are
+// implemented by both the text protocol client of gomemcache and the binary
+// protocol client below.
+type memcachedClient interface {
+	Get(key string) (*memcache.Item, error)
+	Set(item *memcache.Item) error
+	Add(item *memcache.Item) error
+	Delete(key string) error
+}
+
+const (
+	binaryMagicRequest  = 0x80
+	binaryMagicResponse = 0x81
+
+	binaryOpGet    = 0x00
+	binaryOpSet    = 0x01
+	binaryOpAdd    = 0x02
+	binaryOpDelete = 0x04
+
+	binaryStatusOK          = 0x0000
+	binaryStatusKeyNotFound = 0x0001
+	binaryStatusKeyExists   = 0x0002
+	binaryStatusNotStored   = 0x0005
+
+	binaryHeaderLen = 24
+	binaryMaxKeyLen = 250
+)
+
+// binaryClient is a minimal memcached client that speaks the binary protocol,
+// supporting the operations required by the cache. Errors match those of the
+// gomemcache text protocol client so that both can be used interchangeably.
+type binaryClient struct {
+	selector memcache.ServerSelector
+	timeout  time.Duration
+
+	mut      sync.Mutex
+	freeConn map[string][]net.Conn
+}
+
+func newBinaryClient(selector memcache.ServerSelector) *binaryClient {
+	return &binaryClient{
+		selector: selector,
+		timeout:  memcache.DefaultTimeout,
+		freeConn: map[string][]net.Conn{},
+	}
+}
+
+type binaryResponse struct {
+	status uint16
+	extras []byte
+	value  []byte
+}
+
+func (c *binaryClient) getConn(addr net.Addr) (net.Conn, error) {
+	c.mut.Lock()
+	if conns := c.freeConn[addr.String()]; len(conns) > 0 {
+		conn := conns[len(conns)-1]
+		c.freeConn[addr.String()] = conns[:len(conns)-1]
+		c.mut.Unlock()
+		return conn, nil
+	}
+	c.mut.Unlock()
+	return net.DialTimeout(addr.Network(), addr.String(), c.timeout)
+}
+
+func (c *binaryClient) putConn(addr net.Addr, conn net.Conn) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	if len(c.freeConn[addr.String()]) >= memcache.DefaultMaxIdleConns {
+		_ = conn.Close()
+		return
+	}
+	c.freeConn[addr.String()] = append(c.freeConn[addr.String()], conn)
+}
+
+// roundTrip writes a single request to the server responsible for a key and
+// reads its response. Connections are only returned to the pool once a full
+// response has been read.
+func (c *binaryClient) roundTrip(opcode byte, key string, extras, value []byte) (*binaryResponse, error) {
+	if len(key) == 0 || len(key) > binaryMaxKeyLen {
+		return nil, memcache.ErrMalformedKey
+	}
+	addr, err := c.selector.PickServer(key)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := c.getConn(addr)
+	if err != nil {
+		return nil, err
+	}
+	if err := conn.SetDeadline(time.Now().Add(c.timeout)); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	req := make([]byte, binaryHeaderLen, binaryHeaderLen+len(extras)+len(key)+len(value))
+	req[0] = binaryMagicRequest
+	req[1] = opcode
+	binary.BigEndian.PutUint16(req[2:4], uint16(len(key)))
+	req[4] = byte(len(extras))
+	binary.BigEndian.PutUint32(req[8:12], uint32(len(extras)+len(key)+len(value)))
+	req = append(req, extras...)
+	req = append(req, key...)
+	req = append(req, value...)
+
+	if _, err := conn.Write(req); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	res, err := readBinaryResponse(conn, opcode)
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	c.putConn(addr, conn)
+	return res, nil
+}
+
+func readBinaryResponse(r io.Reader, opcode byte) (*binaryResponse, error) {
+	header := make([]byte, binaryHeaderLen)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, err
+	}
+	if header[0] != binaryMagicResponse {
+		return nil, fmt.Errorf("unexpected response magic byte: %#x", header[0])
+	}
+	if header[1] != opcode {
+		return nil, fmt.Errorf("unexpected response opcode: %#x", header[1])
+	}
+	keyLen := int(binary.BigEndian.Uint16(header[2:4]))
+	extrasLen := int(header[4])
+	bodyLen := int(binary.BigEndian.Uint32(header[8:12]))
+	if bodyLen < keyLen+extrasLen {
+		return nil, fmt.Errorf("invalid response body length: %v", bodyLen)
+	}
+
+	body := make([]byte, bodyLen)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, err
+	}
+	return &binaryResponse{
+		status: binary.BigEndian.Uint16(header[6:8]),
+		extras: body[:extrasLen],
+		value:  body[extrasLen+keyLen:],
+	}, nil
+}
+
+func binaryStatusErr(res *binaryResponse) error {
+	return fmt.Errorf("%w: status %#x: %s", memcache.ErrServerError, res.status, res.value)
+}
+
+func (c *binaryClient) Get(key string) (*memcache.Item, error) {
+	res, err := c.roundTrip(binaryOpGet, key, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	switch res.status {
+	case binaryStatusOK:
+	case binaryStatusKeyNotFound:
+		return nil, memcache.ErrCacheMiss
+	default:
+		return nil, binaryStatusErr(res)
+	}
+	item := &memcache.Item{Key: key, Value: res.value}
+	if len(res.extras) >= 4 {
+		item.Flags = binary.BigEndian.Uint32(res.extras)
+	}
+	return item, nil
+}
+
+func (c *binaryClient) store(opcode byte, item *memcache.Item) error {
+	extras := make([]byte, 8)
+	binary.BigEndian.PutUint32(extras[0:4], item.Flags)
+	binary.BigEndian.PutUint32(extras[4:8], uint32(item.Expiration))
+
+	res, err := c.roundTrip(opcode, item.Key, extras, item.Value)
+	if err != nil {
+		return err
+	}
+	switch res.status {
+	case binaryStatusOK:
+		return nil
+	case binaryStatusKeyExists, binaryStatusNotStored:
+		return memcache.ErrNotStored
+	}
+	return binaryStatusErr(res)
+}
+
+func (c *binaryClient) Set(item *memcache.Item) error {
+	return c.store(binaryOpSet, item)
+}
+
+func (c *binaryClient) Add(item *memcache.Item) error {
+	return c.store(binaryOpAdd, item)
+}
+
+func (c *binaryClient) Delete(key string) error {
+	res, err := c.roundTrip(binaryOpDelete, key, nil, nil)
+	if err != nil {
+		return err
+	}
+	switch res.status {
+	case binaryStatusOK:
+		return nil
+	case binaryStatusKeyNotFound:
+		return memcache.ErrCacheMiss
+	}
+	return binaryStatusErr(res)
+}
+
+// Close closes all idle connections.
+func (c *binaryClient) Close() {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	for addr, conns := range c.freeConn {
+		for _, conn := range conns {
+			_ = conn.Close()
+		}
+		delete(c.freeConn, addr)
+	}
+}
@@ -0,0 +1,162 @@
+package memcached
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bradfitz/gomemcache/memcache"
+	"github.com/cenkalti/backoff/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+// fakeBinaryServer implements enough of the memcached binary protocol in order
+// to exercise the client.
+type fakeBinaryServer struct {
+	mut   sync.Mutex
+	items map[string][]byte
+	exps  map[string]uint32
+}
+
+func startFakeBinaryServer(t *testing.T) (*fakeBinaryServer, string) {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = ln.Close()
+	})
+
+	s := &fakeBinaryServer{
+		items: map[string][]byte{},
+		exps:  map[string]uint32{},
+	}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s, ln.Addr().String()
+}
+
+func (s *fakeBinaryServer) serve(conn net.Conn) {
+	defer conn.Close()
+	for {
+		header := make([]byte, binaryHeaderLen)
+		if _, err := io.ReadFull(conn, header); err != nil {
+			return
+		}
+		keyLen := int(binary.BigEndian.Uint16(header[2:4]))
+		extrasLen := int(header[4])
+		body := make([]byte, binary.BigEndian.Uint32(header[8:12]))
+		if _, err := io.ReadFull(conn, body); err != nil {
+			return
+		}
+		extras := body[:extrasLen]
+		key := string(body[extrasLen : extrasLen+keyLen])
+		value := body[extrasLen+keyLen:]
+
+		var resExtras, resValue []byte
+		status := uint16(binaryStatusOK)
+
+		s.mut.Lock()
+		switch header[1] {
+		case binaryOpGet:
+			if v, exists := s.items[key]; exists {
+				resExtras, resValue = make([]byte, 4), v
+			} else {
+				status = binaryStatusKeyNotFound
+			}
+		case binaryOpAdd:
+			if _, exists := s.items[key]; exists {
+				status = binaryStatusKeyExists
+				break
+			}
+			fallthrough
+		case binaryOpSet:
+			s.items[key] = append([]byte(nil), value...)
+			s.exps[key] = binary.BigEndian.Uint32(extras[4:8])
+		case binaryOpDelete:
+			if _, exists := s.items[key]; !exists {
+				status = binaryStatusKeyNotFound
+			}
+			delete(s.items, key)
+		}
+		s.mut.Unlock()
+
+		res := make([]byte, binaryHeaderLen)
+		res[0] = binaryMagicResponse
+		res[1] = header[1]
+		res[4] = byte(len(resExtras))
+		binary.BigEndian.PutUint16(res[6:8], status)
+		binary.BigEndian.PutUint32(res[8:12], uint32(len(resExtras)+len(resValue)))
+		res = append(res, resExtras...)
+		res = append(res, resValue...)
+		if _, err := conn.Write(res); err != nil {
+			return
+		}
+	}
+}
+
+func TestMemcachedBinaryProtocol(t *testing.T) {
+	server, addr := startFakeBinaryServer(t)
+
+	backOff := backoff.NewExponentialBackOff()
+	backOff.MaxElapsedTime = time.Millisecond * 100
+
+	c, err := newMemcachedCache([]string{addr}, "foo_", "consistent", "binary", time.Minute, backOff)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	_, err = c.Get(ctx, "a")
+	assert.Equal(t, service.ErrKeyNotFound, err)
+
+	require.NoError(t, c.Set(ctx, "a", []byte("hello"), nil))
+
+	v, err := c.Get(ctx, "a")
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(v))
+
+	assert.Equal(t, service.ErrKeyAlreadyExists, c.Add(ctx, "a", []byte("world"), nil))
+
+	ttl := time.Second * 5
+	require.NoError(t, c.Add(ctx, "b", []byte("world"), &ttl))
+
+	v, err = c.Get(ctx, "b")
+	require.NoError(t, err)
+	assert.Equal(t, "world", string(v))
+
+	require.NoError(t, c.Delete(ctx, "a"))
+	require.NoError(t, c.Delete(ctx, "a"))
+
+	_, err = c.Get(ctx, "a")
+	assert.Equal(t, service.ErrKeyNotFound, err)
+
+	server.mut.Lock()
+	assert.Equal(t, map[string][]byte{"foo_b": []byte("world")}, server.items)
+	assert.Equal(t, uint32(5), server.exps["foo_b"])
+	assert.Equal(t, uint32(60), server.exps["foo_a"])
+	server.mut.Unlock()
+
+	require.NoError(t, c.Close(ctx))
+}
+
+func TestMemcachedBinaryMalformedKey(t *testing.T) {
+	var ss memcache.ServerList
+	require.NoError(t, ss.SetServers("127.0.0.1:1"))
+
+	_, err := newBinaryClient(&ss).Get("")
+	assert.Equal(t, memcache.ErrMalformedKey, err)
+}
@@ -3,6 +3,7 @@ package memcached
 import (
 	"context"
 	"errors"
+	"fmt"
 	"strings"
 	"sync"
 	"time"
@@ -22,11 +23,31 @@ func memcachedConfig() *service.ConfigSpec {
 	spec := service.NewConfigSpec().
 		Stable().
 		Summary(`Connects to a cluster of memcached services, a prefix can be specified to allow multiple cache types to share a memcached cluster under different namespaces.`).
+		Description(`
+Keys are distributed across the servers listed in ` + "`addresses`" + ` according to the ` + "`hashing`" + ` strategy. The default strategy ` + "`modulo`" + ` remaps most keys whenever a server is added or removed, whereas the ` + "`consistent`" + ` strategy places servers on a hash ring so that only a fraction of keys are remapped.
+
+The ` + "`protocol`" + ` field selects whether the text or binary memcached protocol is used in order to communicate with the servers.
+
+TTLs are rounded up to the nearest second, and TTLs larger than 30 days are converted into absolute expiration times as required by the memcached protocol.`).
 		Field(service.NewStringListField("addresses").
 			Description("A list of addresses of memcached servers to use.")).
 		Field(service.NewStringField("prefix").
 			Description("An optional string to prefix item keys with in order to prevent collisions with similar services.").
 			Optional()).
+		Field(service.NewStringAnnotatedEnumField("hashing", map[string]string{
+			"modulo":     "Select a server by the hash of a key modulo the number of servers.",
+			"consistent": "Select a server from a consistent hash ring, which minimises the keys that are remapped when servers change.",
+		}).
+			Description("The strategy used to distribute keys across servers.").
+			Version("4.1.0").
+			Default("modulo")).
+		Field(service.NewStringAnnotatedEnumField("protocol", map[string]string{
+			"text":   "Use the text protocol.",
+			"binary": "Use the binary protocol.",
+		}).
+			Description("The protocol used to communicate with the servers.").
+			Version("4.1.0").
+			Default("text")).
 		Field(service.NewDurationField("default_ttl").
 			Description("A default TTL to set for items, calculated from the moment the item is cached.").
 			Default("300s")).
@@ -66,11 +87,21 @@ func newMemcachedFromConfig(conf *service.ParsedConfig) (*memcachedCache, error)
 		return nil, err
 	}
 
+	hashing, err := conf.FieldString("hashing")
+	if err != nil {
+		return nil, err
+	}
+
+	protocol, err := conf.FieldString("protocol")
+	if err != nil {
+		return nil, err
+	}
+
 	backOff, err := conf.FieldBackOff("retries")
 	if err != nil {
 		return nil, err
 	}
-	return newMemcachedCache(addresses, prefix, ttl, backOff)
+	return newMemcachedCache(addresses, prefix, hashing, protocol, ttl, backOff)
 }
 
 //------------------------------------------------------------------------------
@@ -79,13 +110,16 @@ type memcachedCache struct {
 	prefix     string
 	defaultTTL time.Duration
 
-	mc       *memcache.Client
+	mc       memcachedClient
+	closeFn  func()
 	boffPool sync.Pool
 }
 
 func newMemcachedCache(
 	inAddresses []string,
 	prefix string,
+	hashing string,
+	protocol string,
 	defaultTTL time.Duration,
 	backOff *backoff.ExponentialBackOff,
 ) (*memcachedCache, error) {
@@ -97,8 +131,39 @@ func newMemcachedCache(
 			}
 		}
 	}
+
+	var selector memcache.ServerSelector
+	switch hashing {
+	case "modulo", "":
+		var ss memcache.ServerList
+		if err := ss.SetServers(addresses...); err != nil {
+			return nil, err
+		}
+		selector = &ss
+	case "consistent":
+		var err error
+		if selector, err = newConsistentSelector(addresses...); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("hashing strategy not recognised: %v", hashing)
+	}
+
+	var mc memcachedClient
+	closeFn := func() {}
+	switch protocol {
+	case "text", "":
+		mc = memcache.NewFromSelector(selector)
+	case "binary":
+		bc := newBinaryClient(selector)
+		mc, closeFn = bc, bc.Close
+	default:
+		return nil, fmt.Errorf("protocol not recognised: %v", protocol)
+	}
+
 	return &memcachedCache{
-		mc:         memcache.New(addresses...),
+		mc:         mc,
+		closeFn:    closeFn,
 		prefix:     prefix,
 		defaultTTL: defaultTTL,
 		boffPool: sync.Pool{
@@ -111,17 +176,32 @@ func newMemcachedCache(
 	}, nil
 }
 
+// Memcached interprets expiration values larger than 30 days as an absolute
+// unix timestamp.
+const maxRelativeExpiration = 60 * 60 * 24 * 30
+
+func ttlToExpiration(ttl time.Duration, now time.Time) int32 {
+	if ttl <= 0 {
+		return 0
+	}
+	// Round up so that TTLs of less than a second do not result in items that
+	// never expire.
+	seconds := int64((ttl + time.Second - 1) / time.Second)
+	if seconds > maxRelativeExpiration {
+		return int32(now.Unix() + seconds)
+	}
+	return int32(seconds)
+}
+
 func (m *memcachedCache) getItemFor(key string, value []byte, ttl *time.Duration) *memcache.Item {
-	var expiration int32
+	t := m.defaultTTL
 	if ttl != nil {
-		expiration = int32(ttl.Milliseconds() / 1000)
-	} else {
-		expiration = int32(m.defaultTTL.Milliseconds() / 1000)
+		t = *ttl
 	}
 	return &memcache.Item{
 		Key:        m.prefix + key,
 		Value:      value,
-		Expiration: expiration,
+		Expiration: ttlToExpiration(t, time.Now()),
 	}
 }
 
@@ -218,7 +298,7 @@ func (m *memcachedCache) Delete(ctx context.Context, key string) error {
 
 	for {
 		err := m.mc.Delete(m.prefix + key)
-		if err == memcache.ErrCacheMiss {
+		if err == nil || errors.Is(err, memcache.ErrCacheMiss) {
 			return nil
 		}
 
@@ -235,5 +315,6 @@ func (m *memcachedCache) Delete(ctx context.Context, key string) error {
 }
 
 func (m *memcachedCache) Close(ctx context.Context) error {
+	m.closeFn()
 	return nil
 }
@@ -63,4 +63,19 @@ cache_resources:
 		t, template,
 		integration.CacheTestOptPort(resource.GetPort("11211/tcp")),
 	)
+
+	t.Run("binary", func(t *testing.T) {
+		binaryTemplate := `
+cache_resources:
+  - label: testcache
+    memcached:
+      addresses: [ localhost:$PORT ]
+      prefix: $ID
+      protocol: binary
+`
+		suite.Run(
+			t, binaryTemplate,
+			integration.CacheTestOptPort(resource.GetPort("11211/tcp")),
+		)
+	})
 }
@@ -0,0 +1,82 @@
+package memcached
+
+import (
+	"hash/crc32"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bradfitz/gomemcache/memcache"
+)
+
+// The number of points on the hash ring allocated for each server, a larger
+// number results in a more even distribution of keys.
+const consistentHashPointsPerServer = 160
+
+type ringPoint struct {
+	hash uint32
+	addr net.Addr
+}
+
+// consistentSelector is a memcache.ServerSelector that distributes keys across
+// servers with a consistent hash ring, meaning only a fraction of keys are
+// remapped when a server is added to or removed from the list.
+type consistentSelector struct {
+	addrs []net.Addr
+	ring  []ringPoint
+}
+
+func newConsistentSelector(servers ...string) (*consistentSelector, error) {
+	c := &consistentSelector{}
+	for _, server := range servers {
+		var addr net.Addr
+		var err error
+		if strings.Contains(server, "/") {
+			addr, err = net.ResolveUnixAddr("unix", server)
+		} else {
+			addr, err = net.ResolveTCPAddr("tcp", server)
+		}
+		if err != nil {
+			return nil, err
+		}
+		c.addrs = append(c.addrs, addr)
+
+		// Points are derived from the configured server string rather than the
+		// resolved address so that the ring remains stable across hosts that
+		// resolve names differently.
+		for i := 0; i < consistentHashPointsPerServer; i++ {
+			c.ring = append(c.ring, ringPoint{
+				hash: crc32.ChecksumIEEE([]byte(server + "-" + strconv.Itoa(i))),
+				addr: addr,
+			})
+		}
+	}
+	sort.Slice(c.ring, func(i, j int) bool {
+		return c.ring[i].hash < c.ring[j].hash
+	})
+	return c, nil
+}
+
+func (c *consistentSelector) PickServer(key string) (net.Addr, error) {
+	if len(c.ring) == 0 {
+		return nil, memcache.ErrNoServers
+	}
+	h := crc32.ChecksumIEEE([]byte(key))
+	i := sort.Search(len(c.ring), func(i int) bool {
+		return c.ring[i].hash >= h
+	})
+	if i == len(c.ring) {
+		i = 0
+	}
+	return c.ring[i].addr, nil
+}
+
+func (c *consistentSelector) Each(fn func(net.Addr) error) error {
+	for _, addr := range c.addrs {
+		if err := fn(addr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package memcached
+
+import (
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConsistentSelectorRemapping(t *testing.T) {
+	before, err := newConsistentSelector("127.0.0.1:11211", "127.0.0.1:11212", "127.0.0.1:11213")
+	require.NoError(t, err)
+
+	after, err := newConsistentSelector("127.0.0.1:11211", "127.0.0.1:11212", "127.0.0.1:11213", "127.0.0.1:11214")
+	require.NoError(t, err)
+
+	counts := map[string]int{}
+	remapped := 0
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprintf("key-%v", i)
+
+		a, err := before.PickServer(key)
+		require.NoError(t, err)
+
+		b, err := after.PickServer(key)
+		require.NoError(t, err)
+
+		counts[a.String()]++
+		if a.String() != b.String() {
+			remapped++
+			assert.Equal(t, "127.0.0.1:11214", b.String())
+		}
+	}
+
+	assert.Len(t, counts, 3)
+	assert.Less(t, remapped, 500)
+
+	var addrs []string
+	require.NoError(t, after.Each(func(a net.Addr) error {
+		addrs = append(addrs, a.String())
+		return nil
+	}))
+	assert.Len(t, addrs, 4)
+}
+
+func TestConsistentSelectorEmpty(t *testing.T) {
+	s, err := newConsistentSelector()
+	require.NoError(t, err)
+
+	_, err = s.PickServer("foo")
+	require.Error(t, err)
+}
+
+func TestTTLToExpiration(t *testing.T) {
+	now := time.Unix(1000, 0)
+	assert.Equal(t, int32(0), ttlToExpiration(0, now))
+	assert.Equal(t, int32(1), ttlToExpiration(time.Millisecond*100, now))
+	assert.Equal(t, int32(300), ttlToExpiration(time.Minute*5, now))
+	assert.Equal(t, int32(1000+60*60*24*31), ttlToExpiration(time.Hour*24*31, now))
+}
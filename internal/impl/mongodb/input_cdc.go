@@ -0,0 +1,330 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/benthosdev/benthos/v4/internal/checkpoint"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func mongoCDCConfigSpec() *service.ConfigSpec {
+	spec := service.NewConfigSpec().
+		// Stable(). TODO
+		Version("4.1.0").
+		Categories("Services").
+		Summary("Consumes the change stream of a MongoDB collection or database.").
+		Description(`
+Each message is a [change event](https://www.mongodb.com/docs/manual/reference/change-events/) encoded as relaxed extended JSON. Change streams are only available for replica sets and sharded clusters.
+
+### Checkpointing
+
+When ` + "`checkpoint_cache`" + ` is set the resume token of the latest acknowledged change event is stored in the cache resource under the key ` + "`checkpoint_key`" + `. When the input connects and a resume token is present in the cache the change stream is resumed after it, otherwise only changes made after the stream has been opened are consumed.
+
+When the change stream fails the input reconnects and resumes the stream after the latest change event it had read, regardless of whether a ` + "`checkpoint_cache`" + ` is set, and therefore no events are skipped whilst the input is running.
+
+Resume tokens are only committed once all events preceding them have also been acknowledged, and therefore events may be delivered more than once after a restart.
+
+### Metadata
+
+This input adds the following metadata fields to each message:
+
+` + "``` text" + `
+- mongodb_cdc_operation_type
+- mongodb_cdc_database
+- mongodb_cdc_collection
+` + "```" + `
+
+You can access these metadata fields using [function interpolation](/docs/configuration/interpolation#metadata).`)
+
+	for _, f := range clientFields() {
+		spec = spec.Field(f)
+	}
+
+	return spec.
+		Field(service.NewStringField("database").
+			Description("The name of the target MongoDB database.")).
+		Field(service.NewStringField("collection").
+			Description("The collection to watch. When empty the changes of all collections of the database are consumed.").
+			Default("")).
+		Field(service.NewBloblangField("pipeline").
+			Description("An optional Bloblang mapping that results in an array of [aggregation pipeline stages](https://www.mongodb.com/docs/manual/changeStreams/#modify-change-stream-output) used to filter and modify change events.").
+			Example(`root = [ { "$match": { "operationType": { "$in": [ "insert", "update" ] } } } ]`).
+			Optional()).
+		Field(service.NewStringEnumField("full_document", string(options.Default), string(options.UpdateLookup)).
+			Description("Determines whether update events include the current version of the full document, in which case `updateLookup` should be used.").
+			Default(string(options.Default)).
+			Advanced()).
+		Field(service.NewStringField("checkpoint_cache").
+			Description("An optional [cache resource](/docs/components/caches/about) used to store the resume token of the latest acknowledged change event.").
+			Default("")).
+		Field(service.NewStringField("checkpoint_key").
+			Description("The key under which the resume token is stored within the `checkpoint_cache`.").
+			Default("mongodb_cdc_resume_token").
+			Advanced())
+}
+
+func init() {
+	err := service.RegisterInput(
+		"mongodb_cdc", mongoCDCConfigSpec(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.Input, error) {
+			return newMongoCDCInput(conf, mgr)
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+type mongoCDCInput struct {
+	client        *mongo.Client
+	database      string
+	collection    string
+	pipeline      []interface{}
+	fullDocument  options.FullDocument
+	cacheName     string
+	cacheKey      string
+	checkpointer  *checkpoint.Type
+	checkpointMut sync.Mutex
+
+	mgr *service.Resources
+	log *service.Logger
+
+	streamMut sync.Mutex
+	connected bool
+	stream    *mongo.ChangeStream
+
+	// The resume token of a change stream that has failed, which the stream
+	// is resumed after when reconnecting.
+	resumeToken bson.Raw
+}
+
+func newMongoCDCInput(conf *service.ParsedConfig, mgr *service.Resources) (service.Input, error) {
+	m := &mongoCDCInput{
+		mgr:          mgr,
+		log:          mgr.Logger(),
+		checkpointer: checkpoint.New(),
+	}
+
+	var err error
+	if m.client, err = getClient(conf); err != nil {
+		return nil, err
+	}
+	if m.database, err = conf.FieldString("database"); err != nil {
+		return nil, err
+	}
+	if m.collection, err = conf.FieldString("collection"); err != nil {
+		return nil, err
+	}
+
+	m.pipeline = []interface{}{}
+	if conf.Contains("pipeline") {
+		pipelineExec, err := conf.FieldBloblang("pipeline")
+		if err != nil {
+			return nil, err
+		}
+		res, err := pipelineExec.Query(nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to execute pipeline mapping: %w", err)
+		}
+		var ok bool
+		if m.pipeline, ok = res.([]interface{}); !ok {
+			return nil, fmt.Errorf("expected pipeline mapping to result in an array, got: %T", res)
+		}
+	}
+
+	fullDocument, err := conf.FieldString("full_document")
+	if err != nil {
+		return nil, err
+	}
+	m.fullDocument = options.FullDocument(fullDocument)
+
+	if m.cacheName, err = conf.FieldString("checkpoint_cache"); err != nil {
+		return nil, err
+	}
+	if m.cacheName != "" && !mgr.HasCache(m.cacheName) {
+		return nil, fmt.Errorf("cache resource '%v' was not found", m.cacheName)
+	}
+	if m.cacheKey, err = conf.FieldString("checkpoint_key"); err != nil {
+		return nil, err
+	}
+	return service.AutoRetryNacks(m), nil
+}
+
+func (m *mongoCDCInput) loadResumeToken(ctx context.Context) (bson.Raw, error) {
+	if m.cacheName == "" {
+		return nil, nil
+	}
+
+	var token []byte
+	var cerr error
+	if err := m.mgr.AccessCache(ctx, m.cacheName, func(c service.Cache) {
+		token, cerr = c.Get(ctx, m.cacheKey)
+	}); err != nil {
+		return nil, err
+	}
+	if errors.Is(cerr, service.ErrKeyNotFound) {
+		return nil, nil
+	}
+	if cerr != nil {
+		return nil, fmt.Errorf("failed to obtain resume token: %w", cerr)
+	}
+	return bson.Raw(token), nil
+}
+
+func (m *mongoCDCInput) storeResumeToken(ctx context.Context, token bson.Raw) error {
+	if m.cacheName == "" {
+		return nil
+	}
+
+	var cerr error
+	if err := m.mgr.AccessCache(ctx, m.cacheName, func(c service.Cache) {
+		cerr = c.Set(ctx, m.cacheKey, token, nil)
+	}); err != nil {
+		return err
+	}
+	if cerr != nil {
+		return fmt.Errorf("failed to store resume token: %w", cerr)
+	}
+	return nil
+}
+
+// resumeAfterToken returns the resume token that a change stream should be
+// opened after, which is the token of a previously failed stream when there is
+// one and otherwise the token stored within the checkpoint cache. Must be
+// called whilst holding streamMut.
+func (m *mongoCDCInput) resumeAfterToken(ctx context.Context) (bson.Raw, error) {
+	if m.resumeToken != nil {
+		return m.resumeToken, nil
+	}
+	return m.loadResumeToken(ctx)
+}
+
+func (m *mongoCDCInput) Connect(ctx context.Context) error {
+	m.streamMut.Lock()
+	defer m.streamMut.Unlock()
+	if m.stream != nil {
+		return nil
+	}
+
+	if !m.connected {
+		if err := m.client.Connect(ctx); err != nil {
+			return fmt.Errorf("failed to connect: %w", err)
+		}
+		m.connected = true
+	}
+
+	opts := options.ChangeStream().SetFullDocument(m.fullDocument)
+
+	token, err := m.resumeAfterToken(ctx)
+	if err != nil {
+		return err
+	}
+	if token != nil {
+		opts.SetResumeAfter(token)
+	}
+
+	db := m.client.Database(m.database)
+	if m.collection != "" {
+		m.stream, err = db.Collection(m.collection).Watch(ctx, m.pipeline, opts)
+	} else {
+		m.stream, err = db.Watch(ctx, m.pipeline, opts)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open change stream: %w", err)
+	}
+
+	m.log.Infof("Receiving MongoDB change events from database '%v'", m.database)
+	return nil
+}
+
+func (m *mongoCDCInput) Read(ctx context.Context) (*service.Message, service.AckFunc, error) {
+	m.streamMut.Lock()
+	stream := m.stream
+	m.streamMut.Unlock()
+	if stream == nil {
+		return nil, nil, service.ErrNotConnected
+	}
+
+	if !stream.Next(ctx) {
+		if ctx.Err() != nil {
+			return nil, nil, ctx.Err()
+		}
+		err := stream.Err()
+		if err == nil {
+			err = errors.New("change stream closed")
+		}
+		m.log.Errorf("Change stream error: %v", err)
+
+		m.streamMut.Lock()
+		if m.stream == stream {
+			// The token reflects the latest event read from the stream, and
+			// events before it are either acknowledged or still in flight.
+			if token := stream.ResumeToken(); token != nil {
+				m.resumeToken = make(bson.Raw, len(token))
+				copy(m.resumeToken, token)
+			}
+			_ = stream.Close(context.Background())
+			m.stream = nil
+		}
+		m.streamMut.Unlock()
+		return nil, nil, service.ErrNotConnected
+	}
+
+	eventBytes, err := bson.MarshalExtJSON(stream.Current, false, false)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to encode change event: %w", err)
+	}
+
+	msg := service.NewMessage(eventBytes)
+	if opType, ok := stream.Current.Lookup("operationType").StringValueOK(); ok {
+		msg.MetaSet("mongodb_cdc_operation_type", opType)
+	}
+	if ns, ok := stream.Current.Lookup("ns").DocumentOK(); ok {
+		if db, ok := ns.Lookup("db").StringValueOK(); ok {
+			msg.MetaSet("mongodb_cdc_database", db)
+		}
+		if coll, ok := ns.Lookup("coll").StringValueOK(); ok {
+			msg.MetaSet("mongodb_cdc_collection", coll)
+		}
+	}
+
+	token := make(bson.Raw, len(stream.ResumeToken()))
+	copy(token, stream.ResumeToken())
+
+	m.checkpointMut.Lock()
+	release := m.checkpointer.Track(token, 1)
+	m.checkpointMut.Unlock()
+
+	return msg, func(ctx context.Context, err error) error {
+		// The lock is held while storing the token so that an older token
+		// never overwrites a more recent one.
+		m.checkpointMut.Lock()
+		defer m.checkpointMut.Unlock()
+
+		highest := release()
+		if highest == nil {
+			return nil
+		}
+		return m.storeResumeToken(ctx, highest.(bson.Raw))
+	}, nil
+}
+
+func (m *mongoCDCInput) Close(ctx context.Context) error {
+	m.streamMut.Lock()
+	defer m.streamMut.Unlock()
+	if m.stream != nil {
+		_ = m.stream.Close(ctx)
+		m.stream = nil
+	}
+	if m.connected {
+		m.connected = false
+		return m.client.Disconnect(ctx)
+	}
+	return nil
+}
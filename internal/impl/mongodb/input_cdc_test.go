@@ -0,0 +1,82 @@
+package mongodb
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func TestMongoCDCInputConfig(t *testing.T) {
+	conf := `
+url: "mongodb://localhost:27017"
+database: "foo"
+collection: "bar"
+full_document: updateLookup
+pipeline: |
+  root = [ { "$match": { "operationType": "insert" } } ]
+`
+
+	parsed, err := mongoCDCConfigSpec().ParseYAML(conf, service.NewEnvironment())
+	require.NoError(t, err)
+
+	in, err := newMongoCDCInput(parsed, service.MockResources())
+	require.NoError(t, err)
+	require.NoError(t, in.Close(context.Background()))
+}
+
+func TestMongoCDCInputConfigErrors(t *testing.T) {
+	tests := map[string]struct {
+		conf        string
+		errContains string
+	}{
+		"pipeline not an array": {
+			conf: `
+url: "mongodb://localhost:27017"
+database: "foo"
+pipeline: 'root = { "$match": {} }'
+`,
+			errContains: "expected pipeline mapping to result in an array",
+		},
+		"missing cache": {
+			conf: `
+url: "mongodb://localhost:27017"
+database: "foo"
+checkpoint_cache: nope
+`,
+			errContains: "cache resource 'nope' was not found",
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			parsed, err := mongoCDCConfigSpec().ParseYAML(test.conf, service.NewEnvironment())
+			require.NoError(t, err)
+
+			_, err = newMongoCDCInput(parsed, service.MockResources())
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), test.errContains)
+		})
+	}
+}
+
+func TestMongoCDCInputResumeAfterToken(t *testing.T) {
+	m := &mongoCDCInput{mgr: service.MockResources()}
+
+	token, err := m.resumeAfterToken(context.Background())
+	require.NoError(t, err)
+	assert.Nil(t, token)
+
+	failedToken, err := bson.Marshal(bson.M{"_data": "foo"})
+	require.NoError(t, err)
+
+	m.resumeToken = failedToken
+	token, err = m.resumeAfterToken(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, bson.Raw(failedToken), token)
+}
@@ -72,6 +72,7 @@ generate:
     mapping: root.source = "foo"
     interval: 100ms
     count: 0
+    checkpoint: ""
 `, res.Body.String())
 
 	i.CloseAsync()
@@ -0,0 +1,750 @@
+package net
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
+	"github.com/benthosdev/benthos/v4/public/bloblang"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func httpPollInputConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		// Stable(). TODO
+		Version("4.1.0").
+		Categories("Network").
+		Summary("Periodically polls an HTTP endpoint, following pagination and emitting each record of the responses as a message.").
+		Description(`
+Each poll performs a request to the `+"`url`"+`, the response of which is converted into records with the `+"`records_mapping`"+`. When the mapping results in an array each element becomes a message, otherwise the result is a single message. When the `+"`records_mapping`"+` is empty the body of each response is emitted as a single message.
+
+Responses with a status code outside of the 2XX range are treated as errors and the request is attempted again after a back off.
+
+### Pagination
+
+When a `+"`pagination.next_mapping`"+` is set it is executed against each response in order to determine whether another page should be requested as part of the same poll. The mapping must result in an object, each key of which is added as metadata to the message referenced when interpolating the `+"`url` and `headers`"+` of the next request. The mapping is able to access the metadata of the previous request, which allows offsets to be incremented, as well as response headers listed in `+"`extract_headers`"+`. Pagination ends once the mapping results in `+"`deleted()`"+`, after which the next poll starts from the first page again.
+
+### Deduplication
+
+When `+"`dedupe.cache`"+` is set records are only emitted when their `+"`dedupe.key`"+` is not already present in the cache, and keys are added to the cache once their message has been acknowledged. This prevents records from being emitted again by later polls of an endpoint that returns a sliding window of records.
+
+### Checkpointing
+
+When `+"`checkpoint`"+` is set the metadata of the request for the next page of a poll is stored within the [checkpoint resource](/docs/configuration/resources#checkpoints) under the key `+"`http_poll_position`"+`, once the records of all prior pages have been acknowledged. When the input is restarted during a poll it resumes pagination from the stored page rather than the first page. A checkpoint resource must therefore not be shared by multiple `+"`http_poll`"+` inputs.
+
+### Metadata
+
+Each message inherits the metadata of the response that contained it, including the field `+"`http_status_code`"+` and any headers listed in `+"`extract_headers`"+`.`).
+		Field(service.NewInterpolatedStringField("url").
+			Description("The URL to connect to.")).
+		Field(service.NewStringField("verb").
+			Description("A verb to connect with.").
+			Default("GET").
+			Example("POST").
+			Example("GET").
+			Example("DELETE")).
+		Field(service.NewStringMapField("headers").
+			Description("A map of headers to add to each request, the values of which support [interpolation functions](/docs/configuration/interpolation#bloblang-queries).").
+			Default(map[string]interface{}{}).
+			Example(map[string]interface{}{
+				"Content-Type":  "application/json",
+				"Authorization": `Bearer ${! env("API_TOKEN") }`,
+			})).
+		Field(service.NewStringField("payload").
+			Description("An optional payload to deliver for each request.").
+			Default("")).
+		Field(service.NewObjectField("basic_auth",
+			service.NewBoolField("enabled").
+				Description("Whether to use basic authentication in requests.").
+				Default(false),
+			service.NewStringField("username").
+				Description("A username to authenticate as.").
+				Default(""),
+			service.NewStringField("password").
+				Description("A password to authenticate with.").
+				Default("").
+				Secret(),
+		).Description("Allows you to specify basic authentication.").Advanced()).
+		Field(service.NewTLSToggledField("tls")).
+		Field(service.NewStringField("rate_limit").
+			Description("An optional [`rate_limit`](/docs/components/rate_limits/about) to throttle requests by.").
+			Default("")).
+		Field(service.NewMetadataFilterField("extract_headers").
+			Description("Specify optional matching rules to determine which response headers should be added to resulting messages as metadata.").
+			Advanced()).
+		Field(service.NewDurationField("timeout").
+			Description("A static timeout to apply to requests.").
+			Default("5s").
+			Advanced()).
+		Field(service.NewDurationField("interval").
+			Description("The period of time between the start of consecutive polls.").
+			Default("1m").
+			Example("30s").
+			Example("1h")).
+		Field(service.NewBloblangField("records_mapping").
+			Description("An optional mapping that converts the response of each request into records.").
+			Default("").
+			Example("root = this.items").
+			Example(`root = this.data.map_each(ele -> ele.merge({"page": meta("page")}))`)).
+		Field(service.NewObjectField("pagination",
+			service.NewBloblangField("next_mapping").
+				Description("An optional mapping that determines the metadata of the request for the next page, or results in `deleted()` once the last page has been reached.").
+				Default("").
+				Example(`root = if this.next_cursor != null { {"cursor": this.next_cursor} } else { deleted() }`),
+			service.NewIntField("max_pages").
+				Description("The maximum number of pages to request within a single poll, or zero for no limit.").
+				Default(0).
+				Advanced(),
+		).Description("Configures how subsequent pages of a poll are requested.")).
+		Field(service.NewObjectField("dedupe",
+			service.NewStringField("cache").
+				Description("An optional [cache resource](/docs/components/caches/about) used to store the keys of emitted records.").
+				Default(""),
+			service.NewInterpolatedStringField("key").
+				Description("An interpolated string that determines the key of each record.").
+				Default(`${! content() }`).
+				Example(`${! json("id") }`),
+		).Description("Optionally filters records that have already been emitted.")).
+		Field(service.NewStringField("checkpoint").
+			Description("An optional [checkpoint resource](/docs/configuration/resources#checkpoints) to store the position of polls within.").
+			Default("").
+			Advanced()).
+		Field(service.NewIntField("checkpoint_limit").
+			Description("The maximum number of records that can be processed in parallel before applying back pressure when a `checkpoint` is set.").
+			Default(1024).
+			Advanced()).
+		Example("Cursor Pagination", "Here we poll an API every five minutes, following a cursor through each page of results and emitting each item only once:", `
+input:
+  http_poll:
+    url: https://api.example.com/items?cursor=${! meta("cursor").or("") }
+    verb: GET
+    interval: 5m
+    records_mapping: root = this.items
+    pagination:
+      next_mapping: |
+        root = if this.next_cursor != null { {"cursor": this.next_cursor} } else { deleted() }
+    dedupe:
+      cache: seen_items
+      key: ${! json("id") }
+
+cache_resources:
+  - label: seen_items
+    memory:
+      default_ttl: 24h
+`).
+		Example("Offset Pagination", "Pages can also be requested with an offset that's incremented with each response until an empty page is returned:", `
+input:
+  http_poll:
+    url: https://api.example.com/items?limit=100&offset=${! meta("offset").or("0") }
+    verb: GET
+    interval: 1h
+    records_mapping: root = this.items
+    pagination:
+      next_mapping: |
+        root = if this.items.length() > 0 {
+          {"offset": (meta("offset").or("0").number() + 100).string()}
+        } else { deleted() }
+`).
+		Example("Link Header Pagination", "APIs that provide the URL of the next page in a `Link` header can be followed by extracting the header and interpolating the whole URL:", `
+input:
+  http_poll:
+    url: ${! meta("next_url").or("https://api.example.com/items") }
+    verb: GET
+    extract_headers:
+      include_patterns: [ '^link$' ]
+    interval: 10m
+    records_mapping: root = this
+    pagination:
+      next_mapping: |
+        let next = meta("link").or("").re_find_object("<(?P<url>[^>]+)>;\\s*rel=\"next\"")
+        root = if $next.url != null { {"next_url": $next.url} } else { deleted() }
+`)
+}
+
+func init() {
+	err := service.RegisterInput(
+		"http_poll", httpPollInputConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.Input, error) {
+			h, err := newHTTPPollInputFromParsed(conf, mgr, mgr.Logger())
+			if err != nil {
+				return nil, err
+			}
+			return service.AutoRetryNacks(h), nil
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// httpPollResources is the subset of service.Resources used by the input.
+type httpPollResources interface {
+	HasCache(name string) bool
+	AccessRateLimit(ctx context.Context, name string, fn func(r service.RateLimit)) error
+	HasRateLimit(name string) bool
+	AccessCache(ctx context.Context, name string, fn func(c service.Cache)) error
+	HasCheckpoint(name string) bool
+	AccessCheckpoint(ctx context.Context, name string, fn func(c service.CheckpointStore)) error
+}
+
+// The key that the position of a poll is stored under within a checkpoint
+// resource.
+const httpPollCheckpointKey = "http_poll_position"
+
+// httpPollPosition is the stored position of a poll, which references the page
+// to resume from, or is empty when the poll is complete.
+type httpPollPosition struct {
+	NextPage map[string]string `json:"next_page,omitempty"`
+}
+
+// httpPollPage tracks the records of a page that are yet to be acknowledged.
+type httpPollPage struct {
+	pending int64
+	release func() interface{}
+}
+
+type httpPollRecord struct {
+	msg  *service.Message
+	key  string
+	page *httpPollPage
+}
+
+type httpPollInput struct {
+	mgr httpPollResources
+	log *service.Logger
+
+	client    *http.Client
+	url       *service.InterpolatedString
+	verb      string
+	headers   map[string]*service.InterpolatedString
+	payload   []byte
+	username  string
+	password  string
+	basicAuth bool
+	extract   *service.MetadataFilter
+	rateLimit string
+	interval  time.Duration
+
+	recordsMapping *bloblang.Executor
+	nextMapping    *bloblang.Executor
+	maxPages       int
+	dedupeCache    string
+	dedupeKey      *service.InterpolatedString
+
+	nextPoll time.Time
+	nextRef  *service.Message
+	pages    int
+	fetched  []httpPollRecord
+	pending  []httpPollRecord
+
+	store         service.CheckpointStore
+	storeLoaded   bool
+	checkpointMut sync.Mutex
+	checkpointer  *service.Checkpointer
+}
+
+func newHTTPPollInputFromParsed(conf *service.ParsedConfig, mgr httpPollResources, log *service.Logger) (*httpPollInput, error) {
+	h := &httpPollInput{
+		mgr:     mgr,
+		log:     log,
+		headers: map[string]*service.InterpolatedString{},
+	}
+
+	var err error
+	if h.url, err = conf.FieldInterpolatedString("url"); err != nil {
+		return nil, err
+	}
+	if h.verb, err = conf.FieldString("verb"); err != nil {
+		return nil, err
+	}
+
+	headers, err := conf.FieldStringMap("headers")
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range headers {
+		if h.headers[k], err = service.NewInterpolatedString(v); err != nil {
+			return nil, fmt.Errorf("failed to parse header '%v' expression: %w", k, err)
+		}
+	}
+
+	payload, err := conf.FieldString("payload")
+	if err != nil {
+		return nil, err
+	}
+	if payload != "" {
+		h.payload = []byte(payload)
+	}
+
+	if h.basicAuth, err = conf.FieldBool("basic_auth", "enabled"); err != nil {
+		return nil, err
+	}
+	if h.username, err = conf.FieldString("basic_auth", "username"); err != nil {
+		return nil, err
+	}
+	if h.password, err = conf.FieldString("basic_auth", "password"); err != nil {
+		return nil, err
+	}
+
+	tlsConf, tlsEnabled, err := conf.FieldTLSToggled("tls")
+	if err != nil {
+		return nil, err
+	}
+	timeout, err := conf.FieldDuration("timeout")
+	if err != nil {
+		return nil, err
+	}
+	h.client = &http.Client{Timeout: timeout}
+	if tlsEnabled {
+		if c, ok := http.DefaultTransport.(*http.Transport); ok {
+			cloned := c.Clone()
+			cloned.TLSClientConfig = tlsConf
+			h.client.Transport = cloned
+		} else {
+			h.client.Transport = &http.Transport{
+				TLSClientConfig: tlsConf,
+			}
+		}
+	}
+
+	if h.rateLimit, err = conf.FieldString("rate_limit"); err != nil {
+		return nil, err
+	}
+	if h.rateLimit != "" && !mgr.HasRateLimit(h.rateLimit) {
+		return nil, fmt.Errorf("rate limit resource '%v' was not found", h.rateLimit)
+	}
+	if h.extract, err = conf.FieldMetadataFilter("extract_headers"); err != nil {
+		return nil, err
+	}
+	if h.interval, err = conf.FieldDuration("interval"); err != nil {
+		return nil, err
+	}
+
+	if recordsMapping, _ := conf.FieldString("records_mapping"); recordsMapping != "" {
+		if h.recordsMapping, err = conf.FieldBloblang("records_mapping"); err != nil {
+			return nil, err
+		}
+	}
+	if nextMapping, _ := conf.FieldString("pagination", "next_mapping"); nextMapping != "" {
+		if h.nextMapping, err = conf.FieldBloblang("pagination", "next_mapping"); err != nil {
+			return nil, err
+		}
+	}
+	if h.maxPages, err = conf.FieldInt("pagination", "max_pages"); err != nil {
+		return nil, err
+	}
+
+	if h.dedupeCache, err = conf.FieldString("dedupe", "cache"); err != nil {
+		return nil, err
+	}
+	if h.dedupeCache != "" {
+		if !mgr.HasCache(h.dedupeCache) {
+			return nil, fmt.Errorf("cache resource '%v' was not found", h.dedupeCache)
+		}
+		if h.dedupeKey, err = conf.FieldInterpolatedString("dedupe", "key"); err != nil {
+			return nil, err
+		}
+	}
+
+	checkpointName, err := conf.FieldString("checkpoint")
+	if err != nil {
+		return nil, err
+	}
+	if checkpointName != "" {
+		if !mgr.HasCheckpoint(checkpointName) {
+			return nil, fmt.Errorf("checkpoint resource '%v' was not found", checkpointName)
+		}
+		if err = mgr.AccessCheckpoint(context.Background(), checkpointName, func(c service.CheckpointStore) {
+			h.store = c
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	checkpointLimit, err := conf.FieldInt("checkpoint_limit")
+	if err != nil {
+		return nil, err
+	}
+	if checkpointLimit < 1 {
+		return nil, fmt.Errorf("checkpoint_limit must be greater than zero, got %v", checkpointLimit)
+	}
+	h.checkpointer = service.NewCheckpointer(int64(checkpointLimit))
+	return h, nil
+}
+
+//------------------------------------------------------------------------------
+
+// Connect resumes the pagination of a poll from a checkpoint when one has been
+// stored, requests are made when reading.
+func (h *httpPollInput) Connect(ctx context.Context) error {
+	if h.store == nil || h.storeLoaded {
+		return nil
+	}
+
+	posBytes, err := h.store.Get(ctx, httpPollCheckpointKey)
+	if err != nil && !errors.Is(err, service.ErrKeyNotFound) {
+		return fmt.Errorf("failed to obtain checkpoint: %w", err)
+	}
+	if err == nil {
+		var pos httpPollPosition
+		if err := json.Unmarshal(posBytes, &pos); err != nil {
+			return fmt.Errorf("failed to parse checkpoint: %w", err)
+		}
+		if pos.NextPage != nil {
+			h.nextRef = service.NewMessage(nil)
+			for k, v := range pos.NextPage {
+				h.nextRef.MetaSet(k, v)
+			}
+			h.nextPoll = time.Now().Add(h.interval)
+			h.log.Infof("Resuming poll from page: %v\n", pos.NextPage)
+		}
+	}
+	h.storeLoaded = true
+	return nil
+}
+
+// Tracks the position following a page, which is committed once the records of
+// the page and all prior pages have been acknowledged.
+func (h *httpPollInput) trackPage(ctx context.Context, records []httpPollRecord) error {
+	if h.store == nil {
+		return nil
+	}
+
+	var pos httpPollPosition
+	if h.nextRef != nil {
+		pos.NextPage = map[string]string{}
+		_ = h.nextRef.MetaWalk(func(k, v string) error {
+			pos.NextPage[k] = v
+			return nil
+		})
+	}
+
+	release, err := h.checkpointer.Track(ctx, pos, int64(len(records)))
+	if err != nil {
+		return err
+	}
+	page := &httpPollPage{
+		pending: int64(len(records)),
+		release: release,
+	}
+
+	if len(records) == 0 {
+		return h.commit(ctx, page)
+	}
+	for i := range records {
+		records[i].page = page
+	}
+	return nil
+}
+
+func (h *httpPollInput) commit(ctx context.Context, page *httpPollPage) error {
+	h.checkpointMut.Lock()
+	defer h.checkpointMut.Unlock()
+
+	pos, ok := page.release().(httpPollPosition)
+	if !ok {
+		return nil
+	}
+	posBytes, err := json.Marshal(pos)
+	if err != nil {
+		return err
+	}
+	if err := h.store.Set(ctx, httpPollCheckpointKey, posBytes); err != nil {
+		return fmt.Errorf("failed to store checkpoint: %w", err)
+	}
+	return nil
+}
+
+// Read returns the next record, polling the endpoint when there are no records
+// left from previous requests.
+func (h *httpPollInput) Read(ctx context.Context) (*service.Message, service.AckFunc, error) {
+	for len(h.pending) == 0 {
+		if h.fetched == nil {
+			if h.nextRef == nil {
+				if wait := time.Until(h.nextPoll); wait > 0 {
+					select {
+					case <-time.After(wait):
+					case <-ctx.Done():
+						return nil, nil, ctx.Err()
+					}
+				}
+				h.nextPoll = time.Now().Add(h.interval)
+				h.nextRef = service.NewMessage(nil)
+				h.pages = 0
+			}
+			records, err := h.requestPage(ctx)
+			if err != nil {
+				return nil, nil, err
+			}
+			h.fetched = records
+		}
+		// Records are only released once their page is tracked, which blocks
+		// when the checkpoint limit is reached.
+		if err := h.trackPage(ctx, h.fetched); err != nil {
+			return nil, nil, err
+		}
+		h.pending, h.fetched = h.fetched, nil
+	}
+
+	record := h.pending[0]
+	h.pending = h.pending[1:]
+
+	return record.msg, func(ctx context.Context, res error) error {
+		if res != nil {
+			return nil
+		}
+		if h.dedupeKey != nil {
+			var err error
+			if cerr := h.mgr.AccessCache(ctx, h.dedupeCache, func(c service.Cache) {
+				err = c.Set(ctx, record.key, []byte{'t'}, nil)
+			}); cerr != nil {
+				err = cerr
+			}
+			if err != nil {
+				return err
+			}
+		}
+		if record.page != nil && atomic.AddInt64(&record.page.pending, -1) == 0 {
+			return h.commit(ctx, record.page)
+		}
+		return nil
+	}, nil
+}
+
+func (h *httpPollInput) waitForAccess(ctx context.Context) error {
+	if h.rateLimit == "" {
+		return nil
+	}
+	for {
+		var period time.Duration
+		var err error
+		if rerr := h.mgr.AccessRateLimit(ctx, h.rateLimit, func(rl service.RateLimit) {
+			period, err = rl.Access(ctx)
+		}); rerr != nil {
+			err = rerr
+		}
+		if err != nil {
+			h.log.Errorf("Rate limit error: %v\n", err)
+			period = time.Second
+		}
+		if period <= 0 {
+			return nil
+		}
+		select {
+		case <-time.After(period):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Performs a request interpolated from the reference message, returning the
+// response as a message with the status code and extracted headers as
+// metadata.
+func (h *httpPollInput) send(ctx context.Context, ref *service.Message) (*service.Message, error) {
+	if err := h.waitForAccess(ctx); err != nil {
+		return nil, err
+	}
+
+	var body io.Reader
+	if h.payload != nil {
+		body = bytes.NewReader(h.payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, h.verb, h.url.String(ref), body)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range h.headers {
+		req.Header.Set(k, v.String(ref))
+	}
+	if h.basicAuth {
+		req.SetBasicAuth(h.username, h.password)
+	}
+
+	res, err := h.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	resBytes, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return nil, fmt.Errorf("request returned unexpected response code: %v, body: %s", res.StatusCode, resBytes)
+	}
+
+	resMsg := service.NewMessage(resBytes)
+	resMsg.MetaSet("http_status_code", strconv.Itoa(res.StatusCode))
+
+	headerMsg := service.NewMessage(nil)
+	for k, values := range res.Header {
+		if len(values) > 0 {
+			headerMsg.MetaSet(strings.ToLower(k), values[0])
+		}
+	}
+	_ = h.extract.Walk(headerMsg, func(k, v string) error {
+		resMsg.MetaSet(k, v)
+		return nil
+	})
+	return resMsg, nil
+}
+
+// Requests the page referenced by nextRef and returns its records. The
+// reference of the next page is set when pagination continues, otherwise it is
+// cleared.
+func (h *httpPollInput) requestPage(ctx context.Context) ([]httpPollRecord, error) {
+	resMsg, err := h.send(ctx, h.nextRef)
+	if err != nil {
+		return nil, err
+	}
+	h.pages++
+
+	records, err := h.extractRecords(resMsg)
+	if err != nil {
+		return nil, err
+	}
+	filtered, err := h.filterSeen(ctx, records)
+	if err != nil {
+		return nil, err
+	}
+
+	prevRef := h.nextRef
+	h.nextRef = nil
+	if h.nextMapping == nil || (h.maxPages > 0 && h.pages >= h.maxPages) {
+		return filtered, nil
+	}
+
+	// The next mapping is able to reference the metadata of the previous
+	// request, which is overridden by the metadata of the response.
+	resBytes, _ := resMsg.AsBytes()
+	nextMsg := service.NewMessage(resBytes)
+	_ = prevRef.MetaWalk(func(k, v string) error {
+		nextMsg.MetaSet(k, v)
+		return nil
+	})
+	_ = resMsg.MetaWalk(func(k, v string) error {
+		nextMsg.MetaSet(k, v)
+		return nil
+	})
+
+	mapped, err := nextMsg.BloblangQuery(h.nextMapping)
+	if err != nil {
+		return nil, fmt.Errorf("pagination next_mapping failed: %w", err)
+	}
+	if mapped == nil {
+		return filtered, nil
+	}
+	v, err := mapped.AsStructured()
+	if err != nil {
+		return nil, fmt.Errorf("pagination next_mapping failed: %w", err)
+	}
+	if v == nil {
+		return filtered, nil
+	}
+	obj, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected pagination next_mapping to result in an object, got: %T", v)
+	}
+
+	ref := service.NewMessage(nil)
+	_ = prevRef.MetaWalk(func(k, v string) error {
+		ref.MetaSet(k, v)
+		return nil
+	})
+	for k, v := range obj {
+		ref.MetaSet(k, query.IToString(v))
+	}
+	h.nextRef = ref
+	return filtered, nil
+}
+
+// Converts a response into records, each of which inherits the metadata of the
+// response.
+func (h *httpPollInput) extractRecords(resMsg *service.Message) ([]*service.Message, error) {
+	if h.recordsMapping == nil {
+		if b, _ := resMsg.AsBytes(); len(b) == 0 {
+			return nil, nil
+		}
+		return []*service.Message{resMsg.Copy()}, nil
+	}
+
+	mapped, err := resMsg.BloblangQuery(h.recordsMapping)
+	if err != nil {
+		return nil, fmt.Errorf("records_mapping failed: %w", err)
+	}
+	if mapped == nil {
+		return nil, nil
+	}
+	v, err := mapped.AsStructured()
+	if err != nil {
+		return nil, fmt.Errorf("records_mapping failed: %w", err)
+	}
+
+	values, isArray := v.([]interface{})
+	if !isArray {
+		values = []interface{}{v}
+	}
+
+	records := make([]*service.Message, 0, len(values))
+	for _, value := range values {
+		recordBytes, err := json.Marshal(value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal record: %w", err)
+		}
+		record := service.NewMessage(recordBytes)
+		_ = resMsg.MetaWalk(func(k, v string) error {
+			record.MetaSet(k, v)
+			return nil
+		})
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// Returns the records that have not already been emitted, along with their
+// dedupe keys.
+func (h *httpPollInput) filterSeen(ctx context.Context, records []*service.Message) ([]httpPollRecord, error) {
+	filtered := make([]httpPollRecord, 0, len(records))
+	for _, r := range records {
+		if h.dedupeKey == nil {
+			filtered = append(filtered, httpPollRecord{msg: r})
+			continue
+		}
+
+		key := h.dedupeKey.String(r)
+
+		var err error
+		if cerr := h.mgr.AccessCache(ctx, h.dedupeCache, func(c service.Cache) {
+			_, err = c.Get(ctx, key)
+		}); cerr != nil {
+			return nil, cerr
+		}
+		if err == nil {
+			continue
+		}
+		if !errors.Is(err, service.ErrKeyNotFound) {
+			return nil, fmt.Errorf("failed to check dedupe cache: %w", err)
+		}
+		filtered = append(filtered, httpPollRecord{msg: r, key: key})
+	}
+	return filtered, nil
+}
+
+// Close shuts down the input and stops processing requests.
+func (h *httpPollInput) Close(ctx context.Context) error {
+	h.client.CloseIdleConnections()
+	return nil
+}
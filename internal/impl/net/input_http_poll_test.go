@@ -0,0 +1,400 @@
+package net
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+type fakeStore struct {
+	mut    sync.Mutex
+	values map[string][]byte
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{values: map[string][]byte{}}
+}
+
+func (f *fakeStore) Get(ctx context.Context, key string) ([]byte, error) {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+	v, exists := f.values[key]
+	if !exists {
+		return nil, service.ErrKeyNotFound
+	}
+	return v, nil
+}
+
+func (f *fakeStore) Set(ctx context.Context, key string, value []byte, ttl *time.Duration) error {
+	f.mut.Lock()
+	f.values[key] = value
+	f.mut.Unlock()
+	return nil
+}
+
+func (f *fakeStore) Add(ctx context.Context, key string, value []byte, ttl *time.Duration) error {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+	if _, exists := f.values[key]; exists {
+		return service.ErrKeyAlreadyExists
+	}
+	f.values[key] = value
+	return nil
+}
+
+func (f *fakeStore) Delete(ctx context.Context, key string) error {
+	f.mut.Lock()
+	delete(f.values, key)
+	f.mut.Unlock()
+	return nil
+}
+
+func (f *fakeStore) Close(ctx context.Context) error {
+	return nil
+}
+
+func (f *fakeStore) value(key string) string {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+	return string(f.values[key])
+}
+
+// fakeCheckpointStore adapts a fakeStore to the checkpoint store interface.
+type fakeCheckpointStore struct {
+	*fakeStore
+}
+
+func (f fakeCheckpointStore) Set(ctx context.Context, key string, value []byte) error {
+	return f.fakeStore.Set(ctx, key, value, nil)
+}
+
+type fakeRateLimit struct {
+	accesses int
+}
+
+func (f *fakeRateLimit) Access(ctx context.Context) (time.Duration, error) {
+	f.accesses++
+	if f.accesses%2 == 1 {
+		return time.Millisecond, nil
+	}
+	return 0, nil
+}
+
+func (f *fakeRateLimit) Close(ctx context.Context) error {
+	return nil
+}
+
+type fakeHTTPPollResources struct {
+	caches      map[string]*fakeStore
+	rateLimits  map[string]*fakeRateLimit
+	checkpoints map[string]*fakeStore
+}
+
+func (f fakeHTTPPollResources) HasRateLimit(name string) bool {
+	_, exists := f.rateLimits[name]
+	return exists
+}
+
+func (f fakeHTTPPollResources) AccessRateLimit(ctx context.Context, name string, fn func(r service.RateLimit)) error {
+	r, exists := f.rateLimits[name]
+	if !exists {
+		return service.ErrKeyNotFound
+	}
+	fn(r)
+	return nil
+}
+
+func (f fakeHTTPPollResources) HasCache(name string) bool {
+	_, exists := f.caches[name]
+	return exists
+}
+
+func (f fakeHTTPPollResources) AccessCache(ctx context.Context, name string, fn func(c service.Cache)) error {
+	c, exists := f.caches[name]
+	if !exists {
+		return service.ErrKeyNotFound
+	}
+	fn(c)
+	return nil
+}
+
+func (f fakeHTTPPollResources) HasCheckpoint(name string) bool {
+	_, exists := f.checkpoints[name]
+	return exists
+}
+
+func (f fakeHTTPPollResources) AccessCheckpoint(ctx context.Context, name string, fn func(c service.CheckpointStore)) error {
+	c, exists := f.checkpoints[name]
+	if !exists {
+		return service.ErrKeyNotFound
+	}
+	fn(fakeCheckpointStore{c})
+	return nil
+}
+
+func newTestHTTPPollInput(t *testing.T, conf string, res fakeHTTPPollResources) *httpPollInput {
+	t.Helper()
+
+	parsed, err := httpPollInputConfig().ParseYAML(conf, nil)
+	require.NoError(t, err)
+
+	h, err := newHTTPPollInputFromParsed(parsed, res, service.MockResources().Logger())
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = h.Close(context.Background())
+	})
+	return h
+}
+
+func TestHTTPPollPaginationAndDedupe(t *testing.T) {
+	pages := map[string]string{
+		"":   `{"items":[{"id":"a"},{"id":"b"}],"next_cursor":"c1"}`,
+		"c1": `{"items":[{"id":"c"}],"next_cursor":null}`,
+	}
+
+	var mut sync.Mutex
+	var cursors []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mut.Lock()
+		defer mut.Unlock()
+
+		cursor := r.URL.Query().Get("cursor")
+		cursors = append(cursors, cursor)
+		page, exists := pages[cursor]
+		if !exists {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprint(w, page)
+	}))
+	defer ts.Close()
+
+	seen := newFakeStore()
+	seen.values["b"] = []byte("t")
+
+	h := newTestHTTPPollInput(t, fmt.Sprintf(`
+url: %v?cursor=${! meta("cursor").or("") }
+interval: 1ms
+records_mapping: root = this.items
+pagination:
+  next_mapping: 'root = if this.next_cursor != null { {"cursor": this.next_cursor} } else { deleted() }'
+dedupe:
+  cache: seen
+  key: ${! json("id") }
+`, ts.URL), fakeHTTPPollResources{caches: map[string]*fakeStore{"seen": seen}})
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*10)
+	defer done()
+
+	require.NoError(t, h.Connect(ctx))
+
+	for _, exp := range []string{`{"id":"a"}`, `{"id":"c"}`} {
+		msg, ackFn, err := h.Read(ctx)
+		require.NoError(t, err)
+
+		mBytes, err := msg.AsBytes()
+		require.NoError(t, err)
+		assert.Equal(t, exp, string(mBytes))
+
+		code, _ := msg.MetaGet("http_status_code")
+		assert.Equal(t, "200", code)
+		require.NoError(t, ackFn(ctx, nil))
+	}
+
+	// All records of the next poll have been seen, and therefore reading times
+	// out once the poll has completed.
+	shortCtx, shortDone := context.WithTimeout(ctx, time.Millisecond*100)
+	defer shortDone()
+	_, _, err := h.Read(shortCtx)
+	require.Error(t, err)
+
+	mut.Lock()
+	assert.Equal(t, []string{"", "c1", "", "c1"}, cursors[:4])
+	mut.Unlock()
+
+	assert.Equal(t, "t", seen.value("a"))
+	assert.Equal(t, "t", seen.value("c"))
+}
+
+func TestHTTPPollOffsetPagination(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("offset") {
+		case "0":
+			fmt.Fprint(w, `[1,2]`)
+		case "2":
+			fmt.Fprint(w, `[3]`)
+		default:
+			fmt.Fprint(w, `[]`)
+		}
+	}))
+	defer ts.Close()
+
+	h := newTestHTTPPollInput(t, fmt.Sprintf(`
+url: %v?offset=${! meta("offset").or("0") }
+interval: 1h
+records_mapping: root = this
+pagination:
+  next_mapping: |
+    root = if this.length() > 0 {
+      {"offset": (meta("offset").or("0").number() + this.length()).string()}
+    } else { deleted() }
+`, ts.URL), fakeHTTPPollResources{})
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*10)
+	defer done()
+
+	require.NoError(t, h.Connect(ctx))
+
+	for _, exp := range []string{"1", "2", "3"} {
+		msg, _, err := h.Read(ctx)
+		require.NoError(t, err)
+
+		mBytes, err := msg.AsBytes()
+		require.NoError(t, err)
+		assert.Equal(t, exp, string(mBytes))
+	}
+}
+
+func TestHTTPPollRequestFields(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, _ := r.BasicAuth()
+		w.Header().Set("X-Page", "1")
+		w.Header().Set("X-Ignored", "nope")
+		fmt.Fprintf(w, `{"user":%q,"pass":%q,"foo":%q}`, user, pass, r.Header.Get("X-Foo"))
+	}))
+	defer ts.Close()
+
+	rl := &fakeRateLimit{}
+	h := newTestHTTPPollInput(t, fmt.Sprintf(`
+url: %v
+headers:
+  X-Foo: ${! "bar".uppercase() }
+basic_auth:
+  enabled: true
+  username: foo
+  password: baz
+extract_headers:
+  include_prefixes: [ x-page ]
+rate_limit: foo
+`, ts.URL), fakeHTTPPollResources{rateLimits: map[string]*fakeRateLimit{"foo": rl}})
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*10)
+	defer done()
+
+	require.NoError(t, h.Connect(ctx))
+
+	msg, _, err := h.Read(ctx)
+	require.NoError(t, err)
+
+	mBytes, err := msg.AsBytes()
+	require.NoError(t, err)
+	assert.Equal(t, `{"user":"foo","pass":"baz","foo":"BAR"}`, string(mBytes))
+
+	page, _ := msg.MetaGet("x-page")
+	assert.Equal(t, "1", page)
+
+	_, exists := msg.MetaGet("x-ignored")
+	assert.False(t, exists)
+
+	assert.Equal(t, 2, rl.accesses)
+}
+
+func TestHTTPPollBadStatus(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer ts.Close()
+
+	h := newTestHTTPPollInput(t, fmt.Sprintf(`
+url: %v
+`, ts.URL), fakeHTTPPollResources{})
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*10)
+	defer done()
+
+	_, _, err := h.Read(ctx)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "502")
+}
+
+func TestHTTPPollCheckpoint(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("offset") {
+		case "0":
+			fmt.Fprint(w, `[1,2]`)
+		case "2":
+			fmt.Fprint(w, `[3,4]`)
+		case "4":
+			fmt.Fprint(w, `[5]`)
+		default:
+			fmt.Fprint(w, `[]`)
+		}
+	}))
+	defer ts.Close()
+
+	positions := newFakeStore()
+	res := fakeHTTPPollResources{checkpoints: map[string]*fakeStore{"positions": positions}}
+
+	conf := fmt.Sprintf(`
+url: %v?offset=${! meta("offset").or("0") }
+interval: 1h
+records_mapping: root = this
+pagination:
+  next_mapping: |
+    root = if this.length() > 0 {
+      {"offset": (meta("offset").or("0").number() + this.length()).string()}
+    } else { deleted() }
+checkpoint: positions
+`, ts.URL)
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*10)
+	defer done()
+
+	h := newTestHTTPPollInput(t, conf, res)
+	require.NoError(t, h.Connect(ctx))
+
+	for _, exp := range []string{"1", "2", "3"} {
+		msg, ackFn, err := h.Read(ctx)
+		require.NoError(t, err)
+
+		mBytes, err := msg.AsBytes()
+		require.NoError(t, err)
+		assert.Equal(t, exp, string(mBytes))
+		require.NoError(t, ackFn(ctx, nil))
+	}
+
+	// Only the first page has been acknowledged in full.
+	assert.Equal(t, `{"next_page":{"offset":"2"}}`, positions.value(httpPollCheckpointKey))
+	require.NoError(t, h.Close(ctx))
+
+	h = newTestHTTPPollInput(t, conf, res)
+	require.NoError(t, h.Connect(ctx))
+
+	for _, exp := range []string{"3", "4", "5"} {
+		msg, ackFn, err := h.Read(ctx)
+		require.NoError(t, err)
+
+		mBytes, err := msg.AsBytes()
+		require.NoError(t, err)
+		assert.Equal(t, exp, string(mBytes))
+		require.NoError(t, ackFn(ctx, nil))
+	}
+
+	// The empty page that completes the poll is requested once the last
+	// record is read.
+	shortCtx, shortDone := context.WithTimeout(ctx, time.Millisecond*100)
+	defer shortDone()
+	_, _, err := h.Read(shortCtx)
+	require.Error(t, err)
+
+	assert.Equal(t, `{}`, positions.value(httpPollCheckpointKey))
+}
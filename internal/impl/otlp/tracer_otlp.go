@@ -0,0 +1,113 @@
+package otlp
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
+
+	"github.com/benthosdev/benthos/v4/internal/bundle"
+	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+	"github.com/benthosdev/benthos/v4/internal/component/tracer"
+	"github.com/benthosdev/benthos/v4/internal/docs"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	_ = bundle.AllTracers.Add(NewOpenTelemetryCollector, docs.ComponentSpec{
+		Name:    "open_telemetry_collector",
+		Type:    docs.TypeTracer,
+		Status:  docs.StatusExperimental,
+		Version: "4.1.0",
+		Summary: `Send tracing events to an [Open Telemetry collector](https://opentelemetry.io/docs/collector/).`,
+		Description: `
+Tracing spans are propagated between services using the [W3C Trace Context](https://www.w3.org/TR/trace-context/) format. When this tracer is configured the ` + "`kafka`, `amqp_0_9` and `http_server`" + ` inputs extract the headers ` + "`traceparent` and `tracestate`" + ` from consumed messages by default, and the ` + "`kafka`, `amqp_0_9` and `http_client`" + ` outputs inject them into written messages, allowing spans to flow end-to-end across services. For the ` + "`kafka`" + ` input and output this default can be overridden with the fields ` + "`extract_tracing_map` and `inject_tracing_map`" + ` respectively.`,
+		Config: docs.FieldObject("", "").WithChildren(
+			docs.FieldObject("http", "A list of http collectors.").Array().WithChildren(
+				docs.FieldString("url", "The URL of a collector to send tracing events to.").HasDefault("localhost:4318"),
+				docs.FieldBool("insecure", "Whether to connect to the collector without TLS.").HasDefault(true),
+			).HasDefault([]interface{}{}),
+			docs.FieldObject("grpc", "A list of grpc collectors.").Array().WithChildren(
+				docs.FieldString("url", "The URL of a collector to send tracing events to.").HasDefault("localhost:4317"),
+				docs.FieldBool("insecure", "Whether to connect to the collector without TLS.").HasDefault(true),
+			).HasDefault([]interface{}{}),
+			docs.FieldString("tags", "A map of tags to add to all tracing spans.").Map().Advanced().HasDefault(map[string]interface{}{}),
+		),
+	})
+}
+
+//------------------------------------------------------------------------------
+
+// OpenTelemetryCollector is a tracer with the capability to push spans to one
+// or more Open Telemetry collectors.
+type OpenTelemetryCollector struct {
+	prov *tracesdk.TracerProvider
+}
+
+// NewOpenTelemetryCollector creates and returns a new OpenTelemetryCollector
+// object.
+func NewOpenTelemetryCollector(config tracer.Config, stats metrics.Type) (tracer.Type, error) {
+	conf := config.OpenTelemetryCollector
+	ctx := context.Background()
+
+	var batchers []tracesdk.SpanProcessor
+	for _, c := range conf.HTTP {
+		clientOpts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(c.URL)}
+		if c.Insecure {
+			clientOpts = append(clientOpts, otlptracehttp.WithInsecure())
+		}
+		exp, err := otlptracehttp.New(ctx, clientOpts...)
+		if err != nil {
+			return nil, err
+		}
+		batchers = append(batchers, tracesdk.NewBatchSpanProcessor(exp))
+	}
+	for _, c := range conf.GRPC {
+		clientOpts := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(c.URL)}
+		if c.Insecure {
+			clientOpts = append(clientOpts, otlptracegrpc.WithInsecure())
+		}
+		exp, err := otlptracegrpc.New(ctx, clientOpts...)
+		if err != nil {
+			return nil, err
+		}
+		batchers = append(batchers, tracesdk.NewBatchSpanProcessor(exp))
+	}
+
+	sampler, err := tracer.NewTailSampler(config.Sampling, stats, batchers...)
+	if err != nil {
+		return nil, err
+	}
+
+	attrs := []attribute.KeyValue{semconv.ServiceNameKey.String("benthos")}
+	for k, v := range conf.Tags {
+		attrs = append(attrs, attribute.String(k, v))
+	}
+	tp := tracesdk.NewTracerProvider(
+		tracesdk.WithSpanProcessor(sampler),
+		tracesdk.WithResource(resource.NewWithAttributes(semconv.SchemaURL, attrs...)),
+	)
+
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	return &OpenTelemetryCollector{prov: tp}, nil
+}
+
+//------------------------------------------------------------------------------
+
+// Close stops the tracer.
+func (o *OpenTelemetryCollector) Close() error {
+	if o.prov != nil {
+		_ = o.prov.Shutdown(context.Background())
+		o.prov = nil
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package parquet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/xitongsys/parquet-go/parquet"
+	"github.com/xitongsys/parquet-go/writer"
+
+	"github.com/benthosdev/benthos/v4/internal/codec"
+	"github.com/benthosdev/benthos/v4/internal/impl/parquet/shared"
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+func init() {
+	if err := codec.RegisterFileWriterPlugin("parquet", newParquetCodecFromArgs); err != nil {
+		panic(err)
+	}
+}
+
+var parquetWriterConfig = codec.WriterConfig{
+	Truncate: true,
+}
+
+func newParquetCodecFromArgs(args string) (codec.WriterConstructor, codec.WriterConfig, error) {
+	codecArgs := strings.SplitN(args, ":", 2)
+	if len(codecArgs) != 2 || codecArgs[1] == "" {
+		return nil, codec.WriterConfig{}, errors.New("parquet codec requires a compression type and a non-empty schema file path in the form parquet:<compression>:<schema path>")
+	}
+	compression, err := shared.CompressionType(codecArgs[0])
+	if err != nil {
+		return nil, codec.WriterConfig{}, err
+	}
+	schemaBytes, err := os.ReadFile(codecArgs[1])
+	if err != nil {
+		return nil, codec.WriterConfig{}, fmt.Errorf("failed to read parquet schema file: %w", err)
+	}
+	return func(w io.WriteCloser) (codec.Writer, error) {
+		return newParquetWriter(w, string(schemaBytes), compression)
+	}, parquetWriterConfig, nil
+}
+
+type parquetWriter struct {
+	w  io.WriteCloser
+	pw *writer.JSONWriter
+}
+
+func newParquetWriter(w io.WriteCloser, schema string, compression parquet.CompressionCodec) (codec.Writer, error) {
+	pw, err := writer.NewJSONWriterFromWriter(schema, w, 1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create parquet writer: %w", err)
+	}
+	pw.CompressionType = compression
+	return &parquetWriter{w: w, pw: pw}, nil
+}
+
+func (p *parquetWriter) Write(ctx context.Context, part *message.Part) error {
+	if err := p.pw.Write(part.Get()); err != nil {
+		return fmt.Errorf("failed to write document to parquet file: %w", err)
+	}
+	return nil
+}
+
+func (p *parquetWriter) Close(ctx context.Context) error {
+	if err := p.pw.WriteStop(); err != nil {
+		_ = p.w.Close()
+		return fmt.Errorf("failed to close parquet writer: %w", err)
+	}
+	return p.w.Close()
+}
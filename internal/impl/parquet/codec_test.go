@@ -0,0 +1,77 @@
+package parquet
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xitongsys/parquet-go-source/buffer"
+	"github.com/xitongsys/parquet-go/parquet"
+	"github.com/xitongsys/parquet-go/reader"
+
+	"github.com/benthosdev/benthos/v4/internal/codec"
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+type closableBuffer struct {
+	bytes.Buffer
+}
+
+func (c *closableBuffer) Close() error {
+	return nil
+}
+
+func TestParquetCodec(t *testing.T) {
+	schema := `{
+  "Tag": "name=root, repetitiontype=REQUIRED",
+  "Fields": [
+    {"Tag":"name=name, inname=Name, type=BYTE_ARRAY, convertedtype=UTF8, repetitiontype=REQUIRED"},
+    {"Tag":"name=age, inname=Age, type=INT32, repetitiontype=REQUIRED"}
+  ]
+}`
+	schemaPath := filepath.Join(t.TempDir(), "schema.json")
+	require.NoError(t, os.WriteFile(schemaPath, []byte(schema), 0o644))
+
+	ctor, conf, err := codec.GetFileWriter("parquet:gzip:" + schemaPath)
+	require.NoError(t, err)
+	assert.True(t, conf.Truncate)
+	assert.False(t, conf.CloseAfter)
+
+	var buf closableBuffer
+	w, err := ctor(&buf)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	require.NoError(t, w.Write(ctx, message.NewPart([]byte(`{"Name":"foo","Age":10}`))))
+	require.NoError(t, w.Write(ctx, message.NewPart([]byte(`{"Name":"bar","Age":20}`))))
+	require.NoError(t, w.Close(ctx))
+
+	pr, err := reader.NewParquetReader(buffer.NewBufferFileFromBytes(buf.Bytes()), schema, 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), pr.GetNumRows())
+	assert.Equal(t, parquet.CompressionCodec_GZIP, pr.Footer.RowGroups[0].Columns[0].MetaData.Codec)
+	pr.ReadStop()
+}
+
+func TestParquetCodecErrors(t *testing.T) {
+	schemaPath := filepath.Join(t.TempDir(), "schema.json")
+	require.NoError(t, os.WriteFile(schemaPath, []byte(`{}`), 0o644))
+
+	for _, c := range []string{
+		"parquet:",
+		"parquet:snappy:",
+		"parquet:" + schemaPath,
+		"parquet:nope:" + schemaPath,
+		"parquet:snappy:" + filepath.Join(t.TempDir(), "does_not_exist.json"),
+	} {
+		_, _, err := codec.GetFileWriter(c)
+		assert.Error(t, err, c)
+	}
+
+	_, _, err := codec.GetWriter("parquet:snappy:" + schemaPath)
+	assert.EqualError(t, err, "the parquet codec is only supported by the file output")
+}
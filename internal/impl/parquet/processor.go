@@ -11,6 +11,7 @@ import (
 	"github.com/xitongsys/parquet-go/reader"
 	"github.com/xitongsys/parquet-go/writer"
 
+	"github.com/benthosdev/benthos/v4/internal/impl/parquet/shared"
 	"github.com/benthosdev/benthos/v4/public/service"
 )
 
@@ -118,23 +119,6 @@ func init() {
 
 //------------------------------------------------------------------------------
 
-func getCompressionType(str string) (parquet.CompressionCodec, error) {
-	switch str {
-	case "uncompressed":
-		return parquet.CompressionCodec_UNCOMPRESSED, nil
-	case "snappy":
-		return parquet.CompressionCodec_SNAPPY, nil
-	case "gzip":
-		return parquet.CompressionCodec_GZIP, nil
-	case "lz4":
-		return parquet.CompressionCodec_LZ4, nil
-	case "zstd":
-		return parquet.CompressionCodec_ZSTD, nil
-	}
-	return parquet.CompressionCodec_UNCOMPRESSED, fmt.Errorf("unknown compression type: %v", str)
-
-}
-
 func newParquetProcessorFromConfig(conf *service.ParsedConfig, logger *service.Logger) (*parquetProcessor, error) {
 	operator, err := conf.FieldString("operator")
 	if err != nil {
@@ -186,7 +170,7 @@ func newParquetProcessor(operator, compressionCodec, schemaStr string, logger *s
 	case "from_json":
 		s.operator = s.processBatchWriter
 		var err error
-		if s.cCodec, err = getCompressionType(compressionCodec); err != nil {
+		if s.cCodec, err = shared.CompressionType(compressionCodec); err != nil {
 			return nil, err
 		}
 	case "to_json":
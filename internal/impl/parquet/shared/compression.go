@@ -0,0 +1,27 @@
+// Package shared contains parquet helpers that need to be shared across old and
+// new component implementations, it needs to be separate from the parent
+// package in order to avoid circular dependencies (for now).
+package shared
+
+import (
+	"fmt"
+
+	"github.com/xitongsys/parquet-go/parquet"
+)
+
+// CompressionType returns the parquet compression codec identified by a name.
+func CompressionType(str string) (parquet.CompressionCodec, error) {
+	switch str {
+	case "uncompressed":
+		return parquet.CompressionCodec_UNCOMPRESSED, nil
+	case "snappy":
+		return parquet.CompressionCodec_SNAPPY, nil
+	case "gzip":
+		return parquet.CompressionCodec_GZIP, nil
+	case "lz4":
+		return parquet.CompressionCodec_LZ4, nil
+	case "zstd":
+		return parquet.CompressionCodec_ZSTD, nil
+	}
+	return parquet.CompressionCodec_UNCOMPRESSED, fmt.Errorf("unknown compression type: %v", str)
+}
@@ -1,6 +1,7 @@
 package prometheus
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"sync"
@@ -12,6 +13,7 @@ import (
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/prometheus/client_golang/prometheus/push"
 	"github.com/prometheus/common/model"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/benthosdev/benthos/v4/internal/bundle"
 	"github.com/benthosdev/benthos/v4/internal/component/metrics"
@@ -40,10 +42,35 @@ The Push Gateway is useful for when Benthos instances are short lived. Do not
 include the "/metrics/jobs/..." path in the push URL.
 
 If the Push Gateway requires HTTP Basic Authentication it can be configured with
-` + "`push_basic_auth`.",
+` + "`push_basic_auth`." + `
+
+## Native Histograms
+
+When ` + "`native_histograms.enabled`" + ` is set to ` + "`true`" + ` histograms,
+including timing metrics when ` + "`use_histogram_timing`" + ` is enabled, are
+additionally exported as [native histograms](https://prometheus.io/docs/concepts/metric_types/#histogram).
+Native histograms are only exposed in the protobuf exposition format, which
+requires the Prometheus server scraping Benthos to run with the
+` + "`native-histograms`" + ` feature flag.
+
+## Exemplars
+
+When ` + "`add_exemplars`" + ` is set to ` + "`true`" + ` the latency metrics of
+inputs, processors and outputs are observed with an exemplar containing the
+trace ID of the message being measured as the label ` + "`trace_id`" + `, which
+allows tools such as Grafana to link from a latency spike to the traces of the
+messages responsible. Exemplars are only exposed in the OpenMetrics format,
+which Prometheus requests when the ` + "`exemplar-storage`" + ` feature flag is
+enabled.`,
 		Config: docs.FieldComponent().WithChildren(
-			docs.FieldBool("use_histogram_timing", "Whether to export timing metrics as a histogram, if `false` a summary is used instead. When exporting histogram timings the delta values are converted from nanoseconds into seconds in order to better fit within bucket definitions. For more information on histograms and summaries refer to: https://prometheus.io/docs/practices/histograms/.").HasDefault(false).Advanced().AtVersion("3.63.0"),
+			docs.FieldBool("use_histogram_timing", "Whether to export timing metrics as a histogram, if `false` a summary is used instead, which tracks the 0.5, 0.9, 0.95 and 0.99 quantiles. When exporting histogram timings the delta values are converted from nanoseconds into seconds in order to better fit within bucket definitions. For more information on histograms and summaries refer to: https://prometheus.io/docs/practices/histograms/.").HasDefault(false).Advanced().AtVersion("3.63.0"),
 			docs.FieldFloat("histogram_buckets", "Timing metrics histogram buckets (in seconds). If left empty defaults to DefBuckets (https://pkg.go.dev/github.com/prometheus/client_golang/prometheus#pkg-variables)").Array().HasDefault([]interface{}{}).Advanced().AtVersion("3.63.0"),
+			docs.FieldObject("native_histograms", "Optionally export histograms as [native histograms](#native-histograms), where bucket boundaries are determined dynamically with a resolution defined by a bucket factor. Native histograms are exported alongside the classic buckets and are only scraped by Prometheus servers with the feature enabled.").WithChildren(
+				docs.FieldBool("enabled", "Whether to export native histograms.").HasDefault(false),
+				docs.FieldFloat("bucket_factor", "The maximum factor between the boundaries of consecutive buckets, which must be greater than 1. Lower values result in a higher resolution at the cost of more buckets.").HasDefault(1.1),
+				docs.FieldInt("max_buckets", "The maximum number of buckets of each native histogram, beyond which the resolution of the histogram is reduced. Set to 0 for an unlimited number of buckets.").HasDefault(160),
+			).Advanced().AtVersion("4.1.0"),
+			docs.FieldBool("add_exemplars", "Whether to attach the trace ID of the message being measured as an [exemplar](#exemplars) to latency metrics when a [tracer](/docs/components/tracers/about) is configured. Exemplars are only supported by histograms, and therefore `use_histogram_timing` must also be set to `true`.").Advanced().HasDefault(false).AtVersion("4.1.0"),
 			docs.FieldBool("add_process_metrics", "Whether to export process metrics such as CPU and memory usage in addition to Benthos metrics.").Advanced().HasDefault(false),
 			docs.FieldBool("add_go_metrics", "Whether to export Go runtime metrics such as GC pauses in addition to Benthos metrics.").Advanced().HasDefault(false),
 			docs.FieldString("push_url", "An optional [Push Gateway URL](#push-gateway) to push metrics to.").Advanced().HasDefault(""),
@@ -87,14 +114,33 @@ func (p *promCounter) Incr(count int64) {
 type promTiming struct {
 	sum       prometheus.Observer
 	asSeconds bool
+	exemplars bool
 }
 
-func (p *promTiming) Timing(val int64) {
+func (p *promTiming) value(val int64) float64 {
 	vFloat := float64(val)
 	if p.asSeconds {
 		vFloat /= 1_000_000_000
 	}
-	p.sum.Observe(vFloat)
+	return vFloat
+}
+
+func (p *promTiming) Timing(val int64) {
+	p.sum.Observe(p.value(val))
+}
+
+func (p *promTiming) TimingWithContext(ctx context.Context, val int64) {
+	if p.exemplars {
+		if eObs, ok := p.sum.(prometheus.ExemplarObserver); ok {
+			if sCtx := trace.SpanContextFromContext(ctx); sCtx.HasTraceID() {
+				eObs.ObserveWithExemplar(p.value(val), prometheus.Labels{
+					"trace_id": sCtx.TraceID().String(),
+				})
+				return
+			}
+		}
+	}
+	p.sum.Observe(p.value(val))
 }
 
 //------------------------------------------------------------------------------
@@ -120,16 +166,26 @@ func (p *promTimingVec) With(labelValues ...string) metrics.StatTimer {
 }
 
 type promTimingHistVec struct {
-	sum *prometheus.HistogramVec
+	sum       *prometheus.HistogramVec
+	exemplars bool
 }
 
 func (p *promTimingHistVec) With(labelValues ...string) metrics.StatTimer {
 	return &promTiming{
 		asSeconds: true,
+		exemplars: p.exemplars,
 		sum:       p.sum.WithLabelValues(labelValues...),
 	}
 }
 
+type promObserverVec struct {
+	obs prometheus.ObserverVec
+}
+
+func (p *promObserverVec) With(labelValues ...string) metrics.StatObserver {
+	return p.obs.WithLabelValues(labelValues...)
+}
+
 type promGaugeVec struct {
 	ctr *prometheus.GaugeVec
 }
@@ -142,6 +198,13 @@ func (p *promGaugeVec) With(labelValues ...string) metrics.StatGauge {
 
 //------------------------------------------------------------------------------
 
+// defaultObjectives returns the quantiles tracked by summary metrics when none
+// are specified, which covers the p50, p90, p95 and p99 latencies of
+// components.
+func defaultObjectives() map[float64]float64 {
+	return map[float64]float64{0.5: 0.05, 0.9: 0.01, 0.95: 0.005, 0.99: 0.001}
+}
+
 type prometheusMetrics struct {
 	log        log.Modular
 	closedChan chan struct{}
@@ -151,6 +214,8 @@ type prometheusMetrics struct {
 
 	useHistogramTiming bool
 	histogramBuckets   []float64
+	nativeHistograms   metrics.PrometheusNativeHistograms
+	addExemplars       bool
 
 	pusher *push.Pusher
 	reg    *prometheus.Registry
@@ -159,6 +224,8 @@ type prometheusMetrics struct {
 	gauges     map[string]*prometheus.GaugeVec
 	timers     map[string]*prometheus.SummaryVec
 	timersHist map[string]*prometheus.HistogramVec
+	histograms map[string]*prometheus.HistogramVec
+	summaries  map[string]*prometheus.SummaryVec
 
 	mut sync.Mutex
 }
@@ -171,16 +238,28 @@ func newPrometheus(config metrics.Config, log log.Modular) (metrics.Type, error)
 		closedChan:         make(chan struct{}),
 		useHistogramTiming: promConf.UseHistogramTiming,
 		histogramBuckets:   promConf.HistogramBuckets,
+		nativeHistograms:   promConf.NativeHistograms,
+		addExemplars:       promConf.AddExemplars,
 		reg:                prometheus.NewRegistry(),
 		counters:           map[string]*prometheus.CounterVec{},
 		gauges:             map[string]*prometheus.GaugeVec{},
 		timers:             map[string]*prometheus.SummaryVec{},
 		timersHist:         map[string]*prometheus.HistogramVec{},
+		histograms:         map[string]*prometheus.HistogramVec{},
+		summaries:          map[string]*prometheus.SummaryVec{},
 	}
 
 	if len(p.histogramBuckets) == 0 {
 		p.histogramBuckets = prometheus.DefBuckets
 	}
+	if p.nativeHistograms.Enabled {
+		if p.nativeHistograms.BucketFactor <= 1 {
+			return nil, fmt.Errorf("native histogram bucket factor must be greater than 1, got %v", p.nativeHistograms.BucketFactor)
+		}
+		if p.nativeHistograms.MaxBuckets < 0 {
+			return nil, fmt.Errorf("native histogram max buckets must not be negative, got %v", p.nativeHistograms.MaxBuckets)
+		}
+	}
 
 	if promConf.AddProcessMetrics {
 		if err := p.reg.Register(collectors.NewProcessCollector(collectors.ProcessCollectorOpts{})); err != nil {
@@ -231,7 +310,9 @@ func newPrometheus(config metrics.Config, log log.Modular) (metrics.Type, error)
 
 func (p *prometheusMetrics) HandlerFunc() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		promhttp.HandlerFor(p.reg, promhttp.HandlerOpts{}).ServeHTTP(w, r)
+		promhttp.HandlerFor(p.reg, promhttp.HandlerOpts{
+			EnableOpenMetrics: p.addExemplars,
+		}).ServeHTTP(w, r)
 	}
 }
 
@@ -290,7 +371,7 @@ func (p *prometheusMetrics) GetTimerVec(path string, labelNames ...string) metri
 		tmr = prometheus.NewSummaryVec(prometheus.SummaryOpts{
 			Name:       path,
 			Help:       "Benthos Timing metric",
-			Objectives: map[float64]float64{0.5: 0.05, 0.9: 0.01, 0.99: 0.001},
+			Objectives: defaultObjectives(),
 		}, labelNames)
 		p.reg.MustRegister(tmr)
 		p.timers[path] = tmr
@@ -302,24 +383,34 @@ func (p *prometheusMetrics) GetTimerVec(path string, labelNames ...string) metri
 	}
 }
 
+func (p *prometheusMetrics) histogramOpts(path, help string, buckets []float64) prometheus.HistogramOpts {
+	opts := prometheus.HistogramOpts{
+		Name:    path,
+		Help:    help,
+		Buckets: buckets,
+	}
+	if p.nativeHistograms.Enabled {
+		opts.NativeHistogramBucketFactor = p.nativeHistograms.BucketFactor
+		opts.NativeHistogramMaxBucketNumber = uint32(p.nativeHistograms.MaxBuckets)
+	}
+	return opts
+}
+
 func (p *prometheusMetrics) getTimerHistVec(path string, labelNames ...string) metrics.StatTimerVec {
 	var tmr *prometheus.HistogramVec
 
 	p.mut.Lock()
 	var exists bool
 	if tmr, exists = p.timersHist[path]; !exists {
-		tmr = prometheus.NewHistogramVec(prometheus.HistogramOpts{
-			Name:    path,
-			Help:    "Benthos Timing metric",
-			Buckets: p.histogramBuckets,
-		}, labelNames)
+		tmr = prometheus.NewHistogramVec(p.histogramOpts(path, "Benthos Timing metric", p.histogramBuckets), labelNames)
 		p.reg.MustRegister(tmr)
 		p.timersHist[path] = tmr
 	}
 	p.mut.Unlock()
 
 	return &promTimingHistVec{
-		sum: tmr,
+		sum:       tmr,
+		exemplars: p.addExemplars,
 	}
 }
 
@@ -354,6 +445,66 @@ func (p *prometheusMetrics) GetGaugeVec(path string, labelNames ...string) metri
 	}
 }
 
+func (p *prometheusMetrics) GetHistogramVec(path string, buckets []float64, labelNames ...string) metrics.StatObserverVec {
+	if !model.IsValidMetricName(model.LabelValue(path)) {
+		p.log.Errorf("Ignoring metric '%v' due to invalid name", path)
+		return metrics.FakeObserverVec(func(l ...string) metrics.StatObserver {
+			return &metrics.DudStat{}
+		})
+	}
+
+	if len(buckets) == 0 {
+		buckets = prometheus.DefBuckets
+	}
+
+	var hist *prometheus.HistogramVec
+
+	p.mut.Lock()
+	var exists bool
+	if hist, exists = p.histograms[path]; !exists {
+		hist = prometheus.NewHistogramVec(p.histogramOpts(path, "Benthos Histogram metric", buckets), labelNames)
+		p.reg.MustRegister(hist)
+		p.histograms[path] = hist
+	}
+	p.mut.Unlock()
+
+	return &promObserverVec{
+		obs: hist,
+	}
+}
+
+func (p *prometheusMetrics) GetSummaryVec(path string, objectives map[float64]float64, labelNames ...string) metrics.StatObserverVec {
+	if !model.IsValidMetricName(model.LabelValue(path)) {
+		p.log.Errorf("Ignoring metric '%v' due to invalid name", path)
+		return metrics.FakeObserverVec(func(l ...string) metrics.StatObserver {
+			return &metrics.DudStat{}
+		})
+	}
+
+	if len(objectives) == 0 {
+		objectives = defaultObjectives()
+	}
+
+	var sum *prometheus.SummaryVec
+
+	p.mut.Lock()
+	var exists bool
+	if sum, exists = p.summaries[path]; !exists {
+		sum = prometheus.NewSummaryVec(prometheus.SummaryOpts{
+			Name:       path,
+			Help:       "Benthos Summary metric",
+			Objectives: objectives,
+		}, labelNames)
+		p.reg.MustRegister(sum)
+		p.summaries[path] = sum
+	}
+	p.mut.Unlock()
+
+	return &promObserverVec{
+		obs: sum,
+	}
+}
+
 func (p *prometheusMetrics) Close() error {
 	if atomic.CompareAndSwapInt32(&p.running, 1, 0) {
 		close(p.closedChan)
@@ -1,15 +1,18 @@
 package prometheus
 
 import (
+	"context"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/benthosdev/benthos/v4/internal/component/metrics"
 	"github.com/benthosdev/benthos/v4/internal/log"
@@ -167,6 +170,96 @@ func TestPrometheusHistMetrics(t *testing.T) {
 	assert.Contains(t, body, "\ntimertwo_sum{label3=\"value4\",label4=\"value5\"} 1.4e-08")
 }
 
+func TestPrometheusObserverMetrics(t *testing.T) {
+	nm, handler := getTestProm(t)
+
+	obsType, ok := nm.(metrics.ObserverType)
+	require.True(t, ok)
+
+	hist := obsType.GetHistogramVec("histone", []float64{1, 10}, "label1")
+	hist.With("value1").Observe(0.5)
+	hist.With("value1").Observe(5)
+
+	sum := obsType.GetSummaryVec("sumone", map[float64]float64{0.5: 0.05})
+	sum.With().Observe(2.5)
+
+	body := getPage(t, handler)
+
+	assert.Contains(t, body, "\nhistone_bucket{label1=\"value1\",le=\"1\"} 1")
+	assert.Contains(t, body, "\nhistone_bucket{label1=\"value1\",le=\"10\"} 2")
+	assert.Contains(t, body, "\nhistone_sum{label1=\"value1\"} 5.5")
+	assert.Contains(t, body, "\nsumone{quantile=\"0.5\"} 2.5")
+	assert.Contains(t, body, "\nsumone_count 1")
+}
+
+func TestPrometheusNativeHistograms(t *testing.T) {
+	conf := metrics.NewConfig()
+	conf.Prometheus.UseHistogramTiming = true
+	conf.Prometheus.NativeHistograms.Enabled = true
+
+	nm, err := newPrometheus(conf, log.Noop())
+	require.NoError(t, err)
+
+	nm.GetTimer("timerone").Timing(13)
+	nm.(metrics.ObserverType).GetHistogramVec("histone", []float64{1, 10}).With().Observe(5)
+
+	families, err := nm.(*prometheusMetrics).reg.Gather()
+	require.NoError(t, err)
+
+	schemas := map[string]int32{}
+	for _, f := range families {
+		hist := f.GetMetric()[0].GetHistogram()
+		require.NotNil(t, hist, f.GetName())
+		require.NotNil(t, hist.Schema, f.GetName())
+		schemas[f.GetName()] = hist.GetSchema()
+	}
+	assert.Equal(t, map[string]int32{"timerone": 3, "histone": 3}, schemas)
+}
+
+func TestPrometheusNativeHistogramsBadFactor(t *testing.T) {
+	conf := metrics.NewConfig()
+	conf.Prometheus.NativeHistograms.Enabled = true
+	conf.Prometheus.NativeHistograms.BucketFactor = 1
+
+	_, err := newPrometheus(conf, log.Noop())
+	require.Error(t, err)
+}
+
+func TestPrometheusExemplars(t *testing.T) {
+	conf := metrics.NewConfig()
+	conf.Prometheus.UseHistogramTiming = true
+	conf.Prometheus.AddExemplars = true
+
+	nm, err := newPrometheus(conf, log.Noop())
+	require.NoError(t, err)
+
+	traceID, err := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	require.NoError(t, err)
+	spanID, err := trace.SpanIDFromHex("00f067aa0ba902b7")
+	require.NoError(t, err)
+
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+	}))
+
+	metrics.TimingWithContext(ctx, nm.GetTimer("timerone"), 13)
+	metrics.TimingWithContext(context.Background(), nm.GetTimerVec("timertwo", "label1").With("value1"), 14)
+
+	req := httptest.NewRequest("GET", "http://example.com/metrics", nil)
+	req.Header.Set("Accept", "application/openmetrics-text")
+	w := httptest.NewRecorder()
+	nm.HandlerFunc()(w, req)
+
+	body, err := io.ReadAll(w.Result().Body)
+	require.NoError(t, err)
+
+	assert.Contains(t, string(body), `# {trace_id="4bf92f3577b34da6a3ce929d0e0e4736"} 1.3e-08`)
+	assert.Contains(t, string(body), "\ntimertwo_count{label1=\"value1\"} 1")
+	assert.Equal(t, 1, strings.Count(string(body), "trace_id"))
+}
+
 func TestPrometheusWithFileOutputPath(t *testing.T) {
 	config := metrics.NewConfig()
 	config.Prometheus.FileOutputPath = os.TempDir() + "/benthos_metrics.prom"
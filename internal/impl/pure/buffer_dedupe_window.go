@@ -0,0 +1,351 @@
+package pure
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/OneOfOne/xxhash"
+
+	"github.com/benthosdev/benthos/v4/internal/component"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func dedupeWindowBufferConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		Beta().
+		Categories("Utility").
+		Version("4.1.0").
+		Summary("Stores consumed messages in memory and drops messages that are duplicates of another message seen within a rolling window, where duplicates are identified by an interpolated key.").
+		Description(`
+This buffer is useful when consuming from sources with at-least-once delivery guarantees that frequently redeliver messages, preventing repeated messages from flooding downstream sinks.
+
+The hashes of the keys of recently seen messages are stored within a ring of fixed capacity configured with `+"`size`"+`. A message is considered a duplicate if the hash of its key exists within the ring and was added less than `+"`period`"+` ago. Once the ring is full the oldest hashes are overwritten, and therefore duplicates are only detected within the most recent `+"`size`"+` distinct keys. Since only hashes of keys are stored it is possible, although extremely unlikely, for messages with distinct keys to be considered duplicates of each other.
+
+## Delivery Guarantees
+
+This buffer honours the transaction model within Benthos, messages are not acknowledged until they are either delivered to outputs or dropped as duplicates. If the delivery of a message fails it is placed back into the buffer and retried, and therefore the window of keys is not affected by failed deliveries.
+
+Since the window of keys is held in memory it is lost when Benthos restarts.`).
+		Field(service.NewInterpolatedStringField("key").
+			Description("An interpolated string that identifies messages, where messages resolving to the same key are duplicates of each other.").
+			Example(`${! meta("kafka_key") }`).
+			Example(`${! json("id") }`)).
+		Field(service.NewDurationField("period").
+			Description("The period of time after which a key is no longer considered when detecting duplicates.").
+			Default("1m")).
+		Field(service.NewIntField("size").
+			Description("The maximum number of keys to store within the window, once exceeded the oldest keys are removed.").
+			Default(100000)).
+		Field(service.NewIntField("limit").
+			Description(`The maximum buffer size (in bytes) to allow before applying backpressure upstream.`).
+			Default(524288000).
+			Advanced()).
+		Example("Dedupe Kafka Redeliveries", `
+Drop messages that share a key with another message consumed within the last five minutes.`, `
+input:
+  kafka:
+    addresses: [ TODO ]
+    topics: [ foo ]
+    consumer_group: benthos_group
+
+buffer:
+  dedupe_window:
+    key: ${! meta("kafka_key") }
+    period: 5m
+`)
+}
+
+func init() {
+	err := service.RegisterBatchBuffer(
+		"dedupe_window", dedupeWindowBufferConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.BatchBuffer, error) {
+			return newDedupeWindowBufferFromConfig(conf)
+		})
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+func newDedupeWindowBufferFromConfig(conf *service.ParsedConfig) (*dedupeWindowBuffer, error) {
+	key, err := conf.FieldInterpolatedString("key")
+	if err != nil {
+		return nil, err
+	}
+	period, err := conf.FieldDuration("period")
+	if err != nil {
+		return nil, err
+	}
+	size, err := conf.FieldInt("size")
+	if err != nil {
+		return nil, err
+	}
+	limit, err := conf.FieldInt("limit")
+	if err != nil {
+		return nil, err
+	}
+	return newDedupeWindowBuffer(key, period, size, limit, time.Now)
+}
+
+//------------------------------------------------------------------------------
+
+type seenKey struct {
+	hash uint64
+	ts   time.Time
+}
+
+// keyRing tracks the hashes of recently seen keys in order of insertion.
+type keyRing struct {
+	entries []seenKey
+	head    int
+	length  int
+
+	// The time each hash was most recently added to the ring.
+	latest map[uint64]time.Time
+}
+
+func newKeyRing(size int) *keyRing {
+	return &keyRing{
+		entries: make([]seenKey, size),
+		latest:  make(map[uint64]time.Time, size),
+	}
+}
+
+func (r *keyRing) popOldest() {
+	oldest := r.entries[r.head]
+	if ts, exists := r.latest[oldest.hash]; exists && ts.Equal(oldest.ts) {
+		delete(r.latest, oldest.hash)
+	}
+	r.entries[r.head] = seenKey{}
+	r.head = (r.head + 1) % len(r.entries)
+	r.length--
+}
+
+// expire removes keys that were added before a given time.
+func (r *keyRing) expire(before time.Time) {
+	for r.length > 0 && r.entries[r.head].ts.Before(before) {
+		r.popOldest()
+	}
+}
+
+func (r *keyRing) contains(hash uint64) bool {
+	_, exists := r.latest[hash]
+	return exists
+}
+
+func (r *keyRing) add(hash uint64, ts time.Time) {
+	if r.length == len(r.entries) {
+		r.popOldest()
+	}
+	r.entries[(r.head+r.length)%len(r.entries)] = seenKey{hash: hash, ts: ts}
+	r.length++
+	r.latest[hash] = ts
+}
+
+//------------------------------------------------------------------------------
+
+type pendingBatch struct {
+	b     service.MessageBatch
+	size  int
+	ackFn service.AckFunc
+}
+
+type dedupeWindowBuffer struct {
+	key    *service.InterpolatedString
+	period time.Duration
+	clock  func() time.Time
+
+	ring    *keyRing
+	pending []pendingBatch
+	bytes   int
+
+	cap        int
+	cond       *sync.Cond
+	endOfInput bool
+	closed     bool
+}
+
+func newDedupeWindowBuffer(key *service.InterpolatedString, period time.Duration, size, limit int, clock func() time.Time) (*dedupeWindowBuffer, error) {
+	if period <= 0 {
+		return nil, errors.New("period must be larger than zero")
+	}
+	if size <= 0 {
+		return nil, errors.New("size must be larger than zero")
+	}
+	return &dedupeWindowBuffer{
+		key:    key,
+		period: period,
+		clock:  clock,
+		ring:   newKeyRing(size),
+		cap:    limit,
+		cond:   sync.NewCond(&sync.Mutex{}),
+	}, nil
+}
+
+//------------------------------------------------------------------------------
+
+func (d *dedupeWindowBuffer) ReadBatch(ctx context.Context) (service.MessageBatch, service.AckFunc, error) {
+	ctx, done := context.WithCancel(ctx)
+	defer done()
+
+	go func() {
+		<-ctx.Done()
+		d.cond.Broadcast()
+	}()
+
+	d.cond.L.Lock()
+	defer d.cond.L.Unlock()
+
+	for len(d.pending) == 0 {
+		// Once input has ended we wait for all batches in flight to be
+		// acknowledged as they might yet be placed back into the buffer.
+		if d.closed || (d.endOfInput && d.bytes == 0) {
+			return nil, nil, service.ErrEndOfBuffer
+		}
+		if ctx.Err() != nil {
+			return nil, nil, ctx.Err()
+		}
+		d.cond.Wait()
+	}
+
+	next := d.pending[0]
+	d.pending[0] = pendingBatch{}
+	d.pending = d.pending[1:]
+
+	return next.b, func(ctx context.Context, err error) error {
+		d.cond.L.Lock()
+		if err == nil {
+			d.bytes -= next.size
+		} else {
+			// Failed deliveries are placed back at the front of the buffer so
+			// that they are retried before any newer messages.
+			d.pending = append([]pendingBatch{next}, d.pending...)
+		}
+		d.cond.Broadcast()
+		d.cond.L.Unlock()
+
+		if err != nil {
+			return nil
+		}
+		return next.ackFn(ctx, nil)
+	}, nil
+}
+
+// dedupe returns the messages of a batch whose keys have not been seen within
+// the window nor earlier within the same batch, along with the hashes of their
+// keys and their total size in bytes.
+func (d *dedupeWindowBuffer) dedupe(msgBatch service.MessageBatch) (deduped service.MessageBatch, hashes []uint64, size int, err error) {
+	batchHashes := map[uint64]struct{}{}
+	for _, msg := range msgBatch {
+		hash := xxhash.ChecksumString64(d.key.String(msg))
+		if _, exists := batchHashes[hash]; exists || d.ring.contains(hash) {
+			continue
+		}
+		batchHashes[hash] = struct{}{}
+
+		mBytes, err := msg.AsBytes()
+		if err != nil {
+			return nil, nil, 0, err
+		}
+		size += len(mBytes)
+		deduped = append(deduped, msg)
+		hashes = append(hashes, hash)
+	}
+	return
+}
+
+func (d *dedupeWindowBuffer) WriteBatch(ctx context.Context, msgBatch service.MessageBatch, aFn service.AckFunc) error {
+	ctx, done := context.WithCancel(ctx)
+	defer done()
+
+	go func() {
+		<-ctx.Done()
+		d.cond.Broadcast()
+	}()
+
+	allDuplicates, err := d.bufferBatch(ctx, msgBatch, aFn)
+	if err != nil {
+		return err
+	}
+	if allDuplicates {
+		// All messages were duplicates and are therefore acknowledged
+		// straight away.
+		return aFn(ctx, nil)
+	}
+	return nil
+}
+
+// bufferBatch adds the deduplicated messages of a batch to the buffer, waiting
+// for capacity if necessary. Returns true without buffering anything if all
+// messages of the batch are duplicates.
+func (d *dedupeWindowBuffer) bufferBatch(ctx context.Context, msgBatch service.MessageBatch, aFn service.AckFunc) (bool, error) {
+	d.cond.L.Lock()
+	defer d.cond.L.Unlock()
+
+	var now time.Time
+	var deduped service.MessageBatch
+	var hashes []uint64
+	var extraBytes int
+	for {
+		if d.closed {
+			return false, component.ErrTypeClosed
+		}
+
+		// The window is checked again each time we wait for capacity as other
+		// writes may have added the same keys in the meantime.
+		now = d.clock()
+		d.ring.expire(now.Add(-d.period))
+
+		var err error
+		if deduped, hashes, extraBytes, err = d.dedupe(msgBatch); err != nil {
+			return false, err
+		}
+		if len(deduped) == 0 {
+			return true, nil
+		}
+		if extraBytes > d.cap {
+			return false, component.ErrMessageTooLarge
+		}
+		if (d.bytes + extraBytes) <= d.cap {
+			break
+		}
+		if ctx.Err() != nil {
+			return false, ctx.Err()
+		}
+		d.cond.Wait()
+	}
+
+	d.pending = append(d.pending, pendingBatch{
+		b:     deduped.Copy(),
+		size:  extraBytes,
+		ackFn: aFn,
+	})
+	d.bytes += extraBytes
+
+	// Keys are only added to the window once the messages are buffered, as a
+	// write rejected before this point is redelivered and must not be
+	// considered a duplicate.
+	for _, hash := range hashes {
+		d.ring.add(hash, now)
+	}
+
+	d.cond.Broadcast()
+	return false, nil
+}
+
+func (d *dedupeWindowBuffer) EndOfInput() {
+	d.cond.L.Lock()
+	d.endOfInput = true
+	d.cond.Broadcast()
+	d.cond.L.Unlock()
+}
+
+func (d *dedupeWindowBuffer) Close(ctx context.Context) error {
+	d.cond.L.Lock()
+	d.closed = true
+	d.cond.Broadcast()
+	d.cond.L.Unlock()
+	return nil
+}
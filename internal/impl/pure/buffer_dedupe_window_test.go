@@ -0,0 +1,257 @@
+package pure
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/OneOfOne/xxhash"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/component"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func dedupeBufFromConf(t *testing.T, conf string, clock func() time.Time) *dedupeWindowBuffer {
+	t.Helper()
+
+	parsedConf, err := dedupeWindowBufferConfig().ParseYAML(conf, nil)
+	require.NoError(t, err)
+
+	buf, err := newDedupeWindowBufferFromConfig(parsedConf)
+	require.NoError(t, err)
+
+	if clock != nil {
+		buf.clock = clock
+	}
+	return buf
+}
+
+func TestDedupeWindowBasic(t *testing.T) {
+	ctx := context.Background()
+
+	now := time.Unix(1000, 0)
+	buf := dedupeBufFromConf(t, `
+key: ${! content() }
+period: 1m
+`, func() time.Time { return now })
+	defer buf.Close(ctx)
+
+	var acked []string
+	ackFor := func(name string) service.AckFunc {
+		return func(ctx context.Context, err error) error {
+			require.NoError(t, err)
+			acked = append(acked, name)
+			return nil
+		}
+	}
+
+	require.NoError(t, buf.WriteBatch(ctx, service.MessageBatch{
+		service.NewMessage([]byte("foo")),
+		service.NewMessage([]byte("bar")),
+		service.NewMessage([]byte("foo")),
+	}, ackFor("first")))
+
+	require.NoError(t, buf.WriteBatch(ctx, service.MessageBatch{
+		service.NewMessage([]byte("bar")),
+	}, ackFor("second")))
+	assert.Equal(t, []string{"second"}, acked)
+
+	now = now.Add(time.Minute + time.Second)
+	require.NoError(t, buf.WriteBatch(ctx, service.MessageBatch{
+		service.NewMessage([]byte("foo")),
+		service.NewMessage([]byte("baz")),
+	}, ackFor("third")))
+
+	b, aFn, err := buf.ReadBatch(ctx)
+	require.NoError(t, err)
+	require.Len(t, b, 2)
+	msgEqual(t, "foo", b[0])
+	msgEqual(t, "bar", b[1])
+	require.NoError(t, aFn(ctx, nil))
+	assert.Equal(t, []string{"second", "first"}, acked)
+
+	b, aFn, err = buf.ReadBatch(ctx)
+	require.NoError(t, err)
+	require.Len(t, b, 2)
+	msgEqual(t, "foo", b[0])
+	msgEqual(t, "baz", b[1])
+	require.NoError(t, aFn(ctx, nil))
+	assert.Equal(t, []string{"second", "first", "third"}, acked)
+}
+
+func TestDedupeWindowSize(t *testing.T) {
+	ctx := context.Background()
+	buf := dedupeBufFromConf(t, `
+key: ${! content() }
+size: 2
+`, nil)
+	defer buf.Close(ctx)
+
+	noopAck := func(ctx context.Context, err error) error { return nil }
+	for _, v := range []string{"foo", "bar", "foo", "baz", "foo"} {
+		require.NoError(t, buf.WriteBatch(ctx, service.MessageBatch{
+			service.NewMessage([]byte(v)),
+		}, noopAck))
+	}
+	buf.EndOfInput()
+
+	var results []string
+	for {
+		b, aFn, err := buf.ReadBatch(ctx)
+		if errors.Is(err, service.ErrEndOfBuffer) {
+			break
+		}
+		require.NoError(t, err)
+		for _, m := range b {
+			mBytes, err := m.AsBytes()
+			require.NoError(t, err)
+			results = append(results, string(mBytes))
+		}
+		require.NoError(t, aFn(ctx, nil))
+	}
+	assert.Equal(t, []string{"foo", "bar", "baz", "foo"}, results)
+}
+
+func TestDedupeWindowNack(t *testing.T) {
+	ctx := context.Background()
+	buf := dedupeBufFromConf(t, `
+key: ${! content() }
+`, nil)
+	defer buf.Close(ctx)
+
+	var ackErrs []error
+	require.NoError(t, buf.WriteBatch(ctx, service.MessageBatch{
+		service.NewMessage([]byte("foo")),
+	}, func(ctx context.Context, err error) error {
+		ackErrs = append(ackErrs, err)
+		return nil
+	}))
+	buf.EndOfInput()
+
+	b, aFn, err := buf.ReadBatch(ctx)
+	require.NoError(t, err)
+	require.Len(t, b, 1)
+	require.NoError(t, aFn(ctx, errors.New("nope")))
+	assert.Empty(t, ackErrs)
+
+	b, aFn, err = buf.ReadBatch(ctx)
+	require.NoError(t, err)
+	require.Len(t, b, 1)
+	msgEqual(t, "foo", b[0])
+	require.NoError(t, aFn(ctx, nil))
+	assert.Equal(t, []error{nil}, ackErrs)
+
+	_, _, err = buf.ReadBatch(ctx)
+	assert.Equal(t, service.ErrEndOfBuffer, err)
+}
+
+func TestDedupeWindowClosedWhileBlocked(t *testing.T) {
+	ctx := context.Background()
+	buf := dedupeBufFromConf(t, `
+key: ${! content() }
+limit: 3
+`, nil)
+
+	noopAck := func(ctx context.Context, err error) error { return nil }
+	require.NoError(t, buf.WriteBatch(ctx, service.MessageBatch{
+		service.NewMessage([]byte("foo")),
+	}, noopAck))
+
+	errChan := make(chan error)
+	go func() {
+		errChan <- buf.WriteBatch(ctx, service.MessageBatch{
+			service.NewMessage([]byte("bar")),
+		}, noopAck)
+	}()
+
+	select {
+	case err := <-errChan:
+		t.Fatalf("expected write to block, got: %v", err)
+	case <-time.After(time.Millisecond * 50):
+	}
+
+	require.NoError(t, buf.Close(ctx))
+	select {
+	case err := <-errChan:
+		assert.Equal(t, component.ErrTypeClosed, err)
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+
+	// The rejected message must not be recorded within the window, otherwise
+	// its redelivery would be dropped as a duplicate.
+	assert.False(t, buf.ring.contains(xxhash.ChecksumString64("bar")))
+	assert.True(t, buf.ring.contains(xxhash.ChecksumString64("foo")))
+}
+
+func TestDedupeWindowCancelledWhileBlocked(t *testing.T) {
+	ctx := context.Background()
+	buf := dedupeBufFromConf(t, `
+key: ${! content() }
+limit: 3
+`, nil)
+	defer buf.Close(ctx)
+
+	noopAck := func(ctx context.Context, err error) error { return nil }
+	require.NoError(t, buf.WriteBatch(ctx, service.MessageBatch{
+		service.NewMessage([]byte("foo")),
+	}, noopAck))
+
+	writeCtx, cancel := context.WithCancel(ctx)
+	errChan := make(chan error)
+	go func() {
+		errChan <- buf.WriteBatch(writeCtx, service.MessageBatch{
+			service.NewMessage([]byte("bar")),
+		}, noopAck)
+	}()
+
+	select {
+	case err := <-errChan:
+		t.Fatalf("expected write to block, got: %v", err)
+	case <-time.After(time.Millisecond * 50):
+	}
+
+	cancel()
+	select {
+	case err := <-errChan:
+		assert.Equal(t, context.Canceled, err)
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+	assert.False(t, buf.ring.contains(xxhash.ChecksumString64("bar")))
+}
+
+func TestDedupeWindowDuplicateAckUnlocked(t *testing.T) {
+	ctx := context.Background()
+	buf := dedupeBufFromConf(t, `
+key: ${! content() }
+`, nil)
+	defer buf.Close(ctx)
+
+	noopAck := func(ctx context.Context, err error) error { return nil }
+	require.NoError(t, buf.WriteBatch(ctx, service.MessageBatch{
+		service.NewMessage([]byte("foo")),
+	}, noopAck))
+
+	// The ack of a duplicate accesses the buffer, which would deadlock if the
+	// ack were called whilst holding the buffer lock.
+	errChan := make(chan error)
+	go func() {
+		errChan <- buf.WriteBatch(ctx, service.MessageBatch{
+			service.NewMessage([]byte("foo")),
+		}, func(ctx context.Context, err error) error {
+			buf.EndOfInput()
+			return err
+		})
+	}()
+
+	select {
+	case err := <-errChan:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+}
@@ -0,0 +1,542 @@
+package pure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/internal/batch"
+	"github.com/benthosdev/benthos/v4/public/bloblang"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const (
+	windowTypeTumbling = "tumbling"
+	windowTypeSliding  = "sliding"
+	windowTypeSession  = "session"
+)
+
+func windowBufferConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		Beta().
+		Version("4.1.0").
+		Categories("Windowing").
+		Summary("Groups messages by an interpolated key into tumbling, sliding or session windows, and flushes each window of each key as a batch once it has ended according to the system clock.").
+		Description(`
+Messages are allocated to windows by a timestamp, which is either the time at which they're ingested (the processing time) or a time extracted from the message itself (the event time), and this is controlled via the `+"[`timestamp_mapping` field](#timestamp_mapping)"+`. Each key resolved by the `+"[`key` field](#key)"+` has its own set of windows, and therefore each flushed batch only contains messages of a single key. This makes it possible to aggregate each batch, for example with a `+"[`bloblang` processor](/docs/components/processors/bloblang)"+`, into a single summary message.
+
+A window is flushed once the system clock surpasses its end plus the `+"[`allowed_lateness`](#allowed_lateness)"+`, and messages that arrive after the window they belong to has been flushed are dropped.
+
+Each message of a flushed batch has the metadata fields `+"`window_key`"+`, `+"`window_start_timestamp` and `window_end_timestamp`"+` added to it, where the timestamps are RFC3339 strings.
+
+## Window Types
+
+### Tumbling
+
+Tumbling windows are of a fixed `+"`size`"+`, and the beginning of a window immediately follows the end of the prior window. Windows are aligned against the zeroth minute of the zeroth hour of the day on the UTC clock.
+
+### Sliding
+
+Sliding windows are of a fixed `+"`size`"+` but begin at an interval of `+"`slide`"+` from the beginning of the prior window, and therefore messages may belong to multiple windows.
+
+### Session
+
+Session windows have no fixed size, a session of a key begins with the first message of that key and is extended by each message that arrives within the `+"`gap`"+` of another message of the session. A session ends once no messages of the key have been seen for the duration of the `+"`gap`"+`, and messages that bridge the gap between two sessions of a key merge them into one.
+
+## Delivery Guarantees
+
+This buffer honours the transaction model within Benthos in order to ensure that messages are not acknowledged until they are either intentionally dropped or successfully delivered to outputs. A message belonging to multiple sliding windows is only acknowledged once all of those windows have been delivered.
+
+During graceful termination any windows that have not yet been flushed are nacked such that their messages are re-consumed the next time the service starts.
+
+Since all windows are held in memory you should ensure that you have enough system memory to store the messages of all open windows at a given time, including any allowed lateness.
+`).
+		Field(service.NewInterpolatedStringField("key").
+			Description("An interpolated string that resolves the key of each message, where each key has its own windows. When empty all messages share the same windows.").
+			Example(`${! json("user_id") }`).
+			Example(`${! meta("kafka_key") }`).
+			Default("")).
+		Field(service.NewBloblangField("timestamp_mapping").
+			Description(`
+A [Bloblang mapping](/docs/guides/bloblang/about) applied to each message during ingestion that provides the timestamp to use for allocating it a window. By default the function `+"`now()`"+` is used in order to generate a fresh timestamp at the time of ingestion (the processing time), whereas this mapping can instead extract a timestamp from the message itself (the event time).
+
+The timestamp value assigned to `+"`root`"+` must either be a numerical unix time in seconds (with up to nanosecond precision via decimals), or a string in ISO 8601 format. If the mapping fails or provides an invalid result the message will be rejected (with logging to describe the problem).
+`).
+			Default("root = now()").
+			Example("root = this.created_at").Example(`root = meta("kafka_timestamp_unix").number()`)).
+		Field(service.NewStringEnumField("type", windowTypeTumbling, windowTypeSliding, windowTypeSession).
+			Description("The type of windows to create.").
+			Default(windowTypeTumbling)).
+		Field(service.NewStringField("size").
+			Description("A duration string describing the size of each window, which is required for `tumbling` and `sliding` windows.").
+			Default("").
+			Example("30s").Example("10m")).
+		Field(service.NewStringField("slide").
+			Description("A duration string describing by how much time the beginning of each window is offset from the beginning of the previous, which is required for `sliding` windows and must be smaller than the `size`.").
+			Default("").
+			Example("30s").Example("1m")).
+		Field(service.NewStringField("gap").
+			Description("A duration string describing the period of inactivity after which a session ends, which is required for `session` windows.").
+			Default("").
+			Example("30s").Example("5m")).
+		Field(service.NewStringField("allowed_lateness").
+			Description("An optional duration string describing the length of time to wait after a window has ended before flushing it, allowing late arrivals to be included.").
+			Default("").
+			Example("10s").Example("1m")).
+		Example("User Sessions", `Given a stream of click events of the form:
+
+`+"```json"+`
+{
+  "user_id": "bd4f8e08",
+  "page": "/checkout",
+  "created_at": "2021-08-07T09:49:35Z"
+}
+`+"```"+`
+
+We can group the events of each user into sessions that end after five minutes of inactivity, and reduce each session to a single summary message:`,
+			`
+buffer:
+  window:
+    type: session
+    key: ${! json("user_id") }
+    timestamp_mapping: root = this.created_at
+    gap: 5m
+    allowed_lateness: 30s
+
+pipeline:
+  processors:
+    - bloblang: |
+        root = if batch_index() == 0 {
+          {
+            "user_id": this.user_id,
+            "started_at": meta("window_start_timestamp"),
+            "ended_at": meta("window_end_timestamp"),
+            "pages": json("page").from_all(),
+          }
+        } else { deleted() }
+`,
+		)
+}
+
+func init() {
+	err := service.RegisterBatchBuffer(
+		"window", windowBufferConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.BatchBuffer, error) {
+			return newWindowBufferFromConfig(conf, mgr.Logger())
+		})
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+func newWindowBufferFromConfig(conf *service.ParsedConfig, logger *service.Logger) (*windowBuffer, error) {
+	key, err := conf.FieldInterpolatedString("key")
+	if err != nil {
+		return nil, err
+	}
+	tsMapping, err := conf.FieldBloblang("timestamp_mapping")
+	if err != nil {
+		return nil, err
+	}
+	wType, err := conf.FieldString("type")
+	if err != nil {
+		return nil, err
+	}
+	size, err := getDuration(conf, false, "size")
+	if err != nil {
+		return nil, err
+	}
+	slide, err := getDuration(conf, false, "slide")
+	if err != nil {
+		return nil, err
+	}
+	gap, err := getDuration(conf, false, "gap")
+	if err != nil {
+		return nil, err
+	}
+	allowedLateness, err := getDuration(conf, false, "allowed_lateness")
+	if err != nil {
+		return nil, err
+	}
+
+	switch wType {
+	case windowTypeTumbling:
+		if size <= 0 {
+			return nil, errors.New("a size must be specified for tumbling windows")
+		}
+		if slide != 0 || gap != 0 {
+			return nil, errors.New("the fields slide and gap cannot be used with tumbling windows")
+		}
+		slide = size
+	case windowTypeSliding:
+		if size <= 0 || slide <= 0 {
+			return nil, errors.New("a size and slide must be specified for sliding windows")
+		}
+		if slide >= size {
+			return nil, fmt.Errorf("invalid window slide '%v' must be lower than the size '%v'", slide, size)
+		}
+		if gap != 0 {
+			return nil, errors.New("the field gap cannot be used with sliding windows")
+		}
+	case windowTypeSession:
+		if gap <= 0 {
+			return nil, errors.New("a gap must be specified for session windows")
+		}
+		if size != 0 || slide != 0 {
+			return nil, errors.New("the fields size and slide cannot be used with session windows")
+		}
+	default:
+		return nil, fmt.Errorf("window type not recognised: %v", wType)
+	}
+	if allowedLateness < 0 {
+		return nil, fmt.Errorf("invalid allowed_lateness '%v' must not be negative", allowedLateness)
+	}
+
+	return newWindowBuffer(key, tsMapping, func() time.Time {
+		return time.Now().UTC()
+	}, wType == windowTypeSession, size, slide, gap, allowedLateness, logger), nil
+}
+
+//------------------------------------------------------------------------------
+
+// windowedMessage is a message that belongs to one or more windows, and is
+// acknowledged once all of those windows have been acknowledged.
+type windowedMessage struct {
+	ts    time.Time
+	m     *service.Message
+	ackFn service.AckFunc
+
+	mut       sync.Mutex
+	remaining int
+	err       error
+}
+
+func (w *windowedMessage) ack(ctx context.Context, err error) {
+	w.mut.Lock()
+	if err != nil {
+		w.err = err
+	}
+	w.remaining--
+	remaining, ackErr := w.remaining, w.err
+	w.mut.Unlock()
+
+	if remaining == 0 {
+		_ = w.ackFn(ctx, ackErr)
+	}
+}
+
+type openWindow struct {
+	key string
+
+	// Both the start and end of a window are inclusive.
+	start, end time.Time
+	msgs       []*windowedMessage
+}
+
+// flushesBefore returns whether a window should be flushed before another,
+// where windows that end at the same time are ordered by their keys.
+func (o *openWindow) flushesBefore(other *openWindow) bool {
+	if o.end.Equal(other.end) {
+		return o.key < other.key
+	}
+	return o.end.Before(other.end)
+}
+
+type windowID struct {
+	key   string
+	start int64
+}
+
+type windowBuffer struct {
+	logger *service.Logger
+
+	key       *service.InterpolatedString
+	tsMapping *bloblang.Executor
+	clock     utcNowProvider
+
+	session                           bool
+	size, slide, gap, allowedLateness time.Duration
+
+	mut      sync.Mutex
+	windows  map[windowID]*openWindow
+	sessions map[string][]*openWindow
+
+	notifyChan chan struct{}
+
+	endOfInputChan      chan struct{}
+	closeEndOfInputOnce sync.Once
+}
+
+func newWindowBuffer(
+	key *service.InterpolatedString,
+	tsMapping *bloblang.Executor,
+	clock utcNowProvider,
+	session bool,
+	size, slide, gap, allowedLateness time.Duration,
+	logger *service.Logger,
+) *windowBuffer {
+	return &windowBuffer{
+		logger:          logger,
+		key:             key,
+		tsMapping:       tsMapping,
+		clock:           clock,
+		session:         session,
+		size:            size,
+		slide:           slide,
+		gap:             gap,
+		allowedLateness: allowedLateness,
+		windows:         map[windowID]*openWindow{},
+		sessions:        map[string][]*openWindow{},
+		notifyChan:      make(chan struct{}, 1),
+		endOfInputChan:  make(chan struct{}),
+	}
+}
+
+// expired returns whether a window ending at a given time should be flushed.
+func (w *windowBuffer) expired(end time.Time, now time.Time) bool {
+	return !now.Before(end.Add(w.allowedLateness))
+}
+
+// addToFixedWindows adds a message to all open tumbling or sliding windows that
+// it fits within, and returns the number of windows it was added to.
+func (w *windowBuffer) addToFixedWindows(key string, msg *windowedMessage, now time.Time) int {
+	added := 0
+	for start := msg.ts.Truncate(w.slide); start.Add(w.size).After(msg.ts); start = start.Add(-w.slide) {
+		end := start.Add(w.size - 1)
+		if w.expired(end, now) {
+			break
+		}
+
+		id := windowID{key: key, start: start.UnixNano()}
+		win, exists := w.windows[id]
+		if !exists {
+			win = &openWindow{key: key, start: start, end: end}
+			w.windows[id] = win
+		}
+		win.msgs = append(win.msgs, msg)
+		added++
+	}
+	return added
+}
+
+// addToSession adds a message to the session of a key that it falls within the
+// gap of, merging any sessions that the message bridges, and returns the
+// number of windows it was added to.
+func (w *windowBuffer) addToSession(key string, msg *windowedMessage, now time.Time) int {
+	if w.expired(msg.ts.Add(w.gap), now) {
+		return 0
+	}
+
+	merged := &openWindow{
+		key:   key,
+		start: msg.ts,
+		end:   msg.ts.Add(w.gap),
+		msgs:  []*windowedMessage{msg},
+	}
+
+	sessions := w.sessions[key]
+	remaining := sessions[:0]
+	for _, s := range sessions {
+		if msg.ts.Before(s.start.Add(-w.gap)) || msg.ts.After(s.end) {
+			remaining = append(remaining, s)
+			continue
+		}
+		if s.start.Before(merged.start) {
+			merged.start = s.start
+		}
+		if s.end.After(merged.end) {
+			merged.end = s.end
+		}
+		merged.msgs = append(s.msgs, merged.msgs...)
+	}
+	w.sessions[key] = append(remaining, merged)
+	return 1
+}
+
+func (w *windowBuffer) WriteBatch(ctx context.Context, msgBatch service.MessageBatch, aFn service.AckFunc) error {
+	w.mut.Lock()
+	defer w.mut.Unlock()
+
+	now := w.clock()
+
+	type keyedMessage struct {
+		key string
+		msg *windowedMessage
+	}
+	msgs := make([]keyedMessage, len(msgBatch))
+	for i, msg := range msgBatch {
+		ts, err := getMappedTimestamp(w.tsMapping, w.logger, i, msgBatch)
+		if err != nil {
+			return err
+		}
+		msgs[i] = keyedMessage{
+			key: msgBatch.InterpolatedString(i, w.key),
+			msg: &windowedMessage{ts: ts, m: msg},
+		}
+	}
+
+	var derived []*windowedMessage
+	for _, km := range msgs {
+		var added int
+		if w.session {
+			added = w.addToSession(km.key, km.msg, now)
+		} else {
+			added = w.addToFixedWindows(km.key, km.msg, now)
+		}
+		if added == 0 {
+			w.logger.Debugf("Dropping message as its window has already been flushed")
+			continue
+		}
+		km.msg.remaining = added
+		derived = append(derived, km.msg)
+	}
+
+	if len(derived) == 0 {
+		// If none of the messages have fit into a window we reject them by
+		// acknowledging the batch.
+		_ = aFn(ctx, nil)
+		return nil
+	}
+
+	aggregatedAck := batch.NewCombinedAcker(batch.AckFunc(aFn))
+	for _, msg := range derived {
+		msg.ackFn = service.AckFunc(aggregatedAck.Derive())
+	}
+
+	select {
+	case w.notifyChan <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// popExpired removes and returns the window that expired first, or if no
+// window has expired returns the duration until the next window expires, which
+// is negative if there are no windows.
+func (w *windowBuffer) popExpired(now time.Time) (*openWindow, time.Duration) {
+	w.mut.Lock()
+	defer w.mut.Unlock()
+
+	var next *openWindow
+	var nextSessionIndex int
+	if w.session {
+		for _, sessions := range w.sessions {
+			for i, s := range sessions {
+				if next == nil || s.flushesBefore(next) {
+					next, nextSessionIndex = s, i
+				}
+			}
+		}
+	} else {
+		for _, win := range w.windows {
+			if next == nil || win.flushesBefore(next) {
+				next = win
+			}
+		}
+	}
+
+	if next == nil {
+		return nil, -1
+	}
+	if !w.expired(next.end, now) {
+		return nil, next.end.Add(w.allowedLateness).Sub(now)
+	}
+
+	if w.session {
+		sessions := w.sessions[next.key]
+		if sessions = append(sessions[:nextSessionIndex], sessions[nextSessionIndex+1:]...); len(sessions) == 0 {
+			delete(w.sessions, next.key)
+		} else {
+			w.sessions[next.key] = sessions
+		}
+	} else {
+		delete(w.windows, windowID{key: next.key, start: next.start.UnixNano()})
+	}
+	return next, 0
+}
+
+func (w *windowBuffer) flushWindow(win *openWindow) (service.MessageBatch, service.AckFunc) {
+	flushBatch := make(service.MessageBatch, 0, len(win.msgs))
+	for _, msg := range win.msgs {
+		tmpMsg := msg.m.Copy()
+		tmpMsg.MetaSet("window_key", win.key)
+		tmpMsg.MetaSet("window_start_timestamp", win.start.Format(time.RFC3339Nano))
+		tmpMsg.MetaSet("window_end_timestamp", win.end.Format(time.RFC3339Nano))
+		flushBatch = append(flushBatch, tmpMsg)
+	}
+	return flushBatch, func(ctx context.Context, err error) error {
+		for _, msg := range win.msgs {
+			msg.ack(ctx, err)
+		}
+		return nil
+	}
+}
+
+// nackAll rejects all messages of windows that have not yet been flushed.
+func (w *windowBuffer) nackAll(ctx context.Context) {
+	w.mut.Lock()
+	defer w.mut.Unlock()
+
+	for _, win := range w.windows {
+		for _, msg := range win.msgs {
+			msg.ack(ctx, errWindowClosed)
+		}
+	}
+	for _, sessions := range w.sessions {
+		for _, s := range sessions {
+			for _, msg := range s.msgs {
+				msg.ack(ctx, errWindowClosed)
+			}
+		}
+	}
+	w.windows = map[windowID]*openWindow{}
+	w.sessions = map[string][]*openWindow{}
+}
+
+func (w *windowBuffer) ReadBatch(ctx context.Context) (msgBatch service.MessageBatch, aFn service.AckFunc, err error) {
+	for {
+		win, wait := w.popExpired(w.clock())
+		if win != nil {
+			msgBatch, aFn = w.flushWindow(win)
+			return
+		}
+
+		// When there are no windows we wait for a notification that one was
+		// created, as a nil timer channel blocks forever.
+		var timer *time.Timer
+		var timerChan <-chan time.Time
+		if wait >= 0 {
+			timer = time.NewTimer(wait)
+			timerChan = timer.C
+		}
+
+		select {
+		case <-timerChan:
+		case <-w.notifyChan:
+		case <-ctx.Done():
+			err = ctx.Err()
+		case <-w.endOfInputChan:
+			w.nackAll(ctx)
+			err = service.ErrEndOfBuffer
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+}
+
+func (w *windowBuffer) EndOfInput() {
+	w.closeEndOfInputOnce.Do(func() {
+		close(w.endOfInputChan)
+	})
+}
+
+func (w *windowBuffer) Close(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,379 @@
+package pure
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func TestWindowBufferConfigs(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		errStr string
+	}{
+		{
+			name:   "tumbling",
+			config: `size: 60m`,
+		},
+		{
+			name:   "tumbling without size",
+			config: `type: tumbling`,
+			errStr: "a size must be specified for tumbling windows",
+		},
+		{
+			name:   "tumbling with gap",
+			config: "size: 60m\ngap: 1m",
+			errStr: "the fields slide and gap cannot be used with tumbling windows",
+		},
+		{
+			name:   "sliding",
+			config: "type: sliding\nsize: 60m\nslide: 10m\nallowed_lateness: 1m",
+		},
+		{
+			name:   "sliding without slide",
+			config: "type: sliding\nsize: 60m",
+			errStr: "a size and slide must be specified for sliding windows",
+		},
+		{
+			name:   "sliding with large slide",
+			config: "type: sliding\nsize: 60m\nslide: 60m",
+			errStr: "invalid window slide '1h0m0s' must be lower than the size '1h0m0s'",
+		},
+		{
+			name:   "session",
+			config: "type: session\ngap: 5m",
+		},
+		{
+			name:   "session without gap",
+			config: "type: session",
+			errStr: "a gap must be specified for session windows",
+		},
+		{
+			name:   "session with size",
+			config: "type: session\ngap: 5m\nsize: 10m",
+			errStr: "the fields size and slide cannot be used with session windows",
+		},
+		{
+			name:   "negative lateness",
+			config: "size: 10m\nallowed_lateness: -1m",
+			errStr: "invalid allowed_lateness '-1m0s' must not be negative",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			pConf, err := windowBufferConfig().ParseYAML(test.config, nil)
+			require.NoError(t, err)
+
+			_, err = newWindowBufferFromConfig(pConf, nil)
+			if test.errStr != "" {
+				require.EqualError(t, err, test.errStr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+type windowTestClock struct {
+	mut sync.Mutex
+	now time.Time
+}
+
+func (c *windowTestClock) Now() time.Time {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	return c.now
+}
+
+func (c *windowTestClock) Set(ts string) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	var err error
+	if c.now, err = time.Parse(time.RFC3339Nano, ts); err != nil {
+		panic(err)
+	}
+}
+
+func newWindowBufferForTest(t *testing.T, conf string, clock *windowTestClock) *windowBuffer {
+	t.Helper()
+
+	pConf, err := windowBufferConfig().ParseYAML(conf, nil)
+	require.NoError(t, err)
+
+	w, err := newWindowBufferFromConfig(pConf, nil)
+	require.NoError(t, err)
+
+	w.clock = clock.Now
+	return w
+}
+
+func windowTestBatch(contents ...string) service.MessageBatch {
+	var batch service.MessageBatch
+	for _, c := range contents {
+		batch = append(batch, service.NewMessage([]byte(c)))
+	}
+	return batch
+}
+
+type windowTestResult struct {
+	key, start, end string
+	contents        []string
+}
+
+func readWindowForTest(t *testing.T, w *windowBuffer) (windowTestResult, service.AckFunc) {
+	t.Helper()
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second)
+	defer done()
+
+	batch, aFn, err := w.ReadBatch(ctx)
+	require.NoError(t, err)
+	require.NotEmpty(t, batch)
+
+	var res windowTestResult
+	res.key, _ = batch[0].MetaGet("window_key")
+	res.start, _ = batch[0].MetaGet("window_start_timestamp")
+	res.end, _ = batch[0].MetaGet("window_end_timestamp")
+	for _, m := range batch {
+		mBytes, err := m.AsBytes()
+		require.NoError(t, err)
+		res.contents = append(res.contents, string(mBytes))
+	}
+	return res, aFn
+}
+
+func TestWindowBufferTumbling(t *testing.T) {
+	clock := &windowTestClock{}
+	clock.Set("2021-08-07T10:00:30Z")
+
+	w := newWindowBufferForTest(t, `
+key: ${! json("key") }
+timestamp_mapping: root = this.ts
+size: 1m
+allowed_lateness: 10s
+`, clock)
+
+	var acked []error
+	ackFn := func(ctx context.Context, err error) error {
+		acked = append(acked, err)
+		return nil
+	}
+
+	require.NoError(t, w.WriteBatch(context.Background(), windowTestBatch(
+		`{"key":"b","ts":"2021-08-07T10:00:10Z"}`,
+		`{"key":"a","ts":"2021-08-07T10:00:20Z"}`,
+		`{"key":"a","ts":"2021-08-07T10:01:05Z"}`,
+	), ackFn))
+
+	clock.Set("2021-08-07T10:01:08Z")
+	require.NoError(t, w.WriteBatch(context.Background(), windowTestBatch(
+		`{"key":"a","ts":"2021-08-07T10:00:50Z"}`,
+	), ackFn))
+
+	clock.Set("2021-08-07T10:01:10Z")
+
+	res, aFnA := readWindowForTest(t, w)
+	assert.Equal(t, windowTestResult{
+		key:   "a",
+		start: "2021-08-07T10:00:00Z",
+		end:   "2021-08-07T10:00:59.999999999Z",
+		contents: []string{
+			`{"key":"a","ts":"2021-08-07T10:00:20Z"}`,
+			`{"key":"a","ts":"2021-08-07T10:00:50Z"}`,
+		},
+	}, res)
+
+	res, aFnB := readWindowForTest(t, w)
+	assert.Equal(t, windowTestResult{
+		key:      "b",
+		start:    "2021-08-07T10:00:00Z",
+		end:      "2021-08-07T10:00:59.999999999Z",
+		contents: []string{`{"key":"b","ts":"2021-08-07T10:00:10Z"}`},
+	}, res)
+
+	// Messages arriving after their window has been flushed are dropped.
+	require.NoError(t, w.WriteBatch(context.Background(), windowTestBatch(
+		`{"key":"a","ts":"2021-08-07T10:00:55Z"}`,
+	), ackFn))
+	assert.Equal(t, []error{nil}, acked)
+
+	require.NoError(t, aFnA(context.Background(), nil))
+	require.NoError(t, aFnB(context.Background(), nil))
+	assert.Equal(t, []error{nil, nil}, acked)
+
+	clock.Set("2021-08-07T10:02:10Z")
+	res, aFn := readWindowForTest(t, w)
+	assert.Equal(t, windowTestResult{
+		key:      "a",
+		start:    "2021-08-07T10:01:00Z",
+		end:      "2021-08-07T10:01:59.999999999Z",
+		contents: []string{`{"key":"a","ts":"2021-08-07T10:01:05Z"}`},
+	}, res)
+
+	require.NoError(t, aFn(context.Background(), nil))
+	assert.Equal(t, []error{nil, nil, nil}, acked)
+}
+
+func TestWindowBufferSliding(t *testing.T) {
+	clock := &windowTestClock{}
+	clock.Set("2021-08-07T10:00:30Z")
+
+	w := newWindowBufferForTest(t, `
+timestamp_mapping: root = this.ts
+type: sliding
+size: 1m
+slide: 30s
+`, clock)
+
+	var acked []error
+	require.NoError(t, w.WriteBatch(context.Background(), windowTestBatch(
+		`{"ts":"2021-08-07T10:00:40Z"}`,
+	), func(ctx context.Context, err error) error {
+		acked = append(acked, err)
+		return nil
+	}))
+
+	clock.Set("2021-08-07T10:01:40Z")
+
+	res, aFn := readWindowForTest(t, w)
+	assert.Equal(t, windowTestResult{
+		start:    "2021-08-07T10:00:00Z",
+		end:      "2021-08-07T10:00:59.999999999Z",
+		contents: []string{`{"ts":"2021-08-07T10:00:40Z"}`},
+	}, res)
+	require.NoError(t, aFn(context.Background(), nil))
+
+	// The message belongs to a second window and therefore isn't acknowledged
+	// until that is also delivered.
+	assert.Empty(t, acked)
+
+	res, aFn = readWindowForTest(t, w)
+	assert.Equal(t, windowTestResult{
+		start:    "2021-08-07T10:00:30Z",
+		end:      "2021-08-07T10:01:29.999999999Z",
+		contents: []string{`{"ts":"2021-08-07T10:00:40Z"}`},
+	}, res)
+
+	errFailed := errors.New("failed")
+	require.NoError(t, aFn(context.Background(), errFailed))
+	assert.Equal(t, []error{errFailed}, acked)
+}
+
+func TestWindowBufferSession(t *testing.T) {
+	clock := &windowTestClock{}
+	clock.Set("2021-08-07T10:00:00Z")
+
+	w := newWindowBufferForTest(t, `
+key: ${! json("key") }
+timestamp_mapping: root = this.ts
+type: session
+gap: 30s
+`, clock)
+
+	require.NoError(t, w.WriteBatch(context.Background(), windowTestBatch(
+		`{"key":"a","ts":"2021-08-07T10:00:00Z"}`,
+		`{"key":"a","ts":"2021-08-07T10:01:00Z"}`,
+		`{"key":"b","ts":"2021-08-07T10:00:10Z"}`,
+		`{"key":"a","ts":"2021-08-07T10:00:20Z"}`,
+	), noopAck))
+
+	// Bridges the gap between both sessions of key a, merging them.
+	require.NoError(t, w.WriteBatch(context.Background(), windowTestBatch(
+		`{"key":"a","ts":"2021-08-07T10:00:45Z"}`,
+	), noopAck))
+
+	clock.Set("2021-08-07T10:02:00Z")
+
+	res, _ := readWindowForTest(t, w)
+	assert.Equal(t, windowTestResult{
+		key:      "b",
+		start:    "2021-08-07T10:00:10Z",
+		end:      "2021-08-07T10:00:40Z",
+		contents: []string{`{"key":"b","ts":"2021-08-07T10:00:10Z"}`},
+	}, res)
+
+	res, _ = readWindowForTest(t, w)
+	assert.Equal(t, windowTestResult{
+		key:   "a",
+		start: "2021-08-07T10:00:00Z",
+		end:   "2021-08-07T10:01:30Z",
+		contents: []string{
+			`{"key":"a","ts":"2021-08-07T10:00:00Z"}`,
+			`{"key":"a","ts":"2021-08-07T10:00:20Z"}`,
+			`{"key":"a","ts":"2021-08-07T10:01:00Z"}`,
+			`{"key":"a","ts":"2021-08-07T10:00:45Z"}`,
+		},
+	}, res)
+}
+
+func TestWindowBufferEndOfInput(t *testing.T) {
+	clock := &windowTestClock{}
+	clock.Set("2021-08-07T10:00:00Z")
+
+	w := newWindowBufferForTest(t, `
+timestamp_mapping: root = this.ts
+size: 1m
+`, clock)
+
+	var acked []error
+	require.NoError(t, w.WriteBatch(context.Background(), windowTestBatch(
+		`{"ts":"2021-08-07T10:00:10Z"}`,
+		`{"ts":"2021-08-07T10:00:20Z"}`,
+	), func(ctx context.Context, err error) error {
+		acked = append(acked, err)
+		return nil
+	}))
+
+	go func() {
+		<-time.After(time.Millisecond * 50)
+		w.EndOfInput()
+	}()
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second)
+	defer done()
+
+	_, _, err := w.ReadBatch(ctx)
+	require.Equal(t, service.ErrEndOfBuffer, err)
+	assert.Equal(t, []error{errWindowClosed}, acked)
+}
+
+func TestWindowBufferWaitsForWindows(t *testing.T) {
+	w := newWindowBufferForTest(t, `size: 50ms`, &windowTestClock{})
+	w.clock = func() time.Time {
+		return time.Now().UTC()
+	}
+
+	readChan := make(chan service.MessageBatch)
+	go func() {
+		ctx, done := context.WithTimeout(context.Background(), time.Second)
+		defer done()
+
+		batch, _, err := w.ReadBatch(ctx)
+		assert.NoError(t, err)
+		readChan <- batch
+	}()
+
+	<-time.After(time.Millisecond * 10)
+	require.NoError(t, w.WriteBatch(context.Background(), windowTestBatch(`hello world`), noopAck))
+
+	select {
+	case batch := <-readChan:
+		require.Len(t, batch, 1)
+		mBytes, err := batch[0].AsBytes()
+		require.NoError(t, err)
+		assert.Equal(t, "hello world", string(mBytes))
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+}
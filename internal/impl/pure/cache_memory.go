@@ -198,6 +198,16 @@ func (m *memoryCache) Get(_ context.Context, key string) ([]byte, error) {
 	return k.value, nil
 }
 
+func (m *memoryCache) GetMulti(ctx context.Context, keys ...string) (map[string][]byte, error) {
+	res := make(map[string][]byte, len(keys))
+	for _, key := range keys {
+		if v, err := m.Get(ctx, key); err == nil {
+			res[key] = v
+		}
+	}
+	return res, nil
+}
+
 func (m *memoryCache) Set(_ context.Context, key string, value []byte, ttl *time.Duration) error {
 	var expires time.Time
 	if ttl != nil {
@@ -253,3 +253,23 @@ shards: 10
 		assert.Equal(b, value, res)
 	}
 }
+
+func TestMemoryCacheGetMulti(t *testing.T) {
+	defConf, err := memCacheConfig().ParseYAML(`
+shards: 4
+init_values:
+  foo: foo value
+  bar: bar value
+`, nil)
+	require.NoError(t, err)
+
+	c, err := newMemCacheFromConfig(defConf)
+	require.NoError(t, err)
+
+	res, err := c.GetMulti(context.Background(), "foo", "bar", "baz")
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]byte{
+		"foo": []byte("foo value"),
+		"bar": []byte("bar value"),
+	}, res)
+}
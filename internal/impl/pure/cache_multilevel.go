@@ -3,16 +3,62 @@ package pure
 import (
 	"context"
 	"fmt"
+	"sync"
 	"time"
 
+	"github.com/cenkalti/backoff/v4"
+
 	"github.com/benthosdev/benthos/v4/public/service"
 )
 
 func multilevelCacheConfig() *service.ConfigSpec {
+	writeBackRetriesDefaults := backoff.NewExponentialBackOff()
+	writeBackRetriesDefaults.InitialInterval = time.Millisecond * 100
+	writeBackRetriesDefaults.MaxInterval = time.Second
+	writeBackRetriesDefaults.MaxElapsedTime = time.Second * 10
+
 	spec := service.NewConfigSpec().
 		Stable().
-		Summary(`Combines multiple caches as levels, performing read-through and write-through operations across them.`).
-		Field(service.NewStringListField("")).
+		Summary(`Combines multiple caches as levels, performing read-through operations across them and writes according to a write policy.`).
+		Description(`
+Levels are listed in order of priority, typically starting with the fastest (local) cache and ending with the slowest (remote) cache, and at least two levels must be specified. The levels can also be specified as a list in place of the config object, in which case the `+"`write_through`"+` policy is used.
+
+### Reads
+
+A Get command attempts each level in order until the key is found. When the key is found at a level then it is also set on all of the levels before it (read-through population), using the default TTL of each of those levels.
+
+### Writes
+
+With the `+"`write_through`"+` policy Set and Delete commands are applied to every level in order, and the command fails as soon as any level returns an error.
+
+With the `+"`write_back`"+` policy Set and Delete commands are only applied to the first level before returning, and are then applied to the remaining levels in the background in the order that they were made. Each attempt to write to a level is bounded by the `+"`write_back_timeout`"+`, and failed writes are retried according to `+"`write_back_retries`"+`. A write that still fails once its retries are exhausted is logged and dropped, leaving that level with a stale value until the key is written again or expires. Pending writes are flushed when the cache is closed, and writes that remain once the shutdown deadline is reached are dropped. Reads may therefore observe stale values from the slower levels until the pending writes have been applied.
+
+An Add command first checks that the key does not exist within any of the levels before the last, then adds the key to the last level, and finally adds it to the remaining levels in reverse order, so that the slowest level acts as the source of truth for conflicts. Add commands are therefore always applied to every level before returning regardless of the write policy.`).
+		Field(service.NewStringListField("levels").
+			Description("A list of cache resources to use as levels, in order of priority.").
+			Example([]string{"hot", "cold"})).
+		Field(service.NewStringAnnotatedEnumField("write_policy", map[string]string{
+			"write_through": "Writes are applied to all levels before returning.",
+			"write_back":    "Writes are applied to the first level before returning, and to the remaining levels in the background.",
+		}).
+			Description("The policy used for applying Set and Delete commands to the levels.").
+			Version("4.1.0").
+			Default("write_through")).
+		Field(service.NewIntField("write_back_buffer").
+			Description("The maximum number of writes that can be pending for the remaining levels with the `write_back` policy, once reached writes block until pending writes have been applied.").
+			Version("4.1.0").
+			Default(1000).
+			Advanced()).
+		Field(service.NewDurationField("write_back_timeout").
+			Description("The maximum period to wait for each attempt to write to a level beyond the first with the `write_back` policy.").
+			Version("4.1.0").
+			Default("5s").
+			Advanced()).
+		Field(service.NewBackOffField("write_back_retries", false, writeBackRetriesDefaults).
+			Description("Determine time intervals and cut offs for retrying failed writes to the levels beyond the first with the `write_back` policy.").
+			Version("4.1.0").
+			Advanced()).
+		Shorthand("levels").
 		Example(
 			"Hot and cold cache",
 			"The multilevel cache is useful for reducing traffic against a remote cache by routing it through a local cache. In the following example requests will only go through to the memcached server if the local memory cache is missing the key.",
@@ -37,6 +83,25 @@ cache_resources:
     memory:
       default_ttl: 60s
 
+  - label: cold
+    memcached:
+      addresses: [ TODO:11211 ]
+      default_ttl: 60s
+`).
+		Example(
+			"Hot and cold write-back cache",
+			"In the following example writes are only made to the local memory cache before returning, and are applied to the memcached server in the background.",
+			`
+cache_resources:
+  - label: leveled
+    multilevel:
+      levels: [ hot, cold ]
+      write_policy: write_back
+
+  - label: hot
+    memory:
+      default_ttl: 60s
+
   - label: cold
     memcached:
       addresses: [ TODO:11211 ]
@@ -49,11 +114,7 @@ func init() {
 	err := service.RegisterCache(
 		"multilevel", multilevelCacheConfig(),
 		func(conf *service.ParsedConfig, mgr *service.Resources) (service.Cache, error) {
-			levels, err := conf.FieldStringList()
-			if err != nil {
-				return nil, err
-			}
-			return newMultilevelCache(levels, mgr, mgr.Logger())
+			return newMultilevelCacheFromConfig(conf, mgr)
 		})
 
 	if err != nil {
@@ -67,24 +128,180 @@ type cacheProvider interface {
 	AccessCache(ctx context.Context, name string, fn func(c service.Cache)) error
 }
 
+// levelWrite is a pending write to the levels beyond the first of a cache
+// with a write-back policy.
+type levelWrite struct {
+	key    string
+	value  []byte
+	ttl    *time.Duration
+	delete bool
+}
+
+// writeBackConfig determines how writes are applied to the levels beyond the
+// first of a cache with a write-back policy.
+type writeBackConfig struct {
+	buffer  int
+	timeout time.Duration
+	retries *backoff.ExponentialBackOff
+}
+
 type multilevelCache struct {
 	mgr    cacheProvider
 	log    *service.Logger
 	caches []string
+
+	// Only used with a write-back policy.
+	writeBack    writeBackConfig
+	writesMut    sync.RWMutex
+	writes       chan levelWrite
+	writesClosed bool
+	writesDone   chan struct{}
+	writesCtx    context.Context
+	writesCancel func()
 }
 
-func newMultilevelCache(levels []string, mgr cacheProvider, log *service.Logger) (service.Cache, error) {
+func newMultilevelCacheFromConfig(conf *service.ParsedConfig, mgr *service.Resources) (*multilevelCache, error) {
+	levels, err := conf.FieldStringList("levels")
+	if err != nil {
+		return nil, err
+	}
+	writePolicy, err := conf.FieldString("write_policy")
+	if err != nil {
+		return nil, err
+	}
+	var wb writeBackConfig
+	if wb.buffer, err = conf.FieldInt("write_back_buffer"); err != nil {
+		return nil, err
+	}
+	if wb.timeout, err = conf.FieldDuration("write_back_timeout"); err != nil {
+		return nil, err
+	}
+	if wb.retries, err = conf.FieldBackOff("write_back_retries"); err != nil {
+		return nil, err
+	}
+	return newMultilevelCacheWithPolicy(levels, writePolicy, wb, mgr, mgr.Logger())
+}
+
+func newMultilevelCache(levels []string, mgr cacheProvider, log *service.Logger) (*multilevelCache, error) {
+	return newMultilevelCacheWithPolicy(levels, "write_through", writeBackConfig{}, mgr, log)
+}
+
+func newMultilevelCacheWithPolicy(levels []string, writePolicy string, wb writeBackConfig, mgr cacheProvider, log *service.Logger) (*multilevelCache, error) {
 	if len(levels) < 2 {
 		return nil, fmt.Errorf("expected at least two cache levels, found %v", len(levels))
 	}
 	// TODO: Probe caches
 	// for _, name := range levels {
 	// }
-	return &multilevelCache{
+	l := &multilevelCache{
 		mgr:    mgr,
 		log:    log,
 		caches: levels,
-	}, nil
+	}
+	switch writePolicy {
+	case "write_through":
+	case "write_back":
+		if wb.buffer < 1 {
+			return nil, fmt.Errorf("write back buffer must be at least one, got %v", wb.buffer)
+		}
+		if wb.timeout <= 0 {
+			return nil, fmt.Errorf("write back timeout must be greater than zero, got %v", wb.timeout)
+		}
+		if wb.retries == nil {
+			wb.retries = backoff.NewExponentialBackOff()
+			wb.retries.MaxElapsedTime = wb.timeout
+		}
+		l.writeBack = wb
+		l.writes = make(chan levelWrite, wb.buffer)
+		l.writesDone = make(chan struct{})
+		l.writesCtx, l.writesCancel = context.WithCancel(context.Background())
+		go l.writeBackLoop()
+	default:
+		return nil, fmt.Errorf("write policy not recognised: %v", writePolicy)
+	}
+	return l, nil
+}
+
+//------------------------------------------------------------------------------
+
+// queueWrite schedules a write to all levels beyond the first, returning an
+// error if the cache has been closed.
+func (l *multilevelCache) queueWrite(ctx context.Context, w levelWrite) error {
+	l.writesMut.RLock()
+	defer l.writesMut.RUnlock()
+	if l.writesClosed {
+		return service.ErrNotConnected
+	}
+	select {
+	case l.writes <- w:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return nil
+}
+
+// writeBackLoop applies queued writes to all levels beyond the first in the
+// order that they were made, until the cache is closed and the queue drained or
+// the flushing of pending writes is abandoned.
+func (l *multilevelCache) writeBackLoop() {
+	defer close(l.writesDone)
+
+	dropped := 0
+	for w := range l.writes {
+		if l.writesCtx.Err() != nil {
+			dropped++
+			continue
+		}
+		for _, name := range l.caches[1:] {
+			if err := l.writeBackLevel(name, w); err != nil {
+				l.log.Errorf("Unable to write back key '%v' to cache '%v': %v", w.key, name, err)
+			}
+		}
+	}
+	if dropped > 0 {
+		l.log.Errorf("Dropped %v pending writes to the remaining levels as the cache was closed before they could be applied", dropped)
+	}
+}
+
+// writeBackLevel applies a write to a level, retrying it until it succeeds or
+// the retries are exhausted.
+func (l *multilevelCache) writeBackLevel(name string, w levelWrite) error {
+	l.writeBack.retries.Reset()
+	for {
+		err := l.writeBackLevelOnce(name, w)
+		if err == nil {
+			return nil
+		}
+
+		wait := l.writeBack.retries.NextBackOff()
+		if wait == backoff.Stop {
+			return err
+		}
+		select {
+		case <-time.After(wait):
+		case <-l.writesCtx.Done():
+			return err
+		}
+	}
+}
+
+func (l *multilevelCache) writeBackLevelOnce(name string, w levelWrite) error {
+	ctx, done := context.WithTimeout(l.writesCtx, l.writeBack.timeout)
+	defer done()
+
+	var err error
+	if cerr := l.mgr.AccessCache(ctx, name, func(c service.Cache) {
+		if w.delete {
+			if err = c.Delete(ctx, w.key); err == service.ErrKeyNotFound {
+				err = nil
+			}
+		} else {
+			err = c.Set(ctx, w.key, w.value, w.ttl)
+		}
+	}); cerr != nil {
+		err = cerr
+	}
+	return err
 }
 
 //------------------------------------------------------------------------------
@@ -128,7 +345,11 @@ func (l *multilevelCache) Get(ctx context.Context, key string) ([]byte, error) {
 }
 
 func (l *multilevelCache) Set(ctx context.Context, key string, value []byte, ttl *time.Duration) error {
-	for _, name := range l.caches {
+	levels := l.caches
+	if l.writes != nil {
+		levels = levels[:1]
+	}
+	for _, name := range levels {
 		var err error
 		if cerr := l.mgr.AccessCache(ctx, name, func(c service.Cache) {
 			err = c.Set(ctx, key, value, ttl)
@@ -139,6 +360,9 @@ func (l *multilevelCache) Set(ctx context.Context, key string, value []byte, ttl
 			return err
 		}
 	}
+	if l.writes != nil {
+		return l.queueWrite(ctx, levelWrite{key: key, value: value, ttl: ttl})
+	}
 	return nil
 }
 
@@ -183,7 +407,11 @@ func (l *multilevelCache) Add(ctx context.Context, key string, value []byte, ttl
 }
 
 func (l *multilevelCache) Delete(ctx context.Context, key string) error {
-	for _, name := range l.caches {
+	levels := l.caches
+	if l.writes != nil {
+		levels = levels[:1]
+	}
+	for _, name := range levels {
 		var err error
 		if cerr := l.mgr.AccessCache(ctx, name, func(c service.Cache) {
 			err = c.Delete(ctx, key)
@@ -194,9 +422,32 @@ func (l *multilevelCache) Delete(ctx context.Context, key string) error {
 			return err
 		}
 	}
+	if l.writes != nil {
+		return l.queueWrite(ctx, levelWrite{key: key, delete: true})
+	}
 	return nil
 }
 
+// Close flushes any pending writes of a write-back policy, abandoning them
+// once the context is cancelled.
 func (l *multilevelCache) Close(ctx context.Context) error {
+	if l.writes == nil {
+		return nil
+	}
+
+	l.writesMut.Lock()
+	if !l.writesClosed {
+		l.writesClosed = true
+		close(l.writes)
+	}
+	l.writesMut.Unlock()
+
+	select {
+	case <-l.writesDone:
+	case <-ctx.Done():
+		l.writesCancel()
+		return ctx.Err()
+	}
+	l.writesCancel()
 	return nil
 }
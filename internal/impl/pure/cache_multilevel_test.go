@@ -3,9 +3,11 @@ package pure
 import (
 	"context"
 	"errors"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/cenkalti/backoff/v4"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -361,3 +363,157 @@ func TestMultilevelCacheAddMoreCaches(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, val, []byte("test value 4"))
 }
+
+func TestMultilevelCacheConfig(t *testing.T) {
+	conf, err := multilevelCacheConfig().ParseYAML(`[ foo, bar ]`, nil)
+	require.NoError(t, err)
+
+	levels, err := conf.FieldStringList("levels")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"foo", "bar"}, levels)
+
+	writePolicy, err := conf.FieldString("write_policy")
+	require.NoError(t, err)
+	assert.Equal(t, "write_through", writePolicy)
+
+	conf, err = multilevelCacheConfig().ParseYAML(`
+levels: [ foo, bar ]
+write_policy: write_back
+`, nil)
+	require.NoError(t, err)
+
+	levels, err = conf.FieldStringList("levels")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"foo", "bar"}, levels)
+
+	writePolicy, err = conf.FieldString("write_policy")
+	require.NoError(t, err)
+	assert.Equal(t, "write_back", writePolicy)
+
+	wb := writeBackConfig{buffer: 10, timeout: time.Second}
+
+	_, err = newMultilevelCacheWithPolicy([]string{"foo", "bar"}, "nope", wb, &mockCacheProv{}, nil)
+	require.EqualError(t, err, "write policy not recognised: nope")
+
+	_, err = newMultilevelCacheWithPolicy([]string{"foo", "bar"}, "write_back", writeBackConfig{timeout: time.Second}, &mockCacheProv{}, nil)
+	require.Error(t, err)
+
+	_, err = newMultilevelCacheWithPolicy([]string{"foo", "bar"}, "write_back", writeBackConfig{buffer: 10}, &mockCacheProv{}, nil)
+	require.Error(t, err)
+}
+
+func TestMultilevelCacheWriteBack(t *testing.T) {
+	memCache1 := newMemCache(time.Minute, 0, 1, nil)
+	memCache2 := newMemCache(time.Minute, 0, 1, nil)
+	p := &mockCacheProv{
+		caches: map[string]service.Cache{
+			"foo": memCache1,
+			"bar": memCache2,
+		},
+	}
+
+	c, err := newMultilevelCacheWithPolicy([]string{"foo", "bar"}, "write_back", writeBackConfig{buffer: 10, timeout: time.Second}, p, nil)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	require.NoError(t, c.Set(ctx, "a", []byte("a value"), nil))
+	require.NoError(t, c.Set(ctx, "b", []byte("b value"), nil))
+	require.NoError(t, c.Delete(ctx, "a"))
+
+	_, err = memCache1.Get(ctx, "a")
+	assert.Equal(t, service.ErrKeyNotFound, err)
+
+	val, err := memCache1.Get(ctx, "b")
+	require.NoError(t, err)
+	assert.Equal(t, "b value", string(val))
+
+	require.NoError(t, c.Close(ctx))
+
+	_, err = memCache2.Get(ctx, "a")
+	assert.Equal(t, service.ErrKeyNotFound, err)
+
+	val, err = memCache2.Get(ctx, "b")
+	require.NoError(t, err)
+	assert.Equal(t, "b value", string(val))
+
+	assert.Equal(t, service.ErrNotConnected, c.Set(ctx, "c", []byte("c value"), nil))
+	require.NoError(t, c.Close(ctx))
+}
+
+// flakyCache fails a number of sets before delegating to another cache, and
+// blocks sets until their context is cancelled when stuck.
+type flakyCache struct {
+	service.Cache
+	failures int32
+	stuck    bool
+}
+
+func (f *flakyCache) Set(ctx context.Context, key string, value []byte, ttl *time.Duration) error {
+	if f.stuck {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	if atomic.AddInt32(&f.failures, -1) >= 0 {
+		return errors.New("nope")
+	}
+	return f.Cache.Set(ctx, key, value, ttl)
+}
+
+func TestMultilevelCacheWriteBackRetries(t *testing.T) {
+	memCache2 := newMemCache(time.Minute, 0, 1, nil)
+	p := &mockCacheProv{
+		caches: map[string]service.Cache{
+			"foo": newMemCache(time.Minute, 0, 1, nil),
+			"bar": &flakyCache{Cache: memCache2, failures: 2},
+		},
+	}
+
+	retries := backoff.NewExponentialBackOff()
+	retries.InitialInterval = time.Millisecond
+	retries.MaxElapsedTime = time.Second * 5
+
+	c, err := newMultilevelCacheWithPolicy([]string{"foo", "bar"}, "write_back", writeBackConfig{
+		buffer:  10,
+		timeout: time.Second,
+		retries: retries,
+	}, p, nil)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	require.NoError(t, c.Set(ctx, "a", []byte("a value"), nil))
+	require.NoError(t, c.Close(ctx))
+
+	val, err := memCache2.Get(ctx, "a")
+	require.NoError(t, err)
+	assert.Equal(t, "a value", string(val))
+}
+
+func TestMultilevelCacheWriteBackCloseTimeout(t *testing.T) {
+	p := &mockCacheProv{
+		caches: map[string]service.Cache{
+			"foo": newMemCache(time.Minute, 0, 1, nil),
+			"bar": &flakyCache{Cache: newMemCache(time.Minute, 0, 1, nil), stuck: true},
+		},
+	}
+
+	c, err := newMultilevelCacheWithPolicy([]string{"foo", "bar"}, "write_back", writeBackConfig{
+		buffer:  10,
+		timeout: time.Minute,
+	}, p, nil)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	require.NoError(t, c.Set(ctx, "a", []byte("a value"), nil))
+	require.NoError(t, c.Set(ctx, "b", []byte("b value"), nil))
+
+	closeCtx, done := context.WithTimeout(ctx, time.Millisecond*50)
+	defer done()
+	assert.Equal(t, context.DeadlineExceeded, c.Close(closeCtx))
+
+	select {
+	case <-c.writesDone:
+	case <-time.After(time.Second * 5):
+		t.Fatal("pending writes were not abandoned")
+	}
+}
@@ -0,0 +1,237 @@
+package pure
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/internal/component"
+	"github.com/benthosdev/benthos/v4/internal/component/input"
+	"github.com/benthosdev/benthos/v4/internal/message"
+	"github.com/benthosdev/benthos/v4/internal/shutdown"
+)
+
+type priorityInput struct {
+	tier int
+	in   input.Streamed
+	open bool
+}
+
+// priorityInputBroker reads from child inputs in order of their priority tier,
+// where a lower tier number is a higher priority, and only reads from lower
+// priorities when all higher priority inputs have no transactions ready.
+type priorityInputBroker struct {
+	transactions chan message.Transaction
+
+	// Inputs ordered by tier, where inputs of the same tier are read from in
+	// turn.
+	inputs         []*priorityInput
+	maxConsecutive int
+
+	shutSig *shutdown.Signaller
+}
+
+// newPriorityInputBroker creates a priority broker where each input is
+// assigned the tier at the same index. A max consecutive greater than zero
+// results in a transaction being read from a lower tier, when one is ready,
+// after that many consecutive transactions have been read from higher tiers.
+func newPriorityInputBroker(inputs []input.Streamed, tiers []int, maxConsecutive int) (*priorityInputBroker, error) {
+	i := &priorityInputBroker{
+		transactions:   make(chan message.Transaction),
+		maxConsecutive: maxConsecutive,
+		shutSig:        shutdown.NewSignaller(),
+	}
+	for n, in := range inputs {
+		i.inputs = append(i.inputs, &priorityInput{
+			tier: tiers[n],
+			in:   in,
+			open: true,
+		})
+	}
+	sort.SliceStable(i.inputs, func(a, b int) bool {
+		return i.inputs[a].tier < i.inputs[b].tier
+	})
+
+	go i.loop()
+	return i, nil
+}
+
+func (i *priorityInputBroker) TransactionChan() <-chan message.Transaction {
+	return i.transactions
+}
+
+func (i *priorityInputBroker) Connected() bool {
+	for _, in := range i.inputs {
+		if !in.in.Connected() {
+			return false
+		}
+	}
+	return true
+}
+
+// tryRead attempts to read a transaction from the open inputs with a tier of
+// at least minTier without blocking, in priority order. Inputs of the same
+// tier are read starting from the input following the one last read from.
+func (i *priorityInputBroker) tryRead(minTier int, lastRead *int) (message.Transaction, int, bool) {
+	for start := 0; start < len(i.inputs); {
+		tier := i.inputs[start].tier
+		end := start
+		for end < len(i.inputs) && i.inputs[end].tier == tier {
+			end++
+		}
+		if tier >= minTier {
+			n, offset := end-start, 0
+			if *lastRead >= start && *lastRead < end {
+				offset = *lastRead - start + 1
+			}
+			for j := 0; j < n; j++ {
+				idx := start + (offset+j)%n
+				pi := i.inputs[idx]
+				if !pi.open {
+					continue
+				}
+				select {
+				case t, open := <-pi.in.TransactionChan():
+					if !open {
+						pi.open = false
+						continue
+					}
+					*lastRead = idx
+					return t, tier, true
+				default:
+				}
+			}
+		}
+		start = end
+	}
+	return message.Transaction{}, 0, false
+}
+
+// waitRead blocks until a transaction is read from any open input, returning
+// false once all inputs are closed.
+func (i *priorityInputBroker) waitRead(lastRead *int) (message.Transaction, int, bool) {
+	for {
+		cases := make([]reflect.SelectCase, 0, len(i.inputs))
+		indexes := make([]int, 0, len(i.inputs))
+		for idx, pi := range i.inputs {
+			if !pi.open {
+				continue
+			}
+			cases = append(cases, reflect.SelectCase{
+				Dir:  reflect.SelectRecv,
+				Chan: reflect.ValueOf(pi.in.TransactionChan()),
+			})
+			indexes = append(indexes, idx)
+		}
+		if len(cases) == 0 {
+			return message.Transaction{}, 0, false
+		}
+
+		chosen, v, open := reflect.Select(cases)
+		pi := i.inputs[indexes[chosen]]
+		if !open {
+			pi.open = false
+			continue
+		}
+		*lastRead = indexes[chosen]
+		return v.Interface().(message.Transaction), pi.tier, true
+	}
+}
+
+func (i *priorityInputBroker) loop() {
+	defer func() {
+		close(i.transactions)
+		i.shutSig.ShutdownComplete()
+	}()
+
+	lastRead := -1
+	consecutive := 0
+	for {
+		var t message.Transaction
+		var tier int
+		var ok bool
+
+		if i.maxConsecutive > 0 && consecutive >= i.maxConsecutive {
+			// Give lower tiers a chance to avoid starving them, the streak is
+			// reset regardless as lower tiers that are idle aren't starved.
+			t, tier, ok = i.tryRead(i.inputs[lastRead].tier+1, &lastRead)
+			consecutive = 0
+		}
+		if !ok {
+			t, tier, ok = i.tryRead(0, &lastRead)
+		}
+		if !ok {
+			if t, tier, ok = i.waitRead(&lastRead); !ok {
+				return
+			}
+		}
+
+		if tier < i.inputs[len(i.inputs)-1].tier {
+			consecutive++
+		} else {
+			consecutive = 0
+		}
+
+		select {
+		case i.transactions <- t:
+		case <-i.shutSig.CloseNowChan():
+			i.nackOnClose(t)
+			return
+		}
+	}
+}
+
+// nackOnClose rejects a transaction that was read but could not be sent before
+// shutting down, as otherwise the input it was read from would be left waiting
+// on it. The rejection is abandoned once the inputs have closed.
+func (i *priorityInputBroker) nackOnClose(t message.Transaction) {
+	ctx, done := context.WithCancel(context.Background())
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		_ = t.Ack(ctx, component.ErrTypeClosed)
+	}()
+
+	for _, pi := range i.inputs {
+		pi.in.CloseAsync()
+	}
+	go func() {
+		// Shutting down is already urgent, so the inputs are told to close
+		// immediately.
+		for _, pi := range i.inputs {
+			_ = pi.in.WaitForClose(0)
+		}
+	}()
+	for _, pi := range i.inputs {
+		_ = pi.in.WaitForClose(shutdown.MaximumShutdownWait())
+	}
+
+	done()
+	wg.Wait()
+}
+
+func (i *priorityInputBroker) CloseAsync() {
+	for _, in := range i.inputs {
+		in.in.CloseAsync()
+	}
+	i.shutSig.CloseAtLeisure()
+}
+
+func (i *priorityInputBroker) WaitForClose(timeout time.Duration) error {
+	go func() {
+		if tAfter := timeout - time.Second; tAfter > 0 {
+			<-time.After(timeout - time.Second)
+		}
+		i.shutSig.CloseNow()
+	}()
+	select {
+	case <-i.shutSig.HasClosedChan():
+	case <-time.After(timeout):
+		return component.ErrTimeout
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package pure
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/component"
+	"github.com/benthosdev/benthos/v4/internal/component/input"
+	"github.com/benthosdev/benthos/v4/internal/manager/mock"
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+var _ input.Streamed = &priorityInputBroker{}
+
+func priorityTestInputs(t *testing.T, contents ...[]string) ([]input.Streamed, []*mock.Input) {
+	t.Helper()
+
+	var inputs []input.Streamed
+	var mockInputs []*mock.Input
+	for _, c := range contents {
+		mInput := &mock.Input{
+			TChan: make(chan message.Transaction, len(c)),
+		}
+		for _, v := range c {
+			mInput.TChan <- message.NewTransaction(message.QuickBatch([][]byte{[]byte(v)}), make(chan error, 1))
+		}
+		inputs = append(inputs, mInput)
+		mockInputs = append(mockInputs, mInput)
+	}
+	return inputs, mockInputs
+}
+
+func readPriority(t *testing.T, ctx context.Context, b *priorityInputBroker, n int) []string {
+	t.Helper()
+
+	var res []string
+	for i := 0; i < n; i++ {
+		select {
+		case tran := <-b.TransactionChan():
+			res = append(res, string(tran.Payload.Get(0).Get()))
+		case <-ctx.Done():
+			t.Fatal("timed out")
+		}
+	}
+	return res
+}
+
+func TestPriorityInputBrokerOrdering(t *testing.T) {
+	tCtx, done := context.WithTimeout(context.Background(), time.Second*10)
+	defer done()
+
+	inputs, mockInputs := priorityTestInputs(t,
+		[]string{"low1", "low2"},
+		[]string{"high1", "high2", "high3"},
+		[]string{"mid1"},
+	)
+
+	b, err := newPriorityInputBroker(inputs, []int{2, 0, 1}, 0)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"high1", "high2", "high3", "mid1", "low1", "low2"}, readPriority(t, tCtx, b, 6))
+
+	// Once idle, messages are read from whichever input has one ready.
+	mockInputs[0].TChan <- message.NewTransaction(message.QuickBatch([][]byte{[]byte("low3")}), make(chan error, 1))
+	assert.Equal(t, []string{"low3"}, readPriority(t, tCtx, b, 1))
+
+	b.CloseAsync()
+	require.NoError(t, b.WaitForClose(time.Second*5))
+}
+
+func TestPriorityInputBrokerStarvation(t *testing.T) {
+	tCtx, done := context.WithTimeout(context.Background(), time.Second*10)
+	defer done()
+
+	inputs, _ := priorityTestInputs(t,
+		[]string{"high1", "high2", "high3", "high4", "high5"},
+		[]string{"low1", "low2"},
+	)
+
+	b, err := newPriorityInputBroker(inputs, []int{0, 1}, 2)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"high1", "high2", "low1",
+		"high3", "high4", "low2",
+		"high5",
+	}, readPriority(t, tCtx, b, 7))
+
+	b.CloseAsync()
+	require.NoError(t, b.WaitForClose(time.Second*5))
+}
+
+func TestPriorityInputBrokerSameTier(t *testing.T) {
+	tCtx, done := context.WithTimeout(context.Background(), time.Second*10)
+	defer done()
+
+	inputs, _ := priorityTestInputs(t,
+		[]string{"a1", "a2"},
+		[]string{"b1", "b2"},
+	)
+
+	b, err := newPriorityInputBroker(inputs, []int{0, 0}, 0)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"a1", "b1", "a2", "b2"}, readPriority(t, tCtx, b, 4))
+
+	b.CloseAsync()
+	require.NoError(t, b.WaitForClose(time.Second*5))
+}
+
+func TestPriorityInputBrokerShutdownNacks(t *testing.T) {
+	// The input remains open and the broker is blocked sending a transaction
+	// that is never consumed.
+	in := newOrderedTestInput("2022-01-01T00:00:00Z")
+
+	b, err := newPriorityInputBroker([]input.Streamed{in}, []int{0}, 0)
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		return len(in.TChan) == 0
+	}, time.Second, time.Millisecond*10)
+
+	b.CloseAsync()
+	require.NoError(t, b.WaitForClose(time.Second))
+	assert.Equal(t, component.ErrTypeClosed, <-in.ackErrs)
+}
@@ -2,16 +2,22 @@ package pure
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strconv"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/robfig/cron/v3"
 
+	"github.com/benthosdev/benthos/v4/internal/bloblang"
 	"github.com/benthosdev/benthos/v4/internal/bloblang/mapping"
 	"github.com/benthosdev/benthos/v4/internal/bloblang/parser"
+	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
 	"github.com/benthosdev/benthos/v4/internal/bundle"
+	"github.com/benthosdev/benthos/v4/internal/checkpoint"
 	"github.com/benthosdev/benthos/v4/internal/component"
 	"github.com/benthosdev/benthos/v4/internal/component/input"
 	"github.com/benthosdev/benthos/v4/internal/docs"
@@ -36,12 +42,21 @@ func init() {
 Generates messages at a given interval using a [Bloblang](/docs/guides/bloblang/about)
 mapping executed without a context. This allows you to generate messages for
 testing your pipeline configs.`,
+		Description: `
+### Sequence
+
+The mapping of this input has access to a function ` + "`sequence()`" + ` that returns the number of messages generated by the input so far, starting at 1 for the first message. Unlike the ` + "[`count` function](/docs/guides/bloblang/functions#count)" + ` the sequence is unique to each generate input and resets when the input is restarted, unless it's checkpointed.
+
+### Checkpointing
+
+When ` + "`checkpoint`" + ` is set the sequence of the latest acknowledged message, such that all messages generated before it have also been acknowledged, is stored within the [checkpoint resource](/docs/configuration/resources#checkpoints) under the key ` + "`generate_sequence`" + `, and therefore a checkpoint resource must not be shared by multiple ` + "`generate`" + ` inputs. When the input is restarted the sequence resumes from the stored value, and a ` + "`count`" + ` only includes messages that were not acknowledged before the restart.`,
 		Config: docs.FieldComponent().WithChildren(
-			docs.FieldBloblang(
+			docs.FieldString(
 				"mapping", "A [bloblang](/docs/guides/bloblang/about) mapping to use for generating messages.",
 				`root = "hello world"`,
 				`root = {"test":"message","id":uuid_v4()}`,
-			),
+				`root = {"id":sequence()}`,
+			).LinterFunc(lintGenerateMapping),
 			docs.FieldString(
 				"interval",
 				"The time interval at which messages should be generated, expressed either as a duration string or as a cron expression. If set to an empty string messages will be generated as fast as downstream services can process them. Cron expressions can specify a timezone by prefixing the expression with `TZ=<location name>`, where the location name corresponds to a file within the IANA Time Zone database.",
@@ -49,6 +64,7 @@ testing your pipeline configs.`,
 				"@every 1s", "0,30 */2 * * * *", "TZ=Europe/London 30 3-6,20-23 * * *",
 			),
 			docs.FieldInt("count", "An optional number of messages to generate, if set above 0 the specified number of messages is generated and then the input will shut down."),
+			docs.FieldString("checkpoint", "An optional [checkpoint resource](/docs/configuration/resources#checkpoints) to store the sequence of acknowledged messages within.").AtVersion("4.1.0").Advanced(),
 		).ChildDefaultAndTypesFromStruct(oinput.NewGenerateConfig()),
 		Categories: []string{
 			"Utility",
@@ -102,6 +118,7 @@ input:
 //------------------------------------------------------------------------------
 
 type generateReader struct {
+	sequence    *int64
 	remaining   int64
 	limited     bool
 	firstIsFree bool
@@ -109,6 +126,12 @@ type generateReader struct {
 	timer       *time.Ticker
 	schedule    *cron.Schedule
 	location    *time.Location
+
+	store         *checkpoint.Store
+	storeLoaded   bool
+	checkpointMut sync.Mutex
+	checkpointer  *checkpoint.Type
+	committed     int64
 }
 
 func newGenerateReader(mgr interop.Manager, conf oinput.GenerateConfig) (*generateReader, error) {
@@ -135,25 +158,74 @@ func newGenerateReader(mgr interop.Manager, conf oinput.GenerateConfig) (*genera
 			timer = time.NewTicker(duration)
 		}
 	}
-	exec, err := mgr.BloblEnvironment().NewMapping(conf.Mapping)
+	var sequence int64
+	env, err := generateEnvironment(mgr.BloblEnvironment(), &sequence)
+	if err != nil {
+		return nil, err
+	}
+	exec, err := env.NewMapping(conf.Mapping)
 	if err != nil {
 		if perr, ok := err.(*parser.Error); ok {
 			return nil, fmt.Errorf("failed to parse mapping: %v", perr.ErrorAtPosition([]rune(conf.Mapping)))
 		}
 		return nil, fmt.Errorf("failed to parse mapping: %v", err)
 	}
+	var store *checkpoint.Store
+	if conf.Checkpoint != "" {
+		if !mgr.ProbeCheckpoint(conf.Checkpoint) {
+			return nil, fmt.Errorf("checkpoint resource '%v' was not found", conf.Checkpoint)
+		}
+		if err := mgr.AccessCheckpoint(context.Background(), conf.Checkpoint, func(s *checkpoint.Store) {
+			store = s
+		}); err != nil {
+			return nil, err
+		}
+	}
 	remaining := int64(conf.Count)
 	return &generateReader{
-		exec:        exec,
-		remaining:   remaining,
-		limited:     remaining > 0,
-		timer:       timer,
-		schedule:    schedule,
-		location:    location,
-		firstIsFree: firstIsFree,
+		store:        store,
+		checkpointer: checkpoint.New(),
+		sequence:     &sequence,
+		exec:         exec,
+		remaining:    remaining,
+		limited:      remaining > 0,
+		timer:        timer,
+		schedule:     schedule,
+		location:     location,
+		firstIsFree:  firstIsFree,
 	}, nil
 }
 
+// generateEnvironment returns a copy of a bloblang environment with the
+// function sequence added, which returns the current value of a counter.
+func generateEnvironment(env *bloblang.Environment, sequence *int64) (*bloblang.Environment, error) {
+	env = env.WithoutFunctions()
+	if err := env.RegisterFunction(query.NewFunctionSpec(
+		query.FunctionCategoryGeneral, "sequence",
+		"Returns the number of messages generated by the input so far, starting at 1 for the first message.",
+	).MarkImpure(), func(args *query.ParsedParams) (query.Function, error) {
+		return query.ClosureFunction("function sequence", func(ctx query.FunctionContext) (interface{}, error) {
+			return atomic.LoadInt64(sequence), nil
+		}, nil), nil
+	}); err != nil {
+		return nil, err
+	}
+	return env, nil
+}
+
+func lintGenerateMapping(ctx docs.LintContext, line, col int, v interface{}) []docs.Lint {
+	if ctx.BloblangEnv == nil {
+		ctx.BloblangEnv = bloblang.GlobalEnvironment()
+	}
+	var sequence int64
+	env, err := generateEnvironment(ctx.BloblangEnv, &sequence)
+	if err != nil {
+		return []docs.Lint{docs.NewLintError(line, err.Error())}
+	}
+	ctx.BloblangEnv = env
+	return docs.LintBloblangMapping(ctx, line, col, v)
+}
+
 func getDurationTillNextSchedule(schedule cron.Schedule, location *time.Location) time.Duration {
 	now := time.Now().In(location)
 	return schedule.Next(now).Sub(now)
@@ -183,8 +255,49 @@ func parseCronExpression(cronExpression string) (*cron.Schedule, *time.Location,
 	return &cronSchedule, loc, nil
 }
 
-// ConnectWithContext establishes a Bloblang reader.
+// The key that the sequence is stored under within a checkpoint resource.
+const generateCheckpointKey = "generate_sequence"
+
+// ConnectWithContext establishes a Bloblang reader, resuming the sequence from
+// a checkpoint when one has been stored.
 func (b *generateReader) ConnectWithContext(ctx context.Context) error {
+	if b.store == nil || b.storeLoaded {
+		return nil
+	}
+
+	seqBytes, err := b.store.Get(ctx, generateCheckpointKey)
+	if err != nil && !errors.Is(err, component.ErrKeyNotFound) {
+		return fmt.Errorf("failed to obtain checkpoint: %w", err)
+	}
+	if err == nil {
+		seq, err := strconv.ParseInt(string(seqBytes), 10, 64)
+		if err != nil {
+			return fmt.Errorf("failed to parse checkpoint: %w", err)
+		}
+		atomic.StoreInt64(b.sequence, seq)
+		b.committed = seq
+		if b.limited {
+			atomic.AddInt64(&b.remaining, -seq)
+		}
+	}
+	b.storeLoaded = true
+	return nil
+}
+
+// Commits the sequence of the latest message acknowledged such that all prior
+// messages have also been acknowledged.
+func (b *generateReader) commit(ctx context.Context, release func() interface{}) error {
+	b.checkpointMut.Lock()
+	defer b.checkpointMut.Unlock()
+
+	highest, _ := release().(int64)
+	if highest <= b.committed {
+		return nil
+	}
+	if err := b.store.Set(ctx, generateCheckpointKey, []byte(strconv.FormatInt(highest, 10))); err != nil {
+		return fmt.Errorf("failed to store checkpoint: %w", err)
+	}
+	b.committed = highest
 	return nil
 }
 
@@ -211,6 +324,8 @@ func (b *generateReader) ReadWithContext(ctx context.Context) (*message.Batch, r
 	}
 
 	b.firstIsFree = false
+	seq := atomic.AddInt64(b.sequence, 1)
+
 	p, err := b.exec.MapPart(0, message.QuickBatch(nil))
 	if err != nil {
 		return nil, nil, err
@@ -222,7 +337,19 @@ func (b *generateReader) ReadWithContext(ctx context.Context) (*message.Batch, r
 	msg := message.QuickBatch(nil)
 	msg.Append(p)
 
-	return msg, func(context.Context, error) error { return nil }, nil
+	var release func() interface{}
+	if b.store != nil {
+		b.checkpointMut.Lock()
+		release = b.checkpointer.Track(seq, 1)
+		b.checkpointMut.Unlock()
+	}
+
+	return msg, func(ctx context.Context, res error) error {
+		if release == nil {
+			return nil
+		}
+		return b.commit(ctx, release)
+	}, nil
 }
 
 // CloseAsync shuts down the bloblang reader.
@@ -9,9 +9,12 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
-	oinput "github.com/benthosdev/benthos/v4/internal/old/input"
-
+	"github.com/benthosdev/benthos/v4/internal/checkpoint"
+	"github.com/benthosdev/benthos/v4/internal/component"
+	"github.com/benthosdev/benthos/v4/internal/component/metrics"
 	"github.com/benthosdev/benthos/v4/internal/manager/mock"
+	oinput "github.com/benthosdev/benthos/v4/internal/old/input"
+	"github.com/benthosdev/benthos/v4/public/service"
 )
 
 func TestBloblangInterval(t *testing.T) {
@@ -191,3 +194,117 @@ func TestBloblangUnboundedEmpty(t *testing.T) {
 	b.CloseAsync()
 	require.NoError(t, b.WaitForClose(time.Second))
 }
+
+func TestBloblangSequence(t *testing.T) {
+	ctx, done := context.WithTimeout(context.Background(), time.Second)
+	defer done()
+
+	conf := oinput.NewGenerateConfig()
+	conf.Mapping = `root = "foo %v".format(sequence())`
+	conf.Interval = ""
+	conf.Count = 3
+
+	b, err := newGenerateReader(mock.NewManager(), conf)
+	require.NoError(t, err)
+
+	// A distinct input must not share the sequence.
+	other, err := newGenerateReader(mock.NewManager(), conf)
+	require.NoError(t, err)
+
+	err = b.ConnectWithContext(ctx)
+	require.NoError(t, err)
+
+	for i := 1; i <= 3; i++ {
+		m, _, err := b.ReadWithContext(ctx)
+		require.NoError(t, err)
+		require.Equal(t, 1, m.Len())
+		assert.Equal(t, fmt.Sprintf("foo %v", i), string(m.Get(0).Get()))
+	}
+
+	_, _, err = b.ReadWithContext(ctx)
+	assert.Equal(t, component.ErrTypeClosed, err)
+
+	m, _, err := other.ReadWithContext(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "foo 1", string(m.Get(0).Get()))
+
+	b.CloseAsync()
+	require.NoError(t, b.WaitForClose(time.Second))
+}
+
+func TestBloblangSequenceLint(t *testing.T) {
+	strmBuilder := service.NewStreamBuilder()
+	require.NoError(t, strmBuilder.AddInputYAML(`
+generate:
+  count: 1
+  mapping: 'root.id = sequence()'
+`))
+
+	err := strmBuilder.AddInputYAML(`
+generate:
+  count: 1
+  mapping: 'root.id = nope()'
+`)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unrecognised function 'nope'")
+}
+
+func TestGenerateCheckpoint(t *testing.T) {
+	ctx := context.Background()
+	mgr := mock.NewManager()
+	mgr.Caches["foocache"] = map[string]mock.CacheItem{}
+
+	storeConf := checkpoint.NewStoreConfig()
+	storeConf.Cache = "foocache"
+	store, err := checkpoint.NewStore(storeConf, mgr, metrics.Noop())
+	require.NoError(t, err)
+
+	conf := oinput.NewGenerateConfig()
+	conf.Mapping = `root = sequence()`
+	conf.Interval = ""
+	conf.Count = 5
+	conf.Checkpoint = "foocheckpoint"
+
+	_, err = newGenerateReader(mgr, conf)
+	require.EqualError(t, err, "checkpoint resource 'foocheckpoint' was not found")
+
+	mgr.Checkpoints["foocheckpoint"] = store
+
+	b, err := newGenerateReader(mgr, conf)
+	require.NoError(t, err)
+	require.NoError(t, b.ConnectWithContext(ctx))
+
+	var ackFns []func(context.Context, error) error
+	for i := 1; i <= 3; i++ {
+		m, ackFn, err := b.ReadWithContext(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, fmt.Sprintf("%v", i), string(m.Get(0).Get()))
+		ackFns = append(ackFns, ackFn)
+	}
+
+	// The sequence is only committed once all prior messages are acknowledged.
+	require.NoError(t, ackFns[1](ctx, nil))
+	assert.NotContains(t, mgr.Caches["foocache"], "generate_sequence")
+
+	require.NoError(t, ackFns[0](ctx, nil))
+	assert.Equal(t, "2", mgr.Caches["foocache"]["generate_sequence"].Value)
+
+	b.CloseAsync()
+
+	// A new reader resumes from the committed sequence and generates the
+	// remaining messages of the count.
+	b, err = newGenerateReader(mgr, conf)
+	require.NoError(t, err)
+	require.NoError(t, b.ConnectWithContext(ctx))
+
+	for i := 3; i <= 5; i++ {
+		m, ackFn, err := b.ReadWithContext(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, fmt.Sprintf("%v", i), string(m.Get(0).Get()))
+		require.NoError(t, ackFn(ctx, nil))
+	}
+
+	_, _, err = b.ReadWithContext(ctx)
+	assert.Equal(t, component.ErrTypeClosed, err)
+	assert.Equal(t, "5", mgr.Caches["foocache"]["generate_sequence"].Value)
+}
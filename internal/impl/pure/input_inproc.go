@@ -40,8 +40,9 @@ that you connect the inputs of a stream with an output of the same stream, as
 feedback loops can lead to deadlocks in your message flow.
 
 It is possible to connect multiple inputs to the same inproc ID, resulting in
-messages dispatching in a round-robin fashion to connected inputs. However, only
-one output can assume an inproc ID, and will replace existing outputs if a
+messages dispatching in a round-robin fashion to connected inputs, or to every
+connected input when the output is configured with the ` + "`broadcast`" + ` mode. However,
+only one output can assume an inproc ID, and will replace existing outputs if a
 collision occurs.`,
 		Categories: []string{
 			"Utility",
@@ -75,6 +76,16 @@ func (i *inprocInput) loop() {
 		close(i.closedChan)
 	}()
 
+	// Outputs in broadcast mode send transactions to each subscriber rather
+	// than over the shared pipe.
+	subChan := make(chan message.Transaction)
+	sub := &interop.PipeSubscriber{
+		Transactions: subChan,
+		Done:         i.closeChan,
+	}
+	i.mgr.SubscribePipe(i.pipe, sub)
+	defer i.mgr.UnsubscribePipe(i.pipe, sub)
+
 	var inprocChan <-chan message.Transaction
 
 messageLoop:
@@ -95,17 +106,20 @@ messageLoop:
 				}
 			}
 		}
+		var t message.Transaction
 		select {
-		case t, open := <-inprocChan:
+		case tran, open := <-inprocChan:
 			if !open {
 				inprocChan = nil
 				continue messageLoop
 			}
-			select {
-			case i.transactions <- t:
-			case <-i.closeChan:
-				return
-			}
+			t = tran
+		case t = <-subChan:
+		case <-i.closeChan:
+			return
+		}
+		select {
+		case i.transactions <- t:
 		case <-i.closeChan:
 			return
 		}
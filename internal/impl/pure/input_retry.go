@@ -0,0 +1,372 @@
+package pure
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+
+	"github.com/benthosdev/benthos/v4/internal/shutdown"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func retryInputConfig() *service.ConfigSpec {
+	defaultBackOff := backoff.NewExponentialBackOff()
+	defaultBackOff.InitialInterval = time.Millisecond * 100
+	defaultBackOff.MaxInterval = time.Second * 5
+	defaultBackOff.MaxElapsedTime = 0
+
+	return service.NewConfigSpec().
+		Beta().
+		Categories("Utility").
+		Version("4.1.0").
+		Summary("Reads messages from a child input and replays messages that are rejected downstream (nacked) with an exponential backoff, routing messages that continue to fail to an optional quarantine output.").
+		Description(`
+Inputs typically respond to a rejected message by either redelivering it immediately or, in the case of sources without the concept of a rejection, retrying it indefinitely. This input instead replays rejected messages itself, waiting for an exponentially increasing period between attempts, and gives up on a message once it has been retried `+"`max_retries`"+` times or the `+"`max_elapsed_time`"+` of the backoff has been reached.
+
+Messages that are given up on are written to the `+"`quarantine`"+` output, and only once the quarantine write succeeds is the message acknowledged with the child input. If the quarantine write fails, or no quarantine output is configured, then the message is rejected with the child input, leaving it to that input to decide how the failure is handled. This means that a message is never acknowledged with its source until it has either been delivered or quarantined.
+
+Each attempt delivers a copy of the message as it was originally consumed, and therefore modifications made by processors during a failed attempt are not carried over into the next attempt or the quarantine output.
+
+### Metadata
+
+Messages written to the quarantine output have the metadata field `+"`retry_error`"+` set to the error that caused the final attempt to fail.`).
+		Field(service.NewInputField("input").
+			Description("The child input to consume from.")).
+		Field(service.NewIntField("max_retries").
+			Description("The maximum number of times a rejected message is retried before it is given up on. If set to zero messages are given up on immediately after their first rejection.").
+			Default(3)).
+		Field(service.NewBackOffField("backoff", true, defaultBackOff).
+			Advanced()).
+		Field(service.NewOutputField("quarantine").
+			Description("An optional output that messages are written to once retries are exhausted. If omitted then messages that exhaust their retries are rejected with the child input.").
+			Optional()).
+		Example("Quarantine Poison Messages", `
+Consume from a Kafka topic and retry messages that fail to be delivered five times before writing them to a dead letter topic, allowing the consumer to progress past messages that can never be delivered.`, `
+input:
+  retry:
+    max_retries: 5
+    input:
+      kafka:
+        addresses: [ TODO ]
+        topics: [ foo ]
+        consumer_group: benthos_group
+    quarantine:
+      kafka:
+        addresses: [ TODO ]
+        topic: foo_dead_letters
+`)
+}
+
+func init() {
+	err := service.RegisterBatchInput(
+		"retry", retryInputConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.BatchInput, error) {
+			return newRetryInputFromConfig(conf, mgr.Logger())
+		})
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+func newRetryInputFromConfig(conf *service.ParsedConfig, log *service.Logger) (*retryInput, error) {
+	maxRetries, err := conf.FieldInt("max_retries")
+	if err != nil {
+		return nil, err
+	}
+	boff, err := conf.FieldBackOff("backoff")
+	if err != nil {
+		return nil, err
+	}
+	child, err := conf.FieldInput("input")
+	if err != nil {
+		return nil, err
+	}
+	var quarantine *service.OwnedOutput
+	if conf.Contains("quarantine") {
+		if quarantine, err = conf.FieldOutput("quarantine"); err != nil {
+			return nil, err
+		}
+	}
+	return newRetryInput(child, quarantine, maxRetries, boff, log), nil
+}
+
+//------------------------------------------------------------------------------
+
+type retryPending struct {
+	batch    service.MessageBatch
+	ackFn    service.AckFunc
+	boff     backoff.BackOff
+	attempts int
+}
+
+type childRead struct {
+	pending *retryPending
+	err     error
+}
+
+type retryInput struct {
+	child      *service.OwnedInput
+	quarantine *service.OwnedOutput
+	maxRetries int
+	boff       backoff.ExponentialBackOff
+	log        *service.Logger
+
+	reads   chan childRead
+	resends chan *retryPending
+
+	// The number of batches consumed from the child input that are yet to be
+	// either acknowledged or rejected with it, once the child input has ended
+	// drained is closed when this reaches zero.
+	pending    int
+	inputEnded bool
+	drained    chan struct{}
+	pendingMut sync.Mutex
+
+	// Tracks the goroutines waiting to resend batches, which must reject
+	// their batches before the child input is closed.
+	resendsWG     sync.WaitGroup
+	resendsClosed bool
+
+	shutSig *shutdown.Signaller
+}
+
+func newRetryInput(child *service.OwnedInput, quarantine *service.OwnedOutput, maxRetries int, boff *backoff.ExponentialBackOff, log *service.Logger) *retryInput {
+	r := &retryInput{
+		child:      child,
+		quarantine: quarantine,
+		maxRetries: maxRetries,
+		boff:       *boff,
+		log:        log,
+		reads:      make(chan childRead),
+		resends:    make(chan *retryPending),
+		drained:    make(chan struct{}),
+		shutSig:    shutdown.NewSignaller(),
+	}
+	go r.readLoop()
+	return r
+}
+
+func (r *retryInput) readLoop() {
+	defer r.shutSig.ShutdownComplete()
+
+	ctx, done := r.shutSig.CloseNowCtx(context.Background())
+	defer done()
+
+	for {
+		batch, ackFn, err := r.child.ReadBatch(ctx)
+		if err != nil {
+			if r.shutSig.ShouldCloseNow() {
+				return
+			}
+			select {
+			case r.reads <- childRead{err: err}:
+			case <-r.shutSig.CloseNowChan():
+				return
+			}
+			if err == service.ErrEndOfInput {
+				r.endInput()
+				close(r.reads)
+				return
+			}
+			continue
+		}
+
+		boff := r.boff
+		boff.Reset()
+		p := &retryPending{
+			batch: batch,
+			ackFn: ackFn,
+			boff:  &boff,
+		}
+		r.addPending()
+		select {
+		case r.reads <- childRead{pending: p}:
+		case <-r.shutSig.CloseNowChan():
+			r.donePending()
+			_ = ackFn(context.Background(), service.ErrNotConnected)
+			return
+		}
+	}
+}
+
+func (r *retryInput) addPending() {
+	r.pendingMut.Lock()
+	r.pending++
+	r.pendingMut.Unlock()
+}
+
+func (r *retryInput) donePending() {
+	r.pendingMut.Lock()
+	r.pending--
+	if r.inputEnded && r.pending == 0 {
+		close(r.drained)
+	}
+	r.pendingMut.Unlock()
+}
+
+func (r *retryInput) endInput() {
+	r.pendingMut.Lock()
+	r.inputEnded = true
+	if r.pending == 0 {
+		close(r.drained)
+	}
+	r.pendingMut.Unlock()
+}
+
+func (r *retryInput) Connect(ctx context.Context) error {
+	return nil
+}
+
+// scheduleResend queues a rejected batch to be dispatched again once its
+// backoff period has elapsed. Returns false if the retries of the batch are
+// exhausted.
+func (r *retryInput) scheduleResend(p *retryPending) bool {
+	p.attempts++
+	if p.attempts > r.maxRetries {
+		return false
+	}
+	delay := p.boff.NextBackOff()
+	if delay == backoff.Stop {
+		return false
+	}
+	r.pendingMut.Lock()
+	if r.resendsClosed {
+		r.pendingMut.Unlock()
+		r.abandon(p)
+		return true
+	}
+	r.resendsWG.Add(1)
+	r.pendingMut.Unlock()
+
+	go func() {
+		defer r.resendsWG.Done()
+
+		// Batches abandoned due to shutting down are rejected so that the
+		// child input is able to redeliver them.
+		select {
+		case <-time.After(delay):
+		case <-r.shutSig.CloseNowChan():
+			r.abandon(p)
+			return
+		}
+		select {
+		case r.resends <- p:
+		case <-r.shutSig.CloseNowChan():
+			r.abandon(p)
+		}
+	}()
+	return true
+}
+
+func (r *retryInput) abandon(p *retryPending) {
+	r.donePending()
+	_ = p.ackFn(context.Background(), service.ErrNotConnected)
+}
+
+func (r *retryInput) giveUp(ctx context.Context, p *retryPending, err error) error {
+	defer r.donePending()
+	if r.quarantine == nil {
+		r.log.Errorf("Rejecting message after %v attempts: %v", p.attempts, err)
+		return p.ackFn(ctx, err)
+	}
+
+	qBatch := p.batch.Copy()
+	for _, m := range qBatch {
+		m.MetaSet("retry_error", err.Error())
+	}
+	if qErr := r.quarantine.WriteBatch(ctx, qBatch); qErr != nil {
+		r.log.Errorf("Failed to write message to quarantine after %v attempts: %v", p.attempts, qErr)
+		return p.ackFn(ctx, err)
+	}
+	r.log.Debugf("Quarantined message after %v attempts: %v", p.attempts, err)
+	return p.ackFn(ctx, nil)
+}
+
+func (r *retryInput) dispatch(p *retryPending) (service.MessageBatch, service.AckFunc) {
+	return p.batch.Copy(), func(ctx context.Context, err error) error {
+		if err == nil {
+			r.donePending()
+			return p.ackFn(ctx, nil)
+		}
+		if r.scheduleResend(p) {
+			return nil
+		}
+		return r.giveUp(ctx, p, err)
+	}
+}
+
+func (r *retryInput) ReadBatch(ctx context.Context) (service.MessageBatch, service.AckFunc, error) {
+	// Batches awaiting a retry are prioritised over new batches.
+	select {
+	case p := <-r.resends:
+		b, aFn := r.dispatch(p)
+		return b, aFn, nil
+	default:
+	}
+
+	select {
+	case p := <-r.resends:
+		b, aFn := r.dispatch(p)
+		return b, aFn, nil
+	case read, open := <-r.reads:
+		if !open {
+			break
+		}
+		if read.err != nil {
+			if read.err == service.ErrEndOfInput {
+				break
+			}
+			return nil, nil, read.err
+		}
+		b, aFn := r.dispatch(read.pending)
+		return b, aFn, nil
+	case <-ctx.Done():
+		return nil, nil, ctx.Err()
+	}
+
+	// The child input has ended, but we remain open until all pending batches
+	// are either delivered or given up on.
+	select {
+	case p := <-r.resends:
+		b, aFn := r.dispatch(p)
+		return b, aFn, nil
+	case <-r.drained:
+		return nil, nil, service.ErrEndOfInput
+	case <-ctx.Done():
+		return nil, nil, ctx.Err()
+	}
+}
+
+func (r *retryInput) Close(ctx context.Context) error {
+	r.shutSig.CloseNow()
+
+	r.pendingMut.Lock()
+	r.resendsClosed = true
+	r.pendingMut.Unlock()
+
+	// Batches abandoned whilst shutting down must be rejected before the child
+	// input is closed.
+	resendsDone := make(chan struct{})
+	go func() {
+		r.resendsWG.Wait()
+		close(resendsDone)
+	}()
+	for _, c := range []<-chan struct{}{r.shutSig.HasClosedChan(), resendsDone} {
+		select {
+		case <-c:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	if err := r.child.Close(ctx); err != nil {
+		return err
+	}
+	if r.quarantine != nil {
+		return r.quarantine.Close(ctx)
+	}
+	return nil
+}
@@ -0,0 +1,193 @@
+package pure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func runRetryInputStream(t *testing.T, inputConf string, fn service.MessageHandlerFunc) {
+	t.Helper()
+
+	strmBuilder := service.NewStreamBuilder()
+	require.NoError(t, strmBuilder.SetLoggerYAML(`level: NONE`))
+	require.NoError(t, strmBuilder.AddInputYAML(inputConf))
+	require.NoError(t, strmBuilder.AddProcessorYAML(`bloblang: 'root = content().uppercase()'`))
+	require.NoError(t, strmBuilder.AddConsumerFunc(fn))
+
+	strm, err := strmBuilder.Build()
+	require.NoError(t, err)
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*10)
+	defer done()
+	require.NoError(t, strm.Run(ctx))
+}
+
+func TestRetryInputQuarantine(t *testing.T) {
+	qDir := t.TempDir()
+
+	var attemptsMut sync.Mutex
+	attempts := map[string]int{}
+
+	runRetryInputStream(t, fmt.Sprintf(`
+retry:
+  max_retries: 2
+  backoff:
+    initial_interval: 1ms
+    max_interval: 1ms
+  input:
+    generate:
+      count: 2
+      interval: ""
+      mapping: 'root = if count("retry_input_quarantine") == 1 { "good" } else { "bad" }'
+  quarantine:
+    file:
+      path: '%v/${! meta("retry_error") }.txt'
+      codec: lines
+`, qDir), func(ctx context.Context, m *service.Message) error {
+		b, err := m.AsBytes()
+		require.NoError(t, err)
+
+		attemptsMut.Lock()
+		attempts[string(b)]++
+		attemptsMut.Unlock()
+
+		if string(b) == "BAD" {
+			return errors.New("nope")
+		}
+		return nil
+	})
+
+	assert.Equal(t, map[string]int{
+		"GOOD": 1,
+		"BAD":  3,
+	}, attempts)
+
+	qBytes, err := os.ReadFile(filepath.Join(qDir, "nope.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "bad\n", string(qBytes))
+}
+
+func TestRetryInputRecovers(t *testing.T) {
+	qPath := filepath.Join(t.TempDir(), "quarantine.txt")
+
+	var attemptsMut sync.Mutex
+	var attempts int
+
+	runRetryInputStream(t, fmt.Sprintf(`
+retry:
+  max_retries: 5
+  backoff:
+    initial_interval: 1ms
+    max_interval: 1ms
+  input:
+    generate:
+      count: 1
+      interval: ""
+      mapping: 'root = "hello"'
+  quarantine:
+    file:
+      path: %v
+      codec: lines
+`, qPath), func(ctx context.Context, m *service.Message) error {
+		attemptsMut.Lock()
+		defer attemptsMut.Unlock()
+
+		attempts++
+		if attempts < 3 {
+			return errors.New("nope")
+		}
+		b, err := m.AsBytes()
+		require.NoError(t, err)
+		assert.Equal(t, "HELLO", string(b))
+		return nil
+	})
+
+	assert.Equal(t, 3, attempts)
+
+	_, err := os.Stat(qPath)
+	assert.True(t, os.IsNotExist(err), err)
+}
+
+type retryTestChildInput struct {
+	read    bool
+	ackErrs chan error
+}
+
+func (c *retryTestChildInput) Connect(ctx context.Context) error {
+	return nil
+}
+
+func (c *retryTestChildInput) ReadBatch(ctx context.Context) (service.MessageBatch, service.AckFunc, error) {
+	if c.read {
+		<-ctx.Done()
+		return nil, nil, ctx.Err()
+	}
+	c.read = true
+	return service.MessageBatch{service.NewMessage([]byte("foo"))}, func(ctx context.Context, err error) error {
+		c.ackErrs <- err
+		return nil
+	}, nil
+}
+
+func (c *retryTestChildInput) Close(ctx context.Context) error {
+	return nil
+}
+
+func TestRetryInputAbandonedOnClose(t *testing.T) {
+	ctx, done := context.WithTimeout(context.Background(), time.Second*10)
+	defer done()
+
+	child := &retryTestChildInput{ackErrs: make(chan error, 1)}
+
+	env := service.NewEnvironment()
+	require.NoError(t, env.RegisterBatchInput("retry_test_child", service.NewConfigSpec(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.BatchInput, error) {
+			return child, nil
+		}))
+
+	pConf, err := service.NewConfigSpec().Field(service.NewInputField("input")).ParseYAML(`
+input:
+  retry_test_child: {}
+`, env)
+	require.NoError(t, err)
+
+	childInput, err := pConf.FieldInput("input")
+	require.NoError(t, err)
+
+	boff := backoff.NewExponentialBackOff()
+	boff.InitialInterval = time.Hour
+	boff.MaxElapsedTime = 0
+	r := newRetryInput(childInput, nil, 5, boff, nil)
+
+	b, aFn, err := r.ReadBatch(ctx)
+	require.NoError(t, err)
+	require.Len(t, b, 1)
+
+	// The batch is scheduled to be resent in an hour.
+	require.NoError(t, aFn(ctx, errors.New("nope")))
+	select {
+	case err := <-child.ackErrs:
+		t.Fatalf("unexpected ack: %v", err)
+	case <-time.After(time.Millisecond * 50):
+	}
+
+	require.NoError(t, r.Close(ctx))
+	select {
+	case err := <-child.ackErrs:
+		assert.Equal(t, service.ErrNotConnected, err)
+	case <-ctx.Done():
+		t.Fatal("timed out")
+	}
+}
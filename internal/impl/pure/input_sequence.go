@@ -32,7 +32,23 @@ Reads messages from a sequence of child inputs, starting with the first and once
 that input gracefully terminates starts consuming from the next, and so on.`,
 		Description: `
 This input is useful for consuming from inputs that have an explicit end but
-must not be consumed in parallel.`,
+must not be consumed in parallel.
+
+### Ordered Merge
+
+When ` + "`ordered_merge.enabled`" + ` is ` + "`true`" + ` all inputs are instead consumed in
+parallel, and the next message of each input is buffered until every input that
+hasn't terminated has a message buffered. The buffered message with the
+earliest timestamp is then emitted, which results in a stream that is globally
+ordered by the timestamp as long as the messages of each input are also ordered.
+This is useful for replaying multiple historical sources in the order that the
+events occurred.
+
+The timestamp of a message is resolved with the interpolated string
+` + "`ordered_merge.timestamp`" + `, which must result in either a number of seconds
+since the unix epoch or an RFC 3339 formatted string. Messages with a timestamp
+that cannot be parsed are emitted immediately. For batched messages the
+timestamp of the first message of the batch is used.`,
 		Examples: []docs.AnnotatedExample{
 			{
 				Title:   "End of Stream Message",
@@ -46,6 +62,24 @@ input:
       - generate:
           count: 1
           mapping: 'root = {"status":"finished"}'
+`,
+			},
+			{
+				Title:   "Replaying Logs In Order",
+				Summary: "Multiple log files, each ordered by the field `ts`, can be replayed as a single stream ordered by the field `ts` with an ordered merge.",
+				Config: `
+input:
+  sequence:
+    ordered_merge:
+      enabled: true
+      timestamp: '${! json("ts") }'
+    inputs:
+      - file:
+          paths: [ ./service_a.ndjson ]
+          codec: lines
+      - file:
+          paths: [ ./service_b.ndjson ]
+          codec: lines
 `,
 			},
 			{
@@ -158,6 +192,13 @@ Each message must be structured (JSON or otherwise processed into a structured f
 					"The chosen strategy to use when a data join would otherwise result in a collision of field values. The strategy `array` means non-array colliding values are placed into an array and colliding arrays are merged. The strategy `replace` replaces old values with new values. The strategy `keep` keeps the old value.",
 				).HasOptions("array", "replace", "keep"),
 			).AtVersion("3.40.0").Advanced(),
+			docs.FieldObject(
+				"ordered_merge",
+				"Consume all inputs in parallel and emit their messages in order of a timestamp, as described in [ordered merge](#ordered-merge). An ordered merge cannot be combined with a sharded join.",
+			).WithChildren(
+				docs.FieldBool("enabled", "Whether to perform an ordered merge of the inputs."),
+				docs.FieldInterpolatedString("timestamp", "The timestamp of each message, which must resolve to either a number of seconds since the unix epoch or an RFC 3339 formatted string.", `${! json("timestamp") }`, `${! meta("kafka_timestamp_unix") }`),
+			).AtVersion("4.1.0").Advanced(),
 			docs.FieldInput("inputs", "An array of inputs to read from sequentially.").Array(),
 		).ChildDefaultAndTypesFromStruct(oinput.NewSequenceConfig()),
 		Categories: []string{
@@ -335,6 +376,9 @@ func newSequenceInput(conf oinput.Config, mgr interop.Manager, log log.Modular)
 	if len(conf.Sequence.Inputs) == 0 {
 		return nil, errors.New("requires at least one child input")
 	}
+	if conf.Sequence.OrderedMerge.Enabled {
+		return newOrderedSequenceInput(conf.Sequence, mgr, log)
+	}
 
 	targets := make([]sequenceTarget, 0, len(conf.Sequence.Inputs))
 	for i, c := range conf.Sequence.Inputs {
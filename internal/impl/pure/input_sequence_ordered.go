@@ -0,0 +1,230 @@
+package pure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang/field"
+	"github.com/benthosdev/benthos/v4/internal/component"
+	"github.com/benthosdev/benthos/v4/internal/component/input"
+	"github.com/benthosdev/benthos/v4/internal/interop"
+	"github.com/benthosdev/benthos/v4/internal/log"
+	"github.com/benthosdev/benthos/v4/internal/message"
+	oinput "github.com/benthosdev/benthos/v4/internal/old/input"
+	"github.com/benthosdev/benthos/v4/internal/shutdown"
+)
+
+// parseOrderedTimestamp parses a timestamp either as a number of seconds since
+// the unix epoch or as an RFC 3339 formatted string.
+func parseOrderedTimestamp(v string) (time.Time, error) {
+	if f, err := strconv.ParseFloat(v, 64); err == nil {
+		secs, frac := math.Modf(f)
+		return time.Unix(int64(secs), int64(frac*1e9)), nil
+	}
+	return time.Parse(time.RFC3339Nano, v)
+}
+
+type orderedHead struct {
+	tran message.Transaction
+	ts   time.Time
+}
+
+type orderedSequenceInput struct {
+	inputs    []input.Streamed
+	heads     []*orderedHead
+	timestamp *field.Expression
+
+	log log.Modular
+
+	transactions chan message.Transaction
+
+	shutSig *shutdown.Signaller
+}
+
+func newOrderedSequenceInput(conf oinput.SequenceConfig, mgr interop.Manager, log log.Modular) (input.Streamed, error) {
+	if conf.ShardedJoin.Type != "none" {
+		return nil, errors.New("ordered merge cannot be combined with a sharded join")
+	}
+	if conf.OrderedMerge.Timestamp == "" {
+		return nil, errors.New("a timestamp must be specified for an ordered merge")
+	}
+
+	timestamp, err := mgr.BloblEnvironment().NewField(conf.OrderedMerge.Timestamp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse timestamp expression: %v", err)
+	}
+
+	r := &orderedSequenceInput{
+		timestamp:    timestamp,
+		log:          log,
+		transactions: make(chan message.Transaction),
+		shutSig:      shutdown.NewSignaller(),
+	}
+	for i, c := range conf.Inputs {
+		wMgr := mgr.IntoPath("sequence", "inputs", strconv.Itoa(i))
+		in, err := oinput.New(c, wMgr, wMgr.Logger(), wMgr.Metrics())
+		if err != nil {
+			for _, prev := range r.inputs {
+				prev.CloseAsync()
+			}
+			return nil, fmt.Errorf("failed to initialize input index %v: %w", i, err)
+		}
+		r.inputs = append(r.inputs, in)
+	}
+	r.heads = make([]*orderedHead, len(r.inputs))
+
+	go r.loop()
+	return r, nil
+}
+
+// readHead blocks until the next transaction of an input is read, returning
+// false if the input has closed and a nil head if the reader is shutting down.
+func (r *orderedSequenceInput) readHead(index int) (*orderedHead, bool) {
+	select {
+	case tran, open := <-r.inputs[index].TransactionChan():
+		if !open {
+			return nil, false
+		}
+		tsStr := r.timestamp.String(0, tran.Payload)
+		ts, err := parseOrderedTimestamp(tsStr)
+		if err != nil {
+			r.log.Errorf("Failed to parse timestamp '%v' of message from input %v, it will be emitted immediately: %v", tsStr, index, err)
+		}
+		return &orderedHead{tran: tran, ts: ts}, true
+	case <-r.shutSig.CloseAtLeisureChan():
+		return nil, true
+	}
+}
+
+// nackHeads rejects the transactions of all buffered heads, as otherwise the
+// inputs they were read from would be left waiting on them whilst shutting
+// down. The returned func abandons any rejections that are yet to be received
+// and must only be called once the inputs have closed.
+func (r *orderedSequenceInput) nackHeads() func() {
+	ctx, done := context.WithCancel(context.Background())
+
+	var wg sync.WaitGroup
+	for i, head := range r.heads {
+		if head == nil {
+			continue
+		}
+		wg.Add(1)
+		go func(index int, tran message.Transaction) {
+			defer wg.Done()
+			if err := tran.Ack(ctx, component.ErrTypeClosed); err != nil {
+				r.log.Debugf("Failed to reject message of input %v during shutdown: %v", index, err)
+			}
+		}(i, head.tran)
+		r.heads[i] = nil
+	}
+
+	return func() {
+		done()
+		wg.Wait()
+	}
+}
+
+func (r *orderedSequenceInput) loop() {
+	defer func() {
+		abandonNacks := r.nackHeads()
+		for _, in := range r.inputs {
+			in.CloseAsync()
+		}
+		go func() {
+			select {
+			case <-r.shutSig.CloseNowChan():
+				for _, in := range r.inputs {
+					_ = in.WaitForClose(0)
+				}
+			case <-r.shutSig.HasClosedChan():
+			}
+		}()
+		for _, in := range r.inputs {
+			_ = in.WaitForClose(shutdown.MaximumShutdownWait())
+		}
+		abandonNacks()
+		close(r.transactions)
+		r.shutSig.ShutdownComplete()
+	}()
+
+	open := make([]bool, len(r.inputs))
+	for i := range open {
+		open[i] = true
+	}
+
+	for {
+		// Every open input must have a buffered head before the earliest can
+		// be determined.
+		next := -1
+		for i, head := range r.heads {
+			if !open[i] {
+				continue
+			}
+			if head == nil {
+				var stillOpen bool
+				if head, stillOpen = r.readHead(i); !stillOpen {
+					open[i] = false
+					r.log.Debugf("Finished ordered sequence input %v.", i)
+					continue
+				}
+				if head == nil {
+					return
+				}
+				r.heads[i] = head
+			}
+			if next == -1 || head.ts.Before(r.heads[next].ts) {
+				next = i
+			}
+		}
+		if next == -1 {
+			r.log.Infoln("Exhausted all sequence inputs, shutting down.")
+			return
+		}
+
+		// The head is only cleared once it has been sent so that it is rejected
+		// when the send is abandoned.
+		select {
+		case r.transactions <- r.heads[next].tran:
+			r.heads[next] = nil
+		case <-r.shutSig.CloseNowChan():
+			return
+		}
+	}
+}
+
+func (r *orderedSequenceInput) TransactionChan() <-chan message.Transaction {
+	return r.transactions
+}
+
+func (r *orderedSequenceInput) Connected() bool {
+	for _, in := range r.inputs {
+		if !in.Connected() {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *orderedSequenceInput) CloseAsync() {
+	r.shutSig.CloseAtLeisure()
+}
+
+func (r *orderedSequenceInput) WaitForClose(timeout time.Duration) error {
+	go func() {
+		if tAfter := timeout - time.Second; tAfter > 0 {
+			<-time.After(timeout - time.Second)
+		}
+		r.shutSig.CloseNow()
+	}()
+	select {
+	case <-r.shutSig.HasClosedChan():
+	case <-time.After(timeout):
+		return component.ErrTimeout
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package pure
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang"
+	"github.com/benthosdev/benthos/v4/internal/component"
+	"github.com/benthosdev/benthos/v4/internal/component/input"
+	"github.com/benthosdev/benthos/v4/internal/log"
+	"github.com/benthosdev/benthos/v4/internal/manager/mock"
+	"github.com/benthosdev/benthos/v4/internal/message"
+	"github.com/benthosdev/benthos/v4/internal/shutdown"
+)
+
+// orderedTestInput remains open until it is closed and, much like inputs
+// backed by a reader, waits for its transactions to be acknowledged before
+// it finishes closing.
+type orderedTestInput struct {
+	*mock.Input
+
+	pending sync.WaitGroup
+	ackErrs chan error
+}
+
+func newOrderedTestInput(timestamps ...string) *orderedTestInput {
+	in := &orderedTestInput{
+		Input:   &mock.Input{TChan: make(chan message.Transaction, len(timestamps))},
+		ackErrs: make(chan error, len(timestamps)),
+	}
+	for _, ts := range timestamps {
+		in.pending.Add(1)
+		in.TChan <- message.NewTransactionFunc(message.QuickBatch([][]byte{[]byte(`{"ts":"` + ts + `"}`)}), func(ctx context.Context, err error) error {
+			in.ackErrs <- err
+			in.pending.Done()
+			return nil
+		})
+	}
+	return in
+}
+
+func (o *orderedTestInput) WaitForClose(timeout time.Duration) error {
+	acked := make(chan struct{})
+	go func() {
+		o.pending.Wait()
+		close(acked)
+	}()
+	select {
+	case <-acked:
+	case <-time.After(timeout):
+		return component.ErrTimeout
+	}
+	return nil
+}
+
+func orderedTestSequence(t *testing.T, inputs ...input.Streamed) *orderedSequenceInput {
+	t.Helper()
+
+	timestamp, err := bloblang.GlobalEnvironment().NewField(`${! json("ts") }`)
+	require.NoError(t, err)
+
+	r := &orderedSequenceInput{
+		inputs:       inputs,
+		heads:        make([]*orderedHead, len(inputs)),
+		timestamp:    timestamp,
+		log:          log.Noop(),
+		transactions: make(chan message.Transaction),
+		shutSig:      shutdown.NewSignaller(),
+	}
+	go r.loop()
+	return r
+}
+
+func TestSequenceOrderedShutdownNacksHeads(t *testing.T) {
+	// The first input has a head buffered whilst the second input remains open
+	// without any messages.
+	inA, inB := newOrderedTestInput("2022-01-01T00:00:01Z"), newOrderedTestInput()
+
+	r := orderedTestSequence(t, inA, inB)
+	require.Eventually(t, func() bool {
+		return len(inA.TChan) == 0
+	}, time.Second, time.Millisecond*10)
+
+	r.CloseAsync()
+	require.NoError(t, r.WaitForClose(time.Second*5))
+
+	require.Len(t, inA.ackErrs, 1)
+	assert.Equal(t, component.ErrTypeClosed, <-inA.ackErrs)
+}
+
+func TestSequenceOrderedShutdownNacksInFlight(t *testing.T) {
+	// Both inputs have a head buffered and remain open, and the earliest head
+	// is never consumed.
+	inA, inB := newOrderedTestInput("2022-01-01T00:00:01Z"), newOrderedTestInput("2022-01-01T00:00:02Z")
+
+	r := orderedTestSequence(t, inA, inB)
+	require.Eventually(t, func() bool {
+		return len(inA.TChan) == 0 && len(inB.TChan) == 0
+	}, time.Second, time.Millisecond*10)
+
+	r.CloseAsync()
+	require.NoError(t, r.WaitForClose(time.Second*2))
+
+	for _, in := range []*orderedTestInput{inA, inB} {
+		require.Len(t, in.ackErrs, 1)
+		assert.Equal(t, component.ErrTypeClosed, <-in.ackErrs)
+	}
+}
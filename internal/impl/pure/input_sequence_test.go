@@ -465,3 +465,80 @@ func TestSequenceEarlyTermination(t *testing.T) {
 	rdr.CloseAsync()
 	assert.NoError(t, rdr.WaitForClose(time.Second*5))
 }
+
+func TestSequenceOrderedMerge(t *testing.T) {
+	tCtx, done := context.WithTimeout(context.Background(), time.Minute)
+	defer done()
+
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+
+	writeFiles(t, tmpDir, map[string]string{
+		"a": `{"id":"a1","ts":"2022-01-01T00:00:01Z"}
+{"id":"a2","ts":"2022-01-01T00:00:04Z"}
+{"id":"a3","ts":"2022-01-01T00:00:05Z"}`,
+		"b": `{"id":"b1","ts":1640995200}
+{"id":"b2","ts":1640995202.5}`,
+		"c": `{"id":"c1","ts":"2022-01-01T00:00:03Z"}
+{"id":"c2","ts":"nope"}
+{"id":"c3","ts":"2022-01-01T00:00:06Z"}`,
+	})
+
+	conf := oinput.NewConfig()
+	conf.Type = "sequence"
+	conf.Sequence.OrderedMerge.Enabled = true
+	conf.Sequence.OrderedMerge.Timestamp = `${! json("ts") }`
+
+	for _, k := range []string{"a", "b", "c"} {
+		inConf := oinput.NewConfig()
+		inConf.Type = "file"
+		inConf.File.Paths = []string{filepath.Join(tmpDir, k)}
+		conf.Sequence.Inputs = append(conf.Sequence.Inputs, inConf)
+	}
+
+	rdr, err := bmock.NewManager().NewInput(conf)
+	require.NoError(t, err)
+
+	exp, act := []string{
+		"b1", "a1", "b2", "c1", "c2", "a2", "a3", "c3",
+	}, []string{}
+
+consumeLoop:
+	for {
+		select {
+		case tran, open := <-rdr.TransactionChan():
+			if !open {
+				break consumeLoop
+			}
+			v, err := tran.Payload.Get(0).JSON()
+			require.NoError(t, err)
+			act = append(act, v.(map[string]interface{})["id"].(string))
+			require.NoError(t, tran.Ack(tCtx, nil))
+		case <-tCtx.Done():
+			t.Fatalf("Failed to consume message after: %v", act)
+		}
+	}
+
+	assert.Equal(t, exp, act)
+
+	rdr.CloseAsync()
+	assert.NoError(t, rdr.WaitForClose(time.Second))
+}
+
+func TestSequenceOrderedMergeWithJoin(t *testing.T) {
+	conf := oinput.NewConfig()
+	conf.Type = "sequence"
+	conf.Sequence.OrderedMerge.Enabled = true
+	conf.Sequence.OrderedMerge.Timestamp = `${! json("ts") }`
+	conf.Sequence.ShardedJoin.Type = "full-outter"
+	conf.Sequence.ShardedJoin.IDPath = "id"
+
+	inConf := oinput.NewConfig()
+	inConf.Type = "generate"
+	inConf.Generate.Mapping = `root = {}`
+	conf.Sequence.Inputs = append(conf.Sequence.Inputs, inConf)
+
+	_, err := bmock.NewManager().NewInput(conf)
+	require.Error(t, err)
+}
@@ -0,0 +1,70 @@
+package pure
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/benthosdev/benthos/v4/internal/bundle"
+	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+	"github.com/benthosdev/benthos/v4/internal/docs"
+	"github.com/benthosdev/benthos/v4/internal/log"
+)
+
+func init() {
+	_ = bundle.AllMetrics.Add(func(conf metrics.Config, log log.Modular) (metrics.Type, error) {
+		return newMultiMetrics(conf.Multi, log)
+	}, docs.ComponentSpec{
+		Name:    "multi",
+		Type:    docs.TypeMetrics,
+		Status:  docs.StatusBeta,
+		Version: "4.1.0",
+		Summary: `Sends metrics to multiple targets, each with its own mapping.`,
+		Description: `
+Each target is a metrics type with its own ` + "`mapping`" + ` and ` + "`max_label_cardinality`" + `, which are applied only to the metrics sent to that target. This allows a target such as ` + "`prometheus`" + ` to receive every metric whilst a target that charges per series, such as ` + "`aws_cloudwatch`" + `, only receives a curated subset.
+
+A ` + "`mapping`" + ` configured on the ` + "`multi`" + ` type itself is applied before the mappings of the targets. If more than one target serves metrics over HTTP then only the endpoint of the first such target is exposed.`,
+		Config: docs.FieldComponent().WithChildren(
+			docs.FieldMetrics("targets", "A list of metrics targets to send metrics to.").Array().HasDefault([]interface{}{}),
+		),
+		Examples: []docs.AnnotatedExample{
+			{
+				Title: "Curated CloudWatch Metrics",
+				Summary: `
+All metrics are exposed for Prometheus to scrape, whilst only the metrics of outputs are sent to CloudWatch.`,
+				Config: `
+metrics:
+  multi:
+    targets:
+      - prometheus: {}
+      - aws_cloudwatch:
+          namespace: BenthosStream
+        mapping: |
+          root = if !this.has_prefix("output_") { deleted() }
+`,
+			},
+		},
+	})
+}
+
+func newMultiMetrics(conf metrics.MultiConfig, log log.Modular) (metrics.Type, error) {
+	if len(conf.Targets) == 0 {
+		return nil, errors.New("at least one target must be specified")
+	}
+
+	var m metrics.Type
+	for i, tConf := range conf.Targets {
+		t, err := bundle.AllMetrics.Init(tConf, log)
+		if err != nil {
+			if m != nil {
+				_ = m.Close()
+			}
+			return nil, fmt.Errorf("failed to create target %v: %w", i, err)
+		}
+		if m == nil {
+			m = t
+		} else {
+			m = metrics.Combine(m, t)
+		}
+	}
+	return m, nil
+}
@@ -0,0 +1,54 @@
+package pure_test
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/bundle"
+	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+	"github.com/benthosdev/benthos/v4/internal/log"
+
+	_ "github.com/benthosdev/benthos/v4/internal/impl/net"
+)
+
+func TestMultiMetricsTargetMappings(t *testing.T) {
+	jsonConf := metrics.NewConfig()
+	jsonConf.Type = "json_api"
+	jsonConf.Mapping = `root = if this == "a_foo" { deleted() }`
+
+	loggerConf := metrics.NewConfig()
+	loggerConf.Type = "logger"
+
+	conf := metrics.NewConfig()
+	conf.Type = "multi"
+	conf.Mapping = `root = "a_" + this`
+	conf.Multi.Targets = []metrics.Config{jsonConf, loggerConf}
+
+	m, err := bundle.AllMetrics.Init(conf, log.Noop())
+	require.NoError(t, err)
+	defer m.Close()
+
+	m.GetCounter("foo").Incr(1)
+	m.GetCounter("bar").Incr(2)
+
+	rec := httptest.NewRecorder()
+	m.HandlerFunc()(rec, httptest.NewRequest("GET", "/metrics", nil))
+	assert.JSONEq(t, `{"a_bar":2}`, rec.Body.String())
+}
+
+func TestMultiMetricsErrors(t *testing.T) {
+	conf := metrics.NewConfig()
+	conf.Type = "multi"
+	_, err := bundle.AllMetrics.Init(conf, log.Noop())
+	require.Error(t, err)
+
+	badConf := metrics.NewConfig()
+	badConf.Type = "json_api"
+	badConf.Mapping = `root = this.`
+	conf.Multi.Targets = []metrics.Config{badConf}
+	_, err = bundle.AllMetrics.Init(conf, log.Noop())
+	require.Error(t, err)
+}
@@ -63,7 +63,11 @@ output:
 
 Benthos makes a best attempt at inferring which specific messages of the batch failed, and only propagates those individual messages to the next fallback tier.
 
-However, depending on the output and the error returned it is sometimes not possible to determine the individual messages that failed, in which case the whole batch is passed to the next tier in order to preserve at-least-once delivery guarantees.`,
+However, depending on the output and the error returned it is sometimes not possible to determine the individual messages that failed, in which case the whole batch is passed to the next tier in order to preserve at-least-once delivery guarantees.
+
+### Metadata
+
+Messages passed to a subsequent tier have the metadata field ` + "`fallback_error`" + ` set to the error returned by the previous tier, which can be used in order to route or annotate them.`,
 		Categories: []string{
 			"Utility",
 		},
@@ -179,14 +183,17 @@ func (t *fallbackBroker) loop() {
 		}
 
 		i := 0
+		group, payload := message.NewSortGroup(tran.Payload)
+
 		var ackFn func(ctx context.Context, err error) error
 		ackFn = func(ctx context.Context, err error) error {
 			i++
 			if err == nil || len(t.outputTSChans) <= i {
 				return tran.Ack(ctx, err)
 			}
+			payload = withMetadata(failedSubset(group, payload, err), "fallback_error", err.Error())
 			select {
-			case t.outputTSChans[i] <- message.NewTransactionFunc(tran.Payload, ackFn):
+			case t.outputTSChans[i] <- message.NewTransactionFunc(payload, ackFn):
 			case <-ctx.Done():
 				return ctx.Err()
 			}
@@ -194,7 +201,7 @@ func (t *fallbackBroker) loop() {
 		}
 
 		select {
-		case t.outputTSChans[i] <- message.NewTransactionFunc(tran.Payload, ackFn):
+		case t.outputTSChans[i] <- message.NewTransactionFunc(payload, ackFn):
 		case <-t.shutSig.CloseAtLeisureChan():
 			return
 		}
@@ -15,6 +15,7 @@ import (
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/benthosdev/benthos/v4/internal/batch"
 	"github.com/benthosdev/benthos/v4/internal/bundle"
 	bmock "github.com/benthosdev/benthos/v4/internal/bundle/mock"
 	"github.com/benthosdev/benthos/v4/internal/component/output"
@@ -449,3 +450,64 @@ func TestFallbackAllFailParallel(t *testing.T) {
 }
 
 //------------------------------------------------------------------------------
+
+func TestFallbackBatchErrorSubset(t *testing.T) {
+	tCtx, done := context.WithTimeout(context.Background(), time.Second*5)
+	defer done()
+
+	mockOutputs := []*mock.OutputChanneled{{}, {}}
+	outputs := []output.Streamed{mockOutputs[0], mockOutputs[1]}
+
+	readChan := make(chan message.Transaction)
+	resChan := make(chan error)
+
+	oTM, err := newFallbackBroker(outputs)
+	require.NoError(t, err)
+	require.NoError(t, oTM.Consume(readChan))
+
+	select {
+	case readChan <- message.NewTransaction(message.QuickBatch([][]byte{
+		[]byte("foo"), []byte("bar"), []byte("baz"),
+	}), resChan):
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for broker send")
+	}
+
+	var ts message.Transaction
+	select {
+	case ts = <-mockOutputs[0].TChan:
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for broker propagate")
+	}
+	require.Equal(t, 3, ts.Payload.Len())
+
+	batchErr := batch.NewError(ts.Payload, errors.New("nope")).
+		Failed(0, errors.New("foo failed")).
+		Failed(2, errors.New("baz failed"))
+	go func() {
+		require.NoError(t, ts.Ack(tCtx, batchErr))
+	}()
+
+	select {
+	case ts = <-mockOutputs[1].TChan:
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for broker propagate")
+	}
+	require.Equal(t, 2, ts.Payload.Len())
+	require.Equal(t, "foo", string(ts.Payload.Get(0).Get()))
+	require.Equal(t, "baz", string(ts.Payload.Get(1).Get()))
+	require.Equal(t, batchErr.Error(), ts.Payload.Get(0).MetaGet("fallback_error"))
+	go func() {
+		require.NoError(t, ts.Ack(tCtx, nil))
+	}()
+
+	select {
+	case res := <-resChan:
+		require.NoError(t, res)
+	case <-time.After(time.Second):
+		t.Fatal("Timed out responding to broker")
+	}
+
+	close(readChan)
+	require.NoError(t, oTM.WaitForClose(time.Second*10))
+}
@@ -3,12 +3,14 @@ package pure
 import (
 	"context"
 	"errors"
+	"strconv"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/cenkalti/backoff/v4"
 
+	"github.com/benthosdev/benthos/v4/internal/batch"
 	"github.com/benthosdev/benthos/v4/internal/bundle"
 	"github.com/benthosdev/benthos/v4/internal/component"
 	"github.com/benthosdev/benthos/v4/internal/component/output"
@@ -40,9 +42,18 @@ we want to avoid reapplying to the same message more than once in the pipeline.
 
 Rather than retrying the same output you may wish to retry the send using a
 different output target (a dead letter queue). In which case you should instead
-use the ` + "[`fallback`](/docs/components/outputs/fallback)" + ` output type.`,
+use the ` + "[`fallback`](/docs/components/outputs/fallback)" + ` output type.
+
+### Batches
+
+By default a batch that fails is retried in its entirety. When
+` + "`retry_failed_only`" + ` is enabled, and the child output reports which
+specific messages of a batch failed, only those messages are retried, and each
+retried message has the metadata field ` + "`retry_attempts`" + ` set to the
+number of prior attempts made to deliver it.`,
 		Config: docs.FieldComponent().WithChildren(
 			docs.FieldInt("max_retries", "The maximum number of retries before giving up on the request. If set to zero there is no discrete limit.").HasDefault(0).Advanced(),
+			docs.FieldBool("retry_failed_only", "Whether to retry only the messages of a batch that the child output reports as failed, rather than the whole batch.").HasDefault(false).Advanced().AtVersion("4.1.0"),
 			docs.FieldObject("backoff", "Control time intervals between retry attempts.").WithChildren(
 				docs.FieldString("initial_interval", "The initial period to wait between retry attempts.").HasDefault("100ms"),
 				docs.FieldString("max_interval", "The maximum period to wait between retry attempts.").HasDefault("1s"),
@@ -65,7 +76,7 @@ use the ` + "[`fallback`](/docs/components/outputs/fallback)" + ` output type.`,
 // where send errors downstream are automatically caught and retried rather than
 // propagated upstream as nacks.
 func RetryOutputIndefinitely(mgr interop.Manager, wrapped output.Streamed) (output.Streamed, error) {
-	return newIndefiniteRetry(mgr, nil, wrapped)
+	return newIndefiniteRetry(mgr, nil, wrapped, false)
 }
 
 func retryOutputFromConfig(conf ooutput.RetryConfig, mgr interop.Manager) (output.Streamed, error) {
@@ -83,10 +94,10 @@ func retryOutputFromConfig(conf ooutput.RetryConfig, mgr interop.Manager) (outpu
 		return nil, err
 	}
 
-	return newIndefiniteRetry(mgr, boffCtor, wrapped)
+	return newIndefiniteRetry(mgr, boffCtor, wrapped, conf.RetryFailedOnly)
 }
 
-func newIndefiniteRetry(mgr interop.Manager, backoffCtor func() backoff.BackOff, wrapped output.Streamed) (*indefiniteRetry, error) {
+func newIndefiniteRetry(mgr interop.Manager, backoffCtor func() backoff.BackOff, wrapped output.Streamed, failedOnly bool) (*indefiniteRetry, error) {
 	if backoffCtor == nil {
 		tmpConf := ooutput.NewRetryConfig()
 		var err error
@@ -99,6 +110,7 @@ func newIndefiniteRetry(mgr interop.Manager, backoffCtor func() backoff.BackOff,
 		log:             mgr.Logger(),
 		wrapped:         wrapped,
 		backoffCtor:     backoffCtor,
+		failedOnly:      failedOnly,
 		transactionsOut: make(chan message.Transaction),
 		shutSig:         shutdown.NewSignaller(),
 	}, nil
@@ -109,6 +121,7 @@ func newIndefiniteRetry(mgr interop.Manager, backoffCtor func() backoff.BackOff,
 type indefiniteRetry struct {
 	wrapped     output.Streamed
 	backoffCtor func() backoff.BackOff
+	failedOnly  bool
 
 	log log.Modular
 
@@ -159,18 +172,25 @@ func (r *indefiniteRetry) loop() {
 			return
 		}
 
+		payload := tran.Payload
+		var group *message.SortGroup
+		if r.failedOnly {
+			group, payload = message.NewSortGroup(payload)
+		}
+
 		rChan := make(chan error)
 		select {
-		case r.transactionsOut <- message.NewTransaction(tran.Payload, rChan):
+		case r.transactionsOut <- message.NewTransaction(payload, rChan):
 		case <-r.shutSig.CloseAtLeisureChan():
 			return
 		}
 
 		wg.Add(1)
-		go func(ts message.Transaction, resChan chan error) {
+		go func(ts message.Transaction, payload *message.Batch, resChan chan error) {
 			var backOff backoff.BackOff
 			var resOut error
 			var inErrLoop bool
+			attempts := 1
 
 			defer func() {
 				wg.Done()
@@ -218,8 +238,14 @@ func (r *indefiniteRetry) loop() {
 						return
 					}
 
+					if group != nil {
+						payload = failedSubset(group, payload, res)
+						payload = withMetadata(payload, "retry_attempts", strconv.Itoa(attempts))
+					}
+					attempts++
+
 					select {
-					case r.transactionsOut <- message.NewTransaction(ts.Payload, resChan):
+					case r.transactionsOut <- message.NewTransaction(payload, resChan):
 					case <-r.shutSig.CloseAtLeisureChan():
 						return
 					}
@@ -232,7 +258,7 @@ func (r *indefiniteRetry) loop() {
 			if err := ts.Ack(ctx, resOut); err != nil && ctx.Err() != nil {
 				return
 			}
-		}(tran, rChan)
+		}(tran, payload, rChan)
 	}
 }
 
@@ -269,3 +295,50 @@ func (r *indefiniteRetry) WaitForClose(timeout time.Duration) error {
 	}
 	return nil
 }
+
+// withMetadata returns a shallow copy of a batch where each message has a
+// metadata field set.
+func withMetadata(msg *message.Batch, key, value string) *message.Batch {
+	newMsg := msg.Copy()
+	_ = newMsg.Iter(func(i int, p *message.Part) error {
+		p.MetaSet(key, value)
+		return nil
+	})
+	return newMsg
+}
+
+// failedSubset returns the messages of a batch, which were tagged with a sort
+// group, that failed according to a batch error. If the error doesn't indicate
+// which messages failed, or if they can't be linked back to the batch, then
+// the whole batch is returned.
+func failedSubset(group *message.SortGroup, msg *message.Batch, err error) *message.Batch {
+	walkable, ok := err.(batch.WalkableError)
+	if !ok || walkable.IndexedErrors() == 0 || walkable.IndexedErrors() >= msg.Len() {
+		return msg
+	}
+
+	byIndex := make(map[int]*message.Part, msg.Len())
+	_ = msg.Iter(func(i int, p *message.Part) error {
+		if index := group.GetIndex(p); index >= 0 {
+			byIndex[index] = p
+		}
+		return nil
+	})
+
+	subset := message.QuickBatch(nil)
+	walkable.WalkParts(func(i int, p *message.Part, e error) bool {
+		if e == nil {
+			return true
+		}
+		if original, exists := byIndex[group.GetIndex(p)]; exists {
+			subset.Append(original)
+			return true
+		}
+		subset = msg
+		return false
+	})
+	if subset.Len() == 0 {
+		return msg
+	}
+	return subset
+}
@@ -2,11 +2,14 @@ package pure
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/benthosdev/benthos/v4/internal/batch"
 	"github.com/benthosdev/benthos/v4/internal/bundle"
 	bmock "github.com/benthosdev/benthos/v4/internal/bundle/mock"
 	"github.com/benthosdev/benthos/v4/internal/component"
@@ -307,3 +310,71 @@ func TestRetryParallel(t *testing.T) {
 	output.CloseAsync()
 	require.NoError(t, output.WaitForClose(time.Second*30))
 }
+
+func TestRetryFailedOnly(t *testing.T) {
+	ctx, done := context.WithTimeout(context.Background(), time.Second*30)
+	defer done()
+
+	conf := ooutput.NewConfig()
+	conf.Type = "retry"
+
+	childConf := ooutput.NewConfig()
+	conf.Retry.Output = &childConf
+	conf.Retry.RetryFailedOnly = true
+	conf.Retry.Backoff.InitialInterval = "10us"
+	conf.Retry.Backoff.MaxInterval = "10us"
+
+	output, err := bundle.AllOutputs.Init(conf, bmock.NewManager())
+	require.NoError(t, err)
+
+	ret, ok := output.(*indefiniteRetry)
+	require.True(t, ok)
+
+	mOut := &mock.OutputChanneled{}
+	ret.wrapped = mOut
+
+	tChan := make(chan message.Transaction)
+	resChan := make(chan error)
+	require.NoError(t, ret.Consume(tChan))
+
+	go func() {
+		select {
+		case tChan <- message.NewTransaction(message.QuickBatch([][]byte{
+			[]byte("foo"), []byte("bar"), []byte("baz"),
+		}), resChan):
+		case <-time.After(time.Second):
+			t.Error("timed out")
+		}
+	}()
+
+	var tran message.Transaction
+	select {
+	case tran = <-mOut.TChan:
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+	require.Equal(t, 3, tran.Payload.Len())
+	require.NoError(t, tran.Ack(ctx, batch.NewError(tran.Payload, errors.New("nope")).Failed(1, errors.New("bar failed"))))
+
+	select {
+	case tran = <-mOut.TChan:
+	case <-resChan:
+		t.Fatal("Received response not retry")
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+	require.Equal(t, 1, tran.Payload.Len())
+	assert.Equal(t, "bar", string(tran.Payload.Get(0).Get()))
+	assert.Equal(t, "1", tran.Payload.Get(0).MetaGet("retry_attempts"))
+	require.NoError(t, tran.Ack(ctx, nil))
+
+	select {
+	case res := <-resChan:
+		require.NoError(t, res)
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+
+	output.CloseAsync()
+	require.NoError(t, output.WaitForClose(time.Second*30))
+}
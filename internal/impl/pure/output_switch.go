@@ -2,14 +2,21 @@ package pure
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"path"
 	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/Jeffail/gabs/v2"
+	"gopkg.in/yaml.v3"
 
 	"github.com/benthosdev/benthos/v4/internal/batch"
 	"github.com/benthosdev/benthos/v4/internal/bloblang/mapping"
@@ -17,6 +24,7 @@ import (
 	"github.com/benthosdev/benthos/v4/internal/component"
 	"github.com/benthosdev/benthos/v4/internal/component/output"
 	"github.com/benthosdev/benthos/v4/internal/docs"
+	"github.com/benthosdev/benthos/v4/internal/interop"
 	"github.com/benthosdev/benthos/v4/internal/log"
 	"github.com/benthosdev/benthos/v4/internal/message"
 	ooutput "github.com/benthosdev/benthos/v4/internal/old/output"
@@ -43,7 +51,17 @@ func init() {
 		Summary: `
 The switch output type allows you to route messages to different outputs based on their contents.`,
 		Description: `
-Messages must successfully route to one or more outputs, otherwise this is considered an error and the message is reprocessed. In order to explicitly drop messages that do not match your cases add one final case with a [drop output](/docs/components/outputs/drop).`,
+Messages must successfully route to one or more outputs, otherwise this is considered an error and the message is reprocessed. In order to explicitly drop messages that do not match your cases add one final case with a [drop output](/docs/components/outputs/drop).
+
+### Weighted Cases
+
+The field ` + "`weight`" + ` of a case is the percentage of messages passing the check of the case that are routed to it, where messages that aren't selected are tested against the next case as if the check had failed. This allows a portion of traffic to be split off to a case, such as a canary of a new output.
+
+The weights of the cases can be viewed and adjusted at runtime with the endpoint ` + "`/switch/{id}/weights`" + ` of the [HTTP server](/docs/components/http/about), where the ID is the label of the output or, when it has no label, its path within the config (e.g. ` + "`output`" + `). A GET request returns the current weights as a JSON object of the form ` + "`" + `{"weights":[5,100]}` + "`" + `, and a POST request with an object of the same form replaces them. Weights adjusted with the endpoint are not persisted and revert to the config values when the output is restarted.
+
+### Reloading Cases
+
+The cases themselves can be viewed and replaced at runtime with the endpoint ` + "`/switch/{id}/cases`" + `. A GET request returns the current cases as a YAML array of the same form as the field ` + "`cases`" + `, and a POST request with a YAML array of cases replaces all of them, allowing cases to be added, removed or reconfigured without restarting the output. The outputs of the new cases are created before any messages are routed to them, and the outputs of the replaced cases are closed once all messages that were routed to them are acknowledged. As with weights, reloaded cases are not persisted and revert to the config when the output is restarted.`,
 		Config: docs.FieldComponent().WithChildren(
 			docs.FieldBool(
 				"retry_until_success", `
@@ -60,44 +78,7 @@ This field determines whether an error should be reported if no condition is met
 If set to true, an error is propagated back to the input level. The default
 behavior is false, which will drop the message.`,
 			).Advanced().HasDefault(false),
-			docs.FieldObject(
-				"cases",
-				"A list of switch cases, outlining outputs that can be routed to.",
-				[]interface{}{
-					map[string]interface{}{
-						"check": `this.urls.contains("http://benthos.dev")`,
-						"output": map[string]interface{}{
-							"cache": map[string]interface{}{
-								"target": "foo",
-								"key":    "${!json(\"id\")}",
-							},
-						},
-						"continue": true,
-					},
-					map[string]interface{}{
-						"output": map[string]interface{}{
-							"s3": map[string]interface{}{
-								"bucket": "bar",
-								"path":   "${!json(\"id\")}",
-							},
-						},
-					},
-				},
-			).Array().WithChildren(
-				docs.FieldBloblang(
-					"check",
-					"A [Bloblang query](/docs/guides/bloblang/about/) that should return a boolean value indicating whether a message should be routed to the case output. If left empty the case always passes.",
-					`this.type == "foo"`,
-					`this.contents.urls.contains("https://benthos.dev/")`,
-				).HasDefault(""),
-				docs.FieldOutput(
-					"output", "An [output](/docs/components/outputs/about/) for messages that pass the check to be routed to.",
-				).HasDefault(map[string]interface{}{}),
-				docs.FieldBool(
-					"continue",
-					"Indicates whether, if this case passes for a message, the next case should also be tested.",
-				).HasDefault(false).Advanced(),
-			).HasDefault([]interface{}{}),
+			switchCasesFieldSpec(),
 		).LinterFunc(func(ctx docs.LintContext, line, col int, value interface{}) []docs.Lint {
 			if _, ok := value.(map[string]interface{}); !ok {
 				return nil
@@ -152,6 +133,25 @@ output:
             - bloblang: |
                 root = this
                 root.type = this.type | "unknown"
+`,
+			},
+			{
+				Title: "Canary Output",
+				Summary: `
+The ` + "`weight`" + ` field allows a percentage of messages to be routed to a case. In the following example 5% of messages are sent to a new version of a service whilst the remainder are sent to the current version. The weights can then be adjusted at runtime as confidence in the new version grows.`,
+				Config: `
+output:
+  label: ingest
+  switch:
+    cases:
+      - weight: 5
+        output:
+          http_client:
+            url: http://ingest-canary:4195/post
+
+      - output:
+          http_client:
+            url: http://ingest:4195/post
 `,
 			},
 			{
@@ -185,69 +185,342 @@ output:
 	}
 }
 
-type switchOutput struct {
-	logger log.Modular
+func switchCasesFieldSpec() docs.FieldSpec {
+	return docs.FieldObject(
+		"cases",
+		"A list of switch cases, outlining outputs that can be routed to.",
+		[]interface{}{
+			map[string]interface{}{
+				"check": `this.urls.contains("http://benthos.dev")`,
+				"output": map[string]interface{}{
+					"cache": map[string]interface{}{
+						"target": "foo",
+						"key":    "${!json(\"id\")}",
+					},
+				},
+				"continue": true,
+			},
+			map[string]interface{}{
+				"output": map[string]interface{}{
+					"s3": map[string]interface{}{
+						"bucket": "bar",
+						"path":   "${!json(\"id\")}",
+					},
+				},
+			},
+		},
+	).Array().WithChildren(
+		docs.FieldBloblang(
+			"check",
+			"A [Bloblang query](/docs/guides/bloblang/about/) that should return a boolean value indicating whether a message should be routed to the case output. If left empty the case always passes.",
+			`this.type == "foo"`,
+			`this.contents.urls.contains("https://benthos.dev/")`,
+		).HasDefault(""),
+		docs.FieldOutput(
+			"output", "An [output](/docs/components/outputs/about/) for messages that pass the check to be routed to.",
+		).HasDefault(map[string]interface{}{}),
+		docs.FieldFloat(
+			"weight",
+			"The percentage of messages that pass the check which are routed to the case output, from `0` to `100`. Messages that aren't selected are tested against the next case.",
+			5,
+		).HasDefault(100).Advanced().AtVersion("4.1.0"),
+		docs.FieldBool(
+			"continue",
+			"Indicates whether, if this case passes for a message, the next case should also be tested.",
+		).HasDefault(false).Advanced(),
+	).HasDefault([]interface{}{})
+}
 
-	transactions <-chan message.Transaction
+//------------------------------------------------------------------------------
 
-	strictMode    bool
+// switchCases are the cases of a switch output, which are replaced as a whole
+// when the cases are reloaded at runtime.
+type switchCases struct {
+	confs         []ooutput.SwitchConfigCase
 	outputTSChans []chan message.Transaction
 	outputs       []output.Streamed
 	checks        []*mapping.Executor
 	continues     []bool
-	fallthroughs  []bool
+	weights       []float64
 
-	shutSig *shutdown.Signaller
+	// Tracks messages dispatched to the outputs that are yet to be
+	// acknowledged.
+	pending sync.WaitGroup
 }
 
-func newSwitchOutput(conf ooutput.SwitchConfig, mgr bundle.NewManagement) (output.Streamed, error) {
-	o := &switchOutput{
-		logger:       mgr.Logger(),
-		transactions: nil,
-		strictMode:   conf.StrictMode,
-		shutSig:      shutdown.NewSignaller(),
-	}
-
-	lCases := len(conf.Cases)
+func newSwitchCases(confs []ooutput.SwitchConfigCase, retryUntilSuccess bool, mgr bundle.NewManagement) (*switchCases, error) {
+	lCases := len(confs)
 	if lCases < 2 {
 		return nil, ErrSwitchNoOutputs
 	}
-	if lCases > 0 {
-		o.outputs = make([]output.Streamed, lCases)
-		o.checks = make([]*mapping.Executor, lCases)
-		o.continues = make([]bool, lCases)
-		o.fallthroughs = make([]bool, lCases)
+
+	c := &switchCases{
+		confs:     confs,
+		outputs:   make([]output.Streamed, 0, lCases),
+		checks:    make([]*mapping.Executor, lCases),
+		continues: make([]bool, lCases),
+		weights:   make([]float64, lCases),
 	}
 
 	var err error
-	for i, cConf := range conf.Cases {
+	for i, cConf := range confs {
+		if len(cConf.Check) > 0 {
+			if c.checks[i], err = mgr.BloblEnvironment().NewMapping(cConf.Check); err != nil {
+				c.abandon()
+				return nil, fmt.Errorf("failed to parse case '%v' check mapping: %v", i, err)
+			}
+		}
+		if cConf.Weight < 0 || cConf.Weight > 100 {
+			c.abandon()
+			return nil, fmt.Errorf("case '%v' weight must be between 0 and 100, got %v", i, cConf.Weight)
+		}
+		c.weights[i] = cConf.Weight
+		c.continues[i] = cConf.Continue
+
+		var out output.Streamed
 		oMgr := mgr.IntoPath("switch", strconv.Itoa(i), "output").(bundle.NewManagement)
-		if o.outputs[i], err = oMgr.NewOutput(cConf.Output); err != nil {
+		if out, err = oMgr.NewOutput(cConf.Output); err != nil {
+			c.abandon()
 			return nil, err
 		}
-		if conf.RetryUntilSuccess {
-			if o.outputs[i], err = RetryOutputIndefinitely(oMgr, o.outputs[i]); err != nil {
+		if retryUntilSuccess {
+			if out, err = RetryOutputIndefinitely(oMgr, out); err != nil {
+				c.abandon()
 				return nil, fmt.Errorf("failed to create case '%v' output type '%v': %v", i, cConf.Output.Type, err)
 			}
 		}
-		if len(cConf.Check) > 0 {
-			if o.checks[i], err = mgr.BloblEnvironment().NewMapping(cConf.Check); err != nil {
-				return nil, fmt.Errorf("failed to parse case '%v' check mapping: %v", i, err)
-			}
-		}
-		o.continues[i] = cConf.Continue
+		c.outputs = append(c.outputs, out)
 	}
 
-	o.outputTSChans = make([]chan message.Transaction, len(o.outputs))
-	for i := range o.outputTSChans {
-		o.outputTSChans[i] = make(chan message.Transaction)
-		if err := o.outputs[i].Consume(o.outputTSChans[i]); err != nil {
+	c.outputTSChans = make([]chan message.Transaction, len(c.outputs))
+	for i := range c.outputTSChans {
+		c.outputTSChans[i] = make(chan message.Transaction)
+		if err := c.outputs[i].Consume(c.outputTSChans[i]); err != nil {
+			c.abandon()
 			return nil, err
 		}
 	}
+	return c, nil
+}
+
+// abandon triggers the shut down of the outputs of cases that failed to be
+// created, which may not have consumed any messages yet and therefore aren't
+// waited on.
+func (c *switchCases) abandon() {
+	for _, output := range c.outputs {
+		output.CloseAsync()
+	}
+}
+
+// close shuts down the outputs of the cases, which must no longer be
+// dispatched to.
+func (c *switchCases) close() {
+	for _, tChan := range c.outputTSChans {
+		close(tChan)
+	}
+	for _, output := range c.outputs {
+		output.CloseAsync()
+	}
+	for _, output := range c.outputs {
+		_ = output.WaitForClose(shutdown.MaximumShutdownWait())
+	}
+}
+
+// retire closes the outputs of cases that have been replaced once all
+// messages dispatched to them are acknowledged, or once the switch output is
+// shutting down.
+func (c *switchCases) retire(shutSig *shutdown.Signaller) {
+	ackedChan := make(chan struct{})
+	go func() {
+		c.pending.Wait()
+		close(ackedChan)
+	}()
+	select {
+	case <-ackedChan:
+	case <-shutSig.CloseAtLeisureChan():
+	}
+	c.close()
+}
+
+//------------------------------------------------------------------------------
+
+type switchOutput struct {
+	logger log.Modular
+	mgr    bundle.NewManagement
+
+	transactions <-chan message.Transaction
+
+	retryUntilSuccess bool
+	strictMode        bool
+
+	casesMut    sync.RWMutex
+	cases       *switchCases
+	casesClosed bool
+	retiring    sync.WaitGroup
+
+	weightsMut sync.RWMutex
+	rand       *rand.Rand
+
+	shutSig *shutdown.Signaller
+}
+
+func newSwitchOutput(conf ooutput.SwitchConfig, mgr bundle.NewManagement) (output.Streamed, error) {
+	o := &switchOutput{
+		logger:            mgr.Logger(),
+		mgr:               mgr,
+		transactions:      nil,
+		retryUntilSuccess: conf.RetryUntilSuccess,
+		strictMode:        conf.StrictMode,
+		rand:              rand.New(rand.NewSource(time.Now().UnixNano())),
+		shutSig:           shutdown.NewSignaller(),
+	}
+
+	var err error
+	if o.cases, err = newSwitchCases(conf.Cases, conf.RetryUntilSuccess, mgr); err != nil {
+		return nil, err
+	}
+
+	if id := switchEndpointID(mgr); id != "" {
+		mgr.RegisterEndpoint(
+			path.Join("/switch", id, "weights"),
+			"Get or set the weights of the cases of a switch output as a JSON object of the form `{\"weights\":[5,100]}`.",
+			o.HandleWeights,
+		)
+		mgr.RegisterEndpoint(
+			path.Join("/switch", id, "cases"),
+			"Get or replace the cases of a switch output as a YAML array of case configs.",
+			o.HandleCases,
+		)
+	}
 	return o, nil
 }
 
+// Switch endpoints are identified by the label of the output when it has one,
+// otherwise by its path within the config.
+func switchEndpointID(mgr interop.Manager) string {
+	if label := mgr.Label(); label != "" {
+		return label
+	}
+	return strings.Join(mgr.Path(), ".")
+}
+
+type switchWeights struct {
+	Weights []float64 `json:"weights"`
+}
+
+// HandleWeights is an HTTP handler that writes the current weights of the
+// cases as a JSON object, or replaces them with the weights of a JSON object
+// in the body of a POST request.
+func (o *switchOutput) HandleWeights(rw http.ResponseWriter, r *http.Request) {
+	o.casesMut.RLock()
+	defer o.casesMut.RUnlock()
+
+	switch r.Method {
+	case "GET":
+	case "POST", "PUT":
+		var req switchWeights
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(rw, fmt.Sprintf("Failed to parse weights: %v", err), http.StatusBadRequest)
+			return
+		}
+		if len(req.Weights) != len(o.cases.outputs) {
+			http.Error(rw, fmt.Sprintf("Expected %v weights, got %v", len(o.cases.outputs), len(req.Weights)), http.StatusBadRequest)
+			return
+		}
+		for i, w := range req.Weights {
+			if w < 0 || w > 100 {
+				http.Error(rw, fmt.Sprintf("Case %v weight must be between 0 and 100, got %v", i, w), http.StatusBadRequest)
+				return
+			}
+		}
+		o.weightsMut.Lock()
+		o.cases.weights = req.Weights
+		o.weightsMut.Unlock()
+		o.logger.Infof("Switch case weights updated to: %v", req.Weights)
+	default:
+		http.Error(rw, "Method not supported", http.StatusMethodNotAllowed)
+		return
+	}
+
+	o.weightsMut.RLock()
+	res := switchWeights{Weights: o.cases.weights}
+	o.weightsMut.RUnlock()
+
+	rw.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(rw).Encode(res)
+}
+
+// HandleCases is an HTTP handler that writes the configs of the current cases
+// as a YAML array, or replaces the cases with those of a YAML array in the body
+// of a POST request. Replaced case outputs are closed once all messages that
+// were dispatched to them are acknowledged.
+func (o *switchOutput) HandleCases(rw http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "GET":
+	case "POST", "PUT":
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(rw, fmt.Sprintf("Failed to read cases: %v", err), http.StatusBadRequest)
+			return
+		}
+		var confs []ooutput.SwitchConfigCase
+		if err := yaml.Unmarshal(body, &confs); err != nil {
+			http.Error(rw, fmt.Sprintf("Failed to parse cases: %v", err), http.StatusBadRequest)
+			return
+		}
+		newCases, err := newSwitchCases(confs, o.retryUntilSuccess, o.mgr)
+		if err != nil {
+			http.Error(rw, fmt.Sprintf("Failed to create cases: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		o.casesMut.Lock()
+		if o.casesClosed {
+			o.casesMut.Unlock()
+			go newCases.close()
+			http.Error(rw, "Switch output is shutting down", http.StatusServiceUnavailable)
+			return
+		}
+		prevCases := o.cases
+		o.cases = newCases
+		o.retiring.Add(1)
+		o.casesMut.Unlock()
+
+		go func() {
+			defer o.retiring.Done()
+			prevCases.retire(o.shutSig)
+		}()
+		o.logger.Infof("Switch cases replaced with %v new cases", len(confs))
+	default:
+		http.Error(rw, "Method not supported", http.StatusMethodNotAllowed)
+		return
+	}
+
+	o.casesMut.RLock()
+	confs := o.cases.confs
+	o.casesMut.RUnlock()
+
+	var node yaml.Node
+	if err := node.Encode(confs); err != nil {
+		http.Error(rw, fmt.Sprintf("Failed to encode cases: %v", err), http.StatusInternalServerError)
+		return
+	}
+	sanitConf := docs.NewSanitiseConfig()
+	sanitConf.RemoveTypeField = true
+	if err := switchCasesFieldSpec().SanitiseYAML(&node, sanitConf); err != nil {
+		http.Error(rw, fmt.Sprintf("Failed to encode cases: %v", err), http.StatusInternalServerError)
+		return
+	}
+	resBytes, err := yaml.Marshal(&node)
+	if err != nil {
+		http.Error(rw, fmt.Sprintf("Failed to encode cases: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/yaml")
+	_, _ = rw.Write(resBytes)
+}
+
 func (o *switchOutput) Consume(transactions <-chan message.Transaction) error {
 	if o.transactions != nil {
 		return component.ErrAlreadyStarted
@@ -259,7 +532,10 @@ func (o *switchOutput) Consume(transactions <-chan message.Transaction) error {
 }
 
 func (o *switchOutput) Connected() bool {
-	for _, out := range o.outputs {
+	o.casesMut.RLock()
+	defer o.casesMut.RUnlock()
+
+	for _, out := range o.cases.outputs {
 		if !out.Connected() {
 			return false
 		}
@@ -268,6 +544,7 @@ func (o *switchOutput) Connected() bool {
 }
 
 func (o *switchOutput) dispatchToTargets(
+	cases *switchCases,
 	group *message.SortGroup,
 	sourceMessage *message.Batch,
 	outputTargets [][]*message.Part,
@@ -337,7 +614,7 @@ func (o *switchOutput) dispatchToTargets(
 		msgCopy.SetAll(parts)
 
 		select {
-		case o.outputTSChans[i] <- message.NewTransactionFunc(msgCopy, func(ctx context.Context, err error) error {
+		case cases.outputTSChans[i] <- message.NewTransactionFunc(msgCopy, func(ctx context.Context, err error) error {
 			if err != nil {
 				if bErr, ok := err.(*batch.Error); ok {
 					bErr.WalkParts(func(i int, p *message.Part, e error) bool {
@@ -381,15 +658,14 @@ func (o *switchOutput) loop() {
 				break ackWaitLoop
 			}
 		}
-		for _, tChan := range o.outputTSChans {
-			close(tChan)
-		}
-		for _, output := range o.outputs {
-			output.CloseAsync()
-		}
-		for _, output := range o.outputs {
-			_ = output.WaitForClose(shutdown.MaximumShutdownWait())
-		}
+
+		o.casesMut.Lock()
+		o.casesClosed = true
+		cases := o.cases
+		o.casesMut.Unlock()
+
+		cases.close()
+		o.retiring.Wait()
 		o.shutSig.ShutdownComplete()
 	}()
 
@@ -409,12 +685,21 @@ func (o *switchOutput) loop() {
 			return
 		}
 
+		// The cases are locked until the message has been dispatched so that
+		// they aren't closed whilst in use when they're replaced.
+		o.casesMut.RLock()
+		cases := o.cases
+
 		group, trackedMsg := message.NewSortGroup(ts.Payload)
 
-		outputTargets := make([][]*message.Part, len(o.checks))
+		o.weightsMut.RLock()
+		weights := cases.weights
+		o.weightsMut.RUnlock()
+
+		outputTargets := make([][]*message.Part, len(cases.checks))
 		if checksErr := trackedMsg.Iter(func(i int, p *message.Part) error {
 			routedAtLeastOnce := false
-			for j, exe := range o.checks {
+			for j, exe := range cases.checks {
 				test := true
 				if exe != nil {
 					var err error
@@ -423,10 +708,13 @@ func (o *switchOutput) loop() {
 						o.logger.Errorf("Failed to test case %v: %v\n", j, err)
 					}
 				}
+				if test && weights[j] < 100 {
+					test = o.rand.Float64()*100 < weights[j]
+				}
 				if test {
 					routedAtLeastOnce = true
 					outputTargets[j] = append(outputTargets[j], p.Copy())
-					if !o.continues[j] {
+					if !cases.continues[j] {
 						return nil
 					}
 				}
@@ -436,6 +724,7 @@ func (o *switchOutput) loop() {
 			}
 			return nil
 		}); checksErr != nil {
+			o.casesMut.RUnlock()
 			if err := ts.Ack(shutCtx, checksErr); err != nil && shutCtx.Err() != nil {
 				return
 			}
@@ -443,8 +732,10 @@ func (o *switchOutput) loop() {
 		}
 
 		_ = atomic.AddInt64(&ackPending, 1)
-		o.dispatchToTargets(group, trackedMsg, outputTargets, func(ctx context.Context, err error) error {
+		cases.pending.Add(1)
+		o.dispatchToTargets(cases, group, trackedMsg, outputTargets, func(ctx context.Context, err error) error {
 			ackErr := ts.Ack(ctx, err)
+			cases.pending.Done()
 			_ = atomic.AddInt64(&ackPending, -1)
 			select {
 			case ackInterruptChan <- struct{}{}:
@@ -452,6 +743,7 @@ func (o *switchOutput) loop() {
 			}
 			return ackErr
 		})
+		o.casesMut.RUnlock()
 	}
 }
 
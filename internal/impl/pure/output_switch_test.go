@@ -5,6 +5,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -34,10 +37,10 @@ func newSwitch(t *testing.T, conf ooutput.Config, mockOutputs []*mock.OutputChan
 	require.True(t, ok)
 
 	for i := 0; i < len(mockOutputs); i++ {
-		close(rType.outputTSChans[i])
-		rType.outputs[i] = mockOutputs[i]
-		rType.outputTSChans[i] = make(chan message.Transaction)
-		_ = mockOutputs[i].Consume(rType.outputTSChans[i])
+		close(rType.cases.outputTSChans[i])
+		rType.cases.outputs[i] = mockOutputs[i]
+		rType.cases.outputTSChans[i] = make(chan message.Transaction)
+		_ = mockOutputs[i].Consume(rType.cases.outputTSChans[i])
 	}
 	return rType
 }
@@ -167,14 +170,16 @@ func TestSwitchBatchNoRetries(t *testing.T) {
 	okOut.Type = "drop"
 	conf.Switch.Cases = append(conf.Switch.Cases, ooutput.SwitchConfigCase{
 		Check:  `root = this.id % 2 == 0`,
+		Weight: 100,
 		Output: okOut,
 	})
 
 	errOut := ooutput.NewConfig()
 	errOut.Type = "reject"
-	errOut.Reject = "meow"
+	errOut.Reject.Message = "meow"
 	conf.Switch.Cases = append(conf.Switch.Cases, ooutput.SwitchConfigCase{
 		Check:  `root = true`,
+		Weight: 100,
 		Output: errOut,
 	})
 
@@ -1035,3 +1040,273 @@ bpLoop:
 	close(doneChan)
 	wg.Wait()
 }
+
+func TestSwitchWeights(t *testing.T) {
+	ctx, done := context.WithTimeout(context.Background(), time.Second*30)
+	defer done()
+
+	mockOutputs := []*mock.OutputChanneled{{}, {}}
+
+	conf := ooutput.NewConfig()
+	for i := 0; i < len(mockOutputs); i++ {
+		conf.Switch.Cases = append(conf.Switch.Cases, ooutput.NewSwitchConfigCase())
+	}
+	conf.Switch.Cases[0].Weight = 0
+
+	s := newSwitch(t, conf, mockOutputs)
+
+	readChan := make(chan message.Transaction)
+	resChan := make(chan error, 1)
+	require.NoError(t, s.Consume(readChan))
+
+	sendAndExpect := func(index int) {
+		t.Helper()
+		select {
+		case readChan <- message.NewTransaction(message.QuickBatch([][]byte{[]byte("hello world")}), resChan):
+		case <-time.After(time.Second):
+			t.Fatal("Timed out waiting for broker send")
+		}
+		select {
+		case ts := <-mockOutputs[index].TChan:
+			require.NoError(t, ts.Ack(ctx, nil))
+		case ts := <-mockOutputs[1-index].TChan:
+			t.Fatalf("Message routed to wrong output: %s", ts.Payload.Get(0).Get())
+		case <-time.After(time.Second):
+			t.Fatal("Timed out waiting for broker propagate")
+		}
+		select {
+		case res := <-resChan:
+			require.NoError(t, res)
+		case <-time.After(time.Second):
+			t.Fatal("Timed out responding to broker")
+		}
+	}
+
+	for i := 0; i < 10; i++ {
+		sendAndExpect(1)
+	}
+
+	req := httptest.NewRequest("POST", "/switch/foo/weights", strings.NewReader(`{"weights":[100,100]}`))
+	res := httptest.NewRecorder()
+	s.HandleWeights(res, req)
+	require.Equal(t, http.StatusOK, res.Code, res.Body.String())
+	assert.JSONEq(t, `{"weights":[100,100]}`, res.Body.String())
+
+	for i := 0; i < 10; i++ {
+		sendAndExpect(0)
+	}
+
+	s.CloseAsync()
+	require.NoError(t, s.WaitForClose(time.Second*5))
+}
+
+func TestSwitchWeightsEndpoint(t *testing.T) {
+	handlers := map[string]http.HandlerFunc{}
+	mgr := bmock.NewManager()
+	mgr.OnRegisterEndpoint = func(path string, h http.HandlerFunc) {
+		handlers[path] = h
+	}
+
+	conf := ooutput.NewConfig()
+	conf.Switch.Cases = append(conf.Switch.Cases, ooutput.NewSwitchConfigCase(), ooutput.NewSwitchConfigCase())
+	conf.Switch.Cases[0].Weight = 5
+	conf.Switch.Cases[0].Output.Type = "drop"
+	conf.Switch.Cases[1].Output.Type = "drop"
+
+	s, err := newSwitchOutput(conf.Switch, labelledManager{Manager: mgr, label: "foo"})
+	require.NoError(t, err)
+
+	readChan := make(chan message.Transaction)
+	require.NoError(t, s.Consume(readChan))
+	t.Cleanup(func() {
+		close(readChan)
+		s.CloseAsync()
+		assert.NoError(t, s.WaitForClose(time.Second*5))
+	})
+
+	h, exists := handlers["/switch/foo/weights"]
+	require.True(t, exists, "%v", handlers)
+
+	for _, test := range []struct {
+		method string
+		body   string
+		code   int
+		result string
+	}{
+		{method: "GET", code: http.StatusOK, result: `{"weights":[5,100]}`},
+		{method: "POST", body: `{"weights":[10]}`, code: http.StatusBadRequest},
+		{method: "POST", body: `{"weights":[10,101]}`, code: http.StatusBadRequest},
+		{method: "POST", body: `nope`, code: http.StatusBadRequest},
+		{method: "DELETE", code: http.StatusMethodNotAllowed},
+		{method: "POST", body: `{"weights":[25,100]}`, code: http.StatusOK, result: `{"weights":[25,100]}`},
+		{method: "GET", code: http.StatusOK, result: `{"weights":[25,100]}`},
+	} {
+		req := httptest.NewRequest(test.method, "/switch/foo/weights", strings.NewReader(test.body))
+		res := httptest.NewRecorder()
+		h.ServeHTTP(res, req)
+		require.Equal(t, test.code, res.Code, res.Body.String())
+		if test.result != "" {
+			assert.JSONEq(t, test.result, res.Body.String())
+		}
+	}
+}
+
+func TestSwitchCasesEndpoint(t *testing.T) {
+	ctx, done := context.WithTimeout(context.Background(), time.Second*30)
+	defer done()
+
+	handlers := map[string]http.HandlerFunc{}
+	mgr := bmock.NewManager()
+	mgr.OnRegisterEndpoint = func(path string, h http.HandlerFunc) {
+		handlers[path] = h
+	}
+
+	routedChan := make(chan string)
+	for _, name := range []string{"a", "b"} {
+		name := name
+		mgr.Outputs[name] = func(ctx context.Context, ts message.Transaction) error {
+			select {
+			case routedChan <- name:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			return ts.Ack(ctx, nil)
+		}
+	}
+
+	conf := ooutput.NewConfig()
+	conf.Switch.Cases = append(conf.Switch.Cases, ooutput.NewSwitchConfigCase(), ooutput.NewSwitchConfigCase())
+	conf.Switch.Cases[0].Output.Type = "resource"
+	conf.Switch.Cases[0].Output.Resource = "a"
+	conf.Switch.Cases[1].Output.Type = "resource"
+	conf.Switch.Cases[1].Output.Resource = "b"
+
+	s, err := newSwitchOutput(conf.Switch, labelledManager{Manager: mgr, label: "foo"})
+	require.NoError(t, err)
+
+	readChan := make(chan message.Transaction)
+	resChan := make(chan error, 1)
+	require.NoError(t, s.Consume(readChan))
+
+	sendAndExpect := func(name string) {
+		t.Helper()
+		select {
+		case readChan <- message.NewTransaction(message.QuickBatch([][]byte{[]byte("hello world")}), resChan):
+		case <-ctx.Done():
+			t.Fatal("Timed out waiting for broker send")
+		}
+		select {
+		case routed := <-routedChan:
+			assert.Equal(t, name, routed)
+		case <-ctx.Done():
+			t.Fatal("Timed out waiting for broker propagate")
+		}
+		select {
+		case res := <-resChan:
+			require.NoError(t, res)
+		case <-ctx.Done():
+			t.Fatal("Timed out responding to broker")
+		}
+	}
+
+	h, exists := handlers["/switch/foo/cases"]
+	require.True(t, exists, "%v", handlers)
+
+	doRequest := func(method, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(method, "/switch/foo/cases", strings.NewReader(body))
+		res := httptest.NewRecorder()
+		h.ServeHTTP(res, req)
+		return res
+	}
+
+	sendAndExpect("a")
+
+	res := doRequest("GET", "")
+	require.Equal(t, http.StatusOK, res.Code, res.Body.String())
+	assert.Equal(t, `- check: ""
+  output:
+    resource: a
+  weight: 100
+  continue: false
+- check: ""
+  output:
+    resource: b
+  weight: 100
+  continue: false
+`, res.Body.String())
+
+	for _, test := range []struct {
+		method string
+		body   string
+		code   int
+	}{
+		{method: "POST", body: `nope`, code: http.StatusBadRequest},
+		{method: "POST", body: `[{"output":{"resource":"b"}}]`, code: http.StatusBadRequest},
+		{method: "POST", body: `[{"weight":150,"output":{"resource":"b"}},{"output":{"resource":"a"}}]`, code: http.StatusBadRequest},
+		{method: "DELETE", code: http.StatusMethodNotAllowed},
+	} {
+		res := doRequest(test.method, test.body)
+		require.Equal(t, test.code, res.Code, res.Body.String())
+	}
+	sendAndExpect("a")
+
+	res = doRequest("POST", `
+- check: this.type == "foo"
+  output:
+    resource: a
+- weight: 50
+  output:
+    resource: b
+- output:
+    resource: b
+`)
+	require.Equal(t, http.StatusOK, res.Code, res.Body.String())
+	assert.Equal(t, `- check: this.type == "foo"
+  output:
+    resource: a
+  weight: 100
+  continue: false
+- check: ""
+  output:
+    resource: b
+  weight: 50
+  continue: false
+- check: ""
+  output:
+    resource: b
+  weight: 100
+  continue: false
+`, res.Body.String())
+
+	for i := 0; i < 10; i++ {
+		sendAndExpect("b")
+	}
+
+	// The weights endpoint reflects the new cases.
+	wReq := httptest.NewRequest("GET", "/switch/foo/weights", nil)
+	wRes := httptest.NewRecorder()
+	handlers["/switch/foo/weights"].ServeHTTP(wRes, wReq)
+	require.Equal(t, http.StatusOK, wRes.Code, wRes.Body.String())
+	assert.JSONEq(t, `{"weights":[100,50,100]}`, wRes.Body.String())
+
+	s.CloseAsync()
+	require.NoError(t, s.WaitForClose(time.Second*5))
+}
+
+type labelledManager struct {
+	*bmock.Manager
+	label string
+}
+
+func (l labelledManager) Label() string { return l.label }
+
+func TestSwitchBadWeight(t *testing.T) {
+	conf := ooutput.NewConfig()
+	conf.Switch.Cases = append(conf.Switch.Cases, ooutput.NewSwitchConfigCase())
+	conf.Switch.Cases[0].Weight = 150
+	conf.Switch.Cases[0].Output.Type = "drop"
+
+	_, err := newSwitchOutput(conf.Switch, bmock.NewManager())
+	require.Error(t, err)
+}
@@ -0,0 +1,358 @@
+package pure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const (
+	guardActionReject     = "reject"
+	guardActionTruncate   = "truncate"
+	guardActionQuarantine = "quarantine"
+
+	guardViolationSize            = "size"
+	guardViolationDepth           = "depth"
+	guardViolationFields          = "fields"
+	guardViolationDisallowedField = "disallowed_field"
+)
+
+func guardProcConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		Beta().
+		Categories("Utility").
+		Version("4.1.0").
+		Summary("Enforces limits on the size and structure of messages in order to protect downstream systems from abusive payloads, with a configurable action for messages that violate them.").
+		Description(`
+Each limit is disabled when set to zero. The `+"`max_size`"+` limit applies to the raw contents of all messages, whereas the remaining limits only apply to messages that can be parsed as structured data (JSON), and messages that can't be parsed are not checked against them:
+
+- `+"`max_depth`"+`: The maximum nesting depth of objects and arrays, where a document that is a flat object has a depth of one.
+- `+"`max_fields`"+`: The maximum number of fields of all objects within the document.
+- `+"`disallowed_fields`"+`: Regular expressions that are matched against the path of each field of the document, where path segments are separated by a dot and array elements are referenced by their index (e.g. `+"`users.0.password`"+`).
+
+## Actions
+
+The field `+"`action`"+` determines what happens to messages that violate one or more limits:
+
+- `+"`reject`"+`: The message is left as it was and is flagged as having failed, allowing you to handle it with [standard error handling patterns](/docs/configuration/error_handling).
+- `+"`truncate`"+`: The message is modified in order to satisfy the limits. Disallowed fields are deleted, objects and arrays nested beyond the maximum depth are deleted, and fields beyond the maximum field count are deleted in the order of their paths. If the message still exceeds the maximum size its raw contents are cut to the maximum size, which may result in a document that can no longer be parsed.
+- `+"`quarantine`"+`: The message is written to the `+"`quarantine`"+` output and removed from the pipeline. If the write fails the message is left in the pipeline and flagged as having failed.
+
+Messages that violate limits have the metadata field `+"`guard_violations`"+` set to a comma separated list of the types of violation (`+"`size`, `depth`, `fields` and `disallowed_field`"+`).
+
+## Metrics
+
+The counter `+"`guard_violations`"+` is incremented for each violation with the label `+"`violation`"+` set to its type.`).
+		Field(service.NewIntField("max_size").
+			Description("The maximum size of the raw contents of a message in bytes.").
+			Default(0)).
+		Field(service.NewIntField("max_depth").
+			Description("The maximum nesting depth of structured messages.").
+			Default(0)).
+		Field(service.NewIntField("max_fields").
+			Description("The maximum number of fields of structured messages.").
+			Default(0)).
+		Field(service.NewStringListField("disallowed_fields").
+			Description("A list of regular expressions that must not match the path of any field of structured messages.").
+			Example([]string{`(^|\.)password$`, `^internal\.`}).
+			Default([]string{})).
+		Field(service.NewStringEnumField("action", guardActionReject, guardActionTruncate, guardActionQuarantine).
+			Description("What to do with messages that violate a limit.").
+			Default(guardActionReject)).
+		Field(service.NewOutputField("quarantine").
+			Description("An output that messages are written to when the `action` is `quarantine`.").
+			Optional()).
+		Example("Quarantining Abusive Payloads", `
+Messages received over HTTP that are larger than a megabyte, deeper than ten levels or contain credentials are written to a quarantine file rather than being sent on to a downstream service.`, `
+input:
+  http_server:
+    path: /post
+
+pipeline:
+  processors:
+    - guard:
+        max_size: 1048576
+        max_depth: 10
+        disallowed_fields: [ '(^|\.)(password|secret)$' ]
+        action: quarantine
+        quarantine:
+          file:
+            path: ./quarantine.jsonl
+            codec: lines
+
+output:
+  http_client:
+    url: http://localhost:4196/post
+`)
+}
+
+func init() {
+	err := service.RegisterProcessor(
+		"guard", guardProcConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.Processor, error) {
+			return newGuardProcFromConfig(conf, mgr)
+		})
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type guardProc struct {
+	maxSize          int
+	maxDepth         int
+	maxFields        int
+	disallowedFields []*regexp.Regexp
+
+	action     string
+	quarantine *service.OwnedOutput
+
+	mViolations *service.MetricCounter
+	log         *service.Logger
+}
+
+func newGuardProcFromConfig(conf *service.ParsedConfig, mgr *service.Resources) (*guardProc, error) {
+	g := &guardProc{
+		mViolations: mgr.Metrics().NewCounter("guard_violations", "violation"),
+		log:         mgr.Logger(),
+	}
+
+	var err error
+	if g.maxSize, err = conf.FieldInt("max_size"); err != nil {
+		return nil, err
+	}
+	if g.maxDepth, err = conf.FieldInt("max_depth"); err != nil {
+		return nil, err
+	}
+	if g.maxFields, err = conf.FieldInt("max_fields"); err != nil {
+		return nil, err
+	}
+
+	patterns, err := conf.FieldStringList("disallowed_fields")
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse disallowed field pattern '%v': %w", p, err)
+		}
+		g.disallowedFields = append(g.disallowedFields, re)
+	}
+
+	if g.action, err = conf.FieldString("action"); err != nil {
+		return nil, err
+	}
+	if conf.Contains("quarantine") {
+		if g.quarantine, err = conf.FieldOutput("quarantine"); err != nil {
+			return nil, err
+		}
+	}
+	if g.action == guardActionQuarantine && g.quarantine == nil {
+		return nil, errors.New("a quarantine output must be specified when the action is quarantine")
+	}
+	return g, nil
+}
+
+func (g *guardProc) checksStructure() bool {
+	return g.maxDepth > 0 || g.maxFields > 0 || len(g.disallowedFields) > 0
+}
+
+func (g *guardProc) isDisallowed(path string) bool {
+	for _, re := range g.disallowedFields {
+		if re.MatchString(path) {
+			return true
+		}
+	}
+	return false
+}
+
+func guardFieldPath(parent, key string) string {
+	if parent == "" {
+		return key
+	}
+	return parent + "." + key
+}
+
+func guardSortedKeys(obj map[string]interface{}) []string {
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// guardWalker traverses a structured document in order to measure it against
+// the limits of a guard, deleting the parts of the document that violate them
+// when truncating.
+type guardWalker struct {
+	g        *guardProc
+	truncate bool
+
+	depth            int
+	fields           int
+	disallowedFields []string
+}
+
+// walk traverses a value nested within containers up to the given depth,
+// returning the value with violating parts removed when truncating.
+func (w *guardWalker) walk(v interface{}, path string, depth int) interface{} {
+	if !isGuardContainer(v) {
+		return v
+	}
+	if depth+1 > w.depth {
+		w.depth = depth + 1
+	}
+
+	// Whether children that are containers exceed the maximum depth.
+	pruneContainers := w.truncate && w.g.maxDepth > 0 && depth+1 >= w.g.maxDepth
+
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for _, k := range guardSortedKeys(t) {
+			childPath := guardFieldPath(path, k)
+			if w.g.isDisallowed(childPath) {
+				w.disallowedFields = append(w.disallowedFields, childPath)
+				if w.truncate {
+					delete(t, k)
+					continue
+				}
+			}
+			if w.truncate && ((w.g.maxFields > 0 && w.fields >= w.g.maxFields) || (pruneContainers && isGuardContainer(t[k]))) {
+				delete(t, k)
+				continue
+			}
+			w.fields++
+			t[k] = w.walk(t[k], childPath, depth+1)
+		}
+	case []interface{}:
+		if !w.truncate {
+			for i, e := range t {
+				w.walk(e, guardFieldPath(path, strconv.Itoa(i)), depth+1)
+			}
+			return t
+		}
+		kept := make([]interface{}, 0, len(t))
+		for i, e := range t {
+			if pruneContainers && isGuardContainer(e) {
+				continue
+			}
+			kept = append(kept, w.walk(e, guardFieldPath(path, strconv.Itoa(i)), depth+1))
+		}
+		return kept
+	}
+	return v
+}
+
+func isGuardContainer(v interface{}) bool {
+	switch v.(type) {
+	case map[string]interface{}, []interface{}:
+		return true
+	}
+	return false
+}
+
+// violations returns the types of violation of a message.
+func (g *guardProc) violations(msg *service.Message) ([]string, error) {
+	var violations []string
+
+	raw, err := msg.AsBytes()
+	if err != nil {
+		return nil, err
+	}
+	if g.maxSize > 0 && len(raw) > g.maxSize {
+		violations = append(violations, guardViolationSize)
+	}
+
+	if !g.checksStructure() {
+		return violations, nil
+	}
+	doc, err := msg.AsStructured()
+	if err != nil {
+		return violations, nil
+	}
+
+	w := &guardWalker{g: g}
+	w.walk(doc, "", 0)
+	if g.maxDepth > 0 && w.depth > g.maxDepth {
+		violations = append(violations, guardViolationDepth)
+	}
+	if g.maxFields > 0 && w.fields > g.maxFields {
+		violations = append(violations, guardViolationFields)
+	}
+	if len(w.disallowedFields) > 0 {
+		violations = append(violations, guardViolationDisallowedField)
+	}
+	return violations, nil
+}
+
+// truncate modifies a message in order to satisfy the limits of the guard.
+func (g *guardProc) truncate(msg *service.Message) error {
+	if g.checksStructure() {
+		if doc, err := msg.AsStructuredMut(); err == nil {
+			w := &guardWalker{g: g, truncate: true}
+			msg.SetStructured(w.walk(doc, "", 0))
+		}
+	}
+
+	if g.maxSize > 0 {
+		raw, err := msg.AsBytes()
+		if err != nil {
+			return err
+		}
+		if len(raw) > g.maxSize {
+			msg.SetBytes(raw[:g.maxSize])
+		}
+	}
+	return nil
+}
+
+func (g *guardProc) Process(ctx context.Context, msg *service.Message) (service.MessageBatch, error) {
+	violations, err := g.violations(msg)
+	if err != nil {
+		return nil, err
+	}
+	if len(violations) == 0 {
+		return service.MessageBatch{msg}, nil
+	}
+
+	for _, v := range violations {
+		g.mViolations.Incr(1, v)
+	}
+	violationsStr := strings.Join(violations, ",")
+	msg.MetaSet("guard_violations", violationsStr)
+
+	switch g.action {
+	case guardActionTruncate:
+		if err := g.truncate(msg); err != nil {
+			return nil, err
+		}
+	case guardActionQuarantine:
+		if err := g.quarantine.Write(ctx, msg); err != nil {
+			g.log.Errorf("Failed to write message to quarantine: %v", err)
+			msg.SetError(fmt.Errorf("failed to quarantine message with guard violations %v: %w", violationsStr, err))
+			break
+		}
+		return nil, nil
+	default:
+		g.log.Debugf("Rejecting message with guard violations: %v", violationsStr)
+		msg.SetError(fmt.Errorf("message violates guard limits: %v", violationsStr))
+	}
+	return service.MessageBatch{msg}, nil
+}
+
+func (g *guardProc) Close(ctx context.Context) error {
+	if g.quarantine != nil {
+		return g.quarantine.Close(ctx)
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package pure
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func TestGuardConfigs(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		errStr string
+	}{
+		{
+			name:   "missing quarantine",
+			config: `action: quarantine`,
+			errStr: "a quarantine output must be specified when the action is quarantine",
+		},
+		{
+			name:   "bad pattern",
+			config: `disallowed_fields: [ '(' ]`,
+			errStr: "failed to parse disallowed field pattern '(': error parsing regexp: missing closing ): `(`",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			pConf, err := guardProcConfig().ParseYAML(test.config, nil)
+			require.NoError(t, err)
+
+			_, err = newGuardProcFromConfig(pConf, service.MockResources())
+			require.EqualError(t, err, test.errStr)
+		})
+	}
+}
+
+func TestGuard(t *testing.T) {
+	tests := []struct {
+		name       string
+		config     string
+		input      string
+		output     string
+		violations string
+		errStr     string
+	}{
+		{
+			name:   "within limits",
+			config: `{ max_size: 100, max_depth: 2, max_fields: 3, disallowed_fields: [ 'password$' ] }`,
+			input:  `{"a":{"b":1},"c":[1,2]}`,
+			output: `{"a":{"b":1},"c":[1,2]}`,
+		},
+		{
+			name:       "reject size",
+			config:     `max_size: 5`,
+			input:      `hello world`,
+			output:     `hello world`,
+			violations: "size",
+			errStr:     "message violates guard limits: size",
+		},
+		{
+			name:       "reject structure",
+			config:     `{ max_depth: 2, max_fields: 2, disallowed_fields: [ '(^|\.)password$' ] }`,
+			input:      `{"a":{"b":{"c":1}},"user":{"password":"foo"}}`,
+			output:     `{"a":{"b":{"c":1}},"user":{"password":"foo"}}`,
+			violations: "depth,fields,disallowed_field",
+			errStr:     "message violates guard limits: depth,fields,disallowed_field",
+		},
+		{
+			name:   "unstructured skips structure",
+			config: `{ max_depth: 1, max_fields: 1 }`,
+			input:  `not json`,
+			output: `not json`,
+		},
+		{
+			name:       "truncate depth",
+			config:     `{ max_depth: 2, action: truncate }`,
+			input:      `{"a":{"b":{"c":1},"d":2},"e":[1,[2],{"f":3}]}`,
+			output:     `{"a":{"d":2},"e":[1]}`,
+			violations: "depth",
+		},
+		{
+			name:       "truncate fields",
+			config:     `{ max_fields: 3, action: truncate }`,
+			input:      `{"c":1,"a":{"x":1,"y":2},"b":3}`,
+			output:     `{"a":{"x":1,"y":2}}`,
+			violations: "fields",
+		},
+		{
+			name:       "truncate disallowed fields",
+			config:     `{ disallowed_fields: [ '^users\.\d+\.password$' ], action: truncate }`,
+			input:      `{"users":[{"name":"foo","password":"bar"},{"name":"baz"}]}`,
+			output:     `{"users":[{"name":"foo"},{"name":"baz"}]}`,
+			violations: "disallowed_field",
+		},
+		{
+			name:       "truncate size",
+			config:     `{ max_size: 5, action: truncate }`,
+			input:      `hello world`,
+			output:     `hello`,
+			violations: "size",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			pConf, err := guardProcConfig().ParseYAML(test.config, nil)
+			require.NoError(t, err)
+
+			proc, err := newGuardProcFromConfig(pConf, service.MockResources())
+			require.NoError(t, err)
+
+			batch, err := proc.Process(context.Background(), service.NewMessage([]byte(test.input)))
+			require.NoError(t, err)
+			require.Len(t, batch, 1)
+
+			mBytes, err := batch[0].AsBytes()
+			require.NoError(t, err)
+			assert.Equal(t, test.output, string(mBytes))
+
+			violations, _ := batch[0].MetaGet("guard_violations")
+			assert.Equal(t, test.violations, violations)
+
+			if test.errStr != "" {
+				require.EqualError(t, batch[0].GetError(), test.errStr)
+			} else {
+				require.NoError(t, batch[0].GetError())
+			}
+		})
+	}
+}
+
+func TestGuardQuarantine(t *testing.T) {
+	pConf, err := guardProcConfig().ParseYAML(`
+max_size: 5
+action: quarantine
+quarantine:
+  drop: {}
+`, nil)
+	require.NoError(t, err)
+
+	proc, err := newGuardProcFromConfig(pConf, service.MockResources())
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, proc.Close(context.Background()))
+	})
+
+	batch, err := proc.Process(context.Background(), service.NewMessage([]byte(`hello world`)))
+	require.NoError(t, err)
+	assert.Empty(t, batch)
+
+	batch, err = proc.Process(context.Background(), service.NewMessage([]byte(`hello`)))
+	require.NoError(t, err)
+	require.Len(t, batch, 1)
+	require.NoError(t, batch[0].GetError())
+}
@@ -0,0 +1,108 @@
+package pure
+
+import (
+	"context"
+
+	"github.com/benthosdev/benthos/v4/public/bloblang"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func mutationProcConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		Beta().
+		Categories("Mapping").
+		Version("4.1.0").
+		Summary("Executes a [Bloblang](/docs/guides/bloblang/about) mapping that directly mutates messages, rather than creating an entirely new document.").
+		Description(`
+Unlike the `+"[`bloblang` processor](/docs/components/processors/bloblang)"+`, where `+"`root`"+` begins as an empty document, the mapping of this processor begins with `+"`root`"+` set to the contents of the message, and therefore any fields that are not assigned by the mapping are preserved. Similarly, metadata of the message is preserved unless explicitly modified. This makes it a more convenient and efficient choice when a mapping only modifies a handful of fields of a larger document.
+
+Within the mapping the keyword `+"`this`"+` refers to the message as it was before the mapping began, and is therefore unaffected by assignments made during the mapping.
+
+## Error Handling
+
+Bloblang mappings can fail, in which case the error is logged and the message is flagged as having failed, allowing you to use [standard processor error handling patterns](/docs/configuration/error_handling).
+
+When `+"`preserve_on_failure`"+` is `+"`true`"+`, which is the default, the mapping is executed on a copy of the message and a failed message remains exactly as it was before the mapping, including its metadata. This means that error handling processors such as `+"[`catch`](/docs/components/processors/catch)"+` can recover messages without needing to account for partial results of the mapping.
+
+Setting `+"`preserve_on_failure`"+` to `+"`false`"+` avoids the cost of copying each message, but a failed message retains any assignments that were made by the mapping before the failure occurred.`).
+		Field(service.NewBloblangField("mapping").
+			Description("The [Bloblang](/docs/guides/bloblang/about) mapping to execute on each message.").
+			Example(`root.id = this.id.string()`)).
+		Field(service.NewBoolField("preserve_on_failure").
+			Description("Whether messages that fail the mapping should be left exactly as they were before the mapping was executed.").
+			Default(true).
+			Advanced()).
+		Example("Enriching Documents", `
+Given JSON documents containing many fields we can add or modify a few of them without needing to copy the remaining fields over explicitly. Should the mapping fail, for example because `+"`user.age`"+` is not a number, the message is left untouched and can be handled by a `+"`catch`"+` processor.`, `
+pipeline:
+  processors:
+    - mutation:
+        mapping: |
+          root.user.age_group = (this.user.age / 10).floor() * 10
+          root.user.name = this.user.name.capitalize()
+          meta processed = "true"
+    - catch:
+        - log:
+            message: 'Failed to enrich document: ${! error() }'
+`)
+}
+
+func init() {
+	err := service.RegisterProcessor(
+		"mutation", mutationProcConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.Processor, error) {
+			return newMutationProcFromConfig(conf, mgr)
+		})
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type mutationProc struct {
+	exec     *bloblang.Executor
+	preserve bool
+	log      *service.Logger
+}
+
+func newMutationProcFromConfig(conf *service.ParsedConfig, mgr *service.Resources) (*mutationProc, error) {
+	exec, err := conf.FieldBloblang("mapping")
+	if err != nil {
+		return nil, err
+	}
+	preserve, err := conf.FieldBool("preserve_on_failure")
+	if err != nil {
+		return nil, err
+	}
+	return &mutationProc{
+		exec:     exec,
+		preserve: preserve,
+		log:      mgr.Logger(),
+	}, nil
+}
+
+func (m *mutationProc) Process(ctx context.Context, msg *service.Message) (service.MessageBatch, error) {
+	target := msg
+	if m.preserve {
+		target = msg.Copy()
+	}
+
+	res, err := target.BloblangMutate(m.exec)
+	if err != nil {
+		// When the original is not preserved the target is the message itself,
+		// which may have been partially mutated.
+		m.log.Errorf("%v", err)
+		msg.SetError(err)
+		return service.MessageBatch{msg}, nil
+	}
+	if res == nil {
+		return nil, nil
+	}
+	return service.MessageBatch{res}, nil
+}
+
+func (m *mutationProc) Close(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,106 @@
+package pure
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func newMutationProcForTest(t *testing.T, conf string) *mutationProc {
+	t.Helper()
+
+	pConf, err := mutationProcConfig().ParseYAML(conf, nil)
+	require.NoError(t, err)
+
+	proc, err := newMutationProcFromConfig(pConf, service.MockResources())
+	require.NoError(t, err)
+	return proc
+}
+
+func TestMutationProcPreservesFields(t *testing.T) {
+	proc := newMutationProcForTest(t, `
+mapping: |
+  root.name = this.name.uppercase()
+  root.original_name = this.name
+  meta processed = "true"
+`)
+
+	msg := service.NewMessage([]byte(`{"id":"foo","name":"bar"}`))
+	msg.MetaSet("source", "test")
+
+	batch, err := proc.Process(context.Background(), msg)
+	require.NoError(t, err)
+	require.Len(t, batch, 1)
+
+	require.NoError(t, batch[0].GetError())
+	mBytes, err := batch[0].AsBytes()
+	require.NoError(t, err)
+	assert.Equal(t, `{"id":"foo","name":"BAR","original_name":"bar"}`, string(mBytes))
+
+	v, _ := batch[0].MetaGet("source")
+	assert.Equal(t, "test", v)
+	v, _ = batch[0].MetaGet("processed")
+	assert.Equal(t, "true", v)
+}
+
+func TestMutationProcDeleted(t *testing.T) {
+	proc := newMutationProcForTest(t, `
+mapping: 'root = if this.id == "foo" { deleted() }'
+`)
+
+	batch, err := proc.Process(context.Background(), service.NewMessage([]byte(`{"id":"foo"}`)))
+	require.NoError(t, err)
+	assert.Empty(t, batch)
+}
+
+func TestMutationProcFailure(t *testing.T) {
+	mapping := `
+  root.name = this.name.uppercase()
+  meta processed = "true"
+  root.age = this.age.number()
+`
+
+	tests := []struct {
+		name         string
+		preserve     bool
+		expected     string
+		expectedMeta bool
+	}{
+		{
+			name:     "preserve on failure",
+			preserve: true,
+			expected: `{"name":"bar","age":"nope"}`,
+		},
+		{
+			name:         "partial on failure",
+			preserve:     false,
+			expected:     `{"age":"nope","name":"BAR"}`,
+			expectedMeta: true,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			proc := newMutationProcForTest(t, fmt.Sprintf("preserve_on_failure: %v\nmapping: |%v", test.preserve, mapping))
+
+			batch, err := proc.Process(context.Background(), service.NewMessage([]byte(`{"name":"bar","age":"nope"}`)))
+			require.NoError(t, err)
+			require.Len(t, batch, 1)
+
+			require.Error(t, batch[0].GetError())
+
+			mBytes, err := batch[0].AsBytes()
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, string(mBytes))
+
+			_, exists := batch[0].MetaGet("processed")
+			assert.Equal(t, test.expectedMeta, exists)
+		})
+	}
+}
@@ -0,0 +1,232 @@
+package pure
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func parseCSVProcConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		Beta().
+		Categories("Parsing").
+		Version("4.1.0").
+		Summary("Parses messages as delimited rows of values (CSV, TSV, etc) and converts them into structured messages.").
+		Description(`
+By default the first row of each message is parsed as a header row and each subsequent row becomes a message containing an object, where the keys are the header column names. Each resulting message has the metadata field `+"`csv_row`"+` set to the index of the row (starting from 1, excluding the header).
+
+If `+"`parse_header_row`"+` is set to `+"`false`"+` and no `+"`header`"+` is provided then each row is instead converted into an array of values.
+
+When `+"`expand`"+` is set to `+"`false`"+` all rows of a message are emitted as a single message containing an array of rows.
+
+### Type Coercion
+
+When `+"`coerce_types`"+` is set to `+"`true`"+` values that look like integers and finite floats are converted into numbers, the values `+"`true`"+` and `+"`false`"+` are converted into booleans, and empty values are converted into `+"`null`"+`. All other values remain strings.`).
+		Field(service.NewStringField("delimiter").
+			Description("The delimiter between values of a row, which must be a single character.").
+			Example("\t").
+			Default(",")).
+		Field(service.NewBoolField("parse_header_row").
+			Description("Whether the first row of each message is a header row.").
+			Default(true)).
+		Field(service.NewStringListField("header").
+			Description("An optional list of column names to use instead of a header row. When `parse_header_row` is `true` the first row of each message is skipped.").
+			Example([]string{"id", "name", "age"}).
+			Default([]interface{}{})).
+		Field(service.NewBoolField("lazy_quotes").
+			Description("Whether quotes may appear in unquoted values and non-doubled quotes may appear in quoted values.").
+			Default(false).
+			Advanced()).
+		Field(service.NewBoolField("coerce_types").
+			Description("Whether to convert values that look like numbers, booleans or empty values into those types.").
+			Default(false)).
+		Field(service.NewBoolField("expand").
+			Description("Whether to expand each row into an individual message, otherwise a single message containing an array of rows is emitted.").
+			Default(true)).
+		Example("Parse TSV Files", `
+Files consumed in full are expanded into a message per row, with numeric values converted into numbers.`, `
+input:
+  file:
+    paths: [ ./data/*.tsv ]
+    codec: all-bytes
+
+pipeline:
+  processors:
+    - parse_csv:
+        delimiter: "\t"
+        coerce_types: true
+`)
+}
+
+func init() {
+	err := service.RegisterProcessor(
+		"parse_csv", parseCSVProcConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.Processor, error) {
+			return newParseCSVProcFromConfig(conf)
+		})
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type parseCSVProc struct {
+	delim       rune
+	parseHeader bool
+	header      []string
+	lazyQuotes  bool
+	coerce      bool
+	expand      bool
+}
+
+func newParseCSVProcFromConfig(conf *service.ParsedConfig) (*parseCSVProc, error) {
+	p := &parseCSVProc{}
+
+	delimStr, err := conf.FieldString("delimiter")
+	if err != nil {
+		return nil, err
+	}
+	if utf8.RuneCountInString(delimStr) != 1 {
+		return nil, errors.New("delimiter must be a single character")
+	}
+	p.delim, _ = utf8.DecodeRuneInString(delimStr)
+
+	if p.parseHeader, err = conf.FieldBool("parse_header_row"); err != nil {
+		return nil, err
+	}
+	if p.header, err = conf.FieldStringList("header"); err != nil {
+		return nil, err
+	}
+	if p.lazyQuotes, err = conf.FieldBool("lazy_quotes"); err != nil {
+		return nil, err
+	}
+	if p.coerce, err = conf.FieldBool("coerce_types"); err != nil {
+		return nil, err
+	}
+	if p.expand, err = conf.FieldBool("expand"); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (p *parseCSVProc) coerceValue(v string) interface{} {
+	if !p.coerce {
+		return v
+	}
+	if v == "" {
+		return nil
+	}
+	if i, err := strconv.ParseInt(v, 10, 64); err == nil {
+		return i
+	}
+	// Special values such as NaN and Inf can't be serialized as JSON and are
+	// more likely to be words than numbers, so only finite values are kept.
+	if f, err := strconv.ParseFloat(v, 64); err == nil && !math.IsNaN(f) && !math.IsInf(f, 0) {
+		return f
+	}
+	switch v {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+	return v
+}
+
+func (p *parseCSVProc) rowToStructured(header, row []string) (interface{}, error) {
+	if header == nil {
+		values := make([]interface{}, len(row))
+		for i, v := range row {
+			values[i] = p.coerceValue(v)
+		}
+		return values, nil
+	}
+	if len(row) != len(header) {
+		return nil, fmt.Errorf("row has %v values, expected %v", len(row), len(header))
+	}
+	obj := make(map[string]interface{}, len(header))
+	for i, k := range header {
+		obj[k] = p.coerceValue(row[i])
+	}
+	return obj, nil
+}
+
+func (p *parseCSVProc) Process(ctx context.Context, msg *service.Message) (service.MessageBatch, error) {
+	mBytes, err := msg.AsBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	r := csv.NewReader(bytes.NewReader(mBytes))
+	r.Comma = p.delim
+	r.LazyQuotes = p.lazyQuotes
+	r.FieldsPerRecord = -1
+
+	var header []string
+	if len(p.header) > 0 {
+		header = p.header
+	}
+	if p.parseHeader {
+		headerRow, err := r.Read()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil, errors.New("failed to read header row: message is empty")
+			}
+			return nil, fmt.Errorf("failed to read header row: %w", err)
+		}
+		if header == nil {
+			header = headerRow
+		}
+	}
+
+	var rows []interface{}
+	var batch service.MessageBatch
+	for rowIndex := 1; ; rowIndex++ {
+		row, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read row %v: %w", rowIndex, err)
+		}
+
+		v, err := p.rowToStructured(header, row)
+		if err != nil {
+			return nil, fmt.Errorf("row %v: %w", rowIndex, err)
+		}
+
+		if !p.expand {
+			rows = append(rows, v)
+			continue
+		}
+
+		rowMsg := msg.Copy()
+		rowMsg.SetStructured(v)
+		rowMsg.MetaSet("csv_row", strconv.Itoa(rowIndex))
+		batch = append(batch, rowMsg)
+	}
+
+	if !p.expand {
+		if rows == nil {
+			rows = []interface{}{}
+		}
+		resMsg := msg.Copy()
+		resMsg.SetStructured(rows)
+		return service.MessageBatch{resMsg}, nil
+	}
+	return batch, nil
+}
+
+func (p *parseCSVProc) Close(ctx context.Context) error {
+	return nil
+}
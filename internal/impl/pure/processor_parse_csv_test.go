@@ -0,0 +1,143 @@
+package pure
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func TestParseCSV(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   string
+		input    string
+		output   []string
+		metadata []string
+	}{
+		{
+			name:   "header row",
+			config: `{}`,
+			input:  "id,name\n1,foo\n2,bar\n",
+			output: []string{
+				`{"id":"1","name":"foo"}`,
+				`{"id":"2","name":"bar"}`,
+			},
+			metadata: []string{"1", "2"},
+		},
+		{
+			name: "tsv with coercion",
+			config: `
+delimiter: "\t"
+coerce_types: true
+`,
+			input: "id\tname\tscore\tok\n1\tfoo\t1.5\ttrue\n2\t\t3\tfalse\n",
+			output: []string{
+				`{"id":1,"name":"foo","ok":true,"score":1.5}`,
+				`{"id":2,"name":null,"ok":false,"score":3}`,
+			},
+			metadata: []string{"1", "2"},
+		},
+		{
+			name: "coercion ignores special values",
+			config: `
+coerce_types: true
+`,
+			input: "a,b,c,d,e\nNaN,Inf,Infinity,T,1\n",
+			output: []string{
+				`{"a":"NaN","b":"Inf","c":"Infinity","d":"T","e":1}`,
+			},
+			metadata: []string{"1"},
+		},
+		{
+			name: "header override",
+			config: `
+header: [ a, b ]
+`,
+			input: "x,y\n1,2\n",
+			output: []string{
+				`{"a":"1","b":"2"}`,
+			},
+			metadata: []string{"1"},
+		},
+		{
+			name: "no header",
+			config: `
+parse_header_row: false
+`,
+			input: "1,2\n3,4\n",
+			output: []string{
+				`["1","2"]`,
+				`["3","4"]`,
+			},
+			metadata: []string{"1", "2"},
+		},
+		{
+			name: "not expanded",
+			config: `
+expand: false
+`,
+			input: "id,name\n1,foo\n2,bar\n",
+			output: []string{
+				`[{"id":"1","name":"foo"},{"id":"2","name":"bar"}]`,
+			},
+			metadata: []string{""},
+		},
+		{
+			name: "lazy quotes",
+			config: `
+lazy_quotes: true
+`,
+			input: "id,name\n1,fo\"o\n",
+			output: []string{
+				`{"id":"1","name":"fo\"o"}`,
+			},
+			metadata: []string{"1"},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			conf, err := parseCSVProcConfig().ParseYAML(test.config, nil)
+			require.NoError(t, err)
+
+			proc, err := newParseCSVProcFromConfig(conf)
+			require.NoError(t, err)
+
+			batch, err := proc.Process(context.Background(), service.NewMessage([]byte(test.input)))
+			require.NoError(t, err)
+			require.Len(t, batch, len(test.output))
+
+			for i, msg := range batch {
+				mBytes, err := msg.AsBytes()
+				require.NoError(t, err)
+				assert.Equal(t, test.output[i], string(mBytes))
+
+				row, _ := msg.MetaGet("csv_row")
+				assert.Equal(t, test.metadata[i], row)
+			}
+		})
+	}
+}
+
+func TestParseCSVErrors(t *testing.T) {
+	conf, err := parseCSVProcConfig().ParseYAML(`delimiter: "ab"`, nil)
+	require.NoError(t, err)
+
+	_, err = newParseCSVProcFromConfig(conf)
+	require.Error(t, err)
+
+	conf, err = parseCSVProcConfig().ParseYAML(`{}`, nil)
+	require.NoError(t, err)
+
+	proc, err := newParseCSVProcFromConfig(conf)
+	require.NoError(t, err)
+
+	_, err = proc.Process(context.Background(), service.NewMessage([]byte("a,b\n1,2,3\n")))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "row 1")
+}
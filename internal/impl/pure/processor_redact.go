@@ -0,0 +1,507 @@
+package pure
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const (
+	redactActionMask     = "mask"
+	redactActionHash     = "hash"
+	redactActionTokenize = "tokenize"
+
+	redactDetectorEmail      = "email"
+	redactDetectorCreditCard = "credit_card"
+	redactDetectorIP         = "ip"
+	redactDetectorPhone      = "phone"
+)
+
+func redactProcConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		Beta().
+		Categories("Mapping").
+		Version("4.1.0").
+		Summary("Redacts personally identifiable information (PII) from messages by detecting it within values and masking, hashing or tokenizing it.").
+		Description(`
+For structured (JSON) messages each string and number value of the document is scanned, where numbers are replaced with a string when redacted, and the scan can be limited to specific fields with `+"`paths`"+`. Messages that cannot be parsed as structured data are scanned in their entirety, unless `+"`paths`"+` is set, in which case they are left unchanged.
+
+## Detectors
+
+The following detectors are built in and can be enabled with the field `+"`detectors`"+`:
+
+- `+"`email`"+`: Email addresses.
+- `+"`credit_card`"+`: Credit card numbers of 13 to 19 digits, optionally separated by spaces or dashes, that pass a Luhn checksum.
+- `+"`ip`"+`: IPv4 and IPv6 addresses.
+- `+"`phone`"+`: Phone numbers of eight or more digits, optionally with a country code and separated by spaces, dots or dashes.
+
+Detection is heuristic and favours redacting too much over too little, where the `+"`phone`"+` detector in particular will also match other long numbers. Additional detectors can be added with `+"`custom`"+`, either as a regular expression or a dictionary of words that are matched case insensitively. When the matches of detectors overlap the match of the detector listed first is used, where built in detectors are listed before custom ones.
+
+## Actions
+
+The field `+"`action`"+` determines what detected values are replaced with:
+
+- `+"`mask`"+`: The `+"`mask_text`"+`.
+- `+"`hash`"+`: A hex encoded HMAC-SHA256 hash of the value using the `+"`salt`"+` as the key, allowing redacted values to be correlated without being revealed.
+- `+"`tokenize`"+`: A token of the form `+"`tok_<hash prefix>`"+`, where the original value is stored in the `+"`cache`"+` under the token as its key, allowing authorized systems to recover it. If the value cannot be stored it is masked instead and the message is flagged as having failed.
+
+## Metadata
+
+Messages with redacted values have the metadata field `+"`redacted_paths`"+` set to a comma separated list of the paths of the fields that were redacted, where the path `+"`root`"+` refers to the whole of an unstructured message, and `+"`redacted_detectors`"+` set to a comma separated list of the detectors that matched.`).
+		Field(service.NewStringListField("detectors").
+			Description("A list of built in detectors to enable.").
+			Example([]string{redactDetectorEmail, redactDetectorCreditCard, redactDetectorIP, redactDetectorPhone}).
+			Default([]string{})).
+		Field(service.NewObjectListField("custom",
+			service.NewStringField("name").
+				Description("The name of the detector, which is recorded in metadata when it matches."),
+			service.NewStringField("pattern").
+				Description("A regular expression that matches values to redact.").
+				Optional(),
+			service.NewStringListField("words").
+				Description("A dictionary of words to redact.").
+				Optional(),
+		).Description("A list of custom detectors, each with either a `pattern` or `words`.").
+			Default([]interface{}{})).
+		Field(service.NewStringListField("paths").
+			Description("An optional list of dot separated paths of fields to scan, where fields nested within them are also scanned. When empty all fields are scanned.").
+			Example([]string{"user.email", "notes"}).
+			Default([]string{})).
+		Field(service.NewStringEnumField("action", redactActionMask, redactActionHash, redactActionTokenize).
+			Description("What to replace detected values with.").
+			Default(redactActionMask)).
+		Field(service.NewStringField("mask_text").
+			Description("The text that detected values are replaced with when the `action` is `mask`.").
+			Default("[REDACTED]")).
+		Field(service.NewStringField("salt").
+			Description("The key used when hashing values, which is required for the `hash` and `tokenize` actions. Without a secret key hashes of values with few possible variants, such as phone and credit card numbers, could be reversed by hashing every variant.").
+			Secret().
+			Default("")).
+		Field(service.NewStringField("cache").
+			Description("A [cache resource](/docs/components/caches/about) that values are stored in when the `action` is `tokenize`.").
+			Optional()).
+		Example("Tokenizing Customer Details", `
+Email addresses and credit card numbers within customer records are replaced with tokens, where the original values are stored in a Redis cache so that they can be recovered by authorized systems.`, `
+pipeline:
+  processors:
+    - redact:
+        detectors: [ email, credit_card ]
+        custom:
+          - name: account_id
+            pattern: 'ACC-\d{8}'
+        action: tokenize
+        salt: ${REDACT_SALT}
+        cache: tokens
+
+cache_resources:
+  - label: tokens
+    redis:
+      url: tcp://localhost:6379
+`)
+}
+
+func init() {
+	err := service.RegisterProcessor(
+		"redact", redactProcConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.Processor, error) {
+			return newRedactProcFromConfig(conf, mgr)
+		})
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+var (
+	redactEmailRegexp      = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+	redactCreditCardRegexp = regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`)
+	redactIPRegexp         = regexp.MustCompile(`\b\d{1,3}(?:\.\d{1,3}){3}\b|[0-9A-Fa-f]{0,4}(?::[0-9A-Fa-f]{0,4}){2,7}`)
+	redactPhoneRegexp      = regexp.MustCompile(`(?:\+\d{1,3}[\s.-]?)?(?:\(\d{2,4}\)[\s.-]?|\b\d{2,4}[\s.-]?)\d{3,4}[\s.-]?\d{3,4}\b`)
+)
+
+// luhnValid returns whether the digits of a string pass a Luhn checksum.
+func luhnValid(s string) bool {
+	sum, double := 0, false
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if double {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+type redactDetector struct {
+	name  string
+	re    *regexp.Regexp
+	valid func(string) bool
+}
+
+func builtInRedactDetector(name string) (redactDetector, error) {
+	switch name {
+	case redactDetectorEmail:
+		return redactDetector{name: name, re: redactEmailRegexp}, nil
+	case redactDetectorCreditCard:
+		return redactDetector{name: name, re: redactCreditCardRegexp, valid: luhnValid}, nil
+	case redactDetectorIP:
+		return redactDetector{name: name, re: redactIPRegexp, valid: func(s string) bool {
+			return net.ParseIP(s) != nil
+		}}, nil
+	case redactDetectorPhone:
+		return redactDetector{name: name, re: redactPhoneRegexp}, nil
+	}
+	return redactDetector{}, fmt.Errorf("detector '%v' was not recognised", name)
+}
+
+type redactProc struct {
+	detectors []redactDetector
+	paths     [][]string
+
+	action   string
+	maskText string
+	salt     []byte
+
+	mgr       cacheProvider
+	cacheName string
+	log       *service.Logger
+}
+
+func newRedactProcFromConfig(conf *service.ParsedConfig, mgr *service.Resources) (*redactProc, error) {
+	var cacheName string
+	if conf.Contains("cache") {
+		var err error
+		if cacheName, err = conf.FieldString("cache"); err != nil {
+			return nil, err
+		}
+		if !mgr.HasCache(cacheName) {
+			return nil, fmt.Errorf("cache resource '%v' was not found", cacheName)
+		}
+	}
+	return newRedactProcFromParsed(conf, cacheName, mgr, mgr.Logger())
+}
+
+func newRedactProcFromParsed(conf *service.ParsedConfig, cacheName string, mgr cacheProvider, log *service.Logger) (*redactProc, error) {
+	r := &redactProc{
+		mgr:       mgr,
+		cacheName: cacheName,
+		log:       log,
+	}
+
+	names, err := conf.FieldStringList("detectors")
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range names {
+		d, err := builtInRedactDetector(name)
+		if err != nil {
+			return nil, err
+		}
+		r.detectors = append(r.detectors, d)
+	}
+
+	customConfs, err := conf.FieldObjectList("custom")
+	if err != nil {
+		return nil, err
+	}
+	for _, cConf := range customConfs {
+		var d redactDetector
+		if d.name, err = cConf.FieldString("name"); err != nil {
+			return nil, err
+		}
+		var words []string
+		if cConf.Contains("words") {
+			if words, err = cConf.FieldStringList("words"); err != nil {
+				return nil, err
+			}
+		}
+		if cConf.Contains("pattern") == (len(words) > 0) {
+			return nil, fmt.Errorf("custom detector '%v' must have either a pattern or words", d.name)
+		}
+		var pattern string
+		if cConf.Contains("pattern") {
+			if pattern, err = cConf.FieldString("pattern"); err != nil {
+				return nil, err
+			}
+		} else {
+			quoted := make([]string, 0, len(words))
+			for _, w := range words {
+				quoted = append(quoted, regexp.QuoteMeta(w))
+			}
+			pattern = `(?i)\b(?:` + strings.Join(quoted, "|") + `)\b`
+		}
+		if d.re, err = regexp.Compile(pattern); err != nil {
+			return nil, fmt.Errorf("failed to parse pattern of custom detector '%v': %w", d.name, err)
+		}
+		r.detectors = append(r.detectors, d)
+	}
+	if len(r.detectors) == 0 {
+		return nil, errors.New("at least one detector must be specified")
+	}
+
+	paths, err := conf.FieldStringList("paths")
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range paths {
+		r.paths = append(r.paths, strings.Split(p, "."))
+	}
+
+	if r.action, err = conf.FieldString("action"); err != nil {
+		return nil, err
+	}
+	if r.maskText, err = conf.FieldString("mask_text"); err != nil {
+		return nil, err
+	}
+	salt, err := conf.FieldString("salt")
+	if err != nil {
+		return nil, err
+	}
+	r.salt = []byte(salt)
+
+	if r.action != redactActionMask && len(r.salt) == 0 {
+		return nil, fmt.Errorf("a salt must be specified when the action is %v", r.action)
+	}
+	if r.action == redactActionTokenize && r.cacheName == "" {
+		return nil, errors.New("a cache must be specified when the action is tokenize")
+	}
+	return r, nil
+}
+
+//------------------------------------------------------------------------------
+
+type redactMatch struct {
+	start, end int
+	detector   string
+}
+
+// findMatches returns the non-overlapping matches of all detectors within a
+// string ordered by their position, where matches of detectors listed first
+// take precedence.
+func (r *redactProc) findMatches(s string) []redactMatch {
+	var matches []redactMatch
+	for _, d := range r.detectors {
+	indexLoop:
+		for _, loc := range d.re.FindAllStringIndex(s, -1) {
+			if loc[0] == loc[1] {
+				continue
+			}
+			if d.valid != nil && !d.valid(s[loc[0]:loc[1]]) {
+				continue
+			}
+			for _, m := range matches {
+				if loc[0] < m.end && m.start < loc[1] {
+					continue indexLoop
+				}
+			}
+			matches = append(matches, redactMatch{start: loc[0], end: loc[1], detector: d.name})
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].start < matches[j].start
+	})
+	return matches
+}
+
+func (r *redactProc) hash(v string) string {
+	h := hmac.New(sha256.New, r.salt)
+	_, _ = h.Write([]byte(v))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (r *redactProc) replacement(ctx context.Context, v string) (string, error) {
+	switch r.action {
+	case redactActionHash:
+		return r.hash(v), nil
+	case redactActionTokenize:
+		token := "tok_" + r.hash(v)[:16]
+		var setErr error
+		if err := r.mgr.AccessCache(ctx, r.cacheName, func(c service.Cache) {
+			setErr = c.Set(ctx, token, []byte(v), nil)
+		}); err != nil {
+			return r.maskText, err
+		}
+		if setErr != nil {
+			return r.maskText, setErr
+		}
+		return token, nil
+	}
+	return r.maskText, nil
+}
+
+// redactResult records the redactions made to a message.
+type redactResult struct {
+	paths     []string
+	detectors map[string]struct{}
+	err       error
+}
+
+// redactString returns a string with all detected values replaced, recording
+// the redaction under the given path.
+func (r *redactProc) redactString(ctx context.Context, s, path string, res *redactResult) string {
+	matches := r.findMatches(s)
+	if len(matches) == 0 {
+		return s
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		b.WriteString(s[last:m.start])
+		rep, err := r.replacement(ctx, s[m.start:m.end])
+		if err != nil && res.err == nil {
+			res.err = fmt.Errorf("failed to tokenize value at path '%v': %w", path, err)
+		}
+		b.WriteString(rep)
+		last = m.end
+		res.detectors[m.detector] = struct{}{}
+	}
+	b.WriteString(s[last:])
+
+	res.paths = append(res.paths, path)
+	return b.String()
+}
+
+// inPaths returns whether a path is within one of the configured paths, and
+// whether it could be a parent of one when it isn't.
+func (r *redactProc) inPaths(path []string) (within, parent bool) {
+	if len(r.paths) == 0 {
+		return true, false
+	}
+	for _, p := range r.paths {
+		n := len(path)
+		if n > len(p) {
+			n = len(p)
+		}
+		matched := true
+		for i := 0; i < n; i++ {
+			if p[i] != path[i] {
+				matched = false
+				break
+			}
+		}
+		if !matched {
+			continue
+		}
+		if len(path) >= len(p) {
+			return true, false
+		}
+		parent = true
+	}
+	return false, parent
+}
+
+// redactNumberString formats a number value as it would appear within a JSON
+// document so that it can be scanned, returning false if the value isn't a
+// number.
+func redactNumberString(v interface{}) (string, bool) {
+	switch t := v.(type) {
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64), true
+	case json.Number:
+		return t.String(), true
+	case int64:
+		return strconv.FormatInt(t, 10), true
+	case int:
+		return strconv.Itoa(t), true
+	case uint64:
+		return strconv.FormatUint(t, 10), true
+	}
+	return "", false
+}
+
+func (r *redactProc) walk(ctx context.Context, v interface{}, path []string, res *redactResult) interface{} {
+	within, parent := r.inPaths(path)
+	if !within && !parent {
+		return v
+	}
+	pathStr := func() string {
+		if p := strings.Join(path, "."); p != "" {
+			return p
+		}
+		return "root"
+	}
+	switch t := v.(type) {
+	case string:
+		if within {
+			return r.redactString(ctx, t, pathStr(), res)
+		}
+	case map[string]interface{}:
+		for k, e := range t {
+			t[k] = r.walk(ctx, e, append(path[:len(path):len(path)], k), res)
+		}
+	case []interface{}:
+		for i, e := range t {
+			t[i] = r.walk(ctx, e, append(path[:len(path):len(path)], strconv.Itoa(i)), res)
+		}
+	default:
+		// Numbers such as card or phone numbers are scanned as text, and are
+		// only replaced (with a string) when something within them matches.
+		if numStr, isNum := redactNumberString(v); within && isNum {
+			nPaths := len(res.paths)
+			if redacted := r.redactString(ctx, numStr, pathStr(), res); len(res.paths) > nPaths {
+				return redacted
+			}
+		}
+	}
+	return v
+}
+
+func (r *redactProc) Process(ctx context.Context, msg *service.Message) (service.MessageBatch, error) {
+	res := &redactResult{detectors: map[string]struct{}{}}
+
+	if doc, err := msg.AsStructuredMut(); err == nil {
+		doc = r.walk(ctx, doc, nil, res)
+		if len(res.paths) > 0 {
+			msg.SetStructured(doc)
+		}
+	} else if len(r.paths) == 0 {
+		raw, err := msg.AsBytes()
+		if err != nil {
+			return nil, err
+		}
+		if redacted := r.redactString(ctx, string(raw), "root", res); len(res.paths) > 0 {
+			msg.SetBytes([]byte(redacted))
+		}
+	}
+
+	if len(res.paths) > 0 {
+		detectors := make([]string, 0, len(res.detectors))
+		for d := range res.detectors {
+			detectors = append(detectors, d)
+		}
+		sort.Strings(detectors)
+		sort.Strings(res.paths)
+		msg.MetaSet("redacted_paths", strings.Join(res.paths, ","))
+		msg.MetaSet("redacted_detectors", strings.Join(detectors, ","))
+	}
+	if res.err != nil {
+		r.log.Errorf("%v", res.err)
+		msg.SetError(res.err)
+	}
+	return service.MessageBatch{msg}, nil
+}
+
+func (r *redactProc) Close(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,241 @@
+package pure
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func TestRedactConfigs(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		errStr string
+	}{
+		{
+			name:   "no detectors",
+			config: `action: mask`,
+			errStr: "at least one detector must be specified",
+		},
+		{
+			name:   "unknown detector",
+			config: `detectors: [ nope ]`,
+			errStr: "detector 'nope' was not recognised",
+		},
+		{
+			name: "custom without pattern or words",
+			config: `
+custom:
+  - name: foo`,
+			errStr: "custom detector 'foo' must have either a pattern or words",
+		},
+		{
+			name:   "tokenize without cache",
+			config: `{ detectors: [ email ], action: tokenize, salt: foo }`,
+			errStr: "a cache must be specified when the action is tokenize",
+		},
+		{
+			name:   "hash without salt",
+			config: `{ detectors: [ email ], action: hash }`,
+			errStr: "a salt must be specified when the action is hash",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			pConf, err := redactProcConfig().ParseYAML(test.config, nil)
+			require.NoError(t, err)
+
+			_, err = newRedactProcFromParsed(pConf, "", &mockCacheProv{}, nil)
+			require.EqualError(t, err, test.errStr)
+		})
+	}
+}
+
+func TestRedactDetectors(t *testing.T) {
+	pConf, err := redactProcConfig().ParseYAML(`
+detectors: [ email, credit_card, ip, phone ]
+custom:
+  - name: account
+    pattern: 'ACC-\d{4}'
+  - name: codename
+    words: [ bluebird, "red fox" ]
+`, nil)
+	require.NoError(t, err)
+
+	proc, err := newRedactProcFromParsed(pConf, "", &mockCacheProv{}, nil)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		input     string
+		output    string
+		paths     string
+		detectors string
+	}{
+		{
+			name:      "email",
+			input:     `{"contact":"email foo.bar@example.com now"}`,
+			output:    `{"contact":"email [REDACTED] now"}`,
+			paths:     "contact",
+			detectors: "email",
+		},
+		{
+			name:      "credit card",
+			input:     `{"card":"4111 1111 1111 1111","other_card":"5500-0000-0000-0004","not_card":"4111111111111112"}`,
+			output:    `{"card":"[REDACTED]","not_card":"4111111111111112","other_card":"[REDACTED]"}`,
+			paths:     "card,other_card",
+			detectors: "credit_card",
+		},
+		{
+			name:      "numeric credit card",
+			input:     `{"card":4111111111111111,"phone":442079460958,"not_card":4111111111111112,"amount":12.5}`,
+			output:    `{"amount":12.5,"card":"[REDACTED]","not_card":4111111111111112,"phone":"[REDACTED]"}`,
+			paths:     "card,phone",
+			detectors: "credit_card,phone",
+		},
+		{
+			name:      "ip addresses",
+			input:     `{"a":"from 192.168.0.1","b":"from 2001:db8::1","c":"version 1.2.3"}`,
+			output:    `{"a":"from [REDACTED]","b":"from [REDACTED]","c":"version 1.2.3"}`,
+			paths:     "a,b",
+			detectors: "ip",
+		},
+		{
+			name:      "phone numbers",
+			input:     `{"a":"call +44 20 7946 0958","b":"or (555) 123-4567","c":"on 2021-01-01"}`,
+			output:    `{"a":"call [REDACTED]","b":"or [REDACTED]","c":"on 2021-01-01"}`,
+			paths:     "a,b",
+			detectors: "phone",
+		},
+		{
+			name:      "custom",
+			input:     `{"users":[{"id":"ACC-1234"},{"note":"Operation BlueBird and red fox"}]}`,
+			output:    `{"users":[{"id":"[REDACTED]"},{"note":"Operation [REDACTED] and [REDACTED]"}]}`,
+			paths:     "users.0.id,users.1.note",
+			detectors: "account,codename",
+		},
+		{
+			name:      "unstructured",
+			input:     `contact foo@example.com`,
+			output:    `contact [REDACTED]`,
+			paths:     "root",
+			detectors: "email",
+		},
+		{
+			name:   "nothing to redact",
+			input:  `{"a":"hello world","b":5}`,
+			output: `{"a":"hello world","b":5}`,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			batch, err := proc.Process(context.Background(), service.NewMessage([]byte(test.input)))
+			require.NoError(t, err)
+			require.Len(t, batch, 1)
+			require.NoError(t, batch[0].GetError())
+
+			mBytes, err := batch[0].AsBytes()
+			require.NoError(t, err)
+			assert.Equal(t, test.output, string(mBytes))
+
+			paths, _ := batch[0].MetaGet("redacted_paths")
+			assert.Equal(t, test.paths, paths)
+
+			detectors, _ := batch[0].MetaGet("redacted_detectors")
+			assert.Equal(t, test.detectors, detectors)
+		})
+	}
+}
+
+func TestRedactPaths(t *testing.T) {
+	pConf, err := redactProcConfig().ParseYAML(`
+detectors: [ email ]
+paths: [ user, notes ]
+`, nil)
+	require.NoError(t, err)
+
+	proc, err := newRedactProcFromParsed(pConf, "", &mockCacheProv{}, nil)
+	require.NoError(t, err)
+
+	batch, err := proc.Process(context.Background(), service.NewMessage([]byte(
+		`{"user":{"email":"a@example.com"},"notes":"b@example.com","other":"c@example.com"}`,
+	)))
+	require.NoError(t, err)
+	require.Len(t, batch, 1)
+
+	mBytes, err := batch[0].AsBytes()
+	require.NoError(t, err)
+	assert.Equal(t, `{"notes":"[REDACTED]","other":"c@example.com","user":{"email":"[REDACTED]"}}`, string(mBytes))
+
+	batch, err = proc.Process(context.Background(), service.NewMessage([]byte(`raw a@example.com`)))
+	require.NoError(t, err)
+	mBytes, err = batch[0].AsBytes()
+	require.NoError(t, err)
+	assert.Equal(t, `raw a@example.com`, string(mBytes))
+}
+
+func TestRedactHashAndTokenize(t *testing.T) {
+	pConf, err := redactProcConfig().ParseYAML(`
+detectors: [ email ]
+action: hash
+salt: foo
+`, nil)
+	require.NoError(t, err)
+
+	proc, err := newRedactProcFromParsed(pConf, "", &mockCacheProv{}, nil)
+	require.NoError(t, err)
+
+	batch, err := proc.Process(context.Background(), service.NewMessage([]byte(`a@example.com`)))
+	require.NoError(t, err)
+	mBytes, err := batch[0].AsBytes()
+	require.NoError(t, err)
+	hashed := string(mBytes)
+	assert.Len(t, hashed, 64)
+	assert.Equal(t, proc.hash("a@example.com"), hashed)
+
+	pConf, err = redactProcConfig().ParseYAML(`
+detectors: [ email ]
+action: tokenize
+salt: foo
+cache: foo
+`, nil)
+	require.NoError(t, err)
+
+	cache := newMemCache(time.Minute, 0, 1, nil)
+	proc, err = newRedactProcFromParsed(pConf, "foo", &mockCacheProv{
+		caches: map[string]service.Cache{"foo": cache},
+	}, nil)
+	require.NoError(t, err)
+
+	batch, err = proc.Process(context.Background(), service.NewMessage([]byte(`{"email":"a@example.com"}`)))
+	require.NoError(t, err)
+	require.NoError(t, batch[0].GetError())
+
+	mBytes, err = batch[0].AsBytes()
+	require.NoError(t, err)
+	token := "tok_" + hashed[:16]
+	assert.Equal(t, `{"email":"`+token+`"}`, string(mBytes))
+
+	original, err := cache.Get(context.Background(), token)
+	require.NoError(t, err)
+	assert.Equal(t, "a@example.com", string(original))
+
+	// Values that can't be stored are masked and the message is flagged.
+	proc.cacheName = "nope"
+	batch, err = proc.Process(context.Background(), service.NewMessage([]byte(`{"email":"a@example.com"}`)))
+	require.NoError(t, err)
+	require.Error(t, batch[0].GetError())
+
+	mBytes, err = batch[0].AsBytes()
+	require.NoError(t, err)
+	assert.Equal(t, `{"email":"[REDACTED]"}`, string(mBytes))
+}
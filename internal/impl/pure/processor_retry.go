@@ -0,0 +1,213 @@
+package pure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func retryProcConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		Beta().
+		Categories("Composition").
+		Version("4.1.0").
+		Summary("Executes child processors on each message and, should any resulting message be flagged with an error, executes them again on the original message with an exponential back off until they succeed or a limit is reached.").
+		Description(`
+This processor is useful for wrapping processors that interact with remote services, such as `+"[`http`](/docs/components/processors/http)"+` or `+"[`branch`](/docs/components/processors/branch)"+`, where transient failures can be resolved by simply trying again.
+
+Each attempt is made with a fresh copy of the message as it was before reaching this processor, and therefore changes made by a failed attempt are discarded. When an attempt succeeds, or retries are exhausted, the messages resulting from the final attempt are passed on, and in the case of exhausted retries those messages remain flagged with the errors of that attempt so that they can be handled with [error handling patterns](/docs/configuration/error_handling).
+
+The metadata field `+"`retry_attempts`"+` is set on resulting messages to the number of attempts that were made.
+
+### Error Matching
+
+When `+"`error_pattern`"+` is set only errors that match the regular expression are retried, and messages that fail with any other error are passed on immediately. This is useful for only retrying errors that are known to be transient, such as timeouts or rate limiting responses.
+
+Messages that are already flagged with an error before reaching this processor are not retried.`).
+		Field(service.NewProcessorListField("processors").
+			Description("A list of child processors to execute on each attempt.")).
+		Field(service.NewBackOffField("backoff", true, nil)).
+		Field(service.NewIntField("max_retries").
+			Description("The maximum number of retries before giving up on a message. If set to zero there is no discrete limit, and retries are only bound by the `max_elapsed_time` of the `backoff`.").
+			Default(0)).
+		Field(service.NewStringField("error_pattern").
+			Description("An optional regular expression that errors must match in order to be retried. When empty all errors are retried.").
+			Example(`(?i)timeout|429|503`).
+			Default("")).
+		Example("Retrying HTTP Enrichment", `
+An HTTP request is made for each message in order to enrich it with the response, where requests that fail with a server error are retried up to five times.`, `
+pipeline:
+  processors:
+    - retry:
+        max_retries: 5
+        error_pattern: 'HTTP request returned unexpected response code \(5\d\d\)'
+        backoff:
+          initial_interval: 100ms
+          max_interval: 5s
+        processors:
+          - branch:
+              request_map: 'root.id = this.user_id'
+              processors:
+                - http:
+                    url: http://example.com/users
+                    verb: POST
+              result_map: 'root.user = this'
+`)
+}
+
+func init() {
+	err := service.RegisterProcessor(
+		"retry", retryProcConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.Processor, error) {
+			return newRetryProcFromConfig(conf, mgr)
+		})
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type retryProc struct {
+	children     []*service.OwnedProcessor
+	boff         backoff.ExponentialBackOff
+	maxRetries   int
+	errorPattern *regexp.Regexp
+
+	log *service.Logger
+
+	closeChan chan struct{}
+	closeOnce sync.Once
+}
+
+func newRetryProcFromConfig(conf *service.ParsedConfig, mgr *service.Resources) (*retryProc, error) {
+	r := &retryProc{
+		log:       mgr.Logger(),
+		closeChan: make(chan struct{}),
+	}
+
+	var err error
+	if r.children, err = conf.FieldProcessorList("processors"); err != nil {
+		return nil, err
+	}
+	if len(r.children) == 0 {
+		return nil, errors.New("at least one child processor must be specified")
+	}
+
+	boff, err := conf.FieldBackOff("backoff")
+	if err != nil {
+		return nil, err
+	}
+	r.boff = *boff
+
+	if r.maxRetries, err = conf.FieldInt("max_retries"); err != nil {
+		return nil, err
+	}
+	if r.maxRetries < 0 {
+		return nil, errors.New("max_retries must not be negative")
+	}
+
+	pattern, err := conf.FieldString("error_pattern")
+	if err != nil {
+		return nil, err
+	}
+	if pattern != "" {
+		if r.errorPattern, err = regexp.Compile(pattern); err != nil {
+			return nil, fmt.Errorf("failed to compile error_pattern: %w", err)
+		}
+	}
+	return r, nil
+}
+
+func (r *retryProc) execute(ctx context.Context, msg *service.Message) (service.MessageBatch, error) {
+	batch := service.MessageBatch{msg}
+	for _, proc := range r.children {
+		var nextBatch service.MessageBatch
+		for _, m := range batch {
+			res, err := proc.Process(ctx, m)
+			if err != nil {
+				return nil, err
+			}
+			nextBatch = append(nextBatch, res...)
+		}
+		if batch = nextBatch; len(batch) == 0 {
+			break
+		}
+	}
+	return batch, nil
+}
+
+// retryableErr returns the first error of a batch that should be retried, or
+// nil if there isn't one.
+func (r *retryProc) retryableErr(batch service.MessageBatch) error {
+	for _, m := range batch {
+		err := m.GetError()
+		if err == nil {
+			continue
+		}
+		if r.errorPattern == nil || r.errorPattern.MatchString(err.Error()) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *retryProc) Process(ctx context.Context, msg *service.Message) (service.MessageBatch, error) {
+	if msg.GetError() != nil {
+		return r.execute(ctx, msg)
+	}
+
+	boff := r.boff
+	boff.Reset()
+
+	attempts := 0
+	for {
+		attempts++
+		batch, err := r.execute(ctx, msg.Copy())
+		if err != nil {
+			return nil, err
+		}
+
+		wait := backoff.Stop
+		if rErr := r.retryableErr(batch); rErr != nil && (r.maxRetries == 0 || attempts <= r.maxRetries) {
+			if wait = boff.NextBackOff(); wait != backoff.Stop {
+				r.log.Debugf("Retrying child processors after attempt %v failed: %v", attempts, rErr)
+			}
+		}
+		if wait == backoff.Stop {
+			for _, m := range batch {
+				m.MetaSet("retry_attempts", strconv.Itoa(attempts))
+			}
+			return batch, nil
+		}
+
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-r.closeChan:
+			return nil, errors.New("processor closed")
+		}
+	}
+}
+
+func (r *retryProc) Close(ctx context.Context) error {
+	r.closeOnce.Do(func() {
+		close(r.closeChan)
+	})
+	for _, proc := range r.children {
+		if err := proc.Close(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package pure
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func newRetryProcForTest(t *testing.T, conf string) *retryProc {
+	t.Helper()
+
+	pConf, err := retryProcConfig().ParseYAML(conf, nil)
+	require.NoError(t, err)
+
+	proc, err := newRetryProcFromConfig(pConf, service.MockResources())
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = proc.Close(context.Background())
+	})
+	return proc
+}
+
+func TestRetryProcSucceedsEventually(t *testing.T) {
+	proc := newRetryProcForTest(t, `
+backoff:
+  initial_interval: 1ms
+  max_interval: 1ms
+processors:
+  - bloblang: |
+      root = if count("retry_proc_eventually") < 3 { throw("nope") } else { content().uppercase() }
+`)
+
+	msg := service.NewMessage([]byte("hello world"))
+	batch, err := proc.Process(context.Background(), msg)
+	require.NoError(t, err)
+	require.Len(t, batch, 1)
+
+	require.NoError(t, batch[0].GetError())
+	mBytes, err := batch[0].AsBytes()
+	require.NoError(t, err)
+	assert.Equal(t, "HELLO WORLD", string(mBytes))
+
+	attempts, _ := batch[0].MetaGet("retry_attempts")
+	assert.Equal(t, "3", attempts)
+
+	// The original message is never modified by attempts
+	mBytes, err = msg.AsBytes()
+	require.NoError(t, err)
+	assert.Equal(t, "hello world", string(mBytes))
+}
+
+func TestRetryProcMaxRetries(t *testing.T) {
+	proc := newRetryProcForTest(t, `
+max_retries: 2
+backoff:
+  initial_interval: 1ms
+  max_interval: 1ms
+processors:
+  - bloblang: |
+      meta attempt = count("retry_proc_max").string()
+      root = throw("nope")
+`)
+
+	batch, err := proc.Process(context.Background(), service.NewMessage([]byte("hello world")))
+	require.NoError(t, err)
+	require.Len(t, batch, 1)
+
+	require.Error(t, batch[0].GetError())
+	attempts, _ := batch[0].MetaGet("retry_attempts")
+	assert.Equal(t, "3", attempts)
+}
+
+func TestRetryProcErrorPattern(t *testing.T) {
+	proc := newRetryProcForTest(t, `
+error_pattern: transient
+backoff:
+  initial_interval: 1ms
+  max_interval: 1ms
+processors:
+  - bloblang: |
+      root = match count("retry_proc_pattern") {
+        1 => throw("a transient failure")
+        _ => throw("a permanent failure")
+      }
+`)
+
+	batch, err := proc.Process(context.Background(), service.NewMessage([]byte("hello world")))
+	require.NoError(t, err)
+	require.Len(t, batch, 1)
+
+	require.Error(t, batch[0].GetError())
+	assert.Contains(t, batch[0].GetError().Error(), "a permanent failure")
+	attempts, _ := batch[0].MetaGet("retry_attempts")
+	assert.Equal(t, "2", attempts)
+}
+
+func TestRetryProcAlreadyErrored(t *testing.T) {
+	proc := newRetryProcForTest(t, `
+backoff:
+  initial_interval: 1ms
+  max_interval: 1ms
+processors:
+  - bloblang: |
+      meta attempt = count("retry_proc_errored").string()
+`)
+
+	msg := service.NewMessage([]byte("hello world"))
+	msg.SetError(assert.AnError)
+
+	batch, err := proc.Process(context.Background(), msg)
+	require.NoError(t, err)
+	require.Len(t, batch, 1)
+
+	attempt, _ := batch[0].MetaGet("attempt")
+	assert.Equal(t, "1", attempt)
+	_, exists := batch[0].MetaGet("retry_attempts")
+	assert.False(t, exists)
+}
@@ -0,0 +1,273 @@
+package pure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	jsonschema "github.com/xeipuuv/gojsonschema"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
+	"github.com/benthosdev/benthos/v4/public/bloblang"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const (
+	schemaEvolutionOnFailureReject = "reject"
+	schemaEvolutionOnFailureDrop   = "drop"
+)
+
+func schemaEvolutionProcConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		Beta().
+		Categories("Mapping").
+		Version("4.1.0").
+		Summary("Upgrades documents to the latest version of a schema by applying a ladder of Bloblang mappings, one for each version step, validating documents against the JSON schema of each version along the way.").
+		Description(`
+The version of each document is obtained by executing the `+"`version_mapping`"+`, and must match one of the versions listed in `+"`versions`"+`, which are ordered from oldest to newest. A document is first validated against the schema of its version, and then the `+"`upgrade`"+` mapping of each subsequent version is executed in order, where after each upgrade the document is validated against the schema of the version it was upgraded to. Documents of the latest version are only validated.
+
+Upgrade mappings begin with `+"`root`"+` set to the document as it was before the upgrade, in the same way as the `+"[`mutation` processor](/docs/components/processors/mutation)"+`, and are therefore only required to modify the fields that changed between versions. An upgrade mapping should also update the field that the `+"`version_mapping`"+` reads from. If an upgrade mapping deletes the document it is removed from the pipeline.
+
+Upgraded documents have the metadata field `+"`schema_version`"+` set to the latest version.
+
+## Failures
+
+A document fails when its version cannot be determined or is not listed in `+"`versions`"+`, when it does not satisfy the schema of a version, or when an upgrade mapping fails. The field `+"`on_failure`"+` determines what happens to failed documents:
+
+- `+"`reject`"+`: The document is left exactly as it was before reaching this processor and is flagged as having failed, allowing you to quarantine it with [standard error handling patterns](/docs/configuration/error_handling).
+- `+"`drop`"+`: The document is logged and removed from the pipeline.`).
+		Field(service.NewBloblangField("version_mapping").
+			Description("A [Bloblang mapping](/docs/guides/bloblang/about) that provides the version of a document as an integer.").
+			Default("root = this.version").
+			Example(`root = meta("schema_version").number()`)).
+		Field(service.NewObjectListField("versions",
+			service.NewIntField("version").
+				Description("The version number."),
+			service.NewStringField("schema").
+				Description("An optional JSON schema that documents of this version must satisfy.").
+				Optional(),
+			service.NewBloblangField("upgrade").
+				Description("A [Bloblang mapping](/docs/guides/bloblang/about) that upgrades a document of the previous version to this version, which is required for all but the first version.").
+				Optional(),
+		).Description("The ladder of schema versions, ordered from oldest to newest.")).
+		Field(service.NewStringEnumField("on_failure", schemaEvolutionOnFailureReject, schemaEvolutionOnFailureDrop).
+			Description("What to do with documents that cannot be validated or upgraded.").
+			Default(schemaEvolutionOnFailureReject)).
+		Example("Upgrading User Records", `
+User records originally contained separate first and last names, which were merged into a single field in version two, and then version three introduced a mandatory email field that defaults to an empty string. Records that can't be upgraded are written to a quarantine file.`, `
+pipeline:
+  processors:
+    - schema_evolution:
+        versions:
+          - version: 1
+          - version: 2
+            upgrade: |
+              root.name = this.first_name + " " + this.last_name
+              root.first_name = deleted()
+              root.last_name = deleted()
+              root.version = 2
+          - version: 3
+            schema: |
+              {
+                "type": "object",
+                "required": [ "name", "email" ]
+              }
+            upgrade: |
+              root.email = this.email | ""
+              root.version = 3
+
+output:
+  switch:
+    cases:
+      - check: errored()
+        output:
+          file:
+            path: ./quarantine.jsonl
+            codec: lines
+      - output:
+          stdout: {}
+`)
+}
+
+func init() {
+	err := service.RegisterProcessor(
+		"schema_evolution", schemaEvolutionProcConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.Processor, error) {
+			return newSchemaEvolutionProcFromConfig(conf, mgr)
+		})
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type schemaVersion struct {
+	version int
+	schema  *jsonschema.Schema
+	upgrade *bloblang.Executor
+}
+
+type schemaEvolutionProc struct {
+	versionMapping *bloblang.Executor
+	versions       []schemaVersion
+	drop           bool
+	log            *service.Logger
+}
+
+func newSchemaEvolutionProcFromConfig(conf *service.ParsedConfig, mgr *service.Resources) (*schemaEvolutionProc, error) {
+	s := &schemaEvolutionProc{
+		log: mgr.Logger(),
+	}
+
+	var err error
+	if s.versionMapping, err = conf.FieldBloblang("version_mapping"); err != nil {
+		return nil, err
+	}
+
+	versionConfs, err := conf.FieldObjectList("versions")
+	if err != nil {
+		return nil, err
+	}
+	if len(versionConfs) == 0 {
+		return nil, errors.New("at least one version must be specified")
+	}
+	for i, vConf := range versionConfs {
+		var v schemaVersion
+		if v.version, err = vConf.FieldInt("version"); err != nil {
+			return nil, err
+		}
+		if i > 0 && v.version <= s.versions[i-1].version {
+			return nil, fmt.Errorf("versions must be in ascending order, version %v follows version %v", v.version, s.versions[i-1].version)
+		}
+		if vConf.Contains("schema") {
+			schemaStr, err := vConf.FieldString("schema")
+			if err != nil {
+				return nil, err
+			}
+			if v.schema, err = jsonschema.NewSchema(jsonschema.NewStringLoader(schemaStr)); err != nil {
+				return nil, fmt.Errorf("failed to parse schema of version %v: %w", v.version, err)
+			}
+		}
+		if vConf.Contains("upgrade") {
+			if i == 0 {
+				return nil, fmt.Errorf("the first version %v cannot have an upgrade mapping", v.version)
+			}
+			if v.upgrade, err = vConf.FieldBloblang("upgrade"); err != nil {
+				return nil, err
+			}
+		} else if i > 0 {
+			return nil, fmt.Errorf("version %v requires an upgrade mapping", v.version)
+		}
+		s.versions = append(s.versions, v)
+	}
+
+	onFailure, err := conf.FieldString("on_failure")
+	if err != nil {
+		return nil, err
+	}
+	s.drop = onFailure == schemaEvolutionOnFailureDrop
+	return s, nil
+}
+
+func (s *schemaEvolutionProc) versionIndex(msg *service.Message) (int, error) {
+	res, err := msg.BloblangQuery(s.versionMapping)
+	if err != nil {
+		return 0, fmt.Errorf("version mapping failed: %w", err)
+	}
+	if res == nil {
+		return 0, errors.New("version mapping resulted in a deleted message")
+	}
+	v, err := res.AsStructured()
+	if err != nil {
+		return 0, fmt.Errorf("version mapping result could not be parsed: %w", err)
+	}
+	version, err := query.IGetInt(v)
+	if err != nil {
+		return 0, fmt.Errorf("version mapping result was not an integer: %w", err)
+	}
+	for i, sv := range s.versions {
+		if int64(sv.version) == version {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("document version %v is not recognised", version)
+}
+
+func (s *schemaEvolutionProc) validate(msg *service.Message, v schemaVersion) error {
+	if v.schema == nil {
+		return nil
+	}
+	doc, err := msg.AsStructured()
+	if err != nil {
+		return fmt.Errorf("failed to parse document: %w", err)
+	}
+	result, err := v.schema.Validate(jsonschema.NewGoLoader(doc))
+	if err != nil {
+		return err
+	}
+	if !result.Valid() {
+		errStrs := make([]string, 0, len(result.Errors()))
+		for _, desc := range result.Errors() {
+			description := strings.ToLower(desc.Description())
+			if property := desc.Details()["property"]; property != nil {
+				description = property.(string) + strings.TrimPrefix(description, strings.ToLower(property.(string)))
+			}
+			errStrs = append(errStrs, desc.Field()+" "+description)
+		}
+		return fmt.Errorf("document does not satisfy the schema of version %v: %v", v.version, strings.Join(errStrs, ", "))
+	}
+	return nil
+}
+
+// evolve returns an upgraded copy of a message, or nil if an upgrade mapping
+// deleted it.
+func (s *schemaEvolutionProc) evolve(msg *service.Message) (*service.Message, error) {
+	index, err := s.versionIndex(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	res := msg.Copy()
+	if err := s.validate(res, s.versions[index]); err != nil {
+		return nil, err
+	}
+	for _, v := range s.versions[index+1:] {
+		if res, err = res.BloblangMutate(v.upgrade); err != nil {
+			return nil, fmt.Errorf("failed to upgrade document to version %v: %w", v.version, err)
+		}
+		if res == nil {
+			return nil, nil
+		}
+		if err := s.validate(res, v); err != nil {
+			return nil, err
+		}
+	}
+
+	res.MetaSet("schema_version", strconv.Itoa(s.versions[len(s.versions)-1].version))
+	return res, nil
+}
+
+func (s *schemaEvolutionProc) Process(ctx context.Context, msg *service.Message) (service.MessageBatch, error) {
+	res, err := s.evolve(msg)
+	if err != nil {
+		if s.drop {
+			s.log.Warnf("Dropping document: %v", err)
+			return nil, nil
+		}
+		s.log.Errorf("%v", err)
+		msg.SetError(err)
+		return service.MessageBatch{msg}, nil
+	}
+	if res == nil {
+		return nil, nil
+	}
+	return service.MessageBatch{res}, nil
+}
+
+func (s *schemaEvolutionProc) Close(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,184 @@
+package pure
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const schemaEvolutionTestConfig = `
+versions:
+  - version: 1
+    schema: '{"type":"object","required":["first_name","last_name"]}'
+  - version: 2
+    upgrade: |
+      root.name = this.first_name + " " + this.last_name
+      root.first_name = deleted()
+      root.last_name = deleted()
+      root.version = 2
+  - version: 3
+    schema: '{"type":"object","required":["name","email"]}'
+    upgrade: |
+      root.email = this.email | deleted()
+      root.version = 3
+`
+
+func TestSchemaEvolutionConfigs(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		errStr string
+	}{
+		{
+			name:   "no versions",
+			config: `versions: []`,
+			errStr: "at least one version must be specified",
+		},
+		{
+			name: "unordered versions",
+			config: `
+versions:
+  - version: 2
+  - version: 1
+    upgrade: root = this`,
+			errStr: "versions must be in ascending order, version 1 follows version 2",
+		},
+		{
+			name: "first version upgrade",
+			config: `
+versions:
+  - version: 1
+    upgrade: root = this`,
+			errStr: "the first version 1 cannot have an upgrade mapping",
+		},
+		{
+			name: "missing upgrade",
+			config: `
+versions:
+  - version: 1
+  - version: 2`,
+			errStr: "version 2 requires an upgrade mapping",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			pConf, err := schemaEvolutionProcConfig().ParseYAML(test.config, nil)
+			require.NoError(t, err)
+
+			_, err = newSchemaEvolutionProcFromConfig(pConf, service.MockResources())
+			require.EqualError(t, err, test.errStr)
+		})
+	}
+}
+
+func TestSchemaEvolution(t *testing.T) {
+	pConf, err := schemaEvolutionProcConfig().ParseYAML(schemaEvolutionTestConfig, nil)
+	require.NoError(t, err)
+
+	proc, err := newSchemaEvolutionProcFromConfig(pConf, service.MockResources())
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		input   string
+		output  string
+		version string
+		errStr  string
+	}{
+		{
+			name:    "from first version",
+			input:   `{"version":1,"first_name":"foo","last_name":"bar","email":"foo@example.com"}`,
+			output:  `{"email":"foo@example.com","name":"foo bar","version":3}`,
+			version: "3",
+		},
+		{
+			name:    "from second version",
+			input:   `{"version":2,"name":"foo bar","email":"foo@example.com"}`,
+			output:  `{"email":"foo@example.com","name":"foo bar","version":3}`,
+			version: "3",
+		},
+		{
+			name:    "latest version",
+			input:   `{"version":3,"name":"foo bar","email":"foo@example.com"}`,
+			output:  `{"version":3,"name":"foo bar","email":"foo@example.com"}`,
+			version: "3",
+		},
+		{
+			name:   "invalid first version",
+			input:  `{"version":1,"first_name":"foo"}`,
+			output: `{"version":1,"first_name":"foo"}`,
+			errStr: "document does not satisfy the schema of version 1: (root) last_name is required",
+		},
+		{
+			name:   "invalid after upgrade",
+			input:  `{"version":1,"first_name":"foo","last_name":"bar"}`,
+			output: `{"version":1,"first_name":"foo","last_name":"bar"}`,
+			errStr: "document does not satisfy the schema of version 3: (root) email is required",
+		},
+		{
+			name:   "failed upgrade",
+			input:  `{"version":1,"first_name":"foo","last_name":5}`,
+			output: `{"version":1,"first_name":"foo","last_name":5}`,
+			errStr: "failed to upgrade document to version 2: failed assignment (line 1): cannot add types string (from string literal) and number (from field `this.last_name`)",
+		},
+		{
+			name:   "unknown version",
+			input:  `{"version":4}`,
+			output: `{"version":4}`,
+			errStr: "document version 4 is not recognised",
+		},
+		{
+			name:   "missing version",
+			input:  `{"name":"foo"}`,
+			output: `{"name":"foo"}`,
+			errStr: "version mapping result was not an integer: expected number value, got null",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			batch, err := proc.Process(context.Background(), service.NewMessage([]byte(test.input)))
+			require.NoError(t, err)
+			require.Len(t, batch, 1)
+
+			mBytes, err := batch[0].AsBytes()
+			require.NoError(t, err)
+			assert.Equal(t, test.output, string(mBytes))
+
+			version, _ := batch[0].MetaGet("schema_version")
+			assert.Equal(t, test.version, version)
+
+			if test.errStr != "" {
+				require.EqualError(t, batch[0].GetError(), test.errStr)
+			} else {
+				require.NoError(t, batch[0].GetError())
+			}
+		})
+	}
+}
+
+func TestSchemaEvolutionDrop(t *testing.T) {
+	pConf, err := schemaEvolutionProcConfig().ParseYAML(schemaEvolutionTestConfig+`
+on_failure: drop
+`, nil)
+	require.NoError(t, err)
+
+	proc, err := newSchemaEvolutionProcFromConfig(pConf, service.MockResources())
+	require.NoError(t, err)
+
+	batch, err := proc.Process(context.Background(), service.NewMessage([]byte(`{"version":1,"first_name":"foo"}`)))
+	require.NoError(t, err)
+	assert.Empty(t, batch)
+
+	batch, err = proc.Process(context.Background(), service.NewMessage([]byte(`{"version":2,"name":"foo","email":"foo@example.com"}`)))
+	require.NoError(t, err)
+	require.Len(t, batch, 1)
+	require.NoError(t, batch[0].GetError())
+}
@@ -0,0 +1,170 @@
+package pure
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/Jeffail/gabs/v2"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func splitJSONProcConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		Beta().
+		Categories("Utility").
+		Version("4.1.0").
+		Summary("Expands an array or object within each message into a message per element.").
+		Description(`
+The value found at `+"`path`"+` must be an array or an object. Each element of an array results in a message with the metadata field `+"`array_index`"+` set to the index of the element, and each entry of an object results in a message with the metadata field `+"`object_key`"+` set to the key of the entry, where entries are emitted in the order of their keys. An empty array or object results in no messages.
+
+When `+"`keep_parent`"+` is `+"`true`"+`, which is the default, each resulting message is a copy of the original document where the value at `+"`path`"+` is replaced with the element, and therefore retains all other fields of the document. Otherwise each resulting message contains only the element.
+
+All metadata of the original message is carried over to the resulting messages. Messages that cannot be parsed as JSON, or where the value at `+"`path`"+` is neither an array nor an object, are flagged as having failed and are passed on unchanged, allowing you to use [standard processor error handling patterns](/docs/configuration/error_handling).`).
+		Field(service.NewStringField("path").
+			Description("A [dot path](/docs/configuration/field_paths) pointing to the array or object to expand. When empty the root of the document is expanded.").
+			Example("items").
+			Example("order.line_items")).
+		Field(service.NewBoolField("keep_parent").
+			Description("Whether each resulting message should retain the fields of the original document, with the value at `path` replaced by the element.").
+			Default(true)).
+		Example("Unnesting Order Items", `
+Given documents such as `+"`{\"id\":\"foo\",\"items\":[{\"sku\":\"a\"},{\"sku\":\"b\"}]}`"+` we can emit a message for each item that still contains the order ID, resulting in `+"`{\"id\":\"foo\",\"items\":{\"sku\":\"a\"}}`"+` and `+"`{\"id\":\"foo\",\"items\":{\"sku\":\"b\"}}`"+`.`, `
+pipeline:
+  processors:
+    - split_json:
+        path: items
+    - mutation:
+        mapping: |
+          root.item = this.items
+          root.item.position = meta("array_index").number()
+          root.items = deleted()
+`)
+}
+
+func init() {
+	err := service.RegisterProcessor(
+		"split_json", splitJSONProcConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.Processor, error) {
+			return newSplitJSONProcFromConfig(conf)
+		})
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type splitJSONProc struct {
+	path       []string
+	keepParent bool
+}
+
+func newSplitJSONProcFromConfig(conf *service.ParsedConfig) (*splitJSONProc, error) {
+	s := &splitJSONProc{}
+
+	pathStr, err := conf.FieldString("path")
+	if err != nil {
+		return nil, err
+	}
+	if pathStr != "" {
+		s.path = gabs.DotPathToSlice(pathStr)
+	}
+	if s.keepParent, err = conf.FieldBool("keep_parent"); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// cloneWithValueAt returns a deep copy of root where the value at a path is
+// replaced with v.
+func cloneWithValueAt(root interface{}, path []string, v interface{}) interface{} {
+	if len(path) == 0 {
+		return query.IClone(v)
+	}
+	switch t := root.(type) {
+	case map[string]interface{}:
+		newMap := make(map[string]interface{}, len(t))
+		for k, c := range t {
+			if k == path[0] {
+				newMap[k] = cloneWithValueAt(c, path[1:], v)
+			} else {
+				newMap[k] = query.IClone(c)
+			}
+		}
+		return newMap
+	case []interface{}:
+		index, _ := strconv.Atoi(path[0])
+		newSlice := make([]interface{}, len(t))
+		for i, c := range t {
+			if i == index {
+				newSlice[i] = cloneWithValueAt(c, path[1:], v)
+			} else {
+				newSlice[i] = query.IClone(c)
+			}
+		}
+		return newSlice
+	}
+	return query.IClone(root)
+}
+
+func (s *splitJSONProc) newPart(msg *service.Message, root, v interface{}) *service.Message {
+	part := msg.Copy()
+	if s.keepParent {
+		part.SetStructured(cloneWithValueAt(root, s.path, v))
+	} else {
+		part.SetStructured(query.IClone(v))
+	}
+	return part
+}
+
+func (s *splitJSONProc) Process(ctx context.Context, msg *service.Message) (service.MessageBatch, error) {
+	root, err := msg.AsStructured()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse message as JSON: %w", err)
+	}
+
+	target := root
+	if len(s.path) > 0 {
+		if target = gabs.Wrap(root).Search(s.path...).Data(); target == nil {
+			return nil, fmt.Errorf("path %v not found", query.SliceToDotPath(s.path...))
+		}
+	}
+
+	switch t := target.(type) {
+	case []interface{}:
+		batch := make(service.MessageBatch, 0, len(t))
+		for i, v := range t {
+			part := s.newPart(msg, root, v)
+			part.MetaSet("array_index", strconv.Itoa(i))
+			batch = append(batch, part)
+		}
+		return batch, nil
+	case map[string]interface{}:
+		keys := make([]string, 0, len(t))
+		for k := range t {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		batch := make(service.MessageBatch, 0, len(t))
+		for _, k := range keys {
+			part := s.newPart(msg, root, t[k])
+			part.MetaSet("object_key", k)
+			batch = append(batch, part)
+		}
+		return batch, nil
+	}
+	if len(s.path) == 0 {
+		return nil, fmt.Errorf("expected array or object value, found: %v", query.ITypeOf(target))
+	}
+	return nil, fmt.Errorf("expected array or object value at path %v, found: %v", query.SliceToDotPath(s.path...), query.ITypeOf(target))
+}
+
+func (s *splitJSONProc) Close(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,139 @@
+package pure
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func TestSplitJSONProc(t *testing.T) {
+	tests := []struct {
+		name     string
+		conf     string
+		input    string
+		output   []string
+		metaKey  string
+		metaVals []string
+		err      string
+	}{
+		{
+			name:     "array keep parent",
+			conf:     `path: items`,
+			input:    `{"id":"foo","items":[{"sku":"a"},{"sku":"b"}]}`,
+			output:   []string{`{"id":"foo","items":{"sku":"a"}}`, `{"id":"foo","items":{"sku":"b"}}`},
+			metaKey:  "array_index",
+			metaVals: []string{"0", "1"},
+		},
+		{
+			name:     "nested array without parent",
+			conf:     "path: order.items\nkeep_parent: false",
+			input:    `{"order":{"items":[1,2,3]}}`,
+			output:   []string{`1`, `2`, `3`},
+			metaKey:  "array_index",
+			metaVals: []string{"0", "1", "2"},
+		},
+		{
+			name:     "object keep parent",
+			conf:     `path: doc.tags`,
+			input:    `{"doc":{"id":1,"tags":{"b":"second","a":"first"}}}`,
+			output:   []string{`{"doc":{"id":1,"tags":"first"}}`, `{"doc":{"id":1,"tags":"second"}}`},
+			metaKey:  "object_key",
+			metaVals: []string{"a", "b"},
+		},
+		{
+			name:     "root array",
+			conf:     `path: ""`,
+			input:    `[{"a":1},{"b":2}]`,
+			output:   []string{`{"a":1}`, `{"b":2}`},
+			metaKey:  "array_index",
+			metaVals: []string{"0", "1"},
+		},
+		{
+			name:   "empty array",
+			conf:   `path: items`,
+			input:  `{"items":[]}`,
+			output: []string{},
+		},
+		{
+			name:  "missing path",
+			conf:  `path: items`,
+			input: `{"nope":[]}`,
+			err:   "path items not found",
+		},
+		{
+			name:  "wrong type",
+			conf:  `path: items`,
+			input: `{"items":"foo"}`,
+			err:   "expected array or object value at path items, found: string",
+		},
+		{
+			name:  "not json",
+			conf:  `path: items`,
+			input: `not json`,
+			err:   "failed to parse message as JSON: invalid character 'o' in literal null (expecting 'u')",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			pConf, err := splitJSONProcConfig().ParseYAML(test.conf, nil)
+			require.NoError(t, err)
+
+			proc, err := newSplitJSONProcFromConfig(pConf)
+			require.NoError(t, err)
+
+			msg := service.NewMessage([]byte(test.input))
+			msg.MetaSet("source", "test")
+
+			batch, err := proc.Process(context.Background(), msg)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, batch, len(test.output))
+
+			for i, m := range batch {
+				mBytes, err := m.AsBytes()
+				require.NoError(t, err)
+				assert.Equal(t, test.output[i], string(mBytes))
+
+				v, _ := m.MetaGet(test.metaKey)
+				assert.Equal(t, test.metaVals[i], v)
+				v, _ = m.MetaGet("source")
+				assert.Equal(t, "test", v)
+			}
+		})
+	}
+}
+
+func TestSplitJSONProcIsolatesMessages(t *testing.T) {
+	pConf, err := splitJSONProcConfig().ParseYAML(`path: items`, nil)
+	require.NoError(t, err)
+
+	proc, err := newSplitJSONProcFromConfig(pConf)
+	require.NoError(t, err)
+
+	msg := service.NewMessage([]byte(`{"meta":{"count":1},"items":[{"sku":"a"},{"sku":"b"}]}`))
+	batch, err := proc.Process(context.Background(), msg)
+	require.NoError(t, err)
+	require.Len(t, batch, 2)
+
+	v, err := batch[0].AsStructuredMut()
+	require.NoError(t, err)
+	v.(map[string]interface{})["meta"].(map[string]interface{})["count"] = 2
+	v.(map[string]interface{})["items"].(map[string]interface{})["sku"] = "c"
+
+	mBytes, err := batch[1].AsBytes()
+	require.NoError(t, err)
+	assert.Equal(t, `{"items":{"sku":"b"},"meta":{"count":1}}`, string(mBytes))
+
+	mBytes, err = msg.AsBytes()
+	require.NoError(t, err)
+	assert.Equal(t, `{"meta":{"count":1},"items":[{"sku":"a"},{"sku":"b"}]}`, string(mBytes))
+}
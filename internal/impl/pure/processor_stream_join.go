@@ -0,0 +1,320 @@
+package pure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/gabs/v2"
+
+	"github.com/benthosdev/benthos/v4/public/bloblang"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func streamJoinProcConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		Beta().
+		Categories("Composition").
+		Version("4.1.0").
+		Summary("Joins the messages of two streams that share a key, by buffering the messages of one stream within a cache and enriching the messages of the other stream that arrive within a TTL.").
+		Description(`
+This processor is intended for pipelines that consume from two streams, usually by combining inputs with a `+"[`broker`](/docs/components/inputs/broker)"+`, where the `+"`buffer_check`"+` mapping identifies which stream each message belongs to.
+
+Messages for which `+"`buffer_check`"+` resolves `+"`true`"+` are stored within the `+"`cache`"+` resource under their `+"`key`"+` for the duration of the `+"`ttl`"+` and are removed from the pipeline, where a buffered message replaces any previously buffered message of the same key. All other messages are enriched with the buffered message of their key, if one exists, and are then passed on with the metadata field `+"`join_status`"+` set to `+"`matched`"+`, or `+"`unmatched`"+` if no buffered message was found.
+
+When `+"`target_path`"+` is set the buffered message is placed at that path within the enriched message, otherwise both messages must be objects and the fields of the buffered message are added to the enriched message, where fields of the enriched message take precedence. A buffered message remains within the cache until its TTL expires, and can therefore enrich any number of messages.
+
+Since the state of the join lives within a cache resource it is possible to share it across multiple instances of Benthos by using a distributed cache such as `+"[`redis`](/docs/components/caches/redis)"+`.
+
+## Unmatched Buffered Messages
+
+When `+"`emit_unmatched`"+` is `+"`true`"+` buffered messages that expire without having enriched any message are emitted with the metadata field `+"`join_status`"+` set to `+"`expired`"+`, allowing you to route or log them. Since processors only produce messages in response to consuming them, expired messages are emitted along with the next message processed after their expiry.
+
+Buffered messages are also kept in memory for this purpose and are tracked by each instance of the processor separately, and therefore a buffered message matched by another instance sharing the same cache may still be considered unmatched.`).
+		Field(service.NewStringField("cache").
+			Description("The [`cache` resource](/docs/components/caches/about) to store buffered messages within.")).
+		Field(service.NewInterpolatedStringField("key").
+			Description("An interpolated string resolving the key of each message, where messages of both streams with the same key are joined.").
+			Example(`${! json("order_id") }`).
+			Example(`${! meta("kafka_key") }`)).
+		Field(service.NewBloblangField("buffer_check").
+			Description("A [Bloblang query](/docs/guides/bloblang/about) that should return a boolean value indicating whether a message belongs to the stream that is buffered.").
+			Example(`meta("kafka_topic") == "orders"`).
+			Example(`this.type == "payment"`)).
+		Field(service.NewDurationField("ttl").
+			Description("The period of time that buffered messages are available for joining. Caches that do not support per key TTLs may retain buffered messages for longer.").
+			Default("5m")).
+		Field(service.NewStringField("target_path").
+			Description("An optional [dot path](/docs/configuration/field_paths) at which the buffered message is placed within the enriched message. When empty the fields of the buffered message are added to the enriched message.").
+			Example("order").
+			Default("")).
+		Field(service.NewBoolField("emit_unmatched").
+			Description("Whether buffered messages that expire without having been joined should be emitted.").
+			Default(false).
+			Advanced()).
+		Example("Joining Orders With Payments", `
+Orders and payments are consumed from two Kafka topics, where each payment is enriched with its order provided that the order was consumed no longer than an hour earlier.`, `
+input:
+  kafka:
+    addresses: [ TODO ]
+    topics: [ orders, payments ]
+    consumer_group: benthos_join
+
+pipeline:
+  processors:
+    - stream_join:
+        cache: orders
+        key: ${! json("order_id") }
+        buffer_check: meta("kafka_topic") == "orders"
+        ttl: 1h
+        target_path: order
+
+cache_resources:
+  - label: orders
+    memory:
+      default_ttl: 1h
+`)
+}
+
+func init() {
+	err := service.RegisterProcessor(
+		"stream_join", streamJoinProcConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.Processor, error) {
+			return newStreamJoinProcFromConfig(conf, mgr)
+		})
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type bufferedJoinMessage struct {
+	msg     *service.Message
+	expires time.Time
+}
+
+type streamJoinProc struct {
+	mgr           cacheProvider
+	cacheName     string
+	key           *service.InterpolatedString
+	check         *bloblang.Executor
+	ttl           time.Duration
+	targetPath    []string
+	emitUnmatched bool
+	now           func() time.Time
+	log           *service.Logger
+
+	unmatched    map[string]bufferedJoinMessage
+	unmatchedMut sync.Mutex
+}
+
+func newStreamJoinProcFromConfig(conf *service.ParsedConfig, mgr *service.Resources) (*streamJoinProc, error) {
+	cacheName, err := conf.FieldString("cache")
+	if err != nil {
+		return nil, err
+	}
+	if !mgr.HasCache(cacheName) {
+		return nil, fmt.Errorf("cache resource '%v' was not found", cacheName)
+	}
+	return newStreamJoinProcFromParsed(conf, cacheName, mgr, mgr.Logger())
+}
+
+func newStreamJoinProcFromParsed(conf *service.ParsedConfig, cacheName string, mgr cacheProvider, log *service.Logger) (*streamJoinProc, error) {
+	j := &streamJoinProc{
+		mgr:       mgr,
+		log:       log,
+		cacheName: cacheName,
+		now:       time.Now,
+		unmatched: map[string]bufferedJoinMessage{},
+	}
+
+	var err error
+	if j.key, err = conf.FieldInterpolatedString("key"); err != nil {
+		return nil, err
+	}
+	if j.check, err = conf.FieldBloblang("buffer_check"); err != nil {
+		return nil, err
+	}
+	if j.ttl, err = conf.FieldDuration("ttl"); err != nil {
+		return nil, err
+	}
+	if j.ttl <= 0 {
+		return nil, errors.New("ttl must be greater than zero")
+	}
+
+	targetPath, err := conf.FieldString("target_path")
+	if err != nil {
+		return nil, err
+	}
+	if targetPath != "" {
+		j.targetPath = gabs.DotPathToSlice(targetPath)
+	}
+	if j.emitUnmatched, err = conf.FieldBool("emit_unmatched"); err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+func (j *streamJoinProc) isBuffered(msg *service.Message) (bool, error) {
+	res, err := msg.BloblangQuery(j.check)
+	if err != nil {
+		return false, fmt.Errorf("buffer_check mapping failed: %w", err)
+	}
+	if res == nil {
+		return false, errors.New("buffer_check mapping resulted in a deleted message")
+	}
+	if v, err := res.AsStructured(); err == nil {
+		if b, ok := v.(bool); ok {
+			return b, nil
+		}
+	}
+	resBytes, _ := res.AsBytes()
+	return false, fmt.Errorf("expected boolean result from buffer_check mapping, got: %s", resBytes)
+}
+
+// popExpired removes and returns buffered messages that have expired without
+// being joined, ordered by their expiry.
+func (j *streamJoinProc) popExpired() service.MessageBatch {
+	j.unmatchedMut.Lock()
+	defer j.unmatchedMut.Unlock()
+
+	now := j.now()
+
+	var expired []bufferedJoinMessage
+	for k, b := range j.unmatched {
+		if !now.Before(b.expires) {
+			expired = append(expired, b)
+			delete(j.unmatched, k)
+		}
+	}
+	sort.Slice(expired, func(i, k int) bool {
+		return expired[i].expires.Before(expired[k].expires)
+	})
+
+	batch := make(service.MessageBatch, 0, len(expired))
+	for _, b := range expired {
+		b.msg.MetaSet("join_status", "expired")
+		batch = append(batch, b.msg)
+	}
+	return batch
+}
+
+func (j *streamJoinProc) store(ctx context.Context, key string, msg *service.Message) error {
+	msgBytes, err := msg.AsBytes()
+	if err != nil {
+		return err
+	}
+
+	var cerr error
+	if err = j.mgr.AccessCache(ctx, j.cacheName, func(c service.Cache) {
+		cerr = c.Set(ctx, key, msgBytes, &j.ttl)
+	}); err != nil {
+		return err
+	}
+	if cerr != nil {
+		return fmt.Errorf("failed to store buffered message: %w", cerr)
+	}
+
+	if j.emitUnmatched {
+		j.unmatchedMut.Lock()
+		j.unmatched[key] = bufferedJoinMessage{
+			msg:     msg.Copy(),
+			expires: j.now().Add(j.ttl),
+		}
+		j.unmatchedMut.Unlock()
+	}
+	return nil
+}
+
+func (j *streamJoinProc) join(ctx context.Context, key string, msg *service.Message) error {
+	var bufferedBytes []byte
+	var cerr error
+	if err := j.mgr.AccessCache(ctx, j.cacheName, func(c service.Cache) {
+		bufferedBytes, cerr = c.Get(ctx, key)
+	}); err != nil {
+		return err
+	}
+	if errors.Is(cerr, service.ErrKeyNotFound) {
+		msg.MetaSet("join_status", "unmatched")
+		return nil
+	}
+	if cerr != nil {
+		return fmt.Errorf("failed to obtain buffered message: %w", cerr)
+	}
+
+	buffered, err := service.NewMessage(bufferedBytes).AsStructured()
+	if err != nil {
+		return fmt.Errorf("failed to parse buffered message: %w", err)
+	}
+	root, err := msg.AsStructuredMut()
+	if err != nil {
+		return fmt.Errorf("failed to parse message: %w", err)
+	}
+
+	if len(j.targetPath) > 0 {
+		gObj := gabs.Wrap(root)
+		if _, err := gObj.Set(buffered, j.targetPath...); err != nil {
+			return fmt.Errorf("failed to set buffered message at target path: %w", err)
+		}
+		root = gObj.Data()
+	} else {
+		rootObj, ok := root.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("expected message to be an object, got: %T", root)
+		}
+		bufferedObj, ok := buffered.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("expected buffered message to be an object, got: %T", buffered)
+		}
+		for k, v := range bufferedObj {
+			if _, exists := rootObj[k]; !exists {
+				rootObj[k] = v
+			}
+		}
+	}
+	msg.SetStructured(root)
+	msg.MetaSet("join_status", "matched")
+
+	if j.emitUnmatched {
+		j.unmatchedMut.Lock()
+		delete(j.unmatched, key)
+		j.unmatchedMut.Unlock()
+	}
+	return nil
+}
+
+func (j *streamJoinProc) Process(ctx context.Context, msg *service.Message) (service.MessageBatch, error) {
+	var batch service.MessageBatch
+	if j.emitUnmatched {
+		batch = j.popExpired()
+	}
+
+	key := j.key.String(msg)
+
+	buffer, err := j.isBuffered(msg)
+	if err == nil {
+		if buffer {
+			err = j.store(ctx, key, msg)
+		} else {
+			err = j.join(ctx, key, msg)
+		}
+	}
+	if err != nil {
+		j.log.Errorf("Failed to join message: %v", err)
+		msg.SetError(err)
+		return append(batch, msg), nil
+	}
+	if buffer {
+		return batch, nil
+	}
+	return append(batch, msg), nil
+}
+
+func (j *streamJoinProc) Close(ctx context.Context) error {
+	return nil
+}
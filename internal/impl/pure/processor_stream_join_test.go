@@ -0,0 +1,160 @@
+package pure
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func newStreamJoinProcForTest(t *testing.T, conf string) *streamJoinProc {
+	t.Helper()
+
+	pConf, err := streamJoinProcConfig().ParseYAML(conf, nil)
+	require.NoError(t, err)
+
+	p := &mockCacheProv{
+		caches: map[string]service.Cache{
+			"foo": newMemCache(time.Minute, 0, 1, nil),
+		},
+	}
+
+	proc, err := newStreamJoinProcFromParsed(pConf, "foo", p, nil)
+	require.NoError(t, err)
+	return proc
+}
+
+type streamJoinTestResult struct {
+	content string
+	status  string
+	err     string
+}
+
+func processStreamJoinForTest(t *testing.T, proc *streamJoinProc, content string) []streamJoinTestResult {
+	t.Helper()
+
+	batch, err := proc.Process(context.Background(), service.NewMessage([]byte(content)))
+	require.NoError(t, err)
+
+	results := []streamJoinTestResult{}
+	for _, m := range batch {
+		var res streamJoinTestResult
+		mBytes, err := m.AsBytes()
+		require.NoError(t, err)
+		res.content = string(mBytes)
+		res.status, _ = m.MetaGet("join_status")
+		if err := m.GetError(); err != nil {
+			res.err = err.Error()
+		}
+		results = append(results, res)
+	}
+	return results
+}
+
+func TestStreamJoinTargetPath(t *testing.T) {
+	proc := newStreamJoinProcForTest(t, `
+cache: foo
+key: ${! json("order_id") }
+buffer_check: this.type == "order"
+target_path: order
+`)
+
+	assert.Equal(t, []streamJoinTestResult{
+		{content: `{"order_id":"1","type":"payment"}`, status: "unmatched"},
+	}, processStreamJoinForTest(t, proc, `{"order_id":"1","type":"payment"}`))
+
+	assert.Equal(t, []streamJoinTestResult{}, processStreamJoinForTest(t, proc, `{"order_id":"1","type":"order","total":10}`))
+
+	assert.Equal(t, []streamJoinTestResult{
+		{content: `{"order":{"order_id":"1","total":10,"type":"order"},"order_id":"1","type":"payment"}`, status: "matched"},
+	}, processStreamJoinForTest(t, proc, `{"order_id":"1","type":"payment"}`))
+
+	// Buffered messages can enrich any number of messages.
+	assert.Equal(t, []streamJoinTestResult{
+		{content: `{"amount":3,"order":{"order_id":"1","total":10,"type":"order"},"order_id":"1","type":"payment"}`, status: "matched"},
+	}, processStreamJoinForTest(t, proc, `{"order_id":"1","type":"payment","amount":3}`))
+
+	assert.Equal(t, []streamJoinTestResult{
+		{content: `{"order_id":"2","type":"payment"}`, status: "unmatched"},
+	}, processStreamJoinForTest(t, proc, `{"order_id":"2","type":"payment"}`))
+}
+
+func TestStreamJoinMerge(t *testing.T) {
+	proc := newStreamJoinProcForTest(t, `
+cache: foo
+key: ${! json("id") }
+buffer_check: meta("stream") == "users"
+`)
+
+	msg := service.NewMessage([]byte(`{"id":"a","name":"alice","source":"users"}`))
+	msg.MetaSet("stream", "users")
+	batch, err := proc.Process(context.Background(), msg)
+	require.NoError(t, err)
+	assert.Empty(t, batch)
+
+	assert.Equal(t, []streamJoinTestResult{
+		{content: `{"id":"a","name":"alice","score":5,"source":"scores"}`, status: "matched"},
+	}, processStreamJoinForTest(t, proc, `{"id":"a","score":5,"source":"scores"}`))
+
+	msg = service.NewMessage([]byte(`["not","an","object"]`))
+	msg.MetaSet("stream", "users")
+	batch, err = proc.Process(context.Background(), msg)
+	require.NoError(t, err)
+	assert.Empty(t, batch)
+
+	assert.Equal(t, []streamJoinTestResult{
+		{content: `{"id":null}`, err: "expected buffered message to be an object, got: []interface {}"},
+	}, processStreamJoinForTest(t, proc, `{"id":null}`))
+}
+
+func TestStreamJoinCheckErrors(t *testing.T) {
+	proc := newStreamJoinProcForTest(t, `
+cache: foo
+key: ${! json("id") }
+buffer_check: this.type
+`)
+
+	assert.Equal(t, []streamJoinTestResult{
+		{content: `{"id":"a","type":"nope"}`, err: "expected boolean result from buffer_check mapping, got: nope"},
+	}, processStreamJoinForTest(t, proc, `{"id":"a","type":"nope"}`))
+}
+
+func TestStreamJoinEmitUnmatched(t *testing.T) {
+	proc := newStreamJoinProcForTest(t, `
+cache: foo
+key: ${! json("id") }
+buffer_check: this.buffer
+ttl: 1m
+emit_unmatched: true
+`)
+
+	now := time.Unix(100, 0)
+	proc.now = func() time.Time {
+		return now
+	}
+
+	assert.Equal(t, []streamJoinTestResult{}, processStreamJoinForTest(t, proc, `{"id":"a","buffer":true}`))
+	assert.Equal(t, []streamJoinTestResult{}, processStreamJoinForTest(t, proc, `{"id":"b","buffer":true}`))
+
+	now = now.Add(time.Second)
+	assert.Equal(t, []streamJoinTestResult{}, processStreamJoinForTest(t, proc, `{"id":"c","buffer":true}`))
+
+	assert.Equal(t, []streamJoinTestResult{
+		{content: `{"buffer":false,"id":"b"}`, status: "matched"},
+	}, processStreamJoinForTest(t, proc, `{"id":"b","buffer":false}`))
+
+	now = now.Add(time.Minute)
+	assert.Equal(t, []streamJoinTestResult{
+		{content: `{"id":"a","buffer":true}`, status: "expired"},
+		{content: `{"id":"c","buffer":true}`, status: "expired"},
+		{content: `{"id":"d","buffer":false}`, status: "unmatched"},
+	}, processStreamJoinForTest(t, proc, `{"id":"d","buffer":false}`))
+
+	assert.Equal(t, []streamJoinTestResult{
+		{content: `{"id":"e","buffer":false}`, status: "unmatched"},
+	}, processStreamJoinForTest(t, proc, `{"id":"e","buffer":false}`))
+}
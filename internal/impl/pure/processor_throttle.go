@@ -0,0 +1,293 @@
+package pure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const (
+	throttleModeBlock    = "block"
+	throttleModeDrop     = "drop"
+	throttleModeOverflow = "overflow"
+)
+
+func throttleProcConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		Beta().
+		Categories("Utility").
+		Version("4.1.0").
+		Summary("Shapes the throughput of a pipeline according to a [`rate_limit`](/docs/components/rate_limits/about) resource, where messages that exceed the limit are either blocked, dropped or routed to an overflow branch.").
+		Description(`
+Unlike the `+"[`rate_limit` processor](/docs/components/processors/rate_limit)"+`, which always blocks until the rate limit allows a message through, this processor supports alternative strategies for handling excess messages, configured with the field `+"`mode`"+`:
+
+- `+"`block`"+` waits until the rate limit allows the message through.
+- `+"`drop`"+` removes the message from the pipeline.
+- `+"`overflow`"+` passes the message through the processors listed in `+"`overflow`"+`, which can be used to tag, reroute or reshape excess messages.
+
+### Keyed Limits
+
+When `+"`key`"+` is set each message is checked against the limit of the key it resolves to, allowing a single rate limit resource to throttle tenants or API keys independently. The `+"[`local`](/docs/components/rate_limits/local)"+` and `+"[`redis`](/docs/components/rate_limits/redis)"+` rate limits support keys, other rate limits apply the same limit to all messages regardless of the key.
+
+### Bursts
+
+It is possible to allow short bursts of messages beyond the rate limit by setting `+"`burst`"+` to a number larger than zero. Each distinct key is allowed up to `+"`burst`"+` messages in excess of the rate limit within each `+"`burst_period`"+`.`).
+		Field(service.NewStringField("resource").
+			Description("The target [`rate_limit` resource](/docs/components/rate_limits/about).")).
+		Field(service.NewStringAnnotatedEnumField("mode", map[string]string{
+			throttleModeBlock:    "Wait until the rate limit allows the message through.",
+			throttleModeDrop:     "Drop messages that exceed the rate limit.",
+			throttleModeOverflow: "Route messages that exceed the rate limit through the `overflow` processors.",
+		}).
+			Description("The strategy to apply to messages that exceed the rate limit.").
+			Default(throttleModeBlock)).
+		Field(service.NewProcessorListField("overflow").
+			Description("A list of processors to apply to messages that exceed the rate limit when `mode` is `overflow`.").
+			Default([]interface{}{})).
+		Field(service.NewInterpolatedStringField("key").
+			Description("An optional key used to partition messages, allowing each distinct key its own limit (when supported by the rate limit) and its own burst allowance.").
+			Example(`${! meta("tenant_id") }`).
+			Default("")).
+		Field(service.NewIntField("burst").
+			Description("The number of messages per key that are allowed to exceed the rate limit within each `burst_period`. Set to zero in order to disable bursts.").
+			Default(0).
+			Advanced()).
+		Field(service.NewDurationField("burst_period").
+			Description("The period of time after which burst allowances are replenished.").
+			Default("1s").
+			Advanced()).
+		Example("Drop Excess Messages", `
+Allow up to 100 messages per second for each tenant, with bursts of up to 20 extra messages per tenant, and drop everything else.`, `
+pipeline:
+  processors:
+    - throttle:
+        resource: foo_limit
+        mode: drop
+        key: ${! meta("tenant_id") }
+        burst: 20
+
+rate_limit_resources:
+  - label: foo_limit
+    local:
+      count: 100
+      interval: 1s
+`).
+		Example("Route Excess Messages", `
+Messages that exceed the rate limit are flagged with metadata so that a `+"[`switch` output](/docs/components/outputs/switch)"+` can route them elsewhere.`, `
+pipeline:
+  processors:
+    - throttle:
+        resource: foo_limit
+        mode: overflow
+        overflow:
+          - bloblang: 'meta throttled = "true"'
+
+output:
+  switch:
+    cases:
+      - check: meta("throttled") == "true"
+        output:
+          file:
+            path: ./overflow.jsonl
+      - output:
+          stdout: {}
+
+rate_limit_resources:
+  - label: foo_limit
+    local:
+      count: 100
+      interval: 1s
+`)
+}
+
+func init() {
+	err := service.RegisterProcessor(
+		"throttle", throttleProcConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.Processor, error) {
+			return newThrottleProcFromConfig(conf, mgr)
+		})
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type throttleBurst struct {
+	used    int
+	resetAt time.Time
+}
+
+type throttleProc struct {
+	rlName      string
+	mode        string
+	overflow    []*service.OwnedProcessor
+	key         *service.InterpolatedString
+	burst       int
+	burstPeriod time.Duration
+
+	burstMut sync.Mutex
+	bursts   map[string]*throttleBurst
+
+	mgr *service.Resources
+
+	closeChan chan struct{}
+	closeOnce sync.Once
+}
+
+func newThrottleProcFromConfig(conf *service.ParsedConfig, mgr *service.Resources) (*throttleProc, error) {
+	t := &throttleProc{
+		mgr:       mgr,
+		bursts:    map[string]*throttleBurst{},
+		closeChan: make(chan struct{}),
+	}
+
+	var err error
+	if t.rlName, err = conf.FieldString("resource"); err != nil {
+		return nil, err
+	}
+	if !mgr.HasRateLimit(t.rlName) {
+		return nil, fmt.Errorf("rate limit resource '%v' was not found", t.rlName)
+	}
+	if t.mode, err = conf.FieldString("mode"); err != nil {
+		return nil, err
+	}
+	switch t.mode {
+	case throttleModeBlock, throttleModeDrop, throttleModeOverflow:
+	default:
+		return nil, fmt.Errorf("mode not recognised: %v", t.mode)
+	}
+	if t.overflow, err = conf.FieldProcessorList("overflow"); err != nil {
+		return nil, err
+	}
+	if t.key, err = conf.FieldInterpolatedString("key"); err != nil {
+		return nil, err
+	}
+	if t.burst, err = conf.FieldInt("burst"); err != nil {
+		return nil, err
+	}
+	if t.burst < 0 {
+		return nil, errors.New("burst must not be negative")
+	}
+	if t.burstPeriod, err = conf.FieldDuration("burst_period"); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// consumeBurst attempts to consume a burst allowance for a given key, returns
+// true if the allowance was available.
+func (t *throttleProc) consumeBurst(key string) bool {
+	if t.burst <= 0 {
+		return false
+	}
+
+	t.burstMut.Lock()
+	defer t.burstMut.Unlock()
+
+	now := time.Now()
+	b, exists := t.bursts[key]
+	if !exists || now.After(b.resetAt) {
+		// Purge expired allowances so that the map doesn't grow unbounded with
+		// keys that are no longer seen.
+		for k, v := range t.bursts {
+			if now.After(v.resetAt) {
+				delete(t.bursts, k)
+			}
+		}
+		b = &throttleBurst{resetAt: now.Add(t.burstPeriod)}
+		t.bursts[key] = b
+	}
+	if b.used >= t.burst {
+		return false
+	}
+	b.used++
+	return true
+}
+
+func (t *throttleProc) access(ctx context.Context, key string) (time.Duration, error) {
+	var waitFor time.Duration
+	var err error
+	if rerr := t.mgr.AccessRateLimit(ctx, t.rlName, func(rl service.RateLimit) {
+		if krl, ok := rl.(service.KeyedRateLimit); ok && key != "" {
+			waitFor, err = krl.AccessKey(ctx, key)
+			return
+		}
+		waitFor, err = rl.Access(ctx)
+	}); rerr != nil {
+		err = rerr
+	}
+	return waitFor, err
+}
+
+func (t *throttleProc) Process(ctx context.Context, msg *service.Message) (service.MessageBatch, error) {
+	key := t.key.String(msg)
+	for {
+		waitFor, err := t.access(ctx, key)
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if err != nil {
+			t.mgr.Logger().Errorf("Failed to access rate limit: %v", err)
+			waitFor = time.Second
+		} else if waitFor == 0 {
+			return service.MessageBatch{msg}, nil
+		}
+
+		if err == nil && t.consumeBurst(key) {
+			return service.MessageBatch{msg}, nil
+		}
+
+		if err == nil {
+			switch t.mode {
+			case throttleModeDrop:
+				return nil, nil
+			case throttleModeOverflow:
+				return t.processOverflow(ctx, msg)
+			}
+		}
+
+		select {
+		case <-time.After(waitFor):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-t.closeChan:
+			return nil, errors.New("processor closed")
+		}
+	}
+}
+
+func (t *throttleProc) processOverflow(ctx context.Context, msg *service.Message) (service.MessageBatch, error) {
+	batch := service.MessageBatch{msg}
+	for _, proc := range t.overflow {
+		var nextBatch service.MessageBatch
+		for _, m := range batch {
+			res, err := proc.Process(ctx, m)
+			if err != nil {
+				return nil, err
+			}
+			nextBatch = append(nextBatch, res...)
+		}
+		if batch = nextBatch; len(batch) == 0 {
+			break
+		}
+	}
+	return batch, nil
+}
+
+func (t *throttleProc) Close(ctx context.Context) error {
+	t.closeOnce.Do(func() {
+		close(t.closeChan)
+	})
+	for _, proc := range t.overflow {
+		if err := proc.Close(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
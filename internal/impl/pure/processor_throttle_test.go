@@ -0,0 +1,191 @@
+package pure
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func runThrottleStream(t *testing.T, conf string) []string {
+	t.Helper()
+
+	b := service.NewStreamBuilder()
+	require.NoError(t, b.SetYAML(conf))
+
+	var outMut sync.Mutex
+	var outMsgs []string
+	require.NoError(t, b.AddConsumerFunc(func(_ context.Context, m *service.Message) error {
+		outMut.Lock()
+		defer outMut.Unlock()
+
+		b, err := m.AsBytes()
+		require.NoError(t, err)
+		outMsgs = append(outMsgs, string(b))
+		return nil
+	}))
+
+	strm, err := b.Build()
+	require.NoError(t, err)
+	require.NoError(t, strm.Run(context.Background()))
+
+	outMut.Lock()
+	defer outMut.Unlock()
+	return outMsgs
+}
+
+func TestThrottleDrop(t *testing.T) {
+	outMsgs := runThrottleStream(t, `
+input:
+  generate:
+    interval: ""
+    count: 10
+    mapping: 'root = "hello world " + count("throttle_drop").string()'
+
+pipeline:
+  threads: 1
+  processors:
+    - throttle:
+        resource: foo
+        mode: drop
+
+rate_limit_resources:
+  - label: foo
+    local:
+      count: 3
+      interval: 1h
+
+logger:
+  level: NONE
+`)
+	assert.Equal(t, []string{
+		"hello world 1",
+		"hello world 2",
+		"hello world 3",
+	}, outMsgs)
+}
+
+func TestThrottleBurst(t *testing.T) {
+	outMsgs := runThrottleStream(t, `
+input:
+  generate:
+    interval: ""
+    count: 10
+    mapping: |
+      root = "hello world " + count("throttle_burst").string()
+      meta tenant = if count("throttle_burst_tenant") % 2 == 0 { "a" } else { "b" }
+
+pipeline:
+  threads: 1
+  processors:
+    - throttle:
+        resource: foo
+        mode: drop
+        key: ${! meta("tenant") }
+        burst: 2
+        burst_period: 1h
+
+rate_limit_resources:
+  - label: foo
+    local:
+      count: 2
+      interval: 1h
+
+logger:
+  level: NONE
+`)
+	assert.Equal(t, []string{
+		"hello world 1",
+		"hello world 2",
+		"hello world 3",
+		"hello world 4",
+		"hello world 5",
+		"hello world 6",
+		"hello world 7",
+		"hello world 8",
+	}, outMsgs)
+}
+
+func TestThrottleKeyedLimit(t *testing.T) {
+	outMsgs := runThrottleStream(t, `
+input:
+  generate:
+    interval: ""
+    count: 10
+    mapping: |
+      root = "hello world " + count("throttle_keyed").string()
+      meta tenant = if count("throttle_keyed_tenant") <= 6 { "a" } else { "b" }
+
+pipeline:
+  threads: 1
+  processors:
+    - throttle:
+        resource: foo
+        mode: drop
+        key: ${! meta("tenant") }
+
+rate_limit_resources:
+  - label: foo
+    local:
+      count: 2
+      interval: 1h
+
+logger:
+  level: NONE
+`)
+	assert.Equal(t, []string{
+		"hello world 1",
+		"hello world 2",
+		"hello world 7",
+		"hello world 8",
+	}, outMsgs)
+}
+
+func TestThrottleOverflow(t *testing.T) {
+	outMsgs := runThrottleStream(t, `
+input:
+  generate:
+    interval: ""
+    count: 4
+    mapping: 'root = "hello world " + count("throttle_overflow").string()'
+
+pipeline:
+  threads: 1
+  processors:
+    - throttle:
+        resource: foo
+        mode: overflow
+        overflow:
+          - bloblang: 'root = content().uppercase()'
+
+rate_limit_resources:
+  - label: foo
+    local:
+      count: 2
+      interval: 1h
+
+logger:
+  level: NONE
+`)
+	assert.Equal(t, []string{
+		"hello world 1",
+		"hello world 2",
+		"HELLO WORLD 3",
+		"HELLO WORLD 4",
+	}, outMsgs)
+}
+
+func TestThrottleMissingResource(t *testing.T) {
+	conf, err := throttleProcConfig().ParseYAML(`
+resource: nope
+`, nil)
+	require.NoError(t, err)
+
+	_, err = newThrottleProcFromConfig(conf, service.MockResources())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not found")
+}
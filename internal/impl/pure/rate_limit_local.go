@@ -1,6 +1,7 @@
 package pure
 
 import (
+	"container/list"
 	"context"
 	"errors"
 	"sync"
@@ -13,12 +14,23 @@ func localRatelimitConfig() *service.ConfigSpec {
 	spec := service.NewConfigSpec().
 		Stable().
 		Summary(`The local rate limit is a simple X every Y type rate limit that can be shared across any number of components within the pipeline but does not support distributed rate limits across multiple running instances of Benthos.`).
+		Description(`
+### Keyed Limits
+
+Components that support it can provide a key when accessing the rate limit, such as the field ` + "`key`" + ` of the ` + "[`throttle` processor](/docs/components/processors/throttle)" + `, in which case each distinct key is given its own limit of ` + "`count`" + ` requests every ` + "`interval`" + `. Requests without a key share a single limit.
+
+In order to prevent unbounded memory usage the limits of at most ` + "`max_keys`" + ` keys are tracked, once exceeded the least recently accessed key is removed and its limit is reset.`).
 		Field(service.NewIntField("count").
 			Description("The maximum number of requests to allow for a given period of time.").
 			Default(1000)).
 		Field(service.NewDurationField("interval").
 			Description("The time window to limit requests by.").
-			Default("1s"))
+			Default("1s")).
+		Field(service.NewIntField("max_keys").
+			Description("The maximum number of distinct keys to track limits for, once exceeded the least recently accessed keys are removed.").
+			Default(1000).
+			Version("4.1.0").
+			Advanced())
 
 	return spec
 }
@@ -44,15 +56,37 @@ func newLocalRatelimitFromConfig(conf *service.ParsedConfig) (*localRatelimit, e
 	if err != nil {
 		return nil, err
 	}
-	return newLocalRatelimit(count, interval)
+	maxKeys, err := conf.FieldInt("max_keys")
+	if err != nil {
+		return nil, err
+	}
+	r, err := newLocalRatelimit(count, interval)
+	if err != nil {
+		return nil, err
+	}
+	if maxKeys <= 0 {
+		return nil, errors.New("max_keys must be larger than zero")
+	}
+	r.maxKeys = maxKeys
+	return r, nil
 }
 
 //------------------------------------------------------------------------------
 
-type localRatelimit struct {
-	mut         sync.Mutex
-	bucket      int
+type localBucket struct {
+	key         string
+	remaining   int
 	lastRefresh time.Time
+}
+
+type localRatelimit struct {
+	mut    sync.Mutex
+	shared localBucket
+
+	// Buckets of keyed requests, ordered by most recent access.
+	keyed   map[string]*list.Element
+	lru     *list.List
+	maxKeys int
 
 	size   int
 	period time.Duration
@@ -63,30 +97,66 @@ func newLocalRatelimit(count int, interval time.Duration) (*localRatelimit, erro
 		return nil, errors.New("count must be larger than zero")
 	}
 	return &localRatelimit{
-		bucket:      count,
-		lastRefresh: time.Now(),
-		size:        count,
-		period:      interval,
+		shared: localBucket{
+			remaining:   count,
+			lastRefresh: time.Now(),
+		},
+		keyed:   map[string]*list.Element{},
+		lru:     list.New(),
+		maxKeys: 1000,
+		size:    count,
+		period:  interval,
 	}, nil
 }
 
-func (r *localRatelimit) Access(ctx context.Context) (time.Duration, error) {
-	r.mut.Lock()
-	r.bucket--
+func (r *localRatelimit) take(b *localBucket) time.Duration {
+	b.remaining--
 
-	if r.bucket < 0 {
-		r.bucket = 0
-		remaining := r.period - time.Since(r.lastRefresh)
+	if b.remaining < 0 {
+		b.remaining = 0
+		remaining := r.period - time.Since(b.lastRefresh)
 
 		if remaining > 0 {
-			r.mut.Unlock()
-			return remaining, nil
+			return remaining
 		}
-		r.bucket = r.size - 1
-		r.lastRefresh = time.Now()
+		b.remaining = r.size - 1
+		b.lastRefresh = time.Now()
+	}
+	return 0
+}
+
+func (r *localRatelimit) Access(ctx context.Context) (time.Duration, error) {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	return r.take(&r.shared), nil
+}
+
+func (r *localRatelimit) AccessKey(ctx context.Context, key string) (time.Duration, error) {
+	if key == "" {
+		return r.Access(ctx)
+	}
+
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	if e, exists := r.keyed[key]; exists {
+		r.lru.MoveToFront(e)
+		return r.take(e.Value.(*localBucket)), nil
+	}
+
+	for r.lru.Len() >= r.maxKeys {
+		oldest := r.lru.Back()
+		r.lru.Remove(oldest)
+		delete(r.keyed, oldest.Value.(*localBucket).key)
+	}
+
+	b := &localBucket{
+		key:         key,
+		remaining:   r.size,
+		lastRefresh: time.Now(),
 	}
-	r.mut.Unlock()
-	return 0, nil
+	r.keyed[key] = r.lru.PushFront(b)
+	return r.take(b), nil
 }
 
 func (r *localRatelimit) Close(ctx context.Context) error {
@@ -91,6 +91,49 @@ interval: 10ms
 
 //------------------------------------------------------------------------------
 
+func TestLocalRateLimitKeyed(t *testing.T) {
+	conf, err := localRatelimitConfig().ParseYAML(`
+count: 2
+interval: 1m
+max_keys: 2
+`, nil)
+	require.NoError(t, err)
+
+	rl, err := newLocalRatelimitFromConfig(conf)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	for _, key := range []string{"foo", "bar", ""} {
+		for i := 0; i < 2; i++ {
+			period, err := rl.AccessKey(ctx, key)
+			require.NoError(t, err)
+			assert.Equal(t, time.Duration(0), period, key)
+		}
+		period, err := rl.AccessKey(ctx, key)
+		require.NoError(t, err)
+		assert.Greater(t, period, time.Duration(0), key)
+	}
+
+	// Accessing with an empty key is the same as accessing without a key.
+	period, err := rl.Access(ctx)
+	require.NoError(t, err)
+	assert.Greater(t, period, time.Duration(0))
+
+	// A third key evicts the least recently accessed key, which is foo.
+	period, err = rl.AccessKey(ctx, "baz")
+	require.NoError(t, err)
+	assert.Equal(t, time.Duration(0), period)
+
+	period, err = rl.AccessKey(ctx, "bar")
+	require.NoError(t, err)
+	assert.Greater(t, period, time.Duration(0))
+
+	period, err = rl.AccessKey(ctx, "foo")
+	require.NoError(t, err)
+	assert.Equal(t, time.Duration(0), period)
+}
+
 func BenchmarkRateLimit(b *testing.B) {
 	/* A rate limit is typically going to be protecting a networked resource
 	 * where the request will likely be measured at least in hundreds of
@@ -2,12 +2,13 @@ package pure
 
 import (
 	"github.com/benthosdev/benthos/v4/internal/bundle"
+	"github.com/benthosdev/benthos/v4/internal/component/metrics"
 	"github.com/benthosdev/benthos/v4/internal/component/tracer"
 	"github.com/benthosdev/benthos/v4/internal/docs"
 )
 
 func init() {
-	_ = bundle.AllTracers.Add(func(c tracer.Config) (tracer.Type, error) {
+	_ = bundle.AllTracers.Add(func(c tracer.Config, stats metrics.Type) (tracer.Type, error) {
 		return noopTracer{}, nil
 	}, docs.ComponentSpec{
 		Name:    "none",
@@ -139,6 +139,52 @@ func (r *redisCache) Get(ctx context.Context, key string) ([]byte, error) {
 	}
 }
 
+// GetMulti obtains the values of multiple keys with a single pipelined
+// request, which unlike MGET is supported by clusters when keys span slots.
+func (r *redisCache) GetMulti(ctx context.Context, keys ...string) (map[string][]byte, error) {
+	boff := r.boffPool.Get().(backoff.BackOff)
+	defer func() {
+		boff.Reset()
+		r.boffPool.Put(boff)
+	}()
+
+	for {
+		pipe := r.client.Pipeline()
+		cmds := make([]*redis.StringCmd, len(keys))
+		for i, key := range keys {
+			cmds[i] = pipe.Get(r.prefix + key)
+		}
+
+		_, err := pipe.Exec()
+		if err == nil || err == redis.Nil {
+			err = nil
+			res := make(map[string][]byte, len(keys))
+			for i, cmd := range cmds {
+				v, cerr := cmd.Result()
+				if cerr == nil {
+					res[keys[i]] = []byte(v)
+				} else if cerr != redis.Nil {
+					err = cerr
+					break
+				}
+			}
+			if err == nil {
+				return res, nil
+			}
+		}
+
+		wait := boff.NextBackOff()
+		if wait == backoff.Stop {
+			return nil, err
+		}
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return nil, err
+		}
+	}
+}
+
 func (r *redisCache) Set(ctx context.Context, key string, value []byte, ttl *time.Duration) error {
 	boff := r.boffPool.Get().(backoff.BackOff)
 	defer func() {
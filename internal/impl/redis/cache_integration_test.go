@@ -66,6 +66,34 @@ cache_resources:
 		t, template,
 		integration.CacheTestOptPort(resource.GetPort("6379/tcp")),
 	)
+
+	t.Run("get multi", func(t *testing.T) {
+		pConf, err := redisCacheConfig().ParseYAML(fmt.Sprintf(`
+url: tcp://localhost:%v/1
+prefix: get_multi_
+`, resource.GetPort("6379/tcp")), nil)
+		require.NoError(t, err)
+
+		r, err := newRedisCacheFromConfig(pConf)
+		require.NoError(t, err)
+
+		ctx := context.Background()
+		require.NoError(t, r.Set(ctx, "a", []byte("a value"), nil))
+		require.NoError(t, r.Set(ctx, "c", []byte("c value"), nil))
+
+		res, err := r.GetMulti(ctx, "a", "b", "c")
+		require.NoError(t, err)
+		assert.Equal(t, map[string][]byte{
+			"a": []byte("a value"),
+			"c": []byte("c value"),
+		}, res)
+
+		res, err = r.GetMulti(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, res)
+
+		require.NoError(t, r.Close(ctx))
+	})
 }
 
 func TestIntegrationRedisClusterCache(t *testing.T) {
@@ -0,0 +1,162 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func redisRatelimitConfig() *service.ConfigSpec {
+	spec := service.NewConfigSpec().
+		Beta().
+		Version("4.1.0").
+		Summary(`A rate limit implemented as a token bucket stored within Redis, which allows rate limits to be shared across multiple running instances of Benthos.`).
+		Description(`
+Each access attempt executes a Lua script that atomically refills and consumes tokens from a bucket stored at the configured key, and therefore all instances of Benthos that share the same Redis server and key observe the same limit.
+
+The bucket holds at most ` + "`count`" + ` tokens and is refilled continuously at a rate of ` + "`count`" + ` tokens every ` + "`interval`" + `. The time is taken from the Redis server, meaning the clocks of the Benthos instances do not need to be synchronised.
+
+Buckets that are left unused are removed by Redis once they have been idle for twice the length of the ` + "`interval`" + `.
+
+### Keyed Limits
+
+Components that support it can provide a key when accessing the rate limit, such as the field ` + "`key`" + ` of the ` + "[`throttle` processor](/docs/components/processors/throttle)" + `, in which case each distinct key is given its own bucket stored at the configured ` + "`key`" + ` suffixed with a colon and the provided key.`)
+
+	for _, f := range clientFields() {
+		spec = spec.Field(f)
+	}
+
+	spec = spec.
+		Field(service.NewIntField("count").
+			Description("The maximum number of requests to allow for a given period of time.").
+			Default(1000)).
+		Field(service.NewDurationField("interval").
+			Description("The time window to limit requests by.").
+			Default("1s")).
+		Field(service.NewStringField("key").
+			Description("The key to store the bucket under, rate limits that share a key share the same limit.").
+			Example("benthos_rate_limit").
+			Default("benthos_rate_limit"))
+
+	return spec
+}
+
+func init() {
+	err := service.RegisterRateLimit(
+		"redis", redisRatelimitConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.RateLimit, error) {
+			return newRedisRatelimitFromConfig(conf)
+		})
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+func newRedisRatelimitFromConfig(conf *service.ParsedConfig) (*redisRatelimit, error) {
+	client, err := getClient(conf)
+	if err != nil {
+		return nil, err
+	}
+	count, err := conf.FieldInt("count")
+	if err != nil {
+		return nil, err
+	}
+	interval, err := conf.FieldDuration("interval")
+	if err != nil {
+		return nil, err
+	}
+	key, err := conf.FieldString("key")
+	if err != nil {
+		return nil, err
+	}
+	return newRedisRatelimit(client, key, count, interval)
+}
+
+//------------------------------------------------------------------------------
+
+// The token bucket script returns the number of milliseconds to wait before
+// the next access attempt, or zero when a token was consumed. Tokens are
+// stored as a float so that partial refills are not lost between attempts.
+var tokenBucketScript = redis.NewScript(`
+redis.replicate_commands()
+
+local count = tonumber(ARGV[1])
+local interval = tonumber(ARGV[2])
+
+local t = redis.call("TIME")
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+
+local state = redis.call("HMGET", KEYS[1], "tokens", "ts")
+local tokens = tonumber(state[1])
+local ts = tonumber(state[2])
+if tokens == nil or ts == nil then
+  tokens = count
+  ts = now
+end
+
+tokens = math.min(count, tokens + (math.max(0, now - ts) * count / interval))
+
+local wait = 0
+if tokens >= 1 then
+  tokens = tokens - 1
+else
+  wait = math.ceil((1 - tokens) * interval / count)
+end
+
+redis.call("HMSET", KEYS[1], "tokens", tostring(tokens), "ts", tostring(now))
+redis.call("PEXPIRE", KEYS[1], interval * 2)
+return wait
+`)
+
+type redisRatelimit struct {
+	client   redis.UniversalClient
+	key      string
+	size     int
+	periodMS int64
+}
+
+func newRedisRatelimit(client redis.UniversalClient, key string, count int, interval time.Duration) (*redisRatelimit, error) {
+	if count <= 0 {
+		return nil, errors.New("count must be larger than zero")
+	}
+	if interval < time.Millisecond {
+		return nil, errors.New("interval must be at least one millisecond")
+	}
+	if key == "" {
+		return nil, errors.New("key must not be empty")
+	}
+	return &redisRatelimit{
+		client:   client,
+		key:      key,
+		size:     count,
+		periodMS: interval.Milliseconds(),
+	}, nil
+}
+
+func (r *redisRatelimit) Access(ctx context.Context) (time.Duration, error) {
+	return r.access(r.key)
+}
+
+func (r *redisRatelimit) AccessKey(ctx context.Context, key string) (time.Duration, error) {
+	if key == "" {
+		return r.access(r.key)
+	}
+	return r.access(r.key + ":" + key)
+}
+
+func (r *redisRatelimit) access(key string) (time.Duration, error) {
+	waitMS, err := tokenBucketScript.Run(r.client, []string{key}, r.size, r.periodMS).Int64()
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(waitMS) * time.Millisecond, nil
+}
+
+func (r *redisRatelimit) Close(ctx context.Context) error {
+	return r.client.Close()
+}
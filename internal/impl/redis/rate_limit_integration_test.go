@@ -0,0 +1,84 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ory/dockertest/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/integration"
+)
+
+func TestIntegrationRedisRateLimit(t *testing.T) {
+	integration.CheckSkip(t)
+	t.Parallel()
+
+	pool, err := dockertest.NewPool("")
+	require.NoError(t, err)
+
+	pool.MaxWait = time.Second * 30
+
+	resource, err := pool.Run("redis", "latest", nil)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		assert.NoError(t, pool.Purge(resource))
+	})
+
+	_ = resource.Expire(900)
+
+	newRateLimit := func() (*redisRatelimit, error) {
+		pConf, err := redisRatelimitConfig().ParseYAML(fmt.Sprintf(`
+url: tcp://localhost:%v/1
+count: 10
+interval: 10s
+key: benthos_test_rate_limit
+`, resource.GetPort("6379/tcp")), nil)
+		if err != nil {
+			return nil, err
+		}
+		return newRedisRatelimitFromConfig(pConf)
+	}
+
+	var rlA *redisRatelimit
+	require.NoError(t, pool.Retry(func() error {
+		if rlA, err = newRateLimit(); err != nil {
+			return err
+		}
+		return rlA.client.Ping().Err()
+	}))
+	t.Cleanup(func() {
+		assert.NoError(t, rlA.Close(context.Background()))
+	})
+
+	rlB, err := newRateLimit()
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		assert.NoError(t, rlB.Close(context.Background()))
+	})
+
+	ctx := context.Background()
+
+	// Both rate limits share a single bucket of ten tokens.
+	for i := 0; i < 5; i++ {
+		period, err := rlA.Access(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, time.Duration(0), period, i)
+
+		period, err = rlB.Access(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, time.Duration(0), period, i)
+	}
+
+	period, err := rlA.Access(ctx)
+	require.NoError(t, err)
+	assert.Greater(t, period, time.Duration(0))
+	assert.LessOrEqual(t, period, time.Second)
+
+	period, err = rlB.Access(ctx)
+	require.NoError(t, err)
+	assert.Greater(t, period, time.Duration(0))
+}
@@ -0,0 +1,74 @@
+package sql
+
+import (
+	"database/sql"
+	"errors"
+	"sync"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+type sharedDB struct {
+	db       *sql.DB
+	settings connSettings
+	refs     int
+}
+
+// dbPoolsKey is the key under which the database connection pools of a service
+// are stored within its resources.
+type dbPoolsKey struct{}
+
+// dbPools tracks database connection pools that are shared between components
+// of a service targeting the same driver and data source name, allowing
+// multiple processors to operate on a single pool rather than each opening
+// their own.
+type dbPools struct {
+	mut sync.Mutex
+	dbs map[string]*sharedDB
+}
+
+// openSharedDB returns a database connection pool for the given driver and
+// DSN, opening a new one if none exist within the resources of the service. An
+// error is returned when a pool already exists with connection settings that
+// differ from those provided, as they would otherwise be silently ignored. The
+// returned closure must be called once the pool is no longer in use, and the
+// pool is closed once all references have been released.
+func openSharedDB(mgr *service.Resources, driver, dsn string, settings connSettings) (*sql.DB, func() error, error) {
+	key := driver + "|" + dsn
+
+	poolsV, _ := mgr.GetOrSetGeneric(dbPoolsKey{}, &dbPools{dbs: map[string]*sharedDB{}})
+	pools := poolsV.(*dbPools)
+
+	pools.mut.Lock()
+	defer pools.mut.Unlock()
+
+	s, exists := pools.dbs[key]
+	if exists && s.settings != settings {
+		return nil, nil, errors.New("the connection settings must match those of other components sharing the same driver and dsn")
+	}
+	if !exists {
+		db, err := sqlOpenWithReworks(mgr.Logger(), driver, dsn)
+		if err != nil {
+			return nil, nil, err
+		}
+		settings.apply(db)
+
+		s = &sharedDB{db: db, settings: settings}
+		pools.dbs[key] = s
+	}
+	s.refs++
+
+	var releaseOnce sync.Once
+	return s.db, func() (err error) {
+		releaseOnce.Do(func() {
+			pools.mut.Lock()
+			defer pools.mut.Unlock()
+
+			if s.refs--; s.refs <= 0 {
+				delete(pools.dbs, key)
+				err = s.db.Close()
+			}
+		})
+		return
+	}, nil
+}
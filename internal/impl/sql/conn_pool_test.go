@@ -0,0 +1,54 @@
+package sql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	_ "github.com/go-sql-driver/mysql"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func TestSharedDBPool(t *testing.T) {
+	res := service.MockResources()
+
+	dbA, releaseA, err := openSharedDB(res, "mysql", "foo:bar@tcp(localhost:3306)/a", connSettings{})
+	require.NoError(t, err)
+
+	dbB, releaseB, err := openSharedDB(res, "mysql", "foo:bar@tcp(localhost:3306)/a", connSettings{})
+	require.NoError(t, err)
+
+	dbC, releaseC, err := openSharedDB(res, "mysql", "foo:bar@tcp(localhost:3306)/c", connSettings{})
+	require.NoError(t, err)
+
+	assert.Same(t, dbA, dbB)
+	assert.NotSame(t, dbA, dbC)
+
+	// Pools are not shared with the components of other services.
+	dbD, releaseD, err := openSharedDB(service.MockResources(), "mysql", "foo:bar@tcp(localhost:3306)/a", connSettings{maxOpenConns: 10})
+	require.NoError(t, err)
+	assert.NotSame(t, dbA, dbD)
+	require.NoError(t, releaseD())
+
+	_, _, err = openSharedDB(res, "mysql", "foo:bar@tcp(localhost:3306)/a", connSettings{maxOpenConns: 10})
+	require.Error(t, err)
+
+	require.NoError(t, releaseA())
+	require.NoError(t, releaseA())
+	assert.NotEqual(t, "sql: database is closed", errStr(dbB.Ping()))
+
+	require.NoError(t, releaseB())
+	assert.Equal(t, "sql: database is closed", errStr(dbB.Ping()))
+
+	require.NoError(t, releaseC())
+	assert.Equal(t, "sql: database is closed", errStr(dbC.Ping()))
+}
+
+func errStr(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
@@ -57,7 +57,7 @@ args_mapping: 'root = [ this.id ]'
 			require.NoError(t, err)
 			t.Cleanup(func() { insertProc.Close(context.Background()) })
 
-			selectProc, err := isql.NewSQLSelectProcessorFromConfig(selectConfig, nil)
+			selectProc, err := isql.NewSQLSelectProcessorFromConfig(selectConfig, service.MockResources())
 			require.NoError(t, err)
 			t.Cleanup(func() { selectProc.Close(context.Background()) })
 
@@ -2,9 +2,14 @@ package sql
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
 	"testing"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	_ "modernc.org/sqlite"
 
 	"github.com/benthosdev/benthos/v4/public/service"
 )
@@ -28,3 +33,66 @@ args_mapping: 'root = [ this.id ]'
 	require.NoError(t, err)
 	require.NoError(t, insertOutput.Close(context.Background()))
 }
+
+func TestSQLInsertOutputSQLite(t *testing.T) {
+	dsn := "file:" + filepath.Join(t.TempDir(), "foo.db")
+
+	db, err := sql.Open("sqlite", dsn)
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec(`create table footable (id integer primary key, name text)`)
+	require.NoError(t, err)
+
+	conf := `
+driver: sqlite
+dsn: ` + dsn + `
+table: footable
+columns: [ id, name ]
+args_mapping: 'root = [ this.id, this.name ]'
+suffix: ON CONFLICT (id) DO UPDATE SET name = excluded.name
+`
+
+	insertConfig, err := sqlInsertOutputConfig().ParseYAML(conf, nil)
+	require.NoError(t, err)
+
+	insertOutput, err := newSQLInsertOutputFromConfig(insertConfig, nil)
+	require.NoError(t, err)
+	require.NoError(t, insertOutput.Connect(context.Background()))
+
+	require.NoError(t, insertOutput.WriteBatch(context.Background(), service.MessageBatch{
+		service.NewMessage([]byte(`{"id":1,"name":"foo"}`)),
+		service.NewMessage([]byte(`{"id":2,"name":"bar"}`)),
+	}))
+	require.NoError(t, insertOutput.WriteBatch(context.Background(), service.MessageBatch{
+		service.NewMessage([]byte(`{"id":2,"name":"baz"}`)),
+	}))
+
+	err = insertOutput.WriteBatch(context.Background(), service.MessageBatch{
+		service.NewMessage([]byte(`{"id":3,"name":"qux"}`)),
+		service.NewMessage([]byte(`not json`)),
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "message 1")
+
+	var batchErr *service.BatchError
+	require.True(t, errors.As(err, &batchErr))
+	assert.Equal(t, 1, batchErr.IndexedErrors())
+	batchErr.WalkMessages(func(i int, _ *service.Message, err error) bool {
+		assert.Equal(t, i == 1, err != nil, i)
+		return true
+	})
+
+	require.NoError(t, insertOutput.Close(context.Background()))
+
+	rows, err := db.Query(`select id, name from footable order by id`)
+	require.NoError(t, err)
+
+	res, err := sqlRowsToArray(rows)
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"id": int64(1), "name": "foo"},
+		map[string]interface{}{"id": int64(2), "name": "baz"},
+		map[string]interface{}{"id": int64(3), "name": "qux"},
+	}, res)
+}
@@ -0,0 +1,129 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	_ "modernc.org/sqlite"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func TestGroupRowsByColumn(t *testing.T) {
+	rows := []interface{}{
+		map[string]interface{}{"id": int64(1), "name": "a"},
+		map[string]interface{}{"id": int64(2), "name": "b"},
+		map[string]interface{}{"ID": 1.0, "name": "c"},
+		map[string]interface{}{"id": "1", "name": "d"},
+		map[string]interface{}{"name": "e"},
+	}
+	assert.Equal(t, map[string][]interface{}{
+		"n:1": {rows[0], rows[2]},
+		"n:2": {rows[1]},
+		"s:1": {rows[3]},
+	}, groupRowsByColumn(rows, "id"))
+}
+
+func TestBatchKeyString(t *testing.T) {
+	for _, test := range []struct {
+		a, b interface{}
+	}{
+		{a: 1.5, b: json.Number("1.50")},
+		{a: int64(10), b: json.Number("10.0")},
+		{a: int64(10), b: 10.0},
+		{a: uint8(3), b: int(3)},
+		{a: float32(2.5), b: 2.5},
+		{a: "foo", b: []byte("foo")},
+	} {
+		assert.Equal(t, batchKeyString(test.a), batchKeyString(test.b), "%v == %v", test.a, test.b)
+	}
+	for _, test := range []struct {
+		a, b interface{}
+	}{
+		{a: "1.5", b: "1.51"},
+		{a: "007", b: "7"},
+		{a: "1.50", b: "1.5"},
+		{a: "1.5", b: 1.5},
+		{a: []byte("10"), b: int64(10)},
+		{a: true, b: "true"},
+	} {
+		assert.NotEqual(t, batchKeyString(test.a), batchKeyString(test.b), "%v != %v", test.a, test.b)
+	}
+}
+
+func TestSQLSelectBatchKeyedSQLite(t *testing.T) {
+	dsn := "file:" + filepath.Join(t.TempDir(), "foo.db")
+
+	db, err := sql.Open("sqlite", dsn)
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec(`create table footable (id real, name text)`)
+	require.NoError(t, err)
+	_, err = db.Exec(`insert into footable (id, name) values (1.5, 'foo'), (2, 'bar'), (2, 'baz'), (3, 'qux')`)
+	require.NoError(t, err)
+
+	conf := `
+driver: sqlite
+dsn: ` + dsn + `
+table: footable
+columns: [ ID, name ]
+batch_key_column: id
+args_mapping: 'root = [ this.id ]'
+suffix: order by name
+`
+
+	selectConfig, err := SelectProcessorConfig().ParseYAML(conf, nil)
+	require.NoError(t, err)
+
+	proc, err := NewSQLSelectProcessorFromConfig(selectConfig, service.MockResources())
+	require.NoError(t, err)
+
+	batches, err := proc.ProcessBatch(context.Background(), service.MessageBatch{
+		service.NewMessage([]byte(`{"id":"1.50"}`)),
+		service.NewMessage([]byte(`{"id":2}`)),
+		service.NewMessage([]byte(`{"id":4}`)),
+		service.NewMessage([]byte(`{"id":1.5}`)),
+		service.NewMessage([]byte(`not json`)),
+	})
+	require.NoError(t, err)
+	require.Len(t, batches, 1)
+	require.Len(t, batches[0], 5)
+
+	for i, exp := range []string{
+		`[]`,
+		`[{"id":2,"name":"bar"},{"id":2,"name":"baz"}]`,
+		`[]`,
+		`[{"id":1.5,"name":"foo"}]`,
+	} {
+		require.NoError(t, batches[0][i].GetError(), i)
+		b, err := batches[0][i].AsBytes()
+		require.NoError(t, err)
+		assert.JSONEq(t, exp, string(b), i)
+	}
+	assert.Error(t, batches[0][4].GetError())
+
+	require.NoError(t, proc.Close(context.Background()))
+}
+
+func TestSQLSelectBatchKeyColumnMissing(t *testing.T) {
+	conf := `
+driver: sqlite
+dsn: file:foo.db
+table: footable
+columns: [ name ]
+batch_key_column: id
+args_mapping: 'root = [ this.id ]'
+`
+
+	selectConfig, err := SelectProcessorConfig().ParseYAML(conf, nil)
+	require.NoError(t, err)
+
+	_, err = NewSQLSelectProcessorFromConfig(selectConfig, service.MockResources())
+	require.EqualError(t, err, "batch_key_column id must be included within columns")
+}
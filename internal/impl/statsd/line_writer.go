@@ -0,0 +1,141 @@
+package statsd
+
+import (
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	statsd "github.com/smira/go-statsd"
+
+	"github.com/benthosdev/benthos/v4/internal/log"
+)
+
+// The maximum size of a UDP packet written by the line writer, which matches
+// the default of the statsd client library.
+const maxPacketSize = 1432
+
+// lineWriter buffers metric lines of types that are not supported by the
+// statsd client library, such as DogStatsD distributions, and writes them
+// over UDP each flush period or whenever the buffer would exceed the maximum
+// packet size.
+type lineWriter struct {
+	conn        net.Conn
+	metricType  string
+	format      *statsd.TagFormat
+	defaultTags []statsd.Tag
+	log         log.Modular
+
+	bufMut sync.Mutex
+	buf    []byte
+
+	closeOnce  sync.Once
+	closeChan  chan struct{}
+	closedChan chan struct{}
+}
+
+func newLineWriter(address, metricType string, flushPeriod time.Duration, format *statsd.TagFormat, defaultTags []statsd.Tag, log log.Modular) (*lineWriter, error) {
+	conn, err := net.Dial("udp", address)
+	if err != nil {
+		return nil, err
+	}
+	w := &lineWriter{
+		conn:        conn,
+		metricType:  metricType,
+		format:      format,
+		defaultTags: defaultTags,
+		log:         log,
+		buf:         make([]byte, 0, maxPacketSize),
+		closeChan:   make(chan struct{}),
+		closedChan:  make(chan struct{}),
+	}
+	go w.loop(flushPeriod)
+	return w, nil
+}
+
+func (w *lineWriter) appendTags(buf []byte, tags []statsd.Tag) []byte {
+	if len(w.defaultTags)+len(tags) == 0 {
+		return buf
+	}
+	buf = append(buf, w.format.FirstSeparator...)
+	for i, t := range w.defaultTags {
+		if i > 0 {
+			buf = append(buf, w.format.OtherSeparator)
+		}
+		buf = t.Append(buf, w.format)
+	}
+	for i, t := range tags {
+		if i > 0 || len(w.defaultTags) > 0 {
+			buf = append(buf, w.format.OtherSeparator)
+		}
+		buf = t.Append(buf, w.format)
+	}
+	return buf
+}
+
+func (w *lineWriter) write(name string, value int64, tags []statsd.Tag) {
+	line := make([]byte, 0, 64)
+	line = append(line, name...)
+	if w.format.Placement == statsd.TagPlacementName {
+		line = w.appendTags(line, tags)
+	}
+	line = append(line, ':')
+	line = strconv.AppendInt(line, value, 10)
+	line = append(line, '|')
+	line = append(line, w.metricType...)
+	if w.format.Placement == statsd.TagPlacementSuffix {
+		line = w.appendTags(line, tags)
+	}
+	line = append(line, '\n')
+
+	w.bufMut.Lock()
+	if len(w.buf) > 0 && len(w.buf)+len(line) > maxPacketSize {
+		w.flush()
+	}
+	w.buf = append(w.buf, line...)
+	w.bufMut.Unlock()
+}
+
+// flush must be called whilst holding bufMut.
+func (w *lineWriter) flush() {
+	if len(w.buf) == 0 {
+		return
+	}
+	if _, err := w.conn.Write(w.buf); err != nil {
+		w.log.Debugf("Failed to write metrics: %v\n", err)
+	}
+	w.buf = w.buf[:0]
+}
+
+func (w *lineWriter) loop(flushPeriod time.Duration) {
+	defer close(w.closedChan)
+
+	var flushChan <-chan time.Time
+	if flushPeriod > 0 {
+		ticker := time.NewTicker(flushPeriod)
+		defer ticker.Stop()
+		flushChan = ticker.C
+	}
+
+	for {
+		select {
+		case <-flushChan:
+			w.bufMut.Lock()
+			w.flush()
+			w.bufMut.Unlock()
+		case <-w.closeChan:
+			w.bufMut.Lock()
+			w.flush()
+			w.bufMut.Unlock()
+			_ = w.conn.Close()
+			return
+		}
+	}
+}
+
+func (w *lineWriter) close() {
+	w.closeOnce.Do(func() {
+		close(w.closeChan)
+	})
+	<-w.closedChan
+}
@@ -3,6 +3,7 @@ package statsd
 import (
 	"fmt"
 	"net/http"
+	"sort"
 	"time"
 
 	statsd "github.com/smira/go-statsd"
@@ -23,13 +24,45 @@ Pushes metrics using the [StatsD protocol](https://github.com/statsd/statsd).
 Supported tagging formats are 'none', 'datadog' and 'influxdb'.`,
 		Description: `
 The underlying client library has recently been updated in order to support
-tagging.`,
+tagging.
+
+### Timing Types
+
+By default timing metrics are sent as StatsD timers (` + "`ms`" + `). Setting the
+field ` + "`timing_type`" + ` to ` + "`histogram`" + ` sends them as histograms
+(` + "`h`" + `) and ` + "`distribution`" + ` sends them as
+[DogStatsD distributions](https://docs.datadoghq.com/metrics/types/?tab=distribution#metric-types)
+(` + "`d`" + `), which are aggregated globally by Datadog rather than by each agent.
+Distributions and histograms are only understood by DogStatsD compatible
+servers.
+
+### Path Tags
+
+Metrics with a dot separated path, such as those produced by a
+[metrics mapping](/docs/components/metrics/about#metric-mapping), can be
+converted into a name and tags with the field ` + "`path_tags`" + `. Each pattern is
+a dot separated list of segments, where a segment ` + "`{tag}`" + ` captures the
+respective segment of the path as a tag, ` + "`*`" + ` matches any segment and keeps it
+within the name, and any other segment must match the path exactly. Paths are
+converted with the first pattern that matches, and paths that match no
+patterns are sent unchanged.
+
+For example, the pattern ` + "`{stream}.{label}.*`" + ` converts the path
+` + "`foo.my_input.input_received`" + ` into the name ` + "`input_received`" + ` with the
+tags ` + "`stream:foo`" + ` and ` + "`label:my_input`" + `.`,
 		Config: docs.FieldComponent().WithChildren(
 			docs.FieldString("address", "The address to send metrics to.").HasDefault(""),
 			docs.FieldString("flush_period", "The time interval between metrics flushes.").HasDefault("100ms"),
 			docs.FieldString("tag_format", "Metrics tagging is supported in a variety of formats.").HasOptions(
 				"none", "datadog", "influxdb",
 			).HasDefault("none"),
+			docs.FieldString("timing_type", "The StatsD metric type used to send timing metrics.").HasAnnotatedOptions(
+				"timing", "Send timings as StatsD timers.",
+				"histogram", "Send timings as DogStatsD histograms.",
+				"distribution", "Send timings as DogStatsD distributions.",
+			).HasDefault("timing").Advanced().AtVersion("4.1.0"),
+			docs.FieldString("tags", "A map of tags to add to all metrics. Environment variables can be used to set tags that identify a deployment.").Map().HasDefault(map[string]interface{}{}).Advanced().AtVersion("4.1.0"),
+			docs.FieldString("path_tags", "A list of patterns used to convert dot separated metric paths into a name and tags.", "{stream}.{label}.*").Array().HasDefault([]interface{}{}).Advanced().AtVersion("4.1.0"),
 		),
 	})
 }
@@ -53,11 +86,19 @@ const (
 	TagFormatInfluxDB = "influxdb"
 )
 
+// Timing types supported by the statsd metric type.
+const (
+	TimingTypeTiming       = "timing"
+	TimingTypeHistogram    = "histogram"
+	TimingTypeDistribution = "distribution"
+)
+
 //------------------------------------------------------------------------------
 
 type statsdStat struct {
 	path string
 	s    *statsd.Client
+	w    *lineWriter
 	tags []statsd.Tag
 }
 
@@ -70,6 +111,10 @@ func (s *statsdStat) Decr(count int64) {
 }
 
 func (s *statsdStat) Timing(delta int64) {
+	if s.w != nil {
+		s.w.write(s.path, delta, s.tags)
+		return
+	}
 	s.s.Timing(s.path, delta, s.tags...)
 }
 
@@ -80,9 +125,11 @@ func (s *statsdStat) Set(value int64) {
 //------------------------------------------------------------------------------
 
 type statsdMetrics struct {
-	config metrics.Config
-	s      *statsd.Client
-	log    log.Modular
+	config   metrics.Config
+	s        *statsd.Client
+	w        *lineWriter
+	pathTags pathTagPatterns
+	log      log.Modular
 }
 
 func newStatsd(config metrics.Config, log log.Modular) (metrics.Type, error) {
@@ -101,22 +148,69 @@ func newStatsd(config metrics.Config, log log.Modular) (metrics.Type, error) {
 		statsd.Logger(wrappedDatadogLogger{log: s.log}),
 	}
 
+	// The client library defaults to the InfluxDB format when no tag style is
+	// specified.
+	tagFormat := statsd.TagFormatInfluxDB
 	switch config.Statsd.TagFormat {
 	case TagFormatInfluxDB:
-		statsdOpts = append(statsdOpts, statsd.TagStyle(statsd.TagFormatInfluxDB))
 	case TagFormatDatadog:
-		statsdOpts = append(statsdOpts, statsd.TagStyle(statsd.TagFormatDatadog))
+		tagFormat = statsd.TagFormatDatadog
 	case TagFormatNone:
 	default:
 		return nil, fmt.Errorf("tag format '%s' was not recognised", config.Statsd.TagFormat)
 	}
+	statsdOpts = append(statsdOpts, statsd.TagStyle(tagFormat))
+
+	tagKeys := make([]string, 0, len(config.Statsd.Tags))
+	for k := range config.Statsd.Tags {
+		tagKeys = append(tagKeys, k)
+	}
+	sort.Strings(tagKeys)
+	defaultTags := make([]statsd.Tag, 0, len(tagKeys))
+	for _, k := range tagKeys {
+		defaultTags = append(defaultTags, statsd.StringTag(k, config.Statsd.Tags[k]))
+	}
+	if len(defaultTags) > 0 {
+		statsdOpts = append(statsdOpts, statsd.DefaultTags(defaultTags...))
+	}
+
+	for _, p := range config.Statsd.PathTags {
+		pattern, err := parsePathTagPattern(p)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse path tags: %w", err)
+		}
+		s.pathTags = append(s.pathTags, pattern)
+	}
 
-	client := statsd.NewClient(config.Statsd.Address, statsdOpts...)
+	var metricType string
+	switch config.Statsd.TimingType {
+	case TimingTypeTiming:
+	case TimingTypeHistogram:
+		metricType = "h"
+	case TimingTypeDistribution:
+		metricType = "d"
+	default:
+		return nil, fmt.Errorf("timing type '%s' was not recognised", config.Statsd.TimingType)
+	}
+	if metricType != "" {
+		if s.w, err = newLineWriter(config.Statsd.Address, metricType, flushPeriod, tagFormat, defaultTags, log); err != nil {
+			return nil, fmt.Errorf("failed to create %v writer: %w", config.Statsd.TimingType, err)
+		}
+	}
 
-	s.s = client
+	s.s = statsd.NewClient(config.Statsd.Address, statsdOpts...)
 	return s, nil
 }
 
+func (h *statsdMetrics) newStat(path string, labels, values []string) *statsdStat {
+	name, pathTags := h.pathTags.convert(path)
+	return &statsdStat{
+		path: name,
+		s:    h.s,
+		tags: append(pathTags, tags(labels, values)...),
+	}
+}
+
 //------------------------------------------------------------------------------
 
 func (h *statsdMetrics) GetCounter(path string) metrics.StatCounter {
@@ -125,11 +219,7 @@ func (h *statsdMetrics) GetCounter(path string) metrics.StatCounter {
 
 func (h *statsdMetrics) GetCounterVec(path string, n ...string) metrics.StatCounterVec {
 	return metrics.FakeCounterVec(func(l ...string) metrics.StatCounter {
-		return &statsdStat{
-			path: path,
-			s:    h.s,
-			tags: tags(n, l),
-		}
+		return h.newStat(path, n, l)
 	})
 }
 
@@ -139,11 +229,9 @@ func (h *statsdMetrics) GetTimer(path string) metrics.StatTimer {
 
 func (h *statsdMetrics) GetTimerVec(path string, n ...string) metrics.StatTimerVec {
 	return metrics.FakeTimerVec(func(l ...string) metrics.StatTimer {
-		return &statsdStat{
-			path: path,
-			s:    h.s,
-			tags: tags(n, l),
-		}
+		stat := h.newStat(path, n, l)
+		stat.w = h.w
+		return stat
 	})
 }
 
@@ -153,11 +241,7 @@ func (h *statsdMetrics) GetGauge(path string) metrics.StatGauge {
 
 func (h *statsdMetrics) GetGaugeVec(path string, n ...string) metrics.StatGaugeVec {
 	return metrics.FakeGaugeVec(func(l ...string) metrics.StatGauge {
-		return &statsdStat{
-			path: path,
-			s:    h.s,
-			tags: tags(n, l),
-		}
+		return h.newStat(path, n, l)
 	})
 }
 
@@ -166,6 +250,9 @@ func (h *statsdMetrics) HandlerFunc() http.HandlerFunc {
 }
 
 func (h *statsdMetrics) Close() error {
+	if h.w != nil {
+		h.w.close()
+	}
 	h.s.Close()
 	return nil
 }
@@ -0,0 +1,96 @@
+package statsd
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+	"github.com/benthosdev/benthos/v4/internal/log"
+)
+
+func readLines(t *testing.T, conn net.PacketConn, n int) []string {
+	t.Helper()
+
+	var lines []string
+	buf := make([]byte, maxPacketSize*2)
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second*5)))
+	for len(lines) < n {
+		l, _, err := conn.ReadFrom(buf)
+		require.NoError(t, err)
+		for _, line := range strings.Split(strings.TrimSpace(string(buf[:l])), "\n") {
+			if line != "" {
+				lines = append(lines, line)
+			}
+		}
+	}
+	return lines
+}
+
+func TestStatsdDistributions(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	conf := metrics.NewConfig()
+	conf.Statsd.Address = conn.LocalAddr().String()
+	conf.Statsd.FlushPeriod = "10ms"
+	conf.Statsd.TagFormat = TagFormatDatadog
+	conf.Statsd.TimingType = TimingTypeDistribution
+	conf.Statsd.Tags = map[string]string{"env": "prod", "app": "foo"}
+
+	s, err := newStatsd(conf, log.Noop())
+	require.NoError(t, err)
+
+	s.GetTimerVec("foo_latency", "label").With("bar").Timing(10)
+	require.NoError(t, s.Close())
+
+	assert.Equal(t, []string{
+		"foo_latency:10|d|#app:foo,env:prod,label:bar",
+	}, readLines(t, conn, 1))
+}
+
+func TestStatsdPathTags(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	conf := metrics.NewConfig()
+	conf.Statsd.Address = conn.LocalAddr().String()
+	conf.Statsd.FlushPeriod = "10ms"
+	conf.Statsd.TagFormat = TagFormatDatadog
+	conf.Statsd.Tags = map[string]string{"env": "prod"}
+	conf.Statsd.PathTags = []string{"input.{label}.*", "{stream}.{label}.*"}
+
+	s, err := newStatsd(conf, log.Noop())
+	require.NoError(t, err)
+
+	s.GetCounter("input.foo.received").Incr(1)
+	s.GetCounterVec("bar.baz.processed", "path").With("root.pipeline").Incr(2)
+	s.GetGauge("unmatched.path").Set(3)
+	require.NoError(t, s.Close())
+
+	assert.ElementsMatch(t, []string{
+		"input.received:1|c|#env:prod,label:foo",
+		"processed:2|c|#env:prod,stream:bar,label:baz,path:root.pipeline",
+		"unmatched.path:3|g|#env:prod",
+	}, readLines(t, conn, 3))
+}
+
+func TestStatsdBadConfig(t *testing.T) {
+	conf := metrics.NewConfig()
+	conf.Statsd.Address = "127.0.0.1:0"
+	conf.Statsd.TimingType = "nope"
+	_, err := newStatsd(conf, log.Noop())
+	require.Error(t, err)
+
+	conf = metrics.NewConfig()
+	conf.Statsd.Address = "127.0.0.1:0"
+	conf.Statsd.PathTags = []string{"{foo}.{bar}"}
+	_, err = newStatsd(conf, log.Noop())
+	require.Error(t, err)
+}
@@ -0,0 +1,88 @@
+package statsd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	statsd "github.com/smira/go-statsd"
+)
+
+type pathSegment struct {
+	literal  string
+	tag      string
+	wildcard bool
+}
+
+// pathTagPattern converts dot separated metric paths into a name and tags. A
+// segment of the form `{tag}` captures the respective segment of a path as a
+// tag, a segment `*` matches any segment of a path and keeps it within the
+// name, and any other segment must match the path exactly.
+type pathTagPattern []pathSegment
+
+func parsePathTagPattern(pattern string) (pathTagPattern, error) {
+	if pattern == "" {
+		return nil, errors.New("pattern must not be empty")
+	}
+
+	var p pathTagPattern
+	var hasName bool
+	for _, seg := range strings.Split(pattern, ".") {
+		switch {
+		case seg == "":
+			return nil, fmt.Errorf("pattern '%v' contains an empty segment", pattern)
+		case seg == "*":
+			p = append(p, pathSegment{wildcard: true})
+			hasName = true
+		case strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "}"):
+			tag := seg[1 : len(seg)-1]
+			if tag == "" {
+				return nil, fmt.Errorf("pattern '%v' contains an empty tag name", pattern)
+			}
+			p = append(p, pathSegment{tag: tag})
+		default:
+			p = append(p, pathSegment{literal: seg})
+			hasName = true
+		}
+	}
+	if !hasName {
+		return nil, fmt.Errorf("pattern '%v' must contain at least one segment that is not a tag", pattern)
+	}
+	return p, nil
+}
+
+func (p pathTagPattern) match(path string) (name string, tags []statsd.Tag, matched bool) {
+	segs := strings.Split(path, ".")
+	if len(segs) != len(p) {
+		return "", nil, false
+	}
+
+	nameSegs := make([]string, 0, len(segs))
+	for i, seg := range p {
+		switch {
+		case seg.wildcard:
+			nameSegs = append(nameSegs, segs[i])
+		case seg.tag != "":
+			tags = append(tags, statsd.StringTag(seg.tag, segs[i]))
+		default:
+			if seg.literal != segs[i] {
+				return "", nil, false
+			}
+			nameSegs = append(nameSegs, segs[i])
+		}
+	}
+	return strings.Join(nameSegs, "."), tags, true
+}
+
+// pathTagPatterns converts metric paths with the first matching pattern, and
+// paths that match no patterns are left unchanged.
+type pathTagPatterns []pathTagPattern
+
+func (p pathTagPatterns) convert(path string) (string, []statsd.Tag) {
+	for _, pattern := range p {
+		if name, tags, matched := pattern.match(path); matched {
+			return name, tags
+		}
+	}
+	return path, nil
+}
@@ -0,0 +1,91 @@
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/internal/secrets"
+)
+
+const defaultVaultAddr = "https://127.0.0.1:8200"
+
+var vaultClient = &http.Client{Timeout: time.Second * 30}
+
+// The path of a secret is the API path of a secrets engine without the version
+// prefix, such as `secret/data/foo` for the KV version 2 engine mounted at
+// `secret`. The address, token and namespace are obtained from the standard
+// environment variables VAULT_ADDR, VAULT_TOKEN and VAULT_NAMESPACE.
+//
+// The data of the secret is returned as a JSON object, where the metadata of
+// secrets from the KV version 2 engine is omitted.
+func getVaultSecret(ctx context.Context, path string) (string, error) {
+	addr := os.Getenv("VAULT_ADDR")
+	if addr == "" {
+		addr = defaultVaultAddr
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(addr, "/")+"/v1/"+strings.TrimPrefix(path, "/"), nil)
+	if err != nil {
+		return "", err
+	}
+	if token := os.Getenv("VAULT_TOKEN"); token != "" {
+		req.Header.Set("X-Vault-Token", token)
+	}
+	if namespace := os.Getenv("VAULT_NAMESPACE"); namespace != "" {
+		req.Header.Set("X-Vault-Namespace", namespace)
+	}
+
+	res, err := vaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return "", err
+	}
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status code %v: %s", res.StatusCode, body)
+	}
+
+	var secret struct {
+		Data map[string]json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(body, &secret); err != nil {
+		return "", fmt.Errorf("failed to parse response: %w", err)
+	}
+	if secret.Data == nil {
+		return "", errors.New("response does not contain secret data")
+	}
+
+	data := secret.Data
+	if inner, exists := data["data"]; exists {
+		if _, hasMeta := data["metadata"]; hasMeta {
+			var innerData map[string]json.RawMessage
+			if err := json.Unmarshal(inner, &innerData); err != nil {
+				return "", fmt.Errorf("failed to parse secret data: %w", err)
+			}
+			data = innerData
+		}
+	}
+
+	b, err := json.Marshal(data)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func init() {
+	if err := secrets.RegisterProvider("vault", getVaultSecret); err != nil {
+		panic(err)
+	}
+}
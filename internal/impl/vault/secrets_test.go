@@ -0,0 +1,59 @@
+package vault
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVaultSecret(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "footoken" {
+			http.Error(w, `{"errors":["permission denied"]}`, http.StatusForbidden)
+			return
+		}
+		assert.Equal(t, "foons", r.Header.Get("X-Vault-Namespace"))
+		switch r.URL.Path {
+		case "/v1/secret/data/foo":
+			_, _ = w.Write([]byte(`{"data":{"data":{"user":"bob","pass":"hunter2"},"metadata":{"version":2}}}`))
+		case "/v1/kv/bar":
+			_, _ = w.Write([]byte(`{"data":{"api_key":"abc"}}`))
+		default:
+			http.Error(w, `{"errors":[]}`, http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	for k, v := range map[string]string{
+		"VAULT_ADDR":      ts.URL,
+		"VAULT_TOKEN":     "footoken",
+		"VAULT_NAMESPACE": "foons",
+	} {
+		require.NoError(t, os.Setenv(k, v))
+		defer os.Unsetenv(k)
+	}
+
+	ctx := context.Background()
+
+	v, err := getVaultSecret(ctx, "secret/data/foo")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"user":"bob","pass":"hunter2"}`, v)
+
+	v, err = getVaultSecret(ctx, "kv/bar")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"api_key":"abc"}`, v)
+
+	_, err = getVaultSecret(ctx, "kv/baz")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unexpected status code 404")
+
+	require.NoError(t, os.Setenv("VAULT_TOKEN", "nope"))
+	_, err = getVaultSecret(ctx, "kv/bar")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unexpected status code 403")
+}
@@ -0,0 +1,321 @@
+package webdav
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const (
+	ifExistsOverwrite = "overwrite"
+	ifExistsSkip      = "skip"
+	ifExistsFail      = "fail"
+)
+
+func webdavOutputConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		// Stable(). TODO
+		Version("4.1.0").
+		Categories("Network").
+		Summary("Uploads messages as files to a WebDAV server, such as Nextcloud or ownCloud.").
+		Description(`
+Each message is uploaded as an individual file with a PUT request. In order to have a different path for each file you should use function interpolations described [here](/docs/configuration/interpolation#bloblang-queries).
+
+### Directories
+
+When `+"`create_dirs`"+` is enabled and an upload fails because the parent directory of the file does not exist, the missing directories are created with MKCOL requests and the upload is attempted again.
+
+### Existing Files
+
+The field `+"`if_exists`"+` determines what happens when a file already exists at the target path. The `+"`skip`"+` and `+"`fail`"+` options are implemented with the header `+"`If-None-Match: *`"+`, which the server must support in order to prevent files from being overwritten.`).
+		Field(service.NewStringField("url").
+			Description("The base URL of the WebDAV server, which paths are resolved against.").
+			Example("https://cloud.example.com/remote.php/dav/files/benthos/")).
+		Field(service.NewInterpolatedStringField("path").
+			Description("The path of each file relative to the base URL.").
+			Example(`uploads/${! timestamp_unix_nano() }.json`).
+			Example(`${! meta("kafka_topic") }/${! meta("kafka_partition") }/${! meta("kafka_offset") }.txt`)).
+		Field(service.NewInterpolatedStringField("content_type").
+			Description("The content type of each file.").
+			Default("application/octet-stream").
+			Example("application/json")).
+		Field(service.NewBoolField("create_dirs").
+			Description("Whether to create missing parent directories of files.").
+			Default(true)).
+		Field(service.NewStringAnnotatedEnumField("if_exists", map[string]string{
+			ifExistsOverwrite: "Replace the existing file.",
+			ifExistsSkip:      "Keep the existing file and consider the message delivered.",
+			ifExistsFail:      "Keep the existing file and fail to deliver the message.",
+		}).
+			Description("Determines what happens when a file already exists at the target path.").
+			Default(ifExistsOverwrite)).
+		Field(service.NewObjectField("basic_auth",
+			service.NewBoolField("enabled").
+				Description("Whether to use basic authentication in requests.").
+				Default(false),
+			service.NewStringField("username").
+				Description("A username to authenticate as.").
+				Default(""),
+			service.NewStringField("password").
+				Description("A password to authenticate with.").
+				Default(""),
+		).
+			Description("Allows you to specify basic authentication.").
+			Advanced()).
+		Field(service.NewStringMapField("headers").
+			Description("A map of headers to add to requests, which can be used for token based authentication.").
+			Example(map[string]string{"Authorization": "Bearer foo"}).
+			Default(map[string]interface{}{}).
+			Advanced()).
+		Field(service.NewDurationField("timeout").
+			Description("The maximum period to wait for a request to complete.").
+			Default("30s").
+			Advanced()).
+		Field(service.NewTLSToggledField("tls")).
+		Field(service.NewIntField("max_in_flight").
+			Description("The maximum number of messages to have in flight at a given time. Increase this to improve throughput.").
+			Default(64)).
+		Example("Nextcloud",
+			`Here we upload each message consumed from Kafka as a file to a Nextcloud instance, organised into a directory per topic, and never replace files that have already been uploaded:`,
+			`
+output:
+  webdav:
+    url: https://cloud.example.com/remote.php/dav/files/benthos/
+    path: 'kafka/${! meta("kafka_topic") }/${! meta("kafka_partition") }-${! meta("kafka_offset") }.json'
+    content_type: application/json
+    if_exists: skip
+    basic_auth:
+      enabled: true
+      username: benthos
+      password: ${NEXTCLOUD_APP_PASSWORD}
+`,
+		)
+}
+
+func init() {
+	err := service.RegisterOutput(
+		"webdav", webdavOutputConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (out service.Output, maxInFlight int, err error) {
+			if maxInFlight, err = conf.FieldInt("max_in_flight"); err != nil {
+				return
+			}
+			out, err = newWebDAVOutputFromConfig(conf, mgr.Logger())
+			return
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type webdavOutput struct {
+	baseURL     *url.URL
+	path        *service.InterpolatedString
+	contentType *service.InterpolatedString
+	createDirs  bool
+	ifExists    string
+	username    string
+	password    string
+	basicAuth   bool
+	headers     map[string]string
+	timeout     time.Duration
+	tlsConf     *tls.Config
+
+	log *service.Logger
+
+	clientMut  sync.Mutex
+	httpClient *http.Client
+}
+
+func newWebDAVOutputFromConfig(conf *service.ParsedConfig, log *service.Logger) (*webdavOutput, error) {
+	w := &webdavOutput{
+		log: log,
+	}
+
+	urlStr, err := conf.FieldString("url")
+	if err != nil {
+		return nil, err
+	}
+	if w.baseURL, err = url.Parse(urlStr); err != nil {
+		return nil, fmt.Errorf("failed to parse url: %w", err)
+	}
+	if w.baseURL.Scheme != "http" && w.baseURL.Scheme != "https" {
+		return nil, fmt.Errorf("url scheme must be http or https, got: %v", w.baseURL.Scheme)
+	}
+	if !strings.HasSuffix(w.baseURL.Path, "/") {
+		w.baseURL.Path += "/"
+	}
+
+	if w.path, err = conf.FieldInterpolatedString("path"); err != nil {
+		return nil, err
+	}
+	if w.contentType, err = conf.FieldInterpolatedString("content_type"); err != nil {
+		return nil, err
+	}
+	if w.createDirs, err = conf.FieldBool("create_dirs"); err != nil {
+		return nil, err
+	}
+	if w.ifExists, err = conf.FieldString("if_exists"); err != nil {
+		return nil, err
+	}
+	if w.basicAuth, err = conf.FieldBool("basic_auth", "enabled"); err != nil {
+		return nil, err
+	}
+	if w.username, err = conf.FieldString("basic_auth", "username"); err != nil {
+		return nil, err
+	}
+	if w.password, err = conf.FieldString("basic_auth", "password"); err != nil {
+		return nil, err
+	}
+	if w.headers, err = conf.FieldStringMap("headers"); err != nil {
+		return nil, err
+	}
+	if w.timeout, err = conf.FieldDuration("timeout"); err != nil {
+		return nil, err
+	}
+
+	tlsConf, tlsEnabled, err := conf.FieldTLSToggled("tls")
+	if err != nil {
+		return nil, err
+	}
+	if tlsEnabled {
+		w.tlsConf = tlsConf
+	}
+	return w, nil
+}
+
+//------------------------------------------------------------------------------
+
+func (w *webdavOutput) Connect(ctx context.Context) error {
+	w.clientMut.Lock()
+	defer w.clientMut.Unlock()
+	if w.httpClient != nil {
+		return nil
+	}
+
+	w.httpClient = &http.Client{
+		Timeout: w.timeout,
+	}
+	if w.tlsConf != nil {
+		w.httpClient.Transport = &http.Transport{
+			TLSClientConfig: w.tlsConf,
+		}
+	}
+
+	w.log.Infof("Uploading files to WebDAV server at %v", w.baseURL.Redacted())
+	return nil
+}
+
+// resolve returns the URL of a path relative to the base URL.
+func (w *webdavOutput) resolve(p string) *url.URL {
+	return w.baseURL.ResolveReference(&url.URL{Path: strings.TrimPrefix(p, "/")})
+}
+
+func (w *webdavOutput) do(ctx context.Context, client *http.Client, method string, target *url.URL, body []byte, header http.Header) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, method, target.String(), bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	for k, v := range w.headers {
+		req.Header.Set(k, v)
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	if w.basicAuth {
+		req.SetBasicAuth(w.username, w.password)
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		resBody, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return res.StatusCode, fmt.Errorf("%v request to %v returned status %v: %s", method, target.Path, res.StatusCode, bytes.TrimSpace(resBody))
+	}
+	_, _ = io.Copy(io.Discard, res.Body)
+	return res.StatusCode, nil
+}
+
+// mkcolAll creates each directory leading up to a path, directories that
+// already exist are ignored.
+func (w *webdavOutput) mkcolAll(ctx context.Context, client *http.Client, p string) error {
+	segments := strings.Split(strings.Trim(p, "/"), "/")
+
+	var dir string
+	for _, s := range segments[:len(segments)-1] {
+		if s == "" {
+			continue
+		}
+		dir += s + "/"
+		status, err := w.do(ctx, client, "MKCOL", w.resolve(dir), nil, nil)
+		if err != nil && status != http.StatusMethodNotAllowed {
+			return fmt.Errorf("failed to create directory: %w", err)
+		}
+	}
+	return nil
+}
+
+func (w *webdavOutput) Write(ctx context.Context, msg *service.Message) error {
+	w.clientMut.Lock()
+	client := w.httpClient
+	w.clientMut.Unlock()
+	if client == nil {
+		return service.ErrNotConnected
+	}
+
+	mBytes, err := msg.AsBytes()
+	if err != nil {
+		return err
+	}
+
+	p := w.path.String(msg)
+	target := w.resolve(p)
+
+	header := http.Header{}
+	header.Set("Content-Type", w.contentType.String(msg))
+	if w.ifExists != ifExistsOverwrite {
+		header.Set("If-None-Match", "*")
+	}
+
+	status, err := w.do(ctx, client, http.MethodPut, target, mBytes, header)
+	if (status == http.StatusConflict || status == http.StatusNotFound) && w.createDirs {
+		// Servers respond with a conflict (or sometimes not found) when the
+		// parent directory of a resource does not exist.
+		if err := w.mkcolAll(ctx, client, p); err != nil {
+			return err
+		}
+		status, err = w.do(ctx, client, http.MethodPut, target, mBytes, header)
+	}
+	if status == http.StatusPreconditionFailed {
+		if w.ifExists == ifExistsSkip {
+			w.log.Debugf("Skipping upload of existing file %v", target.Path)
+			return nil
+		}
+		return fmt.Errorf("file %v already exists", target.Path)
+	}
+	return err
+}
+
+func (w *webdavOutput) Close(ctx context.Context) error {
+	w.clientMut.Lock()
+	defer w.clientMut.Unlock()
+	if w.httpClient != nil {
+		w.httpClient.CloseIdleConnections()
+		w.httpClient = nil
+	}
+	return nil
+}
@@ -0,0 +1,194 @@
+package webdav
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/webdav"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func startWebDAVServer(t *testing.T) (*httptest.Server, webdav.FileSystem) {
+	t.Helper()
+
+	fs := webdav.NewMemFS()
+	h := &webdav.Handler{
+		FileSystem: fs,
+		LockSystem: webdav.NewMemLS(),
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, _ := r.BasicAuth(); user != "foo" || pass != "bar" {
+			http.Error(w, "nope", http.StatusUnauthorized)
+			return
+		}
+		// The webdav handler does not support conditional requests.
+		if r.Method == http.MethodPut && r.Header.Get("If-None-Match") == "*" {
+			if _, err := fs.Stat(r.Context(), r.URL.Path); err == nil {
+				http.Error(w, "exists", http.StatusPreconditionFailed)
+				return
+			}
+		}
+		h.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, fs
+}
+
+func readMemFile(t *testing.T, fs webdav.FileSystem, path string) string {
+	t.Helper()
+
+	f, err := fs.OpenFile(context.Background(), path, os.O_RDONLY, 0)
+	require.NoError(t, err)
+	defer f.Close()
+
+	info, err := f.Stat()
+	require.NoError(t, err)
+
+	b := make([]byte, info.Size())
+	_, err = f.Read(b)
+	require.NoError(t, err)
+	return string(b)
+}
+
+func newTestWebDAVOutput(t *testing.T, conf string) *webdavOutput {
+	t.Helper()
+
+	parsed, err := webdavOutputConfig().ParseYAML(conf, service.NewEnvironment())
+	require.NoError(t, err)
+
+	out, err := newWebDAVOutputFromConfig(parsed, service.MockResources().Logger())
+	require.NoError(t, err)
+	return out
+}
+
+func TestWebDAVOutputCreateDirs(t *testing.T) {
+	srv, fs := startWebDAVServer(t)
+
+	out := newTestWebDAVOutput(t, `
+url: `+srv.URL+`/
+path: 'a/b/${! meta("id") }.txt'
+basic_auth:
+  enabled: true
+  username: foo
+  password: bar
+`)
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*10)
+	defer done()
+
+	require.NoError(t, out.Connect(ctx))
+
+	for _, id := range []string{"1", "2"} {
+		msg := service.NewMessage([]byte("hello " + id))
+		msg.MetaSet("id", id)
+		require.NoError(t, out.Write(ctx, msg))
+	}
+
+	// Files are overwritten by default.
+	msg := service.NewMessage([]byte("hello again"))
+	msg.MetaSet("id", "1")
+	require.NoError(t, out.Write(ctx, msg))
+
+	require.NoError(t, out.Close(ctx))
+
+	assert.Equal(t, "hello again", readMemFile(t, fs, "/a/b/1.txt"))
+	assert.Equal(t, "hello 2", readMemFile(t, fs, "/a/b/2.txt"))
+}
+
+func TestWebDAVOutputNoCreateDirs(t *testing.T) {
+	srv, _ := startWebDAVServer(t)
+
+	out := newTestWebDAVOutput(t, `
+url: `+srv.URL+`
+path: 'a/b/c.txt'
+create_dirs: false
+basic_auth:
+  enabled: true
+  username: foo
+  password: bar
+`)
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*10)
+	defer done()
+
+	require.NoError(t, out.Connect(ctx))
+	err := out.Write(ctx, service.NewMessage([]byte("hello")))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "returned status 404")
+}
+
+func TestWebDAVOutputIfExists(t *testing.T) {
+	srv, fs := startWebDAVServer(t)
+
+	for _, test := range []struct {
+		ifExists string
+		errs     bool
+	}{
+		{ifExists: "skip"},
+		{ifExists: "fail", errs: true},
+	} {
+		out := newTestWebDAVOutput(t, `
+url: `+srv.URL+`
+path: 'foo.txt'
+if_exists: `+test.ifExists+`
+basic_auth:
+  enabled: true
+  username: foo
+  password: bar
+`)
+
+		ctx, done := context.WithTimeout(context.Background(), time.Second*10)
+		defer done()
+
+		require.NoError(t, out.Connect(ctx))
+		if test.ifExists == "skip" {
+			require.NoError(t, out.Write(ctx, service.NewMessage([]byte("first"))))
+		}
+
+		err := out.Write(ctx, service.NewMessage([]byte("second")))
+		if test.errs {
+			require.Error(t, err, test.ifExists)
+			assert.Contains(t, err.Error(), "already exists")
+		} else {
+			require.NoError(t, err, test.ifExists)
+		}
+		assert.Equal(t, "first", readMemFile(t, fs, "/foo.txt"))
+	}
+}
+
+func TestWebDAVOutputAuthError(t *testing.T) {
+	srv, _ := startWebDAVServer(t)
+
+	out := newTestWebDAVOutput(t, `
+url: `+srv.URL+`
+path: 'foo.txt'
+`)
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*10)
+	defer done()
+
+	require.NoError(t, out.Connect(ctx))
+	err := out.Write(ctx, service.NewMessage([]byte("hello")))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "returned status 401")
+}
+
+func TestWebDAVOutputConfigErrors(t *testing.T) {
+	parsed, err := webdavOutputConfig().ParseYAML(`
+url: ftp://example.com
+path: foo
+`, service.NewEnvironment())
+	require.NoError(t, err)
+
+	_, err = newWebDAVOutputFromConfig(parsed, service.MockResources().Logger())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "url scheme must be http or https")
+}
@@ -5,7 +5,10 @@
 package xml
 
 import (
+	"bytes"
 	"encoding/xml"
+	"io"
+	"strings"
 
 	"github.com/clbanning/mxj/v2"
 	"golang.org/x/net/html/charset"
@@ -27,3 +30,47 @@ func ToMap(xmlBytes []byte, cast bool) (map[string]interface{}, error) {
 	}
 	return map[string]interface{}(root), nil
 }
+
+// FromMap serializes a generic structure as an XML document, where attributes
+// are identified by keys prefixed with a hyphen and element text by the key
+// `#text`.
+func FromMap(root map[string]interface{}) ([]byte, error) {
+	return mxj.Map(root).Xml()
+}
+
+// NamespaceDeclarations returns the attribute paths of all namespace
+// declarations within an XML document, in the same dot separated form as the
+// structure returned by ToMap, e.g. `root.-ns` for a declaration `xmlns:ns` on
+// the element `root`. This is required as the structure returned by ToMap
+// retains only the local name of attributes, and therefore declarations cannot
+// otherwise be told apart from regular attributes.
+func NamespaceDeclarations(xmlBytes []byte) (map[string]struct{}, error) {
+	dec := xml.NewDecoder(bytes.NewReader(xmlBytes))
+	dec.Strict = false
+	dec.CharsetReader = charset.NewReaderLabel
+
+	decls := map[string]struct{}{}
+	var path []string
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return decls, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			path = append(path, t.Name.Local)
+			for _, attr := range t.Attr {
+				if attr.Name.Space == "xmlns" || (attr.Name.Space == "" && attr.Name.Local == "xmlns") {
+					decls[strings.Join(path, ".")+".-"+attr.Name.Local] = struct{}{}
+				}
+			}
+		case xml.EndElement:
+			if len(path) > 0 {
+				path = path[:len(path)-1]
+			}
+		}
+	}
+}
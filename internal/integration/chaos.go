@@ -0,0 +1,205 @@
+package integration
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ory/dockertest/v3"
+)
+
+// StreamChaosFn is a closure that disrupts the integration environment whilst
+// a stream test is running, such as restarting a broker or injecting latency.
+// Chaos functions are expected to return once the disruption has been applied,
+// and the components under test are expected to recover from it.
+type StreamChaosFn func(t testing.TB, ctx context.Context)
+
+// ChaosRestartContainer returns a chaos function that restarts a docker
+// container of the integration environment, killing it if it fails to stop
+// within ten seconds.
+func ChaosRestartContainer(pool *dockertest.Pool, resource *dockertest.Resource) StreamChaosFn {
+	return func(t testing.TB, ctx context.Context) {
+		t.Helper()
+		t.Logf("Chaos: restarting container %v", resource.Container.Name)
+		if err := pool.Client.RestartContainer(resource.Container.ID, 10); err != nil {
+			t.Errorf("Failed to restart container: %v", err)
+		}
+	}
+}
+
+// ChaosLatency returns a chaos function that adds latency to all data flowing
+// through a proxy for a period of time.
+func ChaosLatency(proxy *ChaosProxy, latency, period time.Duration) StreamChaosFn {
+	return func(t testing.TB, ctx context.Context) {
+		t.Helper()
+		t.Logf("Chaos: adding %v of latency for %v", latency, period)
+		proxy.SetLatency(latency)
+		defer proxy.SetLatency(0)
+		select {
+		case <-time.After(period):
+		case <-ctx.Done():
+		}
+	}
+}
+
+// ChaosResetConnections returns a chaos function that abruptly closes all
+// connections that are currently open through a proxy.
+func ChaosResetConnections(proxy *ChaosProxy) StreamChaosFn {
+	return func(t testing.TB, ctx context.Context) {
+		t.Helper()
+		t.Logf("Chaos: resetting %v connections", proxy.ResetConnections())
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// ChaosProxy is a TCP proxy that sits between the components under test and
+// a service of the integration environment, allowing tests to inject latency
+// and connection resets. Components should be configured to connect to the
+// port of the proxy rather than the service.
+type ChaosProxy struct {
+	listener net.Listener
+	target   string
+
+	latency int64
+
+	connsMut sync.Mutex
+	conns    map[net.Conn]struct{}
+	closed   bool
+
+	wg sync.WaitGroup
+}
+
+// NewChaosProxy creates a proxy listening on a free local port that forwards
+// connections to a target address.
+func NewChaosProxy(target string) (*ChaosProxy, error) {
+	listener, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		return nil, err
+	}
+	p := &ChaosProxy{
+		listener: listener,
+		target:   target,
+		conns:    map[net.Conn]struct{}{},
+	}
+	p.wg.Add(1)
+	go p.loop()
+	return p, nil
+}
+
+// Port returns the port that the proxy is listening on.
+func (p *ChaosProxy) Port() string {
+	return strconv.Itoa(p.listener.Addr().(*net.TCPAddr).Port)
+}
+
+// SetLatency sets a delay to be added before each chunk of data is forwarded
+// through the proxy, a latency of zero disables the delay.
+func (p *ChaosProxy) SetLatency(d time.Duration) {
+	atomic.StoreInt64(&p.latency, int64(d))
+}
+
+// ResetConnections abruptly closes all connections that are currently open
+// through the proxy and returns the number of connections closed. New
+// connections are accepted as normal.
+func (p *ChaosProxy) ResetConnections() int {
+	p.connsMut.Lock()
+	defer p.connsMut.Unlock()
+
+	n := len(p.conns)
+	for c := range p.conns {
+		if tcpConn, ok := c.(*net.TCPConn); ok {
+			// A linger of zero results in a reset rather than a graceful close.
+			_ = tcpConn.SetLinger(0)
+		}
+		_ = c.Close()
+		delete(p.conns, c)
+	}
+	return n
+}
+
+// Close the proxy along with all connections open through it.
+func (p *ChaosProxy) Close() error {
+	p.connsMut.Lock()
+	p.closed = true
+	p.connsMut.Unlock()
+
+	err := p.listener.Close()
+	p.ResetConnections()
+	p.wg.Wait()
+	return err
+}
+
+func (p *ChaosProxy) track(conns ...net.Conn) bool {
+	p.connsMut.Lock()
+	defer p.connsMut.Unlock()
+	if p.closed {
+		return false
+	}
+	for _, c := range conns {
+		p.conns[c] = struct{}{}
+	}
+	return true
+}
+
+func (p *ChaosProxy) untrack(conns ...net.Conn) {
+	p.connsMut.Lock()
+	defer p.connsMut.Unlock()
+	for _, c := range conns {
+		delete(p.conns, c)
+	}
+}
+
+func (p *ChaosProxy) loop() {
+	defer p.wg.Done()
+	for {
+		clientConn, err := p.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		targetConn, err := net.Dial("tcp", p.target)
+		if err != nil {
+			_ = clientConn.Close()
+			continue
+		}
+
+		if !p.track(clientConn, targetConn) {
+			_ = clientConn.Close()
+			_ = targetConn.Close()
+			return
+		}
+
+		p.wg.Add(2)
+		go p.pipe(clientConn, targetConn)
+		go p.pipe(targetConn, clientConn)
+	}
+}
+
+func (p *ChaosProxy) pipe(from, to net.Conn) {
+	defer p.wg.Done()
+	defer func() {
+		_ = from.Close()
+		_ = to.Close()
+		p.untrack(from, to)
+	}()
+
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := from.Read(buf)
+		if n > 0 {
+			if latency := time.Duration(atomic.LoadInt64(&p.latency)); latency > 0 {
+				time.Sleep(latency)
+			}
+			if _, werr := to.Write(buf[:n]); werr != nil {
+				return
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"strconv"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -781,6 +782,76 @@ func StreamTestStreamParallelLossyThroughReconnect(n int) StreamTestDefinition {
 	)
 }
 
+// StreamTestChaos sends and receives N messages in parallel whilst executing
+// the chaos functions of the environment, which are spread evenly across the
+// test. All messages are expected to be received at least once, and therefore
+// duplicates are permitted. Environments without chaos functions skip the test.
+func StreamTestChaos(n int) StreamTestDefinition {
+	return namedStreamTest(
+		"can send and receive data in parallel through chaos",
+		func(t *testing.T, env *streamTestEnvironment) {
+			if len(env.chaos) == 0 {
+				t.Skip("Skipping as the environment has no chaos functions")
+			}
+			t.Parallel()
+
+			tranChan := make(chan message.Transaction)
+			input, output := initConnectors(t, tranChan, env)
+			t.Cleanup(func() {
+				closeConnectors(t, input, output)
+			})
+
+			set := map[string][]string{}
+			for i := 0; i < n; i++ {
+				payload := fmt.Sprintf("hello world: %v", i)
+				set[payload] = nil
+			}
+
+			var received int64
+			receivedChan := make(chan struct{})
+
+			wg := sync.WaitGroup{}
+			wg.Add(3)
+
+			go func() {
+				defer wg.Done()
+				for i := 0; i < n; i++ {
+					payload := fmt.Sprintf("hello world: %v", i)
+					require.NoError(t, sendMessage(env.ctx, t, tranChan, payload))
+				}
+			}()
+
+			go func() {
+				defer wg.Done()
+				defer close(receivedChan)
+				for len(set) > 0 {
+					messageInSet(t, true, true, receiveMessage(env.ctx, t, input.TransactionChan(), nil), set)
+					atomic.StoreInt64(&received, int64(n-len(set)))
+				}
+			}()
+
+			go func() {
+				defer wg.Done()
+				for i, chaosFn := range env.chaos {
+					threshold := int64(n * (i + 1) / (len(env.chaos) + 1))
+					for atomic.LoadInt64(&received) < threshold {
+						select {
+						case <-time.After(time.Millisecond * 10):
+						case <-receivedChan:
+							return
+						case <-env.ctx.Done():
+							return
+						}
+					}
+					chaosFn(t, env.ctx)
+				}
+			}()
+
+			wg.Wait()
+		},
+	)
+}
+
 // GetMessageFunc is a closure used to extract message contents from an output
 // directly and can be used to test outputs without the need for an input in the
 // config template.
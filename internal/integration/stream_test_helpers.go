@@ -116,6 +116,9 @@ type streamTestEnvironment struct {
 
 	allowDuplicateMessages bool
 
+	// Disruptions to apply to the environment by chaos tests.
+	chaos []StreamChaosFn
+
 	// Ugly work arounds for slow connectors.
 	sleepAfterInput  time.Duration
 	sleepAfterOutput time.Duration
@@ -271,6 +274,15 @@ func StreamTestOptSleepAfterOutput(t time.Duration) StreamTestOptFunc {
 	}
 }
 
+// StreamTestOptChaos adds chaos functions to be executed by chaos tests in
+// order to disrupt the integration environment. Components of chaos tests are
+// expected to recover from these disruptions with at-least-once delivery.
+func StreamTestOptChaos(fns ...StreamChaosFn) StreamTestOptFunc {
+	return func(env *streamTestEnvironment) {
+		env.chaos = append(env.chaos, fns...)
+	}
+}
+
 // StreamTestOptPreTest adds a closure to be executed before each test.
 func StreamTestOptPreTest(fn StreamPreTestFn) StreamTestOptFunc {
 	return func(env *streamTestEnvironment) {
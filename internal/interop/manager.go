@@ -5,6 +5,7 @@ import (
 	"net/http"
 
 	"github.com/benthosdev/benthos/v4/internal/bloblang"
+	"github.com/benthosdev/benthos/v4/internal/checkpoint"
 	"github.com/benthosdev/benthos/v4/internal/component/cache"
 	"github.com/benthosdev/benthos/v4/internal/component/input"
 	"github.com/benthosdev/benthos/v4/internal/component/metrics"
@@ -59,7 +60,27 @@ type Manager interface {
 	AccessRateLimit(ctx context.Context, name string, fn func(ratelimit.V1)) error
 	// StoreRateLimit(ctx context.Context, name string, conf ratelimit.Config) error
 
+	ProbeCheckpoint(name string) bool
+	AccessCheckpoint(ctx context.Context, name string, fn func(*checkpoint.Store)) error
+
+	GetOrSetGeneric(key, value interface{}) (actual interface{}, loaded bool)
+
 	GetPipe(name string) (<-chan message.Transaction, error)
 	SetPipe(name string, t <-chan message.Transaction)
 	UnsetPipe(name string, t <-chan message.Transaction)
+
+	GetPipeSubscribers(name string) []*PipeSubscriber
+	SubscribePipe(name string, s *PipeSubscriber)
+	UnsubscribePipe(name string, s *PipeSubscriber)
+}
+
+// PipeSubscriber describes a consumer of a named pipe that wishes to receive
+// every transaction sent over the pipe rather than sharing them with other
+// consumers, which is honoured by outputs that broadcast transactions.
+type PipeSubscriber struct {
+	// Transactions receives each broadcast transaction.
+	Transactions chan<- message.Transaction
+
+	// Done is closed once the subscriber is no longer consuming transactions.
+	Done <-chan struct{}
 }
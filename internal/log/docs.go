@@ -8,10 +8,31 @@ func Spec() docs.FieldSpecs {
 		docs.FieldString("level", "Set the minimum severity level for emitting logs.").HasOptions(
 			"OFF", "FATAL", "ERROR", "WARN", "INFO", "DEBUG", "TRACE", "ALL", "NONE",
 		).HasDefault("INFO").LinterFunc(nil),
+		docs.FieldString("level_overrides", "A map of component paths to log levels, overriding the minimum severity level of logs emitted by those components and any of their children. The most specific path that matches a component is used. Overrides can also be changed at runtime via the `/log/levels` HTTP endpoint.", map[string]string{
+			"root.input":                 "DEBUG",
+			"root.pipeline.processors.2": "TRACE",
+		}).Map().Advanced().HasDefault(map[string]string{}).AtVersion("4.1.0"),
 		docs.FieldString("format", "Set the format of emitted logs.").HasOptions("json", "logfmt").HasDefault("logfmt"),
 		docs.FieldBool("add_timestamp", "Whether to include timestamps in logs.").HasDefault(false),
 		docs.FieldString("static_fields", "A map of key/value pairs to add to each structured log.").Map().HasDefault(map[string]string{
 			"@service": "benthos",
 		}),
+		docs.FieldObject("file", "Experimental: Specify fields for optionally writing logs to a file, with optional rotation.").WithChildren(
+			docs.FieldString("path", "The file path to write logs to, if the file does not exist it will be created. Leave this field empty or unset to disable file based logging.").HasDefault(""),
+			docs.FieldBool("rotate", "Whether to rotate log files, which are rotated once they reach `rotate_max_size_mb` and, if set, every `rotate_interval`. Rotated files are renamed to include a timestamp.").HasDefault(false),
+			docs.FieldInt("rotate_max_size_mb", "The maximum size in megabytes of a log file before it is rotated.").Advanced().HasDefault(100),
+			docs.FieldString("rotate_interval", "An optional period of time after which the log file is rotated regardless of its size.", "24h", "1h").Advanced().HasDefault(""),
+			docs.FieldInt("rotate_max_age_days", "The maximum number of days to retain rotated log files based on their timestamp, a value of zero retains them indefinitely.").Advanced().HasDefault(0),
+			docs.FieldInt("rotate_max_backups", "The maximum number of rotated log files to retain, a value of zero retains all of them.").Advanced().HasDefault(0),
+		).AtVersion("4.1.0"),
+		docs.FieldObject("syslog", "Experimental: Specify fields for optionally sending logs to a syslog server as [RFC5424](https://datatracker.ietf.org/doc/html/rfc5424) messages, in addition to the standard log output.").WithChildren(
+			docs.FieldString("network", "The network type of the syslog server, one of `tcp`, `udp` or `unix`. Leave this field empty or unset to disable syslog logging.", "tcp", "udp", "unix").HasDefault(""),
+			docs.FieldString("address", "The address of the syslog server, which is a file path when the network is `unix`.", "localhost:514", "/dev/log").HasDefault(""),
+			docs.FieldString("facility", "The syslog facility of messages.").HasOptions(
+				"kern", "user", "mail", "daemon", "auth", "syslog", "lpr", "news", "uucp", "cron", "authpriv", "ftp",
+				"local0", "local1", "local2", "local3", "local4", "local5", "local6", "local7",
+			).Advanced().HasDefault("user"),
+			docs.FieldString("app_name", "The application name of messages.").Advanced().HasDefault("benthos"),
+		).AtVersion("4.1.0"),
 	}
 }
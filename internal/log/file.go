@@ -0,0 +1,98 @@
+package log
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// FileConfig contains configuration fields for writing logs to a file.
+type FileConfig struct {
+	Path             string `json:"path" yaml:"path"`
+	Rotate           bool   `json:"rotate" yaml:"rotate"`
+	RotateMaxSizeMB  int    `json:"rotate_max_size_mb" yaml:"rotate_max_size_mb"`
+	RotateInterval   string `json:"rotate_interval" yaml:"rotate_interval"`
+	RotateMaxAgeDays int    `json:"rotate_max_age_days" yaml:"rotate_max_age_days"`
+	RotateMaxBackups int    `json:"rotate_max_backups" yaml:"rotate_max_backups"`
+}
+
+// NewFileConfig returns a FileConfig with default values.
+func NewFileConfig() FileConfig {
+	return FileConfig{
+		Path:             "",
+		Rotate:           false,
+		RotateMaxSizeMB:  100,
+		RotateInterval:   "",
+		RotateMaxAgeDays: 0,
+		RotateMaxBackups: 0,
+	}
+}
+
+// rotatingFile is a log file that is rotated at a regular interval until it is
+// closed.
+type rotatingFile struct {
+	*lumberjack.Logger
+
+	ticker *time.Ticker
+	done   chan struct{}
+}
+
+func (r *rotatingFile) loop() {
+	for {
+		select {
+		case <-r.ticker.C:
+			_ = r.Rotate()
+		case <-r.done:
+			return
+		}
+	}
+}
+
+// Close stops the rotation of the file and closes it.
+func (r *rotatingFile) Close() error {
+	r.ticker.Stop()
+	close(r.done)
+	return r.Logger.Close()
+}
+
+// newFileWriter creates a writer that appends logs to a file, which is rotated
+// once it reaches a maximum size and, optionally, at a regular interval.
+func newFileWriter(conf FileConfig) (io.WriteCloser, error) {
+	if conf.RotateMaxSizeMB < 0 {
+		return nil, fmt.Errorf("rotate_max_size_mb must not be negative, got %v", conf.RotateMaxSizeMB)
+	}
+
+	l := &lumberjack.Logger{
+		Filename:   conf.Path,
+		MaxSize:    conf.RotateMaxSizeMB,
+		MaxAge:     conf.RotateMaxAgeDays,
+		MaxBackups: conf.RotateMaxBackups,
+	}
+	if !conf.Rotate {
+		// Lumberjack always rotates at some size, and therefore when rotation
+		// is disabled we set a size that should never be reached.
+		l.MaxSize = 1 << 30
+		l.MaxAge, l.MaxBackups = 0, 0
+		return l, nil
+	}
+
+	if conf.RotateInterval != "" {
+		interval, err := time.ParseDuration(conf.RotateInterval)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse rotate_interval: %w", err)
+		}
+		if interval <= 0 {
+			return nil, fmt.Errorf("rotate_interval must be greater than zero, got %v", interval)
+		}
+		r := &rotatingFile{
+			Logger: l,
+			ticker: time.NewTicker(interval),
+			done:   make(chan struct{}),
+		}
+		go r.loop()
+		return r, nil
+	}
+	return l, nil
+}
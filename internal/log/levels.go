@@ -0,0 +1,148 @@
+package log
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/sirupsen/logrus"
+)
+
+// LevelOverrider is an optional interface implemented by loggers that support
+// overriding the log level of components by their path, which can be changed
+// at runtime.
+type LevelOverrider interface {
+	// SetLevelOverride sets the log level of all components within a given
+	// path, e.g. `root.input`. An empty level removes the override.
+	SetLevelOverride(path, level string) error
+
+	// LevelOverrides returns the current log level overrides by path.
+	LevelOverrides() map[string]string
+}
+
+func parseLevel(level string) (logrus.Level, error) {
+	switch strings.ToUpper(level) {
+	case "OFF", "NONE":
+		return logrus.PanicLevel, nil
+	case "FATAL":
+		return logrus.FatalLevel, nil
+	case "ERROR":
+		return logrus.ErrorLevel, nil
+	case "WARN":
+		return logrus.WarnLevel, nil
+	case "INFO":
+		return logrus.InfoLevel, nil
+	case "DEBUG":
+		return logrus.DebugLevel, nil
+	case "TRACE", "ALL":
+		return logrus.TraceLevel, nil
+	}
+	return logrus.PanicLevel, fmt.Errorf("log level '%v' not recognized", level)
+}
+
+func levelStr(level logrus.Level) string {
+	switch level {
+	case logrus.FatalLevel:
+		return "FATAL"
+	case logrus.ErrorLevel:
+		return "ERROR"
+	case logrus.WarnLevel:
+		return "WARN"
+	case logrus.InfoLevel:
+		return "INFO"
+	case logrus.DebugLevel:
+		return "DEBUG"
+	case logrus.TraceLevel:
+		return "TRACE"
+	}
+	return "OFF"
+}
+
+//------------------------------------------------------------------------------
+
+// levelOverrides is shared by a logger and all of its children, where the
+// generation is incremented with each change so that children can cache the
+// level resolved for their path.
+type levelOverrides struct {
+	base logrus.Level
+	gen  uint64
+
+	mut    sync.RWMutex
+	byPath map[string]logrus.Level
+}
+
+func newLevelOverrides(base logrus.Level) *levelOverrides {
+	return &levelOverrides{
+		base:   base,
+		byPath: map[string]logrus.Level{},
+	}
+}
+
+func (o *levelOverrides) set(path string, level logrus.Level) {
+	o.mut.Lock()
+	o.byPath[path] = level
+	o.mut.Unlock()
+	atomic.AddUint64(&o.gen, 1)
+}
+
+func (o *levelOverrides) remove(path string) {
+	o.mut.Lock()
+	delete(o.byPath, path)
+	o.mut.Unlock()
+	atomic.AddUint64(&o.gen, 1)
+}
+
+// resolve returns the level of the override with the longest path that either
+// matches or is a parent of the provided path, or the base level if none do.
+func (o *levelOverrides) resolve(path string) logrus.Level {
+	o.mut.RLock()
+	defer o.mut.RUnlock()
+
+	level, matchedLen := o.base, -1
+	for p, l := range o.byPath {
+		if len(p) <= matchedLen {
+			continue
+		}
+		if path == p || strings.HasPrefix(path, p+".") {
+			level, matchedLen = l, len(p)
+		}
+	}
+	return level
+}
+
+func (o *levelOverrides) strMap() map[string]string {
+	o.mut.RLock()
+	defer o.mut.RUnlock()
+
+	m := make(map[string]string, len(o.byPath))
+	for p, l := range o.byPath {
+		m[p] = levelStr(l)
+	}
+	return m
+}
+
+//------------------------------------------------------------------------------
+
+// levelCache holds the level resolved for the path of a specific logger.
+type levelCache struct {
+	gen   uint64
+	level uint32
+}
+
+func newLevelCache() *levelCache {
+	// The generation of overrides starts at zero, and so the cache begins at a
+	// generation that can never match in order to force an initial resolve.
+	return &levelCache{gen: ^uint64(0)}
+}
+
+func (c *levelCache) get(o *levelOverrides, path string) logrus.Level {
+	gen := atomic.LoadUint64(&o.gen)
+	if atomic.LoadUint64(&c.gen) == gen {
+		return logrus.Level(atomic.LoadUint32(&c.level))
+	}
+	level := o.resolve(path)
+	atomic.StoreUint32(&c.level, uint32(level))
+	atomic.StoreUint64(&c.gen, gen)
+	return level
+}
@@ -11,21 +11,27 @@ import (
 
 // Config holds configuration options for a logger object.
 type Config struct {
-	LogLevel     string            `json:"level" yaml:"level"`
-	Format       string            `json:"format" yaml:"format"`
-	AddTimeStamp bool              `json:"add_timestamp" yaml:"add_timestamp"`
-	StaticFields map[string]string `json:"static_fields" yaml:"static_fields"`
+	LogLevel       string            `json:"level" yaml:"level"`
+	LevelOverrides map[string]string `json:"level_overrides" yaml:"level_overrides"`
+	Format         string            `json:"format" yaml:"format"`
+	AddTimeStamp   bool              `json:"add_timestamp" yaml:"add_timestamp"`
+	StaticFields   map[string]string `json:"static_fields" yaml:"static_fields"`
+	File           FileConfig        `json:"file" yaml:"file"`
+	Syslog         SyslogConfig      `json:"syslog" yaml:"syslog"`
 }
 
 // NewConfig returns a config struct with the default values for each field.
 func NewConfig() Config {
 	return Config{
-		LogLevel:     "INFO",
-		Format:       "logfmt",
-		AddTimeStamp: false,
+		LogLevel:       "INFO",
+		LevelOverrides: map[string]string{},
+		Format:         "logfmt",
+		AddTimeStamp:   false,
 		StaticFields: map[string]string{
 			"@service": "benthos",
 		},
+		File:   NewFileConfig(),
+		Syslog: NewSyslogConfig(),
 	}
 }
 
@@ -77,14 +83,43 @@ func (conf *Config) UnmarshalYAML(unmarshal func(interface{}) error) error {
 // Logger is an object with support for levelled logging and modular components.
 type Logger struct {
 	entry *logrus.Entry
+
+	// The component path of this logger, which is taken from the path field,
+	// is used to resolve the level of the logger from level overrides.
+	path      string
+	overrides *levelOverrides
+	level     *levelCache
+
+	// Targets of the logger that must be closed on shutdown.
+	closers []io.Closer
 }
 
 // NewV2 returns a new logger from a config, or returns an error if the config
-// is invalid.
+// is invalid. Logs are written to the provided stream unless the config
+// specifies a file to write to.
 func NewV2(stream io.Writer, config Config) (Modular, error) {
 	logger := logrus.New()
 	logger.Out = stream
 
+	var closers []io.Closer
+	if config.File.Path != "" {
+		fileWriter, err := newFileWriter(config.File)
+		if err != nil {
+			return nil, err
+		}
+		logger.Out = fileWriter
+		closers = append(closers, fileWriter)
+	}
+
+	if config.Syslog.Network != "" {
+		hook, err := newSyslogHook(config.Syslog)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create syslog target: %w", err)
+		}
+		logger.AddHook(hook)
+		closers = append(closers, hook)
+	}
+
 	switch config.Format {
 	case "json":
 		logger.SetFormatter(&logrus.JSONFormatter{
@@ -99,31 +134,48 @@ func NewV2(stream io.Writer, config Config) (Modular, error) {
 		return nil, fmt.Errorf("log format '%v' not recognized", config.Format)
 	}
 
-	switch strings.ToUpper(config.LogLevel) {
-	case "OFF", "NONE":
-		logger.Level = logrus.PanicLevel
-	case "FATAL":
-		logger.Level = logrus.FatalLevel
-	case "ERROR":
-		logger.Level = logrus.ErrorLevel
-	case "WARN":
-		logger.Level = logrus.WarnLevel
-	case "INFO":
-		logger.Level = logrus.InfoLevel
-	case "DEBUG":
-		logger.Level = logrus.DebugLevel
-	case "TRACE", "ALL":
-		logger.Level = logrus.TraceLevel
-		logger.Level = logrus.TraceLevel
+	baseLevel := logrus.InfoLevel
+	if level, err := parseLevel(config.LogLevel); err == nil {
+		baseLevel = level
+	}
+
+	overrides := newLevelOverrides(baseLevel)
+	for path, levelStr := range config.LevelOverrides {
+		level, err := parseLevel(levelStr)
+		if err != nil {
+			return nil, fmt.Errorf("level override for path '%v': %w", path, err)
+		}
+		overrides.set(path, level)
 	}
 
+	// Levels are enforced by each logger based on its path, and therefore the
+	// underlying logger emits all levels.
+	logger.Level = logrus.TraceLevel
+
 	sFields := logrus.Fields{}
 	for k, v := range config.StaticFields {
 		sFields[k] = v
 	}
 	logEntry := logger.WithFields(sFields)
 
-	return &Logger{entry: logEntry}, nil
+	return &Logger{
+		entry:     logEntry,
+		overrides: overrides,
+		level:     newLevelCache(),
+		closers:   closers,
+	}, nil
+}
+
+// Close closes any files or connections that logs are written to, after which
+// logs written to those targets are lost.
+func (l *Logger) Close() error {
+	var err error
+	for _, c := range l.closers {
+		if cerr := c.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	return err
 }
 
 //------------------------------------------------------------------------------
@@ -132,7 +184,12 @@ func NewV2(stream io.Writer, config Config) (Modular, error) {
 func Noop() Modular {
 	logger := logrus.New()
 	logger.Out = io.Discard
-	return &Logger{entry: logger.WithFields(logrus.Fields{})}
+	logger.Level = logrus.PanicLevel
+	return &Logger{
+		entry:     logger.WithFields(logrus.Fields{}),
+		overrides: newLevelOverrides(logrus.PanicLevel),
+		level:     newLevelCache(),
+	}
 }
 
 // WithFields returns a logger with new fields added to the JSON formatted
@@ -145,12 +202,17 @@ func (l *Logger) WithFields(inboundFields map[string]string) Modular {
 
 	newLogger := *l
 	newLogger.entry = l.entry.WithFields(newFields)
+	if path, exists := inboundFields["path"]; exists {
+		newLogger.path = path
+		newLogger.level = newLevelCache()
+	}
 	return &newLogger
 }
 
 // With returns a copy of the logger with new labels added to the logging
 // context.
 func (l *Logger) With(keyValues ...interface{}) Modular {
+	newLogger := *l
 	newEntry := l.entry.WithFields(logrus.Fields{})
 	for i := 0; i < (len(keyValues) - 1); i += 2 {
 		key, ok := keyValues[i].(string)
@@ -158,42 +220,87 @@ func (l *Logger) With(keyValues ...interface{}) Modular {
 			continue
 		}
 		newEntry = newEntry.WithField(key, keyValues[i+1])
+		if path, ok := keyValues[i+1].(string); ok && key == "path" {
+			newLogger.path = path
+			newLogger.level = newLevelCache()
+		}
 	}
-
-	newLogger := *l
 	newLogger.entry = newEntry
 	return &newLogger
 }
 
+// SetLevelOverride sets the log level of all components within a given path,
+// e.g. `root.input`. An empty level removes the override. Overrides are shared
+// by a logger and all loggers derived from it.
+func (l *Logger) SetLevelOverride(path, level string) error {
+	if level == "" {
+		l.overrides.remove(path)
+		return nil
+	}
+	lvl, err := parseLevel(level)
+	if err != nil {
+		return err
+	}
+	l.overrides.set(path, lvl)
+	return nil
+}
+
+// LevelOverrides returns the current log level overrides by path.
+func (l *Logger) LevelOverrides() map[string]string {
+	return l.overrides.strMap()
+}
+
+func (l *Logger) enabled(level logrus.Level) bool {
+	return l.level.get(l.overrides, l.path) >= level
+}
+
 //------------------------------------------------------------------------------
 
 // Fatalf prints a fatal message to the console. Does NOT cause panic.
 func (l *Logger) Fatalf(format string, v ...interface{}) {
+	if !l.enabled(logrus.FatalLevel) {
+		return
+	}
 	l.entry.Fatalf(strings.TrimSuffix(format, "\n"), v...)
 }
 
 // Errorf prints an error message to the console.
 func (l *Logger) Errorf(format string, v ...interface{}) {
+	if !l.enabled(logrus.ErrorLevel) {
+		return
+	}
 	l.entry.Errorf(strings.TrimSuffix(format, "\n"), v...)
 }
 
 // Warnf prints a warning message to the console.
 func (l *Logger) Warnf(format string, v ...interface{}) {
+	if !l.enabled(logrus.WarnLevel) {
+		return
+	}
 	l.entry.Warnf(strings.TrimSuffix(format, "\n"), v...)
 }
 
 // Infof prints an information message to the console.
 func (l *Logger) Infof(format string, v ...interface{}) {
+	if !l.enabled(logrus.InfoLevel) {
+		return
+	}
 	l.entry.Infof(strings.TrimSuffix(format, "\n"), v...)
 }
 
 // Debugf prints a debug message to the console.
 func (l *Logger) Debugf(format string, v ...interface{}) {
+	if !l.enabled(logrus.DebugLevel) {
+		return
+	}
 	l.entry.Debugf(strings.TrimSuffix(format, "\n"), v...)
 }
 
 // Tracef prints a trace message to the console.
 func (l *Logger) Tracef(format string, v ...interface{}) {
+	if !l.enabled(logrus.TraceLevel) {
+		return
+	}
 	l.entry.Tracef(strings.TrimSuffix(format, "\n"), v...)
 }
 
@@ -201,30 +308,48 @@ func (l *Logger) Tracef(format string, v ...interface{}) {
 
 // Fatalln prints a fatal message to the console. Does NOT cause panic.
 func (l *Logger) Fatalln(message string) {
+	if !l.enabled(logrus.FatalLevel) {
+		return
+	}
 	l.entry.Fatalln(message)
 }
 
 // Errorln prints an error message to the console.
 func (l *Logger) Errorln(message string) {
+	if !l.enabled(logrus.ErrorLevel) {
+		return
+	}
 	l.entry.Errorln(message)
 }
 
 // Warnln prints a warning message to the console.
 func (l *Logger) Warnln(message string) {
+	if !l.enabled(logrus.WarnLevel) {
+		return
+	}
 	l.entry.Warnln(message)
 }
 
 // Infoln prints an information message to the console.
 func (l *Logger) Infoln(message string) {
+	if !l.enabled(logrus.InfoLevel) {
+		return
+	}
 	l.entry.Infoln(message)
 }
 
 // Debugln prints a debug message to the console.
 func (l *Logger) Debugln(message string) {
+	if !l.enabled(logrus.DebugLevel) {
+		return
+	}
 	l.entry.Debugln(message)
 }
 
 // Traceln prints a trace message to the console.
 func (l *Logger) Traceln(message string) {
+	if !l.enabled(logrus.TraceLevel) {
+		return
+	}
 	l.entry.Traceln(message)
 }
@@ -2,7 +2,15 @@ package log
 
 import (
 	"bytes"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -136,3 +144,179 @@ func TestLogLevels(t *testing.T) {
 		}
 	}
 }
+
+func TestLoggerLevelOverrides(t *testing.T) {
+	loggerConfig := NewConfig()
+	loggerConfig.LogLevel = "WARN"
+	loggerConfig.LevelOverrides = map[string]string{
+		"root.input":              "DEBUG",
+		"root.input.processors.0": "ERROR",
+	}
+	loggerConfig.StaticFields = map[string]string{}
+
+	var buf bytes.Buffer
+
+	logger, err := NewV2(&buf, loggerConfig)
+	require.NoError(t, err)
+
+	inLogger := logger.WithFields(map[string]string{"path": "root.input"})
+	procLogger := logger.WithFields(map[string]string{"path": "root.input.processors.0"})
+	inputsLogger := logger.WithFields(map[string]string{"path": "root.inputs"})
+
+	logger.Infoln("root info")
+	inLogger.Debugln("input debug")
+	procLogger.Warnln("processor warn")
+	procLogger.Errorln("processor error")
+	inputsLogger.Infoln("inputs info")
+
+	require.NoError(t, logger.(LevelOverrider).SetLevelOverride("root.input", ""))
+	require.NoError(t, logger.(LevelOverrider).SetLevelOverride("root", "INFO"))
+	assert.Equal(t, map[string]string{
+		"root":                    "INFO",
+		"root.input.processors.0": "ERROR",
+	}, logger.(LevelOverrider).LevelOverrides())
+
+	inLogger.Debugln("input debug again")
+	inLogger.Infoln("input info")
+	inputsLogger.Infoln("inputs info again")
+
+	assert.Error(t, logger.(LevelOverrider).SetLevelOverride("root", "nope"))
+
+	expected := `level=debug msg="input debug" path=root.input
+level=error msg="processor error" path=root.input.processors.0
+level=info msg="input info" path=root.input
+level=info msg="inputs info again" path=root.inputs
+`
+	assert.Equal(t, expected, buf.String())
+}
+
+func TestLoggerFile(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "benthos.log")
+
+	loggerConfig := NewConfig()
+	loggerConfig.StaticFields = map[string]string{}
+	loggerConfig.File.Path = logPath
+	loggerConfig.File.Rotate = true
+	loggerConfig.File.RotateInterval = "1h"
+
+	var buf bytes.Buffer
+
+	logger, err := NewV2(&buf, loggerConfig)
+	require.NoError(t, err)
+
+	logger.Infoln("hello world")
+	logger.Debugln("not this")
+
+	fileBytes, err := os.ReadFile(logPath)
+	require.NoError(t, err)
+	assert.Equal(t, "level=info msg=\"hello world\"\n", string(fileBytes))
+	assert.Empty(t, buf.String())
+
+	require.NoError(t, logger.(*Logger).Close())
+}
+
+func TestLoggerSyslog(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		conn.Close()
+	})
+
+	loggerConfig := NewConfig()
+	loggerConfig.StaticFields = map[string]string{}
+	loggerConfig.Syslog.Network = "udp"
+	loggerConfig.Syslog.Address = conn.LocalAddr().String()
+	loggerConfig.Syslog.Facility = "local0"
+
+	var buf bytes.Buffer
+
+	logger, err := NewV2(&buf, loggerConfig)
+	require.NoError(t, err)
+
+	logger.Warnln("hello world")
+	assert.Equal(t, "level=warning msg=\"hello world\"\n", buf.String())
+
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second*5)))
+	msgBytes := make([]byte, 1024)
+	n, _, err := conn.ReadFrom(msgBytes)
+	require.NoError(t, err)
+
+	msg := string(msgBytes[:n])
+	assert.True(t, strings.HasPrefix(msg, "<132>1 "), msg)
+	assert.True(t, strings.HasSuffix(msg, " benthos "+strconv.Itoa(os.Getpid())+" - - level=warning msg=\"hello world\""), msg)
+}
+
+func TestLoggerSyslogTCPConcurrent(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		ln.Close()
+	})
+
+	received := make(chan []byte, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		_ = conn.SetReadDeadline(time.Now().Add(time.Second * 5))
+		b, _ := io.ReadAll(conn)
+		received <- b
+	}()
+
+	loggerConfig := NewConfig()
+	loggerConfig.StaticFields = map[string]string{}
+	loggerConfig.Syslog.Network = "tcp"
+	loggerConfig.Syslog.Address = ln.Addr().String()
+
+	logger, err := NewV2(io.Discard, loggerConfig)
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			logger.Infof("message %v", i)
+		}(i)
+	}
+	wg.Wait()
+	require.NoError(t, logger.(*Logger).Close())
+
+	var b []byte
+	select {
+	case b = <-received:
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out")
+	}
+
+	// Every message must be framed intact with its octet count.
+	var count int
+	for len(b) > 0 {
+		sep := bytes.IndexByte(b, ' ')
+		require.True(t, sep > 0, string(b))
+		size, err := strconv.Atoi(string(b[:sep]))
+		require.NoError(t, err)
+		require.True(t, len(b) >= sep+1+size, string(b))
+		assert.Contains(t, string(b[sep+1:sep+1+size]), "msg=\"message ")
+		b = b[sep+1+size:]
+		count++
+	}
+	assert.Equal(t, 10, count)
+}
+
+func TestLoggerSyslogBadConfig(t *testing.T) {
+	loggerConfig := NewConfig()
+	loggerConfig.Syslog.Network = "nope"
+	loggerConfig.Syslog.Address = "localhost:514"
+
+	_, err := NewV2(io.Discard, loggerConfig)
+	require.Error(t, err)
+
+	loggerConfig.Syslog.Network = "udp"
+	loggerConfig.Syslog.Facility = "nope"
+
+	_, err = NewV2(io.Discard, loggerConfig)
+	require.Error(t, err)
+}
@@ -0,0 +1,254 @@
+package log
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// SyslogConfig contains configuration fields for sending logs to a syslog
+// server.
+type SyslogConfig struct {
+	Network  string `json:"network" yaml:"network"`
+	Address  string `json:"address" yaml:"address"`
+	Facility string `json:"facility" yaml:"facility"`
+	AppName  string `json:"app_name" yaml:"app_name"`
+}
+
+// NewSyslogConfig returns a SyslogConfig with default values.
+func NewSyslogConfig() SyslogConfig {
+	return SyslogConfig{
+		Network:  "",
+		Address:  "",
+		Facility: "user",
+		AppName:  "benthos",
+	}
+}
+
+var syslogFacilities = map[string]int{
+	"kern":     0,
+	"user":     1,
+	"mail":     2,
+	"daemon":   3,
+	"auth":     4,
+	"syslog":   5,
+	"lpr":      6,
+	"news":     7,
+	"uucp":     8,
+	"cron":     9,
+	"authpriv": 10,
+	"ftp":      11,
+	"local0":   16,
+	"local1":   17,
+	"local2":   18,
+	"local3":   19,
+	"local4":   20,
+	"local5":   21,
+	"local6":   22,
+	"local7":   23,
+}
+
+func syslogSeverity(level logrus.Level) int {
+	switch level {
+	case logrus.PanicLevel:
+		return 0
+	case logrus.FatalLevel:
+		return 2
+	case logrus.ErrorLevel:
+		return 3
+	case logrus.WarnLevel:
+		return 4
+	case logrus.InfoLevel:
+		return 6
+	}
+	return 7
+}
+
+//------------------------------------------------------------------------------
+
+// syslogHook is a logrus hook that sends each log as an RFC5424 message to a
+// syslog server over TCP, UDP or a unix socket. Messages sent over stream
+// connections are framed with octet counting as described in RFC6587.
+type syslogHook struct {
+	network  string
+	address  string
+	facility int
+	appName  string
+	hostname string
+	pid      string
+
+	mut    sync.Mutex
+	conn   net.Conn
+	stream bool
+}
+
+func newSyslogHook(conf SyslogConfig) (*syslogHook, error) {
+	switch conf.Network {
+	case "tcp", "udp", "unix":
+	default:
+		return nil, fmt.Errorf("syslog network '%v' not recognized, expected tcp, udp or unix", conf.Network)
+	}
+	if conf.Address == "" {
+		return nil, fmt.Errorf("a syslog address must be specified")
+	}
+	facility, exists := syslogFacilities[conf.Facility]
+	if !exists {
+		return nil, fmt.Errorf("syslog facility '%v' not recognized", conf.Facility)
+	}
+
+	hostname, _ := os.Hostname()
+	if hostname == "" {
+		hostname = "-"
+	}
+	appName := conf.AppName
+	if appName == "" {
+		appName = "-"
+	}
+
+	h := &syslogHook{
+		network:  conf.Network,
+		address:  conf.Address,
+		facility: facility,
+		appName:  appName,
+		hostname: hostname,
+		pid:      strconv.Itoa(os.Getpid()),
+	}
+	if err := h.connect(); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// dial opens a new connection to the syslog server, returning whether it is a
+// stream connection.
+func (h *syslogHook) dial() (net.Conn, bool, error) {
+	if h.network != "unix" {
+		conn, err := net.Dial(h.network, h.address)
+		return conn, h.network == "tcp", err
+	}
+
+	// Syslog unix sockets are typically datagram sockets, but stream sockets
+	// are also common.
+	conn, err := net.Dial("unixgram", h.address)
+	if err == nil {
+		return conn, false, nil
+	}
+	if conn, err = net.Dial("unix", h.address); err != nil {
+		return nil, false, err
+	}
+	return conn, true, nil
+}
+
+func (h *syslogHook) connect() error {
+	conn, stream, err := h.dial()
+	if err != nil {
+		return err
+	}
+	h.conn, h.stream = conn, stream
+	return nil
+}
+
+// getConn returns the current connection, dialing a new one when there isn't
+// one. The mutex is only held in order to access the connection and never
+// across network calls.
+func (h *syslogHook) getConn() (net.Conn, bool, error) {
+	h.mut.Lock()
+	conn, stream := h.conn, h.stream
+	h.mut.Unlock()
+	if conn != nil {
+		return conn, stream, nil
+	}
+
+	conn, stream, err := h.dial()
+	if err != nil {
+		return nil, false, err
+	}
+
+	h.mut.Lock()
+	defer h.mut.Unlock()
+	if h.conn != nil {
+		// Another log reconnected in the meantime.
+		_ = conn.Close()
+		return h.conn, h.stream, nil
+	}
+	h.conn, h.stream = conn, stream
+	return conn, stream, nil
+}
+
+// dropConn closes a connection that failed, unless it has already been
+// replaced.
+func (h *syslogHook) dropConn(conn net.Conn) {
+	h.mut.Lock()
+	if h.conn == conn {
+		h.conn = nil
+	}
+	h.mut.Unlock()
+	_ = conn.Close()
+}
+
+func (h *syslogHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (h *syslogHook) format(entry *logrus.Entry) ([]byte, error) {
+	msg, err := entry.Logger.Formatter.Format(entry)
+	if err != nil {
+		return nil, err
+	}
+	msg = bytes.TrimSuffix(msg, []byte("\n"))
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "<%d>1 %s %s %s %s - - ",
+		h.facility*8+syslogSeverity(entry.Level),
+		entry.Time.Format(time.RFC3339Nano),
+		h.hostname, h.appName, h.pid,
+	)
+	buf.Write(msg)
+	return buf.Bytes(), nil
+}
+
+func (h *syslogHook) Fire(entry *logrus.Entry) error {
+	msg, err := h.format(entry)
+	if err != nil {
+		return err
+	}
+
+	// A failed write is attempted once more with a new connection.
+	for i := 0; i < 2; i++ {
+		conn, stream, cerr := h.getConn()
+		if cerr != nil {
+			err = cerr
+			continue
+		}
+
+		// Each message is written with a single call so that concurrent logs
+		// written to a stream connection aren't interleaved.
+		frame := msg
+		if stream {
+			frame = append([]byte(strconv.Itoa(len(msg))+" "), msg...)
+		}
+		if _, err = conn.Write(frame); err == nil {
+			return nil
+		}
+		h.dropConn(conn)
+	}
+	return err
+}
+
+// Close closes the connection to the syslog server.
+func (h *syslogHook) Close() error {
+	h.mut.Lock()
+	defer h.mut.Unlock()
+	if h.conn == nil {
+		return nil
+	}
+	err := h.conn.Close()
+	h.conn = nil
+	return err
+}
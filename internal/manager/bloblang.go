@@ -0,0 +1,98 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang"
+	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
+	"github.com/benthosdev/benthos/v4/internal/component"
+	"github.com/benthosdev/benthos/v4/internal/component/cache"
+	"github.com/benthosdev/benthos/v4/internal/component/ratelimit"
+)
+
+// withResourceFunctions returns a copy of a Bloblang environment where the
+// functions that access resources, if present within the environment, are
+// replaced with implementations that access the resources of the manager.
+func (t *Type) withResourceFunctions(env *bloblang.Environment) *bloblang.Environment {
+	ctors := map[string]query.FunctionCtor{
+		"cache":      t.cacheFunction,
+		"rate_limit": t.rateLimitFunction,
+	}
+
+	specs := map[string]query.FunctionSpec{}
+	env.WalkFunctions(func(name string, spec query.FunctionSpec) {
+		if _, exists := ctors[name]; exists {
+			specs[name] = spec
+		}
+	})
+	if len(specs) == 0 {
+		return env
+	}
+
+	env = env.WithoutFunctions()
+	for name, spec := range specs {
+		if err := env.RegisterFunction(spec, ctors[name]); err != nil {
+			t.logger.Errorf("Failed to register resource function %v: %v\n", name, err)
+		}
+	}
+	return env
+}
+
+func (t *Type) cacheFunction(args *query.ParsedParams) (query.Function, error) {
+	resource, err := args.FieldString("resource")
+	if err != nil {
+		return nil, err
+	}
+	key, err := args.FieldString("key")
+	if err != nil {
+		return nil, err
+	}
+	if !t.ProbeCache(resource) {
+		return nil, ErrResourceNotFound(resource)
+	}
+
+	return query.ClosureFunction("function cache", func(ctx query.FunctionContext) (interface{}, error) {
+		var value []byte
+		var cerr error
+		if err := t.AccessCache(context.Background(), resource, func(c cache.V1) {
+			value, cerr = c.Get(context.Background(), key)
+		}); err != nil {
+			return nil, err
+		}
+		if cerr != nil {
+			if errors.Is(cerr, component.ErrKeyNotFound) {
+				return nil, nil
+			}
+			return nil, cerr
+		}
+		return string(value), nil
+	}, nil), nil
+}
+
+func (t *Type) rateLimitFunction(args *query.ParsedParams) (query.Function, error) {
+	resource, err := args.FieldString("resource")
+	if err != nil {
+		return nil, err
+	}
+	if !t.ProbeRateLimit(resource) {
+		return nil, ErrResourceNotFound(resource)
+	}
+
+	return query.ClosureFunction("function rate_limit", func(ctx query.FunctionContext) (interface{}, error) {
+		var permitted bool
+		var rerr error
+		if err := t.AccessRateLimit(context.Background(), resource, func(r ratelimit.V1) {
+			var tUntil time.Duration
+			tUntil, rerr = r.Access(context.Background())
+			permitted = tUntil <= 0
+		}); err != nil {
+			return nil, err
+		}
+		if rerr != nil {
+			return nil, rerr
+		}
+		return permitted, nil
+	}, nil), nil
+}
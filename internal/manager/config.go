@@ -1,6 +1,7 @@
 package manager
 
 import (
+	"github.com/benthosdev/benthos/v4/internal/checkpoint"
 	"github.com/benthosdev/benthos/v4/internal/component/cache"
 	"github.com/benthosdev/benthos/v4/internal/component/ratelimit"
 	"github.com/benthosdev/benthos/v4/internal/old/input"
@@ -11,21 +12,23 @@ import (
 // ResourceConfig contains fields for specifying resource components at the root
 // of a Benthos config.
 type ResourceConfig struct {
-	ResourceInputs     []input.Config     `json:"input_resources,omitempty" yaml:"input_resources,omitempty"`
-	ResourceProcessors []processor.Config `json:"processor_resources,omitempty" yaml:"processor_resources,omitempty"`
-	ResourceOutputs    []output.Config    `json:"output_resources,omitempty" yaml:"output_resources,omitempty"`
-	ResourceCaches     []cache.Config     `json:"cache_resources,omitempty" yaml:"cache_resources,omitempty"`
-	ResourceRateLimits []ratelimit.Config `json:"rate_limit_resources,omitempty" yaml:"rate_limit_resources,omitempty"`
+	ResourceInputs      []input.Config           `json:"input_resources,omitempty" yaml:"input_resources,omitempty"`
+	ResourceProcessors  []processor.Config       `json:"processor_resources,omitempty" yaml:"processor_resources,omitempty"`
+	ResourceOutputs     []output.Config          `json:"output_resources,omitempty" yaml:"output_resources,omitempty"`
+	ResourceCaches      []cache.Config           `json:"cache_resources,omitempty" yaml:"cache_resources,omitempty"`
+	ResourceRateLimits  []ratelimit.Config       `json:"rate_limit_resources,omitempty" yaml:"rate_limit_resources,omitempty"`
+	ResourceCheckpoints []checkpoint.StoreConfig `json:"checkpoint_resources,omitempty" yaml:"checkpoint_resources,omitempty"`
 }
 
 // NewResourceConfig creates a ResourceConfig with default values.
 func NewResourceConfig() ResourceConfig {
 	return ResourceConfig{
-		ResourceInputs:     []input.Config{},
-		ResourceProcessors: []processor.Config{},
-		ResourceOutputs:    []output.Config{},
-		ResourceCaches:     []cache.Config{},
-		ResourceRateLimits: []ratelimit.Config{},
+		ResourceInputs:      []input.Config{},
+		ResourceProcessors:  []processor.Config{},
+		ResourceOutputs:     []output.Config{},
+		ResourceCaches:      []cache.Config{},
+		ResourceRateLimits:  []ratelimit.Config{},
+		ResourceCheckpoints: []checkpoint.StoreConfig{},
 	}
 }
 
@@ -37,5 +40,6 @@ func (r *ResourceConfig) AddFrom(extra *ResourceConfig) error {
 	r.ResourceOutputs = append(r.ResourceOutputs, extra.ResourceOutputs...)
 	r.ResourceCaches = append(r.ResourceCaches, extra.ResourceCaches...)
 	r.ResourceRateLimits = append(r.ResourceRateLimits, extra.ResourceRateLimits...)
+	r.ResourceCheckpoints = append(r.ResourceCheckpoints, extra.ResourceCheckpoints...)
 	return nil
 }
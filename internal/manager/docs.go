@@ -3,6 +3,7 @@ package manager
 import (
 	"github.com/Jeffail/gabs/v2"
 
+	"github.com/benthosdev/benthos/v4/internal/checkpoint"
 	"github.com/benthosdev/benthos/v4/internal/docs"
 )
 
@@ -42,5 +43,9 @@ func Spec() docs.FieldSpecs {
 		docs.FieldRateLimit(
 			"rate_limit_resources", "A list of rate limit resources, each must have a unique label.",
 		).Array().LinterFunc(lintResource).HasDefault([]interface{}{}),
+
+		docs.FieldObject(
+			"checkpoint_resources", "A list of checkpoint resources, each must have a unique label.",
+		).WithChildren(checkpoint.StoreFieldSpecs()...).Array().LinterFunc(lintResource).HasDefault([]interface{}{}).AtVersion("4.1.0"),
 	}
 }
@@ -89,6 +89,14 @@ func (w *inputWrapper) Connected() bool {
 	return con
 }
 
+// LastError returns the most recent error encountered by the wrapped input.
+func (w *inputWrapper) LastError() (time.Time, error) {
+	w.inputLock.Lock()
+	in := w.ctrl.input
+	w.inputLock.Unlock()
+	return component.LastErrorOf(in)
+}
+
 func (w *inputWrapper) loop() {
 	defer func() {
 		w.inputLock.Lock()
@@ -28,6 +28,17 @@ func (c *Cache) Get(ctx context.Context, key string) ([]byte, error) {
 	return []byte(i.Value), nil
 }
 
+// GetMulti gets multiple mock cache items
+func (c *Cache) GetMulti(ctx context.Context, keys ...string) (map[string][]byte, error) {
+	res := make(map[string][]byte, len(keys))
+	for _, k := range keys {
+		if i, ok := c.Values[k]; ok {
+			res[k] = []byte(i.Value)
+		}
+	}
+	return res, nil
+}
+
 // Set a mock cache item
 func (c *Cache) Set(ctx context.Context, key string, value []byte, ttl *time.Duration) error {
 	c.Values[key] = CacheItem{
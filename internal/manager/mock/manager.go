@@ -3,8 +3,10 @@ package mock
 import (
 	"context"
 	"net/http"
+	"sync"
 
 	"github.com/benthosdev/benthos/v4/internal/bloblang"
+	"github.com/benthosdev/benthos/v4/internal/checkpoint"
 	"github.com/benthosdev/benthos/v4/internal/component"
 	"github.com/benthosdev/benthos/v4/internal/component/cache"
 	"github.com/benthosdev/benthos/v4/internal/component/input"
@@ -20,12 +22,18 @@ import (
 // Manager provides a mock benthos manager that components can use to test
 // interactions with fake resources.
 type Manager struct {
-	Inputs     map[string]*Input
-	Caches     map[string]map[string]CacheItem
-	RateLimits map[string]RateLimit
-	Outputs    map[string]OutputWriter
-	Processors map[string]Processor
-	Pipes      map[string]<-chan message.Transaction
+	Inputs      map[string]*Input
+	Caches      map[string]map[string]CacheItem
+	RateLimits  map[string]RateLimit
+	Checkpoints map[string]*checkpoint.Store
+	Outputs     map[string]OutputWriter
+	Processors  map[string]Processor
+	Pipes       map[string]<-chan message.Transaction
+
+	subscribers    map[string][]*interop.PipeSubscriber
+	subscribersMut sync.Mutex
+
+	genericValues sync.Map
 
 	// OnRegisterEndpoint can be set in order to intercept endpoints registered
 	// by components.
@@ -35,12 +43,15 @@ type Manager struct {
 // NewManager provides a new mock manager.
 func NewManager() *Manager {
 	return &Manager{
-		Inputs:     map[string]*Input{},
-		Caches:     map[string]map[string]CacheItem{},
-		RateLimits: map[string]RateLimit{},
-		Outputs:    map[string]OutputWriter{},
-		Processors: map[string]Processor{},
-		Pipes:      map[string]<-chan message.Transaction{},
+		Inputs:      map[string]*Input{},
+		Caches:      map[string]map[string]CacheItem{},
+		RateLimits:  map[string]RateLimit{},
+		Checkpoints: map[string]*checkpoint.Store{},
+		Outputs:     map[string]OutputWriter{},
+		Processors:  map[string]Processor{},
+		Pipes:       map[string]<-chan message.Transaction{},
+
+		subscribers: map[string][]*interop.PipeSubscriber{},
 	}
 }
 
@@ -110,6 +121,23 @@ func (m *Manager) AccessRateLimit(ctx context.Context, name string, fn func(rate
 	return nil
 }
 
+// ProbeCheckpoint returns true if a checkpoint resource exists under the
+// provided name.
+func (m *Manager) ProbeCheckpoint(name string) bool {
+	_, exists := m.Checkpoints[name]
+	return exists
+}
+
+// AccessCheckpoint executes a closure on a checkpoint resource.
+func (m *Manager) AccessCheckpoint(ctx context.Context, name string, fn func(*checkpoint.Store)) error {
+	s, ok := m.Checkpoints[name]
+	if !ok {
+		return component.ErrCheckpointNotFound
+	}
+	fn(s)
+	return nil
+}
+
 // ProbeInput returns true if an input resource exists under the provided name.
 func (m *Manager) ProbeInput(name string) bool {
 	_, exists := m.Inputs[name]
@@ -160,6 +188,12 @@ func (m *Manager) AccessOutput(ctx context.Context, name string, fn func(output.
 	return nil
 }
 
+// GetOrSetGeneric returns the value stored under a key, storing the provided
+// value when none exists.
+func (m *Manager) GetOrSetGeneric(key, value interface{}) (actual interface{}, loaded bool) {
+	return m.genericValues.LoadOrStore(key, value)
+}
+
 // GetPipe attempts to find a service wide transaction chan by its name.
 func (m *Manager) GetPipe(name string) (<-chan message.Transaction, error) {
 	if p, ok := m.Pipes[name]; ok {
@@ -177,3 +211,33 @@ func (m *Manager) SetPipe(name string, t <-chan message.Transaction) {
 func (m *Manager) UnsetPipe(name string, t <-chan message.Transaction) {
 	delete(m.Pipes, name)
 }
+
+// GetPipeSubscribers returns the subscribers of a named pipe.
+func (m *Manager) GetPipeSubscribers(name string) []*interop.PipeSubscriber {
+	m.subscribersMut.Lock()
+	defer m.subscribersMut.Unlock()
+	return append([]*interop.PipeSubscriber(nil), m.subscribers[name]...)
+}
+
+// SubscribePipe adds a subscriber to a named pipe.
+func (m *Manager) SubscribePipe(name string, s *interop.PipeSubscriber) {
+	m.subscribersMut.Lock()
+	m.subscribers[name] = append(m.subscribers[name], s)
+	m.subscribersMut.Unlock()
+}
+
+// UnsubscribePipe removes a subscriber from a named pipe.
+func (m *Manager) UnsubscribePipe(name string, s *interop.PipeSubscriber) {
+	m.subscribersMut.Lock()
+	m.subscribers[name] = removePipeSubscriber(m.subscribers[name], s)
+	m.subscribersMut.Unlock()
+}
+
+func removePipeSubscriber(subs []*interop.PipeSubscriber, s *interop.PipeSubscriber) []*interop.PipeSubscriber {
+	for i, sub := range subs {
+		if sub == s {
+			return append(subs[:i:i], subs[i+1:]...)
+		}
+	}
+	return subs
+}
@@ -13,6 +13,11 @@ func (r RateLimit) Access(ctx context.Context) (time.Duration, error) {
 	return r(ctx)
 }
 
+// AccessKey the rate limit, the key is ignored
+func (r RateLimit) AccessKey(ctx context.Context, key string) (time.Duration, error) {
+	return r(ctx)
+}
+
 // Close does nothing
 func (r RateLimit) Close(ctx context.Context) error {
 	return nil
@@ -45,6 +45,11 @@ func (w *outputWrapper) Connected() bool {
 	return w.output.Connected()
 }
 
+// LastError returns the most recent error encountered by the wrapped output.
+func (w *outputWrapper) LastError() (time.Time, error) {
+	return component.LastErrorOf(w.output)
+}
+
 func (w *outputWrapper) CloseAsync() {
 	w.closeOnce.Do(func() {
 		close(w.tranChan)
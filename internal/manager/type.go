@@ -11,6 +11,7 @@ import (
 	"github.com/benthosdev/benthos/v4/internal/bloblang"
 	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
 	"github.com/benthosdev/benthos/v4/internal/bundle"
+	"github.com/benthosdev/benthos/v4/internal/checkpoint"
 	"github.com/benthosdev/benthos/v4/internal/component"
 	"github.com/benthosdev/benthos/v4/internal/component/buffer"
 	"github.com/benthosdev/benthos/v4/internal/component/cache"
@@ -71,8 +72,13 @@ type Type struct {
 	processors   map[string]iprocessor.V1
 	outputs      map[string]*outputWrapper
 	rateLimits   map[string]ratelimit.V1
+	checkpoints  map[string]*checkpoint.Store
 	resourceLock *sync.RWMutex
 
+	// Arbitrary values shared by the components of a service, such as
+	// connection pools.
+	genericValues *sync.Map
+
 	// Collections of component constructors
 	env      *bundle.Environment
 	bloblEnv *bloblang.Environment
@@ -80,8 +86,9 @@ type Type struct {
 	logger log.Modular
 	stats  *metrics.Namespaced
 
-	pipes    map[string]<-chan message.Transaction
-	pipeLock *sync.RWMutex
+	pipes       map[string]<-chan message.Transaction
+	subscribers map[string][]*interop.PipeSubscriber
+	pipeLock    *sync.RWMutex
 }
 
 // OptFunc is an opt setting for a manager type.
@@ -114,8 +121,11 @@ func NewV2(conf ResourceConfig, apiReg APIReg, log log.Modular, stats *metrics.N
 		processors:   map[string]iprocessor.V1{},
 		outputs:      map[string]*outputWrapper{},
 		rateLimits:   map[string]ratelimit.V1{},
+		checkpoints:  map[string]*checkpoint.Store{},
 		resourceLock: &sync.RWMutex{},
 
+		genericValues: &sync.Map{},
+
 		// Environment defaults to global (everything that was imported).
 		env:      bundle.GlobalEnvironment,
 		bloblEnv: bloblang.GlobalEnvironment(),
@@ -123,14 +133,19 @@ func NewV2(conf ResourceConfig, apiReg APIReg, log log.Modular, stats *metrics.N
 		logger: log,
 		stats:  stats,
 
-		pipes:    map[string]<-chan message.Transaction{},
-		pipeLock: &sync.RWMutex{},
+		pipes:       map[string]<-chan message.Transaction{},
+		subscribers: map[string][]*interop.PipeSubscriber{},
+		pipeLock:    &sync.RWMutex{},
 	}
 
 	for _, opt := range opts {
 		opt(t)
 	}
 
+	// Mappings parsed with the environment of the manager are able to access
+	// its resources.
+	t.bloblEnv = t.withResourceFunctions(t.bloblEnv)
+
 	seen := map[string]struct{}{}
 
 	checkLabel := func(typeStr, label string) error {
@@ -179,6 +194,12 @@ func NewV2(conf ResourceConfig, apiReg APIReg, log log.Modular, stats *metrics.N
 		}
 		t.rateLimits[c.Label] = nil
 	}
+	for _, c := range conf.ResourceCheckpoints {
+		if err := checkLabel("checkpoint", c.Label); err != nil {
+			return nil, err
+		}
+		t.checkpoints[c.Label] = nil
+	}
 
 	// Labels validated, begin construction
 	for _, conf := range conf.ResourceRateLimits {
@@ -193,6 +214,13 @@ func NewV2(conf ResourceConfig, apiReg APIReg, log log.Modular, stats *metrics.N
 		}
 	}
 
+	// Checkpoints are stored within caches.
+	for _, conf := range conf.ResourceCheckpoints {
+		if err := t.StoreCheckpoint(context.Background(), conf.Label, conf); err != nil {
+			return nil, err
+		}
+	}
+
 	// TODO: Prevent recursive processors.
 	for _, conf := range conf.ResourceProcessors {
 		if err := t.StoreProcessor(context.Background(), conf.Label, conf); err != nil {
@@ -295,6 +323,13 @@ func (t *Type) RegisterEndpoint(apiPath, desc string, h http.HandlerFunc) {
 	}
 }
 
+// GetOrSetGeneric returns the value stored under a key that is shared by all
+// components of the service, storing the provided value when none exists. The
+// loaded result is true if the value was loaded, false if stored.
+func (t *Type) GetOrSetGeneric(key, value interface{}) (actual interface{}, loaded bool) {
+	return t.genericValues.LoadOrStore(key, value)
+}
+
 // SetPipe registers a new transaction chan to a named pipe.
 func (t *Type) SetPipe(name string, tran <-chan message.Transaction) {
 	t.pipeLock.Lock()
@@ -322,6 +357,40 @@ func (t *Type) UnsetPipe(name string, tran <-chan message.Transaction) {
 	t.pipeLock.Unlock()
 }
 
+// GetPipeSubscribers returns the subscribers of a named pipe.
+func (t *Type) GetPipeSubscribers(name string) []*interop.PipeSubscriber {
+	t.pipeLock.RLock()
+	subs := append([]*interop.PipeSubscriber(nil), t.subscribers[name]...)
+	t.pipeLock.RUnlock()
+	return subs
+}
+
+// SubscribePipe registers a subscriber that receives every transaction sent
+// over a named pipe by outputs that broadcast transactions.
+func (t *Type) SubscribePipe(name string, s *interop.PipeSubscriber) {
+	t.pipeLock.Lock()
+	t.subscribers[name] = append(t.subscribers[name], s)
+	t.pipeLock.Unlock()
+}
+
+// UnsubscribePipe removes a subscriber from a named pipe.
+func (t *Type) UnsubscribePipe(name string, s *interop.PipeSubscriber) {
+	t.pipeLock.Lock()
+	subs := t.subscribers[name]
+	for i, sub := range subs {
+		if sub == s {
+			subs = append(subs[:i:i], subs[i+1:]...)
+			break
+		}
+	}
+	if len(subs) == 0 {
+		delete(t.subscribers, name)
+	} else {
+		t.subscribers[name] = subs
+	}
+	t.pipeLock.Unlock()
+}
+
 //------------------------------------------------------------------------------
 
 // WithMetricsMapping returns a manager with the stored metrics exporter wrapped
@@ -710,6 +779,54 @@ func (t *Type) StoreRateLimit(ctx context.Context, name string, conf ratelimit.C
 
 //------------------------------------------------------------------------------
 
+// ProbeCheckpoint returns true if a checkpoint resource exists under the
+// provided name.
+func (t *Type) ProbeCheckpoint(name string) bool {
+	_, exists := t.checkpoints[name]
+	return exists
+}
+
+// AccessCheckpoint attempts to access a checkpoint resource by a unique
+// identifier and executes a closure function with the checkpoint store as an
+// argument. Returns an error if the checkpoint does not exist.
+func (t *Type) AccessCheckpoint(ctx context.Context, name string, fn func(*checkpoint.Store)) error {
+	t.resourceLock.RLock()
+	s, ok := t.checkpoints[name]
+	t.resourceLock.RUnlock()
+	if !ok || s == nil {
+		return ErrResourceNotFound(name)
+	}
+
+	// Stores access caches, and so the closure is called without the resource
+	// lock held.
+	fn(s)
+	return nil
+}
+
+// StoreCheckpoint attempts to store a new checkpoint resource, replacing any
+// existing resource of the same name.
+func (t *Type) StoreCheckpoint(ctx context.Context, name string, conf checkpoint.StoreConfig) error {
+	if conf.Label != "" && conf.Label != name {
+		return fmt.Errorf("label '%v' must be empty or match the resource name '%v'", conf.Label, name)
+	}
+	if conf.Cache == "" && conf.Directory == "" {
+		return fmt.Errorf("checkpoint resource '%v' must have either a cache or a directory", name)
+	}
+
+	cMgr := t.intoPath("checkpoint_resources").forLabel(name)
+	s, err := checkpoint.NewStore(conf, cMgr, cMgr.Metrics())
+	if err != nil {
+		return fmt.Errorf("failed to init checkpoint '%v': %w", name, err)
+	}
+
+	t.resourceLock.Lock()
+	t.checkpoints[name] = s
+	t.resourceLock.Unlock()
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
 // CloseAsync triggers the shut down of all resource types that implement the
 // lifetime interface types.Closable.
 func (t *Type) CloseAsync() {
@@ -6,6 +6,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
 
 	"github.com/benthosdev/benthos/v4/internal/bundle"
 	"github.com/benthosdev/benthos/v4/internal/component"
@@ -473,3 +474,42 @@ func TestManagerPipeGetSet(t *testing.T) {
 }
 
 //------------------------------------------------------------------------------
+
+func TestManagerBloblangResourceFunctions(t *testing.T) {
+	conf := manager.NewResourceConfig()
+	require.NoError(t, yaml.Unmarshal([]byte(`
+cache_resources:
+  - label: foocache
+    memory: {}
+rate_limit_resources:
+  - label: foorl
+    local:
+      count: 1
+      interval: 1h
+`), &conf))
+
+	mgr, err := manager.NewV2(conf, nil, log.Noop(), noopStats())
+	require.NoError(t, err)
+
+	require.NoError(t, mgr.AccessCache(context.Background(), "foocache", func(c cache.V1) {
+		require.NoError(t, c.Set(context.Background(), "foo", []byte("hello world"), nil))
+	}))
+
+	exec, err := mgr.BloblEnvironment().NewMapping(`
+root.foo = cache("foocache", this.key)
+root.bar = cache("foocache", "bar")
+root.permitted = rate_limit("foorl")
+`)
+	require.NoError(t, err)
+
+	res, err := exec.MapPart(0, message.QuickBatch([][]byte{[]byte(`{"key":"foo"}`)}))
+	require.NoError(t, err)
+	assert.Equal(t, `{"bar":null,"foo":"hello world","permitted":true}`, string(res.Get()))
+
+	res, err = exec.MapPart(0, message.QuickBatch([][]byte{[]byte(`{"key":"foo"}`)}))
+	require.NoError(t, err)
+	assert.Equal(t, `{"bar":null,"foo":"hello world","permitted":false}`, string(res.Get()))
+
+	_, err = mgr.BloblEnvironment().NewMapping(`root = cache("nope", "foo")`)
+	require.Error(t, err)
+}
@@ -0,0 +1,151 @@
+package message
+
+import (
+	"context"
+	"sync"
+)
+
+type ackHoldsKey struct{}
+
+// AckHolds tracks the messages of a transaction that processors hold back
+// beyond the processing of the transaction. Held messages are later either
+// emitted as a new batch or released, and the acknowledgement of the
+// transaction is deferred until all of its holds are resolved.
+type AckHolds struct {
+	emitFn func(*Batch, func(context.Context, error) error)
+
+	mut     sync.Mutex
+	sealed  bool
+	pending int
+	err     error
+
+	ackFn func(context.Context, error) error
+	acked bool
+}
+
+// NewAckHolds creates a tracker of held messages for a transaction, where
+// emitFn is called in order to dispatch a batch emitted in place of a held
+// message along with a func used to acknowledge its delivery.
+func NewAckHolds(emitFn func(*Batch, func(context.Context, error) error)) *AckHolds {
+	return &AckHolds{emitFn: emitFn}
+}
+
+// Attach returns a shallow copy of a batch where each message part is
+// associated with the holds, allowing processors to hold them back.
+func (a *AckHolds) Attach(b *Batch) *Batch {
+	parts := make([]*Part, b.Len())
+	_ = b.Iter(func(i int, p *Part) error {
+		parts[i] = p.WithContext(context.WithValue(p.GetContext(), ackHoldsKey{}, a))
+		return nil
+	})
+	newBatch := QuickBatch(nil)
+	newBatch.SetAll(parts)
+	return newBatch
+}
+
+// Seal prevents any further messages from being held and returns an
+// acknowledgement func that defers calling ackFn until all holds are resolved.
+// The first error of either the acknowledgement or a hold is propagated to
+// ackFn. If no messages were held then ackFn is returned as is.
+func (a *AckHolds) Seal(ackFn func(context.Context, error) error) func(context.Context, error) error {
+	a.mut.Lock()
+	defer a.mut.Unlock()
+
+	a.sealed = true
+	if a.pending == 0 {
+		return ackFn
+	}
+	return func(ctx context.Context, err error) error {
+		a.mut.Lock()
+		if a.acked {
+			a.mut.Unlock()
+			return nil
+		}
+		a.acked, a.ackFn = true, ackFn
+		if err != nil && a.err == nil {
+			a.err = err
+		}
+		if a.pending > 0 {
+			a.mut.Unlock()
+			return nil
+		}
+		resErr := a.err
+		a.mut.Unlock()
+		return ackFn(ctx, resErr)
+	}
+}
+
+func (a *AckHolds) resolve(err error) {
+	a.mut.Lock()
+	a.pending--
+	if err != nil && a.err == nil {
+		a.err = err
+	}
+	if a.pending > 0 || !a.acked {
+		a.mut.Unlock()
+		return
+	}
+	ackFn, resErr := a.ackFn, a.err
+	a.mut.Unlock()
+
+	// The context provided with the deferred acknowledgement may have expired
+	// by now and therefore isn't reused.
+	_ = ackFn(context.Background(), resErr)
+}
+
+//------------------------------------------------------------------------------
+
+// AckHold is a message held back by a processor, which prevents the
+// transaction that the message originated from being acknowledged until the
+// hold is resolved by either emitting a batch or releasing it.
+type AckHold struct {
+	holds *AckHolds
+
+	once        sync.Once
+	resolveOnce sync.Once
+}
+
+// HoldAck attempts to hold back a message part, deferring the acknowledgement
+// of its transaction. Returns nil if the part isn't being processed as part of
+// a transaction that supports holding messages.
+func HoldAck(p *Part) *AckHold {
+	holds, ok := p.GetContext().Value(ackHoldsKey{}).(*AckHolds)
+	if !ok {
+		return nil
+	}
+
+	holds.mut.Lock()
+	defer holds.mut.Unlock()
+	if holds.sealed {
+		return nil
+	}
+	holds.pending++
+	return &AckHold{holds: holds}
+}
+
+// Emit resolves the hold by dispatching a batch in place of the held message.
+// Once the delivery of the batch is acknowledged ackFn is called with the
+// result, which is also propagated to the transaction of the held message.
+func (h *AckHold) Emit(b *Batch, ackFn func(context.Context, error) error) {
+	h.once.Do(func() {
+		h.holds.emitFn(b, func(ctx context.Context, err error) error {
+			aErr := ackFn(ctx, err)
+			h.resolve(err)
+			return aErr
+		})
+	})
+}
+
+// Release resolves the hold without emitting anything, where a non-nil error
+// indicates that the held message could not be delivered.
+func (h *AckHold) Release(err error) {
+	h.once.Do(func() {
+		h.resolve(err)
+	})
+}
+
+func (h *AckHold) resolve(err error) {
+	h.resolveOnce.Do(func() {
+		h.holds.resolve(err)
+	})
+}
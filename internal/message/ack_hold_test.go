@@ -0,0 +1,73 @@
+package message
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAckHoldsNoHolds(t *testing.T) {
+	holds := NewAckHolds(func(*Batch, func(context.Context, error) error) {
+		t.Error("unexpected emit")
+	})
+	batch := holds.Attach(QuickBatch([][]byte{[]byte("foo")}))
+
+	var res []error
+	ackFn := holds.Seal(func(ctx context.Context, err error) error {
+		res = append(res, err)
+		return nil
+	})
+	require.NoError(t, ackFn(context.Background(), nil))
+	assert.Equal(t, []error{nil}, res)
+
+	// Holds cannot be taken once sealed.
+	assert.Nil(t, HoldAck(batch.Get(0)))
+}
+
+func TestAckHoldsWithoutAttach(t *testing.T) {
+	assert.Nil(t, HoldAck(NewPart([]byte("foo"))))
+}
+
+func TestAckHoldsEmitAndRelease(t *testing.T) {
+	var emitted []*Batch
+	var emittedAcks []func(context.Context, error) error
+	holds := NewAckHolds(func(b *Batch, ackFn func(context.Context, error) error) {
+		emitted = append(emitted, b)
+		emittedAcks = append(emittedAcks, ackFn)
+	})
+	batch := holds.Attach(QuickBatch([][]byte{[]byte("foo"), []byte("bar")}))
+
+	holdFoo := HoldAck(batch.Get(0))
+	require.NotNil(t, holdFoo)
+	holdBar := HoldAck(batch.Get(1))
+	require.NotNil(t, holdBar)
+
+	var res []error
+	ackFn := holds.Seal(func(ctx context.Context, err error) error {
+		res = append(res, err)
+		return nil
+	})
+	require.NoError(t, ackFn(context.Background(), nil))
+	assert.Empty(t, res)
+
+	var emittedRes []error
+	holdFoo.Emit(QuickBatch([][]byte{[]byte("baz")}), func(ctx context.Context, err error) error {
+		emittedRes = append(emittedRes, err)
+		return nil
+	})
+	require.Len(t, emitted, 1)
+	assert.Equal(t, [][]byte{[]byte("baz")}, GetAllBytes(emitted[0]))
+	assert.Empty(t, res)
+
+	errNope := errors.New("nope")
+	holdBar.Release(errNope)
+	holdBar.Release(nil)
+	assert.Empty(t, res)
+
+	require.NoError(t, emittedAcks[0](context.Background(), nil))
+	assert.Equal(t, []error{nil}, emittedRes)
+	assert.Equal(t, []error{errNope}, res)
+}
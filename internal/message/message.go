@@ -1,5 +1,12 @@
 package message
 
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"sort"
+)
+
 // Batch represents zero or more messages.
 type Batch struct {
 	parts []*Part
@@ -153,8 +160,12 @@ func ToBytes(m *Batch) []byte {
 	return b
 }
 
-// FromBytes deserialises a Message from a byte array.
+// FromBytes deserialises a Message from a byte array, which can be in either
+// the format produced by ToBytes or by ToBytesV2.
 func FromBytes(b []byte) (*Batch, error) {
+	if bytes.HasPrefix(b, v2Magic) {
+		return fromBytesV2(b[len(v2Magic):])
+	}
 	if len(b) < 4 {
 		return nil, ErrBadMessageBytes
 	}
@@ -182,3 +193,172 @@ func FromBytes(b []byte) (*Batch, error) {
 	}
 	return m, nil
 }
+
+//------------------------------------------------------------------------------
+
+/*
+Internal message blob format V2:
+
+- Four magic bytes (0xFF 'B' 'M' 0x02), which can't be mistaken for the start
+  of the original format as the message part count would exceed the size of
+  any practical blob
+- Uvarint containing the number of message parts
+- For each message part:
+    + One byte of flags, where bit 0 indicates an error and bit 1 indicates
+      that the contents are structured
+    + Uvarint containing the number of metadata pairs
+    + For each metadata pair the key and value, each as a uvarint length
+      followed by the bytes
+    + If the error flag is set the error message as a uvarint length followed
+      by the bytes
+    + The content of the message part as a uvarint length followed by the
+      bytes, where structured contents are serialised as a JSON document
+*/
+
+var v2Magic = []byte{0xFF, 'B', 'M', 0x02}
+
+const (
+	v2FlagError byte = 1 << iota
+	v2FlagStructured
+)
+
+func appendV2Bytes(b, v []byte) []byte {
+	b = appendV2Uvarint(b, uint64(len(v)))
+	return append(b, v...)
+}
+
+func appendV2Uvarint(b []byte, v uint64) []byte {
+	var tmp [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(tmp[:], v)
+	return append(b, tmp[:n]...)
+}
+
+// ToBytesV2 serialises a message into a single byte array in a format that,
+// unlike ToBytes, preserves the metadata, error flag and structured contents of
+// each message part. The result can be deserialised with FromBytes.
+//
+// The format is currently only produced by the binary_v2 format of the archive
+// processor. The inproc and retry components hand messages over in memory and
+// therefore never serialise them, and there is no disk buffer that would
+// persist them.
+func ToBytesV2(m *Batch) []byte {
+	b := append([]byte{}, v2Magic...)
+	b = appendV2Uvarint(b, uint64(m.Len()))
+
+	_ = m.Iter(func(i int, p *Part) error {
+		var flags byte
+		if p.data.err != nil {
+			flags |= v2FlagError
+		}
+		if p.data.jsonCache != nil {
+			flags |= v2FlagStructured
+		}
+		b = append(b, flags)
+
+		keys := make([]string, 0, len(p.data.metadata))
+		for k := range p.data.metadata {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		b = appendV2Uvarint(b, uint64(len(keys)))
+		for _, k := range keys {
+			b = appendV2Bytes(b, []byte(k))
+			b = appendV2Bytes(b, []byte(p.data.metadata[k]))
+		}
+		if p.data.err != nil {
+			b = appendV2Bytes(b, []byte(p.data.err.Error()))
+		}
+		b = appendV2Bytes(b, p.Get())
+		return nil
+	})
+	return b
+}
+
+type v2Reader struct {
+	b []byte
+}
+
+func (r *v2Reader) uvarint() (uint64, error) {
+	v, n := binary.Uvarint(r.b)
+	if n <= 0 {
+		return 0, ErrBadMessageBytes
+	}
+	r.b = r.b[n:]
+	return v, nil
+}
+
+func (r *v2Reader) bytes() ([]byte, error) {
+	l, err := r.uvarint()
+	if err != nil {
+		return nil, err
+	}
+	if uint64(len(r.b)) < l {
+		return nil, ErrBadMessageBytes
+	}
+	v := r.b[:l]
+	r.b = r.b[l:]
+	return v, nil
+}
+
+func fromBytesV2(b []byte) (*Batch, error) {
+	r := &v2Reader{b: b}
+
+	numParts, err := r.uvarint()
+	if err != nil {
+		return nil, err
+	}
+	if numParts > uint64(len(r.b)) {
+		return nil, ErrBadMessageBytes
+	}
+
+	m := &Batch{parts: make([]*Part, 0, numParts)}
+	for i := uint64(0); i < numParts; i++ {
+		if len(r.b) == 0 {
+			return nil, ErrBadMessageBytes
+		}
+		flags := r.b[0]
+		r.b = r.b[1:]
+
+		numMeta, err := r.uvarint()
+		if err != nil {
+			return nil, err
+		}
+		if numMeta > uint64(len(r.b)) {
+			return nil, ErrBadMessageBytes
+		}
+
+		p := NewPart(nil)
+		for j := uint64(0); j < numMeta; j++ {
+			k, err := r.bytes()
+			if err != nil {
+				return nil, err
+			}
+			v, err := r.bytes()
+			if err != nil {
+				return nil, err
+			}
+			p.MetaSet(string(k), string(v))
+		}
+		if flags&v2FlagError != 0 {
+			errMsg, err := r.bytes()
+			if err != nil {
+				return nil, err
+			}
+			p.ErrorSet(errors.New(string(errMsg)))
+		}
+
+		content, err := r.bytes()
+		if err != nil {
+			return nil, err
+		}
+		p.Set(content)
+		if flags&v2FlagStructured != 0 {
+			if _, err := p.JSON(); err != nil {
+				return nil, ErrBadMessageBytes
+			}
+		}
+		m.Append(p)
+	}
+	return m, nil
+}
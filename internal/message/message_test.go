@@ -76,6 +76,47 @@ func TestMessageInvalidBytesFormat(t *testing.T) {
 	}
 }
 
+func TestMessageSerializationV2(t *testing.T) {
+	m := QuickBatch([][]byte{
+		[]byte("hello"),
+		[]byte(""),
+		[]byte("world"),
+	})
+	m.Get(0).MetaSet("foo", "bar")
+	m.Get(0).MetaSet("baz", "")
+	m.Get(1).ErrorSet(errors.New("oh no"))
+	m.Get(2).SetJSON(map[string]interface{}{"hello": "world"})
+
+	m2, err := FromBytes(ToBytesV2(m))
+	require.NoError(t, err)
+	require.Equal(t, 3, m2.Len())
+
+	assert.Equal(t, "hello", string(m2.Get(0).Get()))
+	assert.Equal(t, map[string]string{"foo": "bar", "baz": ""}, m2.Get(0).data.metadata)
+	assert.NoError(t, m2.Get(0).ErrorGet())
+
+	assert.Equal(t, "", string(m2.Get(1).Get()))
+	assert.EqualError(t, m2.Get(1).ErrorGet(), "oh no")
+
+	assert.Equal(t, `{"hello":"world"}`, string(m2.Get(2).Get()))
+	assert.Equal(t, map[string]interface{}{"hello": "world"}, m2.Get(2).data.jsonCache)
+
+	m3, err := FromBytes(ToBytesV2(QuickBatch(nil)))
+	require.NoError(t, err)
+	assert.Equal(t, 0, m3.Len())
+}
+
+func TestMessageInvalidBytesFormatV2(t *testing.T) {
+	valid := ToBytesV2(QuickBatch([][]byte{[]byte("hello")}))
+	for i := len(v2Magic); i < len(valid); i++ {
+		_, err := FromBytes(valid[:i])
+		assert.Error(t, err, i)
+	}
+
+	_, err := FromBytes(append(append([]byte{}, v2Magic...), 0xFF))
+	assert.Error(t, err)
+}
+
 func TestMessageIncompleteJSON(t *testing.T) {
 	tests := []struct {
 		message string
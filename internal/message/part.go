@@ -7,6 +7,7 @@ import (
 	"errors"
 	"io"
 	"os"
+	"sync/atomic"
 )
 
 var useNumber = true
@@ -24,6 +25,56 @@ type rwData struct {
 	jsonCache interface{}
 	metadata  map[string]string
 	err       error
+
+	// Markers of whether the metadata map and structured contents are shared
+	// with other parts, in which case they must be cloned before they are
+	// modified. A marker is shared by all parts that reference the same value
+	// so that copying a part never modifies the fields of the original.
+	metaShared *shareMarker
+	jsonShared *shareMarker
+}
+
+// shareMarker records whether a value is referenced by more than one part. It
+// is set atomically as parts may be copied concurrently.
+type shareMarker struct {
+	shared int32
+}
+
+func (s *shareMarker) markShared() {
+	atomic.StoreInt32(&s.shared, 1)
+}
+
+func (s *shareMarker) isShared() bool {
+	return s != nil && atomic.LoadInt32(&s.shared) == 1
+}
+
+// setMeta sets the metadata map to one that is owned by this part.
+func (r *rwData) setMeta(m map[string]string) {
+	r.metadata = m
+	r.metaShared = &shareMarker{}
+}
+
+// setJSON sets the structured contents to a value owned by this part.
+func (r *rwData) setJSON(v interface{}) {
+	r.jsonCache = v
+	r.jsonShared = &shareMarker{}
+}
+
+// writeableMeta ensures that the metadata map is owned by this part and can
+// therefore be safely modified.
+func (r *rwData) writeableMeta() {
+	if r.metadata == nil || !r.metaShared.isShared() {
+		return
+	}
+	r.setMeta(cloneMeta(r.metadata))
+}
+
+func cloneMeta(m map[string]string) map[string]string {
+	clonedMeta := make(map[string]string, len(m))
+	for k, v := range m {
+		clonedMeta[k] = v
+	}
+	return clonedMeta
 }
 
 // Part represents a single Benthos message.
@@ -44,55 +95,54 @@ func NewPart(data []byte) *Part {
 
 //------------------------------------------------------------------------------
 
-// Copy creates a shallow copy of the message part.
+// Copy creates a shallow copy of the message part. The metadata and
+// structured contents of the copy are shared with the original until either
+// part modifies them.
 func (p *Part) Copy() *Part {
-	var clonedMeta map[string]string
+	data := &rwData{
+		rawBytes: p.data.rawBytes,
+		err:      p.data.err,
+	}
 	if p.data.metadata != nil {
-		clonedMeta = make(map[string]string, len(p.data.metadata))
-		for k, v := range p.data.metadata {
-			clonedMeta[k] = v
+		if p.data.metaShared != nil {
+			p.data.metaShared.markShared()
+			data.metadata, data.metaShared = p.data.metadata, p.data.metaShared
+		} else {
+			data.setMeta(cloneMeta(p.data.metadata))
+		}
+	}
+	if p.data.jsonCache != nil {
+		if p.data.jsonShared != nil {
+			p.data.jsonShared.markShared()
+			data.jsonCache, data.jsonShared = p.data.jsonCache, p.data.jsonShared
+		} else if clonedJSON, err := cloneGeneric(p.data.jsonCache); err == nil {
+			data.setJSON(clonedJSON)
 		}
 	}
 	return &Part{
-		data: &rwData{
-			rawBytes:  p.data.rawBytes,
-			metadata:  clonedMeta,
-			jsonCache: p.data.jsonCache,
-			err:       p.data.err,
-		},
-		ctx: p.ctx,
+		data: data,
+		ctx:  p.ctx,
 	}
 }
 
 // DeepCopy creates a new deep copy of the message part.
 func (p *Part) DeepCopy() *Part {
-	var clonedMeta map[string]string
+	data := &rwData{
+		err: p.data.err,
+	}
 	if p.data.metadata != nil {
-		clonedMeta = make(map[string]string, len(p.data.metadata))
-		for k, v := range p.data.metadata {
-			clonedMeta[k] = v
-		}
+		data.setMeta(cloneMeta(p.data.metadata))
 	}
-	var clonedJSON interface{}
 	if p.data.jsonCache != nil {
-		var err error
-		if clonedJSON, err = cloneGeneric(p.data.jsonCache); err != nil {
-			clonedJSON = nil
+		if clonedJSON, err := cloneGeneric(p.data.jsonCache); err == nil {
+			data.setJSON(clonedJSON)
 		}
 	}
-	var np []byte
-	if p.data != nil {
-		np = make([]byte, len(p.data.rawBytes))
-		copy(np, p.data.rawBytes)
-	}
+	data.rawBytes = make([]byte, len(p.data.rawBytes))
+	copy(data.rawBytes, p.data.rawBytes)
 	return &Part{
-		data: &rwData{
-			rawBytes:  np,
-			metadata:  clonedMeta,
-			jsonCache: clonedJSON,
-			err:       p.data.err,
-		},
-		ctx: p.ctx,
+		data: data,
+		ctx:  p.ctx,
 	}
 }
 
@@ -169,38 +219,66 @@ func (p *Part) JSON() (interface{}, error) {
 		dec.UseNumber()
 	}
 
-	err := dec.Decode(&p.data.jsonCache)
+	var jObj interface{}
+	err := dec.Decode(&jObj)
 	if err != nil {
 		return nil, err
 	}
 
 	var dummy json.RawMessage
 	if err = dec.Decode(&dummy); err == io.EOF {
+		p.data.setJSON(jObj)
 		return p.data.jsonCache, nil
 	}
 
-	p.data.jsonCache = nil
 	if err = dec.Decode(&dummy); err == nil || err == io.EOF {
 		err = errors.New("message contains multiple valid documents")
 	}
 	return nil, err
 }
 
+// JSONMut returns the structured contents of the message part in the same way
+// as JSON, but the returned value is safe to mutate. Structured contents that
+// are shared with copies of the message part are deep cloned before they are
+// returned, otherwise they are returned without copying.
+//
+// Since mutations of the returned value modify the contents of the message
+// part any cached serialisation of the contents is discarded, and the value is
+// serialised again once the raw bytes are requested.
+func (p *Part) JSONMut() (interface{}, error) {
+	jObj, err := p.JSON()
+	if err != nil {
+		return nil, err
+	}
+	if p.data.jsonShared.isShared() {
+		if jObj, err = cloneGeneric(jObj); err != nil {
+			return nil, err
+		}
+		p.data.setJSON(jObj)
+	}
+	if jObj != nil {
+		p.data.rawBytes = nil
+	}
+	return jObj, nil
+}
+
 // Set the value of the message part.
 func (p *Part) Set(data []byte) *Part {
 	p.data.rawBytes = data
-	p.data.jsonCache = nil
+	p.data.jsonCache, p.data.jsonShared = nil, nil
 	return p
 }
 
-// SetJSON attempts to marshal a JSON document into a byte slice and stores the
-// result as the contents of the message part.
+// SetJSON sets the structured contents of the message part, which are only
+// marshalled into a byte slice once the raw contents are requested. The part
+// takes ownership of the provided value, which must therefore not be mutated
+// by the caller afterwards.
 func (p *Part) SetJSON(jObj interface{}) {
 	p.data.rawBytes = nil
 	if jObj == nil {
 		p.data.rawBytes = []byte(`null`)
 	}
-	p.data.jsonCache = jObj
+	p.data.setJSON(jObj)
 }
 
 //------------------------------------------------------------------------------
@@ -215,10 +293,11 @@ func (p *Part) MetaGet(key string) string {
 
 // MetaSet sets the value of a metadata key.
 func (p *Part) MetaSet(key, value string) {
+	p.data.writeableMeta()
 	if p.data.metadata == nil {
-		p.data.metadata = map[string]string{
+		p.data.setMeta(map[string]string{
 			key: value,
-		}
+		})
 		return
 	}
 	p.data.metadata[key] = value
@@ -229,6 +308,7 @@ func (p *Part) MetaDelete(key string) {
 	if p.data.metadata == nil {
 		return
 	}
+	p.data.writeableMeta()
 	delete(p.data.metadata, key)
 }
 
@@ -237,7 +317,7 @@ func (p *Part) MetaIter(f func(k, v string) error) error {
 	if p.data.metadata == nil {
 		// Warning: If we remove this we need to compensate with a way to force
 		// initialisation
-		p.data.metadata = map[string]string{}
+		p.data.setMeta(map[string]string{})
 		return nil
 	}
 	for ak, av := range p.data.metadata {
@@ -1,8 +1,14 @@
 package message
 
 import (
+	"fmt"
 	"reflect"
+	"strconv"
+	"sync"
 	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestPartBasic(t *testing.T) {
@@ -83,6 +89,68 @@ func TestPartShallowCopy(t *testing.T) {
 	}
 }
 
+func TestPartCopyMetadataCopyOnWrite(t *testing.T) {
+	p := NewPart(nil)
+	p.MetaSet("foo", "bar")
+
+	p2 := p.Copy()
+	p3 := p2.Copy()
+
+	p2.MetaSet("foo", "p2")
+	p3.MetaDelete("foo")
+	p.MetaSet("baz", "p")
+
+	assert.Equal(t, "bar", p.MetaGet("foo"))
+	assert.Equal(t, "p", p.MetaGet("baz"))
+	assert.Equal(t, "p2", p2.MetaGet("foo"))
+	assert.Equal(t, "", p2.MetaGet("baz"))
+	assert.Equal(t, "", p3.MetaGet("foo"))
+	assert.Equal(t, "", p3.MetaGet("baz"))
+
+	p4 := NewPart(nil).Copy()
+	p4.MetaSet("foo", "p4")
+	assert.Equal(t, "p4", p4.MetaGet("foo"))
+}
+
+func TestPartCopyConcurrent(t *testing.T) {
+	p := NewPart([]byte(`{"foo":"bar"}`))
+	p.MetaSet("foo", "bar")
+	_, err := p.JSON()
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			p2 := p.Copy()
+			p2.MetaSet("foo", strconv.Itoa(i))
+
+			jObj, err := p2.JSONMut()
+			require.NoError(t, err)
+			jObj.(map[string]interface{})["foo"] = strconv.Itoa(i)
+
+			assert.Equal(t, "bar", p.MetaGet("foo"))
+			assert.Equal(t, strconv.Itoa(i), p2.MetaGet("foo"))
+		}(i)
+	}
+	wg.Wait()
+
+	// Modifying the original must not affect copies.
+	p2 := p.Copy()
+
+	jObj, err := p.JSONMut()
+	require.NoError(t, err)
+	jObj.(map[string]interface{})["foo"] = "baz"
+	p.MetaSet("foo", "baz")
+
+	assert.Equal(t, `{"foo":"baz"}`, string(p.Get()))
+	assert.Equal(t, "baz", p.MetaGet("foo"))
+	assert.Equal(t, `{"foo":"bar"}`, string(p2.Get()))
+	assert.Equal(t, "bar", p2.MetaGet("foo"))
+}
+
 func TestPartCopyDirtyJSON(t *testing.T) {
 	p := NewPart(nil)
 	dirtyObj := map[string]int{
@@ -124,6 +192,39 @@ func TestPartCopyDirtyJSON(t *testing.T) {
 	}
 }
 
+func TestPartJSONMut(t *testing.T) {
+	p := NewPart([]byte(`{"foo":"bar"}`))
+
+	v, err := p.JSONMut()
+	require.NoError(t, err)
+	v.(map[string]interface{})["foo"] = "baz"
+	assert.Equal(t, `{"foo":"baz"}`, string(p.Get()))
+
+	p2 := p.Copy()
+
+	v2, err := p2.JSONMut()
+	require.NoError(t, err)
+	v2.(map[string]interface{})["foo"] = "buz"
+	assert.Equal(t, `{"foo":"buz"}`, string(p2.Get()))
+	assert.Equal(t, `{"foo":"baz"}`, string(p.Get()))
+
+	v, err = p.JSONMut()
+	require.NoError(t, err)
+	v.(map[string]interface{})["foo"] = "qux"
+	assert.Equal(t, `{"foo":"qux"}`, string(p.Get()))
+	assert.Equal(t, `{"foo":"buz"}`, string(p2.Get()))
+
+	// Values that are owned by the part are not cloned.
+	obj := map[string]interface{}{"foo": "bar"}
+	p3 := NewPart(nil)
+	p3.SetJSON(obj)
+
+	v3, err := p3.JSONMut()
+	require.NoError(t, err)
+	v3.(map[string]interface{})["foo"] = "baz"
+	assert.Equal(t, "baz", obj["foo"])
+}
+
 func TestPartJSONMarshal(t *testing.T) {
 	p := NewPart(nil)
 	p.SetJSON(map[string]interface{}{
@@ -176,3 +277,17 @@ func TestPartDeepCopy(t *testing.T) {
 		t.Errorf("Metadata changed after copy: %v != %v", act, exp)
 	}
 }
+
+func BenchmarkPartCopyMetadata(b *testing.B) {
+	p := NewPart([]byte("hello world"))
+	for i := 0; i < 10; i++ {
+		p.MetaSet(fmt.Sprintf("key%v", i), "value")
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		p2 := p.Copy()
+		_ = p2.MetaGet("key0")
+	}
+}
@@ -2,6 +2,7 @@ package input
 
 import (
 	"context"
+	"errors"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -21,6 +22,8 @@ import (
 // AsyncReader is an input implementation that reads messages from a
 // reader.Async component.
 type AsyncReader struct {
+	component.LastErrorTracker
+
 	connected   int32
 	connBackoff backoff.BackOff
 
@@ -74,6 +77,7 @@ func (r *AsyncReader) loop() {
 		mFailedConn = r.stats.GetCounter("input_connection_failed")
 		mLostConn   = r.stats.GetCounter("input_connection_lost")
 		mLatency    = r.stats.GetTimer("input_latency_ns")
+		mPending    = r.stats.GetGauge("input_pending")
 	)
 
 	defer func() {
@@ -109,6 +113,7 @@ func (r *AsyncReader) loop() {
 					return false
 				}
 				r.log.Errorf("Failed to connect to %v: %v\n", r.typeStr, err)
+				r.SetLastError(err)
 				mFailedConn.Incr(1)
 				select {
 				case <-time.After(r.connBackoff.NextBackOff()):
@@ -153,6 +158,7 @@ func (r *AsyncReader) loop() {
 		if err != nil || msg == nil {
 			if err != nil && err != component.ErrTimeout && err != component.ErrNotConnected {
 				r.log.Errorf("Failed to read message: %v\n", err)
+				r.SetLastError(err)
 			}
 			select {
 			case <-time.After(r.connBackoff.NextBackOff()):
@@ -177,12 +183,16 @@ func (r *AsyncReader) loop() {
 		}
 
 		pendingAcks.Add(1)
+		mPending.Incr(1)
 		go func(
 			m *message.Batch,
 			aFn reader.AsyncAckFn,
 			rChan chan error,
 		) {
-			defer pendingAcks.Done()
+			defer func() {
+				mPending.Decr(1)
+				pendingAcks.Done()
+			}()
 
 			var res error
 			var open bool
@@ -196,9 +206,14 @@ func (r *AsyncReader) loop() {
 			if !open {
 				return
 			}
-			mLatency.Timing(time.Since(startedAt).Nanoseconds())
+			metrics.TimingWithContext(message.GetContext(m.Get(0)), mLatency, time.Since(startedAt).Nanoseconds())
 			tracing.FinishSpans(m)
 
+			var rErr *component.RejectionError
+			if errors.As(res, &rErr) {
+				r.log.Debugf("Message rejected with code %v: %v\n", rErr.Code, rErr.Message)
+			}
+
 			ackCtx, ackDone := r.shutSig.CloseNowCtx(context.Background())
 			if err = aFn(ackCtx, res); err != nil {
 				r.log.Errorf("Failed to acknowledge message: %v\n", err)
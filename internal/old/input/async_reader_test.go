@@ -807,3 +807,52 @@ func benchmarkAsyncReaderGenerateN(b *testing.B, capacity int) {
 		}
 	}
 }
+
+func TestAsyncReaderPendingGauge(t *testing.T) {
+	tCtx, done := context.WithTimeout(context.Background(), time.Second*5)
+	defer done()
+
+	readerImpl := newMockAsyncReader()
+	readerImpl.msgsToSnd = []*message.Batch{message.QuickBatch([][]byte{[]byte("foo")})}
+
+	stats := metrics.NewLocal()
+	r, err := NewAsyncReader("foo", true, readerImpl, log.Noop(), stats)
+	require.NoError(t, err)
+
+	select {
+	case readerImpl.connChan <- nil:
+	case <-tCtx.Done():
+		t.Fatal("Timed out")
+	}
+
+	select {
+	case readerImpl.readChan <- nil:
+	case <-tCtx.Done():
+		t.Fatal("Timed out")
+	}
+
+	var ts message.Transaction
+	select {
+	case ts = <-r.TransactionChan():
+	case <-tCtx.Done():
+		t.Fatal("Timed out")
+	}
+	assert.Equal(t, int64(1), stats.GetCounters()["input_pending"])
+
+	go func() {
+		select {
+		case readerImpl.ackChan <- nil:
+		case <-tCtx.Done():
+		}
+	}()
+	require.NoError(t, ts.Ack(tCtx, nil))
+
+	assert.Eventually(t, func() bool {
+		return stats.GetCounters()["input_pending"] == 0
+	}, time.Second, time.Millisecond*10)
+
+	r.CloseAsync()
+	close(readerImpl.readChan)
+	close(readerImpl.connChan)
+	require.NoError(t, r.WaitForClose(time.Second))
+}
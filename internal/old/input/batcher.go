@@ -176,6 +176,11 @@ func (m *Batcher) Connected() bool {
 	return m.child.Connected()
 }
 
+// LastError returns the most recent error encountered by the child input.
+func (m *Batcher) LastError() (time.Time, error) {
+	return component.LastErrorOf(m.child)
+}
+
 // TransactionChan returns the channel used for consuming messages from this
 // buffer.
 func (m *Batcher) TransactionChan() <-chan message.Transaction {
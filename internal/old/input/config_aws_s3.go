@@ -28,15 +28,37 @@ func NewAWSS3SQSConfig() AWSS3SQSConfig {
 	}
 }
 
+// AWSS3PollingConfig contains configuration for continuously listing the
+// objects of a bucket.
+type AWSS3PollingConfig struct {
+	Enabled  bool   `json:"enabled" yaml:"enabled"`
+	Interval string `json:"interval" yaml:"interval"`
+	Cache    string `json:"cache" yaml:"cache"`
+	CacheKey string `json:"cache_key" yaml:"cache_key"`
+}
+
+// NewAWSS3PollingConfig creates a new AWSS3PollingConfig with default values.
+func NewAWSS3PollingConfig() AWSS3PollingConfig {
+	return AWSS3PollingConfig{
+		Enabled:  false,
+		Interval: "1m",
+		Cache:    "",
+		CacheKey: "",
+	}
+}
+
 // AWSS3Config contains configuration values for the aws_s3 input type.
 type AWSS3Config struct {
 	sess.Config        `json:",inline" yaml:",inline"`
-	Bucket             string         `json:"bucket" yaml:"bucket"`
-	Codec              string         `json:"codec" yaml:"codec"`
-	Prefix             string         `json:"prefix" yaml:"prefix"`
-	ForcePathStyleURLs bool           `json:"force_path_style_urls" yaml:"force_path_style_urls"`
-	DeleteObjects      bool           `json:"delete_objects" yaml:"delete_objects"`
-	SQS                AWSS3SQSConfig `json:"sqs" yaml:"sqs"`
+	Bucket             string             `json:"bucket" yaml:"bucket"`
+	Codec              string             `json:"codec" yaml:"codec"`
+	Prefix             string             `json:"prefix" yaml:"prefix"`
+	StartAfter         string             `json:"start_after" yaml:"start_after"`
+	ForcePathStyleURLs bool               `json:"force_path_style_urls" yaml:"force_path_style_urls"`
+	DeleteObjects      bool               `json:"delete_objects" yaml:"delete_objects"`
+	MoveObjectsTo      string             `json:"move_objects_to" yaml:"move_objects_to"`
+	Polling            AWSS3PollingConfig `json:"polling" yaml:"polling"`
+	SQS                AWSS3SQSConfig     `json:"sqs" yaml:"sqs"`
 }
 
 // NewAWSS3Config creates a new AWSS3Config with default values.
@@ -45,9 +67,12 @@ func NewAWSS3Config() AWSS3Config {
 		Config:             sess.NewConfig(),
 		Bucket:             "",
 		Prefix:             "",
+		StartAfter:         "",
 		Codec:              "all-bytes",
 		ForcePathStyleURLs: false,
 		DeleteObjects:      false,
+		MoveObjectsTo:      "",
+		Polling:            NewAWSS3PollingConfig(),
 		SQS:                NewAWSS3SQSConfig(),
 	}
 }
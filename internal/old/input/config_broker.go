@@ -4,17 +4,35 @@ import (
 	"github.com/benthosdev/benthos/v4/internal/batch/policy"
 )
 
+// BrokerPriorityConfig contains configuration fields for the priority pattern
+// of the Broker input type.
+type BrokerPriorityConfig struct {
+	MaxConsecutive int `json:"max_consecutive" yaml:"max_consecutive"`
+}
+
+// NewBrokerPriorityConfig creates a new BrokerPriorityConfig with default
+// values.
+func NewBrokerPriorityConfig() BrokerPriorityConfig {
+	return BrokerPriorityConfig{
+		MaxConsecutive: 0,
+	}
+}
+
 // BrokerConfig contains configuration fields for the Broker input type.
 type BrokerConfig struct {
-	Copies   int           `json:"copies" yaml:"copies"`
-	Inputs   []Config      `json:"inputs" yaml:"inputs"`
-	Batching policy.Config `json:"batching" yaml:"batching"`
+	Copies   int                  `json:"copies" yaml:"copies"`
+	Pattern  string               `json:"pattern" yaml:"pattern"`
+	Priority BrokerPriorityConfig `json:"priority" yaml:"priority"`
+	Inputs   []Config             `json:"inputs" yaml:"inputs"`
+	Batching policy.Config        `json:"batching" yaml:"batching"`
 }
 
 // NewBrokerConfig creates a new BrokerConfig with default values.
 func NewBrokerConfig() BrokerConfig {
 	return BrokerConfig{
 		Copies:   1,
+		Pattern:  "fan_in",
+		Priority: NewBrokerPriorityConfig(),
 		Inputs:   []Config{},
 		Batching: policy.NewConfig(),
 	}
@@ -32,16 +32,37 @@ func NewSequenceShardedJoinConfig() SequenceShardedJoinConfig {
 	}
 }
 
+// SequenceOrderedMergeConfig describes an optional mechanism for consuming all
+// inputs of the sequence in parallel and merging their messages in order of a
+// timestamp. The next message of each input is buffered and the message with
+// the earliest timestamp is emitted, which produces a globally ordered stream
+// as long as each input is ordered by the timestamp.
+type SequenceOrderedMergeConfig struct {
+	Enabled   bool   `json:"enabled" yaml:"enabled"`
+	Timestamp string `json:"timestamp" yaml:"timestamp"`
+}
+
+// NewSequenceOrderedMergeConfig creates a new sequence ordered merge
+// configuration with default values.
+func NewSequenceOrderedMergeConfig() SequenceOrderedMergeConfig {
+	return SequenceOrderedMergeConfig{
+		Enabled:   false,
+		Timestamp: "",
+	}
+}
+
 // SequenceConfig contains configuration values for the Sequence input type.
 type SequenceConfig struct {
-	ShardedJoin SequenceShardedJoinConfig `json:"sharded_join" yaml:"sharded_join"`
-	Inputs      []Config                  `json:"inputs" yaml:"inputs"`
+	ShardedJoin  SequenceShardedJoinConfig  `json:"sharded_join" yaml:"sharded_join"`
+	OrderedMerge SequenceOrderedMergeConfig `json:"ordered_merge" yaml:"ordered_merge"`
+	Inputs       []Config                   `json:"inputs" yaml:"inputs"`
 }
 
 // NewSequenceConfig creates a new SequenceConfig with default values.
 func NewSequenceConfig() SequenceConfig {
 	return SequenceConfig{
-		ShardedJoin: NewSequenceShardedJoinConfig(),
-		Inputs:      []Config{},
+		ShardedJoin:  NewSequenceShardedJoinConfig(),
+		OrderedMerge: NewSequenceOrderedMergeConfig(),
+		Inputs:       []Config{},
 	}
 }
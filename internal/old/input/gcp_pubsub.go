@@ -30,7 +30,22 @@ This input adds the following metadata fields to each message:
 ` + "```" + `
 
 You can access these metadata fields using
-[function interpolation](/docs/configuration/interpolation#metadata).`,
+[function interpolation](/docs/configuration/interpolation#metadata).
+
+### Exactly-Once Delivery
+
+When the target subscription has
+[exactly-once delivery](https://cloud.google.com/pubsub/docs/exactly-once-delivery)
+enabled this input waits for each acknowledgement to be confirmed by Pub/Sub.
+Acknowledgements that fail permanently, for example because the ack deadline
+of the message has already expired, are logged and the message is nacked so
+that it is redelivered promptly.
+
+Messages are kept leased by extending their ack deadline until they are
+acknowledged or the duration ` + "`max_extension`" + ` has passed. It is
+recommended to set ` + "`min_extension_period`" + ` to at least ` + "`60s`" + `
+for exactly-once subscriptions in order to reduce the chance of expired
+acknowledgements.`,
 		Categories: []string{
 			"Services",
 			"GCP",
@@ -41,6 +56,8 @@ You can access these metadata fields using
 			docs.FieldBool("sync", "Enable synchronous pull mode."),
 			docs.FieldInt("max_outstanding_messages", "The maximum number of outstanding pending messages to be consumed at a given time."),
 			docs.FieldInt("max_outstanding_bytes", "The maximum number of outstanding pending messages to be consumed measured in bytes."),
+			docs.FieldString("max_extension", "The maximum period of time for which the ack deadline of a message is extended while it is being processed. Set to an empty string in order to use the client default.", "60m", "10m").AtVersion("4.1.0").Advanced(),
+			docs.FieldString("min_extension_period", "The minimum period by which the ack deadline of a message is extended, must be between `10s` and `600s`. Set to an empty string in order to use the client default.", "60s").AtVersion("4.1.0").Advanced(),
 		),
 	}
 }
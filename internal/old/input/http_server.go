@@ -20,6 +20,7 @@ import (
 	"github.com/gorilla/websocket"
 
 	"github.com/benthosdev/benthos/v4/internal/bloblang/field"
+	"github.com/benthosdev/benthos/v4/internal/bloblang/mapping"
 	"github.com/benthosdev/benthos/v4/internal/component"
 	"github.com/benthosdev/benthos/v4/internal/component/input"
 	"github.com/benthosdev/benthos/v4/internal/component/metrics"
@@ -57,6 +58,8 @@ When the rate limit is breached HTTP requests will have a 429 response returned
 
 It's possible to return a response for each message received using [synchronous responses](/docs/guides/sync_responses). When doing so you can customise headers with the ` + "`sync_response` field `headers`" + `, which can also use [function interpolation](/docs/configuration/interpolation#bloblang-queries) in the value based on the response message contents.
 
+When a message is rejected downstream a 502 response is returned with the error as its body. If the message was rejected by a [` + "`reject`" + ` output](/docs/components/outputs/reject) with a structured reason then its code is used as the status of the response when it is a valid HTTP error status, and its message as the body.
+
 ### Endpoints
 
 The following fields specify endpoints that are registered for sending messages, and support path parameters of the form ` + "`/{foo}`" + `, which are added to ingested messages as metadata:
@@ -114,6 +117,12 @@ You can access these metadata fields using [function interpolation](/docs/config
 						"Content-Type": "application/octet-stream",
 					}),
 				docs.FieldObject("metadata_headers", "Specify criteria for which metadata values are added to the response as headers.").WithChildren(imetadata.IncludeFilterDocs()...),
+				docs.FieldBloblang(
+					"merge_mapping",
+					"An optional [Bloblang mapping](/docs/guides/bloblang/about) used to merge the responses returned by multiple outputs, such as those of a `fan_out` broker, into a single response message. The mapping is executed against an array of the contents of each response message, which are parsed as JSON where possible, and the metadata of the first response message.",
+					`root = this.fold({}, item -> item.tally.merge(item.value))`,
+					`root = this.index(0)`,
+				).HasDefault("").AtVersion("4.1.0"),
 			).Advanced(),
 		),
 		Categories: []string{
@@ -130,6 +139,7 @@ type HTTPServerResponseConfig struct {
 	Status          string                        `json:"status" yaml:"status"`
 	Headers         map[string]string             `json:"headers" yaml:"headers"`
 	ExtractMetadata imetadata.IncludeFilterConfig `json:"metadata_headers" yaml:"metadata_headers"`
+	MergeMapping    string                        `json:"merge_mapping" yaml:"merge_mapping"`
 }
 
 // NewHTTPServerResponseConfig creates a new HTTPServerConfig with default values.
@@ -140,6 +150,7 @@ func NewHTTPServerResponseConfig() HTTPServerResponseConfig {
 			"Content-Type": "application/octet-stream",
 		},
 		ExtractMetadata: imetadata.NewIncludeFilterConfig(),
+		MergeMapping:    "",
 	}
 }
 
@@ -199,6 +210,7 @@ type HTTPServer struct {
 	responseStatus  *field.Expression
 	responseHeaders map[string]*field.Expression
 	metaFilter      *imetadata.IncludeFilter
+	responseMerge   *mapping.Executor
 
 	handlerWG    sync.WaitGroup
 	transactions chan message.Transaction
@@ -274,6 +286,12 @@ func NewHTTPServer(conf Config, mgr interop.Manager, log log.Modular, stats metr
 		return nil, fmt.Errorf("failed to construct metadata filter: %w", err)
 	}
 
+	if h.conf.Response.MergeMapping != "" {
+		if h.responseMerge, err = mgr.BloblEnvironment().NewMapping(h.conf.Response.MergeMapping); err != nil {
+			return nil, fmt.Errorf("failed to parse response merge mapping: %v", err)
+		}
+	}
+
 	postHdlr := gzipHandler(h.postHandler)
 	wsHdlr := gzipHandler(h.wsHandler)
 	if mux != nil {
@@ -367,10 +385,12 @@ func (h *HTTPServer) extractMessageFromRequest(r *http.Request) (*message.Batch,
 	}
 	message.SetAllMetadata(msg, meta)
 
+	// Header keys are canonicalised, whereas propagation formats such as W3C
+	// trace context expect lower case keys.
 	textMapGeneric := map[string]interface{}{}
 	for k, vals := range r.Header {
 		for _, v := range vals {
-			textMapGeneric[k] = v
+			textMapGeneric[strings.ToLower(k)] = v
 		}
 	}
 
@@ -378,6 +398,24 @@ func (h *HTTPServer) extractMessageFromRequest(r *http.Request) (*message.Batch,
 	return msg, nil
 }
 
+func (h *HTTPServer) collectResponses(store transaction.ResultStore) (*message.Batch, error) {
+	if h.responseMerge != nil {
+		return transaction.MergeResults(store, h.responseMerge)
+	}
+	return transaction.JoinResults(store), nil
+}
+
+// rejectionResponse returns the body and status code of a response to a request
+// that was nacked, where structured rejections with an HTTP error code are
+// responded to with their code and message.
+func rejectionResponse(err error) (string, int) {
+	var rErr *component.RejectionError
+	if errors.As(err, &rErr) && rErr.Code >= 400 && rErr.Code < 600 {
+		return rErr.Message, rErr.Code
+	}
+	return err.Error(), http.StatusBadGateway
+}
+
 func (h *HTTPServer) postHandler(w http.ResponseWriter, r *http.Request) {
 	h.handlerWG.Add(1)
 	defer h.handlerWG.Done()
@@ -445,7 +483,8 @@ func (h *HTTPServer) postHandler(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, "Server closing", http.StatusServiceUnavailable)
 			return
 		} else if res != nil {
-			http.Error(w, res.Error(), http.StatusBadGateway)
+			errStr, code := rejectionResponse(res)
+			http.Error(w, errStr, code)
 			return
 		}
 		tTaken := time.Since(startedAt).Nanoseconds()
@@ -461,12 +500,11 @@ func (h *HTTPServer) postHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	responseMsg := message.QuickBatch(nil)
-	for _, resMsg := range store.Get() {
-		_ = resMsg.Iter(func(i int, part *message.Part) error {
-			responseMsg.Append(part)
-			return nil
-		})
+	responseMsg, err := h.collectResponses(store)
+	if err != nil {
+		h.log.Errorf("Failed to merge sync responses: %v\n", err)
+		w.WriteHeader(http.StatusBadGateway)
+		return
 	}
 	if responseMsg.Len() > 0 {
 		for k, v := range h.responseHeaders {
@@ -649,12 +687,12 @@ func (h *HTTPServer) wsHandler(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		for _, responseMsg := range store.Get() {
-			if err := responseMsg.Iter(func(i int, part *message.Part) error {
-				return ws.WriteMessage(websocket.TextMessage, part.Get())
-			}); err != nil {
-				h.log.Errorf("Failed to send sync response over websocket: %v\n", err)
-			}
+		if responseMsg, err := h.collectResponses(store); err != nil {
+			h.log.Errorf("Failed to merge sync responses: %v\n", err)
+		} else if err := responseMsg.Iter(func(i int, part *message.Part) error {
+			return ws.WriteMessage(websocket.TextMessage, part.Get())
+		}); err != nil {
+			h.log.Errorf("Failed to send sync response over websocket: %v\n", err)
 		}
 
 		tracing.FinishSpans(msg)
@@ -24,6 +24,7 @@ import (
 	"gopkg.in/yaml.v3"
 
 	"github.com/benthosdev/benthos/v4/internal/api"
+	"github.com/benthosdev/benthos/v4/internal/component"
 	iinput "github.com/benthosdev/benthos/v4/internal/component/input"
 	"github.com/benthosdev/benthos/v4/internal/component/metrics"
 	"github.com/benthosdev/benthos/v4/internal/log"
@@ -504,6 +505,51 @@ func TestHTTPTimeout(t *testing.T) {
 	}
 }
 
+func TestHTTPRejectionStatus(t *testing.T) {
+	t.Parallel()
+
+	reg := apiRegGorillaMutWrapper{mut: mux.NewRouter()}
+	mgr, err := manager.NewV2(manager.NewResourceConfig(), reg, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	conf := input.NewConfig()
+	conf.HTTPServer.Path = "/testpost"
+
+	h, err := input.NewHTTPServer(conf, mgr, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	server := httptest.NewServer(reg.mut)
+	defer server.Close()
+
+	for _, test := range []struct {
+		err  error
+		code int
+		body string
+	}{
+		{err: &component.RejectionError{Code: 429, Message: "slow down"}, code: http.StatusTooManyRequests, body: "slow down\n"},
+		{err: &component.RejectionError{Code: 200, Message: "not an error"}, code: http.StatusBadGateway, body: "not an error (code 200)\n"},
+		{err: errors.New("nope"), code: http.StatusBadGateway, body: "nope\n"},
+	} {
+		go func(rErr error) {
+			tran := <-h.TransactionChan()
+			require.NoError(t, tran.Ack(context.Background(), rErr))
+		}(test.err)
+
+		res, err := http.Post(server.URL+"/testpost", "application/octet-stream", bytes.NewBuffer([]byte("hello world")))
+		require.NoError(t, err)
+
+		body, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+		res.Body.Close()
+
+		assert.Equal(t, test.code, res.StatusCode)
+		assert.Equal(t, test.body, string(body))
+	}
+
+	h.CloseAsync()
+	require.NoError(t, h.WaitForClose(time.Second*5))
+}
+
 func TestHTTPRateLimit(t *testing.T) {
 	tCtx, done := context.WithTimeout(context.Background(), time.Second*5)
 	defer done()
@@ -974,6 +1020,62 @@ func TestHTTPSyncResponseMultipart(t *testing.T) {
 	wg.Wait()
 }
 
+func TestHTTPSyncResponseMergeMapping(t *testing.T) {
+	tCtx, done := context.WithTimeout(context.Background(), time.Second*5)
+	defer done()
+
+	t.Parallel()
+
+	reg := apiRegGorillaMutWrapper{mut: mux.NewRouter()}
+	mgr, err := manager.NewV2(manager.NewResourceConfig(), reg, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	conf := input.NewConfig()
+	conf.HTTPServer.Path = "/testpost"
+	conf.HTTPServer.Response.MergeMapping = `root = this.fold({}, item -> item.tally.merge(item.value))`
+
+	h, err := input.NewHTTPServer(conf, mgr, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	server := httptest.NewServer(reg.mut)
+	t.Cleanup(func() {
+		server.Close()
+	})
+
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		res, err := http.Post(server.URL+"/testpost", "application/json", bytes.NewReader([]byte(`{"id":"foo"}`)))
+		require.NoError(t, err)
+		require.Equal(t, 200, res.StatusCode)
+
+		resBytes, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+		assert.Equal(t, `{"a":"from a","b":"from b"}`, string(resBytes))
+	}()
+
+	var ts message.Transaction
+	select {
+	case ts = <-h.TransactionChan():
+		for _, resp := range []string{`{"a":"from a"}`, `{"b":"from b"}`} {
+			resMsg := ts.Payload.Copy()
+			resMsg.Get(0).Set([]byte(resp))
+			require.NoError(t, transaction.SetAsResponse(resMsg))
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for message")
+	}
+	require.NoError(t, ts.Ack(tCtx, nil))
+
+	h.CloseAsync()
+	err = h.WaitForClose(time.Second * 5)
+	require.NoError(t, err)
+
+	wg.Wait()
+}
+
 func TestHTTPSyncResponseHeadersStatus(t *testing.T) {
 	tCtx, done := context.WithTimeout(context.Background(), time.Second*5)
 	defer done()
@@ -184,6 +184,8 @@ func NewKafka(conf Config, mgr interop.Manager, log log.Modular, stats metrics.T
 		if rdr, err = span.NewReader(TypeKafka, conf.Kafka.ExtractTracingMap, rdr, mgr, log); err != nil {
 			return nil, err
 		}
+	} else {
+		rdr = span.NewMetadataReader(TypeKafka, rdr)
 	}
 	return NewAsyncReader(TypeKafka, false, reader.NewAsyncPreserver(rdr), log, stats)
 }
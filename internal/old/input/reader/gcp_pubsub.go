@@ -2,6 +2,7 @@ package reader
 
 import (
 	"context"
+	"fmt"
 	"strconv"
 	"sync"
 	"time"
@@ -21,6 +22,8 @@ type GCPPubSubConfig struct {
 	MaxOutstandingMessages int    `json:"max_outstanding_messages" yaml:"max_outstanding_messages"`
 	MaxOutstandingBytes    int    `json:"max_outstanding_bytes" yaml:"max_outstanding_bytes"`
 	Sync                   bool   `json:"sync" yaml:"sync"`
+	MaxExtension           string `json:"max_extension" yaml:"max_extension"`
+	MinExtensionPeriod     string `json:"min_extension_period" yaml:"min_extension_period"`
 }
 
 // NewGCPPubSubConfig creates a new Config with default values.
@@ -31,6 +34,8 @@ func NewGCPPubSubConfig() GCPPubSubConfig {
 		MaxOutstandingMessages: pubsub.DefaultReceiveSettings.MaxOutstandingMessages,
 		MaxOutstandingBytes:    pubsub.DefaultReceiveSettings.MaxOutstandingBytes,
 		Sync:                   false,
+		MaxExtension:           "60m",
+		MinExtensionPeriod:     "",
 	}
 }
 
@@ -41,6 +46,9 @@ func NewGCPPubSubConfig() GCPPubSubConfig {
 type GCPPubSub struct {
 	conf GCPPubSubConfig
 
+	maxExtension       time.Duration
+	minExtensionPeriod time.Duration
+
 	subscription *pubsub.Subscription
 	msgsChan     chan *pubsub.Message
 	closeFunc    context.CancelFunc
@@ -58,16 +66,31 @@ func NewGCPPubSub(
 	log log.Modular,
 	stats metrics.Type,
 ) (*GCPPubSub, error) {
-	client, err := pubsub.NewClient(context.Background(), conf.ProjectID)
-	if err != nil {
+	c := &GCPPubSub{
+		conf:  conf,
+		log:   log,
+		stats: stats,
+	}
+
+	var err error
+	if conf.MaxExtension != "" {
+		if c.maxExtension, err = time.ParseDuration(conf.MaxExtension); err != nil {
+			return nil, fmt.Errorf("failed to parse max extension: %w", err)
+		}
+	}
+	if conf.MinExtensionPeriod != "" {
+		if c.minExtensionPeriod, err = time.ParseDuration(conf.MinExtensionPeriod); err != nil {
+			return nil, fmt.Errorf("failed to parse min extension period: %w", err)
+		}
+		if c.minExtensionPeriod < 10*time.Second || c.minExtensionPeriod > 600*time.Second {
+			return nil, fmt.Errorf("min extension period must be between 10s and 600s, got: %v", c.minExtensionPeriod)
+		}
+	}
+
+	if c.client, err = pubsub.NewClient(context.Background(), conf.ProjectID); err != nil {
 		return nil, err
 	}
-	return &GCPPubSub{
-		conf:   conf,
-		log:    log,
-		stats:  stats,
-		client: client,
-	}, nil
+	return c, nil
 }
 
 // Connect attempts to establish a connection to the target subscription.
@@ -88,6 +111,10 @@ func (c *GCPPubSub) ConnectWithContext(ignored context.Context) error {
 	sub.ReceiveSettings.MaxOutstandingMessages = c.conf.MaxOutstandingMessages
 	sub.ReceiveSettings.MaxOutstandingBytes = c.conf.MaxOutstandingBytes
 	sub.ReceiveSettings.Synchronous = c.conf.Sync
+	if c.maxExtension > 0 {
+		sub.ReceiveSettings.MaxExtension = c.maxExtension
+	}
+	sub.ReceiveSettings.MinExtensionPeriod = c.minExtensionPeriod
 
 	subCtx, cancel := context.WithCancel(context.Background())
 	msgsChan := make(chan *pubsub.Message, 1)
@@ -152,14 +179,40 @@ func (c *GCPPubSub) ReadWithContext(ctx context.Context) (*message.Batch, AsyncA
 
 	return msg, func(ctx context.Context, res error) error {
 		if res != nil {
-			gmsg.Nack()
-		} else {
-			gmsg.Ack()
+			return awaitAckResult(ctx, "nack", gmsg.NackWithResult())
+		}
+		if err := awaitAckResult(ctx, "ack", gmsg.AckWithResult()); err != nil {
+			// With exactly-once delivery enabled a failed ack means the message
+			// will be redelivered, we therefore nack it in order for that to
+			// happen as soon as possible rather than once the deadline expires.
+			_ = gmsg.NackWithResult()
+			return err
 		}
 		return nil
 	}, nil
 }
 
+// Blocks until the result of an acknowledgement is known. When exactly-once
+// delivery is disabled for the subscription the result is available
+// immediately and is always successful.
+func awaitAckResult(ctx context.Context, kind string, res *pubsub.AckResult) error {
+	status, err := res.Get(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to %v message: %w", kind, err)
+	}
+	switch status {
+	case pubsub.AcknowledgeStatusSuccess:
+		return nil
+	case pubsub.AcknowledgeStatusPermissionDenied:
+		return fmt.Errorf("failed to %v message: permission denied", kind)
+	case pubsub.AcknowledgeStatusFailedPrecondition:
+		return fmt.Errorf("failed to %v message: failed precondition", kind)
+	case pubsub.AcknowledgeStatusInvalidAckID:
+		return fmt.Errorf("failed to %v message: invalid ack ID", kind)
+	}
+	return fmt.Errorf("failed to %v message: unknown error", kind)
+}
+
 // CloseAsync begins cleaning up resources used by this reader asynchronously.
 func (c *GCPPubSub) CloseAsync() {
 	c.subMut.Lock()
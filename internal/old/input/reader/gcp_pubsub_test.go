@@ -0,0 +1,75 @@
+package reader
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/pubsub"
+	"cloud.google.com/go/pubsub/pstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+	"github.com/benthosdev/benthos/v4/internal/log"
+)
+
+func TestGCPPubSubExactlyOnce(t *testing.T) {
+	srv := pstest.NewServer()
+	defer srv.Close()
+
+	require.NoError(t, os.Setenv("PUBSUB_EMULATOR_HOST", srv.Addr))
+	defer os.Unsetenv("PUBSUB_EMULATOR_HOST")
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*10)
+	defer done()
+
+	client, err := pubsub.NewClient(ctx, "project")
+	require.NoError(t, err)
+	defer client.Close()
+
+	topic, err := client.CreateTopic(ctx, "topic")
+	require.NoError(t, err)
+	defer topic.Stop()
+
+	_, err = client.CreateSubscription(ctx, "sub", pubsub.SubscriptionConfig{
+		Topic:                     topic,
+		EnableExactlyOnceDelivery: true,
+	})
+	require.NoError(t, err)
+
+	_, err = topic.Publish(ctx, &pubsub.Message{Data: []byte("hello world")}).Get(ctx)
+	require.NoError(t, err)
+
+	conf := NewGCPPubSubConfig()
+	conf.ProjectID = "project"
+	conf.SubscriptionID = "sub"
+	conf.MinExtensionPeriod = "60s"
+
+	r, err := NewGCPPubSub(conf, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	require.NoError(t, r.ConnectWithContext(ctx))
+	defer func() {
+		r.CloseAsync()
+		require.NoError(t, r.WaitForClose(time.Second))
+	}()
+
+	msg, ackFn, err := r.ReadWithContext(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "hello world", string(msg.Get(0).Get()))
+	require.NoError(t, ackFn(ctx, nil))
+
+	msgs := srv.Messages()
+	require.Len(t, msgs, 1)
+	assert.Equal(t, 1, msgs[0].Acks)
+}
+
+func TestGCPPubSubConfigErrors(t *testing.T) {
+	conf := NewGCPPubSubConfig()
+	conf.MinExtensionPeriod = "5s"
+
+	_, err := NewGCPPubSub(conf, log.Noop(), metrics.Noop())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "min extension period must be between")
+}
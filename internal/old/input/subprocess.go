@@ -6,10 +6,15 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"os"
 	"os/exec"
 	"sync"
 	"time"
 
+	"github.com/cenkalti/backoff/v4"
+	"gopkg.in/yaml.v3"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang/field"
 	"github.com/benthosdev/benthos/v4/internal/component"
 	"github.com/benthosdev/benthos/v4/internal/component/input"
 	"github.com/benthosdev/benthos/v4/internal/component/metrics"
@@ -25,7 +30,7 @@ import (
 func init() {
 	Constructors[TypeSubprocess] = TypeSpec{
 		constructor: fromSimpleConstructor(func(conf Config, mgr interop.Manager, log log.Modular, stats metrics.Type) (input.Streamed, error) {
-			b, err := newSubprocess(conf.Subprocess)
+			b, err := newSubprocess(conf.Subprocess, mgr, log)
 			if err != nil {
 				return nil, err
 			}
@@ -35,20 +40,47 @@ func init() {
 		Summary: `
 Executes a command, runs it as a subprocess, and consumes messages from it over stdout.`,
 		Description: `
-Messages are consumed according to a specified codec. The command is executed once and if it terminates the input also closes down gracefully. Alternatively, the field ` + "`restart_on_close` can be set to `true`" + ` in order to have Benthos re-execute the command each time it stops.
+Messages are consumed according to a specified codec. By default the command is executed once and if it terminates the input also closes down gracefully. Alternatively, the field ` + "`restart_policy`" + ` can be set in order to have Benthos re-execute the command either each time it stops (` + "`always`" + `) or only when it exits with an error (` + "`on_failure`" + `). Restarts are delayed according to an exponential backoff, which is reset each time a message is consumed, and can be limited with the field ` + "`max_restarts`" + `.
 
 The field ` + "`max_buffer`" + ` defines the maximum message size able to be read from the subprocess. This value should be set significantly above the real expected maximum message size.
 
-The execution environment of the subprocess is the same as the Benthos instance, including environment variables and the current working directory.`,
+The execution environment of the subprocess is the same as the Benthos instance, including environment variables and the current working directory. Additional environment variables can be set with the field ` + "`env`" + `, where values are [interpolated](/docs/configuration/interpolation#bloblang-queries) each time the command is executed.
+
+### Stderr
+
+By default each line written to stderr by the subprocess is reported as an error by the input, which results in it being logged at the ` + "`ERROR`" + ` level. The field ` + "`stderr`" + ` can be set to ` + "`log`" + ` in order to log lines at the ` + "`WARN`" + ` level instead, or to ` + "`message`" + ` in order to consume lines from stderr as messages.
+
+### Metadata
+
+When ` + "`stderr`" + ` is set to ` + "`message`" + ` all messages are given the metadata field ` + "`subprocess_stream`" + `, set to either ` + "`stdout`" + ` or ` + "`stderr`" + ` depending on the stream they were consumed from.`,
 		Config: docs.FieldComponent().WithChildren(
 			docs.FieldString("name", "The command to execute as a subprocess.", "cat", "sed", "awk"),
 			docs.FieldString("args", "A list of arguments to provide the command.").Array(),
+			docs.FieldString(
+				"env", "A map of environment variables to set for the subprocess in addition to those of the Benthos instance.",
+				map[string]string{"FOO": "bar", "STARTED_AT": "${! timestamp_unix() }"},
+			).IsInterpolated().Map().AtVersion("4.1.0"),
 			docs.FieldString(
 				"codec", "The way in which messages should be consumed from the subprocess.",
 			).HasOptions("lines"),
-			docs.FieldBool("restart_on_exit", "Whether the command should be re-executed each time the subprocess ends."),
+			docs.FieldBool("restart_on_exit", "Whether the command should be re-executed each time the subprocess ends. This field is deprecated in favour of `restart_policy`.").Deprecated().MigrateWith(migrateRestartOnExit),
+			docs.FieldString("restart_policy", "Determines when the command should be re-executed after the subprocess ends.").HasAnnotatedOptions(
+				"never", "Never re-execute the command, the input closes once the subprocess ends.",
+				"always", "Always re-execute the command.",
+				"on_failure", "Re-execute the command only when the subprocess exits with an error, the input closes once the subprocess ends successfully.",
+			).AtVersion("4.1.0"),
+			docs.FieldInt("max_restarts", "The maximum number of times the command is re-executed before the input closes. If set to zero there is no limit.").AtVersion("4.1.0"),
+			docs.FieldObject("restart_backoff", "Control time intervals between re-executions of the command.").WithChildren(
+				docs.FieldString("initial_interval", "The initial period to wait before re-executing the command."),
+				docs.FieldString("max_interval", "The maximum period to wait before re-executing the command."),
+			).Advanced().AtVersion("4.1.0"),
+			docs.FieldString("stderr", "Determines how lines written to stderr by the subprocess are handled.").HasAnnotatedOptions(
+				"error", "Report each line as an error of the input.",
+				"log", "Log each line at the `WARN` level.",
+				"message", "Consume each line as a message.",
+			).AtVersion("4.1.0"),
 			docs.FieldInt("max_buffer", "The maximum expected size of an individual message.").Advanced(),
-		),
+		).ChildDefaultAndTypesFromStruct(NewSubprocessConfig()),
 		Categories: []string{
 			"Utility",
 		},
@@ -86,13 +118,25 @@ func codecFromStr(codec string) (subprocCodec, error) {
 
 //------------------------------------------------------------------------------
 
+// SubprocessRestartBackoffConfig contains configuration for the intervals
+// between re-executions of a subprocess.
+type SubprocessRestartBackoffConfig struct {
+	InitialInterval string `json:"initial_interval" yaml:"initial_interval"`
+	MaxInterval     string `json:"max_interval" yaml:"max_interval"`
+}
+
 // SubprocessConfig contains configuration for the Subprocess input type.
 type SubprocessConfig struct {
-	Name          string   `json:"name" yaml:"name"`
-	Args          []string `json:"args" yaml:"args"`
-	Codec         string   `json:"codec" yaml:"codec"`
-	RestartOnExit bool     `json:"restart_on_exit" yaml:"restart_on_exit"`
-	MaxBuffer     int      `json:"max_buffer" yaml:"max_buffer"`
+	Name           string                         `json:"name" yaml:"name"`
+	Args           []string                       `json:"args" yaml:"args"`
+	Env            map[string]string              `json:"env" yaml:"env"`
+	Codec          string                         `json:"codec" yaml:"codec"`
+	RestartOnExit  bool                           `json:"restart_on_exit" yaml:"restart_on_exit"`
+	RestartPolicy  string                         `json:"restart_policy" yaml:"restart_policy"`
+	MaxRestarts    int                            `json:"max_restarts" yaml:"max_restarts"`
+	RestartBackoff SubprocessRestartBackoffConfig `json:"restart_backoff" yaml:"restart_backoff"`
+	Stderr         string                         `json:"stderr" yaml:"stderr"`
+	MaxBuffer      int                            `json:"max_buffer" yaml:"max_buffer"`
 }
 
 // NewSubprocessConfig creates a new SubprocessConfig with default values.
@@ -100,46 +144,160 @@ func NewSubprocessConfig() SubprocessConfig {
 	return SubprocessConfig{
 		Name:          "",
 		Args:          []string{},
+		Env:           map[string]string{},
 		Codec:         "lines",
 		RestartOnExit: false,
-		MaxBuffer:     bufio.MaxScanTokenSize,
+		RestartPolicy: "never",
+		MaxRestarts:   0,
+		RestartBackoff: SubprocessRestartBackoffConfig{
+			InitialInterval: "100ms",
+			MaxInterval:     "10s",
+		},
+		Stderr:    "error",
+		MaxBuffer: bufio.MaxScanTokenSize,
 	}
 }
 
-// Subprocess executes a bloblang mapping with an empty context each time this
-// input is read from. An interval period must be specified that determines how
-// often a message is generated.
+type subprocMsg struct {
+	data   []byte
+	stderr bool
+}
+
+// subprocRun represents a single execution of the command.
+type subprocRun struct {
+	msgChan chan subprocMsg
+	errChan chan error
+
+	// Set before msgChan and errChan are closed.
+	exitErr error
+}
+
+// Subprocess executes a command and consumes messages from its stdout, and
+// optionally stderr, re-executing the command according to a restart policy.
 type Subprocess struct {
 	conf  SubprocessConfig
 	codec subprocCodec
+	env   map[string]*field.Expression
+	log   log.Modular
 
-	msgChan chan []byte
-	errChan chan error
+	run      *subprocRun
+	exited   bool
+	restarts int
+	boff     backoff.BackOff
 
 	close func()
 	ctx   context.Context
 }
 
-// newSubprocess creates a new bloblang input reader type.
-func newSubprocess(conf SubprocessConfig) (*Subprocess, error) {
+// migrateRestartOnExit rewrites restart_on_exit into the equivalent
+// restart_policy, which restart_on_exit only overrides when it is `never`.
+func migrateRestartOnExit(value, parent *yaml.Node) error {
+	var restart bool
+	if err := value.Decode(&restart); err != nil {
+		return err
+	}
+	if !restart {
+		return nil
+	}
+	for i := 0; i < len(parent.Content)-1; i += 2 {
+		if parent.Content[i].Value == "restart_policy" && parent.Content[i+1].Value != "never" {
+			return nil
+		}
+	}
+	return docs.SetYAMLField(parent, "restart_policy", "always")
+}
+
+func newSubprocess(conf SubprocessConfig, mgr interop.Manager, log log.Modular) (*Subprocess, error) {
+	if conf.RestartOnExit && conf.RestartPolicy == "never" {
+		conf.RestartPolicy = "always"
+	}
+	switch conf.RestartPolicy {
+	case "never", "always", "on_failure":
+	default:
+		return nil, fmt.Errorf("restart_policy not recognised: %v", conf.RestartPolicy)
+	}
+	switch conf.Stderr {
+	case "error", "log", "message":
+	default:
+		return nil, fmt.Errorf("stderr not recognised: %v", conf.Stderr)
+	}
+
 	s := &Subprocess{
 		conf: conf,
+		env:  map[string]*field.Expression{},
+		log:  log,
 	}
-	s.ctx, s.close = context.WithCancel(context.Background())
+
+	boff := backoff.NewExponentialBackOff()
+	boff.MaxElapsedTime = 0
 	var err error
+	if boff.InitialInterval, err = time.ParseDuration(conf.RestartBackoff.InitialInterval); err != nil {
+		return nil, fmt.Errorf("failed to parse restart_backoff.initial_interval: %w", err)
+	}
+	if boff.MaxInterval, err = time.ParseDuration(conf.RestartBackoff.MaxInterval); err != nil {
+		return nil, fmt.Errorf("failed to parse restart_backoff.max_interval: %w", err)
+	}
+	boff.Reset()
+	s.boff = boff
+
+	for k, v := range conf.Env {
+		if s.env[k], err = mgr.BloblEnvironment().NewField(v); err != nil {
+			return nil, fmt.Errorf("failed to parse env %v expression: %w", k, err)
+		}
+	}
+
+	s.ctx, s.close = context.WithCancel(context.Background())
 	if s.codec, err = codecFromStr(s.conf.Codec); err != nil {
 		return nil, err
 	}
 	return s, nil
 }
 
-// ConnectWithContext establishes a Subprocess reader.
+func (s *Subprocess) shouldRestart(exitErr error) bool {
+	switch s.conf.RestartPolicy {
+	case "never":
+		return false
+	case "on_failure":
+		if exitErr == nil {
+			return false
+		}
+	}
+	if s.conf.MaxRestarts > 0 && s.restarts >= s.conf.MaxRestarts {
+		s.log.Warnf("Subprocess will not be restarted as the maximum number of restarts (%v) has been reached\n", s.conf.MaxRestarts)
+		return false
+	}
+	return true
+}
+
+func (s *Subprocess) command() *exec.Cmd {
+	cmd := exec.CommandContext(s.ctx, s.conf.Name, s.conf.Args...)
+	if len(s.env) > 0 {
+		cmd.Env = os.Environ()
+		emptyMsg := message.QuickBatch(nil)
+		for k, v := range s.env {
+			cmd.Env = append(cmd.Env, k+"="+v.String(0, emptyMsg))
+		}
+	}
+	return cmd
+}
+
+// ConnectWithContext executes the command, waiting for a backoff period first
+// when the command has previously been executed.
 func (s *Subprocess) ConnectWithContext(ctx context.Context) error {
-	if s.msgChan != nil {
+	if s.run != nil {
 		return nil
 	}
 
-	cmd := exec.CommandContext(s.ctx, s.conf.Name, s.conf.Args...)
+	if s.exited {
+		select {
+		case <-time.After(s.boff.NextBackOff()):
+		case <-ctx.Done():
+			return component.ErrTimeout
+		}
+		s.restarts++
+	}
+
+	cmd := s.command()
 
 	stdout, err := cmd.StdoutPipe()
 	if err != nil {
@@ -153,8 +311,10 @@ func (s *Subprocess) ConnectWithContext(ctx context.Context) error {
 		return err
 	}
 
-	msgChan := make(chan []byte)
-	errChan := make(chan error)
+	run := &subprocRun{
+		msgChan: make(chan subprocMsg),
+		errChan: make(chan error),
+	}
 
 	outScanner, errScanner := s.codec(s.conf, stdout, stderr)
 
@@ -171,14 +331,14 @@ func (s *Subprocess) ConnectWithContext(ctx context.Context) error {
 				copy(dataCopy, data)
 
 				select {
-				case msgChan <- dataCopy:
+				case run.msgChan <- subprocMsg{data: dataCopy}:
 				case <-s.ctx.Done():
 				}
 			}
 
 			if err := outScanner.Err(); err != nil {
 				select {
-				case errChan <- err:
+				case run.errChan <- err:
 				case <-s.ctx.Done():
 				}
 			}
@@ -188,58 +348,89 @@ func (s *Subprocess) ConnectWithContext(ctx context.Context) error {
 			defer wg.Done()
 
 			for errScanner.Scan() {
-				select {
-				case errChan <- errors.New(errScanner.Text()):
-				case <-s.ctx.Done():
+				switch s.conf.Stderr {
+				case "log":
+					s.log.Warnf("Subprocess stderr: %s\n", errScanner.Text())
+				case "message":
+					data := errScanner.Bytes()
+					dataCopy := make([]byte, len(data))
+					copy(dataCopy, data)
+
+					select {
+					case run.msgChan <- subprocMsg{data: dataCopy, stderr: true}:
+					case <-s.ctx.Done():
+					}
+				default:
+					select {
+					case run.errChan <- errors.New(errScanner.Text()):
+					case <-s.ctx.Done():
+					}
 				}
 			}
 
 			if err := errScanner.Err(); err != nil {
 				select {
-				case errChan <- err:
+				case run.errChan <- err:
 				case <-s.ctx.Done():
 				}
 			}
 		}()
 
 		wg.Wait()
-		close(msgChan)
-		close(errChan)
+		run.exitErr = cmd.Wait()
+		close(run.msgChan)
+		close(run.errChan)
 	}()
 
-	s.msgChan = msgChan
-	s.errChan = errChan
+	s.run = run
 	return nil
 }
 
-// ReadWithContext a new bloblang generated message.
+func (s *Subprocess) onExit(run *subprocRun) error {
+	s.run = nil
+	s.exited = true
+	if s.ctx.Err() != nil {
+		return component.ErrTypeClosed
+	}
+	if run.exitErr != nil {
+		s.log.Warnf("Subprocess exited with error: %v\n", run.exitErr)
+	} else {
+		s.log.Infoln("Subprocess exited")
+	}
+	if s.shouldRestart(run.exitErr) {
+		return component.ErrNotConnected
+	}
+	return component.ErrTypeClosed
+}
+
+// ReadWithContext attempts to read a new message from the subprocess.
 func (s *Subprocess) ReadWithContext(ctx context.Context) (*message.Batch, reader.AsyncAckFn, error) {
-	msgChan, errChan := s.msgChan, s.errChan
-	if msgChan == nil {
+	run := s.run
+	if run == nil {
 		return nil, nil, component.ErrNotConnected
 	}
 
 	select {
-	case b, open := <-msgChan:
+	case m, open := <-run.msgChan:
 		if !open {
-			if s.conf.RestartOnExit {
-				s.msgChan = nil
-				s.errChan = nil
-				return nil, nil, component.ErrNotConnected
+			return nil, nil, s.onExit(run)
+		}
+		s.boff.Reset()
+
+		part := message.NewPart(m.data)
+		if s.conf.Stderr == "message" {
+			if m.stderr {
+				part.MetaSet("subprocess_stream", "stderr")
+			} else {
+				part.MetaSet("subprocess_stream", "stdout")
 			}
-			return nil, nil, component.ErrTypeClosed
 		}
 		msg := message.QuickBatch(nil)
-		msg.Append(message.NewPart(b))
+		msg.Append(part)
 		return msg, func(context.Context, error) error { return nil }, nil
-	case err, open := <-errChan:
+	case err, open := <-run.errChan:
 		if !open {
-			if s.conf.RestartOnExit {
-				s.msgChan = nil
-				s.errChan = nil
-				return nil, nil, component.ErrNotConnected
-			}
-			return nil, nil, component.ErrTypeClosed
+			return nil, nil, s.onExit(run)
 		}
 		return nil, nil, err
 	case <-ctx.Done():
@@ -248,12 +439,12 @@ func (s *Subprocess) ReadWithContext(ctx context.Context) (*message.Batch, reade
 	return nil, nil, component.ErrTimeout
 }
 
-// CloseAsync shuts down the bloblang reader.
+// CloseAsync shuts down the subprocess reader.
 func (s *Subprocess) CloseAsync() {
 	s.close()
 }
 
-// WaitForClose blocks until the bloblang input has closed down.
+// WaitForClose blocks until the subprocess input has closed down.
 func (s *Subprocess) WaitForClose(timeout time.Duration) error {
 	return nil
 }
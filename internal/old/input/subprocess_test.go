@@ -171,3 +171,98 @@ func main() {
 	i.CloseAsync()
 	require.NoError(t, i.WaitForClose(time.Second))
 }
+
+func requireTranChanClosed(t *testing.T, tranChan <-chan message.Transaction) {
+	t.Helper()
+
+	select {
+	case _, open := <-tranChan:
+		require.False(t, open)
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out")
+	}
+}
+
+func TestSubprocessRestartOnFailure(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypeSubprocess
+	conf.Subprocess.Name = "sh"
+	conf.Subprocess.Args = []string{"-c", `echo "$FOO"; exit 1`}
+	conf.Subprocess.Env = map[string]string{
+		"FOO": `${! "hello".uppercase() }`,
+	}
+	conf.Subprocess.RestartPolicy = "on_failure"
+	conf.Subprocess.MaxRestarts = 2
+	conf.Subprocess.RestartBackoff.InitialInterval = "1ms"
+	conf.Subprocess.RestartBackoff.MaxInterval = "1ms"
+
+	i, err := New(conf, mock.NewManager(), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	for j := 0; j < 3; j++ {
+		msg := readMsg(t, i.TransactionChan())
+		assert.Equal(t, 1, msg.Len())
+		assert.Equal(t, "HELLO", string(msg.Get(0).Get()))
+	}
+	requireTranChanClosed(t, i.TransactionChan())
+
+	i.CloseAsync()
+	require.NoError(t, i.WaitForClose(time.Second))
+}
+
+func TestSubprocessRestartOnFailureSucceeds(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypeSubprocess
+	conf.Subprocess.Name = "sh"
+	conf.Subprocess.Args = []string{"-c", `echo foo`}
+	conf.Subprocess.RestartPolicy = "on_failure"
+	conf.Subprocess.RestartBackoff.InitialInterval = "1ms"
+
+	i, err := New(conf, mock.NewManager(), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	msg := readMsg(t, i.TransactionChan())
+	assert.Equal(t, 1, msg.Len())
+	assert.Equal(t, "foo", string(msg.Get(0).Get()))
+	requireTranChanClosed(t, i.TransactionChan())
+
+	i.CloseAsync()
+	require.NoError(t, i.WaitForClose(time.Second))
+}
+
+func TestSubprocessStderrMessages(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypeSubprocess
+	conf.Subprocess.Name = "sh"
+	conf.Subprocess.Args = []string{"-c", `echo foo; echo bar >&2`}
+	conf.Subprocess.Stderr = "message"
+
+	i, err := New(conf, mock.NewManager(), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	streams := map[string]string{}
+	for j := 0; j < 2; j++ {
+		msg := readMsg(t, i.TransactionChan())
+		require.Equal(t, 1, msg.Len())
+		streams[msg.Get(0).MetaGet("subprocess_stream")] = string(msg.Get(0).Get())
+	}
+	assert.Equal(t, map[string]string{
+		"stdout": "foo",
+		"stderr": "bar",
+	}, streams)
+	requireTranChanClosed(t, i.TransactionChan())
+
+	i.CloseAsync()
+	require.NoError(t, i.WaitForClose(time.Second))
+}
+
+func TestSubprocessBadRestartPolicy(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypeSubprocess
+	conf.Subprocess.Name = "sh"
+	conf.Subprocess.RestartPolicy = "sometimes"
+
+	_, err := New(conf, mock.NewManager(), log.Noop(), metrics.Noop())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "restart_policy not recognised: sometimes")
+}
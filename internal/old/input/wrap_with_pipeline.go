@@ -3,6 +3,7 @@ package input
 import (
 	"time"
 
+	"github.com/benthosdev/benthos/v4/internal/component"
 	"github.com/benthosdev/benthos/v4/internal/component/input"
 	iprocessor "github.com/benthosdev/benthos/v4/internal/component/processor"
 	"github.com/benthosdev/benthos/v4/internal/message"
@@ -60,6 +61,11 @@ func (i *WithPipeline) Connected() bool {
 	return i.in.Connected()
 }
 
+// LastError returns the most recent error encountered by the child input.
+func (i *WithPipeline) LastError() (time.Time, error) {
+	return component.LastErrorOf(i.in)
+}
+
 //------------------------------------------------------------------------------
 
 // CloseAsync triggers a closure of this object but does not block.
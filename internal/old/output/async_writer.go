@@ -46,6 +46,8 @@ type AsyncSink interface {
 
 // AsyncWriter is an output type that writes messages to a writer.Type.
 type AsyncWriter struct {
+	component.LastErrorTracker
+
 	isConnected int32
 
 	typeStr     string
@@ -53,7 +55,8 @@ type AsyncWriter struct {
 	noCancel    bool
 	writer      AsyncSink
 
-	injectTracingMap *mapping.Executor
+	injectTracingMap  *mapping.Executor
+	injectTracingMeta bool
 
 	mgr   interop.Manager
 	log   log.Modular
@@ -105,6 +108,14 @@ func (w *AsyncWriter) SetInjectTracingMap(mapping string) error {
 	return err
 }
 
+// SetInjectTracingMetadata configures the async writer to inject tracing
+// propagation information into the metadata of outbound messages in the format
+// of the service wide tracer. This is ignored when an inject tracing mapping
+// has been set.
+func (w *AsyncWriter) SetInjectTracingMetadata() {
+	w.injectTracingMeta = true
+}
+
 // SetNoCancel configures the async writer so that write calls do not use a
 // context that gets cancelled on shutdown. This is much more efficient as it
 // reduces allocations, goroutines and defers for each write call, but also
@@ -132,9 +143,17 @@ func (w *AsyncWriter) latencyMeasuringWrite(msg *message.Batch) (latencyNs int64
 }
 
 func (w *AsyncWriter) injectSpans(msg *message.Batch, spans []*tracing.Span) *message.Batch {
-	if w.injectTracingMap == nil || msg.Len() > len(spans) {
+	if msg.Len() > len(spans) {
 		return msg
 	}
+	if w.injectTracingMap == nil {
+		if !w.injectTracingMeta {
+			return msg
+		}
+		newMsg := msg.Copy()
+		tracing.InjectSpansMetadata(spans, newMsg)
+		return newMsg
+	}
 
 	parts := make([]*message.Part, msg.Len())
 
@@ -175,6 +194,7 @@ func (w *AsyncWriter) loop() {
 		mConn       = w.stats.GetCounter("output_connection_up")
 		mFailedConn = w.stats.GetCounter("output_connection_failed")
 		mLostConn   = w.stats.GetCounter("output_connection_lost")
+		mPending    = w.stats.GetGauge("output_pending")
 	)
 
 	defer func() {
@@ -202,6 +222,7 @@ func (w *AsyncWriter) loop() {
 					return false
 				}
 				w.log.Errorf("Failed to connect to %v: %v\n", w.typeStr, err)
+				w.SetLastError(err)
 				mFailedConn.Incr(1)
 				select {
 				case <-time.After(connBackoff.NextBackOff()):
@@ -272,6 +293,7 @@ func (w *AsyncWriter) loop() {
 				return
 			}
 
+			mPending.Incr(1)
 			w.log.Tracef("Attempting to write %v messages to '%v'.\n", ts.Payload.Len(), w.typeStr)
 			spans := tracing.CreateChildSpans("output_"+w.typeStr, ts.Payload)
 			ts.Payload = w.injectSpans(ts.Payload, spans)
@@ -287,6 +309,7 @@ func (w *AsyncWriter) loop() {
 
 			// Close immediately if our writer is closed.
 			if err == component.ErrTypeClosed {
+				mPending.Decr(1)
 				return
 			}
 
@@ -295,13 +318,14 @@ func (w *AsyncWriter) loop() {
 					// TODO: Maybe reintroduce a sleep here if we encounter a
 					// busy retry loop.
 					w.log.Errorf("Failed to send message to %v: %v\n", w.typeStr, err)
+					w.SetLastError(err)
 				} else {
 					w.log.Debugf("Rejecting message: %v\n", err)
 				}
 			} else {
 				mBatchSent.Incr(1)
 				mSent.Incr(int64(batch.MessageCollapsedCount(ts.Payload)))
-				mLatency.Timing(latency)
+				metrics.TimingWithContext(message.GetContext(ts.Payload.Get(0)), mLatency, latency)
 				w.log.Tracef("Successfully wrote %v messages to '%v'.\n", ts.Payload.Len(), w.typeStr)
 			}
 
@@ -310,6 +334,7 @@ func (w *AsyncWriter) loop() {
 			}
 
 			_ = ts.Ack(closeLeisureCtx, err)
+			mPending.Decr(1)
 		}
 	}
 
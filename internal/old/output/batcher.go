@@ -169,6 +169,11 @@ func (m *Batcher) Connected() bool {
 	return m.child.Connected()
 }
 
+// LastError returns the most recent error encountered by the child output.
+func (m *Batcher) LastError() (time.Time, error) {
+	return component.LastErrorOf(m.child)
+}
+
 // Consume assigns a messages channel for the output to read.
 func (m *Batcher) Consume(msgs <-chan message.Transaction) error {
 	if m.messagesIn != nil {
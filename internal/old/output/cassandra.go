@@ -10,9 +10,12 @@ import (
 	"strconv"
 	"sync"
 	"time"
+	"unicode"
 
 	"github.com/gocql/gocql"
+	"gopkg.in/yaml.v3"
 
+	"github.com/benthosdev/benthos/v4/internal/batch"
 	"github.com/benthosdev/benthos/v4/internal/batch/policy"
 	"github.com/benthosdev/benthos/v4/internal/bloblang/field"
 	"github.com/benthosdev/benthos/v4/internal/bloblang/mapping"
@@ -51,7 +54,9 @@ func init() {
 		Summary: `
 Runs a query against a Cassandra database for each message in order to insert data.`,
 		Description: `
-Query arguments can be set using [interpolation functions](/docs/configuration/interpolation#bloblang-queries) in the ` + "`args`" + ` field or by creating a bloblang array for the fields using the ` + "`args_mapping`" + ` field.
+Query arguments can be set using [interpolation functions](/docs/configuration/interpolation#bloblang-queries) in the ` + "`args`" + ` field or by creating a bloblang array for the fields using the ` + "`args_mapping`" + ` field. When the query uses named bind markers such as ` + "`:id`" + ` the ` + "`args_mapping`" + ` can instead result in an object where each key is the name of a marker.
+
+Queries are executed as prepared statements. When ` + "`token_aware`" + ` is enabled queries are routed directly to the replicas that own their partition, and batches of messages are split into one unlogged batch per partition key, which avoids coordinators having to fan writes out across the cluster.
 
 When populating timestamp columns the value must either be a string in ISO 8601 format (2006-01-02T15:04:05Z07:00), or an integer representing unix time in seconds.`,
 		Examples: []docs.AnnotatedExample{
@@ -72,6 +77,23 @@ output:
       ]
     batching:
       count: 500
+`,
+			},
+			{
+				Title:   "Named Arguments",
+				Summary: "Arguments can also be provided by name, which allows the `args_mapping` to be decoupled from the order of the bind markers within the query:",
+				Config: `
+output:
+  cassandra:
+    addresses:
+      - localhost:9042
+    query: 'INSERT INTO foo.bar (id, content, created_at) VALUES (:id, :content, :created_at)'
+    args_mapping: |
+      root.id = this.id
+      root.content = this.content
+      root.created_at = this.timestamp
+    batching:
+      count: 500
 `,
 			},
 			{
@@ -113,20 +135,34 @@ output:
 			docs.FieldString("query", "A query to execute for each message."),
 			docs.FieldBloblang(
 				"args_mapping",
-				"A [Bloblang mapping](/docs/guides/bloblang/about) that can be used to provide arguments to Cassandra queries. The result of the query must be an array containing a matching number of elements to the query arguments.").AtVersion("3.55.0"),
+				"A [Bloblang mapping](/docs/guides/bloblang/about) that can be used to provide arguments to Cassandra queries. The result of the query must be an array containing a matching number of elements to the query arguments, or an object of values keyed by name when the query uses named bind markers.").AtVersion("3.55.0"),
 			docs.FieldString(
 				"consistency",
 				"The consistency level to use.",
 			).HasOptions(
 				"ANY", "ONE", "TWO", "THREE", "QUORUM", "ALL", "LOCAL_QUORUM", "EACH_QUORUM", "LOCAL_ONE",
 			).Advanced(),
+			docs.FieldString(
+				"serial_consistency",
+				"The consistency level to use for the serial phase of conditional updates. When empty the server default is used.",
+			).HasOptions(
+				"", "SERIAL", "LOCAL_SERIAL",
+			).AtVersion("4.1.0").Advanced(),
+			docs.FieldBool(
+				"token_aware",
+				"Whether to route queries to the replicas that own their partition and to split batches into one unlogged batch per partition key.",
+			).AtVersion("4.1.0").Advanced(),
 			docs.FieldInt("max_retries", "The maximum number of retries before giving up on a request.").Advanced(),
 			docs.FieldObject("backoff", "Control time intervals between retry attempts.").WithChildren(
 				docs.FieldString("initial_interval", "The initial period to wait between retry attempts."),
 				docs.FieldString("max_interval", "The maximum period to wait between retry attempts."),
-				docs.FieldString("max_elapsed_time", "").Deprecated(),
+				docs.FieldString("max_elapsed_time", "").Deprecated().MigrateWith(func(value, parent *yaml.Node) error {
+					// The field is ignored and can therefore simply be removed.
+					return nil
+				}),
 			).Advanced(),
 			docs.FieldString("timeout", "The client connection timeout.").AtVersion("3.63.0"),
+			docs.FieldString("connect_timeout", "The timeout for establishing new connections. When empty the value of `timeout` is used.").AtVersion("4.1.0").Advanced(),
 		).WithChildren(
 			docs.FieldInt("max_in_flight", "The maximum number of messages to have in flight at a given time. Increase this to improve throughput."),
 			policy.FieldSpec(),
@@ -153,7 +189,10 @@ type CassandraConfig struct {
 	Query                    string                `json:"query" yaml:"query"`
 	ArgsMapping              string                `json:"args_mapping" yaml:"args_mapping"`
 	Consistency              string                `json:"consistency" yaml:"consistency"`
+	SerialConsistency        string                `json:"serial_consistency" yaml:"serial_consistency"`
+	TokenAware               bool                  `json:"token_aware" yaml:"token_aware"`
 	Timeout                  string                `json:"timeout" yaml:"timeout"`
+	ConnectTimeout           string                `json:"connect_timeout" yaml:"connect_timeout"`
 	// TODO: V4 Remove this and replace with explicit values.
 	retries.Config `json:",inline" yaml:",inline"`
 	MaxInFlight    int           `json:"max_in_flight" yaml:"max_in_flight"`
@@ -180,7 +219,10 @@ func NewCassandraConfig() CassandraConfig {
 		Query:                    "",
 		ArgsMapping:              "",
 		Consistency:              gocql.Quorum.String(),
+		SerialConsistency:        "",
+		TokenAware:               true,
 		Timeout:                  "600ms",
+		ConnectTimeout:           "",
 		Config:                   rConf,
 		MaxInFlight:              64,
 		Batching:                 policy.NewConfig(),
@@ -201,6 +243,7 @@ type cassandraWriter struct {
 
 	args        []*field.Expression
 	argsMapping *mapping.Executor
+	argNames    []string
 }
 
 func newCassandraWriter(conf CassandraConfig, mgr interop.Manager, log log.Modular, stats metrics.Type) (*cassandraWriter, error) {
@@ -236,9 +279,34 @@ func (c *cassandraWriter) parseArgs(mgr interop.Manager) error {
 			return fmt.Errorf("parsing args_mapping: %w", err)
 		}
 	}
+	c.argNames = cassandraBindMarkerNames(c.conf.Query)
 	return nil
 }
 
+// Returns the names of the named bind markers (e.g. :foo) of a query in the
+// order that they appear, ignoring anything within string literals. An empty
+// slice is returned when the query uses positional markers.
+func cassandraBindMarkerNames(query string) []string {
+	var names []string
+	inString := false
+	for i := 0; i < len(query); i++ {
+		switch c := query[i]; {
+		case c == '\'':
+			inString = !inString
+		case c == ':' && !inString:
+			j := i + 1
+			for j < len(query) && (query[j] == '_' || unicode.IsLetter(rune(query[j])) || unicode.IsDigit(rune(query[j]))) {
+				j++
+			}
+			if j > i+1 {
+				names = append(names, query[i+1:j])
+				i = j - 1
+			}
+		}
+	}
+	return names
+}
+
 // ConnectWithContext establishes a connection to Cassandra.
 func (c *cassandraWriter) ConnectWithContext(ctx context.Context) error {
 	c.connLock.Lock()
@@ -256,6 +324,9 @@ func (c *cassandraWriter) ConnectWithContext(ctx context.Context) error {
 		}
 		conn.DisableInitialHostLookup = c.conf.TLS.InsecureSkipVerify
 	}
+	if c.conf.TokenAware {
+		conn.PoolConfig.HostSelectionPolicy = gocql.TokenAwareHostPolicy(gocql.RoundRobinHostPolicy())
+	}
 	if c.conf.PasswordAuthenticator.Enabled {
 		conn.Authenticator = gocql.PasswordAuthenticator{
 			Username: c.conf.PasswordAuthenticator.Username,
@@ -266,6 +337,11 @@ func (c *cassandraWriter) ConnectWithContext(ctx context.Context) error {
 	if conn.Consistency, err = gocql.ParseConsistencyWrapper(c.conf.Consistency); err != nil {
 		return fmt.Errorf("parsing consistency: %w", err)
 	}
+	if c.conf.SerialConsistency != "" {
+		if err = conn.SerialConsistency.UnmarshalText([]byte(c.conf.SerialConsistency)); err != nil {
+			return fmt.Errorf("parsing serial consistency: %w", err)
+		}
+	}
 
 	conn.RetryPolicy = &decorator{
 		NumRetries: int(c.conf.Config.MaxRetries),
@@ -278,6 +354,12 @@ func (c *cassandraWriter) ConnectWithContext(ctx context.Context) error {
 			return fmt.Errorf("failed to parse timeout string: %v", err)
 		}
 	}
+	if tout := c.conf.ConnectTimeout; len(tout) > 0 {
+		var err error
+		if conn.ConnectTimeout, err = time.ParseDuration(tout); err != nil {
+			return fmt.Errorf("failed to parse connect timeout string: %v", err)
+		}
+	}
 	session, err := conn.CreateSession()
 	if err != nil {
 		return fmt.Errorf("creating Cassandra session: %w", err)
@@ -317,22 +399,82 @@ func (c *cassandraWriter) writeRow(session *gocql.Session, msg *message.Batch) e
 }
 
 func (c *cassandraWriter) writeBatch(session *gocql.Session, msg *message.Batch) error {
-	batch := session.NewBatch(gocql.UnloggedBatch)
+	if !c.conf.TokenAware {
+		batch := session.NewBatch(gocql.UnloggedBatch)
+		if err := msg.Iter(func(i int, p *message.Part) error {
+			values, err := c.mapArgs(msg, i)
+			if err != nil {
+				return fmt.Errorf("parsing args for part: %d: %w", i, err)
+			}
+			batch.Query(c.conf.Query, values...)
+			return nil
+		}); err != nil {
+			return err
+		}
+		return session.ExecuteBatch(batch)
+	}
 
+	// Group the messages into one unlogged batch per routing key, messages for
+	// which a routing key cannot be determined are grouped together.
+	var keys []string
+	batches := map[string]*gocql.Batch{}
+	indexes := map[string][]int{}
 	if err := msg.Iter(func(i int, p *message.Part) error {
 		values, err := c.mapArgs(msg, i)
 		if err != nil {
 			return fmt.Errorf("parsing args for part: %d: %w", i, err)
 		}
-		batch.Query(c.conf.Query, values...)
+
+		q := session.Query(c.conf.Query, values...)
+		routingKey, err := q.GetRoutingKey()
+		q.Release()
+		if err != nil {
+			c.log.Debugf("Failed to determine routing key for part %v: %v\n", i, err)
+		}
+
+		key := string(routingKey)
+		b, exists := batches[key]
+		if !exists {
+			b = session.NewBatch(gocql.UnloggedBatch)
+			batches[key] = b
+			keys = append(keys, key)
+		}
+		b.Query(c.conf.Query, values...)
+		indexes[key] = append(indexes[key], i)
 		return nil
 	}); err != nil {
 		return err
 	}
 
-	err := session.ExecuteBatch(batch)
-	if err != nil {
-		return err
+	if len(keys) == 1 {
+		return session.ExecuteBatch(batches[keys[0]])
+	}
+
+	errs := make([]error, len(keys))
+	var wg sync.WaitGroup
+	wg.Add(len(keys))
+	for i, k := range keys {
+		go func(i int, b *gocql.Batch) {
+			defer wg.Done()
+			errs[i] = session.ExecuteBatch(b)
+		}(i, batches[k])
+	}
+	wg.Wait()
+
+	var batchErr *batch.Error
+	for i, err := range errs {
+		if err == nil {
+			continue
+		}
+		if batchErr == nil {
+			batchErr = batch.NewError(msg, err)
+		}
+		for _, index := range indexes[keys[i]] {
+			batchErr.Failed(index, err)
+		}
+	}
+	if batchErr != nil {
+		return batchErr
 	}
 	return nil
 }
@@ -350,6 +492,18 @@ func (c *cassandraWriter) mapArgs(msg *message.Batch, index int) ([]interface{},
 			return nil, fmt.Errorf("parsing bloblang mapping result as json: %w", err)
 		}
 
+		if obj, ok := jraw.(map[string]interface{}); ok && len(c.argNames) > 0 {
+			values := make([]interface{}, 0, len(c.argNames))
+			for _, name := range c.argNames {
+				v, exists := obj[name]
+				if !exists {
+					return nil, fmt.Errorf("bloblang mapping result is missing named argument '%v'", name)
+				}
+				values = append(values, genericValue{v: v})
+			}
+			return values, nil
+		}
+
 		j, ok := jraw.([]interface{})
 		if !ok {
 			return nil, fmt.Errorf("expected bloblang mapping result to be []interface{} but was %T", jraw)
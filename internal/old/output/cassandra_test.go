@@ -0,0 +1,51 @@
+package output
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+	"github.com/benthosdev/benthos/v4/internal/log"
+	"github.com/benthosdev/benthos/v4/internal/manager/mock"
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+func TestCassandraBindMarkerNames(t *testing.T) {
+	tests := map[string][]string{
+		"INSERT INTO foo.bar (id, content) VALUES (?, ?)":                       nil,
+		"INSERT INTO foo.bar (id, content) VALUES (:id, :content)":              {"id", "content"},
+		"UPDATE foo.bar SET content = :content_2 WHERE id = :id":                {"content_2", "id"},
+		"UPDATE foo.bar SET content = 'a:b' WHERE id = :id":                     {"id"},
+		"INSERT INTO foo.bar (id, created_at) VALUES (:id, '2021-01-01 10:00')": {"id"},
+	}
+	for query, exp := range tests {
+		assert.Equal(t, exp, cassandraBindMarkerNames(query), query)
+	}
+}
+
+func TestCassandraNamedArgs(t *testing.T) {
+	conf := NewCassandraConfig()
+	conf.Query = "INSERT INTO foo.bar (id, content) VALUES (:id, :content)"
+	conf.ArgsMapping = `root = this`
+
+	c, err := newCassandraWriter(conf, mock.NewManager(), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	msg := message.QuickBatch([][]byte{
+		[]byte(`{"content":"hello world","id":"foo"}`),
+		[]byte(`{"content":"no id"}`),
+	})
+
+	values, err := c.mapArgs(msg, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{
+		genericValue{v: "foo"},
+		genericValue{v: "hello world"},
+	}, values)
+
+	_, err = c.mapArgs(msg, 1)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "missing named argument 'id'")
+}
@@ -6,6 +6,26 @@ import (
 	"github.com/benthosdev/benthos/v4/internal/metadata"
 )
 
+// AmazonS3MultipartConfig contains configuration fields for assembling objects
+// from many messages with multipart uploads.
+type AmazonS3MultipartConfig struct {
+	Enabled     bool   `json:"enabled" yaml:"enabled"`
+	PartSize    string `json:"part_size" yaml:"part_size"`
+	Check       string `json:"check" yaml:"check"`
+	IdleTimeout string `json:"idle_timeout" yaml:"idle_timeout"`
+}
+
+// NewAmazonS3MultipartConfig creates a new AmazonS3MultipartConfig with default
+// values.
+func NewAmazonS3MultipartConfig() AmazonS3MultipartConfig {
+	return AmazonS3MultipartConfig{
+		Enabled:     false,
+		PartSize:    "5MiB",
+		Check:       "",
+		IdleTimeout: "1m",
+	}
+}
+
 // AmazonS3Config contains configuration fields for the AmazonS3 output type.
 type AmazonS3Config struct {
 	sess.Config             `json:",inline" yaml:",inline"`
@@ -24,6 +44,7 @@ type AmazonS3Config struct {
 	Timeout                 string                       `json:"timeout" yaml:"timeout"`
 	KMSKeyID                string                       `json:"kms_key_id" yaml:"kms_key_id"`
 	ServerSideEncryption    string                       `json:"server_side_encryption" yaml:"server_side_encryption"`
+	Multipart               AmazonS3MultipartConfig      `json:"multipart" yaml:"multipart"`
 	MaxInFlight             int                          `json:"max_in_flight" yaml:"max_in_flight"`
 	Batching                policy.Config                `json:"batching" yaml:"batching"`
 }
@@ -47,6 +68,7 @@ func NewAmazonS3Config() AmazonS3Config {
 		Timeout:                 "5s",
 		KMSKeyID:                "",
 		ServerSideEncryption:    "",
+		Multipart:               NewAmazonS3MultipartConfig(),
 		MaxInFlight:             64,
 		Batching:                policy.NewConfig(),
 	}
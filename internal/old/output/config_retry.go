@@ -8,8 +8,9 @@ import (
 
 // RetryConfig contains configuration values for the Retry output type.
 type RetryConfig struct {
-	Output         *Config `json:"output" yaml:"output"`
-	retries.Config `json:",inline" yaml:",inline"`
+	Output          *Config `json:"output" yaml:"output"`
+	RetryFailedOnly bool    `json:"retry_failed_only" yaml:"retry_failed_only"`
+	retries.Config  `json:",inline" yaml:",inline"`
 }
 
 // NewRetryConfig creates a new RetryConfig with default values.
@@ -20,21 +21,24 @@ func NewRetryConfig() RetryConfig {
 	rConf.Backoff.MaxInterval = "1s"
 	rConf.Backoff.MaxElapsedTime = "0s"
 	return RetryConfig{
-		Output: nil,
-		Config: retries.NewConfig(),
+		Output:          nil,
+		RetryFailedOnly: false,
+		Config:          retries.NewConfig(),
 	}
 }
 
 type dummyRetryConfig struct {
-	Output         interface{} `json:"output" yaml:"output"`
-	retries.Config `json:",inline" yaml:",inline"`
+	Output          interface{} `json:"output" yaml:"output"`
+	RetryFailedOnly bool        `json:"retry_failed_only" yaml:"retry_failed_only"`
+	retries.Config  `json:",inline" yaml:",inline"`
 }
 
 // MarshalJSON prints an empty object instead of nil.
 func (r RetryConfig) MarshalJSON() ([]byte, error) {
 	dummy := dummyRetryConfig{
-		Output: r.Output,
-		Config: r.Config,
+		Output:          r.Output,
+		RetryFailedOnly: r.RetryFailedOnly,
+		Config:          r.Config,
 	}
 	if r.Output == nil {
 		dummy.Output = struct{}{}
@@ -45,8 +49,9 @@ func (r RetryConfig) MarshalJSON() ([]byte, error) {
 // MarshalYAML prints an empty object instead of nil.
 func (r RetryConfig) MarshalYAML() (interface{}, error) {
 	dummy := dummyRetryConfig{
-		Output: r.Output,
-		Config: r.Config,
+		Output:          r.Output,
+		RetryFailedOnly: r.RetryFailedOnly,
+		Config:          r.Config,
 	}
 	if r.Output == nil {
 		dummy.Output = struct{}{}
@@ -1,5 +1,7 @@
 package output
 
+import "encoding/json"
+
 // SwitchConfig contains configuration fields for the switchOutput output type.
 type SwitchConfig struct {
 	RetryUntilSuccess bool               `json:"retry_until_success" yaml:"retry_until_success"`
@@ -18,16 +20,46 @@ func NewSwitchConfig() SwitchConfig {
 
 // SwitchConfigCase contains configuration fields per output of a switch type.
 type SwitchConfigCase struct {
-	Check    string `json:"check" yaml:"check"`
-	Continue bool   `json:"continue" yaml:"continue"`
-	Output   Config `json:"output" yaml:"output"`
+	Check    string  `json:"check" yaml:"check"`
+	Weight   float64 `json:"weight" yaml:"weight"`
+	Continue bool    `json:"continue" yaml:"continue"`
+	Output   Config  `json:"output" yaml:"output"`
 }
 
 // NewSwitchConfigCase creates a new switch output config with default values.
 func NewSwitchConfigCase() SwitchConfigCase {
 	return SwitchConfigCase{
 		Check:    "",
+		Weight:   100,
 		Continue: false,
 		Output:   NewConfig(),
 	}
 }
+
+// UnmarshalJSON ensures that when parsing configs that are in a map or slice
+// the default values are still applied.
+func (s *SwitchConfigCase) UnmarshalJSON(bytes []byte) error {
+	type confAlias SwitchConfigCase
+	aliased := confAlias(NewSwitchConfigCase())
+
+	if err := json.Unmarshal(bytes, &aliased); err != nil {
+		return err
+	}
+
+	*s = SwitchConfigCase(aliased)
+	return nil
+}
+
+// UnmarshalYAML ensures that when parsing configs that are in a map or slice
+// the default values are still applied.
+func (s *SwitchConfigCase) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type confAlias SwitchConfigCase
+	aliased := confAlias(NewSwitchConfigCase())
+
+	if err := unmarshal(&aliased); err != nil {
+		return err
+	}
+
+	*s = SwitchConfigCase(aliased)
+	return nil
+}
@@ -27,6 +27,24 @@ Both the ` + "`id` and `index`" + ` fields can be dynamically set using function
 interpolations described [here](/docs/configuration/interpolation#bloblang-queries). When
 sending batched messages these interpolations are performed per message part.
 
+The ` + "`action`" + ` field can also be interpolated, which allows each message
+to be indexed, created, updated or deleted individually. When some messages of
+a batch are rejected only those messages are reported as failed.
+
+### Data Streams
+
+In order to write to a [data stream](https://www.elastic.co/guide/en/elasticsearch/reference/current/data-streams.html)
+set the ` + "`index`" + ` to the name of the stream and the ` + "`action`" + ` to
+` + "`create`" + `, which is the only action that data streams accept. Documents
+must contain a ` + "`@timestamp`" + ` field, and the ` + "`id`" + ` can be set to an
+empty string in order for Elasticsearch to generate it.
+
+### OpenSearch
+
+When writing to an [OpenSearch](https://opensearch.org/) cluster set the field
+` + "`opensearch`" + ` to ` + "`true`" + `, which disables sniffing and
+prevents document types from being sent.
+
 ### AWS
 
 It's possible to enable AWS connectivity with this output using the ` + "`aws`" + `
@@ -37,7 +55,7 @@ false for connections to succeed.`,
 		Config: docs.FieldComponent().WithChildren(
 			docs.FieldString("urls", "A list of URLs to connect to. If an item of the list contains commas it will be expanded into multiple URLs.", []string{"http://localhost:9200"}).Array(),
 			docs.FieldString("index", "The index to place messages.").IsInterpolated(),
-			docs.FieldString("action", "The action to take on the document.").IsInterpolated().HasOptions("index", "update", "delete").Advanced(),
+			docs.FieldString("action", "The action to take on the document.").IsInterpolated().HasOptions("index", "create", "update", "delete").Advanced(),
 			docs.FieldString("pipeline", "An optional pipeline id to preprocess incoming documents.").IsInterpolated().Advanced(),
 			docs.FieldString("id", "The ID for indexed messages. Interpolation should be used in order to create a unique ID for each message.").IsInterpolated(),
 			docs.FieldString("type", "The document type.").Deprecated(),
@@ -49,6 +67,8 @@ false for connections to succeed.`,
 			docs.FieldInt("max_in_flight", "The maximum number of messages to have in flight at a given time. Increase this to improve throughput."),
 		).WithChildren(retries.FieldSpecs()...).WithChildren(
 			auth.BasicAuthFieldSpec(),
+			docs.FieldString("api_key", "An optional API key to authenticate with, which must be the base64 encoding of the key ID and key joined by a colon. Cannot be used together with `basic_auth`.").AtVersion("4.1.0").Advanced(),
+			docs.FieldBool("opensearch", "Enables compatibility with OpenSearch clusters.").AtVersion("4.1.0").Advanced(),
 			policy.FieldSpec(),
 			docs.FieldObject("aws", "Enables and customises connectivity to Amazon Elastic Service.").WithChildren(
 				docs.FieldSpecs{
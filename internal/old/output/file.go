@@ -1,13 +1,18 @@
 package output
 
 import (
+	"compress/gzip"
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"sync"
 	"time"
 
+	"github.com/dustin/go-humanize"
+	"github.com/klauspost/compress/zstd"
+
 	"github.com/benthosdev/benthos/v4/internal/bloblang/field"
 	"github.com/benthosdev/benthos/v4/internal/codec"
 	"github.com/benthosdev/benthos/v4/internal/component"
@@ -26,7 +31,18 @@ func init() {
 		constructor: fromSimpleConstructor(NewFile),
 		Summary: `
 Writes messages to files on disk based on a chosen codec.`,
-		Description: `Messages can be written to different files by using [interpolation functions](/docs/configuration/interpolation#bloblang-queries) in the path field. However, only one file is ever open at a given time, and therefore when the path changes the previously open file is closed.`,
+		Description: `
+Messages can be written to different files by using [interpolation functions](/docs/configuration/interpolation#bloblang-queries) in the path field. However, only one file is ever open at a given time, and therefore when the path changes the previously open file is closed. This can be used in order to partition files by time, e.g. with the path ` + "`/tmp/${! now().format_timestamp(\"2006-01-02T15\") }.log`" + ` a new file is written each hour.
+
+### Rotation
+
+Files can be rotated once they reach a size with the field ` + "`rotation.max_size`" + `, or once they have been open for a period with the field ` + "`rotation.max_age`" + `. Rotating a file completes it, and subsequent messages are written to a new file.
+
+### Completing Files
+
+A file is completed when it's rotated, when the path resolved for a message differs from the currently open file, or when Benthos shuts down. When the field ` + "`completed_path`" + ` is set completed files are renamed to the path it resolves to, which is resolved at the time the file is completed using the last message written to it, and therefore functions such as ` + "`now()`" + ` resolve to the time of completion. Since renames are atomic this prevents consumers of the completed path from observing partially written files.
+
+When ` + "`compression`" + ` is set completed files are also compressed, resulting in a file with the extension ` + "`.gz`" + ` or ` + "`.zst`" + ` added to the completed path, and the uncompressed file is removed. Compression requires ` + "`completed_path`" + ` to be set, and an existing compressed file is never replaced, instead an error is reported and the file is left uncompressed at the completed path.`,
 		Config: docs.FieldComponent().WithChildren(
 			docs.FieldString(
 				"path", "The file to write to, if the file does not yet exist it will be created.",
@@ -34,7 +50,18 @@ Writes messages to files on disk based on a chosen codec.`,
 				"/tmp/${! timestamp_unix() }.txt",
 				`/tmp/${! json("document.id") }.json`,
 			).IsInterpolated().AtVersion("3.33.0"),
-			codec.WriterDocs.AtVersion("3.33.0"),
+			codec.FileWriterDocs.AtVersion("3.33.0"),
+			docs.FieldString(
+				"completed_path", "An optional path that files are renamed to once they are completed. This path should resolve to a unique path for each file, e.g. by including the time of completion, as an existing file at the path is replaced.",
+				`/tmp/completed/${! now().format_timestamp("2006-01-02T15-04-05.000") }.txt`,
+			).IsInterpolated().AtVersion("4.1.0"),
+			docs.FieldObject("rotation", "Rotate files once they reach a size or age, which requires `completed_path` to be set in order to avoid overwriting rotated files.").WithChildren(
+				docs.FieldString("max_size", "The size at which files are rotated, such as `100MB` or `1GiB`. Files are only rotated between messages and can therefore exceed this size. When empty files are not rotated by size.", "100MB", "1GiB"),
+				docs.FieldString("max_age", "The period after which open files are rotated, even when no further messages are written. When empty files are not rotated by age.", "1h", "10m"),
+			).AtVersion("4.1.0").Advanced(),
+			docs.FieldString("compression", "An optional algorithm used to compress completed files, which requires `completed_path` to be set.").HasOptions(
+				"none", "gzip", "zstd",
+			).AtVersion("4.1.0").Advanced(),
 		),
 		Categories: []string{
 			"Local",
@@ -44,17 +71,38 @@ Writes messages to files on disk based on a chosen codec.`,
 
 //------------------------------------------------------------------------------
 
+// FileRotationConfig contains configuration fields for rotating the files
+// written by the file output.
+type FileRotationConfig struct {
+	MaxSize string `json:"max_size" yaml:"max_size"`
+	MaxAge  string `json:"max_age" yaml:"max_age"`
+}
+
+// NewFileRotationConfig creates a new FileRotationConfig with default values.
+func NewFileRotationConfig() FileRotationConfig {
+	return FileRotationConfig{
+		MaxSize: "",
+		MaxAge:  "",
+	}
+}
+
 // FileConfig contains configuration fields for the file based output type.
 type FileConfig struct {
-	Path  string `json:"path" yaml:"path"`
-	Codec string `json:"codec" yaml:"codec"`
+	Path          string             `json:"path" yaml:"path"`
+	Codec         string             `json:"codec" yaml:"codec"`
+	CompletedPath string             `json:"completed_path" yaml:"completed_path"`
+	Rotation      FileRotationConfig `json:"rotation" yaml:"rotation"`
+	Compression   string             `json:"compression" yaml:"compression"`
 }
 
 // NewFileConfig creates a new FileConfig with default values.
 func NewFileConfig() FileConfig {
 	return FileConfig{
-		Path:  "",
-		Codec: "lines",
+		Path:          "",
+		Codec:         "lines",
+		CompletedPath: "",
+		Rotation:      NewFileRotationConfig(),
+		Compression:   "none",
 	}
 }
 
@@ -62,7 +110,7 @@ func NewFileConfig() FileConfig {
 
 // NewFile creates a new File output type.
 func NewFile(conf Config, mgr interop.Manager, log log.Modular, stats metrics.Type) (output.Streamed, error) {
-	f, err := newFileWriter(conf.File.Path, conf.File.Codec, mgr, log, stats)
+	f, err := newFileWriter(conf.File, mgr, log, stats)
 	if err != nil {
 		return nil, err
 	}
@@ -82,34 +130,202 @@ type fileWriter struct {
 	log   log.Modular
 	stats metrics.Type
 
-	path      *field.Expression
-	codec     codec.WriterConstructor
-	codecConf codec.WriterConfig
+	path          *field.Expression
+	completedPath *field.Expression
+	codec         codec.WriterConstructor
+	codecConf     codec.WriterConfig
+	maxSize       uint64
+	maxAge        time.Duration
+	compression   string
 
 	handleMut  sync.Mutex
 	handlePath string
 	handle     codec.Writer
+	handleFile *countingFile
+	handleLast *message.Batch
+	ageTimer   *time.Timer
 
 	shutSig *shutdown.Signaller
 }
 
-func newFileWriter(pathStr, codecStr string, mgr interop.Manager, log log.Modular, stats metrics.Type) (*fileWriter, error) {
-	codec, codecConf, err := codec.GetWriter(codecStr)
+func newFileWriter(conf FileConfig, mgr interop.Manager, log log.Modular, stats metrics.Type) (*fileWriter, error) {
+	codec, codecConf, err := codec.GetFileWriter(conf.Codec)
 	if err != nil {
 		return nil, err
 	}
-	path, err := mgr.BloblEnvironment().NewField(pathStr)
+	path, err := mgr.BloblEnvironment().NewField(conf.Path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse path expression: %w", err)
 	}
-	return &fileWriter{
+	w := &fileWriter{
 		codec:     codec,
 		codecConf: codecConf,
 		path:      path,
 		log:       log,
 		stats:     stats,
 		shutSig:   shutdown.NewSignaller(),
-	}, nil
+	}
+	if conf.CompletedPath != "" {
+		if w.completedPath, err = mgr.BloblEnvironment().NewField(conf.CompletedPath); err != nil {
+			return nil, fmt.Errorf("failed to parse completed path expression: %w", err)
+		}
+	}
+	if conf.Rotation.MaxSize != "" {
+		if w.maxSize, err = humanize.ParseBytes(conf.Rotation.MaxSize); err != nil {
+			return nil, fmt.Errorf("failed to parse rotation max size: %w", err)
+		}
+	}
+	if conf.Rotation.MaxAge != "" {
+		if w.maxAge, err = time.ParseDuration(conf.Rotation.MaxAge); err != nil {
+			return nil, fmt.Errorf("failed to parse rotation max age: %w", err)
+		}
+	}
+	if (w.maxSize > 0 || w.maxAge > 0) && w.completedPath == nil {
+		return nil, fmt.Errorf("a completed_path must be set in order to rotate files")
+	}
+	switch conf.Compression {
+	case "", "none":
+	case "gzip", "zstd":
+		w.compression = conf.Compression
+	default:
+		return nil, fmt.Errorf("compression algorithm not recognised: %v", conf.Compression)
+	}
+	if w.compression != "" && w.completedPath == nil {
+		return nil, fmt.Errorf("a completed_path must be set in order to compress files")
+	}
+	return w, nil
+}
+
+//------------------------------------------------------------------------------
+
+// countingFile tracks the number of bytes within a file as it's written to.
+type countingFile struct {
+	*os.File
+	size uint64
+}
+
+func (c *countingFile) Write(p []byte) (int, error) {
+	n, err := c.File.Write(p)
+	c.size += uint64(n)
+	return n, err
+}
+
+// completes reports whether any action is required when a file is completed.
+func (w *fileWriter) completes() bool {
+	return w.completedPath != nil || w.compression != ""
+}
+
+// closeHandle closes the currently open file and completes it. Must be called
+// with the handleMut lock held.
+func (w *fileWriter) closeHandle(ctx context.Context) error {
+	if w.handle == nil {
+		return nil
+	}
+	if w.ageTimer != nil {
+		w.ageTimer.Stop()
+		w.ageTimer = nil
+	}
+
+	handle, path, last := w.handle, w.handlePath, w.handleLast
+	w.handle, w.handleFile, w.handleLast = nil, nil, nil
+	if err := handle.Close(ctx); err != nil {
+		return err
+	}
+	return w.completeFile(path, last)
+}
+
+// completeFile moves a file that's no longer written to its completed path and
+// compresses it.
+func (w *fileWriter) completeFile(path string, last *message.Batch) error {
+	if w.completedPath != nil {
+		completedPath := filepath.Clean(w.completedPath.String(0, last))
+		if err := os.MkdirAll(filepath.Dir(completedPath), os.FileMode(0o777)); err != nil {
+			return err
+		}
+		if err := os.Rename(path, completedPath); err != nil {
+			return fmt.Errorf("failed to move completed file: %w", err)
+		}
+		path = completedPath
+	}
+
+	switch w.compression {
+	case "gzip":
+		return compressFile(path, path+".gz", func(w io.Writer) (io.WriteCloser, error) {
+			return gzip.NewWriter(w), nil
+		})
+	case "zstd":
+		return compressFile(path, path+".zst", func(w io.Writer) (io.WriteCloser, error) {
+			return zstd.NewWriter(w)
+		})
+	}
+	return nil
+}
+
+// compressFile writes a compressed copy of a file to a temporary path and then
+// moves it to the target path, removing the original file. An existing file at
+// the target path is never replaced, in which case the original file is kept.
+func compressFile(path, target string, ctor func(io.Writer) (io.WriteCloser, error)) (err error) {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	tmpPath := target + ".tmp"
+	dst, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(0o666))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = os.Remove(tmpPath)
+		}
+	}()
+
+	cw, err := ctor(dst)
+	if err != nil {
+		_ = dst.Close()
+		return err
+	}
+	if _, err = io.Copy(cw, src); err != nil {
+		_ = cw.Close()
+		_ = dst.Close()
+		return fmt.Errorf("failed to compress completed file: %w", err)
+	}
+	if err = cw.Close(); err != nil {
+		_ = dst.Close()
+		return fmt.Errorf("failed to compress completed file: %w", err)
+	}
+	if err = dst.Close(); err != nil {
+		return err
+	}
+	// Linking fails when the target already exists, unlike a rename.
+	if err = os.Link(tmpPath, target); err != nil {
+		if os.IsExist(err) {
+			err = fmt.Errorf("compressed file %v already exists", target)
+		}
+		return err
+	}
+	if err = os.Remove(tmpPath); err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
+
+// rotateAfter completes the currently open file once it reaches the maximum
+// age, unless it has been closed by then.
+func (w *fileWriter) rotateAfter(handle codec.Writer) {
+	w.ageTimer = time.AfterFunc(w.maxAge, func() {
+		w.handleMut.Lock()
+		defer w.handleMut.Unlock()
+
+		if w.handle != handle {
+			return
+		}
+		if err := w.closeHandle(context.Background()); err != nil {
+			w.log.Errorf("Failed to rotate file: %v\n", err)
+		}
+	})
 }
 
 //------------------------------------------------------------------------------
@@ -126,12 +342,20 @@ func (w *fileWriter) WriteWithContext(ctx context.Context, msg *message.Batch) e
 		defer w.handleMut.Unlock()
 
 		if w.handle != nil && path == w.handlePath {
-			return w.handle.Write(ctx, p)
-		}
-		if w.handle != nil {
-			if err := w.handle.Close(ctx); err != nil {
+			if err := w.handle.Write(ctx, p); err != nil {
 				return err
 			}
+			if w.completes() {
+				w.handleLast = message.QuickBatch(nil)
+				w.handleLast.Append(p)
+			}
+			if w.maxSize > 0 && w.handleFile.size >= w.maxSize {
+				return w.closeHandle(ctx)
+			}
+			return nil
+		}
+		if err := w.closeHandle(ctx); err != nil {
+			return err
 		}
 
 		flag := os.O_CREATE | os.O_RDWR
@@ -151,8 +375,13 @@ func (w *fileWriter) WriteWithContext(ctx context.Context, msg *message.Batch) e
 			return err
 		}
 
+		cFile := &countingFile{File: file}
+		if info, err := file.Stat(); err == nil && w.codecConf.Append {
+			cFile.size = uint64(info.Size())
+		}
+
 		w.handlePath = path
-		handle, err := w.codec(file)
+		handle, err := w.codec(cFile)
 		if err != nil {
 			return err
 		}
@@ -162,10 +391,25 @@ func (w *fileWriter) WriteWithContext(ctx context.Context, msg *message.Batch) e
 			return err
 		}
 
-		if !w.codecConf.CloseAfter {
-			w.handle = handle
-		} else {
-			handle.Close(ctx)
+		last := message.QuickBatch(nil)
+		last.Append(p)
+
+		if w.codecConf.CloseAfter {
+			if err := handle.Close(ctx); err != nil {
+				return err
+			}
+			if w.completes() {
+				return w.completeFile(path, last)
+			}
+			return nil
+		}
+
+		w.handle, w.handleFile, w.handleLast = handle, cFile, last
+		if w.maxSize > 0 && cFile.size >= w.maxSize {
+			return w.closeHandle(ctx)
+		}
+		if w.maxAge > 0 {
+			w.rotateAfter(handle)
 		}
 		return nil
 	})
@@ -179,9 +423,8 @@ func (w *fileWriter) WriteWithContext(ctx context.Context, msg *message.Batch) e
 func (w *fileWriter) CloseAsync() {
 	go func() {
 		w.handleMut.Lock()
-		if w.handle != nil {
-			w.handle.Close(context.Background())
-			w.handle = nil
+		if err := w.closeHandle(context.Background()); err != nil {
+			w.log.Errorf("Failed to complete file: %v\n", err)
 		}
 		w.handleMut.Unlock()
 		w.shutSig.ShutdownComplete()
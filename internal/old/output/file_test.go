@@ -0,0 +1,238 @@
+package output
+
+import (
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+	"github.com/benthosdev/benthos/v4/internal/log"
+	"github.com/benthosdev/benthos/v4/internal/manager/mock"
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+func newTestFileWriter(t *testing.T, conf FileConfig) *fileWriter {
+	t.Helper()
+
+	w, err := newFileWriter(conf, mock.NewManager(), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	require.NoError(t, w.ConnectWithContext(context.Background()))
+	return w
+}
+
+func closeTestFileWriter(t *testing.T, w *fileWriter) {
+	t.Helper()
+
+	w.CloseAsync()
+	require.NoError(t, w.WaitForClose(time.Second*5))
+}
+
+func writeTestFileParts(t *testing.T, w *fileWriter, parts ...string) {
+	t.Helper()
+
+	for _, p := range parts {
+		require.NoError(t, w.WriteWithContext(context.Background(), message.QuickBatch([][]byte{[]byte(p)})))
+	}
+}
+
+func readDirFiles(t *testing.T, dir string) map[string]string {
+	t.Helper()
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+
+	files := map[string]string{}
+	for _, e := range entries {
+		b, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		require.NoError(t, err)
+		files[e.Name()] = string(b)
+	}
+	return files
+}
+
+func TestFileOutputCompletedPath(t *testing.T) {
+	dir := t.TempDir()
+
+	conf := NewFileConfig()
+	conf.Path = filepath.Join(dir, "open", `${! meta("part") }.txt`)
+	conf.CompletedPath = filepath.Join(dir, "done", `${! meta("part") }-${! content() }.txt`)
+
+	w := newTestFileWriter(t, conf)
+
+	for _, p := range []struct {
+		part, content string
+	}{
+		{"a", "foo"},
+		{"a", "bar"},
+		{"b", "baz"},
+	} {
+		msg := message.QuickBatch([][]byte{[]byte(p.content)})
+		msg.Get(0).MetaSet("part", p.part)
+		require.NoError(t, w.WriteWithContext(context.Background(), msg))
+	}
+
+	// Only the file that's no longer written to is completed.
+	assert.Equal(t, map[string]string{"a-bar.txt": "foo\nbar\n"}, readDirFiles(t, filepath.Join(dir, "done")))
+	assert.Equal(t, map[string]string{"b.txt": "baz\n"}, readDirFiles(t, filepath.Join(dir, "open")))
+
+	closeTestFileWriter(t, w)
+
+	assert.Equal(t, map[string]string{
+		"a-bar.txt": "foo\nbar\n",
+		"b-baz.txt": "baz\n",
+	}, readDirFiles(t, filepath.Join(dir, "done")))
+	assert.Empty(t, readDirFiles(t, filepath.Join(dir, "open")))
+}
+
+func TestFileOutputRotateSize(t *testing.T) {
+	dir := t.TempDir()
+
+	conf := NewFileConfig()
+	conf.Path = filepath.Join(dir, "current.txt")
+	conf.CompletedPath = filepath.Join(dir, "done", `${! content() }.txt`)
+	conf.Rotation.MaxSize = "8B"
+
+	w := newTestFileWriter(t, conf)
+	writeTestFileParts(t, w, "foo", "bar", "baz", "buz", "qux")
+	closeTestFileWriter(t, w)
+
+	assert.Equal(t, map[string]string{
+		"bar.txt": "foo\nbar\n",
+		"buz.txt": "baz\nbuz\n",
+		"qux.txt": "qux\n",
+	}, readDirFiles(t, filepath.Join(dir, "done")))
+}
+
+func TestFileOutputRotateAge(t *testing.T) {
+	dir := t.TempDir()
+
+	conf := NewFileConfig()
+	conf.Path = filepath.Join(dir, "current.txt")
+	conf.CompletedPath = filepath.Join(dir, "done", `${! content() }.txt`)
+	conf.Rotation.MaxAge = "10ms"
+
+	w := newTestFileWriter(t, conf)
+	writeTestFileParts(t, w, "foo")
+
+	assert.Eventually(t, func() bool {
+		_, err := os.Stat(filepath.Join(dir, "done", "foo.txt"))
+		return err == nil
+	}, time.Second*5, time.Millisecond*10)
+
+	writeTestFileParts(t, w, "bar")
+	closeTestFileWriter(t, w)
+
+	assert.Equal(t, map[string]string{
+		"foo.txt": "foo\n",
+		"bar.txt": "bar\n",
+	}, readDirFiles(t, filepath.Join(dir, "done")))
+}
+
+func TestFileOutputCompression(t *testing.T) {
+	for _, test := range []struct {
+		algorithm string
+		extension string
+		reader    func(r io.Reader) (io.Reader, error)
+	}{
+		{
+			algorithm: "gzip",
+			extension: ".gz",
+			reader: func(r io.Reader) (io.Reader, error) {
+				return gzip.NewReader(r)
+			},
+		},
+		{
+			algorithm: "zstd",
+			extension: ".zst",
+			reader: func(r io.Reader) (io.Reader, error) {
+				return zstd.NewReader(r)
+			},
+		},
+	} {
+		test := test
+		t.Run(test.algorithm, func(t *testing.T) {
+			dir := t.TempDir()
+
+			conf := NewFileConfig()
+			conf.Path = filepath.Join(dir, "current.txt")
+			conf.CompletedPath = filepath.Join(dir, "done", `${! content() }.txt`)
+			conf.Compression = test.algorithm
+			conf.Rotation.MaxSize = "8B"
+
+			w := newTestFileWriter(t, conf)
+			writeTestFileParts(t, w, "foo", "bar", "baz")
+			closeTestFileWriter(t, w)
+
+			files := readDirFiles(t, filepath.Join(dir, "done"))
+
+			var names []string
+			for k := range files {
+				names = append(names, k)
+			}
+			sort.Strings(names)
+			assert.Equal(t, []string{"bar.txt" + test.extension, "baz.txt" + test.extension}, names)
+
+			r, err := test.reader(strings.NewReader(files["bar.txt"+test.extension]))
+			require.NoError(t, err)
+
+			b, err := io.ReadAll(r)
+			require.NoError(t, err)
+			assert.Equal(t, "foo\nbar\n", string(b))
+		})
+	}
+}
+
+func TestFileOutputCompressionExists(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "done.txt.gz"), []byte("existing"), 0o644))
+
+	conf := NewFileConfig()
+	conf.Path = filepath.Join(dir, "current.txt")
+	conf.CompletedPath = filepath.Join(dir, "done.txt")
+	conf.Compression = "gzip"
+
+	w := newTestFileWriter(t, conf)
+	writeTestFileParts(t, w, "foo")
+	closeTestFileWriter(t, w)
+
+	assert.Equal(t, map[string]string{
+		"done.txt":    "foo\n",
+		"done.txt.gz": "existing",
+	}, readDirFiles(t, dir))
+}
+
+func TestFileOutputConfigErrors(t *testing.T) {
+	conf := NewFileConfig()
+	conf.Path = "/tmp/foo.txt"
+	conf.Rotation.MaxSize = "10MB"
+
+	_, err := newFileWriter(conf, mock.NewManager(), log.Noop(), metrics.Noop())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "completed_path must be set")
+
+	conf = NewFileConfig()
+	conf.Path = "/tmp/foo.txt"
+	conf.Compression = "lz4"
+
+	_, err = newFileWriter(conf, mock.NewManager(), log.Noop(), metrics.Noop())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "compression algorithm not recognised")
+
+	conf = NewFileConfig()
+	conf.Path = "/tmp/foo.txt"
+	conf.Compression = "gzip"
+
+	_, err = newFileWriter(conf, mock.NewManager(), log.Noop(), metrics.Noop())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "completed_path must be set in order to compress")
+}
@@ -1,9 +1,15 @@
 package output
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"sync/atomic"
 	"time"
 
+	"gopkg.in/yaml.v3"
+
 	"github.com/benthosdev/benthos/v4/internal/component"
 	"github.com/benthosdev/benthos/v4/internal/component/metrics"
 	"github.com/benthosdev/benthos/v4/internal/component/output"
@@ -25,25 +31,105 @@ you to hook up isolated streams whilst running Benthos in
 that you connect the inputs of a stream with an output of the same stream, as
 feedback loops can lead to deadlocks in your message flow.
 
-It is possible to connect multiple inputs to the same inproc ID, resulting in
-messages dispatching in a round-robin fashion to connected inputs. However, only
-one output can assume an inproc ID, and will replace existing outputs if a
-collision occurs.`,
+It is possible to connect multiple inputs to the same inproc ID, and by default
+messages are dispatched in a round-robin fashion to connected inputs. However,
+only one output can assume an inproc ID, and will replace existing outputs if a
+collision occurs.
+
+### Subscription Modes
+
+The ID can be specified either directly as a string, or as an object with the
+fields ` + "`id`, `mode` and `buffer_size`" + `:
+
+` + "```yaml" + `
+output:
+  inproc:
+    id: foo
+    mode: broadcast
+    buffer_size: 10
+` + "```" + `
+
+The field ` + "`mode`" + ` determines how messages are dispatched to connected
+inputs. When set to ` + "`shared`" + ` (the default) each message is consumed by
+only one input, and when set to ` + "`broadcast`" + ` every message is consumed
+by all connected inputs, where a message is only acknowledged once all inputs
+have acknowledged it. Whilst no inputs are connected messages are held until one
+connects.
+
+The field ` + "`buffer_size`" + ` sets the number of messages that can be
+dispatched ahead of inputs consuming them, in broadcast mode this applies to
+each connected input individually, allowing inputs to consume at different
+rates. Messages are not acknowledged until they are consumed and acknowledged by
+inputs regardless of buffering.`,
 		Categories: []string{
 			"Utility",
 		},
-		Config: docs.FieldString("", "").HasDefault(""),
+		Config: docs.FieldComponent().WithChildren(
+			docs.FieldString("id", "The ID to send messages to.").HasDefault(""),
+			docs.FieldString("mode", "How messages are dispatched to connected inputs.").HasOptions(InprocModeShared, InprocModeBroadcast).HasDefault(InprocModeShared),
+			docs.FieldInt("buffer_size", "The number of messages that can be dispatched ahead of inputs consuming them.").HasDefault(0),
+		).HasShorthand("id"),
 	}
 }
 
 //------------------------------------------------------------------------------
 
-// InprocConfig contains configuration fields for the Inproc output type.
-type InprocConfig string
+// Modes of dispatching messages to the inputs connected to an inproc ID.
+const (
+	InprocModeShared    = "shared"
+	InprocModeBroadcast = "broadcast"
+)
+
+// InprocConfig contains configuration fields for the Inproc output type, which
+// can be parsed either from a string ID or an object.
+type InprocConfig struct {
+	ID         string `json:"id" yaml:"id"`
+	Mode       string `json:"mode" yaml:"mode"`
+	BufferSize int    `json:"buffer_size" yaml:"buffer_size"`
+}
 
 // NewInprocConfig creates a new InprocConfig with default values.
 func NewInprocConfig() InprocConfig {
-	return InprocConfig("")
+	return InprocConfig{
+		ID:         "",
+		Mode:       InprocModeShared,
+		BufferSize: 0,
+	}
+}
+
+type inprocConfigAlias InprocConfig
+
+// UnmarshalYAML parses either a string ID or an object.
+func (i *InprocConfig) UnmarshalYAML(value *yaml.Node) error {
+	conf := NewInprocConfig()
+	if value.Kind == yaml.ScalarNode {
+		if err := value.Decode(&conf.ID); err != nil {
+			return err
+		}
+		*i = conf
+		return nil
+	}
+	aliased := inprocConfigAlias(conf)
+	if err := value.Decode(&aliased); err != nil {
+		return err
+	}
+	*i = InprocConfig(aliased)
+	return nil
+}
+
+// UnmarshalJSON parses either a string ID or an object.
+func (i *InprocConfig) UnmarshalJSON(data []byte) error {
+	conf := NewInprocConfig()
+	if err := json.Unmarshal(data, &conf.ID); err == nil {
+		*i = conf
+		return nil
+	}
+	aliased := inprocConfigAlias(conf)
+	if err := json.Unmarshal(data, &aliased); err != nil {
+		return err
+	}
+	*i = InprocConfig(aliased)
+	return nil
 }
 
 //------------------------------------------------------------------------------
@@ -52,29 +138,56 @@ func NewInprocConfig() InprocConfig {
 type Inproc struct {
 	running int32
 
-	pipe  string
-	mgr   interop.Manager
-	log   log.Modular
-	stats metrics.Type
+	pipe       string
+	broadcast  bool
+	bufferSize int
+	mgr        interop.Manager
+	log        log.Modular
+	stats      metrics.Type
 
 	transactionsOut chan message.Transaction
 	transactionsIn  <-chan message.Transaction
 
+	// Queues of transactions for each broadcast subscriber.
+	subQueues map[*interop.PipeSubscriber]chan message.Transaction
+
 	closedChan chan struct{}
 	closeChan  chan struct{}
 }
 
 // NewInproc creates a new Inproc output type.
 func NewInproc(conf Config, mgr interop.Manager, log log.Modular, stats metrics.Type) (output.Streamed, error) {
+	var broadcast bool
+	switch conf.Inproc.Mode {
+	case InprocModeShared:
+	case InprocModeBroadcast:
+		broadcast = true
+	default:
+		return nil, fmt.Errorf("inproc mode not recognised: %v", conf.Inproc.Mode)
+	}
+	if conf.Inproc.BufferSize < 0 {
+		return nil, errors.New("inproc buffer_size must not be negative")
+	}
+
 	i := &Inproc{
-		running:         1,
-		pipe:            string(conf.Inproc),
-		mgr:             mgr,
-		log:             log,
-		stats:           stats,
-		transactionsOut: make(chan message.Transaction),
-		closedChan:      make(chan struct{}),
-		closeChan:       make(chan struct{}),
+		running:    1,
+		pipe:       conf.Inproc.ID,
+		broadcast:  broadcast,
+		bufferSize: conf.Inproc.BufferSize,
+		mgr:        mgr,
+		log:        log,
+		stats:      stats,
+		subQueues:  map[*interop.PipeSubscriber]chan message.Transaction{},
+		closedChan: make(chan struct{}),
+		closeChan:  make(chan struct{}),
+	}
+	if broadcast {
+		// The pipe is still set in broadcast mode in order to signal to
+		// inputs that the output exists, but transactions are only sent to
+		// subscribers.
+		i.transactionsOut = make(chan message.Transaction)
+	} else {
+		i.transactionsOut = make(chan message.Transaction, i.bufferSize)
 	}
 	mgr.SetPipe(i.pipe, i.transactionsOut)
 	return i, nil
@@ -87,6 +200,9 @@ func (i *Inproc) loop() {
 	defer func() {
 		atomic.StoreInt32(&i.running, 0)
 		i.mgr.UnsetPipe(i.pipe, i.transactionsOut)
+		for _, queue := range i.subQueues {
+			close(queue)
+		}
 		close(i.transactionsOut)
 		close(i.closedChan)
 	}()
@@ -105,6 +221,13 @@ func (i *Inproc) loop() {
 			return
 		}
 
+		if i.broadcast {
+			if !i.broadcastTransaction(ts) {
+				return
+			}
+			continue
+		}
+
 		select {
 		case i.transactionsOut <- ts:
 		case <-i.closeChan:
@@ -113,6 +236,96 @@ func (i *Inproc) loop() {
 	}
 }
 
+// subscribers blocks until at least one subscriber is connected to the pipe,
+// returning false if the output is closed in the meantime.
+func (i *Inproc) subscribers() ([]*interop.PipeSubscriber, bool) {
+	for {
+		subs := i.mgr.GetPipeSubscribers(i.pipe)
+
+		// Forget the queues of subscribers that are no longer connected.
+		for sub, queue := range i.subQueues {
+			found := false
+			for _, s := range subs {
+				if s == sub {
+					found = true
+					break
+				}
+			}
+			if !found {
+				close(queue)
+				delete(i.subQueues, sub)
+			}
+		}
+
+		if len(subs) > 0 {
+			return subs, true
+		}
+		select {
+		case <-time.After(time.Millisecond * 100):
+		case <-i.closeChan:
+			return nil, false
+		}
+	}
+}
+
+// subQueue returns the queue of transactions for a subscriber, creating it and
+// a goroutine that forwards its transactions when it doesn't yet exist.
+func (i *Inproc) subQueue(sub *interop.PipeSubscriber) chan message.Transaction {
+	if queue, exists := i.subQueues[sub]; exists {
+		return queue
+	}
+	queue := make(chan message.Transaction, i.bufferSize)
+	i.subQueues[sub] = queue
+	go func() {
+		for t := range queue {
+			select {
+			case sub.Transactions <- t:
+				continue
+			case <-sub.Done:
+			case <-i.closeChan:
+			}
+			// The subscriber has gone without consuming this transaction, and
+			// therefore it must be delivered again.
+			_ = t.Ack(context.Background(), component.ErrNotConnected)
+		}
+	}()
+	return queue
+}
+
+func (i *Inproc) broadcastTransaction(ts message.Transaction) bool {
+	subs, ok := i.subscribers()
+	if !ok {
+		return false
+	}
+
+	resChans := make([]chan error, len(subs))
+	for j, sub := range subs {
+		resChans[j] = make(chan error, 1)
+		select {
+		case i.subQueue(sub) <- message.NewTransaction(ts.Payload.Copy(), resChans[j]):
+		case <-i.closeChan:
+			return false
+		}
+	}
+
+	go func() {
+		var res error
+		for _, rChan := range resChans {
+			select {
+			case err := <-rChan:
+				if err != nil && res == nil {
+					res = err
+				}
+			case <-i.closeChan:
+				_ = ts.Ack(context.Background(), component.ErrNotConnected)
+				return
+			}
+		}
+		_ = ts.Ack(context.Background(), res)
+	}()
+	return true
+}
+
 // Consume assigns a messages channel for the output to read.
 func (i *Inproc) Consume(ts <-chan message.Transaction) error {
 	if i.transactionsIn != nil {
@@ -1,16 +1,23 @@
 package output_test
 
 import (
+	"context"
+	"errors"
 	"testing"
 	"time"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
 
 	"github.com/benthosdev/benthos/v4/internal/component"
+	iinput "github.com/benthosdev/benthos/v4/internal/component/input"
 	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+	"github.com/benthosdev/benthos/v4/internal/interop"
 	"github.com/benthosdev/benthos/v4/internal/log"
 	"github.com/benthosdev/benthos/v4/internal/manager"
 	"github.com/benthosdev/benthos/v4/internal/message"
+	"github.com/benthosdev/benthos/v4/internal/old/input"
 	"github.com/benthosdev/benthos/v4/internal/old/output"
 
 	_ "github.com/benthosdev/benthos/v4/public/components/all"
@@ -29,7 +36,7 @@ func TestInproc(t *testing.T) {
 	}
 
 	conf := output.NewConfig()
-	conf.Inproc = "foo"
+	conf.Inproc.ID = "foo"
 
 	ip, err := output.NewInproc(conf, mgr, log.Noop(), metrics.Noop())
 	require.NoError(t, err)
@@ -75,3 +82,163 @@ func TestInproc(t *testing.T) {
 }
 
 //------------------------------------------------------------------------------
+
+func TestInprocConfigParse(t *testing.T) {
+	var conf output.Config
+	require.NoError(t, yaml.Unmarshal([]byte(`inproc: foo`), &conf))
+	assert.Equal(t, output.InprocConfig{ID: "foo", Mode: "shared"}, conf.Inproc)
+
+	require.NoError(t, yaml.Unmarshal([]byte(`
+inproc:
+  id: bar
+  mode: broadcast
+  buffer_size: 5
+`), &conf))
+	assert.Equal(t, output.InprocConfig{ID: "bar", Mode: "broadcast", BufferSize: 5}, conf.Inproc)
+
+	b, err := yaml.Marshal(output.InprocConfig{ID: "foo", Mode: "shared"})
+	require.NoError(t, err)
+	assert.Equal(t, "id: foo\nmode: shared\nbuffer_size: 0\n", string(b))
+}
+
+func TestInprocBufferSize(t *testing.T) {
+	mgr, err := manager.NewV2(manager.NewResourceConfig(), nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	conf := output.NewConfig()
+	conf.Type = output.TypeInproc
+	conf.Inproc.ID = "foo"
+	conf.Inproc.BufferSize = 2
+
+	ip, err := mgr.NewOutput(conf)
+	require.NoError(t, err)
+
+	tinchan := make(chan message.Transaction)
+	require.NoError(t, ip.Consume(tinchan))
+
+	// Two transactions are buffered without a consumer and a third is held by
+	// the output, after which sends block.
+	for i := 0; i < 3; i++ {
+		select {
+		case tinchan <- message.NewTransaction(message.QuickBatch(nil), nil):
+		case <-time.After(time.Second):
+			t.Fatal("timed out")
+		}
+	}
+	select {
+	case tinchan <- message.NewTransaction(message.QuickBatch(nil), nil):
+		t.Fatal("expected send to block")
+	case <-time.After(time.Millisecond * 50):
+	}
+
+	ip.CloseAsync()
+	require.NoError(t, ip.WaitForClose(time.Second))
+}
+
+func TestInprocBroadcast(t *testing.T) {
+	mgr, err := manager.NewV2(manager.NewResourceConfig(), nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	var inputs []iinput.Streamed
+	for i := 0; i < 2; i++ {
+		iConf := input.NewConfig()
+		iConf.Type = input.TypeInproc
+		iConf.Inproc = "foo"
+
+		in, err := mgr.NewInput(iConf)
+		require.NoError(t, err)
+		inputs = append(inputs, in)
+	}
+
+	conf := output.NewConfig()
+	conf.Type = output.TypeInproc
+	conf.Inproc.ID = "foo"
+	conf.Inproc.Mode = output.InprocModeBroadcast
+
+	ip, err := mgr.NewOutput(conf)
+	require.NoError(t, err)
+
+	tinchan := make(chan message.Transaction)
+	require.NoError(t, ip.Consume(tinchan))
+
+	resChan := make(chan error)
+	select {
+	case tinchan <- message.NewTransaction(message.QuickBatch([][]byte{[]byte("hello")}), resChan):
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+
+	for i, in := range inputs {
+		select {
+		case tran := <-in.TransactionChan():
+			assert.Equal(t, "hello", string(tran.Payload.Get(0).Get()))
+			var res error
+			if i == 1 {
+				res = errors.New("nope")
+			}
+			require.NoError(t, tran.Ack(context.Background(), res))
+		case <-time.After(time.Second):
+			t.Fatal("timed out")
+		}
+	}
+
+	select {
+	case err := <-resChan:
+		assert.EqualError(t, err, "nope")
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+
+	ip.CloseAsync()
+	require.NoError(t, ip.WaitForClose(time.Second))
+	for _, in := range inputs {
+		in.CloseAsync()
+		require.NoError(t, in.WaitForClose(time.Second))
+	}
+}
+
+func TestInprocBroadcastSubscriberGone(t *testing.T) {
+	mgr, err := manager.NewV2(manager.NewResourceConfig(), nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	doneChan := make(chan struct{})
+	sub := &interop.PipeSubscriber{
+		Transactions: make(chan message.Transaction),
+		Done:         doneChan,
+	}
+	mgr.SubscribePipe("foo", sub)
+
+	conf := output.NewConfig()
+	conf.Type = output.TypeInproc
+	conf.Inproc.ID = "foo"
+	conf.Inproc.Mode = output.InprocModeBroadcast
+	conf.Inproc.BufferSize = 1
+
+	ip, err := mgr.NewOutput(conf)
+	require.NoError(t, err)
+
+	tinchan := make(chan message.Transaction)
+	require.NoError(t, ip.Consume(tinchan))
+
+	resChan := make(chan error)
+	select {
+	case tinchan <- message.NewTransaction(message.QuickBatch([][]byte{[]byte("hello")}), resChan):
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+
+	// The subscriber leaves without consuming the transaction, which must
+	// therefore be rejected rather than acknowledged.
+	close(doneChan)
+	mgr.UnsubscribePipe("foo", sub)
+
+	select {
+	case err := <-resChan:
+		assert.Equal(t, component.ErrNotConnected, err)
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+
+	ip.CloseAsync()
+	require.NoError(t, ip.WaitForClose(time.Second))
+}
@@ -88,14 +88,16 @@ func NewKafka(conf Config, mgr interop.Manager, log log.Modular, stats metrics.T
 		return nil, err
 	}
 
+	aw, ok := w.(*AsyncWriter)
+	if !ok {
+		return nil, fmt.Errorf("unable to set an inject_tracing_map due to wrong type: %T", w)
+	}
 	if conf.Kafka.InjectTracingMap != "" {
-		aw, ok := w.(*AsyncWriter)
-		if !ok {
-			return nil, fmt.Errorf("unable to set an inject_tracing_map due to wrong type: %T", w)
-		}
 		if err = aw.SetInjectTracingMap(conf.Kafka.InjectTracingMap); err != nil {
 			return nil, fmt.Errorf("failed to initialize inject tracing map: %v", err)
 		}
+	} else {
+		aw.SetInjectTracingMetadata()
 	}
 
 	return NewBatcherFromConfig(conf.Kafka.Batching, w, mgr, log, stats)
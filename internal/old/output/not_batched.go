@@ -153,6 +153,11 @@ func (n *notBatchedOutput) Connected() bool {
 	return n.out.Connected()
 }
 
+// LastError returns the most recent error encountered by the child output.
+func (n *notBatchedOutput) LastError() (time.Time, error) {
+	return component.LastErrorOf(n.out)
+}
+
 func (n *notBatchedOutput) CloseAsync() {
 	n.shutSig.CloseAtLeisure()
 }
@@ -0,0 +1,122 @@
+package output_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/benthosdev/benthos/v4/internal/component"
+	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+	"github.com/benthosdev/benthos/v4/internal/log"
+	"github.com/benthosdev/benthos/v4/internal/manager"
+	"github.com/benthosdev/benthos/v4/internal/message"
+	"github.com/benthosdev/benthos/v4/internal/old/output"
+)
+
+func TestRejectConfigParse(t *testing.T) {
+	var conf output.Config
+	require.NoError(t, yaml.Unmarshal([]byte(`reject: foo`), &conf))
+	assert.Equal(t, output.RejectConfig{Message: "foo"}, conf.Reject)
+
+	require.NoError(t, yaml.Unmarshal([]byte(`
+reject:
+  mapping: 'root.code = 400'
+`), &conf))
+	assert.Equal(t, output.RejectConfig{Mapping: "root.code = 400"}, conf.Reject)
+
+	b, err := yaml.Marshal(output.RejectConfig{Message: "foo"})
+	require.NoError(t, err)
+	assert.Equal(t, "message: foo\nmapping: \"\"\n", string(b))
+}
+
+func TestRejectReasons(t *testing.T) {
+	mgr, err := manager.NewV2(manager.NewResourceConfig(), nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		conf     output.RejectConfig
+		expected component.RejectionError
+	}{
+		{
+			name:     "interpolated message",
+			conf:     output.RejectConfig{Message: `failed ${! content() }`},
+			expected: component.RejectionError{Message: "failed hello"},
+		},
+		{
+			name: "structured mapping",
+			conf: output.RejectConfig{Mapping: `
+root.code = 429
+root.message = "failed " + content()
+`},
+			expected: component.RejectionError{Code: 429, Message: "failed hello"},
+		},
+		{
+			name:     "string mapping",
+			conf:     output.RejectConfig{Mapping: `root = "failed " + content()`},
+			expected: component.RejectionError{Message: "failed hello"},
+		},
+		{
+			name:     "invalid code",
+			conf:     output.RejectConfig{Mapping: `root.code = "nope"`},
+			expected: component.RejectionError{Message: "rejection mapping produced an invalid code: expected number value, got string (\"nope\")"},
+		},
+		{
+			name:     "missing message",
+			conf:     output.RejectConfig{Mapping: `root.code = 400`},
+			expected: component.RejectionError{Code: 400, Message: `{"code":400}`},
+		},
+		{
+			name: "invalid message",
+			conf: output.RejectConfig{Mapping: `
+root.code = 400
+root.message = 10
+`},
+			expected: component.RejectionError{Code: 400, Message: "rejection mapping produced an invalid message: expected string value, got number (10)"},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			conf := output.NewConfig()
+			conf.Type = output.TypeReject
+			conf.Reject = test.conf
+
+			o, err := output.New(conf, mgr, log.Noop(), metrics.Noop())
+			require.NoError(t, err)
+
+			tChan := make(chan message.Transaction)
+			require.NoError(t, o.Consume(tChan))
+
+			resChan := make(chan error)
+			select {
+			case tChan <- message.NewTransaction(message.QuickBatch([][]byte{[]byte("hello")}), resChan):
+			case <-time.After(time.Second * 5):
+				t.Fatal("timed out")
+			}
+
+			select {
+			case res := <-resChan:
+				var rErr *component.RejectionError
+				require.True(t, errors.As(res, &rErr), "%T: %v", res, res)
+				assert.Equal(t, test.expected, *rErr)
+			case <-time.After(time.Second * 5):
+				t.Fatal("timed out")
+			}
+
+			o.CloseAsync()
+			require.NoError(t, o.WaitForClose(time.Second*5))
+		})
+	}
+
+	conf := output.NewConfig()
+	conf.Type = output.TypeReject
+	conf.Reject = output.RejectConfig{Message: "foo", Mapping: `root = "bar"`}
+	_, err = output.New(conf, mgr, log.Noop(), metrics.Noop())
+	require.Error(t, err)
+}
@@ -3,6 +3,7 @@ package output
 import (
 	"time"
 
+	"github.com/benthosdev/benthos/v4/internal/component"
 	"github.com/benthosdev/benthos/v4/internal/component/output"
 	iprocessor "github.com/benthosdev/benthos/v4/internal/component/processor"
 	"github.com/benthosdev/benthos/v4/internal/message"
@@ -61,6 +62,11 @@ func (i *WithPipeline) Connected() bool {
 	return i.out.Connected()
 }
 
+// LastError returns the most recent error encountered by the child output.
+func (i *WithPipeline) LastError() (time.Time, error) {
+	return component.LastErrorOf(i.out)
+}
+
 //------------------------------------------------------------------------------
 
 // CloseAsync triggers a closure of this object but does not block.
@@ -3,6 +3,7 @@ package writer
 import (
 	"context"
 	"crypto/tls"
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
@@ -12,6 +13,7 @@ import (
 	"github.com/olivere/elastic/v7"
 	aws "github.com/olivere/elastic/v7/aws/v4"
 
+	"github.com/benthosdev/benthos/v4/internal/batch"
 	"github.com/benthosdev/benthos/v4/internal/batch/policy"
 	"github.com/benthosdev/benthos/v4/internal/bloblang/field"
 	"github.com/benthosdev/benthos/v4/internal/component"
@@ -51,6 +53,8 @@ type ElasticsearchConfig struct {
 	Timeout         string               `json:"timeout" yaml:"timeout"`
 	TLS             btls.Config          `json:"tls" yaml:"tls"`
 	Auth            auth.BasicAuthConfig `json:"basic_auth" yaml:"basic_auth"`
+	APIKey          string               `json:"api_key" yaml:"api_key"`
+	OpenSearch      bool                 `json:"opensearch" yaml:"opensearch"`
 	AWS             OptionalAWSConfig    `json:"aws" yaml:"aws"`
 	GzipCompression bool                 `json:"gzip_compression" yaml:"gzip_compression"`
 	MaxInFlight     int                  `json:"max_in_flight" yaml:"max_in_flight"`
@@ -78,6 +82,8 @@ func NewElasticsearchConfig() ElasticsearchConfig {
 		Timeout:     "5s",
 		TLS:         btls.NewConfig(),
 		Auth:        auth.NewBasicAuthConfig(),
+		APIKey:      "",
+		OpenSearch:  false,
 		AWS: OptionalAWSConfig{
 			Enabled: false,
 			Config:  sess.NewConfig(),
@@ -124,6 +130,15 @@ func NewElasticsearchV2(conf ElasticsearchConfig, mgr interop.Manager, log log.M
 		healthcheck: conf.Healthcheck,
 	}
 
+	// OpenSearch clusters, and in particular those managed by AWS, commonly
+	// advertise node addresses that aren't reachable by clients.
+	if conf.OpenSearch {
+		e.sniff = false
+	}
+	if conf.APIKey != "" && conf.Auth.Enabled {
+		return nil, errors.New("cannot use both api_key and basic_auth")
+	}
+
 	var err error
 	if e.actionStr, err = mgr.BloblEnvironment().NewField(conf.Action); err != nil {
 		return nil, fmt.Errorf("failed to parse action expression: %v", err)
@@ -194,6 +209,11 @@ func (e *Elasticsearch) Connect() error {
 			e.conf.Auth.Username, e.conf.Auth.Password,
 		))
 	}
+	if e.conf.APIKey != "" {
+		opts = append(opts, elastic.SetHeaders(http.Header{
+			"Authorization": []string{"ApiKey " + e.conf.APIKey},
+		}))
+	}
 
 	if e.conf.TLS.Enabled {
 		opts = append(opts, elastic.SetHttpClient(&http.Client{
@@ -264,35 +284,51 @@ func (e *Elasticsearch) Write(msg *message.Batch) error {
 
 	boff := e.backoffCtor()
 
-	requests := make([]*pendingBulkIndex, msg.Len())
-	if err := msg.Iter(func(i int, part *message.Part) error {
-		jObj, ierr := part.JSON()
-		if ierr != nil {
-			e.log.Errorf("Failed to marshal message into JSON document: %v\n", ierr)
-			return fmt.Errorf("failed to marshal message into JSON document: %w", ierr)
+	// Messages that fail are recorded against their index within the batch so
+	// that only they are retried by the pipeline.
+	var batchErr *batch.Error
+	failed := func(i int, err error) {
+		if batchErr == nil {
+			batchErr = batch.NewError(msg, err)
 		}
-		requests[i] = &pendingBulkIndex{
+		batchErr.Failed(i, err)
+	}
+
+	b := e.client.Bulk()
+
+	var requests []*pendingBulkIndex
+	var indexes []int
+	_ = msg.Iter(func(i int, part *message.Part) error {
+		req := &pendingBulkIndex{
 			Action:   e.actionStr.String(i, msg),
 			Index:    e.indexStr.String(i, msg),
 			Pipeline: e.pipelineStr.String(i, msg),
 			Routing:  e.routingStr.String(i, msg),
 			Type:     e.conf.Type,
-			Doc:      jObj,
 			ID:       e.idStr.String(i, msg),
 		}
-		return nil
-	}); err != nil {
-		return err
-	}
-
-	b := e.client.Bulk()
-	for _, v := range requests {
-		bulkReq, err := e.buildBulkableRequest(v)
+		if e.conf.OpenSearch {
+			req.Type = ""
+		}
+		if req.Action != "delete" {
+			jObj, ierr := part.JSON()
+			if ierr != nil {
+				e.log.Errorf("Failed to marshal message into JSON document: %v\n", ierr)
+				failed(i, fmt.Errorf("failed to marshal message into JSON document: %w", ierr))
+				return nil
+			}
+			req.Doc = jObj
+		}
+		bulkReq, err := e.buildBulkableRequest(req)
 		if err != nil {
-			return err
+			failed(i, err)
+			return nil
 		}
 		b.Add(bulkReq)
-	}
+		requests = append(requests, req)
+		indexes = append(indexes, i)
+		return nil
+	})
 
 	lastErrReason := "no reason given"
 	for b.NumberOfActions() != 0 {
@@ -301,10 +337,11 @@ func (e *Elasticsearch) Write(msg *message.Batch) error {
 			return err
 		}
 		if !result.Errors {
-			return nil
+			break
 		}
 
 		var newRequests []*pendingBulkIndex
+		var newIndexes []int
 		for i, resp := range result.Items {
 			for _, item := range resp {
 				if item.Status >= 200 && item.Status <= 299 {
@@ -319,7 +356,8 @@ func (e *Elasticsearch) Write(msg *message.Batch) error {
 
 				e.log.Errorf("Elasticsearch message '%v' rejected with status [%v]: %v\n", item.Id, item.Status, reason)
 				if !shouldRetry(item.Status) {
-					return fmt.Errorf("failed to send message '%v': %v", item.Id, reason)
+					failed(indexes[i], fmt.Errorf("failed to send message '%v': %v", item.Id, reason))
+					continue
 				}
 
 				// IMPORTANT: i exactly matches the index of our source requests
@@ -332,17 +370,28 @@ func (e *Elasticsearch) Write(msg *message.Batch) error {
 				}
 				b.Add(bulkReq)
 				newRequests = append(newRequests, sourceReq)
+				newIndexes = append(newIndexes, indexes[i])
 			}
 		}
-		requests = newRequests
+		requests, indexes = newRequests, newIndexes
+		if len(requests) == 0 {
+			break
+		}
 
 		wait := boff.NextBackOff()
 		if wait == backoff.Stop {
-			return fmt.Errorf("retries exhausted for messages, aborting with last error reported as: %v", lastErrReason)
+			err := fmt.Errorf("retries exhausted for messages, aborting with last error reported as: %v", lastErrReason)
+			for _, i := range indexes {
+				failed(i, err)
+			}
+			break
 		}
 		time.Sleep(wait)
 	}
 
+	if batchErr != nil {
+		return batchErr
+	}
 	return nil
 }
 
@@ -377,6 +426,17 @@ func (e *Elasticsearch) buildBulkableRequest(p *pendingBulkIndex) (elastic.Bulka
 			r = r.Type(p.Type)
 		}
 		return r, nil
+	case "create":
+		r := elastic.NewBulkCreateRequest().
+			Index(p.Index).
+			Pipeline(p.Pipeline).
+			Routing(p.Routing).
+			Id(p.ID).
+			Doc(p.Doc)
+		if p.Type != "" {
+			r = r.Type(p.Type)
+		}
+		return r, nil
 	case "index":
 		r := elastic.NewBulkIndexRequest().
 			Index(p.Index).
@@ -0,0 +1,116 @@
+package writer
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/batch"
+	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+	"github.com/benthosdev/benthos/v4/internal/log"
+	"github.com/benthosdev/benthos/v4/internal/manager/mock"
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+func TestElasticsearchBulkErrors(t *testing.T) {
+	var mut sync.Mutex
+	var actions []string
+	var authHeaders []string
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mut.Lock()
+		defer mut.Unlock()
+
+		authHeaders = append(authHeaders, r.Header.Get("Authorization"))
+
+		var items []string
+		scanner := bufio.NewScanner(r.Body)
+		for scanner.Scan() {
+			var line map[string]map[string]interface{}
+			require.NoError(t, json.Unmarshal(scanner.Bytes(), &line))
+			for action, meta := range line {
+				actions = append(actions, fmt.Sprintf("%v:%v:%v", action, meta["_index"], meta["_id"]))
+				status := 201
+				if meta["_id"] == "reject" {
+					status = 400
+				}
+				items = append(items, fmt.Sprintf(`{"%v":{"_id":"%v","status":%v,"error":{"reason":"nope"}}}`, action, meta["_id"], status))
+			}
+			if action := actions[len(actions)-1]; !strings.HasPrefix(action, "delete") {
+				scanner.Scan()
+			}
+		}
+		fmt.Fprintf(w, `{"errors":true,"items":[%v]}`, strings.Join(items, ","))
+	}))
+	defer ts.Close()
+
+	conf := NewElasticsearchConfig()
+	conf.URLs = []string{ts.URL}
+	conf.Sniff = false
+	conf.Healthcheck = false
+	conf.Index = "foo"
+	conf.ID = `${! meta("id") }`
+	conf.Action = `${! meta("action") }`
+	conf.APIKey = "c2VjcmV0"
+
+	e, err := NewElasticsearchV2(conf, mock.NewManager(), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	require.NoError(t, e.Connect())
+
+	msg := message.QuickBatch(nil)
+	for _, m := range []struct {
+		id, action, content string
+	}{
+		{id: "first", action: "create", content: `{"a":1}`},
+		{id: "reject", action: "index", content: `{"a":2}`},
+		{id: "broken", action: "index", content: `not json`},
+		{id: "second", action: "delete", content: `not json`},
+	} {
+		part := message.NewPart([]byte(m.content))
+		part.MetaSet("id", m.id)
+		part.MetaSet("action", m.action)
+		msg.Append(part)
+	}
+
+	err = e.Write(msg)
+	require.Error(t, err)
+
+	berr, ok := err.(*batch.Error)
+	require.True(t, ok, "%T", err)
+
+	var failed []int
+	berr.WalkParts(func(i int, _ *message.Part, err error) bool {
+		if err != nil {
+			failed = append(failed, i)
+		}
+		return true
+	})
+	assert.Equal(t, []int{1, 2}, failed)
+
+	mut.Lock()
+	assert.Equal(t, []string{
+		"create:foo:first",
+		"index:foo:reject",
+		"delete:foo:second",
+	}, actions)
+	assert.Equal(t, []string{"ApiKey c2VjcmV0"}, authHeaders)
+	mut.Unlock()
+}
+
+func TestElasticsearchAuthConflict(t *testing.T) {
+	conf := NewElasticsearchConfig()
+	conf.APIKey = "foo"
+	conf.Auth.Enabled = true
+
+	_, err := NewElasticsearchV2(conf, mock.NewManager(), log.Noop(), metrics.Noop())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "cannot use both api_key and basic_auth")
+}
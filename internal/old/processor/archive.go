@@ -5,10 +5,14 @@ import (
 	"archive/zip"
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
+	"sync"
 	"time"
 
+	"github.com/klauspost/compress/zstd"
+
 	"github.com/benthosdev/benthos/v4/internal/batch"
 	"github.com/benthosdev/benthos/v4/internal/bloblang/field"
 	"github.com/benthosdev/benthos/v4/internal/component/metrics"
@@ -49,11 +53,12 @@ of the batch.`,
 		},
 		UsesBatches: true,
 		Config: docs.FieldComponent().WithChildren(
-			docs.FieldString("format", "The archiving [format](#formats) to apply.").HasOptions("tar", "zip", "binary", "lines", "json_array", "concatenate"),
+			docs.FieldString("format", "The archiving [format](#formats) to apply.").HasOptions("tar", "tar_zstd", "zip", "binary", "binary_v2", "lines", "json_lines", "json_array", "concatenate"),
 			docs.FieldString(
 				"path", "The path to set for each message in the archive (when applicable).",
 				"${!count(\"files\")}-${!timestamp_unix_nano()}.txt", "${!meta(\"kafka_key\")}-${!json(\"id\")}.json",
 			).IsInterpolated(),
+			docs.FieldInt("max_part_size", "The maximum size in bytes of each resulting message, where a batch is split into multiple messages in order to respect it. Only applicable to the `json_lines` format, and zero disables the limit.").AtVersion("4.1.0").Advanced(),
 		),
 		Footnotes: `
 ## Formats
@@ -66,6 +71,10 @@ Join the raw contents of each message into a single binary message.
 
 Archive messages to a unix standard tape archive.
 
+### ` + "`tar_zstd`" + `
+
+Archive messages to a unix standard tape archive compressed with zstd.
+
 ### ` + "`zip`" + `
 
 Archive messages to a zip file.
@@ -79,10 +88,27 @@ Archive messages to a binary blob format consisting of:
   + Four bytes containing the length of the message (in big endian)
   + The content of message
 
+### ` + "`binary_v2`" + `
+
+Archive messages to a versioned binary blob format that, unlike ` + "`binary`" + `,
+preserves the metadata, error flags and structured contents of each message.
+Archives of this format can be extracted with the ` + "`binary`" + ` format of the
+` + "[`unarchive` processor](/docs/components/processors/unarchive)" + `.
+
 ### ` + "`lines`" + `
 
 Join the raw contents of each message and insert a line break between each one.
 
+### ` + "`json_lines`" + `
+
+Attempt to parse each message as a JSON document and join their compact
+serialisations with a line break between each one. When ` + "`max_part_size`" + `
+is set the batch is split into as many messages as needed for each to be no
+larger than that size, which is useful when the output enforces a limit on
+the size of requests. Each resulting message adopts the metadata of the first
+message it contains, and a single message that exceeds the size causes the
+batch to fail.
+
 ### ` + "`json_array`" + `
 
 Attempt to parse each message as a JSON document and append the result to an
@@ -114,15 +140,17 @@ pipeline:
 
 // ArchiveConfig contains configuration fields for the Archive processor.
 type ArchiveConfig struct {
-	Format string `json:"format" yaml:"format"`
-	Path   string `json:"path" yaml:"path"`
+	Format      string `json:"format" yaml:"format"`
+	Path        string `json:"path" yaml:"path"`
+	MaxPartSize int    `json:"max_part_size" yaml:"max_part_size"`
 }
 
 // NewArchiveConfig returns a ArchiveConfig with default values.
 func NewArchiveConfig() ArchiveConfig {
 	return ArchiveConfig{
-		Format: "",
-		Path:   ``,
+		Format:      "",
+		Path:        ``,
+		MaxPartSize: 0,
 	}
 }
 
@@ -130,6 +158,10 @@ func NewArchiveConfig() ArchiveConfig {
 
 type archiveFunc func(hFunc headerFunc, msg *message.Batch) (*message.Part, error)
 
+// splitArchiveFunc archives a batch into one or more messages, along with the
+// number of messages archived within each.
+type splitArchiveFunc func(hFunc headerFunc, msg *message.Batch) ([]*message.Part, []int, error)
+
 type headerFunc func(index int, body *message.Part) os.FileInfo
 
 func tarArchive(hFunc headerFunc, msg *message.Batch) (*message.Part, error) {
@@ -160,6 +192,23 @@ func tarArchive(hFunc headerFunc, msg *message.Batch) (*message.Part, error) {
 	return newPart, nil
 }
 
+var (
+	archiveZstdEncOnce sync.Once
+	archiveZstdEnc     *zstd.Encoder
+)
+
+func tarZstdArchive(hFunc headerFunc, msg *message.Batch) (*message.Part, error) {
+	newPart, err := tarArchive(hFunc, msg)
+	if err != nil {
+		return nil, err
+	}
+	archiveZstdEncOnce.Do(func() {
+		archiveZstdEnc, _ = zstd.NewWriter(nil)
+	})
+	newPart.Set(archiveZstdEnc.EncodeAll(newPart.Get(), nil))
+	return newPart, nil
+}
+
 func zipArchive(hFunc headerFunc, msg *message.Batch) (*message.Part, error) {
 	buf := &bytes.Buffer{}
 	zw := zip.NewWriter(buf)
@@ -197,6 +246,12 @@ func binaryArchive(hFunc headerFunc, msg *message.Batch) (*message.Part, error)
 	return newPart, nil
 }
 
+func binaryV2Archive(hFunc headerFunc, msg *message.Batch) (*message.Part, error) {
+	newPart := msg.Get(0).Copy()
+	newPart.Set(message.ToBytesV2(msg))
+	return newPart, nil
+}
+
 func linesArchive(hFunc headerFunc, msg *message.Batch) (*message.Part, error) {
 	tmpParts := make([][]byte, msg.Len())
 	_ = msg.Iter(func(i int, part *message.Part) error {
@@ -240,14 +295,71 @@ func jsonArrayArchive(hFunc headerFunc, msg *message.Batch) (*message.Part, erro
 	return newPart, nil
 }
 
+func newJSONLinesArchiver(maxPartSize int) splitArchiveFunc {
+	return func(hFunc headerFunc, msg *message.Batch) ([]*message.Part, []int, error) {
+		var parts []*message.Part
+		var counts []int
+
+		var buf bytes.Buffer
+		var first *message.Part
+		count := 0
+		flush := func() {
+			newPart := first.Copy()
+			newPart.Set(append([]byte(nil), buf.Bytes()...))
+			parts = append(parts, newPart)
+			counts = append(counts, count)
+			buf.Reset()
+			count = 0
+		}
+
+		err := msg.Iter(func(i int, part *message.Part) error {
+			doc, jerr := part.JSON()
+			if jerr != nil {
+				return fmt.Errorf("failed to parse message as JSON: %v", jerr)
+			}
+			line, jerr := json.Marshal(doc)
+			if jerr != nil {
+				return fmt.Errorf("failed to serialise message as JSON: %v", jerr)
+			}
+			if maxPartSize > 0 {
+				if len(line) > maxPartSize {
+					return fmt.Errorf("message at index %v has a size of %v bytes which exceeds the max_part_size of %v", i, len(line), maxPartSize)
+				}
+				if count > 0 && buf.Len()+1+len(line) > maxPartSize {
+					flush()
+				}
+			}
+			if count == 0 {
+				first = part
+			} else {
+				buf.WriteByte('\n')
+			}
+			buf.Write(line)
+			count++
+			return nil
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		if count > 0 {
+			flush()
+		}
+		return parts, counts, nil
+	}
+}
+
 func strToArchiver(str string) (archiveFunc, error) {
 	switch str {
 	case "tar":
 		return tarArchive, nil
+	case "tar_zstd":
+		return tarZstdArchive, nil
 	case "zip":
 		return zipArchive, nil
 	case "binary":
 		return binaryArchive, nil
+	case "binary_v2":
+		return binaryV2Archive, nil
 	case "lines":
 		return linesArchive, nil
 	case "json_array":
@@ -261,7 +373,7 @@ func strToArchiver(str string) (archiveFunc, error) {
 //------------------------------------------------------------------------------
 
 type archive struct {
-	archive archiveFunc
+	archive splitArchiveFunc
 	path    *field.Expression
 	log     log.Modular
 }
@@ -271,16 +383,31 @@ func newArchive(conf ArchiveConfig, mgr interop.Manager) (processor.V2Batched, e
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse path expression: %v", err)
 	}
+	if conf.MaxPartSize < 0 {
+		return nil, fmt.Errorf("max_part_size must not be negative, got: %v", conf.MaxPartSize)
+	}
+
+	a := &archive{
+		path: path,
+		log:  mgr.Logger(),
+	}
+	if conf.Format == "json_lines" {
+		a.archive = newJSONLinesArchiver(conf.MaxPartSize)
+		return a, nil
+	}
+
 	archiver, err := strToArchiver(conf.Format)
 	if err != nil {
 		return nil, err
 	}
-
-	return &archive{
-		archive: archiver,
-		path:    path,
-		log:     mgr.Logger(),
-	}, nil
+	a.archive = func(hFunc headerFunc, msg *message.Batch) ([]*message.Part, []int, error) {
+		newPart, err := archiver(hFunc, msg)
+		if err != nil {
+			return nil, nil, err
+		}
+		return []*message.Part{newPart}, []int{msg.Len()}, nil
+	}
+	return a, nil
 }
 
 //------------------------------------------------------------------------------
@@ -329,13 +456,15 @@ func (d *archive) ProcessBatch(ctx context.Context, _ []*tracing.Span, msg *mess
 
 	newMsg := msg.Copy()
 
-	newPart, err := d.archive(d.createHeaderFunc(msg), msg)
+	newParts, counts, err := d.archive(d.createHeaderFunc(msg), msg)
 	if err != nil {
 		d.log.Errorf("Failed to create archive: %v\n", err)
 		return nil, err
 	}
-	newPart = batch.WithCollapsedCount(newPart, msg.Len())
-	newMsg.SetAll([]*message.Part{newPart})
+	for i, p := range newParts {
+		newParts[i] = batch.WithCollapsedCount(p, counts[i])
+	}
+	newMsg.SetAll(newParts)
 
 	msgs := [1]*message.Batch{newMsg}
 	return msgs[:], nil
@@ -10,6 +10,8 @@ import (
 	"reflect"
 	"testing"
 
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/benthosdev/benthos/v4/internal/batch"
@@ -293,3 +295,141 @@ func TestArchiveEmpty(t *testing.T) {
 		t.Error("Expected failure with zero part message")
 	}
 }
+
+func TestArchiveTarZstd(t *testing.T) {
+	conf := NewConfig()
+	conf.Archive.Format = "tar_zstd"
+	conf.Archive.Path = "foo-${!meta(\"path\")}"
+
+	proc, err := newArchive(conf.Archive, mock.NewManager())
+	require.NoError(t, err)
+
+	input := message.QuickBatch([][]byte{[]byte("hello"), []byte("world")})
+	input.Get(0).MetaSet("path", "a")
+	input.Get(1).MetaSet("path", "b")
+
+	msgs, res := proc.ProcessBatch(context.Background(), nil, input)
+	require.NoError(t, res)
+	require.Len(t, msgs, 1)
+	require.Equal(t, 1, msgs[0].Len())
+
+	dec, err := zstd.NewReader(nil)
+	require.NoError(t, err)
+	defer dec.Close()
+
+	tarBytes, err := dec.DecodeAll(msgs[0].Get(0).Get(), nil)
+	require.NoError(t, err)
+
+	tr := tar.NewReader(bytes.NewReader(tarBytes))
+	for _, exp := range []struct{ name, content string }{
+		{"foo-a", "hello"},
+		{"foo-b", "world"},
+	} {
+		h, err := tr.Next()
+		require.NoError(t, err)
+		assert.Equal(t, exp.name, h.Name)
+
+		content, err := io.ReadAll(tr)
+		require.NoError(t, err)
+		assert.Equal(t, exp.content, string(content))
+	}
+	_, err = tr.Next()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestArchiveJSONLines(t *testing.T) {
+	input := [][]byte{
+		[]byte(`{"id":1,  "foo":"bar"}`),
+		[]byte(`{"id":2}`),
+		[]byte(`{"id":3,"baz":[1,2,3]}`),
+		[]byte(`{"id":4}`),
+	}
+
+	tests := []struct {
+		name        string
+		maxPartSize int
+		output      []string
+		counts      []int
+		ids         []string
+	}{
+		{
+			name: "no limit",
+			output: []string{
+				"{\"foo\":\"bar\",\"id\":1}\n{\"id\":2}\n{\"baz\":[1,2,3],\"id\":3}\n{\"id\":4}",
+			},
+			counts: []int{4},
+			ids:    []string{"1"},
+		},
+		{
+			name:        "limited",
+			maxPartSize: 30,
+			output: []string{
+				"{\"foo\":\"bar\",\"id\":1}\n{\"id\":2}",
+				"{\"baz\":[1,2,3],\"id\":3}",
+				"{\"id\":4}",
+			},
+			counts: []int{2, 1, 1},
+			ids:    []string{"1", "3", "4"},
+		},
+		{
+			name:        "exact limit",
+			maxPartSize: 29,
+			output: []string{
+				"{\"foo\":\"bar\",\"id\":1}\n{\"id\":2}",
+				"{\"baz\":[1,2,3],\"id\":3}",
+				"{\"id\":4}",
+			},
+			counts: []int{2, 1, 1},
+			ids:    []string{"1", "3", "4"},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			conf := NewConfig()
+			conf.Archive.Format = "json_lines"
+			conf.Archive.MaxPartSize = test.maxPartSize
+
+			proc, err := newArchive(conf.Archive, mock.NewManager())
+			require.NoError(t, err)
+
+			inBatch := message.QuickBatch(input)
+			for i := 0; i < inBatch.Len(); i++ {
+				inBatch.Get(i).MetaSet("id", fmt.Sprintf("%v", i+1))
+			}
+
+			msgs, res := proc.ProcessBatch(context.Background(), nil, inBatch)
+			require.NoError(t, res)
+			require.Len(t, msgs, 1)
+
+			var output []string
+			var counts []int
+			var ids []string
+			_ = msgs[0].Iter(func(i int, p *message.Part) error {
+				output = append(output, string(p.Get()))
+				counts = append(counts, batch.CollapsedCount(p))
+				ids = append(ids, p.MetaGet("id"))
+				return nil
+			})
+			assert.Equal(t, test.output, output)
+			assert.Equal(t, test.counts, counts)
+			assert.Equal(t, test.ids, ids)
+		})
+	}
+}
+
+func TestArchiveJSONLinesTooLarge(t *testing.T) {
+	conf := NewConfig()
+	conf.Archive.Format = "json_lines"
+	conf.Archive.MaxPartSize = 10
+
+	proc, err := newArchive(conf.Archive, mock.NewManager())
+	require.NoError(t, err)
+
+	_, res := proc.ProcessBatch(context.Background(), nil, message.QuickBatch([][]byte{
+		[]byte(`{"id":1}`),
+		[]byte(`{"id":"too large"}`),
+	}))
+	require.EqualError(t, res, "message at index 1 has a size of 18 bytes which exceeds the max_part_size of 10")
+}
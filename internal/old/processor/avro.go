@@ -1,11 +1,16 @@
 package processor
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"math/big"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/linkedin/goavro/v2"
 
@@ -48,15 +53,36 @@ specifies how the source documents are encoded.
 ### ` + "`from_json`" + `
 
 Attempts to convert JSON documents into Avro documents according to the
-specified encoding.`,
+specified encoding.
+
+## Object Container Files
+
+When the encoding is set to ` + "`ocf`" + ` the operator ` + "`to_json`" + ` expands each Avro
+[Object Container File](https://avro.apache.org/docs/current/spec.html#Object+Container+Files)
+into a message per record using the schema embedded within the file, and
+therefore the ` + "`schema`" + ` and ` + "`schema_path`" + ` fields must be left empty. The operator ` + "`from_json`" + `
+produces a container file holding a single record.
+
+## Logical Types
+
+Decimal values are converted to and from JSON numbers, and the timestamp
+logical types (` + "`timestamp-millis`" + ` and ` + "`timestamp-micros`" + `) are converted to
+and from RFC 3339 formatted strings. Numeric timestamp values are also accepted
+by the ` + "`from_json`" + ` operator.
+
+## Schema Registry References
+
+When ` + "`schema_path`" + ` points to a schema version of a schema registry service
+(e.g. ` + "`http://localhost:8081/subjects/foo/versions/1`" + `) the schema is
+extracted from the response envelope.`,
 		Config: docs.FieldComponent().WithChildren(
 			docs.FieldString("operator", "The [operator](#operators) to execute").HasOptions("to_json", "from_json"),
-			docs.FieldString("encoding", "An Avro encoding format to use for conversions to and from a schema.").HasOptions("textual", "binary", "single"),
+			docs.FieldString("encoding", "An Avro encoding format to use for conversions to and from a schema.").HasOptions("textual", "binary", "single", "ocf"),
 			docs.FieldString("schema", "A full Avro schema to use."),
 			docs.FieldString(
 				"schema_path", "The path of a schema document to apply. Use either this or the `schema` field.",
 				"file://path/to/spec.avsc",
-				"http://localhost:8081/path/to/spec/versions/1",
+				"http://localhost:8081/subjects/foo/versions/1",
 			),
 		),
 	}
@@ -84,92 +110,119 @@ func NewAvroConfig() AvroConfig {
 
 //------------------------------------------------------------------------------
 
-type avroOperator func(part *message.Part) error
+type avroOperator func(part *message.Part) ([]*message.Part, error)
 
 func newAvroToJSONOperator(encoding string, codec *goavro.Codec) (avroOperator, error) {
+	var fromFn func([]byte) (interface{}, []byte, error)
 	switch encoding {
 	case "textual":
-		return func(part *message.Part) error {
-			jObj, _, err := codec.NativeFromTextual(part.Get())
-			if err != nil {
-				return fmt.Errorf("failed to convert Avro document to JSON: %v", err)
-			}
-			part.SetJSON(jObj)
-			return nil
-		}, nil
+		fromFn = codec.NativeFromTextual
 	case "binary":
-		return func(part *message.Part) error {
-			jObj, _, err := codec.NativeFromBinary(part.Get())
-			if err != nil {
-				return fmt.Errorf("failed to convert Avro document to JSON: %v", err)
-			}
-			part.SetJSON(jObj)
-			return nil
-		}, nil
+		fromFn = codec.NativeFromBinary
 	case "single":
-		return func(part *message.Part) error {
-			jObj, _, err := codec.NativeFromSingle(part.Get())
+		fromFn = codec.NativeFromSingle
+	case "ocf":
+		return func(part *message.Part) ([]*message.Part, error) {
+			r, err := goavro.NewOCFReader(bytes.NewReader(part.Get()))
 			if err != nil {
-				return fmt.Errorf("failed to convert Avro document to JSON: %v", err)
+				return nil, fmt.Errorf("failed to read Avro container file: %v", err)
 			}
-			part.SetJSON(jObj)
-			return nil
+			var parts []*message.Part
+			for r.Scan() {
+				jObj, err := r.Read()
+				if err != nil {
+					return nil, fmt.Errorf("failed to convert Avro document to JSON: %v", err)
+				}
+				newPart := part.Copy()
+				newPart.SetJSON(avroNativeToJSON(jObj))
+				parts = append(parts, newPart)
+			}
+			if err := r.Err(); err != nil {
+				return nil, fmt.Errorf("failed to read Avro container file: %v", err)
+			}
+			return parts, nil
 		}, nil
+	default:
+		return nil, fmt.Errorf("encoding '%v' not recognised", encoding)
 	}
-	return nil, fmt.Errorf("encoding '%v' not recognised", encoding)
+	return func(part *message.Part) ([]*message.Part, error) {
+		jObj, _, err := fromFn(part.Get())
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert Avro document to JSON: %v", err)
+		}
+		part.SetJSON(avroNativeToJSON(jObj))
+		return []*message.Part{part}, nil
+	}, nil
 }
 
-func newAvroFromJSONOperator(encoding string, codec *goavro.Codec) (avroOperator, error) {
+func newAvroFromJSONOperator(encoding string, codec *goavro.Codec, normaliser *avroLogicalNormaliser) (avroOperator, error) {
+	var toFn func([]byte, interface{}) ([]byte, error)
 	switch encoding {
 	case "textual":
-		return func(part *message.Part) error {
-			jObj, err := part.JSON()
-			if err != nil {
-				return fmt.Errorf("failed to parse message as JSON: %v", err)
-			}
-			var textual []byte
-			if textual, err = codec.TextualFromNative(nil, jObj); err != nil {
-				return fmt.Errorf("failed to convert JSON to Avro schema: %v", err)
-			}
-			part.Set(textual)
-			return nil
-		}, nil
+		toFn = codec.TextualFromNative
 	case "binary":
-		return func(part *message.Part) error {
-			jObj, err := part.JSON()
-			if err != nil {
-				return fmt.Errorf("failed to parse message as JSON: %v", err)
-			}
-			var binary []byte
-			if binary, err = codec.BinaryFromNative(nil, jObj); err != nil {
-				return fmt.Errorf("failed to convert JSON to Avro schema: %v", err)
-			}
-			part.Set(binary)
-			return nil
-		}, nil
+		toFn = codec.BinaryFromNative
 	case "single":
-		return func(part *message.Part) error {
-			jObj, err := part.JSON()
+		toFn = codec.SingleFromNative
+	case "ocf":
+		toFn = func(_ []byte, native interface{}) ([]byte, error) {
+			var buf bytes.Buffer
+			w, err := goavro.NewOCFWriter(goavro.OCFConfig{
+				W:     &buf,
+				Codec: codec,
+			})
 			if err != nil {
-				return fmt.Errorf("failed to parse message as JSON: %v", err)
+				return nil, err
 			}
-			var single []byte
-			if single, err = codec.SingleFromNative(nil, jObj); err != nil {
-				return fmt.Errorf("failed to convert JSON to Avro schema: %v", err)
+			if err = w.Append([]interface{}{native}); err != nil {
+				return nil, err
 			}
-			part.Set(single)
-			return nil
-		}, nil
+			return buf.Bytes(), nil
+		}
+	default:
+		return nil, fmt.Errorf("encoding '%v' not recognised", encoding)
 	}
-	return nil, fmt.Errorf("encoding '%v' not recognised", encoding)
+	return func(part *message.Part) ([]*message.Part, error) {
+		jObj, err := part.JSON()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse message as JSON: %v", err)
+		}
+		if jObj, err = normaliser.normalise(jObj); err != nil {
+			return nil, fmt.Errorf("failed to convert JSON to Avro schema: %v", err)
+		}
+		var result []byte
+		if result, err = toFn(nil, jObj); err != nil {
+			return nil, fmt.Errorf("failed to convert JSON to Avro schema: %v", err)
+		}
+		part.Set(result)
+		return []*message.Part{part}, nil
+	}, nil
 }
 
-func strToAvroOperator(opStr, encoding string, codec *goavro.Codec) (avroOperator, error) {
+func strToAvroOperator(opStr, encoding, schema string) (avroOperator, error) {
+	// Container files embed their own schema which is always used when
+	// reading them, and therefore a configured schema would be ignored.
+	if opStr == "to_json" && encoding == "ocf" {
+		if schema != "" {
+			return nil, errors.New("a schema cannot be specified for the to_json operator with the ocf encoding as the schema embedded within each container file is used")
+		}
+		return newAvroToJSONOperator(encoding, nil)
+	}
+
+	codec, err := goavro.NewCodec(schema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse schema: %v", err)
+	}
+
 	switch opStr {
 	case "to_json":
 		return newAvroToJSONOperator(encoding, codec)
 	case "from_json":
-		return newAvroFromJSONOperator(encoding, codec)
+		normaliser, err := newAvroLogicalNormaliser(schema)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse schema: %v", err)
+		}
+		return newAvroFromJSONOperator(encoding, codec, normaliser)
 	}
 	return nil, fmt.Errorf("operator not recognised: %v", opStr)
 }
@@ -193,11 +246,300 @@ func loadSchema(schemaPath string) (string, error) {
 		return "", err
 	}
 
+	// Schema registry services wrap schemas within an envelope that we need to
+	// extract from.
+	var envelope map[string]interface{}
+	if err := json.Unmarshal(body, &envelope); err == nil {
+		if _, hasType := envelope["type"]; !hasType {
+			if schema, ok := envelope["schema"].(string); ok {
+				return schema, nil
+			}
+		}
+	}
+
 	return string(body), nil
 }
 
 //------------------------------------------------------------------------------
 
+// avroNativeToJSON walks a value decoded by goavro and converts logical type
+// values into JSON friendly representations.
+func avroNativeToJSON(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, e := range t {
+			t[k] = avroNativeToJSON(e)
+		}
+	case []interface{}:
+		for i, e := range t {
+			t[i] = avroNativeToJSON(e)
+		}
+	case *big.Rat:
+		return json.Number(avroRatToDecimalString(t))
+	case time.Time:
+		return t.Format(time.RFC3339Nano)
+	}
+	return v
+}
+
+func avroRatToDecimalString(r *big.Rat) string {
+	// Decimals always have a denominator that is a divisor of a power of ten,
+	// therefore we find the smallest number of decimal places required to
+	// represent the value exactly.
+	places, pow, ten := 0, big.NewInt(1), big.NewInt(10)
+	for new(big.Int).Mod(pow, r.Denom()).Sign() != 0 && places < 64 {
+		pow.Mul(pow, ten)
+		places++
+	}
+	return r.FloatString(places)
+}
+
+// avroLogicalNormaliser converts generic JSON values into the native types
+// expected by goavro, guided by a schema.
+type avroLogicalNormaliser struct {
+	root  interface{}
+	named map[string]interface{}
+}
+
+func newAvroLogicalNormaliser(schema string) (*avroLogicalNormaliser, error) {
+	var root interface{}
+	if err := json.Unmarshal([]byte(schema), &root); err != nil {
+		return nil, err
+	}
+	n := &avroLogicalNormaliser{
+		root:  root,
+		named: map[string]interface{}{},
+	}
+	n.register(root, "")
+	return n, nil
+}
+
+func avroFullName(s map[string]interface{}, namespace string) (fullName, newNamespace string) {
+	name, _ := s["name"].(string)
+	if strings.Contains(name, ".") {
+		return name, name[:strings.LastIndex(name, ".")]
+	}
+	if ns, ok := s["namespace"].(string); ok {
+		namespace = ns
+	}
+	if namespace == "" {
+		return name, namespace
+	}
+	return namespace + "." + name, namespace
+}
+
+func (n *avroLogicalNormaliser) register(schema interface{}, namespace string) {
+	switch s := schema.(type) {
+	case []interface{}:
+		for _, b := range s {
+			n.register(b, namespace)
+		}
+	case map[string]interface{}:
+		switch t := s["type"].(type) {
+		case string:
+			switch t {
+			case "record", "error", "enum", "fixed":
+				fullName, ns := avroFullName(s, namespace)
+				n.named[fullName] = s
+				if fields, ok := s["fields"].([]interface{}); ok {
+					for _, f := range fields {
+						if fObj, ok := f.(map[string]interface{}); ok {
+							n.register(fObj["type"], ns)
+						}
+					}
+				}
+			case "array":
+				n.register(s["items"], namespace)
+			case "map":
+				n.register(s["values"], namespace)
+			}
+		default:
+			n.register(t, namespace)
+		}
+	}
+}
+
+func (n *avroLogicalNormaliser) lookup(name, namespace string) (interface{}, string, bool) {
+	if namespace != "" && !strings.Contains(name, ".") {
+		if s, exists := n.named[namespace+"."+name]; exists {
+			return s, namespace + "." + name, true
+		}
+	}
+	s, exists := n.named[name]
+	return s, name, exists
+}
+
+// unionName returns the name goavro uses in order to identify a branch of a
+// union.
+func (n *avroLogicalNormaliser) unionName(schema interface{}, namespace string) string {
+	switch s := schema.(type) {
+	case string:
+		if _, fullName, exists := n.lookup(s, namespace); exists {
+			return fullName
+		}
+		return s
+	case map[string]interface{}:
+		t, _ := s["type"].(string)
+		switch t {
+		case "record", "error", "enum", "fixed":
+			fullName, _ := avroFullName(s, namespace)
+			return fullName
+		}
+		if logical, ok := s["logicalType"].(string); ok {
+			return t + "." + logical
+		}
+		return t
+	}
+	return ""
+}
+
+func (n *avroLogicalNormaliser) normalise(v interface{}) (interface{}, error) {
+	if n == nil {
+		return v, nil
+	}
+	return n.normaliseWith(n.root, "", v)
+}
+
+func (n *avroLogicalNormaliser) normaliseWith(schema interface{}, namespace string, v interface{}) (interface{}, error) {
+	switch s := schema.(type) {
+	case string:
+		if named, fullName, exists := n.lookup(s, namespace); exists {
+			ns := ""
+			if i := strings.LastIndex(fullName, "."); i >= 0 {
+				ns = fullName[:i]
+			}
+			return n.normaliseWith(named, ns, v)
+		}
+		return avroNormalisePrimitive(s, "", v)
+	case []interface{}:
+		obj, ok := v.(map[string]interface{})
+		if !ok || len(obj) != 1 {
+			return v, nil
+		}
+		for k, inner := range obj {
+			for _, branch := range s {
+				if n.unionName(branch, namespace) != k {
+					continue
+				}
+				res, err := n.normaliseWith(branch, namespace, inner)
+				if err != nil {
+					return nil, err
+				}
+				return map[string]interface{}{k: res}, nil
+			}
+		}
+		return v, nil
+	case map[string]interface{}:
+		t, ok := s["type"].(string)
+		if !ok {
+			return n.normaliseWith(s["type"], namespace, v)
+		}
+		logical, _ := s["logicalType"].(string)
+		switch t {
+		case "record", "error":
+			_, ns := avroFullName(s, namespace)
+			obj, ok := v.(map[string]interface{})
+			if !ok {
+				return v, nil
+			}
+			fields, _ := s["fields"].([]interface{})
+			newObj := make(map[string]interface{}, len(obj))
+			for k, fv := range obj {
+				newObj[k] = fv
+			}
+			for _, f := range fields {
+				fObj, ok := f.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				fName, _ := fObj["name"].(string)
+				fv, exists := obj[fName]
+				if !exists {
+					continue
+				}
+				res, err := n.normaliseWith(fObj["type"], ns, fv)
+				if err != nil {
+					return nil, fmt.Errorf("field %v: %w", fName, err)
+				}
+				newObj[fName] = res
+			}
+			return newObj, nil
+		case "array":
+			arr, ok := v.([]interface{})
+			if !ok {
+				return v, nil
+			}
+			newArr := make([]interface{}, len(arr))
+			for i, e := range arr {
+				res, err := n.normaliseWith(s["items"], namespace, e)
+				if err != nil {
+					return nil, err
+				}
+				newArr[i] = res
+			}
+			return newArr, nil
+		case "map":
+			obj, ok := v.(map[string]interface{})
+			if !ok {
+				return v, nil
+			}
+			newObj := make(map[string]interface{}, len(obj))
+			for k, e := range obj {
+				res, err := n.normaliseWith(s["values"], namespace, e)
+				if err != nil {
+					return nil, err
+				}
+				newObj[k] = res
+			}
+			return newObj, nil
+		}
+		return avroNormalisePrimitive(t, logical, v)
+	}
+	return v, nil
+}
+
+func avroNormalisePrimitive(typeStr, logical string, v interface{}) (interface{}, error) {
+	switch logical {
+	case "decimal":
+		switch t := v.(type) {
+		case json.Number:
+			if r, ok := new(big.Rat).SetString(string(t)); ok {
+				return r, nil
+			}
+			return nil, fmt.Errorf("failed to parse decimal value: %v", t)
+		case float64:
+			return new(big.Rat).SetFloat64(t), nil
+		case string:
+			if r, ok := new(big.Rat).SetString(t); ok {
+				return r, nil
+			}
+		}
+		return v, nil
+	case "timestamp-millis", "timestamp-micros":
+		if str, ok := v.(string); ok {
+			ts, err := time.Parse(time.RFC3339Nano, str)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse timestamp value: %w", err)
+			}
+			return ts, nil
+		}
+	}
+	if num, ok := v.(json.Number); ok {
+		switch typeStr {
+		case "int", "long":
+			if i, err := num.Int64(); err == nil {
+				return i, nil
+			}
+			return num.Float64()
+		case "float", "double":
+			return num.Float64()
+		}
+	}
+	return v, nil
+}
+
+//------------------------------------------------------------------------------
+
 type avro struct {
 	operator avroOperator
 	log      log.Modular
@@ -210,8 +552,10 @@ func newAvro(conf AvroConfig, mgr interop.Manager) (processor.V2, error) {
 	var err error
 
 	if schemaPath := conf.SchemaPath; schemaPath != "" {
-		if !(strings.HasPrefix(schemaPath, "file://") || strings.HasPrefix(schemaPath, "http://")) {
-			return nil, fmt.Errorf("invalid schema_path provided, must start with file:// or http://")
+		if !(strings.HasPrefix(schemaPath, "file://") ||
+			strings.HasPrefix(schemaPath, "http://") ||
+			strings.HasPrefix(schemaPath, "https://")) {
+			return nil, fmt.Errorf("invalid schema_path provided, must start with file://, http:// or https://")
 		}
 
 		schema, err = loadSchema(schemaPath)
@@ -222,12 +566,7 @@ func newAvro(conf AvroConfig, mgr interop.Manager) (processor.V2, error) {
 		schema = conf.Schema
 	}
 
-	codec, err := goavro.NewCodec(schema)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse schema: %v", err)
-	}
-
-	if a.operator, err = strToAvroOperator(conf.Operator, conf.Encoding, codec); err != nil {
+	if a.operator, err = strToAvroOperator(conf.Operator, conf.Encoding, schema); err != nil {
 		return nil, err
 	}
 	return a, nil
@@ -236,13 +575,12 @@ func newAvro(conf AvroConfig, mgr interop.Manager) (processor.V2, error) {
 //------------------------------------------------------------------------------
 
 func (p *avro) Process(ctx context.Context, msg *message.Part) ([]*message.Part, error) {
-	msg = msg.Copy()
-	err := p.operator(msg)
+	parts, err := p.operator(msg.Copy())
 	if err != nil {
 		p.log.Debugf("Operator failed: %v\n", err)
 		return nil, err
 	}
-	return []*message.Part{msg}, nil
+	return parts, nil
 }
 
 func (p *avro) Close(context.Context) error {
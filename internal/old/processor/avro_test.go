@@ -2,12 +2,15 @@ package processor
 
 import (
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"reflect"
 	"strconv"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/benthosdev/benthos/v4/internal/component/metrics"
 	"github.com/benthosdev/benthos/v4/internal/log"
@@ -328,3 +331,103 @@ func TestAvroSchemaPathNotExist(t *testing.T) {
 		t.Error("expected error from loading non existant schema file")
 	}
 }
+
+const avroLogicalTypesSchema = `{
+	"type": "record",
+	"name": "measurement",
+	"fields": [
+		{ "name": "amount", "type": { "type": "bytes", "logicalType": "decimal", "precision": 8, "scale": 2 }},
+		{ "name": "count", "type": "long" },
+		{ "name": "at", "type": ["null", { "type": "long", "logicalType": "timestamp-millis" }] }
+	]
+}`
+
+func TestAvroLogicalTypes(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypeAvro
+	conf.Avro.Operator = "from_json"
+	conf.Avro.Encoding = "binary"
+	conf.Avro.Schema = avroLogicalTypesSchema
+
+	encoder, err := New(conf, mock.NewManager(), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	conf.Avro.Operator = "to_json"
+	decoder, err := New(conf, mock.NewManager(), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	msgs, res := encoder.ProcessMessage(message.QuickBatch([][]byte{
+		[]byte(`{"amount":12.5,"count":3,"at":{"long.timestamp-millis":"2022-04-20T10:11:12.5Z"}}`),
+	}))
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+	require.NoError(t, msgs[0].Get(0).ErrorGet())
+
+	msgs, res = decoder.ProcessMessage(msgs[0])
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+	require.NoError(t, msgs[0].Get(0).ErrorGet())
+
+	assert.Equal(t, `{"amount":12.5,"at":{"long.timestamp-millis":"2022-04-20T10:11:12.5Z"},"count":3}`, string(msgs[0].Get(0).Get()))
+}
+
+func TestAvroOCF(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypeAvro
+	conf.Avro.Operator = "from_json"
+	conf.Avro.Encoding = "ocf"
+	conf.Avro.Schema = avroLogicalTypesSchema
+
+	encoder, err := New(conf, mock.NewManager(), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	// The schema is embedded within container files and is therefore not
+	// required for decoding.
+	conf.Avro.Operator = "to_json"
+	conf.Avro.Schema = ""
+	decoder, err := New(conf, mock.NewManager(), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	msgs, res := encoder.ProcessMessage(message.QuickBatch([][]byte{
+		[]byte(`{"amount":"1.25","count":10,"at":null}`),
+	}))
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+	require.NoError(t, msgs[0].Get(0).ErrorGet())
+
+	msgs, res = decoder.ProcessMessage(msgs[0])
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+	require.Equal(t, 1, msgs[0].Len())
+	require.NoError(t, msgs[0].Get(0).ErrorGet())
+
+	assert.Equal(t, `{"amount":1.25,"at":null,"count":10}`, string(msgs[0].Get(0).Get()))
+
+	conf.Avro.Schema = avroLogicalTypesSchema
+	_, err = New(conf, mock.NewManager(), log.Noop(), metrics.Noop())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "schema embedded within each container file")
+}
+
+func TestAvroSchemaRegistryPath(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/subjects/foo/versions/1", r.URL.Path)
+		_, _ = w.Write([]byte(`{"subject":"foo","version":1,"id":3,"schema":"{\"type\":\"record\",\"name\":\"foo\",\"fields\":[{\"name\":\"Name\",\"type\":\"string\"}]}"}`))
+	}))
+	t.Cleanup(ts.Close)
+
+	conf := NewConfig()
+	conf.Type = TypeAvro
+	conf.Avro.Operator = "to_json"
+	conf.Avro.Encoding = "binary"
+	conf.Avro.SchemaPath = ts.URL + "/subjects/foo/versions/1"
+
+	proc, err := New(conf, mock.NewManager(), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	msgs, res := proc.ProcessMessage(message.QuickBatch([][]byte{[]byte("\x06foo")}))
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+	require.NoError(t, msgs[0].Get(0).ErrorGet())
+	assert.Equal(t, `{"Name":"foo"}`, string(msgs[0].Get(0).Get()))
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"sort"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/benthosdev/benthos/v4/internal/bloblang/mapping"
@@ -53,6 +54,11 @@ root.bar.id = this.user.id`,
 	this
 }`,
 	).HasDefault(""),
+	docs.FieldInt(
+		"parallel",
+		"When set to a number greater than zero each message of a batch is sent through the child processors individually, with up to this many messages being processed concurrently. This can dramatically improve the throughput of processors that perform network requests, such as `http`, when processing large batches. When set to zero the child processors are executed on the batch as a whole.",
+		10,
+	).HasDefault(0).Advanced().AtVersion("4.1.0"),
 }
 
 func init() {
@@ -90,6 +96,12 @@ the message will remain unchanged. Under any of these conditions standard
 [error handling methods](/docs/configuration/error_handling) can be used in
 order to filter, DLQ or recover the failed messages.
 
+### Parallel Processing
+
+By default the child processors of a branch are executed on the whole batch of request messages at once. Setting the field ` + "`parallel`" + ` to a number greater than zero instead executes the child processors on each request message individually, with up to that number of messages in flight at any given time. This is useful for enriching large batches with processors that perform a request per message, such as the ` + "`http`" + ` processor, which would otherwise process the messages one after the other.
+
+When ` + "`parallel`" + ` is enabled the child processors must produce exactly one message for each request message, and a single instance of each child processor is shared by all messages in flight.
+
 ### Conditional Branching
 
 If the root of your request map is set to ` + "`deleted()`" + ` then the branch
@@ -192,6 +204,7 @@ type BranchConfig struct {
 	RequestMap string   `json:"request_map" yaml:"request_map"`
 	Processors []Config `json:"processors" yaml:"processors"`
 	ResultMap  string   `json:"result_map" yaml:"result_map"`
+	Parallel   int      `json:"parallel" yaml:"parallel"`
 }
 
 // NewBranchConfig returns a BranchConfig with default values.
@@ -200,6 +213,7 @@ func NewBranchConfig() BranchConfig {
 		RequestMap: "",
 		Processors: []Config{},
 		ResultMap:  "",
+		Parallel:   0,
 	}
 }
 
@@ -214,6 +228,7 @@ type Branch struct {
 	requestMap *mapping.Executor
 	resultMap  *mapping.Executor
 	children   []processor.V1
+	parallel   int
 
 	// Metrics
 	mReceived      metrics.StatCounter
@@ -237,10 +252,14 @@ func newBranch(conf BranchConfig, mgr interop.Manager) (*Branch, error) {
 	if len(children) == 0 {
 		return nil, errors.New("the branch processor requires at least one child processor")
 	}
+	if conf.Parallel < 0 {
+		return nil, errors.New("the branch processor field parallel must not be negative")
+	}
 
 	stats := mgr.Metrics()
 	b := &Branch{
 		children: children,
+		parallel: conf.Parallel,
 		log:      mgr.Logger(),
 
 		mReceived:      stats.GetCounter("processor_received"),
@@ -379,7 +398,7 @@ func (b *Branch) ProcessMessage(msg *message.Batch) ([]*message.Batch, error) {
 		b.log.Errorf("Branch error: %v", e.err)
 	}
 
-	b.mLatency.Timing(time.Since(startedAt).Nanoseconds())
+	metrics.TimingWithContext(message.GetContext(result.Get(0)), b.mLatency, time.Since(startedAt).Nanoseconds())
 	return []*message.Batch{result}, nil
 }
 
@@ -443,7 +462,9 @@ func (b *Branch) createResult(parts []*message.Part, referenceMsg *message.Batch
 		var res error
 		msg := message.QuickBatch(nil)
 		msg.SetAll(parts)
-		if procResults, res = ExecuteAll(b.children, msg); res != nil {
+		if b.parallel > 0 {
+			procResults = []*message.Batch{b.executeParallel(parts)}
+		} else if procResults, res = ExecuteAll(b.children, msg); res != nil {
 			err = fmt.Errorf("child processors failed: %v", res)
 		}
 		if len(procResults) == 0 {
@@ -477,6 +498,62 @@ func (b *Branch) createResult(parts []*message.Part, referenceMsg *message.Batch
 	return alignedResult, mapErrs, nil
 }
 
+// executeParallel executes the child processors on each request part
+// individually, with up to b.parallel parts being processed concurrently. The
+// resulting batch is aligned with the request parts, where parts that fail to
+// produce exactly one result are replaced with a copy of the request flagged
+// with an error.
+func (b *Branch) executeParallel(parts []*message.Part) *message.Batch {
+	results := make([]*message.Part, len(parts))
+
+	sem := make(chan struct{}, b.parallel)
+	wg := sync.WaitGroup{}
+	wg.Add(len(parts))
+
+	for i, p := range parts {
+		sem <- struct{}{}
+		go func(index int, part *message.Part) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			failed := func(err error) {
+				failedPart := part.Copy()
+				failedPart.ErrorSet(err)
+				results[index] = failedPart
+			}
+
+			msg := message.QuickBatch(nil)
+			msg.Append(part)
+
+			procResults, res := ExecuteAll(b.children, msg)
+			if res != nil {
+				failed(res)
+				return
+			}
+
+			var resParts []*message.Part
+			for _, m := range procResults {
+				_ = m.Iter(func(_ int, p *message.Part) error {
+					resParts = append(resParts, p)
+					return nil
+				})
+			}
+			if len(resParts) != 1 {
+				failed(fmt.Errorf("resulted in %v messages, expected one", len(resParts)))
+				return
+			}
+			results[index] = resParts[0]
+		}(i, p)
+	}
+	wg.Wait()
+
+	msg := message.QuickBatch(nil)
+	msg.SetAll(results)
+	return msg
+}
+
 // overlayResult attempts to merge the result of a process_map with the original
 // payload as per the map specified in the postmap and postmap_optional fields.
 func (b *Branch) overlayResult(payload *message.Batch, results []*message.Part) ([]branchMapError, error) {
@@ -2,6 +2,7 @@ package processor
 
 import (
 	"errors"
+	"fmt"
 	"testing"
 	"time"
 
@@ -280,3 +281,86 @@ func TestBranchBasic(t *testing.T) {
 		})
 	}
 }
+
+func TestBranchParallel(t *testing.T) {
+	sleepConf := NewConfig()
+	sleepConf.Type = TypeSleep
+	sleepConf.Sleep.Duration = "100ms"
+
+	procConf := NewConfig()
+	procConf.Type = TypeBloblang
+	procConf.Bloblang = `root.upper = if this.name == "fail" { throw("nope") } else { this.name.uppercase() }`
+
+	conf := NewConfig()
+	conf.Type = TypeBranch
+	conf.Branch.RequestMap = `root.name = this.name`
+	conf.Branch.Processors = append(conf.Branch.Processors, sleepConf, procConf)
+	conf.Branch.ResultMap = `root.result = this.upper`
+	conf.Branch.Parallel = 10
+
+	proc, err := New(conf, mock.NewManager(), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	defer func() {
+		proc.CloseAsync()
+		assert.NoError(t, proc.WaitForClose(time.Second))
+	}()
+
+	var input [][]byte
+	for i := 0; i < 20; i++ {
+		name := fmt.Sprintf("name%v", i)
+		if i == 5 {
+			name = "fail"
+		}
+		input = append(input, []byte(fmt.Sprintf(`{"id":%v,"name":"%v"}`, i, name)))
+	}
+
+	startedAt := time.Now()
+	outMsgs, res := proc.ProcessMessage(message.QuickBatch(input))
+	require.Nil(t, res)
+	assert.Less(t, time.Since(startedAt), time.Second)
+
+	require.Len(t, outMsgs, 1)
+	require.Equal(t, 20, outMsgs[0].Len())
+	for i := 0; i < 20; i++ {
+		part := outMsgs[0].Get(i)
+		if i == 5 {
+			assert.Equal(t, `{"id":5,"name":"fail"}`, string(part.Get()))
+			assert.EqualError(t, part.ErrorGet(), "processors failed: failed assignment (line 1): nope")
+			continue
+		}
+		assert.NoError(t, part.ErrorGet())
+		assert.Equal(t, fmt.Sprintf(`{"id":%v,"name":"name%v","result":"NAME%v"}`, i, i, i), string(part.Get()))
+	}
+}
+
+func TestBranchParallelMessageCount(t *testing.T) {
+	procConf := NewConfig()
+	procConf.Type = TypeBloblang
+	procConf.Bloblang = `root = if this.name == "drop" { deleted() }`
+
+	conf := NewConfig()
+	conf.Type = TypeBranch
+	conf.Branch.Processors = append(conf.Branch.Processors, procConf)
+	conf.Branch.ResultMap = `root.result = this.name`
+	conf.Branch.Parallel = 2
+
+	proc, err := New(conf, mock.NewManager(), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	outMsgs, res := proc.ProcessMessage(message.QuickBatch([][]byte{
+		[]byte(`{"name":"keep"}`),
+		[]byte(`{"name":"drop"}`),
+	}))
+	require.Nil(t, res)
+	require.Len(t, outMsgs, 1)
+	require.Equal(t, 2, outMsgs[0].Len())
+
+	assert.Equal(t, `{"name":"keep","result":"keep"}`, string(outMsgs[0].Get(0).Get()))
+	assert.NoError(t, outMsgs[0].Get(0).ErrorGet())
+
+	assert.Equal(t, `{"name":"drop"}`, string(outMsgs[0].Get(1).Get()))
+	assert.EqualError(t, outMsgs[0].Get(1).ErrorGet(), "processors failed: resulted in 0 messages, expected one")
+
+	proc.CloseAsync()
+	assert.NoError(t, proc.WaitForClose(time.Second))
+}
@@ -142,6 +142,9 @@ Retrieve the contents of a cached key and replace the original message payload
 with the result. If the key does not exist the action fails with an error, which
 can be detected with [processor error handling](/docs/configuration/error_handling).
 
+The keys of all messages of a batch are retrieved together, which allows caches
+that support it to resolve an entire batch within a single request.
+
 ### ` + "`delete`" + `
 
 Delete a key and its contents from the cache.  If the key does not exist the
@@ -181,6 +184,7 @@ type cacheProc struct {
 	mgr       interop.Manager
 	cacheName string
 	operator  cacheOperator
+	isGet     bool
 }
 
 func newCache(conf CacheConfig, mgr interop.Manager) (*cacheProc, error) {
@@ -221,6 +225,7 @@ func newCache(conf CacheConfig, mgr interop.Manager) (*cacheProc, error) {
 		mgr:       mgr,
 		cacheName: cacheName,
 		operator:  op,
+		isGet:     conf.Operator == "get",
 	}, nil
 }
 
@@ -273,6 +278,10 @@ func cacheOperatorFromString(operator string) (cacheOperator, error) {
 //------------------------------------------------------------------------------
 
 func (c *cacheProc) ProcessBatch(ctx context.Context, spans []*tracing.Span, msg *message.Batch) ([]*message.Batch, error) {
+	if c.isGet {
+		return c.processBatchGet(spans, msg)
+	}
+
 	resMsg := msg.Copy()
 	_ = resMsg.Iter(func(index int, part *message.Part) error {
 		key := c.key.String(index, msg)
@@ -316,6 +325,49 @@ func (c *cacheProc) ProcessBatch(ctx context.Context, spans []*tracing.Span, msg
 	return []*message.Batch{resMsg}, nil
 }
 
+// processBatchGet resolves the keys of an entire batch with a single GetMulti
+// call.
+func (c *cacheProc) processBatchGet(spans []*tracing.Span, msg *message.Batch) ([]*message.Batch, error) {
+	resMsg := msg.Copy()
+
+	keys := make([]string, msg.Len())
+	uniqueKeys := make([]string, 0, msg.Len())
+	seen := make(map[string]struct{}, msg.Len())
+	for i := range keys {
+		keys[i] = c.key.String(i, msg)
+		if _, exists := seen[keys[i]]; !exists {
+			seen[keys[i]] = struct{}{}
+			uniqueKeys = append(uniqueKeys, keys[i])
+		}
+	}
+
+	var results map[string][]byte
+	var err error
+	if cerr := c.mgr.AccessCache(context.Background(), c.cacheName, func(cache cache.V1) {
+		results, err = cache.GetMulti(context.Background(), uniqueKeys...)
+	}); cerr != nil {
+		err = cerr
+	}
+
+	_ = resMsg.Iter(func(index int, part *message.Part) error {
+		if err != nil {
+			c.mgr.Logger().Debugf("Operator failed for key '%s': %v\n", keys[index], err)
+			processor.MarkErr(part, spans[index], err)
+			return nil
+		}
+		result, exists := results[keys[index]]
+		if !exists {
+			c.mgr.Logger().Debugf("Operator failed for key '%s': %v\n", keys[index], component.ErrKeyNotFound)
+			processor.MarkErr(part, spans[index], component.ErrKeyNotFound)
+			return nil
+		}
+		part.Set(result)
+		return nil
+	})
+
+	return []*message.Batch{resMsg}, nil
+}
+
 func (c *cacheProc) Close(ctx context.Context) error {
 	return nil
 }
@@ -6,9 +6,13 @@ import (
 	"compress/gzip"
 	"compress/zlib"
 	"context"
+	"errors"
 	"fmt"
+	"os"
+	"sync"
 
 	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
 	"github.com/pierrec/lz4/v4"
 
 	"github.com/benthosdev/benthos/v4/internal/component/metrics"
@@ -35,12 +39,20 @@ func init() {
 		},
 		Summary: `
 Compresses messages according to the selected algorithm. Supported compression
-algorithms are: gzip, zlib, flate, snappy, lz4.`,
+algorithms are: gzip, zlib, flate, snappy, lz4, zstd.`,
 		Description: `
-The 'level' field might not apply to all algorithms.`,
+The 'level' field might not apply to all algorithms.
+
+### Zstandard
+
+When using the ` + "`zstd`" + ` algorithm a dictionary trained on samples of your data, for example with ` + "`zstd --train`" + `, can be specified with the field ` + "`dictionary`" + `, which greatly improves the compression ratio of small messages. Messages compressed with a dictionary can only be decompressed with the same dictionary.
+
+Large messages can be compressed across multiple goroutines by setting ` + "`concurrency`" + ` above one, in which case each message is split into blocks of at least 128KB that are compressed in parallel as independent frames. Messages compressed this way can be decompressed by any zstd decoder.`,
 		Config: docs.FieldComponent().WithChildren(
-			docs.FieldString("algorithm", "The compression algorithm to use.").HasOptions("gzip", "zlib", "flate", "snappy", "lz4"),
+			docs.FieldString("algorithm", "The compression algorithm to use.").HasOptions("gzip", "zlib", "flate", "snappy", "lz4", "zstd"),
 			docs.FieldInt("level", "The level of compression to use. May not be applicable to all algorithms."),
+			docs.FieldString("dictionary", "An optional path to a zstd dictionary file to compress messages with. Only applicable to the `zstd` algorithm.", "./zstd.dict").AtVersion("4.1.0").Advanced(),
+			docs.FieldInt("concurrency", "The number of goroutines used to compress the blocks of each message in parallel. Only applicable to the `zstd` algorithm.").AtVersion("4.1.0").Advanced(),
 		),
 	}
 }
@@ -49,15 +61,19 @@ The 'level' field might not apply to all algorithms.`,
 
 // CompressConfig contains configuration fields for the Compress processor.
 type CompressConfig struct {
-	Algorithm string `json:"algorithm" yaml:"algorithm"`
-	Level     int    `json:"level" yaml:"level"`
+	Algorithm   string `json:"algorithm" yaml:"algorithm"`
+	Level       int    `json:"level" yaml:"level"`
+	Dictionary  string `json:"dictionary" yaml:"dictionary"`
+	Concurrency int    `json:"concurrency" yaml:"concurrency"`
 }
 
 // NewCompressConfig returns a CompressConfig with default values.
 func NewCompressConfig() CompressConfig {
 	return CompressConfig{
-		Algorithm: "",
-		Level:     -1,
+		Algorithm:   "",
+		Level:       -1,
+		Dictionary:  "",
+		Concurrency: 1,
 	}
 }
 
@@ -137,6 +153,78 @@ func lz4Compress(level int, b []byte) ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
+// zstdMinBlockSize is the smallest block that messages are split into when
+// compressing them in parallel.
+const zstdMinBlockSize = 128 * 1024
+
+func readZstdDictionary(path string) ([]byte, error) {
+	if path == "" {
+		return nil, nil
+	}
+	dict, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read zstd dictionary '%v': %w", path, err)
+	}
+	return dict, nil
+}
+
+func newZstdCompressor(level int, dictPath string, concurrency int) (compressFunc, error) {
+	if concurrency < 1 {
+		return nil, errors.New("concurrency must be at least 1")
+	}
+
+	opts := []zstd.EOption{zstd.WithEncoderConcurrency(1)}
+	if level > 0 {
+		opts = append(opts, zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(level)))
+	}
+	dict, err := readZstdDictionary(dictPath)
+	if err != nil {
+		return nil, err
+	}
+	if len(dict) > 0 {
+		opts = append(opts, zstd.WithEncoderDict(dict))
+	}
+
+	// EncodeAll is safe to call concurrently and is therefore able to share a
+	// single encoder across all blocks.
+	enc, err := zstd.NewWriter(nil, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(_ int, b []byte) ([]byte, error) {
+		blockSize := (len(b) + concurrency - 1) / concurrency
+		if blockSize < zstdMinBlockSize {
+			blockSize = zstdMinBlockSize
+		}
+		if len(b) <= blockSize {
+			return enc.EncodeAll(b, nil), nil
+		}
+
+		// Each block is compressed as an independent frame, and since
+		// decoders concatenate the output of consecutive frames the result
+		// can be decompressed as a single message.
+		var blocks [][]byte
+		for len(b) > blockSize {
+			blocks = append(blocks, b[:blockSize])
+			b = b[blockSize:]
+		}
+		blocks = append(blocks, b)
+
+		frames := make([][]byte, len(blocks))
+		var wg sync.WaitGroup
+		wg.Add(len(blocks))
+		for i, block := range blocks {
+			go func(i int, block []byte) {
+				frames[i] = enc.EncodeAll(block, nil)
+				wg.Done()
+			}(i, block)
+		}
+		wg.Wait()
+		return bytes.Join(frames, nil), nil
+	}, nil
+}
+
 func strToCompressor(str string) (compressFunc, error) {
 	switch str {
 	case "gzip":
@@ -162,7 +250,13 @@ type compressProc struct {
 }
 
 func newCompress(conf CompressConfig, mgr interop.Manager) (*compressProc, error) {
-	cor, err := strToCompressor(conf.Algorithm)
+	var cor compressFunc
+	var err error
+	if conf.Algorithm == "zstd" {
+		cor, err = newZstdCompressor(conf.Level, conf.Dictionary, conf.Concurrency)
+	} else {
+		cor, err = strToCompressor(conf.Algorithm)
+	}
 	if err != nil {
 		return nil, err
 	}
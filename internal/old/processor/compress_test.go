@@ -5,11 +5,15 @@ import (
 	"compress/flate"
 	"compress/gzip"
 	"compress/zlib"
+	"os"
 	"reflect"
 	"testing"
 
 	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
 	"github.com/pierrec/lz4/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/benthosdev/benthos/v4/internal/component/metrics"
 	"github.com/benthosdev/benthos/v4/internal/log"
@@ -265,3 +269,123 @@ func TestCompressLZ4(t *testing.T) {
 		t.Errorf("Unexpected output: %s != %s", act, exp)
 	}
 }
+
+func TestCompressZstd(t *testing.T) {
+	dict, err := os.ReadFile("./testdata/zstd.dict")
+	require.NoError(t, err)
+
+	input := [][]byte{
+		[]byte(`{"id":1,"type":"order","customer":{"name":"customer 1","country":"GB"}}`),
+		[]byte(`{"id":2,"type":"refund","customer":{"name":"customer 2","country":"US"}}`),
+		[]byte("5"),
+	}
+
+	tests := []struct {
+		name       string
+		dictionary string
+		decOpts    []zstd.DOption
+	}{
+		{
+			name: "no dictionary",
+		},
+		{
+			name:       "dictionary",
+			dictionary: "./testdata/zstd.dict",
+			decOpts:    []zstd.DOption{zstd.WithDecoderDicts(dict)},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			conf := NewConfig()
+			conf.Type = "compress"
+			conf.Compress.Algorithm = "zstd"
+			conf.Compress.Level = 3
+			conf.Compress.Dictionary = test.dictionary
+
+			proc, err := New(conf, mock.NewManager(), log.Noop(), metrics.Noop())
+			require.NoError(t, err)
+
+			msgs, res := proc.ProcessMessage(message.QuickBatch(input))
+			require.Nil(t, res)
+			require.Len(t, msgs, 1)
+
+			dec, err := zstd.NewReader(nil, test.decOpts...)
+			require.NoError(t, err)
+			defer dec.Close()
+
+			for i, b := range message.GetAllBytes(msgs[0]) {
+				act, err := dec.DecodeAll(b, nil)
+				require.NoError(t, err)
+				assert.Equal(t, string(input[i]), string(act))
+			}
+		})
+	}
+}
+
+func TestCompressZstdDictionaryRequired(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = "compress"
+	conf.Compress.Algorithm = "zstd"
+	conf.Compress.Dictionary = "./testdata/zstd.dict"
+
+	proc, err := New(conf, mock.NewManager(), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	msgs, res := proc.ProcessMessage(message.QuickBatch([][]byte{[]byte(`{"id":1,"type":"order"}`)}))
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+
+	dec, err := zstd.NewReader(nil)
+	require.NoError(t, err)
+	defer dec.Close()
+
+	_, err = dec.DecodeAll(msgs[0].Get(0).Get(), nil)
+	require.Error(t, err)
+}
+
+func TestCompressZstdConcurrency(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = "compress"
+	conf.Compress.Algorithm = "zstd"
+	conf.Compress.Concurrency = 4
+
+	input := bytes.Repeat([]byte("hello world, this is a large message "), 50000)
+
+	proc, err := New(conf, mock.NewManager(), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	msgs, res := proc.ProcessMessage(message.QuickBatch([][]byte{input}))
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+
+	compressed := msgs[0].Get(0).Get()
+	assert.Equal(t, 4, bytes.Count(compressed, []byte{0x28, 0xb5, 0x2f, 0xfd}), "expected a frame per block")
+
+	dec, err := zstd.NewReader(nil)
+	require.NoError(t, err)
+	defer dec.Close()
+
+	act, err := dec.DecodeAll(compressed, nil)
+	require.NoError(t, err)
+	assert.Equal(t, input, act)
+}
+
+func TestCompressZstdBadConfig(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = "compress"
+	conf.Compress.Algorithm = "zstd"
+	conf.Compress.Dictionary = "./testdata/does_not_exist.dict"
+
+	_, err := New(conf, mock.NewManager(), log.Noop(), metrics.Noop())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to read zstd dictionary './testdata/does_not_exist.dict'")
+
+	conf.Compress.Dictionary = ""
+	conf.Compress.Concurrency = 0
+
+	_, err = New(conf, mock.NewManager(), log.Noop(), metrics.Noop())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "concurrency must be at least 1")
+}
@@ -11,6 +11,7 @@ import (
 	"io"
 
 	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
 	"github.com/pierrec/lz4/v4"
 
 	"github.com/benthosdev/benthos/v4/internal/component/metrics"
@@ -37,9 +38,18 @@ func init() {
 		},
 		Summary: `
 Decompresses messages according to the selected algorithm. Supported
-decompression types are: gzip, zlib, bzip2, flate, snappy, lz4.`,
+decompression types are: gzip, zlib, bzip2, flate, snappy, lz4, zstd.`,
+		Description: `
+### Automatic Detection
+
+The algorithm ` + "`auto`" + ` detects the compression of each message individually from its magic bytes, supporting gzip, zstd, lz4 and the snappy framing format. Messages that match none of these are decoded as snappy blocks, which are produced by the ` + "[`compress` processor](/docs/components/processors/compress)" + ` and have no magic bytes.
+
+### Zstandard
+
+Messages compressed with a zstd dictionary can only be decompressed when the same dictionary is specified with the field ` + "`dictionary`" + `, which also applies to zstd messages detected by the ` + "`auto`" + ` algorithm.`,
 		Config: docs.FieldComponent().WithChildren(
-			docs.FieldString("algorithm", "The decompression algorithm to use.").HasOptions("gzip", "zlib", "bzip2", "flate", "snappy", "lz4"),
+			docs.FieldString("algorithm", "The decompression algorithm to use.").HasOptions("gzip", "zlib", "bzip2", "flate", "snappy", "lz4", "zstd", "auto"),
+			docs.FieldString("dictionary", "An optional path to a zstd dictionary file to decompress messages with. Only applicable to the `zstd` and `auto` algorithms.", "./zstd.dict").AtVersion("4.1.0").Advanced(),
 		),
 	}
 }
@@ -48,13 +58,15 @@ decompression types are: gzip, zlib, bzip2, flate, snappy, lz4.`,
 
 // DecompressConfig contains configuration fields for the Decompress processor.
 type DecompressConfig struct {
-	Algorithm string `json:"algorithm" yaml:"algorithm"`
+	Algorithm  string `json:"algorithm" yaml:"algorithm"`
+	Dictionary string `json:"dictionary" yaml:"dictionary"`
 }
 
 // NewDecompressConfig returns a DecompressConfig with default values.
 func NewDecompressConfig() DecompressConfig {
 	return DecompressConfig{
-		Algorithm: "",
+		Algorithm:  "",
+		Dictionary: "",
 	}
 }
 
@@ -130,6 +142,40 @@ func lz4Decompress(b []byte) ([]byte, error) {
 	return outBuf.Bytes(), nil
 }
 
+func snappyFramedDecompress(b []byte) ([]byte, error) {
+	outBuf := bytes.Buffer{}
+	if _, err := outBuf.ReadFrom(snappy.NewReader(bytes.NewReader(b))); err != nil {
+		return nil, err
+	}
+	return outBuf.Bytes(), nil
+}
+
+var (
+	gzipMagic         = []byte{0x1f, 0x8b}
+	zstdMagic         = []byte{0x28, 0xb5, 0x2f, 0xfd}
+	lz4Magic          = []byte{0x04, 0x22, 0x4d, 0x18}
+	snappyFramedMagic = []byte("\xff\x06\x00\x00sNaPpY")
+)
+
+// newAutoDecompressor returns a decompressFunc that detects the compression
+// algorithm of each message from its magic bytes.
+func newAutoDecompressor(zstdDecomp decompressFunc) decompressFunc {
+	return func(b []byte) ([]byte, error) {
+		switch {
+		case bytes.HasPrefix(b, gzipMagic):
+			return gzipDecompress(b)
+		case bytes.HasPrefix(b, zstdMagic):
+			return zstdDecomp(b)
+		case bytes.HasPrefix(b, lz4Magic):
+			return lz4Decompress(b)
+		case bytes.HasPrefix(b, snappyFramedMagic):
+			return snappyFramedDecompress(b)
+		}
+		// Snappy blocks have no magic bytes.
+		return snappyDecompress(b)
+	}
+}
+
 func strToDecompressor(str string) (decompressFunc, error) {
 	switch str {
 	case "gzip":
@@ -152,18 +198,44 @@ func strToDecompressor(str string) (decompressFunc, error) {
 
 type decompressProc struct {
 	decomp decompressFunc
+	zstd   *zstd.Decoder
 	log    log.Modular
 }
 
 func newDecompress(conf DecompressConfig, mgr interop.Manager) (*decompressProc, error) {
-	dcor, err := strToDecompressor(conf.Algorithm)
+	d := &decompressProc{
+		log: mgr.Logger(),
+	}
+
+	if conf.Algorithm != "zstd" && conf.Algorithm != "auto" {
+		var err error
+		if d.decomp, err = strToDecompressor(conf.Algorithm); err != nil {
+			return nil, err
+		}
+		return d, nil
+	}
+
+	var opts []zstd.DOption
+	dict, err := readZstdDictionary(conf.Dictionary)
 	if err != nil {
 		return nil, err
 	}
-	return &decompressProc{
-		decomp: dcor,
-		log:    mgr.Logger(),
-	}, nil
+	if len(dict) > 0 {
+		opts = append(opts, zstd.WithDecoderDicts(dict))
+	}
+	if d.zstd, err = zstd.NewReader(nil, opts...); err != nil {
+		return nil, err
+	}
+
+	zstdDecomp := func(b []byte) ([]byte, error) {
+		return d.zstd.DecodeAll(b, nil)
+	}
+	if conf.Algorithm == "auto" {
+		d.decomp = newAutoDecompressor(zstdDecomp)
+	} else {
+		d.decomp = zstdDecomp
+	}
+	return d, nil
 }
 
 func (d *decompressProc) Process(ctx context.Context, msg *message.Part) ([]*message.Part, error) {
@@ -179,5 +251,8 @@ func (d *decompressProc) Process(ctx context.Context, msg *message.Part) ([]*mes
 }
 
 func (d *decompressProc) Close(context.Context) error {
+	if d.zstd != nil {
+		d.zstd.Close()
+	}
 	return nil
 }
@@ -5,11 +5,15 @@ import (
 	"compress/flate"
 	"compress/gzip"
 	"compress/zlib"
+	"os"
 	"reflect"
 	"testing"
 
 	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
 	"github.com/pierrec/lz4/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/benthosdev/benthos/v4/internal/component/metrics"
 	"github.com/benthosdev/benthos/v4/internal/log"
@@ -268,3 +272,82 @@ func TestDecompressLZ4(t *testing.T) {
 		t.Errorf("Unexpected output: %s != %s", act, exp)
 	}
 }
+
+func TestDecompressZstdDictionary(t *testing.T) {
+	dict, err := os.ReadFile("./testdata/zstd.dict")
+	require.NoError(t, err)
+
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderDict(dict))
+	require.NoError(t, err)
+
+	input := []byte(`{"id":1,"type":"order","customer":{"name":"customer 1","country":"GB"}}`)
+	compressed := enc.EncodeAll(input, nil)
+
+	conf := NewConfig()
+	conf.Type = "decompress"
+	conf.Decompress.Algorithm = "zstd"
+
+	proc, err := New(conf, mock.NewManager(), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	msgs, _ := proc.ProcessMessage(message.QuickBatch([][]byte{compressed}))
+	require.Len(t, msgs, 1)
+	assert.Error(t, msgs[0].Get(0).ErrorGet())
+
+	conf.Decompress.Dictionary = "./testdata/zstd.dict"
+	proc, err = New(conf, mock.NewManager(), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	msgs, _ = proc.ProcessMessage(message.QuickBatch([][]byte{compressed}))
+	require.Len(t, msgs, 1)
+	require.NoError(t, msgs[0].Get(0).ErrorGet())
+	assert.Equal(t, string(input), string(msgs[0].Get(0).Get()))
+}
+
+func TestDecompressAuto(t *testing.T) {
+	raw := []byte("hello world")
+
+	var gzipBuf bytes.Buffer
+	gw := gzip.NewWriter(&gzipBuf)
+	_, err := gw.Write(raw)
+	require.NoError(t, err)
+	require.NoError(t, gw.Close())
+
+	enc, err := zstd.NewWriter(nil)
+	require.NoError(t, err)
+
+	var lz4Buf bytes.Buffer
+	lw := lz4.NewWriter(&lz4Buf)
+	_, err = lw.Write(raw)
+	require.NoError(t, err)
+	require.NoError(t, lw.Close())
+
+	var snappyBuf bytes.Buffer
+	sw := snappy.NewBufferedWriter(&snappyBuf)
+	_, err = sw.Write(raw)
+	require.NoError(t, err)
+	require.NoError(t, sw.Close())
+
+	input := [][]byte{
+		gzipBuf.Bytes(),
+		enc.EncodeAll(raw, nil),
+		lz4Buf.Bytes(),
+		snappyBuf.Bytes(),
+		snappy.Encode(nil, raw),
+	}
+
+	conf := NewConfig()
+	conf.Type = "decompress"
+	conf.Decompress.Algorithm = "auto"
+
+	proc, err := New(conf, mock.NewManager(), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	msgs, res := proc.ProcessMessage(message.QuickBatch(input))
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+	for i, b := range message.GetAllBytes(msgs[0]) {
+		require.NoError(t, msgs[0].Get(i).ErrorGet(), i)
+		assert.Equal(t, string(raw), string(b), i)
+	}
+}
@@ -4,6 +4,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strconv"
+	"sync"
+	"time"
 
 	"github.com/benthosdev/benthos/v4/internal/bloblang/field"
 	"github.com/benthosdev/benthos/v4/internal/component"
@@ -41,15 +44,37 @@ When using this processor with an output target that might fail you should alway
 
 This processor enacts on individual messages only, in order to perform a deduplication on behalf of a batch (or window) of messages instead use the ` + "[`cache` processor](/docs/components/processors/cache#examples)" + `.
 
+## Strategies
+
+The field ` + "`strategy`" + ` determines which messages of a key are kept:
+
+- ` + "`first`" + `: The first message of each key is kept and subsequent messages with a key that exists within the cache are dropped.
+- ` + "`last`" + `: The first message of each key is held back for the duration of ` + "`flush_delay`" + `, during which any subsequent message of the same key replaces it. Once the delay has passed the most recent message of the key is emitted.
+- ` + "`count_only`" + `: No messages are dropped, instead the number of times the key of each message has been seen, including the message itself, is stored within the cache and added to the message as the metadata field ` + "`dedupe_count`" + `.
+
+The ` + "`last`" + ` strategy holds messages in memory and therefore does not use a cache. The acknowledgements of held and replaced messages are deferred until the message emitted for their key is delivered, and therefore messages held when Benthos shuts down are rejected so that they can be redelivered. Since held messages are emitted directly by the pipeline this strategy must not be nested within processors that merge their results back into messages, such as ` + "`branch`" + ` or ` + "`workflow`" + `, and it is not supported by ` + "[unit tests](/docs/configuration/unit_testing)" + `, where held messages are passed through with an error flag.
+
+The ` + "`count_only`" + ` strategy reads and then writes the count of a key as two separate cache operations, and therefore counts can be inaccurate when multiple processors share a cache and concurrently process messages of the same key.
+
+## Metrics
+
+This processor emits the counters ` + "`dedupe_hit`" + `, counting the messages with a key that has been seen before, and ` + "`dedupe_miss`" + `, counting messages with a key that hasn't been seen before.
+
 ## Delivery Guarantees
 
 Performing deduplication on a stream using a distributed cache voids any at-least-once guarantees that it previously had. This is because the cache will preserve message signatures even if the message fails to leave the Benthos pipeline, which would cause message loss in the event of an outage at the output sink followed by a restart of the Benthos instance (or a server crash, etc).
 
 This problem can be mitigated by using an in-memory cache and distributing messages to horizontally scaled Benthos pipelines partitioned by the deduplication key. However, in situations where at-least-once delivery guarantees are important it is worth avoiding deduplication in favour of implement idempotent behaviour at the edge of your stream pipelines.`,
 		Config: docs.FieldComponent().WithChildren(
-			docs.FieldString("cache", "The [`cache` resource](/docs/components/caches/about) to target with this processor."),
+			docs.FieldString("cache", "The [`cache` resource](/docs/components/caches/about) to target with this processor, which is not required for the `last` strategy."),
 			docs.FieldString("key", "An interpolated string yielding the key to deduplicate by for each message.", `${! meta("kafka_key") }`, `${! content().hash("xxhash64") }`).IsInterpolated(),
 			docs.FieldBool("drop_on_err", "Whether messages should be dropped when the cache returns a general error such as a network issue."),
+			docs.FieldString("strategy", "The [strategy](#strategies) determining which messages of a key are kept.").HasAnnotatedOptions(
+				"first", "Keep the first message of each key.",
+				"last", "Keep the last message of each key seen within the `flush_delay`.",
+				"count_only", "Keep all messages and add the number of occurrences of their key as metadata.",
+			).AtVersion("4.1.0"),
+			docs.FieldString("flush_delay", "The period of time that the first message of a key is held back by the `last` strategy, during which it can be replaced by subsequent messages of the same key.", "1s", "1m").AtVersion("4.1.0").Advanced(),
 		),
 		Examples: []docs.AnnotatedExample{
 			{
@@ -66,6 +91,18 @@ cache_resources:
   - label: keycache
     memory:
       default_ttl: 60s
+`,
+			},
+			{
+				Title:   "Keep the latest state",
+				Summary: "The following configuration reduces a stream of frequent device updates to the most recent update of each device every ten seconds.",
+				Config: `
+pipeline:
+  processors:
+    - dedupe:
+        key: ${! json("device_id") }
+        strategy: last
+        flush_delay: 10s
 `,
 			},
 		},
@@ -79,6 +116,8 @@ type DedupeConfig struct {
 	Cache          string `json:"cache" yaml:"cache"`
 	Key            string `json:"key" yaml:"key"`
 	DropOnCacheErr bool   `json:"drop_on_err" yaml:"drop_on_err"`
+	Strategy       string `json:"strategy" yaml:"strategy"`
+	FlushDelay     string `json:"flush_delay" yaml:"flush_delay"`
 }
 
 // NewDedupeConfig returns a DedupeConfig with default values.
@@ -87,18 +126,35 @@ func NewDedupeConfig() DedupeConfig {
 		Cache:          "",
 		Key:            "",
 		DropOnCacheErr: true,
+		Strategy:       "first",
+		FlushDelay:     "1s",
 	}
 }
 
 //------------------------------------------------------------------------------
 
+type heldDedupe struct {
+	part     *message.Part
+	hold     *message.AckHold
+	replaced []*message.AckHold
+	timer    *time.Timer
+}
+
 type dedupeProc struct {
 	log log.Modular
 
-	dropOnErr bool
-	key       *field.Expression
-	mgr       interop.Manager
-	cacheName string
+	dropOnErr  bool
+	key        *field.Expression
+	mgr        interop.Manager
+	cacheName  string
+	strategy   string
+	flushDelay time.Duration
+
+	held    map[string]*heldDedupe
+	heldMut sync.Mutex
+
+	mHit  metrics.StatCounter
+	mMiss metrics.StatCounter
 }
 
 func newDedupe(conf DedupeConfig, mgr interop.Manager) (*dedupeProc, error) {
@@ -110,55 +166,183 @@ func newDedupe(conf DedupeConfig, mgr interop.Manager) (*dedupeProc, error) {
 		return nil, fmt.Errorf("failed to parse key expression: %v", err)
 	}
 
-	if !mgr.ProbeCache(conf.Cache) {
-		return nil, fmt.Errorf("cache resource '%v' was not found", conf.Cache)
-	}
-
-	return &dedupeProc{
+	d := &dedupeProc{
 		log:       mgr.Logger(),
 		dropOnErr: conf.DropOnCacheErr,
 		key:       key,
 		mgr:       mgr,
 		cacheName: conf.Cache,
-	}, nil
+		strategy:  conf.Strategy,
+		held:      map[string]*heldDedupe{},
+		mHit:      mgr.Metrics().GetCounter("dedupe_hit"),
+		mMiss:     mgr.Metrics().GetCounter("dedupe_miss"),
+	}
+
+	switch conf.Strategy {
+	case "first", "count_only":
+		if !mgr.ProbeCache(conf.Cache) {
+			return nil, fmt.Errorf("cache resource '%v' was not found", conf.Cache)
+		}
+	case "last":
+		if d.flushDelay, err = time.ParseDuration(conf.FlushDelay); err != nil {
+			return nil, fmt.Errorf("failed to parse flush_delay: %v", err)
+		}
+		if d.flushDelay <= 0 {
+			return nil, errors.New("flush_delay must be greater than zero")
+		}
+	default:
+		return nil, fmt.Errorf("dedupe strategy not recognised: %v", conf.Strategy)
+	}
+	return d, nil
 }
 
 //------------------------------------------------------------------------------
 
+func (d *dedupeProc) cacheErr(i int, p *message.Part, spans []*tracing.Span, err error) *message.Part {
+	d.log.Errorf("Cache error: %v\n", err)
+	if d.dropOnErr {
+		spans[i].LogKV(
+			"event", "dropped",
+			"type", "deduplicated",
+		)
+		return nil
+	}
+
+	p = p.Copy()
+	processor.MarkErr(p, spans[i], err)
+	return p
+}
+
+func (d *dedupeProc) processFirst(i int, p *message.Part, spans []*tracing.Span, key string) *message.Part {
+	var err error
+	if cerr := d.mgr.AccessCache(context.Background(), d.cacheName, func(cache cache.V1) {
+		err = cache.Add(context.Background(), key, []byte{'t'}, nil)
+	}); cerr != nil {
+		err = cerr
+	}
+	if err != nil {
+		if err == component.ErrKeyAlreadyExists {
+			d.mHit.Incr(1)
+			spans[i].LogKV(
+				"event", "dropped",
+				"type", "deduplicated",
+			)
+			return nil
+		}
+		return d.cacheErr(i, p, spans, err)
+	}
+	d.mMiss.Incr(1)
+	return p
+}
+
+func (d *dedupeProc) processCountOnly(i int, p *message.Part, spans []*tracing.Span, key string) *message.Part {
+	var count int64
+	var err error
+	if cerr := d.mgr.AccessCache(context.Background(), d.cacheName, func(c cache.V1) {
+		var countBytes []byte
+		if countBytes, err = c.Get(context.Background(), key); err == nil {
+			if count, err = strconv.ParseInt(string(countBytes), 10, 64); err != nil {
+				err = fmt.Errorf("failed to parse cached count: %w", err)
+				return
+			}
+		} else if err == component.ErrKeyNotFound {
+			err = nil
+		}
+		if err == nil {
+			err = c.Set(context.Background(), key, []byte(strconv.FormatInt(count+1, 10)), nil)
+		}
+	}); cerr != nil {
+		err = cerr
+	}
+	if err != nil {
+		return d.cacheErr(i, p, spans, err)
+	}
+
+	if count > 0 {
+		d.mHit.Incr(1)
+	} else {
+		d.mMiss.Incr(1)
+	}
+
+	p = p.Copy()
+	p.MetaSet("dedupe_count", strconv.FormatInt(count+1, 10))
+	return p
+}
+
+var errDedupeHoldUnsupported = errors.New("the last strategy cannot hold back messages outside of a pipeline")
+
+// holdLast holds a message back until the flush delay of its key has passed,
+// replacing any message of the same key already being held.
+func (d *dedupeProc) holdLast(i int, p *message.Part, spans []*tracing.Span, key string) *message.Part {
+	hold := message.HoldAck(p)
+	if hold == nil {
+		p = p.Copy()
+		processor.MarkErr(p, spans[i], errDedupeHoldUnsupported)
+		return p
+	}
+
+	d.heldMut.Lock()
+	defer d.heldMut.Unlock()
+
+	if h, exists := d.held[key]; exists {
+		d.mHit.Incr(1)
+		h.replaced = append(h.replaced, h.hold)
+		h.part, h.hold = p.Copy(), hold
+	} else {
+		d.mMiss.Incr(1)
+		d.held[key] = &heldDedupe{
+			part: p.Copy(),
+			hold: hold,
+			timer: time.AfterFunc(d.flushDelay, func() {
+				d.flushLast(key)
+			}),
+		}
+	}
+	spans[i].LogKV(
+		"event", "held",
+		"type", "deduplicated",
+	)
+	return nil
+}
+
+// flushLast emits the most recent message held for a key, the messages it
+// replaced are acknowledged along with it.
+func (d *dedupeProc) flushLast(key string) {
+	d.heldMut.Lock()
+	h, exists := d.held[key]
+	delete(d.held, key)
+	d.heldMut.Unlock()
+	if !exists {
+		return
+	}
+
+	batch := message.QuickBatch(nil)
+	batch.Append(h.part)
+	h.hold.Emit(batch, func(ctx context.Context, err error) error {
+		for _, r := range h.replaced {
+			r.Release(err)
+		}
+		return nil
+	})
+}
+
 func (d *dedupeProc) ProcessBatch(ctx context.Context, spans []*tracing.Span, batch *message.Batch) ([]*message.Batch, error) {
 	newBatch := message.QuickBatch(nil)
 	_ = batch.Iter(func(i int, p *message.Part) error {
 		key := d.key.String(i, batch)
 
-		var err error
-		if cerr := d.mgr.AccessCache(context.Background(), d.cacheName, func(cache cache.V1) {
-			err = cache.Add(context.Background(), key, []byte{'t'}, nil)
-		}); cerr != nil {
-			err = cerr
+		switch d.strategy {
+		case "last":
+			p = d.holdLast(i, p, spans, key)
+		case "count_only":
+			p = d.processCountOnly(i, p, spans, key)
+		default:
+			p = d.processFirst(i, p, spans, key)
 		}
-		if err != nil {
-			if err == component.ErrKeyAlreadyExists {
-				spans[i].LogKV(
-					"event", "dropped",
-					"type", "deduplicated",
-				)
-				return nil
-			}
-
-			d.log.Errorf("Cache error: %v\n", err)
-			if d.dropOnErr {
-				spans[i].LogKV(
-					"event", "dropped",
-					"type", "deduplicated",
-				)
-				return nil
-			}
 
-			p = p.Copy()
-			processor.MarkErr(p, spans[i], err)
+		if p != nil {
+			newBatch.Append(p)
 		}
-
-		newBatch.Append(p)
 		return nil
 	})
 
@@ -169,5 +353,18 @@ func (d *dedupeProc) ProcessBatch(ctx context.Context, spans []*tracing.Span, ba
 }
 
 func (d *dedupeProc) Close(context.Context) error {
+	d.heldMut.Lock()
+	held := d.held
+	d.held = map[string]*heldDedupe{}
+	d.heldMut.Unlock()
+
+	// Messages still being held are rejected so that they can be redelivered.
+	for _, h := range held {
+		h.timer.Stop()
+		h.hold.Release(component.ErrTypeClosed)
+		for _, r := range h.replaced {
+			r.Release(component.ErrTypeClosed)
+		}
+	}
 	return nil
 }
@@ -1,15 +1,20 @@
 package processor
 
 import (
+	"context"
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/benthosdev/benthos/v4/internal/component"
 	"github.com/benthosdev/benthos/v4/internal/component/metrics"
 	"github.com/benthosdev/benthos/v4/internal/log"
 	"github.com/benthosdev/benthos/v4/internal/manager/mock"
 	"github.com/benthosdev/benthos/v4/internal/message"
+	"github.com/benthosdev/benthos/v4/internal/tracing"
 )
 
 func TestDedupe(t *testing.T) {
@@ -95,3 +100,235 @@ func TestDedupeCacheErrors(t *testing.T) {
 	require.NoError(t, err)
 	assert.Len(t, msgs, 1)
 }
+
+func processDedupeForTest(t *testing.T, d *dedupeProc, contents ...string) []string {
+	t.Helper()
+
+	var parts [][]byte
+	for _, c := range contents {
+		parts = append(parts, []byte(c))
+	}
+	batch := message.QuickBatch(parts)
+
+	msgs, err := d.ProcessBatch(context.Background(), tracing.CreateChildSpans("dedupe", batch), batch)
+	require.NoError(t, err)
+
+	output := []string{}
+	for _, m := range msgs {
+		for _, b := range message.GetAllBytes(m) {
+			output = append(output, string(b))
+		}
+	}
+	return output
+}
+
+func TestDedupeCountOnly(t *testing.T) {
+	mgr := mock.NewManager()
+	mgr.Caches["foocache"] = map[string]mock.CacheItem{}
+
+	conf := NewDedupeConfig()
+	conf.Cache = "foocache"
+	conf.Key = `${! json("id") }`
+	conf.Strategy = "count_only"
+
+	d, err := newDedupe(conf, mgr)
+	require.NoError(t, err)
+
+	stats := metrics.NewLocal()
+	d.mHit, d.mMiss = stats.GetCounter("dedupe_hit"), stats.GetCounter("dedupe_miss")
+
+	batch := message.QuickBatch([][]byte{
+		[]byte(`{"id":"a"}`),
+		[]byte(`{"id":"b"}`),
+		[]byte(`{"id":"a"}`),
+	})
+	msgs, err := d.ProcessBatch(context.Background(), tracing.CreateChildSpans("dedupe", batch), batch)
+	require.NoError(t, err)
+	require.Len(t, msgs, 1)
+	require.Equal(t, 3, msgs[0].Len())
+
+	var counts []string
+	_ = msgs[0].Iter(func(i int, p *message.Part) error {
+		counts = append(counts, p.MetaGet("dedupe_count"))
+		return nil
+	})
+	assert.Equal(t, []string{"1", "1", "2"}, counts)
+	assert.Equal(t, map[string]int64{
+		"dedupe_hit":  1,
+		"dedupe_miss": 2,
+	}, stats.GetCounters())
+}
+
+func TestDedupeFirstMetrics(t *testing.T) {
+	mgr := mock.NewManager()
+	mgr.Caches["foocache"] = map[string]mock.CacheItem{}
+
+	conf := NewDedupeConfig()
+	conf.Cache = "foocache"
+	conf.Key = `${! content() }`
+
+	d, err := newDedupe(conf, mgr)
+	require.NoError(t, err)
+
+	stats := metrics.NewLocal()
+	d.mHit, d.mMiss = stats.GetCounter("dedupe_hit"), stats.GetCounter("dedupe_miss")
+
+	assert.Equal(t, []string{"foo", "bar"}, processDedupeForTest(t, d, "foo", "bar", "foo", "foo"))
+	assert.Equal(t, map[string]int64{
+		"dedupe_hit":  2,
+		"dedupe_miss": 2,
+	}, stats.GetCounters())
+}
+
+type dedupeEmitted struct {
+	content string
+	ackFn   func(context.Context, error) error
+}
+
+// holdDedupeForTest processes a batch as a transaction that supports held
+// messages and returns a channel receiving the result of the transaction.
+func holdDedupeForTest(t *testing.T, d *dedupeProc, emitted chan<- dedupeEmitted, contents ...string) <-chan error {
+	t.Helper()
+
+	var parts [][]byte
+	for _, c := range contents {
+		parts = append(parts, []byte(c))
+	}
+
+	holds := message.NewAckHolds(func(b *message.Batch, ackFn func(context.Context, error) error) {
+		emitted <- dedupeEmitted{content: string(b.Get(0).Get()), ackFn: ackFn}
+	})
+	batch := holds.Attach(message.QuickBatch(parts))
+
+	msgs, err := d.ProcessBatch(context.Background(), tracing.CreateChildSpans("dedupe", batch), batch)
+	require.NoError(t, err)
+	require.Empty(t, msgs)
+
+	resChan := make(chan error, 1)
+	require.NoError(t, holds.Seal(func(ctx context.Context, err error) error {
+		resChan <- err
+		return nil
+	})(context.Background(), nil))
+	return resChan
+}
+
+func TestDedupeLast(t *testing.T) {
+	conf := NewDedupeConfig()
+	conf.Key = `${! json("id") }`
+	conf.Strategy = "last"
+	conf.FlushDelay = "100ms"
+
+	d, err := newDedupe(conf, mock.NewManager())
+	require.NoError(t, err)
+
+	stats := metrics.NewLocal()
+	d.mHit, d.mMiss = stats.GetCounter("dedupe_hit"), stats.GetCounter("dedupe_miss")
+
+	emitted := make(chan dedupeEmitted, 10)
+
+	resA := holdDedupeForTest(t, d, emitted, `{"id":"a","v":1}`, `{"id":"b","v":1}`)
+	resB := holdDedupeForTest(t, d, emitted, `{"id":"a","v":2}`)
+	resC := holdDedupeForTest(t, d, emitted, `{"id":"a","v":3}`)
+
+	flushed := map[string]dedupeEmitted{}
+	for i := 0; i < 2; i++ {
+		select {
+		case e := <-emitted:
+			flushed[e.content] = e
+		case <-time.After(time.Second):
+			t.Fatal("timed out")
+		}
+	}
+	require.Contains(t, flushed, `{"id":"a","v":3}`)
+	require.Contains(t, flushed, `{"id":"b","v":1}`)
+
+	assert.Equal(t, map[string]int64{
+		"dedupe_hit":  2,
+		"dedupe_miss": 2,
+	}, stats.GetCounters())
+
+	// Acknowledgements are held until the emitted messages are delivered.
+	select {
+	case <-resA:
+		t.Fatal("unexpected ack")
+	case <-resB:
+		t.Fatal("unexpected ack")
+	case <-resC:
+		t.Fatal("unexpected ack")
+	default:
+	}
+
+	require.NoError(t, flushed[`{"id":"b","v":1}`].ackFn(context.Background(), nil))
+	select {
+	case <-resA:
+		t.Fatal("unexpected ack")
+	default:
+	}
+
+	nackErr := errors.New("nope")
+	require.NoError(t, flushed[`{"id":"a","v":3}`].ackFn(context.Background(), nackErr))
+	for _, res := range []<-chan error{resA, resB, resC} {
+		select {
+		case err := <-res:
+			assert.Equal(t, nackErr, err)
+		case <-time.After(time.Second):
+			t.Fatal("timed out")
+		}
+	}
+}
+
+func TestDedupeLastClose(t *testing.T) {
+	conf := NewDedupeConfig()
+	conf.Key = `${! json("id") }`
+	conf.Strategy = "last"
+	conf.FlushDelay = "1h"
+
+	d, err := newDedupe(conf, mock.NewManager())
+	require.NoError(t, err)
+
+	emitted := make(chan dedupeEmitted, 10)
+
+	resA := holdDedupeForTest(t, d, emitted, `{"id":"a","v":1}`)
+	resB := holdDedupeForTest(t, d, emitted, `{"id":"a","v":2}`)
+
+	require.NoError(t, d.Close(context.Background()))
+	for _, res := range []<-chan error{resA, resB} {
+		select {
+		case err := <-res:
+			assert.Equal(t, component.ErrTypeClosed, err)
+		case <-time.After(time.Second):
+			t.Fatal("timed out")
+		}
+	}
+	assert.Empty(t, emitted)
+}
+
+func TestDedupeLastWithoutHolds(t *testing.T) {
+	conf := NewDedupeConfig()
+	conf.Key = `${! json("id") }`
+	conf.Strategy = "last"
+
+	d, err := newDedupe(conf, mock.NewManager())
+	require.NoError(t, err)
+
+	batch := message.QuickBatch([][]byte{[]byte(`{"id":"a"}`)})
+	msgs, err := d.ProcessBatch(context.Background(), tracing.CreateChildSpans("dedupe", batch), batch)
+	require.NoError(t, err)
+	require.Len(t, msgs, 1)
+	assert.Equal(t, errDedupeHoldUnsupported, msgs[0].Get(0).ErrorGet())
+}
+
+func TestDedupeBadStrategy(t *testing.T) {
+	conf := NewDedupeConfig()
+	conf.Key = `${! json("id") }`
+	conf.Strategy = "nope"
+
+	_, err := newDedupe(conf, mock.NewManager())
+	require.EqualError(t, err, "dedupe strategy not recognised: nope")
+
+	conf.Strategy = "last"
+	conf.FlushDelay = "0s"
+
+	_, err = newDedupe(conf, mock.NewManager())
+	require.EqualError(t, err, "flush_delay must be greater than zero")
+}
@@ -75,6 +75,26 @@ pipeline:
           - '%{VPCFLOWLOG}'
         pattern_definitions:
           VPCFLOWLOG: '%{NUMBER:version:int} %{NUMBER:accountid} %{NOTSPACE:interfaceid} %{NOTSPACE:srcaddr} %{NOTSPACE:dstaddr} %{NOTSPACE:srcport:int} %{NOTSPACE:dstport:int} %{NOTSPACE:protocol:int} %{NOTSPACE:packets:int} %{NOTSPACE:bytes:int} %{NUMBER:start:int} %{NUMBER:end:int} %{NOTSPACE:action} %{NOTSPACE:logstatus}'
+`,
+			},
+			{
+				Title: "Nginx Access Logs",
+				Summary: `
+The [default patterns](#default-patterns) include common log formats, which means access logs written by Nginx with the default ` + "`combined`" + ` format can be parsed without writing any regular expressions. Custom patterns can be added alongside the defaults in order to handle additional fields, such as a request time appended to each line:
+
+` + "```text" + `
+127.0.0.1 - - [23/Apr/2014:22:58:32 +0200] "GET /index.php HTTP/1.1" 404 207 "-" "curl/7.68.0" 0.005
+` + "```" + `
+
+With the following config:`,
+				Config: `
+pipeline:
+  processors:
+    - grok:
+        expressions:
+          - '%{NGINXACCESS}'
+        pattern_definitions:
+          NGINXACCESS: '%{COMBINEDAPACHELOG} %{NUMBER:request_time:float}'
 `,
 			},
 		},
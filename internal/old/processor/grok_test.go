@@ -87,6 +87,15 @@ func TestGrok(t *testing.T) {
 			pattern: "%{WORD:nested.name} %{INT:nested.value:int} bazes from %{IPV4:nested.ipv4}",
 			output:  `{"nested":{"ipv4":"192.0.1.11","name":"foo","value":5}}`,
 		},
+		{
+			name: "Nginx access log with request time",
+			definitions: map[string]string{
+				"NGINXACCESS": "%{COMBINEDAPACHELOG} %{NUMBER:request_time:float}",
+			},
+			input:   `127.0.0.1 - - [23/Apr/2014:22:58:32 +0200] "GET /index.php HTTP/1.1" 404 207 "-" "curl/7.68.0" 0.005`,
+			pattern: "%{NGINXACCESS}",
+			output:  `{"agent":"\"curl/7.68.0\"","auth":"-","bytes":"207","clientip":"127.0.0.1","httpversion":"1.1","ident":"-","referrer":"\"-\"","request":"/index.php","request_time":0.005,"response":"404","timestamp":"23/Apr/2014:22:58:32 +0200","verb":"GET"}`,
+		},
 	}
 
 	for _, test := range tests {
@@ -3,7 +3,10 @@ package processor
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
 
 	jmespath "github.com/jmespath/go-jmespath"
 
@@ -66,11 +69,62 @@ pipeline:
   processors:
     - jmespath:
         query: "locations[?state == 'WA'].name | sort(@) | {Cities: join(', ', @)}"
+`,
+			},
+			{
+				Title: "Multiple Queries",
+				Summary: `
+Multiple fields can be extracted from the same document in a single pass with ` + "`queries`" + `, where custom functions allow us to reuse an expression across those queries. When receiving JSON documents of the form:
+
+` + "```json" + `
+{
+  "user": {"first": "Ada", "last": "Lovelace"},
+  "manager": {"first": "Charles", "last": "Babbage"},
+  "tags": ["maths", "computing"]
+}
+` + "```" + `
+
+We could produce the document:
+
+` + "```json" + `
+{"manager":"Charles Babbage","tag_count":2,"user":"Ada Lovelace"}
+` + "```" + `
+
+With the following config:`,
+				Config: `
+pipeline:
+  processors:
+    - jmespath:
+        functions:
+          full_name: "join(' ', [first, last])"
+        queries:
+          user: full_name(user)
+          manager: full_name(manager)
+          tag_count: length(tags)
 `,
 			},
 		},
+		Footnotes: `
+## Custom Functions
+
+Functions defined within the field ` + "`functions`" + ` are JMESPath expressions that can be called by name from ` + "`query`" + `, ` + "`queries`" + ` and other custom functions. A custom function accepts a single argument, which is the current node (` + "`@`" + `) of its expression, and cannot call itself either directly or indirectly.
+
+Custom functions are compiled into each query that calls them when the processor is created, and therefore calling them has no more overhead than writing their expression out in full.`,
 		Config: docs.FieldComponent().WithChildren(
-			docs.FieldString("query", "The JMESPath query to apply to messages."),
+			docs.FieldString("query", "The JMESPath query to apply to messages. When `queries` are also specified the result of this query must be an object, into which the results of `queries` are added."),
+			docs.FieldString(
+				"queries", "A map of named JMESPath queries to apply to messages, where the result of each query is set as a field of the resulting document under its name. Each query is executed against the original document rather than the result of other queries. When `query` is empty the resulting document is a new object.",
+				map[string]string{
+					"id":    "user.id",
+					"total": "sum(items[*].price)",
+				},
+			).Map().AtVersion("4.1.0"),
+			docs.FieldString(
+				"functions", "A map of custom functions, each defined as a JMESPath expression, that can be called by name from queries.",
+				map[string]string{
+					"full_name": "join(' ', [first, last])",
+				},
+			).Map().AtVersion("4.1.0").Advanced(),
 		),
 	}
 }
@@ -79,35 +133,297 @@ pipeline:
 
 // JMESPathConfig contains configuration fields for the JMESPath processor.
 type JMESPathConfig struct {
-	Query string `json:"query" yaml:"query"`
+	Query     string            `json:"query" yaml:"query"`
+	Queries   map[string]string `json:"queries" yaml:"queries"`
+	Functions map[string]string `json:"functions" yaml:"functions"`
 }
 
 // NewJMESPathConfig returns a JMESPathConfig with default values.
 func NewJMESPathConfig() JMESPathConfig {
 	return JMESPathConfig{
-		Query: "",
+		Query:     "",
+		Queries:   map[string]string{},
+		Functions: map[string]string{},
 	}
 }
 
 //------------------------------------------------------------------------------
 
-type jmespathProc struct {
+type namedJMESPath struct {
+	name  string
 	query *jmespath.JMESPath
-	log   log.Modular
+}
+
+type jmespathProc struct {
+	query   *jmespath.JMESPath
+	queries []namedJMESPath
+	log     log.Modular
 }
 
 func newJMESPath(conf JMESPathConfig, mgr interop.Manager) (processor.V2, error) {
-	query, err := jmespath.Compile(conf.Query)
+	if conf.Query == "" && len(conf.Queries) == 0 {
+		return nil, errors.New("a query or queries must be specified")
+	}
+
+	funcs, err := newJMESPathFunctions(conf.Functions)
 	if err != nil {
-		return nil, fmt.Errorf("failed to compile JMESPath query: %v", err)
+		return nil, err
 	}
+
 	j := &jmespathProc{
-		query: query,
-		log:   mgr.Logger(),
+		log: mgr.Logger(),
+	}
+	if conf.Query != "" {
+		if j.query, err = funcs.compile(conf.Query); err != nil {
+			return nil, fmt.Errorf("failed to compile JMESPath query: %v", err)
+		}
 	}
+	for name, q := range conf.Queries {
+		query, err := funcs.compile(q)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compile JMESPath query '%v': %v", name, err)
+		}
+		j.queries = append(j.queries, namedJMESPath{name: name, query: query})
+	}
+	sort.Slice(j.queries, func(i, k int) bool {
+		return j.queries[i].name < j.queries[k].name
+	})
 	return j, nil
 }
 
+//------------------------------------------------------------------------------
+
+// jmespathFunctions expands calls to custom functions within JMESPath queries.
+// The JMESPath library doesn't support registering functions, and so instead
+// calls are replaced with the expression of the function before compiling.
+type jmespathFunctions map[string]string
+
+func newJMESPathFunctions(defs map[string]string) (jmespathFunctions, error) {
+	funcs := jmespathFunctions{}
+	for name, expr := range defs {
+		if !isJMESPathIdentifier(name) {
+			return nil, fmt.Errorf("invalid JMESPath function name '%v'", name)
+		}
+		if _, err := jmespath.Search(name+"(@)", nil); err == nil || err.Error() != "unknown function: "+name {
+			return nil, fmt.Errorf("JMESPath function '%v' collides with a built-in function", name)
+		}
+		funcs[name] = expr
+	}
+
+	// Expand the bodies of functions up front so that they're only expanded
+	// once, this also detects functions that call themselves.
+	expanded := jmespathFunctions{}
+	var expand func(name string, stack []string) error
+	expand = func(name string, stack []string) error {
+		if _, exists := expanded[name]; exists {
+			return nil
+		}
+		for _, s := range stack {
+			if s == name {
+				return fmt.Errorf("JMESPath function '%v' is recursive", name)
+			}
+		}
+		stack = append(stack, name)
+		for _, dep := range funcs.calledBy(funcs[name]) {
+			if err := expand(dep, stack); err != nil {
+				return err
+			}
+		}
+		body, err := expanded.expand(funcs[name])
+		if err != nil {
+			return fmt.Errorf("failed to expand JMESPath function '%v': %v", name, err)
+		}
+		if _, err := jmespath.Compile(body); err != nil {
+			return fmt.Errorf("failed to compile JMESPath function '%v': %v", name, err)
+		}
+		expanded[name] = body
+		return nil
+	}
+	names := make([]string, 0, len(funcs))
+	for name := range funcs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := expand(name, nil); err != nil {
+			return nil, err
+		}
+	}
+	return expanded, nil
+}
+
+func isJMESPathIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if !isJMESPathIdentifierChar(s[i], i == 0) {
+			return false
+		}
+	}
+	return true
+}
+
+func isJMESPathIdentifierChar(c byte, first bool) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (!first && c >= '0' && c <= '9')
+}
+
+// skipJMESPathQuoted returns the index following a quoted string, raw string
+// or JSON literal that begins at index i.
+func skipJMESPathQuoted(expr string, i int) (int, error) {
+	quote := expr[i]
+	for i++; i < len(expr); i++ {
+		switch expr[i] {
+		case '\\':
+			i++
+		case quote:
+			return i + 1, nil
+		}
+	}
+	return 0, fmt.Errorf("unterminated %c", quote)
+}
+
+// scanCalls walks an expression and calls fn for each call to a custom
+// function, with the byte range of the call and the range of its arguments.
+func (f jmespathFunctions) scanCalls(expr string, fn func(name string, start, argsStart, argsEnd int) error) error {
+	for i := 0; i < len(expr); {
+		c := expr[i]
+		if c == '\'' || c == '"' || c == '`' {
+			next, err := skipJMESPathQuoted(expr, i)
+			if err != nil {
+				return err
+			}
+			i = next
+			continue
+		}
+		if !isJMESPathIdentifierChar(c, true) {
+			i++
+			continue
+		}
+
+		start := i
+		for i < len(expr) && isJMESPathIdentifierChar(expr[i], false) {
+			i++
+		}
+		name := expr[start:i]
+		if _, exists := f[name]; !exists {
+			continue
+		}
+
+		j := i
+		for j < len(expr) && expr[j] == ' ' {
+			j++
+		}
+		if j == len(expr) || expr[j] != '(' {
+			continue
+		}
+
+		argsStart, depth := j+1, 1
+		for j = argsStart; j < len(expr) && depth > 0; {
+			switch expr[j] {
+			case '\'', '"', '`':
+				next, err := skipJMESPathQuoted(expr, j)
+				if err != nil {
+					return err
+				}
+				j = next
+				continue
+			case '(', '[', '{':
+				depth++
+			case ')', ']', '}':
+				depth--
+			}
+			j++
+		}
+		if depth > 0 {
+			return fmt.Errorf("unterminated call to function '%v'", name)
+		}
+		if err := fn(name, start, argsStart, j-1); err != nil {
+			return err
+		}
+		i = j
+	}
+	return nil
+}
+
+// calledBy returns the names of custom functions called within an expression,
+// including those called within arguments.
+func (f jmespathFunctions) calledBy(expr string) []string {
+	var names []string
+	_ = f.scanCalls(expr, func(name string, start, argsStart, argsEnd int) error {
+		names = append(names, name)
+		names = append(names, f.calledBy(expr[argsStart:argsEnd])...)
+		return nil
+	})
+	return names
+}
+
+// expand replaces all calls to custom functions within an expression with
+// the expression of the function, where the argument is piped into it.
+func (f jmespathFunctions) expand(expr string) (string, error) {
+	var b strings.Builder
+	last := 0
+	if err := f.scanCalls(expr, func(name string, start, argsStart, argsEnd int) error {
+		arg := strings.TrimSpace(expr[argsStart:argsEnd])
+		if arg == "" || f.hasTopLevelComma(arg) {
+			return fmt.Errorf("function '%v' expects a single argument", name)
+		}
+		if strings.HasPrefix(arg, "&") {
+			return fmt.Errorf("function '%v' does not accept expression references", name)
+		}
+		arg, err := f.expand(arg)
+		if err != nil {
+			return err
+		}
+
+		// Only identifiers, functions and multi-selects may follow a dot, and
+		// so the expansion is wrapped in not_null, which acts as identity.
+		b.WriteString(expr[last:start])
+		fmt.Fprintf(&b, "not_null((%v) | (%v))", arg, f[name])
+		last = argsEnd + 1
+		return nil
+	}); err != nil {
+		return "", err
+	}
+	b.WriteString(expr[last:])
+	return b.String(), nil
+}
+
+func (f jmespathFunctions) hasTopLevelComma(expr string) bool {
+	depth := 0
+	for i := 0; i < len(expr); {
+		switch expr[i] {
+		case '\'', '"', '`':
+			next, err := skipJMESPathQuoted(expr, i)
+			if err != nil {
+				return false
+			}
+			i = next
+			continue
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			depth--
+		case ',':
+			if depth == 0 {
+				return true
+			}
+		}
+		i++
+	}
+	return false
+}
+
+func (f jmespathFunctions) compile(expr string) (*jmespath.JMESPath, error) {
+	expanded, err := f.expand(expr)
+	if err != nil {
+		return nil, err
+	}
+	return jmespath.Compile(expanded)
+}
+
+//------------------------------------------------------------------------------
+
 func safeSearch(part interface{}, j *jmespath.JMESPath) (res interface{}, err error) {
 	defer func() {
 		if r := recover(); r != nil {
@@ -147,7 +463,7 @@ func clearNumbers(v interface{}) (interface{}, bool) {
 func (p *jmespathProc) Process(ctx context.Context, msg *message.Part) ([]*message.Part, error) {
 	newMsg := msg.Copy()
 
-	jsonPart, err := newMsg.JSON()
+	jsonPart, err := newMsg.JSONMut()
 	if err != nil {
 		p.log.Debugf("Failed to parse part into json: %v\n", err)
 		return nil, err
@@ -157,9 +473,40 @@ func (p *jmespathProc) Process(ctx context.Context, msg *message.Part) ([]*messa
 	}
 
 	var result interface{}
-	if result, err = safeSearch(jsonPart, p.query); err != nil {
-		p.log.Debugf("Failed to search json: %v\n", err)
-		return nil, err
+	if p.query != nil {
+		if result, err = safeSearch(jsonPart, p.query); err != nil {
+			p.log.Debugf("Failed to search json: %v\n", err)
+			return nil, err
+		}
+	}
+
+	if len(p.queries) > 0 {
+		queryObj, ok := result.(map[string]interface{})
+		if result != nil && !ok {
+			err = fmt.Errorf("expected query result to be an object, got: %T", result)
+			p.log.Debugf("Failed to merge query results: %v\n", err)
+			return nil, err
+		}
+
+		// The result of the query may be a reference into the document, and
+		// so all queries are executed before merging their results into a
+		// new object.
+		queryResults := make([]interface{}, len(p.queries))
+		for i, q := range p.queries {
+			if queryResults[i], err = safeSearch(jsonPart, q.query); err != nil {
+				p.log.Debugf("Failed to search json with query '%v': %v\n", q.name, err)
+				return nil, err
+			}
+		}
+
+		resultObj := make(map[string]interface{}, len(queryObj)+len(p.queries))
+		for k, v := range queryObj {
+			resultObj[k] = v
+		}
+		for i, q := range p.queries {
+			resultObj[q.name] = queryResults[i]
+		}
+		result = resultObj
 	}
 
 	newMsg.SetJSON(result)
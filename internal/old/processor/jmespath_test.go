@@ -1,10 +1,14 @@
 package processor
 
 import (
+	"context"
+	"encoding/json"
 	"strconv"
 	"testing"
 
 	"github.com/Jeffail/gabs/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/benthosdev/benthos/v4/internal/component/metrics"
 	"github.com/benthosdev/benthos/v4/internal/log"
@@ -100,6 +104,41 @@ func TestJMESPathMutation(t *testing.T) {
 	}
 }
 
+func TestJMESPathNumbersNotMutated(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = "jmespath"
+	conf.JMESPath.Query = "foo"
+
+	jSet, err := New(conf, mock.NewManager(), log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msgIn := message.QuickBatch([][]byte{[]byte(`{"foo":{"bar":5}}`)})
+	if _, err := msgIn.Get(0).JSON(); err != nil {
+		t.Fatal(err)
+	}
+
+	msgs, res := jSet.ProcessMessage(msgIn)
+	if len(msgs) != 1 {
+		t.Fatal("Wrong count of messages")
+	}
+	if res != nil {
+		t.Fatal("Non-nil result")
+	}
+	if exp, act := `{"bar":5}`, string(msgs[0].Get(0).Get()); exp != act {
+		t.Errorf("Wrong output: %v != %v", act, exp)
+	}
+
+	ogJSON, err := msgIn.Get(0).JSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, isNumber := gabs.Wrap(ogJSON).S("foo", "bar").Data().(json.Number); !isNumber {
+		t.Errorf("Original contents were mutated: %T", gabs.Wrap(ogJSON).S("foo", "bar").Data())
+	}
+}
+
 func TestJMESPath(t *testing.T) {
 	tLog := log.Noop()
 	tStats := metrics.Noop()
@@ -187,3 +226,217 @@ func TestJMESPath(t *testing.T) {
 		}
 	}
 }
+
+func TestJMESPathQueries(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		queries map[string]string
+		input   string
+		output  string
+		errStr  string
+	}{
+		{
+			name: "queries only",
+			queries: map[string]string{
+				"id":    "user.id",
+				"total": "sum(items[*].price)",
+			},
+			input:  `{"user":{"id":"foo"},"items":[{"price":1},{"price":2.5}]}`,
+			output: `{"id":"foo","total":3.5}`,
+		},
+		{
+			name:  "merged into query",
+			query: "user",
+			queries: map[string]string{
+				"id":    "user.id",
+				"count": "length(items)",
+			},
+			input:  `{"user":{"id":"foo","name":"bar"},"items":[1,2]}`,
+			output: `{"count":2,"id":"foo","name":"bar"}`,
+		},
+		{
+			name:  "null query result",
+			query: "nope",
+			queries: map[string]string{
+				"id": "user.id",
+			},
+			input:  `{"user":{"id":"foo"}}`,
+			output: `{"id":"foo"}`,
+		},
+		{
+			name:  "queries read the original document",
+			query: "@",
+			queries: map[string]string{
+				"a": "'set'",
+				"b": "a",
+			},
+			input:  `{"a":"original"}`,
+			output: `{"a":"set","b":"original"}`,
+		},
+		{
+			name:  "query result not an object",
+			query: "user.id",
+			queries: map[string]string{
+				"id": "user.id",
+			},
+			input:  `{"user":{"id":"foo"}}`,
+			errStr: "expected query result to be an object, got: string",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			conf := NewJMESPathConfig()
+			conf.Query = test.query
+			conf.Queries = test.queries
+
+			proc, err := newJMESPath(conf, mock.NewManager())
+			require.NoError(t, err)
+
+			msgs, err := proc.Process(context.Background(), message.NewPart([]byte(test.input)))
+			if test.errStr != "" {
+				require.EqualError(t, err, test.errStr)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, msgs, 1)
+			assert.Equal(t, test.output, string(msgs[0].Get()))
+		})
+	}
+}
+
+func TestJMESPathFunctions(t *testing.T) {
+	functions := map[string]string{
+		"full_name": "join(' ', [first, last])",
+		"shout":     "join('', [@, '!'])",
+		"greeting":  "shout(join(' ', ['hello', full_name(@)]))",
+	}
+
+	tests := []struct {
+		name   string
+		query  string
+		input  string
+		output string
+	}{
+		{
+			name:   "top level",
+			query:  "full_name(@)",
+			input:  `{"first":"foo","last":"bar"}`,
+			output: `"foo bar"`,
+		},
+		{
+			name:   "argument",
+			query:  "full_name(user)",
+			input:  `{"user":{"first":"foo","last":"bar"}}`,
+			output: `"foo bar"`,
+		},
+		{
+			name:   "projection",
+			query:  "users[*].full_name(@)",
+			input:  `{"users":[{"first":"foo","last":"bar"},{"first":"baz","last":"buz"}]}`,
+			output: `["foo bar","baz buz"]`,
+		},
+		{
+			name:   "nested calls",
+			query:  "{a: greeting(user), b: shout(user.first)}",
+			input:  `{"user":{"first":"foo","last":"bar"}}`,
+			output: `{"a":"hello foo bar!","b":"foo!"}`,
+		},
+		{
+			name:   "within literals",
+			query:  "[full_name(@), 'full_name(@)', \"full_name\"]",
+			input:  `{"first":"foo","last":"bar","full_name":"baz"}`,
+			output: `["foo bar","full_name(@)","baz"]`,
+		},
+		{
+			name:   "within queries",
+			query:  "sort_by(users, &first)[*].full_name(@)",
+			input:  `{"users":[{"first":"baz","last":"buz"},{"first":"foo","last":"bar"}]}`,
+			output: `["baz buz","foo bar"]`,
+		},
+		{
+			name:   "built in functions",
+			query:  "length(full_name(@))",
+			input:  `{"first":"foo","last":"bar"}`,
+			output: `7`,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			conf := NewJMESPathConfig()
+			conf.Query = test.query
+			conf.Functions = functions
+
+			proc, err := newJMESPath(conf, mock.NewManager())
+			require.NoError(t, err)
+
+			msgs, err := proc.Process(context.Background(), message.NewPart([]byte(test.input)))
+			require.NoError(t, err)
+			require.Len(t, msgs, 1)
+			assert.Equal(t, test.output, string(msgs[0].Get()))
+		})
+	}
+}
+
+func TestJMESPathFunctionErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		functions map[string]string
+		errStr    string
+	}{
+		{
+			name:      "built in collision",
+			query:     "@",
+			functions: map[string]string{"length": "@"},
+			errStr:    "JMESPath function 'length' collides with a built-in function",
+		},
+		{
+			name:      "invalid name",
+			query:     "@",
+			functions: map[string]string{"foo-bar": "@"},
+			errStr:    "invalid JMESPath function name 'foo-bar'",
+		},
+		{
+			name:      "recursive",
+			query:     "@",
+			functions: map[string]string{"foo": "bar(@)", "bar": "foo(@)"},
+			errStr:    "JMESPath function 'bar' is recursive",
+		},
+		{
+			name:      "bad function",
+			query:     "@",
+			functions: map[string]string{"foo": "foo.["},
+			errStr:    "failed to compile JMESPath function 'foo': SyntaxError: Incomplete expression",
+		},
+		{
+			name:      "too many arguments",
+			query:     "foo(a, b)",
+			functions: map[string]string{"foo": "@"},
+			errStr:    "failed to compile JMESPath query: function 'foo' expects a single argument",
+		},
+		{
+			name:      "expression reference argument",
+			query:     "foo(&bar)",
+			functions: map[string]string{"foo": "@"},
+			errStr:    "failed to compile JMESPath query: function 'foo' does not accept expression references",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			conf := NewJMESPathConfig()
+			conf.Query = test.query
+			conf.Functions = test.functions
+
+			_, err := newJMESPath(conf, mock.NewManager())
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), test.errStr)
+		})
+	}
+}
@@ -50,7 +50,8 @@ differences in how these queries are executed versus the jq cli which you can
 
 If the query does not emit any value then the message is filtered, if the query
 returns multiple values then the resulting message will be an array containing
-all values.
+all values. Alternatively, when the field ` + "`expand`" + ` is set to ` + "`true`" + ` each
+value emitted by the query becomes an individual message of the resulting batch.
 
 The full query syntax is described in [jq's documentation][jq-docs].
 
@@ -99,6 +100,7 @@ pipeline:
 			docs.FieldString("query", "The jq query to filter and transform messages with."),
 			docs.FieldBool("raw", "Whether to process the input as a raw string instead of as JSON.").Advanced(),
 			docs.FieldBool("output_raw", "Whether to output raw text (unquoted) instead of JSON strings when the emitted values are string types.").Advanced(),
+			docs.FieldBool("expand", "Whether to expand each value emitted by the query into an individual message instead of combining multiple values into an array.").Advanced().AtVersion("4.1.0"),
 		),
 	}
 }
@@ -110,6 +112,7 @@ type JQConfig struct {
 	Query     string `json:"query" yaml:"query"`
 	Raw       bool   `json:"raw" yaml:"raw"`
 	OutputRaw bool   `json:"output_raw" yaml:"output_raw"`
+	Expand    bool   `json:"expand" yaml:"expand"`
 }
 
 // NewJQConfig returns a JQConfig with default values.
@@ -128,6 +131,7 @@ var jqCompileOptions = []gojq.CompilerOption{
 type jqProc struct {
 	inRaw  bool
 	outRaw bool
+	expand bool
 	log    log.Modular
 	code   *gojq.Code
 }
@@ -136,6 +140,7 @@ func newJQ(conf JQConfig, mgr interop.Manager) (*jqProc, error) {
 	j := &jqProc{
 		inRaw:  conf.Raw,
 		outRaw: conf.OutputRaw,
+		expand: conf.Expand,
 		log:    mgr.Logger(),
 	}
 
@@ -165,11 +170,7 @@ func (j *jqProc) getPartValue(part *message.Part, raw bool) (obj interface{}, er
 	if raw {
 		return string(part.Get()), nil
 	}
-	obj, err = part.JSON()
-	if err == nil {
-		obj, err = message.CopyJSON(obj)
-	}
-	if err != nil {
+	if obj, err = part.JSONMut(); err != nil {
 		j.log.Debugf("Failed to parse part into json: %v\n", err)
 		return nil, err
 	}
@@ -199,6 +200,10 @@ func (j *jqProc) Process(ctx context.Context, msg *message.Part) ([]*message.Par
 		emitted = append(emitted, out)
 	}
 
+	if j.expand {
+		return j.expandParts(part, emitted)
+	}
+
 	if j.outRaw {
 		raw, err := j.marshalRaw(emitted)
 		if err != nil {
@@ -226,6 +231,28 @@ func (j *jqProc) Process(ctx context.Context, msg *message.Part) ([]*message.Par
 	return []*message.Part{part}, nil
 }
 
+func (j *jqProc) expandParts(part *message.Part, emitted []interface{}) ([]*message.Part, error) {
+	parts := make([]*message.Part, 0, len(emitted))
+	for _, v := range emitted {
+		newPart := part.Copy()
+		if j.outRaw {
+			raw, err := j.marshalRaw([]interface{}{v})
+			if err != nil {
+				j.log.Debugf("Failed to marshal raw text: %s", err)
+				return nil, err
+			}
+			newPart.Set(raw)
+		} else {
+			newPart.SetJSON(v)
+		}
+		parts = append(parts, newPart)
+	}
+	if len(parts) == 0 {
+		return nil, nil
+	}
+	return parts, nil
+}
+
 func (*jqProc) Close(ctx context.Context) error {
 	return nil
 }
@@ -259,3 +259,29 @@ func TestJQ_OutputRaw(t *testing.T) {
 		})
 	}
 }
+
+func TestJQExpand(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = "jq"
+	conf.JQ.Query = `.items[] | {id: ., source: $metadata.source}`
+	conf.JQ.Expand = true
+
+	jSet, err := New(conf, mock.NewManager(), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	msgIn := message.QuickBatch([][]byte{
+		[]byte(`{"items":["a","b","c"]}`),
+		[]byte(`{"items":[]}`),
+	})
+	msgIn.Get(0).MetaSet("source", "foo")
+
+	msgs, res := jSet.ProcessMessage(msgIn)
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+	assert.Equal(t, [][]byte{
+		[]byte(`{"id":"a","source":"foo"}`),
+		[]byte(`{"id":"b","source":"foo"}`),
+		[]byte(`{"id":"c","source":"foo"}`),
+	}, message.GetAllBytes(msgs[0]))
+	assert.Equal(t, "foo", msgs[0].Get(2).MetaGet("source"))
+}
@@ -36,6 +36,8 @@ Custom metrics such as these are emitted along with Benthos internal metrics, wh
 				"counter_by",
 				"gauge",
 				"timing",
+				"histogram",
+				"summary",
 			),
 			docs.FieldString("name", "The name of the metric to create, this must be unique across all Benthos components otherwise it will overwrite those other metrics."),
 			docs.FieldString(
@@ -46,6 +48,14 @@ Custom metrics such as these are emitted along with Benthos internal metrics, wh
 				},
 			).IsInterpolated().Map(),
 			docs.FieldString("value", "For some metric types specifies a value to set, increment.").IsInterpolated(),
+			docs.FieldFloat("buckets", "The upper bounds of the buckets of a `histogram` metric. If left empty the default buckets of the metrics exporter are used.", []float64{0.01, 0.1, 1, 10}).Array().HasDefault([]interface{}{}).Advanced().AtVersion("4.1.0"),
+			docs.FieldObject("objectives", "The quantile objectives of a `summary` metric, where each quantile is paired with its allowed absolute error. If left empty the default objectives of the metrics exporter are used.", []interface{}{
+				map[string]interface{}{"quantile": 0.5, "error": 0.05},
+				map[string]interface{}{"quantile": 0.99, "error": 0.001},
+			}).Array().WithChildren(
+				docs.FieldFloat("quantile", "The quantile to track, between 0 and 1.").HasDefault(0.5),
+				docs.FieldFloat("error", "The absolute error allowed for the quantile.").HasDefault(0.05),
+			).HasDefault([]interface{}{}).Advanced().AtVersion("4.1.0"),
 		),
 		Examples: []docs.AnnotatedExample{
 			{
@@ -136,7 +146,29 @@ pipeline:
 
 ### ` + "`timing`" + `
 
-Equivalent to ` + "`gauge`" + ` where instead the metric is a timing. It is recommended that timing values are recorded in nanoseconds in order to be consistent with standard Benthos timing metrics, as in some cases these values are automatically converted into other units such as when exporting timings as histograms with Prometheus metrics.`,
+Equivalent to ` + "`gauge`" + ` where instead the metric is a timing. It is recommended that timing values are recorded in nanoseconds in order to be consistent with standard Benthos timing metrics, as in some cases these values are automatically converted into other units such as when exporting timings as histograms with Prometheus metrics.
+
+### ` + "`histogram`" + `
+
+If the contents of ` + "`value`" + ` can be parsed as a number then it is observed by a histogram with the bucket upper bounds specified by the field ` + "`buckets`" + `. Unlike ` + "`timing`" + ` values are recorded as they are, and therefore floating point values such as durations in seconds or message sizes are supported.
+
+For example, the following configuration will record the size of documents into a histogram:
+
+` + "```yaml" + `
+pipeline:
+  processors:
+    - metric:
+        type: histogram
+        name: DocumentSize
+        value: ${! content().length() }
+        buckets: [ 100, 1000, 10000, 100000 ]
+` + "```" + `
+
+### ` + "`summary`" + `
+
+Equivalent to ` + "`histogram`" + ` where instead the value is observed by a summary that tracks the quantiles specified by the field ` + "`objectives`" + `.
+
+Histograms and summaries are only supported natively by some metrics exporters (such as ` + "`prometheus`" + `), for all other exporters observed values are truncated to integers and emitted as timings.`,
 	}
 }
 
@@ -144,19 +176,29 @@ Equivalent to ` + "`gauge`" + ` where instead the metric is a timing. It is reco
 
 // MetricConfig contains configuration fields for the Metric processor.
 type MetricConfig struct {
-	Type   string            `json:"type" yaml:"type"`
-	Name   string            `json:"name" yaml:"name"`
-	Labels map[string]string `json:"labels" yaml:"labels"`
-	Value  string            `json:"value" yaml:"value"`
+	Type       string                  `json:"type" yaml:"type"`
+	Name       string                  `json:"name" yaml:"name"`
+	Labels     map[string]string       `json:"labels" yaml:"labels"`
+	Value      string                  `json:"value" yaml:"value"`
+	Buckets    []float64               `json:"buckets" yaml:"buckets"`
+	Objectives []MetricObjectiveConfig `json:"objectives" yaml:"objectives"`
+}
+
+// MetricObjectiveConfig describes a quantile objective of a summary metric.
+type MetricObjectiveConfig struct {
+	Quantile float64 `json:"quantile" yaml:"quantile"`
+	Error    float64 `json:"error" yaml:"error"`
 }
 
 // NewMetricConfig returns a MetricConfig with default values.
 func NewMetricConfig() MetricConfig {
 	return MetricConfig{
-		Type:   "",
-		Name:   "",
-		Labels: map[string]string{},
-		Value:  "",
+		Type:       "",
+		Name:       "",
+		Labels:     map[string]string{},
+		Value:      "",
+		Buckets:    []float64{},
+		Objectives: []MetricObjectiveConfig{},
 	}
 }
 
@@ -179,6 +221,8 @@ type Metric struct {
 	mGaugeVec   metrics.StatGaugeVec
 	mTimerVec   metrics.StatTimerVec
 
+	mObserverVec metrics.StatObserverVec
+
 	handler func(string, int, *message.Batch) error
 }
 
@@ -275,6 +319,25 @@ func NewMetric(
 			m.mTimer = stats.GetTimer(name)
 		}
 		m.handler = m.handleTimer
+	case "histogram":
+		buckets := conf.Metric.Buckets
+		for i := 1; i < len(buckets); i++ {
+			if buckets[i] <= buckets[i-1] {
+				return nil, errors.New("histogram buckets must be in increasing order")
+			}
+		}
+		m.mObserverVec = metrics.GetHistogramVec(stats, name, buckets, m.labels.names()...)
+		m.handler = m.handleObserver
+	case "summary":
+		objectives := make(map[float64]float64, len(conf.Metric.Objectives))
+		for _, o := range conf.Metric.Objectives {
+			if o.Quantile < 0 || o.Quantile > 1 {
+				return nil, fmt.Errorf("summary objective quantile must be between 0 and 1, got %v", o.Quantile)
+			}
+			objectives[o.Quantile] = o.Error
+		}
+		m.mObserverVec = metrics.GetSummaryVec(stats, name, objectives, m.labels.names()...)
+		m.handler = m.handleObserver
 	default:
 		return nil, fmt.Errorf("metric type unrecognised: %v", conf.Metric.Type)
 	}
@@ -339,6 +402,15 @@ func (m *Metric) handleTimer(val string, index int, msg *message.Batch) error {
 	return nil
 }
 
+func (m *Metric) handleObserver(val string, index int, msg *message.Batch) error {
+	f, err := strconv.ParseFloat(val, 64)
+	if err != nil {
+		return err
+	}
+	m.mObserverVec.With(m.labels.values(index, msg)...).Observe(f)
+	return nil
+}
+
 // ProcessMessage applies the processor to a message
 func (m *Metric) ProcessMessage(msg *message.Batch) ([]*message.Batch, error) {
 	_ = iterateParts(nil, msg, func(index int, p *message.Part) error {
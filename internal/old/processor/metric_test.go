@@ -214,3 +214,56 @@ func TestMetricTiming(t *testing.T) {
 
 	assert.Equal(t, expTimingAvgs, actTimingAvgs)
 }
+
+func TestMetricHistogramFallback(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = "metric"
+	conf.Metric.Type = "histogram"
+	conf.Metric.Name = "foo.bar"
+	conf.Metric.Value = "${!json(\"foo.bar\")}"
+	conf.Metric.Buckets = []float64{1, 10, 100}
+
+	mockMetrics := metrics.NewLocal()
+
+	proc, err := New(conf, mock.NewManager(), log.Noop(), mockMetrics)
+	require.NoError(t, err)
+
+	inputs := [][][]byte{
+		{
+			[]byte(`{"foo":{"bar":5.5}}`),
+			[]byte(`{}`),
+		},
+		{
+			[]byte(`{"foo":{"bar":"hello world"}}`),
+			[]byte(`{"foo":{"bar":7}}`),
+		},
+	}
+
+	for _, i := range inputs {
+		msg, res := proc.ProcessMessage(message.QuickBatch(i))
+		assert.Len(t, msg, 1)
+		assert.Nil(t, res)
+	}
+
+	timings := mockMetrics.FlushTimings()
+	require.Contains(t, timings, "foo.bar")
+	assert.Equal(t, int64(2), timings["foo.bar"].Count())
+	assert.Equal(t, int64(12), timings["foo.bar"].Sum())
+}
+
+func TestMetricSummaryBadObjectives(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = "metric"
+	conf.Metric.Type = "summary"
+	conf.Metric.Name = "foo.bar"
+	conf.Metric.Objectives = []MetricObjectiveConfig{
+		{Quantile: 1.5, Error: 0.01},
+	}
+	_, err := New(conf, mock.NewManager(), log.Noop(), metrics.Noop())
+	require.Error(t, err)
+
+	conf.Metric.Type = "histogram"
+	conf.Metric.Buckets = []float64{10, 1}
+	_, err = New(conf, mock.NewManager(), log.Noop(), metrics.Noop())
+	require.Error(t, err)
+}
@@ -18,6 +18,8 @@ import (
 	"github.com/golang/protobuf/jsonpb"
 	// nolint:staticcheck // Ignore SA1019 deprecation warning until we can switch to "google.golang.org/protobuf/types/dynamicpb"
 	"github.com/golang/protobuf/proto"
+	// nolint:staticcheck // Ignore SA1019 deprecation warning until we can switch to "google.golang.org/protobuf/types/descriptorpb"
+	dpb "github.com/golang/protobuf/protoc-gen-go/descriptor"
 
 	"github.com/jhump/protoreflect/desc"
 	"github.com/jhump/protoreflect/desc/protoparse"
@@ -63,11 +65,20 @@ to manipulate the contents of the document within Benthos.
 
 ### ` + "`from_json`" + `
 
-Attempts to create a target protobuf message from a generic JSON structure.`,
+Attempts to create a target protobuf message from a generic JSON structure.
+
+## Descriptor Sets
+
+As an alternative to parsing .proto files it is possible to provide compiled
+` + "`FileDescriptorSet`" + ` files, which can be generated with
+` + "`protoc --include_imports --descriptor_set_out=schema.pb`" + `. All messages
+found within both descriptor sets and import paths can be resolved when
+converting ` + "`google.protobuf.Any`" + ` fields.`,
 		Config: docs.FieldComponent().WithChildren(
 			docs.FieldString("operator", "The [operator](#operators) to execute").HasOptions("to_json", "from_json"),
 			docs.FieldString("message", "The fully qualified name of the protobuf message to convert to/from."),
 			docs.FieldString("import_paths", "A list of directories containing .proto files, including all definitions required for parsing the target message. If left empty the current directory is used. Each directory listed will be walked with all found .proto files imported.").Array(),
+			docs.FieldString("descriptor_sets", "A list of paths to compiled `FileDescriptorSet` files containing definitions of the target message. When descriptor sets are provided and `import_paths` is empty no .proto files are parsed.").Array().AtVersion("4.1.0"),
 		),
 		Examples: []docs.AnnotatedExample{
 			{
@@ -164,17 +175,19 @@ pipeline:
 
 // ProtobufConfig contains configuration fields for the Protobuf processor.
 type ProtobufConfig struct {
-	Operator    string   `json:"operator" yaml:"operator"`
-	Message     string   `json:"message" yaml:"message"`
-	ImportPaths []string `json:"import_paths" yaml:"import_paths"`
+	Operator       string   `json:"operator" yaml:"operator"`
+	Message        string   `json:"message" yaml:"message"`
+	ImportPaths    []string `json:"import_paths" yaml:"import_paths"`
+	DescriptorSets []string `json:"descriptor_sets" yaml:"descriptor_sets"`
 }
 
 // NewProtobufConfig returns a ProtobufConfig with default values.
 func NewProtobufConfig() ProtobufConfig {
 	return ProtobufConfig{
-		Operator:    "",
-		Message:     "",
-		ImportPaths: []string{},
+		Operator:       "",
+		Message:        "",
+		ImportPaths:    []string{},
+		DescriptorSets: []string{},
 	}
 }
 
@@ -182,19 +195,19 @@ func NewProtobufConfig() ProtobufConfig {
 
 type protobufOperator func(part *message.Part) error
 
-func newProtobufToJSONOperator(msg string, importPaths []string) (protobufOperator, error) {
+func newProtobufToJSONOperator(msg string, importPaths, descriptorSets []string) (protobufOperator, error) {
 	if msg == "" {
 		return nil, errors.New("message field must not be empty")
 	}
 
-	descriptors, err := loadDescriptors(importPaths)
+	descriptors, err := loadAllDescriptors(importPaths, descriptorSets)
 	if err != nil {
 		return nil, err
 	}
 
 	m := getMessageFromDescriptors(msg, descriptors)
 	if m == nil {
-		return nil, fmt.Errorf("unable to find message '%v' definition within '%v'", msg, importPaths)
+		return nil, fmt.Errorf("unable to find message '%v' definition within '%v'", msg, descriptorSources(importPaths, descriptorSets))
 	}
 
 	marshaller := &jsonpb.Marshaler{
@@ -217,19 +230,19 @@ func newProtobufToJSONOperator(msg string, importPaths []string) (protobufOperat
 	}, nil
 }
 
-func newProtobufFromJSONOperator(msg string, importPaths []string) (protobufOperator, error) {
+func newProtobufFromJSONOperator(msg string, importPaths, descriptorSets []string) (protobufOperator, error) {
 	if msg == "" {
 		return nil, errors.New("message field must not be empty")
 	}
 
-	descriptors, err := loadDescriptors(importPaths)
+	descriptors, err := loadAllDescriptors(importPaths, descriptorSets)
 	if err != nil {
 		return nil, err
 	}
 
 	m := getMessageFromDescriptors(msg, descriptors)
 	if m == nil {
-		return nil, fmt.Errorf("unable to find message '%v' definition within '%v'", msg, importPaths)
+		return nil, fmt.Errorf("unable to find message '%v' definition within '%v'", msg, descriptorSources(importPaths, descriptorSets))
 	}
 
 	unmarshaler := &jsonpb.Unmarshaler{
@@ -252,16 +265,72 @@ func newProtobufFromJSONOperator(msg string, importPaths []string) (protobufOper
 	}, nil
 }
 
-func strToProtobufOperator(opStr, message string, importPaths []string) (protobufOperator, error) {
+func strToProtobufOperator(opStr, message string, importPaths, descriptorSets []string) (protobufOperator, error) {
 	switch opStr {
 	case "to_json":
-		return newProtobufToJSONOperator(message, importPaths)
+		return newProtobufToJSONOperator(message, importPaths, descriptorSets)
 	case "from_json":
-		return newProtobufFromJSONOperator(message, importPaths)
+		return newProtobufFromJSONOperator(message, importPaths, descriptorSets)
 	}
 	return nil, fmt.Errorf("operator not recognised: %v", opStr)
 }
 
+// descriptorSources returns the import paths and descriptor sets that
+// descriptors are loaded from, for use in error messages.
+func descriptorSources(importPaths, descriptorSets []string) []string {
+	sources := make([]string, 0, len(importPaths)+len(descriptorSets))
+	sources = append(sources, importPaths...)
+	return append(sources, descriptorSets...)
+}
+
+func loadAllDescriptors(importPaths, descriptorSets []string) ([]*desc.FileDescriptor, error) {
+	var fds []*desc.FileDescriptor
+	for _, path := range descriptorSets {
+		setFds, err := loadDescriptorSet(path)
+		if err != nil {
+			return nil, err
+		}
+		fds = append(fds, setFds...)
+	}
+	if len(descriptorSets) > 0 && len(importPaths) == 0 {
+		return fds, nil
+	}
+
+	protoFds, err := loadDescriptors(importPaths)
+	if err != nil {
+		return nil, err
+	}
+	return append(fds, protoFds...), nil
+}
+
+func loadDescriptorSet(path string) ([]*desc.FileDescriptor, error) {
+	setBytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read descriptor set '%v': %w", path, err)
+	}
+
+	var set dpb.FileDescriptorSet
+	if err := proto.Unmarshal(setBytes, &set); err != nil {
+		return nil, fmt.Errorf("failed to parse descriptor set '%v': %w", path, err)
+	}
+
+	fdMap, err := desc.CreateFileDescriptorsFromSet(&set)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve descriptor set '%v': %w", path, err)
+	}
+	if len(fdMap) == 0 {
+		return nil, fmt.Errorf("no file descriptors were found in descriptor set '%v'", path)
+	}
+
+	fds := make([]*desc.FileDescriptor, 0, len(fdMap))
+	for _, fdProto := range set.File {
+		if fd, exists := fdMap[fdProto.GetName()]; exists {
+			fds = append(fds, fd)
+		}
+	}
+	return fds, nil
+}
+
 func loadDescriptors(importPaths []string) ([]*desc.FileDescriptor, error) {
 	var parser protoparse.Parser
 	if len(importPaths) == 0 {
@@ -323,7 +392,7 @@ func newProtobuf(conf ProtobufConfig, mgr interop.Manager) (*protobufProc, error
 		log: mgr.Logger(),
 	}
 	var err error
-	if p.operator, err = strToProtobufOperator(conf.Operator, conf.Message, conf.ImportPaths); err != nil {
+	if p.operator, err = strToProtobufOperator(conf.Operator, conf.Message, conf.ImportPaths, conf.DescriptorSets); err != nil {
 		return nil, err
 	}
 	return p, nil
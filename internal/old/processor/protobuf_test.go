@@ -1,8 +1,14 @@
 package processor
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 
+	// nolint:staticcheck // Ignore SA1019 deprecation warning until we can switch to "google.golang.org/protobuf/types/dynamicpb"
+	"github.com/golang/protobuf/proto"
+	"github.com/jhump/protoreflect/desc"
+
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -138,6 +144,78 @@ func TestProtobuf(t *testing.T) {
 	}
 }
 
+func TestProtobufDescriptorSet(t *testing.T) {
+	fds, err := loadDescriptors([]string{"../../../config/test/protobuf/schema"})
+	require.NoError(t, err)
+
+	setBytes, err := proto.Marshal(desc.ToFileDescriptorSet(fds...))
+	require.NoError(t, err)
+
+	setPath := filepath.Join(t.TempDir(), "schema.pb")
+	require.NoError(t, os.WriteFile(setPath, setBytes, 0o644))
+
+	conf := NewConfig()
+	conf.Type = TypeProtobuf
+	conf.Protobuf.Operator = "from_json"
+	conf.Protobuf.Message = "testing.Envelope"
+	conf.Protobuf.DescriptorSets = []string{setPath}
+
+	proc, err := New(conf, mock.NewManager(), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	conf.Protobuf.Operator = "to_json"
+	reverseProc, err := New(conf, mock.NewManager(), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	input := `{"id":747,"content":{"@type":"type.googleapis.com/testing.Person","firstName":"bob"}}`
+
+	msgs, res := proc.ProcessMessage(message.QuickBatch([][]byte{[]byte(input)}))
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+	require.NoError(t, msgs[0].Get(0).ErrorGet())
+
+	msgs, res = reverseProc.ProcessMessage(msgs[0])
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+	require.NoError(t, msgs[0].Get(0).ErrorGet())
+
+	assert.Equal(t, input, string(msgs[0].Get(0).Get()))
+}
+
+func TestProtobufDescriptorSetErrors(t *testing.T) {
+	setPath := filepath.Join(t.TempDir(), "schema.pb")
+	require.NoError(t, os.WriteFile(setPath, []byte("not a descriptor set"), 0o644))
+
+	conf := NewConfig()
+	conf.Type = TypeProtobuf
+	conf.Protobuf.Operator = "to_json"
+	conf.Protobuf.Message = "testing.Person"
+	conf.Protobuf.DescriptorSets = []string{setPath}
+
+	_, err := New(conf, mock.NewManager(), log.Noop(), metrics.Noop())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to parse descriptor set")
+
+	missingPath := filepath.Join(t.TempDir(), "missing.pb")
+	conf.Protobuf.DescriptorSets = []string{missingPath}
+
+	_, err = New(conf, mock.NewManager(), log.Noop(), metrics.Noop())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), missingPath)
+}
+
+func TestProtobufDescriptorSourcesCopy(t *testing.T) {
+	importPaths := make([]string, 1, 2)
+	importPaths[0] = "foo"
+
+	sources := descriptorSources(importPaths, []string{"bar"})
+	assert.Equal(t, []string{"foo", "bar"}, sources)
+
+	sources[0] = "baz"
+	assert.Equal(t, []string{"foo"}, importPaths)
+	assert.Equal(t, "", importPaths[:2][1])
+}
+
 func TestProtobufErrors(t *testing.T) {
 	type testCase struct {
 		name       string
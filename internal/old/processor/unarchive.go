@@ -10,6 +10,9 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
 
 	"github.com/benthosdev/benthos/v4/internal/component/metrics"
 	"github.com/benthosdev/benthos/v4/internal/component/processor"
@@ -42,12 +45,12 @@ the batch. Messages that are selected but fail to unarchive (invalid format)
 will remain unchanged in the message batch but will be flagged as having failed,
 allowing you to [error handle them](/docs/configuration/error_handling).
 
-For the unarchive formats that contain file information (tar, zip), a metadata
+For the unarchive formats that contain file information (tar, tar_zstd, zip), a metadata
 field is added to each message called ` + "`archive_filename`" + ` with the
 extracted filename.`,
 		Config: docs.FieldComponent().WithChildren(
 			docs.FieldString("format", "The unarchive [format](#formats) to use.").HasOptions(
-				"tar", "zip", "binary", "lines", "json_documents", "json_array", "json_map", "csv",
+				"tar", "tar_zstd", "zip", "binary", "lines", "json_lines", "json_documents", "json_array", "json_map", "csv",
 			),
 		),
 		Footnotes: `
@@ -57,6 +60,10 @@ extracted filename.`,
 
 Extract messages from a unix standard tape archive.
 
+### ` + "`tar_zstd`" + `
+
+Extract messages from a unix standard tape archive compressed with zstd.
+
 ### ` + "`zip`" + `
 
 Extract messages from a zip file.
@@ -70,10 +77,20 @@ Extract messages from a binary blob format consisting of:
   + Four bytes containing the length of the message (in big endian)
   + The content of message
 
+Blobs created with the ` + "`binary_v2`" + ` format of the
+` + "[`archive` processor](/docs/components/processors/archive)" + ` are also
+supported, in which case the metadata, error flags and structured contents of
+each message are restored.
+
 ### ` + "`lines`" + `
 
 Extract the lines of a message each into their own message.
 
+### ` + "`json_lines`" + `
+
+Attempt to parse each line of a message as a JSON document, and extract each
+into its own message. Empty lines are skipped.
+
 ### ` + "`json_documents`" + `
 
 Attempt to parse a message as a stream of concatenated JSON documents. Each
@@ -147,6 +164,25 @@ func tarUnarchive(part *message.Part) ([]*message.Part, error) {
 	return newParts, nil
 }
 
+var (
+	unarchiveZstdDecOnce sync.Once
+	unarchiveZstdDec     *zstd.Decoder
+)
+
+func tarZstdUnarchive(part *message.Part) ([]*message.Part, error) {
+	unarchiveZstdDecOnce.Do(func() {
+		unarchiveZstdDec, _ = zstd.NewReader(nil)
+	})
+	tarBytes, err := unarchiveZstdDec.DecodeAll(part.Get(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress archive: %v", err)
+	}
+
+	tarPart := part.Copy()
+	tarPart.Set(tarBytes)
+	return tarUnarchive(tarPart)
+}
+
 func zipUnarchive(part *message.Part) ([]*message.Part, error) {
 	buf := bytes.NewReader(part.Get())
 	zr, err := zip.NewReader(buf, int64(buf.Len()))
@@ -184,8 +220,18 @@ func binaryUnarchive(part *message.Part) ([]*message.Part, error) {
 	}
 	parts := make([]*message.Part, msg.Len())
 	_ = msg.Iter(func(i int, p *message.Part) error {
-		newPart := part.Copy()
-		newPart.Set(p.Get())
+		// Metadata and errors of the archive are inherited unless the message
+		// carries its own, which is only possible with the V2 format.
+		newPart := p.WithContext(part.GetContext())
+		_ = part.MetaIter(func(k, v string) error {
+			if newPart.MetaGet(k) == "" {
+				newPart.MetaSet(k, v)
+			}
+			return nil
+		})
+		if newPart.ErrorGet() == nil {
+			newPart.ErrorSet(part.ErrorGet())
+		}
 		parts[i] = newPart
 		return nil
 	})
@@ -204,6 +250,23 @@ func linesUnarchive(part *message.Part) ([]*message.Part, error) {
 	return parts, nil
 }
 
+func jsonLinesUnarchive(part *message.Part) ([]*message.Part, error) {
+	var parts []*message.Part
+	for i, l := range bytes.Split(part.Get(), []byte("\n")) {
+		if len(bytes.TrimSpace(l)) == 0 {
+			continue
+		}
+		var m interface{}
+		if err := json.Unmarshal(l, &m); err != nil {
+			return nil, fmt.Errorf("failed to parse line %v as JSON: %v", i+1, err)
+		}
+		newPart := part.Copy()
+		newPart.SetJSON(m)
+		parts = append(parts, newPart)
+	}
+	return parts, nil
+}
+
 func jsonDocumentsUnarchive(part *message.Part) ([]*message.Part, error) {
 	var parts []*message.Part
 	dec := json.NewDecoder(bytes.NewReader(part.Get()))
@@ -320,12 +383,16 @@ func strToUnarchiver(str string) (unarchiveFunc, error) {
 	switch str {
 	case "tar":
 		return tarUnarchive, nil
+	case "tar_zstd":
+		return tarZstdUnarchive, nil
 	case "zip":
 		return zipUnarchive, nil
 	case "binary":
 		return binaryUnarchive, nil
 	case "lines":
 		return linesUnarchive, nil
+	case "json_lines":
+		return jsonLinesUnarchive, nil
 	case "json_documents":
 		return jsonDocumentsUnarchive, nil
 	case "json_array":
@@ -9,7 +9,9 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/klauspost/compress/zstd"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/benthosdev/benthos/v4/internal/component/metrics"
 	"github.com/benthosdev/benthos/v4/internal/log"
@@ -359,6 +361,49 @@ func TestUnarchiveBinary(t *testing.T) {
 	}
 }
 
+func TestUnarchiveBinaryV2(t *testing.T) {
+	aConf := NewConfig()
+	aConf.Type = "archive"
+	aConf.Archive.Format = "binary_v2"
+
+	uConf := NewConfig()
+	uConf.Type = "unarchive"
+	uConf.Unarchive.Format = "binary"
+
+	archiver, err := New(aConf, mock.NewManager(), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	unarchiver, err := New(uConf, mock.NewManager(), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	testMsg := message.QuickBatch([][]byte{[]byte("hello"), []byte("world")})
+	testMsg.Get(0).MetaSet("foo", "first")
+	testMsg.Get(1).MetaSet("foo", "second")
+	testMsg.Get(1).MetaSet("bar", "baz")
+
+	msgs, res := archiver.ProcessMessage(testMsg)
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+	require.Equal(t, 1, msgs[0].Len())
+
+	msgs[0].Get(0).MetaSet("archived", "true")
+
+	msgs, res = unarchiver.ProcessMessage(msgs[0])
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+	require.Equal(t, 2, msgs[0].Len())
+
+	assert.Equal(t, "hello", string(msgs[0].Get(0).Get()))
+	assert.Equal(t, "first", msgs[0].Get(0).MetaGet("foo"))
+	assert.Equal(t, "", msgs[0].Get(0).MetaGet("bar"))
+	assert.Equal(t, "true", msgs[0].Get(0).MetaGet("archived"))
+
+	assert.Equal(t, "world", string(msgs[0].Get(1).Get()))
+	assert.Equal(t, "second", msgs[0].Get(1).MetaGet("foo"))
+	assert.Equal(t, "baz", msgs[0].Get(1).MetaGet("bar"))
+	assert.Equal(t, "true", msgs[0].Get(1).MetaGet("archived"))
+}
+
 func TestUnarchiveCSV(t *testing.T) {
 	conf := NewConfig()
 	conf.Type = "unarchive"
@@ -406,3 +451,71 @@ func TestUnarchiveCSV(t *testing.T) {
 		}
 	}
 }
+
+func TestUnarchiveTarZstd(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = "unarchive"
+	conf.Unarchive.Format = "tar_zstd"
+
+	var tarBuf bytes.Buffer
+	tw := tar.NewWriter(&tarBuf)
+	for _, f := range []struct{ name, content string }{
+		{"foo.txt", "hello"},
+		{"bar.txt", "world"},
+	} {
+		require.NoError(t, tw.WriteHeader(&tar.Header{
+			Name: f.name,
+			Mode: 0o600,
+			Size: int64(len(f.content)),
+		}))
+		_, err := tw.Write([]byte(f.content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+
+	enc, err := zstd.NewWriter(nil)
+	require.NoError(t, err)
+
+	proc, err := New(conf, mock.NewManager(), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	msgs, res := proc.ProcessMessage(message.QuickBatch([][]byte{
+		enc.EncodeAll(tarBuf.Bytes(), nil),
+	}))
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+	assert.Equal(t, [][]byte{[]byte("hello"), []byte("world")}, message.GetAllBytes(msgs[0]))
+	assert.Equal(t, "foo.txt", msgs[0].Get(0).MetaGet("archive_filename"))
+	assert.Equal(t, "bar.txt", msgs[0].Get(1).MetaGet("archive_filename"))
+
+	msgs, _ = proc.ProcessMessage(message.QuickBatch([][]byte{tarBuf.Bytes()}))
+	require.Len(t, msgs, 1)
+	assert.Error(t, msgs[0].Get(0).ErrorGet())
+}
+
+func TestUnarchiveJSONLines(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = "unarchive"
+	conf.Unarchive.Format = "json_lines"
+
+	proc, err := New(conf, mock.NewManager(), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	msgs, res := proc.ProcessMessage(message.QuickBatch([][]byte{
+		[]byte("{\"foo\":\"bar\"}\n5\n\n[\"root\", \"is\", \"an\", \"array\"]\n"),
+	}))
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+	assert.Equal(t, [][]byte{
+		[]byte(`{"foo":"bar"}`),
+		[]byte(`5`),
+		[]byte(`["root","is","an","array"]`),
+	}, message.GetAllBytes(msgs[0]))
+
+	msgs, _ = proc.ProcessMessage(message.QuickBatch([][]byte{
+		[]byte("{\"foo\":\"bar\"}\n{\"foo\":\"bar\"} 5"),
+	}))
+	require.Len(t, msgs, 1)
+	require.Error(t, msgs[0].Get(0).ErrorGet())
+	assert.Contains(t, msgs[0].Get(0).ErrorGet().Error(), "failed to parse line 2 as JSON")
+}
@@ -1,8 +1,12 @@
 package processor
 
 import (
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"path"
 	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -113,7 +117,18 @@ For example, if our meta object is stored at the path ` + "`meta.workflow`" + `
 
 However, if structured metadata is disabled by setting the field ` + "`meta_path`" + ` to empty then the workflow processor instead adds a general error flag to messages when any executed branch fails. In this case it's possible to handle failures using [standard error handling patterns][configuration.error-handling].
 
+## Observability
+
+The resolved DAG of a workflow can be inspected by querying the HTTP server of Benthos at the endpoint ` + "`/workflow/<id>/dag`" + `, where ` + "`<id>`" + ` is the label of the processor or, when it has no label, the dot path of the processor within the config such as ` + "`pipeline.processors.0`" + `. The DAG is returned as a JSON object listing the tiers of the workflow and the dependencies of each branch, and adding the query parameter ` + "`format=dot`" + ` returns it in the [DOT language][dot_wiki] instead, which can be rendered with tools such as Graphviz:
+
+` + "```sh" + `
+curl -s 'http://localhost:4195/workflow/enrichment/dag?format=dot' | dot -Tsvg > dag.svg
+` + "```" + `
+
+For each branch the workflow also emits the counters ` + "`workflow_branch_success`" + ` and ` + "`workflow_branch_error`" + `, which count the messages that each branch succeeded or failed for, and the timer ` + "`workflow_branch_latency_ns`" + `, all of which are labelled with the name of the branch as ` + "`branch`" + `.
+
 [dag_wiki]: https://en.wikipedia.org/wiki/Directed_acyclic_graph
+[dot_wiki]: https://en.wikipedia.org/wiki/DOT_(graph_description_language)
 [processors.switch]: /docs/components/processors/switch
 [processors.http]: /docs/components/processors/http
 [processors.aws_lambda]: /docs/components/processors/aws_lambda
@@ -301,6 +316,10 @@ type Workflow struct {
 	mBatchSent     metrics.StatCounter
 	mError         metrics.StatCounter
 	mLatency       metrics.StatTimer
+
+	mBranchSuccess metrics.StatCounterVec
+	mBranchError   metrics.StatCounterVec
+	mBranchLatency metrics.StatTimerVec
 }
 
 // NewWorkflow instanciates a new workflow processor.
@@ -317,6 +336,10 @@ func NewWorkflow(conf WorkflowConfig, mgr interop.Manager) (*Workflow, error) {
 		mBatchSent:     stats.GetCounter("processor_batch_sent"),
 		mError:         stats.GetCounter("processor_error"),
 		mLatency:       stats.GetTimer("processor_latency_ns"),
+
+		mBranchSuccess: stats.GetCounterVec("workflow_branch_success", "branch"),
+		mBranchError:   stats.GetCounterVec("workflow_branch_error", "branch"),
+		mBranchLatency: stats.GetTimerVec("workflow_branch_latency_ns", "branch"),
 	}
 	if len(conf.MetaPath) > 0 {
 		w.metaPath = gabs.DotPathToSlice(conf.MetaPath)
@@ -330,9 +353,25 @@ func NewWorkflow(conf WorkflowConfig, mgr interop.Manager) (*Workflow, error) {
 		w.allStages[k] = struct{}{}
 	}
 
+	if id := workflowEndpointID(mgr); id != "" {
+		mgr.RegisterEndpoint(
+			path.Join("/workflow", id, "dag"),
+			"Returns the resolved DAG of a workflow processor as JSON, or in DOT format when the query parameter `format=dot` is set.",
+			w.HandleDAG,
+		)
+	}
 	return w, nil
 }
 
+// Workflow endpoints are identified by the label of the processor when it has
+// one, otherwise by its path within the config.
+func workflowEndpointID(mgr interop.Manager) string {
+	if label := mgr.Label(); label != "" {
+		return label
+	}
+	return strings.Join(mgr.Path(), ".")
+}
+
 // Flow returns the calculated workflow as a 2D slice.
 func (w *Workflow) Flow() [][]string {
 	return w.children.dag
@@ -340,6 +379,96 @@ func (w *Workflow) Flow() [][]string {
 
 //------------------------------------------------------------------------------
 
+type workflowDAGNode struct {
+	Name         string   `json:"name"`
+	Tier         int      `json:"tier"`
+	Dependencies []string `json:"dependencies"`
+}
+
+type workflowDAG struct {
+	Tiers [][]string        `json:"tiers"`
+	Nodes []workflowDAGNode `json:"nodes"`
+}
+
+// resolveDAG returns the currently resolved workflow, where the dependencies of each
+// node are the branches that provide the data it consumes.
+func (w *Workflow) resolveDAG() (workflowDAG, error) {
+	dag, children, unlock, err := w.children.Lock()
+	if err != nil {
+		return workflowDAG{}, err
+	}
+	defer unlock()
+
+	res := workflowDAG{
+		Tiers: make([][]string, len(dag)),
+		Nodes: []workflowDAGNode{},
+	}
+	for i, tier := range dag {
+		// Tiers are copied before sorting as the order of branches within a
+		// tier of the resolved DAG is not deterministic.
+		res.Tiers[i] = append([]string{}, tier...)
+		sort.Strings(res.Tiers[i])
+	}
+	for i, tier := range res.Tiers {
+		for _, id := range tier {
+			deps := getBranchDeps(id, children[id].targetsUsed(), children)
+			sort.Strings(deps)
+			res.Nodes = append(res.Nodes, workflowDAGNode{
+				Name:         id,
+				Tier:         i,
+				Dependencies: deps,
+			})
+		}
+	}
+	return res, nil
+}
+
+// DOT returns the DAG in the DOT graph description language, where nodes of
+// the same tier share a rank.
+func (d workflowDAG) DOT() string {
+	var b strings.Builder
+	b.WriteString("digraph workflow {\n")
+	b.WriteString("  rankdir=LR;\n")
+	for i, tier := range d.Tiers {
+		fmt.Fprintf(&b, "  subgraph tier_%v {\n    rank=same;\n", i)
+		for _, id := range tier {
+			fmt.Fprintf(&b, "    %q;\n", id)
+		}
+		b.WriteString("  }\n")
+	}
+	for _, n := range d.Nodes {
+		for _, dep := range n.Dependencies {
+			fmt.Fprintf(&b, "  %q -> %q;\n", dep, n.Name)
+		}
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// HandleDAG is an HTTP handler that writes the resolved DAG of the workflow as
+// a JSON object, or in DOT format when the query parameter `format` is set to
+// `dot`.
+func (w *Workflow) HandleDAG(rw http.ResponseWriter, r *http.Request) {
+	dag, err := w.resolveDAG()
+	if err != nil {
+		http.Error(rw, fmt.Sprintf("Failed to resolve DAG: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	switch format := r.URL.Query().Get("format"); format {
+	case "dot":
+		rw.Header().Set("Content-Type", "text/vnd.graphviz")
+		_, _ = rw.Write([]byte(dag.DOT()))
+	case "", "json":
+		rw.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(rw).Encode(dag)
+	default:
+		http.Error(rw, fmt.Sprintf("Format not supported: %v", format), http.StatusBadRequest)
+	}
+}
+
+//------------------------------------------------------------------------------
+
 type resultTracker struct {
 	succeeded map[string]struct{}
 	skipped   map[string]struct{}
@@ -526,7 +655,9 @@ func (w *Workflow) ProcessMessage(msg *message.Batch) ([]*message.Batch, error)
 				})
 
 				var mapErrs []branchMapError
+				branchStartedAt := time.Now()
 				results[index], mapErrs, errors[index] = children[id].createResult(branchParts, propMsg)
+				w.mBranchLatency.With(id).Timing(time.Since(branchStartedAt).Nanoseconds())
 				for _, s := range branchSpans {
 					s.Finish()
 				}
@@ -561,6 +692,19 @@ func (w *Workflow) ProcessMessage(msg *message.Batch) ([]*message.Batch, error)
 				records[e.index].Failed(id, e.err.Error())
 			}
 		}
+
+		for _, id := range layer {
+			var succeeded, failed int64
+			for _, r := range records {
+				if _, exists := r.failed[id]; exists {
+					failed++
+				} else if _, exists := r.succeeded[id]; exists {
+					succeeded++
+				}
+			}
+			w.mBranchSuccess.With(id).Incr(succeeded)
+			w.mBranchError.With(id).Incr(failed)
+		}
 	}
 
 	// Finally, set the meta records of each document.
@@ -603,7 +747,7 @@ func (w *Workflow) ProcessMessage(msg *message.Batch) ([]*message.Batch, error)
 
 	w.mSent.Incr(int64(payload.Len()))
 	w.mBatchSent.Incr(1)
-	w.mLatency.Timing(time.Since(startedAt).Nanoseconds())
+	metrics.TimingWithContext(message.GetContext(payload.Get(0)), w.mLatency, time.Since(startedAt).Nanoseconds())
 	return []*message.Batch{payload}, nil
 }
 
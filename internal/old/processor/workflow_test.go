@@ -2,6 +2,8 @@ package processor_test
 
 import (
 	"errors"
+	"net/http"
+	"net/http/httptest"
 	"sort"
 	"strconv"
 	"sync"
@@ -923,3 +925,122 @@ func TestWorkflowsWithOrderResources(t *testing.T) {
 		})
 	}
 }
+
+func newWorkflowTestConfig(branches ...[3]string) processor.Config {
+	conf := processor.NewConfig()
+	for _, mappings := range branches {
+		branchConf := processor.NewBranchConfig()
+		branchConf.RequestMap = mappings[1]
+		branchConf.ResultMap = mappings[2]
+		proc := processor.NewConfig()
+		proc.Type = processor.TypeBloblang
+		proc.Bloblang = "root = this"
+		branchConf.Processors = append(branchConf.Processors, proc)
+		conf.Workflow.Branches[mappings[0]] = branchConf
+	}
+	return conf
+}
+
+func TestWorkflowDAGEndpoint(t *testing.T) {
+	conf := newWorkflowTestConfig(
+		[3]string{"a", "root = this.foo", "root.bar = this"},
+		[3]string{"b", "root = this.foo", "root.baz = this"},
+		[3]string{"c", "root = [this.bar, this.baz]", "root.buz = this"},
+	)
+
+	handlers := map[string]http.HandlerFunc{}
+	mockAPI := mock.NewManager()
+	mockAPI.OnRegisterEndpoint = func(path string, h http.HandlerFunc) {
+		handlers[path] = h
+	}
+	mgr, err := manager.NewV2(manager.NewResourceConfig(), mockAPI, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	p, err := processor.NewWorkflow(conf.Workflow, mgr.IntoPath("pipeline", "processors", "0"))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		p.CloseAsync()
+		assert.NoError(t, p.WaitForClose(time.Second))
+	})
+
+	h, exists := handlers["/workflow/pipeline.processors.0/dag"]
+	require.True(t, exists, "%v", handlers)
+
+	req := httptest.NewRequest("GET", "/workflow/pipeline.processors.0/dag", nil)
+	res := httptest.NewRecorder()
+	h.ServeHTTP(res, req)
+	require.Equal(t, http.StatusOK, res.Code, res.Body.String())
+	assert.JSONEq(t, `{
+	"tiers": [["a","b"],["c"]],
+	"nodes": [
+		{"name":"a","tier":0,"dependencies":[]},
+		{"name":"b","tier":0,"dependencies":[]},
+		{"name":"c","tier":1,"dependencies":["a","b"]}
+	]
+}`, res.Body.String())
+
+	req = httptest.NewRequest("GET", "/workflow/pipeline.processors.0/dag?format=dot", nil)
+	res = httptest.NewRecorder()
+	h.ServeHTTP(res, req)
+	require.Equal(t, http.StatusOK, res.Code, res.Body.String())
+	assert.Equal(t, `digraph workflow {
+  rankdir=LR;
+  subgraph tier_0 {
+    rank=same;
+    "a";
+    "b";
+  }
+  subgraph tier_1 {
+    rank=same;
+    "c";
+  }
+  "a" -> "c";
+  "b" -> "c";
+}
+`, res.Body.String())
+
+	req = httptest.NewRequest("GET", "/workflow/pipeline.processors.0/dag?format=nope", nil)
+	res = httptest.NewRecorder()
+	h.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusBadRequest, res.Code)
+}
+
+func TestWorkflowBranchMetrics(t *testing.T) {
+	conf := newWorkflowTestConfig(
+		[3]string{"a", "root.v = this.foo.not_null()", "root.bar = this.v"},
+		[3]string{"b", "root.v = this.bar.not_null()", "root.baz = this.v"},
+		[3]string{"c", "root = if this.skip_c == true { deleted() } else { this }", "root.c = true"},
+	)
+
+	stats := metrics.NewLocal()
+	mgr, err := manager.NewV2(manager.NewResourceConfig(), mock.NewManager(), log.Noop(), metrics.NewNamespaced(stats))
+	require.NoError(t, err)
+
+	p, err := processor.NewWorkflow(conf.Workflow, mgr)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		p.CloseAsync()
+		assert.NoError(t, p.WaitForClose(time.Second))
+	})
+
+	msgs, res := p.ProcessMessage(message.QuickBatch([][]byte{
+		[]byte(`{"foo":"hello"}`),
+		[]byte(`{"nope":"world","skip_c":true}`),
+	}))
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+
+	counters := stats.GetCounters()
+	assert.Equal(t, int64(1), counters[`workflow_branch_success{branch="a"}`], "%v", counters)
+	assert.Equal(t, int64(1), counters[`workflow_branch_error{branch="a"}`], "%v", counters)
+	assert.Equal(t, int64(1), counters[`workflow_branch_success{branch="b"}`], "%v", counters)
+	assert.Equal(t, int64(1), counters[`workflow_branch_error{branch="b"}`], "%v", counters)
+	assert.Equal(t, int64(1), counters[`workflow_branch_success{branch="c"}`], "%v", counters)
+	assert.Equal(t, int64(0), counters[`workflow_branch_error{branch="c"}`], "%v", counters)
+
+	timings := stats.GetTimings()
+	for _, id := range []string{"a", "b", "c"} {
+		_, exists := timings[`workflow_branch_latency_ns{branch="`+id+`"}`]
+		assert.True(t, exists, id)
+	}
+}
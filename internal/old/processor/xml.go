@@ -2,7 +2,9 @@ package processor
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/benthosdev/benthos/v4/internal/component/metrics"
 	"github.com/benthosdev/benthos/v4/internal/component/processor"
@@ -92,10 +94,30 @@ With cast set to true, the resulting JSON structure would look like this:
     ]
   }
 }
-` + "```" + ``,
+` + "```" + `
+
+The field ` + "`array_paths`" + ` can be used in order to ensure that elements are
+always represented as arrays, even when they only appear once within a document.
+Paths are dot separated and include the root element, e.g. ` + "`root.elements`" + `.
+
+When ` + "`strip_namespaces`" + ` is set to ` + "`true`" + ` namespace prefixes are removed
+from element and attribute names, and namespace declarations are omitted. If
+removing the prefix of a name would result in the same name as another element
+or attribute of the same parent then the prefixed name is kept.
+
+### ` + "`from_json`" + `
+
+Converts a JSON document into XML following the same rules as ` + "`to_json`" + ` in
+reverse, where keys prefixed with the ` + "`attribute_prefix`" + ` become attributes
+and the key ` + "`#text`" + ` becomes the text of an element. The document must be
+an object, and if it contains more than one key the document is wrapped in a
+root element ` + "`doc`" + `.`,
 		Config: docs.FieldComponent().WithChildren(
-			docs.FieldString("operator", "An XML [operation](#operators) to apply to messages.").HasOptions("to_json"),
+			docs.FieldString("operator", "An XML [operation](#operators) to apply to messages.").HasOptions("to_json", "from_json"),
 			docs.FieldBool("cast", "Whether to try to cast values that are numbers and booleans to the right type. Default: all values are strings."),
+			docs.FieldString("attribute_prefix", "A prefix applied to the keys of attributes.").AtVersion("4.1.0"),
+			docs.FieldString("array_paths", "A list of dot separated element paths that should always be represented as arrays when converting to JSON.", []string{"root.elements"}).Array().AtVersion("4.1.0"),
+			docs.FieldBool("strip_namespaces", "Whether to remove namespace prefixes from element and attribute names when converting to JSON.").AtVersion("4.1.0"),
 		),
 	}
 }
@@ -104,44 +126,182 @@ With cast set to true, the resulting JSON structure would look like this:
 
 // XMLConfig contains configuration fields for the XML processor.
 type XMLConfig struct {
-	Operator string `json:"operator" yaml:"operator"`
-	Cast     bool   `json:"cast" yaml:"cast"`
+	Operator        string   `json:"operator" yaml:"operator"`
+	Cast            bool     `json:"cast" yaml:"cast"`
+	AttributePrefix string   `json:"attribute_prefix" yaml:"attribute_prefix"`
+	ArrayPaths      []string `json:"array_paths" yaml:"array_paths"`
+	StripNamespaces bool     `json:"strip_namespaces" yaml:"strip_namespaces"`
 }
 
 // NewXMLConfig returns a XMLConfig with default values.
 func NewXMLConfig() XMLConfig {
 	return XMLConfig{
-		Operator: "",
-		Cast:     false,
+		Operator:        "",
+		Cast:            false,
+		AttributePrefix: "-",
+		ArrayPaths:      []string{},
+		StripNamespaces: false,
 	}
 }
 
 //------------------------------------------------------------------------------
 
 type xmlProc struct {
-	log  log.Modular
-	cast bool
+	log        log.Modular
+	toJSON     bool
+	cast       bool
+	attrPrefix string
+	arrayPaths map[string]struct{}
+	stripNS    bool
 }
 
 func newXML(conf XMLConfig, mgr interop.Manager) (*xmlProc, error) {
-	if conf.Operator != "to_json" {
+	j := &xmlProc{
+		log:        mgr.Logger(),
+		cast:       conf.Cast,
+		attrPrefix: conf.AttributePrefix,
+		arrayPaths: map[string]struct{}{},
+		stripNS:    conf.StripNamespaces,
+	}
+	switch conf.Operator {
+	case "to_json":
+		j.toJSON = true
+	case "from_json":
+	default:
 		return nil, fmt.Errorf("operator not recognised: %v", conf.Operator)
 	}
-	j := &xmlProc{
-		log:  mgr.Logger(),
-		cast: conf.Cast,
+	if j.attrPrefix == "" {
+		return nil, errors.New("attribute_prefix must not be empty")
+	}
+	for _, p := range conf.ArrayPaths {
+		j.arrayPaths[p] = struct{}{}
 	}
 	return j, nil
 }
 
+// xmlStripNamespace removes a namespace prefix from an element or attribute
+// name, where attributes retain the hyphen prefix.
+func xmlStripNamespace(key string) string {
+	i := strings.LastIndex(key, ":")
+	if i < 0 {
+		return key
+	}
+	if strings.HasPrefix(key, "-") {
+		return "-" + key[i+1:]
+	}
+	return key[i+1:]
+}
+
+// shapeToJSON applies array hints, namespace stripping and attribute prefix
+// mappings to a structure parsed from XML.
+func (p *xmlProc) shapeToJSON(path string, v interface{}, nsDecls map[string]struct{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		var localNames map[string]int
+		if p.stripNS {
+			localNames = make(map[string]int, len(t))
+			for k := range t {
+				localNames[xmlStripNamespace(k)]++
+			}
+		}
+		newObj := make(map[string]interface{}, len(t))
+		for k, e := range t {
+			// A prefix is only removed when the name without it is unique
+			// within the element, otherwise the prefixed name is kept so that
+			// neither value replaces the other.
+			if stripped := xmlStripNamespace(k); p.stripNS && localNames[stripped] == 1 {
+				k = stripped
+			}
+			childPath := k
+			if path != "" {
+				childPath = path + "." + k
+			}
+			if _, isDecl := nsDecls[childPath]; isDecl {
+				continue
+			}
+			e = p.shapeToJSON(childPath, e, nsDecls)
+			if _, isArrayPath := p.arrayPaths[childPath]; isArrayPath {
+				if _, isArray := e.([]interface{}); !isArray {
+					e = []interface{}{e}
+				}
+			}
+			if p.attrPrefix != "-" && strings.HasPrefix(k, "-") {
+				k = p.attrPrefix + k[1:]
+			}
+			newObj[k] = e
+		}
+		return newObj
+	case []interface{}:
+		newArr := make([]interface{}, len(t))
+		for i, e := range t {
+			newArr[i] = p.shapeToJSON(path, e, nsDecls)
+		}
+		return newArr
+	}
+	return v
+}
+
+// shapeFromJSON maps custom attribute prefixes back to the hyphen prefix
+// expected by the XML serializer.
+func (p *xmlProc) shapeFromJSON(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		newObj := make(map[string]interface{}, len(t))
+		for k, e := range t {
+			if p.attrPrefix != "-" && strings.HasPrefix(k, p.attrPrefix) {
+				k = "-" + strings.TrimPrefix(k, p.attrPrefix)
+			}
+			newObj[k] = p.shapeFromJSON(e)
+		}
+		return newObj
+	case []interface{}:
+		newArr := make([]interface{}, len(t))
+		for i, e := range t {
+			newArr[i] = p.shapeFromJSON(e)
+		}
+		return newArr
+	}
+	return v
+}
+
 func (p *xmlProc) Process(ctx context.Context, msg *message.Part) ([]*message.Part, error) {
 	newPart := msg.Copy()
+	if !p.toJSON {
+		jObj, err := newPart.JSON()
+		if err != nil {
+			p.log.Debugf("Failed to parse part as JSON: %v", err)
+			return nil, err
+		}
+		root, ok := p.shapeFromJSON(jObj).(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("expected object, got %T", jObj)
+		}
+		xmlBytes, err := xml.FromMap(root)
+		if err != nil {
+			p.log.Debugf("Failed to serialize part as XML: %v", err)
+			return nil, err
+		}
+		newPart.Set(xmlBytes)
+		return []*message.Part{newPart}, nil
+	}
+
 	root, err := xml.ToMap(newPart.Get(), p.cast)
 	if err != nil {
 		p.log.Debugf("Failed to parse part as XML: %v", err)
 		return nil, err
 	}
-	newPart.SetJSON(root)
+	var nsDecls map[string]struct{}
+	if p.stripNS {
+		if nsDecls, err = xml.NamespaceDeclarations(newPart.Get()); err != nil {
+			p.log.Debugf("Failed to parse part as XML: %v", err)
+			return nil, err
+		}
+	}
+	if p.stripNS || p.attrPrefix != "-" || len(p.arrayPaths) > 0 {
+		newPart.SetJSON(p.shapeToJSON("", root, nsDecls))
+	} else {
+		newPart.SetJSON(root)
+	}
 	return []*message.Part{newPart}, nil
 }
 
@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/benthosdev/benthos/v4/internal/component/metrics"
 	"github.com/benthosdev/benthos/v4/internal/log"
@@ -141,3 +142,79 @@ func TestXMLWithCast(t *testing.T) {
 	}
 	assert.NoError(t, msgsOut[0].Get(0).ErrorGet())
 }
+
+func TestXMLToJSONOptions(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = "xml"
+	conf.XML.Operator = "to_json"
+	conf.XML.AttributePrefix = "@"
+	conf.XML.ArrayPaths = []string{"root.elements", "root.missing"}
+	conf.XML.StripNamespaces = true
+
+	proc, err := New(conf, mock.NewManager(), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	input := `<ns:root xmlns:ns="http://example.com/ns" xmlns="http://example.com/default"><ns:title ns:lang="en">Hello</ns:title><ns:elements>foo</ns:elements></ns:root>`
+
+	msgsOut, res := proc.ProcessMessage(message.QuickBatch([][]byte{[]byte(input)}))
+	require.Nil(t, res)
+	require.Len(t, msgsOut, 1)
+	require.NoError(t, msgsOut[0].Get(0).ErrorGet())
+
+	assert.Equal(t, `{"root":{"elements":["foo"],"title":{"#text":"Hello","@lang":"en"}}}`, string(msgsOut[0].Get(0).Get()))
+}
+
+func TestXMLFromJSON(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = "xml"
+	conf.XML.Operator = "from_json"
+	conf.XML.AttributePrefix = "@"
+
+	proc, err := New(conf, mock.NewManager(), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	msgsOut, res := proc.ProcessMessage(message.QuickBatch([][]byte{
+		[]byte(`{"root":{"title":{"#text":"Hello","@lang":"en"}}}`),
+		[]byte(`["not","an","object"]`),
+	}))
+	require.Nil(t, res)
+	require.Len(t, msgsOut, 1)
+
+	require.NoError(t, msgsOut[0].Get(0).ErrorGet())
+	assert.Equal(t, `<root><title lang="en">Hello</title></root>`, string(msgsOut[0].Get(0).Get()))
+
+	require.Error(t, msgsOut[0].Get(1).ErrorGet())
+}
+
+func TestXMLStripNamespacesCollision(t *testing.T) {
+	conf := NewXMLConfig()
+	conf.Operator = "to_json"
+	conf.StripNamespaces = true
+
+	proc, err := newXML(conf, mock.NewManager())
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]interface{}{
+		"root": map[string]interface{}{
+			"a:id":  "1",
+			"id":    "2",
+			"title": "foo",
+			"-a:id": "3",
+			"-id":   "4",
+			"-b:x":  "5",
+			"-c:x":  "6",
+			"-lang": "en",
+		},
+	}, proc.shapeToJSON("", map[string]interface{}{
+		"ns:root": map[string]interface{}{
+			"a:id":    "1",
+			"id":      "2",
+			"a:title": "foo",
+			"-a:id":   "3",
+			"-id":     "4",
+			"-b:x":    "5",
+			"-c:x":    "6",
+			"-a:lang": "en",
+		},
+	}, nil))
+}
@@ -26,6 +26,10 @@ type Processor struct {
 
 	messagesIn <-chan message.Transaction
 
+	heldWG     sync.WaitGroup
+	heldMut    sync.Mutex
+	heldClosed bool
+
 	shutSig *shutdown.Signaller
 }
 
@@ -49,6 +53,13 @@ func (p *Processor) loop() {
 			c.CloseAsync()
 		}
 
+		// Held messages emitted by processors may still be sending over our
+		// messages channel.
+		p.heldMut.Lock()
+		p.heldClosed = true
+		p.heldMut.Unlock()
+		p.heldWG.Wait()
+
 		close(p.messagesOut)
 		p.shutSig.ShutdownComplete()
 	}()
@@ -68,19 +79,21 @@ func (p *Processor) loop() {
 			return
 		}
 
-		resultMsgs, resultRes := processor.ExecuteAll(p.msgProcessors, tran.Payload)
+		holds := message.NewAckHolds(p.emitHeld)
+		resultMsgs, resultRes := processor.ExecuteAll(p.msgProcessors, holds.Attach(tran.Payload))
+		ackFn := holds.Seal(tran.Ack)
 		if len(resultMsgs) == 0 {
-			if err := tran.Ack(closeCtx, resultRes); err != nil && closeCtx.Err() != nil {
+			if err := ackFn(closeCtx, resultRes); err != nil && closeCtx.Err() != nil {
 				return
 			}
 			continue
 		}
 
 		if len(resultMsgs) > 1 {
-			p.dispatchMessages(closeCtx, resultMsgs, tran.Ack)
+			p.dispatchMessages(closeCtx, resultMsgs, ackFn)
 		} else {
 			select {
-			case p.messagesOut <- message.NewTransactionFunc(resultMsgs[0], tran.Ack):
+			case p.messagesOut <- message.NewTransactionFunc(resultMsgs[0], ackFn):
 			case <-p.shutSig.CloseAtLeisureChan():
 				return
 			}
@@ -88,6 +101,29 @@ func (p *Processor) loop() {
 	}
 }
 
+// emitHeld dispatches a batch emitted by a processor in place of a message it
+// held back, which can happen at any time and therefore the batch is sent
+// asynchronously.
+func (p *Processor) emitHeld(b *message.Batch, ackFn func(context.Context, error) error) {
+	p.heldMut.Lock()
+	if p.heldClosed {
+		p.heldMut.Unlock()
+		_ = ackFn(context.Background(), component.ErrTypeClosed)
+		return
+	}
+	p.heldWG.Add(1)
+	p.heldMut.Unlock()
+
+	go func() {
+		defer p.heldWG.Done()
+		select {
+		case p.messagesOut <- message.NewTransactionFunc(b, ackFn):
+		case <-p.shutSig.CloseAtLeisureChan():
+			_ = ackFn(context.Background(), component.ErrTypeClosed)
+		}
+	}()
+}
+
 // dispatchMessages attempts to send a multiple messages results of processors
 // over the shared messages channel. This send is retried until success.
 func (p *Processor) dispatchMessages(ctx context.Context, msgs []*message.Batch, ackFn func(context.Context, error) error) {
@@ -395,3 +395,77 @@ func TestProcessorMultiMsgsOddSync(t *testing.T) {
 		t.Error("Expected mockproc to have waited for close")
 	}
 }
+
+type mockHoldProcessor struct {
+	holds chan *message.AckHold
+}
+
+func (m *mockHoldProcessor) ProcessMessage(msg *message.Batch) ([]*message.Batch, error) {
+	m.holds <- message.HoldAck(msg.Get(0))
+	return nil, nil
+}
+
+func (m *mockHoldProcessor) CloseAsync() {}
+
+func (m *mockHoldProcessor) WaitForClose(timeout time.Duration) error {
+	return nil
+}
+
+func TestProcessorHeldMessages(t *testing.T) {
+	ctx, done := context.WithTimeout(context.Background(), time.Second*30)
+	defer done()
+
+	mockProc := &mockHoldProcessor{holds: make(chan *message.AckHold, 1)}
+	proc := NewProcessor(mockProc)
+
+	tChan, resChan := make(chan message.Transaction), make(chan error, 1)
+	require.NoError(t, proc.Consume(tChan))
+
+	select {
+	case tChan <- message.NewTransaction(message.QuickBatch([][]byte{[]byte("foo")}), resChan):
+	case <-ctx.Done():
+		t.Fatal("timed out")
+	}
+
+	var hold *message.AckHold
+	select {
+	case hold = <-mockProc.holds:
+	case <-ctx.Done():
+		t.Fatal("timed out")
+	}
+	require.NotNil(t, hold)
+
+	select {
+	case <-resChan:
+		t.Fatal("unexpected ack of held message")
+	case <-time.After(time.Millisecond * 50):
+	}
+
+	var emittedRes error
+	hold.Emit(message.QuickBatch([][]byte{[]byte("bar")}), func(ctx context.Context, err error) error {
+		emittedRes = err
+		return nil
+	})
+
+	var procT message.Transaction
+	select {
+	case procT = <-proc.TransactionChan():
+	case <-ctx.Done():
+		t.Fatal("timed out")
+	}
+	assert.Equal(t, [][]byte{[]byte("bar")}, message.GetAllBytes(procT.Payload))
+
+	errNope := errors.New("nope")
+	require.NoError(t, procT.Ack(ctx, errNope))
+	assert.Equal(t, errNope, emittedRes)
+
+	select {
+	case err := <-resChan:
+		assert.Equal(t, errNope, err)
+	case <-ctx.Done():
+		t.Fatal("timed out")
+	}
+
+	proc.CloseAsync()
+	require.NoError(t, proc.WaitForClose(time.Second*5))
+}
@@ -0,0 +1,207 @@
+// Package secrets provides a registry of secret providers and the resolution
+// of secret references within config files, which take the form
+// `${secret:<provider>://<path>#<key>}`.
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Provider obtains the value of a secret at a path, where the format of the
+// path is specific to the provider.
+type Provider func(ctx context.Context, path string) (string, error)
+
+var (
+	providersMut sync.RWMutex
+	providers    = map[string]Provider{}
+)
+
+// RegisterProvider adds a provider of secrets under a name, which is the
+// scheme of the secret references that it resolves.
+func RegisterProvider(name string, p Provider) error {
+	providersMut.Lock()
+	defer providersMut.Unlock()
+
+	if _, exists := providers[name]; exists {
+		return fmt.Errorf("secret provider '%v' has already been registered", name)
+	}
+	providers[name] = p
+	return nil
+}
+
+func getProvider(name string) (Provider, error) {
+	providersMut.RLock()
+	defer providersMut.RUnlock()
+
+	p, exists := providers[name]
+	if !exists {
+		return nil, fmt.Errorf("secret provider '%v' was not recognised", name)
+	}
+	return p, nil
+}
+
+//------------------------------------------------------------------------------
+
+var secretRegex = regexp.MustCompile(`\${secret:([0-9A-Za-z_]+)://([^}#]+)(#([^}]+))?}`)
+
+// Cache holds the values of secrets by their provider and path so that the
+// same secret referenced many times, or by config files that are read again, is
+// only obtained once until it is refreshed.
+type Cache struct {
+	mut    sync.Mutex
+	values map[[2]string]string
+}
+
+// NewCache creates an empty cache of secret values.
+func NewCache() *Cache {
+	return &Cache{
+		values: map[[2]string]string{},
+	}
+}
+
+func (c *Cache) get(ctx context.Context, provider, path string) (string, error) {
+	c.mut.Lock()
+	v, exists := c.values[[2]string{provider, path}]
+	c.mut.Unlock()
+	if exists {
+		return v, nil
+	}
+
+	p, err := getProvider(provider)
+	if err != nil {
+		return "", err
+	}
+
+	// The cache isn't locked whilst calling the provider as it may block on
+	// network calls for a long time.
+	if v, err = p(ctx, path); err != nil {
+		return "", fmt.Errorf("failed to obtain secret '%v://%v': %w", provider, path, err)
+	}
+
+	c.mut.Lock()
+	c.values[[2]string{provider, path}] = v
+	c.mut.Unlock()
+	return v, nil
+}
+
+// extractKey obtains the value of a key from a secret that is a JSON object.
+func extractKey(secret, key string) (string, error) {
+	var obj map[string]interface{}
+	if err := json.Unmarshal([]byte(secret), &obj); err != nil {
+		return "", fmt.Errorf("secret is not a JSON object and therefore key '%v' cannot be extracted", key)
+	}
+
+	v, exists := obj[key]
+	if !exists {
+		return "", fmt.Errorf("secret does not contain key '%v'", key)
+	}
+	if s, ok := v.(string); ok {
+		return s, nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// resolveString replaces all secret references within a string with the
+// values of the secrets.
+func (c *Cache) resolveString(ctx context.Context, s string) (string, error) {
+	var err error
+	replaced := secretRegex.ReplaceAllStringFunc(s, func(content string) string {
+		if err != nil {
+			return ""
+		}
+
+		groups := secretRegex.FindStringSubmatch(content)
+		provider, path, key := groups[1], groups[2], groups[4]
+
+		var value string
+		if value, err = c.get(ctx, provider, path); err != nil {
+			return ""
+		}
+		if key != "" {
+			if value, err = extractKey(value, key); err != nil {
+				err = fmt.Errorf("secret '%v://%v': %w", provider, path, err)
+				return ""
+			}
+		}
+		return value
+	})
+	if err != nil {
+		return "", err
+	}
+	return replaced, nil
+}
+
+// Replace searches a YAML document for secret references of the form
+// `${secret:<provider>://<path>#<key>}` and replaces each with the value of the
+// secret obtained from the provider. When a key is specified the secret is
+// parsed as a JSON object and the value of the key is used, otherwise the
+// entire secret is used.
+//
+// Values are escaped, or the scalar containing them quoted, according to the
+// style of the YAML scalar that they are referenced within, and therefore the
+// structure of the document is never changed by the contents of a secret.
+//
+// Any secret that cannot be obtained results in an error, as running with an
+// empty secret is almost never the desired behaviour.
+func (c *Cache) Replace(ctx context.Context, inBytes []byte) ([]byte, error) {
+	if !secretRegex.Match(inBytes) {
+		return inBytes, nil
+	}
+	return c.replaceYAML(ctx, inBytes)
+}
+
+// Refresh obtains the values of all secrets that have been resolved so far
+// from their providers, and returns true if any of them have changed since
+// they were last obtained. Secrets that fail to be obtained retain their
+// previous value and result in an error once all other secrets have been
+// refreshed.
+func (c *Cache) Refresh(ctx context.Context) (changed bool, err error) {
+	c.mut.Lock()
+	current := make(map[[2]string]string, len(c.values))
+	for k, v := range c.values {
+		current[k] = v
+	}
+	c.mut.Unlock()
+
+	var errs []string
+	for k, v := range current {
+		p, perr := getProvider(k[0])
+		if perr != nil {
+			errs = append(errs, perr.Error())
+			continue
+		}
+		newV, perr := p(ctx, k[1])
+		if perr != nil {
+			errs = append(errs, fmt.Sprintf("failed to obtain secret '%v://%v': %v", k[0], k[1], perr))
+			continue
+		}
+		if newV != v {
+			c.mut.Lock()
+			c.values[k] = newV
+			c.mut.Unlock()
+			changed = true
+		}
+	}
+	if len(errs) > 0 {
+		sort.Strings(errs)
+		err = errors.New(strings.Join(errs, ", "))
+	}
+	return
+}
+
+// Replace resolves the secret references within a YAML document using a cache
+// that only lives for the duration of the call, see Cache.Replace for details.
+func Replace(ctx context.Context, inBytes []byte) ([]byte, error) {
+	return NewCache().Replace(ctx, inBytes)
+}
@@ -0,0 +1,268 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// scalarRef is a scalar node of a parsed document along with the byte offset
+// of its start within the raw document.
+type scalarRef struct {
+	node   *yaml.Node
+	offset int
+}
+
+// edit replaces the bytes of a raw document between start and end.
+type edit struct {
+	start, end int
+	value      []byte
+}
+
+// replaceYAML resolves secret references by editing the raw bytes of a
+// document, where the contents of each edit depend on the style of the scalar
+// that a reference belongs to. Editing the raw bytes rather than encoding the
+// parsed document again preserves comments, formatting and line numbers, which
+// are later used by linting.
+func (c *Cache) replaceYAML(ctx context.Context, inBytes []byte) ([]byte, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(inBytes, &root); err != nil {
+		return nil, fmt.Errorf("failed to parse config for secret references: %w", err)
+	}
+
+	lineStarts := []int{0}
+	for i, b := range inBytes {
+		if b == '\n' {
+			lineStarts = append(lineStarts, i+1)
+		}
+	}
+
+	var scalars []scalarRef
+	var walk func(n *yaml.Node)
+	walk = func(n *yaml.Node) {
+		if n.Kind == yaml.ScalarNode && n.Line > 0 && n.Line <= len(lineStarts) {
+			scalars = append(scalars, scalarRef{
+				node:   n,
+				offset: columnOffset(inBytes, lineStarts[n.Line-1], n.Column),
+			})
+		}
+		for _, child := range n.Content {
+			walk(child)
+		}
+	}
+	walk(&root)
+	sort.SliceStable(scalars, func(i, j int) bool {
+		return scalars[i].offset < scalars[j].offset
+	})
+
+	var edits []edit
+	lastScalar := -1
+	for _, match := range secretRegex.FindAllIndex(inBytes, -1) {
+		i := sort.Search(len(scalars), func(i int) bool {
+			return scalars[i].offset > match[0]
+		}) - 1
+		if i < 0 {
+			// References outside of any scalar are within comments.
+			continue
+		}
+		s := scalars[i]
+
+		switch {
+		case s.node.Style&(yaml.LiteralStyle|yaml.FoldedStyle) != 0:
+			if match[0] >= blockScalarEnd(inBytes, s.offset) {
+				continue
+			}
+			value, err := c.resolveString(ctx, string(inBytes[match[0]:match[1]]))
+			if err != nil {
+				return nil, err
+			}
+			edits = append(edits, edit{
+				start: match[0],
+				end:   match[1],
+				value: []byte(indentBlockValue(inBytes, match[0], value, s.node.Style&yaml.FoldedStyle != 0)),
+			})
+		default:
+			if i == lastScalar {
+				// The entire scalar has already been replaced.
+				continue
+			}
+			start, end, err := scalarSpan(inBytes, s)
+			if err != nil {
+				return nil, err
+			}
+			if match[0] < start || match[1] > end {
+				continue
+			}
+			lastScalar = i
+
+			value, err := c.resolveString(ctx, s.node.Value)
+			if err != nil {
+				return nil, err
+			}
+			if s.node.Style&(yaml.DoubleQuotedStyle|yaml.SingleQuotedStyle) != 0 || !isPlainSafe(value) {
+				value = quoteDouble(value)
+			}
+			edits = append(edits, edit{start: start, end: end, value: []byte(value)})
+		}
+	}
+
+	var buf bytes.Buffer
+	last := 0
+	for _, e := range edits {
+		buf.Write(inBytes[last:e.start])
+		buf.Write(e.value)
+		last = e.end
+	}
+	buf.Write(inBytes[last:])
+	return buf.Bytes(), nil
+}
+
+// columnOffset converts a column of a line, which is counted in characters
+// starting from 1, to a byte offset.
+func columnOffset(b []byte, lineStart, column int) int {
+	offset := lineStart
+	for c := 1; c < column && offset < len(b); c++ {
+		_, size := utf8.DecodeRune(b[offset:])
+		offset += size
+	}
+	return offset
+}
+
+// scalarSpan returns the byte offsets of the raw text of a plain or quoted
+// scalar, including its quotes.
+func scalarSpan(b []byte, s scalarRef) (start, end int, err error) {
+	switch {
+	case s.node.Style&yaml.DoubleQuotedStyle != 0:
+		if start = bytes.IndexByte(b[s.offset:], '"'); start == -1 {
+			break
+		}
+		start += s.offset
+		for i := start + 1; i < len(b); i++ {
+			switch b[i] {
+			case '\\':
+				i++
+			case '"':
+				return start, i + 1, nil
+			}
+		}
+	case s.node.Style&yaml.SingleQuotedStyle != 0:
+		if start = bytes.IndexByte(b[s.offset:], '\''); start == -1 {
+			break
+		}
+		start += s.offset
+		for i := start + 1; i < len(b); i++ {
+			if b[i] == '\'' {
+				if i+1 < len(b) && b[i+1] == '\'' {
+					i++
+					continue
+				}
+				return start, i + 1, nil
+			}
+		}
+	default:
+		// The raw text of a plain scalar is identical to its value unless it
+		// spans multiple lines, in which case it has been folded.
+		lineEnd := bytes.IndexByte(b[s.offset:], '\n')
+		if lineEnd == -1 {
+			lineEnd = len(b) - s.offset
+		}
+		if i := bytes.Index(b[s.offset:s.offset+lineEnd], []byte(s.node.Value)); i != -1 {
+			start = s.offset + i
+			return start, start + len(s.node.Value), nil
+		}
+		return 0, 0, fmt.Errorf("line %v: secret references within multiple line plain scalars are not supported, quote the value instead", s.node.Line)
+	}
+	return 0, 0, fmt.Errorf("line %v: failed to find the end of the quoted scalar", s.node.Line)
+}
+
+// blockScalarEnd returns the byte offset at which the content of a literal or
+// folded block scalar ends, given the offset of its indicator.
+func blockScalarEnd(b []byte, offset int) int {
+	lineEnd := bytes.IndexByte(b[offset:], '\n')
+	if lineEnd == -1 {
+		return len(b)
+	}
+
+	indent := -1
+	for i := offset + lineEnd + 1; i < len(b); {
+		next := bytes.IndexByte(b[i:], '\n')
+		if next == -1 {
+			next = len(b) - i
+		}
+		line := b[i : i+next]
+		if trimmed := bytes.TrimLeft(line, " "); len(bytes.TrimSpace(trimmed)) > 0 {
+			lineIndent := len(line) - len(trimmed)
+			if indent == -1 {
+				indent = lineIndent
+			} else if lineIndent < indent {
+				return i
+			}
+		}
+		i += next + 1
+	}
+	return len(b)
+}
+
+// indentBlockValue prepares a value for a block scalar by indenting each line
+// after the first to the indentation of the line it is referenced from.
+func indentBlockValue(b []byte, offset int, value string, folded bool) string {
+	lineStart := bytes.LastIndexByte(b[:offset], '\n') + 1
+	indent := lineStart
+	for indent < offset && (b[indent] == ' ' || b[indent] == '\t') {
+		indent++
+	}
+
+	sep := "\n" + string(b[lineStart:indent])
+	if folded {
+		// Line breaks are folded into spaces unless followed by an empty line.
+		sep = "\n" + sep
+	}
+	return strings.ReplaceAll(value, "\n", sep)
+}
+
+// isPlainSafe returns true if a value can be written as a plain scalar within
+// either a block or flow context without changing the structure of the
+// document.
+func isPlainSafe(v string) bool {
+	if v == "" || strings.TrimSpace(v) != v {
+		return false
+	}
+	for _, r := range v {
+		if r < 0x20 || r == 0x7f {
+			return false
+		}
+	}
+	if strings.ContainsAny(v, ",[]{}") ||
+		strings.Contains(v, ": ") ||
+		strings.Contains(v, " #") ||
+		strings.HasSuffix(v, ":") ||
+		strings.HasPrefix(v, "---") ||
+		strings.HasPrefix(v, "...") {
+		return false
+	}
+	switch v[0] {
+	case '!', '&', '*', '|', '>', '\'', '"', '%', '@', '`', '#':
+		return false
+	case '-', '?', ':':
+		if len(v) == 1 || v[1] == ' ' {
+			return false
+		}
+	}
+	return true
+}
+
+// quoteDouble writes a value as a double quoted scalar. JSON strings are valid
+// YAML double quoted scalars.
+func quoteDouble(v string) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(v)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
@@ -117,7 +117,7 @@ func NewHandler(conf config.Type) (*Handler, error) {
 	}
 
 	// Create our tracer type.
-	trac, err := bundle.AllTracers.Init(conf.Tracer)
+	trac, err := bundle.AllTracers.Init(conf.Tracer, stats)
 	if err != nil {
 		logger.Errorf("Failed to initialise tracer: %v\n", err)
 		trac = tracer.Noop{}
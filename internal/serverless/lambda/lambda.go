@@ -40,7 +40,7 @@ func Run() {
 	errorCase := output.NewSwitchConfigCase()
 	errorCase.Check = "errored()"
 	errorCase.Output.Type = "reject"
-	errorCase.Output.Reject = "processing failed due to: ${! error() }"
+	errorCase.Output.Reject.Message = "processing failed due to: ${! error() }"
 
 	responseCase := output.NewSwitchConfigCase()
 	responseCase.Output.Type = "sync_response"
@@ -0,0 +1,61 @@
+package stream
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/internal/component"
+)
+
+// ComponentHealth describes the health of an input or output of a stream.
+type ComponentHealth struct {
+	Connected   bool       `json:"connected"`
+	LastError   string     `json:"last_error,omitempty"`
+	LastErrorAt *time.Time `json:"last_error_at,omitempty"`
+}
+
+func componentHealth(c interface{ Connected() bool }) ComponentHealth {
+	h := ComponentHealth{Connected: c.Connected()}
+	if at, err := component.LastErrorOf(c); err != nil {
+		h.LastError = err.Error()
+		h.LastErrorAt = &at
+	}
+	return h
+}
+
+// Health describes the health of a stream.
+type Health struct {
+	Ready     bool            `json:"ready"`
+	Uptime    float64         `json:"uptime"`
+	UptimeStr string          `json:"uptime_str"`
+	Input     ComponentHealth `json:"input"`
+	Output    ComponentHealth `json:"output"`
+}
+
+// Health returns a detailed description of the health of the stream, including
+// the connection status and most recent error of its input and output.
+func (t *Type) Health() Health {
+	uptime := time.Since(t.createdAt)
+	h := Health{
+		Uptime:    uptime.Seconds(),
+		UptimeStr: uptime.String(),
+		Input:     componentHealth(t.inputLayer),
+		Output:    componentHealth(t.outputLayer),
+	}
+	h.Ready = h.Input.Connected && h.Output.Connected
+	return h
+}
+
+func writeHealthDetail(w http.ResponseWriter, ready bool, detail interface{}) {
+	jBytes, err := json.Marshal(detail)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if !ready {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	_, _ = w.Write(jBytes)
+}
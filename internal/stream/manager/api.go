@@ -31,7 +31,8 @@ import (
 func (m *Type) registerEndpoints(enableCrud bool) {
 	m.manager.RegisterEndpoint(
 		"/ready",
-		"Returns 200 OK if the inputs and outputs of all running streams are connected, otherwise a 503 is returned. If there are no active streams 200 is returned.",
+		"Returns 200 OK if the inputs and outputs of all running streams are connected, otherwise a 503 is returned. If there are no active streams 200 is returned."+
+			" Set the GET parameter detail=true for a JSON object describing the connection status, most recent error and uptime of the components of each stream.",
 		m.HandleStreamReady,
 	)
 	if !enableCrud {
@@ -61,6 +62,13 @@ func (m *Type) registerEndpoints(enableCrud bool) {
 		"POST: Create or replace a given resource configuration of a specified type. Types supported are `cache`, `input`, `output`, `processor` and `rate_limit`.",
 		m.HandleResourceCRUD,
 	)
+	m.manager.RegisterEndpoint(
+		"/debug/streams",
+		"DEBUG: Returns a JSON object containing the total number of goroutines,"+
+			" along with the number of goroutines and messages in flight within"+
+			" each active stream.",
+		m.HandleStreamsDebug,
+	)
 }
 
 // ConfigSet is a map of stream configurations mapped by ID, which can be YAML
@@ -611,6 +619,11 @@ func (m *Type) HandleStreamStats(w http.ResponseWriter, r *http.Request) {
 // HandleStreamReady is an http.HandleFunc for providing a ready check across
 // all streams.
 func (m *Type) HandleStreamReady(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Query().Get("detail") == "true" {
+		m.handleStreamReadyDetail(w)
+		return
+	}
+
 	var notReady []string
 
 	m.lock.Lock()
@@ -629,3 +642,36 @@ func (m *Type) HandleStreamReady(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusServiceUnavailable)
 	fmt.Fprintf(w, "streams %v are not connected\n", strings.Join(notReady, ", "))
 }
+
+func (m *Type) handleStreamReadyDetail(w http.ResponseWriter) {
+	ready := true
+	streams := map[string]stream.Health{}
+
+	m.lock.Lock()
+	for k, v := range m.streams {
+		h := v.strm.Health()
+		if !h.Ready {
+			ready = false
+		}
+		streams[k] = h
+	}
+	m.lock.Unlock()
+
+	jBytes, err := json.Marshal(struct {
+		Ready   bool                     `json:"ready"`
+		Streams map[string]stream.Health `json:"streams"`
+	}{
+		Ready:   ready,
+		Streams: streams,
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if !ready {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	_, _ = w.Write(jBytes)
+}
@@ -886,3 +886,60 @@ file:
 	require.NoError(t, err)
 	assert.Equal(t, `{"id":"second","content":"hello world 2"}`, string(file2Bytes))
 }
+
+func TestTypeAPIStreamsDebug(t *testing.T) {
+	mgr, err := bmanager.NewV2(bmanager.NewResourceConfig(), mock.NewManager(), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	smgr := manager.New(mgr)
+
+	require.NoError(t, smgr.Create("foo", harmlessConf()))
+	require.NoError(t, smgr.Create("bar", harmlessConf()))
+
+	<-time.After(time.Millisecond * 100)
+
+	response := httptest.NewRecorder()
+	smgr.HandleStreamsDebug(response, genRequest("GET", "/debug/streams", nil))
+	assert.Equal(t, http.StatusOK, response.Code)
+
+	stats, err := gabs.ParseJSON(response.Body.Bytes())
+	require.NoError(t, err)
+
+	total, _ := stats.S("goroutines").Data().(float64)
+	assert.Greater(t, total, 0.0, response.Body.String())
+
+	for _, id := range []string{"foo", "bar"} {
+		goroutines, _ := stats.S("streams", id, "goroutines").Data().(float64)
+		assert.Greater(t, goroutines, 0.0, response.Body.String())
+		assert.Less(t, goroutines, total, response.Body.String())
+		assert.True(t, stats.Exists("streams", id, "backlog", "input_pending"), response.Body.String())
+		assert.True(t, stats.Exists("streams", id, "backlog", "output_pending"), response.Body.String())
+	}
+
+	require.NoError(t, smgr.Stop(time.Second))
+}
+
+func TestTypeAPIReadyDetail(t *testing.T) {
+	mgr, err := bmanager.NewV2(bmanager.NewResourceConfig(), mock.NewManager(), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	smgr := manager.New(mgr)
+
+	require.NoError(t, smgr.Create("foo", harmlessConf()))
+
+	<-time.After(time.Millisecond * 100)
+
+	response := httptest.NewRecorder()
+	smgr.HandleStreamReady(response, genRequest("GET", "/ready?detail=true", nil))
+	assert.Equal(t, http.StatusOK, response.Code)
+
+	detail, err := gabs.ParseJSON(response.Body.Bytes())
+	require.NoError(t, err)
+
+	assert.Equal(t, true, detail.S("ready").Data(), response.Body.String())
+	assert.Equal(t, true, detail.S("streams", "foo", "input", "connected").Data(), response.Body.String())
+	assert.Equal(t, true, detail.S("streams", "foo", "output", "connected").Data(), response.Body.String())
+	assert.True(t, detail.Exists("streams", "foo", "uptime"), response.Body.String())
+
+	require.NoError(t, smgr.Stop(time.Second))
+}
@@ -0,0 +1,109 @@
+package manager
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"runtime/pprof"
+	"strconv"
+	"strings"
+)
+
+// streamLabel is the pprof label that goroutines started by a stream are
+// tagged with, which is set to the stream identifier.
+const streamLabel = "benthos_stream"
+
+// backlogGauges are the gauges that track messages that are in flight within
+// components of a stream.
+var backlogGauges = []string{
+	"input_pending",
+	"processor_pending",
+	"output_pending",
+}
+
+var goroutineCountRegexp = regexp.MustCompile(`^(\d+) @`)
+
+// goroutinesByStream returns the total number of goroutines along with the
+// number of goroutines started by each stream, which is derived from the
+// labels of a goroutine profile.
+func goroutinesByStream() (total int, byStream map[string]int) {
+	var buf bytes.Buffer
+	_ = pprof.Lookup("goroutine").WriteTo(&buf, 1)
+
+	byStream = map[string]int{}
+
+	var lastCount int
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if matches := goroutineCountRegexp.FindStringSubmatch(line); matches != nil {
+			lastCount, _ = strconv.Atoi(matches[1])
+			total += lastCount
+			continue
+		}
+		labelsStr := strings.TrimPrefix(line, "# labels: ")
+		if len(labelsStr) == len(line) {
+			continue
+		}
+		var labels map[string]string
+		if err := json.Unmarshal([]byte(labelsStr), &labels); err != nil {
+			continue
+		}
+		if id, exists := labels[streamLabel]; exists {
+			byStream[id] += lastCount
+		}
+	}
+	return
+}
+
+type streamDebugStats struct {
+	Goroutines int              `json:"goroutines"`
+	Backlog    map[string]int64 `json:"backlog"`
+}
+
+// HandleStreamsDebug is an http.HandleFunc for obtaining the number of
+// goroutines and messages in flight within each active stream.
+func (m *Type) HandleStreamsDebug(w http.ResponseWriter, r *http.Request) {
+	total, byStream := goroutinesByStream()
+
+	streams := map[string]streamDebugStats{}
+
+	m.lock.Lock()
+	for id, info := range m.streams {
+		backlog := make(map[string]int64, len(backlogGauges))
+		for _, name := range backlogGauges {
+			backlog[name] = 0
+		}
+		for k, v := range info.metrics.GetCounters() {
+			name := k
+			if i := strings.Index(k, "{"); i >= 0 {
+				name = k[:i]
+			}
+			if _, exists := backlog[name]; exists {
+				backlog[name] += v
+			}
+		}
+		streams[id] = streamDebugStats{
+			Goroutines: byStream[id],
+			Backlog:    backlog,
+		}
+	}
+	m.lock.Unlock()
+
+	jBytes, err := json.Marshal(struct {
+		Goroutines int                         `json:"goroutines"`
+		Streams    map[string]streamDebugStats `json:"streams"`
+	}{
+		Goroutines: total,
+		Streams:    streams,
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(jBytes)
+}
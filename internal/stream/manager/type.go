@@ -1,9 +1,11 @@
 package manager
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"reflect"
+	"runtime/pprof"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -91,8 +93,9 @@ type Type struct {
 	closed  bool
 	streams map[string]*StreamStatus
 
-	manager    bundle.NewManagement
-	apiEnabled bool
+	manager      bundle.NewManagement
+	apiEnabled   bool
+	shutdownConf stream.ShutdownConfig
 
 	lock sync.Mutex
 }
@@ -100,9 +103,10 @@ type Type struct {
 // New creates a new stream manager.Type.
 func New(mgr bundle.NewManagement, opts ...func(*Type)) *Type {
 	t := &Type{
-		streams:    map[string]*StreamStatus{},
-		apiEnabled: true,
-		manager:    mgr,
+		streams:      map[string]*StreamStatus{},
+		apiEnabled:   true,
+		manager:      mgr,
+		shutdownConf: stream.NewShutdownConfig(),
 	}
 	for _, opt := range opts {
 		opt(t)
@@ -121,6 +125,14 @@ func OptAPIEnabled(b bool) func(*Type) {
 	}
 }
 
+// OptShutdown sets the per-phase shutdown timeouts applied to streams created
+// by the manager.
+func OptShutdown(conf stream.ShutdownConfig) func(*Type) {
+	return func(t *Type) {
+		t.shutdownConf = conf
+	}
+}
+
 //------------------------------------------------------------------------------
 
 // Errors specifically returned by a stream manager.
@@ -149,9 +161,16 @@ func (m *Type) Create(id string, conf stream.Config) error {
 	sMgr := m.manager.ForStream(id).WithAddedMetrics(strmFlatMetrics).(bundle.NewManagement)
 
 	var wrapper *StreamStatus
-	strm, err := stream.New(conf, sMgr, stream.OptOnClose(func() {
-		wrapper.setClosed()
-	}))
+	var strm *stream.Type
+	var err error
+
+	// Goroutines started by the stream are labelled with its identifier so
+	// that they can be attributed to it within goroutine profiles.
+	pprof.Do(context.Background(), pprof.Labels(streamLabel, id), func(context.Context) {
+		strm, err = stream.New(conf, sMgr, stream.OptOnClose(func() {
+			wrapper.setClosed()
+		}), stream.OptShutdown(m.shutdownConf))
+	})
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,99 @@
+package stream
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/internal/component"
+	"github.com/benthosdev/benthos/v4/internal/docs"
+)
+
+// ShutdownConfig describes the per-phase timeouts of an ordered stream drain.
+// Empty values indicate that a phase may consume whatever remains of the
+// overall shutdown timeout.
+type ShutdownConfig struct {
+	InputTimeout  string `json:"input_timeout" yaml:"input_timeout"`
+	BufferTimeout string `json:"buffer_timeout" yaml:"buffer_timeout"`
+	OutputTimeout string `json:"output_timeout" yaml:"output_timeout"`
+}
+
+// NewShutdownConfig returns a ShutdownConfig with default values.
+func NewShutdownConfig() ShutdownConfig {
+	return ShutdownConfig{
+		InputTimeout:  "",
+		BufferTimeout: "",
+		OutputTimeout: "",
+	}
+}
+
+// ShutdownSpec returns a docs.FieldSpec describing a ShutdownConfig.
+func ShutdownSpec() docs.FieldSpec {
+	return docs.FieldObject(
+		"shutdown", "Configures the phases of a graceful shutdown. Streams are drained in order by first stopping inputs, then flushing buffers and processing pipelines, and finally waiting for outputs to finish writing. Each phase can be given its own timeout, and when a phase exceeds its deadline the component holding it up is logged.",
+	).WithChildren(
+		docs.FieldString("input_timeout", "The maximum period of time to wait for inputs to stop. When empty the phase may consume the remainder of `shutdown_timeout`.", "5s").HasDefault(""),
+		docs.FieldString("buffer_timeout", "The maximum period of time to wait for buffers and processing pipelines to flush pending messages. When empty the phase may consume the remainder of `shutdown_timeout`.", "10s").HasDefault(""),
+		docs.FieldString("output_timeout", "The maximum period of time to wait for outputs to finish writing in-flight messages. When empty the phase may consume the remainder of `shutdown_timeout`.", "5s").HasDefault(""),
+	).Advanced()
+}
+
+//------------------------------------------------------------------------------
+
+// Drain phases of a stream shutdown.
+const (
+	PhaseInputs  = "inputs"
+	PhaseBuffers = "buffers"
+	PhaseOutputs = "outputs"
+)
+
+// PhaseTimeoutError is returned when a phase of a graceful shutdown exceeds its
+// deadline, and names the component that held it up.
+type PhaseTimeoutError struct {
+	Phase     string
+	Component string
+	Waited    time.Duration
+}
+
+// Error returns a human readable description of the timeout.
+func (e *PhaseTimeoutError) Error() string {
+	return fmt.Sprintf("shutdown phase '%v' timed out after %v waiting for %v to close", e.Phase, e.Waited, e.Component)
+}
+
+// Unwrap returns component.ErrTimeout so that callers can treat the error as a
+// regular timeout.
+func (e *PhaseTimeoutError) Unwrap() error {
+	return component.ErrTimeout
+}
+
+type shutdownPhases struct {
+	input, buffer, output time.Duration
+}
+
+func parseShutdownConfig(conf ShutdownConfig) (p shutdownPhases, err error) {
+	for _, f := range []struct {
+		name string
+		str  string
+		dst  *time.Duration
+	}{
+		{"input_timeout", conf.InputTimeout, &p.input},
+		{"buffer_timeout", conf.BufferTimeout, &p.buffer},
+		{"output_timeout", conf.OutputTimeout, &p.output},
+	} {
+		if f.str == "" {
+			continue
+		}
+		if *f.dst, err = time.ParseDuration(f.str); err != nil {
+			return p, fmt.Errorf("failed to parse shutdown %v: %w", f.name, err)
+		}
+	}
+	return
+}
+
+// phaseDeadline returns the period to allocate to a phase given its configured
+// timeout and the time remaining of the overall deadline.
+func phaseDeadline(phaseTimeout, remaining time.Duration) time.Duration {
+	if phaseTimeout > 0 && phaseTimeout < remaining {
+		return phaseTimeout
+	}
+	return remaining
+}
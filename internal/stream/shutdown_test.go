@@ -0,0 +1,58 @@
+package stream
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/component"
+)
+
+type fakeCloseWaiter struct {
+	closeAfter time.Duration
+}
+
+func (f fakeCloseWaiter) WaitForClose(timeout time.Duration) error {
+	if timeout < f.closeAfter {
+		<-time.After(timeout)
+		return component.ErrTimeout
+	}
+	<-time.After(f.closeAfter)
+	return nil
+}
+
+func TestShutdownPhaseDeadline(t *testing.T) {
+	assert.Equal(t, time.Second, phaseDeadline(0, time.Second))
+	assert.Equal(t, time.Millisecond, phaseDeadline(time.Millisecond, time.Second))
+	assert.Equal(t, time.Second, phaseDeadline(time.Minute, time.Second))
+}
+
+func TestShutdownWaitForPhase(t *testing.T) {
+	require.NoError(t, waitForPhase(PhaseInputs, "input (foo)", fakeCloseWaiter{}, time.Second))
+
+	err := waitForPhase(PhaseBuffers, "buffer (memory)", fakeCloseWaiter{closeAfter: time.Minute}, time.Millisecond*10)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, component.ErrTimeout)
+	assert.EqualError(t, err, "shutdown phase 'buffers' timed out after 10ms waiting for buffer (memory) to close")
+
+	err = waitForPhase(PhaseOutputs, "output (bar)", fakeCloseWaiter{}, 0)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, component.ErrTimeout)
+
+	var pErr *PhaseTimeoutError
+	require.ErrorAs(t, err, &pErr)
+	assert.Equal(t, PhaseOutputs, pErr.Phase)
+	assert.Equal(t, "output (bar)", pErr.Component)
+}
+
+func TestShutdownConfigParse(t *testing.T) {
+	conf := NewShutdownConfig()
+	conf.InputTimeout = "1s"
+	conf.OutputTimeout = "5s"
+
+	phases, err := parseShutdownConfig(conf)
+	require.NoError(t, err)
+	assert.Equal(t, shutdownPhases{input: time.Second, output: time.Second * 5}, phases)
+}
@@ -2,6 +2,8 @@ package stream
 
 import (
 	"bytes"
+	"errors"
+	"fmt"
 	"net/http"
 	"runtime/pprof"
 	"time"
@@ -26,7 +28,11 @@ type Type struct {
 	pipelineLayer pipeline.Type
 	outputLayer   ioutput.Streamed
 
-	manager bundle.NewManagement
+	manager   bundle.NewManagement
+	createdAt time.Time
+
+	shutdownConf ShutdownConfig
+	phases       shutdownPhases
 
 	onClose func()
 }
@@ -34,18 +40,29 @@ type Type struct {
 // New creates a new stream.Type.
 func New(conf Config, mgr bundle.NewManagement, opts ...func(*Type)) (*Type, error) {
 	t := &Type{
-		conf:    conf,
-		manager: mgr,
-		onClose: func() {},
+		conf:      conf,
+		manager:   mgr,
+		createdAt: time.Now(),
+		onClose:   func() {},
 	}
 	for _, opt := range opts {
 		opt(t)
 	}
+	var err error
+	if t.phases, err = parseShutdownConfig(t.shutdownConf); err != nil {
+		return nil, err
+	}
 	if err := t.start(); err != nil {
 		return nil, err
 	}
 
 	healthCheck := func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("detail") == "true" {
+			h := t.Health()
+			writeHealthDetail(w, h.Ready, h)
+			return
+		}
+
 		connected := true
 		if !t.inputLayer.Connected() {
 			connected = false
@@ -63,7 +80,9 @@ func New(conf Config, mgr bundle.NewManagement, opts ...func(*Type)) (*Type, err
 	}
 	t.manager.RegisterEndpoint(
 		"/ready",
-		"Returns 200 OK if all inputs and outputs are connected, otherwise a 503 is returned.",
+		"Returns 200 OK if all inputs and outputs are connected, otherwise a 503 is returned."+
+			" Set the GET parameter detail=true for a JSON object describing the connection status,"+
+			" most recent error and uptime of the stream components.",
 		healthCheck,
 	)
 	return t, nil
@@ -78,6 +97,14 @@ func OptOnClose(onClose func()) func(*Type) {
 	}
 }
 
+// OptShutdown sets the per-phase timeouts applied when the stream is stopped
+// gracefully.
+func OptShutdown(conf ShutdownConfig) func(*Type) {
+	return func(t *Type) {
+		t.shutdownConf = conf
+	}
+}
+
 //------------------------------------------------------------------------------
 
 // IsReady returns a boolean indicating whether both the input and output layers
@@ -86,6 +113,16 @@ func (t *Type) IsReady() bool {
 	return t.inputLayer.Connected() && t.outputLayer.Connected()
 }
 
+// InputLayer returns the input layer of the stream.
+func (t *Type) InputLayer() iinput.Streamed {
+	return t.inputLayer
+}
+
+// OutputLayer returns the output layer of the stream.
+func (t *Type) OutputLayer() ioutput.Streamed {
+	return t.outputLayer
+}
+
 func (t *Type) start() (err error) {
 	// Constructors
 	iMgr := t.manager.IntoPath("input").(bundle.NewManagement)
@@ -145,24 +182,32 @@ func (t *Type) start() (err error) {
 // closing the input layer and waiting for all other layers to terminate by
 // proxy. This should guarantee that all in-flight and buffered data is resolved
 // before shutting down.
+//
+// The drain is performed in phases (inputs, then buffers and pipelines, then
+// outputs), each bounded by its configured shutdown timeout as well as the
+// overall timeout provided. When a phase exceeds its deadline a
+// *PhaseTimeoutError is returned naming the component that held it up.
 func (t *Type) StopGracefully(timeout time.Duration) (err error) {
-	t.inputLayer.CloseAsync()
 	started := time.Now()
-	if err = t.inputLayer.WaitForClose(timeout); err != nil {
+	remaining := func() time.Duration {
+		return timeout - time.Since(started)
+	}
+
+	t.inputLayer.CloseAsync()
+	if err = waitForPhase(PhaseInputs, t.layerName("input"), t.inputLayer, phaseDeadline(t.phases.input, remaining())); err != nil {
 		return
 	}
 
-	var remaining time.Duration
+	// The buffers phase shares a single deadline across both the buffer and the
+	// processing pipeline, as both hold messages that are yet to be flushed.
+	bufferStarted := time.Now()
+	bufferTimeout := phaseDeadline(t.phases.buffer, remaining())
 
 	// If we have a buffer then wait right here. We want to try and allow the
 	// buffer to empty out before prompting the other layers to shut down.
 	if t.bufferLayer != nil {
 		t.bufferLayer.StopConsuming()
-		remaining = timeout - time.Since(started)
-		if remaining < 0 {
-			return component.ErrTimeout
-		}
-		if err = t.bufferLayer.WaitForClose(remaining); err != nil {
+		if err = waitForPhase(PhaseBuffers, t.layerName("buffer"), t.bufferLayer, bufferTimeout); err != nil {
 			return
 		}
 	}
@@ -170,25 +215,41 @@ func (t *Type) StopGracefully(timeout time.Duration) (err error) {
 	// After this point we can start closing the remaining components.
 	if t.pipelineLayer != nil {
 		t.pipelineLayer.CloseAsync()
-		remaining = timeout - time.Since(started)
-		if remaining < 0 {
-			return component.ErrTimeout
-		}
-		if err = t.pipelineLayer.WaitForClose(remaining); err != nil {
+		if err = waitForPhase(PhaseBuffers, "pipeline", t.pipelineLayer, bufferTimeout-time.Since(bufferStarted)); err != nil {
 			return
 		}
 	}
 
 	t.outputLayer.CloseAsync()
-	remaining = timeout - time.Since(started)
-	if remaining < 0 {
-		return component.ErrTimeout
+	return waitForPhase(PhaseOutputs, t.layerName("output"), t.outputLayer, phaseDeadline(t.phases.output, remaining()))
+}
+
+type closeWaiter interface {
+	WaitForClose(timeout time.Duration) error
+}
+
+func waitForPhase(phase, name string, c closeWaiter, timeout time.Duration) error {
+	if timeout <= 0 {
+		return &PhaseTimeoutError{Phase: phase, Component: name}
 	}
-	if err = t.outputLayer.WaitForClose(remaining); err != nil {
-		return
+	err := c.WaitForClose(timeout)
+	if errors.Is(err, component.ErrTimeout) {
+		return &PhaseTimeoutError{Phase: phase, Component: name, Waited: timeout}
 	}
+	return err
+}
 
-	return nil
+// layerName returns a descriptive name of a stream layer for use in logs.
+func (t *Type) layerName(layer string) string {
+	switch layer {
+	case "input":
+		return fmt.Sprintf("input (%v)", t.conf.Input.Type)
+	case "buffer":
+		return fmt.Sprintf("buffer (%v)", t.conf.Buffer.Type)
+	case "output":
+		return fmt.Sprintf("output (%v)", t.conf.Output.Type)
+	}
+	return layer
 }
 
 // StopOrdered attempts to close all components of the stream in the order of
@@ -300,7 +361,10 @@ func (t *Type) Stop(timeout time.Duration) error {
 	if err == nil {
 		return nil
 	}
-	if err == component.ErrTimeout {
+	var pErr *PhaseTimeoutError
+	if errors.As(err, &pErr) {
+		t.manager.Logger().Warnf("Unable to fully drain buffered messages within target time, %v\n", pErr)
+	} else if errors.Is(err, component.ErrTimeout) {
 		t.manager.Logger().Infoln("Unable to fully drain buffered messages within target time.")
 	} else {
 		t.manager.Logger().Errorf("Encountered error whilst shutting down: %v\n", err)
@@ -310,7 +374,7 @@ func (t *Type) Stop(timeout time.Duration) error {
 	if err == nil {
 		return nil
 	}
-	if err == component.ErrTimeout {
+	if errors.Is(err, component.ErrTimeout) {
 		t.manager.Logger().Errorln("Failed to stop stream gracefully within target time.")
 
 		dumpBuf := bytes.NewBuffer(nil)
@@ -1,6 +1,9 @@
 package stream_test
 
 import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
@@ -122,3 +125,70 @@ func TestTypeCloseUnordered(t *testing.T) {
 	require.NoError(t, err)
 	assert.NoError(t, strm.StopUnordered(time.Minute))
 }
+
+func TestTypeHealthDetail(t *testing.T) {
+	conf := stream.NewConfig()
+	conf.Input.Type = input.TypeHTTPServer
+	conf.Output.Type = output.TypeSocket
+	conf.Output.Socket.Network = "tcp"
+	conf.Output.Socket.Address = "localhost:1"
+
+	var readyHandler http.HandlerFunc
+	mockAPI := mock.NewManager()
+	mockAPI.OnRegisterEndpoint = func(path string, h http.HandlerFunc) {
+		if path == "/ready" {
+			readyHandler = h
+		}
+	}
+
+	newMgr, err := manager.NewV2(manager.NewResourceConfig(), mockAPI, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	strm, err := stream.New(conf, newMgr)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		assert.NoError(t, strm.Stop(time.Minute))
+	})
+
+	require.Eventually(t, func() bool {
+		return strm.Health().Output.LastError != ""
+	}, time.Second*5, time.Millisecond*10)
+
+	h := strm.Health()
+	assert.False(t, h.Ready)
+	assert.True(t, h.Input.Connected)
+	assert.Empty(t, h.Input.LastError)
+	assert.False(t, h.Output.Connected)
+	assert.Contains(t, h.Output.LastError, "connection refused")
+	assert.NotNil(t, h.Output.LastErrorAt)
+	assert.Greater(t, h.Uptime, 0.0)
+
+	require.NotNil(t, readyHandler)
+
+	res := httptest.NewRecorder()
+	readyHandler(res, httptest.NewRequest("GET", "/ready?detail=true", http.NoBody))
+	assert.Equal(t, http.StatusServiceUnavailable, res.Code)
+
+	var resBody stream.Health
+	require.NoError(t, json.Unmarshal(res.Body.Bytes(), &resBody))
+	assert.False(t, resBody.Ready)
+	assert.True(t, resBody.Input.Connected)
+	assert.Contains(t, resBody.Output.LastError, "connection refused")
+}
+
+func TestTypeShutdownBadConfig(t *testing.T) {
+	conf := stream.NewConfig()
+	conf.Input.Type = input.TypeGenerate
+	conf.Input.Generate.Mapping = `root = "hello world"`
+	conf.Output.Type = output.TypeDrop
+
+	newMgr, err := manager.NewV2(manager.NewResourceConfig(), mock.NewManager(), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	shutConf := stream.NewShutdownConfig()
+	shutConf.OutputTimeout = "not a duration"
+
+	_, err = stream.New(conf, newMgr, stream.OptShutdown(shutConf))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "output_timeout")
+}
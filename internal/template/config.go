@@ -19,12 +19,15 @@ import (
 
 // FieldConfig describes a configuration field used in the template.
 type FieldConfig struct {
-	Name        string       `yaml:"name"`
-	Description string       `yaml:"description"`
-	Type        *string      `yaml:"type,omitempty"`
-	Kind        *string      `yaml:"kind,omitempty"`
-	Default     *interface{} `yaml:"default,omitempty"`
-	Advanced    bool         `yaml:"advanced"`
+	Name        string        `yaml:"name"`
+	Description string        `yaml:"description"`
+	Type        *string       `yaml:"type,omitempty"`
+	Kind        *string       `yaml:"kind,omitempty"`
+	Default     *interface{}  `yaml:"default,omitempty"`
+	Advanced    bool          `yaml:"advanced"`
+	Options     []string      `yaml:"options,omitempty"`
+	Linter      string        `yaml:"linter,omitempty"`
+	Examples    []interface{} `yaml:"examples,omitempty"`
 }
 
 // TestConfig defines a unit test for the template.
@@ -50,7 +53,7 @@ type Config struct {
 
 // FieldSpec creates a documentation field spec from a template field config.
 func (c FieldConfig) FieldSpec() (docs.FieldSpec, error) {
-	f := docs.FieldAnything(c.Name, c.Description)
+	f := docs.FieldAnything(c.Name, c.Description, c.Examples...)
 	f.IsAdvanced = c.Advanced
 	if c.Default != nil {
 		f = f.HasDefault(*c.Default)
@@ -70,6 +73,18 @@ func (c FieldConfig) FieldSpec() (docs.FieldSpec, error) {
 			return f, fmt.Errorf("unrecognised scalar type: %v", *c.Kind)
 		}
 	}
+	if len(c.Options) > 0 && c.Linter != "" {
+		return f, errors.New("a field cannot specify both options and a linter")
+	}
+	if len(c.Options) > 0 {
+		f = f.HasOptions(c.Options...)
+	}
+	if c.Linter != "" {
+		if _, err := bloblang.NewEnvironment().OnlyPure().NewMapping(c.Linter); err != nil {
+			return f, fmt.Errorf("parse linter: %w", err)
+		}
+		f = f.LinterBlobl(c.Linter)
+	}
 	return f, nil
 }
 
@@ -159,6 +174,9 @@ func (c Config) Test() ([]string, error) {
 
 	var failures []string
 	for _, test := range c.Tests {
+		for _, lint := range compiled.spec.Config.Children.LintYAML(docs.NewLintContext(), &test.Config) {
+			failures = append(failures, fmt.Sprintf("test '%v': lint error in config: line %v: %v", test.Name, lint.Line, lint.What))
+		}
 		outConf, err := compiled.ExpandToNode(&test.Config)
 		if err != nil {
 			return nil, fmt.Errorf("test '%v': %w", test.Name, err)
@@ -223,6 +241,9 @@ func FieldConfigSpec() docs.FieldSpecs {
 		).HasDefault("scalar"),
 		docs.FieldAnything("default", "An optional default value for the field. If a default value is not specified then a configuration without the field is considered incorrect.").Optional(),
 		docs.FieldBool("advanced", "Whether this field is considered advanced.").HasDefault(false),
+		docs.FieldString("options", "An optional list of values that the field is restricted to, where any other value results in a linting error.").Array().AtVersion("4.1.0").Optional(),
+		docs.FieldBloblang("linter", "An optional [Bloblang](/docs/guides/bloblang/about) mapping that validates the value of the field, where `this` is the value and the mapping returns either a string or an array of strings describing problems with it, which are reported as linting errors. Only pure functions are available within the mapping.", `root = if this < 1 || this > 100 { "value must be between 1 and 100" }`).AtVersion("4.1.0").Optional(),
+		docs.FieldAnything("examples", "An optional list of example values of the field, which are included in the documentation of the template.").Array().AtVersion("4.1.0").Optional(),
 	}
 }
 
@@ -8,6 +8,9 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/benthosdev/benthos/v4/internal/bundle"
+	"github.com/benthosdev/benthos/v4/internal/config"
+	"github.com/benthosdev/benthos/v4/internal/docs"
 	"github.com/benthosdev/benthos/v4/internal/template"
 	_ "github.com/benthosdev/benthos/v4/public/components/all"
 )
@@ -29,3 +32,80 @@ func TestTemplateTesting(t *testing.T) {
 		})
 	}
 }
+
+func TestTemplateFieldValidation(t *testing.T) {
+	tmplPath := filepath.Join(t.TempDir(), "validated.yaml")
+	require.NoError(t, os.WriteFile(tmplPath, []byte(`
+name: validated_template_test
+type: processor
+
+fields:
+  - name: mode
+    type: string
+    options: [ upper, lower ]
+  - name: count
+    type: int
+    default: 1
+    linter: 'root = if this < 1 || this > 10 { "count must be between 1 and 10" }'
+
+mapping: |
+  root.bloblang = if this.mode == "upper" { "root = content().uppercase()" } else { "root = content().lowercase()" }
+
+tests:
+  - name: valid
+    config:
+      mode: upper
+      count: 5
+  - name: invalid
+    config:
+      mode: nope
+      count: 20
+`), 0o644))
+
+	conf, lints, err := template.ReadConfig(tmplPath)
+	require.NoError(t, err)
+	assert.Empty(t, lints)
+
+	testErrs, err := conf.Test()
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"test 'invalid': lint error in config: line 24: value nope is not a valid option for this field",
+		"test 'invalid': lint error in config: line 25: count must be between 1 and 10",
+	}, testErrs)
+
+	_, err = template.InitTemplates(tmplPath)
+	require.NoError(t, err)
+
+	lintCtx := docs.NewLintContext()
+	lintCtx.DocsProvider = bundle.GlobalEnvironment
+	confLints, err := config.LintBytes(lintCtx, []byte(`
+pipeline:
+  processors:
+    - validated_template_test:
+        mode: sideways
+        count: 0
+`))
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"line 5: value sideways is not a valid option for this field",
+		"line 6: count must be between 1 and 10",
+	}, confLints)
+}
+
+func TestTemplateFieldValidationErrors(t *testing.T) {
+	tmplPath := filepath.Join(t.TempDir(), "invalid.yaml")
+	require.NoError(t, os.WriteFile(tmplPath, []byte(`
+name: invalid_template_test
+type: processor
+fields:
+  - name: mode
+    type: string
+    options: [ upper, lower ]
+    linter: 'root = "nope"'
+mapping: 'root.noop = {}'
+`), 0o644))
+
+	_, err := template.InitTemplates(tmplPath)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "a field cannot specify both options and a linter")
+}
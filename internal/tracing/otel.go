@@ -190,6 +190,74 @@ func InitSpansFromParentTextMap(operationName string, textMapGeneric map[string]
 	return nil
 }
 
+// InitSpansFromMetadata obtains a span parent reference from the metadata of
+// each message part, and for parts containing one that do not already have a
+// span attached creates a child span. Parts without a span parent reference
+// are unchanged.
+func InitSpansFromMetadata(operationName string, msg *message.Batch) {
+	tracedParts := make([]*message.Part, msg.Len())
+	_ = msg.Iter(func(i int, p *message.Part) error {
+		tracedParts[i] = InitSpanFromCarrier(operationName, metadataCarrier{p: p}, p)
+		return nil
+	})
+	msg.SetAll(tracedParts)
+}
+
+// InitSpanFromCarrier obtains a span parent reference from a text map carrier,
+// such as the headers of an HTTP request, and if one is found creates a child
+// span on a message part that does not already have a span attached.
+func InitSpanFromCarrier(operationName string, c propagation.TextMapCarrier, part *message.Part) *message.Part {
+	if GetSpan(part) != nil {
+		return part
+	}
+	ctx := otel.GetTextMapPropagator().Extract(context.Background(), c)
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		return part
+	}
+	pCtx, _ := otel.GetTracerProvider().Tracer(name).Start(ctx, operationName)
+	return message.WithContext(pCtx, part)
+}
+
+// InjectSpansMetadata injects the span propagation information of each span
+// into the metadata of the corresponding message part. The length of the spans
+// slice must match the message size.
+func InjectSpansMetadata(spans []*Span, msg *message.Batch) {
+	_ = msg.Iter(func(i int, p *message.Part) error {
+		if i < len(spans) && spans[i] != nil {
+			otel.GetTextMapPropagator().Inject(spans[i].ctx, metadataCarrier{p: p})
+		}
+		return nil
+	})
+}
+
+// InjectPartCarrier injects the span propagation information of the span
+// attached to a message part, if there is one, into a text map carrier such as
+// the headers of an HTTP request.
+func InjectPartCarrier(part *message.Part, c propagation.TextMapCarrier) {
+	otel.GetTextMapPropagator().Inject(message.GetContext(part), c)
+}
+
+type metadataCarrier struct {
+	p *message.Part
+}
+
+func (m metadataCarrier) Get(key string) string {
+	return m.p.MetaGet(key)
+}
+
+func (m metadataCarrier) Set(key, value string) {
+	m.p.MetaSet(key, value)
+}
+
+func (m metadataCarrier) Keys() []string {
+	var keys []string
+	_ = m.p.MetaIter(func(k, _ string) error {
+		keys = append(keys, k)
+		return nil
+	})
+	return keys
+}
+
 // FinishSpans calls Finish on all message parts containing a span.
 func FinishSpans(msg *message.Batch) {
 	_ = msg.Iter(func(i int, p *message.Part) error {
@@ -0,0 +1,48 @@
+package tracing
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+func TestMetadataPropagation(t *testing.T) {
+	prevProv, prevProp := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	t.Cleanup(func() {
+		otel.SetTracerProvider(prevProv)
+		otel.SetTextMapPropagator(prevProp)
+	})
+	otel.SetTracerProvider(tracesdk.NewTracerProvider())
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	outMsg := message.QuickBatch([][]byte{[]byte("foo"), []byte("bar")})
+	InitSpans("output_foo", outMsg)
+	spans := CreateChildSpans("output_foo", outMsg)
+	InjectSpansMetadata(spans, outMsg)
+
+	for i := 0; i < outMsg.Len(); i++ {
+		assert.NotEmpty(t, outMsg.Get(i).MetaGet("traceparent"), i)
+	}
+
+	inMsg := message.QuickBatch([][]byte{[]byte("foo"), []byte("bar"), []byte("baz")})
+	inMsg.Get(0).MetaSet("traceparent", outMsg.Get(0).MetaGet("traceparent"))
+	inMsg.Get(1).MetaSet("traceparent", outMsg.Get(1).MetaGet("traceparent"))
+	InitSpansFromMetadata("input_foo", inMsg)
+
+	for i := 0; i < 2; i++ {
+		span := GetSpan(inMsg.Get(i))
+		require.NotNil(t, span, i)
+		assert.Equal(t,
+			trace.SpanContextFromContext(spans[i].ctx).TraceID(),
+			span.unwrap().SpanContext().TraceID(),
+		)
+	}
+	assert.Nil(t, GetSpan(inMsg.Get(2)))
+}
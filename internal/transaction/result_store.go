@@ -5,6 +5,7 @@ import (
 	"errors"
 	"sync"
 
+	"github.com/benthosdev/benthos/v4/internal/bloblang/mapping"
 	"github.com/benthosdev/benthos/v4/internal/message"
 )
 
@@ -109,3 +110,57 @@ func SetAsResponse(msg *message.Batch) error {
 }
 
 //------------------------------------------------------------------------------
+
+// JoinResults flattens the messages of each batch added to a ResultStore, which
+// may have been added by multiple outputs of a broker, into a single batch.
+func JoinResults(store ResultStore) *message.Batch {
+	joined := message.QuickBatch(nil)
+	for _, resMsg := range store.Get() {
+		_ = resMsg.Iter(func(i int, part *message.Part) error {
+			joined.Append(part)
+			return nil
+		})
+	}
+	return joined
+}
+
+// MergeResults joins the messages of each batch added to a ResultStore and
+// reduces them into a batch of a single message by executing a mapping. The
+// mapping is executed against a document that is an array of the contents of
+// each message, parsed as JSON where possible and as a string otherwise, and
+// with the metadata of the first message.
+//
+// If the store is empty then an empty batch is returned.
+func MergeResults(store ResultStore, merge *mapping.Executor) (*message.Batch, error) {
+	joined := JoinResults(store)
+	if joined.Len() == 0 {
+		return joined, nil
+	}
+
+	contents := make([]interface{}, joined.Len())
+	_ = joined.Iter(func(i int, part *message.Part) error {
+		if v, err := part.JSON(); err == nil {
+			contents[i] = v
+		} else {
+			contents[i] = string(part.Get())
+		}
+		return nil
+	})
+
+	merged := joined.Get(0).Copy()
+	merged.SetJSON(contents)
+
+	mergedMsg := message.QuickBatch(nil)
+	mergedMsg.Append(merged)
+
+	part, err := merge.MapPart(0, mergedMsg)
+	if err != nil {
+		return nil, err
+	}
+
+	mergedMsg = message.QuickBatch(nil)
+	if part != nil {
+		mergedMsg.Append(part)
+	}
+	return mergedMsg, nil
+}
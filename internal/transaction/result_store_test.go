@@ -4,6 +4,10 @@ import (
 	"context"
 	"testing"
 
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang"
 	"github.com/benthosdev/benthos/v4/internal/message"
 )
 
@@ -39,3 +43,30 @@ func TestResultStore(t *testing.T) {
 		t.Errorf("Unexpected count of stored messages: %v != %v", act, exp)
 	}
 }
+
+func TestMergeResults(t *testing.T) {
+	store := NewResultStore()
+
+	first := message.QuickBatch([][]byte{[]byte(`{"a":"first"}`)})
+	first.Get(0).MetaSet("foo", "bar")
+	store.Add(first)
+	store.Add(message.QuickBatch([][]byte{[]byte(`not json`), []byte(`{"b":"third"}`)}))
+
+	joined := JoinResults(store)
+	require.Equal(t, 3, joined.Len())
+	assert.Equal(t, `not json`, string(joined.Get(1).Get()))
+
+	merge, err := bloblang.GlobalEnvironment().NewMapping(`root = this
+meta result = meta("foo")`)
+	require.NoError(t, err)
+
+	merged, err := MergeResults(store, merge)
+	require.NoError(t, err)
+	require.Equal(t, 1, merged.Len())
+	assert.Equal(t, `[{"a":"first"},"not json",{"b":"third"}]`, string(merged.Get(0).Get()))
+	assert.Equal(t, "bar", merged.Get(0).MetaGet("result"))
+
+	merged, err = MergeResults(NewResultStore(), merge)
+	require.NoError(t, err)
+	assert.Equal(t, 0, merged.Len())
+}
@@ -12,11 +12,14 @@ import (
 	_ "github.com/benthosdev/benthos/v4/internal/impl/amqp1"
 	_ "github.com/benthosdev/benthos/v4/internal/impl/aws"
 	_ "github.com/benthosdev/benthos/v4/internal/impl/confluent"
+	_ "github.com/benthosdev/benthos/v4/internal/impl/container"
 	_ "github.com/benthosdev/benthos/v4/internal/impl/dgraph"
+	_ "github.com/benthosdev/benthos/v4/internal/impl/email"
 	_ "github.com/benthosdev/benthos/v4/internal/impl/fs"
 	_ "github.com/benthosdev/benthos/v4/internal/impl/gcp"
 	_ "github.com/benthosdev/benthos/v4/internal/impl/influxdb"
 	_ "github.com/benthosdev/benthos/v4/internal/impl/jaeger"
+	_ "github.com/benthosdev/benthos/v4/internal/impl/javascript"
 	_ "github.com/benthosdev/benthos/v4/internal/impl/kafka"
 	_ "github.com/benthosdev/benthos/v4/internal/impl/maxmind"
 	_ "github.com/benthosdev/benthos/v4/internal/impl/memcached"
@@ -25,6 +28,7 @@ import (
 	_ "github.com/benthosdev/benthos/v4/internal/impl/msgpack"
 	_ "github.com/benthosdev/benthos/v4/internal/impl/nats"
 	_ "github.com/benthosdev/benthos/v4/internal/impl/net"
+	_ "github.com/benthosdev/benthos/v4/internal/impl/otlp"
 	_ "github.com/benthosdev/benthos/v4/internal/impl/parquet"
 	_ "github.com/benthosdev/benthos/v4/internal/impl/prometheus"
 	_ "github.com/benthosdev/benthos/v4/internal/impl/pure"
@@ -32,12 +36,16 @@ import (
 	_ "github.com/benthosdev/benthos/v4/internal/impl/snowflake"
 	_ "github.com/benthosdev/benthos/v4/internal/impl/sql"
 	_ "github.com/benthosdev/benthos/v4/internal/impl/statsd"
+	_ "github.com/benthosdev/benthos/v4/internal/impl/vault"
+	_ "github.com/benthosdev/benthos/v4/internal/impl/webdav"
 	"github.com/benthosdev/benthos/v4/internal/template"
 
 	// Import all (supported) sql drivers
 	_ "github.com/denisenkom/go-mssqldb"
 	_ "github.com/go-sql-driver/mysql"
 	_ "github.com/lib/pq"
+	_ "github.com/snowflakedb/gosnowflake"
+	_ "modernc.org/sqlite"
 )
 
 func init() {
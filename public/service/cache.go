@@ -55,17 +55,29 @@ type batchedCache interface {
 	SetMulti(ctx context.Context, keyValues ...CacheItem) error
 }
 
+// batchedGetCache represents a cache where the underlying implementation is
+// able to benefit from batched get requests. This interface is optional for
+// caches and when implemented will automatically be utilised where possible.
+type batchedGetCache interface {
+	// GetMulti attempts to obtain the values of multiple keys in as few
+	// requests as possible, keys that do not exist are omitted from the
+	// result.
+	GetMulti(ctx context.Context, keys ...string) (map[string][]byte, error)
+}
+
 //------------------------------------------------------------------------------
 
 // Implements types.Cache
 type airGapCache struct {
-	c  Cache
-	cm batchedCache
+	c   Cache
+	cm  batchedCache
+	cgm batchedGetCache
 }
 
 func newAirGapCache(c Cache, stats metrics.Type) cache.V1 {
-	ag := &airGapCache{c, nil}
+	ag := &airGapCache{c: c}
 	ag.cm, _ = c.(batchedCache)
+	ag.cgm, _ = c.(batchedGetCache)
 	return cache.MetricsForCache(ag, stats)
 }
 
@@ -77,6 +89,24 @@ func (a *airGapCache) Get(ctx context.Context, key string) ([]byte, error) {
 	return b, err
 }
 
+func (a *airGapCache) GetMulti(ctx context.Context, keys ...string) (map[string][]byte, error) {
+	if a.cgm != nil {
+		return a.cgm.GetMulti(ctx, keys...)
+	}
+	res := make(map[string][]byte, len(keys))
+	for _, k := range keys {
+		b, err := a.c.Get(ctx, k)
+		if err != nil {
+			if errors.Is(err, ErrKeyNotFound) {
+				continue
+			}
+			return nil, err
+		}
+		res[k] = b
+	}
+	return res, nil
+}
+
 func (a *airGapCache) Set(ctx context.Context, key string, value []byte, ttl *time.Duration) error {
 	return a.c.Set(ctx, key, value, ttl)
 }
@@ -30,7 +30,7 @@ func (c *closableCache) Get(ctx context.Context, key string) ([]byte, error) {
 	}
 	i, ok := c.m[key]
 	if !ok {
-		return nil, component.ErrKeyNotFound
+		return nil, ErrKeyNotFound
 	}
 	return i.b, nil
 }
@@ -120,6 +120,62 @@ func TestCacheAirGapGet(t *testing.T) {
 	assert.EqualError(t, err, "key does not exist")
 }
 
+type closableCacheGetMulti struct {
+	*closableCache
+
+	getMultiCalls int
+}
+
+func (c *closableCacheGetMulti) GetMulti(ctx context.Context, keys ...string) (map[string][]byte, error) {
+	c.getMultiCalls++
+	res := map[string][]byte{}
+	for _, k := range keys {
+		if i, ok := c.m[k]; ok {
+			res[k] = i.b
+		}
+	}
+	return res, nil
+}
+
+func TestCacheAirGapGetMulti(t *testing.T) {
+	ctx := context.Background()
+	rl := &closableCache{
+		m: map[string]testCacheItem{
+			"foo": {b: []byte("bar")},
+			"baz": {b: []byte("buz")},
+		},
+	}
+	agrl := newAirGapCache(rl, metrics.Noop())
+
+	res, err := agrl.GetMulti(ctx, "foo", "not exist", "baz")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string][]byte{
+		"foo": []byte("bar"),
+		"baz": []byte("buz"),
+	}, res)
+
+	rl.err = errors.New("nope")
+	_, err = agrl.GetMulti(ctx, "foo")
+	assert.EqualError(t, err, "nope")
+}
+
+func TestCacheAirGapGetMultiPassthrough(t *testing.T) {
+	ctx := context.Background()
+	rl := &closableCacheGetMulti{
+		closableCache: &closableCache{
+			m: map[string]testCacheItem{
+				"foo": {b: []byte("bar")},
+			},
+		},
+	}
+	agrl := newAirGapCache(rl, metrics.Noop())
+
+	res, err := agrl.GetMulti(ctx, "foo", "not exist")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string][]byte{"foo": []byte("bar")}, res)
+	assert.Equal(t, 1, rl.getMultiCalls)
+}
+
 func TestCacheAirGapSet(t *testing.T) {
 	ctx := context.Background()
 	rl := &closableCache{
@@ -317,6 +373,10 @@ func (c *closableCacheType) Get(ctx context.Context, key string) ([]byte, error)
 	return i.b, nil
 }
 
+func (c *closableCacheType) GetMulti(ctx context.Context, keys ...string) (map[string][]byte, error) {
+	return nil, errors.New("not implemented")
+}
+
 func (c *closableCacheType) Set(ctx context.Context, key string, value []byte, ttl *time.Duration) error {
 	if c.err != nil {
 		return c.err
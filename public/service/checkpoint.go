@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/benthosdev/benthos/v4/internal/checkpoint"
+	"github.com/benthosdev/benthos/v4/internal/component"
+)
+
+// CheckpointStore persists the positions of inputs that lack native offset
+// storage in order for them to resume from where they left off after a
+// restart. Checkpoint stores are declared as checkpoint resources and accessed
+// by name via Resources.
+//
+// Reads and writes of positions emit the metrics checkpoint_store_get,
+// checkpoint_store_set, checkpoint_store_delete, checkpoint_store_error and
+// checkpoint_store_latency_ns.
+type CheckpointStore interface {
+	// Get returns the stored position of a key, or ErrKeyNotFound if a
+	// position has not been stored for the key.
+	Get(ctx context.Context, key string) ([]byte, error)
+
+	// Set stores the position of a key.
+	Set(ctx context.Context, key string, value []byte) error
+
+	// Delete removes the stored position of a key.
+	Delete(ctx context.Context, key string) error
+}
+
+//------------------------------------------------------------------------------
+
+// Implements CheckpointStore around a checkpoint resource.
+type reverseAirGapCheckpointStore struct {
+	s *checkpoint.Store
+}
+
+func (r *reverseAirGapCheckpointStore) Get(ctx context.Context, key string) ([]byte, error) {
+	v, err := r.s.Get(ctx, key)
+	if errors.Is(err, component.ErrKeyNotFound) {
+		err = ErrKeyNotFound
+	}
+	return v, err
+}
+
+func (r *reverseAirGapCheckpointStore) Set(ctx context.Context, key string, value []byte) error {
+	return r.s.Set(ctx, key, value)
+}
+
+func (r *reverseAirGapCheckpointStore) Delete(ctx context.Context, key string) error {
+	return r.s.Delete(ctx, key)
+}
@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/checkpoint"
+	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+	"github.com/benthosdev/benthos/v4/internal/log"
+	"github.com/benthosdev/benthos/v4/internal/manager"
+)
+
+func TestCheckpointResourceDirectory(t *testing.T) {
+	dir := t.TempDir()
+
+	cConf := checkpoint.NewStoreConfig()
+	cConf.Label = "foo"
+	cConf.Directory = dir
+	cConf.KeyPrefix = "foo_"
+
+	mConf := manager.NewResourceConfig()
+	mConf.ResourceCheckpoints = append(mConf.ResourceCheckpoints, cConf)
+
+	mgr, err := manager.NewV2(mConf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	res := newResourcesFromManager(mgr)
+	assert.True(t, res.HasCheckpoint("foo"))
+	assert.False(t, res.HasCheckpoint("bar"))
+
+	require.Error(t, res.AccessCheckpoint(context.Background(), "bar", func(c CheckpointStore) {
+		t.Error("should not be called")
+	}))
+
+	var s CheckpointStore
+	require.NoError(t, res.AccessCheckpoint(context.Background(), "foo", func(c CheckpointStore) {
+		s = c
+	}))
+	require.NotNil(t, s)
+
+	ctx := context.Background()
+
+	_, err = s.Get(ctx, "bar")
+	assert.Equal(t, ErrKeyNotFound, err)
+
+	require.NoError(t, s.Set(ctx, "bar", []byte("baz")))
+
+	v, err := s.Get(ctx, "bar")
+	require.NoError(t, err)
+	assert.Equal(t, "baz", string(v))
+
+	fileBytes, err := os.ReadFile(filepath.Join(dir, "foo_bar"))
+	require.NoError(t, err)
+	assert.Equal(t, "baz", string(fileBytes))
+
+	require.NoError(t, s.Delete(ctx, "bar"))
+
+	_, err = s.Get(ctx, "bar")
+	assert.Equal(t, ErrKeyNotFound, err)
+}
+
+func TestCheckpointResourceErrors(t *testing.T) {
+	mConf := manager.NewResourceConfig()
+	cConf := checkpoint.NewStoreConfig()
+	cConf.Label = "foo"
+	mConf.ResourceCheckpoints = append(mConf.ResourceCheckpoints, cConf)
+
+	_, err := manager.NewV2(mConf, nil, log.Noop(), metrics.Noop())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "must have either a cache or a directory")
+
+	cConf.Cache = "nope"
+	mConf.ResourceCheckpoints = []checkpoint.StoreConfig{cConf}
+
+	_, err = manager.NewV2(mConf, nil, log.Noop(), metrics.Noop())
+	require.Error(t, err)
+}
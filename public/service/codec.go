@@ -0,0 +1,200 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+
+	"github.com/benthosdev/benthos/v4/internal/codec"
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+// CodecReader is an interface implemented by codec plugins that convert the
+// bytes of a data source into discrete message batches. Reader codecs can be
+// used from the `codec` field of inputs such as `file`, `socket`, `sftp` and
+// `aws_s3`.
+type CodecReader interface {
+	// Next returns the next message batch of the data source. Once the data
+	// source is fully consumed io.EOF must be returned.
+	Next(ctx context.Context) (MessageBatch, error)
+
+	// Close the codec along with the underlying data source.
+	Close(ctx context.Context) error
+}
+
+// CodecReaderConstructor is a func that's provided the arguments of a codec,
+// which are the characters following the first colon of the codec name (e.g.
+// `foo:bar` results in the arguments `bar`), the path of the data source if
+// applicable, and the data source itself. It must return a CodecReader, or an
+// error.
+type CodecReaderConstructor func(args, path string, r io.ReadCloser) (CodecReader, error)
+
+// RegisterCodecReader attempts to register a new reader codec plugin. Built-in
+// codecs take precedence over plugins of the same name, and the name must not
+// contain the characters `:` or `/`, which are used for providing arguments and
+// chaining codecs respectively.
+//
+// Codec plugins are available to all environments, and therefore there is no
+// equivalent method for registering a codec to a specific Environment.
+//
+// Experimental: This function may change outside of major version releases.
+func RegisterCodecReader(name string, ctor CodecReaderConstructor) error {
+	return codec.RegisterReaderPlugin(name, func(args string, conf codec.ReaderConfig) (codec.ReaderConstructor, error) {
+		return func(path string, r io.ReadCloser, ackFn codec.ReaderAckFn) (codec.Reader, error) {
+			cr, err := ctor(args, path, r)
+			if err != nil {
+				return nil, err
+			}
+			return newAirGapCodecReader(cr, ackFn), nil
+		}, nil
+	})
+}
+
+// CodecWriter is an interface implemented by codec plugins that write messages
+// into a data stream. Writer codecs can be used from the `codec` field of
+// outputs such as `file`, `socket` and `sftp`.
+type CodecWriter interface {
+	// Write a message to the data stream.
+	Write(ctx context.Context, msg *Message) error
+
+	// Close the codec along with the underlying data stream.
+	Close(ctx context.Context) error
+}
+
+// CodecWriterConfig describes how outputs should open the data streams that a
+// writer codec writes to.
+type CodecWriterConfig struct {
+	// Append indicates that messages should be appended to existing data.
+	Append bool
+
+	// Truncate indicates that existing data should be removed before writing.
+	Truncate bool
+
+	// CloseAfter indicates that the data stream should be closed after each
+	// message is written.
+	CloseAfter bool
+}
+
+// CodecWriterConstructor is a func that's provided the arguments of a codec,
+// which are the characters following the first colon of the codec name, and
+// the data stream to write to. It must return a CodecWriter, or an error.
+type CodecWriterConstructor func(args string, w io.WriteCloser) (CodecWriter, error)
+
+// RegisterCodecWriter attempts to register a new writer codec plugin along with
+// a config that describes how outputs should open the data streams it writes
+// to. Built-in codecs take precedence over plugins of the same name, and the
+// name must not contain the characters `:` or `/`.
+//
+// Codec plugins are available to all environments, and therefore there is no
+// equivalent method for registering a codec to a specific Environment.
+//
+// Experimental: This function may change outside of major version releases.
+func RegisterCodecWriter(name string, conf CodecWriterConfig, ctor CodecWriterConstructor) error {
+	wConf := codec.WriterConfig{
+		Append:     conf.Append,
+		Truncate:   conf.Truncate,
+		CloseAfter: conf.CloseAfter,
+	}
+	return codec.RegisterWriterPlugin(name, func(args string) (codec.WriterConstructor, codec.WriterConfig, error) {
+		return func(w io.WriteCloser) (codec.Writer, error) {
+			cw, err := ctor(args, w)
+			if err != nil {
+				return nil, err
+			}
+			return &airGapCodecWriter{cw}, nil
+		}, wConf, nil
+	})
+}
+
+//------------------------------------------------------------------------------
+
+// airGapCodecReader tracks the acknowledgements of batches produced by a codec
+// plugin and acknowledges the data source once it has been fully consumed and
+// all batches are acknowledged.
+type airGapCodecReader struct {
+	r         CodecReader
+	sourceAck codec.ReaderAckFn
+
+	mut      sync.Mutex
+	finished bool
+	pending  int32
+	ackOnce  sync.Once
+}
+
+func newAirGapCodecReader(r CodecReader, ackFn codec.ReaderAckFn) *airGapCodecReader {
+	return &airGapCodecReader{r: r, sourceAck: ackFn}
+}
+
+func (a *airGapCodecReader) ackSource(ctx context.Context, err error) (ackErr error) {
+	a.ackOnce.Do(func() {
+		ackErr = a.sourceAck(ctx, err)
+	})
+	return
+}
+
+func (a *airGapCodecReader) ack(ctx context.Context, err error) error {
+	a.mut.Lock()
+	a.pending--
+	doAck := a.pending == 0 && a.finished
+	a.mut.Unlock()
+
+	if err != nil {
+		return a.ackSource(ctx, err)
+	}
+	if doAck {
+		return a.ackSource(ctx, nil)
+	}
+	return nil
+}
+
+func (a *airGapCodecReader) Next(ctx context.Context) ([]*message.Part, codec.ReaderAckFn, error) {
+	batch, err := a.r.Next(ctx)
+
+	a.mut.Lock()
+	defer a.mut.Unlock()
+
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			a.finished = true
+			if a.pending == 0 {
+				_ = a.ackSource(ctx, nil)
+			}
+			return nil, nil, io.EOF
+		}
+		_ = a.ackSource(ctx, err)
+		return nil, nil, err
+	}
+
+	parts := make([]*message.Part, len(batch))
+	for i, m := range batch {
+		parts[i] = m.part
+	}
+	a.pending++
+	return parts, a.ack, nil
+}
+
+func (a *airGapCodecReader) Close(ctx context.Context) error {
+	a.mut.Lock()
+	defer a.mut.Unlock()
+
+	if !a.finished {
+		_ = a.ackSource(ctx, errors.New("service shutting down"))
+	}
+	if a.pending == 0 {
+		_ = a.ackSource(ctx, nil)
+	}
+	return a.r.Close(ctx)
+}
+
+type airGapCodecWriter struct {
+	w CodecWriter
+}
+
+func (a *airGapCodecWriter) Write(ctx context.Context, p *message.Part) error {
+	return a.w.Write(ctx, newMessageFromPart(p))
+}
+
+func (a *airGapCodecWriter) Close(ctx context.Context) error {
+	return a.w.Close(ctx)
+}
@@ -0,0 +1,105 @@
+package service_test
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+type pairsCodecReader struct {
+	scanner *bufio.Scanner
+	r       io.ReadCloser
+}
+
+func (p *pairsCodecReader) Next(ctx context.Context) (service.MessageBatch, error) {
+	var batch service.MessageBatch
+	for len(batch) < 2 && p.scanner.Scan() {
+		batch = append(batch, service.NewMessage([]byte(p.scanner.Text())))
+	}
+	if len(batch) == 0 {
+		if err := p.scanner.Err(); err != nil {
+			return nil, err
+		}
+		return nil, io.EOF
+	}
+	return batch, nil
+}
+
+func (p *pairsCodecReader) Close(ctx context.Context) error {
+	return p.r.Close()
+}
+
+type prefixCodecWriter struct {
+	prefix string
+	w      io.WriteCloser
+}
+
+func (p *prefixCodecWriter) Write(ctx context.Context, msg *service.Message) error {
+	b, err := msg.AsBytes()
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(p.w, "%v%s\n", p.prefix, b)
+	return err
+}
+
+func (p *prefixCodecWriter) Close(ctx context.Context) error {
+	return p.w.Close()
+}
+
+func TestCodecPlugins(t *testing.T) {
+	require.NoError(t, service.RegisterCodecReader("test_pairs", func(args, path string, r io.ReadCloser) (service.CodecReader, error) {
+		return &pairsCodecReader{scanner: bufio.NewScanner(r), r: r}, nil
+	}))
+	require.Error(t, service.RegisterCodecReader("test_pairs", nil))
+	require.Error(t, service.RegisterCodecReader("test:pairs", nil))
+
+	require.NoError(t, service.RegisterCodecWriter("test_prefix", service.CodecWriterConfig{Append: true}, func(args string, w io.WriteCloser) (service.CodecWriter, error) {
+		return &prefixCodecWriter{prefix: args, w: w}, nil
+	}))
+
+	tmpDir := t.TempDir()
+	inPath := filepath.Join(tmpDir, "in.txt")
+	outPath := filepath.Join(tmpDir, "out.txt")
+	require.NoError(t, os.WriteFile(inPath, []byte("a\nb\nc\nd\ne\n"), 0o644))
+
+	b := service.NewStreamBuilder()
+	require.NoError(t, b.SetLoggerYAML("level: NONE"))
+	require.NoError(t, b.SetYAML(fmt.Sprintf(`
+input:
+  file:
+    paths: [ %v ]
+    codec: test_pairs
+pipeline:
+  processors:
+    - bloblang: 'root = content().string() + "-" + batch_size().string()'
+output:
+  file:
+    path: %v
+    codec: test_prefix:foo_
+`, inPath, outPath)))
+
+	strm, err := b.Build()
+	require.NoError(t, err)
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*30)
+	defer done()
+	require.NoError(t, strm.Run(ctx))
+
+	outBytes, err := os.ReadFile(outPath)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"foo_a-2", "foo_b-2", "foo_c-2", "foo_d-2", "foo_e-1",
+	}, strings.Split(strings.TrimSpace(string(outBytes)), "\n"))
+}
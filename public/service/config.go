@@ -171,6 +171,13 @@ func (c *ConfigField) Deprecated() *ConfigField {
 	return c
 }
 
+// Secret marks a config field as containing sensitive information, which is
+// highlighted in the documentation of the field.
+func (c *ConfigField) Secret() *ConfigField {
+	c.field = c.field.Secret()
+	return c
+}
+
 // Default specifies a default value that this field will assume if it is
 // omitted from a provided config. Fields that do not have a default value are
 // considered mandatory, and so parsing a config will fail in their absence.
@@ -360,6 +367,16 @@ func (c *ConfigSpec) Field(f *ConfigField) *ConfigSpec {
 	return c
 }
 
+// Shorthand specifies a field of the config spec that can be set by providing
+// its value in place of the entire config object, which allows a config spec
+// that previously consisted of a single root value to gain fields without
+// breaking existing configs. The field must have been added to the spec with
+// Field.
+func (c *ConfigSpec) Shorthand(name string) *ConfigSpec {
+	c.component.Config = c.component.Config.HasShorthand(name)
+	return c
+}
+
 // Example adds an example to the plugin configuration spec that demonstrates
 // how the component can be used. An example has a title, summary, and a YAML
 // configuration showing a real use case.
@@ -0,0 +1,136 @@
+package service
+
+import (
+	"context"
+	"sync"
+
+	"github.com/benthosdev/benthos/v4/internal/checkpoint"
+)
+
+// Checkpointer tracks an ordered sequence of offsets read from a source and
+// resolves them as they are acknowledged in any order, returning the highest
+// offset that can be committed such that no offset prior to it is still
+// pending. This is useful for implementing at-least-once delivery from sources
+// that are read sequentially, such as partitioned logs.
+//
+// If the number of pending messages reaches the configured limit then calls to
+// Track block until prior offsets are resolved, providing back pressure.
+//
+// This component is safe to use concurrently across goroutines.
+type Checkpointer struct {
+	c *checkpoint.Capped
+}
+
+// NewCheckpointer returns a Checkpointer that allows up to limit messages to be
+// pending at any given time.
+func NewCheckpointer(limit int64) *Checkpointer {
+	return &Checkpointer{
+		c: checkpoint.NewCapped(limit),
+	}
+}
+
+// Track a new offset, which is assumed to be greater than all previously
+// tracked offsets, along with the number of messages it represents. The
+// returned function must be called once the messages have been delivered, and
+// returns the highest offset that is safe to commit, or nil if there isn't
+// one.
+//
+// If the limit of pending messages has been reached this call blocks until
+// either enough prior offsets are resolved or the context is cancelled, in
+// which case an error is returned.
+func (c *Checkpointer) Track(ctx context.Context, offset interface{}, batchSize int64) (func() interface{}, error) {
+	return c.c.Track(ctx, offset, batchSize)
+}
+
+// Highest returns the highest offset that is currently safe to commit, or nil
+// if there isn't one.
+func (c *Checkpointer) Highest() interface{} {
+	return c.c.Highest()
+}
+
+//------------------------------------------------------------------------------
+
+// CheckpointedBatchInput is an interface implemented by batched inputs that
+// read from a sequential source and commit offsets once messages have been
+// delivered. It can be converted into a BatchInput with
+// NewCheckpointedBatchInput, which takes care of ordering commits such that an
+// offset is never committed before all prior offsets have been delivered.
+type CheckpointedBatchInput interface {
+	// Establish a connection to the upstream service, with the same semantics
+	// as the Connect method of BatchInput.
+	Connect(context.Context) error
+
+	// ReadBatchCheckpointed reads a message batch from a source along with the
+	// offset of the batch. Offsets must be read in ascending order, and the
+	// method has the same semantics as the ReadBatch method of BatchInput.
+	ReadBatchCheckpointed(context.Context) (MessageBatch, interface{}, error)
+
+	// CommitCheckpoint commits an offset, indicating that the batch of that
+	// offset and all prior batches have been delivered. Calls are serialised
+	// and never provide an offset lower than a previous call, although the
+	// same offset may be committed more than once.
+	CommitCheckpoint(ctx context.Context, offset interface{}) error
+
+	Closer
+}
+
+// NewCheckpointedBatchInput wraps a CheckpointedBatchInput in order to create
+// a BatchInput that commits offsets in order as batches are acknowledged,
+// allowing up to checkpointLimit messages to be processed in parallel before
+// applying back pressure. This mirrors the `checkpoint_limit` behaviour of
+// inputs such as `kafka` and `aws_kinesis`.
+//
+// Batches that are rejected downstream are automatically reattempted with
+// AutoRetryNacksBatched, and therefore the offset of a rejected batch is never
+// committed.
+func NewCheckpointedBatchInput(i CheckpointedBatchInput, checkpointLimit int64) BatchInput {
+	return AutoRetryNacksBatched(&checkpointedBatchInput{
+		child:        i,
+		checkpointer: NewCheckpointer(checkpointLimit),
+	})
+}
+
+type checkpointedBatchInput struct {
+	child        CheckpointedBatchInput
+	checkpointer *Checkpointer
+	commitMut    sync.Mutex
+}
+
+func (c *checkpointedBatchInput) Connect(ctx context.Context) error {
+	return c.child.Connect(ctx)
+}
+
+func (c *checkpointedBatchInput) ReadBatch(ctx context.Context) (MessageBatch, AckFunc, error) {
+	batch, offset, err := c.child.ReadBatchCheckpointed(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	release, err := c.checkpointer.Track(ctx, offset, int64(len(batch)))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return batch, func(ctx context.Context, err error) error {
+		if err != nil {
+			// Nacks are caught by the auto retry wrapper and never reach us,
+			// but in case they do we must not resolve the offset.
+			return nil
+		}
+
+		// Resolving and committing under the same lock ensures that commits
+		// are always made in ascending order.
+		c.commitMut.Lock()
+		defer c.commitMut.Unlock()
+
+		highest := release()
+		if highest == nil {
+			return nil
+		}
+		return c.child.CommitCheckpoint(ctx, highest)
+	}, nil
+}
+
+func (c *checkpointedBatchInput) Close(ctx context.Context) error {
+	return c.child.Close(ctx)
+}
@@ -0,0 +1,106 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockCheckpointedInput struct {
+	nextOffset int
+	commits    []interface{}
+	mut        sync.Mutex
+}
+
+func (m *mockCheckpointedInput) Connect(ctx context.Context) error {
+	return nil
+}
+
+func (m *mockCheckpointedInput) ReadBatchCheckpointed(ctx context.Context) (MessageBatch, interface{}, error) {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+
+	offset := m.nextOffset
+	m.nextOffset++
+	return MessageBatch{NewMessage([]byte("foo"))}, offset, nil
+}
+
+func (m *mockCheckpointedInput) CommitCheckpoint(ctx context.Context, offset interface{}) error {
+	m.mut.Lock()
+	m.commits = append(m.commits, offset)
+	m.mut.Unlock()
+	return nil
+}
+
+func (m *mockCheckpointedInput) Close(ctx context.Context) error {
+	return nil
+}
+
+func TestCheckpointedBatchInputOrdering(t *testing.T) {
+	ctx, done := context.WithTimeout(context.Background(), time.Second*30)
+	defer done()
+
+	mock := &mockCheckpointedInput{}
+	i := NewCheckpointedBatchInput(mock, 10)
+	require.NoError(t, i.Connect(ctx))
+
+	var ackFns []AckFunc
+	for j := 0; j < 3; j++ {
+		_, ackFn, err := i.ReadBatch(ctx)
+		require.NoError(t, err)
+		ackFns = append(ackFns, ackFn)
+	}
+
+	// Acking the last batch first must not commit anything.
+	require.NoError(t, ackFns[2](ctx, nil))
+	assert.Empty(t, mock.commits)
+
+	require.NoError(t, ackFns[1](ctx, nil))
+	assert.Empty(t, mock.commits)
+
+	require.NoError(t, ackFns[0](ctx, nil))
+	assert.Equal(t, []interface{}{2}, mock.commits)
+
+	require.NoError(t, i.Close(ctx))
+}
+
+func TestCheckpointedBatchInputNackRetried(t *testing.T) {
+	ctx, done := context.WithTimeout(context.Background(), time.Second*30)
+	defer done()
+
+	mock := &mockCheckpointedInput{}
+	i := NewCheckpointedBatchInput(mock, 10)
+	require.NoError(t, i.Connect(ctx))
+
+	batch, ackFn, err := i.ReadBatch(ctx)
+	require.NoError(t, err)
+	require.NoError(t, ackFn(ctx, errors.New("nope")))
+	assert.Empty(t, mock.commits)
+
+	retryBatch, ackFn, err := i.ReadBatch(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, batch, retryBatch)
+
+	require.NoError(t, ackFn(ctx, nil))
+	assert.Equal(t, []interface{}{0}, mock.commits)
+}
+
+func TestCheckpointedBatchInputLimit(t *testing.T) {
+	mock := &mockCheckpointedInput{}
+	i := NewCheckpointedBatchInput(mock, 1)
+	require.NoError(t, i.Connect(context.Background()))
+
+	_, _, err := i.ReadBatch(context.Background())
+	require.NoError(t, err)
+
+	ctx, done := context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer done()
+
+	_, _, err = i.ReadBatch(ctx)
+	require.Error(t, err)
+}
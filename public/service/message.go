@@ -117,12 +117,8 @@ func (m *Message) AsStructured() (interface{}, error) {
 // reference type (slice or map), as the structured contents will be lazily deep
 // cloned if it is still owned by an upstream component.
 func (m *Message) AsStructuredMut() (interface{}, error) {
-	// TODO: Use refactored APIs to determine if the contents are owned.
-	v, err := m.part.JSON()
-	if err != nil {
-		return nil, err
-	}
-	return message.CopyJSON(v)
+	m.ensureCopied()
+	return m.part.JSONMut()
 }
 
 // SetBytes sets the underlying contents of the message as a byte slice.
@@ -212,6 +208,37 @@ func (m *Message) BloblangQuery(blobl *bloblang.Executor) (*Message, error) {
 	return nil, nil
 }
 
+// BloblangMutate executes a parsed Bloblang mapping onto a message where the
+// contents of the message are mutated directly rather than creating an entirely
+// new object. Fields of the message that are not assigned by the mapping are
+// therefore preserved.
+//
+// Returns the same message back in a mutated form, or an error if the mapping
+// fails. If the mapping fails the message may have been partially mutated, and
+// therefore a copy of the message should be provided if the original contents
+// need to be preserved. If the mapping results in the root being deleted the
+// returned message will be nil, which indicates it has been filtered.
+func (m *Message) BloblangMutate(blobl *bloblang.Executor) (*Message, error) {
+	uw := blobl.XUnwrapper().(interface {
+		Unwrap() *mapping.Executor
+	}).Unwrap()
+
+	m.ensureCopied()
+
+	// The mapping references the message as it was prior to being mutated.
+	msg := message.QuickBatch(nil)
+	msg.Append(m.part.Copy())
+
+	res, err := uw.MapOnto(m.part, 0, msg)
+	if err != nil {
+		return nil, err
+	}
+	if res != nil {
+		return m, nil
+	}
+	return nil, nil
+}
+
 // BloblangQuery executes a parsed Bloblang mapping on a message batch, from the
 // perspective of a particular message index, and returns a message back or an
 // error if the mapping fails. If the mapping results in the root being deleted
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"github.com/benthosdev/benthos/v4/internal/batch"
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+// BatchError is an error type that can be returned by a BatchOutput in order to
+// indicate which messages of a batch failed, allowing the messages that were
+// written successfully to be acknowledged and only the failed messages to be
+// reattempted.
+type BatchError struct {
+	wrapped *batch.Error
+}
+
+// NewBatchError creates a new batch-wide error, where it's possible to add
+// granular errors for individual messages of the batch with Failed.
+func NewBatchError(b MessageBatch, headline error) *BatchError {
+	ib := message.QuickBatch(nil)
+	for _, m := range b {
+		ib.Append(m.part)
+	}
+	return &BatchError{wrapped: batch.NewError(ib, headline)}
+}
+
+// Failed stores an error state for a particular message of a batch. Returns a
+// pointer to the underlying error, allowing the method to be chained.
+//
+// If Failed is not called then all messages are assumed to have failed. If it
+// is called at least once then all message indexes that aren't explicitly
+// failed are assumed to have been processed successfully.
+func (err *BatchError) Failed(i int, merr error) *BatchError {
+	err.wrapped.Failed(i, merr)
+	return err
+}
+
+// IndexedErrors returns the number of indexed errors that have been registered
+// for the batch.
+func (err *BatchError) IndexedErrors() int {
+	return err.wrapped.IndexedErrors()
+}
+
+// WalkMessages applies a closure to each message of the batch that caused this
+// error. The closure is provided the message index, the message, and its
+// individual error, which may be nil if the message itself was processed
+// successfully. The closure returns a bool which indicates whether the
+// iteration should be continued.
+func (err *BatchError) WalkMessages(fn func(int, *Message, error) bool) {
+	err.wrapped.WalkParts(func(i int, p *message.Part, merr error) bool {
+		return fn(i, newMessageFromPart(p), merr)
+	})
+}
+
+// Error implements the common error interface.
+func (err *BatchError) Error() string {
+	return err.wrapped.Error()
+}
+
+// Unwrap returns the underlying common error.
+func (err *BatchError) Unwrap() error {
+	return err.wrapped.Unwrap()
+}
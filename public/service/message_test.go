@@ -240,6 +240,49 @@ func TestMessageMapping(t *testing.T) {
 	}, resI)
 }
 
+func TestMessageMappingMutate(t *testing.T) {
+	part := NewMessage(nil)
+	part.SetStructured(map[string]interface{}{
+		"content": "hello world",
+		"other":   "remains",
+	})
+	part.MetaSet("foo", "bar")
+
+	original := part.Copy()
+
+	blobl, err := bloblang.Parse(`
+root.content = this.content.uppercase()
+root.old_content = this.content
+meta baz = "buz"
+`)
+	require.NoError(t, err)
+
+	res, err := part.BloblangMutate(blobl)
+	require.NoError(t, err)
+
+	resI, err := res.AsStructured()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"content":     "HELLO WORLD",
+		"old_content": "hello world",
+		"other":       "remains",
+	}, resI)
+
+	v, _ := res.MetaGet("foo")
+	assert.Equal(t, "bar", v)
+	v, _ = res.MetaGet("baz")
+	assert.Equal(t, "buz", v)
+
+	originalI, err := original.AsStructured()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"content": "hello world",
+		"other":   "remains",
+	}, originalI)
+	_, exists := original.MetaGet("baz")
+	assert.False(t, exists)
+}
+
 func TestMessageBatchMapping(t *testing.T) {
 	partOne := NewMessage(nil)
 	partOne.SetStructured(map[string]interface{}{
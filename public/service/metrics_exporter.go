@@ -0,0 +1,194 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/benthosdev/benthos/v4/internal/bundle"
+	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+	"github.com/benthosdev/benthos/v4/internal/docs"
+	"github.com/benthosdev/benthos/v4/internal/log"
+)
+
+// MetricsExporterCounter represents a counter metric of a given name and
+// labels.
+type MetricsExporterCounter interface {
+	// Incr increments a counter metric by an integer amount.
+	Incr(count int64)
+}
+
+// MetricsExporterTimer represents a timing metric of a given name and labels.
+type MetricsExporterTimer interface {
+	// Timing sets a timing metric in nanoseconds.
+	Timing(delta int64)
+}
+
+// MetricsExporterGauge represents a gauge metric of a given name and labels.
+type MetricsExporterGauge interface {
+	// Set a gauge metric.
+	Set(value int64)
+}
+
+// MetricsExporterCounterCtor is a constructor for a MetricsExporterCounter
+// that must be called with a variadic list of label values exactly matching the
+// length and order of the label keys provided.
+type MetricsExporterCounterCtor func(labelValues ...string) MetricsExporterCounter
+
+// MetricsExporterTimerCtor is a constructor for a MetricsExporterTimer that
+// must be called with a variadic list of label values exactly matching the
+// length and order of the label keys provided.
+type MetricsExporterTimerCtor func(labelValues ...string) MetricsExporterTimer
+
+// MetricsExporterGaugeCtor is a constructor for a MetricsExporterGauge that
+// must be called with a variadic list of label values exactly matching the
+// length and order of the label keys provided.
+type MetricsExporterGaugeCtor func(labelValues ...string) MetricsExporterGauge
+
+// MetricsExporter is an interface implemented by Benthos metrics exporters.
+// Metric names provided to the exporter have already been namespaced and
+// mapped according to the `metrics` config, and therefore match the names
+// received by built-in exporters.
+type MetricsExporter interface {
+	NewCounterCtor(name string, labelKeys ...string) MetricsExporterCounterCtor
+	NewTimerCtor(name string, labelKeys ...string) MetricsExporterTimerCtor
+	NewGaugeCtor(name string, labelKeys ...string) MetricsExporterGaugeCtor
+	Close(ctx context.Context) error
+}
+
+// MetricsExporterConstructor is a func that's provided a configuration type
+// and a logger and must return an instantiation of a metrics exporter based on
+// the config, or an error.
+//
+// Configuration fields that require access to resources, such as interpolated
+// strings, are not supported by metrics exporters.
+type MetricsExporterConstructor func(conf *ParsedConfig, log *Logger) (MetricsExporter, error)
+
+// RegisterMetricsExporter attempts to register a new metrics exporter plugin by
+// providing a description of the configuration for the plugin as well as a
+// constructor for the exporter itself. The constructor will be called once for
+// each service that is configured to use the exporter.
+//
+// Metrics exporters are available to all environments, and therefore there is
+// no equivalent method for registering an exporter to a specific Environment.
+func RegisterMetricsExporter(name string, spec *ConfigSpec, ctor MetricsExporterConstructor) error {
+	componentSpec := spec.component
+	componentSpec.Name = name
+	componentSpec.Type = docs.TypeMetrics
+	return bundle.AllMetrics.Add(func(conf metrics.Config, l log.Modular) (metrics.Type, error) {
+		pluginConf, err := extractConfig(nil, spec, name, conf.Plugin, conf)
+		if err != nil {
+			return nil, err
+		}
+		m, err := ctor(pluginConf, newReverseAirGapLogger(l))
+		if err != nil {
+			return nil, err
+		}
+		return newAirGapMetrics(m), nil
+	}, componentSpec)
+}
+
+//------------------------------------------------------------------------------
+
+type airGapMetrics struct {
+	airGapped MetricsExporter
+}
+
+func newAirGapMetrics(m MetricsExporter) metrics.Type {
+	return &airGapMetrics{airGapped: m}
+}
+
+type airGapCounterVec struct {
+	ctor MetricsExporterCounterCtor
+}
+
+func (c *airGapCounterVec) With(labelValues ...string) metrics.StatCounter {
+	return c.ctor(labelValues...)
+}
+
+type airGapTimerVec struct {
+	ctor MetricsExporterTimerCtor
+}
+
+func (t *airGapTimerVec) With(labelValues ...string) metrics.StatTimer {
+	return t.ctor(labelValues...)
+}
+
+// airGapGauge adapts a gauge that can only be set into one that can also be
+// incremented and decremented by tracking the current value.
+type airGapGauge struct {
+	value int64
+	g     MetricsExporterGauge
+}
+
+func (g *airGapGauge) Set(value int64) {
+	atomic.StoreInt64(&g.value, value)
+	g.g.Set(value)
+}
+
+func (g *airGapGauge) Incr(count int64) {
+	g.g.Set(atomic.AddInt64(&g.value, count))
+}
+
+func (g *airGapGauge) Decr(count int64) {
+	g.g.Set(atomic.AddInt64(&g.value, -count))
+}
+
+// airGapGaugeVec caches gauges by their label values so that increments and
+// decrements of the same series are applied to the same tracked value.
+type airGapGaugeVec struct {
+	ctor   MetricsExporterGaugeCtor
+	gauges map[string]*airGapGauge
+	mut    sync.Mutex
+}
+
+func (g *airGapGaugeVec) With(labelValues ...string) metrics.StatGauge {
+	key := strings.Join(labelValues, "\x00")
+
+	g.mut.Lock()
+	defer g.mut.Unlock()
+
+	gauge, exists := g.gauges[key]
+	if !exists {
+		gauge = &airGapGauge{g: g.ctor(labelValues...)}
+		g.gauges[key] = gauge
+	}
+	return gauge
+}
+
+func (a *airGapMetrics) GetCounter(path string) metrics.StatCounter {
+	return a.airGapped.NewCounterCtor(path)()
+}
+
+func (a *airGapMetrics) GetCounterVec(path string, labelNames ...string) metrics.StatCounterVec {
+	return &airGapCounterVec{ctor: a.airGapped.NewCounterCtor(path, labelNames...)}
+}
+
+func (a *airGapMetrics) GetTimer(path string) metrics.StatTimer {
+	return a.airGapped.NewTimerCtor(path)()
+}
+
+func (a *airGapMetrics) GetTimerVec(path string, labelNames ...string) metrics.StatTimerVec {
+	return &airGapTimerVec{ctor: a.airGapped.NewTimerCtor(path, labelNames...)}
+}
+
+func (a *airGapMetrics) GetGauge(path string) metrics.StatGauge {
+	return &airGapGauge{g: a.airGapped.NewGaugeCtor(path)()}
+}
+
+func (a *airGapMetrics) GetGaugeVec(path string, labelNames ...string) metrics.StatGaugeVec {
+	return &airGapGaugeVec{
+		ctor:   a.airGapped.NewGaugeCtor(path, labelNames...),
+		gauges: map[string]*airGapGauge{},
+	}
+}
+
+func (a *airGapMetrics) HandlerFunc() http.HandlerFunc {
+	return nil
+}
+
+func (a *airGapMetrics) Close() error {
+	return a.airGapped.Close(context.Background())
+}
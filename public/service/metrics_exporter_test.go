@@ -0,0 +1,121 @@
+package service_test
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+type mockExporter struct {
+	prefix string
+	values map[string]int64
+	mut    sync.Mutex
+	closed bool
+}
+
+type mockExporterStat struct {
+	key string
+	e   *mockExporter
+}
+
+func (m *mockExporterStat) Incr(count int64) {
+	m.e.mut.Lock()
+	m.e.values[m.key] += count
+	m.e.mut.Unlock()
+}
+
+func (m *mockExporterStat) Timing(delta int64) {
+	m.Set(delta)
+}
+
+func (m *mockExporterStat) Set(value int64) {
+	m.e.mut.Lock()
+	m.e.values[m.key] = value
+	m.e.mut.Unlock()
+}
+
+func (m *mockExporter) key(name string, labelValues []string) string {
+	return m.prefix + name + "{" + strings.Join(labelValues, ",") + "}"
+}
+
+func (m *mockExporter) NewCounterCtor(name string, labelKeys ...string) service.MetricsExporterCounterCtor {
+	return func(labelValues ...string) service.MetricsExporterCounter {
+		return &mockExporterStat{key: m.key(name, labelValues), e: m}
+	}
+}
+
+func (m *mockExporter) NewTimerCtor(name string, labelKeys ...string) service.MetricsExporterTimerCtor {
+	return func(labelValues ...string) service.MetricsExporterTimer {
+		return &mockExporterStat{key: m.key(name, labelValues), e: m}
+	}
+}
+
+func (m *mockExporter) NewGaugeCtor(name string, labelKeys ...string) service.MetricsExporterGaugeCtor {
+	return func(labelValues ...string) service.MetricsExporterGauge {
+		return &mockExporterStat{key: m.key(name, labelValues), e: m}
+	}
+}
+
+func (m *mockExporter) Close(ctx context.Context) error {
+	m.mut.Lock()
+	m.closed = true
+	m.mut.Unlock()
+	return nil
+}
+
+func TestMetricsExporterPlugin(t *testing.T) {
+	var exporter *mockExporter
+
+	require.NoError(t, service.RegisterMetricsExporter(
+		"meow_metrics",
+		service.NewConfigSpec().Field(service.NewStringField("prefix")),
+		func(conf *service.ParsedConfig, log *service.Logger) (service.MetricsExporter, error) {
+			prefix, err := conf.FieldString("prefix")
+			if err != nil {
+				return nil, err
+			}
+			exporter = &mockExporter{prefix: prefix, values: map[string]int64{}}
+			return exporter, nil
+		},
+	))
+
+	b := service.NewStreamBuilder()
+	require.NoError(t, b.SetLoggerYAML("level: NONE"))
+	require.NoError(t, b.SetYAML(`
+input:
+  label: foo
+  generate:
+    count: 3
+    interval: ""
+    mapping: 'root = "hello world"'
+output:
+  label: bar
+  drop: {}
+`))
+	require.NoError(t, b.SetMetricsYAML(`
+meow_metrics:
+  prefix: meow_
+`))
+
+	strm, err := b.Build()
+	require.NoError(t, err)
+	require.NotNil(t, exporter)
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*30)
+	defer done()
+	require.NoError(t, strm.Run(ctx))
+
+	exporter.mut.Lock()
+	defer exporter.mut.Unlock()
+
+	assert.True(t, exporter.closed)
+	assert.Equal(t, int64(3), exporter.values["meow_input_received{foo,root.input}"])
+	assert.Equal(t, int64(3), exporter.values["meow_output_sent{bar,root.output}"])
+}
@@ -141,6 +141,10 @@ func (a *airGapBatchWriter) WriteWithContext(ctx context.Context, msg *message.B
 	if err != nil && errors.Is(err, ErrNotConnected) {
 		err = component.ErrNotConnected
 	}
+	var bErr *BatchError
+	if errors.As(err, &bErr) {
+		return bErr.wrapped
+	}
 	return err
 }
 
@@ -19,10 +19,46 @@ type RateLimit interface {
 	Closer
 }
 
+// KeyedRateLimit is an optional interface that rate limits can implement in
+// order to apply a distinct limit to each key provided by callers, allowing a
+// single rate limit resource to throttle tenants or API keys independently.
+//
+// Rate limits obtained with Resources.AccessRateLimit always implement this
+// interface, and for rate limits that do not support keys calls to AccessKey
+// are equivalent to calls to Access.
+type KeyedRateLimit interface {
+	// AccessKey is equivalent to Access but targets a limit that is distinct
+	// to the provided key.
+	AccessKey(ctx context.Context, key string) (time.Duration, error)
+}
+
 //------------------------------------------------------------------------------
 
+// Implements ratelimit.V1 around a RateLimit
+type airGapRateLimit struct {
+	r  RateLimit
+	rk KeyedRateLimit
+}
+
 func newAirGapRateLimit(c RateLimit, stats metrics.Type) ratelimit.V1 {
-	return ratelimit.MetricsForRateLimit(c, stats)
+	ag := &airGapRateLimit{r: c}
+	ag.rk, _ = c.(KeyedRateLimit)
+	return ratelimit.MetricsForRateLimit(ag, stats)
+}
+
+func (a *airGapRateLimit) Access(ctx context.Context) (time.Duration, error) {
+	return a.r.Access(ctx)
+}
+
+func (a *airGapRateLimit) AccessKey(ctx context.Context, key string) (time.Duration, error) {
+	if a.rk != nil {
+		return a.rk.AccessKey(ctx, key)
+	}
+	return a.r.Access(ctx)
+}
+
+func (a *airGapRateLimit) Close(ctx context.Context) error {
+	return a.r.Close(ctx)
 }
 
 //------------------------------------------------------------------------------
@@ -40,6 +76,10 @@ func (a *reverseAirGapRateLimit) Access(ctx context.Context) (time.Duration, err
 	return a.r.Access(ctx)
 }
 
+func (a *reverseAirGapRateLimit) AccessKey(ctx context.Context, key string) (time.Duration, error) {
+	return a.r.AccessKey(ctx, key)
+}
+
 func (a *reverseAirGapRateLimit) Close(ctx context.Context) error {
 	return a.r.Close(ctx)
 }
@@ -49,6 +49,40 @@ func TestRateLimitAirGapShutdown(t *testing.T) {
 	assert.True(t, rl.closed)
 }
 
+type keyedRateLimit struct {
+	closableRateLimit
+	keys []string
+}
+
+func (k *keyedRateLimit) AccessKey(ctx context.Context, key string) (time.Duration, error) {
+	k.keys = append(k.keys, key)
+	return time.Minute, nil
+}
+
+func TestRateLimitAirGapKeyed(t *testing.T) {
+	ctx := context.Background()
+
+	rl := &closableRateLimit{next: time.Second}
+	agrl := newAirGapRateLimit(rl, metrics.Noop())
+
+	tout, err := agrl.AccessKey(ctx, "foo")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Second, tout)
+
+	krl := &keyedRateLimit{closableRateLimit: closableRateLimit{next: time.Second}}
+	agrl = newAirGapRateLimit(krl, metrics.Noop())
+
+	tout, err = agrl.AccessKey(ctx, "foo")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Minute, tout)
+
+	tout, err = agrl.Access(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Second, tout)
+
+	assert.Equal(t, []string{"foo"}, krl.keys)
+}
+
 //------------------------------------------------------------------------------
 
 type closableRateLimitType struct {
@@ -61,6 +95,10 @@ func (c *closableRateLimitType) Access(ctx context.Context) (time.Duration, erro
 	return c.next, c.err
 }
 
+func (c *closableRateLimitType) AccessKey(ctx context.Context, key string) (time.Duration, error) {
+	return c.next, c.err
+}
+
 func (c *closableRateLimitType) Close(ctx context.Context) error {
 	c.closed = true
 	return nil
@@ -5,6 +5,7 @@ import (
 
 	"github.com/benthosdev/benthos/v4/internal/bundle"
 	"github.com/benthosdev/benthos/v4/internal/bundle/mock"
+	"github.com/benthosdev/benthos/v4/internal/checkpoint"
 	"github.com/benthosdev/benthos/v4/internal/component/cache"
 	"github.com/benthosdev/benthos/v4/internal/component/ratelimit"
 )
@@ -76,3 +77,28 @@ func (r *Resources) AccessRateLimit(ctx context.Context, name string, fn func(r
 func (r *Resources) HasRateLimit(name string) bool {
 	return r.mgr.ProbeRateLimit(name)
 }
+
+// AccessCheckpoint attempts to access a checkpoint resource by name.
+func (r *Resources) AccessCheckpoint(ctx context.Context, name string, fn func(c CheckpointStore)) error {
+	return r.mgr.AccessCheckpoint(ctx, name, func(s *checkpoint.Store) {
+		fn(&reverseAirGapCheckpointStore{s: s})
+	})
+}
+
+// GetOrSetGeneric returns the value stored under a key that is shared by all
+// components of the service, storing the provided value when none exists. The
+// loaded result is true if the value was loaded, false if stored. This allows
+// plugins to share state such as connection pools between components without
+// sharing it across separate services or stream builders within the same
+// process. Keys should be of an unexported type in order to avoid collisions
+// between plugins, similar to the keys of context values.
+func (r *Resources) GetOrSetGeneric(key, value interface{}) (actual interface{}, loaded bool) {
+	return r.mgr.GetOrSetGeneric(key, value)
+}
+
+// HasCheckpoint confirms whether a checkpoint with a given name has been
+// registered as a resource. This method is useful during component
+// initialisation as it is defensive against ordering.
+func (r *Resources) HasCheckpoint(name string) bool {
+	return r.mgr.ProbeCheckpoint(name)
+}
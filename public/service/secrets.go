@@ -0,0 +1,22 @@
+package service
+
+import (
+	"context"
+
+	"github.com/benthosdev/benthos/v4/internal/secrets"
+)
+
+// SecretProviderFunc obtains the value of a secret at a path, where the format
+// of the path is specific to the provider.
+type SecretProviderFunc func(ctx context.Context, path string) (string, error)
+
+// RegisterSecretProvider adds a provider of secrets that resolves references
+// within config files of the form `${secret:<name>://<path>#<key>}`, where
+// name is the name of the provider. When a key is specified the secret is
+// parsed as a JSON object and the value of the key is used.
+//
+// Secret providers are registered globally as secrets are resolved before
+// config files are parsed.
+func RegisterSecretProvider(name string, fn SecretProviderFunc) error {
+	return secrets.RegisterProvider(name, secrets.Provider(fn))
+}
@@ -0,0 +1,27 @@
+package service_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/config"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func TestRegisterSecretProvider(t *testing.T) {
+	require.NoError(t, service.RegisterSecretProvider("test_service", func(ctx context.Context, path string) (string, error) {
+		return `{"pass":"` + path + ` secret"}`, nil
+	}))
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`foo: ${secret:test_service://bar#pass}`), 0o644))
+
+	b, _, err := config.ReadFileEnvSwap(path)
+	require.NoError(t, err)
+	assert.Equal(t, "foo: bar secret", string(b))
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/benthosdev/benthos/v4/internal/component/metrics"
 	"github.com/benthosdev/benthos/v4/internal/log"
 	"github.com/benthosdev/benthos/v4/internal/manager"
+	"github.com/benthosdev/benthos/v4/internal/message"
 	"github.com/benthosdev/benthos/v4/internal/shutdown"
 	"github.com/benthosdev/benthos/v4/internal/stream"
 )
@@ -21,15 +22,20 @@ type Stream struct {
 	shutSig *shutdown.Signaller
 	onStart func()
 
-	conf   stream.Config
-	mgr    *manager.Type
-	stats  metrics.Type
-	logger log.Modular
+	pipes    map[string]chan message.Transaction
+	pipesMut sync.Mutex
+
+	conf    stream.Config
+	resConf manager.ResourceConfig
+	mgr     *manager.Type
+	stats   metrics.Type
+	logger  log.Modular
 }
 
-func newStream(conf stream.Config, mgr *manager.Type, stats metrics.Type, logger log.Modular, onStart func()) *Stream {
+func newStream(conf stream.Config, resConf manager.ResourceConfig, mgr *manager.Type, stats metrics.Type, logger log.Modular, onStart func()) *Stream {
 	return &Stream{
 		conf:    conf,
+		resConf: resConf,
 		mgr:     mgr,
 		stats:   stats,
 		logger:  logger,
@@ -83,8 +89,9 @@ func (s *Stream) StopWithin(timeout time.Duration) error {
 	strm := s.strm
 	s.strmMut.Unlock()
 	if strm == nil {
-		return errors.New("stream has not been run yet")
+		return ErrStreamNotRunning
 	}
+	defer s.releasePipes()
 
 	stopAt := time.Now().Add(timeout)
 	if err := strm.Stop(timeout); err != nil {
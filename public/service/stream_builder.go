@@ -310,7 +310,7 @@ func (s *StreamBuilder) AddConsumerFunc(fn MessageHandlerFunc) error {
 
 	conf := output.NewConfig()
 	conf.Type = output.TypeInproc
-	conf.Inproc = output.InprocConfig(s.consumerID)
+	conf.Inproc.ID = s.consumerID
 	s.outputs = append(s.outputs, conf)
 
 	return nil
@@ -346,7 +346,7 @@ func (s *StreamBuilder) AddBatchConsumerFunc(fn MessageBatchHandlerFunc) error {
 
 	conf := output.NewConfig()
 	conf.Type = output.TypeInproc
-	conf.Inproc = output.InprocConfig(s.consumerID)
+	conf.Inproc.ID = s.consumerID
 	s.outputs = append(s.outputs, conf)
 
 	return nil
@@ -738,7 +738,7 @@ func (s *StreamBuilder) buildWithEnv(env *bundle.Environment) (*Stream, error) {
 		mgr.SetPipe(s.producerID, s.producerChan)
 	}
 
-	return newStream(conf.Config, mgr, stats, logger, func() {
+	return newStream(conf.Config, conf.ResourceConfig, mgr, stats, logger, func() {
 		if err := s.runConsumerFunc(mgr); err != nil {
 			logger.Errorf("Failed to run func consumer: %v", err)
 		}
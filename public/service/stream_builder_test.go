@@ -503,6 +503,9 @@ func TestStreamBuilderSetYAMLBrokers(t *testing.T) {
     label: ""
     broker:
         copies: 1
+        pattern: fan_in
+        priority:
+            max_consecutive: 0
         inputs:`,
 		`            - label: ""
               kafka:`,
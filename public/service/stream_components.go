@@ -0,0 +1,163 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/internal/component"
+	iinput "github.com/benthosdev/benthos/v4/internal/component/input"
+	ioutput "github.com/benthosdev/benthos/v4/internal/component/output"
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+// ErrStreamNotRunning is returned when attempting to access the components of a
+// stream that has not yet been run.
+var ErrStreamNotRunning = errors.New("stream has not been run yet")
+
+// ComponentStatus describes the state of a component within a running stream.
+//
+// Experimental: This type may change outside of major version releases.
+type ComponentStatus struct {
+	// Path describes the location of the component within the stream config,
+	// e.g. `input`, `output` or `input_resources.foo`.
+	Path string
+
+	// Type is the type of the component, e.g. `kafka`.
+	Type string
+
+	// Connected indicates whether the component is currently connected to its
+	// target.
+	Connected bool
+
+	// LastError is the most recent error reported by the component, if any,
+	// along with the time at which it occurred.
+	LastError   error
+	LastErrorAt time.Time
+}
+
+func newComponentStatus(path, typeStr string, c interface{ Connected() bool }) ComponentStatus {
+	s := ComponentStatus{
+		Path:      path,
+		Type:      typeStr,
+		Connected: c.Connected(),
+	}
+	s.LastErrorAt, s.LastError = component.LastErrorOf(c)
+	return s
+}
+
+// Components returns the status of the inputs and outputs of a running
+// stream, including its input and output resources. An error is returned if the
+// stream has not yet been run.
+//
+// Experimental: This method may change outside of major version releases.
+func (s *Stream) Components(ctx context.Context) ([]ComponentStatus, error) {
+	s.strmMut.Lock()
+	strm := s.strm
+	s.strmMut.Unlock()
+	if strm == nil {
+		return nil, ErrStreamNotRunning
+	}
+
+	comps := []ComponentStatus{
+		newComponentStatus("input", s.conf.Input.Type, strm.InputLayer()),
+		newComponentStatus("output", s.conf.Output.Type, strm.OutputLayer()),
+	}
+
+	for _, conf := range s.resConf.ResourceInputs {
+		var status ComponentStatus
+		if err := s.mgr.AccessInput(ctx, conf.Label, func(i iinput.Streamed) {
+			status = newComponentStatus("input_resources."+conf.Label, conf.Type, i)
+		}); err != nil {
+			return nil, err
+		}
+		comps = append(comps, status)
+	}
+	for _, conf := range s.resConf.ResourceOutputs {
+		var status ComponentStatus
+		if err := s.mgr.AccessOutput(ctx, conf.Label, func(o ioutput.Sync) {
+			status = newComponentStatus("output_resources."+conf.Label, conf.Type, o)
+		}); err != nil {
+			return nil, err
+		}
+		comps = append(comps, status)
+	}
+	return comps, nil
+}
+
+// IsReady returns true if the input and output of a running stream are both
+// connected.
+//
+// Experimental: This method may change outside of major version releases.
+func (s *Stream) IsReady() bool {
+	s.strmMut.Lock()
+	strm := s.strm
+	s.strmMut.Unlock()
+	if strm == nil {
+		return false
+	}
+	return strm.IsReady()
+}
+
+// SendBatchToPipe writes a message batch into a named inproc pipe, where it
+// can be consumed by `inproc` inputs of the stream configured with the same
+// name. The call blocks until the batch is acknowledged downstream, or the
+// context is cancelled.
+//
+// The stream claims the pipe the first time it is written to, and an error is
+// returned if the pipe is already being written to by an `inproc` output.
+//
+// Experimental: This method may change outside of major version releases.
+func (s *Stream) SendBatchToPipe(ctx context.Context, name string, b MessageBatch) error {
+	tChan, err := s.claimPipe(name)
+	if err != nil {
+		return err
+	}
+
+	tmpMsg := message.QuickBatch(nil)
+	for _, m := range b {
+		tmpMsg.Append(m.part)
+	}
+	resChan := make(chan error)
+	select {
+	case tChan <- message.NewTransaction(tmpMsg, resChan):
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	select {
+	case res := <-resChan:
+		return res
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *Stream) claimPipe(name string) (chan message.Transaction, error) {
+	s.pipesMut.Lock()
+	defer s.pipesMut.Unlock()
+
+	if s.pipes == nil {
+		s.pipes = map[string]chan message.Transaction{}
+	}
+	if tChan, exists := s.pipes[name]; exists {
+		return tChan, nil
+	}
+	if _, err := s.mgr.GetPipe(name); err == nil {
+		return nil, fmt.Errorf("pipe %v is already owned by an inproc output", name)
+	}
+
+	tChan := make(chan message.Transaction)
+	s.mgr.SetPipe(name, tChan)
+	s.pipes[name] = tChan
+	return tChan, nil
+}
+
+func (s *Stream) releasePipes() {
+	s.pipesMut.Lock()
+	for name, tChan := range s.pipes {
+		s.mgr.UnsetPipe(name, tChan)
+	}
+	s.pipes = nil
+	s.pipesMut.Unlock()
+}
@@ -0,0 +1,86 @@
+package service_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func TestStreamComponentsAndPipes(t *testing.T) {
+	b := service.NewStreamBuilder()
+	require.NoError(t, b.SetLoggerYAML("level: NONE"))
+	require.NoError(t, b.AddInputYAML(`inproc: foo`))
+	require.NoError(t, b.AddResourcesYAML(`
+output_resources:
+  - label: bar
+    drop: {}
+`))
+
+	var outMsgs []string
+	var outMut sync.Mutex
+	require.NoError(t, b.AddConsumerFunc(func(_ context.Context, m *service.Message) error {
+		outMut.Lock()
+		defer outMut.Unlock()
+
+		b, err := m.AsBytes()
+		assert.NoError(t, err)
+
+		outMsgs = append(outMsgs, string(b))
+		return nil
+	}))
+
+	strm, err := b.Build()
+	require.NoError(t, err)
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*30)
+	defer done()
+
+	_, err = strm.Components(ctx)
+	assert.Equal(t, service.ErrStreamNotRunning, err)
+	assert.False(t, strm.IsReady())
+
+	runErr := make(chan error, 1)
+	go func() {
+		runErr <- strm.Run(ctx)
+	}()
+
+	require.Eventually(t, func() bool {
+		_, err := strm.Components(ctx)
+		return err == nil
+	}, time.Second*5, time.Millisecond*10)
+
+	require.NoError(t, strm.SendBatchToPipe(ctx, "foo", service.MessageBatch{
+		service.NewMessage([]byte("hello")),
+		service.NewMessage([]byte("world")),
+	}))
+
+	outMut.Lock()
+	assert.Equal(t, []string{"hello", "world"}, outMsgs)
+	outMut.Unlock()
+
+	comps, err := strm.Components(ctx)
+	require.NoError(t, err)
+	require.Len(t, comps, 3)
+
+	assert.Equal(t, "input", comps[0].Path)
+	assert.Equal(t, "inproc", comps[0].Type)
+	assert.True(t, comps[0].Connected)
+
+	assert.Equal(t, "output", comps[1].Path)
+	assert.Equal(t, "inproc", comps[1].Type)
+
+	assert.Equal(t, "output_resources.bar", comps[2].Path)
+	assert.Equal(t, "drop", comps[2].Type)
+	assert.True(t, comps[2].Connected)
+
+	assert.True(t, strm.IsReady())
+
+	require.NoError(t, strm.StopWithin(time.Second*5))
+	<-runErr
+}